@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sakuhanight/gopier/internal/acldiff"
+	"github.com/sakuhanight/gopier/internal/filter"
+)
+
+var (
+	aclDiffFormat  string
+	aclDiffOutput  string
+	aclDiffInclude string
+	aclDiffExclude string
+)
+
+// aclDiffCmd represents the acl-diff command
+var aclDiffCmd = &cobra.Command{
+	Use:   "acl-diff SOURCE DEST",
+	Short: "ソースと宛先のアクセス権を比較",
+	Long: `ミラーしたディレクトリツリーの各ファイル・ディレクトリについて、
+ソースと宛先の所有者とACLを比較し、差分のあるパスを報告します。
+移行後にアクセス権が正しく引き継がれているかの監査に使用できます。
+
+WindowsではセキュリティのDACL（許可・拒否のACE）と所有者をSIDで比較します。
+LinuxではPOSIX ACL（設定されていない場合はパーミッション）と所有者（uid:gid）を比較します。
+
+出力形式:
+  text - 差分を人が読む形式で表示（デフォルト）
+  csv  - 1行に1つの変更（所有者の変更、追加・削除されたACE、宛先の欠落、エラー）
+  json - パスごとの差分
+
+差分がある場合は終了コード1で終了します。`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if aclDiffFormat != "text" && aclDiffFormat != "csv" && aclDiffFormat != "json" {
+			fmt.Fprintf(os.Stderr, "サポートされていない出力形式: %s\n", aclDiffFormat)
+			os.Exit(1)
+		}
+
+		result, err := acldiff.Compare(args[0], args[1], filter.NewFilter(aclDiffInclude, aclDiffExclude))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "アクセス権の比較に失敗: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := writeACLDiff(result, aclDiffFormat, aclDiffOutput); err != nil {
+			fmt.Fprintf(os.Stderr, "比較結果の出力に失敗: %v\n", err)
+			os.Exit(1)
+		}
+		if aclDiffOutput != "" {
+			fmt.Printf("比較: %d件, 差分: %d件 (%s)\n", result.Compared, len(result.Entries), aclDiffOutput)
+		}
+
+		if result.HasDifferences() {
+			os.Exit(1)
+		}
+	},
+}
+
+// writeACLDiff は比較結果を指定された形式で書き出す（出力先が空の場合は標準出力）
+func writeACLDiff(result *acldiff.Result, format, outputPath string) error {
+	write := acldiff.WriteText
+	switch format {
+	case "csv":
+		write = acldiff.WriteCSV
+	case "json":
+		write = acldiff.WriteJSON
+	}
+
+	if outputPath == "" {
+		return write(os.Stdout, result)
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("ファイル作成エラー: %w", err)
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	if err := write(w, result); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+func init() {
+	rootCmd.AddCommand(aclDiffCmd)
+
+	aclDiffCmd.Flags().StringVar(&aclDiffFormat, "format", "text", "出力形式 (text, csv, json)")
+	aclDiffCmd.Flags().StringVarP(&aclDiffOutput, "output", "o", "", "出力ファイルのパス（省略時は標準出力）")
+	aclDiffCmd.Flags().StringVarP(&aclDiffInclude, "include", "i", "", "含めるファイルパターン（例: *.txt,*.docx）")
+	aclDiffCmd.Flags().StringVarP(&aclDiffExclude, "exclude", "e", "", "除外するファイルパターン（例: *.tmp,*.bak）")
+}
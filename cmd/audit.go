@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sakuhanight/gopier/internal/audit"
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/logger"
+)
+
+var (
+	// auditLogPath は完了した操作を記録する監査ログのパス（--audit-log）
+	auditLogPath string
+	// auditLog は開いた監査ログ（指定しない場合はnil）
+	auditLog *audit.Log
+)
+
+// auditCmd represents the audit command
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "監査ログを扱う",
+	Long:  `--audit-logで記録した監査ログを扱います。`,
+}
+
+// auditVerifyCmd represents the audit verify command
+var auditVerifyCmd = &cobra.Command{
+	Use:   "verify FILE",
+	Short: "監査ログのハッシュの連鎖を検証",
+	Long: `監査ログの各レコードに含まれる直前の行のハッシュと通し番号を先頭から確認し、
+行の改ざん・削除・並べ替えがないことを検証します。
+
+不整合が見つかった場合は、その行番号を表示して終了コード1で終了します。`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		file, err := os.Open(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "監査ログのオープンに失敗: %v\n", err)
+			os.Exit(1)
+		}
+		defer file.Close()
+
+		count, err := audit.Verify(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "監査ログの検証に失敗: %v（%d件目までは正常）\n", err, count)
+			os.Exit(1)
+		}
+		fmt.Printf("監査ログは正常です: %d件のレコード\n", count)
+	},
+}
+
+// openAuditLog は--audit-logが指定されている場合に監査ログを開く
+func openAuditLog() error {
+	if auditLogPath == "" {
+		return nil
+	}
+
+	var err error
+	auditLog, err = audit.Open(auditLogPath)
+	return err
+}
+
+// closeAuditLog は監査ログをディスクに書き込んでから閉じる
+// 失敗した実行の記録こそ必要になるため、途中で中断した場合もRunの遅延処理で必ず呼び出す
+// 記録が欠けた場合はコンプライアンス上の問題になるため、エラーを出力して終了コードで知らせる
+func closeAuditLog(log *logger.Logger) {
+	if auditLog == nil {
+		return
+	}
+
+	err := auditLog.Err()
+	if closeErr := auditLog.Close(); err == nil {
+		err = closeErr
+	}
+	auditLog = nil
+	if err != nil {
+		log.Error("監査ログの記録に失敗しました: %v", err)
+		if runExitCode == errcode.ExitOK {
+			runExitCode = errcode.ExitError
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+	auditCmd.AddCommand(auditVerifyCmd)
+}
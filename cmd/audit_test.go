@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sakuhanight/gopier/internal/audit"
+	"github.com/sakuhanight/gopier/internal/errcode"
+)
+
+// TestRun_AuditLogOnFailure は失敗した実行でも監査ログを閉じ、ハッシュの連鎖が検証できることをテスト
+func TestRun_AuditLogOnFailure(t *testing.T) {
+	tempDir := t.TempDir()
+	source := filepath.Join(tempDir, "source")
+	dest := filepath.Join(tempDir, "dest")
+	os.MkdirAll(source, 0755)
+	os.MkdirAll(dest, 0755)
+	os.WriteFile(filepath.Join(source, "a.txt"), []byte("source"), 0644)
+	os.WriteFile(filepath.Join(dest, "a.txt"), []byte("changed"), 0644)
+	path := filepath.Join(tempDir, "audit.jsonl")
+
+	// フラグの値を元に戻す
+	origSource, origDest, origAudit, origDB := sourceDir, destDir, auditLogPath, syncDBPath
+	origVerifyOnly, origVerifyAll, origNoProgress, origExitCode := verifyOnly, verifyAll, noProgress, runExitCode
+	defer func() {
+		sourceDir, destDir, auditLogPath, syncDBPath = origSource, origDest, origAudit, origDB
+		verifyOnly, verifyAll, noProgress, runExitCode = origVerifyOnly, origVerifyAll, origNoProgress, origExitCode
+	}()
+	sourceDir, destDir, auditLogPath = source, dest, path
+	// 作業ディレクトリにデフォルトのDBを作成しないよう、一時ディレクトリのDBを使用する
+	syncDBPath = filepath.Join(t.TempDir(), "sync_state.db")
+	verifyOnly, verifyAll, noProgress = true, true, true
+	runExitCode = errcode.ExitOK
+
+	// 検証で不一致が見つかった実行は、os.Exitせずに終了コードを設定して戻る
+	rootCmd.Run(rootCmd, nil)
+	if runExitCode != errcode.ExitVerifyFailed {
+		t.Errorf("runExitCode = %d, want %d", runExitCode, errcode.ExitVerifyFailed)
+	}
+	// 遅延処理で監査ログをディスクに書き込んでから閉じている
+	if auditLog != nil {
+		t.Error("監査ログが閉じられていません")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	count, err := audit.Verify(file)
+	if err != nil || count == 0 {
+		t.Errorf("audit.Verify() = %d, %v", count, err)
+	}
+}
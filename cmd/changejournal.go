@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/changejournal"
+	"github.com/sakuhanight/gopier/internal/copier"
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/logger"
+)
+
+// changeJournalFilter は変更ジャーナルの位置と一緒に記録する、処理するファイルを決める設定
+// 前回の実行と異なる場合は、新たに対象になったファイルを漏らさないよう全体を走査する
+func changeJournalFilter() string {
+	return fmt.Sprintf("include=%s exclude=%s profiles=%s hidden=%t system=%t",
+		includePattern, excludePattern, profileExclusions, includeHidden, includeSystem)
+}
+
+// prepareChangeJournal は--change-journalを指定した場合に、前回の実行以降に変更されたファイルを
+// 変更ジャーナルから取得し、コピーするパスの一覧に設定する
+// 変更ジャーナルを使用できない場合や初回の実行では全体を走査する（一覧を設定しない）。
+// コピーが完了した後に記録する位置を返す（変更ジャーナルを使用しない場合はnil）
+func prepareChangeJournal(log *logger.Logger, syncDB *database.SyncDB, options *copier.Options) *database.ChangeJournalCursor {
+	if !changeJournal || syncDB == nil {
+		return nil
+	}
+	source, err := filepath.Abs(sourceDir)
+	if err != nil {
+		log.Warn("変更ジャーナルを使用せず、全体を走査します: %v", err)
+		return nil
+	}
+
+	// コピー中の変更を漏らさないよう、ジャーナルを読み込む前の位置を次回の開始位置にする
+	position, err := changejournal.Current(source)
+	if err != nil {
+		log.Warn("変更ジャーナルを使用せず、全体を走査します: %v", err)
+		return nil
+	}
+	next := &database.ChangeJournalCursor{
+		Source:     source,
+		Volume:     position.Volume,
+		JournalID:  position.JournalID,
+		NextUSN:    position.NextUSN,
+		Filter:     changeJournalFilter(),
+		RecordedAt: time.Now(),
+	}
+
+	prev, err := syncDB.GetChangeJournalCursor(source)
+	switch {
+	case err != nil:
+		log.Warn("変更ジャーナルの位置を取得できません。全体を走査します: %v", err)
+		return next
+	case prev == nil:
+		log.Info("変更ジャーナルの位置が記録されていないため、全体を走査します")
+		return next
+	case syncMode == string(database.InitialSync):
+		log.Info("初回同期モードのため、全体を走査します")
+		return next
+	case prev.Filter != next.Filter:
+		log.Info("フィルタが前回の実行から変更されたため、全体を走査します")
+		return next
+	}
+
+	since := changejournal.Position{Volume: prev.Volume, JournalID: prev.JournalID, NextUSN: prev.NextUSN}
+	result, err := changejournal.Changes(source, since, position)
+	if err != nil {
+		if errors.Is(err, changejournal.ErrUnavailable) {
+			log.Warn("%v。全体を走査します", err)
+		} else {
+			log.Warn("変更ジャーナルの読み込みに失敗したため、全体を走査します: %v", err)
+		}
+		return next
+	}
+
+	// 前回までに失敗・不一致になったファイルは変更がなくても再度コピーする
+	list := result.Paths
+	retries := 0
+	if includeFailed {
+		failed, err := syncDB.GetFailedFiles(maxFailCount)
+		if err != nil {
+			log.Warn("変更ジャーナルを使用せず、全体を走査します: 失敗したファイルを取得できません: %v", err)
+			return next
+		}
+		mismatched, err := syncDB.GetFilesByStatus(database.StatusMismatch)
+		if err != nil {
+			log.Warn("変更ジャーナルを使用せず、全体を走査します: 不一致のファイルを取得できません: %v", err)
+			return next
+		}
+		for _, file := range append(failed, mismatched...) {
+			list = append(list, file.Path)
+			retries++
+		}
+	}
+
+	options.FileList = list
+	options.FilterFileList = true
+	log.Info("変更ジャーナルから変更されたパスを取得しました: %d件（レコード %d件, 削除・移動済み %d件, 前回までに失敗したファイル %d件）",
+		len(result.Paths), result.Records, result.Removed, retries)
+	return next
+}
+
+// saveChangeJournal はコピーが完了した後に変更ジャーナルの位置を記録する
+func saveChangeJournal(log *logger.Logger, syncDB *database.SyncDB, cursor *database.ChangeJournalCursor) {
+	if cursor == nil || dryRun {
+		return
+	}
+	if err := syncDB.SaveChangeJournalCursor(*cursor); err != nil {
+		log.Warn("変更ジャーナルの位置を記録できません: %v", err)
+	}
+}
@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"encoding/csv"
-	"encoding/json"
 	"fmt"
 	"os"
 	"sort"
@@ -12,16 +10,29 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/locale"
 )
 
 var (
-	dbPath    string
-	dbOutput  string
-	dbFormat  string
-	dbStatus  string
-	dbLimit   int
-	dbSortBy  string
-	dbReverse bool
+	dbPath        string
+	dbOutput      string
+	dbFormat      string
+	dbStatus      string
+	dbLimit       int
+	dbSortBy      string
+	dbReverse     bool
+	dbCompress    bool
+	dbDetailed    bool
+	dbLocale      string
+	dbRebuild     bool
+	dbTrend       bool
+	dbTrendLast   int
+	dbTop         int
+	dbJSON        bool
+	dbCleanFilter string
+	dbOlderThan   int
+	dbDryRun      bool
+	dbYes         bool
 )
 
 // dbCmd represents the db command
@@ -33,9 +44,13 @@ var dbCmd = &cobra.Command{
 利用可能なサブコマンド:
   list     - データベース内のファイル一覧を表示
   stats    - 同期統計情報を表示
+  sessions - 同期セッションの一覧を表示
   export   - データベースの内容をファイルにエクスポート
   clean    - 古いレコードを削除
-  reset    - データベースをリセット（初期同期モード用）`,
+  reset    - データベースをリセット（初期同期モード用）
+  vacuum   - データベースファイルを最適化して未使用領域を解放
+  check    - データベースの整合性をチェック
+  ack      - 確認済みの不一致を登録・一覧表示`,
 }
 
 // listCmd represents the list command
@@ -123,7 +138,13 @@ var listCmd = &cobra.Command{
 var statsCmd = &cobra.Command{
 	Use:   "stats",
 	Short: "同期統計情報を表示",
-	Long:  `データベースに記録されている同期統計情報を表示します。`,
+	Long: `データベースに記録されている同期統計情報を表示します。
+
+サイズ分布、サイズの大きいファイル、失敗回数の多いファイルの上位--top件も表示します。
+--jsonを指定するとJSON形式で出力します。
+
+--trendを指定すると、セッションごとの検証結果（不一致率）の推移を表示します。
+同期を繰り返す中で不一致率が上昇している場合は、記録媒体の劣化などが疑われます。`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if dbPath == "" {
 			fmt.Fprintf(os.Stderr, "データベースパスが指定されていません。--dbフラグを使用してください。\n")
@@ -138,6 +159,16 @@ var statsCmd = &cobra.Command{
 		}
 		defer syncDB.Close()
 
+		if dbTrend {
+			sessions, err := syncDB.GetSessions()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "セッション一覧の取得に失敗: %v\n", err)
+				os.Exit(1)
+			}
+			printVerificationTrend(sessions, dbTrendLast)
+			return
+		}
+
 		// 統計情報を取得
 		stats, err := syncDB.GetSyncStats()
 		if err != nil {
@@ -145,49 +176,29 @@ var statsCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		// ファイル一覧を取得して詳細統計を計算
-		files, err := syncDB.GetAllFiles()
+		// ファイルを1件ずつ読みながら詳細統計を計算
+		collector := newStatsCollector(dbTop)
+		err = syncDB.ForEachFile(false, func(file database.FileInfo) error {
+			collector.Add(file)
+			return nil
+		})
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "ファイル一覧の取得に失敗: %v\n", err)
 			os.Exit(1)
 		}
 
-		fmt.Printf("データベース: %s\n", dbPath)
-		fmt.Println(strings.Repeat("=", 50))
-
-		// 基本統計
-		fmt.Printf("総ファイル数: %d\n", len(files))
-		fmt.Printf("総サイズ: %s\n", formatBytes(calculateTotalSize(files)))
-
-		// ステータス別統計
-		statusCount := make(map[database.FileStatus]int)
-		for _, file := range files {
-			statusCount[file.Status]++
-		}
-
-		fmt.Println("\nステータス別統計:")
-		for status, count := range statusCount {
-			fmt.Printf("  %s: %d件\n", status, count)
-		}
-
-		// 同期セッション統計
-		fmt.Println("\n同期セッション統計:")
-		for key, value := range stats {
-			fmt.Printf("  %s: %d\n", key, value)
-		}
+		report := collector.Report()
+		report.Database = dbPath
+		report.SessionStats = stats
 
-		// 失敗回数統計
-		failCounts := make(map[int]int)
-		for _, file := range files {
-			failCounts[file.FailCount]++
-		}
-
-		fmt.Println("\n失敗回数別統計:")
-		for failCount, count := range failCounts {
-			if failCount > 0 {
-				fmt.Printf("  失敗%d回: %d件\n", failCount, count)
+		if dbJSON {
+			if err := writeStatsJSON(os.Stdout, report); err != nil {
+				fmt.Fprintf(os.Stderr, "統計情報の出力に失敗: %v\n", err)
+				os.Exit(1)
 			}
+			return
 		}
+		printStats(os.Stdout, report)
 	},
 }
 
@@ -195,11 +206,21 @@ var statsCmd = &cobra.Command{
 var exportCmd = &cobra.Command{
 	Use:   "export",
 	Short: "データベースの内容をファイルにエクスポート",
-	Long: `データベースの内容をCSVまたはJSON形式でファイルにエクスポートします。
+	Long: `データベースの内容をCSV・JSON・NDJSON形式でファイルにエクスポートします。
+レコードは1件ずつ書き出すため、大規模なデータベースでもメモリを圧迫しません
+（--sort-byにpath以外を指定した場合は全件を読み込んでからソートします）。
+端末上では標準エラー出力に進捗を表示します。
 
 サポートされている形式:
-  csv  - CSVファイル（デフォルト）
-  json - JSONファイル`,
+  csv    - CSVファイル（デフォルト）
+  json   - JSONファイル
+  ndjson - 1行1レコードのJSON（NDJSON）
+
+--compressを指定するとgzipで圧縮して出力します。
+--detailedを指定すると、CSVに--detailed-reportで記録したコピーの処理時間・再試行回数・
+ワーカー・スループットの列を加えます。
+--localeでヘッダと最終エラーのメッセージの言語（ja, en）を指定します。ステータスと
+エラーコードは言語によらず同じ値のため、プログラムから判別する場合はこちらを使用します。`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if dbPath == "" {
 			fmt.Fprintf(os.Stderr, "データベースパスが指定されていません。--dbフラグを使用してください。\n")
@@ -219,44 +240,77 @@ var exportCmd = &cobra.Command{
 		}
 		defer syncDB.Close()
 
-		// ファイル一覧を取得
-		files, err := syncDB.GetAllFiles()
+		format := strings.ToLower(dbFormat)
+		if format != "csv" && format != "json" && format != "ndjson" {
+			fmt.Fprintf(os.Stderr, "サポートされていない形式: %s\n", dbFormat)
+			os.Exit(1)
+		}
+		loc, err := locale.Parse(dbLocale)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "ファイル一覧の取得に失敗: %v\n", err)
+			fmt.Fprintf(os.Stderr, "%v\n", err)
 			os.Exit(1)
 		}
 
-		// フィルタリング
-		if dbStatus != "" {
-			filtered := make([]database.FileInfo, 0)
-			for _, file := range files {
-				if string(file.Status) == dbStatus {
-					filtered = append(filtered, file)
-				}
-			}
-			files = filtered
+		total, err := syncDB.CountFiles()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ファイル数の取得に失敗: %v\n", err)
+			os.Exit(1)
 		}
 
-		// ソート
-		sortFiles(files, dbSortBy, dbReverse)
-
-		// エクスポート
-		switch strings.ToLower(dbFormat) {
-		case "csv":
-			err = exportToCSV(files, dbOutput)
-		case "json":
-			err = exportToJSON(files, dbOutput)
-		default:
-			fmt.Fprintf(os.Stderr, "サポートされていない形式: %s\n", dbFormat)
+		out, err := openExportOutput(dbOutput, dbCompress)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "エクスポートに失敗: %v\n", err)
+			os.Exit(1)
+		}
+		writer, err := newRecordWriter(format, out, dbDetailed, loc)
+		if err != nil {
+			out.Close()
+			fmt.Fprintf(os.Stderr, "エクスポートに失敗: %v\n", err)
 			os.Exit(1)
 		}
 
+		// 1件ずつ書き出す（フィルタリングもここで行う）
+		progress := newExportProgress(total)
+		exported := 0
+		write := func(file database.FileInfo) error {
+			progress.Add()
+			if dbStatus != "" && string(file.Status) != dbStatus {
+				return nil
+			}
+			exported++
+			return writer.Write(file)
+		}
+
+		if dbSortBy == "" || dbSortBy == "path" {
+			// パス順はデータベースのキー順と同じため、全件を読み込まずに書き出せる
+			err = syncDB.ForEachFile(dbReverse, write)
+		} else {
+			// パス以外でソートする場合は全件を読み込む必要がある
+			var files []database.FileInfo
+			files, err = syncDB.GetAllFiles()
+			if err == nil {
+				sortFiles(files, dbSortBy, dbReverse)
+				for _, file := range files {
+					if err = write(file); err != nil {
+						break
+					}
+				}
+			}
+		}
+		if err == nil {
+			err = writer.Close()
+		}
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		progress.Done()
+
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "エクスポートに失敗: %v\n", err)
 			os.Exit(1)
 		}
 
-		fmt.Printf("データベースの内容を %s にエクスポートしました: %s\n", dbFormat, dbOutput)
+		fmt.Printf("データベースの内容を %s にエクスポートしました: %s (%d件)\n", format, dbOutput, exported)
 	},
 }
 
@@ -264,13 +318,26 @@ var exportCmd = &cobra.Command{
 var cleanCmd = &cobra.Command{
 	Use:   "clean",
 	Short: "古いレコードを削除",
-	Long:  `指定された日数より古いレコードを削除します。`,
+	Long: `条件に一致するレコードを削除します。指定したすべての条件を満たすレコードが対象です。
+
+  --older-than - 最終同期時間が指定日数より前のレコード（デフォルト: 30日、0で条件なし）
+  --filter     - パスのglobパターン（カンマ区切り。"/"を含まないパターンはファイル名と照合）
+  --status     - 同期状態（カンマ区切りで複数指定可）
+
+--dry-runを指定すると、削除せずに対象のレコードを表示します。
+削除の前に確認を求めます。--yesを指定すると確認を省略します。`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if dbPath == "" {
 			fmt.Fprintf(os.Stderr, "データベースパスが指定されていません。--dbフラグを使用してください。\n")
 			os.Exit(1)
 		}
 
+		query, err := buildCleanQuery(dbCleanFilter, dbStatus, dbOlderThan, time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+
 		// データベースを開く
 		syncDB, err := database.NewSyncDB(dbPath, database.NormalSync)
 		if err != nil {
@@ -279,25 +346,50 @@ var cleanCmd = &cobra.Command{
 		}
 		defer syncDB.Close()
 
-		// ファイル一覧を取得
-		files, err := syncDB.GetAllFiles()
+		// 対象を数える（ドライランでは対象を表示する）
+		var preview func(database.FileInfo)
+		if dbDryRun {
+			preview = func(file database.FileInfo) {
+				syncTimeStr := "未同期"
+				if !file.LastSyncTime.IsZero() {
+					syncTimeStr = file.LastSyncTime.Format("2006-01-02 15:04:05")
+				}
+				fmt.Printf("%-50s %-10s %s\n", truncateString(file.Path, 50), file.Status, syncTimeStr)
+			}
+		}
+		count, err := syncDB.DeleteFiles(query, true, preview)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "ファイル一覧の取得に失敗: %v\n", err)
+			fmt.Fprintf(os.Stderr, "レコードの検索に失敗: %v\n", err)
 			os.Exit(1)
 		}
 
-		// 古いレコードを削除
-		cutoff := time.Now().AddDate(0, 0, -30) // デフォルト30日前
-		deletedCount := 0
+		if dbDryRun {
+			fmt.Printf("%d件のレコードが削除対象です（ドライランのため削除していません）。\n", count)
+			return
+		}
+		if count == 0 {
+			fmt.Println("削除対象のレコードはありません。")
+			return
+		}
 
-		for _, file := range files {
-			if file.LastSyncTime.Before(cutoff) {
-				// レコードを削除（実装は後で追加）
-				deletedCount++
+		// 確認
+		if !dbYes {
+			fmt.Printf("%d件のレコードを削除しますか？ (y/N): ", count)
+			var response string
+			fmt.Scanln(&response)
+			if strings.ToLower(response) != "y" && strings.ToLower(response) != "yes" {
+				fmt.Println("削除をキャンセルしました。")
+				return
 			}
 		}
 
-		fmt.Printf("%d件の古いレコードを削除しました。\n", deletedCount)
+		deleted, err := syncDB.DeleteFiles(query, false, nil)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "レコードの削除に失敗: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("%d件のレコードを削除しました。\n", deleted)
 	},
 }
 
@@ -341,6 +433,101 @@ var resetCmd = &cobra.Command{
 	},
 }
 
+// vacuumCmd represents the vacuum command
+var vacuumCmd = &cobra.Command{
+	Use:   "vacuum",
+	Short: "データベースファイルを最適化",
+	Long: `データベースファイルを作り直して未使用領域を解放し、最適化前後のサイズを表示します。
+大量のレコードを削除・移行した後はファイルが肥大化したままになるため、このコマンドで縮小できます。
+
+--rebuildを指定すると、最適化の前にファイル情報のキーを正規化したパスで作り直し、
+読み込めないレコードを削除します。
+
+注意: 実行中は同期処理など他のプロセスがデータベースを使用していないことを確認してください。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if dbPath == "" {
+			fmt.Fprintf(os.Stderr, "データベースパスが指定されていません。--dbフラグを使用してください。\n")
+			os.Exit(1)
+		}
+		if _, err := os.Stat(dbPath); err != nil {
+			fmt.Fprintf(os.Stderr, "データベースファイルが見つかりません: %v\n", err)
+			os.Exit(1)
+		}
+
+		if dbRebuild {
+			syncDB, err := database.NewSyncDB(dbPath, database.NormalSync)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "データベースのオープンに失敗: %v\n", err)
+				os.Exit(1)
+			}
+			rebuilt, removed, err := syncDB.RebuildFileRecords()
+			syncDB.Close()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "レコードの再構築に失敗: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("レコードを再構築しました: 作り直し %d件, 削除 %d件\n", rebuilt, removed)
+		}
+
+		result, err := database.Compact(dbPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "データベースの最適化に失敗: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("データベースを最適化しました: %s -> %s (%s 削減)\n",
+			formatBytes(result.SizeBefore), formatBytes(result.SizeAfter),
+			formatBytes(result.SizeBefore-result.SizeAfter))
+	},
+}
+
+// checkCmd represents the check command
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "データベースの整合性をチェック",
+	Long: `データベースファイルの構造の破損と、読み込めないレコードやキーとパスが一致しない
+レコードを検出します。データベースの内容は変更しません。
+問題が見つかった場合は終了コード1で終了します。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if dbPath == "" {
+			fmt.Fprintf(os.Stderr, "データベースパスが指定されていません。--dbフラグを使用してください。\n")
+			os.Exit(1)
+		}
+
+		result, err := database.Check(dbPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "整合性チェックに失敗: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("データベース: %s (%s)\n", dbPath, formatBytes(result.Size))
+		fmt.Printf("ファイル情報: %d件, セッション: %d件\n", result.Files, result.Sessions)
+
+		if result.OK() {
+			fmt.Println("問題は見つかりませんでした。")
+			return
+		}
+
+		for _, e := range result.PageErrors {
+			fmt.Printf("  [破損] %s\n", e)
+		}
+		for _, e := range result.RecordErrors {
+			fmt.Printf("  [レコード] %s\n", e)
+		}
+		if shown := len(result.PageErrors) + len(result.RecordErrors); shown < result.ErrorCount {
+			fmt.Printf("  ...他 %d件\n", result.ErrorCount-shown)
+		}
+
+		fmt.Printf("%d件の問題が見つかりました。\n", result.ErrorCount)
+		if len(result.PageErrors) > 0 {
+			fmt.Println("ファイル構造が破損しています。バックアップからの復元を検討してください。")
+		} else {
+			fmt.Println("レコードの問題は 'gopier db vacuum --rebuild' で修復できます。")
+		}
+		os.Exit(1)
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(dbCmd)
 
@@ -350,6 +537,8 @@ func init() {
 	dbCmd.AddCommand(exportCmd)
 	dbCmd.AddCommand(cleanCmd)
 	dbCmd.AddCommand(resetCmd)
+	dbCmd.AddCommand(vacuumCmd)
+	dbCmd.AddCommand(checkCmd)
 
 	// 共通フラグ
 	dbCmd.PersistentFlags().StringVar(&dbPath, "db", "", "データベースファイルのパス")
@@ -362,10 +551,112 @@ func init() {
 
 	// exportコマンドのフラグ
 	exportCmd.Flags().StringVar(&dbOutput, "output", "", "出力ファイルのパス")
-	exportCmd.Flags().StringVar(&dbFormat, "format", "csv", "出力形式 (csv, json)")
+	exportCmd.Flags().StringVar(&dbFormat, "format", "csv", "出力形式 (csv, json, ndjson)")
+	exportCmd.Flags().BoolVar(&dbCompress, "compress", false, "gzipで圧縮して出力")
+	exportCmd.Flags().StringVar(&dbLocale, "locale", "ja", "ヘッダと最終エラーのメッセージの言語 (ja, en)。ステータスとエラーコードは言語によらず同じ値")
+	exportCmd.Flags().BoolVar(&dbDetailed, "detailed", false, "CSVにコピーの処理時間・再試行回数・ワーカー・スループットの列を加える（--detailed-reportで記録した場合）")
+
+	// statsコマンドのフラグ
+	statsCmd.Flags().BoolVar(&dbTrend, "trend", false, "セッションごとの検証結果の推移を表示")
+	statsCmd.Flags().IntVar(&dbTrendLast, "last", 20, "--trendで表示する直近のセッション数（0ですべて）")
+	statsCmd.Flags().IntVar(&dbTop, "top", 10, "サイズ・失敗回数のランキングに表示する件数（0で表示しない）")
+	statsCmd.Flags().BoolVar(&dbJSON, "json", false, "統計情報をJSON形式で出力")
+
+	// cleanコマンドのフラグ
+	cleanCmd.Flags().StringVar(&dbCleanFilter, "filter", "", "削除対象のパスのglobパターン（カンマ区切り）")
+	cleanCmd.Flags().IntVar(&dbOlderThan, "older-than", 30, "最終同期時間がこの日数より前のレコードを削除（0で条件なし）")
+	cleanCmd.Flags().BoolVar(&dbDryRun, "dry-run", false, "削除せずに対象のレコードを表示")
+	cleanCmd.Flags().BoolVarP(&dbYes, "yes", "y", false, "確認せずに削除")
+
+	// vacuumコマンドのフラグ
+	vacuumCmd.Flags().BoolVar(&dbRebuild, "rebuild", false, "最適化の前にレコードのキーを作り直す")
 }
 
 // ヘルパー関数
+
+// verifiedSessions は検証結果が記録されたセッションのうち直近last件を返す（lastが0の場合はすべて）
+func verifiedSessions(sessions []database.SyncSession, last int) []database.SyncSession {
+	var verified []database.SyncSession
+	for _, session := range sessions {
+		if session.Verification != nil {
+			verified = append(verified, session)
+		}
+	}
+	if last > 0 && len(verified) > last {
+		verified = verified[len(verified)-last:]
+	}
+	return verified
+}
+
+// mismatchRate は複数のセッションをまとめた不一致率を返す
+func mismatchRate(sessions []database.SyncSession) float64 {
+	var total database.VerificationSummary
+	for _, session := range sessions {
+		total.Matched += session.Verification.Matched
+		total.Mismatched += session.Verification.Mismatched
+	}
+	return total.MismatchRate()
+}
+
+// buildCleanQuery はcleanコマンドのフラグから削除条件を作成する
+func buildCleanQuery(filter, statuses string, olderThanDays int, now time.Time) (database.FileQuery, error) {
+	var query database.FileQuery
+	if olderThanDays < 0 {
+		return query, fmt.Errorf("--older-thanには0以上の日数を指定してください")
+	}
+	if olderThanDays > 0 {
+		query.OlderThan = now.AddDate(0, 0, -olderThanDays)
+	}
+	for _, pattern := range strings.Split(filter, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			query.Patterns = append(query.Patterns, pattern)
+		}
+	}
+	for _, status := range strings.Split(statuses, ",") {
+		if status = strings.TrimSpace(status); status != "" {
+			query.Statuses = append(query.Statuses, database.FileStatus(status))
+		}
+	}
+	return query, query.Validate()
+}
+
+// printVerificationTrend はセッションごとの検証結果の推移を表示する
+func printVerificationTrend(sessions []database.SyncSession, last int) {
+	verified := verifiedSessions(sessions, last)
+
+	fmt.Printf("データベース: %s\n", dbPath)
+	fmt.Println(strings.Repeat("=", 50))
+
+	if len(verified) == 0 {
+		fmt.Println("検証結果が記録されたセッションはありません。")
+		return
+	}
+
+	fmt.Println("検証結果の推移:")
+	fmt.Printf("  %-19s  %-6s  %8s  %8s  %8s  %8s  %9s\n", "日時", "種類", "検証", "不一致", "宛先なし", "エラー", "不一致率")
+	for _, session := range verified {
+		v := session.Verification
+		sessionType := session.Type
+		if sessionType == "" {
+			sessionType = string(database.SessionCopy)
+		}
+		fmt.Printf("  %-19s  %-6s  %8d  %8d  %8d  %8d  %8.3f%%\n",
+			session.StartTime.Format("2006-01-02 15:04:05"), sessionType,
+			v.Verified(), v.Mismatched, v.MissingDest, v.Errors, v.MismatchRate()*100)
+	}
+
+	// 前半と後半の不一致率を比較して傾向を示す
+	if len(verified) >= 4 {
+		half := len(verified) / 2
+		earlier := mismatchRate(verified[:half])
+		recent := mismatchRate(verified[half:])
+		fmt.Printf("\n不一致率: 直近%d回 %.3f%% / それ以前%d回 %.3f%%\n",
+			len(verified)-half, recent*100, half, earlier*100)
+		if recent > earlier {
+			fmt.Println("不一致率が上昇しています。記録媒体の劣化がないか確認してください。")
+		}
+	}
+}
 func sortFiles(files []database.FileInfo, sortBy string, reverse bool) {
 	sort.Slice(files, func(i, j int) bool {
 		var result bool
@@ -419,50 +710,9 @@ func calculateTotalSize(files []database.FileInfo) int64 {
 }
 
 func exportToCSV(files []database.FileInfo, outputPath string) error {
-	file, err := os.Create(outputPath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
-
-	// ヘッダー
-	header := []string{"パス", "サイズ", "更新日時", "ステータス", "ソースハッシュ", "宛先ハッシュ", "失敗回数", "最終同期", "最終エラー"}
-	if err := writer.Write(header); err != nil {
-		return err
-	}
-
-	// データ
-	for _, file := range files {
-		row := []string{
-			file.Path,
-			fmt.Sprintf("%d", file.Size),
-			file.ModTime.Format(time.RFC3339),
-			string(file.Status),
-			file.SourceHash,
-			file.DestHash,
-			fmt.Sprintf("%d", file.FailCount),
-			file.LastSyncTime.Format(time.RFC3339),
-			file.LastError,
-		}
-		if err := writer.Write(row); err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return exportFiles(files, outputPath, "csv", false)
 }
 
 func exportToJSON(files []database.FileInfo, outputPath string) error {
-	file, err := os.Create(outputPath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(files)
+	return exportFiles(files, outputPath, "json", false)
 }
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sakuhanight/gopier/internal/database"
+)
+
+var (
+	dbAckReason string
+	dbAckRemove bool
+)
+
+// ackCmd は確認済みの不一致を登録・解除するコマンド
+var ackCmd = &cobra.Command{
+	Use:   "ack [PATTERN...]",
+	Short: "確認済みの不一致を登録・一覧表示",
+	Long: `検証レポートを確認して許容すると判断した不一致（ごみ箱など）のパスをデータベースに登録します。
+verify・report diffとコピー時の検証に--suppress-acknowledgedを指定すると、登録したパスの差分・失敗を報告しません。
+
+パターンはパスまたはglobパターンで、一致したディレクトリの配下も対象になります
+（例: '$RECYCLE.BIN'、'*.lnk'、'users/*/AppData'）。
+パターンを指定しない場合は登録済みの一覧を表示します。--removeを指定すると登録を解除します。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if dbPath == "" {
+			fmt.Fprintf(os.Stderr, "データベースパスが指定されていません。--dbフラグを使用してください。\n")
+			os.Exit(1)
+		}
+		if dbAckRemove && len(args) == 0 {
+			fmt.Fprintf(os.Stderr, "解除するパターンを指定してください。\n")
+			os.Exit(1)
+		}
+
+		// データベースを開く
+		syncDB, err := database.NewSyncDB(dbPath, database.NormalSync)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "データベースのオープンに失敗: %v\n", err)
+			os.Exit(1)
+		}
+		defer syncDB.Close()
+
+		if len(args) == 0 {
+			acks, err := syncDB.GetAcknowledgements()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "確認済みの不一致の取得に失敗: %v\n", err)
+				os.Exit(1)
+			}
+			printAcknowledgements(acks)
+			return
+		}
+
+		for _, pattern := range args {
+			if dbAckRemove {
+				removed, err := syncDB.Unacknowledge(pattern)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "登録の解除に失敗: %s: %v\n", pattern, err)
+					os.Exit(1)
+				}
+				if removed {
+					fmt.Printf("登録を解除しました: %s\n", pattern)
+				} else {
+					fmt.Printf("登録されていません: %s\n", pattern)
+				}
+				continue
+			}
+
+			if err := syncDB.Acknowledge(pattern, dbAckReason); err != nil {
+				fmt.Fprintf(os.Stderr, "登録に失敗: %s: %v\n", pattern, err)
+				os.Exit(1)
+			}
+			fmt.Printf("確認済みとして登録しました: %s\n", pattern)
+		}
+	},
+}
+
+func init() {
+	dbCmd.AddCommand(ackCmd)
+
+	ackCmd.Flags().StringVar(&dbAckReason, "reason", "", "確認済みとした理由")
+	ackCmd.Flags().BoolVar(&dbAckRemove, "remove", false, "指定したパターンの登録を解除")
+}
+
+// printAcknowledgements は確認済みの不一致の一覧を表示する
+func printAcknowledgements(acks database.Acknowledgements) {
+	fmt.Printf("データベース: %s\n", dbPath)
+	fmt.Println(strings.Repeat("=", 50))
+
+	if len(acks) == 0 {
+		fmt.Println("確認済みの不一致は登録されていません。")
+		return
+	}
+	for _, ack := range acks {
+		fmt.Printf("%-40s  %s  %s\n", ack.Pattern, ack.AcknowledgedAt.Format("2006-01-02 15:04:05"), ack.Reason)
+	}
+}
+
+// loadAcknowledgements は--suppress-acknowledgedで使用する確認済みの不一致を読み込む
+// 存在しないデータベースを作成しないよう、ファイルがない場合はエラーにする
+func loadAcknowledgements(path string) (database.Acknowledgements, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+
+	syncDB, err := database.NewSyncDB(path, database.NormalSync)
+	if err != nil {
+		return nil, err
+	}
+	defer syncDB.Close()
+
+	return syncDB.GetAcknowledgements()
+}
+
+// isAcknowledged はパスが確認済みの不一致に該当するかを返す関数を作成する
+func isAcknowledged(acks database.Acknowledgements) func(string) bool {
+	return func(path string) bool {
+		_, ok := acks.Match(path)
+		return ok
+	}
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sakuhanight/gopier/internal/changelist"
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/hasher"
+)
+
+var (
+	changelistSession  int64
+	changelistFormat   string
+	changelistOutput   string
+	changelistDest     string
+	changelistHashAlgo string
+)
+
+// changelistCmd は1回の実行で宛先に加えた変更の一覧を書き出すコマンド
+var changelistCmd = &cobra.Command{
+	Use:   "changelist",
+	Short: "実行で宛先に加えた変更の一覧を出力",
+	Long: `1回の実行で宛先に作成・更新・削除したファイルの一覧を、データベースの記録からJSONで出力します。
+検索インデクサやCDCのパイプラインなど、宛先の変更を後段のシステムに伝えるために使用します。
+
+1回の実行は、コピーセッションと、次のコピーセッションまでに行った検証セッション
+（--extras-action deleteなどによる余分なファイルの削除を含む）です。
+--sessionを省略すると最新のコピーセッションを使用します（セッションIDはdb sessionsで確認できます）。
+
+出力形式:
+  json   - セッションの情報と変更の配列
+  ndjson - 1行に1件の変更（逐次読み込む場合）
+
+ハッシュはデータベースに記録されている場合（検証や事前作成を行った場合）に出力します。
+--destを指定すると、作成・更新したファイルのハッシュを宛先から計算します。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if dbPath == "" {
+			fmt.Fprintf(os.Stderr, "データベースパスが指定されていません。--dbフラグを使用してください。\n")
+			os.Exit(1)
+		}
+		if changelistFormat != "json" && changelistFormat != "ndjson" {
+			fmt.Fprintf(os.Stderr, "サポートされていない出力形式: %s\n", changelistFormat)
+			os.Exit(1)
+		}
+
+		// データベースを開く
+		syncDB, err := database.NewSyncDB(dbPath, database.NormalSync)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "データベースのオープンに失敗: %v\n", err)
+			os.Exit(1)
+		}
+		defer syncDB.Close()
+
+		opts := changelist.Options{SessionID: changelistSession}
+		if changelistDest != "" {
+			opts.DestDir = changelistDest
+			opts.Hasher = hasher.NewHasher(hasher.Algorithm(changelistHashAlgo), 0)
+		}
+		list, err := changelist.Build(syncDB, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "変更の一覧の作成に失敗: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := writeChangelist(list, changelistFormat, changelistOutput); err != nil {
+			fmt.Fprintf(os.Stderr, "変更の一覧の出力に失敗: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// writeChangelist は変更の一覧を指定された形式で書き出す（出力先が空の場合は標準出力）
+func writeChangelist(list *changelist.Changelist, format, outputPath string) error {
+	write := changelist.WriteJSON
+	if format == "ndjson" {
+		write = changelist.WriteNDJSON
+	}
+
+	if outputPath == "" {
+		return write(os.Stdout, list)
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("ファイル作成エラー: %w", err)
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	if err := write(w, list); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+func init() {
+	dbCmd.AddCommand(changelistCmd)
+
+	changelistCmd.Flags().Int64Var(&changelistSession, "session", 0, "コピーセッションのID（省略時は最新のコピーセッション）")
+	changelistCmd.Flags().StringVar(&changelistFormat, "format", "json", "出力形式 (json, ndjson)")
+	changelistCmd.Flags().StringVarP(&changelistOutput, "output", "o", "", "出力ファイルのパス（省略時は標準出力）")
+	changelistCmd.Flags().StringVar(&changelistDest, "dest", "", "作成・更新したファイルのハッシュを計算する宛先ディレクトリ")
+	changelistCmd.Flags().StringVar(&changelistHashAlgo, "hash-algorithm", "sha256", "宛先から計算するハッシュのアルゴリズム (md5, sha1, sha256, sha512)")
+}
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/filter"
+	"github.com/sakuhanight/gopier/internal/toollog"
+)
+
+var (
+	compareLogFormat      string
+	compareLogRoot        string
+	compareLogStripPrefix string
+	compareLogExclude     string
+	compareLogIgnoreCase  bool
+	compareLogOutFormat   string
+	compareLogOutput      string
+)
+
+// compareLogCmd は別のツールのログとDBの記録を突き合わせるコマンド
+var compareLogCmd = &cobra.Command{
+	Use:   "compare-log LOGFILE",
+	Short: "rsync・robocopyのログとデータベースの記録を突き合わせ",
+	Long: `移行の途中でrsyncやrobocopyからgopierに切り替えた場合に、前のツールのログを読み込み、
+前のツールが処理したファイルをgopierが漏れなく処理したかをデータベースの記録と突き合わせます。
+データベースとログはどちらも変更しません。
+
+対応するログ:
+  rsync    - --itemize-changes（-i）の出力、または--log-fileのログ
+  robocopy - /LOG・/UNILOGのログ（UTF-16のログも読み込めます）
+--log-formatを省略すると内容から判定します。
+
+パスはソースからの相対パスで突き合わせます。robocopyではログのSourceを基準にします。
+rsyncのエラー（"rsync: ... failed to open"）の絶対パスを突き合わせるには--source-rootを、
+ソースの末尾に/を付けずに実行したログ（パスの先頭にディレクトリ名が付く）には--strip-prefixを指定します。
+
+不一致の種類:
+  missing     - 前のツールが処理したが、データベースに記録がない
+  prev_failed - 前のツールで失敗し、データベースに記録がない
+  failed      - データベースの記録が失敗・不一致・処理待ち
+  removed     - gopierが余分なファイルとして削除・隔離した
+
+出力形式:
+  text    - 1行に1件の不一致と集計（デフォルト）
+  summary - 集計のみ
+  csv     - 1行に1件の不一致
+  json    - 不一致と種類ごとの件数
+  list    - 不一致のパスのみ（--files-fromで再実行する作業リスト、removedを除く）
+
+不一致がある場合は終了コード1で終了します。`,
+	Example: `  gopier db compare-log rsync.log --db sync_state.db --source-root /mnt/share
+  gopier db compare-log robocopy.log --db sync_state.db --ignore-case --format list -o worklist.txt
+  gopier -s /mnt/share -d /backup --db sync_state.db --files-from worklist.txt`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if dbPath == "" {
+			fmt.Fprintf(os.Stderr, "データベースパスが指定されていません。--dbフラグを使用してください。\n")
+			os.Exit(1)
+		}
+		switch compareLogOutFormat {
+		case "text", "summary", "csv", "json", "list":
+		default:
+			fmt.Fprintf(os.Stderr, "サポートされていない出力形式: %s\n", compareLogOutFormat)
+			os.Exit(1)
+		}
+
+		file, err := os.Open(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ログを開けません: %v\n", err)
+			os.Exit(1)
+		}
+		log, err := toollog.Parse(file, toollog.Options{
+			Format:      compareLogFormat,
+			Root:        compareLogRoot,
+			StripPrefix: compareLogStripPrefix,
+		})
+		file.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ログの読み込みに失敗: %v\n", err)
+			os.Exit(1)
+		}
+
+		// データベースを開く
+		syncDB, err := database.NewSyncDB(dbPath, database.NormalSync)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "データベースのオープンに失敗: %v\n", err)
+			os.Exit(1)
+		}
+		defer syncDB.Close()
+
+		opts := toollog.CompareOptions{IgnoreCase: compareLogIgnoreCase}
+		if compareLogExclude != "" {
+			opts.Filter = filter.NewFilter("", compareLogExclude)
+		}
+		result, err := toollog.Compare(syncDB, log, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "突き合わせに失敗: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := writeCompareLog(result, compareLogOutFormat, compareLogOutput); err != nil {
+			fmt.Fprintf(os.Stderr, "突き合わせの結果の出力に失敗: %v\n", err)
+			os.Exit(1)
+		}
+		if compareLogOutput != "" {
+			fmt.Printf("突き合わせ: %d件, 不一致: %d件 (%s)\n", result.Files, len(result.Entries), compareLogOutput)
+		}
+
+		if result.HasDiscrepancies() {
+			syncDB.Close()
+			os.Exit(1)
+		}
+	},
+}
+
+// writeCompareLog は突き合わせの結果を指定された形式で書き出す（出力先が空の場合は標準出力）
+func writeCompareLog(result *toollog.Result, format, outputPath string) error {
+	write := toollog.WriteText
+	switch format {
+	case "summary":
+		write = toollog.WriteSummary
+	case "csv":
+		write = toollog.WriteCSV
+	case "json":
+		write = toollog.WriteJSON
+	case "list":
+		write = toollog.WriteList
+	}
+
+	if outputPath == "" {
+		return write(os.Stdout, result)
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("ファイル作成エラー: %w", err)
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	if err := write(w, result); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+func init() {
+	dbCmd.AddCommand(compareLogCmd)
+
+	compareLogCmd.Flags().StringVar(&compareLogFormat, "log-format", toollog.FormatAuto, "ログの形式 (auto, rsync, robocopy)")
+	compareLogCmd.Flags().StringVar(&compareLogRoot, "source-root", "", "前のツールのソースのルート（ログ中の絶対パスの基準、robocopyでは省略時にログのSource）")
+	compareLogCmd.Flags().StringVar(&compareLogStripPrefix, "strip-prefix", "", "ログの相対パスから取り除く接頭辞（例: share/）")
+	compareLogCmd.Flags().StringVarP(&compareLogExclude, "exclude", "e", "", "突き合わせないファイルパターン（gopierで除外したもの、例: *.tmp,Thumbs.db）")
+	compareLogCmd.Flags().BoolVar(&compareLogIgnoreCase, "ignore-case", false, "パスの大文字・小文字を区別しない（Windowsのソース向け）")
+	compareLogCmd.Flags().StringVar(&compareLogOutFormat, "format", "text", "出力形式 (text, summary, csv, json, list)")
+	compareLogCmd.Flags().StringVarP(&compareLogOutput, "output", "o", "", "出力ファイルのパス（省略時は標準出力）")
+}
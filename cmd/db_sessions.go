@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sakuhanight/gopier/internal/database"
+)
+
+var dbLabel string
+
+// sessionsCmd はセッションの一覧を表示するコマンド
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "同期セッションの一覧を表示",
+	Long: `データベースに記録されている同期セッションの一覧を表示します。
+
+--labelを指定すると、--labelを付けて実行したセッションのみを表示し、
+件数とバイト数の合計も表示します。移行の段階ごとの集計などに使用します。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if dbPath == "" {
+			fmt.Fprintf(os.Stderr, "データベースパスが指定されていません。--dbフラグを使用してください。\n")
+			os.Exit(1)
+		}
+
+		// データベースを開く
+		syncDB, err := database.NewSyncDB(dbPath, database.NormalSync)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "データベースのオープンに失敗: %v\n", err)
+			os.Exit(1)
+		}
+		defer syncDB.Close()
+
+		var sessions []database.SyncSession
+		if dbLabel != "" {
+			sessions, err = syncDB.GetSessionsByLabel(dbLabel)
+		} else {
+			sessions, err = syncDB.GetSessions()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "セッション一覧の取得に失敗: %v\n", err)
+			os.Exit(1)
+		}
+
+		if dbJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if sessions == nil {
+				sessions = []database.SyncSession{}
+			}
+			if err := encoder.Encode(sessions); err != nil {
+				fmt.Fprintf(os.Stderr, "JSONの出力に失敗: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		printSessions(sessions, dbLabel)
+	},
+}
+
+func init() {
+	dbCmd.AddCommand(sessionsCmd)
+
+	sessionsCmd.Flags().StringVar(&dbLabel, "label", "", "指定したラベルのセッションのみ表示")
+	sessionsCmd.Flags().BoolVar(&dbJSON, "json", false, "セッション一覧をJSON形式で出力")
+}
+
+// sumSessions は複数のセッションの件数とバイト数を合計する
+func sumSessions(sessions []database.SyncSession) database.SyncSession {
+	var total database.SyncSession
+	for _, session := range sessions {
+		total.FilesCopied += session.FilesCopied
+		total.FilesSkipped += session.FilesSkipped
+		total.FilesFailed += session.FilesFailed
+		total.BytesCopied += session.BytesCopied
+	}
+	return total
+}
+
+// printSessions はセッションの一覧を表示する（ラベルを指定した場合は合計も表示する）
+func printSessions(sessions []database.SyncSession, label string) {
+	fmt.Printf("データベース: %s\n", dbPath)
+	fmt.Println(strings.Repeat("=", 50))
+
+	if len(sessions) == 0 {
+		if label != "" {
+			fmt.Printf("ラベル '%s' のセッションはありません。\n", label)
+		} else {
+			fmt.Println("セッションはありません。")
+		}
+		return
+	}
+
+	fmt.Printf("%-19s  %-19s  %-11s  %-9s  %8s  %8s  %8s  %10s  %s\n",
+		"ID", "開始日時", "種類", "状態", "コピー", "スキップ", "失敗", "バイト数", "ラベル")
+	for _, session := range sessions {
+		fmt.Printf("%-19d  %-19s  %-11s  %-9s  %8d  %8d  %8d  %10s  %s\n",
+			session.ID,
+			session.StartTime.Format("2006-01-02 15:04:05"),
+			session.Mode,
+			session.Status,
+			session.FilesCopied,
+			session.FilesSkipped,
+			session.FilesFailed,
+			formatBytes(session.BytesCopied),
+			session.Label)
+		if len(session.Tags) > 0 {
+			fmt.Printf("%-19s  タグ: %s\n", "", formatTags(session.Tags))
+		}
+		if session.Paths != nil && session.Paths.Source != "" {
+			fmt.Printf("%-19s  パス: %s -> %s\n", "", session.Paths.Source, session.Paths.Destination)
+		}
+	}
+
+	if label != "" {
+		total := sumSessions(sessions)
+		fmt.Println(strings.Repeat("-", 50))
+		fmt.Printf("ラベル '%s' の合計 (%dセッション): コピー %d件, スキップ %d件, 失敗 %d件, %s\n",
+			label, len(sessions), total.FilesCopied, total.FilesSkipped, total.FilesFailed, formatBytes(total.BytesCopied))
+	}
+}
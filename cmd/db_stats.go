@@ -0,0 +1,277 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/errcode"
+)
+
+// sizeBucketLimits はサイズ分布の各区分の上限（この値未満）。最後の区分は上限なし
+var sizeBucketLimits = []int64{
+	1 << 10,   // 1KB
+	64 << 10,  // 64KB
+	1 << 20,   // 1MB
+	16 << 20,  // 16MB
+	128 << 20, // 128MB
+	1 << 30,   // 1GB
+}
+
+// sizeBucket はサイズ分布の1区分の集計
+type sizeBucket struct {
+	Label string `json:"label"`
+	Min   int64  `json:"min_bytes"`
+	Max   int64  `json:"max_bytes,omitempty"` // 0は上限なし
+	Count int    `json:"count"`
+	Bytes int64  `json:"bytes"`
+}
+
+// statsFileEntry はランキングに表示するファイル
+type statsFileEntry struct {
+	Path      string              `json:"path"`
+	Size      int64               `json:"size"`
+	Status    database.FileStatus `json:"status"`
+	FailCount int                 `json:"fail_count"`
+	LastError string              `json:"last_error,omitempty"`
+	Code      string              `json:"code,omitempty"` // LastErrorのエラーコード・理由
+	Error     *errcode.Detail     `json:"error,omitempty"`
+}
+
+// targetStats は宛先ごとの同期状態の集計
+type targetStats struct {
+	Success int  `json:"success"`
+	Skipped int  `json:"skipped"`
+	Failed  int  `json:"failed"`
+	Done    bool `json:"complete"`
+}
+
+// dbStatsReport はdb statsの集計結果
+type dbStatsReport struct {
+	Database      string                 `json:"database"`
+	TotalFiles    int                    `json:"total_files"`
+	TotalBytes    int64                  `json:"total_bytes"`
+	StatusCounts  map[string]int         `json:"status_counts"`
+	SessionStats  map[string]int         `json:"session_stats"`
+	FailCounts    map[int]int            `json:"fail_counts"`
+	SizeHistogram []sizeBucket           `json:"size_histogram"`
+	Largest       []statsFileEntry       `json:"largest_files"`
+	MostFailed    []statsFileEntry       `json:"most_failed_files"`
+	Targets       map[string]targetStats `json:"targets,omitempty"`
+}
+
+// statsCollector はファイルを1件ずつ受け取って統計を集計する。
+// ランキングは上位top件のみ保持するため、全件をメモリに載せる必要はない
+type statsCollector struct {
+	top    int
+	report dbStatsReport
+}
+
+func newStatsCollector(top int) *statsCollector {
+	c := &statsCollector{top: top}
+	c.report.StatusCounts = make(map[string]int)
+	c.report.FailCounts = make(map[int]int)
+	c.report.Targets = make(map[string]targetStats)
+
+	var min int64
+	for _, limit := range sizeBucketLimits {
+		c.report.SizeHistogram = append(c.report.SizeHistogram, sizeBucket{
+			Label: fmt.Sprintf("%s - %s", formatBytes(min), formatBytes(limit)),
+			Min:   min,
+			Max:   limit,
+		})
+		min = limit
+	}
+	c.report.SizeHistogram = append(c.report.SizeHistogram, sizeBucket{
+		Label: fmt.Sprintf("%s -", formatBytes(min)),
+		Min:   min,
+	})
+	return c
+}
+
+// Add はファイル1件を集計に加える
+func (c *statsCollector) Add(file database.FileInfo) {
+	r := &c.report
+	r.TotalFiles++
+	r.TotalBytes += file.Size
+	r.StatusCounts[string(file.Status)]++
+	if file.FailCount > 0 {
+		r.FailCounts[file.FailCount]++
+	}
+
+	i := sort.Search(len(sizeBucketLimits), func(i int) bool { return file.Size < sizeBucketLimits[i] })
+	r.SizeHistogram[i].Count++
+	r.SizeHistogram[i].Bytes += file.Size
+
+	for target, status := range file.Targets {
+		ts := r.Targets[target]
+		switch status.Status {
+		case database.StatusSuccess:
+			ts.Success++
+		case database.StatusSkipped:
+			ts.Skipped++
+		case database.StatusFailed:
+			ts.Failed++
+		}
+		r.Targets[target] = ts
+	}
+
+	if c.top <= 0 {
+		return
+	}
+	entry := statsFileEntry{
+		Path:      file.Path,
+		Size:      file.Size,
+		Status:    file.Status,
+		FailCount: file.FailCount,
+		LastError: file.LastError,
+		Code:      file.Code(),
+		Error:     file.Error,
+	}
+	r.Largest = insertRanked(r.Largest, entry, c.top, func(a, b statsFileEntry) bool {
+		return a.Size > b.Size
+	})
+	if file.FailCount > 0 {
+		r.MostFailed = insertRanked(r.MostFailed, entry, c.top, func(a, b statsFileEntry) bool {
+			if a.FailCount != b.FailCount {
+				return a.FailCount > b.FailCount
+			}
+			return a.Size > b.Size
+		})
+	}
+}
+
+// Report は集計結果を返す
+func (c *statsCollector) Report() *dbStatsReport {
+	for target, ts := range c.report.Targets {
+		ts.Done = ts.Failed == 0
+		c.report.Targets[target] = ts
+	}
+	if c.report.Largest == nil {
+		c.report.Largest = []statsFileEntry{}
+	}
+	if c.report.MostFailed == nil {
+		c.report.MostFailed = []statsFileEntry{}
+	}
+	return &c.report
+}
+
+// insertRanked はless順に並んだentriesへentryを挿入し、先頭max件に切り詰める。
+// 同順位の場合は先に追加されたものを優先する
+func insertRanked(entries []statsFileEntry, entry statsFileEntry, max int, less func(a, b statsFileEntry) bool) []statsFileEntry {
+	i := sort.Search(len(entries), func(i int) bool { return less(entry, entries[i]) })
+	if i >= max {
+		return entries
+	}
+	if len(entries) < max {
+		entries = append(entries, statsFileEntry{})
+	}
+	copy(entries[i+1:], entries[i:])
+	entries[i] = entry
+	return entries
+}
+
+// writeStatsJSON は集計結果をJSONで出力する
+func writeStatsJSON(w io.Writer, report *dbStatsReport) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// printStats は集計結果を表形式で出力する
+func printStats(w io.Writer, report *dbStatsReport) {
+	fmt.Fprintf(w, "データベース: %s\n", report.Database)
+	fmt.Fprintln(w, strings.Repeat("=", 50))
+
+	// 基本統計
+	fmt.Fprintf(w, "総ファイル数: %d\n", report.TotalFiles)
+	fmt.Fprintf(w, "総サイズ: %s\n", formatBytes(report.TotalBytes))
+
+	// ステータス別統計
+	fmt.Fprintln(w, "\nステータス別統計:")
+	for _, status := range sortedKeys(report.StatusCounts) {
+		fmt.Fprintf(w, "  %s: %d件\n", status, report.StatusCounts[status])
+	}
+
+	// 同期セッション統計
+	fmt.Fprintln(w, "\n同期セッション統計:")
+	for _, key := range sortedKeys(report.SessionStats) {
+		fmt.Fprintf(w, "  %s: %d\n", key, report.SessionStats[key])
+	}
+
+	// 失敗回数統計
+	fmt.Fprintln(w, "\n失敗回数別統計:")
+	failCounts := make([]int, 0, len(report.FailCounts))
+	for failCount := range report.FailCounts {
+		failCounts = append(failCounts, failCount)
+	}
+	sort.Ints(failCounts)
+	for _, failCount := range failCounts {
+		fmt.Fprintf(w, "  失敗%d回: %d件\n", failCount, report.FailCounts[failCount])
+	}
+
+	// サイズ分布
+	fmt.Fprintln(w, "\nサイズ分布:")
+	for _, bucket := range report.SizeHistogram {
+		ratio := 0.0
+		if report.TotalFiles > 0 {
+			ratio = float64(bucket.Count) / float64(report.TotalFiles) * 100
+		}
+		fmt.Fprintf(w, "  %-22s %8d件 (%5.1f%%) %12s\n", bucket.Label, bucket.Count, ratio, formatBytes(bucket.Bytes))
+	}
+
+	// サイズの大きいファイル
+	if len(report.Largest) > 0 {
+		fmt.Fprintf(w, "\nサイズの大きいファイル（上位%d件）:\n", len(report.Largest))
+		for i, entry := range report.Largest {
+			fmt.Fprintf(w, "  %2d. %12s  %s\n", i+1, formatBytes(entry.Size), entry.Path)
+		}
+	}
+
+	// 失敗回数の多いファイル
+	if len(report.MostFailed) > 0 {
+		fmt.Fprintf(w, "\n失敗回数の多いファイル（上位%d件）:\n", len(report.MostFailed))
+		for i, entry := range report.MostFailed {
+			fmt.Fprintf(w, "  %2d. 失敗%d回  %s\n", i+1, entry.FailCount, entry.Path)
+			if entry.LastError != "" {
+				message := truncateString(entry.LastError, 100)
+				if entry.Code != "" {
+					message = fmt.Sprintf("[%s] %s", entry.Code, message)
+				}
+				fmt.Fprintf(w, "      最後のエラー: %s\n", message)
+			}
+		}
+	}
+
+	// 宛先別統計（複数の宛先にコピーした場合のみ）
+	if len(report.Targets) > 0 {
+		fmt.Fprintln(w, "\n宛先別統計:")
+		targets := make([]string, 0, len(report.Targets))
+		for target := range report.Targets {
+			targets = append(targets, target)
+		}
+		sort.Strings(targets)
+		for _, target := range targets {
+			ts := report.Targets[target]
+			state := "完了"
+			if !ts.Done {
+				state = "未完了"
+			}
+			fmt.Fprintf(w, "  %s [%s]: 成功 %d件, スキップ %d件, 失敗 %d件\n", target, state,
+				ts.Success, ts.Skipped, ts.Failed)
+		}
+	}
+}
+
+// sortedKeys はマップのキーを昇順で返す
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
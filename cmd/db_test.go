@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
@@ -704,3 +706,173 @@ func BenchmarkDBExportCmd(b *testing.B) {
 		// コマンドの構築をベンチマーク
 	}
 }
+
+func TestVerifiedSessionsAndMismatchRate(t *testing.T) {
+	var sessions []database.SyncSession
+	for i := 0; i < 5; i++ {
+		session := database.SyncSession{ID: int64(i)}
+		if i != 2 {
+			session.Verification = &database.VerificationSummary{Matched: 9, Mismatched: i}
+		}
+		sessions = append(sessions, session)
+	}
+
+	verified := verifiedSessions(sessions, 0)
+	if len(verified) != 4 {
+		t.Fatalf("検証結果のあるセッション数 = %d, want 4", len(verified))
+	}
+
+	last := verifiedSessions(sessions, 2)
+	if len(last) != 2 || last[0].ID != 3 || last[1].ID != 4 {
+		t.Errorf("直近のセッション = %+v", last)
+	}
+
+	// (3+4) / (9+3+9+4)
+	if rate := mismatchRate(last); rate != 7.0/25.0 {
+		t.Errorf("mismatchRate() = %v, want %v", rate, 7.0/25.0)
+	}
+}
+
+func TestStatsCollector(t *testing.T) {
+	files := []database.FileInfo{
+		{Path: "tiny.txt", Size: 10, Status: database.StatusSuccess},
+		{Path: "small.txt", Size: 2 << 10, Status: database.StatusSuccess},
+		{Path: "medium.bin", Size: 3 << 20, Status: database.StatusFailed, FailCount: 2, LastError: "permission denied"},
+		{Path: "large.iso", Size: 2 << 30, Status: database.StatusFailed, FailCount: 1},
+		{Path: "boundary.bin", Size: 1 << 10, Status: database.StatusSkipped, FailCount: 5},
+	}
+
+	collector := newStatsCollector(2)
+	for _, file := range files {
+		collector.Add(file)
+	}
+	report := collector.Report()
+
+	if report.TotalFiles != 5 {
+		t.Errorf("TotalFiles = %d, want 5", report.TotalFiles)
+	}
+	if report.StatusCounts["failed"] != 2 || report.StatusCounts["success"] != 2 {
+		t.Errorf("StatusCounts = %v", report.StatusCounts)
+	}
+	if report.FailCounts[1] != 1 || report.FailCounts[2] != 1 || report.FailCounts[5] != 1 || len(report.FailCounts) != 3 {
+		t.Errorf("FailCounts = %v", report.FailCounts)
+	}
+
+	// 1KBちょうどは「1KB - 64KB」の区分に入る
+	wantCounts := []int{1, 2, 0, 1, 0, 0, 1}
+	if len(report.SizeHistogram) != len(wantCounts) {
+		t.Fatalf("区分数 = %d, want %d", len(report.SizeHistogram), len(wantCounts))
+	}
+	var histogramBytes int64
+	for i, bucket := range report.SizeHistogram {
+		if bucket.Count != wantCounts[i] {
+			t.Errorf("区分 %s の件数 = %d, want %d", bucket.Label, bucket.Count, wantCounts[i])
+		}
+		histogramBytes += bucket.Bytes
+	}
+	if histogramBytes != report.TotalBytes {
+		t.Errorf("区分ごとのサイズの合計 = %d, want %d", histogramBytes, report.TotalBytes)
+	}
+
+	if len(report.Largest) != 2 || report.Largest[0].Path != "large.iso" || report.Largest[1].Path != "medium.bin" {
+		t.Errorf("Largest = %+v", report.Largest)
+	}
+	if len(report.MostFailed) != 2 || report.MostFailed[0].Path != "boundary.bin" || report.MostFailed[1].Path != "medium.bin" {
+		t.Errorf("MostFailed = %+v", report.MostFailed)
+	}
+
+	var buf bytes.Buffer
+	if err := writeStatsJSON(&buf, report); err != nil {
+		t.Fatalf("writeStatsJSON() error = %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("JSONとして読み込めません: %v", err)
+	}
+	for _, key := range []string{"size_histogram", "largest_files", "most_failed_files"} {
+		if _, ok := decoded[key]; !ok {
+			t.Errorf("JSONに%sが含まれていません", key)
+		}
+	}
+}
+
+func TestStatsCollector_NoRanking(t *testing.T) {
+	collector := newStatsCollector(0)
+	collector.Add(database.FileInfo{Path: "a", Size: 100, FailCount: 3})
+	report := collector.Report()
+	if len(report.Largest) != 0 || len(report.MostFailed) != 0 {
+		t.Errorf("--top 0でランキングが作成されました: %+v %+v", report.Largest, report.MostFailed)
+	}
+}
+
+func TestBuildCleanQuery(t *testing.T) {
+	now := time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+
+	query, err := buildCleanQuery("*.tmp, logs/*.log", "failed,skipped", 7, now)
+	if err != nil {
+		t.Fatalf("buildCleanQuery() error = %v", err)
+	}
+	if len(query.Patterns) != 2 || query.Patterns[1] != "logs/*.log" {
+		t.Errorf("Patterns = %v", query.Patterns)
+	}
+	if len(query.Statuses) != 2 || query.Statuses[0] != database.StatusFailed {
+		t.Errorf("Statuses = %v", query.Statuses)
+	}
+	if !query.OlderThan.Equal(now.AddDate(0, 0, -7)) {
+		t.Errorf("OlderThan = %v", query.OlderThan)
+	}
+
+	query, err = buildCleanQuery("", "", 0, now)
+	if err != nil {
+		t.Fatalf("buildCleanQuery() error = %v", err)
+	}
+	if !query.OlderThan.IsZero() || query.Patterns != nil || query.Statuses != nil {
+		t.Errorf("条件なしのはずが %+v", query)
+	}
+
+	if _, err := buildCleanQuery("", "", -1, now); err == nil {
+		t.Error("負の日数でエラーになりません")
+	}
+	// 同期状態の誤りは0件の削除ではなくエラーにする
+	if _, err := buildCleanQuery("", "failed,faild", 0, now); err == nil {
+		t.Error("無効な同期状態でエラーになりません")
+	}
+	if _, err := buildCleanQuery("[a-", "", 0, now); err == nil {
+		t.Error("無効なパターンでエラーになりません")
+	}
+}
+
+func TestSumSessions(t *testing.T) {
+	sessions := []database.SyncSession{
+		{FilesCopied: 3, FilesSkipped: 1, FilesFailed: 0, BytesCopied: 300},
+		{FilesCopied: 2, FilesSkipped: 4, FilesFailed: 1, BytesCopied: 200},
+	}
+
+	total := sumSessions(sessions)
+	if total.FilesCopied != 5 || total.FilesSkipped != 5 || total.FilesFailed != 1 || total.BytesCopied != 500 {
+		t.Errorf("sumSessions() = %+v", total)
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	tags, err := parseTags([]string{"owner=finance", " wave = 3 ", "note="})
+	if err != nil {
+		t.Fatalf("parseTags() error = %v", err)
+	}
+	if tags["owner"] != "finance" || tags["wave"] != "3" || tags["note"] != "" || len(tags) != 3 {
+		t.Errorf("parseTags() = %v", tags)
+	}
+	if got := formatTags(tags); got != "note=,owner=finance,wave=3" {
+		t.Errorf("formatTags() = %q", got)
+	}
+
+	for _, value := range []string{"owner", "=finance"} {
+		if _, err := parseTags([]string{value}); err == nil {
+			t.Errorf("parseTags(%q) はエラーになるべき", value)
+		}
+	}
+
+	if tags, err := parseTags(nil); err != nil || tags != nil {
+		t.Errorf("parseTags(nil) = %v, %v", tags, err)
+	}
+}
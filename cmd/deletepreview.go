@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sakuhanight/gopier/internal/logger"
+	"github.com/sakuhanight/gopier/internal/units"
+	"github.com/sakuhanight/gopier/internal/verifier"
+)
+
+// newDeleteConfirmation は--extras-action deleteで削除する前に、削除するファイルの一覧と合計サイズを表示して確認する関数を返す
+// 件数・サイズが上限（--delete-max-files/--delete-max-size）を超える場合は、--confirm-deleteまたは端末での入力がなければ削除しない
+func newDeleteConfirmation(log *logger.Logger) (func(preview *verifier.DeletePreview) bool, error) {
+	maxBytes, err := units.ParseSize(deleteMaxSize, units.Byte)
+	if err != nil {
+		return nil, fmt.Errorf("削除するサイズの上限の指定が不正です: %w", err)
+	}
+
+	return func(preview *verifier.DeletePreview) bool {
+		printDeletePreview(preview)
+
+		exceeded := deleteLimitExceeded(preview, deleteMaxFiles, maxBytes)
+		if exceeded == "" || confirmDelete {
+			return true
+		}
+
+		if isTerminal(os.Stdin) {
+			fmt.Printf("%sを超えています。削除しますか？ (y/N): ", exceeded)
+			var response string
+			fmt.Scanln(&response)
+			if strings.ToLower(response) == "y" || strings.ToLower(response) == "yes" {
+				return true
+			}
+			fmt.Println("削除をキャンセルしました。")
+		}
+		log.Warn("%sを超えているため、余分なファイルを削除せずに報告のみ行います（削除する場合は--confirm-deleteを指定してください）", exceeded)
+		return false
+	}, nil
+}
+
+// deleteLimitExceeded は削除するファイル数・サイズが上限を超えている場合に、超えた上限の説明を返す（0の上限は無制限）
+func deleteLimitExceeded(preview *verifier.DeletePreview, maxFiles int, maxBytes int64) string {
+	var exceeded []string
+	if maxFiles > 0 && len(preview.Files) > maxFiles {
+		exceeded = append(exceeded, fmt.Sprintf("削除するファイル数の上限（%d件）", maxFiles))
+	}
+	if maxBytes > 0 && preview.Bytes > maxBytes {
+		exceeded = append(exceeded, fmt.Sprintf("削除する合計サイズの上限（%s）", formatBytes(maxBytes)))
+	}
+	return strings.Join(exceeded, "と")
+}
+
+// printDeletePreview は削除するファイルの一覧と、削除で空く容量を表示する
+func printDeletePreview(preview *verifier.DeletePreview) {
+	fmt.Printf("\n削除するファイル:\n")
+	for _, entry := range preview.Files {
+		fmt.Printf("  %s (%s)\n", entry.Path, formatBytes(entry.Size))
+	}
+	fmt.Printf("合計: %d件のファイル・%d個のディレクトリ, 空く容量: %s\n", len(preview.Files), preview.Dirs, formatBytes(preview.Bytes))
+}
+
+// isTerminal はファイルが端末かどうかを返す
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/sakuhanight/gopier/internal/verifier"
+)
+
+func TestDeleteLimitExceeded(t *testing.T) {
+	preview := &verifier.DeletePreview{
+		Files: []verifier.DeleteEntry{{Path: "a", Size: 600}, {Path: "b", Size: 600}},
+		Bytes: 1200,
+	}
+	tests := []struct {
+		name     string
+		maxFiles int
+		maxBytes int64
+		want     string
+	}{
+		{"上限以内", 2, 1200, ""},
+		{"無制限", 0, 0, ""},
+		{"ファイル数", 1, 0, "削除するファイル数の上限（1件）"},
+		{"サイズ", 0, 1024, "削除する合計サイズの上限（1.0 KB）"},
+		{"両方", 1, 1024, "削除するファイル数の上限（1件）と削除する合計サイズの上限（1.0 KB）"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := deleteLimitExceeded(preview, tt.maxFiles, tt.maxBytes); got != tt.want {
+				t.Errorf("deleteLimitExceeded() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
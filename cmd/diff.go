@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sakuhanight/gopier/internal/filter"
+	"github.com/sakuhanight/gopier/internal/treediff"
+	"github.com/sakuhanight/gopier/internal/units"
+)
+
+var (
+	diffFormat       string
+	diffOutput       string
+	diffInclude      string
+	diffExclude      string
+	diffHashAlgo     string
+	diffWorkers      int
+	diffIgnoreMeta   bool
+	diffModifyWindow time.Duration
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff A B",
+	Short: "任意の2つのディレクトリツリーを比較",
+	Long: `コピーの関係にない2つのディレクトリツリーを比較し、差分を報告します。
+ソース・宛先の区別や同期DBを必要とせず、どちらのツリーも変更しません。
+バックアップ同士や、別のツールで複製したツリーとの突き合わせに使用します。
+
+差分の種類:
+  only_in_a - Aにのみ存在する（ディレクトリ全体の場合は1件として報告）
+  only_in_b - Bにのみ存在する（ディレクトリ全体の場合は1件として報告）
+  content   - 内容が異なる（サイズまたはハッシュ値）
+  metadata  - 内容は同じで、更新日時またはパーミッションが異なる
+  error     - ファイルを読み込めない
+
+出力形式:
+  text    - 1行に1つのパスと集計（デフォルト、行頭の記号は - Aのみ, + Bのみ, M 内容, T メタデータ, ! エラー）
+  summary - 集計のみ
+  csv     - 1行に1つのパス
+  json    - 差分と種類ごとの件数
+
+差分がある場合は終了コード1で終了します。`,
+	Example: `  gopier diff /backup/2024-06 /backup/2024-07
+  gopier diff //nas1/share //nas2/share --format summary --ignore-metadata
+  gopier diff ./a ./b --format csv -o diff.csv --modify-window 2s`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if diffFormat != "text" && diffFormat != "summary" && diffFormat != "csv" && diffFormat != "json" {
+			fmt.Fprintf(os.Stderr, "サポートされていない出力形式: %s\n", diffFormat)
+			os.Exit(1)
+		}
+
+		result, err := treediff.Compare(args[0], args[1], treediff.Options{
+			HashAlgorithm: diffHashAlgo,
+			Workers:       diffWorkers,
+			Filter:        filter.NewFilter(diffInclude, diffExclude),
+			IgnoreMeta:    diffIgnoreMeta,
+			ModifyWindow:  diffModifyWindow,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "比較に失敗: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := writeTreeDiff(result, diffFormat, diffOutput); err != nil {
+			fmt.Fprintf(os.Stderr, "比較結果の出力に失敗: %v\n", err)
+			os.Exit(1)
+		}
+		if diffOutput != "" {
+			fmt.Printf("比較: %d件, 差分: %d件 (%s)\n", result.Compared, len(result.Entries), diffOutput)
+		}
+
+		if result.HasDifferences() {
+			os.Exit(1)
+		}
+	},
+}
+
+// writeTreeDiff は比較結果を指定された形式で書き出す（出力先が空の場合は標準出力）
+func writeTreeDiff(result *treediff.Result, format, outputPath string) error {
+	write := treediff.WriteText
+	switch format {
+	case "summary":
+		write = treediff.WriteSummary
+	case "csv":
+		write = treediff.WriteCSV
+	case "json":
+		write = treediff.WriteJSON
+	}
+
+	if outputPath == "" {
+		return write(os.Stdout, result)
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("ファイル作成エラー: %w", err)
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	if err := write(w, result); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringVar(&diffFormat, "format", "text", "出力形式 (text, summary, csv, json)")
+	diffCmd.Flags().StringVarP(&diffOutput, "output", "o", "", "出力ファイルのパス（省略時は標準出力）")
+	diffCmd.Flags().StringVarP(&diffInclude, "include", "i", "", "含めるファイルパターン（例: *.txt,*.docx）")
+	diffCmd.Flags().StringVarP(&diffExclude, "exclude", "e", "", "除外するファイルパターン（例: *.tmp,*.bak）")
+	diffCmd.Flags().StringVar(&diffHashAlgo, "hash-algorithm", "sha256", "ハッシュアルゴリズム (md5, sha1, sha256, sha512)")
+	diffCmd.Flags().IntVarP(&diffWorkers, "workers", "w", 4, "並行して比較するファイル数")
+	diffCmd.Flags().BoolVar(&diffIgnoreMeta, "ignore-metadata", false, "更新日時とパーミッションを比較しない")
+	diffCmd.Flags().Var(units.NewDurationValue(&diffModifyWindow, 0, 0), "modify-window", "更新日時の差をこの範囲まで同じとみなす（例: 2s、FATやSMB向け）")
+}
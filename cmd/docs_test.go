@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteCompletion(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		t.Run(shell, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeCompletion(&buf, shell); err != nil {
+				t.Fatalf("writeCompletion(%s) error = %v", shell, err)
+			}
+			if !strings.Contains(buf.String(), "gopier") {
+				t.Errorf("補完スクリプトにコマンド名が含まれていません")
+			}
+		})
+	}
+
+	if err := writeCompletion(&bytes.Buffer{}, "tcsh"); err == nil {
+		t.Error("未対応のシェルはエラーになるべき")
+	}
+}
+
+func TestGenerateManPages(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "man1")
+	if err := generateManPages(dir); err != nil {
+		t.Fatalf("generateManPages() error = %v", err)
+	}
+
+	// ルートとサブコマンドのページが生成される
+	for _, name := range []string{"gopier.1", "gopier-db-sessions.1", "gopier-completion.1", "gopier-docs-man.1"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("%sが生成されていません: %v", name, err)
+			continue
+		}
+		if !strings.Contains(string(data), ".TH \"GOPIER\"") {
+			t.Errorf("%sのヘッダーが不正です", name)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sakuhanight/gopier/internal/elevate"
+)
+
+// requestElevation は管理者権限がない場合の処理を行い、終了コードを返す
+// relaunchが指定されていれば管理者として起動し直し（Unix系OSではsudoコマンドを表示）、
+// そうでなければ--elevateの使用を案内する
+func requestElevation(relaunch bool) int {
+	exe, err := os.Executable()
+	if err != nil {
+		exe = os.Args[0]
+	}
+	args := elevate.StripFlag(os.Args[1:], "elevate")
+	return handleElevation(os.Stdout, os.Stderr, relaunch, exe, args, elevate.Relaunch)
+}
+
+// handleElevation はrequestElevationの本体（テストのため出力先と再起動処理を引数で受け取る）
+func handleElevation(stdout, stderr io.Writer, relaunch bool, exe string, args []string,
+	relaunchFunc func(exe string, args []string) error) int {
+	if !relaunch {
+		fmt.Fprintln(stderr, "--preserve-permissionsで所有者やアクセス権を設定するには管理者権限が必要です。")
+		fmt.Fprintln(stderr, "管理者として実行するか、--elevateを指定してください。")
+		return 1
+	}
+
+	err := relaunchFunc(exe, args)
+	switch {
+	case err == nil:
+		fmt.Fprintln(stdout, "管理者権限でgopierを起動しました。処理は新しいウィンドウで続行されます。")
+		return 0
+	case errors.Is(err, elevate.ErrRelaunchUnsupported):
+		fmt.Fprintln(stderr, "--preserve-permissionsで所有者やアクセス権を設定するには管理者権限が必要です。")
+		fmt.Fprintln(stderr, "次のコマンドで実行してください:")
+		fmt.Fprintf(stderr, "  %s\n", elevate.SudoCommand(exe, args))
+		return 1
+	default:
+		fmt.Fprintf(stderr, "管理者権限での起動に失敗: %v\n", err)
+		return 1
+	}
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sakuhanight/gopier/internal/elevate"
+)
+
+func TestHandleElevation(t *testing.T) {
+	args := []string{"-s", "src dir", "-d", "dst", "--preserve-permissions"}
+
+	tests := []struct {
+		name       string
+		relaunch   bool
+		err        error
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{"--elevateなし", false, nil, 1, "", "--elevateを指定してください"},
+		{"再起動成功", true, nil, 0, "管理者権限でgopierを起動しました", ""},
+		{"sudoを案内", true, elevate.ErrRelaunchUnsupported, 1, "", "sudo /usr/bin/gopier -s 'src dir' -d dst --preserve-permissions"},
+		{"再起動失敗", true, errors.New("canceled"), 1, "", "管理者権限での起動に失敗: canceled"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			called := false
+			code := handleElevation(&stdout, &stderr, tt.relaunch, "/usr/bin/gopier", args, func(exe string, got []string) error {
+				called = true
+				if exe != "/usr/bin/gopier" || len(got) != len(args) {
+					t.Errorf("再起動の引数 = %s %v", exe, got)
+				}
+				return tt.err
+			})
+
+			if code != tt.wantCode {
+				t.Errorf("終了コード = %d, want %d", code, tt.wantCode)
+			}
+			if called != tt.relaunch {
+				t.Errorf("再起動の呼び出し = %v, want %v", called, tt.relaunch)
+			}
+			if tt.wantStdout != "" && !strings.Contains(stdout.String(), tt.wantStdout) {
+				t.Errorf("stdout = %q", stdout.String())
+			}
+			if tt.wantStderr != "" && !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("stderr = %q", stderr.String())
+			}
+		})
+	}
+}
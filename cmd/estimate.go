@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/sakuhanight/gopier/internal/copier"
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/filter"
+	"github.com/sakuhanight/gopier/internal/transform"
+	"github.com/sakuhanight/gopier/internal/units"
+)
+
+var (
+	estimateSource        string
+	estimateDest          string
+	estimateInclude       string
+	estimateExclude       string
+	estimateDBPath        string
+	estimateBWLimit       string
+	estimateTransform     string
+	estimateSkipNewer     bool
+	estimateIncludeHidden bool
+	estimateIncludeSystem bool
+	estimateNoDest        bool
+	estimateProbeSize     string
+	estimateProbeWrite    bool
+	estimateFormat        string
+)
+
+// estimateCmd represents the estimate command
+var estimateCmd = &cobra.Command{
+	Use:   "estimate",
+	Short: "コピーせずに処理量と所要時間を見積もる",
+	Long: `ソース（と宛先）を走査し、現在のオプションでコピーを実行した場合の
+ファイル数・バイト数、コピーされるファイルとスキップされるファイルの件数を報告します。
+コピー対象のファイルの一部を読み込んでスループットを計測し、コピーにかかる時間を見積もります。
+--probe-writeを指定した場合は、宛先に一時ファイル（.gopier-probe-*）を作成して書き込みも計測します。
+宛先と同期DBには書き込みません（同期DBは読み取り専用で開きます）。
+
+フラグで指定しなかった項目は設定ファイルの値（source, destination, include_pattern,
+exclude_pattern, skip_newer, include_hidden, include_system, bwlimit, transform, sync_db_path）を使用します。
+--format jsonで計画ツール向けにJSONで出力します。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		source := firstNonEmpty(estimateSource, viper.GetString("source"))
+		dest := firstNonEmpty(estimateDest, viper.GetString("destination"))
+		if source == "" || dest == "" {
+			fmt.Fprintf(os.Stderr, "--sourceと--destinationを指定してください。\n")
+			os.Exit(1)
+		}
+		if estimateFormat != "text" && estimateFormat != "json" {
+			fmt.Fprintf(os.Stderr, "サポートされていない出力形式: %s\n", estimateFormat)
+			os.Exit(1)
+		}
+		probeSize, err := units.ParseSize(estimateProbeSize, units.Byte)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "計測サイズの指定が不正です: %v\n", err)
+			os.Exit(1)
+		}
+
+		options := copier.DefaultOptions()
+		options.SkipNewer = configBool(cmd, "skip-newer", "skip_newer", estimateSkipNewer)
+		options.IncludeHidden = configBool(cmd, "include-hidden", "include_hidden", estimateIncludeHidden)
+		options.IncludeSystem = configBool(cmd, "include-system", "include_system", estimateIncludeSystem)
+		if options.BandwidthLimit, err = copier.ParseBandwidth(firstNonEmpty(estimateBWLimit, viper.GetString("bwlimit"))); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		if options.Transforms, err = transform.Parse(firstNonEmpty(estimateTransform, viper.GetString("transform"))); err != nil {
+			fmt.Fprintf(os.Stderr, "変換の設定エラー: %v\n", err)
+			os.Exit(1)
+		}
+
+		// 変換するファイルのスキップの判定にはDBの記録が必要なため、既存のDBのみ読み取り専用で開く
+		var syncDB *database.SyncDB
+		dbPath := firstNonEmpty(estimateDBPath, viper.GetString("sync_db_path"))
+		if options.Transforms != nil && dbPath != "" {
+			if _, err := os.Stat(dbPath); err == nil {
+				if syncDB, err = database.OpenReadOnly(dbPath); err != nil {
+					fmt.Fprintf(os.Stderr, "データベースのオープンに失敗: %v\n", err)
+					os.Exit(1)
+				}
+				defer syncDB.Close()
+			}
+		}
+
+		fileFilter := filter.NewFilter(
+			firstNonEmpty(estimateInclude, viper.GetString("include_pattern")),
+			firstNonEmpty(estimateExclude, viper.GetString("exclude_pattern")))
+		// コピーと同じく、設定ファイルで有効にした除外プロファイルのディレクトリは走査しない
+		var customProfiles map[string]filter.Profile
+		if err := viper.UnmarshalKey("exclusion_profiles", &customProfiles); err != nil {
+			fmt.Fprintf(os.Stderr, "設定エラー: exclusion_profiles: %v\n", err)
+			os.Exit(1)
+		}
+		profile, err := filter.ResolveProfiles(viper.GetString("profile_exclusions"), customProfiles)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "設定エラー: %v\n", err)
+			os.Exit(1)
+		}
+		fileFilter.AddProfile(profile)
+		fc := copier.NewFileCopier(source, dest, options, fileFilter, syncDB, nil)
+		result, err := fc.Estimate(copier.EstimateOptions{
+			CompareDest: !estimateNoDest,
+			ProbeBytes:  probeSize,
+			ProbeWrite:  estimateProbeWrite,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "見積もりに失敗: %v\n", err)
+			os.Exit(1)
+		}
+
+		if estimateFormat == "json" {
+			err = writeEstimateJSON(os.Stdout, result)
+		} else {
+			err = writeEstimateText(os.Stdout, result)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "見積もりの出力に失敗: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// configBool はフラグが指定されていればその値を、なければ設定ファイルの値を返す
+func configBool(cmd *cobra.Command, flag, key string, value bool) bool {
+	if !cmd.Flags().Changed(flag) && viper.IsSet(key) {
+		return viper.GetBool(key)
+	}
+	return value
+}
+
+// writeEstimateJSON は見積もりをJSONで書き出す
+func writeEstimateJSON(w io.Writer, result *copier.Estimate) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+// writeEstimateText は見積もりを人が読む形式で書き出す
+func writeEstimateText(w io.Writer, result *copier.Estimate) error {
+	fmt.Fprintf(w, "ソース: %d件 (%s)\n", result.SourceFiles, formatBytes(result.SourceBytes))
+	fmt.Fprintf(w, "コピー: %d件 (%s)\n", result.CopyFiles, formatBytes(result.CopyBytes))
+	if result.DestCompared {
+		fmt.Fprintf(w, "スキップ: %d件 (%s)\n", result.SkipFiles, formatBytes(result.SkipBytes))
+	} else {
+		fmt.Fprintf(w, "スキップ: 宛先と比較していません\n")
+	}
+	fmt.Fprintf(w, "フィルタで除外: %d件 (%s)\n", result.FilteredFiles, formatBytes(result.FilteredBytes))
+	if result.ExcludedHidden > 0 || result.ExcludedSystem > 0 {
+		fmt.Fprintf(w, "除外した隠しファイル: %d件, システムファイル: %d件\n", result.ExcludedHidden, result.ExcludedSystem)
+	}
+	if result.Errors > 0 {
+		fmt.Fprintf(w, "情報を取得できなかったエントリ: %d件\n", result.Errors)
+	}
+	fmt.Fprintf(w, "走査時間: %s\n", (time.Duration(result.ScanSeconds * float64(time.Second))).Truncate(time.Millisecond))
+
+	if result.Throughput == 0 {
+		_, err := fmt.Fprintf(w, "所要時間の見積もり: 計測していません\n")
+		return err
+	}
+	target := "読み込み"
+	if result.ProbeWrite {
+		target = "読み込みと宛先への書き込み"
+	}
+	fmt.Fprintf(w, "スループット: %s/s（%d件・%sの%sで計測）\n",
+		formatBytes(int64(result.Throughput)), result.ProbeFiles, formatBytes(result.ProbeBytes), target)
+	_, err := fmt.Fprintf(w, "所要時間の見積もり: %s\n", (time.Duration(result.EstimatedSeconds * float64(time.Second))).Truncate(time.Second))
+	return err
+}
+
+func init() {
+	rootCmd.AddCommand(estimateCmd)
+
+	estimateCmd.Flags().StringVarP(&estimateSource, "source", "s", "", "コピー元ディレクトリ")
+	estimateCmd.Flags().StringVarP(&estimateDest, "destination", "d", "", "コピー先ディレクトリ")
+	estimateCmd.Flags().StringVarP(&estimateInclude, "include", "i", "", "含めるファイルパターン（例: *.txt,*.docx）")
+	estimateCmd.Flags().StringVarP(&estimateExclude, "exclude", "e", "", "除外するファイルパターン（例: *.tmp,*.bak）")
+	estimateCmd.Flags().StringVar(&estimateDBPath, "db", "", "同期状態データベースのパス（変換するファイルのスキップの判定に使用）")
+	estimateCmd.Flags().StringVar(&estimateBWLimit, "bwlimit", "", "帯域制限（見積もりに反映、例: 10M）")
+	estimateCmd.Flags().StringVar(&estimateTransform, "transform", "", "拡張子・MIMEタイプごとの変換（例: \".jpg=strip-exif\"）")
+	estimateCmd.Flags().BoolVar(&estimateSkipNewer, "skip-newer", false, "宛先の方が新しい場合はスキップ")
+	estimateCmd.Flags().BoolVar(&estimateIncludeHidden, "include-hidden", true, "隠しファイル・ディレクトリ（ドットファイルを含む）を対象にする")
+	estimateCmd.Flags().BoolVar(&estimateIncludeSystem, "include-system", true, "システム属性のファイル・ディレクトリを対象にする（Windowsのみ）")
+	estimateCmd.Flags().BoolVar(&estimateNoDest, "no-dest", false, "宛先を走査せず、すべてのファイルをコピー対象として見積もる")
+	estimateCmd.Flags().StringVar(&estimateProbeSize, "probe-size", "64M", "スループットの計測で読み込むサイズ（0で計測しない）")
+	estimateCmd.Flags().BoolVar(&estimateProbeWrite, "probe-write", false, "スループットの計測で宛先に一時ファイルを作成して書き込みも計測する")
+	estimateCmd.Flags().StringVar(&estimateFormat, "format", "text", "出力形式 (text, json)")
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"github.com/sakuhanight/gopier/internal/copier"
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/runsummary"
+)
+
+// runExitCode はコマンドが終わった後にExecuteで使用する終了コード
+// 一部のファイルの失敗のように、処理を続けてから知らせる場合に設定する
+// 途中で中断する場合も、DB・監査ログ・ネットワーク共有の接続などを閉じる遅延処理を実行するよう、
+// os.Exitを呼ばずにこの値を設定して戻る
+var runExitCode = errcode.ExitOK
+
+// failuresExitCode はコピーに失敗したファイルに対応する終了コードを返す
+// すべての失敗が同じ種類であればその種類の終了コードを、そうでなければExitFilesFailedを返す
+func failuresExitCode(failures []copier.CopyFailure) int {
+	if len(failures) == 0 {
+		return errcode.ExitOK
+	}
+
+	code := errcode.ExitCode(failures[0].Err)
+	for _, failure := range failures[1:] {
+		if errcode.ExitCode(failure.Err) != code {
+			return errcode.ExitFilesFailed
+		}
+	}
+	if code == errcode.ExitError {
+		return errcode.ExitFilesFailed
+	}
+	return code
+}
+
+// copyExitCode はコピーの終了コードを返す
+// 内容はコピーしたがアクセス権をコピーできなかったファイル（permFailures）は、--permission-errors failの場合のみ失敗として扱う
+func copyExitCode(failures, permFailures []copier.CopyFailure, permissionErrors string) int {
+	if permissionErrors == "fail" {
+		failures = append(append([]copier.CopyFailure(nil), failures...), permFailures...)
+	}
+	return failuresExitCode(failures)
+}
+
+// gradedCopyExitCode はコピーと同時に検証した場合の終了コードに、検証結果の判定を反映する
+// 合格の場合は検証の失敗を終了コードに含めず、不合格の場合はほかに失敗がなくても検証の失敗とする
+func gradedCopyExitCode(grade *runsummary.Grade, failures, permFailures []copier.CopyFailure, permissionErrors string) int {
+	if grade == nil {
+		return copyExitCode(failures, permFailures, permissionErrors)
+	}
+	if grade.Passed() {
+		var copyFailures []copier.CopyFailure
+		for _, failure := range failures {
+			if errcode.ExitCode(failure.Err) != errcode.ExitVerifyFailed {
+				copyFailures = append(copyFailures, failure)
+			}
+		}
+		return copyExitCode(copyFailures, permFailures, permissionErrors)
+	}
+	if code := copyExitCode(failures, permFailures, permissionErrors); code != errcode.ExitOK {
+		return code
+	}
+	return errcode.ExitVerifyFailed
+}
+
+// validPermissionErrors は--permission-errorsの値が正しいかどうかを返す
+func validPermissionErrors(mode string) bool {
+	return mode == "fail" || mode == "warn"
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sakuhanight/gopier/internal/copier"
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/runsummary"
+)
+
+func TestFailuresExitCode(t *testing.T) {
+	conflict := errcode.Errorf(errcode.ErrConflict, "宛先の方が新しいため上書きしません")
+	permission := errcode.Wrap(errcode.ErrPermissionCopy, errors.New("拒否"))
+
+	tests := []struct {
+		name     string
+		failures []copier.CopyFailure
+		want     int
+	}{
+		{"失敗なし", nil, errcode.ExitOK},
+		{"すべて衝突", []copier.CopyFailure{{Path: "a", Err: conflict}, {Path: "b", Err: conflict}}, errcode.ExitConflict},
+		{"種類が混在", []copier.CopyFailure{{Path: "a", Err: conflict}, {Path: "b", Err: permission}}, errcode.ExitFilesFailed},
+		{"分類なし", []copier.CopyFailure{{Path: "a", Err: errors.New("I/Oエラー")}}, errcode.ExitFilesFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := failuresExitCode(tt.failures); got != tt.want {
+				t.Errorf("failuresExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCopyExitCode(t *testing.T) {
+	permission := []copier.CopyFailure{{Path: "a", Err: errcode.Wrap(errcode.ErrPermissionCopy, errors.New("拒否"))}}
+	failed := []copier.CopyFailure{{Path: "b", Err: errors.New("I/Oエラー")}}
+
+	tests := []struct {
+		name     string
+		failures []copier.CopyFailure
+		mode     string
+		want     int
+	}{
+		{"アクセス権のみ（fail）", nil, "fail", errcode.ExitPermissionCopy},
+		{"アクセス権のみ（warn）", nil, "warn", errcode.ExitOK},
+		{"他の失敗あり（warn）", failed, "warn", errcode.ExitFilesFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := copyExitCode(tt.failures, permission, tt.mode); got != tt.want {
+				t.Errorf("copyExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+	if !validPermissionErrors("warn") || validPermissionErrors("ignore") {
+		t.Error("validPermissionErrors() の判定が正しくありません")
+	}
+}
+
+func TestGradedExitCode(t *testing.T) {
+	mismatch := []copier.CopyFailure{{Path: "a", Err: errcode.Errorf(errcode.ErrHashMismatch, "ハッシュ値が一致しません")}}
+	failed := []copier.CopyFailure{{Path: "b", Err: errors.New("I/Oエラー")}}
+	pass := &runsummary.Grade{Result: runsummary.GradePass}
+	fail := &runsummary.Grade{Result: runsummary.GradeFail}
+
+	tests := []struct {
+		name     string
+		grade    *runsummary.Grade
+		failures []copier.CopyFailure
+		want     int
+	}{
+		{"判定なし", nil, mismatch, errcode.ExitVerifyFailed},
+		{"合格・検証の失敗のみ", pass, mismatch, errcode.ExitOK},
+		{"合格・コピーの失敗あり", pass, append(mismatch, failed...), errcode.ExitFilesFailed},
+		{"不合格・失敗なし", fail, nil, errcode.ExitVerifyFailed},
+		{"不合格・コピーの失敗あり", fail, failed, errcode.ExitFilesFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := gradedCopyExitCode(tt.grade, tt.failures, nil, "warn"); got != tt.want {
+				t.Errorf("gradedCopyExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	verifyErr := errcode.Errorf(errcode.ErrVerifyFailed, "1 個のファイルで不一致が検出されました")
+	cancelled := errcode.Errorf(errcode.ErrCancelled, "キャンセルされました")
+	if err := gradedError(verifyErr, nil); err != verifyErr {
+		t.Errorf("判定なし: %v", err)
+	}
+	if err := gradedError(verifyErr, pass); err != nil {
+		t.Errorf("合格: %v", err)
+	}
+	if err := gradedError(cancelled, pass); err != cancelled {
+		t.Errorf("合格・キャンセル: %v", err)
+	}
+	if err := gradedError(nil, fail); errcode.ExitCode(err) != errcode.ExitVerifyFailed {
+		t.Errorf("不合格: %v", err)
+	}
+}
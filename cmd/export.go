@@ -0,0 +1,275 @@
+package cmd
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/locale"
+)
+
+// recordWriter はエクスポート形式ごとにファイル情報を1件ずつ書き込む
+type recordWriter interface {
+	Write(file database.FileInfo) error
+	Close() error
+}
+
+// csvHeaders は言語ごとのCSVのヘッダ（detailedの列を含む）
+var csvHeaders = map[locale.Locale][]string{
+	locale.Japanese: {"パス", "サイズ", "更新日時", "ステータス", "ソースハッシュ", "宛先ハッシュ", "失敗回数", "最終同期", "最終エラー", "エラーコード",
+		"処理時間(秒)", "再試行回数", "ワーカー", "スループット(バイト/秒)"},
+	locale.English: {"path", "size", "mod_time", "status", "source_hash", "dest_hash", "fail_count", "last_sync_time", "last_error", "code",
+		"seconds", "retries", "worker", "throughput_bytes_per_sec"},
+}
+
+// newRecordWriter は指定された形式のrecordWriterを作成する
+// detailedを指定した場合は、CSVにコピーの処理時間・再試行回数・ワーカー・スループットの列を加える
+// （JSON・NDJSONには記録されていれば常に含まれる）
+// ステータスとエラーコードは言語によらず同じ値を書き込み、ヘッダと最終エラーのメッセージのみlocの言語にする
+func newRecordWriter(format string, w io.Writer, detailed bool, loc locale.Locale) (recordWriter, error) {
+	switch format {
+	case "csv":
+		writer := csv.NewWriter(w)
+		header := csvHeaders[loc][:10]
+		if detailed {
+			header = csvHeaders[loc]
+		}
+		if err := writer.Write(header); err != nil {
+			return nil, err
+		}
+		return &csvRecordWriter{writer: writer, detailed: detailed, locale: loc}, nil
+	case "json":
+		return &jsonRecordWriter{w: w, locale: loc}, nil
+	case "ndjson":
+		return &ndjsonRecordWriter{encoder: json.NewEncoder(w), locale: loc}, nil
+	default:
+		return nil, fmt.Errorf("サポートされていない形式: %s", format)
+	}
+}
+
+// csvRecordWriter はCSV形式で書き込む
+type csvRecordWriter struct {
+	writer   *csv.Writer
+	detailed bool
+	locale   locale.Locale
+}
+
+func (c *csvRecordWriter) Write(file database.FileInfo) error {
+	record := []string{
+		file.Path,
+		fmt.Sprintf("%d", file.Size),
+		file.ModTime.Format(time.RFC3339),
+		string(file.Status),
+		file.SourceHash,
+		file.DestHash,
+		fmt.Sprintf("%d", file.FailCount),
+		file.LastSyncTime.Format(time.RFC3339),
+		locale.FileMessage(c.locale, &file),
+		file.Code(),
+	}
+	if c.detailed {
+		record = append(record, processingColumns(file.Processing)...)
+	}
+	return c.writer.Write(record)
+}
+
+// processingColumns はコピーの処理の詳細をCSVの列に変換する（記録していない場合は空）
+func processingColumns(p *database.Processing) []string {
+	if p == nil {
+		return []string{"", "", "", ""}
+	}
+	return []string{
+		fmt.Sprintf("%.3f", p.Seconds),
+		fmt.Sprintf("%d", p.Retries),
+		fmt.Sprintf("%d", p.Worker),
+		fmt.Sprintf("%.0f", p.Throughput),
+	}
+}
+
+// localizeFile はJSON・NDJSONに書き込むファイル情報の最終エラーのメッセージをlocの言語にする
+func localizeFile(loc locale.Locale, file database.FileInfo) database.FileInfo {
+	if loc == locale.Japanese {
+		return file
+	}
+	file.LastError = locale.FileMessage(loc, &file)
+	if len(file.Targets) > 0 {
+		targets := make(map[string]database.TargetStatus, len(file.Targets))
+		for root, target := range file.Targets {
+			target.LastError = locale.TargetMessage(loc, target)
+			targets[root] = target
+		}
+		file.Targets = targets
+	}
+	return file
+}
+
+func (c *csvRecordWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+// jsonRecordWriter はJSON配列として1件ずつ書き込む
+type jsonRecordWriter struct {
+	w      io.Writer
+	count  int
+	locale locale.Locale
+}
+
+func (j *jsonRecordWriter) Write(file database.FileInfo) error {
+	data, err := json.MarshalIndent(localizeFile(j.locale, file), "  ", "  ")
+	if err != nil {
+		return err
+	}
+
+	prefix := ",\n  "
+	if j.count == 0 {
+		prefix = "[\n  "
+	}
+	j.count++
+
+	if _, err := io.WriteString(j.w, prefix); err != nil {
+		return err
+	}
+	_, err = j.w.Write(data)
+	return err
+}
+
+func (j *jsonRecordWriter) Close() error {
+	closing := "\n]\n"
+	if j.count == 0 {
+		closing = "[]\n"
+	}
+	_, err := io.WriteString(j.w, closing)
+	return err
+}
+
+// ndjsonRecordWriter は1行1レコードのJSON（NDJSON）形式で書き込む
+type ndjsonRecordWriter struct {
+	encoder *json.Encoder
+	locale  locale.Locale
+}
+
+func (n *ndjsonRecordWriter) Write(file database.FileInfo) error {
+	return n.encoder.Encode(localizeFile(n.locale, file))
+}
+
+func (n *ndjsonRecordWriter) Close() error {
+	return nil
+}
+
+// exportOutput はエクスポート先のファイルで、バッファリングと任意のgzip圧縮を行う
+type exportOutput struct {
+	file *os.File
+	buf  *bufio.Writer
+	gz   *gzip.Writer
+	io.Writer
+}
+
+// openExportOutput はエクスポート先のファイルを作成する
+func openExportOutput(path string, compress bool) (*exportOutput, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &exportOutput{file: file, buf: bufio.NewWriterSize(file, 1024*1024)}
+	out.Writer = out.buf
+	if compress {
+		out.gz = gzip.NewWriter(out.buf)
+		out.Writer = out.gz
+	}
+	return out, nil
+}
+
+// Close は圧縮とバッファの内容を書き出してファイルを閉じる
+func (o *exportOutput) Close() error {
+	var firstErr error
+	if o.gz != nil {
+		firstErr = o.gz.Close()
+	}
+	if err := o.buf.Flush(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	if err := o.file.Close(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
+
+// exportProgress はエクスポートの進捗を端末に表示する
+type exportProgress struct {
+	out     io.Writer
+	total   int
+	current int
+	last    time.Time
+	enabled bool
+}
+
+// newExportProgress は新しいexportProgressを作成する（標準エラー出力が端末の場合のみ表示する）
+func newExportProgress(total int) *exportProgress {
+	enabled := false
+	if info, err := os.Stderr.Stat(); err == nil {
+		enabled = info.Mode()&os.ModeCharDevice != 0
+	}
+	return &exportProgress{out: os.Stderr, total: total, enabled: enabled}
+}
+
+// Add は処理件数を1件増やし、一定間隔で進捗を表示する
+func (p *exportProgress) Add() {
+	p.current++
+	if !p.enabled || time.Since(p.last) < 200*time.Millisecond {
+		return
+	}
+	p.last = time.Now()
+	p.print()
+}
+
+// Done は最終的な進捗を表示して行を終える
+func (p *exportProgress) Done() {
+	if !p.enabled {
+		return
+	}
+	p.print()
+	fmt.Fprintln(p.out)
+}
+
+func (p *exportProgress) print() {
+	percent := 100.0
+	if p.total > 0 {
+		percent = float64(p.current) / float64(p.total) * 100
+	}
+	fmt.Fprintf(p.out, "\r\033[Kエクスポート中: %d/%d件 (%.1f%%)", p.current, p.total, percent)
+}
+
+// exportFiles はファイル情報のリストを指定された形式で書き出す
+func exportFiles(files []database.FileInfo, outputPath, format string, compress bool) error {
+	out, err := openExportOutput(outputPath, compress)
+	if err != nil {
+		return err
+	}
+
+	writer, err := newRecordWriter(format, out, false, locale.Japanese)
+	if err != nil {
+		out.Close()
+		return err
+	}
+
+	for _, file := range files {
+		if err := writer.Write(file); err != nil {
+			out.Close()
+			return err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/locale"
+)
+
+func testExportFiles() []database.FileInfo {
+	return []database.FileInfo{
+		{Path: "a.txt", Size: 1, ModTime: time.Now(), Status: database.StatusSuccess},
+		{Path: "b.txt", Size: 2, ModTime: time.Now(), Status: database.StatusFailed, LastError: "err"},
+	}
+}
+
+func TestExportFiles_JSONIsValidArray(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// 通常のリスト
+	outputPath := filepath.Join(tempDir, "out.json")
+	if err := exportFiles(testExportFiles(), outputPath, "json", false); err != nil {
+		t.Fatalf("エクスポートに失敗: %v", err)
+	}
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded []database.FileInfo
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("JSONとして読み込めません: %v\n%s", err, data)
+	}
+	if len(decoded) != 2 || decoded[1].LastError != "err" {
+		t.Errorf("読み込んだ内容が一致しません: %+v", decoded)
+	}
+
+	// 空のリスト
+	emptyPath := filepath.Join(tempDir, "empty.json")
+	if err := exportFiles(nil, emptyPath, "json", false); err != nil {
+		t.Fatalf("エクスポートに失敗: %v", err)
+	}
+	data, _ = os.ReadFile(emptyPath)
+	if string(data) != "[]\n" {
+		t.Errorf("空の出力 = %q, want %q", data, "[]\n")
+	}
+}
+
+func TestExportFiles_NDJSONCompressed(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "out.ndjson.gz")
+	if err := exportFiles(testExportFiles(), outputPath, "ndjson", true); err != nil {
+		t.Fatalf("エクスポートに失敗: %v", err)
+	}
+
+	file, err := os.Open(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("gzipとして読み込めません: %v", err)
+	}
+
+	var paths []string
+	scanner := bufio.NewScanner(gz)
+	for scanner.Scan() {
+		var info database.FileInfo
+		if err := json.Unmarshal(scanner.Bytes(), &info); err != nil {
+			t.Fatalf("行をJSONとして読み込めません: %v", err)
+		}
+		paths = append(paths, info.Path)
+	}
+	if len(paths) != 2 || paths[0] != "a.txt" || paths[1] != "b.txt" {
+		t.Errorf("読み込んだパス = %v", paths)
+	}
+}
+
+func TestNewRecordWriter_UnsupportedFormat(t *testing.T) {
+	if _, err := newRecordWriter("xml", os.Stdout, false, locale.Japanese); err == nil {
+		t.Error("サポートされていない形式でエラーになりません")
+	}
+}
+
+func TestNewRecordWriter_DetailedCSV(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := newRecordWriter("csv", &buf, true, locale.Japanese)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := testExportFiles()
+	files[0].Processing = &database.Processing{Seconds: 1.5, Retries: 2, Worker: 3, Throughput: 1024}
+	for _, file := range files {
+		writer.Write(file)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasSuffix(lines[0], ",処理時間(秒),再試行回数,ワーカー,スループット(バイト/秒)") {
+		t.Fatalf("CSV = %q", buf.String())
+	}
+	if !strings.HasSuffix(lines[1], ",1.500,2,3,1024") || !strings.HasSuffix(lines[2], ",,,,") {
+		t.Errorf("CSVの行 = %q", lines[1:])
+	}
+}
+
+func TestNewRecordWriter_Locale(t *testing.T) {
+	files := []database.FileInfo{
+		{Path: "a.txt", Status: database.StatusMismatch, LastError: "ハッシュ値が一致しません",
+			Error: &errcode.Detail{Code: errcode.EHashMismatch}},
+		{Path: "b.txt", Status: database.StatusSkipped, LastError: "フィルタによりスキップ", Reason: database.ReasonFiltered},
+	}
+
+	var buf bytes.Buffer
+	writer, err := newRecordWriter("csv", &buf, false, locale.English)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		writer.Write(file)
+	}
+	writer.Close()
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "path,size,") ||
+		!strings.HasSuffix(lines[1], ",hash mismatch,E_HASH_MISMATCH") || !strings.HasSuffix(lines[2], ",skipped by filter,filtered") ||
+		!strings.Contains(lines[1], ",mismatch,") {
+		t.Errorf("CSV = %q", buf.String())
+	}
+
+	// 日本語では記録したメッセージをそのまま書き込み、コードは言語によらず同じ
+	buf.Reset()
+	writer, _ = newRecordWriter("ndjson", &buf, false, locale.Japanese)
+	writer.Write(files[1])
+	var decoded database.FileInfo
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || decoded.LastError != "フィルタによりスキップ" || decoded.Reason != database.ReasonFiltered {
+		t.Errorf("NDJSON = %q", buf.String())
+	}
+	buf.Reset()
+	writer, _ = newRecordWriter("ndjson", &buf, false, locale.English)
+	writer.Write(files[1])
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || decoded.LastError != "skipped by filter" || decoded.Reason != database.ReasonFiltered {
+		t.Errorf("NDJSON = %q", buf.String())
+	}
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"github.com/sakuhanight/gopier/internal/faultinject"
+	"github.com/sakuhanight/gopier/internal/logger"
+)
+
+var (
+	// faultInject は動作確認のために擬似的な障害を発生させる指定（--fault-inject、ヘルプには表示しない）
+	faultInject string
+	// faults は--fault-injectを解析した結果（指定しない場合はnil）
+	faults *faultinject.Injector
+	// faultsReported は前回ログに出力した時点の障害の件数
+	faultsReported faultinject.Counts
+)
+
+// reportFaults は前回の出力以降に発生させた障害の件数をログに出力する
+func reportFaults(log *logger.Logger) {
+	if faults == nil {
+		return
+	}
+
+	counts := faults.Counts()
+	log.Warn("障害注入: 読み込みエラー %d件, 遅いI/O %d件, ハッシュ不一致 %d件",
+		counts.ReadErrors-faultsReported.ReadErrors,
+		counts.SlowReads-faultsReported.SlowReads,
+		counts.HashMismatches-faultsReported.HashMismatches)
+	faultsReported = counts
+}
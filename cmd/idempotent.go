@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/filter"
+	"github.com/sakuhanight/gopier/internal/logger"
+	"github.com/sakuhanight/gopier/internal/manifest"
+)
+
+// idempotentModTimePrecision は抜き取り確認で更新日時を比較する精度（FATの更新日時は2秒単位のため）
+const idempotentModTimePrecision = 2 * time.Second
+
+// idempotencySettings はマニフェストと一緒にハッシュに含める、宛先の内容に影響する設定
+// 前回の実行と異なる場合はコピーを省略しない
+func idempotencySettings() string {
+	return fmt.Sprintf("%s mirror=%t empty-dirs=%t dir-times=%t perms=%t chmod=%s chown=%s sidecar=%t stamp=%t extra=%s",
+		changeJournalFilter(), mirror, copyEmptyDirs, preserveDirTimes, preservePermissions, chmodSpec, chownSpec,
+		metaSidecar, stampXattr, strings.Join(extraDests, ","))
+}
+
+// checkIdempotency は--idempotentを指定した場合に、ソースのマニフェストを前回正常に完了した実行と比較する
+// 一致し、宛先の抜き取り確認で問題がなければ最新の状態であることを報告してtrueを返す（コピーを省略する）。
+// 省略しない場合は、実行が正常に完了した後に記録するマニフェストを返す（使用しない場合はnil）
+func checkIdempotency(log *logger.Logger, syncDB *database.SyncDB, fileFilter *filter.Filter) (*database.IdempotencyRecord, bool) {
+	if !idempotent || syncDB == nil {
+		return nil, false
+	}
+	source, err := filepath.Abs(sourceDir)
+	if err != nil {
+		log.Warn("コピーを省略できるかを判定できません: %v", err)
+		return nil, false
+	}
+	dest, err := filepath.Abs(destDir)
+	if err != nil {
+		log.Warn("コピーを省略できるかを判定できません: %v", err)
+		return nil, false
+	}
+
+	// コピー中の変更を次回に漏らさないよう、コピーを開始する前のソースのマニフェストを記録する
+	m, err := manifest.Compute(source, manifest.Options{
+		Filter:        fileFilter,
+		IncludeHidden: includeHidden,
+		IncludeSystem: includeSystem,
+		Settings:      idempotencySettings(),
+		Samples:       idempotentSamples,
+	})
+	if err != nil {
+		log.Warn("ソースのマニフェストを計算できないため、コピーを実行します: %v", err)
+		return nil, false
+	}
+	next := &database.IdempotencyRecord{
+		Source:      source,
+		Destination: dest,
+		Manifest:    m.Hash,
+		Files:       m.Files,
+		Bytes:       m.Bytes,
+		RecordedAt:  time.Now(),
+	}
+
+	prev, err := syncDB.GetIdempotencyRecord(source, dest)
+	switch {
+	case err != nil:
+		log.Warn("前回の実行のマニフェストを取得できないため、コピーを実行します: %v", err)
+		return next, false
+	case prev == nil:
+		log.Info("前回の実行のマニフェストが記録されていないため、コピーを実行します")
+		return next, false
+	case syncMode == string(database.InitialSync):
+		log.Info("初回同期モードのため、コピーを実行します")
+		return next, false
+	case prev.Manifest != next.Manifest:
+		log.Info("ソースまたは設定が前回の実行から変更されたため、コピーを実行します（ファイル数 %d -> %d）", prev.Files, next.Files)
+		return next, false
+	}
+
+	// 宛先が前回の実行の後に変更・削除されていないかを抜き取って確認する
+	for _, d := range append([]string{destDir}, extraDests...) {
+		if err := manifest.Check(pluginFS, d, m.Samples, idempotentModTimePrecision); err != nil {
+			log.Info("宛先の抜き取り確認で一致しなかったため、コピーを実行します: %s: %v", d, err)
+			return next, false
+		}
+	}
+
+	log.Info("ソースのマニフェストが前回の実行（%s）と一致し、宛先の抜き取り確認（%d件）で問題がありませんでした",
+		prev.RecordedAt.Format(time.RFC3339), len(m.Samples))
+	fmt.Printf("最新の状態です（up to date）: %d件, %s。コピーを省略しました\n", m.Files, formatBytes(m.Bytes))
+	if runSummary != nil {
+		runSummary.UpToDate = true
+		saveRunSummary(log)
+		notifyPlugins(log, "copy_finished", runSummary)
+	}
+	return nil, true
+}
+
+// saveIdempotency は実行が正常に完了した後にソースのマニフェストを記録する
+// 一部のファイルが失敗した場合や検証で一致しなかった場合は、次回もコピーを省略しないよう記録しない
+func saveIdempotency(log *logger.Logger, syncDB *database.SyncDB, record *database.IdempotencyRecord) {
+	if record == nil || dryRun || runExitCode != errcode.ExitOK {
+		return
+	}
+	if err := syncDB.SaveIdempotencyRecord(*record); err != nil {
+		log.Warn("マニフェストを記録できません: %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/logger"
+)
+
+func TestCheckIdempotency(t *testing.T) {
+	tempDir := t.TempDir()
+	src, dst := filepath.Join(tempDir, "src"), filepath.Join(tempDir, "dst")
+	for _, dir := range []string{src, dst} {
+		os.MkdirAll(dir, 0755)
+		os.WriteFile(filepath.Join(dir, "a.txt"), []byte("artifact"), 0644)
+	}
+
+	oldSource, oldDest, oldIdempotent, oldSamples, oldExit := sourceDir, destDir, idempotent, idempotentSamples, runExitCode
+	defer func() {
+		sourceDir, destDir, idempotent, idempotentSamples, runExitCode = oldSource, oldDest, oldIdempotent, oldSamples, oldExit
+	}()
+	sourceDir, destDir, idempotent, idempotentSamples, runExitCode = src, dst, true, 16, errcode.ExitOK
+
+	log := logger.NewLogger("", false, false)
+	defer log.Close()
+	db, err := database.NewSyncDB(filepath.Join(tempDir, "sync.db"), database.NormalSync)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// 記録がない場合はコピーを実行し、失敗した実行のマニフェストは記録しない
+	record, upToDate := checkIdempotency(log, db, nil)
+	if upToDate || record == nil || record.Files != 1 {
+		t.Fatalf("初回のcheckIdempotency() = %+v, %v", record, upToDate)
+	}
+	runExitCode = errcode.ExitError
+	saveIdempotency(log, db, record)
+	if _, upToDate := checkIdempotency(log, db, nil); upToDate {
+		t.Fatal("失敗した実行の後にコピーを省略しました")
+	}
+
+	// 正常に完了した実行と同じ入力であれば省略する
+	runExitCode = errcode.ExitOK
+	saveIdempotency(log, db, record)
+	if record, upToDate := checkIdempotency(log, db, nil); !upToDate || record != nil {
+		t.Fatalf("同じ入力のcheckIdempotency() = %+v, %v", record, upToDate)
+	}
+
+	// 宛先のファイルが変更された場合は省略しない
+	os.WriteFile(filepath.Join(dst, "a.txt"), []byte("tampered artifact"), 0644)
+	if _, upToDate := checkIdempotency(log, db, nil); upToDate {
+		t.Error("宛先が変更された後にコピーを省略しました")
+	}
+}
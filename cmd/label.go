@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// parseTags は--tagで指定したkey=valueの一覧をマップに変換する
+func parseTags(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	tags := make(map[string]string, len(values))
+	for _, value := range values {
+		key, val, ok := strings.Cut(value, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("タグはkey=valueの形式で指定してください: %q", value)
+		}
+		tags[key] = strings.TrimSpace(val)
+	}
+	return tags, nil
+}
+
+// sessionTagMap は指定されたタグをマップで返す（形式の確認は実行開始時に行う）
+func sessionTagMap() map[string]string {
+	tags, _ := parseTags(sessionTags)
+	return tags
+}
+
+// formatTags はタグをkey=valueのカンマ区切りで、キーの順に並べて返す
+func formatTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = key + "=" + tags[key]
+	}
+	return strings.Join(parts, ",")
+}
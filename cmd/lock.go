@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/runlock"
+)
+
+// waitForLock は同じデータベースを使用する他の実行の終了を待つ時間（負の値は無期限）
+var waitForLock time.Duration
+
+// lockDatabase はデータベースのロックファイルのロックを取得する
+// 別のgopierが同じデータベースを使用している場合は、--wait-for-lockの間だけ終了を待ち、取得できなければエラーを返す
+// （エラーはerrcode.ExitCodeで終了コードに変換できる）
+func lockDatabase(dbPath string) (*runlock.Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return nil, fmt.Errorf("データベースディレクトリの作成に失敗: %w", err)
+	}
+
+	lock, err := runlock.Acquire(runlock.PathFor(dbPath), waitForLock)
+	if err != nil {
+		var held *runlock.HeldError
+		if errors.As(err, &held) && waitForLock == 0 {
+			return nil, fmt.Errorf("%w\n終了を待つ場合は--wait-for-lockを指定してください（例: --wait-for-lock 10m）", err)
+		}
+		return nil, err
+	}
+	return lock, nil
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/logger"
+	"github.com/sakuhanight/gopier/internal/netshare"
+	"github.com/sakuhanight/gopier/internal/runas"
+)
+
+// shareConnections は--connect-sharesで接続したネットワーク共有
+type shareConnections struct {
+	conns  []*netshare.Connection
+	source bool // ソースの共有に--source-userの資格情報で接続したかどうか
+	dest   bool // 宛先（--destination）の共有に--dest-userの資格情報で接続したかどうか
+}
+
+// prepareShares はソース・宛先のネットワーク共有（UNCパス・ネットワークドライブ）を検出し、
+// 実行の途中で資格情報の期限切れや切断に気付くことがないよう、共有にアクセスできるかを先に確認する
+// --connect-sharesを指定した場合は、--source-user/--dest-userの資格情報（指定しない場合は実行中のユーザー）で
+// 共有に接続してから確認する。接続した共有はCloseで切断する
+func prepareShares(log *logger.Logger) (*shareConnections, error) {
+	shares := &shareConnections{}
+	sides := []struct {
+		name        string
+		paths       []string
+		user        string
+		passwordEnv string
+		connected   *bool
+	}{
+		{"ソース", []string{sourceDir}, sourceUser, "GOPIER_SOURCE_PASSWORD", &shares.source},
+		{"宛先", append([]string{destDir}, extraDests...), destUser, "GOPIER_DEST_PASSWORD", &shares.dest},
+	}
+
+	checked := make(map[string]bool)
+	for _, side := range sides {
+		var cred *runas.Credential
+		if side.user != "" {
+			c := runas.ParseUser(side.user, os.Getenv(side.passwordEnv))
+			cred = &c
+		}
+		for _, path := range side.paths {
+			share, err := netshare.Detect(path)
+			if err != nil {
+				log.Warn("%sがネットワーク共有かを判定できません: %s: %v", side.name, path, err)
+				continue
+			}
+			if share == nil || checked[share.Remote] {
+				continue
+			}
+			checked[share.Remote] = true
+			log.Info("%sのネットワーク共有を検出しました: %s", side.name, share)
+
+			if connectShares {
+				conn, err := netshare.Connect(share, cred, isTerminal(os.Stdin))
+				if err != nil {
+					shares.Close(log)
+					return nil, errcode.Wrap(errcode.ErrPreflight, err)
+				}
+				shares.conns = append(shares.conns, conn)
+				// 共有の資格情報で接続した場合は、ソース・宛先のパスへのアクセスで資格情報を切り替えない
+				if path == side.paths[0] && cred != nil {
+					*side.connected = true
+				}
+				if conn.Existing {
+					log.Info("%sのネットワーク共有は接続済みのため、終了時に切断しません: %s", side.name, share)
+				} else {
+					log.Info("%sのネットワーク共有に接続しました: %s", side.name, share)
+				}
+			}
+			if err := netshare.Check(share); err != nil {
+				shares.Close(log)
+				return nil, errcode.Wrap(errcode.ErrPreflight, err)
+			}
+		}
+	}
+	return shares, nil
+}
+
+// Close は接続した共有を接続と逆の順に切断する
+func (s *shareConnections) Close(log *logger.Logger) {
+	for i := len(s.conns) - 1; i >= 0; i-- {
+		if err := s.conns[i].Close(); err != nil {
+			log.Warn("%v", err)
+		}
+	}
+	s.conns = nil
+}
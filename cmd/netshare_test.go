@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/sakuhanight/gopier/internal/logger"
+)
+
+func TestPrepareShares_Local(t *testing.T) {
+	oldSource, oldDest, oldExtra, oldConnect := sourceDir, destDir, extraDests, connectShares
+	defer func() {
+		sourceDir, destDir, extraDests, connectShares = oldSource, oldDest, oldExtra, oldConnect
+	}()
+	sourceDir, destDir, extraDests, connectShares = t.TempDir(), t.TempDir(), nil, true
+
+	log := logger.NewLogger("", false, false)
+	defer log.Close()
+
+	// ローカルのパスでは共有に接続せず、資格情報の切り替えも省略しない
+	shares, err := prepareShares(log)
+	if err != nil {
+		t.Fatalf("prepareShares() error = %v", err)
+	}
+	defer shares.Close(log)
+	if len(shares.conns) != 0 || shares.source || shares.dest {
+		t.Errorf("prepareShares() = %+v", shares)
+	}
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/sakuhanight/gopier/internal/filter"
+	"github.com/sakuhanight/gopier/internal/logger"
+	"github.com/sakuhanight/gopier/internal/plugin"
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+var (
+	// pluginSpecs は起動するプラグインのコマンド（--plugin、複数指定可）
+	pluginSpecs []string
+	// plugins は起動したプラグイン
+	plugins []*plugin.Plugin
+	// pluginConns は宛先のストレージの接続数に合わせて追加で起動したプラグイン
+	pluginConns []*plugin.Plugin
+	// backends は設定ファイルのbackendsで定義したプラグインごとの接続の設定
+	backends map[string]plugin.Backend
+	// pluginFS は宛先のストレージを提供するプラグインのファイルシステム（ない場合はnil）
+	pluginFS vfs.FS
+)
+
+// startPlugins は--pluginで指定されたプラグインを起動し、提供する機能を組み込む
+// フィルタはfileFilterの判定に追加し、宛先のストレージは宛先のディレクトリ以下に使用する（1つのみ）
+// 宛先のストレージにはbackendsのプラグインの名前の設定（接続数、タイムアウト、再試行、TLS）を適用する
+func startPlugins(log *logger.Logger, fileFilter *filter.Filter) error {
+	for _, spec := range pluginSpecs {
+		p, err := plugin.Start(spec)
+		if err != nil {
+			return err
+		}
+		plugins = append(plugins, p)
+
+		var caps []string
+		if p.Has(plugin.CapFilter) {
+			caps = append(caps, plugin.CapFilter)
+			fileFilter.AddCheck(func(path string) bool {
+				include, err := p.Include(path)
+				if err != nil {
+					log.Warn("プラグイン(%s)で判定できないため除外します: %s: %v", p.Name(), path, err)
+					return false
+				}
+				return include
+			})
+		}
+		if p.Has(plugin.CapNotify) {
+			caps = append(caps, plugin.CapNotify)
+		}
+		if p.Has(plugin.CapFS) {
+			if pluginFS != nil {
+				return fmt.Errorf("宛先のストレージを提供するプラグインは1つのみ指定できます: %s", p.Name())
+			}
+			caps = append(caps, plugin.CapFS)
+			if p.Has(plugin.CapResume) {
+				caps = append(caps, plugin.CapResume)
+			}
+			backend := backends[p.Name()]
+			conns, err := connectBackend(spec, p, backend)
+			if err != nil {
+				return err
+			}
+			pluginFS = plugin.PoolFS(destDir, conns, backend)
+			if len(conns) > 1 {
+				log.Info("宛先のストレージの接続数: %d", len(conns))
+			}
+		}
+		log.Info("プラグインを起動しました: %s %v", p.Name(), caps)
+	}
+
+	for name := range backends {
+		if !hasStoragePlugin(name) {
+			log.Warn("backendsの%sに対応する宛先のストレージのプラグインがないため、設定を使用しません", name)
+		}
+	}
+	return nil
+}
+
+// connectBackend は宛先のストレージを提供するプラグインに接続の設定を渡し、接続数までプラグインを追加で起動する
+// 返すプロセスの1つ目はpで、追加で起動したプロセスはpluginConnsに加えてclosePluginsで終了させる
+func connectBackend(spec string, p *plugin.Plugin, backend plugin.Backend) ([]*plugin.Plugin, error) {
+	if err := p.Configure(backend); err != nil {
+		return nil, err
+	}
+	conns := []*plugin.Plugin{p}
+	for len(conns) < backend.MaxConnections {
+		c, err := plugin.Start(spec)
+		if err != nil {
+			return nil, err
+		}
+		pluginConns = append(pluginConns, c)
+		if err := c.Configure(backend); err != nil {
+			return nil, err
+		}
+		conns = append(conns, c)
+	}
+	return conns, nil
+}
+
+// hasStoragePlugin は宛先のストレージを提供する名前のプラグインがあるかどうかを返す
+func hasStoragePlugin(name string) bool {
+	for _, p := range plugins {
+		if p.Name() == name && p.Has(plugin.CapFS) {
+			return true
+		}
+	}
+	return false
+}
+
+// notifyPlugins は通知を提供するプラグインにイベントを通知する（失敗してもコピー・検証は続ける）
+func notifyPlugins(log *logger.Logger, event string, data interface{}) {
+	for _, p := range plugins {
+		if !p.Has(plugin.CapNotify) {
+			continue
+		}
+		if err := p.Notify(event, data); err != nil {
+			log.Warn("プラグイン(%s)への通知に失敗: %s: %v", p.Name(), event, err)
+		}
+	}
+}
+
+// hasNotifyPlugins は通知を提供するプラグインがあるかどうかを返す
+func hasNotifyPlugins() bool {
+	for _, p := range plugins {
+		if p.Has(plugin.CapNotify) {
+			return true
+		}
+	}
+	return false
+}
+
+// closePlugins はプラグインを終了させる
+func closePlugins(log *logger.Logger) {
+	for _, p := range append(plugins, pluginConns...) {
+		if err := p.Close(); err != nil {
+			log.Warn("%v", err)
+		}
+	}
+	plugins = nil
+	pluginConns = nil
+}
@@ -0,0 +1,291 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	presetOutput string
+	presetForce  bool
+)
+
+// preset は用途ごとにまとめた設定値
+// 設定ファイルと同じキーで記述し、指定していない項目はデフォルトの設定値を使用する
+type preset struct {
+	Name        string
+	Description string
+	ConfigFile  string                 // 使用例で作成する設定ファイルの名前
+	ExampleArgs string                 // 使用例で設定ファイルと共に指定する引数
+	Settings    map[string]interface{} // デフォルトの設定値から変更する項目
+}
+
+// presets は組み込みのプリセット（表示する順に並べる）
+// 経験の少ない運用者でも安全に実行できるよう、削除を伴う設定（mirrorなど）は含めない
+var presets = []preset{
+	{
+		Name:        "nas-migration",
+		Description: "NASの移行（アクセス権と更新日時を保持し、コピー後にすべてのファイルを検証）",
+		ConfigFile:  "nas.yaml",
+		ExampleArgs: "-s /mnt/old-nas/share -d /mnt/new-nas/share",
+		Settings: map[string]interface{}{
+			"workers":              8,
+			"retry_count":          5,
+			"retry_wait":           "10s",
+			"defer_retries":        true,
+			"catch_up_passes":      2,
+			"preserve_permissions": true,
+			"permission_errors":    "warn",
+			"ignore_errors_on":     "$RECYCLE.BIN,.snapshot,#recycle",
+			"profile_exclusions":   "windows-system,macos",
+			"sync_mode":            "initial",
+			"verify_all":           true,
+			"final_report":         "verification_report.csv",
+			"summary_json":         "summary.json",
+			"failed_files_out":     "failed_files.txt",
+		},
+	},
+	{
+		Name:        "usb-archive",
+		Description: "USBドライブなど低速な外部メディアへのアーカイブ（並列数を抑え、宛先の新しいファイルは上書きしない）",
+		ConfigFile:  "usb.yaml",
+		ExampleArgs: "-s ~/Documents -d /media/usb/archive",
+		Settings: map[string]interface{}{
+			"workers":            2,
+			"read_ahead":         2,
+			"skip_newer":         true,
+			"conflict":           "skip",
+			"include_system":     false,
+			"profile_exclusions": "windows-system,macos,dev-projects",
+			"verify_changed":     true,
+			"failed_files_out":   "failed_files.txt",
+		},
+	},
+	{
+		Name:        "verify-audit",
+		Description: "コピーせずにソースと宛先をハッシュで照合し、監査用のレポートとログを残す",
+		ConfigFile:  "audit.yaml",
+		ExampleArgs: "-s /data -d /backup/data",
+		Settings: map[string]interface{}{
+			"verify_only":    true,
+			"verify_all":     true,
+			"verify_hash":    true,
+			"hash_algorithm": "sha256",
+			"extras_action":  "report",
+			"final_report":   "verification_report.csv",
+			"summary_json":   "summary.json",
+			"audit_log":      "audit.jsonl",
+		},
+	},
+}
+
+// findPreset は名前でプリセットを検索する
+func findPreset(name string) (preset, error) {
+	var names []string
+	for _, p := range presets {
+		if p.Name == name {
+			return p, nil
+		}
+		names = append(names, p.Name)
+	}
+	return preset{}, fmt.Errorf("不明なプリセット: %s (%sのいずれかを指定してください)", name, strings.Join(names, ", "))
+}
+
+// config はデフォルトの設定値にプリセットの設定値を適用した設定を返す
+func (p preset) config() (Config, error) {
+	config := defaultConfig()
+	v := viper.New()
+	if err := v.MergeConfigMap(p.Settings); err != nil {
+		return Config{}, fmt.Errorf("プリセット %s の読み込みに失敗: %w", p.Name, err)
+	}
+	if err := v.Unmarshal(&config, configDecodeHook()); err != nil {
+		return Config{}, fmt.Errorf("プリセット %s の解析に失敗: %w", p.Name, err)
+	}
+	if err := validateConfig(&config); err != nil {
+		return Config{}, fmt.Errorf("プリセット %s の検証エラー: %w", p.Name, err)
+	}
+	return config, nil
+}
+
+// configValues は設定を設定ファイルと同じキーの値に変換する
+func configValues(config Config) map[string]interface{} {
+	values := make(map[string]interface{})
+	rv := reflect.ValueOf(config)
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		key := rt.Field(i).Tag.Get("mapstructure")
+		if key == "" || key == "-" {
+			continue
+		}
+		values[key] = rv.Field(i).Interface()
+	}
+	return values
+}
+
+// writePreset はプリセットを適用した設定を、設定ファイルとして読み込める形式で出力する
+func writePreset(w io.Writer, p preset) error {
+	config, err := p.config()
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(configValues(config))
+	if err != nil {
+		return fmt.Errorf("設定のマーシャルエラー: %w", err)
+	}
+	fmt.Fprintf(w, "# gopierプリセット: %s\n# %s\n", p.Name, p.Description)
+	_, err = w.Write(data)
+	return err
+}
+
+// presetCmd represents the preset command
+var presetCmd = &cobra.Command{
+	Use:   "preset",
+	Short: "用途ごとの設定のプリセットを扱う",
+	Long: `NASの移行・外部メディアへのアーカイブ・監査用の検証など、用途ごとに安全な設定値をまとめたプリセットを扱います。
+プリセットは設定ファイルと同じ形式で、applyで設定ファイルとして保存し、--configで指定して使用します。
+
+利用可能なサブコマンド:
+  list  - プリセットの一覧を表示
+  show  - プリセットを適用した設定を表示
+  apply - プリセットを適用した設定ファイルを作成`,
+}
+
+// presetListCmd represents the preset list command
+var presetListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "プリセットの一覧を表示",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, p := range presets {
+			fmt.Printf("%-15s %s\n", p.Name, p.Description)
+		}
+	},
+}
+
+// presetShowCmd represents the preset show command
+var presetShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "プリセットを適用した設定を表示",
+	Long: `デフォルトの設定値にプリセットを適用した設定を、設定ファイルの形式で表示します。
+出力をそのまま設定ファイルとして保存して編集できます。`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		p, err := findPreset(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		if err := writePreset(os.Stdout, p); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// presetApplyCmd represents the preset apply command
+var presetApplyCmd = &cobra.Command{
+	Use:   "apply <name>",
+	Short: "プリセットを適用した設定ファイルを作成",
+	Long: `デフォルトの設定値にプリセットを適用した設定ファイルを--outputに作成します。
+既存のファイルは--forceを指定した場合のみ上書きします。
+作成した設定ファイルは--configで指定し、ソースと宛先などを引数で指定して実行します。`,
+	Example: `  gopier preset apply nas-migration --output nas.yaml
+  gopier --config nas.yaml -s /mnt/old-nas/share -d /mnt/new-nas/share`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		p, err := findPreset(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		if err := applyPreset(p, presetOutput, presetForce); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("プリセット %s の設定ファイルを作成しました: %s\n", p.Name, presetOutput)
+		fmt.Printf("実行例: gopier --config %s -s <ソース> -d <宛先>\n", presetOutput)
+	},
+}
+
+// applyPreset はプリセットを適用した設定ファイルを作成する
+func applyPreset(p preset, path string, force bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !force {
+		flags |= os.O_EXCL
+	}
+	file, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("設定ファイル %s は既に存在します（上書きする場合は--forceを指定してください）", path)
+		}
+		return fmt.Errorf("設定ファイルの作成エラー: %w", err)
+	}
+	if err := writePreset(file, p); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("設定ファイルの作成エラー: %w", err)
+	}
+	return nil
+}
+
+// examplesCmd は用途ごとの使用例を表示するコマンド
+var examplesCmd = &cobra.Command{
+	Use:   "examples",
+	Short: "用途ごとの使用例を表示",
+	Long:  `よく使う操作と、各プリセットを使用する場合のコマンドの例を表示します。`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		writeExamples(cmd.OutOrStdout())
+	},
+}
+
+// commonExamples はプリセットを使用しない基本的な操作の例
+var commonExamples = []struct {
+	description string
+	commands    []string
+}{
+	{"ディレクトリをコピーする", []string{"gopier -s /data -d /backup/data"}},
+	{"実行せずにコピーするファイルを確認する", []string{"gopier -s /data -d /backup/data --dry-run --verbose"}},
+	{"処理量と所要時間を見積もる", []string{"gopier estimate -s /data -d /backup/data"}},
+	{"コピー済みの宛先を検証する", []string{"gopier -s /data -d /backup/data --verify-only --verify-all"}},
+	{"2つのディレクトリツリーの違いを表示する", []string{"gopier diff /backup/2024-06 /backup/2024-07"}},
+}
+
+// writeExamples は用途ごとの使用例をmanページのEXAMPLESの形式で出力する
+func writeExamples(w io.Writer) {
+	fmt.Fprintln(w, "EXAMPLES")
+	for _, e := range commonExamples {
+		fmt.Fprintf(w, "  %s\n", e.description)
+		for _, c := range e.commands {
+			fmt.Fprintf(w, "      $ %s\n", c)
+		}
+		fmt.Fprintln(w)
+	}
+	for _, p := range presets {
+		fmt.Fprintf(w, "  %s（プリセット %s）\n", p.Description, p.Name)
+		fmt.Fprintf(w, "      $ gopier preset apply %s --output %s\n", p.Name, p.ConfigFile)
+		fmt.Fprintf(w, "      $ gopier --config %s %s\n", p.ConfigFile, p.ExampleArgs)
+		fmt.Fprintln(w)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(presetCmd)
+	rootCmd.AddCommand(examplesCmd)
+	presetCmd.AddCommand(presetListCmd)
+	presetCmd.AddCommand(presetShowCmd)
+	presetCmd.AddCommand(presetApplyCmd)
+
+	presetApplyCmd.Flags().StringVarP(&presetOutput, "output", "o", "", "作成する設定ファイルのパス")
+	presetApplyCmd.Flags().BoolVar(&presetForce, "force", false, "既存の設定ファイルを上書きする")
+	presetApplyCmd.MarkFlagRequired("output")
+}
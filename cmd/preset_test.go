@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestPresets(t *testing.T) {
+	for _, p := range presets {
+		t.Run(p.Name, func(t *testing.T) {
+			config, err := p.config()
+			if err != nil {
+				t.Fatalf("プリセットの設定が不正です: %v", err)
+			}
+			// プリセットのキーはすべて設定ファイルの項目であること
+			values := configValues(config)
+			for key, value := range p.Settings {
+				got, ok := values[key]
+				if !ok {
+					t.Errorf("不明な設定項目: %s", key)
+					continue
+				}
+				if got != value {
+					t.Errorf("%s = %v, want %v", key, got, value)
+				}
+			}
+			if config.Mirror || config.ExtrasAction == "delete" {
+				t.Error("プリセットに宛先のファイルを削除する設定が含まれています")
+			}
+		})
+	}
+
+	if _, err := findPreset("unknown"); err == nil || !strings.Contains(err.Error(), "nas-migration") {
+		t.Errorf("不明なプリセットのエラー = %v, want プリセットの一覧を含むエラー", err)
+	}
+}
+
+func TestApplyPreset(t *testing.T) {
+	p, err := findPreset("usb-archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "usb.yaml")
+	if err := applyPreset(p, path, false); err != nil {
+		t.Fatalf("applyPresetが失敗しました: %v", err)
+	}
+
+	// 作成した設定ファイルは設定ファイルとして読み込める
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗: %v", err)
+	}
+	var config Config
+	if err := v.Unmarshal(&config); err != nil {
+		t.Fatalf("設定ファイルの解析に失敗: %v", err)
+	}
+	if err := validateConfig(&config); err != nil {
+		t.Errorf("設定ファイルの検証エラー: %v", err)
+	}
+	if config.Workers != 2 || !config.SkipNewer || config.ProfileExclusions != "windows-system,macos,dev-projects" || config.BufferSize != "8M" {
+		t.Errorf("読み込んだ設定 = workers:%d skip_newer:%v profile_exclusions:%q buffer_size:%s",
+			config.Workers, config.SkipNewer, config.ProfileExclusions, config.BufferSize)
+	}
+
+	// 既存のファイルは--forceを指定した場合のみ上書きする
+	os.WriteFile(path, []byte("workers: 1\n"), 0644)
+	if err := applyPreset(p, path, false); err == nil {
+		t.Error("既存の設定ファイルを上書きしました")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "workers: 1\n" {
+		t.Errorf("既存の設定ファイルが変更されています: %q", data)
+	}
+	if err := applyPreset(p, path, true); err != nil {
+		t.Errorf("--forceでの上書きに失敗: %v", err)
+	}
+}
+
+func TestWriteExamples(t *testing.T) {
+	var buf bytes.Buffer
+	writeExamples(&buf)
+	output := buf.String()
+	if !strings.HasPrefix(output, "EXAMPLES\n") {
+		t.Errorf("出力の先頭がEXAMPLESではありません: %q", output)
+	}
+	for _, p := range presets {
+		if !strings.Contains(output, "gopier preset apply "+p.Name+" --output "+p.ConfigFile) {
+			t.Errorf("プリセット %s の使用例がありません", p.Name)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/logger"
+	"github.com/spf13/viper"
+)
+
+// configReloader は実行中に設定ファイルの変更を検出し、再読み込みする
+// 変更後の設定が不正な場合は適用せず、直前の有効な設定を維持する
+type configReloader struct {
+	path     string
+	interval time.Duration
+	apply    func(old, new *Config)
+	log      *logger.Logger
+
+	mu      sync.Mutex
+	current Config
+	modTime time.Time
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// newConfigReloader は新しいconfigReloaderを作成する
+func newConfigReloader(path string, current Config, apply func(old, new *Config), log *logger.Logger) *configReloader {
+	r := &configReloader{
+		path:     path,
+		interval: 2 * time.Second,
+		apply:    apply,
+		log:      log,
+		current:  current,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if info, err := os.Stat(path); err == nil {
+		r.modTime = info.ModTime()
+	}
+	return r
+}
+
+// Start は監視を開始する
+func (r *configReloader) Start() {
+	go func() {
+		defer close(r.done)
+
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+				r.check()
+			}
+		}
+	}()
+}
+
+// Stop は監視を終了する
+func (r *configReloader) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+		<-r.done
+	})
+}
+
+// Current は現在適用されている設定を返す
+func (r *configReloader) Current() Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// check は設定ファイルが更新されていれば再読み込みする
+func (r *configReloader) check() {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !info.ModTime().After(r.modTime) {
+		return
+	}
+	r.modTime = info.ModTime()
+
+	config, err := readConfigFile(r.path)
+	if err != nil {
+		r.log.Warn("設定ファイルの再読み込みに失敗したため、変更を適用しません: %v", err)
+		return
+	}
+
+	old := r.current
+	r.current = *config
+	r.log.Info("設定ファイルを再読み込みしました: %s", r.path)
+	r.apply(&old, config)
+}
+
+// readConfigFile は設定ファイルを読み込み、妥当性を検証する
+func readConfigFile(path string) (*Config, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("設定ファイルの読み込みエラー: %w", err)
+	}
+
+	var config Config
+	if err := v.Unmarshal(&config, configDecodeHook()); err != nil {
+		return nil, fmt.Errorf("設定ファイルの解析エラー: %w", err)
+	}
+	if err := validateConfig(&config); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/logger"
+)
+
+func writeReloadTestConfig(t *testing.T, path, bwlimit string, workers int) {
+	t.Helper()
+	content := "workers: " + strconv.Itoa(workers) + "\nbuffer_size: 8\nbwlimit: \"" + bwlimit + "\"\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("設定ファイルの作成に失敗: %v", err)
+	}
+}
+
+func TestConfigReloader(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.yaml")
+	writeReloadTestConfig(t, configPath, "1M", 4)
+
+	log := logger.NewLogger("", false, false)
+	defer log.Close()
+
+	var applied []Config
+	initial, err := readConfigFile(configPath)
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗: %v", err)
+	}
+	reloader := newConfigReloader(configPath, *initial, func(old, new *Config) {
+		applied = append(applied, *new)
+	}, log)
+
+	// 変更がなければ何もしない
+	reloader.check()
+	if len(applied) != 0 {
+		t.Fatal("変更がないのに設定が適用されました")
+	}
+
+	// 有効な変更は適用される
+	writeReloadTestConfig(t, configPath, "2M", 4)
+	os.Chtimes(configPath, time.Now(), time.Now().Add(time.Second))
+	reloader.check()
+	if len(applied) != 1 || applied[0].BWLimit != "2M" {
+		t.Fatalf("変更が適用されていません: %+v", applied)
+	}
+
+	// 不正な変更は適用されず、直前の設定が維持される
+	writeReloadTestConfig(t, configPath, "invalid", 4)
+	os.Chtimes(configPath, time.Now(), time.Now().Add(2*time.Second))
+	reloader.check()
+	if len(applied) != 1 {
+		t.Error("不正な設定が適用されました")
+	}
+	if reloader.Current().BWLimit != "2M" {
+		t.Errorf("直前の設定が維持されていません: %s", reloader.Current().BWLimit)
+	}
+
+	writeReloadTestConfig(t, configPath, "3M", 0)
+	os.Chtimes(configPath, time.Now(), time.Now().Add(3*time.Second))
+	reloader.check()
+	if len(applied) != 1 {
+		t.Error("検証エラーのある設定が適用されました")
+	}
+}
+
+func TestConfigReloader_StartStop(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.yaml")
+	writeReloadTestConfig(t, configPath, "1M", 4)
+
+	log := logger.NewLogger("", false, false)
+	defer log.Close()
+
+	applied := make(chan Config, 1)
+	reloader := newConfigReloader(configPath, Config{BWLimit: "1M"}, func(old, new *Config) {
+		applied <- *new
+	}, log)
+	reloader.interval = 10 * time.Millisecond
+	reloader.Start()
+	defer reloader.Stop()
+
+	writeReloadTestConfig(t, configPath, "5M", 4)
+	os.Chtimes(configPath, time.Now(), time.Now().Add(time.Second))
+
+	select {
+	case config := <-applied:
+		if config.BWLimit != "5M" {
+			t.Errorf("帯域制限: 期待値=5M, 実際=%s", config.BWLimit)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("設定ファイルの変更が検出されませんでした")
+	}
+
+	reloader.Stop()
+	// 二重に停止しても問題ない
+	reloader.Stop()
+}
@@ -0,0 +1,357 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sakuhanight/gopier/internal/copier"
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/logger"
+	"github.com/sakuhanight/gopier/internal/runsummary"
+	"github.com/sakuhanight/gopier/internal/stats"
+	"github.com/sakuhanight/gopier/internal/verifier"
+)
+
+var (
+	reportDiffFormat   string
+	reportDiffAckDB    string
+	reportDiffSuppress bool
+)
+
+// runSummary は--summary-jsonを指定した場合の実行結果（指定しない場合はnil）
+var runSummary *runsummary.Summary
+
+// gradeThresholds は--verify-thresholdで指定した検証結果の合格の基準（指定しない場合はnil）
+var gradeThresholds []runsummary.Threshold
+
+// reportCmd represents the report command
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "実行結果のレポートを扱う",
+	Long:  `--summary-jsonで保存した実行結果を扱います。`,
+}
+
+// reportDiffCmd represents the report diff command
+var reportDiffCmd = &cobra.Command{
+	Use:   "diff RUN1 RUN2",
+	Short: "2回の実行結果を比較",
+	Long: `--summary-jsonで保存した2回の実行結果を比較し、新たに失敗したファイル、
+解消したファイル、失敗が続いているファイルとスループットの変化を報告します。
+不安定なストレージに対して、失敗のない実行になるまで繰り返す場合に使用します。
+
+db export --format jsonで書き出したファイル情報も指定できます（スループットは比較しません）。
+--suppress-acknowledgedを指定すると、db ackで確認済みとして登録したパスの失敗を報告しません。
+
+新たに失敗したファイルがある場合は終了コード1で終了します。`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if reportDiffFormat != "text" && reportDiffFormat != "json" {
+			fmt.Fprintf(os.Stderr, "サポートされていない出力形式: %s\n", reportDiffFormat)
+			os.Exit(1)
+		}
+
+		before, err := runsummary.Load(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "実行結果の読み込みに失敗: %v\n", err)
+			os.Exit(1)
+		}
+		after, err := runsummary.Load(args[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "実行結果の読み込みに失敗: %v\n", err)
+			os.Exit(1)
+		}
+
+		diff := runsummary.Compare(before, after)
+		if reportDiffSuppress {
+			acks, err := loadAcknowledgements(reportDiffAckDB)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "確認済みの不一致の読み込みに失敗: %v\n", err)
+				os.Exit(1)
+			}
+			diff.Suppress(isAcknowledged(acks))
+		}
+		write := runsummary.WriteText
+		if reportDiffFormat == "json" {
+			write = runsummary.WriteJSON
+		}
+		if err := write(os.Stdout, diff); err != nil {
+			fmt.Fprintf(os.Stderr, "比較結果の出力に失敗: %v\n", err)
+			os.Exit(1)
+		}
+
+		if len(diff.NewlyFailed) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// startRunSummary は--summary-jsonまたは--failed-files-outが指定されている場合に実行結果の記録を開始する
+func startRunSummary() {
+	if summaryJSON == "" && failedFilesOut == "" && !hasNotifyPlugins() {
+		return
+	}
+	runSummary = &runsummary.Summary{
+		Source:      sourceDir,
+		Destination: destDir,
+		Label:       sessionLabel,
+		Tags:        sessionTagMap(),
+		StartedAt:   time.Now(),
+	}
+}
+
+// recordCopySummary はコピーの結果を実行結果に記録して保存する
+func recordCopySummary(log *logger.Logger, fc *copier.FileCopier, elapsed time.Duration) {
+	if runSummary == nil {
+		return
+	}
+
+	st := fc.GetStats()
+	runSummary.CopySeconds = elapsed.Seconds()
+	runSummary.FilesCopied = st.GetCopiedCount()
+	runSummary.FilesSkipped = st.GetSkippedCount()
+	runSummary.FilesFailed = st.GetFailedCount()
+	runSummary.FilesIgnored = st.GetIgnoredCount()
+	runSummary.FilesConflicted = st.GetConflictedCount()
+	runSummary.SharingRetries = st.GetSharingRetries()
+	runSummary.FilesLocked = st.GetLockedCount()
+	runSummary.MetaUpdated = st.GetMetaUpdatedCount()
+	runSummary.FilesPermFailed = st.GetPermFailedCount()
+	runSummary.BytesCopied = st.GetCopiedBytes()
+	if elapsed > 0 {
+		runSummary.Throughput = float64(runSummary.BytesCopied) / elapsed.Seconds()
+	}
+	for _, failure := range fc.GetFailures() {
+		runSummary.AddFailure(failure.Path, runsummary.StageCopy, failure.Err)
+	}
+	for _, failure := range fc.GetPermFailures() {
+		runSummary.PermFailures = append(runSummary.PermFailures, runsummary.NewFailure(failure.Path, runsummary.StageCopy, failure.Err))
+	}
+	for _, r := range fc.GetFolderResults() {
+		runSummary.Folders = append(runSummary.Folders, runsummary.Folder{
+			Folder:            r.Folder,
+			FilesCopied:       r.Copied,
+			FilesSkipped:      r.Skipped,
+			FilesFailed:       r.Failed,
+			BytesCopied:       r.BytesCopied,
+			BytesSkipped:      r.BytesSkipped,
+			CompletionPercent: r.CompletionPercent(),
+		})
+	}
+	for _, f := range st.GetSlowestFiles() {
+		runSummary.SlowestFiles = append(runSummary.SlowestFiles, runsummary.SlowFile{
+			Path:       f.Path,
+			Bytes:      f.Bytes,
+			Seconds:    f.Duration.Seconds(),
+			Throughput: f.Throughput(),
+		})
+	}
+	for _, d := range st.GetSlowestDirs() {
+		runSummary.SlowestDirs = append(runSummary.SlowestDirs, runsummary.SlowDir{
+			Dir:     d.Dir,
+			Files:   d.Files,
+			Bytes:   d.Bytes,
+			Seconds: d.Duration.Seconds(),
+		})
+	}
+	if catchUp := fc.GetCatchUpResult(); catchUp.Passes > 0 {
+		runSummary.CatchUp = &runsummary.CatchUp{
+			Passes:   catchUp.Passes,
+			Recopied: catchUp.Recopied,
+			Unstable: catchUp.Unstable,
+		}
+	}
+	if drift := fc.GetSourceDrift(); drift != nil {
+		runSummary.SourceDrift = &runsummary.SourceDrift{
+			StartedAt:     drift.Start.TakenAt,
+			FinishedAt:    drift.End.TakenAt,
+			FilesBefore:   drift.Start.Files,
+			FilesAfter:    drift.End.Files,
+			BytesBefore:   drift.Start.Bytes,
+			BytesAfter:    drift.End.Bytes,
+			NewestModTime: drift.Start.NewestModTime,
+			ChangedFiles:  drift.ChangedFiles,
+			ChangedBytes:  drift.ChangedBytes,
+			Changed:       drift.Changed(),
+		}
+	}
+	for _, r := range fc.GetCaseRenames() {
+		runSummary.Renames = append(runSummary.Renames, runsummary.Rename{From: r.From, To: r.To, Destination: r.Destination})
+	}
+	if summary := fc.GetVerificationSummary(); summary.Verified() > 0 {
+		runSummary.Verification = &summary
+	}
+	saveRunSummary(log)
+	notifyPlugins(log, "copy_finished", runSummary)
+}
+
+// printFolderResults はフォルダごとのコピー結果と完了率を表示する
+func printFolderResults(w io.Writer, results []copier.FolderResult) {
+	fmt.Fprintf(w, "\nフォルダ別の結果:\n")
+	for _, r := range results {
+		fmt.Fprintf(w, "  %s [完了率 %.1f%%]: コピー %d件 (%s), スキップ %d件 (%s), 失敗 %d件\n",
+			r.Folder, r.CompletionPercent(), r.Copied, formatBytes(r.BytesCopied), r.Skipped, formatBytes(r.BytesSkipped), r.Failed)
+	}
+}
+
+// printLockedFiles は他のプロセスが使用中のためコピーできなかったファイルを表示する
+func printLockedFiles(w io.Writer, files []copier.LockedFile) {
+	if len(files) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n使用中のためコピーできなかったファイル: %d件（ファイルを閉じてから再実行してください）\n", len(files))
+	for _, f := range files {
+		fmt.Fprintf(w, "  %s\n", f.Path)
+	}
+}
+
+// printPermFailures は内容はコピーしたが、アクセス権をコピーできなかったファイルを表示する
+func printPermFailures(w io.Writer, files []copier.CopyFailure) {
+	if len(files) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\nアクセス権をコピーできなかったファイル: %d件（内容はコピー済み、次回の実行でアクセス権のみ再設定します）\n", len(files))
+	for _, f := range files {
+		fmt.Fprintf(w, "  %s: %v\n", f.Path, f.Err)
+	}
+}
+
+// printCaseRenames は宛先で名前の大文字・小文字を変更したファイル・ディレクトリを表示する
+func printCaseRenames(w io.Writer, renames []copier.CaseRename) {
+	if len(renames) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n名前を変更したファイル（大文字・小文字のみ）: %d件\n", len(renames))
+	for _, r := range renames {
+		fmt.Fprintf(w, "  %s -> %s (%s)\n", r.From, r.To, r.Destination)
+	}
+}
+
+// printCatchUp は追いかけコピーで再コピーしたファイル数と、変更され続けているファイルを表示する
+func printCatchUp(w io.Writer, result copier.CatchUpResult) {
+	if result.Passes == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\nコピー中に変更されたファイルの再コピー: %d件（%d回）\n", result.Recopied, result.Passes)
+	if len(result.Unstable) > 0 {
+		fmt.Fprintf(w, "変更され続けているファイル（宛先の内容が最新でない可能性があります）: %d件\n", len(result.Unstable))
+		for _, path := range result.Unstable {
+			fmt.Fprintf(w, "  %s\n", path)
+		}
+	}
+}
+
+// printSourceDrift は開始時から終了時までのソースの変化を表示する
+// 変化があった場合は、切り替えの前に差分のコピーを再実行する必要があることを警告する
+func printSourceDrift(w io.Writer, drift *copier.SourceDrift) {
+	if drift == nil {
+		return
+	}
+	if !drift.Changed() {
+		fmt.Fprintf(w, "\nコピー中のソースの変化: なし（%d件, %s）\n", drift.End.Files, formatBytes(drift.End.Bytes))
+		return
+	}
+	fmt.Fprintf(w, "\n警告: コピー中にソースが変更されました（切り替えの前に差分のコピーを再実行してください）\n")
+	fmt.Fprintf(w, "  ファイル数: %d -> %d (%+d)\n", drift.Start.Files, drift.End.Files, drift.End.Files-drift.Start.Files)
+	fmt.Fprintf(w, "  合計サイズ: %s -> %s (%+d bytes)\n", formatBytes(drift.Start.Bytes), formatBytes(drift.End.Bytes), drift.End.Bytes-drift.Start.Bytes)
+	fmt.Fprintf(w, "  開始時の最新の更新日時(%s)より後に更新されたファイル: %d件 (%s)\n",
+		drift.Start.NewestModTime.Format("2006-01-02 15:04:05"), drift.ChangedFiles, formatBytes(drift.ChangedBytes))
+}
+
+// printSlowest は処理時間の長いファイルとディレクトリを表示する
+// ウイルス対策ソフトの検査や劣化したディスクなど、特定のファイル・場所だけが遅い原因の調査に使用する
+func printSlowest(w io.Writer, st *stats.Stats) {
+	if files := st.GetSlowestFiles(); len(files) > 0 {
+		fmt.Fprintf(w, "\n処理時間の長いファイル:\n")
+		for _, f := range files {
+			fmt.Fprintf(w, "  %8s  %10s  %12s/s  %s\n", f.Duration.Round(time.Millisecond), formatBytes(f.Bytes), formatBytes(int64(f.Throughput())), f.Path)
+		}
+	}
+	if dirs := st.GetSlowestDirs(); len(dirs) > 0 {
+		fmt.Fprintf(w, "\n処理時間の長いディレクトリ（直下のファイルの合計）:\n")
+		for _, d := range dirs {
+			fmt.Fprintf(w, "  %8s  %6d件  %10s  %s\n", d.Duration.Round(time.Millisecond), d.Files, formatBytes(d.Bytes), d.Dir)
+		}
+	}
+}
+
+// recordVerifySummary は検証の結果を実行結果に記録して保存する
+func recordVerifySummary(log *logger.Logger, v *verifier.Verifier) {
+	if runSummary == nil {
+		return
+	}
+
+	summary := v.GetSummary()
+	runSummary.Verification = &summary
+	for _, r := range v.GetFailures() {
+		runSummary.AddFailure(r.Path, runsummary.StageVerify, r.Error)
+	}
+	saveRunSummary(log)
+	notifyPlugins(log, "verify_finished", runSummary)
+}
+
+// gradeVerification は検証結果を合格の基準で判定し、結果を出力して実行結果に記録する
+// 基準が指定されていない場合はnilを返す
+func gradeVerification(log *logger.Logger, summary database.VerificationSummary) *runsummary.Grade {
+	if gradeThresholds == nil {
+		return nil
+	}
+
+	grade := runsummary.Evaluate(gradeThresholds, summary)
+	if grade.Passed() {
+		log.Info("検証結果の判定: %s", grade)
+	} else {
+		log.Error("検証結果の判定: %s", grade)
+	}
+	if runSummary != nil {
+		runSummary.Grade = grade
+	}
+	return grade
+}
+
+// gradedError は検証のエラーに判定の結果を反映する
+// 合格の場合は不一致による検証の失敗をエラーとせず、不合格の場合は不一致がなくても検証の失敗とする
+func gradedError(err error, grade *runsummary.Grade) error {
+	switch {
+	case grade == nil:
+		return err
+	case grade.Passed():
+		if errors.Is(err, errcode.ErrVerifyFailed) {
+			return nil
+		}
+		return err
+	case err == nil:
+		return errcode.Errorf(errcode.ErrVerifyFailed, "検証結果が合格の基準を満たしていません: %s", grade)
+	}
+	return err
+}
+
+// saveRunSummary は実行結果を--summary-jsonのパスに、失敗したファイルの一覧を--failed-files-outのパスに保存する
+// 検証の失敗で終了する場合にも残るよう、コピーと検証のそれぞれの完了時に保存する
+func saveRunSummary(log *logger.Logger) {
+	runSummary.FinishedAt = time.Now()
+	if summaryJSON != "" {
+		if err := runsummary.Save(summaryJSON, runSummary); err != nil {
+			log.Error("実行結果の保存に失敗: %v", err)
+		}
+	}
+	if failedFilesOut != "" {
+		if err := runsummary.SaveFailedFiles(failedFilesOut, runSummary.Failures, failedFilesFormat); err != nil {
+			log.Error("%v", err)
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.AddCommand(reportDiffCmd)
+
+	reportDiffCmd.Flags().StringVar(&reportDiffFormat, "format", "text", "出力形式 (text, json)")
+	reportDiffCmd.Flags().BoolVar(&reportDiffSuppress, "suppress-acknowledged", false, "db ackで確認済みとして登録したパスの失敗を報告しない")
+	reportDiffCmd.Flags().StringVar(&reportDiffAckDB, "db", "sync_state.db", "--suppress-acknowledgedで使用する同期状態データベースのパス")
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/sakuhanight/gopier/internal/copier"
+	"github.com/sakuhanight/gopier/internal/logger"
+	"github.com/sakuhanight/gopier/internal/reslimit"
+	"github.com/sakuhanight/gopier/internal/units"
+)
+
+// applyResourceLimits は--max-procs・--max-memory・--io-limit・--resource-groupで指定した制限を自身に適用する
+// 制限を適用できない場合は、ホストに影響を与えないようコピーを始めずに終了できるようエラーを返す
+func applyResourceLimits(log *logger.Logger) error {
+	limits := reslimit.Limits{
+		MaxProcs: maxProcs,
+		Group:    resourceGroup,
+		Paths:    append([]string{sourceDir, destDir}, extraDests...),
+	}
+	var err error
+	if limits.MaxMemory, err = units.ParseSize(maxMemory, units.Byte); err != nil {
+		return fmt.Errorf("メモリ上限の指定が不正です: %w", err)
+	}
+	if limits.IOLimit, err = copier.ParseBandwidth(ioLimit); err != nil {
+		return fmt.Errorf("I/O上限の指定が不正です: %w", err)
+	}
+
+	applied, err := reslimit.Apply(limits)
+	if err != nil {
+		return fmt.Errorf("リソースの制限に失敗: %w", err)
+	}
+	for _, note := range applied {
+		log.Info("リソースの制限: %s", note)
+	}
+	return nil
+}
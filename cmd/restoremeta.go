@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sakuhanight/gopier/internal/sidecar"
+)
+
+var (
+	restoreMetaRemove bool
+	restoreMetaDryRun bool
+)
+
+// restoreMetaCmd represents the restore-meta command
+var restoreMetaCmd = &cobra.Command{
+	Use:   "restore-meta DIR",
+	Short: "メタデータのファイル（.gopier.meta）からメタデータを復元",
+	Long: `--meta-sidecarを指定してコピーした際にディレクトリごとに保存したメタデータのファイル（.gopier.meta）を読み込み、
+所有者・ACL・拡張属性・パーミッション・更新日時を各ファイルとディレクトリに復元します。
+FATやオブジェクトストレージなどにコピーしたツリーを、メタデータを保持できるファイルシステムにコピーし直した後で使用します。
+
+WindowsではSDDLで保存したセキュリティ記述子（所有者・グループ・DACL）を、
+LinuxではPOSIX ACLを含む拡張属性と所有者（uid:gid）を復元します。
+保存したOSと異なるOSでは、パーミッションと更新日時のみ復元します。
+所有者の変更には管理者権限が必要です。
+
+復元に失敗したエントリがある場合は終了コード1で終了します。`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		result, err := sidecar.Restore(args[0], sidecar.RestoreOptions{
+			Remove: restoreMetaRemove,
+			DryRun: restoreMetaDryRun,
+			OnError: func(path string, err error) {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			},
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "メタデータの復元に失敗: %v\n", err)
+			os.Exit(1)
+		}
+
+		action := "復元"
+		if restoreMetaDryRun {
+			action = "復元対象"
+		}
+		fmt.Printf("メタデータのファイル: %d件, %s: %d件, 存在しないエントリ: %d件, 失敗: %d件\n",
+			result.Files, action, result.Applied, result.Missing, result.Failed)
+		if result.Removed > 0 {
+			fmt.Printf("削除したメタデータのファイル: %d件\n", result.Removed)
+		}
+
+		if result.Failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(restoreMetaCmd)
+
+	restoreMetaCmd.Flags().BoolVar(&restoreMetaRemove, "remove", false, "復元したメタデータのファイルを削除（すべてのエントリを復元できたディレクトリのみ）")
+	restoreMetaCmd.Flags().BoolVarP(&restoreMetaDryRun, "dry-run", "n", false, "実際には復元せず、対象の件数のみ表示")
+}
@@ -26,6 +26,8 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
@@ -33,11 +35,23 @@ import (
 	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
 
+	"github.com/sakuhanight/gopier/internal/chmod"
 	"github.com/sakuhanight/gopier/internal/copier"
 	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/elevate"
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/faultinject"
 	"github.com/sakuhanight/gopier/internal/filter"
+	"github.com/sakuhanight/gopier/internal/fsmeta"
 	"github.com/sakuhanight/gopier/internal/logger"
+	"github.com/sakuhanight/gopier/internal/plugin"
+	"github.com/sakuhanight/gopier/internal/runsummary"
+	"github.com/sakuhanight/gopier/internal/status"
+	"github.com/sakuhanight/gopier/internal/transform"
+	"github.com/sakuhanight/gopier/internal/tui"
+	"github.com/sakuhanight/gopier/internal/units"
 	"github.com/sakuhanight/gopier/internal/verifier"
+	"github.com/sakuhanight/gopier/internal/vfs"
 )
 
 var (
@@ -48,71 +62,246 @@ var (
 	BuildTime = "unknown"
 
 	// 基本オプション
-	sourceDir      string
-	destDir        string
-	logFile        string
-	numWorkers     int
-	retryCount     int
-	retryWait      int
-	includePattern string
-	excludePattern string
-	mirror         bool
-	dryRun         bool
-	verbose        bool
-	skipNewer      bool
-	noProgress     bool
-	bufferSize     int
-	recursive      bool
+	sourceDir        string
+	destDir          string
+	logFile          string
+	numWorkers       int
+	retryCount       int
+	retryWait        string
+	deferRetries     bool
+	sharingRetries   int
+	sharingWait      string
+	retryLocked      bool
+	includePattern   string
+	excludePattern   string
+	ignoreErrorsOn   string
+	mirror           bool
+	dryRun           bool
+	verbose          bool
+	skipNewer        bool
+	conflict         string
+	catchUpPasses    int
+	detectChanges    bool
+	copyOrder        string
+	metadataOnly     bool
+	caseRenames      bool
+	noProgress       bool
+	tuiMode          bool
+	statusListen     string
+	controlToken     string
+	bwLimit          string
+	bwLimitSchedule  string
+	transformSpec    string
+	reloadConfig     bool
+	extraDests       []string
+	filesFrom        string
+	changeJournal    bool
+	bufferSize       string
+	segments         int
+	segmentThreshold string
+	batchSmallFiles  string
+	batchSize        string
+	resumeInterval   string
+	readAhead        int
+	dedupCache       string
+	dedupMaxFile     string
+	maxProcs         int
+	maxMemory        string
+	ioLimit          string
+	resourceGroup    string
+	recursive        bool
+
+	// 除外プロファイル関連
+	profileExclusions string
+	exclusionProfiles map[string]filter.Profile // 設定ファイルのexclusion_profilesで定義した除外プロファイル
+
+	// ディレクトリ関連
+	copyEmptyDirs    bool
+	includeHidden    bool
+	includeSystem    bool
+	metaSidecar      bool
+	preserveDirTimes bool
+	flatten          bool
+	flattenRename    string
+	structureOnly    bool
+	structureFiles   string
+
+	// アクセス権関連
+	preservePermissions bool
+	permissionErrors    string
+	chmodSpec           string
+	chownSpec           string
+	elevateRun          bool
+	sourceUser          string
+	destUser            string
+	connectShares       bool
 
 	// 同期モード関連
-	syncMode      string
-	syncDBPath    string
-	verifyOnly    bool
-	verifyAll     bool
-	verifyChanged bool
-	includeFailed bool
-	maxFailCount  int
-	finalReport   string
+	syncMode          string
+	syncDBPath        string
+	verifyOnly        bool
+	verifyVia         string
+	verifyAll         bool
+	verifyChanged     bool
+	verifyWorkers     int
+	verifySubtrees    int
+	subtreeSummary    string
+	verifyRetries     int
+	verifyRetryWait   string
+	verifyResumeChunk string
+	dropCache         bool
+	verifyMtime       string
+	verifyThreshold   string
+	preserveAtime     bool
+	stampXattr        bool
+	includeFailed     bool
+	maxFailCount      int
+	dbQueueSize       int
+	idempotent        bool
+	idempotentSamples int
+	sessionLabel      string
+	sessionTags       []string
+	finalReport       string
+	summaryJSON       string
+	failedFilesOut    string
+	failedFilesFormat string
+	folderStats       int
+	slowestCount      int
+	detailedReport    bool
+	suppressAcked     bool
+	extrasAction      string
+	extrasRules       []string
+	quarantineDir     string
+	deleteMaxFiles    int
+	deleteMaxSize     string
+	confirmDelete     bool
 )
 
 // Config は設定ファイルの構造を定義する
 type Config struct {
 	// 基本設定
-	Source      string `mapstructure:"source"`
-	Destination string `mapstructure:"destination"`
-	LogFile     string `mapstructure:"log_file"`
+	WorkDir           string   `mapstructure:"workdir"`
+	Source            string   `mapstructure:"source"`
+	Destination       string   `mapstructure:"destination"`
+	ExtraDestinations []string `mapstructure:"extra_destinations"`
+	FilesFrom         string   `mapstructure:"files_from"`
+	ChangeJournal     bool     `mapstructure:"change_journal"`
+	LogFile           string   `mapstructure:"log_file"`
 
 	// パフォーマンス設定
-	Workers    int `mapstructure:"workers"`
-	BufferSize int `mapstructure:"buffer_size"`
-	RetryCount int `mapstructure:"retry_count"`
-	RetryWait  int `mapstructure:"retry_wait"`
+	Workers          int    `mapstructure:"workers"`
+	BufferSize       string `mapstructure:"buffer_size"`
+	RetryCount       int    `mapstructure:"retry_count"`
+	RetryWait        string `mapstructure:"retry_wait"`
+	DeferRetries     bool   `mapstructure:"defer_retries"`
+	SharingRetries   int    `mapstructure:"sharing_retries"`
+	SharingWait      string `mapstructure:"sharing_wait"`
+	RetryLocked      bool   `mapstructure:"retry_locked"`
+	Segments         int    `mapstructure:"segments"`
+	SegmentThreshold string `mapstructure:"segment_threshold"`
+	BatchSmallFiles  string `mapstructure:"batch_small_files"`
+	BatchSize        string `mapstructure:"batch_size"`
+	ResumeInterval   string `mapstructure:"resume_interval"`
+	ReadAhead        int    `mapstructure:"read_ahead"`
+	DedupCache       string `mapstructure:"dedup_cache"`
+	DedupMaxFile     string `mapstructure:"dedup_max_file"`
+	MaxProcs         int    `mapstructure:"max_procs"`
+	MaxMemory        string `mapstructure:"max_memory"`
+	IOLimit          string `mapstructure:"io_limit"`
+	ResourceGroup    string `mapstructure:"resource_group"`
 
 	// フィルタ設定
-	IncludePattern string `mapstructure:"include_pattern"`
-	ExcludePattern string `mapstructure:"exclude_pattern"`
+	IncludePattern    string                    `mapstructure:"include_pattern"`
+	ExcludePattern    string                    `mapstructure:"exclude_pattern"`
+	IgnoreErrorsOn    string                    `mapstructure:"ignore_errors_on"`
+	ProfileExclusions string                    `mapstructure:"profile_exclusions"`
+	ExclusionProfiles map[string]filter.Profile `mapstructure:"exclusion_profiles"`
 
 	// 動作設定
-	Recursive         bool `mapstructure:"recursive"`
-	Mirror            bool `mapstructure:"mirror"`
-	DryRun            bool `mapstructure:"dry_run"`
-	Verbose           bool `mapstructure:"verbose"`
-	SkipNewer         bool `mapstructure:"skip_newer"`
-	NoProgress        bool `mapstructure:"no_progress"`
-	PreserveModTime   bool `mapstructure:"preserve_mod_time"`
-	OverwriteExisting bool `mapstructure:"overwrite_existing"`
+	Recursive           bool   `mapstructure:"recursive"`
+	Mirror              bool   `mapstructure:"mirror"`
+	DryRun              bool   `mapstructure:"dry_run"`
+	Verbose             bool   `mapstructure:"verbose"`
+	SkipNewer           bool   `mapstructure:"skip_newer"`
+	Conflict            string `mapstructure:"conflict"`
+	CatchUpPasses       int    `mapstructure:"catch_up_passes"`
+	DetectSourceChanges bool   `mapstructure:"detect_source_changes"`
+	CopyOrder           string `mapstructure:"copy_order"`
+	MetadataOnlyUpdates bool   `mapstructure:"metadata_only_updates"`
+	CaseRenames         bool   `mapstructure:"case_renames"`
+	NoProgress          bool   `mapstructure:"no_progress"`
+	TUI                 bool   `mapstructure:"tui"`
+	StatusListen        string `mapstructure:"status_listen"`
+	BWLimit             string `mapstructure:"bwlimit"`
+	BWLimitSchedule     string `mapstructure:"bwlimit_schedule"`
+	Transform           string `mapstructure:"transform"`
+	ReloadConfig        bool   `mapstructure:"reload_config"`
+	PreserveModTime     bool   `mapstructure:"preserve_mod_time"`
+	OverwriteExisting   bool   `mapstructure:"overwrite_existing"`
+	CopyEmptyDirs       bool   `mapstructure:"copy_empty_dirs"`
+	IncludeHidden       bool   `mapstructure:"include_hidden"`
+	IncludeSystem       bool   `mapstructure:"include_system"`
+	MetaSidecar         bool   `mapstructure:"meta_sidecar"`
+	PreserveDirTimes    bool   `mapstructure:"preserve_dir_times"`
+	PreservePermissions bool   `mapstructure:"preserve_permissions"`
+	PermissionErrors    string `mapstructure:"permission_errors"`
+	Chmod               string `mapstructure:"chmod"`
+	Chown               string `mapstructure:"chown"`
+	SourceUser          string `mapstructure:"source_user"`
+	DestUser            string `mapstructure:"dest_user"`
+	ConnectShares       bool   `mapstructure:"connect_shares"`
+	Flatten             bool   `mapstructure:"flatten"`
+	FlattenRename       string `mapstructure:"flatten_rename"`
+	StructureOnly       bool   `mapstructure:"structure_only"`
+	StructureFiles      string `mapstructure:"structure_files"`
 
 	// 同期設定
 	SyncMode      string `mapstructure:"sync_mode"`
 	SyncDBPath    string `mapstructure:"sync_db_path"`
 	IncludeFailed bool   `mapstructure:"include_failed"`
 	MaxFailCount  int    `mapstructure:"max_fail_count"`
+	DBQueueSize   int    `mapstructure:"db_queue_size"`
+
+	// 同じ入力での再実行の省略
+	Idempotent        bool `mapstructure:"idempotent"`
+	IdempotentSamples int  `mapstructure:"idempotent_samples"`
+
+	// セッションのラベルとタグ
+	Label string            `mapstructure:"label"`
+	Tags  map[string]string `mapstructure:"tags"`
 
 	// 検証設定
-	VerifyOnly    bool   `mapstructure:"verify_only"`
-	VerifyChanged bool   `mapstructure:"verify_changed"`
-	VerifyAll     bool   `mapstructure:"verify_all"`
-	FinalReport   string `mapstructure:"final_report"`
+	VerifyOnly        bool                      `mapstructure:"verify_only"`
+	VerifyVia         string                    `mapstructure:"verify_via"`
+	VerifyChanged     bool                      `mapstructure:"verify_changed"`
+	VerifyAll         bool                      `mapstructure:"verify_all"`
+	VerifyWorkers     int                       `mapstructure:"verify_workers"`
+	VerifySubtrees    int                       `mapstructure:"verify_subtrees"`
+	SubtreeSummary    string                    `mapstructure:"subtree_summary"`
+	VerifyRetries     int                       `mapstructure:"verify_retries"`
+	VerifyRetryWait   string                    `mapstructure:"verify_retry_wait"`
+	VerifyResumeChunk string                    `mapstructure:"verify_resume_chunk"`
+	DropCache         bool                      `mapstructure:"drop_cache"`
+	VerifyMtime       string                    `mapstructure:"verify_mtime"`
+	VerifyThreshold   string                    `mapstructure:"verify_threshold"`
+	PreserveAtime     bool                      `mapstructure:"preserve_atime"`
+	StampXattr        bool                      `mapstructure:"stamp_xattr"`
+	FinalReport       string                    `mapstructure:"final_report"`
+	SummaryJSON       string                    `mapstructure:"summary_json"`
+	FailedFilesOut    string                    `mapstructure:"failed_files_out"`
+	FailedFilesFormat string                    `mapstructure:"failed_files_format"`
+	FolderStats       int                       `mapstructure:"folder_stats"`
+	Slowest           int                       `mapstructure:"slowest"`
+	DetailedReport    bool                      `mapstructure:"detailed_report"`
+	SuppressAcked     bool                      `mapstructure:"suppress_acknowledged"`
+	AuditLog          string                    `mapstructure:"audit_log"`
+	Plugins           []string                  `mapstructure:"plugins"`
+	Backends          map[string]plugin.Backend `mapstructure:"backends"`
+	ExtrasAction      string                    `mapstructure:"extras_action"`
+	ExtrasRules       []string                  `mapstructure:"extras_rules"`
+	QuarantineDir     string                    `mapstructure:"quarantine_dir"`
+	DeleteMaxFiles    int                       `mapstructure:"delete_max_files"`
+	DeleteMaxSize     string                    `mapstructure:"delete_max_size"`
 
 	// ハッシュ設定
 	HashAlgorithm string `mapstructure:"hash_algorithm"`
@@ -142,7 +331,8 @@ var rootCmd = &cobra.Command{
 			execPath, err := os.Executable()
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "実行ファイルパスの取得エラー: %v\n", err)
-				os.Exit(1)
+				runExitCode = 1
+				return
 			}
 			execDir := filepath.Dir(execPath)
 			configPath := filepath.Join(execDir, ".gopier.yaml")
@@ -150,7 +340,8 @@ var rootCmd = &cobra.Command{
 
 			if err := createDefaultConfig(configPath); err != nil {
 				fmt.Fprintf(os.Stderr, "設定ファイル作成エラー: %v\n", err)
-				os.Exit(1)
+				runExitCode = 1
+				return
 			}
 
 			fmt.Printf("設定ファイルを作成しました: %s\n", configPath)
@@ -169,6 +360,38 @@ var rootCmd = &cobra.Command{
 			return
 		}
 
+		// 追加の宛先はコピー元・主宛先と重複できない
+		for _, extra := range extraDests {
+			if filepath.Clean(extra) == filepath.Clean(destDir) || filepath.Clean(extra) == filepath.Clean(sourceDir) {
+				fmt.Fprintf(os.Stderr, "追加の宛先(%s)はコピー元・宛先と異なるディレクトリを指定してください\n", extra)
+				runExitCode = 1
+				return
+			}
+		}
+
+		// 宛先のファイルを削除する場合は、ルートやソースと重なる宛先などの明らかな誤りを拒否する
+		if deletesFromDest() && !iKnowWhatIAmDoing {
+			if err := checkDangerousDestinations(sourceDir, append([]string{destDir}, extraDests...)); err != nil {
+				fmt.Fprintf(os.Stderr, "%v。宛先のファイルを削除する設定（--mirror/--extras-action delete）では実行できません（--i-know-what-i-am-doingで確認を省略できます）\n", err)
+				runExitCode = 1
+				return
+			}
+		}
+
+		// 所有者の指定は名前の誤りをコピーを始める前に検出する
+		owner, err := fsmeta.ParseOwner(chownSpec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "所有者の変更の設定エラー: %v\n", err)
+			runExitCode = 1
+			return
+		}
+
+		// アクセス権の保持・所有者の変更には管理者権限が必要なため、コピーを始める前に確認する
+		if (preservePermissions || owner != nil) && !dryRun && !verifyOnly && !elevate.IsElevated() {
+			runExitCode = requestElevation(elevateRun)
+			return
+		}
+
 		// デフォルトのワーカー数はCPUコア数
 		if numWorkers <= 0 {
 			numWorkers = runtime.NumCPU()
@@ -178,19 +401,305 @@ var rootCmd = &cobra.Command{
 		log := logger.NewLogger(logFile, verbose, !noProgress)
 		defer log.Close()
 
+		// 設定を誤ってもホストを停止させないよう、処理を始める前に自身の使用量を制限する
+		if err := applyResourceLimits(log); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			runExitCode = 1
+			return
+		}
+
 		// フィルターの設定
 		fileFilter := filter.NewFilter(includePattern, excludePattern)
+		profile, err := filter.ResolveProfiles(profileExclusions, exclusionProfiles)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "設定エラー: %v\n", err)
+			runExitCode = 1
+			return
+		}
+		fileFilter.AddProfile(profile)
+
+		// プラグインの起動（フィルタ・宛先のストレージはコピーの設定より先に組み込む）
+		defer closePlugins(log)
+		if err := startPlugins(log, fileFilter); err != nil {
+			fmt.Fprintf(os.Stderr, "プラグインエラー: %v\n", err)
+			closePlugins(log)
+			runExitCode = 1
+			return
+		}
 
 		// コピーオプションの設定
 		options := copier.DefaultOptions()
-		options.BufferSize = bufferSize * 1024 * 1024 // MBからバイトに変換
+		if options.BufferSize, err = parseBufferSize(bufferSize); err != nil {
+			fmt.Fprintf(os.Stderr, "--bufferの指定が不正です: %v\n", err)
+			runExitCode = 1
+			return
+		}
 		options.Recursive = recursive
 		options.MaxRetries = retryCount
-		options.RetryDelay = time.Duration(retryWait) * time.Second
+		if options.RetryDelay, err = parseWait(retryWait, retryWaitUnit); err != nil {
+			fmt.Fprintf(os.Stderr, "--waitの指定が不正です: %v\n", err)
+			runExitCode = 1
+			return
+		}
+		options.DeferRetries = deferRetries
+		options.SharingRetries = sharingRetries
+		if options.SharingRetryDelay, err = parseWait(sharingWait, sharingWaitUnit); err != nil {
+			fmt.Fprintf(os.Stderr, "--sharing-waitの指定が不正です: %v\n", err)
+			runExitCode = 1
+			return
+		}
+		options.MismatchRetries = verifyRetries
+		if options.MismatchRetryDelay, err = parseWait(verifyRetryWait, verifyRetryWaitUnit); err != nil {
+			fmt.Fprintf(os.Stderr, "--verify-retry-waitの指定が不正です: %v\n", err)
+			runExitCode = 1
+			return
+		}
+		if dropCache && !vfs.UncachedSupported {
+			fmt.Fprintf(os.Stderr, "--drop-cacheはこの環境では対応していません（LinuxとWindowsのみ）\n")
+			runExitCode = 1
+			return
+		}
+		options.DropCache = dropCache
+		if options.ModTimePrecision, err = verifier.ParseModTimePrecision(verifyMtime); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			runExitCode = 1
+			return
+		}
+		options.PreserveAtime = preserveAtime
+		if gradeThresholds, err = runsummary.ParseThresholds(verifyThreshold); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			runExitCode = 1
+			return
+		}
+		options.RetryLocked = retryLocked
 		options.MaxConcurrent = numWorkers
-		options.OverwriteExisting = !skipNewer
+		options.SkipNewer = skipNewer
 		options.CreateDirs = true
 		options.VerifyHash = verifyChanged || verifyAll
+		options.CopyEmptyDirs = copyEmptyDirs
+		options.IncludeHidden = includeHidden
+		options.IncludeSystem = includeSystem
+		options.MetaSidecar = metaSidecar
+		options.IgnoreErrorsOn = ignoreErrorsOn
+		options.PreserveDirTimes = preserveDirTimes
+		options.PreservePermissions = preservePermissions
+		options.Flatten = flatten
+		options.FlattenRename = copier.FlattenRename(flattenRename)
+		options.StructureOnly = structureOnly
+		options.FS = pluginFS
+		limit, err := copier.ParseBandwidth(bwLimit)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			runExitCode = 1
+			return
+		}
+		options.BandwidthLimit = limit
+		if options.BandwidthSchedule, err = copier.ParseBandwidthSchedule(bwLimitSchedule); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			runExitCode = 1
+			return
+		}
+		if options.BandwidthSchedule.UsesPercent() && limit == 0 {
+			fmt.Fprintf(os.Stderr, "--bwlimit-scheduleで割合を指定する場合は--bwlimitで基本の帯域制限を指定してください\n")
+			runExitCode = 1
+			return
+		}
+		options.SegmentsPerFile = segments
+		options.ReadAhead = readAhead
+		options.FolderStatsDepth = folderStats
+		options.SlowestCount = slowestCount
+		options.DetailedRecords = detailedReport
+		if options.DedupCacheSize, err = units.ParseSize(dedupCache, units.Byte); err != nil {
+			fmt.Fprintf(os.Stderr, "キャッシュサイズの指定が不正です: %v\n", err)
+			runExitCode = 1
+			return
+		}
+		if options.DedupMaxFileSize, err = units.ParseSize(dedupMaxFile, units.Byte); err != nil {
+			fmt.Fprintf(os.Stderr, "キャッシュの対象サイズの指定が不正です: %v\n", err)
+			runExitCode = 1
+			return
+		}
+		if options.SegmentThreshold, err = units.ParseSize(segmentThreshold, units.Byte); err != nil {
+			fmt.Fprintf(os.Stderr, "分割コピーの対象サイズの指定が不正です: %v\n", err)
+			runExitCode = 1
+			return
+		}
+		if options.BatchThreshold, err = units.ParseSize(batchSmallFiles, units.Byte); err != nil {
+			fmt.Fprintf(os.Stderr, "まとめて書き込む対象サイズの指定が不正です: %v\n", err)
+			runExitCode = 1
+			return
+		}
+		if options.BatchSize, err = units.ParseSize(batchSize, units.Byte); err != nil {
+			fmt.Fprintf(os.Stderr, "セグメントのサイズの指定が不正です: %v\n", err)
+			runExitCode = 1
+			return
+		}
+		if options.ResumeInterval, err = units.ParseSize(resumeInterval, units.Byte); err != nil {
+			fmt.Fprintf(os.Stderr, "再開用のトークンを記録する間隔の指定が不正です: %v\n", err)
+			runExitCode = 1
+			return
+		}
+		if !validPermissionErrors(permissionErrors) {
+			fmt.Fprintf(os.Stderr, "--permission-errorsにはfail, warnのいずれかを指定してください: %s\n", permissionErrors)
+			runExitCode = 1
+			return
+		}
+		if options.Conflict, err = copier.ParseConflictAction(conflict); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			runExitCode = 1
+			return
+		}
+		if options.Conflict == copier.ConflictError {
+			// 衝突をエラーとする場合は、宛先の方が新しいかどうかを常に確認する
+			options.SkipNewer = true
+		}
+		options.CatchUpPasses = catchUpPasses
+		options.DetectSourceChanges = detectChanges
+		if options.CopyOrder, err = copier.ParseCopyOrder(copyOrder); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			runExitCode = 1
+			return
+		}
+		options.MetadataOnlyUpdates = metadataOnly
+		options.CaseRenames = caseRenames
+		if options.StructureFiles, err = copier.ParseStructureFiles(structureFiles); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			runExitCode = 1
+			return
+		}
+		if _, err := parseTags(sessionTags); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			runExitCode = 1
+			return
+		}
+		if faults, err = faultinject.Parse(faultInject); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			runExitCode = 1
+			return
+		}
+		if faults != nil {
+			options.Faults = faults
+			log.Warn("障害注入が有効です（--fault-inject %s）。動作確認以外では使用しないでください", faultInject)
+		}
+		if structureOnly && (verifyOnly || verifyChanged || verifyAll) {
+			// 内容のないファイルは検証で必ず不一致になる
+			fmt.Fprintf(os.Stderr, "--structure-onlyは検証オプションと同時に指定できません\n")
+			runExitCode = 1
+			return
+		}
+		options.ExtraDestinations = extraDests
+		if err := runsummary.ValidateListFormat(failedFilesFormat); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			runExitCode = 1
+			return
+		}
+		if filesFrom != "" {
+			if options.FileList, err = loadFileList(filesFrom); err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				runExitCode = 1
+				return
+			}
+			log.Info("ファイル一覧のパスのみをコピーします: %d件（%s）", len(options.FileList), filesFrom)
+		}
+		if changeJournal {
+			// 前回の実行の位置をDBに記録する
+			if syncMode == "" || syncDBPath == "" {
+				fmt.Fprintf(os.Stderr, "--change-journalには--dbの指定が必要です\n")
+				runExitCode = 1
+				return
+			}
+			if filesFrom != "" {
+				fmt.Fprintf(os.Stderr, "--change-journalは--files-fromと同時に指定できません\n")
+				runExitCode = 1
+				return
+			}
+		}
+		if idempotent {
+			// 前回の実行のマニフェストをDBに記録する
+			if syncMode == "" || syncDBPath == "" {
+				fmt.Fprintf(os.Stderr, "--idempotentには--dbの指定が必要です\n")
+				runExitCode = 1
+				return
+			}
+			// ソースと宛先のパス・サイズが対応しないため、抜き取り確認ができない
+			if filesFrom != "" || flatten || structureOnly || transformSpec != "" || options.BatchThreshold > 0 {
+				fmt.Fprintf(os.Stderr, "--idempotentは--files-from, --flatten, --structure-only, --transform, --batch-small-filesと同時に指定できません\n")
+				runExitCode = 1
+				return
+			}
+			if idempotentSamples < 0 {
+				fmt.Fprintf(os.Stderr, "--idempotent-samplesには0以上の値を指定してください\n")
+				runExitCode = 1
+				return
+			}
+		}
+		if options.Transforms, err = transform.Parse(transformSpec); err != nil {
+			fmt.Fprintf(os.Stderr, "変換の設定エラー: %v\n", err)
+			runExitCode = 1
+			return
+		}
+		options.Owner = owner
+		if options.Chmod, err = chmod.Parse(chmodSpec); err != nil {
+			fmt.Fprintf(os.Stderr, "アクセス権の変更の設定エラー: %v\n", err)
+			runExitCode = 1
+			return
+		}
+		// ネットワーク共有の資格情報の期限切れや切断に実行の途中で気付くことがないよう、先に確認する
+		shares, err := prepareShares(log)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "事前確認エラー: %v\n", err)
+			runExitCode = errcode.ExitCode(err)
+			return
+		}
+		defer shares.Close(log)
+		if !shares.source {
+			if options.SourceIdentity, err = openIdentity(sourceUser, "GOPIER_SOURCE_PASSWORD", sourceDir); err != nil {
+				fmt.Fprintf(os.Stderr, "ソースの資格情報エラー: %v\n", err)
+				runExitCode = 1
+				return
+			}
+			if options.SourceIdentity != nil {
+				defer options.SourceIdentity.Close()
+			}
+		}
+		if !shares.dest {
+			if options.DestIdentity, err = openIdentity(destUser, "GOPIER_DEST_PASSWORD", destDir); err != nil {
+				fmt.Fprintf(os.Stderr, "宛先の資格情報エラー: %v\n", err)
+				runExitCode = 1
+				return
+			}
+			if options.DestIdentity != nil {
+				defer options.DestIdentity.Close()
+			}
+		}
+		if flatten && (verifyChanged || verifyAll) {
+			// フラット化した宛先はソースと構造が異なるため、コピーと同時に検証する
+			// 検証用の経路はコピーを始める前に確認する
+			if err := prepareVerifyVia(log); err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				runExitCode = 1
+				return
+			}
+			options.Mode = copier.ModeCopyAndVerify
+			options.VerifyVia = verifyVia
+			options.VerifyConcurrent = verifyWorkers
+		}
+		// 検証する場合は検証で一致したファイルに記録し、コピーではソースのハッシュを改めて計算しない
+		options.StampXattr = stampXattr && (!(verifyChanged || verifyAll) || options.Mode == copier.ModeCopyAndVerify)
+		if options.BatchThreshold > 0 {
+			// セグメントの中の位置はDBに記録するため、DBが必要
+			if syncMode == "" || syncDBPath == "" {
+				fmt.Fprintf(os.Stderr, "--batch-small-filesには--syncと--dbの指定が必要です\n")
+				runExitCode = 1
+				return
+			}
+			// セグメントにはソースの内容をそのまま書き込み、宛先ごとのファイルの属性は設定しない
+			if flatten || structureOnly || len(extraDests) > 0 || transformSpec != "" || chownSpec != "" || chmodSpec != "" {
+				fmt.Fprintf(os.Stderr, "--batch-small-filesは--flatten, --structure-only, --extra-dest, --transform, --chown, --chmodと同時に指定できません\n")
+				runExitCode = 1
+				return
+			}
+		}
 
 		// データベースの初期化（同期モードが指定されている場合）
 		var syncDB *database.SyncDB
@@ -203,100 +712,538 @@ var rootCmd = &cobra.Command{
 			case "incremental":
 				syncModeEnum = database.IncrementalSync
 			}
+			dbLock, err := lockDatabase(syncDBPath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				runExitCode = errcode.ExitCode(err)
+				return
+			}
+			defer dbLock.Release()
 			syncDB, err = database.NewSyncDB(syncDBPath, syncModeEnum)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "データベース初期化エラー: %v\n", err)
-				os.Exit(1)
+				runExitCode = errcode.ExitCode(err)
+				return
 			}
 			defer syncDB.Close()
+			syncDB.SetSessionLabel(sessionLabel, sessionTagMap())
+			syncDB.SetSessionPaths(sessionPaths())
 		}
 
+		startRunSummary()
+		notifyPlugins(log, "start", runSummary)
+
+		// 監査ログを開く（追記専用で、ローテーションは行わない）
+		if err := openAuditLog(); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			runExitCode = 1
+			return
+		}
+		defer closeAuditLog(log)
+		options.Audit = auditLog
+
 		// 検証のみモードの場合
 		if verifyOnly {
-			verifierOptions := verifier.DefaultOptions()
-			verifierOptions.Recursive = recursive
-			verifierOptions.MaxConcurrent = numWorkers
-			verifierOptions.BufferSize = bufferSize * 1024 * 1024
+			if err := prepareVerifyVia(log); err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				runExitCode = 1
+				return
+			}
+			verifierOptions, err := newVerifierOptions(log, syncDB)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "検証オプションエラー: %v\n", err)
+				runExitCode = 1
+				return
+			}
 
-			v := verifier.NewVerifier(sourceDir, destDir, verifierOptions, fileFilter, syncDB)
+			v := verifier.NewVerifier(sourceDir, verifyDestination(), verifierOptions, fileFilter, syncDB)
 
 			if verifyAll {
+				if err := watchSubtrees(log, v); err != nil {
+					fmt.Fprintf(os.Stderr, "%v\n", err)
+					runExitCode = 1
+					return
+				}
 				// すべてのファイルを検証（最終検証）
 				log.Info("すべてのファイルのハッシュ検証を開始します...")
-				if err := v.Verify(); err != nil {
+				err := finishVerification(log, v, v.Verify())
+				if err != nil {
 					fmt.Fprintf(os.Stderr, "検証中にエラーが発生しました: %v\n", err)
-					os.Exit(1)
+					runExitCode = errcode.ExitCode(err)
+					return
 				}
 				// レポート生成
 				if finalReport != "" {
 					if err := v.GenerateReport(finalReport); err != nil {
 						fmt.Fprintf(os.Stderr, "レポート生成エラー: %v\n", err)
-						os.Exit(1)
+						runExitCode = 1
+						return
 					}
 				}
 			} else {
-				// 変更されたファイルのみ検証
+				// 直近のコピーセッションで同期したファイルのみ検証
 				log.Info("変更されたファイルのハッシュ検証を開始します...")
-				if err := v.Verify(); err != nil {
+				err := finishVerification(log, v, verifyChangedFiles(v, syncDB, 0, log))
+				if err != nil {
 					fmt.Fprintf(os.Stderr, "検証中にエラーが発生しました: %v\n", err)
-					os.Exit(1)
+					runExitCode = errcode.ExitCode(err)
+					return
 				}
 			}
 			return
 		}
 
+		// 前回の実行から入力が変わっていなければコピーを省略する
+		idempotency, upToDate := checkIdempotency(log, syncDB, fileFilter)
+		if upToDate {
+			return
+		}
+		defer saveIdempotency(log, syncDB, idempotency)
+
+		// 前回の実行以降に変更されたファイルのみをコピーする
+		journalCursor := prepareChangeJournal(log, syncDB, &options)
+
 		// コピー実行
 		fileCopier := copier.NewFileCopier(sourceDir, destDir, options, fileFilter, syncDB, log)
-		err := fileCopier.CopyFiles()
+
+		// 大量のデータをコピーした後で失敗しないよう、宛先で必要な操作ができるかを先に確認する
+		if !dryRun {
+			if err := fileCopier.Preflight(); err != nil {
+				fmt.Fprintf(os.Stderr, "事前確認エラー: %v\n", err)
+				// DB・監査ログ・ネットワーク共有の接続を閉じてから、読み取り専用などの終了コードで終了する
+				runExitCode = errcode.ExitCode(err)
+				return
+			}
+		}
+
+		// ライブダッシュボードの表示（表示中はコンソールへのログ出力を抑止する）
+		var dashboard *tui.Dashboard
+		if tuiMode {
+			log.SetConsoleEnabled(false)
+			dashboard = tui.NewDashboard(fileCopier.GetStats(), fileCopier, os.Stdin, os.Stdout, "gopier: "+sourceDir+" -> "+destDir, numWorkers)
+			dashboard.Start()
+		}
+
+		// ステータスAPIの開始
+		var statusServer *status.Server
+		if statusListen != "" {
+			statusServer = status.NewServer(fileCopier, status.JobInfo{
+				Source:      sourceDir,
+				Destination: destDir,
+				Mode:        syncMode,
+				Workers:     numWorkers,
+				Label:       sessionLabel,
+				Tags:        sessionTagMap(),
+			})
+			// 操作用エンドポイントはトークンが指定された場合のみ有効にする
+			if controlToken == "" {
+				controlToken = os.Getenv("GOPIER_CONTROL_TOKEN")
+			}
+			if controlToken != "" {
+				statusServer.EnableControl(fileCopier, controlToken)
+			}
+			if err := statusServer.Start(statusListen); err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				runExitCode = 1
+				return
+			}
+			defer statusServer.Close()
+			log.Info("ステータスAPIを開始しました: %s", statusListen)
+		}
+
+		// 設定ファイルの変更を監視（実行中に適用するのは帯域制限のみ）
+		var reloader *configReloader
+		if reloadConfig && viper.ConfigFileUsed() != "" {
+			var current Config
+			if err := viper.Unmarshal(&current, configDecodeHook()); err == nil {
+				reloader = newConfigReloader(viper.ConfigFileUsed(), current, func(old, new *Config) {
+					applyReloadedConfig(cmd, fileCopier, log, old, new)
+				}, log)
+				reloader.Start()
+			}
+		}
+
+		if syncDB != nil {
+			syncDB.EnableWriteQueue(dbQueueSize, func(err error) {
+				log.Error("データベース書き込みエラー: %v", err)
+			})
+		}
+
+		copyStart := time.Now()
+		err = fileCopier.CopyFiles()
+		var copyGrade *runsummary.Grade
+		if options.Mode == copier.ModeCopyAndVerify {
+			copyGrade = gradeVerification(log, fileCopier.GetVerificationSummary())
+		}
+		recordCopySummary(log, fileCopier, time.Since(copyStart))
+		reportFaults(log)
+		if reloader != nil {
+			reloader.Stop()
+		}
+		if syncDB != nil {
+			// キューに積まれた書き込みを反映し、以降は同期的に書き込む
+			if qs := syncDB.DisableWriteQueue(); qs != nil {
+				logWriteQueueStats(log, qs)
+			}
+		}
+		if dashboard != nil {
+			dashboard.Stop()
+			log.SetConsoleEnabled(true)
+		}
+		if statusServer != nil {
+			statusServer.Finish(err)
+		}
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "コピー中にエラーが発生しました: %v\n", err)
-			os.Exit(1)
+			runExitCode = errcode.ExitCode(err)
+			return
+		}
+		saveChangeJournal(log, syncDB, journalCursor)
+		// 一部のファイルのコピーに失敗した場合は、検証などを終えた後に終了コードで知らせる
+		runExitCode = gradedCopyExitCode(copyGrade, fileCopier.GetFailures(), fileCopier.GetPermFailures(), permissionErrors)
+
+		// 宛先ごとの結果の報告
+		if results := fileCopier.GetTargetResults(); len(results) > 0 {
+			fmt.Printf("\n宛先別の結果:\n")
+			for _, r := range results {
+				state := "完了"
+				if !r.Complete() {
+					state = "未完了"
+				}
+				fmt.Printf("  %s [%s]: コピー %d件, スキップ %d件, 失敗 %d件\n", r.Destination, state, r.Copied, r.Skipped, r.Failed)
+			}
+		}
+
+		// フォルダごとの結果の報告
+		if results := fileCopier.GetFolderResults(); len(results) > 0 {
+			printFolderResults(os.Stdout, results)
+		}
+
+		// 処理時間の長いファイル・ディレクトリの報告
+		printSlowest(os.Stdout, fileCopier.GetStats())
+
+		// 名前の大文字・小文字の変更の報告
+		printCaseRenames(os.Stdout, fileCopier.GetCaseRenames())
+
+		// 追いかけコピーの報告
+		printCatchUp(os.Stdout, fileCopier.GetCatchUpResult())
+
+		// コピー中のソースの変化の報告
+		printSourceDrift(os.Stdout, fileCopier.GetSourceDrift())
+
+		// 使用中のファイルの報告
+		printLockedFiles(os.Stdout, fileCopier.GetLockedFiles())
+
+		// アクセス権をコピーできなかったファイルの報告
+		printPermFailures(os.Stdout, fileCopier.GetPermFailures())
+
+		// メタデータのみ更新したファイルの報告
+		if updated := fileCopier.GetStats().GetMetaUpdatedCount(); updated > 0 {
+			fmt.Printf("\nメタデータのみ更新したファイル: %d件（内容が同じため更新日時・アクセス権のみ適用、スキップに含む）\n", updated)
+		}
+
+		// 再検証で一致したファイルの報告
+		if intermittent := fileCopier.GetVerificationSummary().Intermittent; intermittent > 0 {
+			fmt.Printf("\n再検証で一致したファイル（一時的な不一致）: %d件（読み込み経路が不安定な可能性があります）\n", intermittent)
+		}
+
+		// 共有違反による再試行の報告
+		if retries := fileCopier.GetStats().GetSharingRetries(); retries > 0 {
+			fmt.Printf("\n共有違反による再試行: %d回（ウイルス対策ソフトなどがファイルを使用中）\n", retries)
+		}
+
+		// エラーを無視したファイルの報告
+		if ignored := fileCopier.GetStats().GetIgnoredCount(); ignored > 0 {
+			fmt.Printf("\nエラーを無視したファイル: %d件（--ignore-errors-on）\n", ignored)
+		}
+
+		// 内容のキャッシュの利用状況の報告
+		if dedup := fileCopier.GetDedupStats(); dedup.Hits > 0 {
+			fmt.Printf("\nキャッシュから書き込んだファイル: %d件（読み込みを省略: %d bytes）\n", dedup.Hits, dedup.BytesSaved)
+		}
+
+		// 宛先の方が新しかったファイルの報告
+		if conflicts := fileCopier.GetConflicts(); len(conflicts) > 0 {
+			action := "スキップ"
+			if options.Conflict == copier.ConflictError {
+				action = "失敗"
+			}
+			fmt.Printf("\n宛先の方が新しいファイル: %d件（%s）\n", len(conflicts), action)
+			for _, c := range conflicts {
+				fmt.Printf("  %s [%s] ソース: %s, 宛先: %s\n", c.Path, c.Destination,
+					c.SourceTime.Format(time.RFC3339), c.DestTime.Format(time.RFC3339))
+			}
+		}
+
+		// 属性によって除外したファイルの報告
+		if excluded := fileCopier.GetExcludedCounts(); excluded.Hidden > 0 || excluded.System > 0 {
+			fmt.Printf("\n除外した隠しファイル: %d件, システムファイル: %d件\n", excluded.Hidden, excluded.System)
+		}
+
+		// フラット化時のファイル名衝突の報告
+		if collisions := fileCopier.GetFlattenCollisions(); len(collisions) > 0 {
+			fmt.Printf("\nファイル名の衝突: %d件\n", len(collisions))
+			for _, c := range collisions {
+				if c.DestName == "" {
+					fmt.Printf("  %s (既存: %s) -> スキップ\n", c.SourcePath, c.ClaimedBy)
+				} else {
+					fmt.Printf("  %s (既存: %s) -> %s\n", c.SourcePath, c.ClaimedBy, c.DestName)
+				}
+			}
+		}
+
+		// 構造のみ作成した場合の報告
+		if structureOnly {
+			fmt.Printf("\n内容のないファイルを作成: %d件（--structure-only、ファイルの内容はコピーしていません）\n", fileCopier.GetStats().GetCopiedCount())
+		}
+
+		// フラット化時はコピーと同時に検証済み
+		if flatten {
+			return
+		}
+		if verifyChanged || verifyAll {
+			if err := prepareVerifyVia(log); err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				runExitCode = 1
+				return
+			}
 		}
 
 		// コピー後に変更されたファイルのみ検証
 		if verifyChanged {
 			log.Info("同期したファイルのハッシュ検証を開始します...")
-			verifierOptions := verifier.DefaultOptions()
-			verifierOptions.Recursive = recursive
-			verifierOptions.MaxConcurrent = numWorkers
-			verifierOptions.BufferSize = bufferSize * 1024 * 1024
+			verifierOptions, err := newVerifierOptions(log, syncDB)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "検証オプションエラー: %v\n", err)
+				runExitCode = 1
+				return
+			}
 
-			v := verifier.NewVerifier(sourceDir, destDir, verifierOptions, fileFilter, syncDB)
-			if err := v.Verify(); err != nil {
+			v := verifier.NewVerifier(sourceDir, verifyDestination(), verifierOptions, fileFilter, syncDB)
+			err = finishVerification(log, v, verifyChangedFiles(v, syncDB, fileCopier.GetSessionID(), log))
+			if err != nil {
 				fmt.Fprintf(os.Stderr, "検証中にエラーが発生しました: %v\n", err)
-				os.Exit(1)
+				runExitCode = errcode.ExitCode(err)
+				return
 			}
 		}
 
 		// すべてのファイルを検証（最終検証）
 		if verifyAll {
 			log.Info("すべてのファイルのハッシュ検証を開始します...")
-			verifierOptions := verifier.DefaultOptions()
-			verifierOptions.Recursive = recursive
-			verifierOptions.MaxConcurrent = numWorkers
-			verifierOptions.BufferSize = bufferSize * 1024 * 1024
+			verifierOptions, err := newVerifierOptions(log, syncDB)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "検証オプションエラー: %v\n", err)
+				runExitCode = 1
+				return
+			}
 
-			v := verifier.NewVerifier(sourceDir, destDir, verifierOptions, fileFilter, syncDB)
-			if err := v.Verify(); err != nil {
+			v := verifier.NewVerifier(sourceDir, verifyDestination(), verifierOptions, fileFilter, syncDB)
+			if err := watchSubtrees(log, v); err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				runExitCode = 1
+				return
+			}
+			err = finishVerification(log, v, v.Verify())
+			if err != nil {
 				fmt.Fprintf(os.Stderr, "検証中にエラーが発生しました: %v\n", err)
-				os.Exit(1)
+				runExitCode = errcode.ExitCode(err)
+				return
 			}
 			// レポート生成
 			if finalReport != "" {
 				if err := v.GenerateReport(finalReport); err != nil {
 					fmt.Fprintf(os.Stderr, "レポート生成エラー: %v\n", err)
-					os.Exit(1)
+					runExitCode = 1
+					return
 				}
 			}
 		}
 	},
 }
 
+// applyReloadedConfig は再読み込みした設定のうち、帯域制限を適用する
+// フィルタ・コピー元・宛先は走査やコピーの途中で変えると結果が一貫しないため、変更を警告するのみとする
+// コマンドラインで指定された値は設定ファイルより優先されるため変更しない
+func applyReloadedConfig(cmd *cobra.Command, fc *copier.FileCopier, log *logger.Logger, old, new *Config) {
+	if new.BWLimit != old.BWLimit && !cmd.Flags().Changed("bwlimit") {
+		// 妥当性は再読み込み時に検証済み
+		limit, _ := copier.ParseBandwidth(new.BWLimit)
+		fc.SetBandwidthLimit(limit)
+		log.Info("帯域制限を変更しました: %q -> %q", old.BWLimit, new.BWLimit)
+	}
+	if new.BWLimitSchedule != old.BWLimitSchedule && !cmd.Flags().Changed("bwlimit-schedule") {
+		// 妥当性は再読み込み時に検証済み
+		schedule, _ := copier.ParseBandwidthSchedule(new.BWLimitSchedule)
+		fc.SetBandwidthSchedule(schedule)
+		log.Info("時刻ごとの帯域制限を変更しました: %q -> %q", old.BWLimitSchedule, new.BWLimitSchedule)
+	}
+
+	if new.IncludePattern != old.IncludePattern || new.ExcludePattern != old.ExcludePattern ||
+		new.Source != old.Source || new.Destination != old.Destination ||
+		!slices.Equal(new.ExtraDestinations, old.ExtraDestinations) {
+		log.Warn("フィルタ・コピー元・宛先の変更は実行中のコピーには適用されません（次回の実行から反映されます）")
+	}
+}
+
+// loadFileList は--files-fromのファイル一覧を読み込む（-の場合は標準入力）
+func loadFileList(path string) ([]string, error) {
+	if path == "-" {
+		return copier.ReadFileList(os.Stdin)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("ファイル一覧のオープンエラー: %w", err)
+	}
+	defer file.Close()
+	return copier.ReadFileList(file)
+}
+
+// finishVerification は検証の結果を判定して実行結果に記録し、--ignore-errors-onに一致したため
+// 失敗として扱わなかった検証結果の件数をログに出力する
+// 判定した場合は、その結果を反映した検証のエラーを返す
+func finishVerification(log *logger.Logger, v *verifier.Verifier, err error) error {
+	grade := gradeVerification(log, v.GetGradingSummary())
+	recordVerifySummary(log, v)
+	reportFaults(log)
+	if ignored := v.GetIgnoredCount(); ignored > 0 {
+		log.Warn("エラーを無視した検証結果: %d件（--ignore-errors-on）", ignored)
+	}
+	if suppressed := v.GetSuppressedCount(); suppressed > 0 {
+		log.Info("確認済みの不一致として報告しなかった検証結果: %d件（--suppress-acknowledged）", suppressed)
+	}
+	if intermittent := v.GetSummary().Intermittent; intermittent > 0 {
+		log.Warn("再検証で一致したファイル（一時的な不一致）: %d件。読み込み経路が不安定な可能性があります（--verify-retries）", intermittent)
+	}
+	return gradedError(err, grade)
+}
+
+// logWriteQueueStats はDB書き込みキューの統計情報をログに出力する
+func logWriteQueueStats(log *logger.Logger, qs *database.WriteQueueStats) {
+	log.Debug("DB書き込みキュー: 書き込み %d件, トランザクション %d回, 最大待ち %d/%d件, 失敗 %d件",
+		qs.Committed+qs.Failed, qs.Batches, qs.MaxDepth, qs.Capacity, qs.Failed)
+	if qs.Blocked > 0 {
+		log.Warn("DB書き込みキューが満杯になり、ワーカーが%d回（合計%s）待機しました。db_queue_sizeを増やすと改善する場合があります",
+			qs.Blocked, qs.BlockedTime.Truncate(time.Millisecond))
+	}
+}
+
+// verifyChangedFiles は指定されたコピーセッションで同期したファイルのみを検証する
+// sessionIDが0の場合はデータベース上の最新のコピーセッションを対象とする
+// データベースが使用できない場合はすべてのファイルを検証する
+func verifyChangedFiles(v *verifier.Verifier, syncDB *database.SyncDB, sessionID int64, log *logger.Logger) error {
+	if syncDB == nil {
+		log.Warn("同期データベースが無効なため、すべてのファイルを検証します")
+		return v.Verify()
+	}
+
+	if sessionID == 0 {
+		session, err := syncDB.GetLatestSession(database.SessionCopy)
+		if err != nil {
+			return fmt.Errorf("セッション情報の取得エラー: %w", err)
+		}
+		if session == nil {
+			log.Info("コピーセッションの記録がないため、検証対象のファイルはありません")
+			return nil
+		}
+		sessionID = session.ID
+	}
+
+	files, err := syncDB.GetFilesBySession(sessionID)
+	if err != nil {
+		return fmt.Errorf("ファイル情報の取得エラー: %w", err)
+	}
+
+	var targets []database.FileInfo
+	for _, file := range files {
+		switch file.Status {
+		case database.StatusSuccess, database.StatusVerified, database.StatusIntermittent, database.StatusMismatch:
+			targets = append(targets, file)
+		}
+	}
+
+	log.Info("検証対象: %dファイル（セッション %d）", len(targets), sessionID)
+	return v.VerifyFiles(targets)
+}
+
+// newVerifierOptions はフラグの値から検証オプションを構築する
+// --suppress-acknowledgedを指定した場合は、確認済みの不一致を同期状態データベースから読み込む
+func newVerifierOptions(log *logger.Logger, syncDB *database.SyncDB) (verifier.Options, error) {
+	options := verifier.DefaultOptions()
+	options.Recursive = recursive
+	options.MaxConcurrent = numWorkers
+	options.SubtreeWorkers = verifySubtrees
+
+	var err error
+	if options.BufferSize, err = parseBufferSize(bufferSize); err != nil {
+		return options, fmt.Errorf("--bufferの指定が不正です: %w", err)
+	}
+	action, err := verifier.ParseExtrasAction(extrasAction)
+	if err != nil {
+		return options, err
+	}
+	options.ExtrasAction = action
+	if options.ExtrasRules, err = verifier.ParseExtrasRules(extrasRules); err != nil {
+		return options, err
+	}
+	options.QuarantineDir = quarantineDir
+	if options.ConfirmDelete, err = newDeleteConfirmation(log); err != nil {
+		return options, err
+	}
+	options.IncludeHidden = includeHidden
+	options.IncludeSystem = includeSystem
+	options.MetaSidecar = metaSidecar
+	options.IgnoreErrorsOn = ignoreErrorsOn
+	options.Faults = faults
+	options.Audit = auditLog
+	options.MismatchRetries = verifyRetries
+	if options.MismatchRetryDelay, err = parseWait(verifyRetryWait, verifyRetryWaitUnit); err != nil {
+		return options, fmt.Errorf("--verify-retry-waitの指定が不正です: %w", err)
+	}
+	if options.ResumeChunkSize, err = parseResumeChunk(verifyResumeChunk); err != nil {
+		return options, fmt.Errorf("--verify-resume-chunkの指定が不正です: %w", err)
+	}
+	options.DropCache = dropCache
+	if options.ModTimePrecision, err = verifier.ParseModTimePrecision(verifyMtime); err != nil {
+		return options, err
+	}
+	options.StampXattr = stampXattr
+	options.DetailedReport = detailedReport
+	options.Logger = log
+	options.FS = pluginFS
+	if verifyVia != "" {
+		// 検証用の経路から読み込む場合も、余分なファイルの削除・隔離とハッシュの記録は実際の宛先に対して行う
+		options.ActionDest = destDir
+	}
+	if options.Owner, err = fsmeta.ParseOwner(chownSpec); err != nil {
+		return options, err
+	}
+	if options.Transforms, err = transform.Parse(transformSpec); err != nil {
+		return options, err
+	}
+	if suppressAcked {
+		if syncDB == nil {
+			return options, fmt.Errorf("--suppress-acknowledgedには同期状態データベースが必要です")
+		}
+		acks, err := syncDB.GetAcknowledgements()
+		if err != nil {
+			return options, fmt.Errorf("確認済みの不一致の読み込みに失敗しました: %w", err)
+		}
+		options.Suppress = isAcknowledged(acks)
+	}
+
+	return options, nil
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
-		os.Exit(1)
+		os.Exit(errcode.ExitError)
+	}
+	if runExitCode != errcode.ExitOK {
+		os.Exit(runExitCode)
 	}
 }
 
@@ -307,6 +1254,8 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "設定ファイル (デフォルト: $HOME/.gopier.yaml)")
 	rootCmd.PersistentFlags().Bool("create-config", false, "デフォルトの設定ファイルを作成")
 	rootCmd.PersistentFlags().Bool("show-config", false, "現在の設定値を表示")
+	rootCmd.PersistentFlags().StringVar(&workDir, "workdir", "", "相対パスの基準にする作業ディレクトリ（ソース・宛先・DB・ログなどの相対パスに適用）")
+	rootCmd.PersistentFlags().Var(units.NewDurationValue(&waitForLock, 0, 0), "wait-for-lock", "同じデータベースを使用する他の実行の終了を待つ時間（例: 10m、負の値は無期限）")
 	rootCmd.PersistentFlags().Bool("version", false, "バージョン情報を表示")
 
 	// 基本オプション
@@ -315,26 +1264,112 @@ func init() {
 	rootCmd.Flags().StringVarP(&logFile, "log", "l", "", "ログファイルのパス")
 	rootCmd.Flags().IntVarP(&numWorkers, "workers", "w", runtime.NumCPU(), "並列ワーカー数")
 	rootCmd.Flags().IntVarP(&retryCount, "retry", "r", 3, "エラー時のリトライ回数")
-	rootCmd.Flags().IntVarP(&retryWait, "wait", "", 5, "リトライ間の待機時間（秒）")
+	rootCmd.Flags().StringVarP(&retryWait, "wait", "", "5s", "リトライ間の待機時間（例: 5s, 1m、単位を省略した場合は秒）")
+	rootCmd.Flags().BoolVarP(&deferRetries, "defer-retries", "", false, "失敗したファイルをすぐにリトライせず、他のファイルのコピーが終わった後にリトライ")
+	rootCmd.Flags().IntVarP(&sharingRetries, "sharing-retries", "", 5, "共有違反（ウイルス対策ソフトなどが使用中）の場合にリトライ回数とは別に再試行する回数（Windowsのみ）")
+	rootCmd.Flags().StringVarP(&sharingWait, "sharing-wait", "", "200ms", "共有違反の再試行の待機時間（例: 200ms, 1s、単位を省略した場合はミリ秒、50%から150%の範囲でばらつかせる）")
+	rootCmd.Flags().BoolVarP(&retryLocked, "retry-locked", "", false, "他のプロセスが使用中のファイルを、他のファイルのコピーが終わった後に再試行")
 	rootCmd.Flags().StringVarP(&includePattern, "include", "i", "", "含めるファイルパターン（例: *.txt,*.docx）")
 	rootCmd.Flags().StringVarP(&excludePattern, "exclude", "e", "", "除外するファイルパターン（例: *.tmp,*.bak）")
+	rootCmd.Flags().StringVar(&profileExclusions, "profile-exclusions", "", "有効にする除外プロファイル（カンマ区切り: "+strings.Join(filter.ProfileNames(), ", ")+"）")
+	rootCmd.Flags().StringVarP(&ignoreErrorsOn, "ignore-errors-on", "", "", "エラーを無視するパスのパターン（例: $RECYCLE.BIN,.snapshot、失敗は別に数えて終了コードに影響させない）")
 	rootCmd.Flags().BoolVarP(&mirror, "mirror", "m", false, "ミラーモード（宛先にない元ファイルを削除）")
 	rootCmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "ドライラン（実際にはコピーしない）")
 	rootCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "詳細なログ出力")
 	rootCmd.Flags().BoolVarP(&skipNewer, "skip-newer", "", false, "宛先の方が新しい場合はスキップ")
+	rootCmd.Flags().StringVarP(&conflict, "conflict", "", "skip", "宛先の方が新しいファイルの扱い (skip, error、errorの場合は--skip-newerなしでも確認)")
+	rootCmd.Flags().StringVarP(&copyOrder, "copy-order", "", "walk", "ファイルをコピーする順序 (walk: 走査した順, newest-first: 更新日時の新しい順)")
+	rootCmd.Flags().IntVarP(&catchUpPasses, "catch-up-passes", "", 0, "コピー中に変更されたファイルを終了前に再コピーする最大の回数（0は再コピーしない）")
+	rootCmd.Flags().BoolVarP(&detectChanges, "detect-source-changes", "", false, "開始時と終了時にソースを走査し、コピー中のソースの変化（ファイル数・サイズ・更新日時）を報告")
+	rootCmd.Flags().BoolVarP(&caseRenames, "case-renames", "", false, "名前の大文字・小文字のみ変わったファイル・ディレクトリを、宛先で古い名前から名前を変更して反映")
+	rootCmd.Flags().BoolVarP(&metadataOnly, "metadata-only-updates", "", false, "更新日時のみ異なるファイルのサイズとハッシュが同じ場合は、内容をコピーせず更新日時とアクセス権のみ更新")
 	rootCmd.Flags().BoolVarP(&noProgress, "no-progress", "", false, "進捗表示を無効化")
-	rootCmd.Flags().IntVarP(&bufferSize, "buffer", "b", 8, "バッファサイズ（MB）")
+	rootCmd.Flags().BoolVarP(&tuiMode, "tui", "", false, "実行中の状況をライブダッシュボードで表示（キー操作で一時停止・中断・エラーのスクロール）")
+	rootCmd.Flags().StringVarP(&statusListen, "status-listen", "", "", "実行中の状況をJSONで公開するステータスAPIの待ち受けアドレス（例: :8080）")
+	rootCmd.Flags().StringVarP(&controlToken, "control-token", "", "", "ステータスAPIの操作用エンドポイントの認証トークン（環境変数 GOPIER_CONTROL_TOKEN でも指定可）")
+	rootCmd.Flags().StringVarP(&bwLimit, "bwlimit", "", "", "帯域制限（例: 512K, 10M、0は無制限）")
+	rootCmd.Flags().StringVarP(&bwLimitSchedule, "bwlimit-schedule", "", "", "時刻ごとの帯域制限（例: \"22:00-06:00=100%,*=20%\"、割合は--bwlimitに対する値）")
+	rootCmd.Flags().StringVarP(&transformSpec, "transform", "", "", "拡張子・MIMEタイプごとにコピー時の内容を変換（例: \".jpg,.jpeg=strip-exif;text/*=lf\"）")
+	rootCmd.Flags().BoolVarP(&reloadConfig, "reload-config", "", false, "実行中に設定ファイルの変更を検出して再読み込み（適用するのは帯域制限のみ、フィルタ・コピー元・宛先は次回の実行から反映）")
+	rootCmd.Flags().StringVarP(&filesFrom, "files-from", "", "", "ソースを走査せず、一覧のパスのみをコピー（1行に1つの相対パス、NUL区切りも可、-は標準入力）")
+	rootCmd.Flags().BoolVarP(&changeJournal, "change-journal", "", false, "前回の実行以降に変更されたファイルのみをNTFSの変更ジャーナル（USN）から取得してコピー（Windowsのみ、--dbが必要）")
+	rootCmd.Flags().StringSliceVarP(&extraDests, "extra-dest", "", nil, "追加の宛先ディレクトリ（複数指定可、ソースを一度だけ読み込んですべての宛先に書き込む）")
+	rootCmd.Flags().StringVarP(&bufferSize, "buffer", "b", "8M", "バッファサイズ（例: 512K, 8M、単位を省略した場合はMB）")
+	rootCmd.Flags().IntVarP(&segments, "segments", "", 1, "巨大なファイルを分割して並行にコピーする数（1は分割しない）")
+	rootCmd.Flags().IntVarP(&readAhead, "read-ahead", "", 4, "読み込みと書き込みを重ねる場合の先読みするチャンク数（0で同期的にコピー）")
+	rootCmd.Flags().StringVarP(&dedupCache, "dedup-cache", "", "", "同じ内容のファイルをメモリから書き込むためのキャッシュのサイズ（例: 256M、空または0で無効、内容のハッシュで見分ける）")
+	rootCmd.Flags().StringVarP(&dedupMaxFile, "dedup-max-file", "", "1M", "キャッシュの対象とするファイルサイズの上限")
+	rootCmd.Flags().IntVarP(&maxProcs, "max-procs", "", 0, "gopierが使用するCPU数の上限（GOMAXPROCS、0は制限しない）")
+	rootCmd.Flags().StringVarP(&maxMemory, "max-memory", "", "", "gopierのメモリ使用量の上限（例: 2G、--resource-groupを指定した場合はOSが強制）")
+	rootCmd.Flags().StringVarP(&ioLimit, "io-limit", "", "", "ソース・宛先のディスクごとの読み書きの上限（例: 50M、Linuxで--resource-groupが必要）")
+	rootCmd.Flags().StringVarP(&resourceGroup, "resource-group", "", "", "自身を所属させて上限を強制するリソースグループ（Linuxでは/sys/fs/cgroup配下のcgroup、WindowsではJob Objectの名前）")
+	rootCmd.Flags().StringVarP(&segmentThreshold, "segment-threshold", "", "1G", "分割コピーの対象とする最小のファイルサイズ（例: 512M, 1G）")
+	rootCmd.Flags().StringVarP(&batchSmallFiles, "batch-small-files", "", "", "指定したサイズ以下のファイルをtar形式のセグメントにまとめて書き込む（例: 64K、空または0で無効、--syncと--dbが必要）")
+	rootCmd.Flags().StringVarP(&batchSize, "batch-size", "", "64M", "--batch-small-filesで1つのセグメントにまとめる合計サイズの目安")
+	rootCmd.Flags().StringVarP(&resumeInterval, "resume-interval", "", "64M", "再開に対応したプラグインのストレージで、中断した書き込みを再開するためのトークンを記録する間隔（0で無効、--dbが必要）")
 	rootCmd.Flags().BoolVarP(&recursive, "recursive", "R", true, "サブディレクトリを再帰的にコピー")
+	rootCmd.Flags().BoolVarP(&copyEmptyDirs, "copy-empty-dirs", "", true, "空のディレクトリもコピー")
+	rootCmd.Flags().BoolVarP(&includeHidden, "include-hidden", "", true, "隠しファイル・ディレクトリ（ドットファイルを含む）をコピー")
+	rootCmd.Flags().BoolVarP(&metaSidecar, "meta-sidecar", "", false, "所有者・ACL・拡張属性・更新日時をディレクトリごとの.gopier.metaに保存（FATなどメタデータを保持できない宛先向け）")
+	rootCmd.Flags().BoolVarP(&includeSystem, "include-system", "", true, "システム属性のファイル・ディレクトリをコピー（Windowsのみ）")
+	rootCmd.Flags().BoolVarP(&preserveDirTimes, "preserve-dir-times", "", true, "ディレクトリの更新日時を保持")
+	rootCmd.Flags().BoolVarP(&preservePermissions, "preserve-permissions", "", false, "パーミッション・所有者（WindowsではACL）を保持（管理者権限が必要）")
+	rootCmd.Flags().StringVarP(&permissionErrors, "permission-errors", "", "fail", "内容はコピーしたがアクセス権をコピーできなかったファイルの扱い (fail: 終了コード5, warn: 警告のみ)")
+	rootCmd.Flags().StringVarP(&chownSpec, "chown", "", "", "宛先のファイル・ディレクトリの所有者を変更（\"ユーザー:グループ\"・\"ユーザー\"・\":グループ\"、Unix系OSのみ、root権限が必要、検証でも比較）")
+	rootCmd.Flags().StringVarP(&chmodSpec, "chmod", "", "", "宛先のファイル・ディレクトリのアクセス権を変更（例: \"D755,F644\"・\"Dgo+rx,Fgo-w\"、rsyncの--chmodと同じ形式）")
+	rootCmd.Flags().StringVarP(&sourceUser, "source-user", "", "", "ソースにアクセスするユーザー（例: DOMAIN\\user、パスワードは環境変数 GOPIER_SOURCE_PASSWORD）")
+	rootCmd.Flags().StringVarP(&destUser, "dest-user", "", "", "宛先にアクセスするユーザー（例: DOMAIN\\user、パスワードは環境変数 GOPIER_DEST_PASSWORD）")
+	rootCmd.Flags().BoolVarP(&connectShares, "connect-shares", "", false, "ソース・宛先のネットワーク共有（UNCパス・切断されたネットワークドライブ）に--source-user/--dest-userの資格情報で接続し、終了時に切断する（Windowsのみ）")
+	rootCmd.Flags().BoolVarP(&elevateRun, "elevate", "", false, "--preserve-permissionsに管理者権限が必要な場合、管理者として起動し直す（Unix系OSではsudoコマンドを表示）")
+	rootCmd.Flags().BoolVarP(&flatten, "flatten", "", false, "すべてのファイルを宛先ディレクトリ直下にコピー")
+	rootCmd.Flags().StringVarP(&flattenRename, "flatten-rename", "", "counter", "フラット化時のファイル名衝突の解決方法 (counter, hash, skip)")
+	rootCmd.Flags().BoolVarP(&structureOnly, "structure-only", "", false, "ファイルの内容をコピーせず、ディレクトリ構造と内容のないファイルのみ作成")
+	rootCmd.Flags().StringVarP(&structureFiles, "structure-files", "", "sized", "構造のみ作成する場合のファイルの作成方法 (sized:ソースと同じサイズ, empty:サイズ0)")
 
 	// 同期モード関連のフラグ
 	rootCmd.Flags().StringVarP(&syncMode, "mode", "", "normal", "同期モード (initial:初期同期, incremental:追加同期)")
 	rootCmd.Flags().StringVarP(&syncDBPath, "db", "", "sync_state.db", "同期状態データベースのパス")
 	rootCmd.Flags().BoolVarP(&verifyOnly, "verify-only", "", false, "コピーせずに検証のみを実行")
+	rootCmd.Flags().StringVarP(&verifyVia, "verify-via", "", "", "検証時に宛先を読み込む別の経路（例: SMBでコピーした宛先をNFSのマウントから検証）")
 	rootCmd.Flags().BoolVarP(&verifyChanged, "verify-changed", "", false, "同期したファイルのみハッシュ検証を実行")
 	rootCmd.Flags().BoolVarP(&verifyAll, "verify-all", "", false, "すべてのファイルのハッシュ検証を実行（最終検証）")
+	rootCmd.Flags().IntVarP(&verifyWorkers, "verify-workers", "", 0, "コピーと同時に検証する場合（--flatten）の検証の並行数（0はコピーのワーカーで続けて検証）")
+	rootCmd.Flags().IntVarP(&verifySubtrees, "verify-subtrees", "", 0, "--verify-allでソースの最上位のディレクトリを並行して検証する数（完了したディレクトリから結果を報告、0は報告しない）")
+	rootCmd.Flags().StringVarP(&subtreeSummary, "subtree-summary", "", "", "最上位のディレクトリの検証結果を完了するごとに追記するJSON Linesファイルのパス（--verify-subtrees）")
+	rootCmd.Flags().IntVarP(&verifyRetries, "verify-retries", "", 0, "ハッシュが一致しない場合にソースと宛先を読み直して再検証する回数（0は再検証しない）")
+	rootCmd.Flags().StringVarP(&verifyRetryWait, "verify-retry-wait", "", "1s", "再検証の前の待機時間（例: 500ms, 1s、単位を省略した場合はミリ秒）")
+	rootCmd.Flags().StringVarP(&verifyResumeChunk, "verify-resume-chunk", "", "1G", "これより大きいファイルの検証で、中断した位置から再開できるよう途中の状態をDBに記録する間隔（例: 1G、0は記録しない）")
+	rootCmd.Flags().BoolVarP(&dropCache, "drop-cache", "", false, "検証でキャッシュを経由せずにディスクから読み込んでハッシュ値を計算（Linux・Windowsのみ）")
+	rootCmd.Flags().StringVarP(&verifyMtime, "verify-mtime", "", "", "検証で更新日時も比較する精度（1ns, 100ns, 1s, 2sなど、空の場合は比較しない）")
+	rootCmd.Flags().StringVarP(&verifyThreshold, "verify-threshold", "", "", "検証結果の合格の基準（例: mismatched=0,missing=0.01%、超えた場合は終了コード4）")
+	rootCmd.Flags().BoolVarP(&preserveAtime, "preserve-atime", "", false, "更新日時に加えてソースのアクセス日時を宛先に保持")
+	rootCmd.Flags().BoolVarP(&stampXattr, "stamp-xattr", "", false, "宛先のファイルの拡張属性（WindowsではADS）にハッシュとコピーの日時を記録（verify --stampsで検証）")
 	rootCmd.Flags().BoolVarP(&includeFailed, "include-failed", "", true, "前回までに失敗したファイルも同期する")
 	rootCmd.Flags().IntVarP(&maxFailCount, "max-fail-count", "", 5, "最大失敗回数（これを超えるとスキップ、0は無制限）")
+	rootCmd.Flags().IntVarP(&dbQueueSize, "db-queue-size", "", 1024, "コピー中のDB書き込みキューの容量（0で無効）")
+	rootCmd.Flags().BoolVarP(&idempotent, "idempotent", "", false, "ソースのマニフェストが前回の実行と同じで、宛先の抜き取り確認で問題がなければコピーを省略（--dbが必要）")
+	rootCmd.Flags().IntVarP(&idempotentSamples, "idempotent-samples", "", 16, "--idempotentで宛先に存在するかを確認するファイル数")
+	rootCmd.Flags().StringVarP(&sessionLabel, "label", "", "", "セッションに付けるラベル（DB・実行結果・通知に記録、db sessions --labelで検索）")
+	rootCmd.Flags().StringArrayVarP(&sessionTags, "tag", "", nil, "セッションに記録するメタデータ（key=value、複数指定可）")
+	rootCmd.Flags().StringVarP(&faultInject, "fault-inject", "", "", "動作確認のために擬似的な障害を発生させる（例: read-error=0.01,slow=0.05,hash-mismatch=0.01,seed=1）")
+	rootCmd.Flags().MarkHidden("fault-inject")
 	rootCmd.Flags().StringVarP(&finalReport, "final-report", "", "", "最終検証レポートの出力パス")
+	rootCmd.Flags().StringVarP(&summaryJSON, "summary-json", "", "", "実行結果（件数・スループット・失敗したファイル）をJSONで保存するパス（report diffで比較）")
+	rootCmd.Flags().StringVarP(&failedFilesOut, "failed-files-out", "", "", "失敗したファイルの相対パスの一覧を保存するパス（--files-fromで再試行）")
+	rootCmd.Flags().StringVarP(&failedFilesFormat, "failed-files-format", "", "plain", "失敗したファイルの一覧の形式 (plain, null, csv)")
+	rootCmd.Flags().IntVarP(&folderStats, "folder-stats", "", 0, "結果をフォルダごとに集計して表示する階層（1は最上位のフォルダ、0は集計しない）")
+	rootCmd.Flags().IntVarP(&slowestCount, "slowest", "", 0, "処理時間の長いファイルとディレクトリを終了時に表示する件数（0は表示しない）")
+	rootCmd.Flags().BoolVarP(&detailedReport, "detailed-report", "", false, "ファイルごとの処理時間・再試行回数・ワーカー・スループットをDBと最終検証レポートに記録")
+	rootCmd.Flags().BoolVarP(&suppressAcked, "suppress-acknowledged", "", false, "db ackで確認済みとして登録したパスの検証の失敗を報告しない（終了コードと最終検証レポートにも含めない）")
+	rootCmd.Flags().StringArrayVarP(&pluginSpecs, "plugin", "", nil, "起動するプラグインのコマンドと引数（複数指定可、フィルタ・通知・宛先のストレージを追加）")
+	rootCmd.Flags().StringVarP(&auditLogPath, "audit-log", "", "", "完了した操作を追記専用のJSONLで記録する監査ログのパス（audit verifyで検証）")
+	rootCmd.Flags().StringVarP(&extrasAction, "extras-action", "", "report", "宛先にのみ存在するファイルの処理 (report, ignore, delete, move-to-quarantine)")
+	rootCmd.Flags().StringArrayVarP(&extrasRules, "extras-rule", "", nil, "パターンに一致する余分なファイルの処理（パターン=処理方法、複数指定可、最初に一致した規則を使用）")
+	rootCmd.Flags().StringVarP(&quarantineDir, "quarantine-dir", "", "", "move-to-quarantine時の隔離先ディレクトリ（デフォルト: <宛先>.quarantine）")
+	rootCmd.Flags().IntVarP(&deleteMaxFiles, "delete-max-files", "", 1000, "extras-action deleteで確認なしに削除するファイル数の上限（0は無制限）")
+	rootCmd.Flags().StringVarP(&deleteMaxSize, "delete-max-size", "", "10G", "extras-action deleteで確認なしに削除する合計サイズの上限（例: 10G、0は無制限）")
+	rootCmd.Flags().BoolVarP(&iKnowWhatIAmDoing, "i-know-what-i-am-doing", "", false, "宛先のファイルを削除する設定で、ルート・ホームディレクトリ・ソースと重なる宛先を拒否する確認を省略する")
+	rootCmd.Flags().BoolVarP(&confirmDelete, "confirm-delete", "", false, "削除するファイル数・サイズが上限を超えても確認せずに削除する")
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -374,6 +1409,12 @@ func initConfig() {
 	}
 
 	loadConfig(rootCmd)
+
+	// 相対パスは以降すべて作業ディレクトリを基準にする
+	if err := applyWorkDir(); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
 }
 
 // validateConfig は設定値の妥当性をチェックする
@@ -384,14 +1425,129 @@ func validateConfig(config *Config) error {
 	if config.Workers < 1 {
 		errors = append(errors, "workers: 1以上の値を指定してください")
 	}
-	if config.BufferSize < 1 {
+	if size, err := parseBufferSize(config.BufferSize); err != nil {
+		errors = append(errors, "buffer_size: "+err.Error())
+	} else if config.BufferSize != "" && size < 1 {
 		errors = append(errors, "buffer_size: 1以上の値を指定してください")
 	}
+	if config.ReadAhead < 0 {
+		errors = append(errors, "read_ahead: 0以上の値を指定してください")
+	}
+	if _, err := units.ParseSize(config.DedupCache, units.Byte); err != nil {
+		errors = append(errors, "dedup_cache: 256M, 1Gなどの形式で指定してください")
+	}
+	if _, err := units.ParseSize(config.DedupMaxFile, units.Byte); err != nil {
+		errors = append(errors, "dedup_max_file: 512K, 1Mなどの形式で指定してください")
+	}
+	if config.MaxProcs < 0 {
+		errors = append(errors, "max_procs: 0以上の値を指定してください")
+	}
+	if _, err := units.ParseSize(config.MaxMemory, units.Byte); err != nil {
+		errors = append(errors, "max_memory: 512M, 2Gなどの形式で指定してください")
+	}
+	if _, err := copier.ParseBandwidth(config.IOLimit); err != nil {
+		errors = append(errors, "io_limit: 512K, 10Mなどの形式で指定してください")
+	}
+	if config.Segments < 0 {
+		errors = append(errors, "segments: 0以上の値を指定してください")
+	}
+	if _, err := units.ParseSize(config.SegmentThreshold, units.Byte); err != nil {
+		errors = append(errors, "segment_threshold: 512M, 1Gなどの形式で指定してください")
+	}
+	if _, err := units.ParseSize(config.BatchSmallFiles, units.Byte); err != nil {
+		errors = append(errors, "batch_small_files: 64K, 1Mなどの形式で指定してください")
+	}
+	if _, err := units.ParseSize(config.BatchSize, units.Byte); err != nil {
+		errors = append(errors, "batch_size: 64M, 256Mなどの形式で指定してください")
+	}
+	if _, err := units.ParseSize(config.ResumeInterval, units.Byte); err != nil {
+		errors = append(errors, "resume_interval: 64M, 256Mなどの形式で指定してください")
+	}
+	if _, err := filter.ResolveProfiles(config.ProfileExclusions, config.ExclusionProfiles); err != nil {
+		errors = append(errors, "profile_exclusions: "+err.Error())
+	}
+	for name, backend := range config.Backends {
+		if err := backend.Validate(); err != nil {
+			errors = append(errors, fmt.Sprintf("backends.%s.%v", name, err))
+		}
+	}
 	if config.RetryCount < 0 {
 		errors = append(errors, "retry_count: 0以上の値を指定してください")
 	}
-	if config.RetryWait < 0 {
-		errors = append(errors, "retry_wait: 0以上の値を指定してください")
+	if _, err := parseWait(config.RetryWait, retryWaitUnit); err != nil {
+		errors = append(errors, "retry_wait: "+err.Error())
+	}
+	if config.SharingRetries < 0 {
+		errors = append(errors, "sharing_retries: 0以上の値を指定してください")
+	}
+	if _, err := parseWait(config.SharingWait, sharingWaitUnit); err != nil {
+		errors = append(errors, "sharing_wait: "+err.Error())
+	}
+
+	// フラット化設定の検証
+	if config.FlattenRename != "" && config.FlattenRename != "counter" && config.FlattenRename != "hash" && config.FlattenRename != "skip" {
+		errors = append(errors, "flatten_rename: counter, hash, skipのいずれかを指定してください")
+	}
+
+	// 構造のみ作成する設定の検証
+	if _, err := copier.ParseStructureFiles(config.StructureFiles); err != nil {
+		errors = append(errors, "structure_files: sized, emptyのいずれかを指定してください")
+	}
+	if config.StructureOnly && (config.VerifyOnly || config.VerifyChanged || config.VerifyAll) {
+		errors = append(errors, "structure_only: 検証（verify_only/verify_changed/verify_all）と同時に指定できません")
+	}
+
+	// 帯域制限の検証
+	if _, err := copier.ParseBandwidth(config.BWLimit); err != nil {
+		errors = append(errors, "bwlimit: 512K, 10Mなどの形式で指定してください")
+	}
+	if _, err := copier.ParseBandwidthSchedule(config.BWLimitSchedule); err != nil {
+		errors = append(errors, fmt.Sprintf("bwlimit_schedule: %v", err))
+	}
+
+	// 変換設定の検証
+	if _, err := transform.Parse(config.Transform); err != nil {
+		errors = append(errors, fmt.Sprintf("transform: %v", err))
+	}
+	if _, err := chmod.Parse(config.Chmod); err != nil {
+		errors = append(errors, fmt.Sprintf("chmod: %v", err))
+	}
+	if _, err := fsmeta.ParseOwner(config.Chown); err != nil {
+		errors = append(errors, fmt.Sprintf("chown: %v", err))
+	}
+
+	if config.PermissionErrors != "" && !validPermissionErrors(config.PermissionErrors) {
+		errors = append(errors, "permission_errors: fail, warnのいずれかを指定してください")
+	}
+	if _, err := copier.ParseConflictAction(config.Conflict); err != nil {
+		errors = append(errors, "conflict: skip, errorのいずれかを指定してください")
+	}
+	if config.CatchUpPasses < 0 {
+		errors = append(errors, "catch_up_passes: 0以上の値を指定してください")
+	}
+	if _, err := copier.ParseCopyOrder(config.CopyOrder); err != nil {
+		errors = append(errors, "copy_order: walk, newest-firstのいずれかを指定してください")
+	}
+	if config.VerifyWorkers < 0 {
+		errors = append(errors, "verify_workers: 0以上の値を指定してください")
+	}
+	if config.VerifySubtrees < 0 {
+		errors = append(errors, "verify_subtrees: 0以上の値を指定してください")
+	}
+	if config.VerifyRetries < 0 {
+		errors = append(errors, "verify_retries: 0以上の値を指定してください")
+	}
+	if _, err := parseWait(config.VerifyRetryWait, verifyRetryWaitUnit); err != nil {
+		errors = append(errors, "verify_retry_wait: "+err.Error())
+	}
+	if _, err := parseResumeChunk(config.VerifyResumeChunk); err != nil {
+		errors = append(errors, "verify_resume_chunk: "+err.Error())
+	}
+	if _, err := verifier.ParseModTimePrecision(config.VerifyMtime); err != nil {
+		errors = append(errors, "verify_mtime: 1ns, 100ns, 1s, 2sなどの形式で指定してください")
+	}
+	if _, err := runsummary.ParseThresholds(config.VerifyThreshold); err != nil {
+		errors = append(errors, fmt.Sprintf("verify_threshold: %v", err))
 	}
 
 	// 同期設定の検証
@@ -401,6 +1557,43 @@ func validateConfig(config *Config) error {
 	if config.MaxFailCount < 0 {
 		errors = append(errors, "max_fail_count: 0以上の値を指定してください")
 	}
+	if config.DBQueueSize < 0 {
+		errors = append(errors, "db_queue_size: 0以上の値を指定してください")
+	}
+	if config.IdempotentSamples < 0 {
+		errors = append(errors, "idempotent_samples: 0以上の値を指定してください")
+	}
+	for key := range config.Tags {
+		if strings.TrimSpace(key) == "" {
+			errors = append(errors, "tags: 空のキーは指定できません")
+			break
+		}
+	}
+
+	// 検証設定の検証
+	if _, err := verifier.ParseExtrasAction(config.ExtrasAction); err != nil {
+		errors = append(errors, "extras_action: report, ignore, delete, move-to-quarantineのいずれかを指定してください")
+	}
+	if _, err := verifier.ParseExtrasRules(config.ExtrasRules); err != nil {
+		errors = append(errors, fmt.Sprintf("extras_rules: %v", err))
+	}
+	if config.DeleteMaxFiles < 0 {
+		errors = append(errors, "delete_max_files: 0以上の値を指定してください")
+	}
+	if _, err := units.ParseSize(config.DeleteMaxSize, units.Byte); err != nil {
+		errors = append(errors, "delete_max_size: 1G, 500Mなどの形式で指定してください")
+	}
+	if config.FailedFilesFormat != "" {
+		if err := runsummary.ValidateListFormat(config.FailedFilesFormat); err != nil {
+			errors = append(errors, "failed_files_format: plain, null, csvのいずれかを指定してください")
+		}
+	}
+	if config.FolderStats < 0 {
+		errors = append(errors, "folder_stats: 0以上の値を指定してください")
+	}
+	if config.Slowest < 0 {
+		errors = append(errors, "slowest: 0以上の値を指定してください")
+	}
 
 	// ハッシュ設定の検証
 	if config.HashAlgorithm != "" {
@@ -431,7 +1624,7 @@ func loadConfig(cmd *cobra.Command) {
 	var config Config
 	if viper.ConfigFileUsed() != "" {
 		// 設定ファイルが存在する場合
-		if err := viper.Unmarshal(&config); err != nil {
+		if err := viper.Unmarshal(&config, configDecodeHook()); err != nil {
 			fmt.Fprintf(os.Stderr, "設定ファイルの解析エラー: %v\n", err)
 			return
 		}
@@ -439,32 +1632,65 @@ func loadConfig(cmd *cobra.Command) {
 		// 設定ファイルが存在しない場合はデフォルト値を設定
 		config = Config{
 			// パフォーマンス設定
-			Workers:    runtime.NumCPU(),
-			BufferSize: 8,
-			RetryCount: 3,
-			RetryWait:  5,
+			Workers:          runtime.NumCPU(),
+			BufferSize:       "8M",
+			RetryCount:       3,
+			RetryWait:        "5s",
+			SharingRetries:   5,
+			SharingWait:      "200ms",
+			VerifyRetryWait:  "1s",
+			DropCache:        false,
+			Segments:         1,
+			SegmentThreshold: "1G",
+			BatchSize:        "64M",
+			ResumeInterval:   "64M",
+			ReadAhead:        4,
+			DedupMaxFile:     "1M",
 
 			// 動作設定
-			Recursive:         true,
-			Mirror:            false,
-			DryRun:            false,
-			Verbose:           false,
-			SkipNewer:         false,
-			NoProgress:        false,
-			PreserveModTime:   true,
-			OverwriteExisting: true,
+			Recursive:           true,
+			Mirror:              false,
+			DryRun:              false,
+			Verbose:             false,
+			SkipNewer:           false,
+			Conflict:            "skip",
+			CopyOrder:           "walk",
+			NoProgress:          false,
+			PreserveModTime:     true,
+			OverwriteExisting:   true,
+			CopyEmptyDirs:       true,
+			IncludeHidden:       true,
+			IncludeSystem:       true,
+			MetaSidecar:         false,
+			PreserveDirTimes:    true,
+			PreservePermissions: false,
+			PermissionErrors:    "fail",
+			FlattenRename:       "counter",
+			StructureOnly:       false,
+			StructureFiles:      "sized",
 
 			// 同期設定
 			SyncMode:      "normal",
 			SyncDBPath:    "sync_state.db",
 			IncludeFailed: true,
 			MaxFailCount:  5,
+			DBQueueSize:   1024,
+			Label:         "",
+			Tags:          map[string]string{},
+
+			// 同じ入力での再実行の省略
+			IdempotentSamples: 16,
 
 			// 検証設定
-			VerifyOnly:    false,
-			VerifyChanged: false,
-			VerifyAll:     false,
-			FinalReport:   "",
+			VerifyOnly:        false,
+			VerifyChanged:     false,
+			VerifyAll:         false,
+			FinalReport:       "",
+			FailedFilesFormat: "plain",
+			ExtrasAction:      "report",
+			DeleteMaxFiles:    1000,
+			DeleteMaxSize:     "10G",
+			VerifyResumeChunk: "1G",
 
 			// ハッシュ設定
 			HashAlgorithm: "sha256",
@@ -485,12 +1711,24 @@ func loadConfig(cmd *cobra.Command) {
 // bindConfigToFlags は設定ファイルの値をフラグにバインドする
 func bindConfigToFlags(config *Config, cmd *cobra.Command) {
 	// 基本設定
+	if workDir == "" && config.WorkDir != "" {
+		workDir = configWorkDir(config.WorkDir, viper.ConfigFileUsed())
+	}
 	if sourceDir == "" && config.Source != "" {
 		sourceDir = config.Source
 	}
 	if destDir == "" && config.Destination != "" {
 		destDir = config.Destination
 	}
+	if !cmd.Flags().Changed("extra-dest") && len(config.ExtraDestinations) > 0 {
+		extraDests = config.ExtraDestinations
+	}
+	if filesFrom == "" && config.FilesFrom != "" {
+		filesFrom = config.FilesFrom
+	}
+	if !cmd.Flags().Changed("change-journal") && config.ChangeJournal {
+		changeJournal = config.ChangeJournal
+	}
 	if logFile == "" && config.LogFile != "" {
 		logFile = config.LogFile
 	}
@@ -499,15 +1737,63 @@ func bindConfigToFlags(config *Config, cmd *cobra.Command) {
 	if numWorkers <= 0 && config.Workers > 0 {
 		numWorkers = config.Workers
 	}
-	if bufferSize <= 0 && config.BufferSize > 0 {
+	if !cmd.Flags().Changed("buffer") && config.BufferSize != "" {
 		bufferSize = config.BufferSize
 	}
+	if !cmd.Flags().Changed("read-ahead") && viper.IsSet("read_ahead") {
+		readAhead = config.ReadAhead
+	}
+	if !cmd.Flags().Changed("dedup-cache") && config.DedupCache != "" {
+		dedupCache = config.DedupCache
+	}
+	if !cmd.Flags().Changed("dedup-max-file") && config.DedupMaxFile != "" {
+		dedupMaxFile = config.DedupMaxFile
+	}
+	if !cmd.Flags().Changed("max-procs") && config.MaxProcs > 0 {
+		maxProcs = config.MaxProcs
+	}
+	if maxMemory == "" && config.MaxMemory != "" {
+		maxMemory = config.MaxMemory
+	}
+	if ioLimit == "" && config.IOLimit != "" {
+		ioLimit = config.IOLimit
+	}
+	if resourceGroup == "" && config.ResourceGroup != "" {
+		resourceGroup = config.ResourceGroup
+	}
+	if !cmd.Flags().Changed("segments") && config.Segments > 0 {
+		segments = config.Segments
+	}
+	if !cmd.Flags().Changed("segment-threshold") && config.SegmentThreshold != "" {
+		segmentThreshold = config.SegmentThreshold
+	}
+	if !cmd.Flags().Changed("batch-small-files") && config.BatchSmallFiles != "" {
+		batchSmallFiles = config.BatchSmallFiles
+	}
+	if !cmd.Flags().Changed("batch-size") && config.BatchSize != "" {
+		batchSize = config.BatchSize
+	}
+	if !cmd.Flags().Changed("resume-interval") && config.ResumeInterval != "" {
+		resumeInterval = config.ResumeInterval
+	}
 	if retryCount <= 0 && config.RetryCount > 0 {
 		retryCount = config.RetryCount
 	}
-	if retryWait <= 0 && config.RetryWait > 0 {
+	if !cmd.Flags().Changed("wait") && config.RetryWait != "" {
 		retryWait = config.RetryWait
 	}
+	if !cmd.Flags().Changed("sharing-retries") && viper.IsSet("sharing_retries") {
+		sharingRetries = config.SharingRetries
+	}
+	if !cmd.Flags().Changed("sharing-wait") && config.SharingWait != "" {
+		sharingWait = config.SharingWait
+	}
+	if !cmd.Flags().Changed("defer-retries") && config.DeferRetries {
+		deferRetries = config.DeferRetries
+	}
+	if !cmd.Flags().Changed("retry-locked") && config.RetryLocked {
+		retryLocked = config.RetryLocked
+	}
 
 	// フィルタ設定
 	if includePattern == "" && config.IncludePattern != "" {
@@ -516,6 +1802,13 @@ func bindConfigToFlags(config *Config, cmd *cobra.Command) {
 	if excludePattern == "" && config.ExcludePattern != "" {
 		excludePattern = config.ExcludePattern
 	}
+	if ignoreErrorsOn == "" && config.IgnoreErrorsOn != "" {
+		ignoreErrorsOn = config.IgnoreErrorsOn
+	}
+	if profileExclusions == "" && config.ProfileExclusions != "" {
+		profileExclusions = config.ProfileExclusions
+	}
+	exclusionProfiles = config.ExclusionProfiles
 
 	// 動作設定
 	if !cmd.Flags().Changed("recursive") && config.Recursive {
@@ -533,9 +1826,93 @@ func bindConfigToFlags(config *Config, cmd *cobra.Command) {
 	if !cmd.Flags().Changed("skip-newer") && config.SkipNewer {
 		skipNewer = config.SkipNewer
 	}
+	if !cmd.Flags().Changed("conflict") && config.Conflict != "" {
+		conflict = config.Conflict
+	}
+	if !cmd.Flags().Changed("copy-order") && config.CopyOrder != "" {
+		copyOrder = config.CopyOrder
+	}
+	if !cmd.Flags().Changed("catch-up-passes") && viper.IsSet("catch_up_passes") {
+		catchUpPasses = config.CatchUpPasses
+	}
+	if !cmd.Flags().Changed("detect-source-changes") && config.DetectSourceChanges {
+		detectChanges = config.DetectSourceChanges
+	}
+	if !cmd.Flags().Changed("metadata-only-updates") && config.MetadataOnlyUpdates {
+		metadataOnly = config.MetadataOnlyUpdates
+	}
+	if !cmd.Flags().Changed("case-renames") && config.CaseRenames {
+		caseRenames = config.CaseRenames
+	}
 	if !cmd.Flags().Changed("no-progress") && config.NoProgress {
 		noProgress = config.NoProgress
 	}
+	if !cmd.Flags().Changed("tui") && config.TUI {
+		tuiMode = config.TUI
+	}
+	if !cmd.Flags().Changed("status-listen") && config.StatusListen != "" {
+		statusListen = config.StatusListen
+	}
+	if !cmd.Flags().Changed("bwlimit") && config.BWLimit != "" {
+		bwLimit = config.BWLimit
+	}
+	if !cmd.Flags().Changed("bwlimit-schedule") && config.BWLimitSchedule != "" {
+		bwLimitSchedule = config.BWLimitSchedule
+	}
+	if !cmd.Flags().Changed("transform") && config.Transform != "" {
+		transformSpec = config.Transform
+	}
+	if !cmd.Flags().Changed("reload-config") && config.ReloadConfig {
+		reloadConfig = config.ReloadConfig
+	}
+	if !cmd.Flags().Changed("copy-empty-dirs") && viper.IsSet("copy_empty_dirs") {
+		copyEmptyDirs = config.CopyEmptyDirs
+	}
+	if !cmd.Flags().Changed("include-hidden") && viper.IsSet("include_hidden") {
+		includeHidden = config.IncludeHidden
+	}
+	if !cmd.Flags().Changed("include-system") && viper.IsSet("include_system") {
+		includeSystem = config.IncludeSystem
+	}
+	if !cmd.Flags().Changed("meta-sidecar") && config.MetaSidecar {
+		metaSidecar = config.MetaSidecar
+	}
+	if !cmd.Flags().Changed("preserve-dir-times") && viper.IsSet("preserve_dir_times") {
+		preserveDirTimes = config.PreserveDirTimes
+	}
+	if !cmd.Flags().Changed("preserve-permissions") && config.PreservePermissions {
+		preservePermissions = true
+	}
+	if !cmd.Flags().Changed("permission-errors") && config.PermissionErrors != "" {
+		permissionErrors = config.PermissionErrors
+	}
+	if !cmd.Flags().Changed("chmod") && config.Chmod != "" {
+		chmodSpec = config.Chmod
+	}
+	if !cmd.Flags().Changed("chown") && config.Chown != "" {
+		chownSpec = config.Chown
+	}
+	if !cmd.Flags().Changed("source-user") && config.SourceUser != "" {
+		sourceUser = config.SourceUser
+	}
+	if !cmd.Flags().Changed("dest-user") && config.DestUser != "" {
+		destUser = config.DestUser
+	}
+	if !cmd.Flags().Changed("connect-shares") && config.ConnectShares {
+		connectShares = config.ConnectShares
+	}
+	if !cmd.Flags().Changed("flatten") && config.Flatten {
+		flatten = config.Flatten
+	}
+	if !cmd.Flags().Changed("flatten-rename") && config.FlattenRename != "" {
+		flattenRename = config.FlattenRename
+	}
+	if !cmd.Flags().Changed("structure-only") && config.StructureOnly {
+		structureOnly = config.StructureOnly
+	}
+	if !cmd.Flags().Changed("structure-files") && config.StructureFiles != "" {
+		structureFiles = config.StructureFiles
+	}
 
 	// 同期設定
 	if syncMode == "" && config.SyncMode != "" {
@@ -550,20 +1927,118 @@ func bindConfigToFlags(config *Config, cmd *cobra.Command) {
 	if maxFailCount <= 0 && config.MaxFailCount > 0 {
 		maxFailCount = config.MaxFailCount
 	}
+	if !cmd.Flags().Changed("db-queue-size") && viper.IsSet("db_queue_size") {
+		dbQueueSize = config.DBQueueSize
+	}
+	if !cmd.Flags().Changed("idempotent") && config.Idempotent {
+		idempotent = config.Idempotent
+	}
+	if !cmd.Flags().Changed("idempotent-samples") && viper.IsSet("idempotent_samples") {
+		idempotentSamples = config.IdempotentSamples
+	}
+	if sessionLabel == "" && config.Label != "" {
+		sessionLabel = config.Label
+	}
+	if !cmd.Flags().Changed("tag") && len(config.Tags) > 0 {
+		sessionTags = nil
+		for key, value := range config.Tags {
+			sessionTags = append(sessionTags, key+"="+value)
+		}
+		sort.Strings(sessionTags)
+	}
 
 	// 検証設定
 	if !cmd.Flags().Changed("verify-only") && config.VerifyOnly {
 		verifyOnly = config.VerifyOnly
 	}
+	if !cmd.Flags().Changed("verify-via") && config.VerifyVia != "" {
+		verifyVia = config.VerifyVia
+	}
 	if !cmd.Flags().Changed("verify-changed") && config.VerifyChanged {
 		verifyChanged = config.VerifyChanged
 	}
 	if !cmd.Flags().Changed("verify-all") && config.VerifyAll {
 		verifyAll = config.VerifyAll
 	}
+	if !cmd.Flags().Changed("verify-workers") && viper.IsSet("verify_workers") {
+		verifyWorkers = config.VerifyWorkers
+	}
+	if !cmd.Flags().Changed("verify-subtrees") && config.VerifySubtrees != 0 {
+		verifySubtrees = config.VerifySubtrees
+	}
+	if subtreeSummary == "" && config.SubtreeSummary != "" {
+		subtreeSummary = config.SubtreeSummary
+	}
+	if !cmd.Flags().Changed("verify-retries") && viper.IsSet("verify_retries") {
+		verifyRetries = config.VerifyRetries
+	}
+	if !cmd.Flags().Changed("verify-retry-wait") && config.VerifyRetryWait != "" {
+		verifyRetryWait = config.VerifyRetryWait
+	}
+	if !cmd.Flags().Changed("verify-resume-chunk") && config.VerifyResumeChunk != "" {
+		verifyResumeChunk = config.VerifyResumeChunk
+	}
+	if !cmd.Flags().Changed("drop-cache") && config.DropCache {
+		dropCache = config.DropCache
+	}
+	if !cmd.Flags().Changed("verify-mtime") && config.VerifyMtime != "" {
+		verifyMtime = config.VerifyMtime
+	}
+	if !cmd.Flags().Changed("verify-threshold") && config.VerifyThreshold != "" {
+		verifyThreshold = config.VerifyThreshold
+	}
+	if !cmd.Flags().Changed("preserve-atime") && config.PreserveAtime {
+		preserveAtime = config.PreserveAtime
+	}
+	if !cmd.Flags().Changed("stamp-xattr") && config.StampXattr {
+		stampXattr = config.StampXattr
+	}
 	if finalReport == "" && config.FinalReport != "" {
 		finalReport = config.FinalReport
 	}
+	if summaryJSON == "" && config.SummaryJSON != "" {
+		summaryJSON = config.SummaryJSON
+	}
+	if failedFilesOut == "" && config.FailedFilesOut != "" {
+		failedFilesOut = config.FailedFilesOut
+	}
+	if !cmd.Flags().Changed("failed-files-format") && config.FailedFilesFormat != "" {
+		failedFilesFormat = config.FailedFilesFormat
+	}
+	if !cmd.Flags().Changed("folder-stats") && viper.IsSet("folder_stats") {
+		folderStats = config.FolderStats
+	}
+	if !cmd.Flags().Changed("slowest") && viper.IsSet("slowest") {
+		slowestCount = config.Slowest
+	}
+	if !cmd.Flags().Changed("detailed-report") && config.DetailedReport {
+		detailedReport = config.DetailedReport
+	}
+	if !cmd.Flags().Changed("suppress-acknowledged") && config.SuppressAcked {
+		suppressAcked = config.SuppressAcked
+	}
+	if auditLogPath == "" && config.AuditLog != "" {
+		auditLogPath = config.AuditLog
+	}
+	if !cmd.Flags().Changed("plugin") && len(config.Plugins) > 0 {
+		pluginSpecs = config.Plugins
+	}
+	backends = config.Backends
+	if !cmd.Flags().Changed("extras-action") && config.ExtrasAction != "" {
+		extrasAction = config.ExtrasAction
+	}
+	if !cmd.Flags().Changed("extras-rule") && len(config.ExtrasRules) > 0 {
+		extrasRules = config.ExtrasRules
+	}
+	if quarantineDir == "" && config.QuarantineDir != "" {
+		quarantineDir = config.QuarantineDir
+	}
+	if !cmd.Flags().Changed("delete-max-files") && viper.IsSet("delete_max_files") {
+		deleteMaxFiles = config.DeleteMaxFiles
+	}
+	if !cmd.Flags().Changed("delete-max-size") && config.DeleteMaxSize != "" {
+		deleteMaxSize = config.DeleteMaxSize
+	}
 
 	// ハッシュ設定
 	if !cmd.Flags().Changed("verify-hash") && config.VerifyHash {
@@ -576,39 +2051,7 @@ func bindConfigToFlags(config *Config, cmd *cobra.Command) {
 
 // createDefaultConfig はデフォルトの設定ファイルを作成する
 func createDefaultConfig(configPath string) error {
-	config := Config{
-		// パフォーマンス設定
-		Workers:    runtime.NumCPU(),
-		BufferSize: 8,
-		RetryCount: 3,
-		RetryWait:  5,
-
-		// 動作設定
-		Recursive:         true,
-		Mirror:            false,
-		DryRun:            false,
-		Verbose:           false,
-		SkipNewer:         false,
-		NoProgress:        false,
-		PreserveModTime:   true,
-		OverwriteExisting: true,
-
-		// 同期設定
-		SyncMode:      "normal",
-		SyncDBPath:    "sync_state.db",
-		IncludeFailed: true,
-		MaxFailCount:  5,
-
-		// 検証設定
-		VerifyOnly:    false,
-		VerifyChanged: false,
-		VerifyAll:     false,
-		FinalReport:   "",
-
-		// ハッシュ設定
-		HashAlgorithm: "sha256",
-		VerifyHash:    true,
-	}
+	config := defaultConfig()
 
 	// 設定値の妥当性チェック
 	if err := validateConfig(&config); err != nil {
@@ -634,45 +2077,200 @@ func createDefaultConfig(configPath string) error {
 	return nil
 }
 
+// defaultConfig は設定ファイルを作成する際のデフォルトの設定値を返す
+func defaultConfig() Config {
+	return Config{
+		// パフォーマンス設定
+		Workers:          runtime.NumCPU(),
+		BufferSize:       "8M",
+		RetryCount:       3,
+		RetryWait:        "5s",
+		SharingRetries:   5,
+		SharingWait:      "200ms",
+		VerifyRetryWait:  "1s",
+		DropCache:        false,
+		Segments:         1,
+		SegmentThreshold: "1G",
+		BatchSize:        "64M",
+		ResumeInterval:   "64M",
+		ReadAhead:        4,
+		DedupMaxFile:     "1M",
+
+		// 動作設定
+		Recursive:           true,
+		Mirror:              false,
+		DryRun:              false,
+		Verbose:             false,
+		SkipNewer:           false,
+		Conflict:            "skip",
+		CopyOrder:           "walk",
+		NoProgress:          false,
+		PreserveModTime:     true,
+		OverwriteExisting:   true,
+		CopyEmptyDirs:       true,
+		IncludeHidden:       true,
+		IncludeSystem:       true,
+		MetaSidecar:         false,
+		PreserveDirTimes:    true,
+		PreservePermissions: false,
+		PermissionErrors:    "fail",
+		FlattenRename:       "counter",
+		StructureOnly:       false,
+		StructureFiles:      "sized",
+
+		// 同期設定
+		SyncMode:      "normal",
+		SyncDBPath:    "sync_state.db",
+		IncludeFailed: true,
+		MaxFailCount:  5,
+		DBQueueSize:   1024,
+		Label:         "",
+		Tags:          map[string]string{},
+
+		// 同じ入力での再実行の省略
+		IdempotentSamples: 16,
+
+		// 検証設定
+		VerifyOnly:        false,
+		VerifyChanged:     false,
+		VerifyAll:         false,
+		FinalReport:       "",
+		FailedFilesFormat: "plain",
+		ExtrasAction:      "report",
+		DeleteMaxFiles:    1000,
+		DeleteMaxSize:     "10G",
+		VerifyResumeChunk: "1G",
+
+		// ハッシュ設定
+		HashAlgorithm: "sha256",
+		VerifyHash:    true,
+	}
+}
+
 // showCurrentConfig は現在の設定値を表示する
 func showCurrentConfig() {
 	config := Config{
 		// 基本設定
-		Source:      sourceDir,
-		Destination: destDir,
-		LogFile:     logFile,
+		WorkDir:           workDir,
+		Source:            sourceDir,
+		Destination:       destDir,
+		ExtraDestinations: extraDests,
+		FilesFrom:         filesFrom,
+		ChangeJournal:     changeJournal,
+		LogFile:           logFile,
 
 		// パフォーマンス設定
-		Workers:    numWorkers,
-		BufferSize: bufferSize,
-		RetryCount: retryCount,
-		RetryWait:  retryWait,
+		Workers:          numWorkers,
+		BufferSize:       bufferSize,
+		RetryCount:       retryCount,
+		RetryWait:        retryWait,
+		DeferRetries:     deferRetries,
+		SharingRetries:   sharingRetries,
+		SharingWait:      sharingWait,
+		RetryLocked:      retryLocked,
+		Segments:         segments,
+		SegmentThreshold: segmentThreshold,
+		BatchSmallFiles:  batchSmallFiles,
+		BatchSize:        batchSize,
+		ResumeInterval:   resumeInterval,
+		ReadAhead:        readAhead,
+		DedupCache:       dedupCache,
+		DedupMaxFile:     dedupMaxFile,
+		MaxProcs:         maxProcs,
+		MaxMemory:        maxMemory,
+		IOLimit:          ioLimit,
+		ResourceGroup:    resourceGroup,
 
 		// フィルタ設定
-		IncludePattern: includePattern,
-		ExcludePattern: excludePattern,
+		IncludePattern:    includePattern,
+		ExcludePattern:    excludePattern,
+		IgnoreErrorsOn:    ignoreErrorsOn,
+		ProfileExclusions: profileExclusions,
+		ExclusionProfiles: exclusionProfiles,
 
 		// 動作設定
-		Recursive:         recursive,
-		Mirror:            mirror,
-		DryRun:            dryRun,
-		Verbose:           verbose,
-		SkipNewer:         skipNewer,
-		NoProgress:        noProgress,
-		PreserveModTime:   true, // デフォルト値
-		OverwriteExisting: !skipNewer,
+		Recursive:           recursive,
+		Mirror:              mirror,
+		DryRun:              dryRun,
+		Verbose:             verbose,
+		SkipNewer:           skipNewer,
+		Conflict:            conflict,
+		CatchUpPasses:       catchUpPasses,
+		DetectSourceChanges: detectChanges,
+		CopyOrder:           copyOrder,
+		MetadataOnlyUpdates: metadataOnly,
+		CaseRenames:         caseRenames,
+		NoProgress:          noProgress,
+		TUI:                 tuiMode,
+		StatusListen:        statusListen,
+		BWLimit:             bwLimit,
+		BWLimitSchedule:     bwLimitSchedule,
+		Transform:           transformSpec,
+		ReloadConfig:        reloadConfig,
+		PreserveModTime:     true, // デフォルト値
+		OverwriteExisting:   true,
+		CopyEmptyDirs:       copyEmptyDirs,
+		IncludeHidden:       includeHidden,
+		IncludeSystem:       includeSystem,
+		MetaSidecar:         metaSidecar,
+		PreserveDirTimes:    preserveDirTimes,
+		PreservePermissions: preservePermissions,
+		PermissionErrors:    permissionErrors,
+		Chmod:               chmodSpec,
+		Chown:               chownSpec,
+		SourceUser:          sourceUser,
+		DestUser:            destUser,
+		ConnectShares:       connectShares,
+		Flatten:             flatten,
+		FlattenRename:       flattenRename,
+		StructureOnly:       structureOnly,
+		StructureFiles:      structureFiles,
 
 		// 同期設定
 		SyncMode:      syncMode,
 		SyncDBPath:    syncDBPath,
 		IncludeFailed: includeFailed,
 		MaxFailCount:  maxFailCount,
+		DBQueueSize:   dbQueueSize,
+		Label:         sessionLabel,
+		Tags:          sessionTagMap(),
+
+		// 同じ入力での再実行の省略
+		Idempotent:        idempotent,
+		IdempotentSamples: idempotentSamples,
 
 		// 検証設定
-		VerifyOnly:    verifyOnly,
-		VerifyChanged: verifyChanged,
-		VerifyAll:     verifyAll,
-		FinalReport:   finalReport,
+		VerifyOnly:        verifyOnly,
+		VerifyVia:         verifyVia,
+		VerifyChanged:     verifyChanged,
+		VerifyAll:         verifyAll,
+		VerifyWorkers:     verifyWorkers,
+		VerifySubtrees:    verifySubtrees,
+		SubtreeSummary:    subtreeSummary,
+		VerifyRetries:     verifyRetries,
+		VerifyRetryWait:   verifyRetryWait,
+		VerifyResumeChunk: verifyResumeChunk,
+		DropCache:         dropCache,
+		VerifyMtime:       verifyMtime,
+		VerifyThreshold:   verifyThreshold,
+		PreserveAtime:     preserveAtime,
+		StampXattr:        stampXattr,
+		FinalReport:       finalReport,
+		SummaryJSON:       summaryJSON,
+		FailedFilesOut:    failedFilesOut,
+		FailedFilesFormat: failedFilesFormat,
+		FolderStats:       folderStats,
+		Slowest:           slowestCount,
+		DetailedReport:    detailedReport,
+		SuppressAcked:     suppressAcked,
+		AuditLog:          auditLogPath,
+		Plugins:           pluginSpecs,
+		Backends:          backends,
+		ExtrasAction:      extrasAction,
+		ExtrasRules:       extrasRules,
+		QuarantineDir:     quarantineDir,
+		DeleteMaxFiles:    deleteMaxFiles,
+		DeleteMaxSize:     deleteMaxSize,
 
 		// ハッシュ設定
 		HashAlgorithm: "sha256", // デフォルト値
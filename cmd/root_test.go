@@ -121,56 +121,56 @@ func TestFlagValidation(t *testing.T) {
 		name        string
 		workers     int
 		retryCount  int
-		retryWait   int
-		bufferSize  int
+		retryWait   string
+		bufferSize  string
 		expectValid bool
 	}{
 		{
 			name:        "有効な値",
 			workers:     4,
 			retryCount:  3,
-			retryWait:   5,
-			bufferSize:  8,
+			retryWait:   "5",
+			bufferSize:  "8",
 			expectValid: true,
 		},
 		{
 			name:        "ワーカー数0",
 			workers:     0,
 			retryCount:  3,
-			retryWait:   5,
-			bufferSize:  8,
+			retryWait:   "5",
+			bufferSize:  "8",
 			expectValid: true, // デフォルト値が使用される
 		},
 		{
 			name:        "ワーカー数負の値",
 			workers:     -1,
 			retryCount:  3,
-			retryWait:   5,
-			bufferSize:  8,
+			retryWait:   "5",
+			bufferSize:  "8",
 			expectValid: true, // デフォルト値が使用される
 		},
 		{
 			name:        "リトライ回数0",
 			workers:     4,
 			retryCount:  0,
-			retryWait:   5,
-			bufferSize:  8,
+			retryWait:   "5",
+			bufferSize:  "8",
 			expectValid: true,
 		},
 		{
 			name:        "待機時間0",
 			workers:     4,
 			retryCount:  3,
-			retryWait:   0,
-			bufferSize:  8,
+			retryWait:   "0",
+			bufferSize:  "8",
 			expectValid: true,
 		},
 		{
 			name:        "バッファサイズ0",
 			workers:     4,
 			retryCount:  3,
-			retryWait:   5,
-			bufferSize:  0,
+			retryWait:   "5",
+			bufferSize:  "0",
 			expectValid: true,
 		},
 	}
@@ -553,7 +553,7 @@ func TestShowCurrentConfig(t *testing.T) {
 	sourceDir = "/test/source"
 	destDir = "/test/dest"
 	numWorkers = 8
-	bufferSize = 16
+	bufferSize = "16M"
 
 	// showCurrentConfigを実行（出力はキャプチャしない）
 	showCurrentConfig()
@@ -571,9 +571,9 @@ func TestBindConfigToFlags(t *testing.T) {
 		Source:         "/test/source",
 		Destination:    "/test/dest",
 		Workers:        6,
-		BufferSize:     12,
+		BufferSize:     "12M",
 		RetryCount:     5,
-		RetryWait:      10,
+		RetryWait:      "10s",
 		IncludePattern: "*.txt",
 		ExcludePattern: "*.tmp",
 		Recursive:      true,
@@ -620,9 +620,9 @@ func TestBindConfigToFlags(t *testing.T) {
 	sourceDir = ""
 	destDir = ""
 	numWorkers = 0
-	bufferSize = 0
+	bufferSize = ""
 	retryCount = 0
-	retryWait = 0
+	retryWait = ""
 	includePattern = ""
 	excludePattern = ""
 	recursive = false
@@ -653,13 +653,13 @@ func TestBindConfigToFlags(t *testing.T) {
 		t.Errorf("Workers: 期待値=%d, 実際=%d", config.Workers, numWorkers)
 	}
 	if bufferSize != config.BufferSize {
-		t.Errorf("BufferSize: 期待値=%d, 実際=%d", config.BufferSize, bufferSize)
+		t.Errorf("BufferSize: 期待値=%s, 実際=%s", config.BufferSize, bufferSize)
 	}
 	if retryCount != config.RetryCount {
 		t.Errorf("RetryCount: 期待値=%d, 実際=%d", config.RetryCount, retryCount)
 	}
 	if retryWait != config.RetryWait {
-		t.Errorf("RetryWait: 期待値=%d, 実際=%d", config.RetryWait, retryWait)
+		t.Errorf("RetryWait: 期待値=%s, 実際=%s", config.RetryWait, retryWait)
 	}
 	if includePattern != config.IncludePattern {
 		t.Errorf("IncludePattern: 期待値=%s, 実際=%s", config.IncludePattern, includePattern)
@@ -735,9 +735,9 @@ func TestValidateConfig(t *testing.T) {
 	// 正常な設定
 	validConfig := &Config{
 		Workers:       4,
-		BufferSize:    8,
+		BufferSize:    "8",
 		RetryCount:    3,
-		RetryWait:     5,
+		RetryWait:     "5",
 		SyncMode:      "normal",
 		MaxFailCount:  5,
 		HashAlgorithm: "sha256",
@@ -758,9 +758,9 @@ func TestValidateConfig(t *testing.T) {
 			name: "ワーカー数が負",
 			config: &Config{
 				Workers:       -1,
-				BufferSize:    8,
+				BufferSize:    "8",
 				RetryCount:    3,
-				RetryWait:     5,
+				RetryWait:     "5",
 				SyncMode:      "normal",
 				MaxFailCount:  5,
 				HashAlgorithm: "sha256",
@@ -771,9 +771,9 @@ func TestValidateConfig(t *testing.T) {
 			name: "バッファサイズが負",
 			config: &Config{
 				Workers:       4,
-				BufferSize:    -1,
+				BufferSize:    "-1",
 				RetryCount:    3,
-				RetryWait:     5,
+				RetryWait:     "5",
 				SyncMode:      "normal",
 				MaxFailCount:  5,
 				HashAlgorithm: "sha256",
@@ -784,9 +784,9 @@ func TestValidateConfig(t *testing.T) {
 			name: "リトライ回数が負",
 			config: &Config{
 				Workers:       4,
-				BufferSize:    8,
+				BufferSize:    "8",
 				RetryCount:    -1,
-				RetryWait:     5,
+				RetryWait:     "5",
 				SyncMode:      "normal",
 				MaxFailCount:  5,
 				HashAlgorithm: "sha256",
@@ -797,9 +797,9 @@ func TestValidateConfig(t *testing.T) {
 			name: "待機時間が負",
 			config: &Config{
 				Workers:       4,
-				BufferSize:    8,
+				BufferSize:    "8",
 				RetryCount:    3,
-				RetryWait:     -1,
+				RetryWait:     "-1",
 				SyncMode:      "normal",
 				MaxFailCount:  5,
 				HashAlgorithm: "sha256",
@@ -810,9 +810,9 @@ func TestValidateConfig(t *testing.T) {
 			name: "無効な同期モード",
 			config: &Config{
 				Workers:       4,
-				BufferSize:    8,
+				BufferSize:    "8",
 				RetryCount:    3,
-				RetryWait:     5,
+				RetryWait:     "5",
 				SyncMode:      "invalid",
 				MaxFailCount:  5,
 				HashAlgorithm: "sha256",
@@ -823,9 +823,9 @@ func TestValidateConfig(t *testing.T) {
 			name: "最大失敗回数が負",
 			config: &Config{
 				Workers:       4,
-				BufferSize:    8,
+				BufferSize:    "8",
 				RetryCount:    3,
-				RetryWait:     5,
+				RetryWait:     "5",
 				SyncMode:      "normal",
 				MaxFailCount:  -1,
 				HashAlgorithm: "sha256",
@@ -836,9 +836,9 @@ func TestValidateConfig(t *testing.T) {
 			name: "無効なハッシュアルゴリズム",
 			config: &Config{
 				Workers:       4,
-				BufferSize:    8,
+				BufferSize:    "8",
 				RetryCount:    3,
-				RetryWait:     5,
+				RetryWait:     "5",
 				SyncMode:      "normal",
 				MaxFailCount:  5,
 				HashAlgorithm: "invalid",
@@ -0,0 +1,264 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/jobs"
+	"github.com/sakuhanight/gopier/internal/runsummary"
+)
+
+var (
+	runReport      string
+	runMaxParallel int
+)
+
+// runCmd represents the run command
+var runCmd = &cobra.Command{
+	Use:   "run <jobs.yaml>",
+	Short: "複数のジョブを依存関係の順に実行",
+	Long: `マニフェスト（jobs.yaml）に記述した複数のジョブを、依存関係（needs）の順に実行します。
+依存するジョブがすべて成功したジョブから順に並行して実行し、依存するジョブが失敗したジョブは実行しません。
+
+各ジョブはgopierを別のプロセスとして、マニフェストのディレクトリで「gopier [--config <config>] <args...>」として実行します。
+ジョブの出力は行頭にジョブの名前を付けて表示し、すべてのジョブの終了後に結果をまとめて表示します。
+サブコマンドを指定しないコピーのジョブは、実行結果（--summary-json）の件数もまとめて集計します。
+
+同じDBを使用するコピーは同時に実行できないため、並行して実行するジョブにはそれぞれ別のDB（--db）を指定してください。
+いずれかのジョブが失敗した場合は、失敗したジョブの終了コード（異なる場合は1）で終了します。`,
+	Example: `  gopier run jobs.yaml
+  gopier run jobs.yaml --report jobs_report.json --max-parallel 2`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		m, err := jobs.Load(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		if cmd.Flags().Changed("max-parallel") {
+			m.MaxParallel = runMaxParallel
+		}
+		if cmd.Flags().Changed("report") {
+			m.Report = absPath(runReport)
+		}
+		if err := m.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+
+		exe, err := os.Executable()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "実行ファイルのパスの取得に失敗: %v\n", err)
+			os.Exit(1)
+		}
+		summaryDir, err := os.MkdirTemp("", "gopier-run-")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "一時ディレクトリの作成に失敗: %v\n", err)
+			os.Exit(1)
+		}
+
+		output := &syncWriter{w: os.Stdout}
+		report := jobs.Run(m, func(job jobs.Job) jobs.Result {
+			return runJob(m, exe, job, summaryDir, output)
+		}, func(e jobs.Event) {
+			printJobEvent(output, e)
+		})
+		os.RemoveAll(summaryDir)
+
+		fmt.Println()
+		jobs.WriteText(os.Stdout, report)
+		if path := m.ReportPath(); path != "" {
+			if err := writeJobsReport(report, path); err != nil {
+				fmt.Fprintf(os.Stderr, "レポートの保存に失敗: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("レポートを保存しました: %s\n", path)
+		}
+
+		if code := jobsExitCode(report); code != errcode.ExitOK {
+			os.Exit(code)
+		}
+	},
+}
+
+// jobArgs はジョブを実行する引数を返す
+// サブコマンドを指定しないコピーのジョブで--summary-jsonを指定していない場合は、summaryPathに実行結果を保存させる
+// 戻り値の2つ目は実行結果を読み込むパス（コピーのジョブでない場合は空）
+func jobArgs(job jobs.Job, summaryPath string) ([]string, string) {
+	var args []string
+	if job.Config != "" {
+		args = append(args, "--config", job.Config)
+	}
+	args = append(args, job.Args...)
+
+	if len(job.Args) > 0 && !strings.HasPrefix(job.Args[0], "-") {
+		return args, ""
+	}
+	for i, arg := range job.Args {
+		if arg == "--summary-json" && i+1 < len(job.Args) {
+			return args, job.Args[i+1]
+		}
+		if value, ok := strings.CutPrefix(arg, "--summary-json="); ok {
+			return args, value
+		}
+	}
+	return append(args, "--summary-json", summaryPath), summaryPath
+}
+
+// runJob はジョブをgopierの別のプロセスとしてマニフェストのディレクトリで実行する
+func runJob(m *jobs.Manifest, exe string, job jobs.Job, summaryDir string, output io.Writer) jobs.Result {
+	args, summaryPath := jobArgs(job, filepath.Join(summaryDir, job.Name+".json"))
+
+	stdout := newPrefixWriter(output, job.Name)
+	stderr := newPrefixWriter(output, job.Name)
+	c := exec.Command(exe, args...)
+	c.Dir = m.Dir()
+	c.Stdout = stdout
+	c.Stderr = stderr
+	err := c.Run()
+	stdout.Flush()
+	stderr.Flush()
+
+	var result jobs.Result
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		result.ExitCode = errcode.ExitError
+		result.Error = fmt.Sprintf("ジョブを実行できません: %v", err)
+	}
+
+	if summaryPath != "" {
+		if !filepath.IsAbs(summaryPath) {
+			summaryPath = filepath.Join(m.Dir(), summaryPath)
+		}
+		if summary, err := runsummary.Load(summaryPath); err == nil {
+			result.Summary = summary
+		}
+	}
+	return result
+}
+
+// printJobEvent はジョブの開始と終了を表示する
+func printJobEvent(w io.Writer, e jobs.Event) {
+	switch e.Kind {
+	case jobs.EventStarted:
+		fmt.Fprintf(w, "[run] 開始: %s (実行中: %s) [%d/%d]\n", e.Job, strings.Join(e.Running, ", "), e.Done, e.Total)
+	case jobs.EventFinished:
+		switch e.Result.Status {
+		case jobs.StatusSkipped:
+			fmt.Fprintf(w, "[run] 未実行: %s: %s [%d/%d]\n", e.Job, e.Result.Error, e.Done, e.Total)
+		default:
+			fmt.Fprintf(w, "[run] 終了: %s %s (終了コード %d, %.1f秒) [%d/%d]\n",
+				e.Job, e.Result.Status, e.Result.ExitCode, e.Result.Seconds, e.Done, e.Total)
+		}
+	}
+}
+
+// writeJobsReport はレポートをJSONで保存する
+func writeJobsReport(report *jobs.Report, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("出力ファイルの作成に失敗: %w", err)
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	if err := jobs.WriteJSON(w, report); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// jobsExitCode はジョブの結果に対応する終了コードを返す
+// 失敗したジョブの終了コードがすべて同じであればその終了コードを、そうでなければExitErrorを返す
+func jobsExitCode(report *jobs.Report) int {
+	// 実行しなかったジョブは、原因となった失敗したジョブの終了コードに従う
+	code := errcode.ExitOK
+	for _, result := range report.Jobs {
+		if result.Status != jobs.StatusFailed {
+			continue
+		}
+		if code != errcode.ExitOK && code != result.ExitCode {
+			return errcode.ExitError
+		}
+		code = result.ExitCode
+	}
+	return code
+}
+
+// syncWriter は複数のジョブの出力を行単位で直列化して書き込む
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// prefixWriter は出力の各行の先頭にジョブの名前を付けて書き込む
+// 行の途中の出力は改行まで保持し、\rで上書きする進捗表示は最後の表示のみを書き込む
+type prefixWriter struct {
+	w      io.Writer
+	prefix string
+	buf    []byte
+}
+
+func newPrefixWriter(w io.Writer, name string) *prefixWriter {
+	return &prefixWriter{w: w, prefix: "[" + name + "] "}
+}
+
+func (p *prefixWriter) Write(data []byte) (int, error) {
+	p.buf = append(p.buf, data...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			return len(data), nil
+		}
+		if err := p.writeLine(p.buf[:i]); err != nil {
+			return len(data), err
+		}
+		p.buf = p.buf[i+1:]
+	}
+}
+
+// Flush は改行で終わっていない最後の行を書き込む
+func (p *prefixWriter) Flush() error {
+	if len(p.buf) == 0 {
+		return nil
+	}
+	err := p.writeLine(p.buf)
+	p.buf = nil
+	return err
+}
+
+func (p *prefixWriter) writeLine(line []byte) error {
+	line = bytes.TrimRight(line, "\r")
+	if i := bytes.LastIndexByte(line, '\r'); i >= 0 {
+		line = line[i+1:]
+	}
+	_, err := p.w.Write([]byte(p.prefix + string(line) + "\n"))
+	return err
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+
+	runCmd.Flags().StringVar(&runReport, "report", "", "すべてのジョブの結果をまとめたレポート（JSON）の保存先（マニフェストのreportより優先）")
+	runCmd.Flags().IntVar(&runMaxParallel, "max-parallel", 0, "同時に実行するジョブの最大数（0は制限しない、マニフェストのmax_parallelより優先）")
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/sakuhanight/gopier/internal/jobs"
+)
+
+func TestJobArgs(t *testing.T) {
+	tests := []struct {
+		name        string
+		job         jobs.Job
+		wantArgs    []string
+		wantSummary string
+	}{
+		{
+			name:        "コピー",
+			job:         jobs.Job{Config: "a.yaml", Args: []string{"-s", "/a", "-d", "/b"}},
+			wantArgs:    []string{"--config", "a.yaml", "-s", "/a", "-d", "/b", "--summary-json", "/tmp/a.json"},
+			wantSummary: "/tmp/a.json",
+		},
+		{
+			name:        "実行結果の保存先を指定したコピー",
+			job:         jobs.Job{Args: []string{"-s", "/a", "-d", "/b", "--summary-json=out.json"}},
+			wantArgs:    []string{"-s", "/a", "-d", "/b", "--summary-json=out.json"},
+			wantSummary: "out.json",
+		},
+		{
+			name:     "サブコマンド",
+			job:      jobs.Job{Config: "a.yaml", Args: []string{"verify", "/a", "/b"}},
+			wantArgs: []string{"--config", "a.yaml", "verify", "/a", "/b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, summary := jobArgs(tt.job, "/tmp/a.json")
+			if !slices.Equal(args, tt.wantArgs) || summary != tt.wantSummary {
+				t.Errorf("jobArgs() = %q, %q, want %q, %q", args, summary, tt.wantArgs, tt.wantSummary)
+			}
+		})
+	}
+}
+
+func TestPrefixWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := newPrefixWriter(&buf, "copy-a")
+	w.Write([]byte("開始\n進捗 10%\r進捗 50%"))
+	w.Write([]byte("\r進捗 100%\r\n完了"))
+	w.Flush()
+
+	want := "[copy-a] 開始\n[copy-a] 進捗 100%\n[copy-a] 完了\n"
+	if buf.String() != want {
+		t.Errorf("出力 = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestJobsExitCode(t *testing.T) {
+	report := func(results ...jobs.Result) *jobs.Report { return &jobs.Report{Jobs: results} }
+	ok := jobs.Result{Status: jobs.StatusSucceeded}
+	failed := func(code int) jobs.Result { return jobs.Result{Status: jobs.StatusFailed, ExitCode: code} }
+	skipped := jobs.Result{Status: jobs.StatusSkipped}
+
+	if got := jobsExitCode(report(ok, ok)); got != 0 {
+		t.Errorf("すべて成功 = %d, want 0", got)
+	}
+	if got := jobsExitCode(report(ok, failed(4), skipped)); got != 4 {
+		t.Errorf("1件失敗 = %d, want 4", got)
+	}
+	if got := jobsExitCode(report(failed(2), failed(4))); got != 1 {
+		t.Errorf("異なる終了コード = %d, want 1", got)
+	}
+}
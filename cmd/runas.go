@@ -0,0 +1,16 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/sakuhanight/gopier/internal/runas"
+)
+
+// openIdentity はユーザー指定から資格情報を開く（ユーザーが空の場合はnilを返す）
+// パスワードはプロセス一覧や設定ファイルに残らないよう、環境変数passwordEnvからのみ読み込む
+func openIdentity(userSpec, passwordEnv, target string) (runas.Identity, error) {
+	if userSpec == "" {
+		return nil, nil
+	}
+	return runas.Open(runas.ParseUser(userSpec, os.Getenv(passwordEnv)), target)
+}
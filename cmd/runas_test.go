@@ -0,0 +1,10 @@
+package cmd
+
+import "testing"
+
+func TestOpenIdentity_Empty(t *testing.T) {
+	identity, err := openIdentity("", "GOPIER_SOURCE_PASSWORD", "/src")
+	if err != nil || identity != nil {
+		t.Errorf("openIdentity(\"\") = %v, %v; want nil, nil", identity, err)
+	}
+}
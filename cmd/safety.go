@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sakuhanight/gopier/internal/overlap"
+	"github.com/sakuhanight/gopier/internal/verifier"
+)
+
+// iKnowWhatIAmDoing は危険な宛先の確認を省略する（--i-know-what-i-am-doing）
+var iKnowWhatIAmDoing bool
+
+// deletesFromDest は宛先のファイルを削除する設定（ミラーモード・--extras-action delete・処理方法がdeleteの--extras-rule）かどうかを返す
+func deletesFromDest() bool {
+	if mirror || extrasAction == "delete" {
+		return true
+	}
+	rules, _ := verifier.ParseExtrasRules(extrasRules)
+	for _, rule := range rules {
+		if rule.Action == verifier.ExtrasDelete {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDangerousDestinations は宛先と追加の宛先（--extra-dest）のそれぞれをcheckDangerousDestinationで確認する
+// 削除する設定は追加の宛先にも適用されるため、いずれかが誤りの可能性が高い場合はエラーを返す
+func checkDangerousDestinations(source string, dests []string) error {
+	for _, dest := range dests {
+		if err := checkDangerousDestination(source, dest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkDangerousDestination は宛先のファイルを削除する場合に、誤りの可能性が高い宛先を拒否する
+// ファイルシステムのルート・ホームディレクトリと、ソースと宛先の一方が他方を含む場合はエラーを返す
+func checkDangerousDestination(source, dest string) error {
+	destPath := overlap.Resolve(dest)
+	if filepath.Dir(destPath) == destPath {
+		return fmt.Errorf("宛先(%s)はファイルシステムのルートです", dest)
+	}
+	if home, err := os.UserHomeDir(); err == nil && overlap.SamePath(destPath, overlap.Resolve(home)) {
+		return fmt.Errorf("宛先(%s)はホームディレクトリです", dest)
+	}
+	switch overlap.Detect(source, dest) {
+	case overlap.Same:
+		return fmt.Errorf("宛先(%s)はソースと同じディレクトリです", dest)
+	case overlap.DestInSource:
+		return fmt.Errorf("宛先(%s)はソース(%s)の中にあります", dest, source)
+	case overlap.SourceInDest:
+		return fmt.Errorf("ソース(%s)は宛先(%s)の中にあります", source, dest)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckDangerousDestination(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "source")
+	os.MkdirAll(source, 0755)
+	link := filepath.Join(root, "link")
+	symlinked := os.Symlink(source, link) == nil
+
+	home, _ := os.UserHomeDir()
+	tests := []struct {
+		name   string
+		source string
+		dest   string
+		want   string
+	}{
+		{"別のディレクトリ", source, filepath.Join(root, "dest"), ""},
+		{"名前が前方一致するだけ", source, source + "-backup", ""},
+		{"ルート", source, filepath.VolumeName(root) + string(filepath.Separator), "ルート"},
+		{"ホームディレクトリ", source, home, "ホームディレクトリ"},
+		{"同じディレクトリ", source, source + string(filepath.Separator), "同じ"},
+		{"ソースの中", source, filepath.Join(source, "backup"), "ソース"},
+		{"宛先の中", filepath.Join(root, "dest", "source"), filepath.Join(root, "dest"), "宛先"},
+	}
+	if symlinked {
+		tests = append(tests, struct {
+			name   string
+			source string
+			dest   string
+			want   string
+		}{"シンボリックリンク経由", link, filepath.Join(source, "backup"), "ソース"})
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.dest == "" {
+				t.Skip("ホームディレクトリを取得できません")
+			}
+			err := checkDangerousDestination(tt.source, tt.dest)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("checkDangerousDestination() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("checkDangerousDestination() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckDangerousDestinations(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "source")
+	os.MkdirAll(source, 0755)
+
+	dests := []string{filepath.Join(root, "dest"), filepath.Join(root, "mirror")}
+	if err := checkDangerousDestinations(source, dests); err != nil {
+		t.Errorf("checkDangerousDestinations() error = %v", err)
+	}
+
+	// 追加の宛先のみがソースと重なる場合も拒否する
+	dests = append(dests, filepath.Join(source, "backup"))
+	if err := checkDangerousDestinations(source, dests); err == nil || !strings.Contains(err.Error(), "ソース") {
+		t.Errorf("checkDangerousDestinations() error = %v, want ソースの中", err)
+	}
+}
@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/sakuhanight/gopier/internal/schedule"
+	"github.com/sakuhanight/gopier/internal/units"
+)
+
+var (
+	scheduleName      string
+	scheduleFormat    string
+	scheduleAt        string
+	scheduleInterval  time.Duration
+	scheduleOutputDir string
+	scheduleUserUnit  bool
+	scheduleEnable    bool
+)
+
+// scheduleCmd represents the schedule command
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "定期的な同期を登録する",
+	Long:  `設定ファイルのジョブを定期的に実行するための、systemdのユニットやWindowsのタスクスケジューラの定義を扱います。`,
+}
+
+// scheduleInstallCmd represents the schedule install command
+var scheduleInstallCmd = &cobra.Command{
+	Use:   "install [-- 追加の引数...]",
+	Short: "systemdのタイマーまたはWindowsのタスクを作成",
+	Long: `設定ファイル（--config、省略時は読み込んだ.gopier.yaml）のジョブを定期的に実行する
+systemdのサービスとタイマーのユニット、またはWindowsのタスクスケジューラのタスク定義（XML）を書き出します。
+ジョブは「gopier --config <設定ファイル>」を設定ファイルのディレクトリで実行します。
+「--」の後に指定した引数はジョブの実行時の引数に追加されます。
+
+形式:
+  systemd - <名前>.serviceと<名前>.timer（デフォルトの出力先: /etc/systemd/system、--userでは~/.config/systemd/user）
+  windows - <名前>.xml（デフォルトの出力先: カレントディレクトリ）
+
+--enableを指定すると、書き出した後にsystemctl enable --now（Windowsではschtasks /Create）で登録します。`,
+	Example: `  gopier schedule install --config /etc/gopier/nightly.yaml --name gopier-nightly --at 02:00 --enable
+  gopier schedule install --config C:\gopier\nightly.yaml --format windows --interval 6h
+  gopier schedule install --config job.yaml -- --mode incremental`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if scheduleFormat != "systemd" && scheduleFormat != "windows" {
+			fmt.Fprintf(os.Stderr, "サポートされていない形式: %s\n", scheduleFormat)
+			os.Exit(1)
+		}
+
+		job, err := newScheduleJob(args)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+
+		if scheduleFormat == "windows" {
+			err = installTask(job)
+		} else {
+			err = installSystemdTimer(job)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// newScheduleJob は設定ファイルとフラグから定期的に実行するジョブを作成する
+func newScheduleJob(extraArgs []string) (schedule.Job, error) {
+	configPath := cfgFile
+	if configPath == "" {
+		configPath = viper.ConfigFileUsed()
+	}
+	if configPath == "" {
+		return schedule.Job{}, fmt.Errorf("設定ファイルが見つかりません。--configでジョブの設定ファイルを指定してください。")
+	}
+	configPath, err := filepath.Abs(configPath)
+	if err != nil {
+		return schedule.Job{}, fmt.Errorf("設定ファイルのパスの解決に失敗: %w", err)
+	}
+	if _, err := os.Stat(configPath); err != nil {
+		return schedule.Job{}, fmt.Errorf("設定ファイルを確認できません: %w", err)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return schedule.Job{}, fmt.Errorf("実行ファイルのパスの取得に失敗: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+
+	hour, minute, err := schedule.ParseTime(scheduleAt)
+	if err != nil {
+		return schedule.Job{}, err
+	}
+
+	// --workdirを指定した場合はジョブでも同じ作業ディレクトリを使用する
+	args := []string{"--config", configPath}
+	if rootCmd.PersistentFlags().Changed("workdir") {
+		args = append(args, "--workdir", workDir)
+	}
+
+	job := schedule.Job{
+		Name:       scheduleName,
+		Executable: exe,
+		Args:       append(args, extraArgs...),
+		WorkDir:    filepath.Dir(configPath),
+		Hour:       hour,
+		Minute:     minute,
+		Interval:   scheduleInterval,
+	}
+	return job, job.Validate()
+}
+
+// installSystemdTimer はsystemdのサービスとタイマーのユニットを書き出し、--enableの場合は有効にする
+func installSystemdTimer(job schedule.Job) error {
+	dir := scheduleOutputDir
+	if dir == "" {
+		dir = "/etc/systemd/system"
+		if scheduleUserUnit {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return fmt.Errorf("ホームディレクトリの取得に失敗: %w", err)
+			}
+			dir = filepath.Join(home, ".config", "systemd", "user")
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("出力先ディレクトリの作成に失敗: %w", err)
+	}
+
+	servicePath := filepath.Join(dir, job.Name+".service")
+	if err := writeScheduleFile(servicePath, func(w io.Writer) error { return schedule.WriteSystemdService(w, job) }); err != nil {
+		return err
+	}
+	timerPath := filepath.Join(dir, job.Name+".timer")
+	if err := writeScheduleFile(timerPath, func(w io.Writer) error { return schedule.WriteSystemdTimer(w, job) }); err != nil {
+		return err
+	}
+	fmt.Printf("サービスユニットを作成しました: %s\n", servicePath)
+	fmt.Printf("タイマーユニットを作成しました: %s\n", timerPath)
+
+	systemctl := []string{"systemctl"}
+	if scheduleUserUnit {
+		systemctl = append(systemctl, "--user")
+	}
+	enable := append(append([]string{}, systemctl...), "enable", "--now", job.Name+".timer")
+	if !scheduleEnable {
+		fmt.Println("次のコマンドで有効にしてください:")
+		fmt.Printf("  %s daemon-reload\n", joinCommand(systemctl))
+		fmt.Printf("  %s\n", joinCommand(enable))
+		return nil
+	}
+
+	if err := runScheduleCommand(append(append([]string{}, systemctl...), "daemon-reload")); err != nil {
+		return err
+	}
+	if err := runScheduleCommand(enable); err != nil {
+		return err
+	}
+	fmt.Printf("タイマーを有効にしました: %s.timer\n", job.Name)
+	return nil
+}
+
+// installTask はWindowsのタスクスケジューラのタスク定義を書き出し、--enableの場合は登録する
+func installTask(job schedule.Job) error {
+	dir := scheduleOutputDir
+	if dir == "" {
+		dir = "."
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("出力先ディレクトリの作成に失敗: %w", err)
+	}
+
+	xmlPath := filepath.Join(dir, job.Name+".xml")
+	if err := writeScheduleFile(xmlPath, func(w io.Writer) error { return schedule.WriteTaskXML(w, job, time.Now()) }); err != nil {
+		return err
+	}
+	fmt.Printf("タスク定義を作成しました: %s\n", xmlPath)
+
+	create := []string{"schtasks", "/Create", "/TN", job.Name, "/XML", xmlPath, "/F"}
+	if !scheduleEnable {
+		fmt.Println("次のコマンドで登録してください（ログオンしていない間も実行する場合は /RU と /RP でユーザーを指定）:")
+		fmt.Printf("  %s\n", joinCommand(create))
+		return nil
+	}
+
+	if err := runScheduleCommand(create); err != nil {
+		return err
+	}
+	fmt.Printf("タスクを登録しました: %s\n", job.Name)
+	return nil
+}
+
+// writeScheduleFile はユニット・タスク定義のファイルを書き出す
+func writeScheduleFile(path string, write func(io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("ファイル作成エラー: %w", err)
+	}
+	defer file.Close()
+
+	if err := write(file); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// runScheduleCommand は登録のためのコマンドを実行する
+func runScheduleCommand(args []string) error {
+	command := exec.Command(args[0], args[1:]...)
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	if err := command.Run(); err != nil {
+		return fmt.Errorf("%sの実行に失敗: %w", joinCommand(args), err)
+	}
+	return nil
+}
+
+// joinCommand は表示するためにコマンドラインを連結する（空白を含む引数は引用符で囲む）
+func joinCommand(args []string) string {
+	line := ""
+	for i, arg := range args {
+		if i > 0 {
+			line += " "
+		}
+		line += quoteIfNeeded(arg)
+	}
+	return line
+}
+
+// quoteIfNeeded は空白を含む引数を引用符で囲む
+func quoteIfNeeded(s string) string {
+	for _, r := range s {
+		if r == ' ' || r == '\t' {
+			return `"` + s + `"`
+		}
+	}
+	return s
+}
+
+// defaultScheduleFormat は実行中のOSに合わせた形式を返す
+func defaultScheduleFormat() string {
+	if runtime.GOOS == "windows" {
+		return "windows"
+	}
+	return "systemd"
+}
+
+func init() {
+	rootCmd.AddCommand(scheduleCmd)
+	scheduleCmd.AddCommand(scheduleInstallCmd)
+
+	scheduleInstallCmd.Flags().StringVar(&scheduleName, "name", "gopier", "ユニット名・タスク名")
+	scheduleInstallCmd.Flags().StringVar(&scheduleFormat, "format", defaultScheduleFormat(), "形式 (systemd, windows)")
+	scheduleInstallCmd.Flags().StringVar(&scheduleAt, "at", "02:00", "毎日実行する時刻（HH:MM）")
+	scheduleInstallCmd.Flags().Var(units.NewDurationValue(&scheduleInterval, 0, 0), "interval", "一定間隔で繰り返す場合の間隔（例: 6h、0は1日1回）")
+	scheduleInstallCmd.Flags().StringVarP(&scheduleOutputDir, "output-dir", "o", "", "書き出し先のディレクトリ（省略時は形式ごとのデフォルト）")
+	scheduleInstallCmd.Flags().BoolVar(&scheduleUserUnit, "user", false, "systemdのユーザーユニットとして作成（systemdのみ）")
+	scheduleInstallCmd.Flags().BoolVar(&scheduleEnable, "enable", false, "書き出した後にタイマーを有効にする・タスクを登録する")
+}
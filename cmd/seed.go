@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/filter"
+	"github.com/sakuhanight/gopier/internal/logger"
+	"github.com/sakuhanight/gopier/internal/seeder"
+)
+
+var (
+	seedSource  string
+	seedDBPath  string
+	seedHash    string
+	seedWorkers int
+	seedInclude string
+	seedExclude string
+	seedForce   bool
+	seedVerbose bool
+)
+
+// seedCmd represents the seed command
+var seedCmd = &cobra.Command{
+	Use:   "seed",
+	Short: "ソースのハッシュ一覧をデータベースに記録",
+	Long: `コピーを行わずにソースを走査し、すべてのファイルのサイズ・更新日時・ハッシュを
+データベースに記録します。以降の同期や検証で利用できるハッシュ一覧を事前に作成できます。
+
+2回目以降は、記録済みのサイズ・更新日時・ハッシュアルゴリズムが一致するファイルの
+ハッシュは再計算しません。すべて再計算する場合は--forceを指定してください。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if seedSource == "" || seedDBPath == "" {
+			fmt.Fprintf(os.Stderr, "--sourceと--dbを指定してください。\n")
+			os.Exit(1)
+		}
+
+		if seedWorkers <= 0 {
+			seedWorkers = runtime.NumCPU()
+		}
+
+		log := logger.NewLogger("", seedVerbose, false)
+		defer log.Close()
+
+		dbLock, err := lockDatabase(seedDBPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(errcode.ExitCode(err))
+		}
+		defer dbLock.Release()
+
+		syncDB, err := database.NewSyncDB(seedDBPath, database.NormalSync)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "データベースのオープンに失敗: %v\n", err)
+			os.Exit(1)
+		}
+		defer syncDB.Close()
+
+		options := seeder.DefaultOptions()
+		options.HashAlgorithm = seedHash
+		options.MaxConcurrent = seedWorkers
+		options.Force = seedForce
+
+		start := time.Now()
+		s := seeder.NewSeeder(seedSource, options, filter.NewFilter(seedInclude, seedExclude), syncDB, log)
+		result, err := s.Run()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "シード中にエラーが発生しました: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("走査: %d件, ハッシュ計算: %d件 (%s), 最新のため省略: %d件, 失敗: %d件, 所要時間: %s\n",
+			result.Scanned, result.Hashed, formatBytes(result.HashedBytes), result.Fresh, result.Failed,
+			time.Since(start).Truncate(time.Millisecond))
+
+		if result.Failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(seedCmd)
+
+	seedCmd.Flags().StringVarP(&seedSource, "source", "s", "", "ソースディレクトリ")
+	seedCmd.Flags().StringVar(&seedDBPath, "db", "sync_state.db", "同期状態データベースのパス")
+	seedCmd.Flags().StringVar(&seedHash, "hash", "sha256", "ハッシュアルゴリズム (md5, sha1, sha256, sha512)")
+	seedCmd.Flags().IntVarP(&seedWorkers, "workers", "w", 0, "並列ワーカー数（デフォルト: CPUコア数）")
+	seedCmd.Flags().StringVarP(&seedInclude, "include", "i", "", "含めるファイルパターン（例: *.txt,*.docx）")
+	seedCmd.Flags().StringVarP(&seedExclude, "exclude", "e", "", "除外するファイルパターン（例: *.tmp,*.bak）")
+	seedCmd.Flags().BoolVar(&seedForce, "force", false, "変更のないファイルもハッシュを再計算")
+	seedCmd.Flags().BoolVarP(&seedVerbose, "verbose", "v", false, "詳細なログ出力")
+}
@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sakuhanight/gopier/internal/database"
+)
+
+var (
+	runStatsDB    string
+	runStatsLast  int
+	runStatsLabel string
+	runStatsJSON  bool
+)
+
+// runStatsCmd represents the stats command
+var runStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "過去の実行の統計情報を扱う",
+	Long:  `データベースに記録した実行ごとの統計情報を扱います。`,
+}
+
+// runStatsShowCmd represents the stats show command
+var runStatsShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "過去の実行の統計情報を表示",
+	Long: `コピーの終了時にデータベースに記録した統計情報を、直近--last回の実行について表示します。
+実行時のコンソール出力がなくても、所要時間・件数・転送速度を過去の実行と比較できます。
+
+各行には直前の実行からの所要時間と転送速度の変化を表示し、最後に表示した実行の平均・最小・最大を表示します。
+--labelを指定すると、--labelを付けて実行したセッションのみを対象にします。
+--jsonを指定するとJSON形式で出力します。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if runStatsDB == "" {
+			fmt.Fprintf(os.Stderr, "データベースパスが指定されていません。--dbフラグを使用してください。\n")
+			os.Exit(1)
+		}
+
+		syncDB, err := database.NewSyncDB(runStatsDB, database.NormalSync)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "データベースのオープンに失敗: %v\n", err)
+			os.Exit(1)
+		}
+		defer syncDB.Close()
+
+		var sessions []database.SyncSession
+		if runStatsLabel != "" {
+			sessions, err = syncDB.GetSessionsByLabel(runStatsLabel)
+		} else {
+			sessions, err = syncDB.GetSessions()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "セッション一覧の取得に失敗: %v\n", err)
+			os.Exit(1)
+		}
+
+		report := buildRunStats(sessions, runStatsLast)
+		report.Database = runStatsDB
+		if runStatsJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(report); err != nil {
+				fmt.Fprintf(os.Stderr, "JSONの出力に失敗: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		printRunStats(os.Stdout, report)
+	},
+}
+
+// runStatsEntry は1回の実行の統計情報
+type runStatsEntry struct {
+	ID         int64                 `json:"id"`
+	StartTime  time.Time             `json:"start_time"`
+	Duration   float64               `json:"duration_seconds"`
+	Throughput float64               `json:"bytes_per_second"`
+	Label      string                `json:"label,omitempty"`
+	Stats      database.SessionStats `json:"stats"`
+	Change     *runStatsChange       `json:"change,omitempty"` // 直前の実行がない場合はnil
+}
+
+// runStatsChange は直前の実行からの変化
+type runStatsChange struct {
+	FilesCopied       int64    `json:"files_copied"`
+	FilesFailed       int64    `json:"files_failed"`
+	BytesCopied       int64    `json:"bytes_copied"`
+	DurationPercent   *float64 `json:"duration_percent,omitempty"`   // 直前の所要時間が0の場合はnil
+	ThroughputPercent *float64 `json:"throughput_percent,omitempty"` // 直前の転送速度が0の場合はnil
+}
+
+// runStatsSummary は表示した実行全体の集計
+type runStatsSummary struct {
+	Runs          int     `json:"runs"`
+	FilesCopied   int64   `json:"files_copied"`
+	FilesFailed   int64   `json:"files_failed"`
+	BytesCopied   int64   `json:"bytes_copied"`
+	AvgDuration   float64 `json:"avg_duration_seconds"`
+	AvgThroughput float64 `json:"avg_bytes_per_second"`
+	MinThroughput float64 `json:"min_bytes_per_second"`
+	MaxThroughput float64 `json:"max_bytes_per_second"`
+}
+
+// runStatsReport はstats showの出力
+type runStatsReport struct {
+	Database string          `json:"database"`
+	Runs     []runStatsEntry `json:"runs"`
+	Summary  runStatsSummary `json:"summary"`
+}
+
+// buildRunStats は統計情報が記録されたセッションのうち直近last件（0の場合はすべて）について、直前の実行との比較と集計を作成する
+// 表示する最初の実行も、それより前の実行があれば比較する
+func buildRunStats(sessions []database.SyncSession, last int) runStatsReport {
+	var runs []runStatsEntry
+	for _, session := range sessions {
+		if session.Stats == nil {
+			continue
+		}
+		entry := runStatsEntry{
+			ID:        session.ID,
+			StartTime: session.StartTime,
+			Label:     session.Label,
+			Stats:     *session.Stats,
+		}
+		if session.EndTime.After(session.StartTime) {
+			entry.Duration = session.EndTime.Sub(session.StartTime).Seconds()
+			entry.Throughput = float64(entry.Stats.BytesCopied) / entry.Duration
+		}
+		if len(runs) > 0 {
+			entry.Change = compareRuns(runs[len(runs)-1], entry)
+		}
+		runs = append(runs, entry)
+	}
+	if last > 0 && len(runs) > last {
+		runs = runs[len(runs)-last:]
+	}
+
+	report := runStatsReport{Runs: runs}
+	if runs == nil {
+		report.Runs = []runStatsEntry{}
+	}
+	summary := &report.Summary
+	summary.Runs = len(runs)
+	for i, run := range runs {
+		summary.FilesCopied += run.Stats.FilesCopied
+		summary.FilesFailed += run.Stats.FilesFailed
+		summary.BytesCopied += run.Stats.BytesCopied
+		summary.AvgDuration += run.Duration
+		summary.AvgThroughput += run.Throughput
+		if i == 0 || run.Throughput < summary.MinThroughput {
+			summary.MinThroughput = run.Throughput
+		}
+		if run.Throughput > summary.MaxThroughput {
+			summary.MaxThroughput = run.Throughput
+		}
+	}
+	if len(runs) > 0 {
+		summary.AvgDuration /= float64(len(runs))
+		summary.AvgThroughput /= float64(len(runs))
+	}
+	return report
+}
+
+// compareRuns は直前の実行からの変化を計算する
+func compareRuns(prev, cur runStatsEntry) *runStatsChange {
+	return &runStatsChange{
+		FilesCopied:       cur.Stats.FilesCopied - prev.Stats.FilesCopied,
+		FilesFailed:       cur.Stats.FilesFailed - prev.Stats.FilesFailed,
+		BytesCopied:       cur.Stats.BytesCopied - prev.Stats.BytesCopied,
+		DurationPercent:   percentChange(prev.Duration, cur.Duration),
+		ThroughputPercent: percentChange(prev.Throughput, cur.Throughput),
+	}
+}
+
+// percentChange はbeforeからafterへの変化率（%）を返す（beforeが0の場合はnil）
+func percentChange(before, after float64) *float64 {
+	if before == 0 {
+		return nil
+	}
+	change := (after - before) / before * 100
+	return &change
+}
+
+// formatPercentChange は変化率を符号付きで表示用に整形する
+func formatPercentChange(change *float64) string {
+	if change == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%+.1f%%", *change)
+}
+
+// formatSeconds は秒数を表示用に整形する（1秒未満はミリ秒単位）
+func formatSeconds(seconds float64) string {
+	d := time.Duration(seconds * float64(time.Second))
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
+
+// printRunStats は実行ごとの統計情報と直前の実行との比較を表示する
+func printRunStats(w io.Writer, report runStatsReport) {
+	fmt.Fprintf(w, "データベース: %s\n", report.Database)
+	fmt.Fprintln(w, strings.Repeat("=", 50))
+
+	if len(report.Runs) == 0 {
+		fmt.Fprintln(w, "統計情報が記録された実行はありません。")
+		return
+	}
+
+	fmt.Fprintf(w, "%-19s  %9s  %8s  %8s  %8s  %10s  %12s  %9s  %9s  %s\n",
+		"開始日時", "所要時間", "コピー", "スキップ", "失敗", "バイト数", "転送速度", "時間の変化", "速度の変化", "ラベル")
+	for _, run := range report.Runs {
+		durationChange, throughputChange := "-", "-"
+		if run.Change != nil {
+			durationChange = formatPercentChange(run.Change.DurationPercent)
+			throughputChange = formatPercentChange(run.Change.ThroughputPercent)
+		}
+		fmt.Fprintf(w, "%-19s  %9s  %8d  %8d  %8d  %10s  %12s  %9s  %9s  %s\n",
+			run.StartTime.Format("2006-01-02 15:04:05"),
+			formatSeconds(run.Duration),
+			run.Stats.FilesCopied,
+			run.Stats.FilesSkipped,
+			run.Stats.FilesFailed,
+			formatBytes(run.Stats.BytesCopied),
+			formatBytes(int64(run.Throughput))+"/s",
+			durationChange,
+			throughputChange,
+			run.Label)
+	}
+
+	summary := report.Summary
+	fmt.Fprintln(w, strings.Repeat("-", 50))
+	fmt.Fprintf(w, "直近%d回の合計: コピー %d件, 失敗 %d件, %s\n",
+		summary.Runs, summary.FilesCopied, summary.FilesFailed, formatBytes(summary.BytesCopied))
+	fmt.Fprintf(w, "平均所要時間: %s, 転送速度: 平均 %s/s, 最小 %s/s, 最大 %s/s\n",
+		formatSeconds(summary.AvgDuration), formatBytes(int64(summary.AvgThroughput)),
+		formatBytes(int64(summary.MinThroughput)), formatBytes(int64(summary.MaxThroughput)))
+}
+
+func init() {
+	rootCmd.AddCommand(runStatsCmd)
+	runStatsCmd.AddCommand(runStatsShowCmd)
+
+	runStatsShowCmd.Flags().StringVar(&runStatsDB, "db", "", "データベースファイルのパス")
+	runStatsShowCmd.Flags().IntVar(&runStatsLast, "last", 10, "表示する直近の実行数（0ですべて）")
+	runStatsShowCmd.Flags().StringVar(&runStatsLabel, "label", "", "指定したラベルの実行のみ表示")
+	runStatsShowCmd.Flags().BoolVar(&runStatsJSON, "json", false, "統計情報をJSON形式で出力")
+}
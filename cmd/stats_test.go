@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/database"
+)
+
+func TestBuildRunStats(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	session := func(minutes int, seconds int, copied, failed, bytes int64) database.SyncSession {
+		begin := start.Add(time.Duration(minutes) * time.Minute)
+		return database.SyncSession{
+			ID:        begin.UnixNano(),
+			StartTime: begin,
+			EndTime:   begin.Add(time.Duration(seconds) * time.Second),
+			Stats:     &database.SessionStats{FilesCopied: copied, FilesFailed: failed, BytesCopied: bytes},
+		}
+	}
+	sessions := []database.SyncSession{
+		session(0, 10, 10, 0, 1000),
+		{ID: 1, StartTime: start.Add(5 * time.Minute)}, // 統計情報を記録する前の実行は対象にしない
+		session(10, 20, 20, 1, 4000),
+		session(20, 10, 5, 0, 1000),
+	}
+
+	report := buildRunStats(sessions, 2)
+	if len(report.Runs) != 2 {
+		t.Fatalf("実行数 = %d, want 2", len(report.Runs))
+	}
+	first, second := report.Runs[0], report.Runs[1]
+	if first.Duration != 20 || first.Throughput != 200 {
+		t.Errorf("所要時間・転送速度 = %v, %v; want 20, 200", first.Duration, first.Throughput)
+	}
+
+	// 表示する最初の実行も、それより前の実行と比較する
+	if first.Change == nil || first.Change.FilesCopied != 10 || first.Change.FilesFailed != 1 ||
+		*first.Change.DurationPercent != 100 || *first.Change.ThroughputPercent != 100 {
+		t.Errorf("最初の実行の変化 = %+v", first.Change)
+	}
+	if *second.Change.DurationPercent != -50 || *second.Change.ThroughputPercent != -50 {
+		t.Errorf("2回目の実行の変化 = %+v", second.Change)
+	}
+
+	summary := report.Summary
+	if summary.Runs != 2 || summary.FilesCopied != 25 || summary.BytesCopied != 5000 ||
+		summary.AvgDuration != 15 || summary.MinThroughput != 100 || summary.MaxThroughput != 200 || summary.AvgThroughput != 150 {
+		t.Errorf("集計 = %+v", summary)
+	}
+
+	if all := buildRunStats(sessions, 0); len(all.Runs) != 3 || all.Runs[0].Change != nil {
+		t.Errorf("すべての実行 = %+v", all.Runs)
+	}
+
+	var buf bytes.Buffer
+	printRunStats(&buf, report)
+	for _, want := range []string{"+100.0%", "-50.0%", "200 B/s", "直近2回の合計"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("出力に%qが含まれません:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestBuildRunStats_Empty(t *testing.T) {
+	report := buildRunStats(nil, 10)
+	if report.Runs == nil || report.Summary.Runs != 0 {
+		t.Errorf("空の結果 = %+v", report)
+	}
+	var buf bytes.Buffer
+	printRunStats(&buf, report)
+	if !strings.Contains(buf.String(), "統計情報が記録された実行はありません") {
+		t.Errorf("出力 = %s", buf.String())
+	}
+}
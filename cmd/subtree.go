@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/logger"
+	"github.com/sakuhanight/gopier/internal/runsummary"
+	"github.com/sakuhanight/gopier/internal/verifier"
+)
+
+// subtreeReport は最上位のディレクトリの検証結果として出力・通知する内容
+type subtreeReport struct {
+	verifier.SubtreeSummary
+	Passed bool              `json:"passed"`          // 利用者に公開してよいか（判定した場合は合格、しない場合は不一致なし）
+	Grade  *runsummary.Grade `json:"grade,omitempty"` // --verify-thresholdを指定した場合の判定
+}
+
+func newSubtreeReport(summary verifier.SubtreeSummary) subtreeReport {
+	report := subtreeReport{SubtreeSummary: summary, Passed: summary.Clean()}
+	if gradeThresholds != nil {
+		report.Grade = runsummary.Evaluate(gradeThresholds, summary.Verification)
+		report.Passed = report.Grade.Passed()
+	}
+	return report
+}
+
+// watchSubtrees は--verify-subtreesを指定した場合に、最上位のディレクトリの検証が完了するごとに
+// 結果をログに出力し、--subtree-summaryのファイルへの追記と通知プラグインへの通知を行う
+func watchSubtrees(log *logger.Logger, v *verifier.Verifier) error {
+	if verifySubtrees <= 0 {
+		return nil
+	}
+	if subtreeSummary != "" {
+		// 前回の実行の結果と混ざらないよう、開始時に空にする
+		if err := os.WriteFile(subtreeSummary, nil, 0644); err != nil {
+			return fmt.Errorf("ディレクトリ別の検証結果のファイルを作成できません: %w", err)
+		}
+	}
+
+	v.SetSubtreeCallback(func(summary verifier.SubtreeSummary) {
+		report := newSubtreeReport(summary)
+		s := summary.Verification
+		state := "合格"
+		if !report.Passed {
+			state = "不合格"
+		}
+		message := fmt.Sprintf("ディレクトリの検証完了: %s [%s]: 一致 %d件, 不一致 %d件, 宛先なし %d件, エラー %d件 (%s)",
+			summary.Dir, state, s.Matched, s.Mismatched+s.LinkMismatch, s.MissingDest, s.Errors,
+			summary.FinishedAt.Sub(summary.StartedAt).Round(time.Millisecond))
+		if report.Passed {
+			log.Info("%s", message)
+		} else {
+			log.Warn("%s", message)
+		}
+
+		if subtreeSummary != "" {
+			if err := appendJSONLine(subtreeSummary, report); err != nil {
+				log.Error("ディレクトリ別の検証結果の書き込みに失敗: %v", err)
+			}
+		}
+		notifyPlugins(log, "subtree_verified", report)
+	})
+	return nil
+}
+
+// appendJSONLine は値をJSONの1行としてファイルに追記する
+func appendJSONLine(path string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/sakuhanight/gopier/internal/support"
+)
+
+var (
+	supportOutput   string
+	supportDBPath   string
+	supportLogFile  string
+	supportReport   string
+	supportLogLines int
+)
+
+// supportBundleCmd represents the support-bundle command
+var supportBundleCmd = &cobra.Command{
+	Use:   "support-bundle",
+	Short: "不具合報告用の情報をzipにまとめる",
+	Long: `不具合報告に添付するための情報を1つのzipファイルにまとめます。
+
+含まれる情報:
+  version.txt      - バージョンと実行環境
+  config.yaml      - 設定ファイル（パスワードやトークンなどは伏せ字）
+  logs/            - ログファイルの末尾
+  db_summary.json  - 同期データベースの統計・最新セッション・失敗ファイル
+  report/          - 最後の検証レポート
+  problems.txt     - 収集できなかった情報とその理由
+
+ログファイル・データベース・レポートのパスは、指定がなければ設定ファイルの値を使用します。`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if supportOutput == "" {
+			supportOutput = fmt.Sprintf("gopier-support-%s.zip", time.Now().Format("20060102-150405"))
+		}
+
+		opts := support.Options{
+			ConfigPath: viper.ConfigFileUsed(),
+			LogFile:    firstNonEmpty(supportLogFile, viper.GetString("log_file")),
+			LogLines:   supportLogLines,
+			DBPath:     firstNonEmpty(supportDBPath, viper.GetString("sync_db_path"), "sync_state.db"),
+			ReportPath: firstNonEmpty(supportReport, viper.GetString("final_report")),
+			Version:    Version,
+			BuildTime:  BuildTime,
+		}
+
+		result, err := support.CreateBundle(supportOutput, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "サポートバンドルの作成に失敗: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("サポートバンドルを作成しました: %s\n", supportOutput)
+		for _, name := range result.Included {
+			fmt.Printf("  + %s\n", name)
+		}
+		for _, problem := range result.Problems {
+			fmt.Printf("  - %s\n", problem)
+		}
+	},
+}
+
+// firstNonEmpty は最初の空でない文字列を返す
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func init() {
+	rootCmd.AddCommand(supportBundleCmd)
+
+	supportBundleCmd.Flags().StringVarP(&supportOutput, "output", "o", "", "出力するzipファイルのパス（デフォルト: gopier-support-<日時>.zip）")
+	supportBundleCmd.Flags().StringVar(&supportDBPath, "db", "", "同期状態データベースのパス")
+	supportBundleCmd.Flags().StringVarP(&supportLogFile, "log", "l", "", "ログファイルのパス")
+	supportBundleCmd.Flags().StringVar(&supportReport, "report", "", "含める検証レポートのパス")
+	supportBundleCmd.Flags().IntVar(&supportLogLines, "log-lines", 2000, "含めるログの末尾の行数（0ですべて）")
+}
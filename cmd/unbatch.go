@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sakuhanight/gopier/internal/batch"
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/pathkey"
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+var (
+	unbatchDBPath    string
+	unbatchOutput    string
+	unbatchOverwrite bool
+	unbatchPrune     bool
+)
+
+// unbatchCmd represents the unbatch command
+var unbatchCmd = &cobra.Command{
+	Use:   "unbatch DEST",
+	Short: "セグメントにまとめて書き込んだファイルを個別のファイルに展開",
+	Long: `--batch-small-filesでセグメント（DEST/.gopier-batches/*.tar）にまとめて書き込んだファイルを、
+同期DBに記録した位置から読み込んで個別のファイルとして展開します。
+展開したファイルには、書き込んだ時点のソースの更新日時を設定します。
+
+--outputを指定しない場合はDESTに展開し、展開したファイルのセグメントの中の位置の記録を同期DBから削除します。
+既に存在するファイルは、--overwriteを指定しない限り上書きしません。
+--pruneを指定した場合は、展開後に同期DBから参照されなくなったセグメントを削除します。
+
+セグメントは標準のtar形式のため、同期DBがない場合もtarコマンドで展開できます
+（同じファイルを複数回書き込んだ場合は、後のセグメントの内容が新しい内容です）。
+展開に失敗したファイルがある場合は終了コード1で終了します。`,
+	Example: `  gopier unbatch /backup/data --db sync_state.db
+  gopier unbatch /backup/data --db sync_state.db --output /restore/data`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if unbatchDBPath == "" {
+			fmt.Fprintf(os.Stderr, "--dbで同期DBを指定してください\n")
+			os.Exit(1)
+		}
+		if _, err := os.Stat(unbatchDBPath); err != nil {
+			fmt.Fprintf(os.Stderr, "同期DBを開けません: %v\n", err)
+			os.Exit(1)
+		}
+
+		lock, err := lockDatabase(unbatchDBPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(errcode.ExitCode(err))
+		}
+		defer lock.Release()
+		syncDB, err := database.NewSyncDB(unbatchDBPath, database.NormalSync)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "データベース接続エラー: %v\n", err)
+			os.Exit(1)
+		}
+		defer syncDB.Close()
+
+		result, err := unbatch(syncDB, vfs.OS, args[0], unbatchOutput, unbatchOverwrite, unbatchPrune, func(path string, err error) {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "展開に失敗: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("展開: %d件, 既に存在するためスキップ: %d件, 失敗: %d件\n", result.Extracted, result.Skipped, result.Failed)
+		if unbatchPrune {
+			fmt.Printf("削除したセグメント: %d件\n", result.Pruned)
+		}
+
+		if result.Failed > 0 {
+			syncDB.Close()
+			lock.Release()
+			os.Exit(1)
+		}
+	},
+}
+
+// unbatchResult は展開の結果
+type unbatchResult struct {
+	Extracted int
+	Skipped   int
+	Failed    int
+	Pruned    int
+}
+
+// unbatch はfsys上のセグメントに書き込んだファイルをoutput（空の場合はdestDir）に展開する
+// destDirに展開した場合は、展開したファイルのセグメントの中の位置の記録を削除する
+func unbatch(db *database.SyncDB, fsys vfs.FS, destDir, output string, overwrite, prune bool, onError func(string, error)) (*unbatchResult, error) {
+	if output == "" {
+		output = destDir
+	}
+	inPlace := filepath.Clean(output) == filepath.Clean(destDir)
+
+	entries, err := db.GetBatchEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &unbatchResult{}
+	var segment vfs.File
+	defer func() {
+		if segment != nil {
+			segment.Close()
+		}
+	}()
+
+	for _, entry := range entries {
+		target := filepath.Join(output, pathkey.ToNative(entry.Path))
+		if _, err := fsys.Lstat(target); err == nil && !overwrite {
+			result.Skipped++
+			continue
+		}
+
+		// エントリはセグメントの順に並んでいるため、同じセグメントは開き直さない
+		segmentPath := filepath.Join(destDir, pathkey.ToNative(entry.Segment))
+		if segment == nil || segment.Name() != segmentPath {
+			if segment != nil {
+				segment.Close()
+				segment = nil
+			}
+			if segment, err = fsys.Open(segmentPath); err != nil {
+				segment = nil
+				result.Failed++
+				onError(entry.Path, fmt.Errorf("セグメント(%s)を開けません: %w", entry.Segment, err))
+				continue
+			}
+		}
+
+		if err := batch.Extract(fsys, segment, entry.Offset, entry.Size, entry.ModTime, target); err != nil {
+			err = fmt.Errorf("セグメント(%s)から展開できません: %w", entry.Segment, err)
+			result.Failed++
+			onError(entry.Path, err)
+			continue
+		}
+		result.Extracted++
+
+		if inPlace {
+			if err := db.DeleteBatchMember(entry.Path); err != nil {
+				onError(entry.Path, fmt.Errorf("セグメントの中の位置の記録を削除できません: %w", err))
+			}
+		}
+	}
+
+	if prune {
+		if result.Pruned, err = pruneSegments(db, fsys, destDir); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// pruneSegments は同期DBから参照されなくなったセグメントと、書き込みの途中で残ったセグメントを削除する
+func pruneSegments(db *database.SyncDB, fsys vfs.FS, destDir string) (int, error) {
+	entries, err := db.GetBatchEntries()
+	if err != nil {
+		return 0, err
+	}
+	referenced := make(map[string]bool)
+	for _, entry := range entries {
+		referenced[entry.Segment] = true
+	}
+
+	dir := filepath.Join(destDir, pathkey.ToNative(batch.Dir))
+	files, err := fsys.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("セグメントのディレクトリを読み込めません: %w", err)
+	}
+
+	pruned := 0
+	for _, f := range files {
+		if f.IsDir() || referenced[batch.Dir+"/"+f.Name()] {
+			continue
+		}
+		if err := fsys.Remove(filepath.Join(dir, f.Name())); err != nil {
+			return pruned, fmt.Errorf("セグメント(%s)を削除できません: %w", f.Name(), err)
+		}
+		pruned++
+	}
+
+	// すべてのセグメントを削除した場合はディレクトリも削除する（空でない場合は残る）
+	if len(referenced) == 0 {
+		fsys.Remove(dir)
+	}
+	return pruned, nil
+}
+
+func init() {
+	rootCmd.AddCommand(unbatchCmd)
+
+	unbatchCmd.Flags().StringVarP(&unbatchDBPath, "db", "", "", "コピー時に使用した同期DBのパス")
+	unbatchCmd.Flags().StringVarP(&unbatchOutput, "output", "o", "", "展開先のディレクトリ（デフォルト: DEST）")
+	unbatchCmd.Flags().BoolVar(&unbatchOverwrite, "overwrite", false, "既に存在するファイルを上書き")
+	unbatchCmd.Flags().BoolVar(&unbatchPrune, "prune", false, "展開後に同期DBから参照されなくなったセグメントを削除")
+}
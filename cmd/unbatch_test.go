@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/batch"
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+func TestUnbatch(t *testing.T) {
+	tempDir := t.TempDir()
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(filepath.Join(destDir, batch.Dir), 0755)
+
+	syncDB, err := database.NewSyncDB(filepath.Join(tempDir, "test.db"), database.NormalSync)
+	if err != nil {
+		t.Fatalf("DBの作成に失敗: %v", err)
+	}
+	defer syncDB.Close()
+
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	segmentName := batch.SegmentName(1, 1)
+	segment, _ := os.Create(filepath.Join(destDir, filepath.FromSlash(segmentName)))
+	w := batch.NewWriter(segment)
+	files := map[string]string{"a.txt": "aaa", "dir/b.txt": "bbbb", "exists.txt": "new"}
+	for _, name := range []string{"a.txt", "dir/b.txt", "exists.txt"} {
+		content := files[name]
+		offset, err := w.Add(name, int64(len(content)), modTime, 0644, strings.NewReader(content))
+		if err != nil {
+			t.Fatalf("セグメントへの追加に失敗: %v", err)
+		}
+		syncDB.AddBatchedFile(database.FileInfo{Path: name, Size: int64(len(content)), ModTime: modTime},
+			database.BatchMember{Segment: segmentName, Offset: offset, Size: int64(len(content)), ModTime: modTime})
+	}
+	w.Close()
+	segment.Close()
+	// 参照されないセグメント
+	os.WriteFile(filepath.Join(destDir, filepath.FromSlash(batch.SegmentName(1, 2))), []byte("garbage"), 0644)
+	os.WriteFile(filepath.Join(destDir, "exists.txt"), []byte("individual"), 0644)
+
+	onError := func(path string, err error) { t.Errorf("%s: %v", path, err) }
+
+	// 別のディレクトリへの展開では位置の記録を残し、参照されないセグメントのみ削除する
+	output := filepath.Join(tempDir, "restore")
+	result, err := unbatch(syncDB, vfs.OS, destDir, output, false, true, onError)
+	if err != nil || result.Extracted != 3 || result.Pruned != 1 {
+		t.Fatalf("別のディレクトリへの展開 = %+v, %v", result, err)
+	}
+	for name, content := range files {
+		path := filepath.Join(output, filepath.FromSlash(name))
+		got, _ := os.ReadFile(path)
+		info, _ := os.Stat(path)
+		if string(got) != content || info == nil || !info.ModTime().Equal(modTime) {
+			t.Errorf("%s = %q, %v", name, got, info)
+		}
+	}
+	if entries, _ := syncDB.GetBatchEntries(); len(entries) != 3 {
+		t.Errorf("位置の記録 = %d件, want 3", len(entries))
+	}
+
+	// 宛先への展開では既存のファイルを上書きせず、展開したファイルの記録を削除する
+	result, err = unbatch(syncDB, vfs.OS, destDir, "", false, true, onError)
+	if err != nil || result.Extracted != 2 || result.Skipped != 1 || result.Pruned != 0 {
+		t.Fatalf("宛先への展開 = %+v, %v", result, err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(destDir, "exists.txt")); string(got) != "individual" {
+		t.Errorf("既存のファイルが上書きされました: %q", got)
+	}
+	if entries, _ := syncDB.GetBatchEntries(); len(entries) != 1 || entries[0].Path != "exists.txt" {
+		t.Errorf("残った位置の記録 = %+v", entries)
+	}
+
+	// 上書きを指定するとすべて展開し、参照されなくなったセグメントを削除する
+	result, err = unbatch(syncDB, vfs.OS, destDir, "", true, true, onError)
+	if err != nil || result.Extracted != 1 || result.Pruned != 1 {
+		t.Fatalf("上書きを指定した展開 = %+v, %v", result, err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(destDir, "exists.txt")); string(got) != "new" {
+		t.Errorf("exists.txt = %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, batch.Dir)); !os.IsNotExist(err) {
+		t.Errorf("セグメントのディレクトリが残っています: %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/viper"
+
+	"github.com/sakuhanight/gopier/internal/units"
+)
+
+// 単位を省略した場合の単位（以前の整数での指定との互換性のため）
+const (
+	bufferSizeUnit      = units.MiB
+	retryWaitUnit       = time.Second
+	sharingWaitUnit     = time.Millisecond
+	verifyRetryWaitUnit = time.Millisecond
+)
+
+// parseBufferSize は--buffer（buffer_size）のバッファサイズをバイト数で返す（空の場合は0でデフォルトを使用する）
+func parseBufferSize(s string) (int, error) {
+	size, err := units.ParseSize(s, bufferSizeUnit)
+	if err != nil {
+		return 0, err
+	}
+	if size > units.GiB {
+		return 0, fmt.Errorf("バッファサイズは1G以下で指定してください: %q", s)
+	}
+	return int(size), nil
+}
+
+// parseResumeChunk は--verify-resume-chunk（verify_resume_chunk）の間隔をバイト数で返す（0は記録しない）
+// 途中の状態はチャンクの境界ごとにDBに記録するため、小さすぎる間隔は受け付けない
+func parseResumeChunk(s string) (int64, error) {
+	size, err := units.ParseSize(s, units.Byte)
+	if err != nil {
+		return 0, err
+	}
+	if size > 0 && size < units.MiB {
+		return 0, fmt.Errorf("検証の途中の状態を記録する間隔は1M以上で指定してください: %q", s)
+	}
+	return size, nil
+}
+
+// parseWait は再試行の待機時間を解析する（単位のない数値はunitの倍数）
+func parseWait(s string, unit time.Duration) (time.Duration, error) {
+	wait, err := units.ParseDuration(s, unit)
+	if err != nil {
+		return 0, err
+	}
+	if wait < 0 {
+		return 0, fmt.Errorf("待機時間には0以上の値を指定してください: %q", s)
+	}
+	return wait, nil
+}
+
+// configDecodeHook は設定ファイルの時間（time.Duration）の項目をunits.ParseDurationで解析するデコードフック
+// 単位のない数値がナノ秒として扱われないよう、0以外はエラーにする
+func configDecodeHook() viper.DecoderConfigOption {
+	return viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		func(from, to reflect.Type, data interface{}) (interface{}, error) {
+			if to != reflect.TypeOf(time.Duration(0)) || from == to {
+				return data, nil
+			}
+			return units.ParseDuration(fmt.Sprint(data), 0)
+		},
+		mapstructure.StringToSliceHookFunc(","),
+	))
+}
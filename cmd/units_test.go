@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadConfigFile_Units(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte("workers: 4\n"+content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 以前の整数での指定は、それぞれの項目の単位として扱う
+	write("buffer_size: 16\nretry_wait: 5\nsharing_wait: 200\nverify_retry_wait: 1.5s\nverify_resume_chunk: 512M\nbackends:\n  s3:\n    retry_wait: 2d\n")
+	config, err := readConfigFile(path)
+	if err != nil {
+		t.Fatalf("readConfigFile() error = %v", err)
+	}
+	if size, _ := parseBufferSize(config.BufferSize); size != 16<<20 {
+		t.Errorf("buffer_size = %d, want 16M", size)
+	}
+	for _, tt := range []struct {
+		value string
+		unit  time.Duration
+		want  time.Duration
+	}{
+		{config.RetryWait, retryWaitUnit, 5 * time.Second},
+		{config.SharingWait, sharingWaitUnit, 200 * time.Millisecond},
+		{config.VerifyRetryWait, verifyRetryWaitUnit, 1500 * time.Millisecond},
+	} {
+		if got, err := parseWait(tt.value, tt.unit); err != nil || got != tt.want {
+			t.Errorf("parseWait(%q) = %v, %v, want %v", tt.value, got, err, tt.want)
+		}
+	}
+	if size, err := parseResumeChunk(config.VerifyResumeChunk); err != nil || size != 512<<20 {
+		t.Errorf("verify_resume_chunk = %d, %v, want 512M", size, err)
+	}
+	if wait := config.Backends["s3"].RetryWait; wait != 48*time.Hour {
+		t.Errorf("backends.s3.retry_wait = %v, want 48h", wait)
+	}
+
+	// 不正な値は項目名とともにエラーにする
+	write("buffer_size: 8X\nretry_wait: -1s\nverify_resume_chunk: 64K\n")
+	if _, err := readConfigFile(path); err == nil || !strings.Contains(err.Error(), "buffer_size") || !strings.Contains(err.Error(), "retry_wait") ||
+		!strings.Contains(err.Error(), "verify_resume_chunk") {
+		t.Errorf("readConfigFile() error = %v", err)
+	}
+	// 単位のない時間がナノ秒として扱われないようにする
+	write("backends:\n  s3:\n    retry_wait: 5\n")
+	if _, err := readConfigFile(path); err == nil {
+		t.Error("単位のないbackendsの時間でエラーが発生しませんでした")
+	}
+}
@@ -0,0 +1,335 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sakuhanight/gopier/internal/baseline"
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/filter"
+	"github.com/sakuhanight/gopier/internal/stampcheck"
+	"github.com/sakuhanight/gopier/internal/structcheck"
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+var (
+	verifyBaseline string
+	verifyFormat   string
+	verifyOutput   string
+	verifyInclude  string
+	verifyExclude  string
+	verifyHashAlgo string
+	verifyAckDB    string
+	verifySuppress bool
+	verifySource   string
+	verifyDest     string
+	verifyCached   bool
+	verifyStruct   bool
+	verifyAllDirs  bool
+	verifyNoCache  bool
+	verifyStamps   bool
+)
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify SOURCE DEST [PATH...]",
+	Short: "宛先をソースとベースラインの両方と比較",
+	Long: `ソースツリーの各ファイルについて、宛先の同じパスとハッシュ値を比較します。
+--baselineで以前の同期DB（またはdb export --format jsonで書き出したファイル情報）を指定すると、
+一致しないファイルをベースラインに記録されたハッシュ値（記録がなければサイズと更新日時）とも比較し、
+コピー後にソースが変更されたのか、宛先が破損したのかを判別します。
+ソースが変わり続ける長期間の移行で、再コピーすべきファイルと調査すべきファイルを区別する場合に使用します。
+
+差分の種類:
+  source_changed - コピー後にソースが変更された（宛先はベースラインと一致）
+  dest_corrupted - 宛先が破損している（ソースはベースラインから変更されていない）
+  both_changed   - ソースと宛先の両方がベースラインと一致しない
+  mismatch       - ソースと宛先が一致せず、ベースラインに記録がない
+  missing_dest   - 宛先に存在しない
+  error          - ファイルを読み込めない
+
+出力形式:
+  text - 差分を人が読む形式で表示（デフォルト）
+  csv  - 1行に1つのファイル
+  json - 差分と種類ごとの件数
+
+ソースからの相対パス（ファイルまたはディレクトリ）を指定すると、そのパスのみを比較します。
+ツリー全体を走査せずに一部を抜き取り検査する場合に使用します（--source・--destinationで指定した場合、引数はすべてパスです）。
+--use-cached-hashesを指定すると、サイズと更新日時がベースラインの記録と一致するソースは読み込まずに記録されたハッシュを使用します。
+
+--drop-cacheを指定すると、キャッシュを経由せずにディスクから読み込んでハッシュ値を計算します（Linux・Windowsのみ）。
+定期的にビット腐敗を検査する場合に、キャッシュに残った内容ではなくディスク上の内容を検証します。
+
+--suppress-acknowledgedを指定すると、db ackで確認済みとして登録したパスの差分を報告しません。
+
+--structureを指定すると、ファイルの内容を読み込まずに、ディレクトリごとのファイル数・ディレクトリ数とエントリの名前のみを比較します。
+巨大なツリーで時間のかかるハッシュの検証を始める前に、欠落や余分なエントリを短時間で確認する場合に使用します。
+--all-dirsを指定すると、一致したディレクトリのエントリ数もCSV・JSONに出力します。
+
+--stampsを指定すると、ソースと比較せずに、宛先（引数は1つ）の各ファイルの内容を--stamp-xattrで拡張属性
+（WindowsではADS）に記録したハッシュと比較します。同期DBやソースにアクセスできない環境で宛先を検証する場合に使用します。
+
+ソースの変更以外の差分がある場合は終了コード4で終了します。`,
+	Example: `  gopier verify ./src ./dst --baseline sync_state.db
+  gopier verify --source ./src --destination ./dst --baseline sync_state.db --use-cached-hashes projects/2024 docs/report.pdf
+  gopier verify /mnt/share /backup/share --structure --all-dirs --format csv -o dirs.csv
+  gopier verify --stamps /backup/share --drop-cache`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if verifyStamps {
+			return cobra.ExactArgs(1)(cmd, args)
+		}
+		if verifySource != "" || verifyDest != "" {
+			if verifySource == "" || verifyDest == "" {
+				return fmt.Errorf("--sourceと--destinationの両方を指定してください")
+			}
+			return nil
+		}
+		return cobra.MinimumNArgs(2)(cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if verifyFormat != "text" && verifyFormat != "csv" && verifyFormat != "json" {
+			fmt.Fprintf(os.Stderr, "サポートされていない出力形式: %s\n", verifyFormat)
+			os.Exit(1)
+		}
+		if verifyNoCache && !vfs.UncachedSupported {
+			fmt.Fprintf(os.Stderr, "--drop-cacheはこの環境では対応していません（LinuxとWindowsのみ）\n")
+			os.Exit(1)
+		}
+		if verifyCached && verifyBaseline == "" {
+			fmt.Fprintf(os.Stderr, "--use-cached-hashesには--baselineの指定が必要です\n")
+			os.Exit(1)
+		}
+
+		if verifyStamps {
+			if verifySource != "" || verifyDest != "" || verifyBaseline != "" || verifyStruct {
+				fmt.Fprintf(os.Stderr, "--stampsは--source・--destination・--baseline・--structureと同時に使用できません\n")
+				os.Exit(1)
+			}
+			runStampCheck(args[0])
+			return
+		}
+
+		source, dest, paths := verifySource, verifyDest, args
+		if source == "" {
+			source, dest, paths = args[0], args[1], args[2:]
+		}
+
+		if verifyStruct {
+			if verifyBaseline != "" || len(paths) > 0 {
+				fmt.Fprintf(os.Stderr, "--structureは--baseline・比較するパスの指定と同時に使用できません\n")
+				os.Exit(1)
+			}
+			runStructureCheck(source, dest)
+			return
+		}
+
+		var acks database.Acknowledgements
+		if verifySuppress {
+			var err error
+			if acks, err = loadAcknowledgements(verifyAckDB); err != nil {
+				fmt.Fprintf(os.Stderr, "確認済みの不一致の読み込みに失敗: %v\n", err)
+				os.Exit(errcode.ExitCode(err))
+			}
+		}
+
+		var records map[string]database.FileInfo
+		if verifyBaseline != "" {
+			var err error
+			if records, err = baseline.Load(verifyBaseline); err != nil {
+				fmt.Fprintf(os.Stderr, "ベースラインの読み込みに失敗: %v\n", err)
+				os.Exit(errcode.ExitCode(err))
+			}
+		}
+
+		result, err := baseline.Compare(source, dest, baseline.Options{
+			Baseline:        verifyBaseline,
+			HashAlgorithm:   verifyHashAlgo,
+			Filter:          filter.NewFilter(verifyInclude, verifyExclude),
+			Records:         records,
+			Paths:           paths,
+			UseCachedHashes: verifyCached,
+			DropCache:       verifyNoCache,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "比較に失敗: %v\n", err)
+			os.Exit(1)
+		}
+		if verifySuppress {
+			result.Suppress(isAcknowledged(acks))
+		}
+
+		if err := writeBaselineResult(result, verifyFormat, verifyOutput); err != nil {
+			fmt.Fprintf(os.Stderr, "比較結果の出力に失敗: %v\n", err)
+			os.Exit(1)
+		}
+		if verifyOutput != "" {
+			fmt.Printf("比較: %d件, 差分: %d件 (%s)\n", result.Compared, len(result.Entries), verifyOutput)
+		}
+
+		if result.Damaged() {
+			os.Exit(errcode.ExitVerifyFailed)
+		}
+	},
+}
+
+// runStructureCheck はディレクトリごとのエントリ数と名前のみを比較し、差分がある場合は終了コード4で終了する
+func runStructureCheck(source, dest string) {
+	result, err := structcheck.Compare(source, dest, structcheck.Options{
+		Filter:  filter.NewFilter(verifyInclude, verifyExclude),
+		AllDirs: verifyAllDirs,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "比較に失敗: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := writeStructureResult(result, verifyFormat, verifyOutput); err != nil {
+		fmt.Fprintf(os.Stderr, "比較結果の出力に失敗: %v\n", err)
+		os.Exit(1)
+	}
+	if verifyOutput != "" {
+		fmt.Printf("比較: %dディレクトリ, 不一致: %dディレクトリ (%s)\n", result.Dirs, result.Mismatched, verifyOutput)
+	}
+
+	if result.Mismatched > 0 {
+		os.Exit(errcode.ExitVerifyFailed)
+	}
+}
+
+// runStampCheck は宛先の各ファイルを拡張属性に記録したハッシュと比較し、不一致がある場合は終了コード4で終了する
+func runStampCheck(dest string) {
+	result, err := stampcheck.Verify(dest, stampcheck.Options{
+		Filter:    filter.NewFilter(verifyInclude, verifyExclude),
+		DropCache: verifyNoCache,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "検証に失敗: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := writeStampResult(result, verifyFormat, verifyOutput); err != nil {
+		fmt.Fprintf(os.Stderr, "検証結果の出力に失敗: %v\n", err)
+		os.Exit(1)
+	}
+	if verifyOutput != "" {
+		fmt.Printf("検証: %d件, 一致: %d件, 不一致・エラー: %d件 (%s)\n", result.Files, result.Matched, len(result.Entries)-result.Counts[stampcheck.KindUnstamped], verifyOutput)
+	}
+
+	if result.Damaged() {
+		os.Exit(errcode.ExitVerifyFailed)
+	}
+}
+
+// writeStampResult は記録したハッシュとの比較結果を指定された形式で書き出す（出力先が空の場合は標準出力）
+func writeStampResult(result *stampcheck.Result, format, outputPath string) error {
+	write := stampcheck.WriteText
+	switch format {
+	case "csv":
+		write = stampcheck.WriteCSV
+	case "json":
+		write = stampcheck.WriteJSON
+	}
+
+	if outputPath == "" {
+		return write(os.Stdout, result)
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("ファイル作成エラー: %w", err)
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	if err := write(w, result); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// writeBaselineResult は比較結果を指定された形式で書き出す（出力先が空の場合は標準出力）
+func writeBaselineResult(result *baseline.Result, format, outputPath string) error {
+	write := baseline.WriteText
+	switch format {
+	case "csv":
+		write = baseline.WriteCSV
+	case "json":
+		write = baseline.WriteJSON
+	}
+
+	if outputPath == "" {
+		return write(os.Stdout, result)
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("ファイル作成エラー: %w", err)
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	if err := write(w, result); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// writeStructureResult は構造の比較結果を指定された形式で書き出す（出力先が空の場合は標準出力）
+func writeStructureResult(result *structcheck.Result, format, outputPath string) error {
+	write := structcheck.WriteText
+	switch format {
+	case "csv":
+		write = structcheck.WriteCSV
+	case "json":
+		write = structcheck.WriteJSON
+	}
+
+	if outputPath == "" {
+		return write(os.Stdout, result)
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("ファイル作成エラー: %w", err)
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	if err := write(w, result); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+
+	verifyCmd.Flags().StringVarP(&verifySource, "source", "s", "", "ソースディレクトリ（指定した場合、引数はすべて比較するパス）")
+	verifyCmd.Flags().StringVarP(&verifyDest, "destination", "d", "", "宛先ディレクトリ（--sourceと同時に指定）")
+	verifyCmd.Flags().StringVar(&verifyBaseline, "baseline", "", "ベースラインの同期DBまたはdb export --format jsonのファイル（省略時はソースと宛先のみ比較）")
+	verifyCmd.Flags().StringVar(&verifyFormat, "format", "text", "出力形式 (text, csv, json)")
+	verifyCmd.Flags().StringVarP(&verifyOutput, "output", "o", "", "出力ファイルのパス（省略時は標準出力）")
+	verifyCmd.Flags().StringVarP(&verifyInclude, "include", "i", "", "含めるファイルパターン（例: *.txt,*.docx）")
+	verifyCmd.Flags().StringVarP(&verifyExclude, "exclude", "e", "", "除外するファイルパターン（例: *.tmp,*.bak）")
+	verifyCmd.Flags().StringVar(&verifyHashAlgo, "hash-algorithm", "sha256", "ハッシュアルゴリズム (md5, sha1, sha256, sha512)")
+	verifyCmd.Flags().BoolVar(&verifyCached, "use-cached-hashes", false, "サイズと更新日時がベースラインの記録と一致するソースは読み込まずに記録されたハッシュを使用")
+	verifyCmd.Flags().BoolVar(&verifyNoCache, "drop-cache", false, "キャッシュを経由せずにディスクから読み込んでハッシュ値を計算（Linux・Windowsのみ）")
+	verifyCmd.Flags().BoolVar(&verifySuppress, "suppress-acknowledged", false, "db ackで確認済みとして登録したパスの差分を報告しない")
+	verifyCmd.Flags().BoolVar(&verifyStruct, "structure", false, "ハッシュを計算せずに、ディレクトリごとのエントリ数と名前のみを比較")
+	verifyCmd.Flags().BoolVar(&verifyStamps, "stamps", false, "ソースと比較せずに、宛先の各ファイルを--stamp-xattrで記録したハッシュと比較")
+	verifyCmd.Flags().BoolVar(&verifyAllDirs, "all-dirs", false, "--structureで一致したディレクトリのエントリ数も出力")
+	verifyCmd.Flags().StringVar(&verifyAckDB, "db", "sync_state.db", "--suppress-acknowledgedで使用する同期状態データベースのパス")
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sakuhanight/gopier/internal/logger"
+)
+
+// verifyDestination は検証時に宛先として読み込むディレクトリを返す
+func verifyDestination() string {
+	if verifyVia != "" {
+		return verifyVia
+	}
+	return destDir
+}
+
+// checkVerifyVia は検証用の経路がディレクトリとして読み込めるかを確認する
+// 宛先と同じディレクトリを同じ経路で指している場合は、独立した経路にならないため警告を返す
+func checkVerifyVia(dest, via string) (string, error) {
+	viaInfo, err := os.Stat(via)
+	if err != nil {
+		return "", fmt.Errorf("検証用の経路にアクセスできません: %w", err)
+	}
+	if !viaInfo.IsDir() {
+		return "", fmt.Errorf("検証用の経路がディレクトリではありません: %s", via)
+	}
+
+	if filepath.Clean(dest) == filepath.Clean(via) {
+		return "検証用の経路が宛先と同じパスです。プロトコルによる破損は検出できません", nil
+	}
+	if destInfo, err := os.Stat(dest); err == nil && os.SameFile(destInfo, viaInfo) {
+		return "検証用の経路が宛先と同じマウントを指しています。読み込みがキャッシュされ、プロトコルによる破損を検出できない可能性があります", nil
+	}
+	return "", nil
+}
+
+// prepareVerifyVia は検証の前に検証用の経路を確認し、使用できない場合はエラーを返す
+func prepareVerifyVia(log *logger.Logger) error {
+	if verifyVia == "" {
+		return nil
+	}
+	warning, err := checkVerifyVia(destDir, verifyVia)
+	if err != nil {
+		return err
+	}
+	if warning != "" {
+		log.Warn("%s", warning)
+	}
+	log.Info("宛先を別の経路から読み込んで検証します: %s", verifyVia)
+	return nil
+}
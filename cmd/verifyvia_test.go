@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckVerifyVia(t *testing.T) {
+	tempDir := t.TempDir()
+	dest := filepath.Join(tempDir, "dest")
+	via := filepath.Join(tempDir, "via")
+	os.MkdirAll(dest, 0755)
+	os.MkdirAll(via, 0755)
+
+	if warning, err := checkVerifyVia(dest, via); err != nil || warning != "" {
+		t.Errorf("別の経路: warning=%q, err=%v", warning, err)
+	}
+	if warning, err := checkVerifyVia(dest, dest+string(filepath.Separator)); err != nil || warning == "" {
+		t.Errorf("同じパス: warning=%q, err=%v; want 警告", warning, err)
+	}
+
+	link := filepath.Join(tempDir, "link")
+	if err := os.Symlink(dest, link); err == nil {
+		if warning, err := checkVerifyVia(dest, link); err != nil || warning == "" {
+			t.Errorf("同じディレクトリ: warning=%q, err=%v; want 警告", warning, err)
+		}
+	}
+
+	if _, err := checkVerifyVia(dest, filepath.Join(tempDir, "missing")); err == nil {
+		t.Error("存在しない経路でエラーになりません")
+	}
+	file := filepath.Join(tempDir, "file")
+	os.WriteFile(file, nil, 0644)
+	if _, err := checkVerifyVia(dest, file); err == nil {
+		t.Error("ファイルを指定した場合にエラーになりません")
+	}
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/viper"
+
+	"github.com/sakuhanight/gopier/internal/database"
+)
+
+// workDir は相対パスの基準にする作業ディレクトリ（--workdir、空の場合は現在のディレクトリ）
+var workDir string
+
+// applyWorkDir は作業ディレクトリに移動し、以降に使用する相対パスがすべて作業ディレクトリを基準にするようにする
+// 設定ファイル（--config）は移動する前に読み込むため、実行したディレクトリを基準にする
+func applyWorkDir() error {
+	if workDir == "" {
+		return nil
+	}
+	dir, err := filepath.Abs(workDir)
+	if err != nil {
+		return fmt.Errorf("作業ディレクトリ(%s)のパスを解決できません: %w", workDir, err)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("作業ディレクトリ(%s)を確認できません: %w", workDir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("作業ディレクトリ(%s)はディレクトリではありません", workDir)
+	}
+	// 移動した後に設定ファイルのパスを使用する場合（再読み込み・scheduleなど）に備えて絶対パスにしておく
+	cfgFile = absPath(cfgFile)
+	if used := viper.ConfigFileUsed(); used != "" {
+		viper.SetConfigFile(absPath(used))
+	}
+	if err := os.Chdir(dir); err != nil {
+		return fmt.Errorf("作業ディレクトリ(%s)に移動できません: %w", workDir, err)
+	}
+	workDir = dir
+	return nil
+}
+
+// configWorkDir は設定ファイルのworkdirを返す（相対パスは設定ファイルのディレクトリを基準にする）
+// 設定ファイルと同じ場所にあるデータを、設定ファイルを置いた場所によらず参照できるようにする
+func configWorkDir(dir, configFile string) string {
+	if dir == "" || filepath.IsAbs(dir) || configFile == "" {
+		return dir
+	}
+	return filepath.Join(filepath.Dir(configFile), dir)
+}
+
+// sessionPaths はセッションに記録する作業ディレクトリと、ソース・宛先・DB・ログの絶対パスを返す
+func sessionPaths() *database.SessionPaths {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	return &database.SessionPaths{
+		WorkDir:     wd,
+		Source:      absPath(sourceDir),
+		Destination: absPath(destDir),
+		SyncDB:      absPath(syncDBPath),
+		LogFile:     absPath(logFile),
+	}
+}
+
+// absPath はパスを絶対パスにする（空の場合や解決できない場合はそのまま返す）
+func absPath(path string) string {
+	if path == "" {
+		return ""
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestApplyWorkDir(t *testing.T) {
+	original, _ := os.Getwd()
+	originalWorkDir, originalSource, originalDest, originalDB, originalLog := workDir, sourceDir, destDir, syncDBPath, logFile
+	defer func() {
+		os.Chdir(original)
+		workDir, sourceDir, destDir, syncDBPath, logFile = originalWorkDir, originalSource, originalDest, originalDB, originalLog
+	}()
+
+	root, _ := filepath.EvalSymlinks(t.TempDir())
+	workDir = root
+	sourceDir, destDir, syncDBPath, logFile = "src", filepath.Join(root, "dst"), "state/sync.db", ""
+	if err := applyWorkDir(); err != nil {
+		t.Fatalf("applyWorkDir() error = %v", err)
+	}
+
+	paths := sessionPaths()
+	if paths.WorkDir != root || paths.Source != filepath.Join(root, "src") || paths.Destination != filepath.Join(root, "dst") ||
+		paths.SyncDB != filepath.Join(root, "state", "sync.db") || paths.LogFile != "" {
+		t.Errorf("sessionPaths() = %+v", paths)
+	}
+
+	workDir = filepath.Join(root, "missing")
+	if err := applyWorkDir(); err == nil {
+		t.Error("存在しない作業ディレクトリでエラーになりません")
+	}
+}
+
+func TestConfigWorkDir(t *testing.T) {
+	config := filepath.Join("etc", "gopier", "job.yaml")
+	abs, _ := filepath.Abs("data")
+	tests := []struct {
+		dir, configFile, want string
+	}{
+		{"", config, ""},
+		{"data", config, filepath.Join("etc", "gopier", "data")},
+		{".", config, filepath.Join("etc", "gopier")},
+		{abs, config, abs},
+		{"data", "", "data"},
+	}
+	for _, tt := range tests {
+		if got := configWorkDir(tt.dir, tt.configFile); got != tt.want {
+			t.Errorf("configWorkDir(%q, %q) = %q, want %q", tt.dir, tt.configFile, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+// Package acldiff はミラーされた2つのディレクトリツリーのアクセス権（所有者とACL）を比較する
+package acldiff
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/sakuhanight/gopier/internal/filter"
+	"github.com/sakuhanight/gopier/internal/fsmeta"
+	"github.com/sakuhanight/gopier/internal/pathkey"
+)
+
+// 差分の種類
+const (
+	KindChanged     = "changed"      // 所有者またはACLが異なる
+	KindMissingDest = "missing_dest" // 宛先に存在しない
+	KindError       = "error"        // ACLを取得できない
+)
+
+// Entry はパスごとの比較結果を表す構造体
+type Entry struct {
+	Path  string `json:"path"`
+	Kind  string `json:"kind"`
+	Error string `json:"error,omitempty"`
+	fsmeta.ACLDiff
+}
+
+// Result は比較結果全体を表す構造体
+type Result struct {
+	Source   string  `json:"source"`
+	Dest     string  `json:"dest"`
+	Compared int     `json:"compared"`
+	Entries  []Entry `json:"entries"`
+}
+
+// HasDifferences は差分またはエラーがあるかどうかを返す
+func (r *Result) HasDifferences() bool {
+	return len(r.Entries) > 0
+}
+
+// readACL はACLの取得関数（テストで差し替えられるように変数にしている）
+var readACL = fsmeta.ReadACL
+
+// Compare はソースツリーの各ファイル・ディレクトリについて、宛先の同じパスとアクセス権を比較する
+// フィルタはファイルにのみ適用し、ディレクトリはすべて比較する
+func Compare(source, dest string, f *filter.Filter) (*Result, error) {
+	if _, err := os.Stat(source); err != nil {
+		return nil, fmt.Errorf("ソースディレクトリにアクセスできません: %w", err)
+	}
+	if _, err := os.Stat(dest); err != nil {
+		return nil, fmt.Errorf("宛先ディレクトリにアクセスできません: %w", err)
+	}
+
+	result := &Result{Source: source, Dest: dest, Entries: []Entry{}}
+	err := filepath.WalkDir(source, func(path string, d fs.DirEntry, err error) error {
+		rel, relErr := pathkey.Rel(source, path)
+		if relErr != nil {
+			return relErr
+		}
+		if err != nil {
+			result.Entries = append(result.Entries, Entry{Path: rel, Kind: KindError, Error: err.Error()})
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() && f != nil && !f.ShouldInclude(path) {
+			return nil
+		}
+
+		result.Compared++
+		if entry, ok := compareOne(rel, path, filepath.Join(dest, pathkey.ToNative(rel))); ok {
+			result.Entries = append(result.Entries, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// compareOne は1つのパスのアクセス権を比較する。差分がない場合はfalseを返す
+func compareOne(rel, sourcePath, destPath string) (Entry, bool) {
+	entry := Entry{Path: rel}
+
+	if _, err := os.Lstat(destPath); os.IsNotExist(err) {
+		entry.Kind = KindMissingDest
+		return entry, true
+	}
+
+	sourceACL, err := readACL(sourcePath)
+	if err != nil {
+		entry.Kind = KindError
+		entry.Error = fmt.Sprintf("ソース: %v", err)
+		return entry, true
+	}
+	destACL, err := readACL(destPath)
+	if err != nil {
+		entry.Kind = KindError
+		entry.Error = fmt.Sprintf("宛先: %v", err)
+		return entry, true
+	}
+
+	entry.ACLDiff = fsmeta.DiffACL(sourceACL, destACL)
+	if entry.ACLDiff.Empty() {
+		return entry, false
+	}
+	entry.Kind = KindChanged
+	return entry, true
+}
+
+// WriteJSON は比較結果をJSONで書き出す
+func WriteJSON(w io.Writer, result *Result) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+// WriteCSV は比較結果をCSVで書き出す
+// 1行に1つの変更（所有者の変更、追加・削除されたACE、宛先の欠落、エラー）を出力する
+func WriteCSV(w io.Writer, result *Result) error {
+	writer := csv.NewWriter(w)
+	header := []string{"path", "change", "principal", "type", "mask", "flags", "from", "to", "error"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	aceRow := func(path, change string, ace fsmeta.ACE) []string {
+		return []string{path, change, ace.Principal, ace.Type,
+			fmt.Sprintf("0x%08x", ace.Mask), strconv.FormatUint(uint64(ace.Flags), 10), "", "", ""}
+	}
+
+	for _, entry := range result.Entries {
+		var rows [][]string
+		switch entry.Kind {
+		case KindMissingDest:
+			rows = append(rows, []string{entry.Path, KindMissingDest, "", "", "", "", "", "", ""})
+		case KindError:
+			rows = append(rows, []string{entry.Path, KindError, "", "", "", "", "", "", entry.Error})
+		default:
+			if entry.OwnerChanged() {
+				rows = append(rows, []string{entry.Path, "owner", "", "", "", "", entry.OwnerFrom, entry.OwnerTo, ""})
+			}
+			for _, ace := range entry.Removed {
+				rows = append(rows, aceRow(entry.Path, "ace_removed", ace))
+			}
+			for _, ace := range entry.Added {
+				rows = append(rows, aceRow(entry.Path, "ace_added", ace))
+			}
+		}
+		for _, row := range rows {
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteText は比較結果を人が読む形式で書き出す
+func WriteText(w io.Writer, result *Result) error {
+	for _, entry := range result.Entries {
+		switch entry.Kind {
+		case KindMissingDest:
+			fmt.Fprintf(w, "%s: 宛先に存在しません\n", entry.Path)
+		case KindError:
+			fmt.Fprintf(w, "%s: エラー: %s\n", entry.Path, entry.Error)
+		default:
+			fmt.Fprintf(w, "%s:\n", entry.Path)
+			if entry.OwnerChanged() {
+				fmt.Fprintf(w, "  所有者: %s -> %s\n", entry.OwnerFrom, entry.OwnerTo)
+			}
+			for _, ace := range entry.Removed {
+				fmt.Fprintf(w, "  - %s\n", ace)
+			}
+			for _, ace := range entry.Added {
+				fmt.Fprintf(w, "  + %s\n", ace)
+			}
+		}
+	}
+	_, err := fmt.Fprintf(w, "比較: %d件, 差分: %d件\n", result.Compared, len(result.Entries))
+	return err
+}
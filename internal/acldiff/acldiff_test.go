@@ -0,0 +1,150 @@
+package acldiff
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sakuhanight/gopier/internal/filter"
+	"github.com/sakuhanight/gopier/internal/fsmeta"
+)
+
+// stubACLs はパスの末尾（ソース・宛先のディレクトリ名を含む）ごとに返すACLを差し替える
+func stubACLs(t *testing.T, acls map[string]*fsmeta.ACL) {
+	t.Helper()
+	original := readACL
+	readACL = func(path string) (*fsmeta.ACL, error) {
+		for suffix, acl := range acls {
+			if strings.HasSuffix(filepath.ToSlash(path), suffix) {
+				if acl == nil {
+					return nil, errors.New("access denied")
+				}
+				return acl, nil
+			}
+		}
+		return &fsmeta.ACL{Owner: "0:0"}, nil
+	}
+	t.Cleanup(func() { readACL = original })
+}
+
+func setupTrees(t *testing.T) (string, string) {
+	t.Helper()
+	base := t.TempDir()
+	source := filepath.Join(base, "src")
+	dest := filepath.Join(base, "dst")
+	for _, dir := range []string{source, dest} {
+		os.MkdirAll(filepath.Join(dir, "sub"), 0755)
+		os.WriteFile(filepath.Join(dir, "same.txt"), []byte("x"), 0644)
+		os.WriteFile(filepath.Join(dir, "sub", "changed.txt"), []byte("x"), 0644)
+		os.WriteFile(filepath.Join(dir, "denied.txt"), []byte("x"), 0644)
+	}
+	os.WriteFile(filepath.Join(source, "missing.txt"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(source, "skip.tmp"), []byte("x"), 0644)
+	return source, dest
+}
+
+func TestCompare(t *testing.T) {
+	source, dest := setupTrees(t)
+	stubACLs(t, map[string]*fsmeta.ACL{
+		"src/sub/changed.txt": {Owner: "1000:1000", Entries: []fsmeta.ACE{{Principal: "user:1001", Type: "allow", Mask: 4}}},
+		"dst/sub/changed.txt": {Owner: "0:0", Entries: []fsmeta.ACE{{Principal: "user:1002", Type: "allow", Mask: 4}}},
+		"dst/denied.txt":      nil,
+	})
+
+	result, err := Compare(source, dest, filter.NewFilter("", "*.tmp"))
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+
+	// ルート、sub、same.txt、sub/changed.txt、denied.txt、missing.txt
+	if result.Compared != 6 {
+		t.Errorf("Compared = %d, want 6", result.Compared)
+	}
+
+	kinds := make(map[string]Entry)
+	for _, entry := range result.Entries {
+		kinds[entry.Path] = entry
+	}
+	if len(kinds) != 3 {
+		t.Fatalf("差分 = %+v, want 3件", result.Entries)
+	}
+	if kinds["missing.txt"].Kind != KindMissingDest {
+		t.Errorf("missing.txt = %+v", kinds["missing.txt"])
+	}
+	if entry := kinds["denied.txt"]; entry.Kind != KindError || !strings.Contains(entry.Error, "宛先") {
+		t.Errorf("denied.txt = %+v", entry)
+	}
+	changed := kinds["sub/changed.txt"]
+	if changed.Kind != KindChanged || !changed.OwnerChanged() || len(changed.Added) != 1 || len(changed.Removed) != 1 {
+		t.Errorf("sub/changed.txt = %+v", changed)
+	}
+	if !result.HasDifferences() {
+		t.Error("HasDifferences() = false")
+	}
+}
+
+func TestCompare_MissingRoot(t *testing.T) {
+	if _, err := Compare(filepath.Join(t.TempDir(), "none"), t.TempDir(), nil); err == nil {
+		t.Error("存在しないソースでエラーになりません")
+	}
+}
+
+func TestWriters(t *testing.T) {
+	result := &Result{
+		Source:   "src",
+		Dest:     "dst",
+		Compared: 3,
+		Entries: []Entry{
+			{Path: "a.txt", Kind: KindChanged, ACLDiff: fsmeta.ACLDiff{
+				OwnerFrom: "1:1", OwnerTo: "2:2",
+				Added:   []fsmeta.ACE{{Principal: "S-1-5-32-545", Type: "allow", Mask: 0x1200a9, Flags: 3}},
+				Removed: []fsmeta.ACE{{Principal: "S-1-5-32-544", Type: "allow", Mask: 0x1f01ff}},
+			}},
+			{Path: "b.txt", Kind: KindMissingDest},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, result); err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("CSVとして読み込めません: %v", err)
+	}
+	// ヘッダー、所有者、削除、追加、欠落
+	if len(records) != 5 {
+		t.Fatalf("行数 = %d, want 5: %v", len(records), records)
+	}
+	if records[1][1] != "owner" || records[2][1] != "ace_removed" || records[3][1] != "ace_added" || records[4][1] != KindMissingDest {
+		t.Errorf("変更の種類が期待値と異なります: %v", records)
+	}
+	if records[3][2] != "S-1-5-32-545" || records[3][4] != "0x001200a9" || records[3][5] != "3" {
+		t.Errorf("追加されたACEの行 = %v", records[3])
+	}
+
+	buf.Reset()
+	if err := WriteJSON(&buf, result); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	var decoded Result
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("JSONとして読み込めません: %v", err)
+	}
+	if len(decoded.Entries) != 2 || decoded.Entries[0].OwnerTo != "2:2" || len(decoded.Entries[0].Added) != 1 {
+		t.Errorf("JSONの内容が期待値と異なります: %+v", decoded)
+	}
+
+	buf.Reset()
+	if err := WriteText(&buf, result); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "所有者: 1:1 -> 2:2") || !strings.Contains(buf.String(), "差分: 2件") {
+		t.Errorf("テキスト出力 = %s", buf.String())
+	}
+}
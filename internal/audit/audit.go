@@ -0,0 +1,268 @@
+// Package audit はコンプライアンスのための監査ログを追記専用のJSONL（1行に1つのJSONオブジェクト）で書き出す
+// 完了した操作ごとに、誰が・いつ・何を・どのハッシュで・どういう結果で行ったかを記録する
+// 各レコードには直前のレコード（行）のSHA-256を含めるため、行の改ざん・削除・並べ替えをVerifyで検出できる
+// 通常のログとは異なりローテーションは行わず、既存のファイルには続きから追記する
+// nilのLogは何も記録しない
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"sync"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/database"
+)
+
+// 操作の種類
+const (
+	ActionCopy       = "copy"       // ファイルのコピー
+	ActionSkip       = "skip"       // 宛先と同一などのためコピーしなかった
+	ActionMetadata   = "metadata"   // 内容が同じため更新日時とアクセス権のみ更新した
+	ActionVerify     = "verify"     // ハッシュの検証
+	ActionDelete     = "delete"     // 余分なファイルの削除
+	ActionQuarantine = "quarantine" // 余分なファイルの隔離
+)
+
+// 操作の結果
+const (
+	ResultSuccess      = "success"       // 成功（コピー・スキップ・削除・隔離）
+	ResultFailed       = "failed"        // 失敗
+	ResultMatched      = "matched"       // 検証で内容が一致した
+	ResultMismatched   = "mismatched"    // 検証で内容が一致しなかった
+	ResultIntermittent = "intermittent"  // 検証で一度一致せず、再検証で一致した
+	ResultMissingDest  = "missing_dest"  // 検証で宛先が存在しなかった
+	ResultLinkMismatch = "link_mismatch" // 検証でリンク先が一致しなかった
+	ResultExtra        = "extra"         // 検証で宛先にのみ存在した
+)
+
+// Record は監査ログの1レコード
+type Record struct {
+	Seq        int64     `json:"seq"`  // ファイル内の通し番号（1から）
+	Time       time.Time `json:"time"` // 操作が完了した日時
+	User       string    `json:"user"` // 実行したユーザー
+	Host       string    `json:"host,omitempty"`
+	SessionID  int64     `json:"session_id,omitempty"`
+	Action     string    `json:"action"`
+	Path       string    `json:"path"` // ソースからの相対パス
+	Size       int64     `json:"size,omitempty"`
+	HashAlgo   string    `json:"hash_algo,omitempty"`
+	SourceHash string    `json:"source_hash,omitempty"`
+	DestHash   string    `json:"dest_hash,omitempty"`
+	Result     string    `json:"result"`
+	Error      string    `json:"error,omitempty"`
+	Prev       string    `json:"prev"` // 直前の行のSHA-256（先頭のレコードは空）
+}
+
+// Log は追記専用の監査ログ
+type Log struct {
+	mu   sync.Mutex
+	file *os.File
+	user string
+	host string
+	seq  int64
+	prev string
+	err  error // 最初の書き込みエラー（以降のレコードは書き込まない）
+}
+
+// Open は監査ログを開く（存在しない場合は作成する）
+// 既存のファイルは最後のレコードから通し番号とハッシュの連鎖を引き継ぐ
+func Open(path string) (*Log, error) {
+	l := &Log{user: currentUser()}
+	l.host, _ = os.Hostname()
+
+	last, err := readLastLine(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("監査ログの読み込みエラー: %w", err)
+	}
+	if len(last) > 0 {
+		var rec Record
+		if err := json.Unmarshal(last, &rec); err != nil {
+			return nil, fmt.Errorf("監査ログの最後のレコードが不正です（追記すると連鎖が途切れるため中断します）: %w", err)
+		}
+		l.seq = rec.Seq
+		l.prev = lineHash(last)
+	}
+
+	l.file, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+	if err != nil {
+		return nil, fmt.Errorf("監査ログのオープンエラー: %w", err)
+	}
+	return l, nil
+}
+
+// Write はレコードを追記する（通し番号・ユーザー・ホスト・直前の行のハッシュは自動的に設定する）
+// 異常終了した場合にも失われないよう、バッファせずに1行ずつ書き込む
+// 書き込みに失敗した場合は、途中まで書いた行の後に続けると連鎖が壊れるため、以降のレコードは書き込まない
+func (l *Log) Write(rec Record) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.err != nil {
+		return l.err
+	}
+
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+	rec.Seq = l.seq + 1
+	rec.User = l.user
+	rec.Host = l.host
+	rec.Prev = l.prev
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("監査ログのシリアライズエラー: %w", err)
+	}
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		l.err = fmt.Errorf("監査ログの書き込みエラー: %w", err)
+		return l.err
+	}
+
+	l.seq = rec.Seq
+	l.prev = lineHash(line)
+	return nil
+}
+
+// Err は最初の書き込みエラーを返す（エラーがない場合はnil）
+func (l *Log) Err() error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+// Close はディスクに書き込んでから監査ログを閉じる
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.file.Sync(); err != nil {
+		l.file.Close()
+		return err
+	}
+	return l.file.Close()
+}
+
+// Verify は監査ログのハッシュの連鎖と通し番号を検証し、検証したレコード数を返す
+// 最初に見つかった不整合を行番号とともにエラーで返す
+func Verify(r io.Reader) (int64, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var count int64
+	var prev string
+	for line := int64(1); scanner.Scan(); line++ {
+		data := scanner.Bytes()
+		var rec Record
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return count, fmt.Errorf("%d行目: レコードの形式が不正です: %w", line, err)
+		}
+		if rec.Prev != prev {
+			return count, fmt.Errorf("%d行目: 直前の行のハッシュが一致しません（改ざん・削除・並べ替えの可能性があります）", line)
+		}
+		if rec.Seq != count+1 {
+			return count, fmt.Errorf("%d行目: 通し番号が連続していません（%d、期待値 %d）", line, rec.Seq, count+1)
+		}
+		prev = lineHash(data)
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		return count, err
+	}
+	return count, nil
+}
+
+// lineHash は行（改行を含まない）のSHA-256を返す
+func lineHash(line []byte) string {
+	sum := sha256.Sum256(line)
+	return hex.EncodeToString(sum[:])
+}
+
+// readLastLine はファイルの最後の行（改行を含まない）を返す
+// 監査ログは大きくなるため、末尾から必要な分だけ読み込む
+func readLastLine(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	const chunk = 64 * 1024
+	var tail []byte
+	for offset := info.Size(); offset > 0; {
+		n := int64(chunk)
+		if n > offset {
+			n = offset
+		}
+		offset -= n
+		buf := make([]byte, n)
+		if _, err := file.ReadAt(buf, offset); err != nil {
+			return nil, err
+		}
+		tail = append(buf, tail...)
+
+		trimmed := bytes.TrimRight(tail, "\r\n")
+		if i := bytes.LastIndexByte(trimmed, '\n'); i >= 0 {
+			return trimmed[i+1:], nil
+		}
+		if offset == 0 {
+			return trimmed, nil
+		}
+	}
+	return nil, nil
+}
+
+// currentUser は実行中のユーザー名を返す
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
+
+// VerifyResult は検証結果の分類を監査ログの結果に変換する
+func VerifyResult(outcome database.VerifyOutcome) string {
+	switch outcome {
+	case database.VerifyMatched:
+		return ResultMatched
+	case database.VerifyMismatched:
+		return ResultMismatched
+	case database.VerifyIntermittent:
+		return ResultIntermittent
+	case database.VerifyMissingDest:
+		return ResultMissingDest
+	case database.VerifyLinkMismatch:
+		return ResultLinkMismatch
+	case database.VerifyExtra:
+		return ResultExtra
+	default:
+		return ResultFailed
+	}
+}
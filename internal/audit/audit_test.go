@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readRecords(t *testing.T, path string) []Record {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []Record
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("レコードの形式が不正です: %v", err)
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+func TestLog_Chain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	l, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	l.Write(Record{Action: ActionCopy, Path: "a.txt", Size: 3, Result: ResultSuccess})
+	l.Write(Record{Action: ActionVerify, Path: "a.txt", SourceHash: "abc", DestHash: "abc", Result: ResultMatched})
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 開き直した場合は続きの通し番号と連鎖で追記する
+	l, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	l.Write(Record{Action: ActionCopy, Path: "b.txt", Result: ResultFailed, Error: "denied"})
+	l.Close()
+
+	records := readRecords(t, path)
+	if len(records) != 3 {
+		t.Fatalf("レコード数 = %d, want 3", len(records))
+	}
+	for i, rec := range records {
+		if rec.Seq != int64(i+1) || rec.User == "" || rec.Time.IsZero() {
+			t.Errorf("%d件目 = %+v", i+1, rec)
+		}
+	}
+	if records[0].Prev != "" || records[1].Prev == "" || records[2].Prev == records[1].Prev {
+		t.Errorf("直前のハッシュ = %q, %q, %q", records[0].Prev, records[1].Prev, records[2].Prev)
+	}
+
+	data, _ := os.ReadFile(path)
+	if count, err := Verify(bytes.NewReader(data)); err != nil || count != 3 {
+		t.Errorf("Verify() = %d, %v", count, err)
+	}
+
+	// 行の書き換え・削除・並べ替えを検出する
+	lines := strings.SplitAfter(string(data), "\n")
+	tampered := []string{
+		strings.Replace(string(data), `"path":"a.txt","size":3`, `"path":"a.txt","size":4`, 1),
+		lines[0] + lines[2],
+		lines[1] + lines[0] + lines[2],
+	}
+	for i, content := range tampered {
+		if _, err := Verify(strings.NewReader(content)); err == nil {
+			t.Errorf("改ざん%dを検出できません", i+1)
+		}
+	}
+}
+
+func TestOpen_BrokenTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	os.WriteFile(path, []byte("{\"seq\":1,\"prev\":\"\"}\n{\"seq\":2,"), 0640)
+
+	if _, err := Open(path); err == nil {
+		t.Error("最後のレコードが不正な監査ログに追記できてしまいます")
+	}
+}
+
+func TestLog_Nil(t *testing.T) {
+	var l *Log
+	if err := l.Write(Record{}); err != nil || l.Err() != nil || l.Close() != nil {
+		t.Error("nilのLogでエラーになりました")
+	}
+}
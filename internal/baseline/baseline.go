@@ -0,0 +1,449 @@
+// Package baseline は宛先を現在のソースと記録済みのベースライン（以前の同期DBやそのエクスポート）の両方と比較し、
+// コピー後にソースが変更された場合と宛先が破損した場合を区別する
+package baseline
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/filter"
+	"github.com/sakuhanight/gopier/internal/hasher"
+	"github.com/sakuhanight/gopier/internal/pathkey"
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+// 差分の種類
+const (
+	KindSourceChanged = "source_changed" // コピー後にソースが変更された（宛先はベースラインと一致）
+	KindDestCorrupted = "dest_corrupted" // 宛先がベースラインと一致しない（ソースは変更されていない）
+	KindBothChanged   = "both_changed"   // ソースと宛先の両方がベースラインと一致しない
+	KindMismatch      = "mismatch"       // ソースと宛先が一致せず、ベースラインに記録がないため原因を判別できない
+	KindMissingDest   = "missing_dest"   // 宛先に存在しない
+	KindError         = "error"          // ファイルを読み込めない
+)
+
+// Entry はファイルごとの比較結果を表す構造体
+type Entry struct {
+	Path         string `json:"path"`
+	Kind         string `json:"kind"`
+	SourceHash   string `json:"source_hash,omitempty"`
+	DestHash     string `json:"dest_hash,omitempty"`
+	BaselineHash string `json:"baseline_hash,omitempty"` // ベースラインに記録された宛先の内容のハッシュ
+	Error        string `json:"error,omitempty"`
+}
+
+// Result は比較結果全体を表す構造体
+type Result struct {
+	Source   string         `json:"source"`
+	Dest     string         `json:"dest"`
+	Baseline string         `json:"baseline,omitempty"`
+	Compared int            `json:"compared"`
+	Counts   map[string]int `json:"counts"` // 差分の種類ごとの件数
+	Entries  []Entry        `json:"entries"`
+
+	// 確認済みの不一致として報告しなかった件数（--suppress-acknowledged）
+	Suppressed int `json:"suppressed,omitempty"`
+	// ソースを読み込まずにベースラインに記録されたハッシュを使用した件数（UseCachedHashes）
+	Cached int `json:"cached,omitempty"`
+}
+
+// Damaged は宛先の破損が疑われる差分があるかどうかを返す
+// ソースの変更のみの場合は、宛先はコピーした時点の内容を保っているためfalseを返す
+func (r *Result) Damaged() bool {
+	for _, entry := range r.Entries {
+		if entry.Kind != KindSourceChanged {
+			return true
+		}
+	}
+	return false
+}
+
+// Options は比較のオプションを表す構造体
+type Options struct {
+	Baseline      string         // ベースラインのパス（結果に記録する）
+	HashAlgorithm string         // ハッシュアルゴリズム
+	BufferSize    int            // ハッシュ計算のバッファサイズ（0以下はデフォルト）
+	Filter        *filter.Filter // 比較するファイルのフィルタ
+
+	// ベースラインのファイル情報（キーはソースの相対パス、nilの場合はベースラインなし）
+	Records map[string]database.FileInfo
+
+	// Paths は比較するソースからの相対パス（ファイルまたはディレクトリ、空の場合はツリー全体）
+	// 指定したファイルはFilterに一致しなくても比較する
+	Paths []string
+	// UseCachedHashes はソースのサイズと更新日時がベースラインの記録と一致する場合に、
+	// ソースを読み込まずに記録されたハッシュを使用するかどうか（宛先は常に読み込む）
+	UseCachedHashes bool
+	// DropCache はキャッシュを経由せずにディスクから読み込んでハッシュ値を計算するかどうか
+	DropCache bool
+}
+
+// Load はベースラインのファイル情報を読み込む
+// 同期DBのほか、db export --format jsonで書き出したファイル情報の配列を受け付ける
+func Load(path string) (map[string]database.FileInfo, error) {
+	// 同期DBは大きい場合があるため、先頭だけを読み込んで形式を判別する
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(file, head)
+	file.Close()
+
+	var files []database.FileInfo
+	if trimmed := bytes.TrimSpace(head[:n]); len(trimmed) > 0 && trimmed[0] == '[' {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &files); err != nil {
+			return nil, fmt.Errorf("%s: ファイル情報の形式が不正です: %w", path, err)
+		}
+	} else {
+		syncDB, err := database.NewSyncDB(path, database.NormalSync)
+		if err != nil {
+			return nil, err
+		}
+		defer syncDB.Close()
+		if files, err = syncDB.GetAllFiles(); err != nil {
+			return nil, err
+		}
+	}
+
+	records := make(map[string]database.FileInfo, len(files))
+	for _, file := range files {
+		records[pathkey.Normalize(file.Path)] = file
+	}
+	return records, nil
+}
+
+// Compare はソースツリーの各ファイルについて、宛先の同じパスの内容を比較する
+// 一致しない場合はベースラインに記録されたハッシュ（記録がなければサイズと更新日時）と比較し、
+// ソースと宛先のどちらがコピーした時点から変わったのかを判別する
+func Compare(source, dest string, opts Options) (*Result, error) {
+	if _, err := os.Stat(source); err != nil {
+		return nil, fmt.Errorf("ソースディレクトリにアクセスできません: %w", err)
+	}
+	if _, err := os.Stat(dest); err != nil {
+		return nil, fmt.Errorf("宛先ディレクトリにアクセスできません: %w", err)
+	}
+
+	c := &comparer{
+		dest:   dest,
+		opts:   opts,
+		hasher: hasher.NewHasher(hasher.Algorithm(opts.HashAlgorithm), opts.BufferSize),
+		seen:   make(map[string]bool),
+	}
+	h, err := c.hasher.NewHash()
+	if err != nil {
+		return nil, err
+	}
+	c.hashLen = h.Size() * 2
+
+	c.result = &Result{Source: source, Dest: dest, Baseline: opts.Baseline, Counts: map[string]int{}, Entries: []Entry{}}
+	if len(opts.Paths) == 0 {
+		if err := c.walk(source, source); err != nil {
+			return nil, err
+		}
+		return c.result, nil
+	}
+
+	for _, p := range opts.Paths {
+		rel, err := cleanRel(p)
+		if err != nil {
+			return nil, err
+		}
+		path := filepath.Join(source, pathkey.ToNative(rel))
+		info, err := os.Stat(path)
+		switch {
+		case err != nil:
+			c.result.Compared++
+			c.result.add(Entry{Path: rel, Kind: KindError, Error: fmt.Sprintf("ソース: %v", err)})
+		case info.IsDir():
+			if err := c.walk(source, path); err != nil {
+				return nil, err
+			}
+		case info.Mode().IsRegular():
+			c.compareFile(rel, path)
+		}
+	}
+	return c.result, nil
+}
+
+// cleanRel は比較するパスの指定をソースからの相対パスに正規化する
+// ソースの外を指すパスは比較できないためエラーにする
+func cleanRel(p string) (string, error) {
+	rel := pathkey.Normalize(filepath.Clean(p))
+	if filepath.IsAbs(p) || rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", fmt.Errorf("ソースからの相対パスを指定してください: %s", p)
+	}
+	return rel, nil
+}
+
+// walk はディレクトリ配下のファイルを比較する
+func (c *comparer) walk(source, root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		rel, relErr := pathkey.Rel(source, path)
+		if relErr != nil {
+			return relErr
+		}
+		if err != nil {
+			c.result.add(Entry{Path: rel, Kind: KindError, Error: err.Error()})
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !d.Type().IsRegular() {
+			return nil
+		}
+		if c.opts.Filter != nil && !c.opts.Filter.ShouldInclude(path) {
+			return nil
+		}
+
+		c.compareFile(rel, path)
+		return nil
+	})
+}
+
+// compareFile は1つのファイルを比較して結果に加える（重複して指定されたファイルは1回だけ比較する）
+func (c *comparer) compareFile(rel, path string) {
+	if c.seen[rel] {
+		return
+	}
+	c.seen[rel] = true
+
+	c.result.Compared++
+	if entry, ok := c.compareOne(rel, path); ok {
+		c.result.add(entry)
+	}
+}
+
+// Suppress はmatchがtrueを返すパスの差分を結果から除き、除いた件数を返す
+func (r *Result) Suppress(match func(path string) bool) int {
+	kept := r.Entries[:0]
+	removed := 0
+	for _, entry := range r.Entries {
+		if !match(entry.Path) {
+			kept = append(kept, entry)
+			continue
+		}
+		removed++
+		if r.Counts[entry.Kind]--; r.Counts[entry.Kind] == 0 {
+			delete(r.Counts, entry.Kind)
+		}
+	}
+	r.Entries = kept
+	r.Suppressed += removed
+	return removed
+}
+
+// add は差分を結果に追加する
+func (r *Result) add(entry Entry) {
+	r.Entries = append(r.Entries, entry)
+	r.Counts[entry.Kind]++
+}
+
+// comparer は1回の比較の状態
+type comparer struct {
+	dest    string
+	opts    Options
+	hasher  *hasher.Hasher
+	hashLen int // ハッシュ値の16進数の長さ
+	result  *Result
+	seen    map[string]bool // 比較したファイル
+}
+
+// compareOne は1つのファイルを比較する。差分がない場合はfalseを返す
+func (c *comparer) compareOne(rel, sourcePath string) (Entry, bool) {
+	entry := Entry{Path: rel}
+	record, recorded := c.opts.Records[rel]
+
+	destRel := rel
+	if recorded && record.DestPath != "" {
+		destRel = record.DestPath
+	}
+	destPath := filepath.Join(c.dest, pathkey.ToNative(destRel))
+
+	fail := func(err error) (Entry, bool) {
+		entry.Kind = KindError
+		entry.Error = err.Error()
+		return entry, true
+	}
+
+	sourceInfo, err := os.Stat(sourcePath)
+	if err != nil {
+		return fail(fmt.Errorf("ソース: %w", err))
+	}
+	destInfo, err := os.Stat(destPath)
+	if os.IsNotExist(err) {
+		entry.Kind = KindMissingDest
+		return entry, true
+	} else if err != nil {
+		return fail(fmt.Errorf("宛先: %w", err))
+	}
+
+	if cached, ok := c.cachedSourceHash(record, recorded, sourceInfo); ok {
+		entry.SourceHash = cached
+		c.result.Cached++
+	} else if entry.SourceHash, err = c.hashFile(sourcePath); err != nil {
+		return fail(fmt.Errorf("ソース: %w", err))
+	}
+	if entry.DestHash, err = c.hashFile(destPath); err != nil {
+		return fail(fmt.Errorf("宛先: %w", err))
+	}
+	if entry.SourceHash == entry.DestHash {
+		return entry, false
+	}
+	if !recorded {
+		entry.Kind = KindMismatch
+		return entry, true
+	}
+
+	sourceBase, destBase, destSize := c.baselineOf(record)
+	entry.BaselineHash = destBase
+	sourceSame := sameAsBaseline(entry.SourceHash, sourceBase, sourceInfo, record.Size, record.ModTime)
+	destSame := sameAsBaseline(entry.DestHash, destBase, destInfo, destSize, record.ModTime)
+
+	switch {
+	case sourceSame && destSame:
+		// 変換してコピーした場合など、ソースと宛先の内容がコピーした時点から変わっていない
+		return entry, false
+	case sourceSame:
+		entry.Kind = KindDestCorrupted
+	case destSame:
+		entry.Kind = KindSourceChanged
+	default:
+		entry.Kind = KindBothChanged
+	}
+	return entry, true
+}
+
+// hashFile はファイルのハッシュ値を計算する
+func (c *comparer) hashFile(path string) (string, error) {
+	if !c.opts.DropCache {
+		return c.hasher.HashFile(path)
+	}
+	file, err := vfs.OpenUncached(vfs.OS, path)
+	if err != nil {
+		return "", fmt.Errorf("ファイルを開けません: %w", err)
+	}
+	defer file.Close()
+	return c.hasher.HashReader(file)
+}
+
+// cachedSourceHash はソースを読み込まずに使用できる、ベースラインに記録されたソースのハッシュを返す
+// サイズと更新日時が記録と一致し、同じアルゴリズムで記録されている場合のみ使用する
+func (c *comparer) cachedSourceHash(record database.FileInfo, recorded bool, info os.FileInfo) (string, bool) {
+	if !c.opts.UseCachedHashes || !recorded || record.SourceHash == "" {
+		return "", false
+	}
+	if info.Size() != record.Size || !info.ModTime().Equal(record.ModTime) {
+		return "", false
+	}
+	if record.HashAlgo != "" && record.HashAlgo != c.opts.HashAlgorithm || len(record.SourceHash) != c.hashLen {
+		return "", false
+	}
+	return record.SourceHash, true
+}
+
+// baselineOf はベースラインに記録されたソースと宛先の内容のハッシュ、宛先のサイズを返す
+// 異なるアルゴリズムで記録されたハッシュは比較できないため空にする
+func (c *comparer) baselineOf(record database.FileInfo) (sourceHash, destHash string, destSize int64) {
+	destSize = record.Size
+	if record.Transform != nil {
+		destSize = record.Transform.OutputSize
+	}
+	if record.HashAlgo != "" && record.HashAlgo != c.opts.HashAlgorithm {
+		return "", "", destSize
+	}
+
+	sourceHash, destHash = record.SourceHash, record.DestHash
+	if destHash == "" && record.Transform == nil {
+		destHash = sourceHash
+	}
+	return sourceHash, destHash, destSize
+}
+
+// sameAsBaseline は現在のファイルがベースラインの記録と一致するかどうかを返す
+// ハッシュが記録されていない（または長さが異なりアルゴリズムが違う）場合はサイズと更新日時で判断する
+func sameAsBaseline(hash, baseHash string, info os.FileInfo, baseSize int64, baseTime time.Time) bool {
+	if baseHash != "" && len(baseHash) == len(hash) {
+		return hash == baseHash
+	}
+	return info.Size() == baseSize && info.ModTime().Equal(baseTime)
+}
+
+// WriteJSON は比較結果をJSONで書き出す
+func WriteJSON(w io.Writer, result *Result) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+// WriteCSV は比較結果をCSVで書き出す（1行に1つのファイル）
+func WriteCSV(w io.Writer, result *Result) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"path", "kind", "source_hash", "dest_hash", "baseline_hash", "error"}); err != nil {
+		return err
+	}
+	for _, entry := range result.Entries {
+		row := []string{entry.Path, entry.Kind, entry.SourceHash, entry.DestHash, entry.BaselineHash, entry.Error}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// kindLabels は差分の種類ごとの説明（集計の表示順）
+var kindLabels = []struct {
+	kind, label, detail string
+}{
+	{KindSourceChanged, "ソースの変更", "コピー後にソースが変更されました（宛先はベースラインと一致）"},
+	{KindDestCorrupted, "宛先の破損", "宛先が破損しています（ソースはベースラインから変更されていません）"},
+	{KindBothChanged, "両方の変更", "ソースと宛先の両方がベースラインと一致しません"},
+	{KindMismatch, "不一致", "ソースと宛先が一致しません（ベースラインに記録がありません）"},
+	{KindMissingDest, "宛先なし", "宛先に存在しません"},
+	{KindError, "エラー", "エラー"},
+}
+
+// WriteText は比較結果を人が読む形式で書き出す
+func WriteText(w io.Writer, result *Result) error {
+	for _, entry := range result.Entries {
+		for _, k := range kindLabels {
+			if k.kind != entry.Kind {
+				continue
+			}
+			if entry.Kind == KindError {
+				fmt.Fprintf(w, "%s: %s: %s\n", entry.Path, k.detail, entry.Error)
+			} else {
+				fmt.Fprintf(w, "%s: %s\n", entry.Path, k.detail)
+			}
+		}
+	}
+
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "比較: %d件", result.Compared)
+	for _, k := range kindLabels {
+		fmt.Fprintf(&summary, ", %s: %d件", k.label, result.Counts[k.kind])
+	}
+	if result.Suppressed > 0 {
+		fmt.Fprintf(&summary, ", 確認済み: %d件", result.Suppressed)
+	}
+	if result.Cached > 0 {
+		fmt.Fprintf(&summary, ", 記録済みのハッシュを使用: %d件", result.Cached)
+	}
+	_, err := fmt.Fprintln(w, summary.String())
+	return err
+}
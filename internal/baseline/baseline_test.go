@@ -0,0 +1,285 @@
+package baseline
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/database"
+)
+
+func sha(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestCompare(t *testing.T) {
+	base := t.TempDir()
+	source := filepath.Join(base, "src")
+	dest := filepath.Join(base, "dst")
+	os.MkdirAll(filepath.Join(source, "sub"), 0755)
+	os.MkdirAll(filepath.Join(dest, "sub"), 0755)
+
+	write := func(dir, name, data string) {
+		os.WriteFile(filepath.Join(dir, filepath.FromSlash(name)), []byte(data), 0644)
+	}
+	// 一致、ソースの変更、宛先の破損、両方の変更、ベースラインなし、宛先なし
+	write(source, "same.txt", "same")
+	write(dest, "same.txt", "same")
+	write(source, "edited.txt", "edited after copy")
+	write(dest, "edited.txt", "original")
+	write(source, "sub/rot.txt", "original")
+	write(dest, "sub/rot.txt", "bit rot")
+	write(source, "both.txt", "new source")
+	write(dest, "both.txt", "broken dest")
+	write(source, "new.txt", "new")
+	write(dest, "new.txt", "other")
+	write(source, "missing.txt", "missing")
+
+	// ハッシュのない記録はサイズと更新日時で判断する
+	mtime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	write(source, "nohash.txt", "same size")
+	write(dest, "nohash.txt", "SAME SIZE")
+	os.Chtimes(filepath.Join(source, "nohash.txt"), mtime, mtime)
+
+	records := map[string]database.FileInfo{
+		"same.txt":    {Path: "same.txt", SourceHash: sha("same")},
+		"edited.txt":  {Path: "edited.txt", SourceHash: sha("original")},
+		"sub/rot.txt": {Path: "sub/rot.txt", SourceHash: sha("original"), DestHash: sha("original")},
+		"both.txt":    {Path: "both.txt", SourceHash: sha("original")},
+		"nohash.txt":  {Path: "nohash.txt", Size: 9, ModTime: mtime},
+	}
+
+	result, err := Compare(source, dest, Options{Baseline: "base.db", HashAlgorithm: "sha256", Records: records})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if result.Compared != 7 {
+		t.Errorf("比較件数 = %d, want 7", result.Compared)
+	}
+
+	want := map[string]string{
+		"edited.txt":  KindSourceChanged,
+		"sub/rot.txt": KindDestCorrupted,
+		"both.txt":    KindBothChanged,
+		"new.txt":     KindMismatch,
+		"missing.txt": KindMissingDest,
+		"nohash.txt":  KindDestCorrupted,
+	}
+	got := map[string]string{}
+	for _, entry := range result.Entries {
+		got[entry.Path] = entry.Kind
+	}
+	if len(got) != len(want) {
+		t.Errorf("差分 = %v, want %v", got, want)
+	}
+	for path, kind := range want {
+		if got[path] != kind {
+			t.Errorf("%s の種類 = %q, want %q", path, got[path], kind)
+		}
+	}
+	if result.Counts[KindDestCorrupted] != 2 || !result.Damaged() {
+		t.Errorf("件数 = %v, 破損あり = %v", result.Counts, result.Damaged())
+	}
+
+	// ソースの変更のみの場合は宛先の破損として扱わない
+	onlyChanged := &Result{Entries: []Entry{{Path: "a", Kind: KindSourceChanged}}}
+	if onlyChanged.Damaged() {
+		t.Error("ソースの変更のみで破損として扱われました")
+	}
+}
+
+func TestCompare_Paths(t *testing.T) {
+	base := t.TempDir()
+	source := filepath.Join(base, "src")
+	dest := filepath.Join(base, "dst")
+	for _, dir := range []string{source, dest} {
+		os.MkdirAll(filepath.Join(dir, "sub", "deep"), 0755)
+		os.MkdirAll(filepath.Join(dir, "other"), 0755)
+	}
+	write := func(dir, name, data string) {
+		os.WriteFile(filepath.Join(dir, filepath.FromSlash(name)), []byte(data), 0644)
+	}
+	write(source, "sub/a.txt", "a")
+	write(dest, "sub/a.txt", "a")
+	write(source, "sub/deep/b.txt", "b")
+	write(dest, "sub/deep/b.txt", "broken")
+	write(source, "other/c.txt", "c")
+	write(dest, "other/c.txt", "broken")
+	write(source, "top.txt", "top")
+	write(dest, "top.txt", "top")
+
+	// ディレクトリとファイルを混在して指定でき、重複したファイルは1回だけ比較する
+	result, err := Compare(source, dest, Options{
+		HashAlgorithm: "sha256",
+		Paths:         []string{"sub", "sub/deep/b.txt", "top.txt", "nothing.txt"},
+	})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if result.Compared != 4 {
+		t.Errorf("比較件数 = %d, want 4", result.Compared)
+	}
+	got := map[string]string{}
+	for _, entry := range result.Entries {
+		got[entry.Path] = entry.Kind
+	}
+	if len(got) != 2 || got["sub/deep/b.txt"] != KindMismatch || got["nothing.txt"] != KindError {
+		t.Errorf("差分 = %v", got)
+	}
+
+	// ソースの外を指すパスは指定できない
+	for _, p := range []string{"../src/top.txt", filepath.Join(source, "top.txt")} {
+		if _, err := Compare(source, dest, Options{HashAlgorithm: "sha256", Paths: []string{p}}); err == nil {
+			t.Errorf("Compare(%q) はエラーになるべきです", p)
+		}
+	}
+}
+
+func TestCompare_UseCachedHashes(t *testing.T) {
+	base := t.TempDir()
+	source := filepath.Join(base, "src")
+	dest := filepath.Join(base, "dst")
+	os.MkdirAll(source, 0755)
+	os.MkdirAll(dest, 0755)
+
+	mtime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, name := range []string{"cached.txt", "touched.txt"} {
+		os.WriteFile(filepath.Join(source, name), []byte("data"), 0644)
+		os.WriteFile(filepath.Join(dest, name), []byte("data"), 0644)
+		os.Chtimes(filepath.Join(source, name), mtime, mtime)
+	}
+	later := mtime.Add(time.Hour)
+	os.Chtimes(filepath.Join(source, "touched.txt"), later, later)
+
+	// 記録されたハッシュは実際の内容と異なるため、使用した場合は不一致になる
+	records := map[string]database.FileInfo{
+		"cached.txt":  {Path: "cached.txt", Size: 4, ModTime: mtime, SourceHash: sha("recorded")},
+		"touched.txt": {Path: "touched.txt", Size: 4, ModTime: mtime, SourceHash: sha("recorded")},
+	}
+	result, err := Compare(source, dest, Options{HashAlgorithm: "sha256", Records: records, UseCachedHashes: true})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if result.Cached != 1 || len(result.Entries) != 1 || result.Entries[0].Path != "cached.txt" {
+		t.Errorf("記録済みのハッシュ: %d件, 差分 = %+v", result.Cached, result.Entries)
+	}
+
+	// 異なるアルゴリズムで記録されたハッシュは使用しない
+	records["cached.txt"] = database.FileInfo{Path: "cached.txt", Size: 4, ModTime: mtime, SourceHash: sha("recorded"), HashAlgo: "sha1"}
+	if result, _ = Compare(source, dest, Options{HashAlgorithm: "sha256", Records: records, UseCachedHashes: true}); result.Cached != 0 {
+		t.Errorf("記録済みのハッシュ: %d件, want 0", result.Cached)
+	}
+}
+
+func TestCompare_InvalidAlgorithm(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Compare(dir, dir, Options{HashAlgorithm: "crc"}); err == nil {
+		t.Error("未サポートのアルゴリズムでエラーになりません")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	// db export --format jsonの形式
+	jsonPath := filepath.Join(dir, "export.json")
+	os.WriteFile(jsonPath, []byte(`[{"path":"a/b.txt","size":3,"source_hash":"abc"}]`), 0644)
+	records, err := Load(jsonPath)
+	if err != nil {
+		t.Fatalf("Load(json) error = %v", err)
+	}
+	if records["a/b.txt"].SourceHash != "abc" {
+		t.Errorf("読み込んだ記録 = %+v", records)
+	}
+
+	// 同期DB
+	dbPath := filepath.Join(dir, "sync.db")
+	syncDB, err := database.NewSyncDB(dbPath, database.NormalSync)
+	if err != nil {
+		t.Fatal(err)
+	}
+	syncDB.AddFile(database.FileInfo{Path: "c.txt", SourceHash: "def", Status: database.StatusSuccess})
+	syncDB.Close()
+
+	records, err = Load(dbPath)
+	if err != nil {
+		t.Fatalf("Load(db) error = %v", err)
+	}
+	if records["c.txt"].SourceHash != "def" {
+		t.Errorf("読み込んだ記録 = %+v", records)
+	}
+
+	if _, err := Load(filepath.Join(dir, "none.db")); err == nil {
+		t.Error("存在しないベースラインでエラーになりません")
+	}
+}
+
+func TestWriters(t *testing.T) {
+	result := &Result{
+		Source:   "src",
+		Dest:     "dst",
+		Compared: 2,
+		Counts:   map[string]int{KindDestCorrupted: 1, KindError: 1},
+		Entries: []Entry{
+			{Path: "a.txt", Kind: KindDestCorrupted, SourceHash: "s", DestHash: "d", BaselineHash: "s"},
+			{Path: "b.txt", Kind: KindError, Error: "denied"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteText(&buf, result); err != nil {
+		t.Fatal(err)
+	}
+	text := buf.String()
+	if !strings.Contains(text, "a.txt: 宛先が破損しています") || !strings.Contains(text, "b.txt: エラー: denied") || !strings.Contains(text, "宛先の破損: 1件") {
+		t.Errorf("テキスト出力 = %s", text)
+	}
+
+	buf.Reset()
+	if err := WriteCSV(&buf, result); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 3 || lines[1] != "a.txt,dest_corrupted,s,d,s," {
+		t.Errorf("CSV出力 = %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteJSON(&buf, result); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Result
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || decoded.Counts[KindError] != 1 || len(decoded.Entries) != 2 {
+		t.Errorf("JSON出力 = %s, %v", buf.String(), err)
+	}
+}
+
+func TestResult_Suppress(t *testing.T) {
+	result := &Result{Counts: map[string]int{}}
+	result.add(Entry{Path: "$RECYCLE.BIN/a", Kind: KindDestCorrupted})
+	result.add(Entry{Path: "docs/b.txt", Kind: KindSourceChanged})
+	result.add(Entry{Path: "$RECYCLE.BIN/c", Kind: KindMismatch})
+
+	removed := result.Suppress(func(path string) bool { return strings.HasPrefix(path, "$RECYCLE.BIN/") })
+	if removed != 2 || result.Suppressed != 2 || len(result.Entries) != 1 {
+		t.Fatalf("除外後の結果 = %+v", result)
+	}
+	if _, ok := result.Counts[KindDestCorrupted]; ok || result.Counts[KindSourceChanged] != 1 {
+		t.Errorf("件数 = %v", result.Counts)
+	}
+	if result.Damaged() {
+		t.Error("確認済みの不一致を除いた後も破損として扱われました")
+	}
+
+	var buf bytes.Buffer
+	WriteText(&buf, result)
+	if !strings.Contains(buf.String(), "確認済み: 2件") {
+		t.Errorf("テキスト出力 = %s", buf.String())
+	}
+}
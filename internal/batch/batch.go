@@ -0,0 +1,181 @@
+// Package batch は小さいファイルをまとめて1つのtar形式のファイル（セグメント）として書き込む
+// プラグインのストレージなど遅延の大きい宛先で、ファイルごとの往復が処理時間の大半を占める場合に使用する。
+// セグメントの中の各ファイルの位置は同期DBに記録し、検証と展開（unbatch）ではその位置から内容を読み込む。
+// セグメントは標準のtar形式のため、DBがなくてもtarコマンドで展開できる
+package batch
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+const (
+	// Dir はセグメントを書き込む、宛先のディレクトリからの相対パス
+	Dir = ".gopier-batches"
+	// PartialSuffix は書き込み中のセグメントの名前の末尾（書き込みを終えた後に名前を変更する）
+	PartialSuffix = ".partial"
+)
+
+// SegmentName はセッションの中でseq番目に書き込むセグメントの、宛先からの相対パス（/区切り）を返す
+// 名前の順は書き込んだ順になる
+func SegmentName(sessionID int64, seq int) string {
+	return path.Join(Dir, fmt.Sprintf("%08d-%06d.tar", sessionID, seq))
+}
+
+// IsPartial はセグメントの名前が書き込み中（異常終了した場合は書き込みの途中）のものかどうかを返す
+func IsPartial(name string) bool {
+	return strings.HasSuffix(name, PartialSuffix)
+}
+
+// Writer はセグメントにファイルを追加する
+type Writer struct {
+	w  *countingWriter
+	tw *tar.Writer
+}
+
+// NewWriter はwにセグメントを書き込むWriterを作成する
+func NewWriter(w io.Writer) *Writer {
+	cw := &countingWriter{w: w}
+	return &Writer{w: cw, tw: tar.NewWriter(cw)}
+}
+
+// Add はファイルをセグメントに追加し、セグメントの中の内容の開始位置を返す
+// rから読み込めた内容がsizeに満たない場合は、セグメントの形式を保つため残りを0で埋めてエラーを返す
+// （sizeを超える内容は読み込まない）
+func (w *Writer) Add(name string, size int64, modTime time.Time, mode os.FileMode, r io.Reader) (int64, error) {
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     size,
+		Mode:     int64(mode.Perm()),
+		ModTime:  modTime,
+		Format:   tar.FormatPAX,
+	}
+	if err := w.tw.WriteHeader(header); err != nil {
+		return 0, fmt.Errorf("セグメントへの書き込みエラー: %w", err)
+	}
+	offset := w.w.n
+
+	n, err := io.CopyN(w.tw, r, size)
+	if n < size {
+		// 書き込みエラーの場合は以降も書き込めないため、埋めずに返す
+		if w.w.err != nil {
+			return 0, fmt.Errorf("セグメントへの書き込みエラー: %w", w.w.err)
+		}
+		if _, padErr := io.CopyN(w.tw, zeroReader{}, size-n); padErr != nil {
+			return 0, fmt.Errorf("セグメントへの書き込みエラー: %w", padErr)
+		}
+		if err == nil || err == io.EOF {
+			err = fmt.Errorf("ファイルがコピー中に短くなりました (%d/%dバイト)", n, size)
+		}
+		return 0, err
+	}
+	return offset, nil
+}
+
+// Err はセグメントへの書き込みで最初に発生したエラーを返す
+// エラーが発生した後のセグメントは形式が壊れているため、使用できない
+func (w *Writer) Err() error {
+	return w.w.err
+}
+
+// Size はこれまでに書き込んだサイズを返す
+func (w *Writer) Size() int64 {
+	return w.w.n
+}
+
+// Close はセグメントの終端を書き込む（wは閉じない）
+func (w *Writer) Close() error {
+	return w.tw.Close()
+}
+
+// Section はセグメントの中のファイルの内容を読み込むReaderを返す
+func Section(segment io.ReaderAt, offset, size int64) *io.SectionReader {
+	return io.NewSectionReader(segment, offset, size)
+}
+
+// Extract はセグメントの中のファイルの内容をfsys上のtargetに書き込み、更新日時をmodTimeに設定する
+// 一時ファイルに書き込んでから名前を変更するため、失敗した場合に書き込みの途中のファイルは残らない
+func Extract(fsys vfs.FS, segment io.ReaderAt, offset, size int64, modTime time.Time, target string) error {
+	dir := filepath.Dir(target)
+	if err := fsys.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("ディレクトリ(%s)の作成に失敗: %w", dir, err)
+	}
+
+	file, err := fsys.CreateTemp(dir, ".gopier-unbatch-*")
+	if err != nil {
+		return fmt.Errorf("一時ファイルの作成に失敗: %w", err)
+	}
+	tempPath := file.Name()
+
+	n, err := io.Copy(file, Section(segment, offset, size))
+	if err == nil && n != size {
+		err = fmt.Errorf("セグメントが途中で終わっています (%d/%dバイト)", n, size)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = fsys.Rename(tempPath, target)
+	}
+	if err != nil {
+		fsys.Remove(tempPath)
+		return err
+	}
+
+	if err := fsys.Chtimes(target, modTime, modTime); err != nil {
+		return fmt.Errorf("更新日時の設定に失敗: %w", err)
+	}
+	return nil
+}
+
+// FileInfo はセグメントの中のファイルのファイル情報を返す
+func FileInfo(name string, size int64, modTime time.Time) os.FileInfo {
+	return memberInfo{name: path.Base(name), size: size, modTime: modTime}
+}
+
+// memberInfo はセグメントの中のファイルのファイル情報
+type memberInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (m memberInfo) Name() string       { return m.name }
+func (m memberInfo) Size() int64        { return m.size }
+func (m memberInfo) Mode() os.FileMode  { return 0644 }
+func (m memberInfo) ModTime() time.Time { return m.modTime }
+func (m memberInfo) IsDir() bool        { return false }
+func (m memberInfo) Sys() interface{}   { return nil }
+
+// countingWriter は書き込んだサイズを数える
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error // 最初に発生した書き込みエラー
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	if err != nil && c.err == nil {
+		c.err = err
+	}
+	return n, err
+}
+
+// zeroReader は0を読み込み続ける
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
@@ -0,0 +1,94 @@
+package batch
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"a.txt", "aaa"},
+		{"dir/b.txt", strings.Repeat("b", 1000)},
+		{"empty.txt", ""},
+	}
+	offsets := make([]int64, len(files))
+	for i, f := range files {
+		offset, err := w.Add(f.name, int64(len(f.content)), modTime, 0644, strings.NewReader(f.content))
+		if err != nil {
+			t.Fatalf("Add(%s)が失敗しました: %v", f.name, err)
+		}
+		offsets[i] = offset
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Closeが失敗しました: %v", err)
+	}
+	if w.Size() != int64(buf.Len()) {
+		t.Errorf("Size() = %d, want %d", w.Size(), buf.Len())
+	}
+
+	// 記録した位置から内容を読み込める
+	segment := bytes.NewReader(buf.Bytes())
+	for i, f := range files {
+		got, err := io.ReadAll(Section(segment, offsets[i], int64(len(f.content))))
+		if err != nil || string(got) != f.content {
+			t.Errorf("%sの内容 = %q, %v", f.name, got, err)
+		}
+	}
+
+	// 標準のtar形式として読み込める
+	tr := tar.NewReader(bytes.NewReader(buf.Bytes()))
+	for _, f := range files {
+		header, err := tr.Next()
+		if err != nil {
+			t.Fatalf("tarとして読み込めません: %v", err)
+		}
+		got, _ := io.ReadAll(tr)
+		if header.Name != f.name || string(got) != f.content || !header.ModTime.Equal(modTime) {
+			t.Errorf("tarのエントリ = %s %q %v", header.Name, got, header.ModTime)
+		}
+	}
+	if _, err := tr.Next(); err != io.EOF {
+		t.Errorf("tarの終端 = %v, want io.EOF", err)
+	}
+}
+
+func TestWriter_ShortRead(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+
+	// 内容がサイズに満たない場合はエラーにし、残りを0で埋めて後続のファイルを書き込めるようにする
+	if _, err := w.Add("short.txt", 10, time.Now(), 0644, strings.NewReader("abc")); err == nil {
+		t.Error("短い内容がエラーになりません")
+	}
+	offset, err := w.Add("next.txt", 4, time.Now(), 0644, strings.NewReader("next"))
+	if err != nil {
+		t.Fatalf("後続のファイルのAddが失敗しました: %v", err)
+	}
+	w.Close()
+
+	got, _ := io.ReadAll(Section(bytes.NewReader(buf.Bytes()), offset, 4))
+	if string(got) != "next" {
+		t.Errorf("後続のファイルの内容 = %q", got)
+	}
+}
+
+func TestSegmentName(t *testing.T) {
+	name := SegmentName(12, 3)
+	if name != ".gopier-batches/00000012-000003.tar" {
+		t.Errorf("SegmentName() = %s", name)
+	}
+	if IsPartial(name) || !IsPartial(name+"-123"+PartialSuffix) {
+		t.Error("IsPartialの判定が誤っています")
+	}
+}
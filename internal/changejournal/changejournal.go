@@ -0,0 +1,121 @@
+// Package changejournal はソースのボリュームの変更ジャーナル（NTFSのUSNジャーナル）から、
+// 前回の実行以降に変更されたファイルを取得する
+// 数千万のファイルがあるソースでも、全体を走査せずに変更されたファイルのみを処理できる
+package changejournal
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sakuhanight/gopier/internal/pathkey"
+)
+
+// ErrUnavailable は変更ジャーナルを使用できないことを表す（呼び出し側はソース全体を走査する）
+var ErrUnavailable = errors.New("変更ジャーナルを使用できません")
+
+// Position はボリュームの変更ジャーナルの位置
+type Position struct {
+	Volume    string // ボリュームの名前（\\?\Volume{GUID}\）
+	JournalID uint64 // ジャーナルのID（ジャーナルを作り直すと変わる）
+	NextUSN   int64  // 次に書き込まれるレコードの位置
+}
+
+// Result は変更ジャーナルから取得した変更を表す構造体
+type Result struct {
+	Paths   []string // 変更されたファイル・ディレクトリのソースからの相対パス（スラッシュ区切り、パス順）
+	Removed int      // 変更の後に削除・移動されたため、ソースに存在しないパスの数
+	Records int      // 読み込んだジャーナルのレコード数
+}
+
+// change はジャーナルのレコードから取得した変更
+type change struct {
+	path     string // 変更されたファイル・ディレクトリの絶対パス
+	dirAdded bool   // 作成された、または名前の変更・移動で追加されたディレクトリ（配下もすべて対象にする）
+}
+
+// Current はソースのボリュームの変更ジャーナルの現在の位置を返す
+func Current(sourceDir string) (Position, error) {
+	root, err := filepath.Abs(sourceDir)
+	if err != nil {
+		return Position{}, err
+	}
+	return current(root)
+}
+
+// Changes はsinceからuntilまでに変更された、ソースの配下のファイル・ディレクトリを返す
+// ジャーナルが作り直された場合や、sinceの位置のレコードが既に削除されている場合はErrUnavailableを返す
+func Changes(sourceDir string, since, until Position) (*Result, error) {
+	root, err := filepath.Abs(sourceDir)
+	if err != nil {
+		return nil, err
+	}
+	if since.Volume != until.Volume || since.JournalID != until.JournalID {
+		return nil, fmt.Errorf("%w: ジャーナルが作り直されています", ErrUnavailable)
+	}
+	if since.NextUSN > until.NextUSN {
+		return nil, fmt.Errorf("%w: 記録した位置が現在の位置より後です", ErrUnavailable)
+	}
+
+	changes, records, err := readChanges(root, since, until)
+	if err != nil {
+		return nil, err
+	}
+	result := collect(root, changes)
+	result.Records = records
+	return result, nil
+}
+
+// collect はジャーナルの変更からソースの配下に存在するパスを集める
+// 追加されたディレクトリは配下のレコードがジャーナルにないため、配下を走査して加える
+func collect(root string, changes []change) *Result {
+	result := &Result{Paths: []string{}}
+	seen := make(map[string]bool)
+	removed := make(map[string]bool)
+	add := func(path string) {
+		rel, ok := within(root, path)
+		if !ok || seen[rel] {
+			return
+		}
+		seen[rel] = true
+		result.Paths = append(result.Paths, rel)
+	}
+
+	for _, c := range changes {
+		rel, ok := within(root, c.path)
+		if !ok || seen[rel] || removed[rel] {
+			continue
+		}
+		info, err := os.Lstat(c.path)
+		if err != nil {
+			removed[rel] = true
+			continue
+		}
+		add(c.path)
+		if c.dirAdded && info.IsDir() {
+			filepath.WalkDir(c.path, func(path string, d fs.DirEntry, err error) error {
+				if err == nil && path != c.path {
+					add(path)
+				}
+				return nil
+			})
+		}
+	}
+	result.Removed = len(removed)
+	sort.Strings(result.Paths)
+	return result
+}
+
+// within はパスがrootの配下であれば、rootからの相対パス（スラッシュ区切り）を返す
+// ジャーナルのパスと指定したソースで大文字・小文字が異なる場合があるため、区別せずに比較する
+func within(root, path string) (string, bool) {
+	prefix := strings.TrimSuffix(root, string(filepath.Separator)) + string(filepath.Separator)
+	if len(path) <= len(prefix) || !strings.EqualFold(path[:len(prefix)], prefix) {
+		return "", false
+	}
+	return pathkey.Normalize(path[len(prefix):]), true
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+
+package changejournal
+
+import "fmt"
+
+// Windows以外では変更ジャーナルを使用しない
+// Linuxのinotify・macOSのFSEventsは実行していない間の変更を記録しないため、前回の実行以降の変更を取得できない
+func current(root string) (Position, error) {
+	return Position{}, fmt.Errorf("%w: Windows（NTFS）でのみ使用できます", ErrUnavailable)
+}
+
+func readChanges(root string, since, until Position) ([]change, int, error) {
+	return nil, 0, fmt.Errorf("%w: Windows（NTFS）でのみ使用できます", ErrUnavailable)
+}
@@ -0,0 +1,60 @@
+package changejournal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestCollect(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"docs", "added/sub"} {
+		os.MkdirAll(filepath.Join(root, dir), 0755)
+	}
+	for _, file := range []string{"docs/a.txt", "docs/b.txt", "added/c.txt", "added/sub/d.txt"} {
+		os.WriteFile(filepath.Join(root, file), []byte("x"), 0644)
+	}
+
+	changes := []change{
+		{path: filepath.Join(root, "docs", "a.txt")},
+		{path: filepath.Join(root, "docs", "a.txt")}, // 同じファイルの複数のレコード
+		{path: filepath.Join(root, "docs", "gone.txt")},
+		{path: filepath.Join(root, "added"), dirAdded: true},
+		{path: filepath.Join(root, "added", "c.txt")},
+		{path: filepath.Join(root+"-outside", "e.txt")}, // 名前が前方一致するソースの外
+		{path: root},
+	}
+	result := collect(root, changes)
+	want := []string{"added", "added/c.txt", "added/sub", "added/sub/d.txt", "docs/a.txt"}
+	if !reflect.DeepEqual(result.Paths, want) {
+		t.Errorf("Paths = %v, want %v", result.Paths, want)
+	}
+	if result.Removed != 1 {
+		t.Errorf("Removed = %d, want 1", result.Removed)
+	}
+}
+
+func TestWithin_IgnoreCase(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator)+"Share", "Data")
+	rel, ok := within(root, filepath.Join(string(filepath.Separator)+"share", "DATA", "Sub", "a.txt"))
+	if !ok || rel != "Sub/a.txt" {
+		t.Errorf("within() = %q, %v", rel, ok)
+	}
+}
+
+func TestChanges_Unavailable(t *testing.T) {
+	// ジャーナルが作り直された場合は読み込まない
+	_, err := Changes(t.TempDir(), Position{Volume: "v", JournalID: 1}, Position{Volume: "v", JournalID: 2})
+	if !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Changes() error = %v, want ErrUnavailable", err)
+	}
+
+	if runtime.GOOS != "windows" {
+		if _, err := Current(t.TempDir()); !errors.Is(err, ErrUnavailable) {
+			t.Errorf("Current() error = %v, want ErrUnavailable", err)
+		}
+	}
+}
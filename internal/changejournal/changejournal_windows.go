@@ -0,0 +1,278 @@
+//go:build windows
+
+package changejournal
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	modkernel32      = windows.NewLazySystemDLL("kernel32.dll")
+	procOpenFileById = modkernel32.NewProc("OpenFileById")
+	errNotResolvable = errors.New("親ディレクトリのパスを取得できません")
+)
+
+// USNジャーナルの制御コードとレコードの変更理由
+const (
+	fsctlQueryUsnJournal = 0x000900f4
+	fsctlReadUsnJournal  = 0x000900bb
+
+	usnReasonFileCreate    = 0x00000100
+	usnReasonFileDelete    = 0x00000200
+	usnReasonRenameOldName = 0x00001000
+	usnReasonRenameNewName = 0x00002000
+
+	readBufferSize    = 1 << 20 // 1回に読み込むレコードのバッファの大きさ
+	usnRecordHeaderV2 = 60      // USN_RECORD_V2のファイル名を除く大きさ
+)
+
+// usnJournalData はFSCTL_QUERY_USN_JOURNALの出力（USN_JOURNAL_DATA_V0）
+type usnJournalData struct {
+	UsnJournalID    uint64
+	FirstUsn        int64
+	NextUsn         int64
+	LowestValidUsn  int64
+	MaxUsn          int64
+	MaximumSize     uint64
+	AllocationDelta uint64
+}
+
+// readUsnJournalData はFSCTL_READ_USN_JOURNALの入力（READ_USN_JOURNAL_DATA_V0）
+type readUsnJournalData struct {
+	StartUsn          int64
+	ReasonMask        uint32
+	ReturnOnlyOnClose uint32
+	Timeout           uint64
+	BytesToWaitFor    uint64
+	UsnJournalID      uint64
+}
+
+// fileIDDescriptor はOpenFileByIdに渡すファイルID（FILE_ID_DESCRIPTOR、FileIdType）
+type fileIDDescriptor struct {
+	Size   uint32
+	Type   uint32
+	FileID uint64
+	_      uint64 // 共用体の残り（FILE_ID_128の大きさ）
+}
+
+// volume はソースのボリュームを開いたハンドル
+type volume struct {
+	name   string // \\?\Volume{GUID}\
+	handle windows.Handle
+}
+
+// openVolume はパスを含むボリュームを開く（管理者権限が必要）
+func openVolume(root string) (*volume, error) {
+	path, err := windows.UTF16PtrFromString(root)
+	if err != nil {
+		return nil, err
+	}
+	mount := make([]uint16, windows.MAX_PATH+1)
+	if err := windows.GetVolumePathName(path, &mount[0], uint32(len(mount))); err != nil {
+		return nil, fmt.Errorf("%w: ボリュームを取得できません: %v", ErrUnavailable, err)
+	}
+	guid := make([]uint16, windows.MAX_PATH+1)
+	if err := windows.GetVolumeNameForVolumeMountPoint(&mount[0], &guid[0], uint32(len(guid))); err != nil {
+		return nil, fmt.Errorf("%w: ボリュームの名前を取得できません（ネットワークドライブ・共有フォルダは使用できません）: %v", ErrUnavailable, err)
+	}
+	name := windows.UTF16ToString(guid)
+
+	device, err := windows.UTF16PtrFromString(strings.TrimSuffix(name, `\`))
+	if err != nil {
+		return nil, err
+	}
+	handle, err := windows.CreateFile(device, windows.GENERIC_READ,
+		windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE, nil, windows.OPEN_EXISTING, 0, 0)
+	if err != nil {
+		return nil, unavailable("ボリュームを開けません（管理者権限が必要です）", err)
+	}
+	return &volume{name: name, handle: handle}, nil
+}
+
+func (v *volume) close() {
+	windows.CloseHandle(v.handle)
+}
+
+// query はボリュームのUSNジャーナルの情報を取得する
+func (v *volume) query() (*usnJournalData, error) {
+	var data usnJournalData
+	var returned uint32
+	err := windows.DeviceIoControl(v.handle, fsctlQueryUsnJournal, nil, 0,
+		(*byte)(unsafe.Pointer(&data)), uint32(unsafe.Sizeof(data)), &returned, nil)
+	if err != nil {
+		return nil, unavailable("USNジャーナルを取得できません", err)
+	}
+	return &data, nil
+}
+
+// unavailable はジャーナルを使用できない原因のエラーをErrUnavailableとして返す
+func unavailable(message string, err error) error {
+	switch {
+	case errors.Is(err, windows.ERROR_ACCESS_DENIED),
+		errors.Is(err, windows.ERROR_INVALID_FUNCTION),
+		errors.Is(err, windows.ERROR_JOURNAL_NOT_ACTIVE),
+		errors.Is(err, windows.ERROR_JOURNAL_DELETE_IN_PROGRESS),
+		errors.Is(err, windows.ERROR_JOURNAL_ENTRY_DELETED):
+		return fmt.Errorf("%w: %s: %v", ErrUnavailable, message, err)
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
+
+func current(root string) (Position, error) {
+	v, err := openVolume(root)
+	if err != nil {
+		return Position{}, err
+	}
+	defer v.close()
+
+	data, err := v.query()
+	if err != nil {
+		return Position{}, err
+	}
+	return Position{Volume: v.name, JournalID: data.UsnJournalID, NextUSN: data.NextUsn}, nil
+}
+
+// recordKey はジャーナルのレコードの親ディレクトリと名前
+type recordKey struct {
+	parent uint64
+	name   string
+}
+
+func readChanges(root string, since, until Position) ([]change, int, error) {
+	v, err := openVolume(root)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer v.close()
+
+	data, err := v.query()
+	if err != nil {
+		return nil, 0, err
+	}
+	if v.name != since.Volume || data.UsnJournalID != since.JournalID {
+		return nil, 0, fmt.Errorf("%w: ジャーナルが作り直されています", ErrUnavailable)
+	}
+	if since.NextUSN < data.FirstUsn {
+		return nil, 0, fmt.Errorf("%w: 記録した位置のレコードが既に削除されています", ErrUnavailable)
+	}
+
+	// 同じファイルの変更は複数のレコードになるため、親ディレクトリと名前でまとめてからパスを取得する
+	var keys []recordKey
+	dirAdded := make(map[recordKey]bool)
+	records := 0
+	input := readUsnJournalData{StartUsn: since.NextUSN, ReasonMask: 0xFFFFFFFF, UsnJournalID: since.JournalID}
+	buf := make([]byte, readBufferSize)
+	for input.StartUsn < until.NextUSN {
+		var returned uint32
+		err := windows.DeviceIoControl(v.handle, fsctlReadUsnJournal,
+			(*byte)(unsafe.Pointer(&input)), uint32(unsafe.Sizeof(input)), &buf[0], uint32(len(buf)), &returned, nil)
+		if err != nil {
+			return nil, 0, unavailable("USNジャーナルの読み込みに失敗", err)
+		}
+		if returned <= 8 {
+			break
+		}
+
+		next := int64(binary.LittleEndian.Uint64(buf))
+		for offset := uint32(8); offset+uint32(usnRecordHeaderV2) <= returned; {
+			record := buf[offset:returned]
+			length := binary.LittleEndian.Uint32(record)
+			if length == 0 || int(length) > len(record) {
+				break
+			}
+			offset += length
+			if binary.LittleEndian.Uint16(record[4:]) != 2 {
+				continue
+			}
+			if int64(binary.LittleEndian.Uint64(record[24:])) >= until.NextUSN {
+				next = until.NextUSN
+				break
+			}
+			records++
+
+			reason := binary.LittleEndian.Uint32(record[40:])
+			if reason&(usnReasonFileDelete|usnReasonRenameOldName) != 0 {
+				continue
+			}
+			nameLen := int(binary.LittleEndian.Uint16(record[56:]))
+			nameOff := int(binary.LittleEndian.Uint16(record[58:]))
+			if nameOff+nameLen > int(length) {
+				continue
+			}
+			key := recordKey{parent: binary.LittleEndian.Uint64(record[16:]), name: utf16Name(record[nameOff : nameOff+nameLen])}
+			if _, ok := dirAdded[key]; !ok {
+				keys = append(keys, key)
+				dirAdded[key] = false
+			}
+			attrs := binary.LittleEndian.Uint32(record[52:])
+			if attrs&windows.FILE_ATTRIBUTE_DIRECTORY != 0 && reason&(usnReasonFileCreate|usnReasonRenameNewName) != 0 {
+				dirAdded[key] = true
+			}
+		}
+		if next <= input.StartUsn {
+			break
+		}
+		input.StartUsn = next
+	}
+
+	// 親ディレクトリのパスを取得する（削除された親ディレクトリは対象外）
+	parents := make(map[uint64]string)
+	var changes []change
+	for _, key := range keys {
+		parent, ok := parents[key.parent]
+		if !ok {
+			parent, err = v.pathByID(key.parent)
+			if err != nil {
+				parent = ""
+			}
+			parents[key.parent] = parent
+		}
+		if parent == "" {
+			continue
+		}
+		changes = append(changes, change{path: filepath.Join(parent, key.name), dirAdded: dirAdded[key]})
+	}
+	return changes, records, nil
+}
+
+// pathByID はファイルIDからファイルのパスを取得する
+func (v *volume) pathByID(id uint64) (string, error) {
+	desc := fileIDDescriptor{Size: uint32(unsafe.Sizeof(fileIDDescriptor{})), FileID: id}
+	r, _, err := procOpenFileById.Call(uintptr(v.handle), uintptr(unsafe.Pointer(&desc)), 0,
+		windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE|windows.FILE_SHARE_DELETE, 0, windows.FILE_FLAG_BACKUP_SEMANTICS)
+	handle := windows.Handle(r)
+	if handle == windows.InvalidHandle {
+		return "", fmt.Errorf("%w: %v", errNotResolvable, err)
+	}
+	defer windows.CloseHandle(handle)
+
+	buf := make([]uint16, windows.MAX_LONG_PATH)
+	n, err := windows.GetFinalPathNameByHandle(handle, &buf[0], uint32(len(buf)), 0)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", errNotResolvable, err)
+	}
+	if n > uint32(len(buf)) {
+		return "", errNotResolvable
+	}
+	path := windows.UTF16ToString(buf[:n])
+	if strings.HasPrefix(path, `\\?\UNC\`) {
+		return `\\` + path[len(`\\?\UNC\`):], nil
+	}
+	return strings.TrimPrefix(path, `\\?\`), nil
+}
+
+// utf16Name はレコードのファイル名（UTF-16LE）を文字列に変換する
+func utf16Name(b []byte) string {
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(b[i*2:])
+	}
+	return windows.UTF16ToString(units)
+}
@@ -0,0 +1,182 @@
+// Package changelist は同期DBのセッションごとの記録から、1回の実行で宛先に加えた変更（作成・更新・削除）の一覧を作成する
+// 検索インデクサやCDCのパイプラインなど、宛先の変更を後段のシステムに伝えるために使用する。
+// 1回の実行は、コピーセッションと、次のコピーセッションまでに行った検証セッション（余分なファイルの削除を含む）からなる
+package changelist
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/hasher"
+	"github.com/sakuhanight/gopier/internal/pathkey"
+)
+
+// Entry は宛先に加えた1件の変更を表す構造体
+type Entry struct {
+	Path      string `json:"path"`   // 宛先の相対パス
+	Change    string `json:"change"` // created, updated, deleted
+	Size      int64  `json:"size"`
+	ModTime   string `json:"mod_time,omitempty"` // 更新日時（RFC 3339）
+	Hash      string `json:"hash,omitempty"`     // 宛先の内容のハッシュ（記録・計算していない場合は空）
+	HashAlgo  string `json:"hash_algo,omitempty"`
+	SessionID int64  `json:"session_id"`
+}
+
+// Changelist は1回の実行の変更の一覧を表す構造体
+type Changelist struct {
+	SessionID int64          `json:"session_id"` // コピーセッションのID
+	StartedAt time.Time      `json:"started_at"`
+	Label     string         `json:"label,omitempty"`
+	Sessions  []int64        `json:"sessions"` // 対象にしたセッション（コピーと、その後の検証）
+	Counts    map[string]int `json:"counts"`   // 変更の種類ごとの件数
+	Changes   []Entry        `json:"changes"`
+}
+
+// Options は変更の一覧の作成のオプションを表す構造体
+type Options struct {
+	SessionID int64          // コピーセッションのID（0は最新のコピーセッション）
+	DestDir   string         // 宛先ディレクトリ（指定した場合は作成・更新したファイルのハッシュを宛先から計算する）
+	Hasher    *hasher.Hasher // 宛先のハッシュの計算に使用する
+}
+
+// RunSessions はコピーセッションと、そのセッションから次のコピーセッションまでの検証セッションを返す
+// idが0の場合は最新のコピーセッションを使用する
+func RunSessions(sessions []database.SyncSession, id int64) (database.SyncSession, []int64, error) {
+	start := -1
+	for i, session := range sessions {
+		if !isCopySession(session) {
+			continue
+		}
+		if id == 0 || session.ID == id {
+			start = i
+		}
+		if session.ID == id {
+			break
+		}
+	}
+	if start < 0 {
+		if id == 0 {
+			return database.SyncSession{}, nil, fmt.Errorf("コピーセッションがありません")
+		}
+		return database.SyncSession{}, nil, fmt.Errorf("コピーセッションが見つかりません: %d", id)
+	}
+
+	ids := []int64{sessions[start].ID}
+	for _, session := range sessions[start+1:] {
+		if isCopySession(session) {
+			break
+		}
+		ids = append(ids, session.ID)
+	}
+	return sessions[start], ids, nil
+}
+
+// isCopySession はコピーセッションかどうかを返す（種類を記録していない古いセッションはコピーとして扱う）
+func isCopySession(session database.SyncSession) bool {
+	return session.Type == "" || session.Type == string(database.SessionCopy)
+}
+
+// Build は同期DBの記録から変更の一覧を作成する（パス順）
+func Build(db *database.SyncDB, opts Options) (*Changelist, error) {
+	sessions, err := db.GetSessions()
+	if err != nil {
+		return nil, fmt.Errorf("セッション一覧の取得に失敗: %w", err)
+	}
+	session, ids, err := RunSessions(sessions, opts.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	inRun := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		inRun[id] = true
+	}
+
+	list := &Changelist{
+		SessionID: session.ID,
+		StartedAt: session.StartTime,
+		Label:     session.Label,
+		Sessions:  ids,
+		Counts:    map[string]int{},
+		Changes:   []Entry{},
+	}
+	err = db.ForEachFile(false, func(file database.FileInfo) error {
+		if file.Change == "" || !inRun[file.SessionID] {
+			return nil
+		}
+		entry, err := newEntry(file, opts)
+		if err != nil {
+			return err
+		}
+		list.Changes = append(list.Changes, entry)
+		list.Counts[entry.Change]++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// newEntry はファイル情報を変更に変換する
+func newEntry(file database.FileInfo, opts Options) (Entry, error) {
+	entry := Entry{
+		Path:      file.Path,
+		Change:    string(file.Change),
+		Size:      file.Size,
+		SessionID: file.SessionID,
+	}
+	if file.DestPath != "" {
+		entry.Path = file.DestPath
+	}
+	if !file.ModTime.IsZero() {
+		entry.ModTime = file.ModTime.Format(time.RFC3339Nano)
+	}
+	if file.Change == database.ChangeDeleted {
+		return entry, nil
+	}
+
+	if opts.DestDir != "" && opts.Hasher != nil {
+		hash, err := opts.Hasher.HashFile(filepath.Join(opts.DestDir, pathkey.ToNative(entry.Path)))
+		if errors.Is(err, os.ErrNotExist) {
+			// 実行の後に宛先から削除されたファイルはハッシュなしで出力する
+			return entry, nil
+		}
+		if err != nil {
+			return entry, fmt.Errorf("宛先のハッシュの計算に失敗: %s: %w", entry.Path, err)
+		}
+		entry.Hash, entry.HashAlgo = hash, opts.Hasher.GetAlgorithmName()
+		return entry, nil
+	}
+
+	// 変換していないファイルはソースと宛先の内容が同じため、ソースのハッシュも使用できる
+	entry.Hash = file.DestHash
+	if entry.Hash == "" && file.Transform == nil {
+		entry.Hash = file.SourceHash
+		entry.HashAlgo = file.HashAlgo
+	}
+	return entry, nil
+}
+
+// WriteJSON は変更の一覧をJSONで書き出す
+func WriteJSON(w io.Writer, list *Changelist) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(list)
+}
+
+// WriteNDJSON は変更を1行に1件のJSONで書き出す（後段のシステムで逐次読み込む場合に使用する）
+func WriteNDJSON(w io.Writer, list *Changelist) error {
+	encoder := json.NewEncoder(w)
+	for _, entry := range list.Changes {
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package changelist
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/hasher"
+)
+
+func TestRunSessions(t *testing.T) {
+	sessions := []database.SyncSession{
+		{ID: 1, Type: "copy"},
+		{ID: 2, Type: "verify"},
+		{ID: 3}, // 種類を記録していない古いセッション
+		{ID: 4, Type: "verify"},
+		{ID: 5, Type: "verify"},
+	}
+
+	session, ids, err := RunSessions(sessions, 0)
+	if err != nil || session.ID != 3 || len(ids) != 3 || ids[2] != 5 {
+		t.Errorf("最新の実行 = %d, %v, %v", session.ID, ids, err)
+	}
+	session, ids, err = RunSessions(sessions, 1)
+	if err != nil || session.ID != 1 || len(ids) != 2 || ids[1] != 2 {
+		t.Errorf("指定した実行 = %d, %v, %v", session.ID, ids, err)
+	}
+	if _, _, err := RunSessions(sessions, 2); err == nil {
+		t.Error("検証セッションを指定した場合はエラーになるべき")
+	}
+	if _, _, err := RunSessions(nil, 0); err == nil {
+		t.Error("セッションがない場合はエラーになるべき")
+	}
+}
+
+func TestBuild(t *testing.T) {
+	tempDir := t.TempDir()
+	db, err := database.NewSyncDB(filepath.Join(tempDir, "test.db"), database.NormalSync)
+	if err != nil {
+		t.Fatalf("データベース作成が失敗: %v", err)
+	}
+	defer db.Close()
+
+	// 前回の実行の変更は含めない
+	oldID, _ := db.StartSyncSession()
+	db.AddFile(database.FileInfo{Path: "old.txt", Status: database.StatusSuccess, SessionID: oldID, Change: database.ChangeCreated})
+
+	copyID, _ := db.StartSyncSession()
+	db.AddFile(database.FileInfo{Path: "b/new.txt", Size: 3, Status: database.StatusSuccess, SessionID: copyID, Change: database.ChangeCreated})
+	db.AddFile(database.FileInfo{Path: "a.txt", Status: database.StatusSuccess, SessionID: copyID, Change: database.ChangeUpdated, SourceHash: "abc"})
+	db.AddFile(database.FileInfo{Path: "skipped.txt", Status: database.StatusSkipped})
+	verifyID, _ := db.StartSession(database.SessionVerify)
+	// 検証結果の記録で変更の種類が失われない
+	db.AddFile(database.FileInfo{Path: "a.txt", Status: database.StatusVerified, SourceHash: "abc", DestHash: "abc"})
+	db.AddFile(database.FileInfo{Path: "extra.txt", Status: database.StatusDeleted, SessionID: verifyID, Change: database.ChangeDeleted})
+
+	list, err := Build(db, Options{})
+	if err != nil {
+		t.Fatalf("Buildが失敗: %v", err)
+	}
+	if list.SessionID != copyID || len(list.Sessions) != 2 {
+		t.Errorf("セッション = %d, %v", list.SessionID, list.Sessions)
+	}
+	var got []string
+	for _, e := range list.Changes {
+		got = append(got, e.Change+":"+e.Path+":"+e.Hash)
+	}
+	want := "updated:a.txt:abc,created:b/new.txt:,deleted:extra.txt:"
+	if strings.Join(got, ",") != want {
+		t.Errorf("変更 = %v, want %s", got, want)
+	}
+	if list.Counts["created"] != 1 || list.Counts["updated"] != 1 || list.Counts["deleted"] != 1 {
+		t.Errorf("件数 = %v", list.Counts)
+	}
+
+	// 宛先からハッシュを計算する
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(filepath.Join(destDir, "b"), 0755)
+	os.WriteFile(filepath.Join(destDir, "b", "new.txt"), []byte("new"), 0644)
+	h := hasher.NewHasher(hasher.SHA256, 0)
+	list, err = Build(db, Options{DestDir: destDir, Hasher: h})
+	if err != nil {
+		t.Fatalf("Buildが失敗: %v", err)
+	}
+	want256, _ := h.HashFile(filepath.Join(destDir, "b", "new.txt"))
+	for _, e := range list.Changes {
+		switch e.Path {
+		case "b/new.txt":
+			if e.Hash != want256 || e.HashAlgo != "sha256" {
+				t.Errorf("計算したハッシュ = %s (%s)", e.Hash, e.HashAlgo)
+			}
+		case "a.txt":
+			// 宛先から削除されたファイルはハッシュなし
+			if e.Hash != "" {
+				t.Errorf("存在しないファイルのハッシュ = %s", e.Hash)
+			}
+		}
+	}
+
+	// NDJSONは1行に1件
+	var buf bytes.Buffer
+	if err := WriteNDJSON(&buf, list); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 3 {
+		t.Errorf("NDJSONの行数 = %d", lines)
+	}
+
+	// 指定したコピーセッション
+	list, err = Build(db, Options{SessionID: oldID})
+	if err != nil || len(list.Changes) != 1 || list.Changes[0].Path != "old.txt" {
+		t.Errorf("前回の実行の変更 = %+v, %v", list, err)
+	}
+}
@@ -0,0 +1,139 @@
+// Package chmod は宛先のファイル・ディレクトリのアクセス権を揃えるための、rsyncの--chmodと同様の指定を提供する
+package chmod
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// clause は1つの指定（"D755"・"Fgo-w"など）を表す構造体
+type clause struct {
+	dirs, files bool        // 対象（どちらも指定されていない場合は両方）
+	absolute    bool        // 8進数で指定した場合はtrue
+	mode        os.FileMode // 8進数で指定したアクセス権
+	who         os.FileMode // 記号で指定した場合の対象のビット（u・g・o）
+	op          byte        // '+'・'-'・'='
+	perm        os.FileMode // 記号で指定したr・w・x（whoに合わせる前の所有者・グループ・その他すべてのビット）
+	execIfAny   bool        // X（ディレクトリ、またはいずれかの実行権がある場合のみx）
+}
+
+// Mask はアクセス権の変更の指定を表す構造体
+type Mask struct {
+	clauses []clause
+}
+
+// Parse はカンマ区切りの指定（例: "D755,F644"・"Du+rwx,Fgo-w"）を解析する
+// 各指定は先頭のDでディレクトリのみ、Fでファイルのみを対象とし、8進数または記号（[ugoa]*[-+=][rwxX]*）で指定する
+// 空の場合はnilを返す
+func Parse(spec string) (*Mask, error) {
+	var m Mask
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		c, err := parseClause(part)
+		if err != nil {
+			return nil, err
+		}
+		m.clauses = append(m.clauses, c)
+	}
+	if len(m.clauses) == 0 {
+		return nil, nil
+	}
+	return &m, nil
+}
+
+func parseClause(part string) (clause, error) {
+	var c clause
+	s := part
+	switch s[0] {
+	case 'D':
+		c.dirs, s = true, s[1:]
+	case 'F':
+		c.files, s = true, s[1:]
+	}
+	if s == "" {
+		return c, fmt.Errorf("アクセス権の指定がありません: %s", part)
+	}
+
+	// 8進数の指定
+	if s[0] >= '0' && s[0] <= '7' {
+		mode, err := strconv.ParseUint(s, 8, 32)
+		if err != nil || mode > 0777 {
+			return c, fmt.Errorf("アクセス権の8進数の指定が不正です: %s", part)
+		}
+		c.absolute, c.mode = true, os.FileMode(mode)
+		return c, nil
+	}
+
+	// 記号の指定
+	i := 0
+	for ; i < len(s) && strings.IndexByte("ugoa", s[i]) >= 0; i++ {
+		switch s[i] {
+		case 'u':
+			c.who |= 0700
+		case 'g':
+			c.who |= 0070
+		case 'o':
+			c.who |= 0007
+		case 'a':
+			c.who |= 0777
+		}
+	}
+	if c.who == 0 {
+		c.who = 0777
+	}
+	if i == len(s) || strings.IndexByte("+-=", s[i]) < 0 {
+		return c, fmt.Errorf("アクセス権の指定に+・-・=がありません: %s", part)
+	}
+	c.op = s[i]
+	for _, r := range s[i+1:] {
+		switch r {
+		case 'r':
+			c.perm |= 0444
+		case 'w':
+			c.perm |= 0222
+		case 'x':
+			c.perm |= 0111
+		case 'X':
+			c.execIfAny = true
+		default:
+			return c, fmt.Errorf("アクセス権の指定に使用できない文字があります（r・w・x・Xのみ）: %s", part)
+		}
+	}
+	return c, nil
+}
+
+// Apply はアクセス権permに指定を順に適用した結果を返す
+func (m *Mask) Apply(perm os.FileMode, isDir bool) os.FileMode {
+	if m == nil {
+		return perm
+	}
+	perm &= os.ModePerm
+	for _, c := range m.clauses {
+		if (c.dirs && !isDir) || (c.files && isDir) {
+			continue
+		}
+		if c.absolute {
+			perm = c.mode
+			continue
+		}
+		bits := c.perm
+		if c.execIfAny && (isDir || perm&0111 != 0) {
+			bits |= 0111
+		}
+		bits &= c.who
+		switch c.op {
+		case '+':
+			perm |= bits
+		case '-':
+			perm &^= bits
+		case '=':
+			perm = perm&^c.who | bits
+		}
+	}
+	return perm
+}
@@ -0,0 +1,45 @@
+package chmod
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseApply(t *testing.T) {
+	tests := []struct {
+		spec  string
+		perm  os.FileMode
+		isDir bool
+		want  os.FileMode
+	}{
+		{"D755,F644", 0700, true, 0755},
+		{"D755,F644", 0777, false, 0644},
+		{"go-w", 0777, false, 0755},
+		{"u+rwx,g=rX,o=", 0640, true, 0750},
+		{"a+X", 0644, false, 0644},
+		{"a+X", 0744, false, 0755},
+		{"Fa-x,Da+x", 0766, false, 0666},
+		{"640,o+r", 0777, false, 0644},
+	}
+	for _, tt := range tests {
+		m, err := Parse(tt.spec)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.spec, err)
+		}
+		if got := m.Apply(tt.perm, tt.isDir); got != tt.want {
+			t.Errorf("Parse(%q).Apply(%o, %v) = %o, want %o", tt.spec, tt.perm, tt.isDir, got, tt.want)
+		}
+	}
+
+	if m, err := Parse(" , "); err != nil || m != nil {
+		t.Errorf("Parse(空) = %v, %v; want nil, nil", m, err)
+	}
+	if got := (*Mask)(nil).Apply(0600, false); got != 0600 {
+		t.Errorf("nil.Apply() = %o, want 600", got)
+	}
+	for _, spec := range []string{"D", "F999", "1777", "u", "u+s", "z+r"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) がエラーになりません", spec)
+		}
+	}
+}
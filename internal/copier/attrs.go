@@ -0,0 +1,50 @@
+package copier
+
+import (
+	"os"
+	"sync/atomic"
+
+	"github.com/sakuhanight/gopier/internal/fsmeta"
+)
+
+// ExcludedCounts は属性によって除外したファイル・ディレクトリの件数を表す構造体
+type ExcludedCounts struct {
+	Hidden int64 // 隠しファイル・ディレクトリ
+	System int64 // システムファイル・ディレクトリ
+}
+
+// excludeByAttributes は隠し・システム属性によってエントリを除外するかどうかを判断し、除外した件数を数える
+// 隠しとシステムの両方の属性を持つ場合はシステムとして数える
+func (fc *FileCopier) excludeByAttributes(entry os.DirEntry) bool {
+	counter := fc.attributeCounter(entry)
+	if counter == nil {
+		return false
+	}
+	atomic.AddInt64(counter, 1)
+	return true
+}
+
+// attributeCounter は隠し・システム属性によって除外するエントリの件数のカウンタを返す（除外しない場合はnil）
+func (fc *FileCopier) attributeCounter(entry os.DirEntry) *int64 {
+	if fc.options.IncludeHidden && fc.options.IncludeSystem {
+		return nil
+	}
+
+	info, _ := fc.entryInfo(entry)
+	attrs := fsmeta.FileAttributes(entry.Name(), info)
+	switch {
+	case attrs.System && !fc.options.IncludeSystem:
+		return &fc.excluded.System
+	case attrs.Hidden && !fc.options.IncludeHidden:
+		return &fc.excluded.Hidden
+	}
+	return nil
+}
+
+// GetExcludedCounts は属性によって除外した件数を返す
+func (fc *FileCopier) GetExcludedCounts() ExcludedCounts {
+	return ExcludedCounts{
+		Hidden: atomic.LoadInt64(&fc.excluded.Hidden),
+		System: atomic.LoadInt64(&fc.excluded.System),
+	}
+}
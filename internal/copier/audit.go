@@ -0,0 +1,29 @@
+package copier
+
+import (
+	"sync/atomic"
+
+	"github.com/sakuhanight/gopier/internal/audit"
+)
+
+// writeAudit は完了した操作を監査ログに記録する（監査ログを使用しない場合は何もしない）
+// 書き込みに失敗してもコピーは続けるが、最初の失敗のみエラーを出力する
+func (fc *FileCopier) writeAudit(rec audit.Record, err error) {
+	if fc.options.Audit == nil {
+		return
+	}
+
+	rec.SessionID = atomic.LoadInt64(&fc.sessionID)
+	if rec.SourceHash != "" || rec.DestHash != "" {
+		rec.HashAlgo = fc.options.HashAlgorithm
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	if fc.options.Audit.Err() != nil {
+		return
+	}
+	if err := fc.options.Audit.Write(rec); err != nil && fc.logger != nil {
+		fc.logger.Error("%v（以降の操作は監査ログに記録されません）", err)
+	}
+}
@@ -0,0 +1,355 @@
+package copier
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/batch"
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+// DefaultBatchSize はセグメントのサイズの目安のデフォルト値
+const DefaultBatchSize = 64 * 1024 * 1024
+
+// batches は小さいファイルをまとめて書き込むセグメントの状態
+// セグメントへの書き込みは1つずつ行うため、ワーカーはmuを取得してから書き込む
+type batches struct {
+	mu      sync.Mutex
+	current *segment // 書き込み中のセグメント（nilの場合は次のファイルで作成する）
+	seq     int      // セッションの中で作成したセグメントの数
+	cleaned bool     // 異常終了で残った書き込み中のセグメントを削除したかどうか
+}
+
+// segment は書き込み中のセグメント
+type segment struct {
+	name     string // 宛先からの相対パス（/区切り）
+	tempPath string // 書き込みを終えるまでの一時ファイルのパス
+	file     vfs.File
+	writer   *batch.Writer
+	members  []batchedFile // 書き込んだファイル（セグメントの書き込みを終えた時点で記録する）
+}
+
+// batchedFile はセグメントに書き込んだファイル
+type batchedFile struct {
+	relPath    string
+	sourcePath string
+	destPath   string // セグメントにまとめない場合のコピー先（個別にコピーした古い内容の削除に使用する）
+	sourceInfo os.FileInfo
+	offset     int64
+	hash       string
+	change     database.ChangeKind
+}
+
+// batchEnabled はファイルを個別にコピーせずにセグメントにまとめて書き込むかどうかを判断する
+// セグメントの中の位置はDBに記録するため、DBを使用しない場合はまとめない
+func (fc *FileCopier) batchEnabled(sourceInfo os.FileInfo) bool {
+	return fc.options.BatchThreshold > 0 && fc.db != nil &&
+		sourceInfo.Mode().IsRegular() && sourceInfo.Size() <= fc.options.BatchThreshold
+}
+
+// copyToBatch は小さいファイルを書き込み中のセグメントに追加する
+// DBへの記録とコピーした件数の計上は、セグメントの書き込みを終えた時点（finishSegment）で行う
+func (fc *FileCopier) copyToBatch(sourcePath, destPath, relPath string, sourceInfo os.FileInfo, fileInfo *database.FileInfo) error {
+	member, err := fc.db.GetBatchMember(relPath)
+	if err != nil && fc.logger != nil && fc.logger.Verbose {
+		fc.logger.Warn("データベース検索エラー: %v", err)
+	}
+
+	change := database.ChangeCreated
+	if member != nil {
+		change = database.ChangeUpdated
+		// 前回セグメントに書き込んだ時点から変更されていないファイルはスキップ
+		if member.Size == sourceInfo.Size() && member.ModTime.Equal(sourceInfo.ModTime()) {
+			return fc.skipBatched(relPath, sourceInfo, "", "")
+		}
+	} else if destInfo, err := fc.statDest(destPath); err == nil {
+		change = database.ChangeUpdated
+		// セグメントにまとめる前に個別にコピーしたファイルは、変更されていなければそのまま残す
+		if fc.upToDate(sourceInfo, destInfo, fileInfo, nil) {
+			return fc.skipBatched(relPath, sourceInfo, "", "")
+		}
+	}
+	if change == database.ChangeUpdated && !fc.options.OverwriteExisting {
+		return fc.skipBatched(relPath, sourceInfo, database.ReasonDestExists, "宛先ファイルが既に存在します")
+	}
+
+	// 空のディレクトリを作成しない場合も、展開と検証で宛先の構造が揃うよう親ディレクトリは作成する
+	if fc.options.CreateDirs && !fc.options.CopyEmptyDirs {
+		if err := fc.mkdirDest(filepath.Dir(destPath)); err != nil {
+			return fc.failBatched(relPath, sourceInfo, errcode.Wrap(errcode.ErrDestWrite, err))
+		}
+	}
+
+	sourceFile, err := fc.openSource(sourcePath)
+	if err != nil {
+		return fc.failBatched(relPath, sourceInfo, errcode.Errorf(errcode.ErrSourceRead, "ソースファイル(%s)を開けません: %w", sourcePath, err))
+	}
+	defer sourceFile.Close()
+
+	h, err := fc.hasher.NewHash()
+	if err != nil {
+		return fc.failBatched(relPath, sourceInfo, err)
+	}
+
+	fc.batches.mu.Lock()
+	defer fc.batches.mu.Unlock()
+
+	seg, err := fc.currentSegment()
+	if err != nil {
+		return fc.failBatched(relPath, sourceInfo, errcode.Wrap(errcode.ErrDestWrite, err))
+	}
+	reader := io.TeeReader(fc.sourceReader(sourceFile), h)
+	offset, err := seg.writer.Add(relPath, sourceInfo.Size(), sourceInfo.ModTime(), sourceInfo.Mode(), reader)
+	if writeErr := seg.writer.Err(); writeErr != nil {
+		// セグメントが壊れたため、書き込んだファイルもすべて失敗として扱う
+		fc.batches.current = nil
+		seg.file.Close()
+		fc.removeDest(seg.tempPath)
+		err = errcode.Errorf(errcode.ErrDestWrite, "セグメント(%s)の書き込みエラー: %w", seg.name, writeErr)
+		fc.failSegment(seg, err)
+		return fc.failBatched(relPath, sourceInfo, err)
+	}
+	if err != nil {
+		return fc.failBatched(relPath, sourceInfo, errcode.Wrap(errcode.ErrSourceRead, err))
+	}
+
+	// コピー中に変更されたファイルは、次回の実行でコピーし直すよう失敗として扱う
+	if info, err := fc.statSource(sourcePath); err != nil || info.Size() != sourceInfo.Size() || !info.ModTime().Equal(sourceInfo.ModTime()) {
+		return fc.failBatched(relPath, sourceInfo, errcode.Errorf(errcode.ErrSourceRead, "ファイルがコピー中に変更されました"))
+	}
+
+	seg.members = append(seg.members, batchedFile{
+		relPath:    relPath,
+		sourcePath: sourcePath,
+		destPath:   destPath,
+		sourceInfo: sourceInfo,
+		offset:     offset,
+		hash:       fmt.Sprintf("%x", h.Sum(nil)),
+		change:     change,
+	})
+	if seg.writer.Size() >= fc.batchSize() {
+		fc.finishSegment()
+	}
+	return nil
+}
+
+// batchSize はセグメントのサイズの目安を返す（この大きさを超えた時点で書き込みを終える）
+func (fc *FileCopier) batchSize() int64 {
+	if fc.options.BatchSize > 0 {
+		return fc.options.BatchSize
+	}
+	return DefaultBatchSize
+}
+
+// currentSegment は書き込み中のセグメントを返す（ない場合は作成する）
+// fc.batches.muを取得して呼び出す
+func (fc *FileCopier) currentSegment() (*segment, error) {
+	if fc.batches.current != nil {
+		return fc.batches.current, nil
+	}
+
+	dir := filepath.Join(fc.destDir, filepath.FromSlash(batch.Dir))
+	if err := fc.mkdirDest(dir); err != nil {
+		return nil, fmt.Errorf("セグメントのディレクトリ(%s)の作成エラー: %w", dir, err)
+	}
+	if !fc.batches.cleaned {
+		fc.removePartialSegments(dir)
+		fc.batches.cleaned = true
+	}
+
+	fc.batches.seq++
+	name := batch.SegmentName(atomic.LoadInt64(&fc.sessionID), fc.batches.seq)
+	file, err := fc.createDestTemp(dir, path.Base(name)+"-*"+batch.PartialSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("セグメント(%s)を作成できません: %w", name, err)
+	}
+	fc.batches.current = &segment{
+		name:     name,
+		tempPath: file.Name(),
+		file:     file,
+		writer:   batch.NewWriter(file),
+	}
+	return fc.batches.current, nil
+}
+
+// removePartialSegments は前回の実行で書き込みの途中に異常終了したセグメントを削除する
+// 書き込みの途中のセグメントのファイルはDBに記録していないため、今回の実行で改めて書き込む
+func (fc *FileCopier) removePartialSegments(dir string) {
+	entries, err := fc.readDestDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !batch.IsPartial(entry.Name()) {
+			continue
+		}
+		if err := fc.removeDest(filepath.Join(dir, entry.Name())); err != nil && fc.logger != nil {
+			fc.logger.Warn("書き込みの途中のセグメントを削除できません: %s: %v", entry.Name(), err)
+		}
+	}
+}
+
+// flushBatch は書き込み中のセグメントの書き込みを終える
+func (fc *FileCopier) flushBatch() {
+	fc.batches.mu.Lock()
+	defer fc.batches.mu.Unlock()
+	fc.finishSegment()
+}
+
+// finishSegment は書き込み中のセグメントの書き込みを終え、書き込んだファイルをDBに記録する
+// ハッシュ検証を行う場合は、書き込んだセグメントを読み込み直して各ファイルの内容を検証する
+// fc.batches.muを取得して呼び出す
+func (fc *FileCopier) finishSegment() {
+	seg := fc.batches.current
+	fc.batches.current = nil
+	if seg == nil {
+		return
+	}
+
+	err := seg.writer.Close()
+	if err == nil {
+		err = seg.file.Sync()
+	}
+	if closeErr := seg.file.Close(); err == nil {
+		err = closeErr
+	}
+	segmentPath := filepath.Join(fc.destDir, filepath.FromSlash(seg.name))
+	if err == nil {
+		err = fc.renameDest(seg.tempPath, segmentPath)
+	}
+	if err != nil {
+		fc.removeDest(seg.tempPath)
+		fc.failSegment(seg, errcode.Errorf(errcode.ErrDestWrite, "セグメント(%s)の書き込みエラー: %w", seg.name, err))
+		return
+	}
+
+	var file vfs.File
+	if fc.options.VerifyHash {
+		if file, err = fc.openDest(segmentPath); err != nil {
+			fc.failSegment(seg, errcode.Errorf(errcode.ErrDestRead, "セグメント(%s)を開けません: %w", seg.name, err))
+			return
+		}
+		defer file.Close()
+	}
+
+	for _, m := range seg.members {
+		var destHash string
+		if file != nil {
+			destHash, err = fc.hasher.HashReader(batch.Section(file, m.offset, m.sourceInfo.Size()))
+			if err != nil {
+				fc.failBatchedMember(m, errcode.Errorf(errcode.ErrDestRead, "セグメント(%s)の読み込みエラー: %w", seg.name, err))
+				continue
+			}
+			if destHash != m.hash {
+				fc.failBatchedMember(m, errcode.Errorf(errcode.ErrHashMismatch, "ファイル '%s' のハッシュ値が一致しません (ソース: %s, 宛先: %s)", m.relPath, m.hash, destHash))
+				continue
+			}
+		}
+		fc.recordBatched(seg.name, m, destHash)
+	}
+
+	if fc.logger != nil && fc.logger.Verbose {
+		fc.logger.Info("セグメントを書き込みました: %s (%dファイル)", seg.name, len(seg.members))
+	}
+}
+
+// recordBatched はセグメントに書き込んだファイルをコピーしたファイルとして数え、DBに記録する
+func (fc *FileCopier) recordBatched(segmentName string, m batchedFile, destHash string) {
+	fc.countCopied(m.relPath, m.sourceInfo.Size())
+	fc.noteCopied(m.relPath, m.sourcePath, m.destPath, m.sourceInfo)
+
+	info := database.FileInfo{
+		Path:         m.relPath,
+		Size:         m.sourceInfo.Size(),
+		ModTime:      m.sourceInfo.ModTime(),
+		Status:       database.StatusSuccess,
+		SourceHash:   m.hash,
+		DestHash:     destHash,
+		HashAlgo:     fc.options.HashAlgorithm,
+		LastSyncTime: time.Now(),
+		SessionID:    atomic.LoadInt64(&fc.sessionID),
+		Change:       m.change,
+	}
+	if meta, err := fc.collectSourceMeta(m.sourcePath); err == nil {
+		info.Meta = meta
+	}
+	fc.db.AddBatchedFile(info, database.BatchMember{
+		Segment: segmentName,
+		Offset:  m.offset,
+		Size:    m.sourceInfo.Size(),
+		ModTime: m.sourceInfo.ModTime(),
+	})
+
+	// 個別にコピーした古い内容が残っていると、検証と展開でセグメントの内容より優先されるため削除する
+	if m.change == database.ChangeUpdated {
+		if _, err := fc.statDest(m.destPath); err == nil {
+			if err := fc.removeDest(m.destPath); err != nil && fc.logger != nil {
+				fc.logger.Warn("個別にコピーした古いファイルを削除できません: %s: %v", m.relPath, err)
+			}
+		}
+	}
+
+	if fc.logger != nil && fc.logger.Verbose {
+		fc.logger.Info("ファイルコピー成功: %s (%d bytes, %s)", m.relPath, m.sourceInfo.Size(), segmentName)
+	}
+}
+
+// skipBatched は変更されていないファイルをスキップしたファイルとして数え、DBに記録する
+// 変更されていても上書きしない場合は、その理由とメッセージを記録する
+func (fc *FileCopier) skipBatched(relPath string, sourceInfo os.FileInfo, reason database.Reason, message string) error {
+	fc.countSkipped(relPath, sourceInfo.Size())
+	fc.db.AddFile(database.FileInfo{
+		Path:         relPath,
+		Size:         sourceInfo.Size(),
+		ModTime:      sourceInfo.ModTime(),
+		Status:       database.StatusSkipped,
+		LastSyncTime: time.Now(),
+		LastError:    message,
+		Reason:       reason,
+	})
+	if fc.logger != nil && fc.logger.Verbose {
+		fc.logger.Info("ファイルをスキップ（内容同一）: %s", relPath)
+	}
+	return nil
+}
+
+// failBatched はセグメントに書き込めなかったファイルを失敗として数え、DBに記録する
+func (fc *FileCopier) failBatched(relPath string, sourceInfo os.FileInfo, err error) error {
+	fc.countFailed(relPath, err)
+	fc.db.AddFile(database.FileInfo{
+		Path:         relPath,
+		Size:         sourceInfo.Size(),
+		ModTime:      sourceInfo.ModTime(),
+		Status:       database.StatusFailed,
+		LastSyncTime: time.Now(),
+		LastError:    fmt.Sprintf("ファイルコピーエラー: %v", err),
+		Error:        errcode.Describe(err),
+	})
+	if fc.logger != nil && fc.logger.Verbose {
+		fc.logger.Error("ファイル '%s' のコピーに失敗しました: %v", relPath, err)
+	}
+	return fmt.Errorf("ファイル '%s' のコピーに失敗しました: %w", relPath, err)
+}
+
+// failBatchedMember はセグメントの書き込みを終えた後に失敗したファイルを記録する
+// ワーカーには成功として戻っているため、ワーカーの代わりにエラーを記録する
+func (fc *FileCopier) failBatchedMember(m batchedFile, err error) {
+	err = fc.failBatched(m.relPath, m.sourceInfo, err)
+	fc.stats.RecordError(m.relPath, err)
+	fc.recordFailure(m.relPath, err)
+}
+
+// failSegment はセグメントに書き込んだすべてのファイルを失敗として記録する
+func (fc *FileCopier) failSegment(seg *segment, err error) {
+	for _, m := range seg.members {
+		fc.failBatchedMember(m, err)
+	}
+}
@@ -0,0 +1,165 @@
+package copier
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/batch"
+	"github.com/sakuhanight/gopier/internal/database"
+)
+
+func TestCopyFiles_BatchSmallFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(filepath.Join(sourceDir, "dir"), 0755)
+	os.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("aaa"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "dir", "b.txt"), []byte("bbbb"), 0644)
+	large := bytes.Repeat([]byte("x"), 4096)
+	os.WriteFile(filepath.Join(sourceDir, "large.bin"), large, 0644)
+
+	syncDB, err := database.NewSyncDB(filepath.Join(tempDir, "test.db"), database.NormalSync)
+	if err != nil {
+		t.Fatalf("DBの作成に失敗: %v", err)
+	}
+	defer syncDB.Close()
+
+	run := func() *FileCopier {
+		t.Helper()
+		options := DefaultOptions()
+		options.MaxConcurrent = 2
+		options.VerifyHash = true
+		options.BatchThreshold = 1024
+		fc := NewFileCopier(sourceDir, destDir, options, nil, syncDB, nil)
+		if err := fc.CopyFiles(); err != nil {
+			t.Fatalf("CopyFilesが失敗しました: %v", err)
+		}
+		return fc
+	}
+	readMember := func(name string) (*database.BatchMember, string) {
+		t.Helper()
+		member, err := syncDB.GetBatchMember(name)
+		if err != nil || member == nil {
+			t.Fatalf("%s のセグメントの中の位置 = %+v, %v", name, member, err)
+		}
+		segment, err := os.Open(filepath.Join(destDir, filepath.FromSlash(member.Segment)))
+		if err != nil {
+			t.Fatalf("セグメントを開けません: %v", err)
+		}
+		defer segment.Close()
+		data, _ := io.ReadAll(batch.Section(segment, member.Offset, member.Size))
+		return member, string(data)
+	}
+
+	fc := run()
+	if got := fc.GetStats().GetCopiedCount(); got != 3 {
+		t.Errorf("コピーしたファイル数 = %d, want 3", got)
+	}
+	// 小さいファイルは個別に書き込まず、セグメントにまとめる
+	for _, name := range []string{"a.txt", "dir/b.txt"} {
+		if _, err := os.Stat(filepath.Join(destDir, filepath.FromSlash(name))); !os.IsNotExist(err) {
+			t.Errorf("%s が個別に書き込まれています", name)
+		}
+	}
+	if got, _ := os.ReadFile(filepath.Join(destDir, "large.bin")); !bytes.Equal(got, large) {
+		t.Error("大きいファイルが個別にコピーされていません")
+	}
+	first, content := readMember("a.txt")
+	if content != "aaa" {
+		t.Errorf("a.txtの内容 = %q", content)
+	}
+	if _, content := readMember("dir/b.txt"); content != "bbbb" {
+		t.Errorf("dir/b.txtの内容 = %q", content)
+	}
+	if record, _ := syncDB.GetFile("a.txt"); record == nil || record.DestHash == "" || record.DestHash != record.SourceHash {
+		t.Errorf("a.txtの記録 = %+v", record)
+	}
+	entries, _ := os.ReadDir(filepath.Join(destDir, batch.Dir))
+	for _, e := range entries {
+		if batch.IsPartial(e.Name()) {
+			t.Errorf("書き込み中のセグメントが残っています: %s", e.Name())
+		}
+	}
+
+	// 変更されていないファイルはスキップする
+	fc = run()
+	if copied, skipped := fc.GetStats().GetCopiedCount(), fc.GetStats().GetSkippedCount(); copied != 0 || skipped != 3 {
+		t.Errorf("2回目 = コピー %d, スキップ %d, want 0, 3", copied, skipped)
+	}
+
+	// 変更されたファイルは新しいセグメントに書き込む
+	later := time.Now().Add(time.Hour)
+	os.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("changed"), 0644)
+	os.Chtimes(filepath.Join(sourceDir, "a.txt"), later, later)
+	fc = run()
+	if got := fc.GetStats().GetCopiedCount(); got != 1 {
+		t.Errorf("3回目のコピーしたファイル数 = %d, want 1", got)
+	}
+	member, content := readMember("a.txt")
+	if content != "changed" || member.Segment == first.Segment {
+		t.Errorf("変更後のa.txt = %q (%s)", content, member.Segment)
+	}
+	if record, _ := syncDB.GetFile("a.txt"); record == nil || record.Change != database.ChangeUpdated {
+		t.Errorf("変更後のa.txtの記録 = %+v", record)
+	}
+}
+
+func TestCopyFiles_BatchReplacesIndividualCopy(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(sourceDir, 0755)
+	os.MkdirAll(destDir, 0755)
+	os.WriteFile(filepath.Join(sourceDir, "same.txt"), []byte("same"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "stale.txt"), []byte("new content"), 0644)
+	os.WriteFile(filepath.Join(destDir, "stale.txt"), []byte("old"), 0644)
+
+	syncDB, err := database.NewSyncDB(filepath.Join(tempDir, "test.db"), database.NormalSync)
+	if err != nil {
+		t.Fatalf("DBの作成に失敗: %v", err)
+	}
+	defer syncDB.Close()
+
+	// まとめる前に個別にコピーしたファイル
+	options := DefaultOptions()
+	fc := NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	fc.copyFile(filepath.Join(sourceDir, "same.txt"), filepath.Join(destDir, "same.txt"))
+
+	options.BatchThreshold = 1024
+	fc = NewFileCopier(sourceDir, destDir, options, nil, syncDB, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFilesが失敗しました: %v", err)
+	}
+
+	// 変更されていない個別のファイルはそのまま残し、古い内容の個別のファイルはセグメントに書き込んで削除する
+	if member, _ := syncDB.GetBatchMember("same.txt"); member != nil {
+		t.Errorf("変更されていないファイルがセグメントに書き込まれました: %+v", member)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "same.txt")); err != nil {
+		t.Errorf("個別にコピーしたファイルが削除されました: %v", err)
+	}
+	if member, _ := syncDB.GetBatchMember("stale.txt"); member == nil {
+		t.Error("変更されたファイルがセグメントに書き込まれていません")
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "stale.txt")); !os.IsNotExist(err) {
+		t.Errorf("古い内容の個別のファイルが残っています: %v", err)
+	}
+
+	// まとめずに個別にコピーし直したファイルは、セグメントの中の位置の記録を削除する
+	os.WriteFile(filepath.Join(sourceDir, "stale.txt"), []byte("individual content"), 0644)
+	options.BatchThreshold = 0
+	fc = NewFileCopier(sourceDir, destDir, options, nil, syncDB, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFilesが失敗しました: %v", err)
+	}
+	if member, _ := syncDB.GetBatchMember("stale.txt"); member != nil {
+		t.Errorf("個別にコピーしたファイルの位置の記録が残っています: %+v", member)
+	}
+	if data, _ := os.ReadFile(filepath.Join(destDir, "stale.txt")); string(data) != "individual content" {
+		t.Errorf("個別にコピーしたファイル = %q", data)
+	}
+}
@@ -0,0 +1,158 @@
+package copier
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BandwidthRate は時間帯に適用する帯域制限
+type BandwidthRate struct {
+	Limit   int64   // 秒あたりの最大転送バイト数（0は無制限、Percentが指定された場合は使用しない）
+	Percent float64 // 基本の帯域制限（--bwlimit）に対する割合（0の場合はLimitを使用する）
+}
+
+// apply は基本の帯域制限に対して適用した値を返す（無制限の割合は無制限）
+func (r BandwidthRate) apply(base int64) int64 {
+	if r.Percent <= 0 {
+		return r.Limit
+	}
+	return int64(float64(base) * r.Percent / 100)
+}
+
+// BandwidthWindow は帯域制限を変更する時間帯
+type BandwidthWindow struct {
+	Start int // 開始時刻（0時からの分）
+	End   int // 終了時刻（0時からの分、開始時刻より前の場合は翌日まで）
+	Rate  BandwidthRate
+}
+
+// contains は時刻（0時からの分）が時間帯に含まれるかどうかを返す
+func (w BandwidthWindow) contains(minute int) bool {
+	if w.Start < w.End {
+		return minute >= w.Start && minute < w.End
+	}
+	return minute >= w.Start || minute < w.End
+}
+
+// BandwidthSchedule は時刻ごとの帯域制限のスケジュール
+// 最初に一致した時間帯の帯域制限を適用し、どの時間帯にも一致しない場合はDefault（nilの場合は基本の帯域制限）を適用する
+type BandwidthSchedule struct {
+	Windows []BandwidthWindow
+	Default *BandwidthRate
+}
+
+// LimitAt は指定した時刻に適用する帯域制限を返す
+func (s *BandwidthSchedule) LimitAt(t time.Time, base int64) int64 {
+	if s == nil {
+		return base
+	}
+
+	hour, minute, _ := t.Clock()
+	m := hour*60 + minute
+	for _, w := range s.Windows {
+		if w.contains(m) {
+			return w.Rate.apply(base)
+		}
+	}
+	if s.Default != nil {
+		return s.Default.apply(base)
+	}
+	return base
+}
+
+// UsesPercent は基本の帯域制限に対する割合を指定した時間帯があるかどうかを返す
+func (s *BandwidthSchedule) UsesPercent() bool {
+	if s == nil {
+		return false
+	}
+	for _, w := range s.Windows {
+		if w.Rate.Percent > 0 {
+			return true
+		}
+	}
+	return s.Default != nil && s.Default.Percent > 0
+}
+
+// ParseBandwidthSchedule は時刻ごとの帯域制限の指定を解析する
+// 形式は「時間帯=帯域制限」のカンマ区切りで、時間帯は"HH:MM-HH:MM"（日をまたいでもよい）または
+// それ以外のすべての時刻を表す"*"、帯域制限はParseBandwidthの形式または--bwlimitに対する割合（例: "20%"）
+// 例: "22:00-06:00=100%,*=20%"、"09:00-18:00=5M"
+// 空文字列の場合はnilを返す
+func ParseBandwidthSchedule(spec string) (*BandwidthSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+
+	schedule := &BandwidthSchedule{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("帯域制限のスケジュールの指定が不正です（時間帯=帯域制限の形式で指定してください）: %q", entry)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		rate, err := parseBandwidthRate(value)
+		if err != nil {
+			return nil, err
+		}
+
+		if key == "*" {
+			if schedule.Default != nil {
+				return nil, fmt.Errorf("帯域制限のスケジュールに\"*\"が複数あります")
+			}
+			schedule.Default = &rate
+			continue
+		}
+
+		start, end, ok := strings.Cut(key, "-")
+		if !ok {
+			return nil, fmt.Errorf("時間帯の指定が不正です（HH:MM-HH:MMの形式で指定してください）: %q", key)
+		}
+		window := BandwidthWindow{Rate: rate}
+		if window.Start, err = parseMinuteOfDay(start); err != nil {
+			return nil, err
+		}
+		if window.End, err = parseMinuteOfDay(end); err != nil {
+			return nil, err
+		}
+		if window.Start == window.End {
+			return nil, fmt.Errorf("時間帯の開始と終了が同じです（終日の場合は\"*\"を使用してください）: %q", key)
+		}
+		schedule.Windows = append(schedule.Windows, window)
+	}
+	return schedule, nil
+}
+
+// parseBandwidthRate は時間帯に適用する帯域制限（バイト数または割合）を解析する
+func parseBandwidthRate(s string) (BandwidthRate, error) {
+	if percent, ok := strings.CutSuffix(s, "%"); ok {
+		value, err := strconv.ParseFloat(strings.TrimSpace(percent), 64)
+		if err != nil || value <= 0 {
+			return BandwidthRate{}, fmt.Errorf("帯域制限の割合の指定が不正です: %q", s)
+		}
+		return BandwidthRate{Percent: value}, nil
+	}
+
+	limit, err := ParseBandwidth(s)
+	if err != nil {
+		return BandwidthRate{}, err
+	}
+	return BandwidthRate{Limit: limit}, nil
+}
+
+// parseMinuteOfDay は"HH:MM"形式の時刻を0時からの分に変換する（"24:00"は0時として扱う）
+func parseMinuteOfDay(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "24:00" {
+		return 0, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("時刻の指定が不正です（HH:MMの形式で指定してください）: %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -0,0 +1,98 @@
+package copier
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseBandwidthSchedule(t *testing.T) {
+	schedule, err := ParseBandwidthSchedule("22:00-06:00=100%, 12:00-13:00=0, *=20%")
+	if err != nil {
+		t.Fatalf("ParseBandwidthSchedule() error = %v", err)
+	}
+
+	const base = 10 * 1024 * 1024
+	at := func(clock string) time.Time {
+		t, _ := time.ParseInLocation("15:04", clock, time.Local)
+		return t
+	}
+	tests := []struct {
+		clock string
+		want  int64
+	}{
+		{"23:30", base},            // 日をまたぐ時間帯
+		{"00:00", base},            // 日をまたぐ時間帯
+		{"05:59", base},            // 終了時刻の直前
+		{"06:00", base * 20 / 100}, // 終了時刻は含まない
+		{"12:30", 0},               // 無制限
+		{"15:00", base * 20 / 100}, // どの時間帯にも一致しない
+	}
+	for _, tt := range tests {
+		if got := schedule.LimitAt(at(tt.clock), base); got != tt.want {
+			t.Errorf("LimitAt(%s) = %d, want %d", tt.clock, got, tt.want)
+		}
+	}
+	if !schedule.UsesPercent() {
+		t.Error("UsesPercent() = false, want true")
+	}
+
+	// "*"がない場合は時間帯の外では基本の帯域制限を使用する
+	schedule, err = ParseBandwidthSchedule("09:00-18:00=5M,18:00-24:00=50%")
+	if err != nil {
+		t.Fatalf("ParseBandwidthSchedule() error = %v", err)
+	}
+	if got := schedule.LimitAt(at("10:00"), base); got != 5*1024*1024 {
+		t.Errorf("LimitAt(10:00) = %d", got)
+	}
+	if got := schedule.LimitAt(at("23:59"), base); got != base/2 {
+		t.Errorf("LimitAt(23:59) = %d", got)
+	}
+	if got := schedule.LimitAt(at("03:00"), base); got != base {
+		t.Errorf("LimitAt(03:00) = %d", got)
+	}
+
+	// 指定しない場合はnil（基本の帯域制限のみ）
+	if schedule, err := ParseBandwidthSchedule(""); err != nil || schedule != nil {
+		t.Errorf("ParseBandwidthSchedule(\"\") = %v, %v", schedule, err)
+	}
+	var none *BandwidthSchedule
+	if got := none.LimitAt(at("10:00"), base); got != base || none.UsesPercent() {
+		t.Errorf("nilのスケジュール: LimitAt() = %d", got)
+	}
+
+	for _, spec := range []string{
+		"22:00-06:00",
+		"22:00=1M",
+		"25:00-06:00=1M",
+		"22:00-22:00=1M",
+		"22:00-06:00=abc",
+		"22:00-06:00=0%",
+		"*=1M,*=2M",
+	} {
+		if _, err := ParseBandwidthSchedule(spec); err == nil {
+			t.Errorf("ParseBandwidthSchedule(%q) はエラーになるべきです", spec)
+		}
+	}
+}
+
+func TestThrottle_Schedule(t *testing.T) {
+	th := newThrottle(100 * 1024)
+
+	// 終日の指定は基本の帯域制限より優先する
+	schedule, _ := ParseBandwidthSchedule("*=50%")
+	th.setSchedule(schedule)
+	if got := th.getLimit(); got != 50*1024 {
+		t.Errorf("getLimit() = %d, want %d", got, 50*1024)
+	}
+
+	// 基本の帯域制限を変更すると割合も追従する
+	th.setLimit(200 * 1024)
+	if got := th.getLimit(); got != 100*1024 {
+		t.Errorf("getLimit() = %d, want %d", got, 100*1024)
+	}
+
+	th.setSchedule(nil)
+	if got := th.getLimit(); got != 200*1024 {
+		t.Errorf("getLimit() = %d, want %d", got, 200*1024)
+	}
+}
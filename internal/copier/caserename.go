@@ -0,0 +1,130 @@
+package copier
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/pathkey"
+)
+
+// CaseRename は名前の大文字・小文字のみ変わったため、宛先で名前を変更したファイル・ディレクトリを表す構造体
+type CaseRename struct {
+	From        string // 変更前の相対パス（宛先にあった名前）
+	To          string // 変更後の相対パス（ソースの名前）
+	Destination string // 宛先ディレクトリ
+}
+
+// caseRenames は宛先で名前を変更したファイル・ディレクトリの記録
+type caseRenames struct {
+	mu      sync.Mutex
+	renames []CaseRename
+}
+
+// propagateCaseRenames はソースのディレクトリのエントリと大文字・小文字のみ異なる名前で宛先にあるファイル・ディレクトリを、
+// ソースと同じ名前に変更する。削除と再コピーの代わりに名前の変更のみを行うため、変更後は通常どおり内容で判定される
+// 宛先に同じ名前が既にある場合、候補が複数ある場合、宛先の名前もソースにある場合は変更しない
+// 宛先の古い名前を残さない変更のため、Options.CaseRenamesを指定した場合のみ行う（デフォルトでは行わない）
+func (fc *FileCopier) propagateCaseRenames(sourceDir, destDir string, entries []os.DirEntry) {
+	if !fc.options.CaseRenames || fc.options.Flatten {
+		return
+	}
+
+	sourceNames := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		sourceNames[entry.Name()] = true
+	}
+
+	for i, dir := range append([]string{destDir}, fc.extraPaths(destDir)...) {
+		destEntries, err := fc.readDestDir(dir)
+		if err != nil {
+			// 宛先のディレクトリがまだない場合は変更するものがない
+			continue
+		}
+		destNames := make(map[string]bool, len(destEntries))
+		folded := make(map[string][]string, len(destEntries))
+		for _, entry := range destEntries {
+			destNames[entry.Name()] = true
+			if !sourceNames[entry.Name()] {
+				key := strings.ToLower(entry.Name())
+				folded[key] = append(folded[key], entry.Name())
+			}
+		}
+
+		for _, entry := range entries {
+			name := entry.Name()
+			if destNames[name] || fc.attributeCounter(entry) != nil {
+				continue
+			}
+			sourcePath := filepath.Join(sourceDir, name)
+			if !entry.IsDir() && fc.filter != nil && !fc.filter.ShouldInclude(sourcePath) {
+				continue
+			}
+			if entry.IsDir() && fc.filter != nil && fc.filter.ExcludesDir(sourcePath) {
+				continue
+			}
+			candidates := folded[strings.ToLower(name)]
+			if len(candidates) != 1 {
+				continue
+			}
+
+			oldName := candidates[0]
+			if err := fc.renameCase(dir, oldName, name); err != nil {
+				if fc.logger != nil {
+					fc.logger.Warn("大文字・小文字のみ変わった名前の変更に失敗: %s -> %s: %v", filepath.Join(dir, oldName), name, err)
+				}
+				continue
+			}
+			destNames[name] = true
+			delete(folded, strings.ToLower(name))
+
+			root := fc.destDir
+			if i > 0 {
+				root = fc.options.ExtraDestinations[i-1]
+			}
+			from, _ := pathkey.Rel(fc.sourceDir, filepath.Join(sourceDir, oldName))
+			to, _ := pathkey.Rel(fc.sourceDir, sourcePath)
+			if i == 0 && fc.db != nil {
+				// 記録を変更後の名前で引き継ぐ（ディレクトリの場合は中のファイルの記録も移動する）
+				if _, err := fc.db.RenameFiles(from, to); err != nil && fc.logger != nil {
+					fc.logger.Warn("名前を変更したファイルの記録の移動に失敗: %s -> %s: %v", from, to, err)
+				}
+			}
+			fc.caseRenames.mu.Lock()
+			fc.caseRenames.renames = append(fc.caseRenames.renames, CaseRename{From: from, To: to, Destination: root})
+			fc.caseRenames.mu.Unlock()
+			if fc.logger != nil {
+				fc.logger.Info("名前を変更（大文字・小文字のみ）: %s -> %s", from, to)
+			}
+		}
+	}
+}
+
+// renameCase は宛先の名前の大文字・小文字を変更する
+// 大文字・小文字を区別しないファイルシステムでは直接変更できないことがあるため、一時的な名前を経由する
+func (fc *FileCopier) renameCase(dir, oldName, newName string) error {
+	oldPath := filepath.Join(dir, oldName)
+	tempPath := filepath.Join(dir, ".gopier-rename-"+strconv.FormatInt(time.Now().UnixNano(), 36))
+	if err := fc.renameDest(oldPath, tempPath); err != nil {
+		return err
+	}
+	if err := fc.renameDest(tempPath, filepath.Join(dir, newName)); err != nil {
+		// 元の名前に戻す
+		if restoreErr := fc.renameDest(tempPath, oldPath); restoreErr != nil {
+			return fmt.Errorf("%w（元の名前に戻せません: %s: %v）", err, tempPath, restoreErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// GetCaseRenames は宛先で名前の大文字・小文字を変更したファイル・ディレクトリを返す
+func (fc *FileCopier) GetCaseRenames() []CaseRename {
+	fc.caseRenames.mu.Lock()
+	defer fc.caseRenames.mu.Unlock()
+	return append([]CaseRename(nil), fc.caseRenames.renames...)
+}
@@ -0,0 +1,90 @@
+package copier
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+func TestCopyFiles_CaseRenames(t *testing.T) {
+	mem := vfs.NewMem()
+	sourceDir := filepath.Join("/", "source")
+	destDir := filepath.Join("/", "dest")
+	mem.WriteFile(filepath.Join(sourceDir, "Report.doc"), []byte("report"), 0644)
+	mem.WriteFile(filepath.Join(sourceDir, "Docs", "a.txt"), []byte("a"), 0644)
+	mem.WriteFile(filepath.Join(sourceDir, "Both.txt"), []byte("upper"), 0644)
+	mem.WriteFile(filepath.Join(sourceDir, "both.txt"), []byte("lower"), 0644)
+
+	syncDB, err := database.NewSyncDB(filepath.Join(t.TempDir(), "test.db"), database.NormalSync)
+	if err != nil {
+		t.Fatalf("データベースの作成に失敗: %v", err)
+	}
+	defer syncDB.Close()
+
+	// デフォルトでは名前を変更しない
+	if DefaultOptions().CaseRenames {
+		t.Error("デフォルトで名前の大文字・小文字の変更が有効です")
+	}
+	options := DefaultOptions()
+	options.FS = mem
+	options.CaseRenames = true
+	fc := NewFileCopier(sourceDir, destDir, options, nil, syncDB, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFiles() エラー: %v", err)
+	}
+
+	// ソースで大文字・小文字のみ変更する（両方の名前がソースにあるファイルは対象外）
+	mem.Rename(filepath.Join(sourceDir, "Report.doc"), filepath.Join(sourceDir, "report.doc"))
+	mem.Rename(filepath.Join(sourceDir, "Docs"), filepath.Join(sourceDir, "docs"))
+
+	fc = NewFileCopier(sourceDir, destDir, options, nil, syncDB, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFiles() エラー: %v", err)
+	}
+	renames := fc.GetCaseRenames()
+	if len(renames) != 2 || renames[0].From != "Docs" || renames[0].To != "docs" || renames[1].From != "Report.doc" || renames[1].To != "report.doc" {
+		t.Errorf("名前の変更 = %+v", renames)
+	}
+	// 名前を変更したファイルは内容が同じためコピーしない
+	if copied := fc.GetStats().GetCopiedCount(); copied != 0 {
+		t.Errorf("コピー件数 = %d, want 0", copied)
+	}
+	for _, name := range []string{"report.doc", filepath.Join("docs", "a.txt"), "Both.txt", "both.txt"} {
+		if _, err := mem.Stat(filepath.Join(destDir, name)); err != nil {
+			t.Errorf("宛先に%sがありません: %v", name, err)
+		}
+	}
+	for _, name := range []string{"Report.doc", "Docs"} {
+		if _, err := mem.Stat(filepath.Join(destDir, name)); !os.IsNotExist(err) {
+			t.Errorf("宛先に変更前の名前%sが残っています: %v", name, err)
+		}
+	}
+	// DBの記録は変更後の名前に移動する（ディレクトリの中のファイルを含む）
+	for _, name := range []string{"Report.doc", "Docs/a.txt"} {
+		if record, _ := syncDB.GetFile(name); record != nil {
+			t.Errorf("変更前の名前の記録が残っています: %+v", record)
+		}
+	}
+	for _, name := range []string{"report.doc", "docs/a.txt"} {
+		if record, _ := syncDB.GetFile(name); record == nil || record.Path != name {
+			t.Errorf("%sの記録 = %+v", name, record)
+		}
+	}
+
+	// 無効な場合は新しい名前でコピーし、古い名前は残す
+	mem.Rename(filepath.Join(sourceDir, "report.doc"), filepath.Join(sourceDir, "REPORT.doc"))
+	options.CaseRenames = false
+	fc = NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFiles() エラー: %v", err)
+	}
+	if renames := fc.GetCaseRenames(); len(renames) != 0 || fc.GetStats().GetCopiedCount() != 1 {
+		t.Errorf("無効な場合: 名前の変更 = %+v, コピー = %d", renames, fc.GetStats().GetCopiedCount())
+	}
+	if _, err := mem.Stat(filepath.Join(destDir, "report.doc")); err != nil {
+		t.Errorf("無効な場合に古い名前が残っていません: %v", err)
+	}
+}
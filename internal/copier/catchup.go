@@ -0,0 +1,131 @@
+package copier
+
+import (
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// copiedSource はコピーした時点のソースファイルの状態
+type copiedSource struct {
+	sourcePath string
+	destPath   string
+	modTime    time.Time
+	size       int64
+}
+
+// catchUp はコピー中に変更されたファイルを再コピーするための記録
+type catchUp struct {
+	mu       sync.Mutex
+	copied   map[string]copiedSource // 相対パスごとのコピーした時点の状態
+	passes   int                     // 実行した追いかけコピーの回数
+	recopied int64                   // 追いかけコピーで再コピーの対象にしたファイル数
+	unstable []string                // 上限の回数を実行しても変更され続けたファイル
+}
+
+// CatchUpResult は追いかけコピーの結果を表す構造体
+type CatchUpResult struct {
+	Passes   int      // 実行した追いかけコピーの回数
+	Recopied int64    // 再コピーの対象にしたファイル数（延べ数、再コピーしたファイルはコピー件数にも含まれる）
+	Unstable []string // 上限の回数を実行しても変更され続けたファイルの相対パス
+}
+
+// noteCopied はコピーした時点のソースファイルの更新日時とサイズを記録する（追いかけコピーが無効な場合は何もしない）
+// 記録する状態はコピー前に取得したもので、コピー中に変更された場合は終了後の再走査で差分として検出される
+func (fc *FileCopier) noteCopied(relPath, sourcePath, destPath string, sourceInfo os.FileInfo) {
+	if fc.options.CatchUpPasses <= 0 {
+		return
+	}
+	fc.catchUp.mu.Lock()
+	defer fc.catchUp.mu.Unlock()
+	if fc.catchUp.copied == nil {
+		fc.catchUp.copied = make(map[string]copiedSource)
+	}
+	fc.catchUp.copied[relPath] = copiedSource{
+		sourcePath: sourcePath,
+		destPath:   destPath,
+		modTime:    sourceInfo.ModTime(),
+		size:       sourceInfo.Size(),
+	}
+}
+
+// changedSinceCopy はコピーした後に更新日時またはサイズが変わったファイルを相対パスの順に返す
+// 削除されたファイルや確認できないファイルは対象にしない
+func (fc *FileCopier) changedSinceCopy() []string {
+	fc.catchUp.mu.Lock()
+	copied := make(map[string]copiedSource, len(fc.catchUp.copied))
+	for relPath, src := range fc.catchUp.copied {
+		copied[relPath] = src
+	}
+	fc.catchUp.mu.Unlock()
+
+	var changed []string
+	for relPath, src := range copied {
+		info, err := fc.statSource(src.sourcePath)
+		if err != nil {
+			continue
+		}
+		if !info.ModTime().Equal(src.modTime) || info.Size() != src.size {
+			changed = append(changed, relPath)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// runCatchUp はコピーした後に変更されたファイルを再コピーし、変更がなくなるか上限の回数に達するまで繰り返す
+// 上限に達しても変更され続けたファイルは警告として記録する
+func (fc *FileCopier) runCatchUp() {
+	for pass := 1; ; pass++ {
+		if fc.ctx.Err() != nil {
+			return
+		}
+		changed := fc.changedSinceCopy()
+		if len(changed) == 0 {
+			return
+		}
+		if pass > fc.options.CatchUpPasses {
+			fc.catchUp.mu.Lock()
+			fc.catchUp.unstable = changed
+			fc.catchUp.mu.Unlock()
+			if fc.logger != nil {
+				fc.logger.Warn("追いかけコピーを%d回実行しても変更され続けているファイル: %d件", fc.options.CatchUpPasses, len(changed))
+				for _, relPath := range changed {
+					fc.logger.Warn("変更され続けているファイル: %s", relPath)
+				}
+			}
+			return
+		}
+
+		if fc.logger != nil {
+			fc.logger.Info("コピー中に変更されたファイルを再コピーします (%d/%d): %d件", pass, fc.options.CatchUpPasses, len(changed))
+		}
+		fc.catchUp.mu.Lock()
+		fc.catchUp.passes = pass
+		fc.catchUp.recopied += int64(len(changed))
+		sources := make([]copiedSource, len(changed))
+		for i, relPath := range changed {
+			// 再コピーに成功した場合はnoteCopiedで記録し直す（失敗・スキップしたファイルは以降の再走査の対象にしない）
+			sources[i] = fc.catchUp.copied[relPath]
+			delete(fc.catchUp.copied, relPath)
+		}
+		fc.catchUp.mu.Unlock()
+
+		for _, src := range sources {
+			fc.copyAsync(src.sourcePath, src.destPath)
+		}
+		fc.wg.Wait()
+	}
+}
+
+// GetCatchUpResult は追いかけコピーの結果を返す
+func (fc *FileCopier) GetCatchUpResult() CatchUpResult {
+	fc.catchUp.mu.Lock()
+	defer fc.catchUp.mu.Unlock()
+	return CatchUpResult{
+		Passes:   fc.catchUp.passes,
+		Recopied: fc.catchUp.recopied,
+		Unstable: append([]string(nil), fc.catchUp.unstable...),
+	}
+}
@@ -0,0 +1,51 @@
+package copier
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRunCatchUp(t *testing.T) {
+	sourceDir := t.TempDir()
+	destDir := t.TempDir()
+	sourcePath := filepath.Join(sourceDir, "a.txt")
+	os.WriteFile(sourcePath, []byte("old"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "b.txt"), []byte("stable"), 0644)
+
+	options := DefaultOptions()
+	options.CatchUpPasses = 2
+	fc := NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFilesが失敗しました: %v", err)
+	}
+	if result := fc.GetCatchUpResult(); result.Passes != 0 || result.Recopied != 0 {
+		t.Errorf("変更がない場合の結果 = %+v", result)
+	}
+
+	// コピーした後にソースが変更された場合は再コピーする
+	os.WriteFile(sourcePath, []byte("new content"), 0644)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(sourcePath, later, later)
+	fc.runCatchUp()
+	fc.wg.Wait()
+
+	data, _ := os.ReadFile(filepath.Join(destDir, "a.txt"))
+	if string(data) != "new content" {
+		t.Errorf("再コピー後の内容 = %q", data)
+	}
+	result := fc.GetCatchUpResult()
+	if result.Passes != 1 || result.Recopied != 1 || len(result.Unstable) != 0 {
+		t.Errorf("追いかけコピーの結果 = %+v", result)
+	}
+
+	// 上限の回数を実行しても変更され続けるファイルは記録する
+	fc.options.CatchUpPasses = 0
+	os.WriteFile(sourcePath, []byte("newer"), 0644)
+	os.Chtimes(sourcePath, later.Add(time.Minute), later.Add(time.Minute))
+	fc.runCatchUp()
+	if result := fc.GetCatchUpResult(); len(result.Unstable) != 1 || result.Unstable[0] != "a.txt" {
+		t.Errorf("変更され続けているファイル = %+v", result.Unstable)
+	}
+}
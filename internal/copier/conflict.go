@@ -0,0 +1,110 @@
+package copier
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/errcode"
+)
+
+// ConflictAction は宛先の方が新しいファイルの扱いを表す型
+type ConflictAction string
+
+const (
+	// ConflictSkip は宛先の方が新しいファイルをスキップする
+	ConflictSkip ConflictAction = "skip"
+	// ConflictError は宛先の方が新しいファイルを失敗として扱う
+	ConflictError ConflictAction = "error"
+)
+
+// ParseConflictAction は文字列をConflictActionに変換する
+func ParseConflictAction(s string) (ConflictAction, error) {
+	switch ConflictAction(s) {
+	case "", ConflictSkip:
+		return ConflictSkip, nil
+	case ConflictError:
+		return ConflictError, nil
+	default:
+		return "", fmt.Errorf("無効な衝突時の処理方法: %s (skip, errorのいずれかを指定してください)", s)
+	}
+}
+
+// Conflict は宛先の方が新しかったために上書きしなかったファイルを表す構造体
+type Conflict struct {
+	Path        string    // ファイルの相対パス
+	Destination string    // 宛先ディレクトリ
+	SourceTime  time.Time // ソースの更新日時
+	DestTime    time.Time // 宛先の更新日時
+}
+
+// destNewer は宛先の方が新しいため上書きしないかどうかを判断する
+func (fc *FileCopier) destNewer(sourceInfo, destInfo os.FileInfo) bool {
+	return fc.options.SkipNewer && destInfo.ModTime().After(sourceInfo.ModTime())
+}
+
+// noteConflict は宛先の方が新しかったファイルを記録し、衝突時の処理方法がエラーの場合はエラーを返す
+func (fc *FileCopier) noteConflict(relPath, root string, sourceInfo, destInfo os.FileInfo) error {
+	fc.stats.IncrementConflicted()
+	fc.conflictsMu.Lock()
+	fc.conflicts = append(fc.conflicts, Conflict{
+		Path:        relPath,
+		Destination: root,
+		SourceTime:  sourceInfo.ModTime(),
+		DestTime:    destInfo.ModTime(),
+	})
+	fc.conflictsMu.Unlock()
+
+	if fc.options.Conflict == ConflictError {
+		return errcode.Errorf(errcode.ErrConflict, "宛先の方が新しいため上書きしません (ソース: %s, 宛先: %s)",
+			sourceInfo.ModTime().Format(time.RFC3339), destInfo.ModTime().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// GetConflicts は宛先の方が新しかったために上書きしなかったファイルを返す
+func (fc *FileCopier) GetConflicts() []Conflict {
+	fc.conflictsMu.Lock()
+	defer fc.conflictsMu.Unlock()
+	return append([]Conflict(nil), fc.conflicts...)
+}
+
+// skipNewerFile は宛先の方が新しいファイルを上書きせず、衝突時の処理方法に応じてスキップまたは失敗として記録する
+func (fc *FileCopier) skipNewerFile(relPath string, sourceInfo, destInfo os.FileInfo) error {
+	conflictErr := fc.noteConflict(relPath, fc.destDir, sourceInfo, destInfo)
+
+	record := database.FileInfo{
+		Path:         relPath,
+		Size:         sourceInfo.Size(),
+		ModTime:      sourceInfo.ModTime(),
+		Status:       database.StatusSkipped,
+		LastSyncTime: time.Now(),
+		LastError:    "宛先の方が新しいためスキップ",
+		Reason:       database.ReasonDestNewer,
+	}
+	if conflictErr != nil {
+		fc.countFailed(relPath, conflictErr)
+		record.Status = database.StatusFailed
+		record.LastError = conflictErr.Error()
+		record.Error = errcode.Describe(conflictErr)
+		record.Reason = ""
+	} else {
+		fc.countSkipped(relPath, sourceInfo.Size())
+	}
+
+	// データベースに記録
+	if fc.db != nil {
+		fc.db.AddFile(record)
+	}
+
+	// loggerでスキップ情報を出力
+	if fc.logger != nil && fc.logger.Verbose && conflictErr == nil {
+		fc.logger.Info("ファイルをスキップ（宛先の方が新しい）: %s", relPath)
+	}
+
+	if conflictErr != nil {
+		return fmt.Errorf("ファイル '%s': %w", relPath, conflictErr)
+	}
+	return nil
+}
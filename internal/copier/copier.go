@@ -2,18 +2,34 @@ package copier
 
 import (
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/sakuhanight/gopier/internal/audit"
+	"github.com/sakuhanight/gopier/internal/chmod"
 	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/faultinject"
 	"github.com/sakuhanight/gopier/internal/filter"
+	"github.com/sakuhanight/gopier/internal/fsmeta"
 	"github.com/sakuhanight/gopier/internal/hasher"
 	"github.com/sakuhanight/gopier/internal/logger"
+	"github.com/sakuhanight/gopier/internal/overlap"
+	"github.com/sakuhanight/gopier/internal/pathkey"
+	"github.com/sakuhanight/gopier/internal/runas"
+	"github.com/sakuhanight/gopier/internal/sidecar"
 	"github.com/sakuhanight/gopier/internal/stats"
+	"github.com/sakuhanight/gopier/internal/transform"
+	"github.com/sakuhanight/gopier/internal/vfs"
 )
 
 // CopyMode はコピーモードを表す型
@@ -28,23 +44,112 @@ const (
 	ModeCopyAndVerify
 )
 
+// FlattenRename はフラット化時のファイル名衝突の解決方法を表す型
+type FlattenRename string
+
+const (
+	// FlattenCounter は連番をファイル名の先頭に付与する
+	FlattenCounter FlattenRename = "counter"
+	// FlattenHash は相対パスのハッシュをファイル名の先頭に付与する
+	FlattenHash FlattenRename = "hash"
+	// FlattenSkip は衝突したファイルをコピーしない
+	FlattenSkip FlattenRename = "skip"
+)
+
+// FlattenCollision はフラット化時に発生したファイル名の衝突を表す構造体
+type FlattenCollision struct {
+	SourcePath string // 衝突したファイルの相対パス
+	ClaimedBy  string // 先にその名前を使用したファイルの相対パス
+	DestName   string // 実際のコピー先ファイル名（スキップした場合は空）
+}
+
+// CopyFailure はコピーに失敗したファイルを表す構造体
+type CopyFailure struct {
+	Path string // 失敗したファイルの相対パス
+	Err  error  // 失敗の理由
+}
+
 // ProgressCallback は進捗報告のためのコールバック関数型
 type ProgressCallback func(current, total int64, currentFile string)
 
 // Options はコピーオプションを表す構造体
 type Options struct {
-	BufferSize        int           // コピーバッファサイズ
-	Recursive         bool          // 再帰的にコピーするかどうか
-	PreserveModTime   bool          // 更新日時を保持するかどうか
-	VerifyHash        bool          // ハッシュ検証を行うかどうか
-	HashAlgorithm     string        // ハッシュアルゴリズム
-	OverwriteExisting bool          // 既存ファイルを上書きするかどうか
-	CreateDirs        bool          // 必要なディレクトリを作成するかどうか
-	MaxRetries        int           // 最大再試行回数
-	RetryDelay        time.Duration // 再試行の遅延時間
-	ProgressInterval  time.Duration // 進捗報告の間隔
-	MaxConcurrent     int           // 最大並行コピー数
-	Mode              CopyMode      // コピーモード
+	BufferSize          int                 // コピーバッファサイズ
+	Recursive           bool                // 再帰的にコピーするかどうか
+	PreserveModTime     bool                // 更新日時を保持するかどうか
+	PreserveAtime       bool                // 更新日時を保持する場合に、ソースのアクセス日時も保持するかどうか
+	VerifyHash          bool                // ハッシュ検証を行うかどうか
+	HashAlgorithm       string              // ハッシュアルゴリズム
+	OverwriteExisting   bool                // 既存ファイルを上書きするかどうか
+	SkipNewer           bool                // 宛先の方が新しいファイルを上書きしないかどうか
+	Conflict            ConflictAction      // 宛先の方が新しいファイルの扱い（SkipNewerが有効な場合）
+	CreateDirs          bool                // 必要なディレクトリを作成するかどうか
+	MaxRetries          int                 // 最大再試行回数
+	RetryDelay          time.Duration       // 再試行の遅延時間
+	DeferRetries        bool                // 失敗したファイルをすぐに再試行せず、他のファイルのコピーが終わった後に再試行するかどうか
+	SharingRetries      int                 // 共有違反（他のプロセスが使用中）の場合に通常の再試行とは別に再試行する回数
+	SharingRetryDelay   time.Duration       // 共有違反の再試行の待ち時間（50%から150%の範囲でばらつかせる）
+	RetryLocked         bool                // 使用中のファイルを他のファイルのコピーが終わった後に再試行するかどうか
+	ProgressInterval    time.Duration       // 進捗報告の間隔
+	BandwidthLimit      int64               // 秒あたりの最大転送バイト数（0は無制限）
+	BandwidthSchedule   *BandwidthSchedule  // 時刻ごとの帯域制限（nilの場合はBandwidthLimitのみ）
+	FileList            []string            // コピーするパスの一覧（nilの場合はソースを走査する、ReadFileListで読み込む）
+	FilterFileList      bool                // FileListのパスにもフィルタと隠し・システム属性による除外を適用するかどうか（変更ジャーナルの一覧）
+	MaxConcurrent       int                 // 最大並行コピー数
+	Mode                CopyMode            // コピーモード
+	CopyEmptyDirs       bool                // 空のディレクトリもコピーするかどうか
+	PreserveDirTimes    bool                // ディレクトリの更新日時を保持するかどうか
+	PreservePermissions bool                // パーミッション・所有者（WindowsではACL）を保持するかどうか
+	IncludeHidden       bool                // 隠しファイル（ドットファイルを含む）をコピーするかどうか
+	IncludeSystem       bool                // システムファイル（Windowsのみ）をコピーするかどうか
+	SourceIdentity      runas.Identity      // ソースにアクセスする資格情報（nilの場合は実行中のユーザー）
+	DestIdentity        runas.Identity      // 宛先にアクセスする資格情報（nilの場合は実行中のユーザー）
+	Flatten             bool                // すべてのファイルを宛先ディレクトリ直下にコピーするかどうか
+	FlattenRename       FlattenRename       // フラット化時のファイル名衝突の解決方法
+	ExtraDestinations   []string            // 追加の宛先ディレクトリ（ソースを一度だけ読み込んで書き込む）
+	Transforms          *transform.Pipeline // 拡張子・MIMEタイプごとに内容を変換する規則（nilの場合は変換しない）
+	MetaSidecar         bool                // ディレクトリごとにメタデータのファイル（.gopier.meta）を書き込むかどうか
+	VerifyVia           string              // 検証時に宛先を読み込む別の経路（宛先ディレクトリと同じ内容を指す別のマウントなど）
+	IgnoreErrorsOn      string              // エラーを無視するパスのパターン（カンマ区切り、一致したパス以下の失敗は別に数える）
+	StructureOnly       bool                // ファイルの内容をコピーせず、ディレクトリ構造と内容のないファイルのみ作成するかどうか
+	StructureFiles      StructureFiles      // 構造のみ作成する場合のファイルの作成方法
+	SegmentsPerFile     int                 // 1ファイルを分割して並行にコピーする数（1以下は分割しない）
+	SegmentThreshold    int64               // 分割コピーの対象とする最小のファイルサイズ
+	ReadAhead           int                 // 読み込みと書き込みを重ねる場合の先読みするチャンク数（0は同期的にコピー）
+	DedupCacheSize      int64               // 同じ内容のファイルをメモリから書き込むためのキャッシュの合計サイズ（0は無効）
+	DedupMaxFileSize    int64               // キャッシュの対象とするファイルサイズの上限
+	FolderStatsDepth    int                 // 結果をフォルダごとに集計する階層（1は最上位のフォルダ、0は集計しない）
+	SlowestCount        int                 // 処理時間の長いファイル・ディレクトリを記録する件数（0は記録しない）
+	DetailedRecords     bool                // ファイルごとの処理時間・再試行回数・ワーカー・スループットをDBに記録するかどうか
+	CatchUpPasses       int                 // コピー中に変更されたファイルを再コピーする最大の回数（0は再コピーしない）
+	CopyOrder           CopyOrder           // ファイルをコピーする順序（空の場合は走査した順）
+	VerifyConcurrent    int                 // コピーと同時に検証する場合の検証の並行数（0はコピーのワーカーで続けて検証する）
+	MetadataOnlyUpdates bool                // 内容が同じファイル（サイズとハッシュが一致）は更新日時とアクセス権のみ更新するかどうか
+	CaseRenames         bool                // 名前の大文字・小文字のみ変わったファイル・ディレクトリを宛先で名前の変更として反映するかどうか
+	Chmod               *chmod.Mask         // 宛先のファイル・ディレクトリのアクセス権の変更（nilの場合は変更しない）
+	Owner               *fsmeta.Owner       // 宛先のファイル・ディレクトリに設定する所有者（nilの場合は変更しない、検証でも比較する）
+	MismatchRetries     int                 // ハッシュが一致しなかった場合に、ソースと宛先を読み直して再検証する回数（0は再検証しない）
+	MismatchRetryDelay  time.Duration       // 再検証の前の待ち時間
+	DropCache           bool                // 検証でハッシュ値を計算する際に、キャッシュを経由せずにディスクから読み込むかどうか
+	ModTimePrecision    time.Duration       // 検証で更新日時を比較する精度（差がこの値未満であれば一致、0の場合は比較しない）
+	DetectSourceChanges bool                // 開始時と終了時にソースを走査し、コピー中のソースの変化を報告するかどうか
+	BatchThreshold      int64               // このサイズ以下のファイルを個別にコピーせず、セグメントにまとめて書き込む（0はまとめない、DBが必要）
+	BatchSize           int64               // セグメントのサイズの目安（0はDefaultBatchSize）
+	ResumeInterval      int64               // 再開できる宛先で、再開用のトークンを記録する書き込みサイズの間隔（0は再開しない、DBが必要）
+	StampXattr          bool                // 宛先のファイルの拡張属性（WindowsではADS）にハッシュとコピーの日時を記録するかどうか
+
+	// 動作確認のために擬似的な障害を発生させる（nilの場合は発生させない）
+	Faults *faultinject.Injector
+
+	// 完了した操作を記録する監査ログ（nilの場合は記録しない）
+	Audit *audit.Log
+
+	// ソース・宛先のファイルシステム（nilの場合はOSのファイルシステムを使用する）
+	FS vfs.FS
+
+	// ハッシュ値の計算の実装（nilの場合はHashAlgorithmの組み込みの実装を使用する）
+	// 指定した場合はHashAlgorithmの代わりに実装のアルゴリズムの名前をDBと監査ログに記録する
+	Hasher hasher.Interface
 }
 
 // DefaultOptions はデフォルトのオプションを返す
@@ -56,15 +161,38 @@ func DefaultOptions() Options {
 		VerifyHash:        true,
 		HashAlgorithm:     string(hasher.SHA256),
 		OverwriteExisting: true,
+		Conflict:          ConflictSkip,
 		CreateDirs:        true,
 		MaxRetries:        3,
 		RetryDelay:        time.Second * 2,
+		SharingRetries:    5,
+		SharingRetryDelay: 200 * time.Millisecond,
 		ProgressInterval:  time.Second * 1,
 		MaxConcurrent:     4,
 		Mode:              ModeCopy,
+		CopyEmptyDirs:     true,
+		PreserveDirTimes:  true,
+		IncludeHidden:     true,
+		IncludeSystem:     true,
+		Flatten:           false,
+		FlattenRename:     FlattenCounter,
+		CopyOrder:         OrderWalk,
+		StructureFiles:    StructureSized,
+		SegmentsPerFile:   1,
+		SegmentThreshold:  DefaultSegmentThreshold,
+		ReadAhead:         4,
+		DedupMaxFileSize:  DefaultDedupMaxFileSize,
+		ResumeInterval:    DefaultResumeInterval,
 	}
 }
 
+// dirTime はコピー完了後に適用するディレクトリの更新日時とアクセス権を表す構造体
+type dirTime struct {
+	path    string
+	modTime time.Time // ゼロ値の場合は更新日時を設定しない
+	source  string    // アクセス権のコピー元（空の場合はアクセス権を設定しない）
+}
+
 // FileCopier はファイルコピー処理を管理する構造体
 type FileCopier struct {
 	sourceDir    string
@@ -72,7 +200,8 @@ type FileCopier struct {
 	options      Options
 	stats        *stats.Stats
 	filter       *filter.Filter
-	hasher       *hasher.Hasher
+	hasher       hasher.Interface
+	fs           vfs.FS
 	db           *database.SyncDB
 	logger       *logger.Logger
 	progressChan chan string
@@ -81,6 +210,39 @@ type FileCopier struct {
 	semaphore    chan struct{}
 	ctx          context.Context
 	cancel       context.CancelFunc
+	dirTimes     []dirTime
+	dirTimesMu   sync.Mutex
+	flatNames    map[string]string
+	collisions   []FlattenCollision
+	flatMu       sync.Mutex
+	sessionID    int64
+	throttle     *throttle
+	targetCounts map[string]*TargetResult
+	targetMu     sync.Mutex
+	folderCounts map[string]*FolderResult
+	folderMu     sync.Mutex
+	verification database.VerificationSummary
+	verifyMu     sync.Mutex
+	excluded     ExcludedCounts
+	failures     []CopyFailure
+	failuresMu   sync.Mutex
+	conflicts    []Conflict
+	conflictsMu  sync.Mutex
+	cache        *contentCache
+	dedup        DedupStats
+	catchUp      catchUp
+	lockedFiles  lockedFiles
+	permFailures permFailures
+	retryQueue   deferredRetries
+	verifyQueue  *verifyQueue
+	caseRenames  caseRenames
+	destGuard    *overlap.Guard // ソースの中にある宛先（コピー中に走査しない）
+	queued       []queuedFile   // 順序を決めるため、走査を終えるまでコピーを待つファイル
+	retryCounts  sync.Map       // ファイルごとの再試行回数（DetailedRecordsを指定した場合のみ記録する）
+	journals     sync.Map       // コピー中の宛先のパスごとのジャーナルの記録（*journalCopy）
+	batches      batches        // 小さいファイルをまとめて書き込むセグメント
+	drift        *SourceDrift   // 開始時からのソースの変化（検出しない場合はnil）
+	stampWarned  atomic.Bool    // ハッシュを記録できないファイルシステムの警告を出力した
 }
 
 // NewFileCopier は新しいFileCopierを作成する
@@ -91,22 +253,44 @@ func NewFileCopier(sourceDir, destDir string, options Options, fileFilter *filte
 	semaphore := make(chan struct{}, options.MaxConcurrent)
 
 	// ハッシャーの初期化
-	hashAlgo := hasher.Algorithm(options.HashAlgorithm)
-	fileHasher := hasher.NewHasher(hashAlgo, options.BufferSize)
+	var fileHasher hasher.Interface = options.Hasher
+	if fileHasher != nil {
+		options.HashAlgorithm = fileHasher.GetAlgorithmName()
+	} else {
+		fileHasher = hasher.NewHasher(hasher.Algorithm(options.HashAlgorithm), options.BufferSize)
+	}
+
+	// 内容のキャッシュの初期化
+	var cache *contentCache
+	if options.DedupCacheSize > 0 {
+		cache = newContentCache(options.DedupCacheSize)
+	}
+
+	limiter := newThrottle(options.BandwidthLimit)
+	limiter.setSchedule(options.BandwidthSchedule)
+
+	st := stats.NewStats()
+	if options.SlowestCount > 0 {
+		st.EnableTimings(options.SlowestCount)
+	}
 
 	return &FileCopier{
 		sourceDir:    sourceDir,
 		destDir:      destDir,
 		options:      options,
-		stats:        stats.NewStats(),
+		stats:        st,
 		filter:       fileFilter,
 		hasher:       fileHasher,
+		fs:           vfs.Or(options.FS),
 		db:           syncDB,
 		logger:       log,
 		progressChan: make(chan string, 100),
 		ctx:          ctx,
 		cancel:       cancel,
 		semaphore:    semaphore,
+		flatNames:    make(map[string]string),
+		throttle:     limiter,
+		cache:        cache,
 	}
 }
 
@@ -125,8 +309,82 @@ func (fc *FileCopier) Cancel() {
 	fc.cancel()
 }
 
+// Pause はコピー処理を一時停止する（処理中のファイルは次の読み込みで停止する）
+func (fc *FileCopier) Pause() {
+	fc.throttle.pause()
+}
+
+// Resume は一時停止したコピー処理を再開する
+func (fc *FileCopier) Resume() {
+	fc.throttle.resume()
+}
+
+// IsPaused は一時停止中かどうかを返す
+func (fc *FileCopier) IsPaused() bool {
+	return fc.throttle.isPaused()
+}
+
+// SetBandwidthLimit は実行中のコピーの帯域制限（秒あたりのバイト数、0は無制限）を変更する
+func (fc *FileCopier) SetBandwidthLimit(limit int64) {
+	fc.throttle.setLimit(limit)
+}
+
+// SetBandwidthSchedule は実行中のコピーの時刻ごとの帯域制限を変更する（nilの場合はSetBandwidthLimitの値のみ）
+func (fc *FileCopier) SetBandwidthSchedule(schedule *BandwidthSchedule) {
+	fc.throttle.setSchedule(schedule)
+}
+
+// GetBandwidthLimit は現在の時刻に適用される帯域制限を返す
+func (fc *FileCopier) GetBandwidthLimit() int64 {
+	return fc.throttle.getLimit()
+}
+
+// GetSessionID は直近のCopyFilesで使用した同期セッションのIDを返す
+func (fc *FileCopier) GetSessionID() int64 {
+	return atomic.LoadInt64(&fc.sessionID)
+}
+
+// GetFailures はコピーに失敗したファイルを返す（エラーを無視するパスのファイルは含まない）
+func (fc *FileCopier) GetFailures() []CopyFailure {
+	fc.failuresMu.Lock()
+	defer fc.failuresMu.Unlock()
+	return append([]CopyFailure(nil), fc.failures...)
+}
+
+// recordFailure はファイルのコピーの失敗をログに出力し、失敗したファイルとして記録する
+// エラーを無視するパスの場合は警告として出力し、記録しない
+func (fc *FileCopier) recordFailure(relPath string, err error) {
+	if fc.ignoreErrors(relPath) {
+		if fc.logger != nil {
+			fc.logger.Warn("エラーを無視: %s", relPath)
+		}
+		return
+	}
+
+	// loggerでエラー出力（非同期処理なので詳細は出力しない）
+	if fc.logger != nil {
+		fc.logger.Error("ファイルコピーエラー: %s", relPath)
+	}
+	fc.failuresMu.Lock()
+	fc.failures = append(fc.failures, CopyFailure{Path: relPath, Err: err})
+	fc.failuresMu.Unlock()
+}
+
+// GetFlattenCollisions はフラット化時に発生したファイル名の衝突を返す
+func (fc *FileCopier) GetFlattenCollisions() []FlattenCollision {
+	fc.flatMu.Lock()
+	defer fc.flatMu.Unlock()
+	return append([]FlattenCollision(nil), fc.collisions...)
+}
+
 // CopyFiles はファイルをコピーする
 func (fc *FileCopier) CopyFiles() error {
+	// 前回の実行で中断されたコピーの照合
+	fc.recoverJournal()
+
+	// ソースから削除されたファイルの中断した書き込みを破棄
+	fc.discardStaleResumes()
+
 	// 同期セッションの開始
 	var sessionID int64
 	var err error
@@ -143,6 +401,7 @@ func (fc *FileCopier) CopyFiles() error {
 			}
 			return fmt.Errorf("同期セッション開始エラー: %w", err)
 		}
+		atomic.StoreInt64(&fc.sessionID, sessionID)
 	}
 
 	// 進捗報告ゴルーチンの開始
@@ -151,7 +410,7 @@ func (fc *FileCopier) CopyFiles() error {
 	}
 
 	// ソースディレクトリの存在確認
-	sourceInfo, err := os.Stat(fc.sourceDir)
+	sourceInfo, err := fc.statSource(fc.sourceDir)
 	if err != nil {
 		// loggerでエラー出力
 		if fc.logger != nil {
@@ -162,14 +421,21 @@ func (fc *FileCopier) CopyFiles() error {
 			}
 		}
 		fc.stats.IncrementFailed()
+		if os.IsNotExist(err) {
+			err = errcode.Wrap(errcode.ErrSourceMissing, err)
+		}
 		return fmt.Errorf("ソースディレクトリ(%s)の確認エラー: %w", fc.sourceDir, err)
 	}
 
+	// 検証のワーカーを起動
+	fc.startVerifyQueue()
+	defer fc.finishVerifyQueue()
+
 	// ソースがディレクトリの場合
 	if sourceInfo.IsDir() {
 		// 宛先ディレクトリの作成
 		if fc.options.CreateDirs {
-			if err := os.MkdirAll(fc.destDir, 0755); err != nil {
+			if err := fc.mkdirDest(fc.destDir); err != nil {
 				// loggerでエラー出力
 				if fc.logger != nil {
 					if fc.logger.Verbose {
@@ -182,6 +448,15 @@ func (fc *FileCopier) CopyFiles() error {
 			}
 		}
 
+		// 宛先がソースと重なる場合は、同じディレクトリであれば中止し、ソースの中にあれば除外する
+		if err := fc.overlapError(); err != nil {
+			return err
+		}
+		fc.guardDestinations()
+
+		// 開始時のソースの状態を記録
+		fc.takeSourceSnapshot()
+
 		// loggerで開始情報を出力
 		if fc.logger != nil {
 			if fc.logger.Verbose {
@@ -191,11 +466,19 @@ func (fc *FileCopier) CopyFiles() error {
 			}
 		}
 
-		// ディレクトリのコピー
-		err = fc.copyDirectory(fc.sourceDir, fc.destDir)
+		// ディレクトリのコピー（ファイル一覧を指定した場合は一覧のパスのみ）
+		if fc.options.FileList != nil {
+			err = fc.copyFileList()
+		} else {
+			err = fc.copyDirectory(fc.sourceDir, fc.destDir)
+		}
+
+		// 順序を指定した場合は、走査を終えたファイルを並べてコピー（走査が途中で失敗した場合もそれまでのファイルはコピーする）
+		fc.copyQueued()
 	} else {
 		// 単一ファイルのコピー
 		destPath := filepath.Join(fc.destDir, filepath.Base(fc.sourceDir))
+		fc.takeSourceSnapshot()
 
 		// loggerで開始情報を出力
 		if fc.logger != nil {
@@ -212,6 +495,35 @@ func (fc *FileCopier) CopyFiles() error {
 	// すべてのゴルーチンの完了を待つ
 	fc.wg.Wait()
 
+	// 失敗したため後回しにしたファイルを再試行
+	fc.retryDeferred()
+
+	// 使用中だったファイルを再試行
+	fc.retryLocked()
+
+	// 書き込み中のセグメントの書き込みを終える（追いかけコピーで再コピーしたファイルは再度書き込む）
+	fc.flushBatch()
+
+	// コピー中に変更されたファイルを再コピー
+	if err == nil && fc.options.CatchUpPasses > 0 {
+		fc.runCatchUp()
+		fc.flushBatch()
+	}
+
+	// 開始時からのソースの変化を記録（追いかけコピーで再コピーした後の状態と比較する）
+	fc.compareSourceSnapshot()
+
+	// 検証のワーカーを終了
+	fc.finishVerifyQueue()
+
+	// 途中でキャンセルされた場合はエラーとして扱う
+	if err == nil && fc.ctx.Err() != nil {
+		err = errcode.Errorf(errcode.ErrCancelled, "コピー処理がキャンセルされました")
+	}
+
+	// ディレクトリの更新日時を適用（内容のコピーがすべて終わった後に行う）
+	fc.applyDirTimes()
+
 	// チャンネルがまだ開いている場合のみ閉じる
 	select {
 	case <-fc.progressChan:
@@ -239,6 +551,18 @@ func (fc *FileCopier) CopyFiles() error {
 				}
 			}
 		}
+
+		// 後から過去の実行と比較できるよう、終了時の統計情報をセッションに記録する
+		if recErr := fc.db.RecordStats(sessionID, sessionStats(fc.stats)); recErr != nil && fc.logger != nil {
+			fc.logger.Warn("統計情報の記録エラー: %v", recErr)
+		}
+
+		// コピーと同時に検証した場合は、傾向を分析できるよう検証結果の集計をセッションに記録する
+		if summary := fc.GetVerificationSummary(); summary.Verified() > 0 {
+			if recErr := fc.db.RecordVerification(sessionID, summary); recErr != nil && fc.logger != nil {
+				fc.logger.Warn("検証結果の記録エラー: %v", recErr)
+			}
+		}
 	}
 
 	// 完了情報を出力
@@ -264,13 +588,17 @@ func (fc *FileCopier) copyDirectory(sourceDir, destDir string) error {
 	// コンテキストのキャンセル確認
 	select {
 	case <-fc.ctx.Done():
-		return fmt.Errorf("コピー処理がキャンセルされました")
+		return errcode.Errorf(errcode.ErrCancelled, "コピー処理がキャンセルされました")
 	default:
 	}
 
 	// ソースディレクトリを開く
-	entries, err := os.ReadDir(sourceDir)
+	entries, err := fc.readSourceDir(sourceDir)
 	if err != nil {
+		if fc.ignoreWalkError(sourceDir, err) {
+			return nil
+		}
+
 		// loggerでエラー出力
 		if fc.logger != nil && fc.logger.Verbose {
 			fc.logger.Error("ディレクトリ(%s)の読み込みエラー: %v", sourceDir, err)
@@ -279,14 +607,58 @@ func (fc *FileCopier) copyDirectory(sourceDir, destDir string) error {
 	}
 
 	// 宛先ディレクトリの作成
-	if fc.options.CreateDirs {
-		if err := os.MkdirAll(destDir, 0755); err != nil {
+	// 空ディレクトリをコピーしない場合は、ファイルのコピー時に必要な分だけ作成する
+	if fc.options.CreateDirs && (fc.options.CopyEmptyDirs || sourceDir == fc.sourceDir) {
+		if err := fc.mkdirDest(destDir); err != nil {
+			if fc.ignoreWalkError(sourceDir, err) {
+				return nil
+			}
+
 			// loggerでエラー出力
 			if fc.logger != nil && fc.logger.Verbose {
 				fc.logger.Error("宛先ディレクトリ(%s)の作成エラー: %v", destDir, err)
 			}
 			return fmt.Errorf("宛先ディレクトリ(%s)の作成エラー: %w", destDir, err)
 		}
+
+		// 追加の宛先のディレクトリ作成の失敗は、ファイルのコピー時に宛先ごとに記録される
+		for _, extraDir := range fc.extraPaths(destDir) {
+			if err := fc.mkdirDest(extraDir); err != nil && fc.logger != nil && fc.logger.Verbose {
+				fc.logger.Warn("宛先ディレクトリ(%s)の作成エラー: %v", extraDir, err)
+			}
+		}
+	}
+
+	// 名前の大文字・小文字のみ変わったエントリは、削除と再コピーの代わりに宛先で名前を変更する
+	fc.propagateCaseRenames(sourceDir, destDir, entries)
+
+	// ディレクトリの更新日時とアクセス権を記録（フラット化時はサブディレクトリが存在しないため対象外）
+	// 読み取り専用のディレクトリにも書き込めるよう、アクセス権も内容のコピー後に適用する
+	if (fc.options.PreserveDirTimes || fc.setsPermissions()) && (!fc.options.Flatten || sourceDir == fc.sourceDir) {
+		if info, err := fc.statSource(sourceDir); err == nil {
+			dt := dirTime{}
+			if fc.options.PreserveDirTimes {
+				dt.modTime = info.ModTime()
+			}
+			if fc.setsPermissions() {
+				dt.source = sourceDir
+			}
+			fc.dirTimesMu.Lock()
+			for _, path := range append([]string{destDir}, fc.extraPaths(destDir)...) {
+				dt.path = path
+				fc.dirTimes = append(fc.dirTimes, dt)
+			}
+			fc.dirTimesMu.Unlock()
+		}
+	}
+
+	// メタデータのファイルに記録するエントリ
+	// フラット化時はディレクトリ構造がないため、OS以外のファイルシステムではメタデータを取得できないため対象外
+	var snapshots map[string]*fsmeta.Snapshot
+	if fc.options.MetaSidecar && !fc.options.Flatten && vfs.IsOS(fc.fs) {
+		snapshots = make(map[string]*fsmeta.Snapshot)
+		fc.addSnapshot(snapshots, sidecar.SelfEntry, sourceDir)
+		defer fc.writeSidecar(destDir, snapshots)
 	}
 
 	// 各エントリの処理
@@ -294,13 +666,45 @@ func (fc *FileCopier) copyDirectory(sourceDir, destDir string) error {
 		sourcePath := filepath.Join(sourceDir, entry.Name())
 		destPath := filepath.Join(destDir, entry.Name())
 
+		// 隠しファイル・システムファイルの除外（ディレクトリの場合は中身ごと除外）
+		if fc.excludeByAttributes(entry) {
+			continue
+		}
+
+		// ソースにあるメタデータのファイルは、書き込むファイルで置き換える
+		if snapshots != nil && entry.Name() == sidecar.FileName {
+			continue
+		}
+
 		// ディレクトリの場合
 		if entry.IsDir() {
 			if !fc.options.Recursive {
 				continue
 			}
 
+			// ソースの中にある宛先は走査しない（コピー中に増えていく宛先をコピーし続けないようにする）
+			if fc.isDestDir(sourcePath, entry) {
+				continue
+			}
+
+			// 除外プロファイルに一致するディレクトリは中身ごと走査しない
+			if fc.filter != nil && fc.filter.ExcludesDir(sourcePath) {
+				if fc.logger != nil && fc.logger.Verbose {
+					relPath, _ := pathkey.Rel(fc.sourceDir, sourcePath)
+					fc.logger.Info("ディレクトリをスキップ（除外プロファイル）: %s", relPath)
+				}
+				continue
+			}
+
+			// フラット化時はすべて宛先ディレクトリ直下にコピー
+			if fc.options.Flatten {
+				destPath = fc.destDir
+			}
+
 			// 再帰的にコピー
+			if snapshots != nil {
+				fc.addSnapshot(snapshots, entry.Name(), sourcePath)
+			}
 			if err := fc.copyDirectory(sourcePath, destPath); err != nil {
 				// loggerでエラー出力
 				if fc.logger != nil && fc.logger.Verbose {
@@ -312,8 +716,11 @@ func (fc *FileCopier) copyDirectory(sourceDir, destDir string) error {
 		}
 
 		// ファイルの場合
-		info, err := entry.Info()
+		info, err := fc.entryInfo(entry)
 		if err != nil {
+			if fc.ignoreWalkError(sourcePath, err) {
+				continue
+			}
 			fc.stats.IncrementFailed()
 
 			// loggerでエラー出力
@@ -326,11 +733,11 @@ func (fc *FileCopier) copyDirectory(sourceDir, destDir string) error {
 		// フィルタリング
 		if fc.filter != nil && !fc.filter.ShouldInclude(sourcePath) {
 			// ファイルをスキップ
-			fc.stats.IncrementSkipped(info.Size())
+			relPath, _ := pathkey.Rel(fc.sourceDir, sourcePath)
+			fc.countSkipped(relPath, info.Size())
 
 			// データベースに記録
 			if fc.db != nil {
-				relPath, _ := filepath.Rel(fc.sourceDir, sourcePath)
 				fileInfo := database.FileInfo{
 					Path:         relPath,
 					Size:         info.Size(),
@@ -338,56 +745,221 @@ func (fc *FileCopier) copyDirectory(sourceDir, destDir string) error {
 					Status:       database.StatusSkipped,
 					LastSyncTime: time.Now(),
 					LastError:    "フィルタによりスキップ",
+					Reason:       database.ReasonFiltered,
 				}
 				fc.db.AddFile(fileInfo)
 			}
 
 			// loggerでスキップ情報を出力
 			if fc.logger != nil && fc.logger.Verbose {
-				relPath, _ := filepath.Rel(fc.sourceDir, sourcePath)
 				fc.logger.Info("ファイルをスキップ（フィルタ）: %s", relPath)
 			}
 
 			continue
 		}
 
-		// 非同期でファイルをコピー
-		fc.wg.Add(1)
-		go func(src, dst string) {
-			defer fc.wg.Done()
-
-			// セマフォの取得
-			fc.semaphore <- struct{}{}
-			defer func() {
-				<-fc.semaphore
-			}()
-
-			if err := fc.copyFile(src, dst); err != nil {
-				// loggerでエラー出力（非同期処理なので詳細は出力しない）
-				if fc.logger != nil {
-					relPath, _ := filepath.Rel(fc.sourceDir, src)
-					fc.logger.Error("ファイルコピーエラー: %s", relPath)
+		// フラット化時のコピー先の決定
+		if fc.options.Flatten {
+			destPath = fc.flattenDestPath(sourcePath)
+			if destPath == "" {
+				relPath, _ := pathkey.Rel(fc.sourceDir, sourcePath)
+				fc.countSkipped(relPath, info.Size())
+				if fc.db != nil {
+					fc.db.AddFile(database.FileInfo{
+						Path:         relPath,
+						Size:         info.Size(),
+						ModTime:      info.ModTime(),
+						Status:       database.StatusSkipped,
+						LastSyncTime: time.Now(),
+						LastError:    "フラット化によるファイル名の衝突",
+						Reason:       database.ReasonFlattenCollision,
+					})
 				}
+				continue
 			}
-		}(sourcePath, destPath)
+		}
+
+		if snapshots != nil {
+			fc.addSnapshot(snapshots, entry.Name(), sourcePath)
+		}
+
+		// 非同期でファイルをコピー（順序を指定した場合は走査を終えた後にコピー）
+		fc.enqueueCopy(sourcePath, destPath, info)
 	}
 
 	return nil
 }
 
+// copyAsync はワーカーの空きを待ってファイルをコピーするゴルーチンを起動する
+func (fc *FileCopier) copyAsync(sourcePath, destPath string) {
+	fc.wg.Add(1)
+	ticket := fc.stats.Enqueue()
+	go func(src, dst string) {
+		defer fc.wg.Done()
+
+		// セマフォの取得
+		fc.semaphore <- struct{}{}
+		fc.runCopy(src, dst, ticket)
+	}(sourcePath, destPath)
+}
+
+// runCopy はセマフォを取得したワーカーでファイルをコピーし、終了後にセマフォを解放する
+// ticketは処理待ちとして記録したときの番号
+func (fc *FileCopier) runCopy(src, dst string, ticket uint64) {
+	defer func() {
+		<-fc.semaphore
+	}()
+	fc.stats.Dequeue(ticket)
+
+	relPath, _ := pathkey.Rel(fc.sourceDir, src)
+	slot := fc.stats.BeginWork(relPath)
+	defer fc.stats.EndWork(slot)
+
+	if fc.stats.TimingsEnabled() || fc.options.DetailedRecords {
+		defer fc.recordTiming(relPath, src, slot, time.Now(), fc.throttle.pausedTotal())
+	}
+	if err := fc.copyFile(src, dst); err != nil {
+		fc.stats.RecordError(relPath, err)
+		fc.recordFailure(relPath, err)
+	}
+}
+
+// recordTiming はファイルの処理時間を記録する（一時停止していた時間は含めない）
+// workerはファイルを処理したワーカーのスロット番号
+func (fc *FileCopier) recordTiming(relPath, sourcePath string, worker int, start time.Time, pausedBefore time.Duration) {
+	elapsed := time.Since(start) - (fc.throttle.pausedTotal() - pausedBefore)
+	var size int64
+	if info, err := fc.fs.Stat(sourcePath); err == nil {
+		size = info.Size()
+	}
+	fc.stats.RecordTiming(relPath, size, elapsed)
+	if fc.options.DetailedRecords {
+		fc.recordProcessing(relPath, worker, size, elapsed)
+	}
+}
+
+// recordProcessing はファイルの処理時間・再試行回数・ワーカーをDBのファイルの記録に加える
+func (fc *FileCopier) recordProcessing(relPath string, worker int, size int64, elapsed time.Duration) {
+	retries := 0
+	if n, ok := fc.retryCounts.LoadAndDelete(relPath); ok {
+		retries = n.(int)
+	}
+	if fc.db == nil {
+		return
+	}
+	timing := stats.FileTiming{Path: relPath, Bytes: size, Duration: elapsed}
+	fc.db.UpdateFileProcessing(relPath, database.Processing{
+		Seconds:    elapsed.Seconds(),
+		Retries:    retries,
+		Worker:     worker,
+		Throughput: timing.Throughput(),
+	})
+}
+
+// flattenDestPath はフラット化時のコピー先パスを決定する
+// 同一実行内で既に使用されたファイル名と衝突した場合は設定に従って名前を変更し、衝突を記録する
+// 衝突したファイルをスキップする場合は空文字列を返す
+func (fc *FileCopier) flattenDestPath(sourcePath string) string {
+	fc.flatMu.Lock()
+	defer fc.flatMu.Unlock()
+
+	relPath, err := pathkey.Rel(fc.sourceDir, sourcePath)
+	if err != nil {
+		relPath = filepath.Base(sourcePath)
+	}
+	name := filepath.Base(sourcePath)
+
+	claimedBy, exists := fc.flatNames[name]
+	if !exists {
+		fc.flatNames[name] = relPath
+		return filepath.Join(fc.destDir, name)
+	}
+
+	collision := FlattenCollision{SourcePath: relPath, ClaimedBy: claimedBy}
+	switch fc.options.FlattenRename {
+	case FlattenSkip:
+		// コピーしない
+	case FlattenHash:
+		sum := sha1.Sum([]byte(relPath))
+		candidate := hex.EncodeToString(sum[:])[:8] + "_" + name
+		if _, used := fc.flatNames[candidate]; !used {
+			collision.DestName = candidate
+			break
+		}
+		// ハッシュを付けた名前を他のファイルが使用している場合は連番を付ける
+		fallthrough
+	default:
+		for i := 1; ; i++ {
+			candidate := fmt.Sprintf("%d_%s", i, name)
+			if _, used := fc.flatNames[candidate]; !used {
+				collision.DestName = candidate
+				break
+			}
+		}
+	}
+	fc.collisions = append(fc.collisions, collision)
+
+	if fc.logger != nil {
+		if collision.DestName == "" {
+			fc.logger.Warn("ファイル名が衝突したためスキップします: %s (既存: %s)", relPath, claimedBy)
+		} else {
+			fc.logger.Warn("ファイル名が衝突したため名前を変更します: %s -> %s (既存: %s)", relPath, collision.DestName, claimedBy)
+		}
+	}
+
+	if collision.DestName == "" {
+		return ""
+	}
+	fc.flatNames[collision.DestName] = relPath
+	return filepath.Join(fc.destDir, collision.DestName)
+}
+
+// applyDirTimes は記録したディレクトリの更新日時とアクセス権を深い階層から順に適用する
+func (fc *FileCopier) applyDirTimes() {
+	fc.dirTimesMu.Lock()
+	defer fc.dirTimesMu.Unlock()
+
+	// 親ディレクトリより先に記録されることはないため、逆順に処理すれば子から適用される
+	for i := len(fc.dirTimes) - 1; i >= 0; i-- {
+		dt := fc.dirTimes[i]
+		if !dt.modTime.IsZero() {
+			if err := fc.chtimesDest(dt.path, dt.modTime); err != nil {
+				// 空ディレクトリをコピーしない設定で作成されなかった場合は無視
+				if os.IsNotExist(err) {
+					continue
+				}
+				if fc.logger != nil && fc.logger.Verbose {
+					fc.logger.Warn("ディレクトリ更新日時の設定エラー: %s: %v", dt.path, err)
+				}
+			}
+		}
+		if dt.source != "" {
+			if err := fc.setPermissions(dt.source, dt.path, true); err != nil && !os.IsNotExist(err) && fc.logger != nil {
+				fc.logger.Warn("ディレクトリのアクセス権の設定エラー: %s: %v", dt.path, err)
+			}
+		}
+	}
+	fc.dirTimes = nil
+}
+
 // copyFile は単一ファイルをコピーする
 func (fc *FileCopier) copyFile(sourcePath, destPath string) error {
 	// コンテキストのキャンセル確認
 	select {
 	case <-fc.ctx.Done():
-		return fmt.Errorf("コピー処理がキャンセルされました")
+		return errcode.Errorf(errcode.ErrCancelled, "コピー処理がキャンセルされました")
 	default:
 	}
 
+	// 一時停止中は再開を待つ
+	if err := fc.throttle.waitResume(fc.ctx); err != nil {
+		return err
+	}
+
 	// 相対パスの計算
-	relPath, err := filepath.Rel(fc.sourceDir, sourcePath)
+	relPath, err := pathkey.Rel(fc.sourceDir, sourcePath)
 	if err != nil {
-		relPath = filepath.Base(sourcePath)
+		relPath = pathkey.Normalize(filepath.Base(sourcePath))
 	}
 
 	// 進捗報告
@@ -414,9 +986,10 @@ func (fc *FileCopier) copyFile(sourcePath, destPath string) error {
 	}
 
 	// ソースファイルの情報を取得
-	sourceInfo, err := os.Stat(sourcePath)
+	sourceInfo, err := fc.statSource(sourcePath)
 	if err != nil {
-		fc.stats.IncrementFailed()
+		err = errcode.Wrap(errcode.ErrSourceRead, err)
+		fc.countFailed(relPath, err)
 
 		// データベースに記録
 		if fc.db != nil {
@@ -425,6 +998,7 @@ func (fc *FileCopier) copyFile(sourcePath, destPath string) error {
 				Status:       database.StatusFailed,
 				LastSyncTime: time.Now(),
 				LastError:    fmt.Sprintf("ソースファイル確認エラー: %v", err),
+				Error:        errcode.Describe(err),
 			}
 			fc.db.AddFile(errInfo)
 		}
@@ -446,14 +1020,34 @@ func (fc *FileCopier) copyFile(sourcePath, destPath string) error {
 		return fc.verifyFile(sourcePath, destPath, relPath, sourceInfo)
 	}
 
+	// 構造のみ作成する場合
+	if fc.options.StructureOnly {
+		return fc.createPlaceholders(sourcePath, destPath, relPath, sourceInfo)
+	}
+
+	// 小さいファイルはセグメントにまとめて書き込む
+	if fc.batchEnabled(sourceInfo) {
+		return fc.copyToBatch(sourcePath, destPath, relPath, sourceInfo, fileInfo)
+	}
+
+	// 内容を変換する場合の変換
+	transformers := fc.selectTransforms(sourcePath)
+
+	// 複数の宛先にコピーする場合
+	if fc.fanOutEnabled() {
+		return fc.copyFileFanOut(sourcePath, destPath, relPath, sourceInfo, fileInfo, transformers)
+	}
+
 	// 宛先ファイルの存在確認
-	destInfo, err := os.Stat(destPath)
+	destInfo, err := fc.statDest(destPath)
+	change := database.ChangeCreated
 	if err == nil {
 		// 宛先ファイルが存在する場合
+		change = database.ChangeUpdated
 
 		// 上書きが許可されていない場合はスキップ
 		if !fc.options.OverwriteExisting {
-			fc.stats.IncrementSkipped(sourceInfo.Size())
+			fc.countSkipped(relPath, sourceInfo.Size())
 
 			// データベースに記録
 			if fc.db != nil {
@@ -464,6 +1058,7 @@ func (fc *FileCopier) copyFile(sourcePath, destPath string) error {
 					Status:       database.StatusSkipped,
 					LastSyncTime: time.Now(),
 					LastError:    "宛先ファイルが既に存在します",
+					Reason:       database.ReasonDestExists,
 				}
 				fc.db.AddFile(skipInfo)
 			}
@@ -478,9 +1073,16 @@ func (fc *FileCopier) copyFile(sourcePath, destPath string) error {
 			return nil
 		}
 
+		// 宛先の方が新しい場合は上書きしない
+		if fc.destNewer(sourceInfo, destInfo) {
+			return fc.skipNewerFile(relPath, sourceInfo, destInfo)
+		}
+
 		// サイズと更新時刻が同じ場合はスキップ
-		if sourceInfo.Size() == destInfo.Size() && sourceInfo.ModTime().Equal(destInfo.ModTime()) {
-			fc.stats.IncrementSkipped(sourceInfo.Size())
+		if fc.upToDate(sourceInfo, destInfo, fileInfo, transformers) {
+			// 前回アクセス権をコピーできなかったファイルは、アクセス権のみ再度設定する
+			permErr := fc.retryPermissions(sourcePath, destPath, relPath, fileInfo)
+			fc.countSkipped(relPath, sourceInfo.Size())
 
 			// データベースに記録
 			if fc.db != nil {
@@ -491,6 +1093,14 @@ func (fc *FileCopier) copyFile(sourcePath, destPath string) error {
 					Status:       database.StatusSkipped,
 					LastSyncTime: time.Now(),
 				}
+				if len(transformers) > 0 {
+					skipInfo.Transform = fileInfo.Transform
+				}
+				if permErr != nil {
+					skipInfo.Status = database.StatusDataOKPermissionFailed
+					skipInfo.LastError = fmt.Sprintf("アクセス権の設定エラー: %v", permErr)
+					skipInfo.Error = errcode.Describe(permErr)
+				}
 				fc.db.AddFile(skipInfo)
 			}
 
@@ -503,14 +1113,24 @@ func (fc *FileCopier) copyFile(sourcePath, destPath string) error {
 
 			// 検証と同時コピーモードの場合は検証も行う
 			if fc.options.Mode == ModeCopyAndVerify {
-				return fc.verifyFile(sourcePath, destPath, relPath, sourceInfo)
+				return fc.verifyCopied(sourcePath, destPath, relPath, sourceInfo)
 			}
 
 			return nil
 		}
+
+		// 更新日時やアクセス権のみ変わったファイルは内容をコピーしない
+		if fc.updateMetadataOnly(sourcePath, destPath, relPath, sourceInfo, destInfo, transformers) {
+			// 検証と同時コピーモードの場合は検証も行う
+			if fc.options.Mode == ModeCopyAndVerify {
+				return fc.verifyCopied(sourcePath, destPath, relPath, sourceInfo)
+			}
+			return nil
+		}
 	} else if !os.IsNotExist(err) {
 		// 存在確認でエラーが発生した場合（存在しない以外のエラー）
-		fc.stats.IncrementFailed()
+		err = errcode.Wrap(errcode.ErrDestRead, err)
+		fc.countFailed(relPath, err)
 
 		// データベースに記録
 		if fc.db != nil {
@@ -521,6 +1141,7 @@ func (fc *FileCopier) copyFile(sourcePath, destPath string) error {
 				Status:       database.StatusFailed,
 				LastSyncTime: time.Now(),
 				LastError:    fmt.Sprintf("宛先ファイル確認エラー: %v", err),
+				Error:        errcode.Describe(err),
 			}
 			fc.db.AddFile(errInfo)
 		}
@@ -540,8 +1161,9 @@ func (fc *FileCopier) copyFile(sourcePath, destPath string) error {
 	// 宛先ディレクトリの作成
 	if fc.options.CreateDirs {
 		destDir := filepath.Dir(destPath)
-		if err := os.MkdirAll(destDir, 0755); err != nil {
-			fc.stats.IncrementFailed()
+		if err := fc.mkdirDest(destDir); err != nil {
+			err = errcode.Wrap(errcode.ErrDestWrite, err)
+			fc.countFailed(relPath, err)
 
 			// データベースに記録
 			if fc.db != nil {
@@ -552,6 +1174,7 @@ func (fc *FileCopier) copyFile(sourcePath, destPath string) error {
 					Status:       database.StatusFailed,
 					LastSyncTime: time.Now(),
 					LastError:    fmt.Sprintf("宛先ディレクトリ作成エラー: %v", err),
+					Error:        errcode.Describe(err),
 				}
 				fc.db.AddFile(errInfo)
 			}
@@ -569,12 +1192,34 @@ func (fc *FileCopier) copyFile(sourcePath, destPath string) error {
 		}
 	}
 
+	// 異常終了した場合に照合できるよう、宛先を開いた時点でジャーナルに記録
+	journal := fc.beginCopy(relPath, []string{destPath}, sourceInfo, change, transformers)
+	defer fc.endCopy(journal)
+
 	// ファイルのコピー（リトライロジック付き）
 	var copyErr error
-	for retry := 0; retry <= fc.options.MaxRetries; retry++ {
+	var transformInfo *database.TransformInfo
+	useCache := fc.dedupEligible(sourceInfo, transformers)
+	var cacheKey string
+	if useCache {
+		cacheKey = fc.recordedHash(sourceInfo, fileInfo)
+	}
+	maxRetries := fc.options.MaxRetries
+	if fc.options.DeferRetries {
+		// 再試行はワーカーを塞がないよう、他のファイルのコピーが終わった後に行う
+		maxRetries = 0
+	}
+	retries := fc.deferredPass() // 失敗までに再試行した回数（後回しにした再試行を含む）
+	for retry := 0; retry <= maxRetries; retry++ {
 		if retry > 0 {
-			// リトライ前に遅延
-			time.Sleep(fc.options.RetryDelay)
+			// リトライ前に遅延（キャンセルされた場合は中断）
+			select {
+			case <-time.After(fc.options.RetryDelay):
+			case <-fc.ctx.Done():
+			}
+			if fc.ctx.Err() != nil {
+				break
+			}
 
 			// loggerでリトライ情報を出力
 			if fc.logger != nil {
@@ -584,18 +1229,53 @@ func (fc *FileCopier) copyFile(sourcePath, destPath string) error {
 					fc.logger.Warn("ファイル '%s' のコピーをリトライします (%d/%d)", relPath, retry, fc.options.MaxRetries)
 				}
 			}
+			retries = retry
 		}
 
-		// ファイルのコピー
-		copyErr = fc.doCopyFile(sourcePath, destPath, sourceInfo)
+		// ファイルのコピー（同じ内容のファイルを読み込み済みの場合はキャッシュから書き込む）
+		copyErr = fc.retrySharing(relPath, func() error {
+			if useCache {
+				var err error
+				cacheKey, err = fc.copyFromCache(sourcePath, destPath, sourceInfo, cacheKey)
+				return err
+			}
+			var err error
+			transformInfo, err = fc.doCopyFile(sourcePath, destPath, sourceInfo, transformers)
+			return err
+		})
 		if copyErr == nil {
 			break
 		}
+
+		// キャンセルされた場合と、共有違反の再試行を使い切っても使用中の場合はリトライしない
+		// アクセス権のみコピーできなかった場合は内容をコピーし直しても変わらないため、リトライしない
+		if fc.ctx.Err() != nil || sharingViolation(copyErr) || errors.Is(copyErr, errcode.ErrPermissionCopy) {
+			break
+		}
+	}
+	if fc.options.DetailedRecords && retries > 0 {
+		fc.retryCounts.Store(relPath, retries)
+	}
+
+	// 内容はコピーできたがアクセス権をコピーできなかった場合は、コピーの失敗とは区別して記録する
+	var permErr error
+	if errors.Is(copyErr, errcode.ErrPermissionCopy) {
+		permErr, copyErr = copyErr, nil
+		fc.recordPermFailure(relPath, permErr)
 	}
 
 	// すべてのリトライが失敗した場合
 	if copyErr != nil {
-		fc.stats.IncrementFailed()
+		// 使用中のファイルは終了時に再試行する
+		if fc.deferLocked(relPath, sourcePath, destPath, copyErr) {
+			return nil
+		}
+		// 再試行を後回しにする場合は他のファイルのコピーが終わった後に再試行する
+		if fc.deferRetry(relPath, sourcePath, destPath, copyErr) {
+			return nil
+		}
+		copyErr = errcode.WithRetries(destError(fc.lockedError(relPath, copyErr)), retries)
+		fc.countFailed(relPath, copyErr)
 
 		// データベースに記録
 		if fc.db != nil {
@@ -612,8 +1292,13 @@ func (fc *FileCopier) copyFile(sourcePath, destPath string) error {
 				FailCount:    failCount,
 				LastSyncTime: time.Now(),
 				LastError:    fmt.Sprintf("ファイルコピーエラー: %v", copyErr),
+				Error:        errcode.Describe(copyErr),
+			}
+			if errors.Is(copyErr, errcode.ErrLocked) {
+				errInfo.Status = database.StatusLocked
 			}
 			fc.db.AddFile(errInfo)
+			journal.markRecorded()
 		}
 
 		// loggerでエラー出力
@@ -629,7 +1314,8 @@ func (fc *FileCopier) copyFile(sourcePath, destPath string) error {
 	}
 
 	// コピー成功の記録
-	fc.stats.IncrementCopied(sourceInfo.Size())
+	fc.countCopied(relPath, sourceInfo.Size())
+	fc.noteCopied(relPath, sourcePath, destPath, sourceInfo)
 
 	// データベースに記録
 	if fc.db != nil {
@@ -639,8 +1325,36 @@ func (fc *FileCopier) copyFile(sourcePath, destPath string) error {
 			ModTime:      sourceInfo.ModTime(),
 			Status:       database.StatusSuccess,
 			LastSyncTime: time.Now(),
+			SessionID:    atomic.LoadInt64(&fc.sessionID),
+			Change:       change,
+			Transform:    transformInfo,
+			AccessTime:   fc.sourceAccessTime(sourceInfo),
+		}
+		if transformInfo != nil {
+			successInfo.SourceHash = transformInfo.OriginalHash
+			successInfo.DestHash = transformInfo.OutputHash
+			successInfo.HashAlgo = fc.options.HashAlgorithm
+		}
+		if cacheKey != "" {
+			// キャッシュのキーにしたハッシュは次回以降も使用できるよう記録する
+			successInfo.SourceHash = cacheKey
+			successInfo.HashAlgo = fc.options.HashAlgorithm
 		}
-		fc.db.AddFile(successInfo)
+		if permErr != nil {
+			successInfo.Status = database.StatusDataOKPermissionFailed
+			successInfo.LastError = fmt.Sprintf("ファイルコピーエラー: %v", permErr)
+			successInfo.Error = errcode.Describe(permErr)
+		}
+		if meta, err := fc.collectSourceMeta(sourcePath); err == nil {
+			successInfo.Meta = meta
+		}
+		// コピー先のパスが異なる場合（フラット化など）は記録する
+		if destRel, err := pathkey.Rel(fc.destDir, destPath); err == nil && destRel != relPath {
+			successInfo.DestPath = destRel
+		}
+		// 以前セグメントにまとめて書き込んだファイルは、セグメントの中の位置の記録を削除する
+		fc.db.AddUnbatchedFile(successInfo)
+		journal.markRecorded()
 	}
 
 	// loggerで成功情報を出力
@@ -652,53 +1366,79 @@ func (fc *FileCopier) copyFile(sourcePath, destPath string) error {
 		}
 	}
 
-	// 検証と同時コピーモードの場合は検証も行う
+	// 検証と同時コピーモードの場合は検証も行う（ハッシュは検証で記録する）
 	if fc.options.Mode == ModeCopyAndVerify {
-		return fc.verifyFile(sourcePath, destPath, relPath, sourceInfo)
+		return fc.verifyCopied(sourcePath, destPath, relPath, sourceInfo)
 	}
+	fc.stampCopied(relPath, destPath, sourceInfo, transformInfo, cacheKey)
 
 	return nil
 }
 
 // doCopyFile は実際のファイルコピー処理を行う
-func (fc *FileCopier) doCopyFile(sourcePath, destPath string, sourceInfo os.FileInfo) error {
+// 変換を指定した場合は変換後の内容を書き込み、変換前後のハッシュを返す（変換しない場合はnil）
+func (fc *FileCopier) doCopyFile(sourcePath, destPath string, sourceInfo os.FileInfo, transformers []transform.Transformer) (*database.TransformInfo, error) {
+	// 巨大なファイルは分割して並行にコピーする（変換する場合は先頭から順に処理する必要があるため対象外）
+	if len(transformers) == 0 && fc.segmented(sourceInfo) {
+		return nil, fc.doCopyFileSegmented(sourcePath, destPath, sourceInfo)
+	}
+
+	// 中断した書き込みを再開できる宛先では、書き込みの途中の状態を記録しながらコピーする
+	if r, ok := fc.resumableDest(); ok && len(transformers) == 0 {
+		return nil, fc.doCopyFileResumable(r, sourcePath, destPath, sourceInfo)
+	}
+
 	// ソースファイルを開く
-	sourceFile, err := os.Open(sourcePath)
+	sourceFile, err := fc.openSource(sourcePath)
 	if err != nil {
 		// loggerでエラー出力
 		if fc.logger != nil && fc.logger.Verbose {
 			fc.logger.Error("ソースファイル(%s)を開けません: %v", sourcePath, err)
 		}
-		return fmt.Errorf("ソースファイル(%s)を開けません: %w", sourcePath, err)
+		return nil, errcode.Errorf(errcode.ErrSourceRead, "ソースファイル(%s)を開けません: %w", sourcePath, err)
 	}
 	defer sourceFile.Close()
 
 	// 宛先ファイルを作成
-	destFile, err := os.Create(destPath)
+	destFile, err := fc.createDest(destPath)
 	if err != nil {
 		// loggerでエラー出力
 		if fc.logger != nil && fc.logger.Verbose {
 			fc.logger.Error("宛先ファイル(%s)を作成できません: %v", destPath, err)
 		}
-		return fmt.Errorf("宛先ファイル(%s)を作成できません: %w", destPath, err)
+		return nil, fmt.Errorf("宛先ファイル(%s)を作成できません: %w", destPath, err)
 	}
 	defer destFile.Close()
 
-	// バッファを作成
-	buffer := make([]byte, fc.options.BufferSize)
-
 	// ファイルをコピー
-	copiedBytes, err := io.CopyBuffer(destFile, sourceFile, buffer)
+	var reader io.Reader = fc.sourceReader(sourceFile)
+	var writer io.Writer = destFile
+	var hashes *transformHashes
+	if len(transformers) > 0 {
+		if hashes, err = fc.newTransformHashes(); err != nil {
+			return nil, err
+		}
+		transformed, w := hashes.wrap(reader, destFile, transformers)
+		defer transformed.Close()
+		reader, writer = transformed, w
+	}
+	// 先読みする場合は読み込みと書き込みを別のゴルーチンで行う
+	var copiedBytes int64
+	if fc.options.ReadAhead > 0 {
+		copiedBytes, err = pipelineCopy(writer, reader, fc.options.BufferSize, fc.options.ReadAhead)
+	} else {
+		copiedBytes, err = io.CopyBuffer(writer, reader, make([]byte, fc.options.BufferSize))
+	}
 	if err != nil {
 		// loggerでエラー出力
 		if fc.logger != nil && fc.logger.Verbose {
 			fc.logger.Error("ファイルコピーエラー: %s -> %s: %v", sourcePath, destPath, err)
 		}
-		return fmt.Errorf("ファイルコピーエラー: %w", err)
+		return nil, fmt.Errorf("ファイルコピーエラー: %w", err)
 	}
 
-	// コピーされたバイト数の確認
-	if copiedBytes != sourceInfo.Size() {
+	// コピーされたバイト数の確認（変換した場合はサイズが変わる）
+	if hashes == nil && copiedBytes != sourceInfo.Size() {
 		// loggerで警告出力
 		if fc.logger != nil && fc.logger.Verbose {
 			fc.logger.Warn("コピーされたバイト数が一致しません: 期待値=%d, 実際=%d", sourceInfo.Size(), copiedBytes)
@@ -711,21 +1451,18 @@ func (fc *FileCopier) doCopyFile(sourcePath, destPath string, sourceInfo os.File
 		if fc.logger != nil && fc.logger.Verbose {
 			fc.logger.Error("宛先ファイル(%s)を閉じられません: %v", destPath, err)
 		}
-		return fmt.Errorf("宛先ファイル(%s)を閉じられません: %w", destPath, err)
+		return nil, fmt.Errorf("宛先ファイル(%s)を閉じられません: %w", destPath, err)
 	}
 
-	// 更新日時の保持
-	if fc.options.PreserveModTime {
-		if err = os.Chtimes(destPath, time.Now(), sourceInfo.ModTime()); err != nil {
-			// loggerでエラー出力
-			if fc.logger != nil && fc.logger.Verbose {
-				fc.logger.Error("更新日時の設定エラー: %s: %v", destPath, err)
-			}
-			return fmt.Errorf("更新日時の設定エラー: %w", err)
-		}
+	// 更新日時とアクセス権の保持（アクセス権のみ設定できなかった場合も、内容の変換結果はエラーと合わせて返す）
+	if err = fc.applyFileMetadata(sourcePath, destPath, sourceInfo); err != nil && !errors.Is(err, errcode.ErrPermissionCopy) {
+		return nil, err
 	}
 
-	return nil
+	if hashes != nil {
+		return hashes.info(transformers, copiedBytes), err
+	}
+	return nil, err
 }
 
 // verifyFile はファイルのハッシュ検証を行う
@@ -735,8 +1472,14 @@ func (fc *FileCopier) verifyFile(sourcePath, destPath, relPath string, sourceInf
 		return nil
 	}
 
+	// 宛先は書き込みとは別の経路から読み込む（ハッシュは宛先のパスに記録する）
+	stampPath := destPath
+	destPath = fc.verifyPath(destPath)
+
 	// 宛先ファイルの存在確認
-	if _, err := os.Stat(destPath); os.IsNotExist(err) {
+	destInfo, err := fc.statDest(destPath)
+	if os.IsNotExist(err) {
+		fc.countVerification(relPath, database.VerifyMissingDest, sourceInfo, "", "")
 		// データベースに記録
 		if fc.db != nil {
 			errInfo := database.FileInfo{
@@ -746,6 +1489,7 @@ func (fc *FileCopier) verifyFile(sourcePath, destPath, relPath string, sourceInf
 				Status:       database.StatusMismatch,
 				LastSyncTime: time.Now(),
 				LastError:    "宛先ファイルが存在しません",
+				Error:        errcode.Describe(errcode.ErrDestMissing),
 			}
 			fc.db.AddFile(errInfo)
 		}
@@ -759,12 +1503,69 @@ func (fc *FileCopier) verifyFile(sourcePath, destPath, relPath string, sourceInf
 			}
 		}
 
-		return fmt.Errorf("宛先ファイル '%s' が存在しません", destPath)
+		return errcode.Errorf(errcode.ErrDestMissing, "宛先ファイル '%s' が存在しません", destPath)
+	}
+
+	// 所有者を指定した場合は宛先の所有者も比較する
+	if fc.ownerMismatch(destPath) {
+		fc.countVerification(relPath, database.VerifyMismatched, sourceInfo, "", "")
+		if fc.db != nil {
+			errInfo := database.FileInfo{
+				Path:         relPath,
+				Size:         sourceInfo.Size(),
+				ModTime:      sourceInfo.ModTime(),
+				Status:       database.StatusMismatch,
+				LastSyncTime: time.Now(),
+				LastError:    fmt.Sprintf("所有者が一致しません (指定: %s)", fc.options.Owner),
+				Error:        errcode.Describe(errcode.ErrOwnerMismatch),
+			}
+			fc.db.AddFile(errInfo)
+		}
+		if fc.logger != nil {
+			fc.logger.Error("検証失敗: %s (所有者が%sではありません)", relPath, fc.options.Owner)
+		}
+		return errcode.Errorf(errcode.ErrOwnerMismatch, "ファイル '%s' の所有者が一致しません (指定: %s)", relPath, fc.options.Owner)
+	}
+
+	// 精度を指定した場合は、設定した更新日時が宛先のファイルシステムで保持されたかを比較する
+	if err == nil && sourceInfo != nil && fc.options.PreserveModTime && !fsmeta.ModTimeWithin(sourceInfo.ModTime(), destInfo.ModTime(), fc.options.ModTimePrecision) {
+		mismatch := errcode.Errorf(errcode.ErrModTimeMismatch, "ファイル '%s' の更新日時が一致しません (ソース: %s, 宛先: %s)",
+			relPath, sourceInfo.ModTime().Format(time.RFC3339Nano), destInfo.ModTime().Format(time.RFC3339Nano))
+		fc.countVerification(relPath, database.VerifyMismatched, sourceInfo, "", "")
+		if fc.db != nil {
+			errInfo := database.FileInfo{
+				Path:         relPath,
+				Size:         sourceInfo.Size(),
+				ModTime:      sourceInfo.ModTime(),
+				Status:       database.StatusMismatch,
+				LastSyncTime: time.Now(),
+				LastError:    mismatch.Error(),
+				Error:        errcode.Describe(mismatch),
+			}
+			fc.db.AddFile(errInfo)
+		}
+		if fc.logger != nil {
+			fc.logger.Error("検証失敗: %s (更新日時が一致しません)", relPath)
+		}
+		return mismatch
 	}
 
-	// ソースファイルのハッシュを計算
-	sourceHash, err := fc.hasher.HashFile(sourcePath)
+	// ソースファイルのハッシュを計算（変換する場合は変換後の内容のハッシュを期待値とする）
+	var sourceHash, expectedHash string
+	transformers := fc.selectTransforms(sourcePath)
+	var transformInfo *database.TransformInfo
+	if len(transformers) > 0 {
+		transformInfo, err = fc.hashTransformed(sourcePath, transformers)
+		if err == nil {
+			sourceHash, expectedHash = transformInfo.OriginalHash, transformInfo.OutputHash
+		}
+	} else {
+		sourceHash, err = fc.hashFile(fc.options.SourceIdentity, sourcePath)
+		expectedHash = sourceHash
+	}
 	if err != nil {
+		err = errcode.Wrap(errcode.ErrSourceRead, err)
+		fc.countVerification(relPath, database.VerifyError, sourceInfo, "", "")
 		// データベースに記録
 		if fc.db != nil {
 			errInfo := database.FileInfo{
@@ -774,6 +1575,7 @@ func (fc *FileCopier) verifyFile(sourcePath, destPath, relPath string, sourceInf
 				Status:       database.StatusFailed,
 				LastSyncTime: time.Now(),
 				LastError:    fmt.Sprintf("ソースハッシュ計算エラー: %v", err),
+				Error:        errcode.Describe(err),
 			}
 			fc.db.AddFile(errInfo)
 		}
@@ -791,8 +1593,10 @@ func (fc *FileCopier) verifyFile(sourcePath, destPath, relPath string, sourceInf
 	}
 
 	// 宛先ファイルのハッシュを計算
-	destHash, err := fc.hasher.HashFile(destPath)
+	destHash, err := fc.hashFile(fc.options.DestIdentity, destPath)
 	if err != nil {
+		err = errcode.Wrap(errcode.ErrDestRead, err)
+		fc.countVerification(relPath, database.VerifyError, sourceInfo, sourceHash, "")
 		// データベースに記録
 		if fc.db != nil {
 			errInfo := database.FileInfo{
@@ -802,6 +1606,7 @@ func (fc *FileCopier) verifyFile(sourcePath, destPath, relPath string, sourceInf
 				Status:       database.StatusFailed,
 				LastSyncTime: time.Now(),
 				LastError:    fmt.Sprintf("宛先ハッシュ計算エラー: %v", err),
+				Error:        errcode.Describe(err),
 			}
 			fc.db.AddFile(errInfo)
 		}
@@ -817,14 +1622,34 @@ func (fc *FileCopier) verifyFile(sourcePath, destPath, relPath string, sourceInf
 
 		return fmt.Errorf("宛先ファイル(%s)のハッシュ計算エラー: %w", destPath, err)
 	}
+	destHash = fc.options.Faults.CorruptHash(destHash)
 
 	// ハッシュ値をデータベースに記録
 	if fc.db != nil {
-		fc.db.UpdateFileHash(relPath, sourceHash, destHash)
+		fc.db.UpdateFileHash(relPath, sourceHash, destHash, fc.options.HashAlgorithm)
 	}
 
-	// ハッシュ値の比較
-	if sourceHash != destHash {
+	// ハッシュ値の比較（一致しない場合は読み直して再検証する）
+	outcome := database.VerifyMatched
+	status := database.StatusVerified
+	var note, lastError string
+	var reason database.Reason
+	if expectedHash != destHash && fc.options.MismatchRetries > 0 {
+		s, d, attempts, ok := fc.recheckMismatch(sourcePath, destPath, relPath, transformers)
+		if ok {
+			sourceHash, expectedHash, destHash = s, d, d
+			outcome, status = database.VerifyIntermittent, database.StatusIntermittent
+			lastError = fmt.Sprintf("ハッシュ値が一度一致せず、%d回目の再検証で一致しました", attempts)
+			reason = database.ReasonIntermittent
+			if fc.db != nil {
+				fc.db.UpdateFileHash(relPath, sourceHash, destHash, fc.options.HashAlgorithm)
+			}
+		} else if attempts > 0 {
+			note = fmt.Sprintf("（%d回の再検証でも一致しません）", attempts)
+		}
+	}
+	if expectedHash != destHash {
+		fc.countVerification(relPath, database.VerifyMismatched, sourceInfo, sourceHash, destHash)
 		// データベースに記録
 		if fc.db != nil {
 			errInfo := database.FileInfo{
@@ -834,8 +1659,11 @@ func (fc *FileCopier) verifyFile(sourcePath, destPath, relPath string, sourceInf
 				Status:       database.StatusMismatch,
 				SourceHash:   sourceHash,
 				DestHash:     destHash,
+				HashAlgo:     fc.options.HashAlgorithm,
 				LastSyncTime: time.Now(),
-				LastError:    "ハッシュ値が一致しません",
+				LastError:    "ハッシュ値が一致しません" + note,
+				Error:        errcode.Describe(errcode.ErrHashMismatch),
+				Transform:    transformInfo,
 			}
 			fc.db.AddFile(errInfo)
 		}
@@ -843,25 +1671,31 @@ func (fc *FileCopier) verifyFile(sourcePath, destPath, relPath string, sourceInf
 		// loggerでエラー出力
 		if fc.logger != nil {
 			if fc.logger.Verbose {
-				fc.logger.Error("ファイル '%s' のハッシュ値が一致しません (ソース: %s, 宛先: %s)", relPath, sourceHash, destHash)
+				fc.logger.Error("ファイル '%s' のハッシュ値が一致しません (ソース: %s, 宛先: %s)", relPath, expectedHash, destHash)
 			} else {
 				fc.logger.Error("ハッシュ不一致: %s", relPath)
 			}
 		}
 
-		return fmt.Errorf("ファイル '%s' のハッシュ値が一致しません (ソース: %s, 宛先: %s)", relPath, sourceHash, destHash)
+		return errcode.Errorf(errcode.ErrHashMismatch, "ファイル '%s' のハッシュ値が一致しません (ソース: %s, 宛先: %s)%s", relPath, expectedHash, destHash, note)
 	}
 
 	// 検証成功の記録
+	fc.countVerification(relPath, outcome, sourceInfo, sourceHash, destHash)
+	fc.stampDest(relPath, stampPath, sourceInfo, destHash, sourceHash)
 	if fc.db != nil {
 		verifyInfo := database.FileInfo{
 			Path:         relPath,
 			Size:         sourceInfo.Size(),
 			ModTime:      sourceInfo.ModTime(),
-			Status:       database.StatusVerified,
+			Status:       status,
 			SourceHash:   sourceHash,
 			DestHash:     destHash,
+			HashAlgo:     fc.options.HashAlgorithm,
 			LastSyncTime: time.Now(),
+			LastError:    lastError,
+			Reason:       reason,
+			Transform:    transformInfo,
 		}
 		fc.db.AddFile(verifyInfo)
 	}
@@ -878,6 +1712,64 @@ func (fc *FileCopier) verifyFile(sourcePath, destPath, relPath string, sourceInf
 	return nil
 }
 
+// verifyPath は検証時に宛先のファイルを読み込むパスを返す
+// VerifyViaが指定されている場合は、宛先ディレクトリからの相対パスを検証用の経路に付け替える
+func (fc *FileCopier) verifyPath(destPath string) string {
+	if fc.options.VerifyVia == "" {
+		return destPath
+	}
+	rel, err := filepath.Rel(fc.destDir, destPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return destPath
+	}
+	return filepath.Join(fc.options.VerifyVia, rel)
+}
+
+// countVerification はコピー時の検証結果を集計し、監査ログに記録する
+func (fc *FileCopier) countVerification(relPath string, outcome database.VerifyOutcome, sourceInfo os.FileInfo, sourceHash, destHash string) {
+	var size int64
+	if sourceInfo != nil {
+		size = sourceInfo.Size()
+	}
+
+	fc.verifyMu.Lock()
+	fc.verification.Add(outcome, size)
+	fc.verifyMu.Unlock()
+
+	fc.writeAudit(audit.Record{
+		Action:     audit.ActionVerify,
+		Path:       relPath,
+		Size:       size,
+		SourceHash: sourceHash,
+		DestHash:   destHash,
+		Result:     audit.VerifyResult(outcome),
+	}, nil)
+}
+
+// GetVerificationSummary はコピー時に行った検証結果の集計を返す
+func (fc *FileCopier) GetVerificationSummary() database.VerificationSummary {
+	fc.verifyMu.Lock()
+	defer fc.verifyMu.Unlock()
+	return fc.verification
+}
+
+// sessionStats はセッションに記録する統計情報のスナップショットを作成する
+func sessionStats(st *stats.Stats) database.SessionStats {
+	return database.SessionStats{
+		FilesCopied:    st.GetCopiedCount(),
+		FilesSkipped:   st.GetSkippedCount(),
+		FilesFailed:    st.GetFailedCount(),
+		FilesIgnored:   st.GetIgnoredCount(),
+		Conflicts:      st.GetConflictedCount(),
+		SharingRetries: st.GetSharingRetries(),
+		FilesLocked:    st.GetLockedCount(),
+		MetaUpdated:    st.GetMetaUpdatedCount(),
+		PermFailed:     st.GetPermFailedCount(),
+		BytesCopied:    st.GetCopiedBytes(),
+		BytesSkipped:   st.GetSkippedBytes(),
+	}
+}
+
 // reportProgress は進捗報告を行うゴルーチン
 func (fc *FileCopier) reportProgress() {
 	ticker := time.NewTicker(fc.options.ProgressInterval)
@@ -1,16 +1,33 @@
 package copier
 
 import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/sakuhanight/gopier/internal/audit"
 	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/faultinject"
 	"github.com/sakuhanight/gopier/internal/filter"
+	"github.com/sakuhanight/gopier/internal/fsmeta"
+	"github.com/sakuhanight/gopier/internal/hasher"
 	"github.com/sakuhanight/gopier/internal/logger"
+	"github.com/sakuhanight/gopier/internal/sidecar"
+	"github.com/sakuhanight/gopier/internal/transform"
+	"github.com/sakuhanight/gopier/internal/vfs"
 )
 
 func TestDefaultOptions(t *testing.T) {
@@ -184,6 +201,9 @@ func TestCopyFiles_SuccessAndError(t *testing.T) {
 	if err == nil {
 		t.Error("存在しないソースディレクトリでCopyFilesが失敗しませんでした")
 	}
+	if !errors.Is(err, errcode.ErrSourceMissing) {
+		t.Errorf("存在しないソースのエラーはErrSourceMissingとして判別できるべき: %v", err)
+	}
 }
 
 func TestCopyFile_OverwriteAndErrorCases(t *testing.T) {
@@ -251,6 +271,9 @@ func TestVerifyFile_HashMismatchAndNoDest(t *testing.T) {
 	if err == nil {
 		t.Error("宛先ファイルが存在しない場合、verifyFileは失敗すべきです")
 	}
+	if !errors.Is(err, errcode.ErrDestMissing) {
+		t.Errorf("宛先がない場合のエラーはErrDestMissingとして判別できるべき: %v", err)
+	}
 }
 
 func TestDoCopyFile_Error(t *testing.T) {
@@ -271,7 +294,7 @@ func TestDoCopyFile_Error(t *testing.T) {
 
 	copier := NewFileCopier(sourceDir, destDir, DefaultOptions(), nil, nil, nil)
 	info, _ := os.Stat(srcFile)
-	err = copier.doCopyFile(srcFile, dstFile, info)
+	_, err = copier.doCopyFile(srcFile, dstFile, info, nil)
 	if err == nil {
 		t.Error("読み取り不可ファイルでdoCopyFileが失敗しませんでした")
 	}
@@ -318,6 +341,178 @@ func TestCopyDirectory_SubdirsAndEmpty(t *testing.T) {
 	}
 }
 
+func TestCopyFiles_EmptyDirsAndDirTimes(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	os.MkdirAll(filepath.Join(sourceDir, "sub", "deep"), 0755)
+	os.MkdirAll(filepath.Join(sourceDir, "empty"), 0755)
+	os.WriteFile(filepath.Join(sourceDir, "sub", "deep", "file.txt"), []byte("content"), 0644)
+
+	// ディレクトリの更新日時を過去に設定（子から順に）
+	oldTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.Local)
+	for _, dir := range []string{"sub/deep", "sub", "empty"} {
+		if err := os.Chtimes(filepath.Join(sourceDir, dir), oldTime, oldTime); err != nil {
+			t.Fatalf("更新日時の設定に失敗: %v", err)
+		}
+	}
+
+	// デフォルト: 空ディレクトリをコピーし、更新日時を保持する
+	destDir := filepath.Join(tempDir, "dest")
+	copier := NewFileCopier(sourceDir, destDir, DefaultOptions(), nil, nil, nil)
+	if err := copier.CopyFiles(); err != nil {
+		t.Fatalf("CopyFilesが失敗しました: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "empty")); err != nil {
+		t.Errorf("空ディレクトリがコピーされていません: %v", err)
+	}
+	for _, dir := range []string{"sub/deep", "sub", "empty"} {
+		info, err := os.Stat(filepath.Join(destDir, dir))
+		if err != nil {
+			t.Fatalf("ディレクトリが見つかりません: %v", err)
+		}
+		if !info.ModTime().Equal(oldTime) {
+			t.Errorf("%s の更新日時: 期待値=%v, 実際=%v", dir, oldTime, info.ModTime())
+		}
+	}
+
+	// 空ディレクトリをコピーしない
+	destDir2 := filepath.Join(tempDir, "dest2")
+	options := DefaultOptions()
+	options.CopyEmptyDirs = false
+	copier2 := NewFileCopier(sourceDir, destDir2, options, nil, nil, nil)
+	if err := copier2.CopyFiles(); err != nil {
+		t.Fatalf("CopyFilesが失敗しました: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(destDir2, "empty")); err == nil {
+		t.Error("CopyEmptyDirs=falseのとき、空ディレクトリはコピーされるべきではありません")
+	}
+	if _, err := os.Stat(filepath.Join(destDir2, "sub", "deep", "file.txt")); err != nil {
+		t.Errorf("ファイルがコピーされていません: %v", err)
+	}
+}
+
+func TestCopyFiles_Flatten(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	os.MkdirAll(filepath.Join(sourceDir, "a"), 0755)
+	os.MkdirAll(filepath.Join(sourceDir, "b", "c"), 0755)
+	os.WriteFile(filepath.Join(sourceDir, "a", "log.txt"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "b", "c", "log.txt"), []byte("bc"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "b", "other.txt"), []byte("other"), 0644)
+
+	tests := []struct {
+		name          string
+		rename        FlattenRename
+		expectedFiles int
+	}{
+		{"連番", FlattenCounter, 3},
+		{"ハッシュ", FlattenHash, 3},
+		{"スキップ", FlattenSkip, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			destDir := filepath.Join(tempDir, "dest_"+string(tt.rename))
+			options := DefaultOptions()
+			options.Flatten = true
+			options.FlattenRename = tt.rename
+			copier := NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+			if err := copier.CopyFiles(); err != nil {
+				t.Fatalf("CopyFilesが失敗しました: %v", err)
+			}
+
+			entries, err := os.ReadDir(destDir)
+			if err != nil {
+				t.Fatalf("宛先ディレクトリの読み込みに失敗: %v", err)
+			}
+			for _, entry := range entries {
+				if entry.IsDir() {
+					t.Errorf("フラット化時にサブディレクトリが作成されています: %s", entry.Name())
+				}
+			}
+			if len(entries) != tt.expectedFiles {
+				t.Errorf("期待されるファイル数: %d, 実際: %d", tt.expectedFiles, len(entries))
+			}
+
+			collisions := copier.GetFlattenCollisions()
+			if len(collisions) != 1 {
+				t.Fatalf("期待される衝突数: 1, 実際: %d", len(collisions))
+			}
+			if collisions[0].SourcePath != "b/c/log.txt" || collisions[0].ClaimedBy != "a/log.txt" {
+				t.Errorf("衝突の記録が正しくありません: %+v", collisions[0])
+			}
+			if tt.rename == FlattenCounter && collisions[0].DestName != "1_log.txt" {
+				t.Errorf("連番のファイル名: 期待値=1_log.txt, 実際=%s", collisions[0].DestName)
+			}
+			if tt.rename == FlattenSkip && collisions[0].DestName != "" {
+				t.Errorf("スキップ時はファイル名が空であるべき: %s", collisions[0].DestName)
+			}
+		})
+	}
+}
+
+// TestFlattenDestPath_HashTaken はハッシュを付けた名前が既に使用されている場合に連番を付けることをテスト
+func TestFlattenDestPath_HashTaken(t *testing.T) {
+	sourceDir := filepath.Join(t.TempDir(), "source")
+	destDir := filepath.Join(t.TempDir(), "dest")
+	options := DefaultOptions()
+	options.Flatten = true
+	options.FlattenRename = FlattenHash
+	fc := NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+
+	sum := sha1.Sum([]byte("b/log.txt"))
+	hashName := hex.EncodeToString(sum[:])[:8] + "_log.txt"
+	for _, tt := range []struct{ source, want string }{
+		{"a/log.txt", "log.txt"},
+		{hashName, hashName}, // b/log.txtのハッシュを付けた名前と同じ名前のファイル
+		{"b/log.txt", "1_log.txt"},
+	} {
+		if got := fc.flattenDestPath(filepath.Join(sourceDir, tt.source)); got != filepath.Join(destDir, tt.want) {
+			t.Errorf("flattenDestPath(%s) = %s, want %s", tt.source, got, filepath.Join(destDir, tt.want))
+		}
+	}
+
+	collisions := fc.GetFlattenCollisions()
+	if len(collisions) != 1 || collisions[0].SourcePath != "b/log.txt" || collisions[0].DestName != "1_log.txt" {
+		t.Errorf("衝突の記録 = %+v", collisions)
+	}
+}
+
+func TestCopyFiles_ExclusionProfile(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(filepath.Join(sourceDir, "app", "node_modules", "lib"), 0755)
+	os.MkdirAll(filepath.Join(sourceDir, "app", ".git"), 0755)
+	os.WriteFile(filepath.Join(sourceDir, "app", "main.js"), []byte("main"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "app", "node_modules", "lib", "index.js"), []byte("lib"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "app", ".git", "HEAD"), []byte("ref"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "app", ".DS_Store"), []byte("ds"), 0644)
+
+	profile, err := filter.ResolveProfiles("dev-projects,macos", nil)
+	if err != nil {
+		t.Fatalf("ResolveProfilesが失敗しました: %v", err)
+	}
+	fileFilter := filter.NewFilter("", "")
+	fileFilter.AddProfile(profile)
+	copier := NewFileCopier(sourceDir, destDir, DefaultOptions(), fileFilter, nil, nil)
+	if err := copier.CopyFiles(); err != nil {
+		t.Fatalf("CopyFilesが失敗しました: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(destDir, "app", "main.js")); err != nil {
+		t.Errorf("除外されていないファイルがコピーされていません: %v", err)
+	}
+	for _, name := range []string{"node_modules", ".git", ".DS_Store"} {
+		if _, err := os.Stat(filepath.Join(destDir, "app", name)); !os.IsNotExist(err) {
+			t.Errorf("除外プロファイルに一致する %s が宛先に作成されています", name)
+		}
+	}
+	if copied := copier.GetStats().GetCopiedCount(); copied != 1 {
+		t.Errorf("コピーしたファイル数: 期待値=1, 実際=%d", copied)
+	}
+}
+
 func TestCopyFiles_SingleFile(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "copier_test6")
 	if err != nil {
@@ -592,7 +787,7 @@ func TestDoCopyFile_EdgeCases(t *testing.T) {
 	copier := NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
 
 	// 正常なコピー
-	err = copier.doCopyFile(testFile, filepath.Join(destDir, "test.txt"), sourceInfo)
+	_, err = copier.doCopyFile(testFile, filepath.Join(destDir, "test.txt"), sourceInfo, nil)
 	if err != nil {
 		t.Errorf("正常なコピーが失敗: %v", err)
 	}
@@ -600,13 +795,13 @@ func TestDoCopyFile_EdgeCases(t *testing.T) {
 	// 宛先ディレクトリを作成してからコピー
 	subDir := filepath.Join(destDir, "subdir")
 	os.MkdirAll(subDir, 0755)
-	err = copier.doCopyFile(testFile, filepath.Join(subDir, "test.txt"), sourceInfo)
+	_, err = copier.doCopyFile(testFile, filepath.Join(subDir, "test.txt"), sourceInfo, nil)
 	if err != nil {
 		t.Errorf("サブディレクトリへのコピーが失敗: %v", err)
 	}
 
 	// 存在しないソースファイル
-	err = copier.doCopyFile(filepath.Join(sourceDir, "nonexistent.txt"), filepath.Join(destDir, "nonexistent.txt"), sourceInfo)
+	_, err = copier.doCopyFile(filepath.Join(sourceDir, "nonexistent.txt"), filepath.Join(destDir, "nonexistent.txt"), sourceInfo, nil)
 	if err == nil {
 		t.Error("存在しないソースファイルでエラーが発生しませんでした")
 	}
@@ -980,3 +1175,922 @@ func BenchmarkCopyFiles_WithFilter(b *testing.B) {
 		os.RemoveAll(destDirPath)
 	}
 }
+
+func TestCopyFiles_RecordsVerificationSummary(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(sourceDir, 0755)
+	os.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("aaa"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "b.txt"), []byte("bbbb"), 0644)
+
+	syncDB, err := database.NewSyncDB(filepath.Join(tempDir, "test.db"), database.NormalSync)
+	if err != nil {
+		t.Fatalf("データベースの作成に失敗: %v", err)
+	}
+	defer syncDB.Close()
+
+	options := DefaultOptions()
+	options.Mode = ModeCopyAndVerify
+	options.VerifyHash = true
+	fc := NewFileCopier(sourceDir, destDir, options, nil, syncDB, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFilesが失敗しました: %v", err)
+	}
+
+	session, err := syncDB.GetLatestSession(database.SessionCopy)
+	if err != nil || session == nil {
+		t.Fatalf("セッションが取得できません: %v", err)
+	}
+	if session.Verification == nil {
+		t.Fatal("検証結果がセッションに記録されていません")
+	}
+	if session.Verification.Matched != 2 || session.Verification.Bytes != 7 {
+		t.Errorf("検証結果 = %+v", session.Verification)
+	}
+
+	// 検証しない場合は記録されない
+	fc = NewFileCopier(sourceDir, filepath.Join(tempDir, "dest2"), DefaultOptions(), nil, syncDB, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFilesが失敗しました: %v", err)
+	}
+	session, _ = syncDB.GetLatestSession(database.SessionCopy)
+	if session.Verification != nil {
+		t.Errorf("検証していないセッションに検証結果が記録されました: %+v", session.Verification)
+	}
+}
+
+// countingHasher はハッシュ値の計算回数を数える、組み込みでないハッシュの実装
+type countingHasher struct {
+	*hasher.Hasher
+	calls atomic.Int64
+}
+
+func (h *countingHasher) HashReader(r io.Reader) (string, error) {
+	h.calls.Add(1)
+	return h.Hasher.HashReader(r)
+}
+
+func TestCopyFiles_CustomHasher(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(sourceDir, 0755)
+	os.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("aaa"), 0644)
+
+	syncDB, err := database.NewSyncDB(filepath.Join(tempDir, "test.db"), database.NormalSync)
+	if err != nil {
+		t.Fatalf("データベースの作成に失敗: %v", err)
+	}
+	defer syncDB.Close()
+
+	custom := &countingHasher{Hasher: hasher.NewCustomHasher("hsm-sha256", sha256.New, 0)}
+	options := DefaultOptions()
+	options.Mode = ModeCopyAndVerify
+	options.VerifyHash = true
+	options.Hasher = custom
+	fc := NewFileCopier(sourceDir, destDir, options, nil, syncDB, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFilesが失敗しました: %v", err)
+	}
+
+	if calls := custom.calls.Load(); calls != 2 {
+		t.Errorf("指定した実装でのハッシュ値の計算回数 = %d, 期待値 2", calls)
+	}
+	record, err := syncDB.GetFile("a.txt")
+	if err != nil || record == nil {
+		t.Fatalf("ファイルの記録が取得できません: %v", err)
+	}
+	sum := sha256.Sum256([]byte("aaa"))
+	if record.HashAlgo != "hsm-sha256" || record.SourceHash != hex.EncodeToString(sum[:]) {
+		t.Errorf("記録 = アルゴリズム %q, ハッシュ %s", record.HashAlgo, record.SourceHash)
+	}
+}
+
+func TestCopyFiles_RecoverJournal(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(sourceDir, 0755)
+	os.MkdirAll(destDir, 0755)
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	contents := map[string]string{"done.txt": "done", "partial.txt": "partial"}
+	for name, content := range contents {
+		os.WriteFile(filepath.Join(sourceDir, name), []byte(content), 0644)
+		os.Chtimes(filepath.Join(sourceDir, name), modTime, modTime)
+	}
+
+	// 書き込みを終えてDBに記録する前に中断されたファイル
+	os.WriteFile(filepath.Join(destDir, "done.txt"), []byte("done"), 0644)
+	os.Chtimes(filepath.Join(destDir, "done.txt"), modTime, modTime)
+	// 領域を確保した後、書き込みの途中で中断されたファイル（宛先の方が新しく、サイズは同じ）
+	os.WriteFile(filepath.Join(destDir, "partial.txt"), make([]byte, len("partial")), 0644)
+
+	syncDB, err := database.NewSyncDB(filepath.Join(tempDir, "test.db"), database.NormalSync)
+	if err != nil {
+		t.Fatalf("データベースの作成に失敗: %v", err)
+	}
+	defer syncDB.Close()
+	for name, content := range contents {
+		size := int64(len(content))
+		syncDB.BeginCopy(database.JournalEntry{
+			Path:        name,
+			DestPaths:   []string{filepath.Join(destDir, name)},
+			Size:        size,
+			ModTime:     modTime,
+			DestSize:    size,
+			DestModTime: modTime,
+			Change:      database.ChangeCreated,
+			SessionID:   7,
+		})
+	}
+
+	// 宛先の方が新しいファイルを上書きしない設定でも、書き込み途中のファイルはコピーし直す
+	options := DefaultOptions()
+	options.VerifyHash = false
+	options.SkipNewer = true
+	fc := NewFileCopier(sourceDir, destDir, options, nil, syncDB, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFilesが失敗しました: %v", err)
+	}
+
+	if data, _ := os.ReadFile(filepath.Join(destDir, "partial.txt")); string(data) != "partial" {
+		t.Errorf("書き込み途中のファイルがコピーし直されていません: %q", data)
+	}
+	if got := fc.GetStats().GetCopiedCount(); got != 1 {
+		t.Errorf("コピーしたファイル数 = %d, want 1（書き込み済みのファイルはコピーし直さない）", got)
+	}
+	record, err := syncDB.GetFile("done.txt")
+	if err != nil {
+		t.Fatalf("ファイルの記録が取得できません: %v", err)
+	}
+	if record.SessionID != 7 || record.Change != database.ChangeCreated {
+		t.Errorf("中断されたセッションでの変更が記録されていません: セッション %d, 変更 %q", record.SessionID, record.Change)
+	}
+	if entries, _ := syncDB.GetJournal(); len(entries) != 0 {
+		t.Errorf("ジャーナルに記録が残っています: %+v", entries)
+	}
+}
+
+func TestJournal_OpenedDestsOnly(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join("/", "source")
+	destDir := filepath.Join("/", "dest")
+	mem := vfs.NewMem()
+	mem.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("new"), 0644)
+	mem.WriteFile(filepath.Join(destDir, "a.txt"), []byte("old"), 0644)
+	mem.WriteFile(filepath.Join(sourceDir, "b.txt"), []byte("new"), 0644)
+
+	syncDB, err := database.NewSyncDB(filepath.Join(tempDir, "test.db"), database.NormalSync)
+	if err != nil {
+		t.Fatalf("データベースの作成に失敗: %v", err)
+	}
+	defer syncDB.Close()
+
+	options := DefaultOptions()
+	options.FS = mem
+	fc := NewFileCopier(sourceDir, destDir, options, nil, syncDB, nil)
+	sourceInfo, _ := mem.Stat(filepath.Join(sourceDir, "a.txt"))
+
+	// 宛先を開く前に終えた場合は、ジャーナルに記録せず既存の宛先も削除しない
+	journal := fc.beginCopy("a.txt", []string{filepath.Join(destDir, "a.txt")}, sourceInfo, database.ChangeUpdated, nil)
+	if entries, _ := syncDB.GetJournal(); len(entries) != 0 {
+		t.Errorf("宛先を開く前にジャーナルに記録されています: %+v", entries)
+	}
+	fc.endCopy(journal)
+	if data, _ := mem.ReadFile(filepath.Join(destDir, "a.txt")); string(data) != "old" {
+		t.Errorf("開いていない宛先が変更されています: %q", data)
+	}
+
+	// 宛先を開いた後にDBに記録せずに終えた場合は、開いた宛先を削除してジャーナルの記録を削除する
+	destPath := filepath.Join(destDir, "b.txt")
+	journal = fc.beginCopy("b.txt", []string{destPath}, sourceInfo, database.ChangeCreated, nil)
+	file, err := fc.createDest(destPath)
+	if err != nil {
+		t.Fatalf("宛先を作成できません: %v", err)
+	}
+	file.Write([]byte("ne"))
+	file.Close()
+	entries, _ := syncDB.GetJournal()
+	if len(entries) != 1 || len(entries[0].DestPaths) != 1 || entries[0].DestPaths[0] != destPath {
+		t.Fatalf("開いた宛先がジャーナルに記録されていません: %+v", entries)
+	}
+	fc.endCopy(journal)
+	if _, err := mem.Stat(destPath); !os.IsNotExist(err) {
+		t.Errorf("書き込み途中の宛先が残っています: %v", err)
+	}
+	if entries, _ := syncDB.GetJournal(); len(entries) != 0 {
+		t.Errorf("ジャーナルに記録が残っています: %+v", entries)
+	}
+}
+
+func TestCopyFiles_PreservePermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("パーミッションのビットはWindowsでは検証できません")
+	}
+
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	os.MkdirAll(filepath.Join(sourceDir, "private"), 0755)
+	os.WriteFile(filepath.Join(sourceDir, "private", "secret.txt"), []byte("secret"), 0600)
+	os.Chmod(filepath.Join(sourceDir, "private", "secret.txt"), 0600)
+	os.Chmod(filepath.Join(sourceDir, "private"), 0700)
+
+	options := DefaultOptions()
+	options.VerifyHash = false
+	options.PreservePermissions = true
+	destDir := filepath.Join(tempDir, "dest")
+	fc := NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFilesが失敗しました: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(destDir, "private", "secret.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("ファイルのパーミッション = %o, want 600", info.Mode().Perm())
+	}
+	info, err = os.Stat(filepath.Join(destDir, "private"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0700 {
+		t.Errorf("ディレクトリのパーミッション = %o, want 700", info.Mode().Perm())
+	}
+}
+
+// countingIdentity は資格情報の切り替え回数を数えるテスト用のIdentity
+type countingIdentity struct {
+	calls int64
+}
+
+func (c *countingIdentity) Do(fn func() error) error {
+	atomic.AddInt64(&c.calls, 1)
+	return fn()
+}
+
+func (c *countingIdentity) Close() error { return nil }
+
+func TestCopyFiles_UsesIdentities(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	os.MkdirAll(filepath.Join(sourceDir, "sub"), 0755)
+	os.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "sub", "b.txt"), []byte("b"), 0644)
+
+	source := &countingIdentity{}
+	dest := &countingIdentity{}
+	options := DefaultOptions()
+	options.Mode = ModeCopyAndVerify
+	options.SourceIdentity = source
+	options.DestIdentity = dest
+
+	destDir := filepath.Join(tempDir, "dest")
+	fc := NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFilesが失敗しました: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(destDir, "sub", "b.txt")); err != nil {
+		t.Errorf("ファイルがコピーされていません: %v", err)
+	}
+	// ファイルごとに少なくともstat・open・ハッシュ計算で切り替える
+	if atomic.LoadInt64(&source.calls) < 6 {
+		t.Errorf("ソースの資格情報の使用回数 = %d", source.calls)
+	}
+	if atomic.LoadInt64(&dest.calls) < 6 {
+		t.Errorf("宛先の資格情報の使用回数 = %d", dest.calls)
+	}
+}
+
+func TestCopyFiles_ExcludeHidden(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	os.MkdirAll(filepath.Join(sourceDir, ".git", "objects"), 0755)
+	os.WriteFile(filepath.Join(sourceDir, ".git", "objects", "pack"), []byte("p"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, ".hidden"), []byte("h"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "visible.txt"), []byte("v"), 0644)
+
+	options := DefaultOptions()
+	options.VerifyHash = false
+	options.IncludeHidden = false
+	destDir := filepath.Join(tempDir, "dest")
+	fc := NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFilesが失敗しました: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(destDir, "visible.txt")); err != nil {
+		t.Errorf("通常のファイルがコピーされていません: %v", err)
+	}
+	for _, name := range []string{".hidden", ".git"} {
+		if _, err := os.Stat(filepath.Join(destDir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s が除外されていません", name)
+		}
+	}
+	if got := fc.GetExcludedCounts(); got != (ExcludedCounts{Hidden: 2}) {
+		t.Errorf("GetExcludedCounts() = %+v, want {Hidden:2 System:0}", got)
+	}
+}
+
+func TestCopyFiles_Transform(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	extraDir := filepath.Join(tempDir, "extra")
+	os.MkdirAll(sourceDir, 0755)
+	os.WriteFile(filepath.Join(sourceDir, "notes.txt"), []byte("a\nb\n"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "raw.bin"), []byte("a\nb\n"), 0644)
+
+	syncDB, err := database.NewSyncDB(filepath.Join(tempDir, "test.db"), database.NormalSync)
+	if err != nil {
+		t.Fatalf("データベースの作成に失敗: %v", err)
+	}
+	defer syncDB.Close()
+
+	pipeline, err := transform.Parse(".txt=crlf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := DefaultOptions()
+	options.Mode = ModeCopyAndVerify
+	options.Transforms = pipeline
+	options.ExtraDestinations = []string{extraDir}
+
+	for run := 1; run <= 2; run++ {
+		fc := NewFileCopier(sourceDir, destDir, options, nil, syncDB, nil)
+		if err := fc.CopyFiles(); err != nil {
+			t.Fatalf("%d回目: CopyFilesが失敗しました: %v", run, err)
+		}
+		// 2回目は変換後のサイズをDBの記録と比較してスキップする
+		if run == 2 && fc.GetStats().GetCopiedCount() != 0 {
+			t.Errorf("2回目のコピー件数 = %d, want 0", fc.GetStats().GetCopiedCount())
+		}
+	}
+
+	for _, dir := range []string{destDir, extraDir} {
+		if data, _ := os.ReadFile(filepath.Join(dir, "notes.txt")); string(data) != "a\r\nb\r\n" {
+			t.Errorf("%s: 変換後の内容 = %q", dir, data)
+		}
+		if data, _ := os.ReadFile(filepath.Join(dir, "raw.bin")); string(data) != "a\nb\n" {
+			t.Errorf("%s: 対象外のファイルが変換されました: %q", dir, data)
+		}
+	}
+
+	info, err := syncDB.GetFile("notes.txt")
+	if err != nil || info == nil || info.Transform == nil {
+		t.Fatalf("変換情報が記録されていません: %+v, %v", info, err)
+	}
+	if info.Transform.OriginalHash == info.Transform.OutputHash || info.Transform.OutputSize != 6 {
+		t.Errorf("変換情報 = %+v", info.Transform)
+	}
+	if info.SourceHash != info.Transform.OriginalHash || info.DestHash != info.Transform.OutputHash {
+		t.Errorf("ハッシュ = %s/%s, 変換情報 = %+v", info.SourceHash, info.DestHash, info.Transform)
+	}
+}
+
+func TestCopyFiles_MetaSidecar(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(filepath.Join(sourceDir, "sub"), 0755)
+	os.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "sub", "b.txt"), []byte("b"), 0644)
+	// ソースにある古いメタデータのファイルはコピーせず置き換える
+	os.WriteFile(filepath.Join(sourceDir, sidecar.FileName), []byte("{}"), 0644)
+
+	options := DefaultOptions()
+	options.VerifyHash = false
+	options.MetaSidecar = true
+	fc := NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFilesが失敗しました: %v", err)
+	}
+
+	for dir, want := range map[string][]string{
+		destDir:                       {sidecar.SelfEntry, "a.txt", "sub"},
+		filepath.Join(destDir, "sub"): {sidecar.SelfEntry, "b.txt"},
+	} {
+		file, err := sidecar.Read(dir)
+		if err != nil {
+			t.Fatalf("%s: メタデータのファイルを読み込めません: %v", dir, err)
+		}
+		if len(file.Entries) != len(want) {
+			t.Errorf("%s: エントリ = %v, want %v", dir, file.Entries, want)
+		}
+		for _, name := range want {
+			if file.Entries[name] == nil {
+				t.Errorf("%s: %s のメタデータがありません", dir, name)
+			}
+		}
+	}
+	if fc.GetStats().GetCopiedCount() != 2 {
+		t.Errorf("コピー件数 = %d", fc.GetStats().GetCopiedCount())
+	}
+}
+
+func TestVerifyFile_VerifyVia(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	viaDir := filepath.Join(tempDir, "via")
+	for _, dir := range []string{filepath.Join(sourceDir, "sub"), filepath.Join(destDir, "sub"), filepath.Join(viaDir, "sub")} {
+		os.MkdirAll(dir, 0755)
+	}
+
+	srcFile := filepath.Join(sourceDir, "sub", "file.txt")
+	dstFile := filepath.Join(destDir, "sub", "file.txt")
+	os.WriteFile(srcFile, []byte("abc"), 0644)
+	os.WriteFile(dstFile, []byte("abc"), 0644)
+	// 別の経路からは破損した内容が見える
+	os.WriteFile(filepath.Join(viaDir, "sub", "file.txt"), []byte("abd"), 0644)
+
+	options := DefaultOptions()
+	options.VerifyVia = viaDir
+	copier := NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := copier.verifyFile(srcFile, dstFile, "sub/file.txt", nil); err == nil {
+		t.Error("別の経路から読み込んだ内容が異なる場合、verifyFileは失敗すべきです")
+	}
+
+	os.WriteFile(filepath.Join(viaDir, "sub", "file.txt"), []byte("abc"), 0644)
+	if err := copier.verifyFile(srcFile, dstFile, "sub/file.txt", nil); err != nil {
+		t.Errorf("別の経路から読み込んだ内容が一致する場合、verifyFileは成功すべきです: %v", err)
+	}
+
+	// 宛先ディレクトリの外のパス（追加の宛先など）はそのまま読み込む
+	extraFile := filepath.Join(tempDir, "extra.txt")
+	os.WriteFile(extraFile, []byte("abc"), 0644)
+	if got := copier.verifyPath(extraFile); got != extraFile {
+		t.Errorf("verifyPath(%s) = %s", extraFile, got)
+	}
+}
+
+func TestCopyFiles_IgnoreErrorsOn(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(filepath.Join(sourceDir, ".snapshot"), 0755)
+	os.MkdirAll(filepath.Join(sourceDir, "data"), 0755)
+	os.WriteFile(filepath.Join(sourceDir, ".snapshot", "old.txt"), []byte("old"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "data", "new.txt"), []byte("new"), 0644)
+
+	// 宛先のサブディレクトリを作成できない状態にする
+	os.MkdirAll(destDir, 0755)
+	os.WriteFile(filepath.Join(destDir, ".snapshot"), []byte("file"), 0644)
+
+	options := DefaultOptions()
+	options.MaxRetries = 0
+	fc := NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.CopyFiles(); err == nil {
+		t.Fatal("ディレクトリのエラーでコピーが中断されませんでした")
+	}
+
+	options.IgnoreErrorsOn = ".snapshot"
+	fc = NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("エラーを無視するパスのエラーでコピーが中断されました: %v", err)
+	}
+	if fc.GetStats().GetIgnoredCount() != 1 || fc.GetStats().GetFailedCount() != 0 {
+		t.Errorf("無視した数 = %d, 失敗数 = %d; want 1, 0", fc.GetStats().GetIgnoredCount(), fc.GetStats().GetFailedCount())
+	}
+	if data, _ := os.ReadFile(filepath.Join(destDir, "data", "new.txt")); string(data) != "new" {
+		t.Errorf("エラーを無視したパス以外のファイルがコピーされていません: %q", data)
+	}
+
+	// ファイルのコピーの失敗も別に数える
+	os.Remove(filepath.Join(destDir, ".snapshot"))
+	os.MkdirAll(filepath.Join(destDir, ".snapshot", "old.txt"), 0755)
+	os.MkdirAll(filepath.Join(destDir, "data", "dir.txt"), 0755)
+	os.WriteFile(filepath.Join(sourceDir, "data", "dir.txt"), []byte("x"), 0644)
+	fc = NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFilesが失敗しました: %v", err)
+	}
+	if fc.GetStats().GetIgnoredCount() != 1 || fc.GetStats().GetFailedCount() != 1 {
+		t.Errorf("無視した数 = %d, 失敗数 = %d; want 1, 1", fc.GetStats().GetIgnoredCount(), fc.GetStats().GetFailedCount())
+	}
+}
+
+func TestCopyFiles_SkipNewer(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(sourceDir, 0755)
+	os.MkdirAll(destDir, 0755)
+
+	old := time.Now().Add(-time.Hour)
+	os.WriteFile(filepath.Join(sourceDir, "edited.txt"), []byte("source"), 0644)
+	os.Chtimes(filepath.Join(sourceDir, "edited.txt"), old, old)
+	os.WriteFile(filepath.Join(destDir, "edited.txt"), []byte("edited at destination"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "stale.txt"), []byte("new"), 0644)
+	os.WriteFile(filepath.Join(destDir, "stale.txt"), []byte("old"), 0644)
+	os.Chtimes(filepath.Join(destDir, "stale.txt"), old, old)
+
+	options := DefaultOptions()
+	options.SkipNewer = true
+	fc := NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFilesが失敗しました: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(destDir, "edited.txt")); string(data) != "edited at destination" {
+		t.Errorf("宛先の方が新しいファイルが上書きされました: %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(destDir, "stale.txt")); string(data) != "new" {
+		t.Errorf("宛先の方が古いファイルが上書きされていません: %q", data)
+	}
+	st := fc.GetStats()
+	if st.GetConflictedCount() != 1 || st.GetSkippedCount() != 1 || st.GetFailedCount() != 0 {
+		t.Errorf("衝突 = %d, スキップ = %d, 失敗 = %d; want 1, 1, 0", st.GetConflictedCount(), st.GetSkippedCount(), st.GetFailedCount())
+	}
+	if conflicts := fc.GetConflicts(); len(conflicts) != 1 || conflicts[0].Path != "edited.txt" {
+		t.Errorf("衝突したファイル = %+v", conflicts)
+	}
+
+	// 衝突をエラーとする場合は失敗として数える
+	options.Conflict = ConflictError
+	fc = NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	fc.CopyFiles()
+	if st := fc.GetStats(); st.GetConflictedCount() != 1 || st.GetFailedCount() != 1 {
+		t.Errorf("衝突 = %d, 失敗 = %d; want 1, 1", st.GetConflictedCount(), st.GetFailedCount())
+	}
+	if failures := fc.GetFailures(); len(failures) != 1 || failures[0].Path != "edited.txt" {
+		t.Errorf("失敗したファイル = %+v", failures)
+	}
+
+	// 無効な場合は従来どおり上書きする
+	fc = NewFileCopier(sourceDir, destDir, DefaultOptions(), nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFilesが失敗しました: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(destDir, "edited.txt")); string(data) != "source" {
+		t.Errorf("SkipNewerが無効なのに上書きされていません: %q", data)
+	}
+}
+
+func TestCopyFiles_StructureOnly(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(filepath.Join(sourceDir, "sub", "empty"), 0755)
+	os.WriteFile(filepath.Join(sourceDir, "sub", "data.bin"), bytes.Repeat([]byte("x"), 4096), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "keep.txt"), []byte("source"), 0644)
+	os.MkdirAll(destDir, 0755)
+	os.WriteFile(filepath.Join(destDir, "keep.txt"), []byte("existing"), 0644)
+
+	for _, tc := range []struct {
+		files StructureFiles
+		size  int64
+	}{
+		{StructureSized, 4096},
+		{StructureEmpty, 0},
+	} {
+		t.Run(string(tc.files), func(t *testing.T) {
+			os.RemoveAll(filepath.Join(destDir, "sub"))
+
+			options := DefaultOptions()
+			options.StructureOnly = true
+			options.StructureFiles = tc.files
+			fc := NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+			if err := fc.CopyFiles(); err != nil {
+				t.Fatalf("CopyFilesが失敗しました: %v", err)
+			}
+
+			if _, err := os.Stat(filepath.Join(destDir, "sub", "empty")); err != nil {
+				t.Errorf("空のディレクトリが作成されていません: %v", err)
+			}
+			info, err := os.Stat(filepath.Join(destDir, "sub", "data.bin"))
+			if err != nil {
+				t.Fatalf("ファイルが作成されていません: %v", err)
+			}
+			if info.Size() != tc.size {
+				t.Errorf("サイズ = %d, want %d", info.Size(), tc.size)
+			}
+			if data, _ := os.ReadFile(filepath.Join(destDir, "sub", "data.bin")); bytes.Contains(data, []byte("x")) {
+				t.Error("ファイルの内容がコピーされました")
+			}
+			if !info.ModTime().Equal(placeholderTime) {
+				t.Errorf("更新日時 = %v, want %v", info.ModTime(), placeholderTime)
+			}
+			if data, _ := os.ReadFile(filepath.Join(destDir, "keep.txt")); string(data) != "existing" {
+				t.Errorf("既存のファイルが変更されました: %q", data)
+			}
+			if st := fc.GetStats(); st.GetCopiedCount() != 1 || st.GetSkippedCount() != 1 || st.GetCopiedBytes() != 0 {
+				t.Errorf("コピー = %d, スキップ = %d, バイト数 = %d; want 1, 1, 0", st.GetCopiedCount(), st.GetSkippedCount(), st.GetCopiedBytes())
+			}
+		})
+	}
+
+	// 後続の通常のコピーでは内容がコピーされる
+	fc := NewFileCopier(sourceDir, destDir, DefaultOptions(), nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFilesが失敗しました: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(destDir, "sub", "data.bin")); !bytes.Equal(data, bytes.Repeat([]byte("x"), 4096)) {
+		t.Error("構造のみ作成したファイルに内容がコピーされていません")
+	}
+}
+
+func TestCopyFiles_FaultInject(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(sourceDir, 0755)
+	os.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("content"), 0644)
+
+	// 読み込みエラーはリトライしても発生し続けるため失敗として記録される
+	faults, err := faultinject.Parse("read-error=1,seed=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := DefaultOptions()
+	options.MaxRetries = 1
+	options.RetryDelay = time.Millisecond
+	options.Faults = faults
+	fc := NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	fc.CopyFiles()
+
+	failures := fc.GetFailures()
+	if len(failures) != 1 || !errors.Is(failures[0].Err, faultinject.ErrInjected) {
+		t.Fatalf("失敗したファイル = %+v", failures)
+	}
+	if c := faults.Counts(); c.ReadErrors != 2 {
+		t.Errorf("読み込みエラーの件数 = %d, want 2（初回とリトライ）", c.ReadErrors)
+	}
+
+	// ハッシュ不一致は検証の失敗として記録される
+	faults, _ = faultinject.Parse("hash-mismatch=1,seed=1")
+	options = DefaultOptions()
+	options.MaxRetries = 0
+	options.Mode = ModeCopyAndVerify
+	options.Faults = faults
+	os.RemoveAll(destDir)
+	fc = NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	fc.CopyFiles()
+
+	failures = fc.GetFailures()
+	if len(failures) != 1 || !errors.Is(failures[0].Err, errcode.ErrHashMismatch) {
+		t.Errorf("失敗したファイル = %+v", failures)
+	}
+}
+
+func TestCopyFiles_MemFS(t *testing.T) {
+	mem := vfs.NewMem()
+	sourceDir := filepath.Join("/", "source")
+	destDir := filepath.Join("/", "dest")
+	mem.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("same content"), 0644)
+	mem.WriteFile(filepath.Join(sourceDir, "sub", "b.txt"), []byte("same content"), 0600)
+	mem.WriteFile(filepath.Join(sourceDir, "sub", "c.txt"), []byte("other"), 0644)
+
+	options := DefaultOptions()
+	options.FS = mem
+	options.Mode = ModeCopyAndVerify
+	options.PreservePermissions = true
+	fc := NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFiles() エラー: %v", err)
+	}
+	if copied := fc.GetStats().GetCopiedCount(); copied != 3 {
+		t.Errorf("コピー件数 = %d, want 3", copied)
+	}
+
+	data, err := mem.ReadFile(filepath.Join(destDir, "sub", "b.txt"))
+	if err != nil || string(data) != "same content" {
+		t.Errorf("宛先の内容 = %q, %v", data, err)
+	}
+	sourceInfo, _ := mem.Stat(filepath.Join(sourceDir, "sub", "b.txt"))
+	destInfo, _ := mem.Stat(filepath.Join(destDir, "sub", "b.txt"))
+	if !destInfo.ModTime().Equal(sourceInfo.ModTime()) || destInfo.Mode().Perm() != 0600 {
+		t.Errorf("宛先のファイル情報 = %v %v", destInfo.ModTime(), destInfo.Mode())
+	}
+	if _, err := os.Stat(destDir); !os.IsNotExist(err) {
+		t.Errorf("実際のディスクに宛先が作成されました: %v", err)
+	}
+
+	// 再実行時は更新日時とサイズが一致するためスキップされる
+	fc = NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFiles() エラー: %v", err)
+	}
+	if skipped := fc.GetStats().GetSkippedCount(); skipped != 3 {
+		t.Errorf("スキップ件数 = %d, want 3", skipped)
+	}
+}
+
+// createOrderFS は宛先のファイルを作成した順序を記録するファイルシステム
+type createOrderFS struct {
+	vfs.FS
+	mu      sync.Mutex
+	created []string
+}
+
+func (c *createOrderFS) Create(name string) (vfs.File, error) {
+	c.mu.Lock()
+	c.created = append(c.created, filepath.Base(name))
+	c.mu.Unlock()
+	return c.FS.Create(name)
+}
+
+func TestCopyFiles_NewestFirst(t *testing.T) {
+	mem := vfs.NewMem()
+	sourceDir := filepath.Join("/", "source")
+	destDir := filepath.Join("/", "dest")
+	now := time.Now().Truncate(time.Second)
+	for name, age := range map[string]time.Duration{
+		"a/old.txt":    72 * time.Hour,
+		"a/newest.txt": time.Minute,
+		"b/recent.txt": time.Hour,
+		"middle.txt":   24 * time.Hour,
+	} {
+		path := filepath.Join(sourceDir, filepath.FromSlash(name))
+		mem.WriteFile(path, []byte(name), 0644)
+		mem.Chtimes(path, now.Add(-age), now.Add(-age))
+	}
+
+	// ワーカーが1つの場合はコピーを開始する順序が更新日時の新しい順になる（ディレクトリをまたいで並べる）
+	fsys := &createOrderFS{FS: mem}
+	options := DefaultOptions()
+	options.FS = fsys
+	options.MaxConcurrent = 1
+	options.VerifyHash = false
+	options.CopyOrder = OrderNewestFirst
+	fc := NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFiles() エラー: %v", err)
+	}
+	if copied := fc.GetStats().GetCopiedCount(); copied != 4 {
+		t.Errorf("コピー件数 = %d, want 4", copied)
+	}
+	want := []string{"newest.txt", "recent.txt", "middle.txt", "old.txt"}
+	if fmt.Sprint(fsys.created) != fmt.Sprint(want) {
+		t.Errorf("コピーした順序 = %v, want %v", fsys.created, want)
+	}
+	if queued := fc.GetStats().GetQueued(); queued != 0 {
+		t.Errorf("コピーを待つファイル数 = %d, want 0", queued)
+	}
+
+	if _, err := ParseCopyOrder("largest-first"); err == nil {
+		t.Error("不明な順序がエラーになりません")
+	}
+}
+
+func TestCopyFiles_AuditLog(t *testing.T) {
+	mem := vfs.NewMem()
+	sourceDir := filepath.Join("/", "source")
+	destDir := filepath.Join("/", "dest")
+	mem.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("content"), 0644)
+	mem.WriteFile(filepath.Join(sourceDir, "sub", "b.txt"), []byte("other"), 0644)
+
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditLog, err := audit.Open(auditPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	options := DefaultOptions()
+	options.FS = mem
+	options.Mode = ModeCopyAndVerify
+	options.Audit = auditLog
+	fc := NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFiles() エラー: %v", err)
+	}
+	fc = NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFiles() エラー: %v", err)
+	}
+	if err := auditLog.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(auditPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if count, err := audit.Verify(file); err != nil || count != 8 {
+		t.Fatalf("audit.Verify() = %d, %v, want 8", count, err)
+	}
+
+	// コピーと検証、再実行時のスキップと検証が1件ずつ記録される
+	file.Seek(0, io.SeekStart)
+	counts := map[string]int{}
+	decoder := json.NewDecoder(file)
+	for decoder.More() {
+		var rec audit.Record
+		if err := decoder.Decode(&rec); err != nil {
+			t.Fatal(err)
+		}
+		counts[rec.Action+"/"+rec.Result]++
+		if rec.Action == audit.ActionVerify && (rec.SourceHash == "" || rec.HashAlgo != options.HashAlgorithm) {
+			t.Errorf("検証のレコードにハッシュがありません: %+v", rec)
+		}
+	}
+	want := map[string]int{"copy/success": 2, "skip/success": 2, "verify/matched": 4}
+	for key, n := range want {
+		if counts[key] != n {
+			t.Errorf("%s = %d件, want %d件 (%v)", key, counts[key], n, counts)
+		}
+	}
+}
+
+func TestCopyFiles_PreserveTimes(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(sourceDir, 0755)
+	sourceFile := filepath.Join(sourceDir, "a.txt")
+	os.WriteFile(sourceFile, []byte("content"), 0644)
+
+	modTime := time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC)
+	atime := time.Date(2024, 6, 7, 8, 9, 10, 987654321, time.UTC)
+	os.Chtimes(sourceFile, atime, modTime)
+	info, _ := os.Stat(sourceFile)
+	if !info.ModTime().Equal(modTime) {
+		t.Skipf("ファイルシステムがナノ秒の更新日時に対応していません: %v", info.ModTime())
+	}
+
+	syncDB, err := database.NewSyncDB(filepath.Join(tempDir, "test.db"), database.NormalSync)
+	if err != nil {
+		t.Fatalf("DBの作成に失敗: %v", err)
+	}
+	defer syncDB.Close()
+
+	options := DefaultOptions()
+	options.PreserveAtime = true
+	fc := NewFileCopier(sourceDir, destDir, options, nil, syncDB, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFiles() error = %v", err)
+	}
+	if copied := fc.GetStats().GetCopiedCount(); copied != 1 {
+		t.Fatalf("コピー件数 = %d, want 1 (失敗: %v)", copied, fc.GetFailures())
+	}
+
+	// 更新日時はナノ秒まで保持し、DBにも記録する
+	destInfo, _ := os.Stat(filepath.Join(destDir, "a.txt"))
+	if !destInfo.ModTime().Equal(modTime) {
+		t.Errorf("宛先の更新日時 = %v, want %v", destInfo.ModTime(), modTime)
+	}
+	record, _ := syncDB.GetFile("a.txt")
+	if record == nil || !record.ModTime.Equal(modTime) {
+		t.Fatalf("DBの記録 = %+v", record)
+	}
+
+	// アクセス日時は取得できる環境でのみ保持する（宛先を読み込む検証はアクセス日時を更新することがあるため行わない）
+	if want, ok := fsmeta.AccessTime(info); ok {
+		if got, _ := fsmeta.AccessTime(destInfo); !got.Equal(want) {
+			t.Errorf("宛先のアクセス日時 = %v, want %v", got, want)
+		}
+		if record.AccessTime == nil || !record.AccessTime.Equal(want) {
+			t.Errorf("DBのアクセス日時 = %v, want %v", record.AccessTime, want)
+		}
+	}
+
+	// コピーと同時の検証では、設定した更新日時をナノ秒の精度で比較する
+	options.VerifyHash = true
+	options.Mode = ModeCopyAndVerify
+	options.ModTimePrecision = time.Nanosecond
+	fc = NewFileCopier(sourceDir, filepath.Join(tempDir, "dest2"), options, nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFiles() error = %v", err)
+	}
+	if summary := fc.GetVerificationSummary(); summary.Matched != 1 {
+		t.Errorf("検証結果 = %+v (失敗: %v)", summary, fc.GetFailures())
+	}
+}
+
+func TestCopyFiles_StampXattr(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(sourceDir, 0755)
+	os.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("content"), 0644)
+
+	options := DefaultOptions()
+	options.StampXattr = true
+	fc := NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFiles() error = %v", err)
+	}
+
+	destFile := filepath.Join(destDir, "a.txt")
+	stamp, err := fsmeta.ReadStamp(destFile)
+	if err != nil {
+		t.Fatalf("ReadStamp() error = %v", err)
+	}
+	if stamp == nil {
+		t.Skip("宛先のファイルシステムがハッシュの記録に対応していません")
+	}
+	want, _ := hasher.NewHasher(hasher.Algorithm(options.HashAlgorithm), 0).HashFile(destFile)
+	if stamp.Algorithm != options.HashAlgorithm || stamp.Hash != want || stamp.Size != 7 {
+		t.Errorf("記録 = %+v, want hash %s", stamp, want)
+	}
+
+	// 記録しても宛先の更新日時は変更しない
+	sourceInfo, _ := os.Stat(filepath.Join(sourceDir, "a.txt"))
+	destInfo, _ := os.Stat(destFile)
+	if !destInfo.ModTime().Equal(sourceInfo.ModTime()) {
+		t.Errorf("宛先の更新日時 = %v, want %v", destInfo.ModTime(), sourceInfo.ModTime())
+	}
+}
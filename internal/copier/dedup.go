@@ -0,0 +1,193 @@
+package copier
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/transform"
+)
+
+// DefaultDedupMaxFileSize はキャッシュの対象とするファイルサイズの上限のデフォルト値
+const DefaultDedupMaxFileSize = 1024 * 1024 // 1MB
+
+// DedupStats は内容のキャッシュの利用状況を表す構造体
+type DedupStats struct {
+	Hits       int64 // キャッシュから書き込んだファイル数
+	BytesSaved int64 // DBに記録されたハッシュでキャッシュから書き込んだことで読み込まずに済んだバイト数
+}
+
+// contentCache はハッシュをキーとしてファイルの内容を保持する、合計サイズの上限付きのLRUキャッシュ
+type contentCache struct {
+	mu      sync.Mutex
+	limit   int64
+	size    int64
+	order   *list.List // 先頭が最近使用したもの
+	entries map[string]*list.Element
+}
+
+// cacheEntry はキャッシュの1件
+type cacheEntry struct {
+	hash string
+	data []byte
+}
+
+// newContentCache は合計サイズがlimitバイトまでのキャッシュを作成する
+func newContentCache(limit int64) *contentCache {
+	return &contentCache{
+		limit:   limit,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// get はハッシュに対応する内容を返す
+func (c *contentCache) get(hash string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[hash]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).data, true
+}
+
+// add は内容を追加し、上限を超えた分を古いものから削除する
+func (c *contentCache) add(hash string, data []byte) {
+	if int64(len(data)) > c.limit {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[hash]; ok {
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[hash] = c.order.PushFront(&cacheEntry{hash: hash, data: data})
+	c.size += int64(len(data))
+
+	for c.size > c.limit {
+		oldest := c.order.Back()
+		entry := oldest.Value.(*cacheEntry)
+		c.order.Remove(oldest)
+		delete(c.entries, entry.hash)
+		c.size -= int64(len(entry.data))
+	}
+}
+
+// dedupEligible は内容のキャッシュを使用してコピーするファイルかどうかを返す
+// DBの記録の有無によらず、上限以下の大きさのファイルはキャッシュを使用する
+func (fc *FileCopier) dedupEligible(sourceInfo os.FileInfo, transformers []transform.Transformer) bool {
+	return fc.cache != nil && len(transformers) == 0 && !fc.segmented(sourceInfo) &&
+		sourceInfo.Size() <= fc.options.DedupMaxFileSize
+}
+
+// recordedHash はDBに記録されたソースのハッシュを返す（読み込む前にキャッシュを探すために使用する）
+// サイズと更新日時が記録と一致しない場合は内容が変わっている可能性があるため使用しない
+func (fc *FileCopier) recordedHash(sourceInfo os.FileInfo, record *database.FileInfo) string {
+	if record == nil || record.SourceHash == "" {
+		return ""
+	}
+	if record.HashAlgo != "" && record.HashAlgo != fc.options.HashAlgorithm {
+		return ""
+	}
+	if record.Size != sourceInfo.Size() || !record.ModTime.Equal(sourceInfo.ModTime()) {
+		return ""
+	}
+	return record.SourceHash
+}
+
+// copyFromCache はキャッシュを使用してファイルをコピーし、ソースの内容のハッシュを返す
+// （内容を取得した後に書き込みに失敗した場合もハッシュを返す）
+// DBに記録されたハッシュ（recorded）の内容がキャッシュにあればソースを読み込まずに書き込む。
+// そうでなければソースを1回だけ読み込んでハッシュを計算し、同じ内容を読み込み済みであればキャッシュの内容を共有し、
+// 初めての内容であればハッシュをキーとしてキャッシュに追加してから書き込む
+func (fc *FileCopier) copyFromCache(sourcePath, destPath string, sourceInfo os.FileInfo, recorded string) (string, error) {
+	hash := recorded
+	var data []byte
+	hit := false
+	if recorded != "" {
+		data, hit = fc.cache.get(recorded)
+	}
+	if hit {
+		atomic.AddInt64(&fc.dedup.Hits, 1)
+		atomic.AddInt64(&fc.dedup.BytesSaved, int64(len(data)))
+	} else {
+		var err error
+		if data, err = fc.readSourceContent(sourcePath); err != nil {
+			return "", err
+		}
+		h, err := fc.hasher.NewHash()
+		if err != nil {
+			return "", err
+		}
+		h.Write(data)
+		hash = hex.EncodeToString(h.Sum(nil))
+		if cached, ok := fc.cache.get(hash); ok {
+			data = cached
+			atomic.AddInt64(&fc.dedup.Hits, 1)
+		} else {
+			fc.cache.add(hash, data)
+		}
+	}
+
+	destFile, err := fc.createDest(destPath)
+	if err != nil {
+		return hash, fmt.Errorf("宛先ファイル(%s)を作成できません: %w", destPath, err)
+	}
+	defer destFile.Close()
+
+	reader := &throttledReader{ctx: fc.ctx, reader: bytes.NewReader(data), throttle: fc.throttle}
+	if _, err := io.Copy(destFile, reader); err != nil {
+		return hash, fmt.Errorf("ファイルコピーエラー: %w", err)
+	}
+	if err := destFile.Close(); err != nil {
+		return hash, fmt.Errorf("宛先ファイル(%s)を閉じられません: %w", destPath, err)
+	}
+
+	if fc.options.PreserveModTime {
+		if err := fc.copyTimes(destPath, sourceInfo); err != nil {
+			return hash, fmt.Errorf("更新日時の設定エラー: %w", err)
+		}
+	}
+	if fc.setsPermissions() {
+		if err := fc.setPermissions(sourcePath, destPath, false); err != nil {
+			return hash, fmt.Errorf("アクセス権の設定エラー: %w", err)
+		}
+	}
+	return hash, nil
+}
+
+// readSourceContent はソースファイルの内容をすべて読み込む
+func (fc *FileCopier) readSourceContent(sourcePath string) ([]byte, error) {
+	sourceFile, err := fc.openSource(sourcePath)
+	if err != nil {
+		return nil, errcode.Errorf(errcode.ErrSourceRead, "ソースファイル(%s)を開けません: %w", sourcePath, err)
+	}
+	defer sourceFile.Close()
+
+	data, err := io.ReadAll(fc.sourceReader(sourceFile))
+	if err != nil {
+		return nil, fmt.Errorf("ファイルコピーエラー: %w", err)
+	}
+	return data, nil
+}
+
+// GetDedupStats は内容のキャッシュの利用状況を返す
+func (fc *FileCopier) GetDedupStats() DedupStats {
+	return DedupStats{
+		Hits:       atomic.LoadInt64(&fc.dedup.Hits),
+		BytesSaved: atomic.LoadInt64(&fc.dedup.BytesSaved),
+	}
+}
@@ -0,0 +1,164 @@
+package copier
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/seeder"
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+func TestContentCache(t *testing.T) {
+	c := newContentCache(10)
+	c.add("a", []byte("aaaa"))
+	c.add("b", []byte("bbbb"))
+	if _, ok := c.get("a"); !ok {
+		t.Fatal("aがキャッシュにありません")
+	}
+
+	// 上限を超えると最も古く使用したもの（b）から削除する
+	c.add("c", []byte("cccc"))
+	if _, ok := c.get("b"); ok {
+		t.Error("bが削除されていません")
+	}
+	if data, ok := c.get("a"); !ok || string(data) != "aaaa" {
+		t.Errorf("a = %q, %v", data, ok)
+	}
+	if _, ok := c.get("c"); !ok {
+		t.Error("cがキャッシュにありません")
+	}
+
+	// 上限より大きい内容は追加しない
+	c.add("d", bytes.Repeat([]byte("d"), 11))
+	if _, ok := c.get("d"); ok {
+		t.Error("上限より大きい内容が追加されました")
+	}
+	if c.size != 8 {
+		t.Errorf("size = %d, want 8", c.size)
+	}
+}
+
+func TestCopyFiles_DedupCache(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+
+	content := []byte("module.exports = require('./lib');\n")
+	for i := 0; i < 5; i++ {
+		dir := filepath.Join(sourceDir, fmt.Sprintf("pkg%d", i))
+		os.MkdirAll(dir, 0755)
+		os.WriteFile(filepath.Join(dir, "index.js"), content, 0644)
+	}
+	os.WriteFile(filepath.Join(sourceDir, "other.js"), []byte("other"), 0644)
+
+	syncDB, err := database.NewSyncDB(filepath.Join(tempDir, "test.db"), database.NormalSync)
+	if err != nil {
+		t.Fatalf("DBの作成に失敗: %v", err)
+	}
+	defer syncDB.Close()
+	if _, err := seeder.NewSeeder(sourceDir, seeder.DefaultOptions(), nil, syncDB, nil).Run(); err != nil {
+		t.Fatalf("seedが失敗しました: %v", err)
+	}
+
+	// 記録の後に内容が変わったファイル（サイズが異なるため記録のハッシュは使用しない）
+	os.WriteFile(filepath.Join(sourceDir, "pkg4", "index.js"), []byte("changed"), 0644)
+
+	options := DefaultOptions()
+	options.MaxConcurrent = 1
+	options.DedupCacheSize = 1024 * 1024
+	fc := NewFileCopier(sourceDir, destDir, options, nil, syncDB, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFilesが失敗しました: %v", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		got, _ := os.ReadFile(filepath.Join(destDir, fmt.Sprintf("pkg%d", i), "index.js"))
+		if !bytes.Equal(got, content) {
+			t.Errorf("pkg%d/index.js = %q", i, got)
+		}
+	}
+	if got, _ := os.ReadFile(filepath.Join(destDir, "pkg4", "index.js")); string(got) != "changed" {
+		t.Errorf("pkg4/index.js = %q, want %q", got, "changed")
+	}
+	if got, _ := os.ReadFile(filepath.Join(destDir, "other.js")); string(got) != "other" {
+		t.Errorf("other.js = %q", got)
+	}
+
+	dedup := fc.GetDedupStats()
+	if dedup.Hits != 3 || dedup.BytesSaved != int64(3*len(content)) {
+		t.Errorf("キャッシュの利用状況 = %+v, want 3件, %d bytes", dedup, 3*len(content))
+	}
+
+	// キャッシュから書き込んだファイルもハッシュの記録を保持する
+	record, err := syncDB.GetFile("pkg1/index.js")
+	if err != nil {
+		t.Fatalf("DBの読み込みエラー: %v", err)
+	}
+	if record.Status != database.StatusSuccess || record.SourceHash == "" {
+		t.Errorf("記録 = %+v", record)
+	}
+}
+
+// TestCopyFiles_DedupCacheFresh はDBに記録のない初回のコピーでも、内容のハッシュで同じ内容のファイルを
+// キャッシュから書き込み、各ファイルをソースから1回だけ読み込むことをテスト
+func TestCopyFiles_DedupCacheFresh(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+
+	content := []byte("module.exports = require('./lib');\n")
+	for i := 0; i < 5; i++ {
+		dir := filepath.Join(sourceDir, fmt.Sprintf("pkg%d", i))
+		os.MkdirAll(dir, 0755)
+		os.WriteFile(filepath.Join(dir, "index.js"), content, 0644)
+	}
+	os.WriteFile(filepath.Join(sourceDir, "other.js"), []byte("other"), 0644)
+
+	syncDB, err := database.NewSyncDB(filepath.Join(tempDir, "test.db"), database.NormalSync)
+	if err != nil {
+		t.Fatalf("DBの作成に失敗: %v", err)
+	}
+	defer syncDB.Close()
+
+	fsys := &flakyFS{FS: vfs.OS}
+	options := DefaultOptions()
+	options.MaxConcurrent = 1
+	options.DedupCacheSize = 1024 * 1024
+	options.FS = fsys
+	fc := NewFileCopier(sourceDir, destDir, options, nil, syncDB, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFilesが失敗しました: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		got, _ := os.ReadFile(filepath.Join(destDir, fmt.Sprintf("pkg%d", i), "index.js"))
+		if !bytes.Equal(got, content) {
+			t.Errorf("pkg%d/index.js = %q", i, got)
+		}
+	}
+
+	// ハッシュの計算のために読み込み直さず、各ファイルを1回だけ開く
+	if len(fsys.opened) != 6 {
+		t.Errorf("ソースを開いた回数 = %d (%v), want 6", len(fsys.opened), fsys.opened)
+	}
+	// 同じ内容は1件としてキャッシュに保持し、2件目以降はキャッシュの内容から書き込む
+	if dedup := fc.GetDedupStats(); dedup.Hits != 4 || dedup.BytesSaved != 0 {
+		t.Errorf("キャッシュの利用状況 = %+v, want 4件, 0 bytes", dedup)
+	}
+	if want := int64(len(content) + len("other")); fc.cache.size != want {
+		t.Errorf("キャッシュの合計サイズ = %d, want %d", fc.cache.size, want)
+	}
+
+	// 次回はキャッシュを読み込む前に探せるよう、内容のハッシュを記録する
+	record, err := syncDB.GetFile("pkg3/index.js")
+	if err != nil {
+		t.Fatalf("DBの読み込みエラー: %v", err)
+	}
+	if record == nil || record.SourceHash == "" || record.HashAlgo != options.HashAlgorithm {
+		t.Errorf("記録 = %+v", record)
+	}
+}
@@ -0,0 +1,96 @@
+package copier
+
+import (
+	"sync"
+	"time"
+)
+
+// deferredRetries は失敗したため後回しにして再試行するファイルの記録
+type deferredRetries struct {
+	mu      sync.Mutex
+	pending []deferredFile // 次の再試行で再試行するファイル
+	pass    int            // 実行中の再試行の回数（0は最初のコピー）
+	done    bool           // 再試行を終えた（以降に失敗したファイルは後回しにしない）
+}
+
+// deferRetry は失敗したファイルを、他のファイルのコピーが終わった後の再試行の対象として記録する
+// 再試行を後回しにしない設定の場合、再試行の回数を使い切った場合、キャンセルされた場合、共有違反の場合はfalseを返す
+func (fc *FileCopier) deferRetry(relPath, sourcePath, destPath string, err error) bool {
+	if !fc.options.DeferRetries || fc.ctx.Err() != nil || sharingViolation(err) {
+		return false
+	}
+	fc.retryQueue.mu.Lock()
+	defer fc.retryQueue.mu.Unlock()
+	if fc.retryQueue.done || fc.retryQueue.pass >= fc.options.MaxRetries {
+		return false
+	}
+	fc.retryQueue.pending = append(fc.retryQueue.pending, deferredFile{
+		relPath:    relPath,
+		sourcePath: sourcePath,
+		destPath:   destPath,
+		err:        err,
+	})
+	if fc.logger != nil {
+		if fc.logger.Verbose {
+			fc.logger.Warn("ファイル '%s' のコピーに失敗したため後で再試行します: %v", relPath, err)
+		} else {
+			fc.logger.Warn("ファイル '%s' のコピーに失敗したため後で再試行します", relPath)
+		}
+	}
+	return true
+}
+
+// retryDeferred は後回しにしたファイルを、他のファイルのコピーがすべて終わった後に最大MaxRetries回まで再試行する
+// 各回の再試行で再び失敗したファイルは次の回に再試行し、最後の回で失敗した場合に失敗として数える
+// キャンセルされた場合は再試行せず、失敗として記録する
+// （後回しにした時点で書き込み途中の宛先とジャーナルの記録はendCopyで削除している）
+func (fc *FileCopier) retryDeferred() {
+	defer func() {
+		fc.retryQueue.mu.Lock()
+		fc.retryQueue.done = true
+		fc.retryQueue.mu.Unlock()
+	}()
+
+	for pass := 1; pass <= fc.options.MaxRetries; pass++ {
+		fc.retryQueue.mu.Lock()
+		pending := fc.retryQueue.pending
+		fc.retryQueue.pending = nil
+		fc.retryQueue.pass = pass
+		fc.retryQueue.mu.Unlock()
+
+		if len(pending) == 0 {
+			return
+		}
+
+		// 再試行前に遅延（キャンセルされた場合は中断）
+		if fc.ctx.Err() == nil {
+			if fc.logger != nil {
+				fc.logger.Info("失敗したファイルを再試行します (%d/%d): %d件", pass, fc.options.MaxRetries, len(pending))
+			}
+			select {
+			case <-time.After(fc.options.RetryDelay):
+			case <-fc.ctx.Done():
+			}
+		}
+		if fc.ctx.Err() != nil {
+			for _, f := range pending {
+				fc.countFailed(f.relPath, f.err)
+				fc.stats.RecordError(f.relPath, f.err)
+				fc.recordFailure(f.relPath, f.err)
+			}
+			return
+		}
+
+		for _, f := range pending {
+			fc.copyAsync(f.sourcePath, f.destPath)
+		}
+		fc.wg.Wait()
+	}
+}
+
+// deferredPass は実行中の後回しにした再試行の回数を返す（最初のコピーの場合は0）
+func (fc *FileCopier) deferredPass() int {
+	fc.retryQueue.mu.Lock()
+	defer fc.retryQueue.mu.Unlock()
+	return fc.retryQueue.pass
+}
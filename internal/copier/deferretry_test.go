@@ -0,0 +1,89 @@
+package copier
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+// flakyFS は指定した回数だけファイルを開けず、開いた順序を記録するファイルシステム
+type flakyFS struct {
+	vfs.FS
+	mu     sync.Mutex
+	fails  map[string]int // パスごとの開けない残りの回数
+	opened []string
+}
+
+func (f *flakyFS) Open(name string) (vfs.File, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.opened = append(f.opened, filepath.Base(name))
+	if f.fails[name] > 0 {
+		f.fails[name]--
+		return nil, errors.New("一時的な読み込みエラー")
+	}
+	return f.FS.Open(name)
+}
+
+func newFlakyFS(sourceDir string, fails int) *flakyFS {
+	mem := vfs.NewMem()
+	mem.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("aaa"), 0644)
+	mem.WriteFile(filepath.Join(sourceDir, "b.txt"), []byte("bbb"), 0644)
+	mem.WriteFile(filepath.Join(sourceDir, "0flaky.txt"), []byte("flaky"), 0644)
+	return &flakyFS{FS: mem, fails: map[string]int{filepath.Join(sourceDir, "0flaky.txt"): fails}}
+}
+
+func TestCopyFiles_DeferRetries(t *testing.T) {
+	sourceDir := filepath.Join("/", "source")
+	destDir := filepath.Join("/", "dest")
+
+	options := DefaultOptions()
+	options.MaxConcurrent = 1
+	options.MaxRetries = 3
+	options.RetryDelay = 0
+	options.DeferRetries = true
+
+	// 失敗したファイルは他のファイルのコピーが終わった後に再試行する
+	fs := newFlakyFS(sourceDir, 2)
+	options.FS = fs
+	fc := NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFilesが失敗しました: %v", err)
+	}
+	if fc.stats.GetCopiedCount() != 3 || fc.stats.GetFailedCount() != 0 {
+		t.Errorf("コピー = %d, 失敗 = %d", fc.stats.GetCopiedCount(), fc.stats.GetFailedCount())
+	}
+	// 失敗したファイルは、他のファイルをすべてコピーしてから再試行する
+	var attempts []int
+	others := 0
+	for i, name := range fs.opened {
+		if name == "0flaky.txt" {
+			attempts = append(attempts, i)
+		} else if len(attempts) < 2 {
+			others++
+		}
+	}
+	if len(attempts) != 3 || others != 2 {
+		t.Errorf("開いた順序 = %v", fs.opened)
+	}
+
+	// 再試行の回数を使い切った場合は失敗として数える
+	fs = newFlakyFS(sourceDir, 100)
+	options.FS = fs
+	fc = NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFilesが失敗しました: %v", err)
+	}
+	if fc.stats.GetFailedCount() != 1 {
+		t.Errorf("失敗 = %d, want 1", fc.stats.GetFailedCount())
+	}
+	if remaining := fs.fails[filepath.Join(sourceDir, "0flaky.txt")]; remaining != 100-(options.MaxRetries+1) {
+		t.Errorf("開こうとした回数 = %d, want %d", 100-remaining, options.MaxRetries+1)
+	}
+	if failures := fc.GetFailures(); len(failures) != 1 || failures[0].Path != "0flaky.txt" {
+		t.Errorf("失敗したファイル = %+v", failures)
+	}
+}
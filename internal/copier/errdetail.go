@@ -0,0 +1,30 @@
+package copier
+
+import (
+	"io"
+
+	"github.com/sakuhanight/gopier/internal/errcode"
+)
+
+// sourceErrReader はソースの読み込みエラーにerrcode.ErrSourceReadの種類を付けるReader
+// 読み込みと書き込みのエラーはどちらもio.Copyから返るため、エラーコードを判定できるよう読み込み側で区別する
+type sourceErrReader struct {
+	reader io.Reader
+}
+
+func (r sourceErrReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if err != nil && err != io.EOF {
+		err = errcode.Wrap(errcode.ErrSourceRead, err)
+	}
+	return n, err
+}
+
+// destError はエラーコードを判定できないファイル操作のエラーに、宛先への書き込みの失敗を表す種類を付ける
+// ソースの読み込みのエラーは読み込み時に種類を付けるため、残りのファイル操作のエラーは宛先で発生したものとして扱う
+func destError(err error) error {
+	if errcode.CatalogOf(err) != errcode.EUnknown || !errcode.IsOSError(err) {
+		return err
+	}
+	return errcode.Wrap(errcode.ErrDestWrite, err)
+}
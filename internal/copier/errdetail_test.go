@@ -0,0 +1,65 @@
+package copier
+
+import (
+	"io/fs"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+// eioFS はソースのファイルを開くとEIOを返すファイルシステム
+type eioFS struct {
+	vfs.FS
+	source string
+}
+
+func (f *eioFS) Open(name string) (vfs.File, error) {
+	if name == f.source {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: syscall.EIO}
+	}
+	return f.FS.Open(name)
+}
+
+func TestCopyFiles_ErrorDetail(t *testing.T) {
+	sourceDir := filepath.Join("/", "source")
+	destDir := filepath.Join("/", "dest")
+	mem := vfs.NewMem()
+	mem.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("aaa"), 0644)
+
+	syncDB, err := database.NewSyncDB(filepath.Join(t.TempDir(), "test.db"), database.NormalSync)
+	if err != nil {
+		t.Fatalf("データベースの作成に失敗: %v", err)
+	}
+	defer syncDB.Close()
+
+	options := DefaultOptions()
+	options.MaxRetries = 2
+	options.RetryDelay = 0
+	options.FS = &eioFS{FS: mem, source: filepath.Join(sourceDir, "a.txt")}
+	fc := NewFileCopier(sourceDir, destDir, options, nil, syncDB, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFilesが失敗しました: %v", err)
+	}
+
+	// ソースの読み込みの失敗として、操作・エラー番号・再試行回数を記録する
+	want := errcode.Detail{Code: errcode.ESrcRead, Op: "open", Errno: int(syscall.EIO), Retries: 2}
+	failures := fc.GetFailures()
+	if len(failures) != 1 {
+		t.Fatalf("失敗したファイル = %+v", failures)
+	}
+	if got := errcode.Describe(failures[0].Err); got == nil || *got != want {
+		t.Errorf("失敗の情報 = %+v, want %+v", got, want)
+	}
+
+	record, err := syncDB.GetFile("a.txt")
+	if err != nil || record == nil {
+		t.Fatalf("ファイル情報が取得できません: %v", err)
+	}
+	if record.Error == nil || *record.Error != want {
+		t.Errorf("DBのエラー情報 = %+v, want %+v", record.Error, want)
+	}
+}
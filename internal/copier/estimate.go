@@ -0,0 +1,203 @@
+package copier
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/pathkey"
+	"github.com/sakuhanight/gopier/internal/sidecar"
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+// probeMaxFiles はスループットの計測でコピーする最大ファイル数
+const probeMaxFiles = 64
+
+// EstimateOptions は見積もりのオプションを表す構造体
+type EstimateOptions struct {
+	CompareDest bool  // 宛先を走査してスキップされるファイルを判定するかどうか（falseの場合はすべてコピー対象とする）
+	ProbeBytes  int64 // スループットの計測で読み書きする最大バイト数（0の場合は計測しない）
+	ProbeWrite  bool  // スループットの計測で宛先に一時ファイルを作成して書き込むかどうか（falseの場合は読み込みのみ計測する）
+}
+
+// Estimate はコピーを実行した場合の処理量の見積もりを表す構造体
+type Estimate struct {
+	SourceFiles      int64   `json:"source_files"`             // 対象のファイル数（フィルタで除外したものを含む）
+	SourceBytes      int64   `json:"source_bytes"`             // 対象のファイルの合計サイズ
+	CopyFiles        int64   `json:"copy_files"`               // コピーされるファイル数
+	CopyBytes        int64   `json:"copy_bytes"`               // コピーされるファイルの合計サイズ
+	SkipFiles        int64   `json:"skip_files"`               // 宛先と同一などでスキップされるファイル数
+	SkipBytes        int64   `json:"skip_bytes"`               // スキップされるファイルの合計サイズ
+	FilteredFiles    int64   `json:"filtered_files"`           // フィルタで除外されるファイル数
+	FilteredBytes    int64   `json:"filtered_bytes"`           // フィルタで除外されるファイルの合計サイズ
+	ExcludedHidden   int64   `json:"excluded_hidden"`          // 除外される隠しファイル・ディレクトリ数
+	ExcludedSystem   int64   `json:"excluded_system"`          // 除外されるシステムファイル・ディレクトリ数
+	Errors           int64   `json:"errors"`                   // 情報を取得できなかったファイル・ディレクトリ数
+	DestCompared     bool    `json:"dest_compared"`            // 宛先と比較したかどうか
+	ScanSeconds      float64 `json:"scan_seconds"`             // 走査にかかった秒数
+	ProbeFiles       int64   `json:"probe_files"`              // スループットの計測で読み込んだファイル数
+	ProbeBytes       int64   `json:"probe_bytes"`              // スループットの計測で読み込んだバイト数
+	ProbeSeconds     float64 `json:"probe_seconds"`            // スループットの計測にかかった秒数
+	ProbeWrite       bool    `json:"probe_write"`              // 計測で宛先への書き込みも行ったかどうか
+	Throughput       float64 `json:"throughput_bytes_per_sec"` // 見積もりに使用した秒あたりのバイト数（帯域制限を反映）
+	EstimatedSeconds float64 `json:"estimated_seconds"`        // コピーにかかる見積もり秒数（計測しなかった場合は0）
+	copyPaths        []string
+}
+
+// Estimate はソース（と宛先）を走査し、現在のオプションでコピーを実行した場合の処理量を見積もる
+// ファイルのコピーやデータベースへの記録は行わない
+func (fc *FileCopier) Estimate(opts EstimateOptions) (*Estimate, error) {
+	if _, err := fc.statSource(fc.sourceDir); err != nil {
+		return nil, fmt.Errorf("ソースディレクトリにアクセスできません: %w", err)
+	}
+
+	result := &Estimate{DestCompared: opts.CompareDest}
+	start := time.Now()
+	if err := fc.estimateDirectory(fc.sourceDir, fc.destDir, opts, result); err != nil {
+		return nil, err
+	}
+	result.ScanSeconds = time.Since(start).Seconds()
+
+	excluded := fc.GetExcludedCounts()
+	result.ExcludedHidden = excluded.Hidden
+	result.ExcludedSystem = excluded.System
+
+	if opts.ProbeBytes > 0 {
+		fc.probeThroughput(opts.ProbeBytes, opts.ProbeWrite, result)
+	}
+	result.copyPaths = nil
+	return result, nil
+}
+
+// estimateDirectory はディレクトリ内のファイルを、コピー時と同じ規則でコピー・スキップ・除外に分類する
+func (fc *FileCopier) estimateDirectory(sourceDir, destDir string, opts EstimateOptions, result *Estimate) error {
+	entries, err := fc.readSourceDir(sourceDir)
+	if err != nil {
+		if sourceDir == fc.sourceDir {
+			return fmt.Errorf("ディレクトリ(%s)の読み込みエラー: %w", sourceDir, err)
+		}
+		result.Errors++
+		return nil
+	}
+
+	for _, entry := range entries {
+		sourcePath := filepath.Join(sourceDir, entry.Name())
+		destPath := filepath.Join(destDir, entry.Name())
+
+		if fc.excludeByAttributes(entry) {
+			continue
+		}
+		if fc.options.MetaSidecar && entry.Name() == sidecar.FileName {
+			continue
+		}
+
+		if entry.IsDir() {
+			if fc.options.Recursive && (fc.filter == nil || !fc.filter.ExcludesDir(sourcePath)) {
+				if err := fc.estimateDirectory(sourcePath, destPath, opts, result); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		info, err := fc.entryInfo(entry)
+		if err != nil {
+			result.Errors++
+			continue
+		}
+		result.SourceFiles++
+		result.SourceBytes += info.Size()
+
+		if fc.filter != nil && !fc.filter.ShouldInclude(sourcePath) {
+			result.FilteredFiles++
+			result.FilteredBytes += info.Size()
+			continue
+		}
+
+		if opts.CompareDest && fc.wouldSkip(sourcePath, destPath, info) {
+			result.SkipFiles++
+			result.SkipBytes += info.Size()
+			continue
+		}
+		result.CopyFiles++
+		result.CopyBytes += info.Size()
+		if len(result.copyPaths) < probeMaxFiles {
+			result.copyPaths = append(result.copyPaths, sourcePath)
+		}
+	}
+	return nil
+}
+
+// wouldSkip はコピー時に宛先のファイルがスキップされるかどうかを判定する
+func (fc *FileCopier) wouldSkip(sourcePath, destPath string, sourceInfo os.FileInfo) bool {
+	destInfo, err := fc.statDest(destPath)
+	if err != nil {
+		return false
+	}
+	if !fc.options.OverwriteExisting || fc.destNewer(sourceInfo, destInfo) {
+		return true
+	}
+
+	var record *database.FileInfo
+	transformers := fc.selectTransforms(sourcePath)
+	if len(transformers) > 0 && fc.db != nil {
+		if relPath, err := pathkey.Rel(fc.sourceDir, sourcePath); err == nil {
+			record, _ = fc.db.GetFile(relPath)
+		}
+	}
+	return fc.upToDate(sourceInfo, destInfo, record, transformers)
+}
+
+// probeThroughput はコピー対象のファイルの一部を読み込み（writeを指定し、宛先があれば一時ファイルに書き込み）、
+// 計測したスループットからコピーにかかる時間を見積もる
+func (fc *FileCopier) probeThroughput(limit int64, write bool, result *Estimate) {
+	var dest vfs.File
+	if info, err := fc.statDest(fc.destDir); write && err == nil && info.IsDir() {
+		if file, err := fc.createDestTemp(fc.destDir, ".gopier-probe-*"); err == nil {
+			dest = file
+			defer func() {
+				dest.Close()
+				fc.removeDest(dest.Name())
+			}()
+		}
+	}
+	result.ProbeWrite = dest != nil
+
+	buf := make([]byte, fc.options.BufferSize)
+	start := time.Now()
+	for _, path := range result.copyPaths {
+		if result.ProbeBytes >= limit {
+			break
+		}
+		file, err := fc.openSource(path)
+		if err != nil {
+			continue
+		}
+
+		var dst io.Writer = io.Discard
+		if dest != nil {
+			dst = dest
+		}
+		n, _ := io.CopyBuffer(dst, io.LimitReader(file, limit-result.ProbeBytes), buf)
+		file.Close()
+		result.ProbeFiles++
+		result.ProbeBytes += n
+	}
+	if dest != nil {
+		dest.Sync()
+	}
+	elapsed := time.Since(start)
+
+	if result.ProbeBytes == 0 || elapsed <= 0 {
+		return
+	}
+	result.ProbeSeconds = elapsed.Seconds()
+	result.Throughput = float64(result.ProbeBytes) / result.ProbeSeconds
+	if fc.options.BandwidthLimit > 0 && float64(fc.options.BandwidthLimit) < result.Throughput {
+		result.Throughput = float64(fc.options.BandwidthLimit)
+	}
+	result.EstimatedSeconds = float64(result.CopyBytes) / result.Throughput
+}
@@ -0,0 +1,68 @@
+package copier
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sakuhanight/gopier/internal/filter"
+)
+
+func TestEstimate(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(filepath.Join(sourceDir, "sub"), 0755)
+	os.MkdirAll(destDir, 0755)
+	os.WriteFile(filepath.Join(sourceDir, "same.txt"), []byte("same"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "new.txt"), []byte("new file"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "sub", "deep.txt"), []byte("deep"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "skip.tmp"), []byte("tmp"), 0644)
+
+	// 宛先に同じサイズ・更新日時のファイルがあればスキップされる
+	info, _ := os.Stat(filepath.Join(sourceDir, "same.txt"))
+	os.WriteFile(filepath.Join(destDir, "same.txt"), []byte("same"), 0644)
+	os.Chtimes(filepath.Join(destDir, "same.txt"), info.ModTime(), info.ModTime())
+
+	fc := NewFileCopier(sourceDir, destDir, DefaultOptions(), filter.NewFilter("", "*.tmp"), nil, nil)
+	result, err := fc.Estimate(EstimateOptions{CompareDest: true, ProbeBytes: 1024, ProbeWrite: true})
+	if err != nil {
+		t.Fatalf("Estimateが失敗しました: %v", err)
+	}
+
+	if result.SourceFiles != 4 || result.CopyFiles != 2 || result.SkipFiles != 1 || result.FilteredFiles != 1 {
+		t.Errorf("件数 = ソース%d, コピー%d, スキップ%d, 除外%d; want 4, 2, 1, 1",
+			result.SourceFiles, result.CopyFiles, result.SkipFiles, result.FilteredFiles)
+	}
+	if result.CopyBytes != int64(len("new file")+len("deep")) {
+		t.Errorf("コピーするバイト数 = %d", result.CopyBytes)
+	}
+	if result.ProbeBytes != result.CopyBytes || !result.ProbeWrite || result.Throughput <= 0 || result.EstimatedSeconds <= 0 {
+		t.Errorf("計測結果 = %+v", result)
+	}
+
+	// 見積もりではコピーも計測用の一時ファイルも残さない
+	entries, _ := os.ReadDir(destDir)
+	if len(entries) != 1 {
+		t.Errorf("宛先のエントリ数 = %d, want 1", len(entries))
+	}
+
+	// 書き込みの計測を指定しない場合は読み込みのみ計測する
+	result, err = fc.Estimate(EstimateOptions{CompareDest: true, ProbeBytes: 1024})
+	if err != nil {
+		t.Fatalf("Estimateが失敗しました: %v", err)
+	}
+	if result.ProbeWrite || result.Throughput <= 0 {
+		t.Errorf("読み込みのみの計測結果 = %+v", result)
+	}
+
+	// 宛先と比較しない場合はすべてコピー対象とする
+	fc = NewFileCopier(sourceDir, destDir, DefaultOptions(), nil, nil, nil)
+	result, err = fc.Estimate(EstimateOptions{})
+	if err != nil {
+		t.Fatalf("Estimateが失敗しました: %v", err)
+	}
+	if result.CopyFiles != 4 || result.SkipFiles != 0 || result.Throughput != 0 {
+		t.Errorf("宛先と比較しない見積もり = %+v", result)
+	}
+}
@@ -0,0 +1,446 @@
+package copier
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/pathkey"
+	"github.com/sakuhanight/gopier/internal/transform"
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+// TargetResult は宛先ごとのコピー結果の集計
+type TargetResult struct {
+	Destination string
+	Copied      int64
+	Skipped     int64
+	Failed      int64
+}
+
+// Complete はすべてのファイルがこの宛先に揃っているかどうかを返す
+func (r TargetResult) Complete() bool {
+	return r.Failed == 0
+}
+
+// fanOutTarget はファンアウト時の個々の宛先
+type fanOutTarget struct {
+	root    string // 宛先ディレクトリ
+	path    string // 宛先ファイルのパス
+	existed bool   // コピー前に宛先ファイルが存在したかどうか
+	status  database.FileStatus
+	err     error
+}
+
+// fanOutEnabled は複数の宛先にコピーするかどうかを返す
+func (fc *FileCopier) fanOutEnabled() bool {
+	return len(fc.options.ExtraDestinations) > 0
+}
+
+// destinations は主宛先を含むすべての宛先ディレクトリを返す
+func (fc *FileCopier) destinations() []string {
+	return append([]string{fc.destDir}, fc.options.ExtraDestinations...)
+}
+
+// extraPaths は主宛先のパスに対応する追加の宛先のパスを返す
+func (fc *FileCopier) extraPaths(destPath string) []string {
+	rel, err := filepath.Rel(fc.destDir, destPath)
+	if err != nil {
+		return nil
+	}
+
+	paths := make([]string, 0, len(fc.options.ExtraDestinations))
+	for _, root := range fc.options.ExtraDestinations {
+		paths = append(paths, filepath.Join(root, rel))
+	}
+	return paths
+}
+
+// GetTargetResults は宛先ごとのコピー結果を返す（ファンアウトしていない場合はnil）
+func (fc *FileCopier) GetTargetResults() []TargetResult {
+	if !fc.fanOutEnabled() {
+		return nil
+	}
+
+	fc.targetMu.Lock()
+	defer fc.targetMu.Unlock()
+
+	results := make([]TargetResult, 0, len(fc.destinations()))
+	for _, root := range fc.destinations() {
+		result := TargetResult{Destination: root}
+		if counts := fc.targetCounts[root]; counts != nil {
+			result.Copied = counts.Copied
+			result.Skipped = counts.Skipped
+			result.Failed = counts.Failed
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// recordTarget は宛先ごとの集計を更新する
+func (fc *FileCopier) recordTarget(root string, status database.FileStatus) {
+	fc.targetMu.Lock()
+	defer fc.targetMu.Unlock()
+
+	if fc.targetCounts == nil {
+		fc.targetCounts = make(map[string]*TargetResult)
+	}
+	counts := fc.targetCounts[root]
+	if counts == nil {
+		counts = &TargetResult{Destination: root}
+		fc.targetCounts[root] = counts
+	}
+
+	switch status {
+	case database.StatusSuccess:
+		counts.Copied++
+	case database.StatusSkipped:
+		counts.Skipped++
+	default:
+		counts.Failed++
+	}
+}
+
+// copyFileFanOut はソースファイルを一度だけ読み込み、すべての宛先に並行して書き込む
+// 宛先ごとに独立して状態を判定するため、一部の宛先の失敗は他の宛先に影響しない
+func (fc *FileCopier) copyFileFanOut(sourcePath, destPath, relPath string, sourceInfo os.FileInfo, fileInfo *database.FileInfo, transformers []transform.Transformer) error {
+	targets := []*fanOutTarget{{root: fc.destDir, path: destPath}}
+	for i, path := range fc.extraPaths(destPath) {
+		targets = append(targets, &fanOutTarget{root: fc.options.ExtraDestinations[i], path: path})
+	}
+
+	// 宛先ごとにコピーが必要かどうかを判定
+	var pending []*fanOutTarget
+	for _, target := range targets {
+		destInfo, err := fc.statDest(target.path)
+		target.existed = err == nil
+		switch {
+		case err == nil && (!fc.options.OverwriteExisting || fc.upToDate(sourceInfo, destInfo, fileInfo, transformers)):
+			target.status = database.StatusSkipped
+		case err == nil && fc.destNewer(sourceInfo, destInfo):
+			// 宛先の方が新しい場合は上書きしない
+			target.status = database.StatusSkipped
+			if target.err = fc.noteConflict(relPath, target.root, sourceInfo, destInfo); target.err != nil {
+				target.status = database.StatusFailed
+			}
+		case err != nil && !os.IsNotExist(err):
+			target.status = database.StatusFailed
+			target.err = fmt.Errorf("宛先ファイル確認エラー: %w", err)
+		default:
+			if fc.options.CreateDirs {
+				if err := fc.mkdirDest(filepath.Dir(target.path)); err != nil {
+					target.status = database.StatusFailed
+					target.err = fmt.Errorf("宛先ディレクトリ作成エラー: %w", err)
+					continue
+				}
+			}
+			pending = append(pending, target)
+		}
+	}
+
+	// 異常終了した場合に照合できるよう、書き込む前にジャーナルに記録
+	var journal *journalCopy
+	if len(pending) > 0 {
+		paths := make([]string, len(pending))
+		for i, target := range pending {
+			paths[i] = target.path
+		}
+		change := database.ChangeCreated
+		if targets[0].existed {
+			change = database.ChangeUpdated
+		}
+		journal = fc.beginCopy(relPath, paths, sourceInfo, change, transformers)
+		defer fc.endCopy(journal)
+	}
+
+	// コピー（失敗した宛先のみリトライする）
+	var transformInfo *database.TransformInfo
+	for retry := 0; retry <= fc.options.MaxRetries && len(pending) > 0; retry++ {
+		if retry > 0 {
+			select {
+			case <-time.After(fc.options.RetryDelay):
+			case <-fc.ctx.Done():
+			}
+			if fc.ctx.Err() != nil {
+				break
+			}
+
+			if fc.logger != nil {
+				fc.logger.Warn("ファイル '%s' のコピーをリトライします (%d/%d): %d件の宛先", relPath, retry, fc.options.MaxRetries, len(pending))
+			}
+		}
+
+		paths := make([]string, len(pending))
+		for i, target := range pending {
+			paths[i] = target.path
+		}
+		var errs []error
+		fc.retrySharing(relPath, func() error {
+			errs, transformInfo = fc.doCopyFileMulti(sourcePath, paths, sourceInfo, transformers)
+			for _, err := range errs {
+				if err != nil && sharingViolation(err) {
+					return err
+				}
+			}
+			return nil
+		})
+
+		var failed []*fanOutTarget
+		for i, target := range pending {
+			if errs[i] != nil {
+				target.status = database.StatusFailed
+				target.err = errs[i]
+				failed = append(failed, target)
+			} else {
+				target.status = database.StatusSuccess
+				target.err = nil
+			}
+		}
+		pending = failed
+
+		// キャンセルされた場合と、共有違反の再試行を使い切っても使用中の場合はリトライしない
+		if fc.ctx.Err() != nil || allLocked(pending) {
+			break
+		}
+	}
+
+	// 使用中のファイルは終了時に再試行する
+	if allLocked(pending) && fc.deferLocked(relPath, sourcePath, destPath, pending[0].err) {
+		return nil
+	}
+
+	// 宛先ごとの結果を集計
+	now := time.Now()
+	record := database.FileInfo{
+		Path:         relPath,
+		Size:         sourceInfo.Size(),
+		ModTime:      sourceInfo.ModTime(),
+		LastSyncTime: now,
+		Targets:      make(map[string]database.TargetStatus, len(targets)),
+	}
+	if len(transformers) > 0 {
+		// 今回書き込まなかった場合は前回の変換結果を引き継ぐ
+		record.Transform = transformInfo
+		if record.Transform == nil && fileInfo != nil {
+			record.Transform = fileInfo.Transform
+		}
+		if record.Transform != nil {
+			record.SourceHash = record.Transform.OriginalHash
+			record.DestHash = record.Transform.OutputHash
+			record.HashAlgo = fc.options.HashAlgorithm
+			if transformInfo == nil {
+				record.HashAlgo = fileInfo.HashAlgo
+			}
+		}
+	}
+	if meta, err := fc.collectSourceMeta(sourcePath); err == nil {
+		record.Meta = meta
+	}
+
+	var copied, failed int
+	var firstErr error
+	for _, target := range targets {
+		fc.recordTarget(target.root, target.status)
+
+		status := database.TargetStatus{Status: target.status, LastSyncTime: now}
+		switch target.status {
+		case database.StatusSuccess:
+			copied++
+		case database.StatusFailed:
+			failed++
+			status.LastError = target.err.Error()
+			status.Code = errcode.CatalogOf(target.err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("宛先 %s: %w", target.root, target.err)
+			}
+			if fc.logger != nil {
+				fc.logger.Error("コピー失敗: %s -> %s: %v", relPath, target.root, target.err)
+			}
+		}
+		record.Targets[target.root] = status
+	}
+
+	// ファイル全体の状態は、いずれかの宛先が失敗していれば失敗とする
+	switch {
+	case failed > 0:
+		firstErr = destError(fc.lockedError(relPath, firstErr))
+		fc.countFailed(relPath, firstErr)
+		record.Status = database.StatusFailed
+		if errors.Is(firstErr, errcode.ErrLocked) {
+			record.Status = database.StatusLocked
+		}
+		record.LastError = firstErr.Error()
+		record.Error = errcode.Describe(firstErr)
+		record.FailCount = 1
+		if fileInfo != nil {
+			record.FailCount = fileInfo.FailCount + 1
+		}
+	case copied > 0:
+		fc.countCopied(relPath, sourceInfo.Size())
+		fc.noteCopied(relPath, sourcePath, destPath, sourceInfo)
+		record.Status = database.StatusSuccess
+		record.SessionID = atomic.LoadInt64(&fc.sessionID)
+		// 変更の種類は主宛先で判断する
+		record.Change = database.ChangeCreated
+		if targets[0].existed {
+			record.Change = database.ChangeUpdated
+		}
+		if destRel, err := pathkey.Rel(fc.destDir, destPath); err == nil && destRel != relPath {
+			record.DestPath = destRel
+		}
+		if fc.logger != nil {
+			fc.logger.Info("コピー成功: %s (%d/%d件の宛先)", relPath, copied, len(targets))
+		}
+	default:
+		fc.countSkipped(relPath, sourceInfo.Size())
+		record.Status = database.StatusSkipped
+	}
+
+	if fc.db != nil {
+		if record.Status == database.StatusSuccess {
+			// 以前セグメントにまとめて書き込んだファイルは、セグメントの中の位置の記録を削除する
+			fc.db.AddUnbatchedFile(record)
+		} else {
+			fc.db.AddFile(record)
+		}
+		journal.markRecorded()
+	}
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	// 検証と同時コピーモードの場合は主宛先を検証する
+	if fc.options.Mode == ModeCopyAndVerify {
+		return fc.verifyCopied(sourcePath, destPath, relPath, sourceInfo)
+	}
+
+	return nil
+}
+
+// doCopyFileMulti はソースファイルを一度だけ読み込み、複数の宛先ファイルに並行して書き込む
+// 戻り値は宛先ごとのエラーで、書き込みに失敗した宛先はそれ以降の書き込みから除外される
+// 変換を指定した場合は変換後の内容を書き込み、変換前後のハッシュも返す
+func (fc *FileCopier) doCopyFileMulti(sourcePath string, destPaths []string, sourceInfo os.FileInfo, transformers []transform.Transformer) ([]error, *database.TransformInfo) {
+	errs := make([]error, len(destPaths))
+	fail := func(err error) ([]error, *database.TransformInfo) {
+		for i := range errs {
+			if errs[i] == nil {
+				errs[i] = err
+			}
+		}
+		return errs, nil
+	}
+
+	sourceFile, err := fc.openSource(sourcePath)
+	if err != nil {
+		return fail(errcode.Errorf(errcode.ErrSourceRead, "ソースファイル(%s)を開けません: %w", sourcePath, err))
+	}
+	defer sourceFile.Close()
+
+	files := make([]vfs.File, len(destPaths))
+	for i, path := range destPaths {
+		files[i], errs[i] = fc.createDest(path)
+		if errs[i] != nil {
+			errs[i] = fmt.Errorf("宛先ファイル(%s)を作成できません: %w", path, errs[i])
+		}
+	}
+	defer func() {
+		for _, f := range files {
+			if f != nil {
+				f.Close()
+			}
+		}
+	}()
+
+	var reader io.Reader = fc.sourceReader(sourceFile)
+	var output io.Writer = io.Discard
+	var hashes *transformHashes
+	if len(transformers) > 0 {
+		var err error
+		if hashes, err = fc.newTransformHashes(); err != nil {
+			return fail(err)
+		}
+		transformed, w := hashes.wrap(reader, io.Discard, transformers)
+		defer transformed.Close()
+		reader, output = transformed, w
+	}
+
+	var written int64
+	buffer := make([]byte, fc.options.BufferSize)
+	for {
+		n, readErr := reader.Read(buffer)
+		if n > 0 {
+			output.Write(buffer[:n])
+			written += int64(n)
+
+			// 各宛先への書き込みを並行して行う
+			var wg sync.WaitGroup
+			for i, f := range files {
+				if errs[i] != nil {
+					continue
+				}
+				wg.Add(1)
+				go func(i int, f vfs.File) {
+					defer wg.Done()
+					if _, err := f.Write(buffer[:n]); err != nil {
+						errs[i] = fmt.Errorf("ファイルコピーエラー: %w", err)
+					}
+				}(i, f)
+			}
+			wg.Wait()
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return fail(fmt.Errorf("ファイルコピーエラー: %w", readErr))
+		}
+	}
+
+	for i, f := range files {
+		if errs[i] != nil {
+			continue
+		}
+		err := f.Close()
+		files[i] = nil
+		if err != nil {
+			errs[i] = fmt.Errorf("宛先ファイル(%s)を閉じられません: %w", destPaths[i], err)
+			continue
+		}
+		if fc.options.PreserveModTime {
+			if err := fc.copyTimes(destPaths[i], sourceInfo); err != nil {
+				errs[i] = fmt.Errorf("更新日時の設定エラー: %w", err)
+				continue
+			}
+		}
+		if fc.setsPermissions() {
+			if err := fc.setPermissions(sourcePath, destPaths[i], false); err != nil {
+				errs[i] = fmt.Errorf("アクセス権の設定エラー: %w", err)
+			}
+		}
+	}
+
+	if hashes != nil {
+		return errs, hashes.info(transformers, written)
+	}
+	return errs, nil
+}
+
+// allLocked はコピーに失敗した宛先がすべて使用中のエラーかどうかを返す（失敗した宛先がない場合はfalse）
+func allLocked(failed []*fanOutTarget) bool {
+	for _, target := range failed {
+		if !sharingViolation(target.err) {
+			return false
+		}
+	}
+	return len(failed) > 0
+}
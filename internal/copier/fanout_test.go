@@ -0,0 +1,109 @@
+package copier
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sakuhanight/gopier/internal/database"
+)
+
+func TestCopyFiles_FanOut(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	extraDir := filepath.Join(tempDir, "extra")
+	brokenDir := filepath.Join(tempDir, "broken")
+
+	os.MkdirAll(filepath.Join(sourceDir, "sub"), 0755)
+	os.MkdirAll(filepath.Join(sourceDir, "empty"), 0755)
+	os.WriteFile(filepath.Join(sourceDir, "root.txt"), []byte("root"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "sub", "nested.txt"), []byte("nested"), 0644)
+
+	// ディレクトリの代わりにファイルを置いて、この宛先への書き込みを失敗させる
+	os.MkdirAll(brokenDir, 0755)
+	os.WriteFile(filepath.Join(brokenDir, "sub"), []byte("not a directory"), 0644)
+
+	syncDB, err := database.NewSyncDB(filepath.Join(tempDir, "sync.db"), database.NormalSync)
+	if err != nil {
+		t.Fatalf("データベース作成に失敗: %v", err)
+	}
+	defer syncDB.Close()
+
+	options := DefaultOptions()
+	options.MaxRetries = 0
+	options.ExtraDestinations = []string{extraDir, brokenDir}
+	fc := NewFileCopier(sourceDir, destDir, options, nil, syncDB, nil)
+
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFilesが失敗しました: %v", err)
+	}
+
+	for _, dir := range []string{destDir, extraDir} {
+		for _, rel := range []string{"root.txt", filepath.Join("sub", "nested.txt")} {
+			if _, err := os.Stat(filepath.Join(dir, rel)); err != nil {
+				t.Errorf("%s にコピーされていません: %v", filepath.Join(dir, rel), err)
+			}
+		}
+		if info, err := os.Stat(filepath.Join(dir, "empty")); err != nil || !info.IsDir() {
+			t.Errorf("%s に空のディレクトリが作成されていません", dir)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(brokenDir, "root.txt")); err != nil {
+		t.Errorf("失敗した宛先でも他のファイルはコピーされるべきです: %v", err)
+	}
+
+	// 宛先ごとの状態がデータベースに記録される
+	nested, err := syncDB.GetFile("sub/nested.txt")
+	if err != nil || nested == nil {
+		t.Fatalf("ファイル情報の取得に失敗: %v", err)
+	}
+	if nested.Status != database.StatusFailed {
+		t.Errorf("一部の宛先が失敗した場合は失敗とするべきです: %s", nested.Status)
+	}
+	if nested.Targets[destDir].Status != database.StatusSuccess || nested.Targets[extraDir].Status != database.StatusSuccess {
+		t.Errorf("成功した宛先の状態が正しくありません: %+v", nested.Targets)
+	}
+	if nested.Targets[brokenDir].Status != database.StatusFailed || nested.Targets[brokenDir].LastError == "" {
+		t.Errorf("失敗した宛先の状態が正しくありません: %+v", nested.Targets[brokenDir])
+	}
+
+	// 宛先ごとの集計
+	results := fc.GetTargetResults()
+	if len(results) != 3 {
+		t.Fatalf("宛先数: 期待値=3, 実際=%d", len(results))
+	}
+	for _, r := range results {
+		switch r.Destination {
+		case destDir, extraDir:
+			if !r.Complete() || r.Copied != 2 {
+				t.Errorf("%s の集計が正しくありません: %+v", r.Destination, r)
+			}
+		case brokenDir:
+			if r.Complete() || r.Copied != 1 || r.Failed != 1 {
+				t.Errorf("%s の集計が正しくありません: %+v", r.Destination, r)
+			}
+		}
+	}
+
+	// 2回目は揃っている宛先はスキップされ、失敗した宛先のみ再試行される
+	os.Remove(filepath.Join(brokenDir, "sub"))
+	fc2 := NewFileCopier(sourceDir, destDir, options, nil, syncDB, nil)
+	if err := fc2.CopyFiles(); err != nil {
+		t.Fatalf("2回目のCopyFilesが失敗しました: %v", err)
+	}
+	for _, r := range fc2.GetTargetResults() {
+		if !r.Complete() {
+			t.Errorf("%s が揃っていません: %+v", r.Destination, r)
+		}
+		if r.Destination == brokenDir && r.Copied != 1 {
+			t.Errorf("失敗した宛先が再試行されていません: %+v", r)
+		}
+		if r.Destination == destDir && r.Copied != 0 {
+			t.Errorf("揃っている宛先に再度コピーされました: %+v", r)
+		}
+	}
+	if fc2.GetStats().GetCopiedCount() != 1 || fc2.GetStats().GetSkippedCount() != 1 {
+		t.Errorf("統計が正しくありません: %s", fc2.GetStats())
+	}
+}
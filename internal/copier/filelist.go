@@ -0,0 +1,147 @@
+package copier
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/pathkey"
+)
+
+// ReadFileList はコピーするパスの一覧を読み込む
+// 1行に1つのパスを記述し、空行と#で始まる行は無視する。
+// NUL文字を含む場合はNUL区切りとして扱う（find -print0の出力など、改行を含むファイル名に対応）
+func ReadFileList(r io.Reader) ([]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("ファイル一覧の読み込みエラー: %w", err)
+	}
+
+	paths := []string{}
+	if bytes.IndexByte(data, 0) >= 0 {
+		for _, entry := range bytes.Split(data, []byte{0}) {
+			if len(entry) > 0 {
+				paths = append(paths, string(entry))
+			}
+		}
+		return paths, nil
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		paths = append(paths, line)
+	}
+	return paths, nil
+}
+
+// copyFileList はファイル一覧（Options.FileList）のパスのみをコピーする
+// ディレクトリは走査せず、一覧にあるディレクトリは宛先にディレクトリのみを作成する
+// フィルタと隠しファイル・システムファイルの除外は、Options.FilterFileListを指定した場合のみ適用する
+func (fc *FileCopier) copyFileList() error {
+	seen := make(map[string]bool, len(fc.options.FileList))
+	for _, entry := range fc.options.FileList {
+		select {
+		case <-fc.ctx.Done():
+			return errcode.Errorf(errcode.ErrCancelled, "コピー処理がキャンセルされました")
+		default:
+		}
+
+		relPath, err := fc.listRelPath(entry)
+		if err != nil {
+			relPath = pathkey.Normalize(entry)
+			fc.countFailed(relPath, err)
+			fc.stats.RecordError(relPath, err)
+			fc.recordFailure(relPath, err)
+			continue
+		}
+		if seen[relPath] {
+			continue
+		}
+		seen[relPath] = true
+		if fc.options.FilterFileList && fc.excludesListPath(relPath) {
+			continue
+		}
+
+		sourcePath := filepath.Join(fc.sourceDir, pathkey.ToNative(relPath))
+		destPath := filepath.Join(fc.destDir, pathkey.ToNative(relPath))
+
+		// 存在しないパスはcopyFileで失敗として記録する
+		info, err := fc.statSource(sourcePath)
+		if err == nil && info.IsDir() {
+			if fc.options.Flatten {
+				continue
+			}
+			for _, dir := range append([]string{destPath}, fc.extraPaths(destPath)...) {
+				if err := fc.mkdirDest(dir); err != nil && fc.logger != nil {
+					fc.logger.Warn("宛先ディレクトリ(%s)の作成エラー: %v", dir, err)
+				}
+			}
+			continue
+		}
+
+		if fc.options.Flatten {
+			if destPath = fc.flattenDestPath(sourcePath); destPath == "" {
+				continue
+			}
+		}
+		fc.enqueueCopy(sourcePath, destPath, info)
+	}
+	return nil
+}
+
+// excludesListPath はファイル一覧のパスをソースの走査で処理しないかどうかを返す
+// パスまでのディレクトリのいずれかを走査しない場合も処理しない。
+// 一覧の作成後に削除されたパスは、失敗として記録せずに処理しない
+func (fc *FileCopier) excludesListPath(relPath string) bool {
+	names := strings.Split(relPath, "/")
+	path := fc.sourceDir
+	for i, name := range names {
+		path = filepath.Join(path, name)
+		info, err := fc.statSource(path)
+		if err != nil {
+			return os.IsNotExist(err)
+		}
+		if fc.attributeCounter(fs.FileInfoToDirEntry(info)) != nil {
+			return true
+		}
+		if info.IsDir() {
+			if fc.isDestDir(path, fs.FileInfoToDirEntry(info)) || (fc.filter != nil && fc.filter.ExcludesDir(path)) {
+				return true
+			}
+			continue
+		}
+		if i == len(names)-1 && fc.filter != nil && !fc.filter.ShouldInclude(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// listRelPath はファイル一覧のパスをソースからの相対パスに変換する
+// ソース配下の絶対パスも受け付け、ソースの外を指すパスはエラーにする
+func (fc *FileCopier) listRelPath(entry string) (string, error) {
+	native := filepath.FromSlash(entry)
+	if filepath.IsAbs(native) {
+		base, err := filepath.Abs(fc.sourceDir)
+		if err != nil {
+			return "", err
+		}
+		if native, err = filepath.Rel(base, native); err != nil {
+			return "", fmt.Errorf("ソースからの相対パスに変換できません: %s", entry)
+		}
+	}
+
+	rel := pathkey.Normalize(filepath.Clean(native))
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", fmt.Errorf("ソース配下のファイルを指定してください: %s", entry)
+	}
+	return rel, nil
+}
@@ -0,0 +1,119 @@
+package copier
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sakuhanight/gopier/internal/filter"
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+func TestReadFileList(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"改行区切り", "a.txt\r\n# コメント\n\nsub/b.txt\n", []string{"a.txt", "sub/b.txt"}},
+		{"NUL区切り", "a.txt\x00line\nbreak.txt\x00#not-comment\x00", []string{"a.txt", "line\nbreak.txt", "#not-comment"}},
+		{"空", "", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadFileList(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("ReadFileList() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadFileList() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCopyFiles_FileList(t *testing.T) {
+	mem := vfs.NewMem()
+	sourceDir := filepath.Join("/", "source")
+	destDir := filepath.Join("/", "dest")
+	mem.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("a"), 0644)
+	mem.WriteFile(filepath.Join(sourceDir, "sub", "b.txt"), []byte("b"), 0644)
+	mem.WriteFile(filepath.Join(sourceDir, "sub", "c.txt"), []byte("c"), 0644)
+	mem.WriteFile(filepath.Join(sourceDir, "other", "d.txt"), []byte("d"), 0644)
+	mem.MkdirAll(filepath.Join(sourceDir, "empty"), 0755)
+
+	options := DefaultOptions()
+	options.FS = mem
+	options.FileList = []string{
+		"sub/b.txt",
+		"./a.txt",
+		filepath.Join(sourceDir, "sub", "b.txt"), // ソース配下の絶対パスは相対パスとして扱い、重複はコピーしない
+		"empty",
+		"missing.txt",
+		"../outside.txt",
+	}
+	fc := NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFiles() error = %v", err)
+	}
+
+	for _, name := range []string{"a.txt", "sub/b.txt"} {
+		if _, err := mem.Stat(filepath.Join(destDir, filepath.FromSlash(name))); err != nil {
+			t.Errorf("%s がコピーされていません: %v", name, err)
+		}
+	}
+	// 一覧にないファイルはディレクトリを走査してもコピーしない
+	for _, name := range []string{"sub/c.txt", "other/d.txt"} {
+		if _, err := mem.Stat(filepath.Join(destDir, filepath.FromSlash(name))); err == nil {
+			t.Errorf("%s は一覧にないためコピーされるべきではありません", name)
+		}
+	}
+	if info, err := mem.Stat(filepath.Join(destDir, "empty")); err != nil || !info.IsDir() {
+		t.Errorf("一覧にあるディレクトリが作成されていません: %v", err)
+	}
+
+	stats := fc.GetStats()
+	if stats.GetCopiedCount() != 2 || stats.GetFailedCount() != 2 {
+		t.Errorf("コピー=%d, 失敗=%d, want 2, 2", stats.GetCopiedCount(), stats.GetFailedCount())
+	}
+	failed := map[string]bool{}
+	for _, f := range fc.GetFailures() {
+		failed[f.Path] = true
+	}
+	if !failed["missing.txt"] || !failed["../outside.txt"] {
+		t.Errorf("失敗したファイル = %v", failed)
+	}
+}
+
+func TestCopyFiles_FilterFileList(t *testing.T) {
+	mem := vfs.NewMem()
+	sourceDir := filepath.Join("/", "source")
+	destDir := filepath.Join("/", "dest")
+	for _, name := range []string{"a.txt", "b.tmp", "node_modules/c.txt", ".hidden/d.txt", "sub/e.txt"} {
+		mem.WriteFile(filepath.Join(sourceDir, filepath.FromSlash(name)), []byte("x"), 0644)
+	}
+
+	options := DefaultOptions()
+	options.FS = mem
+	options.IncludeHidden = false
+	options.FilterFileList = true
+	options.FileList = []string{"a.txt", "b.tmp", "node_modules/c.txt", ".hidden/d.txt", "sub/e.txt", "deleted.txt"}
+	fileFilter := filter.NewFilter("", "*.tmp")
+	fileFilter.AddProfile(filter.Profile{Dirs: []string{"node_modules"}})
+	fc := NewFileCopier(sourceDir, destDir, options, fileFilter, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFiles() error = %v", err)
+	}
+
+	for _, name := range options.FileList {
+		_, err := mem.Stat(filepath.Join(destDir, filepath.FromSlash(name)))
+		if copied := name == "a.txt" || name == "sub/e.txt"; copied != (err == nil) {
+			t.Errorf("%s: コピー = %v, want %v", name, err == nil, copied)
+		}
+	}
+	// 一覧の作成後に削除されたパスは失敗として数えない
+	if failed := fc.GetStats().GetFailedCount(); failed != 0 {
+		t.Errorf("失敗 = %d, want 0: %v", failed, fc.GetFailures())
+	}
+}
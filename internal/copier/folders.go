@@ -0,0 +1,105 @@
+package copier
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/sakuhanight/gopier/internal/audit"
+)
+
+// RootFolder はソースの直下にあるファイルの集計先のフォルダ名
+const RootFolder = "."
+
+// FolderResult はフォルダごとのコピー結果の集計
+type FolderResult struct {
+	Folder       string // ソースからの相対パス（スラッシュ区切り）
+	Copied       int64
+	Skipped      int64
+	Failed       int64
+	BytesCopied  int64
+	BytesSkipped int64
+}
+
+// Total は処理したファイル数を返す
+func (r FolderResult) Total() int64 {
+	return r.Copied + r.Skipped + r.Failed
+}
+
+// CompletionPercent は失敗せずに宛先に揃ったファイルの割合を返す（ファイルがない場合は100）
+func (r FolderResult) CompletionPercent() float64 {
+	if r.Total() == 0 {
+		return 100
+	}
+	return float64(r.Copied+r.Skipped) / float64(r.Total()) * 100
+}
+
+// folderOf はファイルの相対パスから集計先のフォルダを返す
+// 指定した階層より深いファイルはその階層のフォルダに、浅いファイルは親フォルダに集計する
+func folderOf(relPath string, depth int) string {
+	parts := strings.Split(relPath, "/")
+	parts = parts[:len(parts)-1]
+	if len(parts) == 0 {
+		return RootFolder
+	}
+	if len(parts) > depth {
+		parts = parts[:depth]
+	}
+	return strings.Join(parts, "/")
+}
+
+// recordFolder はフォルダごとの集計を更新する（集計しない設定の場合は何もしない）
+func (fc *FileCopier) recordFolder(relPath string, update func(*FolderResult)) {
+	if fc.options.FolderStatsDepth <= 0 {
+		return
+	}
+	folder := folderOf(relPath, fc.options.FolderStatsDepth)
+
+	fc.folderMu.Lock()
+	defer fc.folderMu.Unlock()
+
+	if fc.folderCounts == nil {
+		fc.folderCounts = make(map[string]*FolderResult)
+	}
+	counts := fc.folderCounts[folder]
+	if counts == nil {
+		counts = &FolderResult{Folder: folder}
+		fc.folderCounts[folder] = counts
+	}
+	update(counts)
+}
+
+// countCopied はコピーしたファイルを数える
+func (fc *FileCopier) countCopied(relPath string, bytes int64) {
+	fc.stats.IncrementCopied(bytes)
+	fc.writeAudit(audit.Record{Action: audit.ActionCopy, Path: relPath, Size: bytes, Result: audit.ResultSuccess}, nil)
+	fc.recordFolder(relPath, func(r *FolderResult) {
+		r.Copied++
+		r.BytesCopied += bytes
+	})
+}
+
+// countSkipped はスキップしたファイルを数える
+func (fc *FileCopier) countSkipped(relPath string, bytes int64) {
+	fc.stats.IncrementSkipped(bytes)
+	fc.writeAudit(audit.Record{Action: audit.ActionSkip, Path: relPath, Size: bytes, Result: audit.ResultSuccess}, nil)
+	fc.recordFolder(relPath, func(r *FolderResult) {
+		r.Skipped++
+		r.BytesSkipped += bytes
+	})
+}
+
+// GetFolderResults はフォルダごとのコピー結果をフォルダ名の順に返す（集計しない場合はnil）
+func (fc *FileCopier) GetFolderResults() []FolderResult {
+	fc.folderMu.Lock()
+	defer fc.folderMu.Unlock()
+
+	if len(fc.folderCounts) == 0 {
+		return nil
+	}
+	results := make([]FolderResult, 0, len(fc.folderCounts))
+	for _, counts := range fc.folderCounts {
+		results = append(results, *counts)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Folder < results[j].Folder })
+	return results
+}
@@ -0,0 +1,183 @@
+package copier
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+func TestFolderOf(t *testing.T) {
+	tests := []struct {
+		relPath string
+		depth   int
+		want    string
+	}{
+		{"a.txt", 1, RootFolder},
+		{"team/a.txt", 1, "team"},
+		{"team/sub/deep/a.txt", 1, "team"},
+		{"team/sub/deep/a.txt", 2, "team/sub"},
+		{"team/a.txt", 2, "team"},
+	}
+	for _, tt := range tests {
+		if got := folderOf(tt.relPath, tt.depth); got != tt.want {
+			t.Errorf("folderOf(%q, %d) = %q, want %q", tt.relPath, tt.depth, got, tt.want)
+		}
+	}
+}
+
+func TestCopyFiles_FolderStats(t *testing.T) {
+	mem := vfs.NewMem()
+	sourceDir := filepath.Join("/", "source")
+	destDir := filepath.Join("/", "dest")
+	mem.WriteFile(filepath.Join(sourceDir, "readme.txt"), []byte("root"), 0644)
+	mem.WriteFile(filepath.Join(sourceDir, "sales", "a.txt"), []byte("aaaa"), 0644)
+	mem.WriteFile(filepath.Join(sourceDir, "sales", "2024", "b.txt"), []byte("bb"), 0644)
+	mem.WriteFile(filepath.Join(sourceDir, "dev", "c.txt"), []byte("c"), 0644)
+
+	// devは宛先の方を新しくして衝突による失敗にする
+	mem.WriteFile(filepath.Join(destDir, "dev", "c.txt"), []byte("newer"), 0644)
+	mem.Chtimes(filepath.Join(destDir, "dev", "c.txt"), time.Now(), time.Now().Add(time.Hour))
+
+	options := DefaultOptions()
+	options.FS = mem
+	options.MaxRetries = 0
+	options.RetryDelay = time.Millisecond
+	options.SkipNewer = true
+	options.Conflict = ConflictError
+	options.FolderStatsDepth = 1
+	fc := NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	fc.CopyFiles()
+
+	want := []FolderResult{
+		{Folder: RootFolder, Copied: 1, BytesCopied: 4},
+		{Folder: "dev", Failed: 1},
+		{Folder: "sales", Copied: 2, BytesCopied: 6},
+	}
+	got := fc.GetFolderResults()
+	if len(got) != len(want) {
+		t.Fatalf("フォルダ別の結果 = %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("フォルダ別の結果[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if p := got[1].CompletionPercent(); p != 0 {
+		t.Errorf("devの完了率 = %.1f, want 0", p)
+	}
+
+	// 再実行時はスキップとして数える
+	options.FolderStatsDepth = 2
+	fc = NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	fc.CopyFiles()
+	for _, r := range fc.GetFolderResults() {
+		if r.Folder == "sales/2024" && (r.Skipped != 1 || r.BytesSkipped != 2 || r.CompletionPercent() != 100) {
+			t.Errorf("sales/2024の結果 = %+v", r)
+		}
+	}
+
+	// 集計しない場合はnil
+	options.FolderStatsDepth = 0
+	fc = NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	fc.CopyFiles()
+	if results := fc.GetFolderResults(); results != nil {
+		t.Errorf("集計しない場合の結果 = %+v", results)
+	}
+}
+
+func TestCopyFiles_SlowestCount(t *testing.T) {
+	mem := vfs.NewMem()
+	sourceDir := filepath.Join("/", "source")
+	destDir := filepath.Join("/", "dest")
+	mem.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("aaaa"), 0644)
+	mem.WriteFile(filepath.Join(sourceDir, "sub", "b.txt"), []byte("bb"), 0644)
+	mem.WriteFile(filepath.Join(sourceDir, "sub", "c.txt"), []byte("c"), 0644)
+
+	options := DefaultOptions()
+	options.FS = mem
+	options.SlowestCount = 2
+	fc := NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFilesが失敗しました: %v", err)
+	}
+
+	files := fc.GetStats().GetSlowestFiles()
+	if len(files) != 2 {
+		t.Fatalf("処理時間の長いファイル = %+v", files)
+	}
+	sizes := map[string]int64{"a.txt": 4, "sub/b.txt": 2, "sub/c.txt": 1}
+	for _, f := range files {
+		if sizes[f.Path] != f.Bytes {
+			t.Errorf("%s: バイト数 = %d", f.Path, f.Bytes)
+		}
+	}
+	dirs := fc.GetStats().GetSlowestDirs()
+	if len(dirs) != 2 {
+		t.Fatalf("処理時間の長いディレクトリ = %+v", dirs)
+	}
+	for _, d := range dirs {
+		if (d.Dir == "sub" && d.Files != 2) || (d.Dir == "." && d.Files != 1) {
+			t.Errorf("ディレクトリ %s のファイル数 = %d", d.Dir, d.Files)
+		}
+	}
+
+	// 指定しない場合は記録しない
+	options.SlowestCount = 0
+	fc = NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	fc.CopyFiles()
+	if files := fc.GetStats().GetSlowestFiles(); len(files) != 0 {
+		t.Errorf("記録しない場合の結果 = %+v", files)
+	}
+}
+
+func TestCopyFiles_DetailedRecords(t *testing.T) {
+	sourceDir := filepath.Join("/", "source")
+	destDir := filepath.Join("/", "dest")
+	mem := vfs.NewMem()
+	mem.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("aaaa"), 0644)
+	mem.WriteFile(filepath.Join(sourceDir, "bad.txt"), []byte("bb"), 0644)
+
+	syncDB, err := database.NewSyncDB(filepath.Join(t.TempDir(), "test.db"), database.NormalSync)
+	if err != nil {
+		t.Fatalf("データベースの作成に失敗: %v", err)
+	}
+	defer syncDB.Close()
+
+	options := DefaultOptions()
+	options.MaxConcurrent = 2
+	options.MaxRetries = 2
+	options.RetryDelay = 0
+	options.DetailedRecords = true
+	options.FS = &eioFS{FS: mem, source: filepath.Join(sourceDir, "bad.txt")}
+	fc := NewFileCopier(sourceDir, destDir, options, nil, syncDB, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFilesが失敗しました: %v", err)
+	}
+
+	for path, retries := range map[string]int{"a.txt": 0, "bad.txt": 2} {
+		record, err := syncDB.GetFile(path)
+		if err != nil || record == nil {
+			t.Fatalf("%s: ファイル情報が取得できません: %v", path, err)
+		}
+		p := record.Processing
+		if p == nil {
+			t.Fatalf("%s: 処理の詳細が記録されていません", path)
+		}
+		if p.Retries != retries || p.Worker < 0 || p.Worker >= options.MaxConcurrent || p.Seconds <= 0 {
+			t.Errorf("%s: 処理の詳細 = %+v", path, p)
+		}
+	}
+
+	// 指定しない場合は記録しない
+	options.DetailedRecords = false
+	options.FS = mem
+	mem.WriteFile(filepath.Join(sourceDir, "c.txt"), []byte("c"), 0644)
+	fc = NewFileCopier(sourceDir, destDir, options, nil, syncDB, nil)
+	fc.CopyFiles()
+	if record, _ := syncDB.GetFile("c.txt"); record == nil || record.Processing != nil {
+		t.Errorf("記録しない場合のファイル情報 = %+v", record)
+	}
+}
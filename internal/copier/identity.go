@@ -0,0 +1,214 @@
+package copier
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/fsmeta"
+	"github.com/sakuhanight/gopier/internal/runas"
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+// ソース・宛先へのファイルシステム操作は、資格情報が指定されていればその資格情報で行う。
+// 開いたファイルの読み書きは開いた時点の資格情報で許可されるため、切り替えはパスを扱う操作にのみ行う
+// 操作はオプションで指定したファイルシステム（既定ではOSのファイルシステム）に対して行う
+
+// statSource はソースのファイル情報を取得する
+func (fc *FileCopier) statSource(path string) (info os.FileInfo, err error) {
+	err = runas.Run(fc.options.SourceIdentity, func() error {
+		info, err = fc.fs.Stat(path)
+		return err
+	})
+	return info, err
+}
+
+// statDest は宛先のファイル情報を取得する
+func (fc *FileCopier) statDest(path string) (info os.FileInfo, err error) {
+	err = runas.Run(fc.options.DestIdentity, func() error {
+		info, err = fc.fs.Stat(path)
+		return err
+	})
+	return info, err
+}
+
+// readSourceDir はソースのディレクトリのエントリを取得する
+func (fc *FileCopier) readSourceDir(path string) (entries []os.DirEntry, err error) {
+	err = runas.Run(fc.options.SourceIdentity, func() error {
+		entries, err = fc.fs.ReadDir(path)
+		return err
+	})
+	return entries, err
+}
+
+// readDestDir は宛先のディレクトリのエントリを取得する
+func (fc *FileCopier) readDestDir(path string) (entries []os.DirEntry, err error) {
+	err = runas.Run(fc.options.DestIdentity, func() error {
+		entries, err = fc.fs.ReadDir(path)
+		return err
+	})
+	return entries, err
+}
+
+// entryInfo はソースのディレクトリエントリのファイル情報を取得する
+func (fc *FileCopier) entryInfo(entry os.DirEntry) (info os.FileInfo, err error) {
+	err = runas.Run(fc.options.SourceIdentity, func() error {
+		info, err = entry.Info()
+		return err
+	})
+	return info, err
+}
+
+// openSource はソースファイルを開く
+func (fc *FileCopier) openSource(path string) (file vfs.File, err error) {
+	err = runas.Run(fc.options.SourceIdentity, func() error {
+		file, err = fc.fs.Open(path)
+		return err
+	})
+	return file, err
+}
+
+// createDest は宛先ファイルを作成する
+func (fc *FileCopier) createDest(path string) (file vfs.File, err error) {
+	err = runas.Run(fc.options.DestIdentity, func() error {
+		file, err = fc.fs.Create(path)
+		return err
+	})
+	if err == nil {
+		fc.journalOpened(path)
+	}
+	return file, err
+}
+
+// openDest は宛先のファイルを読み込み用に開く
+func (fc *FileCopier) openDest(path string) (file vfs.File, err error) {
+	err = runas.Run(fc.options.DestIdentity, func() error {
+		file, err = fc.fs.Open(path)
+		return err
+	})
+	return file, err
+}
+
+// openDestWrite は既存の宛先ファイルを書き込み用に開く
+func (fc *FileCopier) openDestWrite(path string) (file vfs.File, err error) {
+	err = runas.Run(fc.options.DestIdentity, func() error {
+		file, err = fc.fs.OpenFile(path, os.O_WRONLY, 0)
+		return err
+	})
+	if err == nil {
+		fc.journalOpened(path)
+	}
+	return file, err
+}
+
+// mkdirDest は宛先のディレクトリを作成する
+func (fc *FileCopier) mkdirDest(path string) error {
+	return runas.Run(fc.options.DestIdentity, func() error {
+		return fc.fs.MkdirAll(path, 0755)
+	})
+}
+
+// chtimesDest は宛先の更新日時を設定する（アクセス日時は現在の日時）
+func (fc *FileCopier) chtimesDest(path string, modTime time.Time) error {
+	return fc.setDestTimes(path, time.Now(), modTime)
+}
+
+// copyTimes はソースの更新日時を宛先に設定する
+// アクセス日時の保持を指定した場合は、コピーの前に取得したソースのアクセス日時も設定する（取得できない環境では現在の日時）
+func (fc *FileCopier) copyTimes(path string, sourceInfo os.FileInfo) error {
+	atime := time.Now()
+	if accessTime := fc.sourceAccessTime(sourceInfo); accessTime != nil {
+		atime = *accessTime
+	}
+	return fc.setDestTimes(path, atime, sourceInfo.ModTime())
+}
+
+// sourceAccessTime はアクセス日時の保持を指定した場合に、ソースのアクセス日時を返す（それ以外はnil）
+func (fc *FileCopier) sourceAccessTime(sourceInfo os.FileInfo) *time.Time {
+	if !fc.options.PreserveAtime {
+		return nil
+	}
+	if atime, ok := fsmeta.AccessTime(sourceInfo); ok {
+		return &atime
+	}
+	return nil
+}
+
+// setDestTimes は宛先のアクセス日時と更新日時を設定する（ナノ秒の精度のまま渡し、丸めはファイルシステムに任せる）
+func (fc *FileCopier) setDestTimes(path string, atime, modTime time.Time) error {
+	return runas.Run(fc.options.DestIdentity, func() error {
+		return fc.fs.Chtimes(path, atime, modTime)
+	})
+}
+
+// collectSourceMeta はソースのメタデータを収集する（OS以外のファイルシステムでは収集しない）
+func (fc *FileCopier) collectSourceMeta(path string) (meta *fsmeta.Metadata, err error) {
+	if !vfs.IsOS(fc.fs) {
+		return nil, nil
+	}
+	err = runas.Run(fc.options.SourceIdentity, func() error {
+		meta, err = fsmeta.Collect(path)
+		return err
+	})
+	return meta, err
+}
+
+// copyPermissions はソースのアクセス権を宛先に設定する（宛先の資格情報で行う）
+// OS以外のファイルシステムではモードのアクセス権のみを設定する
+func (fc *FileCopier) copyPermissions(sourcePath, destPath string) error {
+	if !vfs.IsOS(fc.fs) {
+		info, err := fc.fs.Stat(sourcePath)
+		if err == nil {
+			err = fc.fs.Chmod(destPath, info.Mode().Perm())
+		}
+		return errcode.Wrap(errcode.ErrPermissionCopy, err)
+	}
+
+	err := runas.Run(fc.options.DestIdentity, func() error {
+		return fsmeta.CopyPermissions(sourcePath, destPath)
+	})
+	return errcode.Wrap(errcode.ErrPermissionCopy, err)
+}
+
+// hashFile は資格情報を切り替えてファイルのハッシュ値を計算する
+func (fc *FileCopier) hashFile(identity runas.Identity, path string) (hash string, err error) {
+	err = runas.Run(identity, func() error {
+		open := fc.fs.Open
+		if fc.options.DropCache {
+			open = func(name string) (vfs.File, error) { return vfs.OpenUncached(fc.fs, name) }
+		}
+		file, err := open(path)
+		if err != nil {
+			return fmt.Errorf("ファイルを開けません: %w", err)
+		}
+		defer file.Close()
+
+		hash, err = fc.hasher.HashReader(file)
+		return err
+	})
+	return hash, err
+}
+
+// createDestTemp は宛先のディレクトリに一時ファイルを作成する
+func (fc *FileCopier) createDestTemp(dir, pattern string) (file vfs.File, err error) {
+	err = runas.Run(fc.options.DestIdentity, func() error {
+		file, err = fc.fs.CreateTemp(dir, pattern)
+		return err
+	})
+	return file, err
+}
+
+// renameDest は宛先のファイルまたはディレクトリの名前を変更する
+func (fc *FileCopier) renameDest(oldPath, newPath string) error {
+	return runas.Run(fc.options.DestIdentity, func() error {
+		return fc.fs.Rename(oldPath, newPath)
+	})
+}
+
+// removeDest は宛先のファイルを削除する
+func (fc *FileCopier) removeDest(path string) error {
+	return runas.Run(fc.options.DestIdentity, func() error {
+		return fc.fs.Remove(path)
+	})
+}
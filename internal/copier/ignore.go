@@ -0,0 +1,39 @@
+package copier
+
+import (
+	"github.com/sakuhanight/gopier/internal/audit"
+	"github.com/sakuhanight/gopier/internal/filter"
+	"github.com/sakuhanight/gopier/internal/pathkey"
+)
+
+// ignoreErrors はパスのエラーを無視する（失敗として扱わない）かどうかを判断する
+func (fc *FileCopier) ignoreErrors(relPath string) bool {
+	return filter.MatchesUnder(relPath, fc.options.IgnoreErrorsOn)
+}
+
+// countFailed はファイルの失敗を数え、監査ログに記録する
+// エラーを無視するパスの場合は、失敗とは別に数える
+func (fc *FileCopier) countFailed(relPath string, err error) {
+	fc.writeAudit(audit.Record{Action: audit.ActionCopy, Path: relPath, Result: audit.ResultFailed}, err)
+	if fc.ignoreErrors(relPath) {
+		fc.stats.IncrementIgnored()
+		return
+	}
+	fc.stats.IncrementFailed()
+	fc.recordFolder(relPath, func(r *FolderResult) { r.Failed++ })
+}
+
+// ignoreWalkError はディレクトリの走査中のエラーを無視できる場合に記録し、trueを返す
+// 無視できない場合はコピー全体を中断するため、falseを返す
+func (fc *FileCopier) ignoreWalkError(sourcePath string, err error) bool {
+	relPath, relErr := pathkey.Rel(fc.sourceDir, sourcePath)
+	if relErr != nil || sourcePath == fc.sourceDir || !fc.ignoreErrors(relPath) {
+		return false
+	}
+
+	fc.stats.IncrementIgnored()
+	if fc.logger != nil {
+		fc.logger.Warn("エラーを無視: %s: %v", relPath, err)
+	}
+	return true
+}
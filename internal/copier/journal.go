@@ -0,0 +1,193 @@
+package copier
+
+import (
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/transform"
+)
+
+// journalCopy はコピー中のファイルのジャーナルの記録
+type journalCopy struct {
+	mu       sync.Mutex
+	paths    []string // コピーする宛先
+	entry    database.JournalEntry
+	opened   bool // 宛先を書き込み用に開き、ジャーナルに記録した
+	recorded bool // ファイル情報をDBに記録した（ジャーナルの記録も同時に削除される）
+}
+
+// beginCopy はコピー中のファイルのジャーナルの記録を準備する
+// ジャーナルには宛先を書き込み用に開いた時点で、開いた宛先のみを記録する（journalOpened）
+// 記録はファイル情報をDBに記録した時点で削除され、異常終了した場合は次回の起動時にrecoverJournalで照合する
+// 戻り値はコピーを終えた時にendCopyに渡す（DBを使用しない場合はnil）
+func (fc *FileCopier) beginCopy(relPath string, destPaths []string, sourceInfo os.FileInfo, change database.ChangeKind, transformers []transform.Transformer) *journalCopy {
+	if fc.db == nil {
+		return nil
+	}
+
+	entry := database.JournalEntry{
+		Path:      relPath,
+		Size:      sourceInfo.Size(),
+		ModTime:   sourceInfo.ModTime(),
+		DestSize:  -1,
+		Change:    change,
+		SessionID: atomic.LoadInt64(&fc.sessionID),
+		StartedAt: time.Now(),
+	}
+	// 変換する場合は書き込み後のサイズが分からないため、書き込みを終えたかどうかを確認できない
+	if len(transformers) == 0 {
+		entry.DestSize = sourceInfo.Size()
+		if fc.options.PreserveModTime {
+			entry.DestModTime = sourceInfo.ModTime()
+		}
+	}
+
+	j := &journalCopy{paths: destPaths, entry: entry}
+	for _, path := range destPaths {
+		fc.journals.Store(path, j)
+	}
+	return j
+}
+
+// journalOpened は宛先を書き込み用に開いた時に、開いた宛先をジャーナルに記録する
+// 開いていない宛先は記録しないため、異常終了しても今回の実行で書き込んでいない宛先は削除されない
+func (fc *FileCopier) journalOpened(path string) {
+	v, ok := fc.journals.Load(path)
+	if !ok {
+		return
+	}
+	j := v.(*journalCopy)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, opened := range j.entry.DestPaths {
+		if opened == path {
+			return
+		}
+	}
+	j.entry.DestPaths = append(j.entry.DestPaths, path)
+	j.opened = true
+	if err := fc.db.BeginCopy(j.entry); err != nil && fc.logger != nil {
+		fc.logger.Warn("ジャーナルの記録エラー: %s: %v", j.entry.Path, err)
+	}
+}
+
+// markRecorded はファイル情報をDBに記録したことを記録する
+func (j *journalCopy) markRecorded() {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	j.recorded = true
+	j.mu.Unlock()
+}
+
+// endCopy はコピーを終えた時にジャーナルの記録を片付ける
+// ファイル情報をDBに記録せずに終えた場合（使用中や後回しにした再試行）は、書き込み途中の可能性がある
+// 開いた宛先を削除してからジャーナルの記録を削除する（宛先を削除できなかった場合は次回の起動時に照合する）
+func (fc *FileCopier) endCopy(j *journalCopy) {
+	if j == nil {
+		return
+	}
+	for _, path := range j.paths {
+		fc.journals.CompareAndDelete(path, j)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if !j.opened || j.recorded {
+		return
+	}
+	for _, destPath := range j.entry.DestPaths {
+		if err := fc.removeDest(destPath); err != nil && !os.IsNotExist(err) {
+			if fc.logger != nil {
+				fc.logger.Warn("書き込み途中の宛先を削除できません: %s: %v", destPath, err)
+			}
+			return
+		}
+	}
+	if err := fc.db.ClearJournal(j.entry.Path); err != nil && fc.logger != nil {
+		fc.logger.Warn("ジャーナルの削除エラー: %s: %v", j.entry.Path, err)
+	}
+}
+
+// recoverJournal は前回の実行で書き込みの途中またはDBへの記録の前に異常終了したファイルを照合する
+// ジャーナルには前回の実行で書き込み用に開いた宛先のみが記録されている
+// サイズと更新日時から書き込みを終えていることを確認できた宛先は成功として記録し、
+// それ以外の宛先は書き込み途中の可能性があるため削除して、今回の実行でコピーし直す
+// （書き込み途中のファイルは宛先の方が新しいため、削除しないとスキップされる場合がある。
+// 更新日時を保持しない場合や変換する場合は書き込みを終えたことを確認できないため、開いた宛先は常に削除する）
+func (fc *FileCopier) recoverJournal() {
+	if fc.db == nil || fc.options.Mode == ModeVerify {
+		return
+	}
+
+	entries, err := fc.db.GetJournal()
+	if err != nil {
+		if fc.logger != nil {
+			fc.logger.Warn("ジャーナルの読み込みエラー: %v", err)
+		}
+		return
+	}
+
+	var completed, removed int
+	for _, entry := range entries {
+		complete := true
+		failed := false
+		for _, destPath := range entry.DestPaths {
+			destInfo, err := fc.statDest(destPath)
+			if os.IsNotExist(err) {
+				complete = false
+				continue
+			}
+			if err == nil && entry.Complete(destInfo.Size(), destInfo.ModTime()) {
+				continue
+			}
+			complete = false
+			if err == nil {
+				err = fc.removeDest(destPath)
+			}
+			if err != nil && !os.IsNotExist(err) {
+				// 削除できなかった場合は記録を残し、次回の起動時に再度照合する
+				failed = true
+				if fc.logger != nil {
+					fc.logger.Warn("中断されたコピーの宛先を削除できません: %s: %v", destPath, err)
+				}
+				continue
+			}
+			removed++
+			if fc.logger != nil && fc.logger.Verbose {
+				fc.logger.Info("中断されたコピーの宛先を削除しました: %s", destPath)
+			}
+		}
+		if failed {
+			continue
+		}
+
+		record := database.FileInfo{
+			Path:         entry.Path,
+			Size:         entry.Size,
+			ModTime:      entry.ModTime,
+			Status:       database.StatusPending,
+			LastSyncTime: time.Now(),
+			LastError:    "コピーが中断されました",
+			Reason:       database.ReasonInterrupted,
+		}
+		if complete {
+			// 書き込みを終えてからDBに記録するまでの間に中断されたファイル
+			completed++
+			record.Status = database.StatusSuccess
+			record.LastError, record.Reason = "", ""
+			record.SessionID = entry.SessionID
+			record.Change = entry.Change
+		}
+		fc.db.AddFile(record)
+	}
+
+	if len(entries) > 0 && fc.logger != nil {
+		fc.logger.Warn("前回中断されたコピーを照合しました: %d件（書き込み済み: %d件, 削除した宛先: %d件）", len(entries), completed, removed)
+	}
+}
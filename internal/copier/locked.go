@@ -0,0 +1,105 @@
+package copier
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/sakuhanight/gopier/internal/errcode"
+)
+
+// LockedFile は他のプロセスが使用中のためコピーできなかったファイルを表す構造体
+type LockedFile struct {
+	Path string // ファイルの相対パス
+	Err  error  // 共有違反のエラー
+}
+
+// deferredFile は終了時に再試行するファイル
+type deferredFile struct {
+	relPath    string
+	sourcePath string
+	destPath   string
+	err        error
+}
+
+// lockedFiles は使用中のファイルの記録
+type lockedFiles struct {
+	mu       sync.Mutex
+	deferred []deferredFile // 終了時に再試行するファイル
+	retrying bool           // 終了時の再試行中（再び使用中の場合は失敗として扱う）
+	files    []LockedFile
+}
+
+// deferLocked は使用中のファイルを終了時の再試行の対象として記録する
+// 使用中のエラーでない場合、再試行しない設定の場合、終了時の再試行中の場合はfalseを返す
+func (fc *FileCopier) deferLocked(relPath, sourcePath, destPath string, err error) bool {
+	if !fc.options.RetryLocked || !sharingViolation(err) {
+		return false
+	}
+	fc.lockedFiles.mu.Lock()
+	defer fc.lockedFiles.mu.Unlock()
+	if fc.lockedFiles.retrying {
+		return false
+	}
+	fc.lockedFiles.deferred = append(fc.lockedFiles.deferred, deferredFile{
+		relPath:    relPath,
+		sourcePath: sourcePath,
+		destPath:   destPath,
+		err:        err,
+	})
+	if fc.logger != nil {
+		fc.logger.Warn("他のプロセスが使用中のため終了時に再試行します: %s", relPath)
+	}
+	return true
+}
+
+// lockedError は使用中のエラーであれば使用中のファイルとして記録し、種類を付けたエラーを返す
+// 使用中のエラーでない場合はerrをそのまま返す
+func (fc *FileCopier) lockedError(relPath string, err error) error {
+	if !sharingViolation(err) {
+		return err
+	}
+	err = errcode.Wrap(errcode.ErrLocked, err)
+	fc.stats.IncrementLocked()
+	fc.lockedFiles.mu.Lock()
+	fc.lockedFiles.files = append(fc.lockedFiles.files, LockedFile{Path: relPath, Err: err})
+	fc.lockedFiles.mu.Unlock()
+	return err
+}
+
+// retryLocked は使用中だったファイルを、他のファイルのコピーがすべて終わった後に一度だけ再試行する
+// キャンセルされた場合は再試行せず、使用中のファイルとして記録する
+// （後回しにした時点で書き込み途中の宛先とジャーナルの記録はendCopyで削除している）
+func (fc *FileCopier) retryLocked() {
+	fc.lockedFiles.mu.Lock()
+	deferred := fc.lockedFiles.deferred
+	fc.lockedFiles.deferred = nil
+	fc.lockedFiles.retrying = true
+	fc.lockedFiles.mu.Unlock()
+
+	if len(deferred) == 0 {
+		return
+	}
+	if fc.logger != nil && fc.ctx.Err() == nil {
+		fc.logger.Info("使用中だったファイルを再試行します: %d件", len(deferred))
+	}
+	for _, f := range deferred {
+		if fc.ctx.Err() != nil {
+			err := fc.lockedError(f.relPath, f.err)
+			fc.countFailed(f.relPath, err)
+			fc.stats.RecordError(f.relPath, err)
+			fc.recordFailure(f.relPath, err)
+			continue
+		}
+		fc.copyAsync(f.sourcePath, f.destPath)
+	}
+	fc.wg.Wait()
+}
+
+// GetLockedFiles は他のプロセスが使用中のためコピーできなかったファイルを相対パスの順に返す
+func (fc *FileCopier) GetLockedFiles() []LockedFile {
+	fc.lockedFiles.mu.Lock()
+	defer fc.lockedFiles.mu.Unlock()
+	files := append([]LockedFile(nil), fc.lockedFiles.files...)
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files
+}
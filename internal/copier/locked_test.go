@@ -0,0 +1,89 @@
+package copier
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+// lockingFS は指定した回数だけファイルを開けない（他のプロセスが使用中）ファイルシステム
+type lockingFS struct {
+	vfs.FS
+	mu     sync.Mutex
+	locked map[string]int // パスごとの開けない残りの回数
+}
+
+func (l *lockingFS) Open(name string) (vfs.File, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.locked[name] > 0 {
+		l.locked[name]--
+		return nil, errTestSharing
+	}
+	return l.FS.Open(name)
+}
+
+func newLockingFS(t *testing.T, sourceDir string, locks int) *lockingFS {
+	t.Helper()
+	mem := vfs.NewMem()
+	mem.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("aaa"), 0644)
+	mem.WriteFile(filepath.Join(sourceDir, "locked.txt"), []byte("in use"), 0644)
+	return &lockingFS{FS: mem, locked: map[string]int{filepath.Join(sourceDir, "locked.txt"): locks}}
+}
+
+func TestCopyFiles_LockedFile(t *testing.T) {
+	stubSharingViolation(t)
+	sourceDir := filepath.Join("/", "source")
+	destDir := filepath.Join("/", "dest")
+
+	// 共有違反の再試行を使い切った場合は通常のリトライを行わず、使用中のファイルとして失敗にする
+	options := DefaultOptions()
+	options.FS = newLockingFS(t, sourceDir, 100)
+	options.SharingRetries = 1
+	options.SharingRetryDelay = 0
+	options.MaxRetries = 3
+	fc := NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFilesが失敗しました: %v", err)
+	}
+	if remaining := options.FS.(*lockingFS).locked[filepath.Join(sourceDir, "locked.txt")]; remaining != 98 {
+		t.Errorf("開こうとした回数 = %d", 100-remaining)
+	}
+	locked := fc.GetLockedFiles()
+	if len(locked) != 1 || locked[0].Path != "locked.txt" || !errors.Is(locked[0].Err, errcode.ErrLocked) {
+		t.Errorf("使用中のファイル = %+v", locked)
+	}
+	failures := fc.GetFailures()
+	if len(failures) != 1 || errcode.Of(failures[0].Err) != errcode.CodeLocked {
+		t.Errorf("失敗したファイル = %+v", failures)
+	}
+	if fc.stats.GetLockedCount() != 1 || fc.stats.GetCopiedCount() != 1 {
+		t.Errorf("使用中 = %d, コピー = %d", fc.stats.GetLockedCount(), fc.stats.GetCopiedCount())
+	}
+
+	// 終了時の再試行で使用中でなくなった場合はコピーする
+	options.FS = newLockingFS(t, sourceDir, 2)
+	options.RetryLocked = true
+	fc = NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFilesが失敗しました: %v", err)
+	}
+	if locked := fc.GetLockedFiles(); len(locked) != 0 {
+		t.Errorf("再試行後の使用中のファイル = %+v", locked)
+	}
+	if fc.stats.GetCopiedCount() != 2 || fc.stats.GetFailedCount() != 0 {
+		t.Errorf("コピー = %d, 失敗 = %d", fc.stats.GetCopiedCount(), fc.stats.GetFailedCount())
+	}
+
+	// 終了時の再試行でも使用中の場合は失敗にする
+	options.FS = newLockingFS(t, sourceDir, 100)
+	fc = NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	fc.CopyFiles()
+	if locked := fc.GetLockedFiles(); len(locked) != 1 || fc.stats.GetFailedCount() != 1 {
+		t.Errorf("使用中のファイル = %+v, 失敗 = %d", locked, fc.stats.GetFailedCount())
+	}
+}
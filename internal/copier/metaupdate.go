@@ -0,0 +1,107 @@
+package copier
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/audit"
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/transform"
+)
+
+// updateMetadataOnly は内容が同じファイル（サイズとハッシュが一致）の更新日時とアクセス権のみを宛先に適用する
+// 更新日時やアクセス権だけが変わったファイルを再コピーしないために使用し、適用した場合はtrueを返す
+// 内容が異なる場合や確認・適用できなかった場合はfalseを返し、通常どおりコピーする
+func (fc *FileCopier) updateMetadataOnly(sourcePath, destPath, relPath string, sourceInfo, destInfo os.FileInfo, transformers []transform.Transformer) bool {
+	if !fc.options.MetadataOnlyUpdates || len(transformers) > 0 || sourceInfo.Size() != destInfo.Size() {
+		return false
+	}
+
+	// 空のファイルは内容を比較するまでもなく同じ
+	var hash string
+	if sourceInfo.Size() > 0 {
+		sourceHash, err := fc.hashFile(fc.options.SourceIdentity, sourcePath)
+		if err != nil {
+			return false
+		}
+		destHash, err := fc.hashFile(fc.options.DestIdentity, destPath)
+		if err != nil || destHash != sourceHash {
+			return false
+		}
+		hash = sourceHash
+	}
+
+	if err := fc.applyFileMetadata(sourcePath, destPath, sourceInfo); err != nil {
+		if fc.logger != nil {
+			fc.logger.Warn("メタデータのみの更新に失敗したため再コピーします: %s: %v", relPath, err)
+		}
+		return false
+	}
+	fc.countMetaUpdated(relPath, sourceInfo.Size(), hash)
+
+	// データベースに記録（宛先の更新日時を変更したため、変更の一覧では更新として扱う）
+	if fc.db != nil {
+		info := database.FileInfo{
+			Path:         relPath,
+			Size:         sourceInfo.Size(),
+			ModTime:      sourceInfo.ModTime(),
+			Status:       database.StatusSuccess,
+			SourceHash:   hash,
+			DestHash:     hash,
+			LastSyncTime: time.Now(),
+			SessionID:    atomic.LoadInt64(&fc.sessionID),
+			Change:       database.ChangeUpdated,
+		}
+		if hash != "" {
+			info.HashAlgo = fc.options.HashAlgorithm
+		}
+		if meta, err := fc.collectSourceMeta(sourcePath); err == nil {
+			info.Meta = meta
+		}
+		fc.db.AddFile(info)
+	}
+
+	if fc.logger != nil {
+		if fc.logger.Verbose {
+			fc.logger.Info("メタデータのみ更新（内容同一）: %s", relPath)
+		} else {
+			fc.logger.Info("メタデータ更新: %s", relPath)
+		}
+	}
+	return true
+}
+
+// applyFileMetadata はソースの更新日時とアクセス権を宛先に適用する（保持・変更する設定の場合のみ）
+func (fc *FileCopier) applyFileMetadata(sourcePath, destPath string, sourceInfo os.FileInfo) error {
+	// 更新日時の保持
+	if fc.options.PreserveModTime {
+		if err := fc.copyTimes(destPath, sourceInfo); err != nil {
+			// loggerでエラー出力
+			if fc.logger != nil && fc.logger.Verbose {
+				fc.logger.Error("更新日時の設定エラー: %s: %v", destPath, err)
+			}
+			return fmt.Errorf("更新日時の設定エラー: %w", err)
+		}
+	}
+
+	// アクセス権の保持・変更（内容はコピーできているため、エラーは呼び出し元で警告として扱う）
+	if fc.setsPermissions() {
+		if err := fc.setPermissions(sourcePath, destPath, false); err != nil {
+			return fmt.Errorf("アクセス権の設定エラー: %w", err)
+		}
+	}
+	return nil
+}
+
+// countMetaUpdated はメタデータのみ更新したファイルを数える（内容は転送していないため、スキップにも含める）
+func (fc *FileCopier) countMetaUpdated(relPath string, bytes int64, hash string) {
+	fc.stats.IncrementSkipped(bytes)
+	fc.stats.IncrementMetaUpdated()
+	fc.writeAudit(audit.Record{Action: audit.ActionMetadata, Path: relPath, Size: bytes, SourceHash: hash, DestHash: hash, Result: audit.ResultSuccess}, nil)
+	fc.recordFolder(relPath, func(r *FolderResult) {
+		r.Skipped++
+		r.BytesSkipped += bytes
+	})
+}
@@ -0,0 +1,71 @@
+package copier
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+func TestCopyFiles_MetadataOnlyUpdates(t *testing.T) {
+	mem := vfs.NewMem()
+	sourceDir := filepath.Join("/", "source")
+	destDir := filepath.Join("/", "dest")
+	touched := filepath.Join(sourceDir, "touched.txt")
+	empty := filepath.Join(sourceDir, "empty.txt")
+	edited := filepath.Join(sourceDir, "edited.txt")
+	mem.WriteFile(touched, []byte("same content"), 0644)
+	mem.WriteFile(empty, nil, 0644)
+	mem.WriteFile(edited, []byte("abc"), 0644)
+	mem.WriteFile(filepath.Join(sourceDir, "unchanged.txt"), []byte("unchanged"), 0644)
+
+	options := DefaultOptions()
+	options.FS = mem
+	options.PreservePermissions = true
+	options.MetadataOnlyUpdates = true
+	fc := NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFiles() エラー: %v", err)
+	}
+
+	// 内容が同じファイルは更新日時・アクセス権のみ、内容が変わったファイル（サイズは同じ）は再コピーする
+	later := time.Now().Add(time.Hour).Truncate(time.Second)
+	for _, path := range []string{touched, empty, edited} {
+		mem.Chtimes(path, later, later)
+	}
+	mem.Chmod(touched, 0600)
+	mem.WriteFile(edited, []byte("xyz"), 0644)
+	mem.Chtimes(edited, later, later)
+
+	fc = NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFiles() エラー: %v", err)
+	}
+	st := fc.GetStats()
+	if got := st.GetMetaUpdatedCount(); got != 2 {
+		t.Errorf("メタデータのみ更新した件数 = %d, want 2", got)
+	}
+	if copied, skipped := st.GetCopiedCount(), st.GetSkippedCount(); copied != 1 || skipped != 3 {
+		t.Errorf("コピー件数 = %d, スキップ件数 = %d, want 1, 3", copied, skipped)
+	}
+
+	destInfo, _ := mem.Stat(filepath.Join(destDir, "touched.txt"))
+	if !destInfo.ModTime().Equal(later) || destInfo.Mode().Perm() != 0600 {
+		t.Errorf("宛先のファイル情報 = %v %v", destInfo.ModTime(), destInfo.Mode())
+	}
+	if data, _ := mem.ReadFile(filepath.Join(destDir, "edited.txt")); string(data) != "xyz" {
+		t.Errorf("内容が変わったファイルの宛先の内容 = %q", data)
+	}
+
+	// 無効な場合は更新日時が変わったファイルを再コピーする
+	mem.Chtimes(touched, later.Add(time.Hour), later.Add(time.Hour))
+	options.MetadataOnlyUpdates = false
+	fc = NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFiles() エラー: %v", err)
+	}
+	if got := fc.GetStats().GetMetaUpdatedCount(); got != 0 || fc.GetStats().GetCopiedCount() != 1 {
+		t.Errorf("無効な場合: メタデータのみ更新 = %d, コピー = %d", got, fc.GetStats().GetCopiedCount())
+	}
+}
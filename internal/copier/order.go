@@ -0,0 +1,92 @@
+package copier
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// CopyOrder はファイルをコピーする順序を表す型
+type CopyOrder string
+
+const (
+	// OrderWalk はソースを走査した順にコピーする
+	OrderWalk CopyOrder = "walk"
+	// OrderNewestFirst は更新日時の新しいファイルから順にコピーする
+	// 実行できる時間が限られる場合に、最近更新されたファイルを先に宛先に揃える
+	OrderNewestFirst CopyOrder = "newest-first"
+)
+
+// ParseCopyOrder はコピーする順序の指定を解析する（空の場合は走査した順）
+func ParseCopyOrder(s string) (CopyOrder, error) {
+	switch CopyOrder(s) {
+	case "", OrderWalk:
+		return OrderWalk, nil
+	case OrderNewestFirst:
+		return OrderNewestFirst, nil
+	}
+	return "", fmt.Errorf("不明なコピーの順序: %s (walk, newest-firstのいずれかを指定してください)", s)
+}
+
+// queuedFile は順序を決めるため、走査を終えるまでコピーを待つファイル
+type queuedFile struct {
+	sourcePath string
+	destPath   string
+	modTime    time.Time
+	ticket     uint64 // 処理待ちとして記録したときの番号
+}
+
+// enqueueCopy はファイルをコピーする
+// 走査した順にコピーしない場合は記録のみ行い、走査を終えた後にcopyQueuedで順に並べてコピーする
+// （走査は1つのゴルーチンで行うため、記録は排他しない）
+func (fc *FileCopier) enqueueCopy(sourcePath, destPath string, info os.FileInfo) {
+	if fc.options.CopyOrder != OrderNewestFirst || info == nil {
+		fc.copyAsync(sourcePath, destPath)
+		return
+	}
+	fc.queued = append(fc.queued, queuedFile{
+		sourcePath: sourcePath,
+		destPath:   destPath,
+		modTime:    info.ModTime(),
+		ticket:     fc.stats.Enqueue(),
+	})
+}
+
+// copyQueued は走査を終えるまで待たせたファイルを、指定した順に並べてコピーする
+// ワーカーの空きを待ってから次のファイルのコピーを開始するため、コピーを開始する順序は指定した順になる
+// （並行してコピーするため、完了する順序はおおよそになる）
+func (fc *FileCopier) copyQueued() {
+	queued := fc.queued
+	fc.queued = nil
+	if len(queued) == 0 {
+		return
+	}
+
+	// 更新日時が同じファイルは、実行ごとに順序が変わらないようパスの順にする
+	sort.SliceStable(queued, func(i, j int) bool {
+		if !queued[i].modTime.Equal(queued[j].modTime) {
+			return queued[i].modTime.After(queued[j].modTime)
+		}
+		return queued[i].sourcePath < queued[j].sourcePath
+	})
+	if fc.logger != nil && fc.logger.Verbose {
+		fc.logger.Info("更新日時の新しい順にコピーします: %dファイル", len(queued))
+	}
+
+	for i, f := range queued {
+		fc.semaphore <- struct{}{}
+		if fc.ctx.Err() != nil {
+			<-fc.semaphore
+			for _, rest := range queued[i:] {
+				fc.stats.Dequeue(rest.ticket)
+			}
+			return
+		}
+		fc.wg.Add(1)
+		go func(f queuedFile) {
+			defer fc.wg.Done()
+			fc.runCopy(f.sourcePath, f.destPath, f.ticket)
+		}(f)
+	}
+}
@@ -0,0 +1,41 @@
+package copier
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sakuhanight/gopier/internal/overlap"
+)
+
+// overlapError は宛先（追加の宛先を含む）のいずれかがソースと同じディレクトリの場合にエラーを返す
+func (fc *FileCopier) overlapError() error {
+	for _, dest := range fc.destinations() {
+		if overlap.Detect(fc.sourceDir, dest) == overlap.Same {
+			return fmt.Errorf("宛先(%s)はソース(%s)と同じディレクトリです", dest, fc.sourceDir)
+		}
+	}
+	return nil
+}
+
+// guardDestinations はソースの中にある宛先を、コピー中に走査しないよう除外する
+// 宛先を作成した後に呼び出し、パスが一致しない場合でも、シンボリックリンクやマウントポイントを経由して到達した宛先を見分ける
+func (fc *FileCopier) guardDestinations() {
+	for _, dest := range fc.destinations() {
+		if overlap.Detect(fc.sourceDir, dest) == overlap.DestInSource && fc.logger != nil {
+			fc.logger.Warn("宛先(%s)はソースの中にあるため、コピーの対象から除外します", dest)
+		}
+	}
+	fc.destGuard = overlap.NewGuard(fc.statDest, fc.destinations()...)
+}
+
+// isDestDir はソースのディレクトリが宛先のディレクトリかどうかを返す
+func (fc *FileCopier) isDestDir(sourcePath string, entry os.DirEntry) bool {
+	if fc.destGuard == nil {
+		return false
+	}
+	info, err := fc.entryInfo(entry)
+	if err != nil {
+		info = nil
+	}
+	return fc.destGuard.IsDest(sourcePath, info)
+}
@@ -0,0 +1,54 @@
+package copier
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCopyFiles_DestInSource(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "data")
+	os.MkdirAll(filepath.Join(sourceDir, "docs"), 0755)
+	os.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "docs", "b.txt"), []byte("b"), 0644)
+
+	// 宛先がソースの中にある場合は、宛先を走査せずにコピーする（2回目は前回コピーした宛先を含めない）
+	destDir := filepath.Join(sourceDir, "backup")
+	for i := 0; i < 2; i++ {
+		fc := NewFileCopier(sourceDir, destDir, DefaultOptions(), nil, nil, nil)
+		if err := fc.Preflight(); err != nil {
+			t.Fatalf("Preflight() エラー: %v", err)
+		}
+		if err := fc.CopyFiles(); err != nil {
+			t.Fatalf("CopyFiles() エラー: %v", err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "docs", "b.txt")); err != nil {
+		t.Errorf("ファイルがコピーされていません: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "backup")); !os.IsNotExist(err) {
+		t.Errorf("宛先の中に宛先がコピーされました: %v", err)
+	}
+
+	// シンボリックリンクを経由してソースを指定した場合も宛先を見分ける
+	link := filepath.Join(tempDir, "link")
+	if os.Symlink(sourceDir, link) == nil {
+		fc := NewFileCopier(link+string(filepath.Separator), destDir, DefaultOptions(), nil, nil, nil)
+		if err := fc.CopyFiles(); err != nil {
+			t.Fatalf("CopyFiles() エラー: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(destDir, "backup")); !os.IsNotExist(err) {
+			t.Errorf("シンボリックリンク経由で宛先の中に宛先がコピーされました: %v", err)
+		}
+	}
+
+	// ソースと同じ宛先はコピーを始める前にエラーにする
+	fc := NewFileCopier(sourceDir, sourceDir+string(filepath.Separator), DefaultOptions(), nil, nil, nil)
+	if err := fc.Preflight(); err == nil {
+		t.Error("ソースと同じ宛先でPreflightがエラーになりません")
+	}
+	if err := fc.CopyFiles(); err == nil {
+		t.Error("ソースと同じ宛先でCopyFilesがエラーになりません")
+	}
+}
@@ -0,0 +1,55 @@
+package copier
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/sakuhanight/gopier/internal/database"
+)
+
+// permFailures は内容はコピーしたが、アクセス権をコピーできなかったファイルの記録
+type permFailures struct {
+	mu    sync.Mutex
+	files []CopyFailure
+}
+
+// recordPermFailure は内容はコピーしたがアクセス権をコピーできなかったファイルを記録する
+// 内容は宛先に揃っているため、コピーの失敗とは区別して警告として出力する
+func (fc *FileCopier) recordPermFailure(relPath string, err error) {
+	fc.stats.IncrementPermFailed()
+	if fc.logger != nil {
+		if fc.logger.Verbose {
+			fc.logger.Warn("ファイル '%s' の内容はコピーしましたが、アクセス権をコピーできませんでした: %v", relPath, err)
+		} else {
+			fc.logger.Warn("アクセス権のコピー失敗: %s", relPath)
+		}
+	}
+	fc.permFailures.mu.Lock()
+	fc.permFailures.files = append(fc.permFailures.files, CopyFailure{Path: relPath, Err: err})
+	fc.permFailures.mu.Unlock()
+}
+
+// retryPermissions は前回の実行でアクセス権をコピーできなかったファイルに、アクセス権のみ再度設定する
+// 対象でない場合と設定できた場合はnilを、設定できなかった場合は記録してエラーを返す
+func (fc *FileCopier) retryPermissions(sourcePath, destPath, relPath string, record *database.FileInfo) error {
+	if record == nil || record.Status != database.StatusDataOKPermissionFailed || !fc.setsPermissions() {
+		return nil
+	}
+	if err := fc.setPermissions(sourcePath, destPath, false); err != nil {
+		fc.recordPermFailure(relPath, err)
+		return err
+	}
+	if fc.logger != nil && fc.logger.Verbose {
+		fc.logger.Info("前回コピーできなかったアクセス権を設定しました: %s", relPath)
+	}
+	return nil
+}
+
+// GetPermFailures は内容はコピーしたが、アクセス権をコピーできなかったファイルを相対パスの順に返す
+func (fc *FileCopier) GetPermFailures() []CopyFailure {
+	fc.permFailures.mu.Lock()
+	defer fc.permFailures.mu.Unlock()
+	files := append([]CopyFailure(nil), fc.permFailures.files...)
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files
+}
@@ -0,0 +1,85 @@
+package copier
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+// chmodDeniedFS は宛先のファイルのアクセス権を設定できないファイルシステム
+type chmodDeniedFS struct {
+	vfs.FS
+	denied bool
+}
+
+func (f *chmodDeniedFS) Chmod(name string, mode os.FileMode) error {
+	if f.denied {
+		return &os.PathError{Op: "chmod", Path: name, Err: os.ErrPermission}
+	}
+	return f.FS.Chmod(name, mode)
+}
+
+func TestCopyFiles_PermissionFailure(t *testing.T) {
+	mem := vfs.NewMem()
+	sourceDir := filepath.Join("/", "source")
+	destDir := filepath.Join("/", "dest")
+	mem.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("aaa"), 0600)
+	fs := &chmodDeniedFS{FS: mem, denied: true}
+
+	syncDB, err := database.NewSyncDB(filepath.Join(t.TempDir(), "test.db"), database.NormalSync)
+	if err != nil {
+		t.Fatalf("データベースの作成に失敗: %v", err)
+	}
+	defer syncDB.Close()
+
+	options := DefaultOptions()
+	options.FS = fs
+	options.PreservePermissions = true
+	options.MaxRetries = 3
+	options.RetryDelay = 0
+
+	// アクセス権のみコピーできなかった場合は、内容はコピーしたものとして数え、失敗とは区別する
+	fc := NewFileCopier(sourceDir, destDir, options, nil, syncDB, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFiles() エラー: %v", err)
+	}
+	if data, err := mem.ReadFile(filepath.Join(destDir, "a.txt")); err != nil || string(data) != "aaa" {
+		t.Errorf("宛先の内容 = %q, %v", data, err)
+	}
+	if fc.stats.GetCopiedCount() != 1 || fc.stats.GetFailedCount() != 0 || fc.stats.GetPermFailedCount() != 1 {
+		t.Errorf("コピー = %d, 失敗 = %d, アクセス権 = %d",
+			fc.stats.GetCopiedCount(), fc.stats.GetFailedCount(), fc.stats.GetPermFailedCount())
+	}
+	if failures := fc.GetFailures(); len(failures) != 0 {
+		t.Errorf("失敗したファイル = %+v", failures)
+	}
+	perm := fc.GetPermFailures()
+	if len(perm) != 1 || perm[0].Path != "a.txt" || !errors.Is(perm[0].Err, errcode.ErrPermissionCopy) {
+		t.Errorf("アクセス権をコピーできなかったファイル = %+v", perm)
+	}
+	record, err := syncDB.GetFile("a.txt")
+	if err != nil || record == nil || record.Status != database.StatusDataOKPermissionFailed {
+		t.Fatalf("DBの記録 = %+v, %v", record, err)
+	}
+
+	// 次回の実行では内容をコピーし直さず、アクセス権のみ再度設定する
+	fs.denied = false
+	fc = NewFileCopier(sourceDir, destDir, options, nil, syncDB, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFiles() エラー: %v", err)
+	}
+	if fc.stats.GetSkippedCount() != 1 || fc.stats.GetPermFailedCount() != 0 {
+		t.Errorf("スキップ = %d, アクセス権 = %d", fc.stats.GetSkippedCount(), fc.stats.GetPermFailedCount())
+	}
+	if info, err := mem.Stat(filepath.Join(destDir, "a.txt")); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("宛先のアクセス権 = %v, %v", info, err)
+	}
+	if record, _ := syncDB.GetFile("a.txt"); record == nil || record.Status != database.StatusSkipped {
+		t.Errorf("再設定後のDBの記録 = %+v", record)
+	}
+}
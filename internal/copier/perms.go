@@ -0,0 +1,54 @@
+package copier
+
+import (
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/runas"
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+// setsPermissions は宛先のアクセス権・所有者を設定するかどうかを返す（保持する設定、または--chmod・--chownの指定がある場合）
+func (fc *FileCopier) setsPermissions() bool {
+	return fc.options.PreservePermissions || fc.options.Chmod != nil || fc.options.Owner != nil
+}
+
+// setPermissions は宛先のアクセス権・所有者を設定する
+// 保持する設定の場合はソースのアクセス権をコピーし、Ownerの指定がある場合は所有者を変更する
+// Chmodの指定がある場合はソースのアクセス権に指定を適用した値に変更する（所有者の変更で外れるsetuidなどのビットは戻さない）
+func (fc *FileCopier) setPermissions(sourcePath, destPath string, isDir bool) error {
+	if fc.options.PreservePermissions {
+		if err := fc.copyPermissions(sourcePath, destPath); err != nil {
+			return err
+		}
+	}
+	// OS以外のファイルシステムには所有者がない
+	if fc.options.Owner != nil && vfs.IsOS(fc.fs) {
+		err := runas.Run(fc.options.DestIdentity, func() error {
+			return fc.options.Owner.Chown(destPath)
+		})
+		if err != nil {
+			return errcode.Wrap(errcode.ErrPermissionCopy, err)
+		}
+	}
+	if fc.options.Chmod == nil {
+		return nil
+	}
+
+	info, err := fc.statSource(sourcePath)
+	if err != nil {
+		return errcode.Wrap(errcode.ErrPermissionCopy, err)
+	}
+	mode := fc.options.Chmod.Apply(info.Mode().Perm(), isDir)
+	err = runas.Run(fc.options.DestIdentity, func() error {
+		return fc.fs.Chmod(destPath, mode)
+	})
+	return errcode.Wrap(errcode.ErrPermissionCopy, err)
+}
+
+// ownerMismatch は宛先の所有者がOwnerの指定と一致しない場合にtrueを返す（指定がない場合・確認できない場合はfalse）
+func (fc *FileCopier) ownerMismatch(destPath string) bool {
+	if fc.options.Owner == nil {
+		return false
+	}
+	info, err := fc.statDest(destPath)
+	return err == nil && !fc.options.Owner.Matches(info)
+}
@@ -0,0 +1,49 @@
+package copier
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sakuhanight/gopier/internal/chmod"
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+func TestCopyFiles_Chmod(t *testing.T) {
+	mem := vfs.NewMem()
+	sourceDir := filepath.Join("/", "source")
+	destDir := filepath.Join("/", "dest")
+	mem.WriteFile(filepath.Join(sourceDir, "index.html"), []byte("<html>"), 0777)
+	mem.WriteFile(filepath.Join(sourceDir, "cgi", "run.sh"), []byte("#!/bin/sh"), 0700)
+	mem.Chmod(filepath.Join(sourceDir, "cgi"), 0700)
+
+	mask, err := chmod.Parse("D755,F644")
+	if err != nil {
+		t.Fatalf("chmod.Parse() エラー: %v", err)
+	}
+	options := DefaultOptions()
+	options.FS = mem
+	options.Chmod = mask
+	fc := NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFiles() エラー: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want os.FileMode
+	}{
+		{"index.html", 0644},
+		{"cgi", 0755},
+		{filepath.Join("cgi", "run.sh"), 0644},
+	}
+	for _, tt := range tests {
+		info, err := mem.Stat(filepath.Join(destDir, tt.path))
+		if err != nil {
+			t.Fatalf("宛先に%sがありません: %v", tt.path, err)
+		}
+		if got := info.Mode().Perm(); got != tt.want {
+			t.Errorf("%sのアクセス権 = %o, want %o", tt.path, got, tt.want)
+		}
+	}
+}
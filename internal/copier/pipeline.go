@@ -0,0 +1,88 @@
+package copier
+
+import (
+	"io"
+	"sync"
+)
+
+// minPipelineChunk は先読みする1チャンクの最小サイズ
+const minPipelineChunk = 64 * 1024
+
+// pipelineChunk は読み込みのゴルーチンから書き込み側に渡すデータ
+type pipelineChunk struct {
+	data []byte
+	err  error
+}
+
+// pipelineCopy はソースの読み込みと宛先への書き込みを別のゴルーチンで行い、
+// 最大depth個のチャンクを先読みすることで読み込みと書き込みを重ねる
+// バッファはbufferSizeをdepth個に分けて使い回すため、使用するメモリは同期的なコピーと変わらない
+func pipelineCopy(dst io.Writer, src io.Reader, bufferSize, depth int) (int64, error) {
+	chunkSize := bufferSize / depth
+	if chunkSize < minPipelineChunk {
+		chunkSize = minPipelineChunk
+	}
+
+	free := make(chan []byte, depth)
+	for i := 0; i < depth; i++ {
+		free <- make([]byte, chunkSize)
+	}
+	filled := make(chan pipelineChunk, depth)
+	done := make(chan struct{})
+
+	// 書き込みが失敗した場合は読み込みを止め、ゴルーチンの終了を待ってから戻る
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer close(done)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(filled)
+		for {
+			var buf []byte
+			select {
+			case buf = <-free:
+			case <-done:
+				return
+			}
+
+			n, err := src.Read(buf)
+			if n > 0 {
+				select {
+				case filled <- pipelineChunk{data: buf[:n]}:
+				case <-done:
+					return
+				}
+			} else {
+				free <- buf
+			}
+			if err != nil {
+				if err != io.EOF {
+					select {
+					case filled <- pipelineChunk{err: err}:
+					case <-done:
+					}
+				}
+				return
+			}
+		}
+	}()
+
+	var written int64
+	for chunk := range filled {
+		if chunk.err != nil {
+			return written, chunk.err
+		}
+		n, err := dst.Write(chunk.data)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+		if n != len(chunk.data) {
+			return written, io.ErrShortWrite
+		}
+		free <- chunk.data[:cap(chunk.data)]
+	}
+	return written, nil
+}
@@ -0,0 +1,122 @@
+package copier
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// delayedReader は読み込みごとに待機するReader（低速なストレージの模擬）
+type delayedReader struct {
+	r     io.Reader
+	delay time.Duration
+}
+
+func (d *delayedReader) Read(p []byte) (int, error) {
+	time.Sleep(d.delay)
+	return d.r.Read(p)
+}
+
+// delayedWriter は書き込みごとに待機するWriter
+type delayedWriter struct {
+	w     io.Writer
+	delay time.Duration
+}
+
+func (d *delayedWriter) Write(p []byte) (int, error) {
+	time.Sleep(d.delay)
+	return d.w.Write(p)
+}
+
+// failingWriter は指定したバイト数を超えると失敗するWriter
+type failingWriter struct {
+	limit int
+	n     int
+}
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	if f.n+len(p) > f.limit {
+		return 0, errors.New("書き込みエラー")
+	}
+	f.n += len(p)
+	return len(p), nil
+}
+
+func TestPipelineCopy(t *testing.T) {
+	data := make([]byte, 3*1024*1024+17)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	for _, depth := range []int{1, 4, 16} {
+		var dst bytes.Buffer
+		n, err := pipelineCopy(&dst, bytes.NewReader(data), 1024*1024, depth)
+		if err != nil {
+			t.Fatalf("depth=%d: pipelineCopyが失敗しました: %v", depth, err)
+		}
+		if n != int64(len(data)) || !bytes.Equal(dst.Bytes(), data) {
+			t.Errorf("depth=%d: コピーした内容が一致しません (%dバイト)", depth, n)
+		}
+	}
+
+	// 空の入力
+	var dst bytes.Buffer
+	if n, err := pipelineCopy(&dst, bytes.NewReader(nil), 1024*1024, 4); err != nil || n != 0 {
+		t.Errorf("空の入力: n = %d, err = %v", n, err)
+	}
+}
+
+func TestPipelineCopy_Errors(t *testing.T) {
+	data := make([]byte, 1024*1024)
+
+	// 書き込みエラーで読み込みも停止すること
+	if _, err := pipelineCopy(&failingWriter{limit: 200 * 1024}, bytes.NewReader(data), 256*1024, 4); err == nil {
+		t.Error("書き込みエラーが返されませんでした")
+	}
+
+	// 読み込みエラーが返されること
+	readErr := errors.New("読み込みエラー")
+	src := io.MultiReader(bytes.NewReader(data[:1000]), &errReader{err: readErr})
+	var dst bytes.Buffer
+	n, err := pipelineCopy(&dst, src, 256*1024, 4)
+	if !errors.Is(err, readErr) {
+		t.Errorf("err = %v, want %v", err, readErr)
+	}
+	if n != 1000 {
+		t.Errorf("エラーまでに書き込んだバイト数 = %d, want 1000", n)
+	}
+}
+
+type errReader struct{ err error }
+
+func (e *errReader) Read([]byte) (int, error) { return 0, e.err }
+
+// BenchmarkCopyLoop は読み込みと書き込みがそれぞれ遅いストレージでの、同期的なコピーと先読みの比較
+func BenchmarkCopyLoop(b *testing.B) {
+	const size = 4 * 1024 * 1024
+	const bufferSize = 1024 * 1024
+	const delay = 2 * time.Millisecond
+	data := make([]byte, size)
+
+	b.Run("Sync", func(b *testing.B) {
+		b.SetBytes(size)
+		for i := 0; i < b.N; i++ {
+			src := &delayedReader{r: bytes.NewReader(data), delay: delay}
+			dst := &delayedWriter{w: io.Discard, delay: delay}
+			if _, err := io.CopyBuffer(dst, src, make([]byte, bufferSize/4)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("ReadAhead", func(b *testing.B) {
+		b.SetBytes(size)
+		for i := 0; i < b.N; i++ {
+			src := &delayedReader{r: bytes.NewReader(data), delay: delay}
+			dst := &delayedWriter{w: io.Discard, delay: delay}
+			if _, err := pipelineCopy(dst, src, bufferSize, 4); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package copier
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/rofs"
+	"github.com/sakuhanight/gopier/internal/runas"
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+// preflightTime は事前確認で設定する更新日時（FATの2秒単位でも表現できる値）
+var preflightTime = time.Date(2000, 1, 2, 3, 4, 6, 0, time.UTC)
+
+// PreflightError は事前確認で見つかった宛先で実行できない操作を表す
+type PreflightError struct {
+	Destination string // 宛先ディレクトリ
+	Operation   string // 実行できなかった操作
+	Err         error
+	// ReadOnly は宛先が読み取り専用でマウントされているため実行できないことを表す
+	ReadOnly bool
+}
+
+func (e *PreflightError) Error() string {
+	if e.ReadOnly {
+		return fmt.Sprintf("宛先(%s)は読み取り専用でマウントされているため、%sができません: %v"+
+			"（NASのフェイルオーバーの後などに読み取り専用で再マウントされることがあります。マウントの状態を確認してください）",
+			e.Destination, e.Operation, e.Err)
+	}
+	return fmt.Sprintf("宛先(%s)で%sができません: %v", e.Destination, e.Operation, e.Err)
+}
+
+func (e *PreflightError) Unwrap() error {
+	return e.Err
+}
+
+// Is は事前確認のエラーをerrcode.ErrPreflight（読み取り専用の場合はerrcode.ErrReadOnlyも）として判別できるようにする
+func (e *PreflightError) Is(target error) bool {
+	return target == errcode.ErrPreflight || (e.ReadOnly && target == errcode.ErrReadOnly)
+}
+
+// Preflight はコピーを始める前に、各宛先のディレクトリでファイルの作成・更新日時の設定・
+// アクセス権・所有者の設定・削除ができるかを、一時ファイルを使って確認する
+// 大量のデータをコピーした後で失敗に気付くことがないよう、オプションで必要な操作のみ確認する
+// 宛先が読み取り専用でマウントされている場合は、ファイルごとに失敗する前にマウントのフラグで検出する
+// 宛先がソースと同じディレクトリの場合もエラーを返す
+func (fc *FileCopier) Preflight() error {
+	if err := fc.overlapError(); err != nil {
+		return err
+	}
+	for _, root := range fc.destinations() {
+		if err := fc.preflightDest(root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// preflightDest は1つの宛先ディレクトリで必要な操作を確認する
+func (fc *FileCopier) preflightDest(root string) error {
+	fail := func(operation string, err error) error {
+		return &PreflightError{Destination: root, Operation: operation, Err: err, ReadOnly: rofs.IsReadOnly(err)}
+	}
+
+	// マウントの状態を取得できるローカルのファイルシステムは、書き込む前に読み取り専用かを確認する
+	if vfs.IsOS(fc.fs) {
+		if readOnly, err := rofs.Check(root); err == nil && readOnly {
+			return &PreflightError{Destination: root, Operation: "書き込み", Err: errcode.ErrReadOnly, ReadOnly: true}
+		}
+	}
+
+	if fc.options.CreateDirs {
+		if err := fc.mkdirDest(root); err != nil {
+			return fail("ディレクトリの作成", err)
+		}
+	}
+
+	file, err := fc.createDestTemp(root, ".gopier-preflight-*")
+	if err != nil {
+		return fail("ファイルの作成", err)
+	}
+	path := file.Name()
+	removed := false
+	defer func() {
+		if !removed {
+			fc.removeDest(path)
+		}
+	}()
+
+	_, err = file.Write([]byte("gopier"))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fail("ファイルの書き込み", err)
+	}
+
+	if fc.options.PreserveModTime {
+		if err := fc.chtimesDest(path, preflightTime); err != nil {
+			return fail("更新日時の設定", err)
+		}
+		// エラーにならずに無視されるファイルシステムもあるため、設定した値を読み戻す
+		info, err := fc.statDest(path)
+		if err != nil {
+			return fail("更新日時の設定", err)
+		}
+		if diff := info.ModTime().Sub(preflightTime); diff < -2*time.Second || diff > 2*time.Second {
+			return fail("更新日時の設定", fmt.Errorf("設定した更新日時が反映されません（設定: %s, 実際: %s）",
+				preflightTime.Format(time.RFC3339), info.ModTime().Format(time.RFC3339)))
+		}
+	}
+
+	if fc.options.PreservePermissions {
+		if err := fc.copyPermissions(fc.sourceDir, path); err != nil {
+			return fail("アクセス権の設定", err)
+		}
+	}
+
+	// 他のユーザーを所有者にするには権限が必要なため、指定した所有者に変更できるかを確認する（OS以外のファイルシステムには所有者がない）
+	if fc.options.Owner != nil && vfs.IsOS(fc.fs) {
+		err := runas.Run(fc.options.DestIdentity, func() error {
+			return fc.options.Owner.Chown(path)
+		})
+		if err != nil {
+			return fail("所有者の変更", err)
+		}
+		// エラーにならずに無視されるファイルシステムもあるため、設定した所有者を読み戻す
+		if fc.ownerMismatch(path) {
+			return fail("所有者の変更", fmt.Errorf("設定した所有者が反映されません（指定: %s）", fc.options.Owner))
+		}
+	}
+
+	removed = true
+	if err := fc.removeDest(path); err != nil {
+		return fail("ファイルの削除", err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package copier
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/fsmeta"
+)
+
+func TestPreflight(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(sourceDir, 0755)
+
+	options := DefaultOptions()
+	options.PreservePermissions = true
+	fc := NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.Preflight(); err != nil {
+		t.Fatalf("Preflightが失敗しました: %v", err)
+	}
+
+	// 一時ファイルが残っていないこと
+	entries, err := os.ReadDir(destDir)
+	if err != nil {
+		t.Fatalf("宛先ディレクトリが作成されていません: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("一時ファイルが残っています: %v", entries)
+	}
+}
+
+func TestPreflight_Owner(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windowsでは所有者の指定に対応していません")
+	}
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(sourceDir, 0755)
+
+	// 他のユーザーへの変更はroot権限でのみ成功する
+	options := DefaultOptions()
+	options.Owner, _ = fsmeta.ParseOwner(strconv.Itoa(os.Getuid() + 1))
+	err := NewFileCopier(sourceDir, destDir, options, nil, nil, nil).Preflight()
+	if os.Geteuid() == 0 {
+		if err != nil {
+			t.Errorf("Preflightが失敗しました: %v", err)
+		}
+	} else {
+		var preflightErr *PreflightError
+		if !errors.As(err, &preflightErr) || preflightErr.Operation != "所有者の変更" {
+			t.Errorf("Preflight() = %v, want 所有者の変更のエラー", err)
+		}
+	}
+
+	entries, _ := os.ReadDir(destDir)
+	if len(entries) != 0 {
+		t.Errorf("一時ファイルが残っています: %v", entries)
+	}
+}
+
+func TestPreflight_Failure(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	os.MkdirAll(sourceDir, 0755)
+
+	// 宛先のパスにファイルがあるためディレクトリを作成できない
+	blocked := filepath.Join(tempDir, "blocked")
+	os.WriteFile(blocked, []byte("file"), 0644)
+
+	options := DefaultOptions()
+	options.ExtraDestinations = []string{blocked}
+	fc := NewFileCopier(sourceDir, filepath.Join(tempDir, "dest"), options, nil, nil, nil)
+	err := fc.Preflight()
+
+	var preflightErr *PreflightError
+	if !errors.As(err, &preflightErr) {
+		t.Fatalf("PreflightErrorが返されませんでした: %v", err)
+	}
+	if preflightErr.Destination != blocked || preflightErr.Operation != "ディレクトリの作成" {
+		t.Errorf("エラー = %+v", preflightErr)
+	}
+}
+
+// TestPreflightError_ReadOnly は読み取り専用の宛先を専用の終了コードで判別できることをテスト
+func TestPreflightError_ReadOnly(t *testing.T) {
+	err := errcode.Wrap(errcode.ErrPreflight, &PreflightError{Destination: "/mnt/nas", Operation: "書き込み", Err: errcode.ErrReadOnly, ReadOnly: true})
+	if !errors.Is(err, errcode.ErrPreflight) || !errors.Is(err, errcode.ErrReadOnly) {
+		t.Errorf("エラーの種類を判別できません: %v", err)
+	}
+	if got := errcode.ExitCode(err); got != errcode.ExitReadOnly {
+		t.Errorf("ExitCode() = %d, want %d", got, errcode.ExitReadOnly)
+	}
+	if !strings.Contains(err.Error(), "読み取り専用でマウント") {
+		t.Errorf("エラーメッセージ = %q", err.Error())
+	}
+
+	// 読み取り専用でない事前確認のエラーは従来の終了コードのまま
+	err = &PreflightError{Destination: "/mnt/nas", Operation: "削除", Err: os.ErrPermission}
+	if errors.Is(err, errcode.ErrReadOnly) || errcode.ExitCode(err) != errcode.ExitPreflight {
+		t.Errorf("ExitCode() = %d, want %d", errcode.ExitCode(err), errcode.ExitPreflight)
+	}
+}
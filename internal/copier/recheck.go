@@ -0,0 +1,37 @@
+package copier
+
+import (
+	"github.com/sakuhanight/gopier/internal/recheck"
+	"github.com/sakuhanight/gopier/internal/transform"
+)
+
+// recheckMismatch はハッシュが一致しなかったファイルを、ソースと宛先を読み直して最大MismatchRetries回再検証する
+// 再検証で一致した場合はソース（変換する場合は変換前）と宛先のハッシュとtrueを返す。attemptsは再検証した回数
+func (fc *FileCopier) recheckMismatch(sourcePath, destPath, relPath string, transformers []transform.Transformer) (sourceHash, destHash string, attempts int, ok bool) {
+	opts := recheck.Options{Retries: fc.options.MismatchRetries, Delay: fc.options.MismatchRetryDelay, Logger: fc.logger}
+	_, destHash, attempts, ok = recheck.Run(fc.ctx, opts, relPath, func() (string, string, error) {
+		expectedHash := ""
+		if len(transformers) > 0 {
+			info, err := fc.hashTransformed(sourcePath, transformers)
+			if err != nil {
+				return "", "", err
+			}
+			sourceHash, expectedHash = info.OriginalHash, info.OutputHash
+		} else {
+			var err error
+			if sourceHash, err = fc.hashFile(fc.options.SourceIdentity, sourcePath); err != nil {
+				return "", "", err
+			}
+			expectedHash = sourceHash
+		}
+		destHash, err := fc.hashFile(fc.options.DestIdentity, destPath)
+		if err != nil {
+			return "", "", err
+		}
+		return expectedHash, fc.options.Faults.CorruptHash(destHash), nil
+	})
+	if !ok {
+		return "", "", attempts, false
+	}
+	return sourceHash, destHash, attempts, true
+}
@@ -0,0 +1,209 @@
+package copier
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/pathkey"
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+// DefaultResumeInterval は再開用のトークンを記録する書き込みサイズの間隔のデフォルト値
+const DefaultResumeInterval = 64 * 1024 * 1024
+
+// resumableDest は宛先が中断した書き込みを再開できるファイルシステムの場合に、そのファイルシステムを返す
+// 再開用のトークンはDBに記録するため、DBを使用しない場合は再開しない
+func (fc *FileCopier) resumableDest() (vfs.Resumable, bool) {
+	if fc.db == nil || fc.options.ResumeInterval <= 0 {
+		return nil, false
+	}
+	r, ok := fc.fs.(vfs.Resumable)
+	return r, ok
+}
+
+// doCopyFileResumable は書き込みの途中の状態を記録しながらファイルをコピーする
+// 前回の実行で中断した書き込みがあり、ソースが変更されていない場合は続きから書き込む。
+// コピーに失敗した場合は書き込みを完了させずに閉じ、記録したトークンから次回の実行（またはリトライ）で再開する
+func (fc *FileCopier) doCopyFileResumable(r vfs.Resumable, sourcePath, destPath string, sourceInfo os.FileInfo) error {
+	relPath, err := pathkey.Rel(fc.sourceDir, sourcePath)
+	if err != nil {
+		return err
+	}
+	destRel, err := pathkey.Rel(fc.destDir, destPath)
+	if err != nil {
+		return err
+	}
+
+	sourceFile, err := fc.openSource(sourcePath)
+	if err != nil {
+		return errcode.Errorf(errcode.ErrSourceRead, "ソースファイル(%s)を開けません: %w", sourcePath, err)
+	}
+	defer sourceFile.Close()
+
+	destFile, offset, err := fc.openResumable(r, relPath, destRel, destPath, sourceInfo)
+	if err != nil {
+		return fmt.Errorf("宛先ファイル(%s)を作成できません: %w", destPath, err)
+	}
+	if offset > 0 {
+		if _, err := sourceFile.Seek(offset, io.SeekStart); err != nil {
+			r.Suspend(destFile)
+			return errcode.Errorf(errcode.ErrSourceRead, "ソースファイル(%s)の読み込みエラー: %w", sourcePath, err)
+		}
+		if fc.logger != nil {
+			fc.logger.Info("中断した書き込みを再開します: %s (%d/%d bytes)", relPath, offset, sourceInfo.Size())
+		}
+	}
+
+	writer := &checkpointWriter{
+		fc:   fc,
+		r:    r,
+		file: destFile,
+		record: database.ResumeToken{
+			Path:     relPath,
+			DestPath: destRel,
+			Size:     sourceInfo.Size(),
+			ModTime:  sourceInfo.ModTime(),
+		},
+	}
+	reader := fc.sourceReader(sourceFile)
+	var copiedBytes int64
+	if fc.options.ReadAhead > 0 {
+		copiedBytes, err = pipelineCopy(writer, reader, fc.options.BufferSize, fc.options.ReadAhead)
+	} else {
+		copiedBytes, err = io.CopyBuffer(writer, reader, make([]byte, fc.options.BufferSize))
+	}
+	if err != nil {
+		// 書き込めた位置までのトークンを記録してから、完了させずに閉じる
+		writer.checkpoint()
+		if suspendErr := r.Suspend(destFile); suspendErr != nil && fc.logger != nil && fc.logger.Verbose {
+			fc.logger.Warn("中断した書き込みを閉じられません: %s: %v", relPath, suspendErr)
+		}
+		return fmt.Errorf("ファイルコピーエラー: %w", err)
+	}
+
+	if offset+copiedBytes != sourceInfo.Size() && fc.logger != nil && fc.logger.Verbose {
+		fc.logger.Warn("コピーされたバイト数が一致しません: 期待値=%d, 実際=%d", sourceInfo.Size(), offset+copiedBytes)
+	}
+
+	if err := destFile.Close(); err != nil {
+		return fmt.Errorf("宛先ファイル(%s)を閉じられません: %w", destPath, err)
+	}
+	if err := fc.db.DeleteResumeToken(relPath); err != nil && fc.logger != nil {
+		fc.logger.Warn("再開用のトークンを削除できません: %s: %v", relPath, err)
+	}
+
+	return fc.applyFileMetadata(sourcePath, destPath, sourceInfo)
+}
+
+// openResumable は宛先のファイルを書き込み用に開き、書き込みを始める位置を返す
+// 再開用のトークンがあり、ソースが記録した時点から変更されていない場合は中断した書き込みを再開する。
+// 再開できない場合は中断した書き込みを破棄し、最初から書き込む
+func (fc *FileCopier) openResumable(r vfs.Resumable, relPath, destRel, destPath string, sourceInfo os.FileInfo) (vfs.File, int64, error) {
+	token, err := fc.db.GetResumeToken(relPath)
+	if err != nil && fc.logger != nil && fc.logger.Verbose {
+		fc.logger.Warn("データベース検索エラー: %v", err)
+	}
+
+	if token != nil {
+		if token.DestPath == destRel && token.Size == sourceInfo.Size() && token.ModTime.Equal(sourceInfo.ModTime()) {
+			file, offset, err := r.Resume(destPath, token.Token)
+			if err == nil && offset >= 0 && offset <= sourceInfo.Size() {
+				return file, offset, nil
+			}
+			if err == nil {
+				r.Suspend(file)
+				err = fmt.Errorf("再開する位置が不正です (%d/%d bytes)", offset, sourceInfo.Size())
+			}
+			if fc.logger != nil {
+				fc.logger.Warn("中断した書き込みを再開できないため、最初から書き込みます: %s: %v", relPath, err)
+			}
+		}
+		fc.discardResume(r, *token)
+	}
+
+	file, err := fc.createDest(destPath)
+	return file, 0, err
+}
+
+// discardResume は中断した書き込みを破棄し、再開用のトークンの記録を削除する
+// ストレージでの破棄に失敗しても記録は削除する（残った書き込みはストレージの期限で削除される）
+func (fc *FileCopier) discardResume(r vfs.Resumable, token database.ResumeToken) {
+	destPath := filepath.Join(fc.destDir, pathkey.ToNative(token.DestPath))
+	if err := r.Discard(destPath, token.Token); err != nil && !os.IsNotExist(err) && fc.logger != nil {
+		fc.logger.Warn("中断した書き込みを破棄できません: %s: %v", token.Path, err)
+	}
+	if err := fc.db.DeleteResumeToken(token.Path); err != nil && fc.logger != nil {
+		fc.logger.Warn("再開用のトークンを削除できません: %s: %v", token.Path, err)
+	}
+}
+
+// discardStaleResumes はソースから削除されたファイルの中断した書き込みを破棄する
+// 削除されたファイルはコピーの対象にならず、再開も破棄もされないまま残るため、実行の開始時に照合する
+func (fc *FileCopier) discardStaleResumes() {
+	r, ok := fc.resumableDest()
+	if !ok || fc.options.Mode == ModeVerify {
+		return
+	}
+
+	tokens, err := fc.db.GetResumeTokens()
+	if err != nil {
+		if fc.logger != nil {
+			fc.logger.Warn("再開用のトークンの読み込みエラー: %v", err)
+		}
+		return
+	}
+	for _, token := range tokens {
+		if _, err := fc.statSource(filepath.Join(fc.sourceDir, pathkey.ToNative(token.Path))); os.IsNotExist(err) {
+			fc.discardResume(r, token)
+		}
+	}
+}
+
+// checkpointWriter は書き込んだサイズが間隔を超えるごとに、宛先のストレージから再開用のトークンを取得してDBに記録する
+type checkpointWriter struct {
+	fc      *FileCopier
+	r       vfs.Resumable
+	file    vfs.File
+	record  database.ResumeToken
+	pending int64 // 前回トークンを記録してから書き込んだサイズ
+}
+
+func (w *checkpointWriter) Write(p []byte) (int, error) {
+	n, err := w.file.Write(p)
+	w.pending += int64(n)
+	if err == nil && w.pending >= w.fc.options.ResumeInterval {
+		w.checkpoint()
+	}
+	return n, err
+}
+
+// checkpoint は再開用のトークンを記録する（記録に失敗しても書き込みは続ける）
+func (w *checkpointWriter) checkpoint() {
+	if w.pending == 0 {
+		return
+	}
+	w.pending = 0
+
+	token, offset, err := w.r.Checkpoint(w.file)
+	if err != nil {
+		if w.fc.logger != nil && w.fc.logger.Verbose {
+			w.fc.logger.Warn("再開用のトークンを取得できません: %s: %v", w.record.Path, err)
+		}
+		return
+	}
+	if token == "" {
+		return
+	}
+
+	w.record.Token = token
+	w.record.Offset = offset
+	w.record.UpdatedAt = time.Now()
+	if err := w.fc.db.SaveResumeToken(w.record); err != nil && w.fc.logger != nil {
+		w.fc.logger.Warn("再開用のトークンを記録できません: %s: %v", w.record.Path, err)
+	}
+}
@@ -0,0 +1,139 @@
+package copier
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/sakuhanight/gopier/internal/errcode"
+)
+
+// DefaultSegmentThreshold は分割コピーの対象とするファイルサイズのデフォルト値
+const DefaultSegmentThreshold = 1024 * 1024 * 1024 // 1GB
+
+// segmented はファイルを分割して並行にコピーするかどうかを判断する
+func (fc *FileCopier) segmented(sourceInfo os.FileInfo) bool {
+	return fc.options.SegmentsPerFile > 1 && sourceInfo.Size() >= fc.options.SegmentThreshold && sourceInfo.Size() > 0
+}
+
+// segmentRanges はファイルをn個の範囲（開始位置と長さ）に分割する
+func segmentRanges(size int64, n int) [][2]int64 {
+	if int64(n) > size {
+		n = int(size)
+	}
+	ranges := make([][2]int64, 0, n)
+	chunk := size / int64(n)
+	for i := 0; i < n; i++ {
+		offset := int64(i) * chunk
+		length := chunk
+		if i == n-1 {
+			length = size - offset
+		}
+		ranges = append(ranges, [2]int64{offset, length})
+	}
+	return ranges
+}
+
+// doCopyFileSegmented はファイルを複数の範囲に分割し、事前に確保した宛先ファイルへ並行してコピーする
+// 遅延の大きい経路で巨大なファイルを転送する場合に、1本のストリームの帯域の上限を回避する
+// すべての範囲を書き込んだ後、組み立てた宛先ファイルのハッシュをソースと比較する（コピーと同時に検証する場合を除く）
+func (fc *FileCopier) doCopyFileSegmented(sourcePath, destPath string, sourceInfo os.FileInfo) error {
+	// 宛先ファイルを作成し、ソースと同じサイズを確保する
+	destFile, err := fc.createDest(destPath)
+	if err != nil {
+		return fmt.Errorf("宛先ファイル(%s)を作成できません: %w", destPath, err)
+	}
+	if err := destFile.Truncate(sourceInfo.Size()); err != nil {
+		destFile.Close()
+		return fmt.Errorf("宛先ファイル(%s)の領域を確保できません: %w", destPath, err)
+	}
+	if err := destFile.Close(); err != nil {
+		return fmt.Errorf("宛先ファイル(%s)を閉じられません: %w", destPath, err)
+	}
+
+	ranges := segmentRanges(sourceInfo.Size(), fc.options.SegmentsPerFile)
+	errs := make([]error, len(ranges))
+	var wg sync.WaitGroup
+	for i, r := range ranges {
+		wg.Add(1)
+		go func(i int, offset, length int64) {
+			defer wg.Done()
+			errs[i] = fc.copySegment(sourcePath, destPath, offset, length)
+		}(i, r[0], r[1])
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("分割コピーエラー (%d/%d): %w", i+1, len(ranges), err)
+		}
+	}
+
+	// 組み立てた結果の検証（コピーと同時にハッシュを検証する場合は、その検証に任せる）
+	if fc.options.Mode != ModeCopyAndVerify || !fc.options.VerifyHash {
+		if err := fc.checkSegmented(sourcePath, destPath); err != nil {
+			return err
+		}
+	}
+
+	// 更新日時とアクセス権の保持（アクセス権のみ設定できなかった場合は、呼び出し元で警告として扱う）
+	err = fc.applyFileMetadata(sourcePath, destPath, sourceInfo)
+	if err != nil && !errors.Is(err, errcode.ErrPermissionCopy) {
+		return err
+	}
+
+	if fc.logger != nil && fc.logger.Verbose {
+		fc.logger.Info("分割コピー完了: %s (%d分割)", destPath, len(ranges))
+	}
+	return err
+}
+
+// checkSegmented は分割コピーで組み立てた宛先ファイルのハッシュをソースと比較する
+func (fc *FileCopier) checkSegmented(sourcePath, destPath string) error {
+	sourceHash, err := fc.hashFile(fc.options.SourceIdentity, sourcePath)
+	if err != nil {
+		return fmt.Errorf("ソースファイルのハッシュ計算エラー: %w", err)
+	}
+	destHash, err := fc.hashFile(fc.options.DestIdentity, destPath)
+	if err != nil {
+		return fmt.Errorf("宛先ファイルのハッシュ計算エラー: %w", err)
+	}
+	destHash = fc.options.Faults.CorruptHash(destHash)
+	if sourceHash != destHash {
+		return errcode.Errorf(errcode.ErrHashMismatch, "分割コピーしたファイルのハッシュが一致しません: ソース=%s, 宛先=%s", sourceHash, destHash)
+	}
+	return nil
+}
+
+// copySegment はソースファイルの指定した範囲を宛先ファイルの同じ位置にコピーする
+func (fc *FileCopier) copySegment(sourcePath, destPath string, offset, length int64) error {
+	sourceFile, err := fc.openSource(sourcePath)
+	if err != nil {
+		return errcode.Errorf(errcode.ErrSourceRead, "ソースファイル(%s)を開けません: %w", sourcePath, err)
+	}
+	defer sourceFile.Close()
+
+	destFile, err := fc.openDestWrite(destPath)
+	if err != nil {
+		return fmt.Errorf("宛先ファイル(%s)を開けません: %w", destPath, err)
+	}
+	defer destFile.Close()
+
+	reader := fc.sourceReader(io.NewSectionReader(sourceFile, offset, length))
+	writer := io.NewOffsetWriter(destFile, offset)
+	// 分割数だけバッファを確保するため、1つあたりのサイズを抑える
+	bufferSize := fc.options.BufferSize / fc.options.SegmentsPerFile
+	if bufferSize < 64*1024 {
+		bufferSize = 64 * 1024
+	}
+	written, err := io.CopyBuffer(writer, reader, make([]byte, bufferSize))
+	if err != nil {
+		return err
+	}
+	if written != length {
+		return fmt.Errorf("コピーしたバイト数が一致しません: 期待値=%d, 実際=%d", length, written)
+	}
+	return destFile.Close()
+}
@@ -0,0 +1,108 @@
+package copier
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+func TestSegmentRanges(t *testing.T) {
+	tests := []struct {
+		size int64
+		n    int
+		want [][2]int64
+	}{
+		{100, 4, [][2]int64{{0, 25}, {25, 25}, {50, 25}, {75, 25}}},
+		{10, 3, [][2]int64{{0, 3}, {3, 3}, {6, 4}}},
+		{2, 4, [][2]int64{{0, 1}, {1, 1}}},
+	}
+	for _, tt := range tests {
+		got := segmentRanges(tt.size, tt.n)
+		if len(got) != len(tt.want) {
+			t.Errorf("segmentRanges(%d, %d) = %v, want %v", tt.size, tt.n, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("segmentRanges(%d, %d) = %v, want %v", tt.size, tt.n, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestCopyFiles_Segmented(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(sourceDir, 0755)
+
+	data := make([]byte, 1024*1024+123)
+	rand.New(rand.NewSource(1)).Read(data)
+	os.WriteFile(filepath.Join(sourceDir, "huge.bin"), data, 0644)
+	os.WriteFile(filepath.Join(sourceDir, "small.txt"), []byte("small"), 0644)
+	// 既存の宛先ファイルより短い場合も正しく切り詰められること
+	os.MkdirAll(destDir, 0755)
+	os.WriteFile(filepath.Join(destDir, "huge.bin"), make([]byte, 2*1024*1024), 0644)
+
+	options := DefaultOptions()
+	options.SegmentsPerFile = 4
+	options.SegmentThreshold = 1024 * 1024
+	fc := NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFilesが失敗しました: %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(destDir, "huge.bin"))
+	if err != nil {
+		t.Fatalf("宛先ファイルの読み込みエラー: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("分割コピーした内容が一致しません (サイズ: %d, want %d)", len(got), len(data))
+	}
+	if got, _ := os.ReadFile(filepath.Join(destDir, "small.txt")); string(got) != "small" {
+		t.Errorf("small.txt = %q", got)
+	}
+	if st := fc.GetStats(); st.GetCopiedCount() != 2 || st.GetFailedCount() != 0 {
+		t.Errorf("コピー = %d, 失敗 = %d; want 2, 0", st.GetCopiedCount(), st.GetFailedCount())
+	}
+
+	sourceInfo, _ := os.Stat(filepath.Join(sourceDir, "huge.bin"))
+	destInfo, _ := os.Stat(filepath.Join(destDir, "huge.bin"))
+	if !destInfo.ModTime().Equal(sourceInfo.ModTime()) {
+		t.Errorf("更新日時 = %v, want %v", destInfo.ModTime(), sourceInfo.ModTime())
+	}
+}
+
+func TestCopyFiles_SegmentedPermissionFailure(t *testing.T) {
+	mem := vfs.NewMem()
+	sourceDir := filepath.Join("/", "source")
+	destDir := filepath.Join("/", "dest")
+	data := make([]byte, 64*1024+7)
+	rand.New(rand.NewSource(1)).Read(data)
+	mem.WriteFile(filepath.Join(sourceDir, "huge.bin"), data, 0600)
+
+	options := DefaultOptions()
+	options.FS = &chmodDeniedFS{FS: mem, denied: true}
+	options.PreservePermissions = true
+	options.SegmentsPerFile = 4
+	options.SegmentThreshold = 1024
+	options.MaxRetries = 3
+	options.RetryDelay = 0
+
+	// アクセス権のみ設定できなかった場合は、分割コピーでも内容はコピーしたものとして数える
+	fc := NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFiles() エラー: %v", err)
+	}
+	if got, err := mem.ReadFile(filepath.Join(destDir, "huge.bin")); err != nil || !bytes.Equal(got, data) {
+		t.Errorf("分割コピーした内容が一致しません: %v", err)
+	}
+	if st := fc.GetStats(); st.GetCopiedCount() != 1 || st.GetFailedCount() != 0 || st.GetPermFailedCount() != 1 {
+		t.Errorf("コピー = %d, 失敗 = %d, アクセス権 = %d", st.GetCopiedCount(), st.GetFailedCount(), st.GetPermFailedCount())
+	}
+}
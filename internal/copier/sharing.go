@@ -0,0 +1,40 @@
+package copier
+
+import (
+	"math/rand"
+	"time"
+)
+
+// sharingViolation はエラーが他のプロセスがファイルを使用中であることによる共有違反かどうかを判断する
+// （テストで差し替えられるように変数にしている）
+var sharingViolation = isSharingViolation
+
+// retrySharing はattemptを実行し、共有違反で失敗した場合は通常の再試行とは別に、短いランダムな間隔を空けて再試行する
+// Windowsではウイルス対策ソフトや検索インデクサがコピーの直前・直後にファイルを一時的に開くため、
+// 通常の再試行（--wait）を待たずに繰り返すことで、再試行の回数を使い切らずに済む
+func (fc *FileCopier) retrySharing(relPath string, attempt func() error) error {
+	err := attempt()
+	for i := 0; i < fc.options.SharingRetries && err != nil && sharingViolation(err); i++ {
+		select {
+		case <-time.After(sharingDelay(fc.options.SharingRetryDelay)):
+		case <-fc.ctx.Done():
+			return err
+		}
+
+		fc.stats.IncrementSharingRetries()
+		if fc.logger != nil {
+			fc.logger.Debug("共有違反のため再試行します (%d/%d): %s: %v", i+1, fc.options.SharingRetries, relPath, err)
+		}
+		err = attempt()
+	}
+	return err
+}
+
+// sharingDelay は共有違反の再試行までの待ち時間を返す
+// 複数のワーカーとスキャナが同じ周期で衝突し続けないよう、指定した時間の50%から150%の範囲でばらつかせる
+func sharingDelay(base time.Duration) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+	return base/2 + time.Duration(rand.Int63n(int64(base)))
+}
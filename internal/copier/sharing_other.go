@@ -0,0 +1,8 @@
+//go:build !windows
+
+package copier
+
+// isSharingViolation はWindows以外では共有違反が発生しないため、常にfalseを返す
+func isSharingViolation(err error) bool {
+	return false
+}
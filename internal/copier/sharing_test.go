@@ -0,0 +1,70 @@
+package copier
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var errTestSharing = errors.New("別のプロセスが使用中です")
+
+// stubSharingViolation はerrTestSharingを共有違反として扱うよう差し替える
+func stubSharingViolation(t *testing.T) {
+	t.Helper()
+	original := sharingViolation
+	sharingViolation = func(err error) bool { return errors.Is(err, errTestSharing) }
+	t.Cleanup(func() { sharingViolation = original })
+}
+
+func TestRetrySharing(t *testing.T) {
+	stubSharingViolation(t)
+
+	options := DefaultOptions()
+	options.SharingRetries = 3
+	options.SharingRetryDelay = time.Millisecond
+	fc := NewFileCopier(t.TempDir(), t.TempDir(), options, nil, nil, nil)
+
+	// 共有違反が解消するまで再試行する
+	attempts := 0
+	err := fc.retrySharing("a.txt", func() error {
+		if attempts++; attempts < 3 {
+			return errTestSharing
+		}
+		return nil
+	})
+	if err != nil || attempts != 3 || fc.stats.GetSharingRetries() != 2 {
+		t.Errorf("err = %v, 試行回数 = %d, 再試行の回数 = %d", err, attempts, fc.stats.GetSharingRetries())
+	}
+
+	// 回数を使い切った場合は最後のエラーを返す
+	attempts = 0
+	err = fc.retrySharing("b.txt", func() error {
+		attempts++
+		return errTestSharing
+	})
+	if !errors.Is(err, errTestSharing) || attempts != 4 {
+		t.Errorf("err = %v, 試行回数 = %d", err, attempts)
+	}
+
+	// 共有違反以外のエラーは再試行しない
+	attempts = 0
+	fc.retrySharing("c.txt", func() error {
+		attempts++
+		return errors.New("other")
+	})
+	if attempts != 1 {
+		t.Errorf("共有違反以外のエラーの試行回数 = %d", attempts)
+	}
+}
+
+func TestSharingDelay(t *testing.T) {
+	base := 100 * time.Millisecond
+	for i := 0; i < 100; i++ {
+		if d := sharingDelay(base); d < base/2 || d >= base*3/2 {
+			t.Fatalf("sharingDelay() = %v", d)
+		}
+	}
+	if d := sharingDelay(0); d != 0 {
+		t.Errorf("sharingDelay(0) = %v", d)
+	}
+}
@@ -0,0 +1,14 @@
+//go:build windows
+
+package copier
+
+import (
+	"errors"
+
+	"golang.org/x/sys/windows"
+)
+
+// isSharingViolation はERROR_SHARING_VIOLATION・ERROR_LOCK_VIOLATIONかどうかを判断する
+func isSharingViolation(err error) bool {
+	return errors.Is(err, windows.ERROR_SHARING_VIOLATION) || errors.Is(err, windows.ERROR_LOCK_VIOLATION)
+}
@@ -0,0 +1,43 @@
+package copier
+
+import (
+	"github.com/sakuhanight/gopier/internal/fsmeta"
+	"github.com/sakuhanight/gopier/internal/runas"
+	"github.com/sakuhanight/gopier/internal/sidecar"
+)
+
+// addSnapshot はソースのエントリのメタデータを取得し、メタデータのファイルに記録するエントリに追加する
+func (fc *FileCopier) addSnapshot(snapshots map[string]*fsmeta.Snapshot, name, sourcePath string) {
+	var snap *fsmeta.Snapshot
+	err := runas.Run(fc.options.SourceIdentity, func() (err error) {
+		snap, err = fsmeta.Capture(sourcePath)
+		return err
+	})
+	if err != nil {
+		if fc.logger != nil {
+			fc.logger.Warn("メタデータの取得エラー: %s: %v", sourcePath, err)
+		}
+		return
+	}
+	snapshots[name] = snap
+}
+
+// writeSidecar はディレクトリのメタデータのファイルを、すべての宛先の対応するディレクトリに書き込む
+// ディレクトリ自身しか記録がない場合（空のディレクトリをコピーしない設定で中身がない場合など）は書き込まない
+func (fc *FileCopier) writeSidecar(destDir string, snapshots map[string]*fsmeta.Snapshot) {
+	if len(snapshots) == 0 || (len(snapshots) == 1 && snapshots[sidecar.SelfEntry] != nil && !fc.options.CopyEmptyDirs) {
+		return
+	}
+
+	for _, dir := range append([]string{destDir}, fc.extraPaths(destDir)...) {
+		err := fc.mkdirDest(dir)
+		if err == nil {
+			err = runas.Run(fc.options.DestIdentity, func() error {
+				return sidecar.Write(dir, snapshots)
+			})
+		}
+		if err != nil && fc.logger != nil {
+			fc.logger.Error("メタデータのファイルの書き込みエラー: %s: %v", dir, err)
+		}
+	}
+}
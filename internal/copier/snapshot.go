@@ -0,0 +1,143 @@
+package copier
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/sidecar"
+)
+
+// SourceSnapshot はある時点のソースのツリーの状態（コピーの対象のファイル数・合計サイズ・更新日時の最大値）
+type SourceSnapshot struct {
+	TakenAt       time.Time // 走査を始めた日時
+	Files         int64     // 対象のファイル数（フィルタと属性で除外したものを含まない）
+	Bytes         int64     // 対象のファイルの合計サイズ
+	NewestModTime time.Time // 対象のファイルの更新日時の最大値（変更を判定する基準）
+	Errors        int64     // 情報を取得できなかったファイル・ディレクトリ数
+}
+
+// SourceDrift は実行の開始から終了までのソースの変化を表す構造体
+// 開始時の状態を基準に、終了時に再度走査した状態と比較する
+type SourceDrift struct {
+	Start        SourceSnapshot
+	End          SourceSnapshot
+	ChangedFiles int64 // 開始時の更新日時の最大値より後に更新されたファイル数（追加されたファイルを含む）
+	ChangedBytes int64 // 更新されたファイルの合計サイズ
+}
+
+// Changed はコピー中にソースが変化したかどうかを返す
+// 更新日時を変えずに削除・置き換えたファイルも、件数とサイズの差で判定する
+func (d SourceDrift) Changed() bool {
+	return d.ChangedFiles > 0 || d.End.Files != d.Start.Files || d.End.Bytes != d.Start.Bytes
+}
+
+// takeSourceSnapshot は実行の開始時にソースを走査し、状態を記録する（変化の検出が無効な場合は何もしない）
+func (fc *FileCopier) takeSourceSnapshot() {
+	if !fc.options.DetectSourceChanges {
+		return
+	}
+	start := fc.snapshotSource(time.Time{})
+	fc.drift = &SourceDrift{Start: start.snapshot}
+}
+
+// compareSourceSnapshot は実行の終了時にソースを再度走査し、開始時からの変化を記録する
+func (fc *FileCopier) compareSourceSnapshot() {
+	if fc.drift == nil || fc.ctx.Err() != nil {
+		return
+	}
+	// 開始時に対象のファイルがなかった場合は、走査を始めた日時より後の更新を数える
+	mark := fc.drift.Start.NewestModTime
+	if mark.IsZero() {
+		mark = fc.drift.Start.TakenAt
+	}
+	end := fc.snapshotSource(mark)
+	fc.drift.End = end.snapshot
+	fc.drift.ChangedFiles = end.changedFiles
+	fc.drift.ChangedBytes = end.changedBytes
+
+	if fc.drift.Changed() && fc.logger != nil {
+		fc.logger.Warn("コピー中にソースが変更されました: ファイル数 %d -> %d, 更新されたファイル %d件",
+			fc.drift.Start.Files, fc.drift.End.Files, fc.drift.ChangedFiles)
+	}
+}
+
+// GetSourceDrift は実行の開始から終了までのソースの変化を返す（検出しなかった場合はnil）
+func (fc *FileCopier) GetSourceDrift() *SourceDrift {
+	if fc.drift == nil || fc.drift.End.TakenAt.IsZero() {
+		return nil
+	}
+	drift := *fc.drift
+	return &drift
+}
+
+// sourceScan はソースの走査の結果
+type sourceScan struct {
+	snapshot     SourceSnapshot
+	mark         time.Time // この日時より後に更新されたファイルを変更されたファイルとして数える（ゼロの場合は数えない）
+	changedFiles int64
+	changedBytes int64
+}
+
+// snapshotSource はコピーと同じ規則（フィルタ・属性・再帰）で対象とするソースのファイルを走査する
+// ファイルの内容は読み込まず、ディレクトリの一覧と情報のみ取得する
+func (fc *FileCopier) snapshotSource(mark time.Time) *sourceScan {
+	scan := &sourceScan{snapshot: SourceSnapshot{TakenAt: time.Now()}, mark: mark}
+	if info, err := fc.statSource(fc.sourceDir); err != nil {
+		scan.snapshot.Errors++
+	} else if !info.IsDir() {
+		scan.add(info)
+	} else {
+		fc.snapshotDirectory(fc.sourceDir, scan)
+	}
+	return scan
+}
+
+func (fc *FileCopier) snapshotDirectory(dir string, scan *sourceScan) {
+	if fc.ctx.Err() != nil {
+		return
+	}
+	entries, err := fc.readSourceDir(dir)
+	if err != nil {
+		scan.snapshot.Errors++
+		return
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if fc.attributeCounter(entry) != nil {
+			continue
+		}
+		if fc.options.MetaSidecar && entry.Name() == sidecar.FileName {
+			continue
+		}
+
+		if entry.IsDir() {
+			if fc.options.Recursive && (fc.filter == nil || !fc.filter.ExcludesDir(path)) && !fc.isDestDir(path, entry) {
+				fc.snapshotDirectory(path, scan)
+			}
+			continue
+		}
+		if fc.filter != nil && !fc.filter.ShouldInclude(path) {
+			continue
+		}
+		info, err := fc.entryInfo(entry)
+		if err != nil {
+			scan.snapshot.Errors++
+			continue
+		}
+		scan.add(info)
+	}
+}
+
+func (s *sourceScan) add(info os.FileInfo) {
+	s.snapshot.Files++
+	s.snapshot.Bytes += info.Size()
+	if info.ModTime().After(s.snapshot.NewestModTime) {
+		s.snapshot.NewestModTime = info.ModTime()
+	}
+	if !s.mark.IsZero() && info.ModTime().After(s.mark) {
+		s.changedFiles++
+		s.changedBytes += info.Size()
+	}
+}
@@ -0,0 +1,69 @@
+package copier
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/filter"
+)
+
+func TestCopyFiles_DetectSourceChanges(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(filepath.Join(sourceDir, "sub"), 0755)
+	os.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("aaa"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "sub", "b.txt"), []byte("bbbb"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "skip.tmp"), []byte("tmp"), 0644)
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(filepath.Join(sourceDir, "a.txt"), old, old)
+	os.Chtimes(filepath.Join(sourceDir, "sub", "b.txt"), old, old)
+
+	options := DefaultOptions()
+	options.DetectSourceChanges = true
+
+	// 変化がない場合
+	fc := NewFileCopier(sourceDir, destDir, options, filter.NewFilter("", "*.tmp"), nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFiles() error = %v", err)
+	}
+	drift := fc.GetSourceDrift()
+	if drift == nil || drift.Changed() || drift.Start.Files != 2 || drift.Start.Bytes != 7 || !drift.Start.NewestModTime.Equal(old) {
+		t.Fatalf("変化がない場合のGetSourceDrift() = %+v", drift)
+	}
+
+	// コピー中にファイルを追加・更新する（開始時の走査の後、コピーの走査でa.txtを判定した時点）
+	checks := 0
+	f := filter.NewFilter("", "*.tmp")
+	f.AddCheck(func(path string) bool {
+		if filepath.Base(path) == "a.txt" {
+			checks++
+			if checks == 2 {
+				os.WriteFile(filepath.Join(sourceDir, "new.txt"), []byte("new"), 0644)
+				os.WriteFile(filepath.Join(sourceDir, "sub", "b.txt"), []byte("bbbbbb"), 0644)
+			}
+		}
+		return true
+	})
+	fc = NewFileCopier(sourceDir, filepath.Join(tempDir, "dest2"), options, f, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFiles() error = %v", err)
+	}
+	drift = fc.GetSourceDrift()
+	if drift == nil || !drift.Changed() {
+		t.Fatalf("コピー中の変化が検出されません: %+v", drift)
+	}
+	if drift.Start.Files != 2 || drift.End.Files != 3 || drift.End.Bytes != 12 || drift.ChangedFiles != 2 || drift.ChangedBytes != 9 {
+		t.Errorf("GetSourceDrift() = %+v", drift)
+	}
+
+	// 検出を指定しない場合は走査しない
+	options.DetectSourceChanges = false
+	fc = NewFileCopier(sourceDir, filepath.Join(tempDir, "dest3"), options, nil, nil, nil)
+	fc.CopyFiles()
+	if drift := fc.GetSourceDrift(); drift != nil {
+		t.Errorf("検出を指定しない場合のGetSourceDrift() = %+v", drift)
+	}
+}
@@ -0,0 +1,78 @@
+package copier
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/fsmeta"
+	"github.com/sakuhanight/gopier/internal/runas"
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+// stampCopied はコピーしたファイルの拡張属性にハッシュを記録する
+// コピー中にハッシュを計算していない場合（変換・キャッシュ以外）は、書き込んだ宛先の内容から計算する
+// （コピー後に変更されたソースを読み直して、宛先と異なるハッシュを記録しないため）
+func (fc *FileCopier) stampCopied(relPath, destPath string, sourceInfo os.FileInfo, transformInfo *database.TransformInfo, cacheKey string) {
+	if !fc.stamps() {
+		return
+	}
+
+	var hash, sourceHash string
+	switch {
+	case transformInfo != nil:
+		hash, sourceHash = transformInfo.OutputHash, transformInfo.OriginalHash
+	case cacheKey != "":
+		hash, sourceHash = cacheKey, cacheKey
+	default:
+		var err error
+		if hash, err = fc.hashFile(fc.options.DestIdentity, destPath); err != nil {
+			if fc.logger != nil {
+				fc.logger.Warn("記録するハッシュを計算できません: %s: %v", relPath, err)
+			}
+			return
+		}
+		sourceHash = hash
+	}
+	fc.stampDest(relPath, destPath, sourceInfo, hash, sourceHash)
+}
+
+// stampDest は宛先のファイルの拡張属性（WindowsではADS）にハッシュを記録する
+// hashは宛先の内容のハッシュ、sourceHashは変換前のソースのハッシュ（変換しない場合は同じ値）。
+// 記録できなくてもコピー・検証は成功として扱い、警告のみ出力する
+func (fc *FileCopier) stampDest(relPath, destPath string, sourceInfo os.FileInfo, hash, sourceHash string) {
+	if !fc.stamps() {
+		return
+	}
+
+	stamp := fsmeta.Stamp{
+		Algorithm: fc.options.HashAlgorithm,
+		Hash:      hash,
+		Size:      sourceInfo.Size(),
+		ModTime:   sourceInfo.ModTime(),
+		StampedAt: time.Now(),
+	}
+	if sourceHash != hash {
+		stamp.SourceHash = sourceHash
+	}
+	err := runas.Run(fc.options.DestIdentity, func() error {
+		return fsmeta.WriteStamp(destPath, stamp)
+	})
+	if err == nil || fc.logger == nil {
+		return
+	}
+	// 対応していないファイルシステムでは、ファイルごとに警告しないよう最初の1件のみ出力する
+	if errors.Is(err, fsmeta.ErrStampUnsupported) {
+		if !fc.stampWarned.Swap(true) {
+			fc.logger.Warn("宛先のファイルシステムはハッシュの記録（拡張属性）に対応していません: %s", destPath)
+		}
+		return
+	}
+	fc.logger.Warn("ハッシュを拡張属性に記録できません: %s: %v", relPath, err)
+}
+
+// stamps はハッシュを宛先のファイルに記録するかどうかを返す（OS以外のファイルシステムには記録しない）
+func (fc *FileCopier) stamps() bool {
+	return fc.options.StampXattr && vfs.IsOS(fc.fs)
+}
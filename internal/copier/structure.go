@@ -0,0 +1,107 @@
+package copier
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// StructureFiles は構造のみ作成する場合のファイルの作成方法を表す型
+type StructureFiles string
+
+const (
+	// StructureSized はソースと同じサイズのファイルを作成する（対応するファイルシステムではスパースファイルになる）
+	StructureSized StructureFiles = "sized"
+	// StructureEmpty は空のファイルを作成する
+	StructureEmpty StructureFiles = "empty"
+)
+
+// placeholderTime は構造のみ作成したファイルに設定する更新日時
+// ソースと更新日時が一致しないため、後続の通常のコピーで必ず内容がコピーされる
+var placeholderTime = time.Unix(0, 0)
+
+// ParseStructureFiles は文字列をStructureFilesに変換する
+func ParseStructureFiles(s string) (StructureFiles, error) {
+	switch StructureFiles(s) {
+	case "", StructureSized:
+		return StructureSized, nil
+	case StructureEmpty:
+		return StructureEmpty, nil
+	default:
+		return "", fmt.Errorf("無効なファイルの作成方法: %s (sized, emptyのいずれかを指定してください)", s)
+	}
+}
+
+// createPlaceholders は内容をコピーせずに、宛先にファイルを作成する
+// 既存のファイルは内容を失わないよう変更せずにスキップする
+// DBには記録しないため、後続の通常のコピーの判定には影響しない
+func (fc *FileCopier) createPlaceholders(sourcePath, destPath, relPath string, sourceInfo os.FileInfo) error {
+	paths := append([]string{destPath}, fc.extraPaths(destPath)...)
+
+	var created int
+	for _, path := range paths {
+		if _, err := fc.statDest(path); err == nil {
+			continue
+		} else if !os.IsNotExist(err) {
+			fc.countFailed(relPath, err)
+			return fmt.Errorf("宛先ファイル(%s)の確認エラー: %w", path, err)
+		}
+
+		if err := fc.createPlaceholder(sourcePath, path, sourceInfo); err != nil {
+			fc.countFailed(relPath, err)
+			if fc.logger != nil {
+				fc.logger.Error("ファイル作成失敗: %s: %v", relPath, err)
+			}
+			return err
+		}
+		created++
+	}
+
+	if created == 0 {
+		fc.countSkipped(relPath, sourceInfo.Size())
+		if fc.logger != nil && fc.logger.Verbose {
+			fc.logger.Info("ファイルをスキップ（既に存在します）: %s", relPath)
+		}
+		return nil
+	}
+
+	fc.countCopied(relPath, 0)
+	if fc.logger != nil && fc.logger.Verbose {
+		fc.logger.Info("ファイルを作成（内容なし）: %s", relPath)
+	}
+	return nil
+}
+
+// createPlaceholder は宛先に内容のないファイルを1つ作成し、サイズとアクセス権を設定する
+func (fc *FileCopier) createPlaceholder(sourcePath, destPath string, sourceInfo os.FileInfo) error {
+	if fc.options.CreateDirs {
+		if err := fc.mkdirDest(filepath.Dir(destPath)); err != nil {
+			return fmt.Errorf("宛先ディレクトリ(%s)の作成エラー: %w", filepath.Dir(destPath), err)
+		}
+	}
+
+	file, err := fc.createDest(destPath)
+	if err != nil {
+		return fmt.Errorf("宛先ファイル(%s)を作成できません: %w", destPath, err)
+	}
+	if fc.options.StructureFiles != StructureEmpty {
+		if err := file.Truncate(sourceInfo.Size()); err != nil {
+			file.Close()
+			return fmt.Errorf("ファイルサイズの設定エラー: %w", err)
+		}
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("宛先ファイル(%s)を閉じられません: %w", destPath, err)
+	}
+
+	if err := fc.chtimesDest(destPath, placeholderTime); err != nil {
+		return fmt.Errorf("更新日時の設定エラー: %w", err)
+	}
+	if fc.setsPermissions() {
+		if err := fc.setPermissions(sourcePath, destPath, false); err != nil {
+			return fmt.Errorf("アクセス権の設定エラー: %w", err)
+		}
+	}
+	return nil
+}
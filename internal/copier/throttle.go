@@ -0,0 +1,209 @@
+package copier
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/units"
+)
+
+// throttle は実行中のコピーの一時停止と帯域制限を管理する
+// すべてのワーカーで共有され、制限値は実行中に変更できる
+type throttle struct {
+	mu        sync.Mutex
+	paused    bool
+	resumeCh  chan struct{}
+	pausedAt  time.Time     // 一時停止した時刻
+	pausedFor time.Duration // 解除済みの一時停止の合計時間
+
+	limit    int64              // 秒あたりの最大バイト数（0は無制限）
+	schedule *BandwidthSchedule // 時刻ごとの帯域制限（nilの場合はlimitのみ）
+	tokens   float64            // 送信可能なバイト数（負の場合は超過分）
+	last     time.Time
+}
+
+// newThrottle は新しいthrottleを作成する
+func newThrottle(limit int64) *throttle {
+	return &throttle{
+		limit:    limit,
+		resumeCh: make(chan struct{}),
+		last:     time.Now(),
+	}
+}
+
+// pause は一時停止する
+func (t *throttle) pause() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.paused {
+		t.paused = true
+		t.pausedAt = time.Now()
+		t.resumeCh = make(chan struct{})
+	}
+}
+
+// resume は一時停止を解除する
+func (t *throttle) resume() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.paused {
+		t.paused = false
+		t.pausedFor += time.Since(t.pausedAt)
+		close(t.resumeCh)
+	}
+}
+
+// isPaused は一時停止中かどうかを返す
+func (t *throttle) isPaused() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.paused
+}
+
+// pausedTotal はこれまでに一時停止していた合計時間（一時停止中の場合は現在までの時間を含む）を返す
+// 2回の呼び出しの差がその間に一時停止していた時間になる
+func (t *throttle) pausedTotal() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	total := t.pausedFor
+	if t.paused {
+		total += time.Since(t.pausedAt)
+	}
+	return total
+}
+
+// setLimit は帯域制限を変更する
+func (t *throttle) setLimit(limit int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.limit = limit
+	t.tokens = 0
+	t.last = time.Now()
+}
+
+// setSchedule は時刻ごとの帯域制限を変更する（nilの場合はsetLimitの値のみ）
+func (t *throttle) setSchedule(schedule *BandwidthSchedule) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.schedule = schedule
+	t.tokens = 0
+	t.last = time.Now()
+}
+
+// getLimit は現在の時刻に適用される帯域制限を返す
+func (t *throttle) getLimit() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.schedule.LimitAt(time.Now(), t.limit)
+}
+
+// waitResume は一時停止が解除されるまで待機する
+func (t *throttle) waitResume(ctx context.Context) error {
+	t.mu.Lock()
+	paused, ch := t.paused, t.resumeCh
+	t.mu.Unlock()
+
+	if !paused {
+		return nil
+	}
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return errcode.Errorf(errcode.ErrCancelled, "コピー処理がキャンセルされました")
+	}
+}
+
+// waitN は一時停止の解除を待ち、nバイトの転送が帯域制限に収まるまで待機する
+func (t *throttle) waitN(ctx context.Context, n int) error {
+	if err := t.waitResume(ctx); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	// 時刻ごとの帯域制限は読み込みのたびに評価し、長時間の実行中も時間帯に合わせて切り替える
+	now := time.Now()
+	limit := t.schedule.LimitAt(now, t.limit)
+	if limit <= 0 {
+		t.last = now
+		t.mu.Unlock()
+		return nil
+	}
+
+	// 経過時間に応じて補充する（バーストは1秒分まで）
+	t.tokens += now.Sub(t.last).Seconds() * float64(limit)
+	if t.tokens > float64(limit) {
+		t.tokens = float64(limit)
+	}
+	t.last = now
+	t.tokens -= float64(n)
+
+	var delay time.Duration
+	if t.tokens < 0 {
+		delay = time.Duration(-t.tokens / float64(limit) * float64(time.Second))
+	}
+	t.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return errcode.Errorf(errcode.ErrCancelled, "コピー処理がキャンセルされました")
+	}
+}
+
+// throttledReader は読み込みごとに一時停止と帯域制限を適用するReader
+type throttledReader struct {
+	ctx      context.Context
+	reader   io.Reader
+	throttle *throttle
+}
+
+// sourceReader はソースファイルの読み込みに帯域制限と一時停止を適用するReaderを返す
+// 障害注入を指定した場合は、読み込みエラーや遅延も発生させる
+// 読み込みエラーにはエラーコードの判定のためにerrcode.ErrSourceReadの種類を付ける
+func (fc *FileCopier) sourceReader(r io.Reader) io.Reader {
+	return &throttledReader{ctx: fc.ctx, reader: sourceErrReader{reader: fc.options.Faults.Reader(r)}, throttle: fc.throttle}
+}
+
+// Read はデータを読み込み、読み込んだ量に応じて待機する
+func (r *throttledReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		if werr := r.throttle.waitN(r.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+// ParseBandwidth は帯域制限の指定（例: "512K", "10M", "1G/s"）を秒あたりのバイト数に変換する
+// units.ParseSizeの形式に加えて末尾の"/s"を受け付け、空文字列と"0"は無制限を表す
+func ParseBandwidth(s string) (int64, error) {
+	value := strings.TrimSpace(s)
+	if len(value) >= 2 && strings.EqualFold(value[len(value)-2:], "/s") {
+		value = value[:len(value)-2]
+	}
+	limit, err := units.ParseSize(value, units.Byte)
+	if err != nil {
+		return 0, fmt.Errorf("帯域制限の指定が不正です: %w", err)
+	}
+	return limit, nil
+}
@@ -0,0 +1,138 @@
+package copier
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseBandwidth(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"0", 0, false},
+		{"1024", 1024, false},
+		{"512K", 512 * 1024, false},
+		{"10M", 10 * 1024 * 1024, false},
+		{"10MB", 10 * 1024 * 1024, false},
+		{"10mb/s", 10 * 1024 * 1024, false},
+		{"1.5G", 1536 * 1024 * 1024, false},
+		{"abc", 0, true},
+		{"-1M", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseBandwidth(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseBandwidth(%q) エラー: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseBandwidth(%q): 期待値=%d, 実際=%d", tt.input, tt.want, got)
+		}
+	}
+}
+
+func TestThrottle_PauseResume(t *testing.T) {
+	th := newThrottle(0)
+	th.pause()
+	if !th.isPaused() {
+		t.Fatal("一時停止状態になっていません")
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- th.waitResume(context.Background())
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("一時停止中に待機が終了しました")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	th.resume()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("再開後にエラーが返されました: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("再開後も待機が終了しません")
+	}
+
+	// 一時停止中でもキャンセルで待機が終了する
+	th.pause()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := th.waitResume(ctx); err == nil {
+		t.Error("キャンセル時にエラーが返されませんでした")
+	}
+}
+
+func TestThrottle_BandwidthLimit(t *testing.T) {
+	th := newThrottle(0)
+	if th.getLimit() != 0 {
+		t.Errorf("初期値: 期待値=0, 実際=%d", th.getLimit())
+	}
+
+	// 100KB/sで200KBを読み込むと約2秒かかる
+	th.setLimit(100 * 1024)
+	reader := &throttledReader{
+		ctx:      context.Background(),
+		reader:   bytes.NewReader(make([]byte, 200*1024)),
+		throttle: th,
+	}
+
+	start := time.Now()
+	n, err := io.CopyBuffer(io.Discard, reader, make([]byte, 32*1024))
+	elapsed := time.Since(start)
+
+	if err != nil || n != 200*1024 {
+		t.Fatalf("読み込みに失敗: n=%d, err=%v", n, err)
+	}
+	if elapsed < 1500*time.Millisecond || elapsed > 4*time.Second {
+		t.Errorf("帯域制限が適用されていません: 経過時間=%v", elapsed)
+	}
+}
+
+func TestCopyFiles_PauseAndCancel(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(sourceDir, 0755)
+	os.WriteFile(filepath.Join(sourceDir, "file.txt"), []byte("content"), 0644)
+
+	options := DefaultOptions()
+	options.RetryDelay = time.Hour
+	fc := NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+
+	// 一時停止した状態で開始し、キャンセルするとリトライせずに終了する
+	fc.Pause()
+	done := make(chan error)
+	go func() {
+		done <- fc.CopyFiles()
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	if _, err := os.Stat(filepath.Join(destDir, "file.txt")); !os.IsNotExist(err) {
+		t.Error("一時停止中にファイルがコピーされました")
+	}
+
+	fc.Cancel()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("キャンセル時にエラーが返されませんでした")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("キャンセル後も処理が終了しません")
+	}
+}
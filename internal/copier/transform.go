@@ -0,0 +1,102 @@
+package copier
+
+import (
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/transform"
+)
+
+// selectTransforms はソースファイルに適用する変換を返す
+// MIMEタイプによる規則がある場合は、判定のためにファイルの先頭を読み込む
+func (fc *FileCopier) selectTransforms(sourcePath string) []transform.Transformer {
+	pipeline := fc.options.Transforms
+	if pipeline == nil {
+		return nil
+	}
+
+	var head []byte
+	if pipeline.NeedsContent() {
+		if file, err := fc.openSource(sourcePath); err == nil {
+			buf := make([]byte, transform.SniffLen)
+			n, _ := io.ReadFull(file, buf)
+			head = buf[:n]
+			file.Close()
+		}
+	}
+	return pipeline.Select(sourcePath, head)
+}
+
+// upToDate は宛先のファイルがコピー済みで最新かどうかをサイズと更新日時で判断する
+// 内容を変換する場合は宛先のサイズがソースと異なるため、DBに記録した前回の変換結果と比較する
+func (fc *FileCopier) upToDate(sourceInfo, destInfo os.FileInfo, record *database.FileInfo, transformers []transform.Transformer) bool {
+	if !sourceInfo.ModTime().Equal(destInfo.ModTime()) {
+		return false
+	}
+	if len(transformers) == 0 {
+		return sourceInfo.Size() == destInfo.Size()
+	}
+	return record != nil && record.Transform != nil &&
+		record.Size == sourceInfo.Size() &&
+		record.Transform.OutputSize == destInfo.Size() &&
+		slices.Equal(record.Transform.Transformers, transform.NameList(transformers))
+}
+
+// transformHashes は変換前と変換後の内容のハッシュを同時に計算するための構造体
+type transformHashes struct {
+	original hash.Hash
+	output   hash.Hash
+}
+
+func (fc *FileCopier) newTransformHashes() (*transformHashes, error) {
+	original, err := fc.hasher.NewHash()
+	if err != nil {
+		return nil, err
+	}
+	output, err := fc.hasher.NewHash()
+	if err != nil {
+		return nil, err
+	}
+	return &transformHashes{original: original, output: output}, nil
+}
+
+// wrap はsrcに変換を適用したReaderと、変換後の内容を書き込むWriterを返す
+// 変換前の内容はReaderから、変換後の内容はWriterからそれぞれハッシュを計算する
+func (h *transformHashes) wrap(src io.Reader, dst io.Writer, transformers []transform.Transformer) (io.ReadCloser, io.Writer) {
+	return transform.NewReader(io.TeeReader(src, h.original), transformers), io.MultiWriter(dst, h.output)
+}
+
+// info は変換結果の記録を作成する
+func (h *transformHashes) info(transformers []transform.Transformer, outputSize int64) *database.TransformInfo {
+	return &database.TransformInfo{
+		Transformers: transform.NameList(transformers),
+		OriginalHash: hex.EncodeToString(h.original.Sum(nil)),
+		OutputHash:   hex.EncodeToString(h.output.Sum(nil)),
+		OutputSize:   outputSize,
+	}
+}
+
+// hashTransformed はソースファイルに変換を適用し、変換前と変換後の内容のハッシュを計算する
+// 変換は決定的であるため、検証では変換後のハッシュを宛先のハッシュと比較する
+func (fc *FileCopier) hashTransformed(sourcePath string, transformers []transform.Transformer) (*database.TransformInfo, error) {
+	file, err := fc.openSource(sourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("ファイルを開けません: %w", err)
+	}
+	defer file.Close()
+
+	hashes, err := fc.newTransformHashes()
+	if err != nil {
+		return nil, err
+	}
+	size, err := transform.Digest(file, transformers, hashes.original, hashes.output)
+	if err != nil {
+		return nil, err
+	}
+	return hashes.info(transformers, size), nil
+}
@@ -0,0 +1,71 @@
+package copier
+
+import (
+	"os"
+	"sync"
+)
+
+// verifyJob はコピーした後に検証するファイル
+type verifyJob struct {
+	sourcePath string
+	destPath   string
+	relPath    string
+	sourceInfo os.FileInfo
+}
+
+// verifyQueue はコピーのワーカーとは別の並行数で検証するためのキュー
+type verifyQueue struct {
+	jobs chan verifyJob
+	wg   sync.WaitGroup
+}
+
+// startVerifyQueue はコピーと同時に検証する場合に、検証のワーカーを起動する
+// 検証のワーカー数を指定しない場合は、従来どおりコピーのワーカーで続けて検証する
+func (fc *FileCopier) startVerifyQueue() {
+	if fc.options.Mode != ModeCopyAndVerify || fc.options.VerifyConcurrent <= 0 {
+		return
+	}
+
+	// キューが一杯の場合はコピーのワーカーを待たせ、検証がコピーから大きく遅れないようにする
+	q := &verifyQueue{jobs: make(chan verifyJob, fc.options.VerifyConcurrent*2)}
+	for i := 0; i < fc.options.VerifyConcurrent; i++ {
+		q.wg.Add(1)
+		go func() {
+			defer q.wg.Done()
+			for job := range q.jobs {
+				if err := fc.verifyFile(job.sourcePath, job.destPath, job.relPath, job.sourceInfo); err != nil {
+					fc.stats.RecordError(job.relPath, err)
+					fc.recordFailure(job.relPath, err)
+				}
+				fc.wg.Done()
+			}
+		}()
+	}
+	fc.verifyQueue = q
+}
+
+// verifyCopied はコピーした（またはスキップした）ファイルを検証する
+// 検証のワーカーを起動している場合はキューに追加して、検証の完了を待たずに戻る
+// キューに追加した検証もfc.wgで数え、再試行や追いかけコピーが検証中のファイルを書き換えないようにする
+func (fc *FileCopier) verifyCopied(sourcePath, destPath, relPath string, sourceInfo os.FileInfo) error {
+	if fc.verifyQueue == nil {
+		return fc.verifyFile(sourcePath, destPath, relPath, sourceInfo)
+	}
+	fc.wg.Add(1)
+	select {
+	case fc.verifyQueue.jobs <- verifyJob{sourcePath: sourcePath, destPath: destPath, relPath: relPath, sourceInfo: sourceInfo}:
+	case <-fc.ctx.Done():
+		fc.wg.Done()
+	}
+	return nil
+}
+
+// finishVerifyQueue は検証のワーカーを終了する
+func (fc *FileCopier) finishVerifyQueue() {
+	if fc.verifyQueue == nil {
+		return
+	}
+	close(fc.verifyQueue.jobs)
+	fc.verifyQueue.wg.Wait()
+	fc.verifyQueue = nil
+}
@@ -0,0 +1,79 @@
+package copier
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/faultinject"
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+func TestCopyFiles_VerifyConcurrent(t *testing.T) {
+	mem := vfs.NewMem()
+	sourceDir := filepath.Join("/", "source")
+	destDir := filepath.Join("/", "dest")
+	for i := 0; i < 20; i++ {
+		mem.WriteFile(filepath.Join(sourceDir, fmt.Sprintf("dir%d", i%3), fmt.Sprintf("f%d.txt", i)), []byte(fmt.Sprintf("content %d", i)), 0644)
+	}
+
+	options := DefaultOptions()
+	options.FS = mem
+	options.Mode = ModeCopyAndVerify
+	options.MaxConcurrent = 4
+	options.VerifyConcurrent = 2
+	fc := NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFiles() エラー: %v", err)
+	}
+	if fc.verifyQueue != nil {
+		t.Error("終了後も検証のワーカーが残っています")
+	}
+	// 検証はCopyFilesから戻る前にすべて終わっている
+	if summary := fc.GetVerificationSummary(); summary.Matched != 20 || summary.Mismatched != 0 {
+		t.Errorf("検証結果 = %+v, want 20件一致", summary)
+	}
+	if failures := fc.GetFailures(); len(failures) != 0 {
+		t.Errorf("失敗したファイル = %+v", failures)
+	}
+
+	// スキップしたファイルも検証のワーカーで検証する
+	fc = NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFiles() エラー: %v", err)
+	}
+	if skipped := fc.GetStats().GetSkippedCount(); skipped != 20 {
+		t.Errorf("スキップ件数 = %d, want 20", skipped)
+	}
+	if summary := fc.GetVerificationSummary(); summary.Matched != 20 {
+		t.Errorf("再実行時の検証結果 = %+v, want 20件一致", summary)
+	}
+}
+
+func TestCopyFiles_VerifyConcurrentMismatch(t *testing.T) {
+	mem := vfs.NewMem()
+	sourceDir := filepath.Join("/", "source")
+	destDir := filepath.Join("/", "dest")
+	mem.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("content"), 0644)
+
+	// 検証のワーカーで検出したハッシュ不一致も失敗として記録される
+	faults, _ := faultinject.Parse("hash-mismatch=1,seed=1")
+	options := DefaultOptions()
+	options.FS = mem
+	options.MaxRetries = 0
+	options.Mode = ModeCopyAndVerify
+	options.VerifyConcurrent = 1
+	options.Faults = faults
+	fc := NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	fc.CopyFiles()
+
+	failures := fc.GetFailures()
+	if len(failures) != 1 || !errors.Is(failures[0].Err, errcode.ErrHashMismatch) {
+		t.Errorf("失敗したファイル = %+v", failures)
+	}
+	if errs := fc.GetStats().GetRecentErrors(); len(errs) != 1 {
+		t.Errorf("エラーの件数 = %d, want 1", len(errs))
+	}
+}
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.etcd.io/bbolt"
+
+	"github.com/sakuhanight/gopier/internal/filter"
+	"github.com/sakuhanight/gopier/internal/pathkey"
+)
+
+// Acknowledgement はオペレーターが確認済み（許容する）とした不一致を表す構造体
+// ごみ箱など、不一致になることが分かっているパスを登録し、以降の検証やレポートで報告しないようにする
+type Acknowledgement struct {
+	Pattern        string    `json:"pattern"`          // パスまたはglobパターン（一致したディレクトリの配下も対象）
+	Reason         string    `json:"reason,omitempty"` // 確認済みとした理由
+	AcknowledgedAt time.Time `json:"acknowledged_at"`
+}
+
+// Acknowledgements は確認済みの不一致の一覧
+type Acknowledgements []Acknowledgement
+
+// Match はパスが確認済みの不一致に該当するかどうかを返す
+func (a Acknowledgements) Match(path string) (Acknowledgement, bool) {
+	for _, ack := range a {
+		if filter.MatchesUnder(path, ack.Pattern) {
+			return ack, true
+		}
+	}
+	return Acknowledgement{}, false
+}
+
+// Acknowledge は不一致を確認済みとして登録する（登録済みのパターンは理由と日時を更新する）
+func (s *SyncDB) Acknowledge(pattern, reason string) error {
+	pattern = strings.Trim(pathkey.Normalize(strings.TrimSpace(pattern)), "/")
+	if pattern == "" {
+		return fmt.Errorf("パターンが指定されていません")
+	}
+	if strings.Contains(pattern, ",") {
+		return fmt.Errorf("パターンにカンマは使用できません: %s", pattern)
+	}
+
+	return s.update("", func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(ackBucket)
+		if bucket == nil {
+			return fmt.Errorf("確認済みバケットが見つかりません")
+		}
+
+		data, err := json.Marshal(Acknowledgement{Pattern: pattern, Reason: reason, AcknowledgedAt: time.Now()})
+		if err != nil {
+			return fmt.Errorf("確認済み情報のシリアライズエラー: %w", err)
+		}
+		return bucket.Put([]byte(pattern), data)
+	})
+}
+
+// Unacknowledge は確認済みの登録を解除する。登録されていなかった場合はfalseを返す
+func (s *SyncDB) Unacknowledge(pattern string) (bool, error) {
+	pattern = strings.Trim(pathkey.Normalize(strings.TrimSpace(pattern)), "/")
+	var removed bool
+
+	err := s.update("", func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(ackBucket)
+		if bucket == nil {
+			return fmt.Errorf("確認済みバケットが見つかりません")
+		}
+		if bucket.Get([]byte(pattern)) == nil {
+			return nil
+		}
+		removed = true
+		return bucket.Delete([]byte(pattern))
+	})
+
+	return removed, err
+}
+
+// GetAcknowledgements は確認済みの不一致をパターン順に取得する
+func (s *SyncDB) GetAcknowledgements() (Acknowledgements, error) {
+	var acks Acknowledgements
+
+	err := s.view(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(ackBucket)
+		if bucket == nil {
+			return fmt.Errorf("確認済みバケットが見つかりません")
+		}
+
+		return bucket.ForEach(func(k, v []byte) error {
+			var ack Acknowledgement
+			if err := json.Unmarshal(v, &ack); err != nil {
+				return fmt.Errorf("確認済み情報のデシリアライズエラー: %w", err)
+			}
+			acks = append(acks, ack)
+			return nil
+		})
+	})
+
+	return acks, err
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestAcknowledgements(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	db, err := NewSyncDB(dbPath, NormalSync)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Acknowledge(`$RECYCLE.BIN\`, "ごみ箱"); err != nil {
+		t.Fatalf("Acknowledge() error = %v", err)
+	}
+	if err := db.Acknowledge("*.lnk", ""); err != nil {
+		t.Fatalf("Acknowledge() error = %v", err)
+	}
+	if err := db.Acknowledge(" ", ""); err == nil {
+		t.Error("空のパターンでエラーになりません")
+	}
+	if err := db.Acknowledge("a,b", ""); err == nil {
+		t.Error("カンマを含むパターンでエラーになりません")
+	}
+
+	// 開き直しても登録が残る
+	db.Close()
+	db, err = NewSyncDB(dbPath, NormalSync)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	acks, err := db.GetAcknowledgements()
+	if err != nil {
+		t.Fatalf("GetAcknowledgements() error = %v", err)
+	}
+	if len(acks) != 2 || acks[0].Pattern != "$RECYCLE.BIN" || acks[0].Reason != "ごみ箱" || acks[0].AcknowledgedAt.IsZero() {
+		t.Fatalf("確認済みの一覧 = %+v", acks)
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"$RECYCLE.BIN/S-1-5-21/$I123.txt", true},
+		{"users/a/$RECYCLE.BIN/desktop.ini", true},
+		{"docs/shortcut.lnk", true},
+		{"docs/report.docx", false},
+	}
+	for _, tt := range tests {
+		if _, got := acks.Match(tt.path); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	removed, err := db.Unacknowledge("*.lnk")
+	if err != nil || !removed {
+		t.Fatalf("Unacknowledge() = %v, %v", removed, err)
+	}
+	if removed, _ := db.Unacknowledge("*.lnk"); removed {
+		t.Error("登録されていないパターンの解除でtrueが返りました")
+	}
+	if acks, _ := db.GetAcknowledgements(); len(acks) != 1 {
+		t.Errorf("解除後の一覧 = %+v", acks)
+	}
+}
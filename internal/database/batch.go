@@ -0,0 +1,133 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.etcd.io/bbolt"
+
+	"github.com/sakuhanight/gopier/internal/pathkey"
+)
+
+// BatchMember は小さいファイルをまとめて書き込んだファイル（セグメント）の中の、1つのファイルの位置
+// ファイル情報とは別のバケットに記録するため、検証などでファイル情報を更新しても失われない
+type BatchMember struct {
+	Segment string    `json:"segment"`  // セグメントの宛先からの相対パス（/区切り）
+	Offset  int64     `json:"offset"`   // セグメントの中の内容の開始位置
+	Size    int64     `json:"size"`     // 内容のサイズ
+	ModTime time.Time `json:"mod_time"` // 書き込んだ時点のソースの更新日時
+}
+
+// BatchEntry はセグメントに書き込んだファイルのパスと位置
+type BatchEntry struct {
+	Path string `json:"path"` // ソースからの相対パス
+	BatchMember
+}
+
+// AddBatchedFile はセグメントに書き込んだファイルのファイル情報と位置を同じトランザクションで記録する
+func (s *SyncDB) AddBatchedFile(file FileInfo, member BatchMember) error {
+	key := pathkey.Normalize(file.Path)
+	return s.updateAsync(key, func(tx *bbolt.Tx) error {
+		if err := putFile(tx, file); err != nil {
+			return err
+		}
+		bucket := tx.Bucket(batchBucket)
+		if bucket == nil {
+			return fmt.Errorf("セグメントのバケットが見つかりません")
+		}
+		data, err := json.Marshal(member)
+		if err != nil {
+			return fmt.Errorf("セグメントの位置のシリアライズエラー: %w", err)
+		}
+		if err := bucket.Put([]byte(key), data); err != nil {
+			return err
+		}
+		return deleteJournal(tx, key)
+	})
+}
+
+// AddUnbatchedFile は個別にコピーしたファイルのファイル情報を記録し、同じトランザクションでセグメントの中の位置の記録を削除する
+// 以前セグメントに書き込んだファイルを個別にコピーした場合に、検証や展開でセグメントの古い内容を参照しないようにする
+func (s *SyncDB) AddUnbatchedFile(file FileInfo) error {
+	key := pathkey.Normalize(file.Path)
+	return s.updateAsync(key, func(tx *bbolt.Tx) error {
+		if err := putFile(tx, file); err != nil {
+			return err
+		}
+		bucket := tx.Bucket(batchBucket)
+		if bucket == nil {
+			return fmt.Errorf("セグメントのバケットが見つかりません")
+		}
+		if err := bucket.Delete([]byte(key)); err != nil {
+			return err
+		}
+		return deleteJournal(tx, key)
+	})
+}
+
+// GetBatchMember はセグメントに書き込んだファイルの位置を取得する（記録がない場合はnil）
+func (s *SyncDB) GetBatchMember(path string) (*BatchMember, error) {
+	key := pathkey.Normalize(path)
+	var member *BatchMember
+
+	err := s.viewFile(key, func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(batchBucket)
+		if bucket == nil {
+			return fmt.Errorf("セグメントのバケットが見つかりません")
+		}
+		data := bucket.Get([]byte(key))
+		if data == nil {
+			return nil
+		}
+		member = &BatchMember{}
+		if err := json.Unmarshal(data, member); err != nil {
+			return fmt.Errorf("セグメントの位置のデシリアライズエラー: %w", err)
+		}
+		return nil
+	})
+
+	return member, err
+}
+
+// GetBatchEntries はセグメントに書き込んだすべてのファイルを、セグメントと位置の順に取得する
+func (s *SyncDB) GetBatchEntries() ([]BatchEntry, error) {
+	var entries []BatchEntry
+
+	err := s.view(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(batchBucket)
+		if bucket == nil {
+			return fmt.Errorf("セグメントのバケットが見つかりません")
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			entry := BatchEntry{Path: string(k)}
+			if err := json.Unmarshal(v, &entry.BatchMember); err != nil {
+				return fmt.Errorf("セグメントの位置のデシリアライズエラー: %w", err)
+			}
+			entries = append(entries, entry)
+			return nil
+		})
+	})
+
+	// セグメントを先頭から順に読み込めるよう並べる
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Segment != entries[j].Segment {
+			return entries[i].Segment < entries[j].Segment
+		}
+		return entries[i].Offset < entries[j].Offset
+	})
+	return entries, err
+}
+
+// DeleteBatchMember はセグメントに書き込んだファイルの位置の記録を削除する
+func (s *SyncDB) DeleteBatchMember(path string) error {
+	key := pathkey.Normalize(path)
+	return s.update(key, func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(batchBucket)
+		if bucket == nil {
+			return fmt.Errorf("セグメントのバケットが見つかりません")
+		}
+		return bucket.Delete([]byte(key))
+	})
+}
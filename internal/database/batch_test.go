@@ -0,0 +1,76 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBatchMembers(t *testing.T) {
+	db, err := NewSyncDB(filepath.Join(t.TempDir(), "test.db"), NormalSync)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	add := func(path, segment string, offset int64) {
+		t.Helper()
+		file := FileInfo{Path: path, Size: 3, ModTime: modTime, Status: StatusSuccess, SourceHash: "abc"}
+		member := BatchMember{Segment: segment, Offset: offset, Size: 3, ModTime: modTime}
+		if err := db.AddBatchedFile(file, member); err != nil {
+			t.Fatalf("AddBatchedFile(%s) error = %v", path, err)
+		}
+	}
+	add("dir/b.txt", "seg/2.tar", 512)
+	add("dir/a.txt", "seg/2.tar", 0)
+	add("c.txt", "seg/1.tar", 1024)
+
+	// ファイル情報も同時に記録する
+	if file, err := db.GetFile("dir/a.txt"); err != nil || file == nil || file.SourceHash != "abc" {
+		t.Fatalf("GetFile() = %+v, %v", file, err)
+	}
+
+	member, err := db.GetBatchMember(`dir\b.txt`)
+	if err != nil || member == nil || member.Segment != "seg/2.tar" || member.Offset != 512 || !member.ModTime.Equal(modTime) {
+		t.Fatalf("GetBatchMember() = %+v, %v", member, err)
+	}
+	if member, err := db.GetBatchMember("none.txt"); err != nil || member != nil {
+		t.Errorf("記録のないパスのGetBatchMember() = %+v, %v", member, err)
+	}
+
+	// セグメントと位置の順に並べる
+	entries, err := db.GetBatchEntries()
+	if err != nil || len(entries) != 3 {
+		t.Fatalf("GetBatchEntries() = %+v, %v", entries, err)
+	}
+	for i, want := range []string{"c.txt", "dir/a.txt", "dir/b.txt"} {
+		if entries[i].Path != want {
+			t.Errorf("%d番目のエントリ = %s, want %s", i, entries[i].Path, want)
+		}
+	}
+
+	// ファイル情報を更新しても位置の記録は残る
+	db.AddFile(FileInfo{Path: "c.txt", Size: 3, ModTime: modTime, Status: StatusVerified})
+	if member, _ := db.GetBatchMember("c.txt"); member == nil {
+		t.Error("ファイル情報の更新で位置の記録が失われました")
+	}
+
+	if err := db.DeleteBatchMember("c.txt"); err != nil {
+		t.Fatalf("DeleteBatchMember() error = %v", err)
+	}
+	if member, _ := db.GetBatchMember("c.txt"); member != nil {
+		t.Errorf("削除後のGetBatchMember() = %+v", member)
+	}
+
+	// 個別にコピーしたファイルとして記録すると位置の記録を削除する
+	if err := db.AddUnbatchedFile(FileInfo{Path: "dir/a.txt", Size: 4, ModTime: modTime, Status: StatusSuccess}); err != nil {
+		t.Fatalf("AddUnbatchedFile() error = %v", err)
+	}
+	if member, _ := db.GetBatchMember("dir/a.txt"); member != nil {
+		t.Errorf("個別にコピーしたファイルの位置の記録が残っています: %+v", member)
+	}
+	if file, _ := db.GetFile("dir/a.txt"); file == nil || file.Size != 4 {
+		t.Errorf("個別にコピーしたファイルの記録 = %+v", file)
+	}
+}
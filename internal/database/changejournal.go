@@ -0,0 +1,57 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// changeJournalKeyPrefix はメタ情報バケットの変更ジャーナルの位置のキーの接頭辞（ソースのパスを続ける）
+const changeJournalKeyPrefix = "change_journal:"
+
+// ChangeJournalCursor はソースのボリュームの変更ジャーナル（NTFSのUSNジャーナル）を前回読み込んだ位置
+// 次回の実行では、この位置以降に変更されたファイルのみを処理する
+type ChangeJournalCursor struct {
+	Source     string    `json:"source"`     // ソースのパス（絶対パス）
+	Volume     string    `json:"volume"`     // ソースのボリューム
+	JournalID  uint64    `json:"journal_id"` // ジャーナルのID（ジャーナルを作り直すと変わる）
+	NextUSN    int64     `json:"next_usn"`   // 次に読み込む位置
+	Filter     string    `json:"filter"`     // 記録した実行のフィルタ（変更された場合は全体を走査する）
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// SaveChangeJournalCursor は変更ジャーナルの位置を記録する（同じソースの記録は置き換える）
+func (s *SyncDB) SaveChangeJournalCursor(cursor ChangeJournalCursor) error {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return fmt.Errorf("変更ジャーナルの位置のシリアライズエラー: %w", err)
+	}
+	return s.update("", func(tx *bbolt.Tx) error {
+		return tx.Bucket(metaBucket).Put([]byte(changeJournalKeyPrefix+cursor.Source), data)
+	})
+}
+
+// GetChangeJournalCursor はソースの変更ジャーナルの位置を取得する（記録がない場合はnil）
+func (s *SyncDB) GetChangeJournalCursor(source string) (*ChangeJournalCursor, error) {
+	var cursor *ChangeJournalCursor
+	err := s.view(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(metaBucket).Get([]byte(changeJournalKeyPrefix + source))
+		if data == nil {
+			return nil
+		}
+		cursor = &ChangeJournalCursor{}
+		if err := json.Unmarshal(data, cursor); err != nil {
+			return fmt.Errorf("変更ジャーナルの位置のデシリアライズエラー: %w", err)
+		}
+		return nil
+	})
+	return cursor, err
+}
+
+// deleteChangeJournalCursors はすべてのソースの変更ジャーナルの位置を削除する
+// ファイルの記録を消した後に変更されたファイルのみを処理すると、変更されていないファイルが漏れるため
+func deleteChangeJournalCursors(tx *bbolt.Tx) error {
+	return deleteMetaKeys(tx, changeJournalKeyPrefix)
+}
@@ -0,0 +1,39 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestChangeJournalCursor(t *testing.T) {
+	db, err := NewSyncDB(filepath.Join(t.TempDir(), "test.db"), InitialSync)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if cursor, err := db.GetChangeJournalCursor(`D:\share`); err != nil || cursor != nil {
+		t.Fatalf("記録のないソースのGetChangeJournalCursor() = %+v, %v", cursor, err)
+	}
+
+	db.SaveChangeJournalCursor(ChangeJournalCursor{Source: `D:\share`, JournalID: 1, NextUSN: 100})
+	db.SaveChangeJournalCursor(ChangeJournalCursor{Source: `D:\other`, JournalID: 1, NextUSN: 50})
+	// 同じソースの記録は置き換える
+	if err := db.SaveChangeJournalCursor(ChangeJournalCursor{Source: `D:\share`, Volume: `\\?\Volume{1}\`, JournalID: 1, NextUSN: 200, Filter: "f"}); err != nil {
+		t.Fatalf("SaveChangeJournalCursor() error = %v", err)
+	}
+	cursor, err := db.GetChangeJournalCursor(`D:\share`)
+	if err != nil || cursor == nil || cursor.NextUSN != 200 || cursor.Volume != `\\?\Volume{1}\` || cursor.Filter != "f" {
+		t.Fatalf("GetChangeJournalCursor() = %+v, %v", cursor, err)
+	}
+
+	// ファイルの記録を消した場合は、次回は全体を走査する
+	if err := db.ResetDatabase(); err != nil {
+		t.Fatal(err)
+	}
+	for _, source := range []string{`D:\share`, `D:\other`} {
+		if cursor, _ := db.GetChangeJournalCursor(source); cursor != nil {
+			t.Errorf("ResetDatabase()の後に%sの位置が残っています: %+v", source, cursor)
+		}
+	}
+}
@@ -1,13 +1,22 @@
 package database
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.etcd.io/bbolt"
+
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/fsmeta"
+	"github.com/sakuhanight/gopier/internal/pathkey"
 )
 
 // SyncMode は同期モードを表す型
@@ -38,19 +47,116 @@ const (
 	StatusVerified FileStatus = "verified"
 	// StatusMismatch はハッシュ不一致の状態
 	StatusMismatch FileStatus = "mismatch"
+	// StatusDeleted は余分なファイルとして削除された状態
+	StatusDeleted FileStatus = "deleted"
+	// StatusQuarantined は余分なファイルとして隔離された状態
+	StatusQuarantined FileStatus = "quarantined"
+	// StatusLocked は他のプロセスが使用中のためコピーできなかった状態
+	StatusLocked FileStatus = "locked"
+	// StatusDataOKPermissionFailed は内容はコピーしたが、アクセス権をコピーできなかった状態（次回の実行でアクセス権のみ再度設定する）
+	StatusDataOKPermissionFailed FileStatus = "permission_failed"
+	// StatusIntermittent はハッシュが一度一致せず、読み直した再検証で一致した状態（一時的な読み込みの不具合の可能性がある）
+	StatusIntermittent FileStatus = "intermittent"
+)
+
+// FileStatuses はファイルの同期状態の一覧
+var FileStatuses = []FileStatus{
+	StatusPending, StatusSuccess, StatusFailed, StatusSkipped, StatusVerified, StatusMismatch,
+	StatusDeleted, StatusQuarantined, StatusLocked, StatusDataOKPermissionFailed, StatusIntermittent,
+}
+
+// Valid は既知の同期状態かどうかを返す
+func (s FileStatus) Valid() bool {
+	for _, status := range FileStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// ChangeKind はセッション中に宛先に加えた変更の種類を表す型
+type ChangeKind string
+
+const (
+	// ChangeCreated は宛先にファイルを作成した変更
+	ChangeCreated ChangeKind = "created"
+	// ChangeUpdated は宛先の既存のファイルを上書きした変更
+	ChangeUpdated ChangeKind = "updated"
+	// ChangeDeleted は宛先の余分なファイルを削除（隔離を含む）した変更
+	ChangeDeleted ChangeKind = "deleted"
+)
+
+// SessionType はセッションの種類を表す型
+type SessionType string
+
+const (
+	// SessionCopy はコピーセッション
+	SessionCopy SessionType = "copy"
+	// SessionVerify は検証セッション
+	SessionVerify SessionType = "verify"
 )
 
 // FileInfo はファイル情報を表す構造体
 type FileInfo struct {
-	Path         string     `json:"path"`           // ファイルパス（相対パス）
-	Size         int64      `json:"size"`           // ファイルサイズ
-	ModTime      time.Time  `json:"mod_time"`       // 最終更新時間
-	Status       FileStatus `json:"status"`         // 同期状態
-	SourceHash   string     `json:"source_hash"`    // ソースファイルのハッシュ
-	DestHash     string     `json:"dest_hash"`      // 宛先ファイルのハッシュ
-	FailCount    int        `json:"fail_count"`     // 失敗回数
-	LastSyncTime time.Time  `json:"last_sync_time"` // 最終同期時間
-	LastError    string     `json:"last_error"`     // 最後のエラーメッセージ
+	Path         string     `json:"path"`                  // ファイルパス（相対パス）
+	DestPath     string     `json:"dest_path,omitempty"`   // 宛先の相対パス（ソースと異なる場合のみ）
+	Size         int64      `json:"size"`                  // ファイルサイズ
+	ModTime      time.Time  `json:"mod_time"`              // 最終更新時間（ナノ秒まで記録する）
+	AccessTime   *time.Time `json:"access_time,omitempty"` // ソースの最終アクセス時間（アクセス日時を保持した場合のみ）
+	Status       FileStatus `json:"status"`                // 同期状態
+	SourceHash   string     `json:"source_hash"`           // ソースファイルのハッシュ
+	DestHash     string     `json:"dest_hash"`             // 宛先ファイルのハッシュ
+	HashAlgo     string     `json:"hash_algo,omitempty"`   // SourceHash・DestHashのアルゴリズム
+	FailCount    int        `json:"fail_count"`            // 失敗回数
+	LastSyncTime time.Time  `json:"last_sync_time"`        // 最終同期時間
+	LastError    string     `json:"last_error"`            // 最後のエラーメッセージ
+	SessionID    int64      `json:"session_id,omitempty"`  // 最後にコピー処理を行ったセッションのID
+	Change       ChangeKind `json:"change,omitempty"`      // SessionIDのセッションで宛先に加えた変更
+
+	// 最後のエラーのエラーコード・エラー番号・再試行回数（記録していない場合はnil）
+	Error *errcode.Detail `json:"error,omitempty"`
+
+	// 失敗ではない記録（スキップ・余分なファイルなど）のLastErrorの理由
+	Reason Reason `json:"reason,omitempty"`
+
+	// ソースファイルの所有者・パーミッション・inodeなど（記録していない場合はnil）
+	Meta *fsmeta.Metadata `json:"meta,omitempty"`
+
+	// 複数の宛先にコピーする場合の宛先ごとの同期状態（キーは宛先ディレクトリ）
+	Targets map[string]TargetStatus `json:"targets,omitempty"`
+
+	// コピー時に内容を変換した場合の変換情報（変換していない場合はnil）
+	Transform *TransformInfo `json:"transform,omitempty"`
+
+	// 最後のコピーの処理時間・再試行回数・ワーカー（詳細を記録した場合のみ、記録していない場合はnil）
+	Processing *Processing `json:"processing,omitempty"`
+}
+
+// Processing はファイルのコピーの処理の詳細を表す構造体
+// 性能の問題を特定のファイルやワーカーにたどれるよう、詳細なレポートを指定した場合に記録する
+type Processing struct {
+	Seconds    float64 `json:"seconds"`                  // 処理時間（一時停止していた時間は含めない）
+	Retries    int     `json:"retries"`                  // 再試行した回数
+	Worker     int     `json:"worker"`                   // 処理したワーカーの番号（0から）
+	Throughput float64 `json:"throughput_bytes_per_sec"` // ファイルのサイズを処理時間で割った値
+}
+
+// TransformInfo はコピー時に内容を変換したファイルの変換情報を表す構造体
+// 変換したファイルのSourceHashは変換前、DestHashは宛先の内容のハッシュになる
+type TransformInfo struct {
+	Transformers []string `json:"transformers"`  // 適用した変換（適用順）
+	OriginalHash string   `json:"original_hash"` // 変換前の内容のハッシュ
+	OutputHash   string   `json:"output_hash"`   // 変換後の内容のハッシュ
+	OutputSize   int64    `json:"output_size"`   // 変換後のサイズ
+}
+
+// TargetStatus は宛先ごとの同期状態を表す構造体
+type TargetStatus struct {
+	Status       FileStatus      `json:"status"`
+	LastSyncTime time.Time       `json:"last_sync_time"`
+	LastError    string          `json:"last_error,omitempty"`
+	Code         errcode.Catalog `json:"code,omitempty"` // LastErrorのエラーコード
 }
 
 // SyncSession は同期セッション情報を表す構造体
@@ -59,11 +165,121 @@ type SyncSession struct {
 	StartTime    time.Time `json:"start_time"`
 	EndTime      time.Time `json:"end_time"`
 	Mode         string    `json:"mode"`
+	Type         string    `json:"type,omitempty"`
 	FilesCopied  int       `json:"files_copied"`
 	FilesSkipped int       `json:"files_skipped"`
 	FilesFailed  int       `json:"files_failed"`
 	BytesCopied  int64     `json:"bytes_copied"`
 	Status       string    `json:"status"`
+
+	// 実行時に指定したラベルとタグ（移行の段階ごとの集計などに使用する）
+	Label string            `json:"label,omitempty"`
+	Tags  map[string]string `json:"tags,omitempty"`
+
+	// 実行時のパスを絶対パスにしたもの（相対パスで指定した設定をどこで実行したかを確認するために使用する）
+	Paths *SessionPaths `json:"paths,omitempty"`
+
+	// セッション中に行った検証の結果（検証を行わなかった場合はnil）
+	Verification *VerificationSummary `json:"verification,omitempty"`
+
+	// セッション終了時の統計情報（記録する前のバージョンで実行したセッションはnil）
+	Stats *SessionStats `json:"stats,omitempty"`
+}
+
+// SessionStats はセッション終了時の統計情報を表す構造体
+// 実行後にコンソールの出力がなくても、過去の実行と比較できるようにする
+type SessionStats struct {
+	FilesCopied    int64 `json:"files_copied"`
+	FilesSkipped   int64 `json:"files_skipped"`
+	FilesFailed    int64 `json:"files_failed"`
+	FilesIgnored   int64 `json:"files_ignored"`
+	Conflicts      int64 `json:"conflicts"`
+	SharingRetries int64 `json:"sharing_retries"`
+	FilesLocked    int64 `json:"files_locked"`
+	PermFailed     int64 `json:"files_perm_failed"`
+	MetaUpdated    int64 `json:"meta_updated"`
+	BytesCopied    int64 `json:"bytes_copied"`
+	BytesSkipped   int64 `json:"bytes_skipped"`
+}
+
+// SessionPaths はセッションを実行した作業ディレクトリと、ソース・宛先などの絶対パスを表す構造体
+type SessionPaths struct {
+	WorkDir     string `json:"work_dir"`
+	Source      string `json:"source,omitempty"`
+	Destination string `json:"destination,omitempty"`
+	SyncDB      string `json:"sync_db,omitempty"`
+	LogFile     string `json:"log_file,omitempty"`
+}
+
+// VerifyOutcome は1ファイルの検証結果の分類を表す型
+type VerifyOutcome int
+
+const (
+	// VerifyMatched は内容が一致した
+	VerifyMatched VerifyOutcome = iota
+	// VerifyMismatched はサイズまたはハッシュが一致しなかった
+	VerifyMismatched
+	// VerifyMissingDest は宛先ファイルが存在しなかった
+	VerifyMissingDest
+	// VerifyError は読み込みやハッシュ計算に失敗した
+	VerifyError
+	// VerifyExtra は宛先にのみ存在した
+	VerifyExtra
+	// VerifyIntermittent はハッシュが一度一致せず、読み直した再検証で一致した
+	VerifyIntermittent
+	// VerifyLinkMismatch はシンボリックリンク・ジャンクションのリンク先が一致しなかった
+	VerifyLinkMismatch
+)
+
+// VerificationSummary はセッション中の検証結果の集計を表す構造体
+type VerificationSummary struct {
+	Matched      int   `json:"matched"`
+	Mismatched   int   `json:"mismatched"`
+	MissingDest  int   `json:"missing_dest"`
+	Errors       int   `json:"errors"`
+	Extra        int   `json:"extra"`
+	Intermittent int   `json:"intermittent,omitempty"`  // 再検証で一致したファイル数（Matchedにも含める）
+	LinkMismatch int   `json:"link_mismatch,omitempty"` // リンク先が一致しなかったリンクの数
+	Bytes        int64 `json:"bytes"`                   // 内容を比較したバイト数
+}
+
+// Add は1ファイルの検証結果を集計に加える
+func (v *VerificationSummary) Add(outcome VerifyOutcome, bytes int64) {
+	switch outcome {
+	case VerifyMatched:
+		v.Matched++
+		v.Bytes += bytes
+	case VerifyIntermittent:
+		v.Matched++
+		v.Intermittent++
+		v.Bytes += bytes
+	case VerifyMismatched:
+		v.Mismatched++
+		v.Bytes += bytes
+	case VerifyLinkMismatch:
+		v.LinkMismatch++
+	case VerifyMissingDest:
+		v.MissingDest++
+	case VerifyError:
+		v.Errors++
+	case VerifyExtra:
+		v.Extra++
+	}
+}
+
+// Verified は検証したファイル数（宛先にのみ存在したファイルを除く）を返す
+func (v VerificationSummary) Verified() int {
+	return v.Matched + v.Mismatched + v.LinkMismatch + v.MissingDest + v.Errors
+}
+
+// MismatchRate は内容を比較したファイルのうち一致しなかった割合を返す
+// 記録媒体の劣化などによる破損の傾向を見るため、宛先がない・読み込めないファイルは含めない
+func (v VerificationSummary) MismatchRate() float64 {
+	compared := v.Matched + v.Mismatched
+	if compared == 0 {
+		return 0
+	}
+	return float64(v.Mismatched) / float64(compared)
 }
 
 // SyncDB は同期状態データベースを管理する構造体
@@ -71,27 +287,62 @@ type SyncDB struct {
 	db       *bbolt.DB
 	dbPath   string
 	syncMode SyncMode
+	queue    *writeQueue // 書き込みキュー（EnableWriteQueueで有効にした場合のみ）
+
+	// 以降に開始するセッションに記録するラベルとタグ・パス
+	sessionLabel string
+	sessionTags  map[string]string
+	sessionPaths *SessionPaths
 }
 
 // バケット名の定数
 var (
-	fileSyncBucket = []byte("file_sync")
-	sessionBucket  = []byte("sync_session")
-	statsBucket    = []byte("sync_stats")
+	fileSyncBucket     = []byte("file_sync")
+	sessionBucket      = []byte("sync_session")
+	statsBucket        = []byte("sync_stats")
+	metaBucket         = []byte("meta")
+	ackBucket          = []byte("acknowledged")
+	journalBucket      = []byte("journal")
+	batchBucket        = []byte("batch")
+	resumeBucket       = []byte("resume")
+	verifyResumeBucket = []byte("verify_resume")
+)
+
+// メタ情報のキー
+var (
+	pathKeyVersionKey    = []byte("path_key_version")
+	fileSchemaVersionKey = []byte("file_schema_version")
 )
 
+// defaultOpenTimeout は他のプロセスがデータベースを使用している場合にロックを待つ時間
+const defaultOpenTimeout = 1 * time.Second
+
+// currentPathKeyVersion はパスキー形式のバージョン（1: スラッシュ区切り）
+const currentPathKeyVersion = "1"
+
+// currentFileSchemaVersion はファイル情報レコードの形式のバージョン（2: メタデータを含む、3: LastErrorの理由・エラーコードを含む）
+const currentFileSchemaVersion = 3
+
+// reasonSchemaVersion はLastErrorの理由・エラーコードを記録するようになったファイル情報レコードの形式のバージョン
+const reasonSchemaVersion = 3
+
 // NewSyncDB は新しい同期データベースを作成する
 func NewSyncDB(dbPath string, mode SyncMode) (*SyncDB, error) {
 	// データベースディレクトリの作成
 	dbDir := filepath.Dir(dbPath)
 	if err := os.MkdirAll(dbDir, 0755); err != nil {
-		return nil, fmt.Errorf("データベースディレクトリの作成に失敗: %w", err)
+		return nil, errcode.Errorf(errcode.ErrDatabase, "データベースディレクトリの作成に失敗: %w", err)
 	}
 
 	// BoltDBデータベースを開く
-	db, err := bbolt.Open(dbPath, 0600, &bbolt.Options{Timeout: 1 * time.Second})
+	db, err := bbolt.Open(dbPath, 0600, &bbolt.Options{Timeout: defaultOpenTimeout})
 	if err != nil {
-		return nil, fmt.Errorf("データベース接続エラー: %w", err)
+		kind := errcode.ErrDatabase
+		if errors.Is(err, bbolt.ErrTimeout) {
+			// 他のプロセスがデータベースを使用している
+			kind = errcode.ErrDatabaseLocked
+		}
+		return nil, errcode.Errorf(kind, "データベース接続エラー: %w", err)
 	}
 
 	syncDB := &SyncDB{
@@ -103,14 +354,65 @@ func NewSyncDB(dbPath string, mode SyncMode) (*SyncDB, error) {
 	// バケットの初期化
 	if err := syncDB.initBuckets(); err != nil {
 		db.Close()
-		return nil, err
+		return nil, errcode.Wrap(errcode.ErrDatabase, err)
+	}
+
+	// 旧形式のパスキーを移行
+	if err := syncDB.migratePathKeys(); err != nil {
+		db.Close()
+		return nil, errcode.Wrap(errcode.ErrDatabase, err)
+	}
+
+	// ファイル情報レコードの形式を移行
+	if err := syncDB.migrateFileSchema(); err != nil {
+		db.Close()
+		return nil, errcode.Wrap(errcode.ErrDatabase, err)
 	}
 
 	return syncDB, nil
 }
 
+// OpenReadOnly は既存のデータベースを読み取り専用で開く
+// バケットの作成や形式の移行を行わないため、内容を変更しない（書き込む操作はエラーになる）
+func OpenReadOnly(dbPath string) (*SyncDB, error) {
+	db, err := bbolt.Open(dbPath, 0600, &bbolt.Options{Timeout: defaultOpenTimeout, ReadOnly: true})
+	if err != nil {
+		kind := errcode.ErrDatabase
+		if errors.Is(err, bbolt.ErrTimeout) {
+			// 他のプロセスがデータベースを使用している
+			kind = errcode.ErrDatabaseLocked
+		}
+		return nil, errcode.Errorf(kind, "データベース接続エラー: %w", err)
+	}
+
+	// 新しい形式のレコードは正しく読み込めないため開かない
+	err = db.View(func(tx *bbolt.Tx) error {
+		meta := tx.Bucket(metaBucket)
+		if meta == nil {
+			return nil
+		}
+		current, err := parseSchemaVersion(meta.Get(fileSchemaVersionKey))
+		if err != nil {
+			return err
+		}
+		if current > currentFileSchemaVersion {
+			return fmt.Errorf("データベースの形式（バージョン%d）はこのバージョンのgopierでは扱えません", current)
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, errcode.Wrap(errcode.ErrDatabase, err)
+	}
+
+	return &SyncDB{db: db, dbPath: dbPath}, nil
+}
+
 // Close はデータベース接続を閉じる
 func (s *SyncDB) Close() error {
+	if s.queue != nil {
+		s.queue.close()
+	}
 	return s.db.Close()
 }
 
@@ -132,17 +434,146 @@ func (s *SyncDB) initBuckets() error {
 			return fmt.Errorf("統計バケット作成エラー: %w", err)
 		}
 
+		// メタ情報バケット
+		if _, err := tx.CreateBucketIfNotExists(metaBucket); err != nil {
+			return fmt.Errorf("メタ情報バケット作成エラー: %w", err)
+		}
+
+		// 確認済みの不一致バケット
+		if _, err := tx.CreateBucketIfNotExists(ackBucket); err != nil {
+			return fmt.Errorf("確認済みバケット作成エラー: %w", err)
+		}
+
+		// コピー中のファイルのジャーナルバケット
+		if _, err := tx.CreateBucketIfNotExists(journalBucket); err != nil {
+			return fmt.Errorf("ジャーナルバケット作成エラー: %w", err)
+		}
+
+		// 小さいファイルをまとめて書き込んだセグメントの中の位置のバケット
+		if _, err := tx.CreateBucketIfNotExists(batchBucket); err != nil {
+			return fmt.Errorf("セグメントのバケット作成エラー: %w", err)
+		}
+
+		// 中断した書き込みの再開用のトークンのバケット
+		if _, err := tx.CreateBucketIfNotExists(resumeBucket); err != nil {
+			return fmt.Errorf("再開用のトークンのバケット作成エラー: %w", err)
+		}
+
+		// 中断した検証の途中の状態のバケット
+		if _, err := tx.CreateBucketIfNotExists(verifyResumeBucket); err != nil {
+			return fmt.Errorf("検証の途中の状態のバケット作成エラー: %w", err)
+		}
+
 		return nil
 	})
 }
 
+// migratePathKeys はバックスラッシュ区切りで保存された旧形式のパスキーをスラッシュ区切りに移行する
+// 移行済みのデータベースではメタ情報のバージョンを確認するだけで何もしない
+func (s *SyncDB) migratePathKeys() error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		meta := tx.Bucket(metaBucket)
+		if string(meta.Get(pathKeyVersionKey)) == currentPathKeyVersion {
+			return nil
+		}
+
+		bucket := tx.Bucket(fileSyncBucket)
+
+		// ForEach中はバケットを変更できないため、対象キーを先に収集する
+		var oldKeys [][]byte
+		err := bucket.ForEach(func(k, v []byte) error {
+			if pathkey.NeedsMigration(string(k)) {
+				oldKeys = append(oldKeys, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("パスキー移行の走査エラー: %w", err)
+		}
+
+		for _, oldKey := range oldKeys {
+			var fileInfo FileInfo
+			if err := json.Unmarshal(bucket.Get(oldKey), &fileInfo); err != nil {
+				return fmt.Errorf("ファイル情報のデシリアライズエラー: %w", err)
+			}
+			newKey := []byte(pathkey.Normalize(string(oldKey)))
+			fileInfo.Path = string(newKey)
+
+			// 新形式のレコードが既に存在する場合は新しい方を残す
+			if existing := bucket.Get(newKey); existing != nil {
+				var current FileInfo
+				if err := json.Unmarshal(existing, &current); err == nil && current.LastSyncTime.After(fileInfo.LastSyncTime) {
+					if err := bucket.Delete(oldKey); err != nil {
+						return fmt.Errorf("旧パスキーの削除エラー: %w", err)
+					}
+					continue
+				}
+			}
+
+			data, err := json.Marshal(fileInfo)
+			if err != nil {
+				return fmt.Errorf("ファイル情報のシリアライズエラー: %w", err)
+			}
+			if err := bucket.Put(newKey, data); err != nil {
+				return fmt.Errorf("パスキー移行の保存エラー: %w", err)
+			}
+			if err := bucket.Delete(oldKey); err != nil {
+				return fmt.Errorf("旧パスキーの削除エラー: %w", err)
+			}
+		}
+
+		return meta.Put(pathKeyVersionKey, []byte(currentPathKeyVersion))
+	})
+}
+
+// migrateFileSchema はファイル情報レコードの形式のバージョンを更新する
+// バージョン1のレコードはメタデータを持たないが、読み込み時にはnilとして扱えるため変換は不要で、
+// 次回のコピーやシードで記録される。バージョン2以前のレコードはLastErrorの理由・エラーコードを
+// 持たないため、LastErrorのメッセージから設定する。新しい形式のデータベースを古いバージョンで
+// 開いた場合に検出できるよう、メタ情報のバージョンを更新する
+func (s *SyncDB) migrateFileSchema() error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		meta := tx.Bucket(metaBucket)
+		current, err := parseSchemaVersion(meta.Get(fileSchemaVersionKey))
+		if err != nil {
+			return err
+		}
+		if current == currentFileSchemaVersion {
+			return nil
+		}
+		if current > currentFileSchemaVersion {
+			return fmt.Errorf("データベースの形式（バージョン%d）はこのバージョンのgopierでは扱えません", current)
+		}
+		if current < reasonSchemaVersion {
+			if err := migrateLastErrorCodes(tx.Bucket(fileSyncBucket)); err != nil {
+				return err
+			}
+		}
+
+		return meta.Put(fileSchemaVersionKey, []byte(strconv.Itoa(currentFileSchemaVersion)))
+	})
+}
+
+// parseSchemaVersion はメタ情報に記録したファイル情報レコードの形式のバージョンを解析する
+// バージョンを記録する前のデータベース（バージョン1）と新しいデータベースは記録がないため1を返す
+func parseSchemaVersion(value []byte) (int, error) {
+	if len(value) == 0 {
+		return 1, nil
+	}
+	version, err := strconv.Atoi(string(value))
+	if err != nil {
+		return 0, fmt.Errorf("データベースの形式のバージョンが不正です: %q", value)
+	}
+	return version, nil
+}
+
 // ResetDatabase はデータベースをリセットする（初期同期モード用）
 func (s *SyncDB) ResetDatabase() error {
 	if s.syncMode != InitialSync {
 		return fmt.Errorf("初期同期モードでのみデータベースをリセットできます")
 	}
 
-	return s.db.Update(func(tx *bbolt.Tx) error {
+	return s.update("", func(tx *bbolt.Tx) error {
 		// ファイル同期バケットを削除して再作成
 		if err := tx.DeleteBucket(fileSyncBucket); err != nil {
 			return fmt.Errorf("ファイル同期バケット削除エラー: %w", err)
@@ -159,45 +590,156 @@ func (s *SyncDB) ResetDatabase() error {
 			return fmt.Errorf("統計バケット再作成エラー: %w", err)
 		}
 
+		// 変更ジャーナルの位置を削除（次回は全体を走査する）
+		if err := deleteChangeJournalCursors(tx); err != nil {
+			return fmt.Errorf("変更ジャーナルの位置の削除エラー: %w", err)
+		}
+
+		// 前回の実行のマニフェストを削除（次回はコピーを省略しない）
+		if err := deleteMetaKeys(tx, idempotencyKeyPrefix); err != nil {
+			return fmt.Errorf("前回の実行のマニフェストの削除エラー: %w", err)
+		}
+
 		return nil
 	})
 }
 
+// deleteMetaKeys はメタ情報バケットから接頭辞が一致するキーをすべて削除する
+func deleteMetaKeys(tx *bbolt.Tx, prefix string) error {
+	meta := tx.Bucket(metaBucket)
+	var keys [][]byte
+	c := meta.Cursor()
+	for k, _ := c.Seek([]byte(prefix)); k != nil && bytes.HasPrefix(k, []byte(prefix)); k, _ = c.Next() {
+		keys = append(keys, append([]byte(nil), k...))
+	}
+	for _, k := range keys {
+		if err := meta.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // AddFile はファイル情報をデータベースに追加する
+// 同じトランザクションでジャーナルからファイルの記録を削除する
+// 書き込みキューが有効な場合はキューに積んだ時点で戻る
 func (s *SyncDB) AddFile(file FileInfo) error {
-	return s.db.Update(func(tx *bbolt.Tx) error {
+	key := pathkey.Normalize(file.Path)
+	return s.updateAsync(key, func(tx *bbolt.Tx) error {
+		if err := putFile(tx, file); err != nil {
+			return err
+		}
+		return deleteJournal(tx, key)
+	})
+}
+
+// RenameFiles はfromのファイル情報と、fromがディレクトリの場合はその中のファイル情報を、toの下の同じ相対パスに移動する
+// 宛先で名前を変更したファイル・ディレクトリの記録を、変更後の名前で引き継ぐために使用する。移動した件数を返す
+func (s *SyncDB) RenameFiles(from, to string) (int, error) {
+	from, to = pathkey.Normalize(from), pathkey.Normalize(to)
+	moved := 0
+	err := s.update("", func(tx *bbolt.Tx) error {
 		bucket := tx.Bucket(fileSyncBucket)
 		if bucket == nil {
 			return fmt.Errorf("ファイル同期バケットが見つかりません")
 		}
 
-		// ファイル情報をJSONにシリアライズ
-		data, err := json.Marshal(file)
-		if err != nil {
-			return fmt.Errorf("ファイル情報のシリアライズエラー: %w", err)
+		var files []FileInfo
+		var keys [][]byte
+		if data := bucket.Get([]byte(from)); data != nil {
+			var file FileInfo
+			if err := json.Unmarshal(data, &file); err != nil {
+				return fmt.Errorf("ファイル情報のデシリアライズエラー: %w", err)
+			}
+			files, keys = append(files, file), append(keys, []byte(from))
 		}
-
-		// キーとしてファイルパスを使用
-		key := []byte(file.Path)
-		if err := bucket.Put(key, data); err != nil {
-			return fmt.Errorf("ファイル情報の保存エラー: %w", err)
+		prefix := []byte(from + "/")
+		c := bucket.Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var file FileInfo
+			if err := json.Unmarshal(v, &file); err != nil {
+				return fmt.Errorf("ファイル情報のデシリアライズエラー: %w", err)
+			}
+			files, keys = append(files, file), append(keys, append([]byte(nil), k...))
 		}
 
+		for i, file := range files {
+			if err := bucket.Delete(keys[i]); err != nil {
+				return err
+			}
+			file.Path = to + strings.TrimPrefix(string(keys[i]), from)
+			data, err := json.Marshal(file)
+			if err != nil {
+				return fmt.Errorf("ファイル情報のシリアライズエラー: %w", err)
+			}
+			if err := bucket.Put([]byte(file.Path), data); err != nil {
+				return err
+			}
+		}
+		moved = len(files)
 		return nil
 	})
+	return moved, err
+}
+
+// putFile はトランザクション内でファイル情報を保存する
+func putFile(tx *bbolt.Tx, file FileInfo) error {
+	bucket := tx.Bucket(fileSyncBucket)
+	if bucket == nil {
+		return fmt.Errorf("ファイル同期バケットが見つかりません")
+	}
+
+	// キーとしてファイルパスを使用（区切り文字はスラッシュに統一）
+	file.Path = pathkey.Normalize(file.Path)
+	key := []byte(file.Path)
+
+	// セッションIDやメタデータ・アクセス時間が指定されていない場合は既存の値を引き継ぐ
+	// （変更の種類とコピーの処理の詳細はセッションIDとともに引き継ぐ）
+	if file.SessionID == 0 || file.Meta == nil || file.AccessTime == nil {
+		if existing := bucket.Get(key); existing != nil {
+			var current FileInfo
+			if err := json.Unmarshal(existing, &current); err == nil {
+				if file.SessionID == 0 {
+					file.SessionID = current.SessionID
+					file.Change = current.Change
+					if file.Processing == nil {
+						file.Processing = current.Processing
+					}
+				}
+				if file.Meta == nil {
+					file.Meta = current.Meta
+				}
+				if file.AccessTime == nil {
+					file.AccessTime = current.AccessTime
+				}
+			}
+		}
+	}
+
+	// ファイル情報をJSONにシリアライズ
+	data, err := json.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("ファイル情報のシリアライズエラー: %w", err)
+	}
+
+	if err := bucket.Put(key, data); err != nil {
+		return fmt.Errorf("ファイル情報の保存エラー: %w", err)
+	}
+
+	return nil
 }
 
 // GetFile はファイル情報をデータベースから取得する
 func (s *SyncDB) GetFile(path string) (*FileInfo, error) {
 	var fileInfo FileInfo
 
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.viewFile(pathkey.Normalize(path), func(tx *bbolt.Tx) error {
 		bucket := tx.Bucket(fileSyncBucket)
 		if bucket == nil {
 			return fmt.Errorf("ファイル同期バケットが見つかりません")
 		}
 
-		key := []byte(path)
+		key := []byte(pathkey.Normalize(path))
 		data := bucket.Get(key)
 		if data == nil {
 			return fmt.Errorf("ファイルが見つかりません: %s", path)
@@ -219,13 +761,13 @@ func (s *SyncDB) GetFile(path string) (*FileInfo, error) {
 
 // UpdateFileStatus はファイルの状態を更新する
 func (s *SyncDB) UpdateFileStatus(path string, status FileStatus, lastError string) error {
-	return s.db.Update(func(tx *bbolt.Tx) error {
+	return s.update(pathkey.Normalize(path), func(tx *bbolt.Tx) error {
 		bucket := tx.Bucket(fileSyncBucket)
 		if bucket == nil {
 			return fmt.Errorf("ファイル同期バケットが見つかりません")
 		}
 
-		key := []byte(path)
+		key := []byte(pathkey.Normalize(path))
 		data := bucket.Get(key)
 		if data == nil {
 			// ファイルが存在しない場合は新規作成
@@ -235,7 +777,7 @@ func (s *SyncDB) UpdateFileStatus(path string, status FileStatus, lastError stri
 				LastError:    lastError,
 				LastSyncTime: time.Now(),
 			}
-			return s.AddFile(fileInfo)
+			return putFile(tx, fileInfo)
 		}
 
 		// 既存のファイル情報を更新
@@ -246,6 +788,8 @@ func (s *SyncDB) UpdateFileStatus(path string, status FileStatus, lastError stri
 
 		fileInfo.Status = status
 		fileInfo.LastError = lastError
+		fileInfo.Error = nil
+		fileInfo.Reason = ""
 		fileInfo.LastSyncTime = time.Now()
 
 		// 更新された情報を保存
@@ -262,15 +806,15 @@ func (s *SyncDB) UpdateFileStatus(path string, status FileStatus, lastError stri
 	})
 }
 
-// UpdateFileHash はファイルのハッシュ情報を更新する
-func (s *SyncDB) UpdateFileHash(path string, sourceHash, destHash string) error {
-	return s.db.Update(func(tx *bbolt.Tx) error {
+// UpdateFileHash はファイルのハッシュ情報とハッシュのアルゴリズムを更新する
+func (s *SyncDB) UpdateFileHash(path string, sourceHash, destHash, hashAlgo string) error {
+	return s.update(pathkey.Normalize(path), func(tx *bbolt.Tx) error {
 		bucket := tx.Bucket(fileSyncBucket)
 		if bucket == nil {
 			return fmt.Errorf("ファイル同期バケットが見つかりません")
 		}
 
-		key := []byte(path)
+		key := []byte(pathkey.Normalize(path))
 		data := bucket.Get(key)
 		if data == nil {
 			return fmt.Errorf("ファイルが見つかりません: %s", path)
@@ -283,6 +827,7 @@ func (s *SyncDB) UpdateFileHash(path string, sourceHash, destHash string) error
 
 		fileInfo.SourceHash = sourceHash
 		fileInfo.DestHash = destHash
+		fileInfo.HashAlgo = hashAlgo
 		fileInfo.LastSyncTime = time.Now()
 
 		newData, err := json.Marshal(fileInfo)
@@ -298,17 +843,42 @@ func (s *SyncDB) UpdateFileHash(path string, sourceHash, destHash string) error
 	})
 }
 
+// UpdateFileProcessing はファイルのコピーの処理の詳細を記録する
+// ファイルの記録がない場合は何もしない。書き込みキューが有効な場合はキューに積んだ時点で戻る
+func (s *SyncDB) UpdateFileProcessing(path string, processing Processing) error {
+	key := pathkey.Normalize(path)
+	return s.updateAsync(key, func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(fileSyncBucket)
+		data := bucket.Get([]byte(key))
+		if data == nil {
+			return nil
+		}
+
+		var fileInfo FileInfo
+		if err := json.Unmarshal(data, &fileInfo); err != nil {
+			return fmt.Errorf("ファイル情報のデシリアライズエラー: %w", err)
+		}
+		fileInfo.Processing = &processing
+
+		newData, err := json.Marshal(fileInfo)
+		if err != nil {
+			return fmt.Errorf("ファイル情報のシリアライズエラー: %w", err)
+		}
+		return bucket.Put([]byte(key), newData)
+	})
+}
+
 // IncrementFailCount はファイルの失敗回数を増加させる
 func (s *SyncDB) IncrementFailCount(path string) (int, error) {
 	var failCount int
 
-	err := s.db.Update(func(tx *bbolt.Tx) error {
+	err := s.update(pathkey.Normalize(path), func(tx *bbolt.Tx) error {
 		bucket := tx.Bucket(fileSyncBucket)
 		if bucket == nil {
 			return fmt.Errorf("ファイル同期バケットが見つかりません")
 		}
 
-		key := []byte(path)
+		key := []byte(pathkey.Normalize(path))
 		data := bucket.Get(key)
 		if data == nil {
 			return fmt.Errorf("ファイルが見つかりません: %s", path)
@@ -342,7 +912,7 @@ func (s *SyncDB) IncrementFailCount(path string) (int, error) {
 func (s *SyncDB) GetFailedFiles(maxFailCount int) ([]FileInfo, error) {
 	var failedFiles []FileInfo
 
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.view(func(tx *bbolt.Tx) error {
 		bucket := tx.Bucket(fileSyncBucket)
 		if bucket == nil {
 			return fmt.Errorf("ファイル同期バケットが見つかりません")
@@ -354,8 +924,9 @@ func (s *SyncDB) GetFailedFiles(maxFailCount int) ([]FileInfo, error) {
 				return fmt.Errorf("ファイル情報のデシリアライズエラー: %w", err)
 			}
 
-			// 失敗状態で、かつ最大失敗回数未満のファイルを追加
-			if fileInfo.Status == StatusFailed && (maxFailCount == 0 || fileInfo.FailCount < maxFailCount) {
+			// 失敗状態（使用中を含む）で、かつ最大失敗回数未満のファイルを追加
+			failed := fileInfo.Status == StatusFailed || fileInfo.Status == StatusLocked
+			if failed && (maxFailCount == 0 || fileInfo.FailCount < maxFailCount) {
 				failedFiles = append(failedFiles, fileInfo)
 			}
 
@@ -370,7 +941,7 @@ func (s *SyncDB) GetFailedFiles(maxFailCount int) ([]FileInfo, error) {
 func (s *SyncDB) GetFilesByStatus(status FileStatus) ([]FileInfo, error) {
 	var files []FileInfo
 
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.view(func(tx *bbolt.Tx) error {
 		bucket := tx.Bucket(fileSyncBucket)
 		if bucket == nil {
 			return fmt.Errorf("ファイル同期バケットが見つかりません")
@@ -397,7 +968,7 @@ func (s *SyncDB) GetFilesByStatus(status FileStatus) ([]FileInfo, error) {
 func (s *SyncDB) GetAllFiles() ([]FileInfo, error) {
 	var files []FileInfo
 
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.view(func(tx *bbolt.Tx) error {
 		bucket := tx.Bucket(fileSyncBucket)
 		if bucket == nil {
 			return fmt.Errorf("ファイル同期バケットが見つかりません")
@@ -417,11 +988,77 @@ func (s *SyncDB) GetAllFiles() ([]FileInfo, error) {
 	return files, err
 }
 
-// StartSyncSession は新しい同期セッションを開始する
+// ForEachFile はファイル情報をパス順に1件ずつ読み込んでfnに渡す（reverseの場合は逆順）
+// 全件をメモリに読み込まないため、大規模なデータベースの走査に使用する
+func (s *SyncDB) ForEachFile(reverse bool, fn func(FileInfo) error) error {
+	return s.view(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(fileSyncBucket)
+		if bucket == nil {
+			return fmt.Errorf("ファイル同期バケットが見つかりません")
+		}
+
+		c := bucket.Cursor()
+		k, v := c.First()
+		if reverse {
+			k, v = c.Last()
+		}
+		for k != nil {
+			var fileInfo FileInfo
+			if err := json.Unmarshal(v, &fileInfo); err != nil {
+				return fmt.Errorf("ファイル情報のデシリアライズエラー: %w", err)
+			}
+			if err := fn(fileInfo); err != nil {
+				return err
+			}
+
+			if reverse {
+				k, v = c.Prev()
+			} else {
+				k, v = c.Next()
+			}
+		}
+		return nil
+	})
+}
+
+// CountFiles はファイル情報の件数を返す
+func (s *SyncDB) CountFiles() (int, error) {
+	var count int
+
+	err := s.view(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(fileSyncBucket)
+		if bucket == nil {
+			return fmt.Errorf("ファイル同期バケットが見つかりません")
+		}
+
+		count = bucket.Stats().KeyN
+		return nil
+	})
+
+	return count, err
+}
+
+// StartSyncSession は新しいコピーセッションを開始する
 func (s *SyncDB) StartSyncSession() (int64, error) {
+	return s.StartSession(SessionCopy)
+}
+
+// SetSessionLabel は以降に開始するセッションに記録するラベルとタグを設定する
+func (s *SyncDB) SetSessionLabel(label string, tags map[string]string) {
+	s.sessionLabel = label
+	s.sessionTags = tags
+}
+
+// SetSessionPaths は以降に開始するセッションに記録するパスを設定する
+func (s *SyncDB) SetSessionPaths(paths *SessionPaths) {
+	s.sessionPaths = paths
+}
+
+// StartSession は指定された種類の同期セッションを開始する
+func (s *SyncDB) StartSession(sessionType SessionType) (int64, error) {
 	var sessionID int64
 
-	err := s.db.Update(func(tx *bbolt.Tx) error {
+	err := s.update("", func(tx *bbolt.Tx) error {
 		bucket := tx.Bucket(sessionBucket)
 		if bucket == nil {
 			return fmt.Errorf("セッションバケットが見つかりません")
@@ -434,7 +1071,11 @@ func (s *SyncDB) StartSyncSession() (int64, error) {
 			ID:        sessionID,
 			StartTime: time.Now(),
 			Mode:      string(s.syncMode),
+			Type:      string(sessionType),
 			Status:    "running",
+			Label:     s.sessionLabel,
+			Tags:      s.sessionTags,
+			Paths:     s.sessionPaths,
 		}
 
 		data, err := json.Marshal(session)
@@ -450,12 +1091,12 @@ func (s *SyncDB) StartSyncSession() (int64, error) {
 		return nil
 	})
 
-	return sessionID, err
+	return sessionID, errcode.Wrap(errcode.ErrDatabase, err)
 }
 
 // EndSyncSession は同期セッションを終了する
 func (s *SyncDB) EndSyncSession(sessionID int64, filesCopied, filesSkipped, filesFailed int, bytesCopied int64) error {
-	return s.db.Update(func(tx *bbolt.Tx) error {
+	return s.update("", func(tx *bbolt.Tx) error {
 		bucket := tx.Bucket(sessionBucket)
 		if bucket == nil {
 			return fmt.Errorf("セッションバケットが見つかりません")
@@ -492,11 +1133,161 @@ func (s *SyncDB) EndSyncSession(sessionID int64, filesCopied, filesSkipped, file
 	})
 }
 
+// RecordVerification はセッションに検証結果の集計を記録する
+func (s *SyncDB) RecordVerification(sessionID int64, summary VerificationSummary) error {
+	return s.updateSession(sessionID, func(session *SyncSession) {
+		session.Verification = &summary
+	})
+}
+
+// RecordStats はセッションに終了時の統計情報を記録する
+func (s *SyncDB) RecordStats(sessionID int64, stats SessionStats) error {
+	return s.updateSession(sessionID, func(session *SyncSession) {
+		session.Stats = &stats
+	})
+}
+
+// updateSession は記録済みのセッション情報を読み込み、fnで変更して保存する
+func (s *SyncDB) updateSession(sessionID int64, fn func(session *SyncSession)) error {
+	return s.update("", func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(sessionBucket)
+		if bucket == nil {
+			return fmt.Errorf("セッションバケットが見つかりません")
+		}
+
+		key := []byte(fmt.Sprintf("%d", sessionID))
+		data := bucket.Get(key)
+		if data == nil {
+			return fmt.Errorf("セッションが見つかりません: %d", sessionID)
+		}
+
+		var session SyncSession
+		if err := json.Unmarshal(data, &session); err != nil {
+			return fmt.Errorf("セッション情報のデシリアライズエラー: %w", err)
+		}
+
+		fn(&session)
+
+		newData, err := json.Marshal(session)
+		if err != nil {
+			return fmt.Errorf("セッション情報のシリアライズエラー: %w", err)
+		}
+		return bucket.Put(key, newData)
+	})
+}
+
+// GetSessions はすべてのセッションを開始順に取得する
+func (s *SyncDB) GetSessions() ([]SyncSession, error) {
+	var sessions []SyncSession
+
+	err := s.view(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(sessionBucket)
+		if bucket == nil {
+			return fmt.Errorf("セッションバケットが見つかりません")
+		}
+
+		return bucket.ForEach(func(k, v []byte) error {
+			var session SyncSession
+			if err := json.Unmarshal(v, &session); err != nil {
+				return fmt.Errorf("セッション情報のデシリアライズエラー: %w", err)
+			}
+			sessions = append(sessions, session)
+			return nil
+		})
+	})
+
+	// キーは文字列のためID順に並べ直す
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].ID < sessions[j].ID
+	})
+
+	return sessions, err
+}
+
+// GetSessionsByLabel は指定したラベルのセッションを開始順に取得する
+func (s *SyncDB) GetSessionsByLabel(label string) ([]SyncSession, error) {
+	sessions, err := s.GetSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []SyncSession
+	for _, session := range sessions {
+		if session.Label == label {
+			matched = append(matched, session)
+		}
+	}
+	return matched, nil
+}
+
+// GetLatestSession は指定された種類の最新のセッションを取得する
+// 該当するセッションがない場合はnilを返す
+func (s *SyncDB) GetLatestSession(sessionType SessionType) (*SyncSession, error) {
+	var latest *SyncSession
+
+	err := s.view(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(sessionBucket)
+		if bucket == nil {
+			return fmt.Errorf("セッションバケットが見つかりません")
+		}
+
+		return bucket.ForEach(func(k, v []byte) error {
+			var session SyncSession
+			if err := json.Unmarshal(v, &session); err != nil {
+				return fmt.Errorf("セッション情報のデシリアライズエラー: %w", err)
+			}
+
+			// 種類が記録されていない旧セッションはコピーセッションとして扱う
+			sType := session.Type
+			if sType == "" {
+				sType = string(SessionCopy)
+			}
+			if sType != string(sessionType) {
+				return nil
+			}
+
+			if latest == nil || session.ID > latest.ID {
+				latest = &session
+			}
+			return nil
+		})
+	})
+
+	return latest, err
+}
+
+// GetFilesBySession は指定されたセッションでコピー処理されたファイルのリストを取得する
+func (s *SyncDB) GetFilesBySession(sessionID int64) ([]FileInfo, error) {
+	var files []FileInfo
+
+	err := s.view(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(fileSyncBucket)
+		if bucket == nil {
+			return fmt.Errorf("ファイル同期バケットが見つかりません")
+		}
+
+		return bucket.ForEach(func(k, v []byte) error {
+			var fileInfo FileInfo
+			if err := json.Unmarshal(v, &fileInfo); err != nil {
+				return fmt.Errorf("ファイル情報のデシリアライズエラー: %w", err)
+			}
+
+			if fileInfo.SessionID == sessionID {
+				files = append(files, fileInfo)
+			}
+
+			return nil
+		})
+	})
+
+	return files, err
+}
+
 // GetSyncStats は同期統計情報を取得する
 func (s *SyncDB) GetSyncStats() (map[string]int, error) {
 	stats := make(map[string]int)
 
-	err := s.db.View(func(tx *bbolt.Tx) error {
+	err := s.view(func(tx *bbolt.Tx) error {
 		// ファイル同期バケットから統計を取得
 		fileBucket := tx.Bucket(fileSyncBucket)
 		if fileBucket == nil {
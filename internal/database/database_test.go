@@ -1,11 +1,18 @@
 package database
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
+
+	"go.etcd.io/bbolt"
+
+	"github.com/sakuhanight/gopier/internal/fsmeta"
 )
 
 func TestNewSyncDB(t *testing.T) {
@@ -27,6 +34,41 @@ func TestNewSyncDB(t *testing.T) {
 	}
 }
 
+func TestOpenReadOnly(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	db, err := NewSyncDB(dbPath, NormalSync)
+	if err != nil {
+		t.Fatalf("データベース作成が失敗: %v", err)
+	}
+	db.AddFile(FileInfo{Path: "a.txt", Size: 3, Status: StatusSuccess})
+	db.Close()
+	info, _ := os.Stat(dbPath)
+
+	ro, err := OpenReadOnly(dbPath)
+	if err != nil {
+		t.Fatalf("OpenReadOnly() error = %v", err)
+	}
+	if file, err := ro.GetFile("a.txt"); err != nil || file == nil || file.Size != 3 {
+		t.Errorf("GetFile() = %+v, %v", file, err)
+	}
+	if err := ro.AddFile(FileInfo{Path: "b.txt"}); err == nil {
+		t.Error("読み取り専用で開いたデータベースに書き込めました")
+	}
+	ro.Close()
+	if after, _ := os.Stat(dbPath); !after.ModTime().Equal(info.ModTime()) || after.Size() != info.Size() {
+		t.Error("読み取り専用で開いたデータベースが変更されました")
+	}
+
+	// 存在しないデータベースは作成しない
+	missing := filepath.Join(t.TempDir(), "missing.db")
+	if _, err := OpenReadOnly(missing); err == nil {
+		t.Error("存在しないデータベースを開けました")
+	}
+	if _, err := os.Stat(missing); !os.IsNotExist(err) {
+		t.Errorf("存在しないデータベースが作成されました: %v", err)
+	}
+}
+
 func TestSyncDB_AddFile(t *testing.T) {
 	tempDir := t.TempDir()
 	dbPath := filepath.Join(tempDir, "test.db")
@@ -170,7 +212,7 @@ func TestSyncDB_UpdateFileHash(t *testing.T) {
 
 	// ハッシュを更新
 	newHash := "new-hash"
-	err = db.UpdateFileHash("/test/file.txt", newHash, "dest-hash")
+	err = db.UpdateFileHash("/test/file.txt", newHash, "dest-hash", "sha256")
 	if err != nil {
 		t.Errorf("ハッシュ更新が失敗: %v", err)
 	}
@@ -184,6 +226,9 @@ func TestSyncDB_UpdateFileHash(t *testing.T) {
 	if updatedFile.SourceHash != newHash {
 		t.Errorf("ハッシュが更新されていません: 期待値=%s, 実際=%s", newHash, updatedFile.SourceHash)
 	}
+	if updatedFile.HashAlgo != "sha256" {
+		t.Errorf("ハッシュのアルゴリズムが更新されていません: 期待値=sha256, 実際=%s", updatedFile.HashAlgo)
+	}
 }
 
 func TestSyncDB_IncrementFailCount(t *testing.T) {
@@ -422,6 +467,49 @@ func TestSyncDB_EndSyncSession(t *testing.T) {
 	}
 }
 
+func TestSyncDB_SessionLabel(t *testing.T) {
+	tempDir := t.TempDir()
+	db, err := NewSyncDB(filepath.Join(tempDir, "test.db"), NormalSync)
+	if err != nil {
+		t.Fatalf("データベース作成が失敗: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.StartSyncSession(); err != nil {
+		t.Fatalf("同期セッション開始が失敗: %v", err)
+	}
+	db.SetSessionLabel("wave3-finance-share", map[string]string{"ticket": "MIG-42"})
+	db.SetSessionPaths(&SessionPaths{WorkDir: "/work", Source: "/work/src", Destination: "/mnt/dst"})
+	copyID, err := db.StartSyncSession()
+	if err != nil {
+		t.Fatalf("同期セッション開始が失敗: %v", err)
+	}
+	verifyID, err := db.StartSession(SessionVerify)
+	if err != nil {
+		t.Fatalf("検証セッション開始が失敗: %v", err)
+	}
+	if err := db.EndSyncSession(copyID, 1, 0, 0, 10); err != nil {
+		t.Fatalf("同期セッション終了が失敗: %v", err)
+	}
+
+	sessions, err := db.GetSessionsByLabel("wave3-finance-share")
+	if err != nil {
+		t.Fatalf("セッションの取得が失敗: %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].ID != copyID || sessions[1].ID != verifyID {
+		t.Fatalf("ラベルのセッション = %+v", sessions)
+	}
+	// 終了時にもラベルとタグ・パスが保持されること
+	if sessions[0].Status != "completed" || sessions[0].Tags["ticket"] != "MIG-42" ||
+		sessions[0].Paths == nil || sessions[0].Paths.Source != "/work/src" {
+		t.Errorf("セッション = %+v", sessions[0])
+	}
+
+	if sessions, _ := db.GetSessionsByLabel("other"); len(sessions) != 0 {
+		t.Errorf("別のラベルのセッション = %+v", sessions)
+	}
+}
+
 func TestSyncDB_GetSyncStats(t *testing.T) {
 	tempDir := t.TempDir()
 	dbPath := filepath.Join(tempDir, "test.db")
@@ -798,3 +886,384 @@ func TestSyncSession_EdgeCases(t *testing.T) {
 		t.Error("統計情報がnilです")
 	}
 }
+
+func TestMigratePathKeys(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test.db")
+	db, err := NewSyncDB(dbPath, NormalSync)
+	if err != nil {
+		t.Fatalf("データベース作成が失敗: %v", err)
+	}
+	defer db.Close()
+
+	// Windowsで作成された旧形式のレコードを直接書き込む
+	oldInfo := FileInfo{Path: `dir\sub\file.txt`, Size: 10, Status: StatusSuccess, LastSyncTime: time.Now()}
+	err = db.db.Update(func(tx *bbolt.Tx) error {
+		data, err := json.Marshal(oldInfo)
+		if err != nil {
+			return err
+		}
+		if err := tx.Bucket(fileSyncBucket).Put([]byte(oldInfo.Path), data); err != nil {
+			return err
+		}
+		return tx.Bucket(metaBucket).Delete(pathKeyVersionKey)
+	})
+	if err != nil {
+		t.Fatalf("旧形式レコードの書き込みに失敗: %v", err)
+	}
+
+	if err := db.migratePathKeys(); err != nil {
+		t.Fatalf("パスキー移行が失敗: %v", err)
+	}
+
+	fileInfo, err := db.GetFile("dir/sub/file.txt")
+	if err != nil {
+		t.Fatalf("移行後のレコードが見つかりません: %v", err)
+	}
+	if fileInfo.Path != "dir/sub/file.txt" {
+		t.Errorf("Path: 期待値=%s, 実際=%s", "dir/sub/file.txt", fileInfo.Path)
+	}
+
+	files, err := db.GetAllFiles()
+	if err != nil {
+		t.Fatalf("ファイル一覧の取得に失敗: %v", err)
+	}
+	if len(files) != 1 {
+		t.Errorf("旧形式のレコードが残っています: %d件", len(files))
+	}
+
+	// ネイティブ区切りで追加したレコードもスラッシュ区切りで取得できる
+	if err := db.AddFile(FileInfo{Path: filepath.Join("a", "b.txt"), Status: StatusSuccess}); err != nil {
+		t.Fatalf("ファイル追加が失敗: %v", err)
+	}
+	if _, err := db.GetFile("a/b.txt"); err != nil {
+		t.Errorf("スラッシュ区切りのキーで取得できません: %v", err)
+	}
+}
+
+func TestSyncDB_SessionScopedFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	db, err := NewSyncDB(filepath.Join(tempDir, "test.db"), NormalSync)
+	if err != nil {
+		t.Fatalf("データベース作成が失敗: %v", err)
+	}
+	defer db.Close()
+
+	// セッションがない場合はnil
+	session, err := db.GetLatestSession(SessionCopy)
+	if err != nil || session != nil {
+		t.Fatalf("セッションがない場合はnilを返すべき: %v, %v", session, err)
+	}
+
+	copyID, err := db.StartSyncSession()
+	if err != nil {
+		t.Fatalf("セッション開始が失敗: %v", err)
+	}
+	db.AddFile(FileInfo{Path: "copied.txt", Status: StatusSuccess, SessionID: copyID})
+	db.AddFile(FileInfo{Path: "untouched.txt", Status: StatusSkipped})
+
+	verifyID, err := db.StartSession(SessionVerify)
+	if err != nil {
+		t.Fatalf("セッション開始が失敗: %v", err)
+	}
+
+	// 検証結果の記録でコピーセッションIDが失われない
+	db.AddFile(FileInfo{Path: "copied.txt", Status: StatusVerified})
+
+	latest, err := db.GetLatestSession(SessionCopy)
+	if err != nil || latest == nil {
+		t.Fatalf("最新のコピーセッションの取得に失敗: %v", err)
+	}
+	if latest.ID != copyID {
+		t.Errorf("最新のコピーセッション: 期待値=%d, 実際=%d", copyID, latest.ID)
+	}
+
+	latestVerify, err := db.GetLatestSession(SessionVerify)
+	if err != nil || latestVerify == nil || latestVerify.ID != verifyID {
+		t.Errorf("最新の検証セッションの取得に失敗: %v, %v", latestVerify, err)
+	}
+
+	files, err := db.GetFilesBySession(copyID)
+	if err != nil {
+		t.Fatalf("セッション別ファイルの取得に失敗: %v", err)
+	}
+	if len(files) != 1 || files[0].Path != "copied.txt" {
+		t.Errorf("セッションのファイルが正しくありません: %+v", files)
+	}
+	if files[0].Status != StatusVerified {
+		t.Errorf("ステータス: 期待値=%s, 実際=%s", StatusVerified, files[0].Status)
+	}
+}
+
+func TestSyncDB_ForEachFileAndCount(t *testing.T) {
+	tempDir := t.TempDir()
+	db, err := NewSyncDB(filepath.Join(tempDir, "test.db"), NormalSync)
+	if err != nil {
+		t.Fatalf("データベース作成が失敗: %v", err)
+	}
+	defer db.Close()
+
+	for _, path := range []string{"b.txt", "a.txt", "c/d.txt"} {
+		db.AddFile(FileInfo{Path: path, Status: StatusSuccess})
+	}
+
+	count, err := db.CountFiles()
+	if err != nil || count != 3 {
+		t.Errorf("件数: 期待値=3, 実際=%d (%v)", count, err)
+	}
+
+	var paths []string
+	err = db.ForEachFile(false, func(file FileInfo) error {
+		paths = append(paths, file.Path)
+		return nil
+	})
+	if err != nil || strings.Join(paths, ",") != "a.txt,b.txt,c/d.txt" {
+		t.Errorf("パス順に走査されていません: %v (%v)", paths, err)
+	}
+
+	paths = nil
+	db.ForEachFile(true, func(file FileInfo) error {
+		paths = append(paths, file.Path)
+		return nil
+	})
+	if strings.Join(paths, ",") != "c/d.txt,b.txt,a.txt" {
+		t.Errorf("逆順に走査されていません: %v", paths)
+	}
+
+	// コールバックのエラーで走査を中断する
+	stop := fmt.Errorf("stop")
+	visited := 0
+	err = db.ForEachFile(false, func(file FileInfo) error {
+		visited++
+		return stop
+	})
+	if err != stop || visited != 1 {
+		t.Errorf("エラーで走査が中断されていません: visited=%d, err=%v", visited, err)
+	}
+}
+
+func TestSyncDB_FileMetadata(t *testing.T) {
+	tempDir := t.TempDir()
+	dbPath := filepath.Join(tempDir, "test.db")
+	db, err := NewSyncDB(dbPath, NormalSync)
+	if err != nil {
+		t.Fatalf("データベース作成が失敗: %v", err)
+	}
+
+	// メタデータを持たない旧形式のレコードも読み込める
+	err = db.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(fileSyncBucket).Put([]byte("old.txt"), []byte(`{"path":"old.txt","size":1,"status":"success"}`))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	old, err := db.GetFile("old.txt")
+	if err != nil {
+		t.Fatalf("旧形式のレコードが読み込めません: %v", err)
+	}
+	if old.Meta != nil {
+		t.Errorf("旧形式のレコードのMeta = %+v, want nil", old.Meta)
+	}
+
+	// メタデータを記録し、指定せずに更新した場合は引き継がれる
+	meta := &fsmeta.Metadata{Device: 1, FileID: 42, Owner: "1000:1000", Mode: 0644, LinkTarget: "target"}
+	if err := db.AddFile(FileInfo{Path: "new.txt", Status: StatusSuccess, Meta: meta}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AddFile(FileInfo{Path: "new.txt", Status: StatusFailed, LastError: "err"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := db.GetFile("new.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Meta == nil || *got.Meta != *meta {
+		t.Errorf("Meta = %+v, want %+v", got.Meta, meta)
+	}
+	if got.Status != StatusFailed {
+		t.Errorf("Status = %s, want %s", got.Status, StatusFailed)
+	}
+
+	// コピーの処理の詳細は検証の結果で更新しても引き継がれる
+	processing := &Processing{Seconds: 1.5, Retries: 2, Worker: 3, Throughput: 100}
+	db.AddFile(FileInfo{Path: "timed.txt", Status: StatusSuccess, SessionID: 1})
+	if err := db.UpdateFileProcessing("timed.txt", *processing); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AddFile(FileInfo{Path: "timed.txt", Status: StatusVerified}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := db.GetFile("timed.txt"); err != nil || got.Processing == nil || *got.Processing != *processing {
+		t.Errorf("Processing = %+v, want %+v (err = %v)", got.Processing, processing, err)
+	}
+
+	// 形式のバージョンが記録されている
+	var version string
+	db.db.View(func(tx *bbolt.Tx) error {
+		version = string(tx.Bucket(metaBucket).Get(fileSchemaVersionKey))
+		return nil
+	})
+	if version != strconv.Itoa(currentFileSchemaVersion) {
+		t.Errorf("file_schema_version = %q, want %d", version, currentFileSchemaVersion)
+	}
+
+	// 新しい形式のデータベースは開けない
+	db.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(metaBucket).Put(fileSchemaVersionKey, []byte("9"))
+	})
+	db.Close()
+	if db, err := NewSyncDB(dbPath, NormalSync); err == nil {
+		db.Close()
+		t.Error("新しい形式のデータベースを開いてもエラーになりません")
+	}
+}
+
+func TestParseSchemaVersion(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{"", 1, false},
+		{"2", 2, false},
+		// 文字列ではなく数値として比較する
+		{"10", 10, false},
+		{"v3", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseSchemaVersion([]byte(tt.value))
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("parseSchemaVersion(%q) = %d, %v, want %d (エラー: %t)", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestSyncDB_RecordVerification(t *testing.T) {
+	db, err := NewSyncDB(filepath.Join(t.TempDir(), "test.db"), NormalSync)
+	if err != nil {
+		t.Fatalf("データベース作成が失敗: %v", err)
+	}
+	defer db.Close()
+
+	var ids []int64
+	for i := 0; i < 3; i++ {
+		id, err := db.StartSession(SessionVerify)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	var summary VerificationSummary
+	summary.Add(VerifyMatched, 100)
+	summary.Add(VerifyMatched, 100)
+	summary.Add(VerifyMismatched, 50)
+	summary.Add(VerifyMissingDest, 10)
+	summary.Add(VerifyExtra, 10)
+	if summary.Verified() != 4 || summary.Bytes != 250 {
+		t.Errorf("集計が期待値と異なります: %+v", summary)
+	}
+	if rate := summary.MismatchRate(); rate < 0.333 || rate > 0.334 {
+		t.Errorf("MismatchRate() = %v, want 1/3", rate)
+	}
+
+	if err := db.RecordVerification(ids[1], summary); err != nil {
+		t.Fatalf("RecordVerification() error = %v", err)
+	}
+	if err := db.RecordVerification(12345, summary); err == nil {
+		t.Error("存在しないセッションでエラーになりません")
+	}
+
+	sessions, err := db.GetSessions()
+	if err != nil {
+		t.Fatalf("GetSessions() error = %v", err)
+	}
+	if len(sessions) != 3 {
+		t.Fatalf("セッション数 = %d, want 3", len(sessions))
+	}
+	for i, session := range sessions {
+		if session.ID != ids[i] {
+			t.Errorf("sessions[%d].ID = %d, want %d", i, session.ID, ids[i])
+		}
+	}
+	if sessions[0].Verification != nil || sessions[1].Verification == nil || *sessions[1].Verification != summary {
+		t.Errorf("検証結果の記録が期待値と異なります: %+v", sessions)
+	}
+}
+
+func TestSyncDB_RecordStats(t *testing.T) {
+	db, err := NewSyncDB(filepath.Join(t.TempDir(), "test.db"), NormalSync)
+	if err != nil {
+		t.Fatalf("データベース作成が失敗: %v", err)
+	}
+	defer db.Close()
+
+	id, err := db.StartSession(SessionCopy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := SessionStats{FilesCopied: 3, FilesSkipped: 2, FilesFailed: 1, Conflicts: 1, BytesCopied: 4096, BytesSkipped: 1024}
+	if err := db.EndSyncSession(id, 3, 2, 1, 4096); err != nil {
+		t.Fatalf("EndSyncSession() error = %v", err)
+	}
+	if err := db.RecordStats(id, stats); err != nil {
+		t.Fatalf("RecordStats() error = %v", err)
+	}
+	if err := db.RecordStats(12345, stats); err == nil {
+		t.Error("存在しないセッションでエラーになりません")
+	}
+
+	sessions, err := db.GetSessions()
+	if err != nil {
+		t.Fatalf("GetSessions() error = %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].Stats == nil || *sessions[0].Stats != stats {
+		t.Fatalf("統計情報の記録が期待値と異なります: %+v", sessions)
+	}
+	// 統計情報の記録はセッション終了時の情報を変更しない
+	if sessions[0].Status != "completed" || sessions[0].FilesCopied != 3 {
+		t.Errorf("セッション情報が変更されました: %+v", sessions[0])
+	}
+}
+
+func TestSyncDB_Journal(t *testing.T) {
+	db, err := NewSyncDB(filepath.Join(t.TempDir(), "test.db"), NormalSync)
+	if err != nil {
+		t.Fatalf("データベース作成が失敗: %v", err)
+	}
+	defer db.Close()
+	db.EnableWriteQueue(16, nil)
+
+	modTime := time.Now().Truncate(time.Second)
+	for _, path := range []string{`dir\a.txt`, "b.txt"} {
+		entry := JournalEntry{Path: path, DestPaths: []string{"/dest/" + path}, Size: 10, ModTime: modTime, DestSize: 10, DestModTime: modTime}
+		if err := db.BeginCopy(entry); err != nil {
+			t.Fatalf("BeginCopy() error = %v", err)
+		}
+	}
+
+	entries, err := db.GetJournal()
+	if err != nil {
+		t.Fatalf("GetJournal() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].Path != "b.txt" || entries[1].Path != "dir/a.txt" {
+		t.Fatalf("ジャーナル = %+v", entries)
+	}
+	if !entries[0].Complete(10, modTime) || entries[0].Complete(10, modTime.Add(time.Second)) || entries[0].Complete(9, modTime) {
+		t.Error("書き込みを終えたかどうかの判定が期待値と異なります")
+	}
+	if unknown := (JournalEntry{DestSize: -1}); unknown.Complete(-1, time.Time{}) {
+		t.Error("サイズと更新日時が不明な記録を書き込み済みと判定しました")
+	}
+
+	// ファイル情報の記録と同時にジャーナルから削除される
+	if err := db.AddFile(FileInfo{Path: "dir/a.txt", Status: StatusSuccess}); err != nil {
+		t.Fatalf("AddFile() error = %v", err)
+	}
+	if err := db.ClearJournal("b.txt"); err != nil {
+		t.Fatalf("ClearJournal() error = %v", err)
+	}
+	if entries, _ := db.GetJournal(); len(entries) != 0 {
+		t.Errorf("ジャーナルに記録が残っています: %+v", entries)
+	}
+}
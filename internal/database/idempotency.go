@@ -0,0 +1,56 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// idempotencyKeyPrefix はメタ情報バケットの前回の実行のマニフェストのキーの接頭辞（ソースと宛先のパスを続ける）
+const idempotencyKeyPrefix = "idempotency:"
+
+// IdempotencyRecord は前回正常に完了した実行のソースのマニフェスト
+// 次回の実行でマニフェストが一致し、宛先の抜き取り確認で問題がなければコピーを省略する
+type IdempotencyRecord struct {
+	Source      string    `json:"source"`      // ソースのパス（絶対パス）
+	Destination string    `json:"destination"` // 宛先のパス（絶対パス）
+	Manifest    string    `json:"manifest"`    // ソースのマニフェストのハッシュ
+	Files       int64     `json:"files"`       // マニフェストに含まれるファイル数
+	Bytes       int64     `json:"bytes"`       // マニフェストに含まれるファイルの合計サイズ
+	RecordedAt  time.Time `json:"recorded_at"`
+}
+
+// idempotencyKey はソースと宛先の組み合わせのキーを返す
+func idempotencyKey(source, destination string) []byte {
+	return []byte(idempotencyKeyPrefix + source + "\x00" + destination)
+}
+
+// SaveIdempotencyRecord は前回の実行のマニフェストを記録する（同じソースと宛先の記録は置き換える）
+func (s *SyncDB) SaveIdempotencyRecord(record IdempotencyRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("マニフェストのシリアライズエラー: %w", err)
+	}
+	return s.update("", func(tx *bbolt.Tx) error {
+		return tx.Bucket(metaBucket).Put(idempotencyKey(record.Source, record.Destination), data)
+	})
+}
+
+// GetIdempotencyRecord はソースと宛先の前回の実行のマニフェストを取得する（記録がない場合はnil）
+func (s *SyncDB) GetIdempotencyRecord(source, destination string) (*IdempotencyRecord, error) {
+	var record *IdempotencyRecord
+	err := s.view(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(metaBucket).Get(idempotencyKey(source, destination))
+		if data == nil {
+			return nil
+		}
+		record = &IdempotencyRecord{}
+		if err := json.Unmarshal(data, record); err != nil {
+			return fmt.Errorf("マニフェストのデシリアライズエラー: %w", err)
+		}
+		return nil
+	})
+	return record, err
+}
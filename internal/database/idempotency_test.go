@@ -0,0 +1,42 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestIdempotencyRecord(t *testing.T) {
+	db, err := NewSyncDB(filepath.Join(t.TempDir(), "test.db"), InitialSync)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if record, err := db.GetIdempotencyRecord("/src", "/dst"); err != nil || record != nil {
+		t.Fatalf("記録のないGetIdempotencyRecord() = %+v, %v", record, err)
+	}
+
+	db.SaveIdempotencyRecord(IdempotencyRecord{Source: "/src", Destination: "/dst", Manifest: "a"})
+	db.SaveIdempotencyRecord(IdempotencyRecord{Source: "/src", Destination: "/other", Manifest: "b"})
+	// 同じソースと宛先の記録は置き換える
+	if err := db.SaveIdempotencyRecord(IdempotencyRecord{Source: "/src", Destination: "/dst", Manifest: "c", Files: 3, Bytes: 10}); err != nil {
+		t.Fatalf("SaveIdempotencyRecord() error = %v", err)
+	}
+	record, err := db.GetIdempotencyRecord("/src", "/dst")
+	if err != nil || record == nil || record.Manifest != "c" || record.Files != 3 || record.Bytes != 10 {
+		t.Fatalf("GetIdempotencyRecord() = %+v, %v", record, err)
+	}
+	if record, _ := db.GetIdempotencyRecord("/src", "/other"); record == nil || record.Manifest != "b" {
+		t.Errorf("宛先ごとの記録が置き換えられています: %+v", record)
+	}
+
+	// ファイルの記録を消した場合は、次回はコピーを省略しない
+	if err := db.ResetDatabase(); err != nil {
+		t.Fatal(err)
+	}
+	for _, dest := range []string{"/dst", "/other"} {
+		if record, _ := db.GetIdempotencyRecord("/src", dest); record != nil {
+			t.Errorf("ResetDatabase()の後に%sの記録が残っています: %+v", dest, record)
+		}
+	}
+}
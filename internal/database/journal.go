@@ -0,0 +1,91 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+
+	"github.com/sakuhanight/gopier/internal/pathkey"
+)
+
+// JournalEntry はコピー中のファイルの記録（先行書き込みのジャーナル）
+// 宛先への書き込みの前に記録し、ファイル情報の記録（AddFile）と同じトランザクションで削除する
+// 起動時に残っている記録は、書き込みの途中またはDBへの記録の前に異常終了したファイルを表す
+type JournalEntry struct {
+	Path        string     `json:"path"`                    // ソースからの相対パス
+	DestPaths   []string   `json:"dest_paths"`              // 書き込む宛先ファイルのパス
+	Size        int64      `json:"size"`                    // ソースのサイズ
+	ModTime     time.Time  `json:"mod_time"`                // ソースの更新日時
+	DestSize    int64      `json:"dest_size"`               // 書き込みを終えた宛先のサイズ（-1は不明）
+	DestModTime time.Time  `json:"dest_mod_time,omitempty"` // 書き込みを終えた宛先の更新日時（ゼロ値は不明）
+	Change      ChangeKind `json:"change"`                  // 宛先に加える変更の種類
+	SessionID   int64      `json:"session_id"`              // コピーしたセッションのID
+	StartedAt   time.Time  `json:"started_at"`
+}
+
+// Complete はサイズと更新日時から、宛先への書き込みを終えていることを確認できるかどうかを返す
+// 更新日時は書き込みの最後に設定するため、両方が一致する宛先は書き込みを終えている
+func (e *JournalEntry) Complete(destSize int64, destModTime time.Time) bool {
+	return e.DestSize >= 0 && !e.DestModTime.IsZero() &&
+		destSize == e.DestSize && destModTime.Equal(e.DestModTime)
+}
+
+// BeginCopy はファイルのコピーの開始をジャーナルに記録する
+// 書き込みキューが有効な場合も、記録がコミットされるまで待ってから戻る
+func (s *SyncDB) BeginCopy(entry JournalEntry) error {
+	entry.Path = pathkey.Normalize(entry.Path)
+	return s.update(entry.Path, func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(journalBucket)
+		if bucket == nil {
+			return fmt.Errorf("ジャーナルバケットが見つかりません")
+		}
+
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("ジャーナルのシリアライズエラー: %w", err)
+		}
+		return bucket.Put([]byte(entry.Path), data)
+	})
+}
+
+// GetJournal はジャーナルに残っているコピー中のファイルをパス順に取得する
+func (s *SyncDB) GetJournal() ([]JournalEntry, error) {
+	var entries []JournalEntry
+
+	err := s.view(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(journalBucket)
+		if bucket == nil {
+			return fmt.Errorf("ジャーナルバケットが見つかりません")
+		}
+
+		return bucket.ForEach(func(k, v []byte) error {
+			var entry JournalEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return fmt.Errorf("ジャーナルのデシリアライズエラー: %w", err)
+			}
+			entries = append(entries, entry)
+			return nil
+		})
+	})
+
+	return entries, err
+}
+
+// ClearJournal はジャーナルからファイルの記録を削除する
+func (s *SyncDB) ClearJournal(path string) error {
+	key := pathkey.Normalize(path)
+	return s.update(key, func(tx *bbolt.Tx) error {
+		return deleteJournal(tx, key)
+	})
+}
+
+// deleteJournal はトランザクション内でジャーナルからファイルの記録を削除する
+func deleteJournal(tx *bbolt.Tx, key string) error {
+	bucket := tx.Bucket(journalBucket)
+	if bucket == nil {
+		return fmt.Errorf("ジャーナルバケットが見つかりません")
+	}
+	return bucket.Delete([]byte(key))
+}
@@ -0,0 +1,234 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"go.etcd.io/bbolt"
+
+	"github.com/sakuhanight/gopier/internal/pathkey"
+)
+
+// compactTxMaxSize は最適化時に1トランザクションでコピーする最大バイト数
+const compactTxMaxSize = 64 * 1024 * 1024
+
+// maxCheckErrors はチェック結果に保持するエラーの最大件数
+const maxCheckErrors = 1000
+
+// CompactResult はデータベース最適化の結果を表す構造体
+type CompactResult struct {
+	SizeBefore int64
+	SizeAfter  int64
+}
+
+// CheckResult はデータベース整合性チェックの結果を表す構造体
+type CheckResult struct {
+	Size         int64
+	Files        int
+	Sessions     int
+	PageErrors   []string // データベースファイルの構造の破損
+	RecordErrors []string // 読み込めない・キーが一致しないレコード
+	ErrorCount   int      // 検出したエラーの総数（保持する件数には上限がある）
+}
+
+// OK はエラーが検出されなかったかを返す
+func (r *CheckResult) OK() bool {
+	return r.ErrorCount == 0
+}
+
+func (r *CheckResult) addPageError(err error) {
+	r.ErrorCount++
+	if len(r.PageErrors)+len(r.RecordErrors) < maxCheckErrors {
+		r.PageErrors = append(r.PageErrors, err.Error())
+	}
+}
+
+func (r *CheckResult) addRecordError(format string, args ...interface{}) {
+	r.ErrorCount++
+	if len(r.PageErrors)+len(r.RecordErrors) < maxCheckErrors {
+		r.RecordErrors = append(r.RecordErrors, fmt.Sprintf(format, args...))
+	}
+}
+
+// Compact はデータベースファイルを最適化して未使用領域を解放する
+// 一時ファイルに全データをコピーしてから置き換えるため、実行中は他のプロセスがデータベースを使用していないこと
+func Compact(dbPath string) (*CompactResult, error) {
+	info, err := os.Stat(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("データベースファイルの確認エラー: %w", err)
+	}
+	result := &CompactResult{SizeBefore: info.Size()}
+
+	src, err := bbolt.Open(dbPath, 0600, &bbolt.Options{Timeout: defaultOpenTimeout, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("データベース接続エラー: %w", err)
+	}
+
+	tmpPath := dbPath + ".compact"
+	os.Remove(tmpPath)
+	dst, err := bbolt.Open(tmpPath, info.Mode().Perm(), &bbolt.Options{Timeout: defaultOpenTimeout})
+	if err != nil {
+		src.Close()
+		return nil, fmt.Errorf("一時データベースの作成エラー: %w", err)
+	}
+
+	err = bbolt.Compact(dst, src, compactTxMaxSize)
+	src.Close()
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return nil, fmt.Errorf("データベースの最適化エラー: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, dbPath); err != nil {
+		os.Remove(tmpPath)
+		return nil, fmt.Errorf("最適化したデータベースへの置き換えエラー: %w", err)
+	}
+
+	if info, err := os.Stat(dbPath); err == nil {
+		result.SizeAfter = info.Size()
+	}
+	return result, nil
+}
+
+// Check はデータベースの整合性をチェックする
+// ファイル構造の破損に加え、読み込めないレコードやキーとパスが一致しないレコードを検出する
+// データベースは読み取り専用で開くため、内容は変更しない
+func Check(dbPath string) (*CheckResult, error) {
+	info, err := os.Stat(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("データベースファイルの確認エラー: %w", err)
+	}
+
+	db, err := bbolt.Open(dbPath, 0600, &bbolt.Options{Timeout: defaultOpenTimeout, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("データベース接続エラー: %w", err)
+	}
+	defer db.Close()
+
+	result := &CheckResult{Size: info.Size()}
+	err = db.View(func(tx *bbolt.Tx) error {
+		// ファイル構造のチェック
+		for err := range tx.Check() {
+			result.addPageError(err)
+		}
+
+		if bucket := tx.Bucket(fileSyncBucket); bucket == nil {
+			result.addRecordError("ファイル同期バケットが見つかりません")
+		} else {
+			err := bucket.ForEach(func(k, v []byte) error {
+				result.Files++
+				var fileInfo FileInfo
+				if err := json.Unmarshal(v, &fileInfo); err != nil {
+					result.addRecordError("ファイル情報 %q を読み込めません: %v", k, err)
+					return nil
+				}
+				if string(k) != pathkey.Normalize(string(k)) || fileInfo.Path != string(k) {
+					result.addRecordError("ファイル情報 %q のキーとパス %q が一致しません", k, fileInfo.Path)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if bucket := tx.Bucket(sessionBucket); bucket == nil {
+			result.addRecordError("セッションバケットが見つかりません")
+		} else {
+			err := bucket.ForEach(func(k, v []byte) error {
+				result.Sessions++
+				var session SyncSession
+				if err := json.Unmarshal(v, &session); err != nil {
+					result.addRecordError("セッション情報 %x を読み込めません: %v", k, err)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("整合性チェックエラー: %w", err)
+	}
+
+	return result, nil
+}
+
+// RebuildFileRecords はファイル情報のキーを正規化したパスで作り直す
+// キーとパスが一致しないレコードは正規化したパスのキーに移し、読み込めないレコードは削除する
+// 同じキーに複数のレコードが集まる場合は最終同期時間が新しいものを残す
+func (s *SyncDB) RebuildFileRecords() (rebuilt, removed int, err error) {
+	err = s.update("", func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(fileSyncBucket)
+		if bucket == nil {
+			return fmt.Errorf("ファイル同期バケットが見つかりません")
+		}
+
+		// ForEach中はバケットを変更できないため、対象を先に収集する
+		var broken [][]byte
+		moves := make(map[string]FileInfo)
+		err := bucket.ForEach(func(k, v []byte) error {
+			var fileInfo FileInfo
+			if err := json.Unmarshal(v, &fileInfo); err != nil {
+				broken = append(broken, append([]byte(nil), k...))
+				return nil
+			}
+			if fileInfo.Path == "" {
+				fileInfo.Path = string(k)
+			}
+			if newKey := pathkey.Normalize(fileInfo.Path); newKey != string(k) || fileInfo.Path != newKey {
+				fileInfo.Path = newKey
+				moves[string(k)] = fileInfo
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("ファイル情報の走査エラー: %w", err)
+		}
+
+		for _, k := range broken {
+			if err := bucket.Delete(k); err != nil {
+				return fmt.Errorf("ファイル情報の削除エラー: %w", err)
+			}
+			removed++
+		}
+
+		// 移動元をすべて削除してから書き込むことで、移動先が重なる場合も新しいレコードを残せる
+		for oldKey := range moves {
+			if err := bucket.Delete([]byte(oldKey)); err != nil {
+				return fmt.Errorf("ファイル情報の削除エラー: %w", err)
+			}
+		}
+
+		for _, fileInfo := range moves {
+			newKey := []byte(fileInfo.Path)
+			if existing := bucket.Get(newKey); existing != nil {
+				var current FileInfo
+				if err := json.Unmarshal(existing, &current); err == nil && current.LastSyncTime.After(fileInfo.LastSyncTime) {
+					removed++
+					continue
+				}
+			}
+
+			data, err := json.Marshal(fileInfo)
+			if err != nil {
+				return fmt.Errorf("ファイル情報のシリアライズエラー: %w", err)
+			}
+			if err := bucket.Put(newKey, data); err != nil {
+				return fmt.Errorf("ファイル情報の保存エラー: %w", err)
+			}
+			rebuilt++
+		}
+
+		return nil
+	})
+
+	return rebuilt, removed, err
+}
@@ -0,0 +1,154 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+func TestCompact(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	db, err := NewSyncDB(dbPath, InitialSync)
+	if err != nil {
+		t.Fatalf("データベース作成が失敗: %v", err)
+	}
+
+	// 大量のレコードを追加してから削除し、未使用領域を作る
+	padding := strings.Repeat("x", 1024)
+	err = db.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(fileSyncBucket)
+		for i := 0; i < 5000; i++ {
+			key := fmt.Sprintf("file%05d.txt", i)
+			data := fmt.Sprintf(`{"path":%q,"last_error":%q}`, key, padding)
+			if err := bucket.Put([]byte(key), []byte(data)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.AddFile(FileInfo{Path: "keep.txt", Status: StatusSuccess})
+	err = db.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(fileSyncBucket)
+		for i := 0; i < 5000; i++ {
+			if err := bucket.Delete([]byte(fmt.Sprintf("file%05d.txt", i))); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	result, err := Compact(dbPath)
+	if err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+	if result.SizeAfter >= result.SizeBefore {
+		t.Errorf("サイズが縮小されていません: %d -> %d", result.SizeBefore, result.SizeAfter)
+	}
+	if _, err := os.Stat(dbPath + ".compact"); !os.IsNotExist(err) {
+		t.Error("一時ファイルが残っています")
+	}
+
+	// 最適化後もデータが残っている
+	db, err = NewSyncDB(dbPath, NormalSync)
+	if err != nil {
+		t.Fatalf("最適化後のデータベースを開けません: %v", err)
+	}
+	defer db.Close()
+	if f, err := db.GetFile("keep.txt"); err != nil || f.Status != StatusSuccess {
+		t.Errorf("最適化後にレコードが失われました: %v", err)
+	}
+}
+
+func TestCheckAndRebuild(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	db, err := NewSyncDB(dbPath, NormalSync)
+	if err != nil {
+		t.Fatalf("データベース作成が失敗: %v", err)
+	}
+	db.AddFile(FileInfo{Path: "ok.txt", Status: StatusSuccess})
+	db.StartSyncSession()
+	db.Close()
+
+	// 正常なデータベース
+	result, err := Check(dbPath)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if !result.OK() || result.Files != 1 || result.Sessions != 1 {
+		t.Errorf("結果が期待値と異なります: %+v", result)
+	}
+
+	// 読み込めないレコードとキーが一致しないレコードを書き込む
+	db, err = NewSyncDB(dbPath, NormalSync)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	err = db.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(fileSyncBucket)
+		if err := bucket.Put([]byte("broken.txt"), []byte("{not json")); err != nil {
+			return err
+		}
+		data := fmt.Sprintf(`{"path":"dir\\sub.txt","status":"success","last_sync_time":%q}`, now.Format(time.RFC3339Nano))
+		return bucket.Put([]byte(`dir\sub.txt`), []byte(data))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	result, err = Check(dbPath)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if result.OK() || result.ErrorCount != 2 || len(result.PageErrors) != 0 {
+		t.Errorf("問題が検出されていません: %+v", result)
+	}
+
+	// 再構築で修復される
+	db, err = NewSyncDB(dbPath, NormalSync)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rebuilt, removed, err := db.RebuildFileRecords()
+	if err != nil {
+		t.Fatalf("RebuildFileRecords() error = %v", err)
+	}
+	if rebuilt != 1 || removed != 1 {
+		t.Errorf("rebuilt=%d, removed=%d, want 1, 1", rebuilt, removed)
+	}
+	if f, err := db.GetFile("dir/sub.txt"); err != nil || f.Path != "dir/sub.txt" {
+		t.Errorf("再構築したレコードが取得できません: %v", err)
+	}
+	db.Close()
+
+	result, err = Check(dbPath)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if !result.OK() {
+		t.Errorf("再構築後も問題が残っています: %+v", result)
+	}
+}
+
+func TestCheck_MissingFile(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "missing.db")
+	if _, err := Check(dbPath); err == nil {
+		t.Error("存在しないデータベースでエラーになりません")
+	}
+	if _, err := os.Stat(dbPath); !os.IsNotExist(err) {
+		t.Error("存在しないデータベースが作成されました")
+	}
+}
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// FileQuery はファイル情報を絞り込む条件
+// 指定したすべての条件を満たすレコードが対象になる（未指定の条件は絞り込まない）
+type FileQuery struct {
+	// パスのglobパターン（いずれかに一致）。"/"を含まないパターンはファイル名と照合する
+	Patterns []string
+	// 同期状態（いずれかに一致）
+	Statuses []FileStatus
+	// 最終同期時間がこの時刻より前のレコード
+	OlderThan time.Time
+}
+
+// Validate はパターンの書式と同期状態を検証する
+// 同期状態の誤りは何にも一致せず、削除の対象が0件のまま気付かないことがあるため、未知の値はエラーにする
+func (q FileQuery) Validate() error {
+	for _, pattern := range q.Patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("無効なパターンです: %s", pattern)
+		}
+	}
+	for _, status := range q.Statuses {
+		if !status.Valid() {
+			names := make([]string, len(FileStatuses))
+			for i, s := range FileStatuses {
+				names[i] = string(s)
+			}
+			return fmt.Errorf("無効な同期状態です: %s (%sのいずれかを指定してください)", status, strings.Join(names, ", "))
+		}
+	}
+	return nil
+}
+
+// Matches はファイル情報が条件を満たすかどうかを判断する
+func (q FileQuery) Matches(file FileInfo) bool {
+	if !q.OlderThan.IsZero() && !file.LastSyncTime.Before(q.OlderThan) {
+		return false
+	}
+
+	if len(q.Statuses) > 0 {
+		found := false
+		for _, status := range q.Statuses {
+			if file.Status == status {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(q.Patterns) > 0 {
+		name := path.Base(file.Path)
+		for _, pattern := range q.Patterns {
+			target := name
+			if strings.Contains(pattern, "/") {
+				target = file.Path
+			}
+			if matched, _ := path.Match(pattern, target); matched {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// DeleteFiles は条件を満たすファイル情報を1つのトランザクションで削除し、件数を返す
+// dryRunの場合は削除せずに件数だけを数える。fnを指定すると対象のレコードごとに呼び出す
+func (s *SyncDB) DeleteFiles(query FileQuery, dryRun bool, fn func(FileInfo)) (int, error) {
+	if err := query.Validate(); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	scan := func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(fileSyncBucket)
+		if bucket == nil {
+			return fmt.Errorf("ファイル同期バケットが見つかりません")
+		}
+
+		// ForEach中はバケットを変更できないため、対象を先に収集する
+		var keys [][]byte
+		err := bucket.ForEach(func(k, v []byte) error {
+			var fileInfo FileInfo
+			if err := json.Unmarshal(v, &fileInfo); err != nil {
+				return fmt.Errorf("ファイル情報のデシリアライズエラー: %w", err)
+			}
+			if !query.Matches(fileInfo) {
+				return nil
+			}
+			if fn != nil {
+				fn(fileInfo)
+			}
+			keys = append(keys, append([]byte(nil), k...))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		count = len(keys)
+		if dryRun {
+			return nil
+		}
+		for _, k := range keys {
+			if err := bucket.Delete(k); err != nil {
+				return fmt.Errorf("ファイル情報の削除エラー: %w", err)
+			}
+		}
+		return nil
+	}
+
+	var err error
+	if dryRun {
+		err = s.view(scan)
+	} else {
+		err = s.update("", scan)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileQuery_Matches(t *testing.T) {
+	now := time.Now()
+	file := FileInfo{Path: "logs/app/debug.tmp", Status: StatusFailed, LastSyncTime: now.Add(-48 * time.Hour)}
+
+	tests := []struct {
+		name  string
+		query FileQuery
+		want  bool
+	}{
+		{"条件なし", FileQuery{}, true},
+		{"ファイル名のパターン", FileQuery{Patterns: []string{"*.log", "*.tmp"}}, true},
+		{"パスのパターン", FileQuery{Patterns: []string{"logs/*/*.tmp"}}, true},
+		{"パスのパターン不一致", FileQuery{Patterns: []string{"data/*/*.tmp"}}, false},
+		{"ステータス一致", FileQuery{Statuses: []FileStatus{StatusSuccess, StatusFailed}}, true},
+		{"ステータス不一致", FileQuery{Statuses: []FileStatus{StatusSuccess}}, false},
+		{"古い", FileQuery{OlderThan: now.Add(-24 * time.Hour)}, true},
+		{"新しい", FileQuery{OlderThan: now.Add(-72 * time.Hour)}, false},
+		{"すべて一致", FileQuery{Patterns: []string{"*.tmp"}, Statuses: []FileStatus{StatusFailed}, OlderThan: now}, true},
+		{"一部不一致", FileQuery{Patterns: []string{"*.tmp"}, Statuses: []FileStatus{StatusSkipped}, OlderThan: now}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.query.Matches(file); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if err := (FileQuery{Patterns: []string{"[a-"}}).Validate(); err == nil {
+		t.Error("無効なパターンでエラーになりません")
+	}
+}
+
+func TestSyncDB_DeleteFiles(t *testing.T) {
+	db, err := NewSyncDB(filepath.Join(t.TempDir(), "test.db"), NormalSync)
+	if err != nil {
+		t.Fatalf("データベース作成が失敗: %v", err)
+	}
+	defer db.Close()
+
+	old := time.Now().AddDate(0, 0, -60)
+	files := []FileInfo{
+		{Path: "a.tmp", Status: StatusFailed, LastSyncTime: old},
+		{Path: "b.tmp", Status: StatusSuccess, LastSyncTime: old},
+		{Path: "c.tmp", Status: StatusFailed, LastSyncTime: time.Now()},
+		{Path: "d.txt", Status: StatusFailed, LastSyncTime: old},
+	}
+	for _, file := range files {
+		if err := db.AddFile(file); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	query := FileQuery{
+		Patterns:  []string{"*.tmp"},
+		Statuses:  []FileStatus{StatusFailed},
+		OlderThan: time.Now().AddDate(0, 0, -30),
+	}
+
+	var previewed []string
+	count, err := db.DeleteFiles(query, true, func(file FileInfo) {
+		previewed = append(previewed, file.Path)
+	})
+	if err != nil {
+		t.Fatalf("DeleteFiles(dryRun) error = %v", err)
+	}
+	if count != 1 || len(previewed) != 1 || previewed[0] != "a.tmp" {
+		t.Errorf("プレビュー = %d件 %v, want 1件 [a.tmp]", count, previewed)
+	}
+	if n, _ := db.CountFiles(); n != 4 {
+		t.Errorf("ドライランでレコードが削除されました: %d件", n)
+	}
+
+	count, err = db.DeleteFiles(query, false, nil)
+	if err != nil {
+		t.Fatalf("DeleteFiles() error = %v", err)
+	}
+	if count != 1 {
+		t.Errorf("削除件数 = %d, want 1", count)
+	}
+	if file, _ := db.GetFile("a.tmp"); file != nil {
+		t.Error("a.tmpが削除されていません")
+	}
+	if n, _ := db.CountFiles(); n != 3 {
+		t.Errorf("残りのレコード = %d件, want 3", n)
+	}
+
+	if _, err := db.DeleteFiles(FileQuery{Patterns: []string{"[a-"}}, false, nil); err == nil {
+		t.Error("無効なパターンでエラーになりません")
+	}
+}
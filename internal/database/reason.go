@@ -0,0 +1,148 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go.etcd.io/bbolt"
+
+	"github.com/sakuhanight/gopier/internal/errcode"
+)
+
+// Reason は失敗ではない記録（スキップ・余分なファイルなど）のLastErrorの理由を表す言語に依存しないコード
+// LastErrorは利用者向けの日本語のメッセージのため、プログラムから判別する場合は失敗の場合はError.Code、
+// それ以外の場合はReasonを使用する
+type Reason string
+
+const (
+	// ReasonFiltered はフィルタによりスキップした
+	ReasonFiltered Reason = "filtered"
+	// ReasonFlattenCollision はフラット化でファイル名が衝突したためコピーしなかった
+	ReasonFlattenCollision Reason = "flatten_collision"
+	// ReasonDestExists は宛先にファイルが既に存在するためスキップした
+	ReasonDestExists Reason = "dest_exists"
+	// ReasonDestNewer は宛先の方が新しいためスキップした
+	ReasonDestNewer Reason = "dest_newer"
+	// ReasonInterrupted はコピーが中断された
+	ReasonInterrupted Reason = "interrupted"
+	// ReasonIntermittent はハッシュ値が一度一致せず、再検証で一致した
+	ReasonIntermittent Reason = "intermittent"
+	// ReasonExtra はソースに存在しない余分なファイル
+	ReasonExtra Reason = "extra"
+	// ReasonExtraDeleted はソースに存在しない余分なファイルを削除した
+	ReasonExtraDeleted Reason = "extra_deleted"
+	// ReasonExtraQuarantined はソースに存在しない余分なファイルを隔離した
+	ReasonExtraQuarantined Reason = "extra_quarantined"
+)
+
+// Code はLastErrorの種類を表す言語に依存しないコードを返す
+// 失敗の場合はエラーコード、それ以外の場合は理由を返し、LastErrorがない場合は空文字列を返す
+func (f *FileInfo) Code() string {
+	switch {
+	case f.Error != nil && f.Error.Code != "":
+		return string(f.Error.Code)
+	case f.Reason != "":
+		return string(f.Reason)
+	case f.LastError != "":
+		return string(errcode.EUnknown)
+	}
+	return ""
+}
+
+// legacyMessages は旧形式のレコードのLastErrorの先頭と、対応する理由・エラーコードの対応
+// （より具体的なものを先に並べる）
+var legacyMessages = []struct {
+	prefix string
+	reason Reason
+	code   errcode.Catalog
+}{
+	{"フィルタによりスキップ", ReasonFiltered, ""},
+	{"フラット化によるファイル名の衝突", ReasonFlattenCollision, ""},
+	{"宛先ファイルが既に存在します", ReasonDestExists, ""},
+	{"宛先の方が新しいためスキップ", ReasonDestNewer, ""},
+	{"コピーが中断されました", ReasonInterrupted, ""},
+	{"ハッシュ値が一度一致せず", ReasonIntermittent, ""},
+	{"ソースに存在しない余分なファイルを削除しました", ReasonExtraDeleted, ""},
+	{"ソースに存在しない余分なファイルを隔離しました", ReasonExtraQuarantined, ""},
+	{"ソースに存在しない余分なファイルです", ReasonExtra, ""},
+	{"ハッシュ値が一致しません", "", errcode.EHashMismatch},
+	{"変換後のハッシュ値が一致しません", "", errcode.EHashMismatch},
+	{"ファイルサイズが一致しません", "", errcode.ESizeMismatch},
+	{"所有者が一致しません", "", errcode.EOwnerMismatch},
+	{"更新日時が一致しません", "", errcode.EMTimeMismatch},
+	{"宛先ファイルが存在しません", "", errcode.EDstMissing},
+	{"ソースファイル確認エラー", "", errcode.ESrcRead},
+	{"ソースハッシュ計算エラー", "", errcode.ESrcRead},
+	{"ソースファイルのハッシュ計算エラー", "", errcode.ESrcRead},
+	{"宛先ファイル確認エラー", "", errcode.EDstRead},
+	{"宛先ハッシュ計算エラー", "", errcode.EDstRead},
+	{"宛先ファイルのハッシュ計算エラー", "", errcode.EDstRead},
+	{"宛先ディレクトリ作成エラー", "", errcode.EDstWrite},
+	{"アクセス権の設定エラー", "", errcode.EACLCopy},
+}
+
+// classifyLegacy は理由・エラーコードを記録していない旧形式のレコードに、LastErrorから理由またはエラーコードを設定する
+// 変更した場合はtrueを返す
+func classifyLegacy(file *FileInfo) bool {
+	if file.LastError == "" || file.Reason != "" || file.Error != nil {
+		return false
+	}
+	for _, m := range legacyMessages {
+		if strings.HasPrefix(file.LastError, m.prefix) {
+			if m.reason != "" {
+				file.Reason = m.reason
+			} else {
+				file.Error = &errcode.Detail{Code: m.code}
+			}
+			return true
+		}
+	}
+	file.Error = &errcode.Detail{Code: errcode.EUnknown}
+	return true
+}
+
+// migrateLastErrorCodes は旧形式のレコードに、LastErrorのメッセージから理由・エラーコードを設定する
+func migrateLastErrorCodes(bucket *bbolt.Bucket) error {
+	// ForEach中はバケットを変更できないため、変更したレコードを先に収集する
+	updated := make(map[string][]byte)
+	err := bucket.ForEach(func(k, v []byte) error {
+		var file FileInfo
+		if err := json.Unmarshal(v, &file); err != nil {
+			// 読み込めないレコードは移行せず、db checkで報告する
+			return nil
+		}
+		changed := classifyLegacy(&file)
+		for root, target := range file.Targets {
+			if target.LastError != "" && target.Code == "" {
+				legacy := FileInfo{LastError: target.LastError}
+				classifyLegacy(&legacy)
+				target.Code = errcode.EUnknown
+				if legacy.Error != nil {
+					target.Code = legacy.Error.Code
+				}
+				file.Targets[root] = target
+				changed = true
+			}
+		}
+		if !changed {
+			return nil
+		}
+		data, err := json.Marshal(file)
+		if err != nil {
+			return fmt.Errorf("ファイル情報のシリアライズエラー: %w", err)
+		}
+		updated[string(k)] = data
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("エラーコードの移行の走査エラー: %w", err)
+	}
+
+	for key, data := range updated {
+		if err := bucket.Put([]byte(key), data); err != nil {
+			return fmt.Errorf("エラーコードの移行の保存エラー: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package database
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"go.etcd.io/bbolt"
+
+	"github.com/sakuhanight/gopier/internal/errcode"
+)
+
+// TestMigrateLastErrorCodes はバージョン2のレコードにLastErrorから理由・エラーコードを設定する移行をテスト
+func TestMigrateLastErrorCodes(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	db, err := NewSyncDB(dbPath, NormalSync)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// バージョン2の形式のレコードを直接書き込む
+	legacy := []FileInfo{
+		{Path: "skipped.txt", Status: StatusSkipped, LastError: "フィルタによりスキップ"},
+		{Path: "extra.txt", Status: StatusDeleted, LastError: "ソースに存在しない余分なファイルを削除しました"},
+		{Path: "size.txt", Status: StatusMismatch, LastError: "ファイルサイズが一致しません (ソース: 1, 宛先: 2)"},
+		{Path: "other.txt", Status: StatusFailed, LastError: "ファイルコピーエラー: 不明"},
+		{Path: "coded.txt", Status: StatusFailed, LastError: "ハッシュ値が一致しません", Error: &errcode.Detail{Code: errcode.ELocked}},
+		{Path: "ok.txt", Status: StatusSuccess},
+		{Path: "fanout.txt", Status: StatusFailed, LastError: "宛先ハッシュ計算エラー: EIO",
+			Targets: map[string]TargetStatus{"/b": {Status: StatusFailed, LastError: "宛先ディレクトリ作成エラー: EACCES"}}},
+	}
+	db.db.Update(func(tx *bbolt.Tx) error {
+		for _, file := range legacy {
+			data, _ := json.Marshal(file)
+			tx.Bucket(fileSyncBucket).Put([]byte(file.Path), data)
+		}
+		return tx.Bucket(metaBucket).Put(fileSchemaVersionKey, []byte("2"))
+	})
+	db.Close()
+
+	db, err = NewSyncDB(dbPath, NormalSync)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	want := map[string]string{
+		"skipped.txt": "filtered",
+		"extra.txt":   "extra_deleted",
+		"size.txt":    "E_SIZE_MISMATCH",
+		"other.txt":   "E_UNKNOWN",
+		"coded.txt":   "E_LOCKED", // 記録済みのエラーコードは変更しない
+		"ok.txt":      "",
+		"fanout.txt":  "E_DST_READ",
+	}
+	for path, code := range want {
+		got, err := db.GetFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Code() != code {
+			t.Errorf("%s のコード = %q, want %q", path, got.Code(), code)
+		}
+	}
+	if got, _ := db.GetFile("fanout.txt"); got.Targets["/b"].Code != errcode.EDstWrite {
+		t.Errorf("宛先ごとのエラーコード = %q", got.Targets["/b"].Code)
+	}
+}
+
+// TestMigrateFileSchema_Newer は新しいバージョンの形式のデータベースを移行せずに拒否することをテスト
+// （文字列として比較すると"10"は"3"より小さくなり、移行してしまう）
+func TestMigrateFileSchema_Newer(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	db, err := NewSyncDB(dbPath, NormalSync)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := FileInfo{Path: "skipped.txt", Status: StatusSkipped, LastError: "フィルタによりスキップ"}
+	db.db.Update(func(tx *bbolt.Tx) error {
+		data, _ := json.Marshal(record)
+		tx.Bucket(fileSyncBucket).Put([]byte(record.Path), data)
+		return tx.Bucket(metaBucket).Put(fileSchemaVersionKey, []byte("10"))
+	})
+	db.Close()
+
+	if db, err := NewSyncDB(dbPath, NormalSync); err == nil {
+		db.Close()
+		t.Fatal("新しい形式のデータベースを開いてもエラーになりません")
+	}
+
+	// 拒否したデータベースは変更しない
+	raw, err := bbolt.Open(dbPath, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer raw.Close()
+	raw.View(func(tx *bbolt.Tx) error {
+		if version := string(tx.Bucket(metaBucket).Get(fileSchemaVersionKey)); version != "10" {
+			t.Errorf("file_schema_version = %q, want %q", version, "10")
+		}
+		var got FileInfo
+		json.Unmarshal(tx.Bucket(fileSyncBucket).Get([]byte(record.Path)), &got)
+		if got.Reason != "" {
+			t.Errorf("拒否したデータベースのレコードが移行されました: %+v", got)
+		}
+		return nil
+	})
+}
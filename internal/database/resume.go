@@ -0,0 +1,103 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.etcd.io/bbolt"
+
+	"github.com/sakuhanight/gopier/internal/pathkey"
+)
+
+// ResumeToken は宛先のストレージへの書き込みを中断したファイルの、再開用のトークン
+// トークンの内容（アップロードのIDや送信済みのパートの一覧など）はストレージごとに異なり、DBでは解釈しない
+type ResumeToken struct {
+	Path      string    `json:"path"`       // ソースからの相対パス
+	DestPath  string    `json:"dest_path"`  // 宛先からの相対パス
+	Token     string    `json:"token"`      // ストレージが返した再開用のトークン
+	Offset    int64     `json:"offset"`     // 宛先に保存済みのサイズ（再開する位置）
+	Size      int64     `json:"size"`       // 書き込みを開始した時点のソースのサイズ
+	ModTime   time.Time `json:"mod_time"`   // 書き込みを開始した時点のソースの更新日時
+	UpdatedAt time.Time `json:"updated_at"` // トークンを記録した日時
+}
+
+// SaveResumeToken は再開用のトークンを記録する（同じパスの記録は置き換える）
+// 異常終了しても再開できるよう、書き込みキューが有効な場合も記録がコミットされるまで待ってから戻る
+func (s *SyncDB) SaveResumeToken(token ResumeToken) error {
+	key := pathkey.Normalize(token.Path)
+	token.Path = key
+	data, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("再開用のトークンのシリアライズエラー: %w", err)
+	}
+	return s.update(key, func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(resumeBucket)
+		if bucket == nil {
+			return fmt.Errorf("再開用のトークンのバケットが見つかりません")
+		}
+		return bucket.Put([]byte(key), data)
+	})
+}
+
+// GetResumeToken は再開用のトークンを取得する（記録がない場合はnil）
+func (s *SyncDB) GetResumeToken(path string) (*ResumeToken, error) {
+	key := pathkey.Normalize(path)
+	var token *ResumeToken
+
+	err := s.viewFile(key, func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(resumeBucket)
+		if bucket == nil {
+			return fmt.Errorf("再開用のトークンのバケットが見つかりません")
+		}
+		data := bucket.Get([]byte(key))
+		if data == nil {
+			return nil
+		}
+		token = &ResumeToken{}
+		if err := json.Unmarshal(data, token); err != nil {
+			return fmt.Errorf("再開用のトークンのデシリアライズエラー: %w", err)
+		}
+		return nil
+	})
+
+	return token, err
+}
+
+// GetResumeTokens はすべての再開用のトークンをパスの順に取得する
+func (s *SyncDB) GetResumeTokens() ([]ResumeToken, error) {
+	var tokens []ResumeToken
+
+	err := s.view(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(resumeBucket)
+		if bucket == nil {
+			return fmt.Errorf("再開用のトークンのバケットが見つかりません")
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var token ResumeToken
+			if err := json.Unmarshal(v, &token); err != nil {
+				return fmt.Errorf("再開用のトークンのデシリアライズエラー: %w", err)
+			}
+			tokens = append(tokens, token)
+			return nil
+		})
+	})
+
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].Path < tokens[j].Path
+	})
+	return tokens, err
+}
+
+// DeleteResumeToken は再開用のトークンの記録を削除する
+func (s *SyncDB) DeleteResumeToken(path string) error {
+	key := pathkey.Normalize(path)
+	return s.update(key, func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(resumeBucket)
+		if bucket == nil {
+			return fmt.Errorf("再開用のトークンのバケットが見つかりません")
+		}
+		return bucket.Delete([]byte(key))
+	})
+}
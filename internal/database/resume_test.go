@@ -0,0 +1,43 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestResumeTokens(t *testing.T) {
+	db, err := NewSyncDB(filepath.Join(t.TempDir(), "test.db"), NormalSync)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := db.SaveResumeToken(ResumeToken{Path: `dir\b.bin`, DestPath: "dir/b.bin", Token: "upload-1", Offset: 100, Size: 300, ModTime: modTime}); err != nil {
+		t.Fatalf("SaveResumeToken() error = %v", err)
+	}
+	db.SaveResumeToken(ResumeToken{Path: "a.bin", Token: "upload-2"})
+
+	// 同じパスの記録は置き換える
+	db.SaveResumeToken(ResumeToken{Path: "dir/b.bin", DestPath: "dir/b.bin", Token: "upload-1", Offset: 200, Size: 300, ModTime: modTime})
+	token, err := db.GetResumeToken("dir/b.bin")
+	if err != nil || token == nil || token.Offset != 200 || token.Token != "upload-1" || !token.ModTime.Equal(modTime) {
+		t.Fatalf("GetResumeToken() = %+v, %v", token, err)
+	}
+	if token, err := db.GetResumeToken("none.bin"); err != nil || token != nil {
+		t.Errorf("記録のないパスのGetResumeToken() = %+v, %v", token, err)
+	}
+
+	tokens, err := db.GetResumeTokens()
+	if err != nil || len(tokens) != 2 || tokens[0].Path != "a.bin" || tokens[1].Path != "dir/b.bin" {
+		t.Fatalf("GetResumeTokens() = %+v, %v", tokens, err)
+	}
+
+	if err := db.DeleteResumeToken("dir/b.bin"); err != nil {
+		t.Fatalf("DeleteResumeToken() error = %v", err)
+	}
+	if token, _ := db.GetResumeToken("dir/b.bin"); token != nil {
+		t.Errorf("削除後のGetResumeToken() = %+v", token)
+	}
+}
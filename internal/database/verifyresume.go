@@ -0,0 +1,82 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+
+	"github.com/sakuhanight/gopier/internal/pathkey"
+)
+
+// VerifyCheckpoint は大きいファイルの検証を中断した位置から再開するための、ハッシュ値の計算の途中の状態
+// ソースと宛先をチャンクごとに読み込み、チャンクの境界まで計算したハッシュ関数の内部状態を記録する
+type VerifyCheckpoint struct {
+	Path          string    `json:"path"`            // ソースからの相対パス
+	HashAlgo      string    `json:"hash_algo"`       // ハッシュアルゴリズム
+	Size          int64     `json:"size"`            // 検証を開始した時点のファイルのサイズ
+	SourceModTime time.Time `json:"source_mod_time"` // 検証を開始した時点のソースの更新日時
+	DestModTime   time.Time `json:"dest_mod_time"`   // 検証を開始した時点の宛先の更新日時
+	ChunkSize     int64     `json:"chunk_size"`      // 状態を記録するチャンクの大きさ
+	Offset        int64     `json:"offset"`          // 検証済みの位置（チャンクの境界）
+	SourceState   []byte    `json:"source_state"`    // ソースのハッシュ関数の内部状態
+	DestState     []byte    `json:"dest_state"`      // 宛先のハッシュ関数の内部状態
+	// FirstMismatch は内容が最初に一致しなかったチャンクの位置（一致しないチャンクがない場合は-1）
+	FirstMismatch int64     `json:"first_mismatch"`
+	UpdatedAt     time.Time `json:"updated_at"` // 状態を記録した日時
+}
+
+// SaveVerifyCheckpoint は検証の途中の状態を記録する（同じパスの記録は置き換える）
+// 異常終了しても再開できるよう、書き込みキューが有効な場合も記録がコミットされるまで待ってから戻る
+func (s *SyncDB) SaveVerifyCheckpoint(checkpoint VerifyCheckpoint) error {
+	key := pathkey.Normalize(checkpoint.Path)
+	checkpoint.Path = key
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("検証の途中の状態のシリアライズエラー: %w", err)
+	}
+	return s.update(key, func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(verifyResumeBucket)
+		if bucket == nil {
+			return fmt.Errorf("検証の途中の状態のバケットが見つかりません")
+		}
+		return bucket.Put([]byte(key), data)
+	})
+}
+
+// GetVerifyCheckpoint は検証の途中の状態を取得する（記録がない場合はnil）
+func (s *SyncDB) GetVerifyCheckpoint(path string) (*VerifyCheckpoint, error) {
+	key := pathkey.Normalize(path)
+	var checkpoint *VerifyCheckpoint
+
+	err := s.viewFile(key, func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(verifyResumeBucket)
+		if bucket == nil {
+			return fmt.Errorf("検証の途中の状態のバケットが見つかりません")
+		}
+		data := bucket.Get([]byte(key))
+		if data == nil {
+			return nil
+		}
+		checkpoint = &VerifyCheckpoint{}
+		if err := json.Unmarshal(data, checkpoint); err != nil {
+			return fmt.Errorf("検証の途中の状態のデシリアライズエラー: %w", err)
+		}
+		return nil
+	})
+
+	return checkpoint, err
+}
+
+// DeleteVerifyCheckpoint は検証の途中の状態の記録を削除する
+func (s *SyncDB) DeleteVerifyCheckpoint(path string) error {
+	key := pathkey.Normalize(path)
+	return s.update(key, func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(verifyResumeBucket)
+		if bucket == nil {
+			return fmt.Errorf("検証の途中の状態のバケットが見つかりません")
+		}
+		return bucket.Delete([]byte(key))
+	})
+}
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestVerifyCheckpoint(t *testing.T) {
+	db, err := NewSyncDB(filepath.Join(t.TempDir(), "test.db"), NormalSync)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	checkpoint := VerifyCheckpoint{Path: `dir\big.img`, HashAlgo: "sha256", Size: 300, SourceModTime: modTime, DestModTime: modTime,
+		ChunkSize: 100, Offset: 100, SourceState: []byte("src"), DestState: []byte("dst"), FirstMismatch: -1}
+	if err := db.SaveVerifyCheckpoint(checkpoint); err != nil {
+		t.Fatalf("SaveVerifyCheckpoint() error = %v", err)
+	}
+
+	// 同じパスの記録は置き換える
+	checkpoint.Offset, checkpoint.FirstMismatch = 200, 100
+	db.SaveVerifyCheckpoint(checkpoint)
+	got, err := db.GetVerifyCheckpoint("dir/big.img")
+	if err != nil || got == nil || got.Offset != 200 || got.FirstMismatch != 100 || string(got.SourceState) != "src" || !got.SourceModTime.Equal(modTime) {
+		t.Fatalf("GetVerifyCheckpoint() = %+v, %v", got, err)
+	}
+	if got, err := db.GetVerifyCheckpoint("none.img"); err != nil || got != nil {
+		t.Errorf("記録のないパスのGetVerifyCheckpoint() = %+v, %v", got, err)
+	}
+
+	if err := db.DeleteVerifyCheckpoint("dir/big.img"); err != nil {
+		t.Fatalf("DeleteVerifyCheckpoint() error = %v", err)
+	}
+	if got, _ := db.GetVerifyCheckpoint("dir/big.img"); got != nil {
+		t.Errorf("削除した後のGetVerifyCheckpoint() = %+v", got)
+	}
+}
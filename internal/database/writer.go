@@ -0,0 +1,316 @@
+package database
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// maxWriteBatch は1トランザクションにまとめる書き込みの最大件数
+const maxWriteBatch = 256
+
+// WriteQueueStats は書き込みキューの統計情報を表す構造体
+type WriteQueueStats struct {
+	Capacity    int           // キューの容量
+	Depth       int           // 現在キューに積まれている書き込みの数
+	MaxDepth    int           // キューに積まれた書き込みの最大数
+	Enqueued    int64         // キューに積まれた書き込みの総数
+	Committed   int64         // コミットされた書き込みの総数
+	Failed      int64         // 失敗した書き込みの総数
+	Batches     int64         // 実行したトランザクションの数
+	Blocked     int64         // キューが満杯で呼び出し側が待たされた回数
+	BlockedTime time.Duration // キューが満杯で呼び出し側が待たされた時間の合計
+}
+
+// writeOp はキューに積まれる書き込み操作
+type writeOp struct {
+	key  string                   // 書き込み対象のファイルのキー（特定のファイルでない場合は空）
+	fn   func(tx *bbolt.Tx) error // nilの場合は先行する書き込みの完了を待つための目印
+	done chan error               // nilの場合は完了を待たない（非同期）
+}
+
+// writeQueue は専用のゴルーチンで書き込みをまとめて実行するキュー
+// 呼び出し側は書き込みを積むだけで、コミット（fsync）の完了を1件ずつ待たずに済む
+type writeQueue struct {
+	db      *bbolt.DB
+	ops     chan writeOp
+	onError func(error)
+
+	mu     sync.RWMutex // closedとopsのクローズを保護する
+	closed bool
+	wg     sync.WaitGroup
+
+	pendingMu sync.Mutex
+	pending   map[string]int // ファイルごとの未完了の書き込み数
+
+	depth       int64
+	maxDepth    int64
+	enqueued    int64
+	committed   int64
+	failed      int64
+	batches     int64
+	blocked     int64
+	blockedTime int64
+}
+
+// EnableWriteQueue は書き込みキューを有効にする
+// 有効にすると、AddFileはキューに積んだ時点で戻り、書き込みエラーはonErrorに通知される
+// （onErrorは書き込み用のゴルーチンから呼ばれる）。その他の書き込みは完了を待って結果を返す。
+// 書き込みは積まれた順に実行され、読み込みの前には未完了の書き込みが反映される
+func (s *SyncDB) EnableWriteQueue(capacity int, onError func(error)) {
+	if capacity <= 0 || s.queue != nil {
+		return
+	}
+
+	q := &writeQueue{
+		db:      s.db,
+		ops:     make(chan writeOp, capacity),
+		onError: onError,
+		pending: make(map[string]int),
+	}
+	q.wg.Add(1)
+	go q.run()
+	s.queue = q
+}
+
+// WriteQueueStats は書き込みキューの統計情報を返す
+// キューが有効でない場合はnilを返す
+func (s *SyncDB) WriteQueueStats() *WriteQueueStats {
+	q := s.queue
+	if q == nil {
+		return nil
+	}
+
+	return &WriteQueueStats{
+		Capacity:    cap(q.ops),
+		Depth:       int(atomic.LoadInt64(&q.depth)),
+		MaxDepth:    int(atomic.LoadInt64(&q.maxDepth)),
+		Enqueued:    atomic.LoadInt64(&q.enqueued),
+		Committed:   atomic.LoadInt64(&q.committed),
+		Failed:      atomic.LoadInt64(&q.failed),
+		Batches:     atomic.LoadInt64(&q.batches),
+		Blocked:     atomic.LoadInt64(&q.blocked),
+		BlockedTime: time.Duration(atomic.LoadInt64(&q.blockedTime)),
+	}
+}
+
+// Flush はキューに積まれた書き込みがすべて完了するまで待つ
+func (s *SyncDB) Flush() error {
+	if s.queue == nil {
+		return nil
+	}
+	return s.queue.submit("", nil, true)
+}
+
+// update は書き込みを実行し、完了を待って結果を返す
+// keyには書き込み対象のファイルのキーを指定する（特定のファイルでない場合は空）
+func (s *SyncDB) update(key string, fn func(tx *bbolt.Tx) error) error {
+	if s.queue == nil {
+		return s.db.Update(fn)
+	}
+	return s.queue.submit(key, fn, true)
+}
+
+// updateAsync は書き込みをキューに積んで、完了を待たずに戻る
+// キューが有効でない場合は同期的に実行して結果を返す
+func (s *SyncDB) updateAsync(key string, fn func(tx *bbolt.Tx) error) error {
+	if s.queue == nil {
+		return s.db.Update(fn)
+	}
+	return s.queue.submit(key, fn, false)
+}
+
+// view は未完了の書き込みをすべて反映してから読み込みを実行する
+func (s *SyncDB) view(fn func(tx *bbolt.Tx) error) error {
+	if s.queue != nil && atomic.LoadInt64(&s.queue.depth) > 0 {
+		if err := s.queue.submit("", nil, true); err != nil {
+			return err
+		}
+	}
+	return s.db.View(fn)
+}
+
+// viewFile は指定されたファイルへの未完了の書き込みがある場合のみ、それを反映してから読み込みを実行する
+// 他のファイルへの書き込みの完了は待たないため、ワーカーが互いの書き込みで待たされない
+func (s *SyncDB) viewFile(key string, fn func(tx *bbolt.Tx) error) error {
+	if s.queue != nil && s.queue.hasPending(key) {
+		if err := s.queue.submit("", nil, true); err != nil {
+			return err
+		}
+	}
+	return s.db.View(fn)
+}
+
+// hasPending は指定されたファイルへの未完了の書き込みがあるかを返す
+func (q *writeQueue) hasPending(key string) bool {
+	q.pendingMu.Lock()
+	defer q.pendingMu.Unlock()
+	return q.pending[key] > 0
+}
+
+// submit は書き込みをキューに積む
+func (q *writeQueue) submit(key string, fn func(tx *bbolt.Tx) error, wait bool) error {
+	op := writeOp{key: key, fn: fn}
+	if wait {
+		op.done = make(chan error, 1)
+	}
+
+	q.mu.RLock()
+	if q.closed {
+		q.mu.RUnlock()
+		return fmt.Errorf("データベースは既に閉じられています")
+	}
+
+	if fn != nil {
+		atomic.AddInt64(&q.enqueued, 1)
+		depth := atomic.AddInt64(&q.depth, 1)
+		for {
+			max := atomic.LoadInt64(&q.maxDepth)
+			if depth <= max || atomic.CompareAndSwapInt64(&q.maxDepth, max, depth) {
+				break
+			}
+		}
+		if key != "" {
+			q.pendingMu.Lock()
+			q.pending[key]++
+			q.pendingMu.Unlock()
+		}
+	}
+
+	select {
+	case q.ops <- op:
+	default:
+		// キューが満杯の場合は空くまで待つ（バックプレッシャー）
+		start := time.Now()
+		q.ops <- op
+		atomic.AddInt64(&q.blocked, 1)
+		atomic.AddInt64(&q.blockedTime, int64(time.Since(start)))
+	}
+	q.mu.RUnlock()
+
+	if !wait {
+		return nil
+	}
+	return <-op.done
+}
+
+// close は新しい書き込みを受け付けないようにし、積まれた書き込みの完了を待つ
+func (q *writeQueue) close() {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.closed = true
+	close(q.ops)
+	q.mu.Unlock()
+
+	q.wg.Wait()
+}
+
+// run はキューから書き込みを取り出して、まとめて実行する
+func (q *writeQueue) run() {
+	defer q.wg.Done()
+
+	for op := range q.ops {
+		batch := []writeOp{op}
+	collect:
+		for len(batch) < maxWriteBatch {
+			select {
+			case next, ok := <-q.ops:
+				if !ok {
+					break collect
+				}
+				batch = append(batch, next)
+			default:
+				break collect
+			}
+		}
+
+		q.commit(batch)
+	}
+}
+
+// commit は複数の書き込みを1つのトランザクションで実行する
+// いずれかが失敗した場合はトランザクション全体が取り消されるため、1件ずつ実行し直す
+func (q *writeQueue) commit(batch []writeOp) {
+	errs := make([]error, len(batch))
+
+	writes := 0
+	for _, op := range batch {
+		if op.fn != nil {
+			writes++
+		}
+	}
+	if writes == 0 {
+		// 先行する書き込みの完了を待つための目印だけの場合
+		for _, op := range batch {
+			op.done <- nil
+		}
+		return
+	}
+
+	err := q.db.Update(func(tx *bbolt.Tx) error {
+		for _, op := range batch {
+			if op.fn == nil {
+				continue
+			}
+			if err := op.fn(tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	atomic.AddInt64(&q.batches, 1)
+
+	if err != nil {
+		for i, op := range batch {
+			if op.fn == nil {
+				continue
+			}
+			errs[i] = q.db.Update(op.fn)
+			atomic.AddInt64(&q.batches, 1)
+		}
+	}
+
+	for i, op := range batch {
+		if op.fn != nil {
+			atomic.AddInt64(&q.depth, -1)
+			if op.key != "" {
+				q.pendingMu.Lock()
+				if q.pending[op.key]--; q.pending[op.key] <= 0 {
+					delete(q.pending, op.key)
+				}
+				q.pendingMu.Unlock()
+			}
+			if errs[i] != nil {
+				atomic.AddInt64(&q.failed, 1)
+				if op.done == nil && q.onError != nil {
+					q.onError(errs[i])
+				}
+			} else {
+				atomic.AddInt64(&q.committed, 1)
+			}
+		}
+		if op.done != nil {
+			op.done <- errs[i]
+		}
+	}
+}
+
+// DisableWriteQueue はキューに積まれた書き込みの完了を待ってから書き込みキューを無効にし、
+// 以降の書き込みを同期的な実行に戻す。無効にする時点の統計情報を返す
+// 他のゴルーチンがデータベースを使用していない状態で呼び出すこと
+func (s *SyncDB) DisableWriteQueue() *WriteQueueStats {
+	if s.queue == nil {
+		return nil
+	}
+
+	s.queue.close()
+	stats := s.WriteQueueStats()
+	s.queue = nil
+	return stats
+}
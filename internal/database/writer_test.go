@@ -0,0 +1,206 @@
+package database
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"go.etcd.io/bbolt"
+)
+
+func TestWriteQueue_ConcurrentAddFile(t *testing.T) {
+	db, err := NewSyncDB(filepath.Join(t.TempDir(), "test.db"), NormalSync)
+	if err != nil {
+		t.Fatalf("データベース作成が失敗: %v", err)
+	}
+	defer db.Close()
+
+	// 容量を小さくしてバックプレッシャーを発生させる
+	db.EnableWriteQueue(4, func(err error) {
+		t.Errorf("書き込みエラー: %v", err)
+	})
+
+	const workers, perWorker = 8, 200
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				path := fmt.Sprintf("w%d/file%d.txt", w, i)
+				if err := db.AddFile(FileInfo{Path: path, Status: StatusPending}); err != nil {
+					t.Errorf("AddFile() error = %v", err)
+				}
+				// 同じパスへの後続の書き込みは積まれた順に反映される
+				if err := db.UpdateFileStatus(path, StatusSuccess, ""); err != nil {
+					t.Errorf("UpdateFileStatus() error = %v", err)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	// 読み込み前に未完了の書き込みが反映される
+	count, err := db.CountFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != workers*perWorker {
+		t.Errorf("CountFiles() = %d, want %d", count, workers*perWorker)
+	}
+	stats, _ := db.GetSyncStats()
+	if stats["success_files"] != workers*perWorker {
+		t.Errorf("success_files = %d, want %d", stats["success_files"], workers*perWorker)
+	}
+
+	qs := db.WriteQueueStats()
+	if qs.Committed != 2*workers*perWorker || qs.Failed != 0 || qs.Depth != 0 {
+		t.Errorf("統計情報が期待値と異なります: %+v", qs)
+	}
+	if qs.Batches >= qs.Committed {
+		t.Errorf("書き込みがまとめられていません: batches=%d, committed=%d", qs.Batches, qs.Committed)
+	}
+	if qs.MaxDepth == 0 || qs.Capacity != 4 {
+		t.Errorf("キューの深さが記録されていません: %+v", qs)
+	}
+}
+
+func TestWriteQueue_ErrorIsolation(t *testing.T) {
+	db, err := NewSyncDB(filepath.Join(t.TempDir(), "test.db"), NormalSync)
+	if err != nil {
+		t.Fatalf("データベース作成が失敗: %v", err)
+	}
+	defer db.Close()
+
+	var mu sync.Mutex
+	var asyncErrs []error
+	db.EnableWriteQueue(16, func(err error) {
+		mu.Lock()
+		asyncErrs = append(asyncErrs, err)
+		mu.Unlock()
+	})
+
+	db.AddFile(FileInfo{Path: "ok1.txt", Status: StatusSuccess})
+	// 存在しないファイルの更新は失敗するが、同じトランザクションの他の書き込みは反映される
+	if err := db.UpdateFileHash("missing.txt", "a", "b", "sha256"); err == nil {
+		t.Error("存在しないファイルの更新でエラーになりません")
+	}
+	db.AddFile(FileInfo{Path: "ok2.txt", Status: StatusSuccess})
+	// 存在しないファイルの状態更新はレコードを作成する
+	if err := db.UpdateFileStatus("ok3.txt", StatusFailed, "err"); err != nil {
+		t.Errorf("UpdateFileStatus() error = %v", err)
+	}
+
+	if err := db.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	for _, path := range []string{"ok1.txt", "ok2.txt", "ok3.txt"} {
+		if _, err := db.GetFile(path); err != nil {
+			t.Errorf("%s が記録されていません: %v", path, err)
+		}
+	}
+
+	// 非同期の書き込みのエラーはコールバックに通知される
+	err = db.db.Update(func(tx *bbolt.Tx) error {
+		return tx.DeleteBucket(fileSyncBucket)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.AddFile(FileInfo{Path: "lost.txt"})
+	db.Flush()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(asyncErrs) != 1 {
+		t.Errorf("通知されたエラー = %v, want 1件", asyncErrs)
+	}
+	if qs := db.WriteQueueStats(); qs.Failed != 2 {
+		t.Errorf("Failed = %d, want 2", qs.Failed)
+	}
+}
+
+func TestWriteQueue_DisableAndClose(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	db, err := NewSyncDB(dbPath, NormalSync)
+	if err != nil {
+		t.Fatalf("データベース作成が失敗: %v", err)
+	}
+
+	db.EnableWriteQueue(8, nil)
+	for i := 0; i < 50; i++ {
+		db.AddFile(FileInfo{Path: fmt.Sprintf("a%d.txt", i)})
+	}
+
+	// 無効にすると積まれた書き込みが反映され、以降は同期的に書き込まれる
+	qs := db.DisableWriteQueue()
+	if qs == nil || qs.Committed != 50 {
+		t.Errorf("DisableWriteQueue() = %+v", qs)
+	}
+	if db.WriteQueueStats() != nil {
+		t.Error("無効にした後も統計情報が返されます")
+	}
+	if err := db.AddFile(FileInfo{Path: "sync.txt"}); err != nil {
+		t.Errorf("AddFile() error = %v", err)
+	}
+
+	// 閉じるときにも積まれた書き込みが反映される
+	db.EnableWriteQueue(8, nil)
+	for i := 0; i < 50; i++ {
+		db.AddFile(FileInfo{Path: fmt.Sprintf("b%d.txt", i)})
+	}
+	db.Close()
+
+	db, err = NewSyncDB(dbPath, NormalSync)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if count, _ := db.CountFiles(); count != 101 {
+		t.Errorf("CountFiles() = %d, want 101", count)
+	}
+}
+
+func TestUpdateFileStatus_CreatesMissingRecord(t *testing.T) {
+	db, err := NewSyncDB(filepath.Join(t.TempDir(), "test.db"), NormalSync)
+	if err != nil {
+		t.Fatalf("データベース作成が失敗: %v", err)
+	}
+	defer db.Close()
+
+	// 書き込みキューを使わない場合も、トランザクション内で作成される
+	if err := db.UpdateFileStatus(`dir\new.txt`, StatusFailed, "err"); err != nil {
+		t.Fatalf("UpdateFileStatus() error = %v", err)
+	}
+	f, err := db.GetFile("dir/new.txt")
+	if err != nil {
+		t.Fatalf("作成されたレコードが取得できません: %v", err)
+	}
+	if f.Status != StatusFailed || f.LastError != "err" {
+		t.Errorf("記録内容が期待値と異なります: %+v", f)
+	}
+}
+
+func TestWriteQueue_ReadYourWrites(t *testing.T) {
+	db, err := NewSyncDB(filepath.Join(t.TempDir(), "test.db"), NormalSync)
+	if err != nil {
+		t.Fatalf("データベース作成が失敗: %v", err)
+	}
+	defer db.Close()
+	db.EnableWriteQueue(64, nil)
+
+	for i := 0; i < 100; i++ {
+		path := fmt.Sprintf(`dir\file%d.txt`, i)
+		db.AddFile(FileInfo{Path: path, Size: int64(i)})
+
+		// 積んだ直後でも、同じファイルの読み込みには反映されている
+		f, err := db.GetFile(path)
+		if err != nil {
+			t.Fatalf("GetFile(%s) error = %v", path, err)
+		}
+		if f.Size != int64(i) {
+			t.Errorf("Size = %d, want %d", f.Size, i)
+		}
+	}
+}
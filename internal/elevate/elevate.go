@@ -0,0 +1,55 @@
+// Package elevate は管理者権限の確認と、管理者権限での再起動を扱う
+package elevate
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrRelaunchUnsupported は管理者権限での自動再起動に対応していない環境で返される
+var ErrRelaunchUnsupported = errors.New("この環境では管理者権限での再起動に対応していません")
+
+// StripFlag は引数から指定した真偽値フラグ（--name, --name=true など）を取り除く
+func StripFlag(args []string, name string) []string {
+	flag := "--" + name
+	result := make([]string, 0, len(args))
+	for i, arg := range args {
+		if arg == "--" {
+			// 以降はフラグではない
+			result = append(result, args[i:]...)
+			break
+		}
+		if arg == flag || strings.HasPrefix(arg, flag+"=") {
+			continue
+		}
+		result = append(result, arg)
+	}
+	return result
+}
+
+// SudoCommand は管理者権限で同じ処理を実行するためのsudoコマンドラインを返す
+func SudoCommand(exe string, args []string) string {
+	parts := []string{"sudo", shellQuote(exe)}
+	for _, arg := range args {
+		parts = append(parts, shellQuote(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// shellQuote はPOSIXシェルでそのまま渡せるように引数を引用符で囲む
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	safe := true
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=,@%+", r)) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,40 @@
+package elevate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStripFlag(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"なし", []string{"-s", "src", "-d", "dst"}, []string{"-s", "src", "-d", "dst"}},
+		{"フラグのみ", []string{"-s", "src", "--elevate", "-d", "dst"}, []string{"-s", "src", "-d", "dst"}},
+		{"値付き", []string{"--elevate=true", "-s", "src"}, []string{"-s", "src"}},
+		{"似た名前は残す", []string{"--elevated", "x"}, []string{"--elevated", "x"}},
+		{"--以降は残す", []string{"--elevate", "--", "--elevate"}, []string{"--", "--elevate"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StripFlag(tt.args, "elevate"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("StripFlag() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSudoCommand(t *testing.T) {
+	got := SudoCommand("/usr/local/bin/gopier", []string{"-s", "/data/my files", "-d", "/mnt/it's", "--preserve-permissions"})
+	want := `sudo /usr/local/bin/gopier -s '/data/my files' -d '/mnt/it'\''s' --preserve-permissions`
+	if got != want {
+		t.Errorf("SudoCommand() = %s, want %s", got, want)
+	}
+
+	if got := SudoCommand("gopier", []string{""}); got != "sudo gopier ''" {
+		t.Errorf("空の引数 = %s", got)
+	}
+}
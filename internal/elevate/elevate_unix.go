@@ -0,0 +1,16 @@
+//go:build !windows
+
+package elevate
+
+import "os"
+
+// IsElevated はroot権限で実行されているかどうかを返す
+func IsElevated() bool {
+	return os.Geteuid() == 0
+}
+
+// Relaunch はUnix系OSでは対応していないため、常にErrRelaunchUnsupportedを返す
+// 呼び出し側はSudoCommandで実行方法を案内する
+func Relaunch(exe string, args []string) error {
+	return ErrRelaunchUnsupported
+}
@@ -0,0 +1,43 @@
+//go:build windows
+
+package elevate
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// IsElevated はUACで昇格した管理者権限で実行されているかどうかを返す
+func IsElevated() bool {
+	return windows.GetCurrentProcessToken().IsElevated()
+}
+
+// Relaunch はUACの確認画面を表示して、同じ引数で管理者として起動し直す
+// 起動したプロセスは新しいコンソールで実行され、終了を待たずに戻る
+func Relaunch(exe string, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	verb, _ := windows.UTF16PtrFromString("runas")
+	file, err := windows.UTF16PtrFromString(exe)
+	if err != nil {
+		return err
+	}
+	params, err := windows.UTF16PtrFromString(windows.ComposeCommandLine(args))
+	if err != nil {
+		return err
+	}
+	dir, err := windows.UTF16PtrFromString(cwd)
+	if err != nil {
+		return err
+	}
+
+	if err := windows.ShellExecute(0, verb, file, params, dir, windows.SW_SHOWNORMAL); err != nil {
+		return fmt.Errorf("管理者権限での起動に失敗: %w", err)
+	}
+	return nil
+}
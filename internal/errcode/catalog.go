@@ -0,0 +1,159 @@
+package errcode
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// 失敗した操作を表すエラー（Catalogの判定に使用する）
+var (
+	ErrSourceRead = errors.New("ソースを読み込めません")
+	ErrDestRead   = errors.New("宛先を読み込めません")
+	ErrDestWrite  = errors.New("宛先に書き込めません")
+)
+
+// Catalog はDBやレポートに記録する、失敗の原因を表す安定したエラーコード
+// 値はバージョンをまたいで変更しないため、集計やアラートの条件に使用できる
+type Catalog string
+
+const (
+	EUnknown       Catalog = "E_UNKNOWN"
+	ECancelled     Catalog = "E_CANCELLED"
+	ETimeout       Catalog = "E_TIMEOUT"
+	ESrcMissing    Catalog = "E_SRC_MISSING"
+	ESrcRead       Catalog = "E_SRC_READ"
+	EDstMissing    Catalog = "E_DST_MISSING"
+	EDstRead       Catalog = "E_DST_READ"
+	EDstWrite      Catalog = "E_DST_WRITE"
+	EHashMismatch  Catalog = "E_HASH_MISMATCH"
+	ESizeMismatch  Catalog = "E_SIZE_MISMATCH"
+	EOwnerMismatch Catalog = "E_OWNER_MISMATCH"
+	EMTimeMismatch Catalog = "E_MTIME_MISMATCH"
+	ELinkMismatch  Catalog = "E_LINK_MISMATCH"
+	EVerifyFailed  Catalog = "E_VERIFY_FAILED"
+	EACLCopy       Catalog = "E_ACL_COPY"
+	EConflict      Catalog = "E_CONFLICT"
+	ELocked        Catalog = "E_LOCKED"
+	EPreflight     Catalog = "E_PREFLIGHT"
+	EReadOnly      Catalog = "E_READ_ONLY"
+	EDatabase      Catalog = "E_DB"
+)
+
+// catalog はエラーとエラーコードの対応（より具体的なものを先に並べる）
+var catalog = []struct {
+	err  error
+	code Catalog
+}{
+	{context.DeadlineExceeded, ETimeout},
+	{os.ErrDeadlineExceeded, ETimeout},
+	{ErrCancelled, ECancelled},
+	{context.Canceled, ECancelled},
+	{ErrSourceMissing, ESrcMissing},
+	{ErrDatabaseLocked, EDatabase},
+	{ErrDatabase, EDatabase},
+	{ErrReadOnly, EReadOnly},
+	{ErrPreflight, EPreflight},
+	{ErrPermissionCopy, EACLCopy},
+	{ErrConflict, EConflict},
+	{ErrLocked, ELocked},
+	{ErrHashMismatch, EHashMismatch},
+	{ErrSizeMismatch, ESizeMismatch},
+	{ErrOwnerMismatch, EOwnerMismatch},
+	{ErrModTimeMismatch, EMTimeMismatch},
+	{ErrLinkMismatch, ELinkMismatch},
+	{ErrDestMissing, EDstMissing},
+	{ErrVerifyFailed, EVerifyFailed},
+	{ErrSourceRead, ESrcRead},
+	{ErrDestRead, EDstRead},
+	{ErrDestWrite, EDstWrite},
+}
+
+// CatalogOf はエラーに対応するエラーコードを返す（errがnilの場合は空文字列）
+func CatalogOf(err error) Catalog {
+	if err == nil {
+		return ""
+	}
+	for _, c := range catalog {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	// ネットワーク上のファイルシステムなどのタイムアウト（ETIMEDOUTなど）
+	var timeout interface{ Timeout() bool }
+	if errors.As(err, &timeout) && timeout.Timeout() {
+		return ETimeout
+	}
+	return EUnknown
+}
+
+// Detail はDBやレポートに記録する、失敗の機械可読な情報
+// 利用者向けのメッセージ（LastErrorなど）とは別に記録し、プログラムからはこちらを使用する
+type Detail struct {
+	Code    Catalog `json:"code"`              // エラーコード
+	Op      string  `json:"op,omitempty"`      // 失敗したシステムコール・操作（open, read, writeなど）
+	Errno   int     `json:"errno,omitempty"`   // OSのエラー番号（OSのエラーでない場合は0）
+	Retries int     `json:"retries,omitempty"` // 失敗するまでに再試行した回数
+}
+
+// Describe はエラーの機械可読な情報を返す（errがnilの場合はnil）
+func Describe(err error) *Detail {
+	if err == nil {
+		return nil
+	}
+	d := &Detail{Code: CatalogOf(err)}
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		d.Errno = int(errno)
+	}
+	var pathErr *fs.PathError
+	var linkErr *os.LinkError
+	var syscallErr *os.SyscallError
+	switch {
+	case errors.As(err, &pathErr):
+		d.Op = pathErr.Op
+	case errors.As(err, &linkErr):
+		d.Op = linkErr.Op
+	case errors.As(err, &syscallErr):
+		d.Op = syscallErr.Syscall
+	}
+	var retried *retriedError
+	if errors.As(err, &retried) {
+		d.Retries = retried.retries
+	}
+	return d
+}
+
+// retriedError は再試行しても失敗したエラー（メッセージは元のエラーのまま）
+type retriedError struct {
+	err     error
+	retries int
+}
+
+func (e *retriedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retriedError) Unwrap() error {
+	return e.err
+}
+
+// WithRetries はerrに失敗するまでに再試行した回数を付ける（メッセージは変更しない）
+// errがnilの場合と再試行していない場合はerrをそのまま返す
+func WithRetries(err error, retries int) error {
+	if err == nil || retries <= 0 {
+		return err
+	}
+	return &retriedError{err: err, retries: retries}
+}
+
+// IsOSError はerrがファイル操作やシステムコールのエラーを含むかどうかを返す
+func IsOSError(err error) bool {
+	var pathErr *fs.PathError
+	var linkErr *os.LinkError
+	var syscallErr *os.SyscallError
+	var errno syscall.Errno
+	return errors.As(err, &pathErr) || errors.As(err, &linkErr) || errors.As(err, &syscallErr) || errors.As(err, &errno)
+}
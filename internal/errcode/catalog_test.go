@@ -0,0 +1,69 @@
+package errcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestCatalogOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Catalog
+	}{
+		{"nil", nil, ""},
+		{"分類なし", errors.New("エラー"), EUnknown},
+		{"ソースの読み込み", Wrap(ErrSourceRead, errors.New("I/Oエラー")), ESrcRead},
+		{"宛先への書き込み", fmt.Errorf("コピーエラー: %w", Wrap(ErrDestWrite, errors.New("ディスクがいっぱいです"))), EDstWrite},
+		{"アクセス権", Wrap(ErrPermissionCopy, errors.New("拒否")), EACLCopy},
+		{"ハッシュ不一致", Errorf(ErrHashMismatch, "不一致"), EHashMismatch},
+		// 読み込み中のタイムアウトはタイムアウトとして扱う
+		{"タイムアウト", Wrap(ErrSourceRead, os.ErrDeadlineExceeded), ETimeout},
+		{"OSのタイムアウト", &fs.PathError{Op: "read", Path: "a", Err: syscall.ETIMEDOUT}, ETimeout},
+		{"キャンセル", context.Canceled, ECancelled},
+		// 使用中のファイルは読み込みの失敗より優先する
+		{"使用中", Wrap(ErrSourceRead, Wrap(ErrLocked, errors.New("sharing violation"))), ELocked},
+		{"読み取り専用", Wrap(ErrPreflight, Wrap(ErrReadOnly, errors.New("read-only file system"))), EReadOnly},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CatalogOf(tt.err); got != tt.want {
+				t.Errorf("CatalogOf() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	if Describe(nil) != nil {
+		t.Error("Describe(nil)はnilを返すべき")
+	}
+
+	base := &fs.PathError{Op: "write", Path: "/dest/a.txt", Err: syscall.ENOSPC}
+	err := WithRetries(fmt.Errorf("コピーエラー: %w", Wrap(ErrDestWrite, base)), 3)
+
+	// メッセージは元のエラーのまま
+	if err.Error() != "コピーエラー: "+base.Error() {
+		t.Errorf("Error() = %q", err.Error())
+	}
+	want := Detail{Code: EDstWrite, Op: "write", Errno: int(syscall.ENOSPC), Retries: 3}
+	if got := Describe(err); *got != want {
+		t.Errorf("Describe() = %+v, want %+v", *got, want)
+	}
+
+	// OSのエラーでない場合は操作とエラー番号を記録しない
+	want = Detail{Code: EHashMismatch}
+	if got := Describe(Errorf(ErrHashMismatch, "不一致")); *got != want {
+		t.Errorf("Describe() = %+v, want %+v", *got, want)
+	}
+
+	if WithRetries(base, 0) != error(base) || WithRetries(nil, 1) != nil {
+		t.Error("再試行していない場合はエラーをそのまま返すべき")
+	}
+}
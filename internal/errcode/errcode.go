@@ -0,0 +1,147 @@
+// Package errcode は処理の失敗を種類ごとに判別するためのエラーとコードを定義する
+// エラーメッセージは利用者向けに日本語で組み立てるため、プログラムから判別する場合は
+// メッセージではなくerrors.Isとこのパッケージのエラー、またはCodeの値を使用する
+package errcode
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 失敗の種類を表すエラー
+var (
+	ErrSourceMissing   = errors.New("ソースが存在しません")
+	ErrDestMissing     = errors.New("宛先が存在しません")
+	ErrHashMismatch    = errors.New("ハッシュ値が一致しません")
+	ErrSizeMismatch    = errors.New("ファイルサイズが一致しません")
+	ErrOwnerMismatch   = errors.New("所有者が一致しません")
+	ErrModTimeMismatch = errors.New("更新日時が一致しません")
+	ErrLinkMismatch    = errors.New("リンク先が一致しません")
+	ErrVerifyFailed    = errors.New("検証で不一致が検出されました")
+	ErrPermissionCopy  = errors.New("アクセス権をコピーできません")
+	ErrConflict        = errors.New("宛先の方が新しいファイルです")
+	ErrLocked          = errors.New("ファイルは他のプロセスが使用中です")
+	ErrCancelled       = errors.New("処理がキャンセルされました")
+	ErrPreflight       = errors.New("宛先で必要な操作ができません")
+	ErrReadOnly        = errors.New("宛先は読み取り専用でマウントされています")
+	ErrDatabase        = errors.New("データベースエラー")
+	ErrDatabaseLocked  = errors.New("データベースは使用中です")
+)
+
+// Code はJSON出力などで使用するエラーの種類を表す文字列
+type Code string
+
+const (
+	CodeNone            Code = ""
+	CodeUnknown         Code = "error"
+	CodeSourceMissing   Code = "source_missing"
+	CodeDestMissing     Code = "dest_missing"
+	CodeHashMismatch    Code = "hash_mismatch"
+	CodeSizeMismatch    Code = "size_mismatch"
+	CodeOwnerMismatch   Code = "owner_mismatch"
+	CodeModTimeMismatch Code = "mtime_mismatch"
+	CodeLinkMismatch    Code = "link_mismatch"
+	CodeVerifyFailed    Code = "verify_failed"
+	CodePermissionCopy  Code = "permission_copy"
+	CodeConflict        Code = "conflict"
+	CodeLocked          Code = "locked"
+	CodeCancelled       Code = "cancelled"
+	CodePreflight       Code = "preflight"
+	CodeReadOnly        Code = "read_only"
+	CodeDatabase        Code = "database"
+	CodeDatabaseLocked  Code = "database_locked"
+)
+
+// CLIの終了コード
+const (
+	ExitOK             = 0
+	ExitError          = 1   // 分類されないエラー
+	ExitFilesFailed    = 2   // 一部のファイルの処理に失敗した
+	ExitSourceMissing  = 3   // ソースが存在しない
+	ExitVerifyFailed   = 4   // 検証で不一致が検出された
+	ExitPermissionCopy = 5   // アクセス権をコピーできない
+	ExitConflict       = 6   // 宛先の方が新しいファイルがある（--conflict error）
+	ExitDatabase       = 7   // データベースを使用できない
+	ExitPreflight      = 8   // 事前確認で宛先に必要な操作ができない
+	ExitLocked         = 9   // 他のプロセスが使用中のファイルがある
+	ExitReadOnly       = 10  // 宛先が読み取り専用でマウントされている
+	ExitCancelled      = 130 // キャンセルされた
+)
+
+// kinds はエラーとコード・終了コードの対応（より具体的なものを先に並べる）
+var kinds = []struct {
+	err  error
+	code Code
+	exit int
+}{
+	{ErrCancelled, CodeCancelled, ExitCancelled},
+	{ErrSourceMissing, CodeSourceMissing, ExitSourceMissing},
+	{ErrDatabaseLocked, CodeDatabaseLocked, ExitDatabase},
+	{ErrDatabase, CodeDatabase, ExitDatabase},
+	{ErrReadOnly, CodeReadOnly, ExitReadOnly},
+	{ErrPreflight, CodePreflight, ExitPreflight},
+	{ErrPermissionCopy, CodePermissionCopy, ExitPermissionCopy},
+	{ErrConflict, CodeConflict, ExitConflict},
+	{ErrLocked, CodeLocked, ExitLocked},
+	{ErrHashMismatch, CodeHashMismatch, ExitVerifyFailed},
+	{ErrSizeMismatch, CodeSizeMismatch, ExitVerifyFailed},
+	{ErrOwnerMismatch, CodeOwnerMismatch, ExitVerifyFailed},
+	{ErrModTimeMismatch, CodeModTimeMismatch, ExitVerifyFailed},
+	{ErrLinkMismatch, CodeLinkMismatch, ExitVerifyFailed},
+	{ErrDestMissing, CodeDestMissing, ExitVerifyFailed},
+	{ErrVerifyFailed, CodeVerifyFailed, ExitVerifyFailed},
+}
+
+// codedError は失敗の種類を持つエラー（メッセージは元のエラーのまま）
+type codedError struct {
+	kind error
+	err  error
+}
+
+func (e *codedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *codedError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// Wrap はerrにkindの種類を付ける（メッセージは変更しない）
+// errがnilの場合はnilを返す
+func Wrap(kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &codedError{kind: kind, err: err}
+}
+
+// Errorf はfmt.Errorfで作成したエラーにkindの種類を付ける
+func Errorf(kind error, format string, args ...interface{}) error {
+	return Wrap(kind, fmt.Errorf(format, args...))
+}
+
+// Of はエラーの種類を表すコードを返す（errがnilの場合はCodeNone）
+func Of(err error) Code {
+	if err == nil {
+		return CodeNone
+	}
+	for _, k := range kinds {
+		if errors.Is(err, k.err) {
+			return k.code
+		}
+	}
+	return CodeUnknown
+}
+
+// ExitCode はエラーに対応するCLIの終了コードを返す（errがnilの場合はExitOK）
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	for _, k := range kinds {
+		if errors.Is(err, k.err) {
+			return k.exit
+		}
+	}
+	return ExitError
+}
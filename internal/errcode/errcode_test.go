@@ -0,0 +1,59 @@
+package errcode
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestWrap(t *testing.T) {
+	base := errors.New("元のエラー")
+	err := Wrap(ErrHashMismatch, base)
+
+	// メッセージは元のエラーのまま
+	if err.Error() != "元のエラー" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+	if !errors.Is(err, ErrHashMismatch) || !errors.Is(err, base) {
+		t.Error("種類と元のエラーの両方を判別できるべき")
+	}
+
+	if Wrap(ErrHashMismatch, nil) != nil {
+		t.Error("Wrap(kind, nil)はnilを返すべき")
+	}
+}
+
+func TestOfAndExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code Code
+		exit int
+	}{
+		{"nil", nil, CodeNone, ExitOK},
+		{"分類なし", errors.New("エラー"), CodeUnknown, ExitError},
+		{"ソースなし", Errorf(ErrSourceMissing, "ソースがありません"), CodeSourceMissing, ExitSourceMissing},
+		{"ハッシュ不一致", Errorf(ErrHashMismatch, "不一致"), CodeHashMismatch, ExitVerifyFailed},
+		{"アクセス権", Wrap(ErrPermissionCopy, errors.New("拒否")), CodePermissionCopy, ExitPermissionCopy},
+		{"キャンセル", Errorf(ErrCancelled, "キャンセル"), CodeCancelled, ExitCancelled},
+		{"ロック", Errorf(ErrDatabaseLocked, "使用中"), CodeDatabaseLocked, ExitDatabase},
+		{"使用中のファイル", Wrap(ErrLocked, errors.New("sharing violation")), CodeLocked, ExitLocked},
+		// fmt.Errorfで包んでも判別できる
+		{"包んだエラー", fmt.Errorf("ソースディレクトリの確認エラー: %w", Wrap(ErrSourceMissing, errors.New("not found"))), CodeSourceMissing, ExitSourceMissing},
+		// 事前確認中のアクセス権のエラーは事前確認のエラーとして扱う
+		{"複数の種類", Wrap(ErrPreflight, Wrap(ErrPermissionCopy, errors.New("拒否"))), CodePreflight, ExitPreflight},
+		// 読み取り専用の宛先は事前確認のエラーより優先する
+		{"読み取り専用", Wrap(ErrPreflight, Wrap(ErrReadOnly, errors.New("read-only file system"))), CodeReadOnly, ExitReadOnly},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Of(tt.err); got != tt.code {
+				t.Errorf("Of() = %q, want %q", got, tt.code)
+			}
+			if got := ExitCode(tt.err); got != tt.exit {
+				t.Errorf("ExitCode() = %d, want %d", got, tt.exit)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+// Package faultinject はリトライ・即時停止・修復などの動作を確認するため、
+// コピーと検証に擬似的な障害（読み込みエラー・遅いI/O・ハッシュ不一致）を発生させる
+// 障害はファイルごとに指定した確率で発生し、nilのInjectorは何もしない
+package faultinject
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/units"
+)
+
+// ErrInjected は障害注入によって発生させた読み込みエラー
+var ErrInjected = errors.New("障害注入による読み込みエラー")
+
+// DefaultSlowDelay は遅いI/Oを発生させる場合の読み込みごとの待ち時間のデフォルト値
+const DefaultSlowDelay = 100 * time.Millisecond
+
+// Counts は発生させた障害の件数
+type Counts struct {
+	ReadErrors     int64
+	SlowReads      int64
+	HashMismatches int64
+}
+
+// Injector は指定した確率で障害を発生させる
+type Injector struct {
+	readErrorRate    float64
+	slowRate         float64
+	slowDelay        time.Duration
+	hashMismatchRate float64
+
+	mu   sync.Mutex
+	rand *rand.Rand
+
+	readErrors     int64
+	slowReads      int64
+	hashMismatches int64
+}
+
+// Parse は障害の指定を解析する（空文字列の場合はnilを返す）
+// 指定はカンマ区切りのkey=valueで、確率は0から1の値で指定する
+//
+//	read-error=0.01     ファイルの読み込みの途中でエラーを発生させる確率
+//	slow=0.05           ファイルの読み込みを遅くする確率
+//	slow-delay=200ms    遅くする場合の読み込みごとの待ち時間（デフォルト: 100ms）
+//	hash-mismatch=0.01  宛先のハッシュ値を不一致にする確率
+//	seed=42             乱数のシード（省略時は実行ごとに異なる）
+func Parse(spec string) (*Injector, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+
+	inj := &Injector{slowDelay: DefaultSlowDelay}
+	seed := time.Now().UnixNano()
+	for _, item := range strings.Split(spec, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			return nil, fmt.Errorf("障害の指定はkey=valueの形式で指定してください: %q", item)
+		}
+
+		var err error
+		switch strings.TrimSpace(key) {
+		case "read-error":
+			inj.readErrorRate, err = parseRate(value)
+		case "slow":
+			inj.slowRate, err = parseRate(value)
+		case "slow-delay":
+			inj.slowDelay, err = units.ParseDuration(value, 0)
+			if err == nil && inj.slowDelay < 0 {
+				err = fmt.Errorf("0以上の値を指定してください")
+			}
+		case "hash-mismatch":
+			inj.hashMismatchRate, err = parseRate(value)
+		case "seed":
+			seed, err = strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		default:
+			return nil, fmt.Errorf("未対応の障害の種類: %s (read-error, slow, slow-delay, hash-mismatch, seedのいずれかを指定してください)", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("障害の指定(%s)が不正です: %w", item, err)
+		}
+	}
+
+	inj.rand = rand.New(rand.NewSource(seed))
+	return inj, nil
+}
+
+// parseRate は0から1の確率を解析する
+func parseRate(value string) (float64, error) {
+	rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0, err
+	}
+	if rate < 0 || rate > 1 {
+		return 0, fmt.Errorf("確率は0から1の値で指定してください: %v", rate)
+	}
+	return rate, nil
+}
+
+// hit は指定した確率で真を返す
+func (inj *Injector) hit(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	return inj.rand.Float64() < rate
+}
+
+// Reader はファイルの読み込みに障害を発生させるReaderを返す
+// 読み込みエラーは最初の読み込みの後に発生させるため、宛先には途中までの内容が書き込まれる
+func (inj *Injector) Reader(r io.Reader) io.Reader {
+	if inj == nil {
+		return r
+	}
+
+	fr := &faultReader{reader: r}
+	if inj.hit(inj.slowRate) {
+		atomic.AddInt64(&inj.slowReads, 1)
+		fr.delay = inj.slowDelay
+	}
+	if inj.hit(inj.readErrorRate) {
+		atomic.AddInt64(&inj.readErrors, 1)
+		fr.failAfter = 1
+	}
+	if fr.delay == 0 && fr.failAfter == 0 {
+		return r
+	}
+	return fr
+}
+
+// CorruptHash は指定した確率でハッシュ値を書き換え、不一致として検出されるようにする
+func (inj *Injector) CorruptHash(hash string) string {
+	if inj == nil || hash == "" || !inj.hit(inj.hashMismatchRate) {
+		return hash
+	}
+	atomic.AddInt64(&inj.hashMismatches, 1)
+
+	// 最後の1文字を別の16進数字に置き換える
+	last := hash[len(hash)-1]
+	replacement := byte('0')
+	if last == '0' {
+		replacement = '1'
+	}
+	return hash[:len(hash)-1] + string(replacement)
+}
+
+// Counts は発生させた障害の件数を返す
+func (inj *Injector) Counts() Counts {
+	if inj == nil {
+		return Counts{}
+	}
+	return Counts{
+		ReadErrors:     atomic.LoadInt64(&inj.readErrors),
+		SlowReads:      atomic.LoadInt64(&inj.slowReads),
+		HashMismatches: atomic.LoadInt64(&inj.hashMismatches),
+	}
+}
+
+// faultReader は読み込みを遅くし、指定した回数の読み込みの後にエラーを返すReader
+type faultReader struct {
+	reader    io.Reader
+	delay     time.Duration
+	failAfter int // この回数の読み込みの後にエラーを返す（0の場合はエラーを返さない）
+	reads     int
+}
+
+// Read はデータを読み込む
+func (r *faultReader) Read(p []byte) (int, error) {
+	if r.failAfter > 0 && r.reads >= r.failAfter {
+		return 0, ErrInjected
+	}
+	r.reads++
+	if r.delay > 0 {
+		time.Sleep(r.delay)
+	}
+
+	n, err := r.reader.Read(p)
+	if err == io.EOF && r.failAfter > 0 {
+		// ファイル全体を1回で読み込んだ場合も、エラーとして終わらせる
+		return n, ErrInjected
+	}
+	return n, err
+}
@@ -0,0 +1,102 @@
+package faultinject
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	inj, err := Parse("")
+	if err != nil || inj != nil {
+		t.Fatalf("Parse(\"\") = %v, %v", inj, err)
+	}
+
+	inj, err = Parse("read-error=0.5, slow=0.25, slow-delay=10ms, hash-mismatch=1, seed=7")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if inj.readErrorRate != 0.5 || inj.slowRate != 0.25 || inj.slowDelay != 10*time.Millisecond || inj.hashMismatchRate != 1 {
+		t.Errorf("Parse() = %+v", inj)
+	}
+
+	for _, spec := range []string{"read-error", "read-error=2", "slow=-0.1", "unknown=1", "slow-delay=abc", "seed=x"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) はエラーになるべき", spec)
+		}
+	}
+}
+
+func TestReader(t *testing.T) {
+	data := strings.Repeat("x", 100)
+
+	// 確率1の場合は必ず途中でエラーになる
+	inj, _ := Parse("read-error=1,seed=1")
+	buf := make([]byte, 10)
+	r := inj.Reader(strings.NewReader(data))
+	if n, err := r.Read(buf); n != 10 || err != nil {
+		t.Errorf("最初の読み込み = %d, %v", n, err)
+	}
+	if _, err := io.ReadAll(r); !errors.Is(err, ErrInjected) {
+		t.Errorf("2回目以降の読み込みはErrInjectedになるべき: %v", err)
+	}
+
+	// 1回で読み終わる場合もエラーになる
+	if _, err := io.ReadAll(inj.Reader(strings.NewReader("a"))); !errors.Is(err, ErrInjected) {
+		t.Errorf("小さなファイルもErrInjectedになるべき: %v", err)
+	}
+
+	// 確率0の場合は元のReaderのまま
+	inj, _ = Parse("read-error=0,seed=1")
+	got, err := io.ReadAll(inj.Reader(strings.NewReader(data)))
+	if err != nil || string(got) != data {
+		t.Errorf("障害なしの読み込み = %d bytes, %v", len(got), err)
+	}
+
+	// nilのInjectorは何もしない
+	var none *Injector
+	if r := bytes.NewReader(nil); none.Reader(r) != r {
+		t.Error("nilのInjectorは元のReaderを返すべき")
+	}
+
+	if c := inj.Counts(); c.ReadErrors != 0 {
+		t.Errorf("Counts() = %+v", c)
+	}
+}
+
+func TestSlowReader(t *testing.T) {
+	inj, _ := Parse("slow=1,slow-delay=20ms,seed=1")
+	start := time.Now()
+	if _, err := io.ReadAll(inj.Reader(strings.NewReader("abc"))); err != nil {
+		t.Fatalf("読み込みエラー: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("読み込みが遅くなっていません: %v", elapsed)
+	}
+	if c := inj.Counts(); c.SlowReads != 1 {
+		t.Errorf("SlowReads = %d, want 1", c.SlowReads)
+	}
+}
+
+func TestCorruptHash(t *testing.T) {
+	inj, _ := Parse("hash-mismatch=1,seed=1")
+	for _, hash := range []string{"abc0", "abc1", "ffff"} {
+		if got := inj.CorruptHash(hash); got == hash || len(got) != len(hash) {
+			t.Errorf("CorruptHash(%q) = %q", hash, got)
+		}
+	}
+	if got := inj.CorruptHash(""); got != "" {
+		t.Errorf("空のハッシュは変更しないべき: %q", got)
+	}
+	if c := inj.Counts(); c.HashMismatches != 3 {
+		t.Errorf("HashMismatches = %d, want 3", c.HashMismatches)
+	}
+
+	var none *Injector
+	if got := none.CorruptHash("abc"); got != "abc" {
+		t.Errorf("nilのInjectorはハッシュを変更しないべき: %q", got)
+	}
+}
@@ -1,6 +1,7 @@
 package filter
 
 import (
+	slashpath "path"
 	"path/filepath"
 	"strings"
 )
@@ -9,6 +10,8 @@ import (
 type Filter struct {
 	includePatterns []string
 	excludePatterns []string
+	excludeDirs     []string // 中身ごと除外するディレクトリ名のパターン（除外プロファイル）
+	checks          []func(path string) bool
 }
 
 // NewFilter は新しいフィルタを作成する
@@ -50,20 +53,38 @@ func (f *Filter) ShouldInclude(path string) bool {
 
 	// 含めるパターンが指定されていない場合は全て含める
 	if len(f.includePatterns) == 0 {
-		return true
+		return f.passesChecks(path)
 	}
 
 	// 含めるパターンのチェック
 	for _, pattern := range f.includePatterns {
 		matched, err := filepath.Match(pattern, filepath.Base(path))
 		if err == nil && matched {
-			return true
+			return f.passesChecks(path)
 		}
 	}
 
 	return false
 }
 
+// AddCheck はパターンに加えて、ファイルを含めるかどうかを判定する関数を追加する（プラグインによる判定など）
+// 関数はパターンで含めると判断したファイルについてのみ呼び出し、いずれかがfalseを返した場合は除外する
+// 複数のワーカーから同時に呼び出されるため、関数は並行に呼び出せる必要がある
+// 判定を始める前に追加する必要がある
+func (f *Filter) AddCheck(check func(path string) bool) {
+	f.checks = append(f.checks, check)
+}
+
+// passesChecks は追加した判定をすべて満たすかどうかを判断する
+func (f *Filter) passesChecks(path string) bool {
+	for _, check := range f.checks {
+		if !check(path) {
+			return false
+		}
+	}
+	return true
+}
+
 // IsExcluded はファイルが除外パターンに一致するかどうかを判断する
 func (f *Filter) IsExcluded(path string) bool {
 	// 除外パターンのチェック
@@ -105,7 +126,7 @@ func (f *Filter) GetExcludePatterns() []string {
 
 // HasPatterns はフィルタにパターンが設定されているかどうかを判断する
 func (f *Filter) HasPatterns() bool {
-	return len(f.includePatterns) > 0 || len(f.excludePatterns) > 0
+	return len(f.includePatterns) > 0 || len(f.excludePatterns) > 0 || len(f.excludeDirs) > 0
 }
 
 // MatchesPath はパスがパターンに一致するかどうかを判断する
@@ -126,3 +147,29 @@ func MatchesPath(path, patterns string) bool {
 
 	return false
 }
+
+// MatchesUnder はパスのいずれかの階層がパターンに一致するかどうかを判断する
+// パターンはカンマ区切りの複数のパターンを含む文字列で、/を含むパターンは連続する階層と比較する
+// 例: "$RECYCLE.BIN,.snapshot,legacy/broken*"
+func MatchesUnder(path, patterns string) bool {
+	if patterns == "" {
+		return false
+	}
+
+	parts := strings.Split(strings.Trim(filepath.ToSlash(path), "/"), "/")
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.Trim(filepath.ToSlash(strings.TrimSpace(pattern)), "/")
+		if pattern == "" {
+			continue
+		}
+		depth := strings.Count(pattern, "/") + 1
+		for i := 0; i+depth <= len(parts); i++ {
+			matched, err := slashpath.Match(pattern, strings.Join(parts[i:i+depth], "/"))
+			if err == nil && matched {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -1,6 +1,7 @@
 package filter
 
 import (
+	"path/filepath"
 	"testing"
 )
 
@@ -397,3 +398,58 @@ func TestMatchesPath(t *testing.T) {
 		})
 	}
 }
+
+func TestMatchesUnder(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		patterns       string
+		expectedResult bool
+	}{
+		{"空のパターン", "a/b.txt", "", false},
+		{"ディレクトリ名に一致", "$RECYCLE.BIN/S-1-5/file.txt", "$RECYCLE.BIN", true},
+		{"途中の階層に一致", "home/.snapshot/daily/file.txt", ".snapshot", true},
+		{"ファイル名に一致", "docs/broken.doc", "broken.*", true},
+		{"一致しない", "docs/file.txt", "$RECYCLE.BIN,.snapshot", false},
+		{"部分一致はしない", "docs/my.snapshot.old/file.txt", ".snapshot", false},
+		{"複数階層のパターン", "data/legacy/broken2019/file.txt", "legacy/broken*", true},
+		{"複数階層のパターンに一致しない", "data/legacy/ok/broken.txt", "legacy/broken*", false},
+		{"Windowsの区切り文字", `data\legacy\broken\file.txt`, "legacy/broken", filepath.Separator == '\\'},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := MatchesUnder(tt.path, tt.patterns); result != tt.expectedResult {
+				t.Errorf("MatchesUnder(%q, %q) = %v, 期待値 %v", tt.path, tt.patterns, result, tt.expectedResult)
+			}
+		})
+	}
+}
+
+func TestAddCheck(t *testing.T) {
+	f := NewFilter("*.txt", "")
+	var checked []string
+	f.AddCheck(func(path string) bool {
+		checked = append(checked, path)
+		return path != "secret.txt"
+	})
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"a.txt", true},
+		{"secret.txt", false},
+		{"b.doc", false},
+	}
+	for _, tt := range tests {
+		if result := f.ShouldInclude(tt.path); result != tt.expected {
+			t.Errorf("ShouldInclude(%q) = %v, 期待値 %v", tt.path, result, tt.expected)
+		}
+	}
+
+	// パターンで除外したファイルは判定の関数を呼び出さない
+	if len(checked) != 2 {
+		t.Errorf("判定したファイル = %v, 期待値 [a.txt secret.txt]", checked)
+	}
+}
@@ -0,0 +1,86 @@
+package filter
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Profile は名前を付けた除外ルールの集合
+// パターンはfilepath.Matchの形式で、ファイル・ディレクトリの名前と比較する
+type Profile struct {
+	Dirs  []string // 中身ごと除外するディレクトリ名のパターン
+	Files []string // 除外するファイル名のパターン
+}
+
+// builtinProfiles は組み込みの除外プロファイル
+// コピーしても意味がなく、ファイル数が多いため走査に時間がかかるディレクトリとファイルを対象にする
+var builtinProfiles = map[string]Profile{
+	// Windowsのごみ箱・システムの管理用ディレクトリとキャッシュ
+	"windows-system": {
+		Dirs:  []string{"$RECYCLE.BIN", "$Recycle.Bin", "RECYCLER", "System Volume Information", "$WINDOWS.~BT", "$WinREAgent"},
+		Files: []string{"Thumbs.db", "ehthumbs.db", "desktop.ini", "pagefile.sys", "hiberfil.sys", "swapfile.sys"},
+	},
+	// 開発プロジェクトの依存パッケージ・バージョン管理・ビルドのキャッシュ
+	"dev-projects": {
+		Dirs:  []string{"node_modules", ".git", ".svn", ".hg", "__pycache__", ".venv", ".tox", ".gradle", ".terraform", ".next"},
+		Files: []string{"*.pyc", "*.pyo"},
+	},
+	// macOSのインデックス・ごみ箱・リソースフォーク
+	"macos": {
+		Dirs:  []string{".Spotlight-V100", ".Trashes", ".fseventsd", ".TemporaryItems", ".DocumentRevisions-V100"},
+		Files: []string{".DS_Store", "._*", ".localized"},
+	},
+}
+
+// ProfileNames は組み込みの除外プロファイルの名前を辞書順に返す
+func ProfileNames() []string {
+	names := make([]string, 0, len(builtinProfiles))
+	for name := range builtinProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResolveProfiles はカンマ区切りの名前で指定された除外プロファイルのルールをまとめて返す
+// customは設定ファイルで定義したプロファイルで、組み込みと同じ名前の場合は組み込みのルールに追加する
+func ResolveProfiles(names string, custom map[string]Profile) (Profile, error) {
+	var result Profile
+	if names == "" {
+		return result, nil
+	}
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		builtin, isBuiltin := builtinProfiles[name]
+		extra, isCustom := custom[name]
+		if !isBuiltin && !isCustom {
+			return Profile{}, fmt.Errorf("不明な除外プロファイル: %s (%sまたは設定ファイルのexclusion_profilesで定義した名前を指定してください)", name, strings.Join(ProfileNames(), ", "))
+		}
+		result.Dirs = append(append(result.Dirs, builtin.Dirs...), extra.Dirs...)
+		result.Files = append(append(result.Files, builtin.Files...), extra.Files...)
+	}
+	return result, nil
+}
+
+// AddProfile は除外プロファイルのルールをフィルタに追加する
+// ファイルのパターンは除外パターンに加え、ディレクトリのパターンに一致するディレクトリは中身ごと走査しない
+func (f *Filter) AddProfile(p Profile) {
+	f.excludePatterns = append(f.excludePatterns, p.Files...)
+	f.excludeDirs = append(f.excludeDirs, p.Dirs...)
+}
+
+// ExcludesDir はディレクトリを中身ごと除外するかどうかを判断する
+func (f *Filter) ExcludesDir(path string) bool {
+	for _, pattern := range f.excludeDirs {
+		matched, err := filepath.Match(pattern, filepath.Base(path))
+		if err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package filter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveProfiles(t *testing.T) {
+	custom := map[string]Profile{
+		"dev-projects": {Dirs: []string{"vendor"}},
+		"media":        {Dirs: []string{"cache"}, Files: []string{"*.tmp"}},
+	}
+
+	p, err := ResolveProfiles("", custom)
+	if err != nil || len(p.Dirs) != 0 || len(p.Files) != 0 {
+		t.Errorf("空の指定 = %+v, %v, 期待値 空のプロファイル", p, err)
+	}
+
+	p, err = ResolveProfiles(" macos , media", custom)
+	if err != nil {
+		t.Fatalf("ResolveProfilesが失敗しました: %v", err)
+	}
+	if !contains(p.Files, ".DS_Store") || !contains(p.Files, "*.tmp") || !contains(p.Dirs, "cache") {
+		t.Errorf("macos,media = %+v, 期待値 組み込みと設定ファイルのルールを含む", p)
+	}
+
+	// 組み込みと同じ名前のプロファイルは組み込みのルールに追加する
+	p, err = ResolveProfiles("dev-projects", custom)
+	if err != nil {
+		t.Fatalf("ResolveProfilesが失敗しました: %v", err)
+	}
+	if !contains(p.Dirs, "node_modules") || !contains(p.Dirs, "vendor") {
+		t.Errorf("dev-projects = %+v, 期待値 node_modulesとvendorを含む", p.Dirs)
+	}
+
+	if _, err := ResolveProfiles("macos,unknown", custom); err == nil || !strings.Contains(err.Error(), "unknown") {
+		t.Errorf("不明なプロファイルのエラー = %v, 期待値 unknownを含むエラー", err)
+	}
+}
+
+func TestProfileNames(t *testing.T) {
+	expected := []string{"dev-projects", "macos", "windows-system"}
+	if names := ProfileNames(); !reflect.DeepEqual(names, expected) {
+		t.Errorf("ProfileNames() = %v, 期待値 %v", names, expected)
+	}
+}
+
+func TestAddProfile(t *testing.T) {
+	f := NewFilter("", "")
+	if f.HasPatterns() {
+		t.Fatal("パターンのないフィルタでHasPatternsがtrueです")
+	}
+	profile, err := ResolveProfiles("windows-system,macos", nil)
+	if err != nil {
+		t.Fatalf("ResolveProfilesが失敗しました: %v", err)
+	}
+	f.AddProfile(profile)
+	if !f.HasPatterns() {
+		t.Error("プロファイルを追加したフィルタでHasPatternsがfalseです")
+	}
+
+	dirs := []struct {
+		path     string
+		expected bool
+	}{
+		{"$RECYCLE.BIN", true},
+		{"data/System Volume Information", true},
+		{"photos/.Trashes", true},
+		{"docs", false},
+		{"my$RECYCLE.BIN", false},
+	}
+	for _, tt := range dirs {
+		if result := f.ExcludesDir(tt.path); result != tt.expected {
+			t.Errorf("ExcludesDir(%q) = %v, 期待値 %v", tt.path, result, tt.expected)
+		}
+	}
+
+	files := []struct {
+		path     string
+		expected bool
+	}{
+		{"photos/Thumbs.db", false},
+		{"docs/.DS_Store", false},
+		{"docs/._report.doc", false},
+		{"docs/report.doc", true},
+	}
+	for _, tt := range files {
+		if result := f.ShouldInclude(tt.path); result != tt.expected {
+			t.Errorf("ShouldInclude(%q) = %v, 期待値 %v", tt.path, result, tt.expected)
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
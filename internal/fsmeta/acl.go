@@ -0,0 +1,91 @@
+package fsmeta
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ACE はアクセス制御エントリを表す構造体
+type ACE struct {
+	// 対象のプリンシパル（WindowsではSID、Unixでは "user:1000" や "other:" など）
+	Principal string `json:"principal"`
+	// エントリの種類（allow, deny, audit など）
+	Type string `json:"type"`
+	// アクセスマスク（Unixではrwxのビット）
+	Mask uint32 `json:"mask"`
+	// 継承フラグ（Unixではデフォルトエントリの場合に1）
+	Flags uint32 `json:"flags,omitempty"`
+}
+
+// String はエントリを比較・表示用の文字列にする
+func (a ACE) String() string {
+	s := fmt.Sprintf("%s %s 0x%08x", a.Type, a.Principal, a.Mask)
+	if a.Flags != 0 {
+		s += fmt.Sprintf(" flags=0x%02x", a.Flags)
+	}
+	return s
+}
+
+// ACL はファイルの所有者とアクセス制御リストを表す構造体
+type ACL struct {
+	Owner   string `json:"owner"`
+	Entries []ACE  `json:"entries"`
+}
+
+// ACLDiff は2つのACLの差分を表す構造体
+type ACLDiff struct {
+	OwnerFrom string `json:"owner_from,omitempty"`
+	OwnerTo   string `json:"owner_to,omitempty"`
+	Added     []ACE  `json:"added,omitempty"`   // 宛先にのみ存在するエントリ
+	Removed   []ACE  `json:"removed,omitempty"` // ソースにのみ存在するエントリ
+}
+
+// OwnerChanged は所有者が異なるかどうかを返す
+func (d ACLDiff) OwnerChanged() bool {
+	return d.OwnerFrom != d.OwnerTo
+}
+
+// Empty は差分がないかどうかを返す
+func (d ACLDiff) Empty() bool {
+	return !d.OwnerChanged() && len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// DiffACL はソースと宛先のACLを比較する
+// エントリの順序は比較せず、同じエントリが複数ある場合はその個数も比較する
+func DiffACL(source, dest *ACL) ACLDiff {
+	var diff ACLDiff
+	if source.Owner != dest.Owner {
+		diff.OwnerFrom = source.Owner
+		diff.OwnerTo = dest.Owner
+	}
+
+	remaining := make(map[string]int)
+	for _, ace := range dest.Entries {
+		remaining[ace.String()]++
+	}
+	for _, ace := range source.Entries {
+		key := ace.String()
+		if remaining[key] > 0 {
+			remaining[key]--
+			continue
+		}
+		diff.Removed = append(diff.Removed, ace)
+	}
+	for _, ace := range dest.Entries {
+		key := ace.String()
+		if remaining[key] > 0 {
+			remaining[key]--
+			diff.Added = append(diff.Added, ace)
+		}
+	}
+
+	sortACEs(diff.Added)
+	sortACEs(diff.Removed)
+	return diff
+}
+
+func sortACEs(entries []ACE) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].String() < entries[j].String()
+	})
+}
@@ -0,0 +1,96 @@
+package fsmeta
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"syscall"
+)
+
+// aclXattrs はPOSIX ACLが格納される拡張属性
+var aclXattrs = []string{"system.posix_acl_access", "system.posix_acl_default"}
+
+// aclDigest はPOSIX ACLの拡張属性からダイジェストを計算する
+// ACLが設定されていない場合や取得できない場合は空文字列を返す
+func aclDigest(path string) string {
+	h := sha256.New()
+	found := false
+	buf := make([]byte, 4096)
+
+	for _, name := range aclXattrs {
+		n, err := syscall.Getxattr(path, name, buf)
+		if err != nil || n <= 0 {
+			continue
+		}
+		found = true
+		h.Write([]byte(name))
+		h.Write(buf[:n])
+	}
+
+	if !found {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// POSIX ACLの拡張属性のタグ
+const (
+	posixACLVersion  = 2
+	posixACLUserObj  = 0x01
+	posixACLUser     = 0x02
+	posixACLGroupObj = 0x04
+	posixACLGroup    = 0x08
+	posixACLMask     = 0x10
+	posixACLOther    = 0x20
+)
+
+// readPOSIXACL はPOSIX ACLの拡張属性を読み込み、エントリに変換する
+// 拡張属性がない場合や形式が不正な場合はfalseを返す
+func readPOSIXACL(path, name string, flags uint32) ([]ACE, bool) {
+	// エントリの多いACLも読み込めるよう、値のサイズを取得してから読み込む
+	size, err := syscall.Getxattr(path, name, nil)
+	if err != nil || size < 4 {
+		return nil, false
+	}
+	buf := make([]byte, size)
+	n, err := syscall.Getxattr(path, name, buf)
+	if err != nil || n < 4 {
+		return nil, false
+	}
+	return parsePOSIXACL(buf[:n], flags)
+}
+
+// parsePOSIXACL はPOSIX ACLの拡張属性の値（4バイトのバージョンと8バイトのエントリの並び）を解析する
+func parsePOSIXACL(data []byte, flags uint32) ([]ACE, bool) {
+	if len(data) < 4 || (len(data)-4)%8 != 0 || binary.LittleEndian.Uint32(data) != posixACLVersion {
+		return nil, false
+	}
+
+	var entries []ACE
+	for off := 4; off < len(data); off += 8 {
+		tag := binary.LittleEndian.Uint16(data[off:])
+		perm := binary.LittleEndian.Uint16(data[off+2:])
+		id := binary.LittleEndian.Uint32(data[off+4:])
+
+		var principal string
+		switch tag {
+		case posixACLUserObj:
+			principal = "user:"
+		case posixACLUser:
+			principal = fmt.Sprintf("user:%d", id)
+		case posixACLGroupObj:
+			principal = "group:"
+		case posixACLGroup:
+			principal = fmt.Sprintf("group:%d", id)
+		case posixACLMask:
+			principal = "mask:"
+		case posixACLOther:
+			principal = "other:"
+		default:
+			principal = fmt.Sprintf("tag%d:%d", tag, id)
+		}
+		entries = append(entries, ACE{Principal: principal, Type: "allow", Mask: uint32(perm), Flags: flags})
+	}
+	return entries, true
+}
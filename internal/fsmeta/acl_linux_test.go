@@ -0,0 +1,87 @@
+package fsmeta
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestParsePOSIXACL(t *testing.T) {
+	entry := func(tag, perm uint16, id uint32) []byte {
+		b := make([]byte, 8)
+		binary.LittleEndian.PutUint16(b, tag)
+		binary.LittleEndian.PutUint16(b[2:], perm)
+		binary.LittleEndian.PutUint32(b[4:], id)
+		return b
+	}
+
+	data := []byte{2, 0, 0, 0}
+	data = append(data, entry(posixACLUserObj, 6, 0xffffffff)...)
+	data = append(data, entry(posixACLUser, 4, 1001)...)
+	data = append(data, entry(posixACLGroupObj, 4, 0xffffffff)...)
+	data = append(data, entry(posixACLMask, 4, 0xffffffff)...)
+	data = append(data, entry(posixACLOther, 0, 0xffffffff)...)
+
+	entries, ok := parsePOSIXACL(data, 1)
+	if !ok {
+		t.Fatal("解析に失敗しました")
+	}
+	want := []string{"user:", "user:1001", "group:", "mask:", "other:"}
+	if len(entries) != len(want) {
+		t.Fatalf("エントリ数 = %d, want %d", len(entries), len(want))
+	}
+	for i, principal := range want {
+		if entries[i].Principal != principal || entries[i].Flags != 1 {
+			t.Errorf("entries[%d] = %+v, want principal %s", i, entries[i], principal)
+		}
+	}
+	if entries[1].Mask != 4 {
+		t.Errorf("user:1001のMask = %d, want 4", entries[1].Mask)
+	}
+
+	if _, ok := parsePOSIXACL([]byte{1, 0, 0, 0}, 0); ok {
+		t.Error("未知のバージョンを解析しました")
+	}
+	if _, ok := parsePOSIXACL(data[:10], 0); ok {
+		t.Error("途中で切れたデータを解析しました")
+	}
+}
+
+func TestReadPOSIXACL_Large(t *testing.T) {
+	entry := func(tag, perm uint16, id uint32) []byte {
+		b := make([]byte, 8)
+		binary.LittleEndian.PutUint16(b, tag)
+		binary.LittleEndian.PutUint16(b[2:], perm)
+		binary.LittleEndian.PutUint32(b[4:], id)
+		return b
+	}
+
+	// 4096バイトを超えるACL（520ユーザー分のエントリ）
+	const users = 520
+	data := []byte{2, 0, 0, 0}
+	data = append(data, entry(posixACLUserObj, 6, 0xffffffff)...)
+	for i := 0; i < users; i++ {
+		data = append(data, entry(posixACLUser, 4, uint32(10000+i))...)
+	}
+	data = append(data, entry(posixACLGroupObj, 4, 0xffffffff)...)
+	data = append(data, entry(posixACLMask, 4, 0xffffffff)...)
+	data = append(data, entry(posixACLOther, 0, 0xffffffff)...)
+
+	path := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(path, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Setxattr(path, "system.posix_acl_access", data, 0); err != nil {
+		t.Skipf("このファイルシステムでは大きいACLを設定できません: %v", err)
+	}
+
+	entries, ok := readPOSIXACL(path, "system.posix_acl_access", 0)
+	if !ok {
+		t.Fatal("大きいACLを読み込めませんでした")
+	}
+	if len(entries) != users+4 {
+		t.Errorf("エントリ数 = %d, want %d", len(entries), users+4)
+	}
+}
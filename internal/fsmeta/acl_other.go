@@ -0,0 +1,13 @@
+//go:build !linux
+
+package fsmeta
+
+// aclDigest はACLの取得に対応していない環境では空文字列を返す
+func aclDigest(path string) string {
+	return ""
+}
+
+// readPOSIXACL はPOSIX ACLの取得に対応していない環境では常にfalseを返す
+func readPOSIXACL(path, name string, flags uint32) ([]ACE, bool) {
+	return nil, false
+}
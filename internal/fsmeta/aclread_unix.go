@@ -0,0 +1,41 @@
+//go:build !windows
+
+package fsmeta
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// ReadACL はファイルの所有者とアクセス制御リストを取得する
+// POSIX ACLが設定されていない場合は、パーミッションから最小のACLを組み立てる
+func ReadACL(path string) (*ACL, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	acl := &ACL{}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		acl.Owner = fmt.Sprintf("%d:%d", st.Uid, st.Gid)
+	}
+
+	entries, ok := readPOSIXACL(path, "system.posix_acl_access", 0)
+	if !ok {
+		perm := uint32(info.Mode().Perm())
+		entries = []ACE{
+			{Principal: "user:", Type: "allow", Mask: perm >> 6 & 7},
+			{Principal: "group:", Type: "allow", Mask: perm >> 3 & 7},
+			{Principal: "other:", Type: "allow", Mask: perm & 7},
+		}
+	}
+	acl.Entries = entries
+
+	if info.IsDir() {
+		if defaults, ok := readPOSIXACL(path, "system.posix_acl_default", 1); ok {
+			acl.Entries = append(acl.Entries, defaults...)
+		}
+	}
+	return acl, nil
+}
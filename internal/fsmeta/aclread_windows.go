@@ -0,0 +1,61 @@
+//go:build windows
+
+package fsmeta
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// aceTypeNames はACEの種類の表示名
+var aceTypeNames = map[uint8]string{
+	windows.ACCESS_ALLOWED_ACE_TYPE: "allow",
+	windows.ACCESS_DENIED_ACE_TYPE:  "deny",
+	0x02:                            "audit",
+	0x05:                            "allow-object",
+	0x06:                            "deny-object",
+}
+
+// ReadACL はファイルのセキュリティ記述子から所有者とDACLを取得する
+// プリンシパルはSIDの文字列で表す（ドメインをまたぐ移行でもSIDで比較できるように名前解決はしない）
+func ReadACL(path string) (*ACL, error) {
+	sd, err := windows.GetNamedSecurityInfo(path, windows.SE_FILE_OBJECT,
+		windows.OWNER_SECURITY_INFORMATION|windows.DACL_SECURITY_INFORMATION)
+	if err != nil {
+		return nil, fmt.Errorf("セキュリティ記述子の取得に失敗: %w", err)
+	}
+
+	acl := &ACL{}
+	if owner, _, err := sd.Owner(); err == nil && owner != nil {
+		acl.Owner = owner.String()
+	}
+
+	dacl, _, err := sd.DACL()
+	if err != nil || dacl == nil {
+		// DACLがない場合はすべてのアクセスが許可されている
+		return acl, nil
+	}
+
+	for i := uint32(0); i < uint32(dacl.AceCount); i++ {
+		var ace *windows.ACCESS_ALLOWED_ACE
+		if err := windows.GetAce(dacl, i, &ace); err != nil {
+			return nil, fmt.Errorf("ACEの取得に失敗: %w", err)
+		}
+
+		typeName, ok := aceTypeNames[ace.Header.AceType]
+		if !ok {
+			typeName = fmt.Sprintf("type%d", ace.Header.AceType)
+		}
+		entry := ACE{Type: typeName, Mask: uint32(ace.Mask), Flags: uint32(ace.Header.AceFlags)}
+
+		// オブジェクトACEはSIDの位置が異なるため、プリンシパルは記録しない
+		if ace.Header.AceType == windows.ACCESS_ALLOWED_ACE_TYPE || ace.Header.AceType == windows.ACCESS_DENIED_ACE_TYPE {
+			sid := (*windows.SID)(unsafe.Pointer(&ace.SidStart))
+			entry.Principal = sid.String()
+		}
+		acl.Entries = append(acl.Entries, entry)
+	}
+	return acl, nil
+}
@@ -0,0 +1,18 @@
+//go:build darwin || freebsd || netbsd
+
+package fsmeta
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// AccessTime はファイルの最終アクセス日時を返す（取得できない場合はfalse）
+func AccessTime(info os.FileInfo) (time.Time, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(st.Atimespec.Sec), int64(st.Atimespec.Nsec)), true
+}
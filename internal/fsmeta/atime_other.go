@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !windows
+
+package fsmeta
+
+import (
+	"os"
+	"time"
+)
+
+// AccessTime はファイルの最終アクセス日時を返す（この環境では取得できないため常にfalse）
+func AccessTime(info os.FileInfo) (time.Time, bool) {
+	return time.Time{}, false
+}
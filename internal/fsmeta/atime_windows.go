@@ -0,0 +1,18 @@
+//go:build windows
+
+package fsmeta
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// AccessTime はファイルの最終アクセス日時を返す（取得できない場合はfalse）
+func AccessTime(info os.FileInfo) (time.Time, bool) {
+	data, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(0, data.LastAccessTime.Nanoseconds()), true
+}
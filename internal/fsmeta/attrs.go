@@ -0,0 +1,24 @@
+package fsmeta
+
+import (
+	"os"
+	"strings"
+)
+
+// Attributes は隠しファイル・システムファイルの属性を表す構造体
+type Attributes struct {
+	Hidden bool // 隠しファイル（"."で始まる名前、またはWindowsの隠し属性）
+	System bool // システムファイル（Windowsのシステム属性）
+}
+
+// FileAttributes はファイル名とファイル情報から隠し・システム属性を判定する
+// infoがnilの場合はファイル名のみで判定する
+func FileAttributes(name string, info os.FileInfo) Attributes {
+	attrs := Attributes{Hidden: strings.HasPrefix(name, ".") && name != "." && name != ".."}
+	if info != nil {
+		hidden, system := platformAttributes(info)
+		attrs.Hidden = attrs.Hidden || hidden
+		attrs.System = system
+	}
+	return attrs
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package fsmeta
+
+import "os"
+
+// platformAttributes はWindows以外では属性を持たないため、常にfalseを返す
+func platformAttributes(info os.FileInfo) (hidden, system bool) {
+	return false, false
+}
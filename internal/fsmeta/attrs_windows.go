@@ -0,0 +1,18 @@
+//go:build windows
+
+package fsmeta
+
+import (
+	"os"
+	"syscall"
+)
+
+// platformAttributes はWindowsのファイル属性から隠し・システム属性を取得する
+func platformAttributes(info os.FileInfo) (hidden, system bool) {
+	data, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return false, false
+	}
+	return data.FileAttributes&syscall.FILE_ATTRIBUTE_HIDDEN != 0,
+		data.FileAttributes&syscall.FILE_ATTRIBUTE_SYSTEM != 0
+}
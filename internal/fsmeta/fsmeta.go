@@ -0,0 +1,53 @@
+// Package fsmeta はファイルの所有者・パーミッション・inodeなど、
+// ファイルシステム固有のメタデータを収集する
+package fsmeta
+
+import (
+	"os"
+)
+
+// Metadata はファイルシステム固有のメタデータを表す構造体
+type Metadata struct {
+	Device     uint64      `json:"device,omitempty"`      // デバイスID（Windowsではボリュームシリアル番号）
+	FileID     uint64      `json:"file_id,omitempty"`     // inode番号（Windowsではファイルインデックス）
+	Owner      string      `json:"owner,omitempty"`       // 所有者（Unixでは "uid:gid"）
+	Mode       os.FileMode `json:"mode"`                  // パーミッションとファイル種別
+	LinkTarget string      `json:"link_target,omitempty"` // シンボリックリンクの場合のリンク先
+	ACLDigest  string      `json:"acl_digest,omitempty"`  // ACLのダイジェスト（取得できる環境のみ）
+}
+
+// Collect は指定されたパスのメタデータを収集する
+// シンボリックリンクの場合はリンク先を記録し、その他の項目はリンクをたどった先のファイルから取得する
+func Collect(path string) (*Metadata, error) {
+	linfo, err := os.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &Metadata{}
+	info := linfo
+	if linfo.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return nil, err
+		}
+		meta.LinkTarget = target
+
+		if info, err = os.Stat(path); err != nil {
+			return nil, err
+		}
+	}
+
+	meta.Mode = info.Mode()
+	collectPlatform(meta, path, info)
+	return meta, nil
+}
+
+// SameFile は2つのメタデータが同じファイル（デバイスとファイルIDが一致）を指すかを返す
+// どちらかのファイルIDが不明な場合はfalseを返す
+func (m *Metadata) SameFile(other *Metadata) bool {
+	if m == nil || other == nil || m.FileID == 0 || other.FileID == 0 {
+		return false
+	}
+	return m.Device == other.Device && m.FileID == other.FileID
+}
@@ -0,0 +1,319 @@
+package fsmeta
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestCollect_RegularFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(path, []byte("data"), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	meta, err := Collect(path)
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if !meta.Mode.IsRegular() {
+		t.Errorf("Mode = %v, 通常ファイルではありません", meta.Mode)
+	}
+	if meta.LinkTarget != "" {
+		t.Errorf("LinkTarget = %q, want empty", meta.LinkTarget)
+	}
+	if meta.FileID == 0 {
+		t.Error("FileIDが取得されていません")
+	}
+	if runtime.GOOS != "windows" {
+		if meta.Mode.Perm() != 0640 {
+			t.Errorf("Perm = %v, want 0640", meta.Mode.Perm())
+		}
+		if meta.Owner == "" {
+			t.Error("Ownerが取得されていません")
+		}
+	}
+}
+
+func TestCollect_Symlink(t *testing.T) {
+	tempDir := t.TempDir()
+	target := filepath.Join(tempDir, "target.txt")
+	link := filepath.Join(tempDir, "link.txt")
+	if err := os.WriteFile(target, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("シンボリックリンクを作成できません: %v", err)
+	}
+
+	meta, err := Collect(link)
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if meta.LinkTarget != target {
+		t.Errorf("LinkTarget = %q, want %q", meta.LinkTarget, target)
+	}
+
+	// リンク以外の項目はリンク先のファイルのもの
+	targetMeta, err := Collect(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !meta.SameFile(targetMeta) {
+		t.Error("リンクとリンク先が同じファイルとして扱われません")
+	}
+}
+
+func TestCollect_NotExist(t *testing.T) {
+	if _, err := Collect(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("存在しないファイルでエラーになりません")
+	}
+}
+
+func TestMetadata_SameFile(t *testing.T) {
+	tempDir := t.TempDir()
+	a := filepath.Join(tempDir, "a")
+	b := filepath.Join(tempDir, "b")
+	os.WriteFile(a, []byte("a"), 0644)
+	os.WriteFile(b, []byte("b"), 0644)
+
+	metaA, _ := Collect(a)
+	metaB, _ := Collect(b)
+	if metaA.SameFile(metaB) {
+		t.Error("異なるファイルが同じファイルとして扱われました")
+	}
+
+	// 名前を変えてもファイルIDは変わらない
+	moved := filepath.Join(tempDir, "moved")
+	if err := os.Rename(a, moved); err != nil {
+		t.Fatal(err)
+	}
+	metaMoved, _ := Collect(moved)
+	if !metaA.SameFile(metaMoved) {
+		t.Error("移動したファイルが同じファイルとして扱われません")
+	}
+
+	if metaA.SameFile(nil) || (&Metadata{}).SameFile(&Metadata{}) {
+		t.Error("ファイルIDが不明な場合はfalseになるべきです")
+	}
+}
+
+func TestDiffACL(t *testing.T) {
+	source := &ACL{
+		Owner: "1000:1000",
+		Entries: []ACE{
+			{Principal: "user:", Type: "allow", Mask: 6},
+			{Principal: "user:1001", Type: "allow", Mask: 4},
+			{Principal: "other:", Type: "allow", Mask: 4},
+		},
+	}
+
+	if diff := DiffACL(source, source); !diff.Empty() {
+		t.Errorf("同じACLで差分が検出されました: %+v", diff)
+	}
+
+	dest := &ACL{
+		Owner: "0:0",
+		Entries: []ACE{
+			{Principal: "other:", Type: "allow", Mask: 4},
+			{Principal: "user:", Type: "allow", Mask: 6},
+			{Principal: "user:1002", Type: "deny", Mask: 2},
+		},
+	}
+	diff := DiffACL(source, dest)
+	if !diff.OwnerChanged() || diff.OwnerFrom != "1000:1000" || diff.OwnerTo != "0:0" {
+		t.Errorf("所有者の差分 = %q -> %q", diff.OwnerFrom, diff.OwnerTo)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].Principal != "user:1001" {
+		t.Errorf("Removed = %+v", diff.Removed)
+	}
+	if len(diff.Added) != 1 || diff.Added[0].Principal != "user:1002" {
+		t.Errorf("Added = %+v", diff.Added)
+	}
+}
+
+func TestReadACL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(path, []byte("x"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	acl, err := ReadACL(path)
+	if err != nil {
+		t.Fatalf("ReadACL() error = %v", err)
+	}
+	if len(acl.Entries) == 0 {
+		t.Fatal("ACLのエントリが取得できません")
+	}
+
+	if _, err := ReadACL(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("存在しないファイルでエラーになりません")
+	}
+}
+
+func TestCopyPermissions(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "source.txt")
+	dest := filepath.Join(dir, "dest.txt")
+	os.WriteFile(source, []byte("x"), 0600)
+	os.WriteFile(dest, []byte("x"), 0644)
+	os.Chmod(source, 0600)
+
+	if err := CopyPermissions(source, dest); err != nil {
+		t.Fatalf("CopyPermissions() error = %v", err)
+	}
+
+	sourceACL, err := ReadACL(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	destACL, err := ReadACL(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := DiffACL(sourceACL, destACL); !diff.Empty() {
+		t.Errorf("コピー後もアクセス権が異なります: %+v", diff)
+	}
+
+	if err := CopyPermissions(filepath.Join(dir, "missing"), dest); err == nil {
+		t.Error("存在しないソースでエラーになりません")
+	}
+}
+
+func TestFileAttributes(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ".hidden"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(dir, "visible"), []byte("x"), 0644)
+
+	for _, tt := range []struct {
+		name       string
+		wantHidden bool
+	}{
+		{".hidden", true},
+		{"visible", false},
+	} {
+		info, err := os.Stat(filepath.Join(dir, tt.name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := FileAttributes(tt.name, info); got.Hidden != tt.wantHidden || got.System {
+			t.Errorf("FileAttributes(%s) = %+v, want Hidden=%v", tt.name, got, tt.wantHidden)
+		}
+	}
+
+	if got := FileAttributes(".git", nil); !got.Hidden {
+		t.Error("ファイル情報なしでドットファイルを判定できません")
+	}
+	if got := FileAttributes("..", nil); got.Hidden {
+		t.Error("..を隠しファイルと判定しました")
+	}
+}
+
+func TestSnapshot_CaptureApply(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	dst := filepath.Join(dir, "dst.txt")
+	os.WriteFile(src, []byte("a"), 0600)
+	os.WriteFile(dst, []byte("a"), 0644)
+	os.Chmod(src, 0600)
+	modTime := time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)
+	os.Chtimes(src, modTime, modTime)
+
+	snap, err := Capture(src)
+	if err != nil {
+		t.Fatalf("Capture() error = %v", err)
+	}
+	if err := snap.Apply(dst); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	info, _ := os.Stat(dst)
+	if !info.ModTime().Equal(modTime) {
+		t.Errorf("更新日時 = %v, want %v", info.ModTime(), modTime)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("パーミッション = %o, want 600", info.Mode().Perm())
+	}
+
+	// ファイルの種類が異なる場合は適用しない
+	if err := snap.Apply(dir); err == nil {
+		t.Error("ディレクトリにファイルのメタデータを適用できてしまいます")
+	}
+}
+
+func TestParseOwner(t *testing.T) {
+	if o, err := ParseOwner(""); err != nil || o != nil {
+		t.Errorf("ParseOwner(空) = %v, %v; want nil, nil", o, err)
+	}
+	if runtime.GOOS == "windows" {
+		if _, err := ParseOwner("1000:1000"); err == nil {
+			t.Error("Windowsで所有者の指定がエラーになりません")
+		}
+		return
+	}
+
+	tests := []struct {
+		spec     string
+		uid, gid int
+	}{
+		{"1000:2000", 1000, 2000},
+		{"1000", 1000, -1},
+		{":2000", -1, 2000},
+	}
+	for _, tt := range tests {
+		o, err := ParseOwner(tt.spec)
+		if err != nil {
+			t.Fatalf("ParseOwner(%q) error = %v", tt.spec, err)
+		}
+		if o.UID != tt.uid || o.GID != tt.gid || o.String() != tt.spec {
+			t.Errorf("ParseOwner(%q) = %+v", tt.spec, o)
+		}
+	}
+	for _, spec := range []string{":", "no-such-user-gopier", ":no-such-group-gopier"} {
+		if _, err := ParseOwner(spec); err == nil {
+			t.Errorf("ParseOwner(%q) がエラーになりません", spec)
+		}
+	}
+
+	// 現在の所有者と一致するかを比較する
+	path := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	o := &Owner{UID: os.Getuid(), GID: -1}
+	if err := o.Chown(path); err != nil {
+		t.Fatalf("Chown() error = %v", err)
+	}
+	info, _ := os.Stat(path)
+	if !o.Matches(info) {
+		t.Error("自身を所有者に指定したファイルが一致しません")
+	}
+	if (&Owner{UID: os.Getuid() + 1, GID: -1}).Matches(info) {
+		t.Error("異なる所有者の指定が一致しました")
+	}
+}
+
+func TestModTimeWithin(t *testing.T) {
+	base := time.Date(2024, 1, 2, 3, 4, 5, 100, time.UTC)
+	tests := []struct {
+		diff      time.Duration
+		precision time.Duration
+		want      bool
+	}{
+		{0, time.Nanosecond, true},
+		{time.Nanosecond, time.Nanosecond, false},
+		{-99 * time.Nanosecond, 100 * time.Nanosecond, true},
+		{time.Second, 2 * time.Second, true},
+		{time.Hour, 0, true},
+	}
+	for _, tt := range tests {
+		if got := ModTimeWithin(base, base.Add(tt.diff), tt.precision); got != tt.want {
+			t.Errorf("ModTimeWithin(差%v, 精度%v) = %v, want %v", tt.diff, tt.precision, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package fsmeta
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// collectPlatform はUnix系OSのメタデータを収集する
+func collectPlatform(meta *Metadata, path string, info os.FileInfo) {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		meta.Device = uint64(st.Dev)
+		meta.FileID = uint64(st.Ino)
+		meta.Owner = fmt.Sprintf("%d:%d", st.Uid, st.Gid)
+	}
+	meta.ACLDigest = aclDigest(path)
+}
@@ -0,0 +1,33 @@
+//go:build windows
+
+package fsmeta
+
+import (
+	"os"
+	"syscall"
+)
+
+// collectPlatform はWindowsのメタデータを収集する
+// 所有者とACLの取得には対応していない
+func collectPlatform(meta *Metadata, path string, info os.FileInfo) {
+	pathp, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return
+	}
+
+	// ディレクトリも開けるようにFILE_FLAG_BACKUP_SEMANTICSを指定する
+	handle, err := syscall.CreateFile(pathp, 0,
+		syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE,
+		nil, syscall.OPEN_EXISTING, syscall.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return
+	}
+	defer syscall.CloseHandle(handle)
+
+	var d syscall.ByHandleFileInformation
+	if err := syscall.GetFileInformationByHandle(handle, &d); err != nil {
+		return
+	}
+	meta.Device = uint64(d.VolumeSerialNumber)
+	meta.FileID = uint64(d.FileIndexHigh)<<32 | uint64(d.FileIndexLow)
+}
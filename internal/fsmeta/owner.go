@@ -0,0 +1,58 @@
+package fsmeta
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Owner は宛先に設定する所有者を表す構造体（-1は変更しない）
+type Owner struct {
+	UID  int
+	GID  int
+	spec string
+}
+
+// ParseOwner は"ユーザー:グループ"・"ユーザー"・":グループ"の形式の指定を解析する
+// 名前またはuid/gidの数値で指定でき、名前はこのコンピューターのユーザー・グループから探す
+// 空の場合はnilを返す
+func ParseOwner(spec string) (*Owner, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+	if err := ownerSupported(); err != nil {
+		return nil, err
+	}
+	userName, groupName, _ := strings.Cut(spec, ":")
+	if userName == "" && groupName == "" {
+		return nil, fmt.Errorf("所有者の指定が不正です: %s", spec)
+	}
+
+	o := &Owner{UID: -1, GID: -1, spec: spec}
+	var err error
+	if userName != "" {
+		if o.UID, err = lookupID(userName, lookupUser); err != nil {
+			return nil, fmt.Errorf("ユーザー(%s)が見つかりません: %w", userName, err)
+		}
+	}
+	if groupName != "" {
+		if o.GID, err = lookupID(groupName, lookupGroup); err != nil {
+			return nil, fmt.Errorf("グループ(%s)が見つかりません: %w", groupName, err)
+		}
+	}
+	return o, nil
+}
+
+// lookupID は数値の場合はそのまま、名前の場合はlookupで探したIDを返す
+func lookupID(name string, lookup func(string) (int, error)) (int, error) {
+	if id, err := strconv.Atoi(name); err == nil && id >= 0 {
+		return id, nil
+	}
+	return lookup(name)
+}
+
+// String は指定された形式のまま返す
+func (o *Owner) String() string {
+	return o.spec
+}
@@ -0,0 +1,45 @@
+//go:build !windows
+
+package fsmeta
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+func ownerSupported() error {
+	return nil
+}
+
+func lookupUser(name string) (int, error) {
+	u, err := user.Lookup(name)
+	if err != nil {
+		return -1, err
+	}
+	return strconv.Atoi(u.Uid)
+}
+
+func lookupGroup(name string) (int, error) {
+	g, err := user.LookupGroup(name)
+	if err != nil {
+		return -1, err
+	}
+	return strconv.Atoi(g.Gid)
+}
+
+// Chown は宛先の所有者を設定する（シンボリックリンクはリンク自体を変更する）
+// 他のユーザーを所有者にするにはroot権限が必要
+func (o *Owner) Chown(path string) error {
+	return os.Lchown(path, o.UID, o.GID)
+}
+
+// Matches はinfoの所有者が指定と一致するかを返す（所有者を取得できない場合はtrue）
+func (o *Owner) Matches(info os.FileInfo) bool {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return true
+	}
+	return (o.UID < 0 || int(st.Uid) == o.UID) && (o.GID < 0 || int(st.Gid) == o.GID)
+}
@@ -0,0 +1,33 @@
+//go:build windows
+
+package fsmeta
+
+import (
+	"errors"
+	"os"
+)
+
+// errOwnerUnsupported はWindowsでuid/gidによる所有者の指定に対応していないことを表す
+var errOwnerUnsupported = errors.New("Windowsではuid/gidによる所有者の指定に対応していません")
+
+func ownerSupported() error {
+	return errOwnerUnsupported
+}
+
+func lookupUser(name string) (int, error) {
+	return -1, errOwnerUnsupported
+}
+
+func lookupGroup(name string) (int, error) {
+	return -1, errOwnerUnsupported
+}
+
+// Chown は宛先の所有者を設定する（Windowsでは対応していない）
+func (o *Owner) Chown(path string) error {
+	return errOwnerUnsupported
+}
+
+// Matches はinfoの所有者が指定と一致するかを返す（Windowsでは所有者を比較しないため常にtrue）
+func (o *Owner) Matches(info os.FileInfo) bool {
+	return true
+}
@@ -0,0 +1,24 @@
+//go:build !windows
+
+package fsmeta
+
+import (
+	"os"
+	"syscall"
+)
+
+// CopyPermissions はソースのパーミッションと所有者を宛先に設定する
+// 所有者の変更にはroot権限が必要
+func CopyPermissions(source, dest string) error {
+	info, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		if err := os.Chown(dest, int(st.Uid), int(st.Gid)); err != nil {
+			return err
+		}
+	}
+	// chownでsetuid/setgidビットが落ちるため、パーミッションは後から設定する
+	return os.Chmod(dest, info.Mode()&(os.ModePerm|os.ModeSetuid|os.ModeSetgid|os.ModeSticky))
+}
@@ -0,0 +1,47 @@
+//go:build windows
+
+package fsmeta
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows"
+)
+
+// CopyPermissions はソースのセキュリティ記述子（所有者・グループ・DACL）を宛先に設定する
+// 他のユーザーを所有者にするには管理者権限（SeRestorePrivilege）が必要
+func CopyPermissions(source, dest string) error {
+	info := windows.SECURITY_INFORMATION(windows.OWNER_SECURITY_INFORMATION |
+		windows.GROUP_SECURITY_INFORMATION | windows.DACL_SECURITY_INFORMATION)
+
+	sd, err := windows.GetNamedSecurityInfo(source, windows.SE_FILE_OBJECT, info)
+	if err != nil {
+		return fmt.Errorf("セキュリティ記述子の取得に失敗: %w", err)
+	}
+
+	owner, _, err := sd.Owner()
+	if err != nil {
+		return fmt.Errorf("所有者の取得に失敗: %w", err)
+	}
+	group, _, err := sd.Group()
+	if err != nil {
+		return fmt.Errorf("グループの取得に失敗: %w", err)
+	}
+	dacl, _, err := sd.DACL()
+	if err != nil {
+		return fmt.Errorf("DACLの取得に失敗: %w", err)
+	}
+
+	// 継承による変化を防ぐため、ソースが継承を止めている場合は宛先でも止める
+	control, _, err := sd.Control()
+	if err == nil && control&windows.SE_DACL_PROTECTED != 0 {
+		info |= windows.PROTECTED_DACL_SECURITY_INFORMATION
+	} else {
+		info |= windows.UNPROTECTED_DACL_SECURITY_INFORMATION
+	}
+
+	if err := windows.SetNamedSecurityInfo(dest, windows.SE_FILE_OBJECT, info, owner, group, dacl, nil); err != nil {
+		return fmt.Errorf("セキュリティ記述子の設定に失敗: %w", err)
+	}
+	return nil
+}
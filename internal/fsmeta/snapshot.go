@@ -0,0 +1,60 @@
+package fsmeta
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Snapshot は宛先のファイルシステムで失われる可能性のあるメタデータを、
+// 別のファイルシステムで復元できる形で保持する構造体
+type Snapshot struct {
+	Mode     os.FileMode       `json:"mode"`
+	ModTime  time.Time         `json:"mod_time"`
+	Owner    string            `json:"owner,omitempty"`    // 所有者（Unixでは "uid:gid"）
+	Security string            `json:"security,omitempty"` // セキュリティ記述子のSDDL（Windowsのみ）
+	Xattrs   map[string][]byte `json:"xattrs,omitempty"`   // 拡張属性（LinuxではPOSIX ACLを含む）
+}
+
+// Capture は指定されたパスのメタデータを取得する（シンボリックリンクはたどらない）
+func Capture(path string) (*Snapshot, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &Snapshot{Mode: info.Mode(), ModTime: info.ModTime()}
+	if info.Mode()&os.ModeSymlink != 0 {
+		return snap, nil
+	}
+	if err := capturePlatform(snap, path, info); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+// Apply はメタデータを指定されたパスに設定する
+// 一部の項目の設定に失敗しても残りの項目は設定し、失敗した項目のエラーをまとめて返す
+// 所有者の変更でパーミッションが変わることがあるため、所有者・拡張属性、パーミッション、更新日時の順に設定する
+func (s *Snapshot) Apply(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		return nil
+	}
+	if info.Mode().Type() != s.Mode.Type() {
+		return fmt.Errorf("ファイルの種類が一致しません (記録: %s, 実際: %s)", s.Mode.Type(), info.Mode().Type())
+	}
+
+	errs := applyPlatform(s, path)
+	if err := os.Chmod(path, s.Mode&(os.ModePerm|os.ModeSetuid|os.ModeSetgid|os.ModeSticky)); err != nil {
+		errs = append(errs, fmt.Errorf("パーミッションの設定に失敗: %w", err))
+	}
+	if err := os.Chtimes(path, time.Now(), s.ModTime); err != nil {
+		errs = append(errs, fmt.Errorf("更新日時の設定に失敗: %w", err))
+	}
+	return errors.Join(errs...)
+}
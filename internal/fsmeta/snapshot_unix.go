@@ -0,0 +1,42 @@
+//go:build !windows
+
+package fsmeta
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// capturePlatform は所有者と拡張属性を取得する
+func capturePlatform(snap *Snapshot, path string, info os.FileInfo) error {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		snap.Owner = fmt.Sprintf("%d:%d", st.Uid, st.Gid)
+	}
+	xattrs, err := readXattrs(path)
+	if err != nil {
+		return fmt.Errorf("拡張属性の取得に失敗: %w", err)
+	}
+	snap.Xattrs = xattrs
+	return nil
+}
+
+// applyPlatform は所有者と拡張属性を設定する
+// Windowsで記録したセキュリティ記述子と所有者（SID）は復元しない
+func applyPlatform(snap *Snapshot, path string) []error {
+	var errs []error
+	if snap.Owner != "" && snap.Security == "" {
+		var uid, gid int
+		if _, err := fmt.Sscanf(snap.Owner, "%d:%d", &uid, &gid); err != nil {
+			errs = append(errs, fmt.Errorf("所有者の形式が不正です: %s", snap.Owner))
+		} else if err := os.Lchown(path, uid, gid); err != nil {
+			errs = append(errs, fmt.Errorf("所有者の設定に失敗: %w", err))
+		}
+	}
+	for name, value := range snap.Xattrs {
+		if err := setXattr(path, name, value); err != nil {
+			errs = append(errs, fmt.Errorf("拡張属性(%s)の設定に失敗: %w", name, err))
+		}
+	}
+	return errs
+}
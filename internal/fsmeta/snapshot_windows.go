@@ -0,0 +1,54 @@
+//go:build windows
+
+package fsmeta
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// snapshotSecurityInfo は記録・復元するセキュリティ情報
+const snapshotSecurityInfo = windows.OWNER_SECURITY_INFORMATION |
+	windows.GROUP_SECURITY_INFORMATION | windows.DACL_SECURITY_INFORMATION
+
+// capturePlatform はセキュリティ記述子をSDDLで取得する
+func capturePlatform(snap *Snapshot, path string, info os.FileInfo) error {
+	sd, err := windows.GetNamedSecurityInfo(path, windows.SE_FILE_OBJECT, snapshotSecurityInfo)
+	if err != nil {
+		return fmt.Errorf("セキュリティ記述子の取得に失敗: %w", err)
+	}
+	snap.Security = sd.String()
+	if owner, _, err := sd.Owner(); err == nil && owner != nil {
+		snap.Owner = owner.String()
+	}
+	return nil
+}
+
+// applyPlatform はSDDLからセキュリティ記述子（所有者・グループ・DACL）を設定する
+// Unixで記録した所有者（uid:gid）は復元しない
+func applyPlatform(snap *Snapshot, path string) []error {
+	if snap.Security == "" {
+		return nil
+	}
+
+	sd, err := windows.SecurityDescriptorFromString(snap.Security)
+	if err != nil {
+		return []error{fmt.Errorf("セキュリティ記述子の形式が不正です: %w", err)}
+	}
+	owner, _, _ := sd.Owner()
+	group, _, _ := sd.Group()
+	dacl, _, _ := sd.DACL()
+
+	info := windows.SECURITY_INFORMATION(snapshotSecurityInfo)
+	if control, _, err := sd.Control(); err == nil && control&windows.SE_DACL_PROTECTED != 0 {
+		info |= windows.PROTECTED_DACL_SECURITY_INFORMATION
+	} else {
+		info |= windows.UNPROTECTED_DACL_SECURITY_INFORMATION
+	}
+	if err := windows.SetNamedSecurityInfo(path, windows.SE_FILE_OBJECT, info, owner, group, dacl, nil); err != nil {
+		return []error{fmt.Errorf("セキュリティ記述子の設定に失敗: %w", err)}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package fsmeta
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// StampVersion は記録するハッシュの形式のバージョン
+const StampVersion = 1
+
+// ErrStampUnsupported はファイルシステムまたは環境がハッシュの記録（拡張属性・ADS）に対応していないことを表す
+var ErrStampUnsupported = errors.New("この環境ではハッシュを拡張属性に記録できません")
+
+// Stamp は宛先のファイルの拡張属性（WindowsではADS）に記録するハッシュ
+// 同期DBやソースにアクセスできない環境でも、宛先のファイルのみで内容を検証できるようにする
+type Stamp struct {
+	Version    int       `json:"v"`
+	Algorithm  string    `json:"algo"`
+	Hash       string    `json:"hash"`                  // 宛先の内容のハッシュ（変換した場合は変換後の内容）
+	SourceHash string    `json:"source_hash,omitempty"` // 変換した場合の変換前のソースの内容のハッシュ
+	Size       int64     `json:"size"`                  // ソースのサイズ
+	ModTime    time.Time `json:"mtime"`                 // ソースの更新日時
+	StampedAt  time.Time `json:"stamped_at"`            // コピー（検証）してハッシュを記録した日時
+}
+
+// WriteStamp はファイルの拡張属性にハッシュを記録する（既存の記録は置き換える）
+// 更新日時は変更しない
+func WriteStamp(path string, stamp Stamp) error {
+	stamp.Version = StampVersion
+	data, err := json.Marshal(stamp)
+	if err != nil {
+		return err
+	}
+	return writeStampData(path, data)
+}
+
+// ReadStamp はファイルの拡張属性に記録したハッシュを読み込む（記録がない場合はnil）
+func ReadStamp(path string) (*Stamp, error) {
+	data, err := readStampData(path)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	stamp := &Stamp{}
+	if err := json.Unmarshal(data, stamp); err != nil {
+		return nil, fmt.Errorf("記録されたハッシュを読み込めません: %w", err)
+	}
+	if stamp.Version > StampVersion {
+		return nil, fmt.Errorf("記録されたハッシュの形式に対応していません (バージョン %d)", stamp.Version)
+	}
+	return stamp, nil
+}
@@ -0,0 +1,33 @@
+package fsmeta
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// stampXattr はハッシュを記録する拡張属性の名前
+const stampXattr = "user.gopier.stamp"
+
+func writeStampData(path string, data []byte) error {
+	err := unix.Setxattr(path, stampXattr, data, 0)
+	if errors.Is(err, unix.ENOTSUP) {
+		return ErrStampUnsupported
+	}
+	return err
+}
+
+func readStampData(path string) ([]byte, error) {
+	size, err := unix.Getxattr(path, stampXattr, nil)
+	if errors.Is(err, unix.ENOATTR) || errors.Is(err, unix.ENOTSUP) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, size)
+	if size, err = unix.Getxattr(path, stampXattr, data); err != nil {
+		return nil, err
+	}
+	return data[:size], nil
+}
@@ -0,0 +1,32 @@
+package fsmeta
+
+import (
+	"errors"
+	"syscall"
+)
+
+// stampXattr はハッシュを記録する拡張属性の名前
+const stampXattr = "user.gopier.stamp"
+
+func writeStampData(path string, data []byte) error {
+	err := setXattr(path, stampXattr, data)
+	if errors.Is(err, syscall.ENOTSUP) {
+		return ErrStampUnsupported
+	}
+	return err
+}
+
+func readStampData(path string) ([]byte, error) {
+	size, err := syscall.Getxattr(path, stampXattr, nil)
+	if errors.Is(err, syscall.ENODATA) || errors.Is(err, syscall.ENOTSUP) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, size)
+	if size, err = syscall.Getxattr(path, stampXattr, data); err != nil {
+		return nil, err
+	}
+	return data[:size], nil
+}
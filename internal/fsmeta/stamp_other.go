@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !windows
+
+package fsmeta
+
+func writeStampData(path string, data []byte) error {
+	return ErrStampUnsupported
+}
+
+func readStampData(path string) ([]byte, error) {
+	return nil, nil
+}
@@ -0,0 +1,49 @@
+//go:build windows
+
+package fsmeta
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// stampStream はハッシュを記録する代替データストリーム（ADS）の名前
+const stampStream = ":gopier.stamp"
+
+// writeStampData はADSにハッシュを書き込む
+// ADSへの書き込みでファイルの更新日時が変わるため書き込み後に戻し、読み取り専用の属性は一時的に外す
+func writeStampData(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.Mode().Perm()&0200 == 0 {
+		if err := os.Chmod(path, info.Mode().Perm()|0200); err != nil {
+			return err
+		}
+		defer os.Chmod(path, info.Mode().Perm())
+	}
+
+	if err := os.WriteFile(path+stampStream, data, 0644); err != nil {
+		if errors.Is(err, windows.ERROR_INVALID_NAME) {
+			// NTFS以外（FAT・exFATなど）はADSに対応していない
+			return ErrStampUnsupported
+		}
+		return err
+	}
+	atime, ok := AccessTime(info)
+	if !ok {
+		atime = info.ModTime()
+	}
+	return os.Chtimes(path, atime, info.ModTime())
+}
+
+func readStampData(path string) ([]byte, error) {
+	data, err := os.ReadFile(path + stampStream)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
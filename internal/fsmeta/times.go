@@ -0,0 +1,16 @@
+package fsmeta
+
+import "time"
+
+// ModTimeWithin は2つの更新日時の差が精度未満かどうかを返す（精度が0以下の場合は比較せずにtrue）
+// 宛先のファイルシステムの精度（NTFSは100ナノ秒、FATは2秒など）に合わせて精度を指定する
+func ModTimeWithin(a, b time.Time, precision time.Duration) bool {
+	if precision <= 0 {
+		return true
+	}
+	diff := a.Sub(b)
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff < precision
+}
@@ -0,0 +1,53 @@
+package fsmeta
+
+import (
+	"bytes"
+	"errors"
+	"syscall"
+)
+
+// readXattrs はファイルの拡張属性をすべて取得する
+// 拡張属性に対応していないファイルシステムの場合は空を返す
+func readXattrs(path string) (map[string][]byte, error) {
+	size, err := syscall.Listxattr(path, nil)
+	if err != nil {
+		if errors.Is(err, syscall.ENOTSUP) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if size == 0 {
+		return nil, nil
+	}
+
+	list := make([]byte, size)
+	if size, err = syscall.Listxattr(path, list); err != nil {
+		return nil, err
+	}
+
+	xattrs := make(map[string][]byte)
+	for _, name := range bytes.Split(list[:size], []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+		n, err := syscall.Getxattr(path, string(name), nil)
+		if err != nil {
+			// 列挙後に削除された属性や、読み取り権限のない名前空間は記録しない
+			continue
+		}
+		value := make([]byte, n)
+		if n, err = syscall.Getxattr(path, string(name), value); err != nil {
+			continue
+		}
+		xattrs[string(name)] = value[:n]
+	}
+	if len(xattrs) == 0 {
+		return nil, nil
+	}
+	return xattrs, nil
+}
+
+// setXattr は拡張属性を設定する
+func setXattr(path, name string, value []byte) error {
+	return syscall.Setxattr(path, name, value, 0)
+}
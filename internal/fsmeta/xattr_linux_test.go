@@ -0,0 +1,71 @@
+package fsmeta
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSnapshot_Xattrs(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	os.WriteFile(src, []byte("a"), 0644)
+	os.WriteFile(dst, []byte("a"), 0644)
+
+	if err := syscall.Setxattr(src, "user.gopier.test", []byte("value"), 0); err != nil {
+		if errors.Is(err, syscall.ENOTSUP) || errors.Is(err, syscall.EPERM) {
+			t.Skipf("拡張属性に対応していないファイルシステムです: %v", err)
+		}
+		t.Fatal(err)
+	}
+
+	snap, err := Capture(src)
+	if err != nil {
+		t.Fatalf("Capture() error = %v", err)
+	}
+	if string(snap.Xattrs["user.gopier.test"]) != "value" {
+		t.Fatalf("拡張属性 = %v", snap.Xattrs)
+	}
+	if err := snap.Apply(dst); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	buf := make([]byte, 64)
+	n, err := syscall.Getxattr(dst, "user.gopier.test", buf)
+	if err != nil {
+		t.Fatalf("拡張属性が復元されていません: %v", err)
+	}
+	if string(buf[:n]) != "value" {
+		t.Errorf("復元した拡張属性 = %q", buf[:n])
+	}
+}
+
+func TestStamp(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	os.WriteFile(path, []byte("a"), 0644)
+
+	if stamp, err := ReadStamp(path); err != nil || stamp != nil {
+		t.Fatalf("記録のないファイル: ReadStamp() = %+v, %v", stamp, err)
+	}
+
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 123456789, time.UTC)
+	err := WriteStamp(path, Stamp{Algorithm: "sha256", Hash: "abc", Size: 1, ModTime: modTime, StampedAt: time.Now()})
+	if errors.Is(err, ErrStampUnsupported) || errors.Is(err, syscall.EPERM) {
+		t.Skipf("拡張属性に対応していないファイルシステムです: %v", err)
+	}
+	if err != nil {
+		t.Fatalf("WriteStamp() error = %v", err)
+	}
+
+	stamp, err := ReadStamp(path)
+	if err != nil || stamp == nil {
+		t.Fatalf("ReadStamp() = %+v, %v", stamp, err)
+	}
+	if stamp.Version != StampVersion || stamp.Algorithm != "sha256" || stamp.Hash != "abc" || !stamp.ModTime.Equal(modTime) {
+		t.Errorf("ReadStamp() = %+v", stamp)
+	}
+}
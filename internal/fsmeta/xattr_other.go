@@ -0,0 +1,15 @@
+//go:build !linux
+
+package fsmeta
+
+import "errors"
+
+// readXattrs は拡張属性の取得に対応していない環境では空を返す
+func readXattrs(path string) (map[string][]byte, error) {
+	return nil, nil
+}
+
+// setXattr は拡張属性の設定に対応していない環境ではエラーを返す
+func setXattr(path, name string, value []byte) error {
+	return errors.New("この環境では拡張属性を設定できません")
+}
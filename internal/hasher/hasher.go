@@ -4,6 +4,7 @@ import (
 	"crypto/md5"
 	"crypto/sha1"
 	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/hex"
 	"fmt"
 	"hash"
@@ -22,12 +23,28 @@ const (
 	SHA1 Algorithm = "sha1"
 	// SHA256 はSHA-256ハッシュアルゴリズム
 	SHA256 Algorithm = "sha256"
+	// SHA512 はSHA-512ハッシュアルゴリズム
+	SHA512 Algorithm = "sha512"
 )
 
+// Interface はコピーと検証で使用するハッシュ値の計算の実装
+// ハードウェアによる高速化（HSMなど）やFIPS認証済みの実装を組み込む場合に実装する
+type Interface interface {
+	// GetAlgorithmName はハッシュアルゴリズムの名前を返す（DBに記録し、記録済みのハッシュとの比較に使用する）
+	GetAlgorithmName() string
+	// NewHash はハッシュ関数を作成する（変換後の内容など、書き込みながら計算する場合に使用する）
+	NewHash() (hash.Hash, error)
+	// HashReader はReaderから読み込んだ内容のハッシュ値を16進数文字列で返す
+	HashReader(r io.Reader) (string, error)
+}
+
+var _ Interface = (*Hasher)(nil)
+
 // Hasher はファイルハッシュ計算を行う構造体
 type Hasher struct {
 	algorithm  Algorithm
 	bufferSize int
+	newHash    func() hash.Hash // 組み込みでないハッシュ関数（NewCustomHasherで指定）
 }
 
 // NewHasher は新しいハッシャーを作成する
@@ -43,8 +60,20 @@ func NewHasher(algorithm Algorithm, bufferSize int) *Hasher {
 	}
 }
 
+// NewCustomHasher は組み込みでないハッシュ関数を使用するハッシャーを作成する
+// FIPS認証済みのモジュールなど、hash.Hashを実装するハッシュ関数を使用する場合に使用する
+// nameはDBに記録するアルゴリズムの名前で、組み込みのアルゴリズムと同じ結果になる場合は同じ名前を指定する
+func NewCustomHasher(name string, newHash func() hash.Hash, bufferSize int) *Hasher {
+	h := NewHasher(Algorithm(name), bufferSize)
+	h.newHash = newHash
+	return h
+}
+
 // getHasher は指定されたアルゴリズムのハッシャーを返す
 func (h *Hasher) getHasher() (hash.Hash, error) {
+	if h.newHash != nil {
+		return h.newHash(), nil
+	}
 	switch h.algorithm {
 	case MD5:
 		return md5.New(), nil
@@ -52,11 +81,19 @@ func (h *Hasher) getHasher() (hash.Hash, error) {
 		return sha1.New(), nil
 	case SHA256:
 		return sha256.New(), nil
+	case SHA512:
+		return sha512.New(), nil
 	default:
 		return nil, fmt.Errorf("未サポートのハッシュアルゴリズム: %s", h.algorithm)
 	}
 }
 
+// NewHash は設定されたアルゴリズムのハッシュ関数を作成する
+// ファイル以外（変換後の内容など）のハッシュ値を計算する場合に使用する
+func (h *Hasher) NewHash() (hash.Hash, error) {
+	return h.getHasher()
+}
+
 // HashFile はファイルのハッシュ値を計算する
 func (h *Hasher) HashFile(filePath string) (string, error) {
 	// ファイルを開く
@@ -66,6 +103,12 @@ func (h *Hasher) HashFile(filePath string) (string, error) {
 	}
 	defer file.Close()
 
+	return h.HashReader(file)
+}
+
+// HashReader はReaderから読み込んだ内容のハッシュ値を計算する
+// OS以外のファイルシステム（メモリ上など）のファイルのハッシュ値を計算する場合に使用する
+func (h *Hasher) HashReader(r io.Reader) (string, error) {
 	// ハッシャーを取得
 	hasher, err := h.getHasher()
 	if err != nil {
@@ -75,9 +118,9 @@ func (h *Hasher) HashFile(filePath string) (string, error) {
 	// バッファを作成
 	buffer := make([]byte, h.bufferSize)
 
-	// ファイルを読み込んでハッシュを計算
+	// 読み込んでハッシュを計算
 	for {
-		n, err := file.Read(buffer)
+		n, err := r.Read(buffer)
 		if err != nil && err != io.EOF {
 			return "", fmt.Errorf("ファイル読み込みエラー: %w", err)
 		}
@@ -1,9 +1,12 @@
 package hasher
 
 import (
+	"crypto/sha256"
 	"encoding/hex"
+	"hash"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -87,6 +90,11 @@ func TestGetHasher(t *testing.T) {
 			algorithm:   SHA256,
 			expectError: false,
 		},
+		{
+			name:        "SHA512",
+			algorithm:   SHA512,
+			expectError: false,
+		},
 		{
 			name:        "Unknown algorithm",
 			algorithm:   "unknown",
@@ -394,6 +402,32 @@ func TestCompareDirectories(t *testing.T) {
 	}
 }
 
+func TestNewCustomHasher(t *testing.T) {
+	calls := 0
+	h := NewCustomHasher("fips-sha256", func() hash.Hash {
+		calls++
+		return sha256.New()
+	}, 0)
+
+	if name := h.GetAlgorithmName(); name != "fips-sha256" {
+		t.Errorf("GetAlgorithmName() = %s, 期待値 fips-sha256", name)
+	}
+	got, err := h.HashReader(strings.NewReader("hello"))
+	if err != nil {
+		t.Fatalf("HashReaderが失敗しました: %v", err)
+	}
+	sum := sha256.Sum256([]byte("hello"))
+	if got != hex.EncodeToString(sum[:]) {
+		t.Errorf("HashReader() = %s, 期待値 %s", got, hex.EncodeToString(sum[:]))
+	}
+	if _, err := h.NewHash(); err != nil {
+		t.Errorf("NewHashが失敗しました: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("ハッシュ関数の作成回数 = %d, 期待値 2", calls)
+	}
+}
+
 func TestGetAlgorithmName(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -0,0 +1,354 @@
+// Package jobs は複数の同期ジョブと依存関係を記述したマニフェスト（jobs.yaml）を読み込み、
+// 依存関係の順に実行して結果を1つのレポートにまとめる
+package jobs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sakuhanight/gopier/internal/runsummary"
+)
+
+// Job はマニフェストに記述した1つのジョブ（gopierの1回の実行）
+type Job struct {
+	Name   string   `yaml:"name"`
+	Config string   `yaml:"config,omitempty"` // ジョブの設定ファイル（相対パスはマニフェストのディレクトリを基準にする）
+	Args   []string `yaml:"args,omitempty"`   // gopierに渡す引数（サブコマンドを含む）
+	Needs  []string `yaml:"needs,omitempty"`  // 先に成功している必要があるジョブの名前
+}
+
+// Manifest は複数のジョブと依存関係を記述したマニフェスト
+type Manifest struct {
+	MaxParallel int    `yaml:"max_parallel,omitempty"` // 同時に実行するジョブの最大数（0は制限しない）
+	Report      string `yaml:"report,omitempty"`       // 統合したレポートの保存先（相対パスはマニフェストのディレクトリを基準にする）
+	Jobs        []Job  `yaml:"jobs"`
+
+	path string
+}
+
+// Load はマニフェストを読み込んで検証する（不明な項目はエラーにする）
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("マニフェストの読み込みに失敗: %w", err)
+	}
+
+	var m Manifest
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&m); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("%s: マニフェストの形式が不正です: %w", path, err)
+	}
+	if m.path, err = filepath.Abs(path); err != nil {
+		return nil, fmt.Errorf("マニフェストのパスの解決に失敗: %w", err)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &m, nil
+}
+
+// Dir はマニフェストのディレクトリ（ジョブを実行するディレクトリ）を返す
+func (m *Manifest) Dir() string {
+	if m.path == "" {
+		return "."
+	}
+	return filepath.Dir(m.path)
+}
+
+// Validate はジョブの名前と依存関係を検証する（循環する依存関係はエラーにする）
+func (m *Manifest) Validate() error {
+	if len(m.Jobs) == 0 {
+		return fmt.Errorf("ジョブが記述されていません")
+	}
+	if m.MaxParallel < 0 {
+		return fmt.Errorf("max_parallelは0以上で指定してください: %d", m.MaxParallel)
+	}
+
+	index := make(map[string]int, len(m.Jobs))
+	for i, job := range m.Jobs {
+		if job.Name == "" {
+			return fmt.Errorf("%d番目のジョブの名前が指定されていません", i+1)
+		}
+		if strings.ContainsAny(job.Name, " \t/\\") {
+			return fmt.Errorf("ジョブの名前に空白・パスの区切り文字は使用できません: %s", job.Name)
+		}
+		if _, ok := index[job.Name]; ok {
+			return fmt.Errorf("ジョブの名前が重複しています: %s", job.Name)
+		}
+		index[job.Name] = i
+	}
+	for _, job := range m.Jobs {
+		for _, need := range job.Needs {
+			if _, ok := index[need]; !ok {
+				return fmt.Errorf("ジョブ %s が依存するジョブ %s がありません", job.Name, need)
+			}
+		}
+	}
+
+	// 深さ優先探索で循環を検出する（0: 未訪問, 1: 探索中, 2: 探索済み）
+	visited := make([]int, len(m.Jobs))
+	var visit func(i int, path []string) error
+	visit = func(i int, path []string) error {
+		path = append(path, m.Jobs[i].Name)
+		switch visited[i] {
+		case 1:
+			return fmt.Errorf("ジョブの依存関係が循環しています: %s", strings.Join(path, " -> "))
+		case 2:
+			return nil
+		}
+		visited[i] = 1
+		for _, need := range m.Jobs[i].Needs {
+			if err := visit(index[need], path); err != nil {
+				return err
+			}
+		}
+		visited[i] = 2
+		return nil
+	}
+	for i := range m.Jobs {
+		if err := visit(i, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReportPath はレポートの保存先を返す（指定していない場合は空）
+func (m *Manifest) ReportPath() string {
+	if m.Report == "" || filepath.IsAbs(m.Report) {
+		return m.Report
+	}
+	return filepath.Join(m.Dir(), m.Report)
+}
+
+// Status はジョブの実行結果の状態
+type Status string
+
+const (
+	// StatusSucceeded は終了コード0で終了したジョブ
+	StatusSucceeded Status = "succeeded"
+	// StatusFailed は終了コード0以外で終了した、または起動できなかったジョブ
+	StatusFailed Status = "failed"
+	// StatusSkipped は依存するジョブが成功しなかったため実行しなかったジョブ
+	StatusSkipped Status = "skipped"
+)
+
+// Result はジョブの実行結果
+type Result struct {
+	Name       string              `json:"name"`
+	Args       []string            `json:"args"`
+	Needs      []string            `json:"needs,omitempty"`
+	Status     Status              `json:"status"`
+	ExitCode   int                 `json:"exit_code"`
+	Error      string              `json:"error,omitempty"`
+	StartedAt  *time.Time          `json:"started_at,omitempty"` // 実行しなかった場合はnil
+	FinishedAt *time.Time          `json:"finished_at,omitempty"`
+	Seconds    float64             `json:"seconds"`
+	Summary    *runsummary.Summary `json:"summary,omitempty"` // コピーのジョブの実行結果（--summary-json）
+}
+
+// Report はすべてのジョブの実行結果をまとめたレポート
+type Report struct {
+	Manifest     string    `json:"manifest"`
+	StartedAt    time.Time `json:"started_at"`
+	FinishedAt   time.Time `json:"finished_at"`
+	Seconds      float64   `json:"seconds"`
+	Succeeded    int       `json:"succeeded"`
+	Failed       int       `json:"failed"`
+	Skipped      int       `json:"skipped"`
+	FilesCopied  int64     `json:"files_copied"` // コピーのジョブの合計
+	FilesSkipped int64     `json:"files_skipped"`
+	FilesFailed  int64     `json:"files_failed"`
+	BytesCopied  int64     `json:"bytes_copied"`
+	Jobs         []Result  `json:"jobs"` // マニフェストに記述した順
+}
+
+// OK はすべてのジョブが成功したかどうかを返す
+func (r *Report) OK() bool {
+	return r.Failed == 0 && r.Skipped == 0
+}
+
+// RunFunc はジョブを実行して、終了コード・エラー・コピーの実行結果を設定した結果を返す
+// 複数のジョブに対して並行して呼び出される
+type RunFunc func(job Job) Result
+
+// EventKind は進捗の通知の種類
+type EventKind string
+
+const (
+	// EventStarted はジョブを開始した
+	EventStarted EventKind = "started"
+	// EventFinished はジョブが終了した（実行しなかった場合を含む）
+	EventFinished EventKind = "finished"
+)
+
+// Event は進捗の通知
+type Event struct {
+	Kind    EventKind
+	Job     string
+	Result  *Result  // 終了した場合の結果
+	Running []string // 実行中のジョブ
+	Done    int      // 終了したジョブの数
+	Total   int
+}
+
+// Run は依存するジョブがすべて成功したジョブから順に、MaxParallelまで並行して実行する
+// 依存するジョブが失敗した（または実行しなかった）ジョブは実行せず、結果を実行しなかったとする
+// notifyには進捗を通知する（nilの場合は通知しない、呼び出しは直列化される）
+func Run(m *Manifest, run RunFunc, notify func(Event)) *Report {
+	report := &Report{Manifest: m.path, StartedAt: time.Now(), Jobs: make([]Result, len(m.Jobs))}
+	index := make(map[string]int, len(m.Jobs))
+	for i, job := range m.Jobs {
+		index[job.Name] = i
+	}
+
+	type finished struct {
+		i      int
+		result Result
+	}
+	states := make([]Status, len(m.Jobs)) // 空は待機中
+	running := make(map[int]bool)
+	done := make(chan finished)
+	var wg sync.WaitGroup
+	completed := 0
+
+	send := func(kind EventKind, i int) {
+		if notify == nil {
+			return
+		}
+		event := Event{Kind: kind, Job: m.Jobs[i].Name, Done: completed, Total: len(m.Jobs)}
+		if kind == EventFinished {
+			event.Result = &report.Jobs[i]
+		}
+		for j, job := range m.Jobs {
+			if running[j] {
+				event.Running = append(event.Running, job.Name)
+			}
+		}
+		notify(event)
+	}
+	finish := func(i int, result Result) {
+		result.Name = m.Jobs[i].Name
+		result.Args = m.Jobs[i].Args
+		result.Needs = m.Jobs[i].Needs
+		if result.Status == "" {
+			result.Status = StatusSucceeded
+			if result.ExitCode != 0 || result.Error != "" {
+				result.Status = StatusFailed
+			}
+		}
+		states[i] = result.Status
+		report.Jobs[i] = result
+		completed++
+		send(EventFinished, i)
+	}
+
+	for completed < len(m.Jobs) {
+		// 開始できるジョブを開始する（実行しなかったジョブに依存するジョブも続けて判定する）
+		for changed := true; changed; {
+			changed = false
+			for i, job := range m.Jobs {
+				if states[i] != "" || running[i] {
+					continue
+				}
+				ready := true
+				var blocked string
+				for _, need := range job.Needs {
+					switch states[index[need]] {
+					case StatusSucceeded:
+					case StatusFailed, StatusSkipped:
+						blocked = need
+					default:
+						ready = false
+					}
+				}
+				if blocked != "" {
+					finish(i, Result{Status: StatusSkipped, Error: fmt.Sprintf("依存するジョブ %s が成功しなかったため実行しませんでした", blocked)})
+					changed = true
+					continue
+				}
+				if !ready || (m.MaxParallel > 0 && len(running) >= m.MaxParallel) {
+					continue
+				}
+				running[i] = true
+				send(EventStarted, i)
+				wg.Add(1)
+				go func(i int, job Job) {
+					defer wg.Done()
+					started := time.Now()
+					result := run(job)
+					finishedAt := time.Now()
+					result.StartedAt, result.FinishedAt = &started, &finishedAt
+					result.Seconds = finishedAt.Sub(started).Seconds()
+					done <- finished{i: i, result: result}
+				}(i, job)
+			}
+		}
+		if completed == len(m.Jobs) {
+			break
+		}
+
+		f := <-done
+		delete(running, f.i)
+		finish(f.i, f.result)
+	}
+	wg.Wait()
+
+	report.FinishedAt = time.Now()
+	report.Seconds = report.FinishedAt.Sub(report.StartedAt).Seconds()
+	for _, result := range report.Jobs {
+		switch result.Status {
+		case StatusSucceeded:
+			report.Succeeded++
+		case StatusFailed:
+			report.Failed++
+		case StatusSkipped:
+			report.Skipped++
+		}
+		if s := result.Summary; s != nil {
+			report.FilesCopied += s.FilesCopied
+			report.FilesSkipped += s.FilesSkipped
+			report.FilesFailed += s.FilesFailed
+			report.BytesCopied += s.BytesCopied
+		}
+	}
+	return report
+}
+
+// WriteJSON はレポートをJSONで書き出す
+func WriteJSON(w io.Writer, report *Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// WriteText はレポートを人が読む形式で書き出す
+func WriteText(w io.Writer, report *Report) error {
+	for _, result := range report.Jobs {
+		line := fmt.Sprintf("%-20s %-9s", result.Name, result.Status)
+		if result.Status != StatusSkipped {
+			line += fmt.Sprintf(" 終了コード %d, %.1f秒", result.ExitCode, result.Seconds)
+		}
+		if s := result.Summary; s != nil {
+			line += fmt.Sprintf(", コピー: %d, スキップ: %d, 失敗: %d", s.FilesCopied, s.FilesSkipped, s.FilesFailed)
+		}
+		if result.Error != "" {
+			line += " (" + result.Error + ")"
+		}
+		fmt.Fprintln(w, line)
+	}
+	_, err := fmt.Fprintf(w, "ジョブ: 成功 %d, 失敗 %d, 未実行 %d / ファイル: コピー %d, スキップ %d, 失敗 %d, %dバイト (%.1f秒)\n",
+		report.Succeeded, report.Failed, report.Skipped,
+		report.FilesCopied, report.FilesSkipped, report.FilesFailed, report.BytesCopied, report.Seconds)
+	return err
+}
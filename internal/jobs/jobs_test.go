@@ -0,0 +1,166 @@
+package jobs
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/runsummary"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "jobs.yaml")
+	os.WriteFile(path, []byte(`max_parallel: 2
+report: report.json
+jobs:
+  - name: copy-a
+    config: a.yaml
+    args: ["-s", "/a", "-d", "/backup/a"]
+  - name: verify
+    needs: [copy-a]
+    args: ["verify", "/a", "/backup/a"]
+`), 0644)
+
+	m, err := Load(path)
+	if err != nil {
+		t.Fatalf("Loadが失敗しました: %v", err)
+	}
+	if m.MaxParallel != 2 || len(m.Jobs) != 2 || m.Jobs[1].Needs[0] != "copy-a" || m.Jobs[0].Config != "a.yaml" {
+		t.Errorf("読み込んだマニフェスト = %+v", m)
+	}
+	if m.ReportPath() != filepath.Join(dir, "report.json") {
+		t.Errorf("ReportPath() = %s", m.ReportPath())
+	}
+
+	// 不明な項目はエラーにする
+	os.WriteFile(path, []byte("jobs:\n  - name: a\n    need: [b]\n"), 0644)
+	if _, err := Load(path); err == nil {
+		t.Error("不明な項目がエラーになりません")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		jobs []Job
+		want string
+	}{
+		{"ジョブなし", nil, "ジョブが記述されていません"},
+		{"名前なし", []Job{{}}, "名前が指定されていません"},
+		{"重複", []Job{{Name: "a"}, {Name: "a"}}, "重複"},
+		{"不明な依存", []Job{{Name: "a", Needs: []string{"b"}}}, "がありません"},
+		{"循環", []Job{{Name: "a", Needs: []string{"c"}}, {Name: "b", Needs: []string{"a"}}, {Name: "c", Needs: []string{"b"}}}, "循環"},
+		{"自身への依存", []Job{{Name: "a", Needs: []string{"a"}}}, "循環"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Manifest{Jobs: tt.jobs}).Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() = %v, want %q を含むエラー", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	m := &Manifest{Jobs: []Job{
+		{Name: "copy-a"},
+		{Name: "copy-b"},
+		{Name: "verify", Needs: []string{"copy-a", "copy-b"}},
+		{Name: "acl", Needs: []string{"verify"}},
+	}}
+
+	var mu sync.Mutex
+	var order []string
+	running, maxRunning := 0, 0
+	report := Run(m, func(job Job) Result {
+		mu.Lock()
+		order = append(order, job.Name)
+		running++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return Result{Summary: &runsummary.Summary{FilesCopied: 2, BytesCopied: 100}}
+	}, nil)
+
+	if !report.OK() || report.Succeeded != 4 {
+		t.Fatalf("レポート = %+v", report)
+	}
+	if maxRunning != 2 {
+		t.Errorf("同時に実行したジョブの最大数 = %d, want 2（依存関係のないジョブを並行して実行する）", maxRunning)
+	}
+	if strings.Join(order[2:], ",") != "verify,acl" {
+		t.Errorf("実行順 = %v", order)
+	}
+	if report.FilesCopied != 8 || report.BytesCopied != 400 {
+		t.Errorf("合計 = %dファイル, %dバイト", report.FilesCopied, report.BytesCopied)
+	}
+	for i, result := range report.Jobs {
+		if result.Name != m.Jobs[i].Name || result.StartedAt == nil || result.FinishedAt.Before(*result.StartedAt) {
+			t.Errorf("%d番目の結果 = %+v", i, result)
+		}
+	}
+}
+
+func TestRun_FailedDependency(t *testing.T) {
+	m := &Manifest{MaxParallel: 1, Jobs: []Job{
+		{Name: "copy-a"},
+		{Name: "copy-b"},
+		{Name: "verify", Needs: []string{"copy-a", "copy-b"}},
+		{Name: "acl", Needs: []string{"verify"}},
+		{Name: "report", Needs: []string{"copy-b"}},
+	}}
+
+	var events []Event
+	report := Run(m, func(job Job) Result {
+		if job.Name == "copy-a" {
+			return Result{ExitCode: 2}
+		}
+		return Result{}
+	}, func(e Event) {
+		events = append(events, e)
+	})
+
+	want := map[string]Status{
+		"copy-a": StatusFailed,
+		"copy-b": StatusSucceeded,
+		"verify": StatusSkipped,
+		"acl":    StatusSkipped,
+		"report": StatusSucceeded,
+	}
+	for _, result := range report.Jobs {
+		if result.Status != want[result.Name] {
+			t.Errorf("%s の状態 = %s, want %s", result.Name, result.Status, want[result.Name])
+		}
+	}
+	if report.Failed != 1 || report.Skipped != 2 || report.OK() {
+		t.Errorf("レポート = 失敗 %d, 未実行 %d", report.Failed, report.Skipped)
+	}
+	if !strings.Contains(report.Jobs[3].Error, "verify") {
+		t.Errorf("未実行の理由 = %q", report.Jobs[3].Error)
+	}
+
+	// 同時に実行するのは1件まで
+	for _, e := range events {
+		if len(e.Running) > 1 {
+			t.Errorf("同時に実行したジョブ = %v", e.Running)
+		}
+	}
+	if last := events[len(events)-1]; last.Kind != EventFinished || last.Done != 5 || last.Total != 5 {
+		t.Errorf("最後の通知 = %+v", last)
+	}
+
+	var buf bytes.Buffer
+	WriteText(&buf, report)
+	if !strings.Contains(buf.String(), "成功 2, 失敗 1, 未実行 2") {
+		t.Errorf("WriteText = %s", buf.String())
+	}
+}
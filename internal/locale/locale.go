@@ -0,0 +1,103 @@
+// Package locale は同期DBに記録した言語に依存しないコード（エラーコード・理由）を、表示する時点で
+// 選択した言語のメッセージに変換する。DBとエクスポートの状態・コードの値は言語によらず同じで、
+// 利用者向けのメッセージの列のみが変わる
+package locale
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/errcode"
+)
+
+// Locale は表示に使用する言語
+type Locale string
+
+const (
+	// Japanese は日本語（デフォルト）
+	Japanese Locale = "ja"
+	// English は英語
+	English Locale = "en"
+)
+
+// Parse は言語の指定を解析する（ja_JP.UTF-8・en-USのような地域・文字コード付きの指定も受け付ける）
+func Parse(s string) (Locale, error) {
+	lang := strings.ToLower(strings.TrimSpace(s))
+	if i := strings.IndexAny(lang, "_-."); i >= 0 {
+		lang = lang[:i]
+	}
+	switch Locale(lang) {
+	case "", Japanese:
+		return Japanese, nil
+	case English:
+		return English, nil
+	default:
+		return "", fmt.Errorf("サポートされていない言語: %s (ja, enのいずれかを指定してください)", s)
+	}
+}
+
+// messages はエラーコード・理由ごとのメッセージ
+var messages = map[string]map[Locale]string{
+	string(errcode.EUnknown):       {Japanese: "分類されないエラー", English: "unclassified error"},
+	string(errcode.ECancelled):     {Japanese: "キャンセルされました", English: "cancelled"},
+	string(errcode.ETimeout):       {Japanese: "タイムアウトしました", English: "timed out"},
+	string(errcode.ESrcMissing):    {Japanese: "ソースが存在しません", English: "source does not exist"},
+	string(errcode.ESrcRead):       {Japanese: "ソースのファイルを確認・読み込みできません", English: "cannot read source file"},
+	string(errcode.EDstMissing):    {Japanese: "宛先ファイルが存在しません", English: "destination file does not exist"},
+	string(errcode.EDstRead):       {Japanese: "宛先のファイルを確認・読み込みできません", English: "cannot read destination file"},
+	string(errcode.EDstWrite):      {Japanese: "宛先のファイル・ディレクトリを作成・書き込みできません", English: "cannot write destination file or directory"},
+	string(errcode.EHashMismatch):  {Japanese: "ハッシュ値が一致しません", English: "hash mismatch"},
+	string(errcode.ESizeMismatch):  {Japanese: "ファイルサイズが一致しません", English: "size mismatch"},
+	string(errcode.EOwnerMismatch): {Japanese: "所有者が一致しません", English: "owner mismatch"},
+	string(errcode.EMTimeMismatch): {Japanese: "更新日時が一致しません", English: "modification time mismatch"},
+	string(errcode.ELinkMismatch):  {Japanese: "リンク先が一致しません", English: "link target mismatch"},
+	string(errcode.EVerifyFailed):  {Japanese: "検証で不一致が検出されました", English: "verification failed"},
+	string(errcode.EACLCopy):       {Japanese: "アクセス権をコピーできません", English: "cannot copy permissions"},
+	string(errcode.EConflict):      {Japanese: "宛先の方が新しいファイルです", English: "destination is newer than source"},
+	string(errcode.ELocked):        {Japanese: "ファイルは他のプロセスが使用中です", English: "file is in use by another process"},
+	string(errcode.EPreflight):     {Japanese: "宛先で必要な操作ができません", English: "preflight check failed"},
+	string(errcode.EReadOnly):      {Japanese: "宛先は読み取り専用でマウントされています", English: "destination is mounted read-only"},
+	string(errcode.EDatabase):      {Japanese: "データベースエラー", English: "database error"},
+
+	string(database.ReasonFiltered):         {Japanese: "フィルタによりスキップ", English: "skipped by filter"},
+	string(database.ReasonFlattenCollision): {Japanese: "フラット化によるファイル名の衝突", English: "file name collision when flattening"},
+	string(database.ReasonDestExists):       {Japanese: "宛先ファイルが既に存在します", English: "destination file already exists"},
+	string(database.ReasonDestNewer):        {Japanese: "宛先の方が新しいためスキップ", English: "skipped because destination is newer"},
+	string(database.ReasonInterrupted):      {Japanese: "コピーが中断されました", English: "copy was interrupted"},
+	string(database.ReasonIntermittent):     {Japanese: "ハッシュ値が一度一致せず、再検証で一致しました", English: "hash matched on recheck after an initial mismatch"},
+	string(database.ReasonExtra):            {Japanese: "ソースに存在しない余分なファイルです", English: "extra file not present in source"},
+	string(database.ReasonExtraDeleted):     {Japanese: "ソースに存在しない余分なファイルを削除しました", English: "deleted extra file not present in source"},
+	string(database.ReasonExtraQuarantined): {Japanese: "ソースに存在しない余分なファイルを隔離しました", English: "quarantined extra file not present in source"},
+}
+
+// Message はエラーコード・理由のメッセージを返す（未知のコードの場合は空文字列）
+func Message(l Locale, code string) string {
+	return messages[code][l]
+}
+
+// FileMessage はファイル情報のLastErrorを表示するメッセージを返す
+// 日本語ではDBに記録した詳細なメッセージ（パスやOSのエラーを含む）をそのまま使用し、
+// 他の言語ではエラーコード・理由のメッセージに変換する（未知のコードの場合は記録したメッセージ）
+func FileMessage(l Locale, file *database.FileInfo) string {
+	return translate(l, file.LastError, file.Code())
+}
+
+// TargetMessage は宛先ごとの同期状態のLastErrorを表示するメッセージを返す
+func TargetMessage(l Locale, target database.TargetStatus) string {
+	code := string(target.Code)
+	if code == "" && target.LastError != "" {
+		code = string(errcode.EUnknown)
+	}
+	return translate(l, target.LastError, code)
+}
+
+func translate(l Locale, recorded, code string) string {
+	if recorded == "" || l == Japanese {
+		return recorded
+	}
+	if message := Message(l, code); message != "" {
+		return message
+	}
+	return recorded
+}
@@ -0,0 +1,67 @@
+package locale
+
+import (
+	"testing"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/errcode"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Locale
+		ok    bool
+	}{
+		{"", Japanese, true},
+		{"ja", Japanese, true},
+		{"ja_JP.UTF-8", Japanese, true},
+		{"en", English, true},
+		{"EN-us", English, true},
+		{"fr", "", false},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.input)
+		if got != tt.want || (err == nil) != tt.ok {
+			t.Errorf("Parse(%q) = %q, %v", tt.input, got, err)
+		}
+	}
+}
+
+// TestMessages はすべてのエラーコード・理由に各言語のメッセージがあることを確認する
+func TestMessages(t *testing.T) {
+	codes := []string{
+		string(errcode.EUnknown), string(errcode.ECancelled), string(errcode.ETimeout), string(errcode.ESrcMissing),
+		string(errcode.ESrcRead), string(errcode.EDstMissing), string(errcode.EDstRead), string(errcode.EDstWrite),
+		string(errcode.EHashMismatch), string(errcode.ESizeMismatch), string(errcode.EOwnerMismatch), string(errcode.EMTimeMismatch),
+		string(errcode.ELinkMismatch), string(errcode.EVerifyFailed), string(errcode.EACLCopy), string(errcode.EConflict),
+		string(errcode.ELocked), string(errcode.EPreflight), string(errcode.EReadOnly), string(errcode.EDatabase),
+		string(database.ReasonFiltered), string(database.ReasonFlattenCollision), string(database.ReasonDestExists),
+		string(database.ReasonDestNewer), string(database.ReasonInterrupted), string(database.ReasonIntermittent),
+		string(database.ReasonExtra), string(database.ReasonExtraDeleted), string(database.ReasonExtraQuarantined),
+	}
+	for _, code := range codes {
+		for _, l := range []Locale{Japanese, English} {
+			if Message(l, code) == "" {
+				t.Errorf("%s の %s のメッセージがありません", code, l)
+			}
+		}
+	}
+}
+
+func TestFileMessage(t *testing.T) {
+	file := &database.FileInfo{LastError: "ソースハッシュ計算エラー: EIO", Error: &errcode.Detail{Code: errcode.ESrcRead}}
+	if got := FileMessage(Japanese, file); got != file.LastError {
+		t.Errorf("FileMessage(ja) = %q", got)
+	}
+	if got := FileMessage(English, file); got != "cannot read source file" {
+		t.Errorf("FileMessage(en) = %q", got)
+	}
+	if got := FileMessage(English, &database.FileInfo{}); got != "" {
+		t.Errorf("エラーがない場合のFileMessage(en) = %q", got)
+	}
+	target := database.TargetStatus{LastError: "宛先ディレクトリ作成エラー", Code: errcode.EDstWrite}
+	if got := TargetMessage(English, target); got != "cannot write destination file or directory" {
+		t.Errorf("TargetMessage(en) = %q", got)
+	}
+}
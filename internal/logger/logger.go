@@ -19,6 +19,7 @@ type Logger struct {
 	NoProgress bool
 	mu         sync.Mutex
 	lastLine   string
+	console    *zap.AtomicLevel
 }
 
 // NewLogger は新しいロガーを作成する
@@ -48,12 +49,13 @@ func NewLogger(logFile string, verbose bool, showProgress bool) *Logger {
 	// 出力先の設定
 	var cores []zapcore.Core
 
-	// コンソール出力
+	// コンソール出力（ダッシュボード表示中は抑止できるようにレベルを可変にする）
+	consoleLevel := zap.NewAtomicLevelAt(level)
 	consoleEncoder := zapcore.NewConsoleEncoder(encoderConfig)
 	consoleCore := zapcore.NewCore(
 		consoleEncoder,
 		zapcore.Lock(os.Stdout),
-		level,
+		consoleLevel,
 	)
 	cores = append(cores, consoleCore)
 
@@ -91,6 +93,25 @@ func NewLogger(logFile string, verbose bool, showProgress bool) *Logger {
 		sugar:      zapLogger.Sugar(),
 		Verbose:    verbose,
 		NoProgress: !showProgress,
+		console:    &consoleLevel,
+	}
+}
+
+// SetConsoleEnabled はコンソールへのログ出力を切り替える
+// 無効にしてもログファイルへの出力は継続し、致命的エラーのみコンソールに出力される
+func (l *Logger) SetConsoleEnabled(enabled bool) {
+	if l.console == nil {
+		return
+	}
+
+	if enabled {
+		level := zapcore.InfoLevel
+		if l.Verbose {
+			level = zapcore.DebugLevel
+		}
+		l.console.SetLevel(level)
+	} else {
+		l.console.SetLevel(zapcore.FatalLevel)
 	}
 }
 
@@ -0,0 +1,158 @@
+// Package manifest はソースのツリーのマニフェスト（すべてのファイルのパス・サイズ・更新日時）のハッシュを計算する
+// CIでの成果物の配置のように同じ入力で繰り返し実行する場合に、前回の実行から変更がないかを
+// ファイルの内容を読み込まずに判定する
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/filter"
+	"github.com/sakuhanight/gopier/internal/fsmeta"
+	"github.com/sakuhanight/gopier/internal/pathkey"
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+// Entry はマニフェストに含まれるファイル
+type Entry struct {
+	Path    string    // ソースからの相対パス（スラッシュ区切り）
+	Size    int64     // サイズ
+	ModTime time.Time // 更新日時
+}
+
+// Manifest はソースのツリーのマニフェスト
+type Manifest struct {
+	Hash    string  // マニフェストのハッシュ（SHA-256）
+	Files   int64   // ファイル数
+	Bytes   int64   // ファイルの合計サイズ
+	Samples []Entry // 宛先の抜き取り確認に使用する、無作為に選んだファイル
+}
+
+// Options はマニフェストの計算のオプションを表す構造体
+type Options struct {
+	Filter        *filter.Filter // 含めるファイルのフィルタ
+	IncludeHidden bool           // 隠しファイルを含めるかどうか
+	IncludeSystem bool           // システムファイルを含めるかどうか
+	Settings      string         // 宛先の内容に影響する設定（異なる場合はハッシュも異なる）
+	Samples       int            // 抜き取り確認に使用するファイル数
+}
+
+// Compute はソースのツリーを走査してマニフェストを計算する
+// ディレクトリとシンボリックリンクも含めるため、空のディレクトリの追加やリンク先の変更でもハッシュが変わる
+func Compute(root string, opts Options) (*Manifest, error) {
+	if info, err := os.Stat(root); err != nil {
+		return nil, fmt.Errorf("ソースにアクセスできません: %w", err)
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("ソースがディレクトリではありません: %s", root)
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "settings\x00%s\n", opts.Settings)
+	m := &Manifest{}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if excluded(d.Name(), info, opts) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		rel, err := pathkey.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			if opts.Filter != nil && opts.Filter.ExcludesDir(path) {
+				return fs.SkipDir
+			}
+			fmt.Fprintf(h, "d\x00%s\n", rel)
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "l\x00%s\x00%s\n", rel, target)
+		case d.Type().IsRegular():
+			if opts.Filter != nil && !opts.Filter.ShouldInclude(path) {
+				return nil
+			}
+			m.add(h, Entry{Path: rel, Size: info.Size(), ModTime: info.ModTime()}, opts.Samples)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ソースの走査に失敗: %w", err)
+	}
+	m.Hash = hex.EncodeToString(h.Sum(nil))
+	return m, nil
+}
+
+// excluded は隠し・システム属性によってエントリをマニフェストに含めないかどうかを判断する
+func excluded(name string, info os.FileInfo, opts Options) bool {
+	if opts.IncludeHidden && opts.IncludeSystem {
+		return false
+	}
+	attrs := fsmeta.FileAttributes(name, info)
+	return (attrs.Hidden && !opts.IncludeHidden) || (attrs.System && !opts.IncludeSystem)
+}
+
+// add はファイルをマニフェストに加え、抜き取り確認に使用するファイルを無作為に選ぶ（リザーバーサンプリング）
+func (m *Manifest) add(h hash.Hash, entry Entry, samples int) {
+	fmt.Fprintf(h, "f\x00%s\x00%d\x00%d\n", entry.Path, entry.Size, entry.ModTime.UnixNano())
+	m.Files++
+	m.Bytes += entry.Size
+	switch {
+	case len(m.Samples) < samples:
+		m.Samples = append(m.Samples, entry)
+	case samples > 0:
+		if i := rand.Int64N(m.Files); i < int64(samples) {
+			m.Samples[i] = entry
+		}
+	}
+}
+
+// Check は抜き取ったファイルが宛先に同じサイズで存在するかを確認する
+// precisionが0より大きい場合は、更新日時の差がprecision未満であることも確認する。
+// 一致しないファイルがあった場合は、その理由をエラーで返す
+func Check(fsys vfs.FS, dest string, samples []Entry, precision time.Duration) error {
+	fsys = vfs.Or(fsys)
+	if info, err := fsys.Stat(dest); err != nil {
+		return fmt.Errorf("宛先にアクセスできません: %w", err)
+	} else if !info.IsDir() {
+		return fmt.Errorf("宛先がディレクトリではありません: %s", dest)
+	}
+
+	for _, entry := range samples {
+		info, err := fsys.Stat(filepath.Join(dest, filepath.FromSlash(entry.Path)))
+		switch {
+		case err != nil:
+			return fmt.Errorf("%s: %w", entry.Path, err)
+		case !info.Mode().IsRegular():
+			return fmt.Errorf("%s: 宛先が通常のファイルではありません", entry.Path)
+		case info.Size() != entry.Size:
+			return fmt.Errorf("%s: サイズが一致しません (ソース: %d, 宛先: %d)", entry.Path, entry.Size, info.Size())
+		case !fsmeta.ModTimeWithin(entry.ModTime, info.ModTime(), precision):
+			return fmt.Errorf("%s: 更新日時が一致しません (ソース: %s, 宛先: %s)", entry.Path,
+				entry.ModTime.Format(time.RFC3339Nano), info.ModTime().Format(time.RFC3339Nano))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/filter"
+)
+
+// writeTree はテスト用のソースのツリーを作成する
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	mtime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path, mtime, mtime)
+	}
+}
+
+func TestCompute(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"a.txt": "aaa", "sub/b.txt": "bb", "sub/c.log": "c", ".hidden": "h"})
+
+	m, err := Compute(dir, Options{Samples: 10})
+	if err != nil {
+		t.Fatalf("Compute() error = %v", err)
+	}
+	if m.Files != 3 || m.Bytes != 6 || len(m.Samples) != 3 || len(m.Hash) != 64 {
+		t.Errorf("Compute() = %+v", m)
+	}
+
+	// 同じツリーと設定からは同じハッシュになる
+	again, _ := Compute(dir, Options{Samples: 1})
+	if again.Hash != m.Hash || len(again.Samples) != 1 {
+		t.Errorf("再計算したマニフェスト = %+v, 前回のハッシュ = %s", again, m.Hash)
+	}
+
+	changes := []struct {
+		name   string
+		change func(t *testing.T)
+		opts   Options
+	}{
+		{name: "設定の変更", opts: Options{Settings: "include=*.txt"}},
+		{name: "フィルタ", opts: Options{Filter: filter.NewFilter("", "*.log")}},
+		{name: "隠しファイルを含める", opts: Options{IncludeHidden: true, IncludeSystem: true}},
+		{name: "内容の変更", change: func(t *testing.T) { writeTree(t, dir, map[string]string{"a.txt": "aaaa"}) }},
+		{name: "更新日時の変更", change: func(t *testing.T) {
+			mtime := time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)
+			os.Chtimes(filepath.Join(dir, "sub", "b.txt"), mtime, mtime)
+		}},
+		{name: "空のディレクトリの追加", change: func(t *testing.T) { os.Mkdir(filepath.Join(dir, "empty"), 0755) }},
+	}
+	prev := m.Hash
+	for _, tc := range changes {
+		if tc.change != nil {
+			tc.change(t)
+		}
+		got, err := Compute(dir, tc.opts)
+		if err != nil {
+			t.Fatalf("%s: Compute() error = %v", tc.name, err)
+		}
+		if got.Hash == prev {
+			t.Errorf("%s: ハッシュが変わっていません", tc.name)
+		}
+		if tc.change != nil {
+			prev = got.Hash
+		}
+	}
+
+	if _, err := Compute(filepath.Join(dir, "a.txt"), Options{}); err == nil {
+		t.Error("ファイルを指定したCompute()がエラーになりません")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	src, dest := t.TempDir(), t.TempDir()
+	files := map[string]string{"a.txt": "aaa", "sub/b.txt": "bb"}
+	writeTree(t, src, files)
+	writeTree(t, dest, files)
+
+	m, err := Compute(src, Options{Samples: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Check(nil, dest, m.Samples, 2*time.Second); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	// 更新日時はprecisionを指定した場合のみ比較する
+	mtime := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	os.Chtimes(filepath.Join(dest, "a.txt"), mtime, mtime)
+	if err := Check(nil, dest, m.Samples, 0); err != nil {
+		t.Errorf("更新日時を比較しないCheck() error = %v", err)
+	}
+	if err := Check(nil, dest, m.Samples, 2*time.Second); err == nil || !strings.Contains(err.Error(), "a.txt") {
+		t.Errorf("更新日時が異なるCheck() error = %v", err)
+	}
+
+	os.WriteFile(filepath.Join(dest, "sub", "b.txt"), []byte("changed"), 0644)
+	if err := Check(nil, dest, m.Samples, 0); err == nil || !strings.Contains(err.Error(), "sub/b.txt") {
+		t.Errorf("サイズが異なるCheck() error = %v", err)
+	}
+
+	os.Remove(filepath.Join(dest, "sub", "b.txt"))
+	if err := Check(nil, dest, m.Samples, 0); err == nil {
+		t.Error("宛先にないファイルのCheck()がエラーになりません")
+	}
+	if err := Check(nil, filepath.Join(dest, "missing"), nil, 0); err == nil {
+		t.Error("存在しない宛先のCheck()がエラーになりません")
+	}
+}
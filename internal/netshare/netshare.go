@@ -0,0 +1,106 @@
+// Package netshare はネットワーク共有（UNCパス・ネットワークドライブ）上のソース・宛先を扱う
+// 資格情報の期限切れや切断に実行の途中で気付くことがないよう、コピーを始める前に共有を検出して
+// アクセスできるかを確認し、必要に応じて資格情報で共有に接続する（Windowsのみ）
+package netshare
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sakuhanight/gopier/internal/runas"
+)
+
+// ErrUnsupported は共有への接続に対応していない環境で返される
+var ErrUnsupported = errors.New("この環境ではネットワーク共有への接続に対応していません")
+
+// Share はネットワーク共有上のパスとその共有
+type Share struct {
+	Path   string // 指定されたパス
+	Remote string // 共有の名前（\\server\share）
+	Drive  string // 共有を割り当てたドライブ（例: Z:、UNCパスの場合は空）
+	// Disconnected はドライブの割り当てが記憶されているが、接続されていないことを表す
+	Disconnected bool
+}
+
+// String は共有を表示用の文字列で返す
+func (s *Share) String() string {
+	if s.Drive == "" {
+		return s.Remote
+	}
+	return fmt.Sprintf("%s (%s)", s.Drive, s.Remote)
+}
+
+// ParseUNC はUNCパス（\\server\share\...、//server/share/...、\\?\UNC\server\share\...）から
+// 共有の名前（\\server\share）を取得する。UNCパスでない場合はfalseを返す
+func ParseUNC(path string) (string, bool) {
+	p := strings.ReplaceAll(path, "/", `\`)
+	switch {
+	case len(p) >= 8 && strings.EqualFold(p[:8], `\\?\UNC\`):
+		p = p[8:]
+	case strings.HasPrefix(p, `\\?\`), strings.HasPrefix(p, `\\.\`):
+		// デバイスのパス・ローカルの長いパス
+		return "", false
+	case strings.HasPrefix(p, `\\`):
+		p = p[2:]
+	default:
+		return "", false
+	}
+
+	server, rest, _ := strings.Cut(p, `\`)
+	share, _, _ := strings.Cut(rest, `\`)
+	if server == "" || share == "" {
+		return "", false
+	}
+	return `\\` + server + `\` + share, true
+}
+
+// Detect はパスがネットワーク共有上にある場合にその共有を返す（共有上にない場合はnil）
+// UNCパスとネットワークドライブ（Windowsのみ）を検出する
+func Detect(path string) (*Share, error) {
+	return detect(path)
+}
+
+// Check は共有のルートにアクセスできるかを確認する
+// 宛先のディレクトリはまだ存在しない場合があるため、指定されたパスではなく共有のルートを確認する
+func Check(share *Share) error {
+	if share.Disconnected {
+		return fmt.Errorf("ネットワークドライブ %s が切断されています（--connect-sharesで接続できます）", share)
+	}
+	if _, err := os.Stat(share.Remote + `\`); err != nil {
+		return fmt.Errorf("共有 %s にアクセスできません: %w%s", share, err, hint(err))
+	}
+	return nil
+}
+
+// Connection はConnectで接続した共有
+type Connection struct {
+	Share    *Share
+	Existing bool   // 実行前から接続していたかどうか（Closeで切断しない）
+	name     string // 切断に使用する名前（ドライブまたは共有の名前）
+}
+
+// Connect は資格情報で共有に接続する（credがnilの場合は実行中のユーザーの資格情報）
+// 切断されたネットワークドライブは同じドライブに再接続する。
+// interactiveがtrueの場合は、資格情報が正しくなければコンソールで入力を求める
+// 実行前から接続していた共有（ほかのプロセスやnet useの接続）は、Closeで切断しない
+func Connect(share *Share, cred *runas.Credential, interactive bool) (*Connection, error) {
+	name, err := connect(share, cred, interactive)
+	if err != nil {
+		return nil, fmt.Errorf("共有 %s に接続できません: %w%s", share, err, hint(err))
+	}
+	share.Disconnected = false
+	return &Connection{Share: share, Existing: name == "", name: name}, nil
+}
+
+// Close は共有の接続を切断する（実行前から接続していた場合は何もしない）
+func (c *Connection) Close() error {
+	if c.Existing {
+		return nil
+	}
+	if err := disconnect(c.name); err != nil {
+		return fmt.Errorf("共有 %s を切断できません: %w", c.Share, err)
+	}
+	return nil
+}
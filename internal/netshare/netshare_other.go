@@ -0,0 +1,23 @@
+//go:build !windows
+
+package netshare
+
+import "github.com/sakuhanight/gopier/internal/runas"
+
+// detect はWindows以外ではネットワーク共有を検出しない
+// （//server/share はローカルのパスで、共有はファイルシステムとしてマウントされる）
+func detect(path string) (*Share, error) {
+	return nil, nil
+}
+
+func connect(share *Share, cred *runas.Credential, interactive bool) (string, error) {
+	return "", ErrUnsupported
+}
+
+func disconnect(name string) error {
+	return ErrUnsupported
+}
+
+func hint(err error) string {
+	return ""
+}
@@ -0,0 +1,45 @@
+package netshare
+
+import "testing"
+
+func TestParseUNC(t *testing.T) {
+	tests := []struct {
+		path   string
+		remote string
+		ok     bool
+	}{
+		{`\\server\share`, `\\server\share`, true},
+		{`\\server\share\dir\file.txt`, `\\server\share`, true},
+		{`//server/share/dir`, `\\server\share`, true},
+		{`\\?\UNC\server\share\dir`, `\\server\share`, true},
+		{`\\?\unc\server\share`, `\\server\share`, true},
+		{`\\server`, "", false},
+		{`\\server\`, "", false},
+		{`\\?\C:\dir`, "", false},
+		{`\\.\PhysicalDrive0`, "", false},
+		{`C:\dir`, "", false},
+		{`/mnt/share`, "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		remote, ok := ParseUNC(tt.path)
+		if remote != tt.remote || ok != tt.ok {
+			t.Errorf("ParseUNC(%q) = %q, %t, want %q, %t", tt.path, remote, ok, tt.remote, tt.ok)
+		}
+	}
+}
+
+func TestShareString(t *testing.T) {
+	if got := (&Share{Remote: `\\server\share`}).String(); got != `\\server\share` {
+		t.Errorf("String() = %q", got)
+	}
+	if got := (&Share{Remote: `\\server\share`, Drive: "Z:"}).String(); got != `Z: (\\server\share)` {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestCheck_Disconnected(t *testing.T) {
+	if err := Check(&Share{Remote: `\\server\share`, Drive: "Z:", Disconnected: true}); err == nil {
+		t.Error("切断されたドライブのCheck()がエラーになりません")
+	}
+}
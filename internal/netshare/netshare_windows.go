@@ -0,0 +1,207 @@
+//go:build windows
+
+package netshare
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+
+	"github.com/sakuhanight/gopier/internal/runas"
+)
+
+var (
+	modmpr                    = windows.NewLazySystemDLL("mpr.dll")
+	procWNetAddConnection2W   = modmpr.NewProc("WNetAddConnection2W")
+	procWNetCancelConnection2 = modmpr.NewProc("WNetCancelConnection2W")
+	procWNetGetConnectionW    = modmpr.NewProc("WNetGetConnectionW")
+	procWNetOpenEnumW         = modmpr.NewProc("WNetOpenEnumW")
+	procWNetEnumResourceW     = modmpr.NewProc("WNetEnumResourceW")
+	procWNetCloseEnum         = modmpr.NewProc("WNetCloseEnum")
+)
+
+// WNetAddConnection2の資源の種類と接続のフラグ
+const (
+	resourceConnected  = 0x00000001
+	resourceTypeDisk   = 0x00000001
+	connectInteractive = 0x00000008
+	connectCommandLine = 0x00000800
+)
+
+// netResource はWNetAddConnection2に渡す接続先（NETRESOURCEW）
+type netResource struct {
+	Scope       uint32
+	Type        uint32
+	DisplayType uint32
+	Usage       uint32
+	LocalName   *uint16
+	RemoteName  *uint16
+	Comment     *uint16
+	Provider    *uint16
+}
+
+func detect(path string) (*Share, error) {
+	if remote, ok := ParseUNC(path); ok {
+		return &Share{Path: path, Remote: remote}, nil
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	drive := filepath.VolumeName(abs)
+	if len(drive) != 2 || drive[1] != ':' {
+		return nil, nil
+	}
+	remote, err := driveConnection(drive)
+	switch {
+	case err == nil:
+		return &Share{Path: path, Remote: remote, Drive: drive}, nil
+	case errors.Is(err, windows.ERROR_CONNECTION_UNAVAIL):
+		return &Share{Path: path, Remote: remote, Drive: drive, Disconnected: true}, nil
+	case errors.Is(err, windows.ERROR_NOT_CONNECTED), errors.Is(err, windows.ERROR_BAD_DEVICE):
+		// ローカルのドライブ
+		return nil, nil
+	}
+	return nil, err
+}
+
+// driveConnection はネットワークドライブに割り当てた共有の名前を取得する
+// 割り当てが記憶されているが接続されていない場合は、共有の名前とERROR_CONNECTION_UNAVAILを返す
+func driveConnection(drive string) (string, error) {
+	local, err := windows.UTF16PtrFromString(drive)
+	if err != nil {
+		return "", err
+	}
+	buf := make([]uint16, windows.MAX_PATH+1)
+	size := uint32(len(buf))
+	r, _, _ := procWNetGetConnectionW.Call(uintptr(unsafe.Pointer(local)), uintptr(unsafe.Pointer(&buf[0])), uintptr(unsafe.Pointer(&size)))
+	if r != 0 && syscall.Errno(r) != windows.ERROR_CONNECTION_UNAVAIL {
+		return "", syscall.Errno(r)
+	}
+	remote := windows.UTF16ToString(buf)
+	if r != 0 {
+		return remote, syscall.Errno(r)
+	}
+	return remote, nil
+}
+
+func connect(share *Share, cred *runas.Credential, interactive bool) (string, error) {
+	remote, err := windows.UTF16PtrFromString(share.Remote)
+	if err != nil {
+		return "", err
+	}
+	resource := netResource{Type: resourceTypeDisk, RemoteName: remote}
+	name := share.Remote
+	if share.Disconnected {
+		if resource.LocalName, err = windows.UTF16PtrFromString(share.Drive); err != nil {
+			return "", err
+		}
+		name = share.Drive
+	}
+
+	// ユーザー・パスワードを指定しない場合（NULL）は実行中のユーザーの資格情報を使用する
+	var user, password *uint16
+	if cred != nil {
+		if user, err = windows.UTF16PtrFromString(cred.String()); err != nil {
+			return "", err
+		}
+		if cred.Password != "" {
+			if password, err = windows.UTF16PtrFromString(cred.Password); err != nil {
+				return "", err
+			}
+		}
+	}
+	var flags uintptr
+	if interactive {
+		flags = connectInteractive | connectCommandLine
+	}
+
+	// 接続はプロセスではなくログオンセッションに属し、ほかのプロセスと共有される
+	// 実行前から接続していた共有は、終了時に切断しないよう空の名前を返す
+	existing := false
+	if !share.Disconnected {
+		if existing, err = connected(share.Remote); err != nil {
+			return "", err
+		}
+	}
+
+	r, _, _ := procWNetAddConnection2W.Call(uintptr(unsafe.Pointer(&resource)),
+		uintptr(unsafe.Pointer(password)), uintptr(unsafe.Pointer(user)), flags)
+	if r != 0 {
+		return "", syscall.Errno(r)
+	}
+	if existing {
+		return "", nil
+	}
+	return name, nil
+}
+
+// connected はログオンセッションで共有に接続しているか（ドライブの割り当てを含む）を返す
+func connected(remote string) (bool, error) {
+	var handle uintptr
+	r, _, _ := procWNetOpenEnumW.Call(resourceConnected, resourceTypeDisk, 0, 0, uintptr(unsafe.Pointer(&handle)))
+	if r != 0 {
+		return false, syscall.Errno(r)
+	}
+	defer procWNetCloseEnum.Call(handle)
+
+	buf := make([]byte, 16*1024)
+	for {
+		count := ^uint32(0) // 取得できるだけ取得する
+		size := uint32(len(buf))
+		r, _, _ := procWNetEnumResourceW.Call(handle, uintptr(unsafe.Pointer(&count)), uintptr(unsafe.Pointer(&buf[0])), uintptr(unsafe.Pointer(&size)))
+		switch syscall.Errno(r) {
+		case 0:
+		case windows.ERROR_NO_MORE_ITEMS:
+			return false, nil
+		case windows.ERROR_MORE_DATA:
+			buf = make([]byte, size)
+			continue
+		default:
+			return false, syscall.Errno(r)
+		}
+		for _, res := range unsafe.Slice((*netResource)(unsafe.Pointer(&buf[0])), count) {
+			if res.RemoteName != nil && strings.EqualFold(windows.UTF16PtrToString(res.RemoteName), remote) {
+				return true, nil
+			}
+		}
+	}
+}
+
+func disconnect(name string) error {
+	p, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return err
+	}
+	// 接続はログオンセッションに属するため、ほかのプロセスが同じ接続を使用している場合も切断される
+	// （実行前から接続していた共有はConnectで除外している）。記憶されたドライブの割り当ては変更しない
+	if r, _, _ := procWNetCancelConnection2.Call(uintptr(unsafe.Pointer(p)), 0, 0); r != 0 {
+		return syscall.Errno(r)
+	}
+	return nil
+}
+
+// hint はアクセス・接続のエラーの原因と対処の説明を返す
+func hint(err error) string {
+	switch {
+	case errors.Is(err, windows.ERROR_LOGON_FAILURE):
+		return "（ユーザー名またはパスワードが正しくありません）"
+	case errors.Is(err, windows.ERROR_PASSWORD_EXPIRED), errors.Is(err, windows.ERROR_PASSWORD_MUST_CHANGE):
+		return "（パスワードの有効期限が切れています。パスワードを変更してから実行してください）"
+	case errors.Is(err, windows.ERROR_ACCOUNT_LOCKED_OUT), errors.Is(err, windows.ERROR_ACCOUNT_DISABLED):
+		return "（アカウントがロックまたは無効化されています）"
+	case errors.Is(err, windows.ERROR_ACCESS_DENIED):
+		return "（アクセスが拒否されました。--connect-sharesと--source-user/--dest-userで資格情報を指定できます）"
+	case errors.Is(err, windows.ERROR_SESSION_CREDENTIAL_CONFLICT):
+		return "（同じサーバーに別の資格情報で接続しています。net useで既存の接続を切断してください）"
+	case errors.Is(err, windows.ERROR_BAD_NETPATH), errors.Is(err, windows.ERROR_BAD_NET_NAME),
+		errors.Is(err, windows.ERROR_NO_NET_OR_BAD_PATH), errors.Is(err, windows.ERROR_NETWORK_UNREACHABLE):
+		return "（サーバーまたは共有が見つかりません）"
+	}
+	return ""
+}
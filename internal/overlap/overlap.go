@@ -0,0 +1,119 @@
+// Package overlap はソースと宛先のディレクトリが重なっているかどうかを判定する
+// /dataを/data/backupにコピーする場合のように、宛先がソースの中にあると、コピー中に増えていく宛先を走査し続けてしまう
+package overlap
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Relation はソースと宛先の位置関係を表す型
+type Relation int
+
+const (
+	// None は重なっていない
+	None Relation = iota
+	// Same はソースと宛先が同じディレクトリ
+	Same
+	// DestInSource は宛先がソースの中にある
+	DestInSource
+	// SourceInDest はソースが宛先の中にある
+	SourceInDest
+)
+
+// Detect はソースと宛先の位置関係を返す
+// パスはシンボリックリンクを解決してから比較し、バインドマウントなどで別のパスから同じディレクトリを指す場合も同じと判定する
+func Detect(source, dest string) Relation {
+	sourcePath, destPath := Resolve(source), Resolve(dest)
+	switch {
+	case SamePath(sourcePath, destPath):
+		return Same
+	case Within(sourcePath, destPath):
+		return DestInSource
+	case Within(destPath, sourcePath):
+		return SourceInDest
+	}
+	sourceInfo, err := os.Stat(source)
+	if err != nil {
+		return None
+	}
+	if destInfo, err := os.Stat(dest); err == nil && os.SameFile(sourceInfo, destInfo) {
+		return Same
+	}
+	return None
+}
+
+// Resolve はパスを絶対パスにし、シンボリックリンクを解決する（存在しない部分はそのまま残す）
+func Resolve(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	var rest []string
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(append([]string{resolved}, rest...)...)
+		}
+		if filepath.Dir(dir) == dir {
+			return path
+		}
+		rest = append([]string{filepath.Base(dir)}, rest...)
+	}
+}
+
+// SamePath は2つのパスが同じかどうかを返す（Windowsでは大文字・小文字を区別しない）
+func SamePath(a, b string) bool {
+	a, b = filepath.Clean(a), filepath.Clean(b)
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// Within はpathがdirの中にあるかどうかを返す（同じパスの場合はfalse）
+func Within(dir, path string) bool {
+	if runtime.GOOS == "windows" {
+		dir, path = strings.ToLower(dir), strings.ToLower(path)
+	}
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Guard はソースを走査する際に、ソースの中にある宛先のディレクトリを見分ける
+// パスが一致する場合に加えて、シンボリックリンクやマウントポイントを経由して同じディレクトリに到達した場合も判定する
+type Guard struct {
+	roots []root
+}
+
+type root struct {
+	path string
+	info os.FileInfo // 確認できなかった場合はnil
+}
+
+// NewGuard は宛先のディレクトリを見分けるGuardを作成する
+// statは宛先の情報を取得する関数で、宛先のディレクトリを作成した後に呼び出す
+func NewGuard(stat func(string) (os.FileInfo, error), dests ...string) *Guard {
+	g := &Guard{}
+	for _, dest := range dests {
+		r := root{path: filepath.Clean(dest)}
+		if info, err := stat(dest); err == nil {
+			r.info = info
+		}
+		g.roots = append(g.roots, r)
+	}
+	return g
+}
+
+// IsDest はソースのディレクトリが宛先のディレクトリかどうかを返す（infoはディレクトリの情報、ない場合はnil）
+func (g *Guard) IsDest(path string, info os.FileInfo) bool {
+	if g == nil {
+		return false
+	}
+	for _, r := range g.roots {
+		if SamePath(path, r.path) || (info != nil && r.info != nil && os.SameFile(info, r.info)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package overlap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "data")
+	os.MkdirAll(filepath.Join(source, "backup"), 0755)
+	link := filepath.Join(root, "link")
+	symlinked := os.Symlink(source, link) == nil
+
+	tests := []struct {
+		name   string
+		source string
+		dest   string
+		want   Relation
+	}{
+		{"別のディレクトリ", source, filepath.Join(root, "other"), None},
+		{"名前が前方一致するだけ", source, source + "-backup", None},
+		{"同じディレクトリ", source, source + string(filepath.Separator), Same},
+		{"宛先がソースの中", source, filepath.Join(source, "backup"), DestInSource},
+		{"存在しない宛先がソースの中", source, filepath.Join(source, "new", "backup"), DestInSource},
+		{"ソースが宛先の中", filepath.Join(source, "backup"), source, SourceInDest},
+	}
+	if symlinked {
+		tests = append(tests,
+			struct {
+				name   string
+				source string
+				dest   string
+				want   Relation
+			}{"シンボリックリンク経由で同じ", link, source, Same},
+			struct {
+				name   string
+				source string
+				dest   string
+				want   Relation
+			}{"シンボリックリンク経由でソースの中", link, filepath.Join(source, "backup"), DestInSource},
+		)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect(tt.source, tt.dest); got != tt.want {
+				t.Errorf("Detect(%q, %q) = %v, want %v", tt.source, tt.dest, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGuard(t *testing.T) {
+	root := t.TempDir()
+	dest := filepath.Join(root, "data", "backup")
+	os.MkdirAll(dest, 0755)
+	link := filepath.Join(root, "link")
+
+	g := NewGuard(os.Stat, dest)
+	info, _ := os.Stat(dest)
+	if !g.IsDest(dest, nil) || !g.IsDest(dest+string(filepath.Separator), info) {
+		t.Error("宛先のパスを見分けられません")
+	}
+	if g.IsDest(filepath.Join(root, "data"), nil) {
+		t.Error("宛先以外のディレクトリを宛先と判定しました")
+	}
+
+	// 別のパスから到達した場合もディレクトリの情報で見分ける
+	if os.Symlink(filepath.Join(root, "data"), link) == nil {
+		viaLink := filepath.Join(link, "backup")
+		info, _ := os.Stat(viaLink)
+		if !g.IsDest(viaLink, info) {
+			t.Error("シンボリックリンク経由の宛先を見分けられません")
+		}
+	}
+
+	var nilGuard *Guard
+	if nilGuard.IsDest(dest, info) {
+		t.Error("nilのGuardで宛先と判定しました")
+	}
+}
@@ -0,0 +1,35 @@
+package pathkey
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Normalize はパスをデータベースのキー形式（スラッシュ区切り）に変換する
+// Windowsで作成された区切り文字（\）も含めてスラッシュに統一する
+func Normalize(path string) string {
+	key := strings.ReplaceAll(filepath.ToSlash(path), `\`, "/")
+	if len(key) > 2 && strings.HasPrefix(key, "./") {
+		return key[2:]
+	}
+	return key
+}
+
+// Rel はbaseからtargetへの相対パスをキー形式で返す
+func Rel(base, target string) (string, error) {
+	rel, err := filepath.Rel(base, target)
+	if err != nil {
+		return "", err
+	}
+	return Normalize(rel), nil
+}
+
+// ToNative はキー形式のパスを実行環境の区切り文字に変換する
+func ToNative(key string) string {
+	return filepath.FromSlash(key)
+}
+
+// NeedsMigration はキーが旧形式（バックスラッシュ区切り）かどうかを判断する
+func NeedsMigration(key string) bool {
+	return strings.Contains(key, `\`)
+}
@@ -0,0 +1,55 @@
+package pathkey
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"スラッシュ区切り", "dir/sub/file.txt", "dir/sub/file.txt"},
+		{"バックスラッシュ区切り", `dir\sub\file.txt`, "dir/sub/file.txt"},
+		{"混在", `dir\sub/file.txt`, "dir/sub/file.txt"},
+		{"カレントディレクトリ", ".", "."},
+		{"先頭の./", "./file.txt", "file.txt"},
+		{"空文字列", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.input); got != tt.expected {
+				t.Errorf("Normalize(%q): 期待値=%q, 実際=%q", tt.input, tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRel(t *testing.T) {
+	base := filepath.Join("root", "source")
+	target := filepath.Join("root", "source", "dir", "file.txt")
+
+	key, err := Rel(base, target)
+	if err != nil {
+		t.Fatalf("Relが失敗: %v", err)
+	}
+	if key != "dir/file.txt" {
+		t.Errorf("期待値=%q, 実際=%q", "dir/file.txt", key)
+	}
+
+	if native := ToNative(key); native != filepath.Join("dir", "file.txt") {
+		t.Errorf("ToNative: 期待値=%q, 実際=%q", filepath.Join("dir", "file.txt"), native)
+	}
+}
+
+func TestNeedsMigration(t *testing.T) {
+	if !NeedsMigration(`dir\file.txt`) {
+		t.Error("バックスラッシュを含むキーは移行が必要です")
+	}
+	if NeedsMigration("dir/file.txt") {
+		t.Error("スラッシュ区切りのキーは移行不要です")
+	}
+}
@@ -0,0 +1,125 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 再試行の待ち時間のデフォルト値
+const (
+	DefaultRetryWait    = time.Second
+	DefaultRetryMaxWait = 30 * time.Second
+)
+
+// Backend は宛先のストレージを提供するプラグインごとの接続の設定
+// 設定ファイルのbackendsにプラグインの名前（初期化の応答で返された名前）をキーとして記述する。
+// OSのファイルシステム（宛先のディレクトリ以外のパス）には適用しない
+type Backend struct {
+	MaxConnections int           `mapstructure:"max_connections"` // 起動するプラグインのプロセス数（同時に送る要求の数、0と1は1つ）
+	ConnectTimeout time.Duration `mapstructure:"connect_timeout"` // 接続の確立を待つ時間（プラグインに渡す）
+	IOTimeout      time.Duration `mapstructure:"io_timeout"`      // 1回の送受信を待つ時間（プラグインに渡す）
+	Retries        int           `mapstructure:"retries"`         // 一時的なエラー（unavailable）を返した要求を再試行する回数
+	RetryWait      time.Duration `mapstructure:"retry_wait"`      // 最初の再試行までの待ち時間（再試行ごとに2倍にする）
+	RetryMaxWait   time.Duration `mapstructure:"retry_max_wait"`  // 再試行までの待ち時間の上限
+	TLS            TLS           `mapstructure:"tls"`
+}
+
+// TLS はプラグインがストレージとの接続に使用するTLSの設定（プラグインに渡す）
+type TLS struct {
+	CAFile             string `mapstructure:"ca_file" json:"ca_file,omitempty"`                           // サーバー証明書の検証に使用するCA証明書
+	CertFile           string `mapstructure:"cert_file" json:"cert_file,omitempty"`                       // クライアント証明書
+	KeyFile            string `mapstructure:"key_file" json:"key_file,omitempty"`                         // クライアント証明書の秘密鍵
+	ServerName         string `mapstructure:"server_name" json:"server_name,omitempty"`                   // 検証するサーバー名（接続先のホスト名と異なる場合）
+	MinVersion         string `mapstructure:"min_version" json:"min_version,omitempty"`                   // 最小のバージョン（1.2, 1.3）
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify" json:"insecure_skip_verify,omitempty"` // サーバー証明書を検証しない
+}
+
+// Validate は設定の値を検証する
+func (b Backend) Validate() error {
+	switch {
+	case b.MaxConnections < 0:
+		return errors.New("max_connections: 0以上の値を指定してください")
+	case b.ConnectTimeout < 0:
+		return errors.New("connect_timeout: 0以上の値を指定してください")
+	case b.IOTimeout < 0:
+		return errors.New("io_timeout: 0以上の値を指定してください")
+	case b.Retries < 0:
+		return errors.New("retries: 0以上の値を指定してください")
+	case b.RetryWait < 0:
+		return errors.New("retry_wait: 0以上の値を指定してください")
+	case b.RetryMaxWait < 0:
+		return errors.New("retry_max_wait: 0以上の値を指定してください")
+	case (b.TLS.CertFile == "") != (b.TLS.KeyFile == ""):
+		return errors.New("tls: cert_fileとkey_fileは両方指定してください")
+	}
+	switch b.TLS.MinVersion {
+	case "", "1.2", "1.3":
+	default:
+		return fmt.Errorf("tls.min_version: 1.2または1.3を指定してください: %s", b.TLS.MinVersion)
+	}
+	return nil
+}
+
+// retryWait はattempt回目（0から）の再試行までの待ち時間を返す
+func (b Backend) retryWait(attempt int) time.Duration {
+	wait, max := b.RetryWait, b.RetryMaxWait
+	if wait == 0 {
+		wait = DefaultRetryWait
+	}
+	if max == 0 {
+		max = DefaultRetryMaxWait
+	}
+	for i := 0; i < attempt && wait < max; i++ {
+		wait *= 2
+	}
+	if wait > max {
+		wait = max
+	}
+	return wait
+}
+
+// configureParams は接続の設定の要求（configure）のパラメータ
+type configureParams struct {
+	ConnectTimeoutMS int64 `json:"connect_timeout_ms,omitempty"`
+	IOTimeoutMS      int64 `json:"io_timeout_ms,omitempty"`
+	TLS              *TLS  `json:"tls,omitempty"`
+}
+
+// params はプラグインに渡す接続の設定を返す（渡す設定がない場合はnil）
+func (b Backend) params() *configureParams {
+	params := &configureParams{
+		ConnectTimeoutMS: b.ConnectTimeout.Milliseconds(),
+		IOTimeoutMS:      b.IOTimeout.Milliseconds(),
+	}
+	if b.TLS != (TLS{}) {
+		tls := b.TLS
+		params.TLS = &tls
+	}
+	if *params == (configureParams{}) {
+		return nil
+	}
+	return params
+}
+
+// Configure はプラグインに接続の設定（タイムアウトとTLS）を渡す
+// 渡す設定がない場合は何もしない。設定があるのにプラグインが受け付けない場合は、設定が無視されないようエラーを返す
+func (p *Plugin) Configure(b Backend) error {
+	params := b.params()
+	if params == nil {
+		return nil
+	}
+	if !p.Has(CapConfigure) {
+		return fmt.Errorf("プラグイン(%s)は接続の設定（configure）に対応していません", p.name)
+	}
+	if err := p.Call("configure", params, nil); err != nil {
+		return fmt.Errorf("プラグイン(%s)に接続の設定を適用できません: %w", p.name, err)
+	}
+	return nil
+}
+
+// unavailable は要求が一時的なエラーで処理されなかったかどうかを返す
+func unavailable(err error) bool {
+	var pluginErr *Error
+	return errors.As(err, &pluginErr) && pluginErr.Code == CodeUnavailable
+}
@@ -0,0 +1,427 @@
+package plugin
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+// FileInfo はプラグインとやり取りするファイル情報
+type FileInfo struct {
+	Name    string      `json:"name"`
+	Size    int64       `json:"size"`
+	Mode    os.FileMode `json:"mode"`
+	ModTime time.Time   `json:"mod_time"`
+	IsDir   bool        `json:"is_dir"`
+}
+
+// fileInfo はFileInfoをos.FileInfoとして扱うための型
+type fileInfo struct {
+	info FileInfo
+}
+
+func (fi fileInfo) Name() string       { return fi.info.Name }
+func (fi fileInfo) Size() int64        { return fi.info.Size }
+func (fi fileInfo) ModTime() time.Time { return fi.info.ModTime }
+func (fi fileInfo) IsDir() bool        { return fi.info.IsDir }
+func (fi fileInfo) Sys() interface{}   { return nil }
+
+func (fi fileInfo) Mode() os.FileMode {
+	if fi.info.IsDir {
+		return fi.info.Mode | os.ModeDir
+	}
+	return fi.info.Mode
+}
+
+// remoteFS は宛先のディレクトリ以下の操作をプラグインに送るファイルシステム
+// プラグインには宛先のディレクトリからの相対パス（/区切り、宛先自体は"."）を送り、それ以外のパスはOSのファイルシステムで扱う
+type remoteFS struct {
+	conns   []*Plugin
+	next    atomic.Uint32
+	backend Backend
+	root    string
+}
+
+// FS はrootのディレクトリ以下をプラグインのストレージとして扱うファイルシステムを返す
+func (p *Plugin) FS(root string) vfs.FS {
+	return PoolFS(root, []*Plugin{p}, Backend{})
+}
+
+// PoolFS は同じプラグインを起動した複数のプロセスに要求を振り分けるファイルシステムを返す
+// パスに対する操作は順にプロセスを選び、開いたファイルの操作は開いたプロセスに送る。
+// 一時的なエラー（unavailable）を返した要求はbackendの設定に従って再試行する。
+// プラグインが再開（resume）を提供する場合は、中断した書き込みを再開できるファイルシステム（vfs.Resumable）を返す
+func PoolFS(root string, conns []*Plugin, backend Backend) vfs.FS {
+	r := &remoteFS{conns: conns, backend: backend, root: filepath.Clean(root)}
+	if conns[0].Has(CapResume) {
+		return &resumableFS{r}
+	}
+	return r
+}
+
+// conn は要求を送るプロセスを選ぶ
+func (r *remoteFS) conn() *Plugin {
+	if len(r.conns) == 1 {
+		return r.conns[0]
+	}
+	return r.conns[int(r.next.Add(1)-1)%len(r.conns)]
+}
+
+// invoke は要求をプロセスに送り、一時的なエラーの場合は待ち時間を延ばしながら再試行する
+func (r *remoteFS) invoke(p *Plugin, method string, params, result interface{}) error {
+	err := p.Call(method, params, result)
+	for attempt := 0; err != nil && attempt < r.backend.Retries && unavailable(err); attempt++ {
+		time.Sleep(r.backend.retryWait(attempt))
+		err = p.Call(method, params, result)
+	}
+	return err
+}
+
+// rel はプラグインが扱うパスの場合に、rootからの相対パスとtrueを返す
+func (r *remoteFS) rel(name string) (string, bool) {
+	rel, err := filepath.Rel(r.root, filepath.Clean(name))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// call はパスに対する操作をプラグインに送り、エラーを*os.PathErrorで返す
+// エラーの種類が指定された場合はosパッケージのエラーに置き換え、os.IsNotExistなどで判定できるようにする
+func (r *remoteFS) call(op, name, method string, params map[string]interface{}, result interface{}) error {
+	return r.callOn(r.conn(), op, name, method, params, result)
+}
+
+// callOn はパスに対する操作を指定したプロセスに送る
+func (r *remoteFS) callOn(p *Plugin, op, name, method string, params map[string]interface{}, result interface{}) error {
+	if err := r.invoke(p, method, params, result); err != nil {
+		return &os.PathError{Op: op, Path: name, Err: osError(err)}
+	}
+	return nil
+}
+
+// osError はプラグインのエラーを、種類に対応するosパッケージのエラーに置き換える
+func osError(err error) error {
+	var pluginErr *Error
+	if errors.As(err, &pluginErr) && pluginErr.Unwrap() != nil {
+		return pluginErr.Unwrap()
+	}
+	return err
+}
+
+func (r *remoteFS) stat(op, name string) (os.FileInfo, error) {
+	rel, _ := r.rel(name)
+	var info FileInfo
+	if err := r.call(op, name, "fs."+op, map[string]interface{}{"path": rel}, &info); err != nil {
+		return nil, err
+	}
+	return fileInfo{info}, nil
+}
+
+func (r *remoteFS) Stat(name string) (os.FileInfo, error) {
+	if _, ok := r.rel(name); !ok {
+		return vfs.OS.Stat(name)
+	}
+	return r.stat("stat", name)
+}
+
+func (r *remoteFS) Lstat(name string) (os.FileInfo, error) {
+	if _, ok := r.rel(name); !ok {
+		return vfs.OS.Lstat(name)
+	}
+	return r.stat("lstat", name)
+}
+
+func (r *remoteFS) ReadDir(name string) ([]os.DirEntry, error) {
+	rel, ok := r.rel(name)
+	if !ok {
+		return vfs.OS.ReadDir(name)
+	}
+	var result struct {
+		Entries []FileInfo `json:"entries"`
+	}
+	if err := r.call("readdir", name, "fs.readdir", map[string]interface{}{"path": rel}, &result); err != nil {
+		return nil, err
+	}
+	entries := make([]os.DirEntry, len(result.Entries))
+	for i := range result.Entries {
+		entries[i] = fs.FileInfoToDirEntry(fileInfo{result.Entries[i]})
+	}
+	return entries, nil
+}
+
+func (r *remoteFS) Open(name string) (vfs.File, error) {
+	return r.OpenFile(name, os.O_RDONLY, 0)
+}
+
+func (r *remoteFS) Create(name string) (vfs.File, error) {
+	return r.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+func (r *remoteFS) OpenFile(name string, flag int, perm os.FileMode) (vfs.File, error) {
+	rel, ok := r.rel(name)
+	if !ok {
+		return vfs.OS.OpenFile(name, flag, perm)
+	}
+	var result struct {
+		Handle int64 `json:"handle"`
+	}
+	p := r.conn()
+	params := map[string]interface{}{"path": rel, "flag": flag, "perm": perm}
+	if err := r.callOn(p, "open", name, "fs.open", params, &result); err != nil {
+		return nil, err
+	}
+	f := &remoteFile{fs: r, p: p, name: name, handle: result.Handle}
+	if flag&os.O_APPEND != 0 {
+		if _, err := f.Seek(0, io.SeekEnd); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (r *remoteFS) CreateTemp(dir, pattern string) (vfs.File, error) {
+	rel, ok := r.rel(dir)
+	if !ok {
+		return vfs.OS.CreateTemp(dir, pattern)
+	}
+	var result struct {
+		Handle int64  `json:"handle"`
+		Path   string `json:"path"`
+	}
+	p := r.conn()
+	if err := r.callOn(p, "createtemp", dir, "fs.create_temp", map[string]interface{}{"dir": rel, "pattern": pattern}, &result); err != nil {
+		return nil, err
+	}
+	name := filepath.Join(r.root, filepath.FromSlash(result.Path))
+	return &remoteFile{fs: r, p: p, name: name, handle: result.Handle}, nil
+}
+
+func (r *remoteFS) MkdirAll(path string, perm os.FileMode) error {
+	rel, ok := r.rel(path)
+	if !ok {
+		return vfs.OS.MkdirAll(path, perm)
+	}
+	return r.call("mkdir", path, "fs.mkdir_all", map[string]interface{}{"path": rel, "perm": perm}, nil)
+}
+
+func (r *remoteFS) Remove(name string) error {
+	rel, ok := r.rel(name)
+	if !ok {
+		return vfs.OS.Remove(name)
+	}
+	return r.call("remove", name, "fs.remove", map[string]interface{}{"path": rel}, nil)
+}
+
+func (r *remoteFS) RemoveAll(path string) error {
+	rel, ok := r.rel(path)
+	if !ok {
+		return vfs.OS.RemoveAll(path)
+	}
+	return r.call("removeall", path, "fs.remove_all", map[string]interface{}{"path": rel}, nil)
+}
+
+func (r *remoteFS) Rename(oldpath, newpath string) error {
+	oldRel, oldOK := r.rel(oldpath)
+	newRel, newOK := r.rel(newpath)
+	switch {
+	case !oldOK && !newOK:
+		return vfs.OS.Rename(oldpath, newpath)
+	case oldOK != newOK:
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: errors.New("プラグインのストレージとの間では名前を変更できません")}
+	}
+	if err := r.invoke(r.conn(), "fs.rename", map[string]interface{}{"old": oldRel, "new": newRel}, nil); err != nil {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: osError(err)}
+	}
+	return nil
+}
+
+func (r *remoteFS) Chtimes(name string, atime, mtime time.Time) error {
+	rel, ok := r.rel(name)
+	if !ok {
+		return vfs.OS.Chtimes(name, atime, mtime)
+	}
+	return r.call("chtimes", name, "fs.chtimes", map[string]interface{}{"path": rel, "atime": atime, "mtime": mtime}, nil)
+}
+
+func (r *remoteFS) Chmod(name string, mode os.FileMode) error {
+	rel, ok := r.rel(name)
+	if !ok {
+		return vfs.OS.Chmod(name, mode)
+	}
+	return r.call("chmod", name, "fs.chmod", map[string]interface{}{"path": rel, "mode": mode}, nil)
+}
+
+// remoteFile はプラグインのストレージで開いたファイル
+// 読み書きの位置はgopier側で管理し、各要求では位置を指定する
+// ハンドルは開いたプロセスでのみ有効なため、要求は常に同じプロセスに送る
+type remoteFile struct {
+	fs     *remoteFS
+	p      *Plugin
+	name   string
+	handle int64
+	offset int64
+}
+
+func (f *remoteFile) call(op, method string, params map[string]interface{}, result interface{}) error {
+	params["handle"] = f.handle
+	return f.fs.callOn(f.p, op, f.name, method, params, result)
+}
+
+func (f *remoteFile) Name() string {
+	return f.name
+}
+
+func (f *remoteFile) Read(b []byte) (int, error) {
+	n, err := f.ReadAt(b, f.offset)
+	f.offset += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+func (f *remoteFile) ReadAt(b []byte, off int64) (int, error) {
+	if len(b) == 0 {
+		return 0, nil
+	}
+	var result struct {
+		Data []byte `json:"data"`
+	}
+	if err := f.call("read", "file.read", map[string]interface{}{"offset": off, "size": len(b)}, &result); err != nil {
+		return 0, err
+	}
+	n := copy(b, result.Data)
+	if n < len(b) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *remoteFile) Write(b []byte) (int, error) {
+	n, err := f.WriteAt(b, f.offset)
+	f.offset += int64(n)
+	return n, err
+}
+
+func (f *remoteFile) WriteAt(b []byte, off int64) (int, error) {
+	var result struct {
+		N int `json:"n"`
+	}
+	if err := f.call("write", "file.write", map[string]interface{}{"offset": off, "data": b}, &result); err != nil {
+		return result.N, err
+	}
+	if result.N < len(b) {
+		return result.N, io.ErrShortWrite
+	}
+	return result.N, nil
+}
+
+func (f *remoteFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		info, err := f.Stat()
+		if err != nil {
+			return f.offset, err
+		}
+		offset += info.Size()
+	}
+	if offset < 0 {
+		return f.offset, &os.PathError{Op: "seek", Path: f.name, Err: errors.New("負の位置には移動できません")}
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *remoteFile) Stat() (os.FileInfo, error) {
+	var info FileInfo
+	if err := f.call("stat", "file.stat", map[string]interface{}{}, &info); err != nil {
+		return nil, err
+	}
+	return fileInfo{info}, nil
+}
+
+func (f *remoteFile) Truncate(size int64) error {
+	return f.call("truncate", "file.truncate", map[string]interface{}{"size": size}, nil)
+}
+
+func (f *remoteFile) Sync() error {
+	return f.call("sync", "file.sync", map[string]interface{}{}, nil)
+}
+
+func (f *remoteFile) Close() error {
+	return f.call("close", "file.close", map[string]interface{}{}, nil)
+}
+
+// resumableFS は中断した書き込みを再開できるプラグインのストレージ
+// 再開用のトークンの内容（アップロードのIDや送信済みのパートの一覧など）はプラグインが決め、gopierは同期DBに保存して返すのみ
+type resumableFS struct {
+	*remoteFS
+}
+
+// remoteFile はプラグインのストレージで開いたファイルの場合に*remoteFileを返す
+func (r *resumableFS) remoteFile(f vfs.File) (*remoteFile, error) {
+	rf, ok := f.(*remoteFile)
+	if !ok {
+		return nil, &os.PathError{Op: "checkpoint", Path: f.Name(), Err: errors.ErrUnsupported}
+	}
+	return rf, nil
+}
+
+func (r *resumableFS) Checkpoint(f vfs.File) (string, int64, error) {
+	rf, err := r.remoteFile(f)
+	if err != nil {
+		return "", 0, err
+	}
+	var result struct {
+		Token  string `json:"token"`
+		Offset int64  `json:"offset"`
+	}
+	if err := rf.call("checkpoint", "file.checkpoint", map[string]interface{}{}, &result); err != nil {
+		return "", 0, err
+	}
+	return result.Token, result.Offset, nil
+}
+
+func (r *resumableFS) Suspend(f vfs.File) error {
+	rf, err := r.remoteFile(f)
+	if err != nil {
+		return err
+	}
+	return rf.call("suspend", "file.suspend", map[string]interface{}{}, nil)
+}
+
+func (r *resumableFS) Resume(name, token string) (vfs.File, int64, error) {
+	rel, ok := r.rel(name)
+	if !ok {
+		return nil, 0, &os.PathError{Op: "resume", Path: name, Err: errors.ErrUnsupported}
+	}
+	var result struct {
+		Handle int64 `json:"handle"`
+		Offset int64 `json:"offset"`
+	}
+	p := r.conn()
+	if err := r.callOn(p, "resume", name, "fs.resume", map[string]interface{}{"path": rel, "token": token}, &result); err != nil {
+		return nil, 0, err
+	}
+	// 書き込みは再開する位置から続ける
+	return &remoteFile{fs: r.remoteFS, p: p, name: name, handle: result.Handle, offset: result.Offset}, result.Offset, nil
+}
+
+func (r *resumableFS) Discard(name, token string) error {
+	rel, ok := r.rel(name)
+	if !ok {
+		return &os.PathError{Op: "discard", Path: name, Err: errors.ErrUnsupported}
+	}
+	return r.call("discard", name, "fs.discard", map[string]interface{}{"path": rel, "token": token}, nil)
+}
@@ -0,0 +1,221 @@
+// Package plugin は外部のプログラムをプラグインとして起動し、標準入出力でやり取りする
+// メッセージは1行に1つのJSONで、gopierからの要求にプラグインが同じIDの応答を返す
+// プラグインは言語を問わず作成でき、フィルタ（コピーするかどうかの判定）・通知・宛先のストレージを提供できる
+package plugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// ProtocolVersion はプラグインとのやり取りの形式のバージョン
+const ProtocolVersion = 1
+
+// プラグインが提供する機能
+const (
+	CapFilter    = "filter"    // コピーするファイルの判定
+	CapNotify    = "notify"    // 実行の開始・完了の通知
+	CapFS        = "fs"        // 宛先のストレージ
+	CapResume    = "resume"    // 宛先のストレージへの中断した書き込みの再開（fsと合わせて提供する）
+	CapConfigure = "configure" // 接続の設定（タイムアウトとTLS）の受け付け
+)
+
+// エラーの種類（応答のerror.code）
+const (
+	CodeNotExist   = "not_exist"
+	CodeExist      = "exist"
+	CodePermission = "permission"
+	// CodeUnavailable は要求を処理せずに失敗した一時的なエラー（接続の切断やタイムアウトなど）
+	// 一部を処理した後の失敗など、同じ要求を再び送ると結果が変わる場合には使用しない
+	CodeUnavailable = "unavailable"
+)
+
+// Error はプラグインが返したエラー
+type Error struct {
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap はエラーの種類に対応するosパッケージのエラーを返す（errors.Isで判定できるようにする）
+func (e *Error) Unwrap() error {
+	switch e.Code {
+	case CodeNotExist:
+		return os.ErrNotExist
+	case CodeExist:
+		return os.ErrExist
+	case CodePermission:
+		return os.ErrPermission
+	}
+	return nil
+}
+
+// request はgopierからプラグインへの要求
+type request struct {
+	ID     int64       `json:"id"`
+	Method string      `json:"method"`
+	Params interface{} `json:"params,omitempty"`
+}
+
+// response はプラグインからの応答
+type response struct {
+	ID     int64           `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *Error          `json:"error,omitempty"`
+}
+
+// initResult は初期化の要求に対する応答
+type initResult struct {
+	Name         string   `json:"name"`
+	Capabilities []string `json:"capabilities"`
+}
+
+// Plugin は起動したプラグインを表す構造体
+// 要求は1つずつ順に送るため、複数のワーカーから同時に呼び出せる
+type Plugin struct {
+	name string
+	cmd  *exec.Cmd
+	in   io.WriteCloser
+	out  *bufio.Reader
+	caps map[string]bool
+
+	mu     sync.Mutex
+	nextID int64
+	broken error // やり取りに失敗した後は以降の要求をすべて失敗させる
+}
+
+// Start は"コマンド 引数..."の形式の指定でプラグインを起動し、提供する機能を問い合わせる
+// プラグインの標準エラー出力はgopierの標準エラー出力にそのまま出力する
+func Start(spec string) (*Plugin, error) {
+	args := strings.Fields(spec)
+	if len(args) == 0 {
+		return nil, errors.New("プラグインのコマンドが指定されていません")
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stderr = os.Stderr
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("プラグイン(%s)を起動できません: %w", args[0], err)
+	}
+
+	p, err := open(args[0], in, out)
+	if err != nil {
+		in.Close()
+		cmd.Wait()
+		return nil, err
+	}
+	p.cmd = cmd
+	return p, nil
+}
+
+// open は起動済みのプラグインの入出力で初期化を行う
+func open(name string, in io.WriteCloser, out io.Reader) (*Plugin, error) {
+	p := &Plugin{name: name, in: in, out: bufio.NewReader(out), caps: make(map[string]bool)}
+
+	var result initResult
+	if err := p.Call("init", map[string]int{"version": ProtocolVersion}, &result); err != nil {
+		return nil, fmt.Errorf("プラグイン(%s)の初期化エラー: %w", name, err)
+	}
+	if result.Name != "" {
+		p.name = result.Name
+	}
+	for _, c := range result.Capabilities {
+		p.caps[c] = true
+	}
+	return p, nil
+}
+
+// Name はプラグインの名前（初期化の応答で返された名前、ない場合はコマンド）を返す
+func (p *Plugin) Name() string {
+	return p.name
+}
+
+// Has はプラグインが機能を提供するかどうかを返す
+func (p *Plugin) Has(capability string) bool {
+	return p.caps[capability]
+}
+
+// Call は要求を送り、応答の結果をresultに読み込む（resultがnilの場合は結果を読み捨てる）
+func (p *Plugin) Call(method string, params, result interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.broken != nil {
+		return p.broken
+	}
+
+	p.nextID++
+	id := p.nextID
+	data, err := json.Marshal(request{ID: id, Method: method, Params: params})
+	if err != nil {
+		return fmt.Errorf("プラグインへの要求のシリアライズエラー: %w", err)
+	}
+	if _, err := p.in.Write(append(data, '\n')); err != nil {
+		p.broken = fmt.Errorf("プラグイン(%s)に書き込めません: %w", p.name, err)
+		return p.broken
+	}
+
+	line, err := p.out.ReadBytes('\n')
+	if err != nil {
+		p.broken = fmt.Errorf("プラグイン(%s)の応答を読み込めません: %w", p.name, err)
+		return p.broken
+	}
+	var resp response
+	if err := json.Unmarshal(line, &resp); err != nil || resp.ID != id {
+		p.broken = fmt.Errorf("プラグイン(%s)の応答が不正です: %s", p.name, strings.TrimSpace(string(line)))
+		return p.broken
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if result != nil && len(resp.Result) > 0 {
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("プラグイン(%s)の応答の結果が不正です: %w", p.name, err)
+		}
+	}
+	return nil
+}
+
+// Close はプラグインの標準入力を閉じ、終了を待つ
+func (p *Plugin) Close() error {
+	err := p.in.Close()
+	if p.cmd != nil {
+		if waitErr := p.cmd.Wait(); waitErr != nil && err == nil {
+			err = fmt.Errorf("プラグイン(%s)が異常終了しました: %w", p.name, waitErr)
+		}
+	}
+	return err
+}
+
+// Include はフィルタを提供するプラグインに、ソースのファイルをコピーするかどうかを問い合わせる
+func (p *Plugin) Include(path string) (bool, error) {
+	var result struct {
+		Include bool `json:"include"`
+	}
+	if err := p.Call("filter", map[string]string{"path": path}, &result); err != nil {
+		return false, err
+	}
+	return result.Include, nil
+}
+
+// Notify は通知を提供するプラグインにイベントを通知する
+func (p *Plugin) Notify(event string, data interface{}) error {
+	return p.Call("notify", map[string]interface{}{"event": event, "data": data}, nil)
+}
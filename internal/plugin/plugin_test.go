@@ -0,0 +1,475 @@
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/copier"
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/filter"
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+// testServer はテスト用のプラグインの実装（メモリ上のファイルシステムをストレージとして提供する）
+type testServer struct {
+	mem     *vfs.Mem
+	root    string
+	handles map[int64]vfs.File
+	next    int64
+
+	mu     sync.Mutex
+	events []string
+
+	// 中断した書き込みの再開（resume）
+	resumable   bool
+	failWriteAt int64   // この位置以降への書き込みを失敗させる（0は失敗させない）
+	resumed     []int64 // 再開した位置
+	discarded   []string
+
+	// 接続の設定（configure）と一時的なエラー
+	configurable bool
+	configured   json.RawMessage // 受け取った接続の設定
+	unavailable  int             // 残りの回数だけ要求を一時的なエラーで失敗させる
+	requests     int             // 処理した要求の数（初期化を除く）
+}
+
+func (s *testServer) serve(in io.Reader, out io.WriteCloser) {
+	defer out.Close()
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(nil, 64*1024*1024)
+	enc := json.NewEncoder(out)
+	for scanner.Scan() {
+		var req struct {
+			ID     int64           `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		json.Unmarshal(scanner.Bytes(), &req)
+		var result interface{}
+		var err error
+		if s.unavailable > 0 && req.Method != "init" {
+			s.unavailable--
+			err = &Error{Code: CodeUnavailable, Message: "接続がタイムアウトしました"}
+		} else {
+			result, err = s.handle(req.Method, req.Params)
+		}
+		resp := map[string]interface{}{"id": req.ID}
+		if err != nil {
+			e, ok := err.(*Error)
+			if !ok {
+				e = &Error{Message: err.Error()}
+			}
+			if os.IsNotExist(err) {
+				e.Code = CodeNotExist
+			}
+			resp["error"] = e
+		} else {
+			resp["result"] = result
+		}
+		enc.Encode(resp)
+	}
+}
+
+func (s *testServer) path(rel string) string {
+	return filepath.Join(s.root, filepath.FromSlash(rel))
+}
+
+func info(fi os.FileInfo) FileInfo {
+	return FileInfo{Name: fi.Name(), Size: fi.Size(), Mode: fi.Mode().Perm(), ModTime: fi.ModTime(), IsDir: fi.IsDir()}
+}
+
+func (s *testServer) handle(method string, raw json.RawMessage) (interface{}, error) {
+	var p struct {
+		Path    string      `json:"path"`
+		Event   string      `json:"event"`
+		Flag    int         `json:"flag"`
+		Perm    os.FileMode `json:"perm"`
+		Mode    os.FileMode `json:"mode"`
+		Dir     string      `json:"dir"`
+		Pattern string      `json:"pattern"`
+		Old     string      `json:"old"`
+		New     string      `json:"new"`
+		MTime   time.Time   `json:"mtime"`
+		Handle  int64       `json:"handle"`
+		Offset  int64       `json:"offset"`
+		Size    int64       `json:"size"`
+		Data    []byte      `json:"data"`
+		Token   string      `json:"token"`
+	}
+	json.Unmarshal(raw, &p)
+	if method != "init" {
+		s.requests++
+	}
+	f := s.handles[p.Handle]
+	if strings.HasPrefix(method, "file.") && f == nil {
+		return nil, os.ErrClosed
+	}
+
+	switch method {
+	case "init":
+		caps := []string{CapFilter, CapNotify, CapFS}
+		if s.resumable {
+			caps = append(caps, CapResume)
+		}
+		if s.configurable {
+			caps = append(caps, CapConfigure)
+		}
+		return map[string]interface{}{"name": "test", "capabilities": caps}, nil
+	case "configure":
+		s.configured = append(json.RawMessage(nil), raw...)
+		return nil, nil
+	case "filter":
+		return map[string]bool{"include": !strings.HasSuffix(p.Path, ".secret")}, nil
+	case "notify":
+		s.mu.Lock()
+		s.events = append(s.events, p.Event)
+		s.mu.Unlock()
+		return nil, nil
+	case "fs.stat", "fs.lstat":
+		fi, err := s.mem.Stat(s.path(p.Path))
+		if err != nil {
+			return nil, err
+		}
+		return info(fi), nil
+	case "fs.readdir":
+		entries, err := s.mem.ReadDir(s.path(p.Path))
+		if err != nil {
+			return nil, err
+		}
+		var result []FileInfo
+		for _, e := range entries {
+			fi, _ := e.Info()
+			result = append(result, info(fi))
+		}
+		return map[string]interface{}{"entries": result}, nil
+	case "fs.open", "fs.create_temp":
+		var file vfs.File
+		var err error
+		if method == "fs.open" {
+			file, err = s.mem.OpenFile(s.path(p.Path), p.Flag, p.Perm)
+		} else {
+			file, err = s.mem.CreateTemp(s.path(p.Dir), p.Pattern)
+		}
+		if err != nil {
+			return nil, err
+		}
+		s.next++
+		s.handles[s.next] = file
+		rel, _ := filepath.Rel(s.root, file.Name())
+		return map[string]interface{}{"handle": s.next, "path": filepath.ToSlash(rel)}, nil
+	case "fs.mkdir_all":
+		return nil, s.mem.MkdirAll(s.path(p.Path), p.Perm)
+	case "fs.remove":
+		return nil, s.mem.Remove(s.path(p.Path))
+	case "fs.remove_all":
+		return nil, s.mem.RemoveAll(s.path(p.Path))
+	case "fs.rename":
+		return nil, s.mem.Rename(s.path(p.Old), s.path(p.New))
+	case "fs.chtimes":
+		return nil, s.mem.Chtimes(s.path(p.Path), p.MTime, p.MTime)
+	case "fs.chmod":
+		return nil, s.mem.Chmod(s.path(p.Path), p.Mode)
+	case "file.read":
+		buf := make([]byte, p.Size)
+		n, err := f.ReadAt(buf, p.Offset)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		return map[string][]byte{"data": buf[:n]}, nil
+	case "file.write":
+		if s.failWriteAt > 0 && p.Offset >= s.failWriteAt {
+			return nil, &Error{Message: "接続が切断されました"}
+		}
+		n, err := f.WriteAt(p.Data, p.Offset)
+		return map[string]int{"n": n}, err
+	case "file.stat":
+		fi, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		return info(fi), nil
+	case "file.truncate":
+		return nil, f.Truncate(p.Size)
+	case "file.sync":
+		return nil, f.Sync()
+	case "file.close", "file.suspend":
+		delete(s.handles, p.Handle)
+		return nil, f.Close()
+	case "file.checkpoint":
+		// 書き込み済みの内容をそのまま再開に使用し、パスをトークンとする
+		fi, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		rel, _ := filepath.Rel(s.root, f.Name())
+		return map[string]interface{}{"token": "upload:" + filepath.ToSlash(rel), "offset": fi.Size()}, nil
+	case "fs.resume":
+		file, err := s.mem.OpenFile(s.path(strings.TrimPrefix(p.Token, "upload:")), os.O_RDWR, 0)
+		if err != nil {
+			return nil, err
+		}
+		fi, _ := file.Stat()
+		s.next++
+		s.handles[s.next] = file
+		s.resumed = append(s.resumed, fi.Size())
+		return map[string]interface{}{"handle": s.next, "offset": fi.Size()}, nil
+	case "fs.discard":
+		s.discarded = append(s.discarded, p.Token)
+		return nil, nil
+	}
+	return nil, &Error{Message: "未対応の要求です: " + method}
+}
+
+// startTestPlugin はテスト用のプラグインを入出力のパイプでつないで初期化する
+func startTestPlugin(t *testing.T, root string) (*Plugin, *testServer) {
+	t.Helper()
+	return startTestServer(t, &testServer{mem: vfs.NewMem(), root: root, handles: make(map[int64]vfs.File)})
+}
+
+// startTestServer は設定したテスト用のプラグインを起動する
+func startTestServer(t *testing.T, server *testServer) (*Plugin, *testServer) {
+	t.Helper()
+	reqR, reqW := io.Pipe()
+	respR, respW := io.Pipe()
+	go server.serve(reqR, respW)
+
+	p, err := open("test-plugin", reqW, respR)
+	if err != nil {
+		t.Fatalf("open() error = %v", err)
+	}
+	t.Cleanup(func() { p.Close() })
+	return p, server
+}
+
+func TestPlugin_FilterNotify(t *testing.T) {
+	p, server := startTestPlugin(t, "/")
+	if p.Name() != "test" || !p.Has(CapFilter) || !p.Has(CapNotify) || p.Has("unknown") {
+		t.Errorf("名前 = %q, 機能 = %v", p.Name(), p.caps)
+	}
+
+	f := filter.NewFilter("", "*.tmp")
+	f.AddCheck(func(path string) bool {
+		include, err := p.Include(path)
+		return err == nil && include
+	})
+	for path, want := range map[string]bool{"a.txt": true, "b.secret": false, "c.tmp": false} {
+		if got := f.ShouldInclude(path); got != want {
+			t.Errorf("ShouldInclude(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	if err := p.Notify("start", map[string]string{"source": "/src"}); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if len(server.events) != 1 || server.events[0] != "start" {
+		t.Errorf("通知 = %v", server.events)
+	}
+
+	err := p.Call("unknown", nil, nil)
+	if err == nil || err.Error() != "未対応の要求です: unknown" {
+		t.Errorf("Call(unknown) = %v", err)
+	}
+
+	// エラーの種類を指定したプラグインのエラーはosパッケージのエラーとして判定できる
+	if _, err := p.FS("/").Stat("/missing"); !os.IsNotExist(err) {
+		t.Errorf("Stat(/missing) = %v, want not exist", err)
+	}
+}
+
+func TestPlugin_FS(t *testing.T) {
+	sourceDir := filepath.Join(t.TempDir(), "source")
+	os.MkdirAll(filepath.Join(sourceDir, "sub"), 0755)
+	os.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("hello"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "sub", "b.txt"), []byte(strings.Repeat("b", 300000)), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "c.secret"), []byte("secret"), 0644)
+
+	destDir := filepath.Join(t.TempDir(), "remote")
+	p, server := startTestPlugin(t, destDir)
+	f := filter.NewFilter("", "")
+	f.AddCheck(func(path string) bool {
+		include, _ := p.Include(path)
+		return include
+	})
+
+	options := copier.DefaultOptions()
+	options.FS = p.FS(destDir)
+	options.VerifyHash = true
+	options.Mode = copier.ModeCopyAndVerify
+	options.BufferSize = 64 * 1024
+	fc := copier.NewFileCopier(sourceDir, destDir, options, f, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFiles() error = %v", err)
+	}
+	if copied := fc.GetStats().GetCopiedCount(); copied != 2 {
+		t.Errorf("コピー件数 = %d, want 2 (失敗: %v)", copied, fc.GetFailures())
+	}
+
+	// 宛先はプラグインのストレージにのみ書き込まれる
+	if _, err := os.Stat(destDir); !os.IsNotExist(err) {
+		t.Errorf("宛先がOSのファイルシステムに作成されました: %v", err)
+	}
+	for rel, want := range map[string]int{"a.txt": 5, path.Join("sub", "b.txt"): 300000} {
+		data, err := server.mem.ReadFile(filepath.Join(destDir, filepath.FromSlash(rel)))
+		if err != nil || len(data) != want {
+			t.Errorf("%s: %d bytes, %v; want %d bytes", rel, len(data), err, want)
+		}
+	}
+	if _, err := server.mem.Stat(filepath.Join(destDir, "c.secret")); !os.IsNotExist(err) {
+		t.Errorf("プラグインで除外したファイルがコピーされました: %v", err)
+	}
+
+	// 2回目は変更がないためコピーしない（宛先の情報をプラグインから取得する）
+	fc = copier.NewFileCopier(sourceDir, destDir, options, f, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFiles() error = %v", err)
+	}
+	if copied := fc.GetStats().GetCopiedCount(); copied != 0 {
+		t.Errorf("2回目のコピー件数 = %d, want 0", copied)
+	}
+}
+
+func TestPlugin_FSResume(t *testing.T) {
+	sourceDir := filepath.Join(t.TempDir(), "source")
+	os.MkdirAll(sourceDir, 0755)
+	content := make([]byte, 300000)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	os.WriteFile(filepath.Join(sourceDir, "large.bin"), content, 0644)
+
+	destDir := filepath.Join(t.TempDir(), "remote")
+	p, server := startTestServer(t, &testServer{mem: vfs.NewMem(), root: destDir, handles: make(map[int64]vfs.File), resumable: true})
+	if _, ok := p.FS(destDir).(vfs.Resumable); !ok {
+		t.Fatal("再開に対応したプラグインのストレージがvfs.Resumableではありません")
+	}
+	syncDB, err := database.NewSyncDB(filepath.Join(t.TempDir(), "test.db"), database.NormalSync)
+	if err != nil {
+		t.Fatalf("DBの作成に失敗: %v", err)
+	}
+	defer syncDB.Close()
+
+	options := copier.DefaultOptions()
+	options.FS = p.FS(destDir)
+	options.BufferSize = 16 * 1024
+	options.MaxRetries = 0
+	options.ResumeInterval = 64 * 1024
+	copyFiles := func() *copier.FileCopier {
+		t.Helper()
+		fc := copier.NewFileCopier(sourceDir, destDir, options, nil, syncDB, nil)
+		fc.CopyFiles()
+		return fc
+	}
+
+	// 途中で書き込みに失敗すると、書き込めた位置までのトークンを記録する
+	server.failWriteAt = 200000
+	fc := copyFiles()
+	if failed := fc.GetStats().GetFailedCount(); failed != 1 {
+		t.Fatalf("1回目の失敗件数 = %d, want 1", failed)
+	}
+	token, err := syncDB.GetResumeToken("large.bin")
+	if err != nil || token == nil || token.Offset < 64*1024 || token.Offset > int64(len(content)) || token.Token != "upload:large.bin" {
+		t.Fatalf("再開用のトークン = %+v, %v", token, err)
+	}
+
+	// 2回目は記録した位置から再開する
+	server.failWriteAt = 0
+	fc = copyFiles()
+	if copied := fc.GetStats().GetCopiedCount(); copied != 1 {
+		t.Fatalf("2回目のコピー件数 = %d, want 1 (失敗: %v)", copied, fc.GetFailures())
+	}
+	if len(server.resumed) != 1 || server.resumed[0] != token.Offset {
+		t.Errorf("再開した位置 = %v, want [%d]", server.resumed, token.Offset)
+	}
+	if data, _ := server.mem.ReadFile(filepath.Join(destDir, "large.bin")); !bytes.Equal(data, content) {
+		t.Errorf("再開した宛先の内容が一致しません (%d bytes)", len(data))
+	}
+	if token, _ := syncDB.GetResumeToken("large.bin"); token != nil {
+		t.Errorf("完了後もトークンが残っています: %+v", token)
+	}
+
+	// ソースから削除されたファイルの中断した書き込みは破棄する
+	syncDB.SaveResumeToken(database.ResumeToken{Path: "deleted.bin", DestPath: "deleted.bin", Token: "upload:deleted.bin", Offset: 10})
+	copyFiles()
+	if len(server.discarded) != 1 || server.discarded[0] != "upload:deleted.bin" {
+		t.Errorf("破棄した書き込み = %v", server.discarded)
+	}
+	if token, _ := syncDB.GetResumeToken("deleted.bin"); token != nil {
+		t.Errorf("破棄した書き込みのトークンが残っています: %+v", token)
+	}
+}
+
+func TestPlugin_Configure(t *testing.T) {
+	p, server := startTestServer(t, &testServer{mem: vfs.NewMem(), root: "/", handles: make(map[int64]vfs.File), configurable: true})
+	backend := Backend{ConnectTimeout: 3 * time.Second, IOTimeout: 500 * time.Millisecond, TLS: TLS{CAFile: "ca.pem", MinVersion: "1.3"}}
+	if err := p.Configure(backend); err != nil {
+		t.Fatalf("Configure() error = %v", err)
+	}
+	want := `{"connect_timeout_ms":3000,"io_timeout_ms":500,"tls":{"ca_file":"ca.pem","min_version":"1.3"}}`
+	if string(server.configured) != want {
+		t.Errorf("接続の設定 = %s, want %s", server.configured, want)
+	}
+
+	// 接続の設定に対応しないプラグインには、渡す設定がある場合のみエラーを返す
+	plain, _ := startTestPlugin(t, "/")
+	if err := plain.Configure(Backend{MaxConnections: 4, Retries: 3}); err != nil {
+		t.Errorf("渡す設定がない場合のConfigure() error = %v", err)
+	}
+	if err := plain.Configure(backend); err == nil {
+		t.Error("接続の設定に対応しないプラグインでエラーになりませんでした")
+	}
+
+	for _, invalid := range []Backend{{MaxConnections: -1}, {Retries: -1}, {TLS: TLS{CertFile: "client.pem"}}, {TLS: TLS{MinVersion: "1.1"}}} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("Validate(%+v) でエラーになりませんでした", invalid)
+		}
+	}
+}
+
+func TestPlugin_PoolFS(t *testing.T) {
+	sourceDir := filepath.Join(t.TempDir(), "source")
+	os.MkdirAll(sourceDir, 0755)
+	for i := 0; i < 8; i++ {
+		os.WriteFile(filepath.Join(sourceDir, string(rune('a'+i))+".txt"), bytes.Repeat([]byte{byte('a' + i)}, 1000*(i+1)), 0644)
+	}
+
+	// 2つのプロセスが同じストレージに接続する（ハンドルはプロセスごと）
+	destDir := filepath.Join(t.TempDir(), "remote")
+	mem := vfs.NewMem()
+	p1, server1 := startTestServer(t, &testServer{mem: mem, root: destDir, handles: make(map[int64]vfs.File)})
+	p2, server2 := startTestServer(t, &testServer{mem: mem, root: destDir, handles: make(map[int64]vfs.File)})
+	fsys := PoolFS(destDir, []*Plugin{p1, p2}, Backend{Retries: 2, RetryWait: time.Millisecond})
+
+	options := copier.DefaultOptions()
+	options.FS = fsys
+	options.VerifyHash = true
+	options.Mode = copier.ModeCopyAndVerify
+	fc := copier.NewFileCopier(sourceDir, destDir, options, nil, nil, nil)
+	if err := fc.CopyFiles(); err != nil {
+		t.Fatalf("CopyFiles() error = %v", err)
+	}
+	if copied := fc.GetStats().GetCopiedCount(); copied != 8 {
+		t.Errorf("コピー件数 = %d, want 8 (失敗: %v)", copied, fc.GetFailures())
+	}
+	if server1.requests == 0 || server2.requests == 0 {
+		t.Errorf("要求が振り分けられていません: %d, %d", server1.requests, server2.requests)
+	}
+
+	// 一時的なエラーは再試行の回数まで再試行する
+	server1.unavailable, server2.unavailable = 2, 2
+	if _, err := fsys.Stat(filepath.Join(destDir, "a.txt")); err != nil {
+		t.Errorf("再試行したStat() error = %v", err)
+	}
+	server1.unavailable, server2.unavailable = 3, 3
+	if _, err := fsys.Stat(filepath.Join(destDir, "a.txt")); err == nil || os.IsNotExist(err) {
+		t.Errorf("再試行の回数を超えたStat() error = %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+// Package recheck はハッシュが一致しなかったファイルを、ソースと宛先を読み直して再検証する
+// 不安定なネットワークのマウントなどでの一時的な読み込みの不具合を、実際の破損と区別するために使用する
+// コピーと同時の検証（copier）と、コピー後の検証（verifier）で共通に使用する
+package recheck
+
+import (
+	"context"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/logger"
+)
+
+// Options は再検証のオプション
+type Options struct {
+	Retries int            // 再検証する最大の回数（0は再検証しない）
+	Delay   time.Duration  // 再検証の前の待ち時間
+	Logger  *logger.Logger // 再検証の経過を記録するロガー（nilの場合は記録しない）
+}
+
+// Run はhashBothで期待するハッシュと宛先のハッシュを計算し直し、一致するまで最大Retries回再検証する
+// 再検証で一致した場合は一致したハッシュとtrueを返す。attemptsは実行した再検証の回数で、
+// ctxがキャンセルされた場合は待機中の回を含めない
+func Run(ctx context.Context, opts Options, path string, hashBoth func() (expected, dest string, err error)) (expected, dest string, attempts int, ok bool) {
+	log := opts.Logger
+	for attempts = 1; attempts <= opts.Retries; attempts++ {
+		select {
+		case <-time.After(opts.Delay):
+		case <-ctx.Done():
+			return "", "", attempts - 1, false
+		}
+
+		expected, dest, err := hashBoth()
+		switch {
+		case err != nil:
+			if log != nil {
+				log.Warn("再検証 (%d/%d): %s: %v", attempts, opts.Retries, path, err)
+			}
+		case expected == dest:
+			if log != nil {
+				log.Warn("再検証 (%d/%d): %s: 一致しました（一時的な不一致）", attempts, opts.Retries, path)
+			}
+			return expected, dest, attempts, true
+		default:
+			if log != nil {
+				log.Warn("再検証 (%d/%d): %s: ハッシュ値が一致しません (期待値: %s, 宛先: %s)", attempts, opts.Retries, path, expected, dest)
+			}
+		}
+	}
+	return "", "", opts.Retries, false
+}
@@ -0,0 +1,44 @@
+package recheck
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	// 読み込みエラーの後、2回目の再検証で一致した場合
+	calls := 0
+	expected, dest, attempts, ok := Run(context.Background(), Options{Retries: 3}, "a.txt", func() (string, string, error) {
+		calls++
+		if calls == 1 {
+			return "", "", errors.New("読み込みエラー")
+		}
+		return "aaa", "aaa", nil
+	})
+	if !ok || expected != "aaa" || dest != "aaa" || attempts != 2 {
+		t.Errorf("Run() = %q, %q, %d, %v; want aaa, aaa, 2, true", expected, dest, attempts, ok)
+	}
+
+	// 実際に破損している場合は最大回数まで再検証して一致しない
+	calls = 0
+	_, _, attempts, ok = Run(context.Background(), Options{Retries: 2}, "a.txt", func() (string, string, error) {
+		calls++
+		return "aaa", "bbb", nil
+	})
+	if ok || attempts != 2 || calls != 2 {
+		t.Errorf("Run() = %d, %v (呼び出し %d回); want 2, false", attempts, ok, calls)
+	}
+
+	// キャンセルされた場合は再検証しない
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, attempts, ok = Run(ctx, Options{Retries: 2, Delay: time.Hour}, "a.txt", func() (string, string, error) {
+		t.Error("キャンセル後に再検証しました")
+		return "", "", nil
+	})
+	if ok || attempts != 0 {
+		t.Errorf("Run() = %d, %v; want 0, false", attempts, ok)
+	}
+}
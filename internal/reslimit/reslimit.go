@@ -0,0 +1,80 @@
+// Package reslimit はgopier自身のCPU・メモリ・I/Oの使用量を制限する
+// 設定を誤っても移行元・移行先のホストを停止させないために使用する。
+// CPU数（GOMAXPROCS）とGoのメモリ上限はすべての環境で設定でき、リソースグループを指定した場合は
+// Linuxではcgroup v2、WindowsではJob Objectに自身を所属させてOSに上限を強制させる
+package reslimit
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// ErrUnsupported はリソースグループに対応していない環境で返される
+var ErrUnsupported = errors.New("この環境ではリソースグループに対応していません")
+
+// Limits はgopier自身に適用する制限
+type Limits struct {
+	MaxProcs  int      // 使用するCPU数（GOMAXPROCS、0は変更しない）
+	MaxMemory int64    // メモリ使用量の上限（バイト、0は制限しない）
+	IOLimit   int64    // デバイスごとの読み込み・書き込みの上限（秒あたりのバイト数、0は制限しない、Linuxのみ）
+	Group     string   // リソースグループの名前（Linuxでは/sys/fs/cgroup配下のcgroup、WindowsではJob Object）
+	Paths     []string // I/Oを制限するデバイスを特定するためのパス（ソース・宛先）
+}
+
+// Validate は制限の指定を検証する
+func (l Limits) Validate() error {
+	if l.MaxProcs < 0 {
+		return fmt.Errorf("CPU数には0以上の値を指定してください: %d", l.MaxProcs)
+	}
+	if l.MaxMemory < 0 || l.IOLimit < 0 {
+		return fmt.Errorf("上限には0以上の値を指定してください")
+	}
+	if l.IOLimit > 0 && runtime.GOOS != "linux" {
+		return fmt.Errorf("I/Oの制限はLinuxのみ対応しています（帯域制限を使用してください）")
+	}
+	if l.IOLimit > 0 && l.Group == "" {
+		return fmt.Errorf("I/Oの制限にはリソースグループの指定が必要です")
+	}
+	if l.Group != "" && runtime.GOOS == "linux" {
+		if filepath.IsAbs(l.Group) || filepath.Clean(l.Group) != l.Group || strings.HasPrefix(l.Group, "..") {
+			return fmt.Errorf("cgroupの名前には/sys/fs/cgroupからの相対パスを指定してください: %s", l.Group)
+		}
+	}
+	return nil
+}
+
+// Apply は制限を適用し、適用した内容を表す説明（ログ出力用）を返す
+func Apply(l Limits) ([]string, error) {
+	if err := l.Validate(); err != nil {
+		return nil, err
+	}
+
+	var applied []string
+	if l.MaxProcs > 0 {
+		runtime.GOMAXPROCS(l.MaxProcs)
+		applied = append(applied, fmt.Sprintf("GOMAXPROCS=%d", l.MaxProcs))
+	}
+	if l.MaxMemory > 0 {
+		// Goのメモリ上限はGCを頻繁にして使用量を抑えるソフトリミット
+		// OSが上限を強制する場合は、強制終了される前にGCが働くよう少し低く設定する
+		soft := l.MaxMemory
+		if l.Group != "" {
+			soft = l.MaxMemory / 10 * 9
+		}
+		debug.SetMemoryLimit(soft)
+		applied = append(applied, fmt.Sprintf("Goのメモリ上限=%d", soft))
+	}
+
+	if l.Group != "" {
+		notes, err := applyGroup(l)
+		if err != nil {
+			return applied, err
+		}
+		applied = append(applied, notes...)
+	}
+	return applied, nil
+}
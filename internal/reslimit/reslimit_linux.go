@@ -0,0 +1,144 @@
+package reslimit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	cgroupRoot = "/sys/fs/cgroup" // cgroup v2のマウント先
+	cpuPeriod  = 100000           // cpu.maxの期間（マイクロ秒）
+)
+
+// applyGroup はcgroupを作成して制限を設定し、自身のプロセスを所属させる
+func applyGroup(l Limits) ([]string, error) {
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err != nil {
+		return nil, fmt.Errorf("cgroup v2が%sにマウントされていません: %w", cgroupRoot, err)
+	}
+
+	dir := filepath.Join(cgroupRoot, l.Group)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("cgroupの作成に失敗（root権限または委任されたcgroupが必要です）: %w", err)
+	}
+
+	var settings [][2]string
+	if l.MaxMemory > 0 {
+		settings = append(settings, [2]string{"memory.max", strconv.FormatInt(l.MaxMemory, 10)})
+	}
+	if l.MaxProcs > 0 {
+		settings = append(settings, [2]string{"cpu.max", fmt.Sprintf("%d %d", l.MaxProcs*cpuPeriod, cpuPeriod)})
+	}
+
+	var notes []string
+	var ioLines []string
+	if l.IOLimit > 0 {
+		devices, skipped := blockDevices(l.Paths)
+		for _, dev := range devices {
+			ioLines = append(ioLines, fmt.Sprintf("%s rbps=%d wbps=%d", dev, l.IOLimit, l.IOLimit))
+		}
+		for _, path := range skipped {
+			notes = append(notes, fmt.Sprintf("I/Oの制限の対象外（ブロックデバイス上にありません）: %s", path))
+		}
+	}
+
+	// 親のcgroupで必要なコントローラを有効にする（有効にできなかった場合は設定の書き込みで失敗する）
+	var controllers []string
+	if l.MaxMemory > 0 {
+		controllers = append(controllers, "+memory")
+	}
+	if l.MaxProcs > 0 {
+		controllers = append(controllers, "+cpu")
+	}
+	if len(ioLines) > 0 {
+		controllers = append(controllers, "+io")
+	}
+	if len(controllers) > 0 {
+		os.WriteFile(filepath.Join(filepath.Dir(dir), "cgroup.subtree_control"), []byte(strings.Join(controllers, " ")), 0644)
+	}
+
+	for _, setting := range settings {
+		if err := writeCgroupFile(dir, setting[0], setting[1]); err != nil {
+			return notes, err
+		}
+		notes = append(notes, setting[0]+"="+setting[1])
+	}
+	for _, line := range ioLines {
+		if err := writeCgroupFile(dir, "io.max", line); err != nil {
+			return notes, err
+		}
+		notes = append(notes, "io.max="+line)
+	}
+
+	// スレッドを含むプロセス全体を移動する
+	if err := writeCgroupFile(dir, "cgroup.procs", strconv.Itoa(os.Getpid())); err != nil {
+		return notes, err
+	}
+	notes = append(notes, "cgroup="+dir)
+	return notes, nil
+}
+
+// writeCgroupFile はcgroupの設定ファイルに書き込む
+func writeCgroupFile(dir, name, value string) error {
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(value), 0644); err != nil {
+		return fmt.Errorf("cgroupの設定に失敗: %s: %w", name, err)
+	}
+	return nil
+}
+
+// blockDevices はパスが存在するブロックデバイス（パーティションの場合はディスク）の"major:minor"を返す
+// ネットワークファイルシステムなど、ブロックデバイス上にないパスは2つ目の戻り値で返す
+func blockDevices(paths []string) (devices, skipped []string) {
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		dev, err := blockDevice(path)
+		if err != nil {
+			skipped = append(skipped, path)
+			continue
+		}
+		if !seen[dev] {
+			seen[dev] = true
+			devices = append(devices, dev)
+		}
+	}
+	return devices, skipped
+}
+
+// blockDevice はパスが存在するブロックデバイスを返す（宛先がまだない場合は存在する親ディレクトリで判定する）
+func blockDevice(path string) (string, error) {
+	var st unix.Stat_t
+	for {
+		err := unix.Stat(path, &st)
+		if err == nil {
+			break
+		}
+		parent := filepath.Dir(path)
+		if !errors.Is(err, unix.ENOENT) || parent == path {
+			return "", err
+		}
+		path = parent
+	}
+
+	major, minor := unix.Major(uint64(st.Dev)), unix.Minor(uint64(st.Dev))
+	if major == 0 {
+		return "", fmt.Errorf("ブロックデバイスではありません: %d:%d", major, minor)
+	}
+	dev := fmt.Sprintf("%d:%d", major, minor)
+
+	// io.maxはパーティションを受け付けないため、ディスクのデバイス番号に置き換える
+	sysDir, err := filepath.EvalSymlinks(filepath.Join("/sys/dev/block", dev))
+	if err != nil {
+		return dev, nil
+	}
+	if _, err := os.Stat(filepath.Join(sysDir, "partition")); err == nil {
+		if data, err := os.ReadFile(filepath.Join(filepath.Dir(sysDir), "dev")); err == nil {
+			dev = strings.TrimSpace(string(data))
+		}
+	}
+	return dev, nil
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !windows
+
+package reslimit
+
+// applyGroup はこの環境では対応していないため、常にErrUnsupportedを返す
+func applyGroup(l Limits) ([]string, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,59 @@
+package reslimit
+
+import (
+	"runtime"
+	"runtime/debug"
+	"testing"
+)
+
+func TestLimits_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		limits    Limits
+		wantErr   bool
+		linuxOnly bool
+	}{
+		{"指定なし", Limits{}, false, false},
+		{"CPUとメモリ", Limits{MaxProcs: 2, MaxMemory: 1 << 30}, false, false},
+		{"負のCPU数", Limits{MaxProcs: -1}, true, false},
+		{"負のメモリ", Limits{MaxMemory: -1}, true, false},
+		{"グループなしのI/O制限", Limits{IOLimit: 1 << 20}, true, false},
+		{"相対パスのcgroup", Limits{Group: "gopier/migration", IOLimit: 1 << 20}, false, true},
+		{"絶対パスのcgroup", Limits{Group: "/sys/fs/cgroup/gopier"}, true, true},
+		{"親を指すcgroup", Limits{Group: "../gopier"}, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.linuxOnly && runtime.GOOS != "linux" {
+				t.Skip("cgroupはLinuxのみ")
+			}
+			if err := tt.limits.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(0))
+	defer debug.SetMemoryLimit(debug.SetMemoryLimit(-1))
+
+	applied, err := Apply(Limits{MaxProcs: 1, MaxMemory: 512 << 20})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if got := runtime.GOMAXPROCS(0); got != 1 {
+		t.Errorf("GOMAXPROCS = %d, want 1", got)
+	}
+	if got := debug.SetMemoryLimit(-1); got != 512<<20 {
+		t.Errorf("メモリ上限 = %d, want %d", got, 512<<20)
+	}
+	if len(applied) != 2 {
+		t.Errorf("適用した内容 = %v", applied)
+	}
+
+	// 指定しない場合は何も変更しない
+	if applied, err := Apply(Limits{}); err != nil || len(applied) != 0 {
+		t.Errorf("Apply() = %v, %v", applied, err)
+	}
+}
@@ -0,0 +1,67 @@
+package reslimit
+
+import (
+	"fmt"
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// JOBOBJECT_CPU_RATE_CONTROL_INFORMATIONのフラグ
+const (
+	jobObjectCPURateControlEnable  = 0x1
+	jobObjectCPURateControlHardCap = 0x4
+)
+
+// jobObjectCPURateControlInformation はJOBOBJECT_CPU_RATE_CONTROL_INFORMATION
+type jobObjectCPURateControlInformation struct {
+	ControlFlags uint32
+	CPURate      uint32 // 全CPUに対する割合（1/10000単位）
+}
+
+// applyGroup はJob Objectを作成して制限を設定し、自身のプロセスを所属させる
+// Job Objectのハンドルは閉じると制限が解除されるため、プロセスの終了まで保持する
+func applyGroup(l Limits) ([]string, error) {
+	name, err := windows.UTF16PtrFromString(l.Group)
+	if err != nil {
+		return nil, err
+	}
+	job, err := windows.CreateJobObject(nil, name)
+	if err != nil {
+		return nil, fmt.Errorf("Job Objectの作成に失敗: %w", err)
+	}
+
+	var notes []string
+	if l.MaxMemory > 0 {
+		var info windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION
+		info.BasicLimitInformation.LimitFlags = windows.JOB_OBJECT_LIMIT_PROCESS_MEMORY
+		info.ProcessMemoryLimit = uintptr(l.MaxMemory)
+		if _, err := windows.SetInformationJobObject(job, windows.JobObjectExtendedLimitInformation,
+			uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info))); err != nil {
+			windows.CloseHandle(job)
+			return nil, fmt.Errorf("Job Objectのメモリ上限の設定に失敗: %w", err)
+		}
+		notes = append(notes, fmt.Sprintf("ProcessMemoryLimit=%d", l.MaxMemory))
+	}
+
+	if l.MaxProcs > 0 && l.MaxProcs < runtime.NumCPU() {
+		info := jobObjectCPURateControlInformation{
+			ControlFlags: jobObjectCPURateControlEnable | jobObjectCPURateControlHardCap,
+			CPURate:      uint32(l.MaxProcs * 10000 / runtime.NumCPU()),
+		}
+		if _, err := windows.SetInformationJobObject(job, windows.JobObjectCpuRateControlInformation,
+			uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info))); err != nil {
+			windows.CloseHandle(job)
+			return nil, fmt.Errorf("Job ObjectのCPU使用率の上限の設定に失敗: %w", err)
+		}
+		notes = append(notes, fmt.Sprintf("CpuRate=%d.%02d%%", info.CPURate/100, info.CPURate%100))
+	}
+
+	if err := windows.AssignProcessToJobObject(job, windows.CurrentProcess()); err != nil {
+		windows.CloseHandle(job)
+		return nil, fmt.Errorf("Job Objectへの割り当てに失敗: %w", err)
+	}
+	notes = append(notes, "JobObject="+l.Group)
+	return notes, nil
+}
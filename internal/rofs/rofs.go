@@ -0,0 +1,46 @@
+// Package rofs は読み取り専用でマウントされたファイルシステムを検出する
+// NASのフェイルオーバーの後などに宛先が読み取り専用で再マウントされると、すべてのファイルの書き込みが
+// 1つずつ失敗するため、コピーを始める前に検出して直ちに終了できるようにする
+package rofs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// Check はpathを含むファイルシステムが読み取り専用でマウントされているかを返す
+// pathが存在しない場合は、存在する最も近い親ディレクトリで判定する。
+// マウントの状態を取得できない環境では常にfalseを返す（書き込みの失敗はIsReadOnlyで判定する）
+func Check(path string) (bool, error) {
+	dir, err := existingAncestor(path)
+	if err != nil {
+		return false, err
+	}
+	return readOnly(dir)
+}
+
+// IsReadOnly はエラーが読み取り専用のファイルシステム・ボリュームへの書き込みによるものかを返す
+func IsReadOnly(err error) bool {
+	return err != nil && errors.Is(err, readOnlyErrno)
+}
+
+// existingAncestor はpath、またはpathの存在する最も近い親ディレクトリを返す
+func existingAncestor(path string) (string, error) {
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return dir, nil
+		} else if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", &os.PathError{Op: "stat", Path: path, Err: os.ErrNotExist}
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,21 @@
+//go:build darwin || freebsd
+
+package rofs
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// readOnly はマウントのフラグ（MNT_RDONLY）で判定する
+func readOnly(dir string) (bool, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(dir, &st); err != nil {
+		return false, err
+	}
+	return uint64(st.Flags)&unix.MNT_RDONLY != 0, nil
+}
+
+// readOnlyErrno は読み取り専用のファイルシステムへの書き込みのエラー
+var readOnlyErrno error = syscall.EROFS
@@ -0,0 +1,21 @@
+//go:build linux
+
+package rofs
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// readOnly はマウントのフラグ（ST_RDONLY）で判定する
+func readOnly(dir string) (bool, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(dir, &st); err != nil {
+		return false, err
+	}
+	return st.Flags&unix.ST_RDONLY != 0, nil
+}
+
+// readOnlyErrno は読み取り専用のファイルシステムへの書き込みのエラー
+var readOnlyErrno error = syscall.EROFS
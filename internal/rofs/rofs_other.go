@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package rofs
+
+import "syscall"
+
+// readOnly はこの環境ではマウントの状態を取得できないため、常にfalseを返す
+func readOnly(dir string) (bool, error) {
+	return false, nil
+}
+
+// readOnlyErrno は読み取り専用のファイルシステムへの書き込みのエラー
+var readOnlyErrno error = syscall.EROFS
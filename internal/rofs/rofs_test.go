@@ -0,0 +1,33 @@
+package rofs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheck_Writable(t *testing.T) {
+	dir := t.TempDir()
+
+	// 存在しないパスは存在する親ディレクトリで判定する
+	for _, path := range []string{dir, filepath.Join(dir, "not", "yet", "created")} {
+		readOnly, err := Check(path)
+		if err != nil {
+			t.Fatalf("Check(%s) error = %v", path, err)
+		}
+		if readOnly {
+			t.Errorf("Check(%s) = true, 書き込めるディレクトリが読み取り専用と判定されました", path)
+		}
+	}
+}
+
+func TestIsReadOnly(t *testing.T) {
+	if IsReadOnly(nil) || IsReadOnly(errors.New("other")) || IsReadOnly(&os.PathError{Op: "open", Path: "x", Err: os.ErrPermission}) {
+		t.Error("読み取り専用でないエラーがtrueになりました")
+	}
+	if !IsReadOnly(fmt.Errorf("wrap: %w", &os.PathError{Op: "open", Path: "x", Err: readOnlyErrno})) {
+		t.Error("読み取り専用のエラーがfalseになりました")
+	}
+}
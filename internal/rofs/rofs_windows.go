@@ -0,0 +1,27 @@
+//go:build windows
+
+package rofs
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+// readOnly はボリュームのフラグ（FILE_READ_ONLY_VOLUME）で判定する
+func readOnly(dir string) (bool, error) {
+	path, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return false, err
+	}
+	root := make([]uint16, windows.MAX_PATH+1)
+	if err := windows.GetVolumePathName(path, &root[0], uint32(len(root))); err != nil {
+		return false, err
+	}
+	var flags uint32
+	if err := windows.GetVolumeInformation(&root[0], nil, 0, nil, nil, &flags, nil, 0); err != nil {
+		return false, err
+	}
+	return flags&windows.FILE_READ_ONLY_VOLUME != 0, nil
+}
+
+// readOnlyErrno は書き込み禁止のボリュームへの書き込みのエラー
+var readOnlyErrno error = windows.ERROR_WRITE_PROTECT
@@ -0,0 +1,54 @@
+// Package runas は別の資格情報でファイルにアクセスする機能を提供する
+// Windowsではログオンしたユーザーを偽装し、Linuxではファイルシステムのuid/gidを切り替える。
+// どちらもOSスレッド単位で切り替えるため、Doの中で他のゴルーチンを起動してはならない
+package runas
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrUnsupported は別の資格情報でのアクセスに対応していない環境で返される
+var ErrUnsupported = errors.New("この環境では別の資格情報でのアクセスに対応していません")
+
+// Credential はアクセスに使用する資格情報を表す構造体
+type Credential struct {
+	User     string
+	Domain   string
+	Password string
+}
+
+// ParseUser は "DOMAIN\user"、"user@domain"、"user" の形式のユーザー指定を解析する
+func ParseUser(spec, password string) Credential {
+	cred := Credential{User: spec, Password: password}
+	if i := strings.IndexByte(spec, '\\'); i >= 0 {
+		cred.Domain, cred.User = spec[:i], spec[i+1:]
+	} else if i := strings.LastIndexByte(spec, '@'); i >= 0 {
+		cred.User, cred.Domain = spec[:i], spec[i+1:]
+	}
+	return cred
+}
+
+// String はユーザーを "DOMAIN\user" の形式で返す（パスワードは含めない）
+func (c Credential) String() string {
+	if c.Domain == "" {
+		return c.User
+	}
+	return c.Domain + `\` + c.User
+}
+
+// Identity は別の資格情報でのアクセスを表すインターフェース
+type Identity interface {
+	// Do は資格情報を切り替えてfnを実行する
+	Do(fn func() error) error
+	// Close は資格情報を解放する
+	Close() error
+}
+
+// Run はidentityの資格情報でfnを実行する（identityがnilの場合はそのまま実行する）
+func Run(identity Identity, fn func() error) error {
+	if identity == nil {
+		return fn()
+	}
+	return identity.Do(fn)
+}
@@ -0,0 +1,85 @@
+package runas
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"runtime"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// fsIdentity はファイルシステムのuid/gidを切り替えるIdentity
+type fsIdentity struct {
+	uid int
+	gid int
+}
+
+// Open は指定したユーザーとしてファイルにアクセスするIdentityを作成する
+// Linuxではroot権限で実行している場合のみ使用でき、パスワードは使用しない
+// 補助グループは切り替わらないため、アクセス権はユーザーと主グループで判定される
+func Open(cred Credential, target string) (Identity, error) {
+	if cred.Domain != "" {
+		return nil, fmt.Errorf("ドメインの指定には対応していません: %s", cred)
+	}
+	if os.Geteuid() != 0 {
+		return nil, fmt.Errorf("別のユーザー(%s)としてアクセスするにはroot権限が必要です", cred)
+	}
+
+	u, err := user.Lookup(cred.User)
+	if err != nil {
+		if _, convErr := strconv.Atoi(cred.User); convErr != nil {
+			return nil, fmt.Errorf("ユーザー(%s)が見つかりません: %w", cred, err)
+		}
+		if u, err = user.LookupId(cred.User); err != nil {
+			return nil, fmt.Errorf("ユーザー(%s)が見つかりません: %w", cred, err)
+		}
+	}
+
+	uid, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		return nil, fmt.Errorf("ユーザー(%s)のuidを解釈できません: %w", cred, err)
+	}
+	gid, err := strconv.Atoi(u.Gid)
+	if err != nil {
+		return nil, fmt.Errorf("ユーザー(%s)のgidを解釈できません: %w", cred, err)
+	}
+	return &fsIdentity{uid: uid, gid: gid}, nil
+}
+
+// Do は現在のOSスレッドのファイルシステムuid/gidを切り替えてfnを実行する
+// setfsuid/setfsgidは切り替えに失敗してもエラーを返さないため、切り替わったことを確認し、
+// 切り替えられなかった場合はfnを実行せずにエラーを返す
+func (i *fsIdentity) Do(fn func() error) error {
+	runtime.LockOSThread()
+
+	prevGID, _ := unix.SetfsgidRetGid(i.gid)
+	prevUID, _ := unix.SetfsuidRetUid(i.uid)
+	defer func() {
+		unix.SetfsuidRetUid(prevUID)
+		unix.SetfsgidRetGid(prevGID)
+		// 元に戻せなかったスレッドは他のゴルーチンで使われないよう、ロックしたまま終了させる
+		if uid, gid := currentFsIDs(); uid == prevUID && gid == prevGID {
+			runtime.UnlockOSThread()
+		}
+	}()
+
+	if uid, gid := currentFsIDs(); uid != i.uid || gid != i.gid {
+		return fmt.Errorf("ファイルシステムのuid/gidを%d/%dに切り替えられません（現在: %d/%d）", i.uid, i.gid, uid, gid)
+	}
+	return fn()
+}
+
+// currentFsIDs は現在のOSスレッドのファイルシステムuid/gidを返す
+// 無効なID（-1）を指定すると変更されずに現在の値が返る
+func currentFsIDs() (uid, gid int) {
+	uid, _ = unix.SetfsuidRetUid(-1)
+	gid, _ = unix.SetfsgidRetGid(-1)
+	return uid, gid
+}
+
+// Close は何もしない
+func (i *fsIdentity) Close() error {
+	return nil
+}
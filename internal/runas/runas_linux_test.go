@@ -0,0 +1,78 @@
+package runas
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestOpen_SwitchesFilesystemUser(t *testing.T) {
+	if os.Geteuid() != 0 {
+		if _, err := Open(Credential{User: "nobody"}, "/"); err == nil {
+			t.Error("root以外でエラーになりません")
+		}
+		t.Skip("root権限が必要です")
+	}
+
+	identity, err := Open(Credential{User: "nobody"}, "/")
+	if err != nil {
+		t.Skipf("nobodyユーザーを使用できません: %v", err)
+	}
+	defer identity.Close()
+
+	dir := t.TempDir()
+	// t.TempDir()の親ディレクトリは所有者しか辿れないため開放する
+	os.Chmod(filepath.Dir(dir), 0711)
+	os.Chmod(dir, 0777)
+	restricted := filepath.Join(dir, "restricted")
+	os.WriteFile(restricted, []byte("x"), 0600)
+
+	path := filepath.Join(dir, "created.txt")
+	err = identity.Do(func() error {
+		if _, err := os.ReadFile(restricted); err == nil {
+			t.Error("別のユーザーとして読めないはずのファイルを読めました")
+		}
+		return os.WriteFile(path, []byte("x"), 0644)
+	})
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st := info.Sys().(*syscall.Stat_t); int(st.Uid) == os.Geteuid() {
+		t.Errorf("作成したファイルの所有者が切り替わっていません: uid=%d", st.Uid)
+	}
+
+	// 元の資格情報に戻っていること
+	if _, err := os.ReadFile(restricted); err != nil {
+		t.Errorf("元のユーザーで読めません: %v", err)
+	}
+
+	if _, err := Open(Credential{User: "no-such-user-gopier"}, "/"); err == nil {
+		t.Error("存在しないユーザーでエラーになりません")
+	}
+	if _, err := Open(Credential{User: "nobody", Domain: "CORP"}, "/"); err == nil {
+		t.Error("ドメイン指定でエラーになりません")
+	}
+}
+
+func TestDo_FailsWithoutSwitch(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root権限では切り替えが失敗しません")
+	}
+
+	// root以外では別のuidに切り替えられないため、fnを実行せずにエラーを返す
+	identity := &fsIdentity{uid: os.Geteuid() + 1, gid: os.Getegid()}
+	called := false
+	err := identity.Do(func() error {
+		called = true
+		return nil
+	})
+	if err == nil || called {
+		t.Errorf("Do() = %v, 実行 = %t; want エラーで実行しない", err, called)
+	}
+}
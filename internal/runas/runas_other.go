@@ -0,0 +1,8 @@
+//go:build !linux && !windows
+
+package runas
+
+// Open はこの環境では対応していないため、常にErrUnsupportedを返す
+func Open(cred Credential, target string) (Identity, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,55 @@
+package runas
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseUser(t *testing.T) {
+	tests := []struct {
+		spec       string
+		wantUser   string
+		wantDomain string
+		wantString string
+	}{
+		{"backup", "backup", "", "backup"},
+		{`CORP\backup`, "backup", "CORP", `CORP\backup`},
+		{"backup@corp.example.com", "backup", "corp.example.com", `corp.example.com\backup`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			cred := ParseUser(tt.spec, "secret")
+			if cred.User != tt.wantUser || cred.Domain != tt.wantDomain || cred.Password != "secret" {
+				t.Errorf("ParseUser() = %+v", cred)
+			}
+			if cred.String() != tt.wantString {
+				t.Errorf("String() = %s, want %s", cred.String(), tt.wantString)
+			}
+		})
+	}
+}
+
+type recordingIdentity struct {
+	calls int
+}
+
+func (r *recordingIdentity) Do(fn func() error) error {
+	r.calls++
+	return fn()
+}
+
+func (r *recordingIdentity) Close() error { return nil }
+
+func TestRun(t *testing.T) {
+	want := errors.New("failed")
+
+	if err := Run(nil, func() error { return want }); err != want {
+		t.Errorf("Run(nil) = %v, want %v", err, want)
+	}
+
+	identity := &recordingIdentity{}
+	if err := Run(identity, func() error { return nil }); err != nil || identity.calls != 1 {
+		t.Errorf("Run() = %v, calls = %d", err, identity.calls)
+	}
+}
@@ -0,0 +1,83 @@
+package runas
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	modadvapi32                 = windows.NewLazySystemDLL("advapi32.dll")
+	procLogonUserW              = modadvapi32.NewProc("LogonUserW")
+	procImpersonateLoggedOnUser = modadvapi32.NewProc("ImpersonateLoggedOnUser")
+)
+
+// LogonUserのログオンの種類とプロバイダ
+const (
+	logon32LogonInteractive    = 2
+	logon32LogonNewCredentials = 9
+	logon32ProviderDefault     = 0
+	logon32ProviderWinNT50     = 3
+)
+
+// tokenIdentity はログオンしたユーザーを偽装するIdentity
+type tokenIdentity struct {
+	token windows.Token
+}
+
+// Open は指定した資格情報でログオンし、そのユーザーを偽装してアクセスするIdentityを作成する
+// 対象がUNCパス（共有フォルダ）の場合は、ネットワークアクセスにのみ資格情報を使用する
+// （runas /netonly と同じ）。ローカルパスの場合は対話型ログオンを行う
+func Open(cred Credential, target string) (Identity, error) {
+	logonType, provider := uintptr(logon32LogonInteractive), uintptr(logon32ProviderDefault)
+	if strings.HasPrefix(target, `\\`) {
+		logonType, provider = logon32LogonNewCredentials, logon32ProviderWinNT50
+	}
+
+	user, err := windows.UTF16PtrFromString(cred.User)
+	if err != nil {
+		return nil, err
+	}
+	domain, err := windows.UTF16PtrFromString(cred.Domain)
+	if err != nil {
+		return nil, err
+	}
+	password, err := windows.UTF16PtrFromString(cred.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	var token windows.Token
+	r, _, e := procLogonUserW.Call(uintptr(unsafe.Pointer(user)), uintptr(unsafe.Pointer(domain)),
+		uintptr(unsafe.Pointer(password)), logonType, provider, uintptr(unsafe.Pointer(&token)))
+	if r == 0 {
+		return nil, fmt.Errorf("ユーザー(%s)のログオンに失敗: %w", cred, e)
+	}
+	return &tokenIdentity{token: token}, nil
+}
+
+// Do は現在のOSスレッドでユーザーを偽装してfnを実行する
+func (i *tokenIdentity) Do(fn func() error) error {
+	runtime.LockOSThread()
+
+	if r, _, e := procImpersonateLoggedOnUser.Call(uintptr(i.token)); r == 0 {
+		runtime.UnlockOSThread()
+		return fmt.Errorf("ユーザーの偽装に失敗: %w", e)
+	}
+
+	err := fn()
+
+	// 偽装を解除できなかったスレッドは再利用させず、ゴルーチンの終了時に破棄させる
+	if windows.RevertToSelf() == nil {
+		runtime.UnlockOSThread()
+	}
+	return err
+}
+
+// Close はログオンのトークンを閉じる
+func (i *tokenIdentity) Close() error {
+	return i.token.Close()
+}
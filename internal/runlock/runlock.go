@@ -0,0 +1,115 @@
+// Package runlock は同じデータベースに対するgopierの同時実行を防ぐためのロックファイルを扱う
+// ロックはOSの勧告ロック（UnixではFlock、WindowsではLockFileEx）で行うため、
+// プロセスが異常終了した場合も自動的に解放される
+package runlock
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/errcode"
+)
+
+// pollInterval はロックの解放を待つ場合の確認間隔
+const pollInterval = 500 * time.Millisecond
+
+// Owner はロックを取得したプロセスの情報
+type Owner struct {
+	PID       int       `json:"pid"`
+	Host      string    `json:"host"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// HeldError は別のプロセスがロックを取得している場合のエラー
+type HeldError struct {
+	Path  string
+	Owner *Owner // 読み込めなかった場合はnil
+}
+
+func (e *HeldError) Error() string {
+	if e.Owner == nil {
+		return fmt.Sprintf("別のgopierが実行中です（ロックファイル: %s）", e.Path)
+	}
+	return fmt.Sprintf("別のgopierが実行中です（PID %d, ホスト %s, 開始 %s, ロックファイル: %s）",
+		e.Owner.PID, e.Owner.Host, e.Owner.StartedAt.Format(time.RFC3339), e.Path)
+}
+
+// Is はロックの競合をerrcode.ErrDatabaseLockedとして判別できるようにする
+func (e *HeldError) Is(target error) bool {
+	return target == errcode.ErrDatabaseLocked
+}
+
+// Lock は取得したロック
+type Lock struct {
+	file *os.File
+}
+
+// PathFor はデータベースのパスに対応するロックファイルのパスを返す
+func PathFor(dbPath string) string {
+	return dbPath + ".lock"
+}
+
+// Acquire はロックファイルのロックを取得する
+// 別のプロセスがロックを取得している場合は、waitの間（負の値の場合は無期限に）解放を待ち、
+// 取得できなければHeldErrorを返す
+func Acquire(path string, wait time.Duration) (*Lock, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("ロックファイル(%s)を開けません: %w", path, err)
+	}
+
+	deadline := time.Now().Add(wait)
+	for {
+		ok, err := tryLock(file)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("ロックファイル(%s)のロックエラー: %w", path, err)
+		}
+		if ok {
+			break
+		}
+		if wait >= 0 && !time.Now().Before(deadline) {
+			file.Close()
+			return nil, &HeldError{Path: path, Owner: ReadOwner(path)}
+		}
+		time.Sleep(pollInterval)
+	}
+
+	// 別のプロセスが確認できるよう、自分の情報を書き込む
+	host, _ := os.Hostname()
+	data, _ := json.Marshal(Owner{PID: os.Getpid(), Host: host, StartedAt: time.Now()})
+	if err := file.Truncate(0); err == nil {
+		file.WriteAt(data, 0)
+	}
+	return &Lock{file: file}, nil
+}
+
+// Release はロックを解放する
+// 待機中の別のプロセスが同じファイルをロックできるよう、ロックファイルは削除しない
+func (l *Lock) Release() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	l.file.Truncate(0)
+	err := unlock(l.file)
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+	l.file = nil
+	return err
+}
+
+// ReadOwner はロックファイルに書き込まれたプロセスの情報を返す（読み込めない場合はnil）
+func ReadOwner(path string) *Owner {
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	var owner Owner
+	if err := json.Unmarshal(data, &owner); err != nil {
+		return nil
+	}
+	return &owner
+}
@@ -0,0 +1,75 @@
+package runlock
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAcquire(t *testing.T) {
+	path := PathFor(filepath.Join(t.TempDir(), "sync_state.db"))
+
+	lock, err := Acquire(path, 0)
+	if err != nil {
+		t.Fatalf("Acquireが失敗しました: %v", err)
+	}
+	owner := ReadOwner(path)
+	if owner == nil || owner.PID != os.Getpid() {
+		t.Errorf("ロックファイルの情報 = %+v, want PID %d", owner, os.Getpid())
+	}
+
+	// 取得済みの場合はHeldErrorになり、取得したプロセスの情報を含む
+	_, err = Acquire(path, 0)
+	var held *HeldError
+	if !errors.As(err, &held) {
+		t.Fatalf("HeldErrorが返されませんでした: %v", err)
+	}
+	if held.Owner == nil || held.Owner.PID != os.Getpid() {
+		t.Errorf("HeldError.Owner = %+v", held.Owner)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Releaseが失敗しました: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("ロックファイルが削除されました: %v", err)
+	}
+
+	lock, err = Acquire(path, 0)
+	if err != nil {
+		t.Fatalf("解放後のAcquireが失敗しました: %v", err)
+	}
+	lock.Release()
+}
+
+func TestAcquire_Wait(t *testing.T) {
+	path := PathFor(filepath.Join(t.TempDir(), "sync_state.db"))
+
+	lock, err := Acquire(path, 0)
+	if err != nil {
+		t.Fatalf("Acquireが失敗しました: %v", err)
+	}
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		lock.Release()
+	}()
+
+	start := time.Now()
+	second, err := Acquire(path, 5*time.Second)
+	if err != nil {
+		t.Fatalf("解放を待つAcquireが失敗しました: %v", err)
+	}
+	defer second.Release()
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("解放を待たずに取得しました: %v", elapsed)
+	}
+
+	// 待機時間を過ぎた場合はHeldError
+	_, err = Acquire(path, 600*time.Millisecond)
+	var held *HeldError
+	if !errors.As(err, &held) {
+		t.Errorf("HeldErrorが返されませんでした: %v", err)
+	}
+}
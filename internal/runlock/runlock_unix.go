@@ -0,0 +1,23 @@
+//go:build !windows
+
+package runlock
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// tryLock はファイル全体の排他ロックを待たずに取得する
+func tryLock(file *os.File) (bool, error) {
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// unlock はロックを解放する
+func unlock(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,31 @@
+//go:build windows
+
+package runlock
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockOffsetHigh はロックする範囲の位置（上位32ビット）
+// LockFileExでロックした範囲は他のプロセスから読み込めなくなるため、
+// プロセスの情報を書き込む先頭から離れた位置（4GB）の1バイトをロックする
+const lockOffsetHigh = 1
+
+// tryLock はロック用の範囲の排他ロックを待たずに取得する
+func tryLock(file *os.File) (bool, error) {
+	ol := &windows.Overlapped{OffsetHigh: lockOffsetHigh}
+	err := windows.LockFileEx(windows.Handle(file.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, ol)
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// unlock はロックを解放する
+func unlock(file *os.File) error {
+	ol := &windows.Overlapped{OffsetHigh: lockOffsetHigh}
+	return windows.UnlockFileEx(windows.Handle(file.Fd()), 0, 1, 0, ol)
+}
@@ -0,0 +1,113 @@
+package runsummary
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/sakuhanight/gopier/internal/errcode"
+)
+
+// 失敗したファイルの一覧の形式
+const (
+	ListPlain = "plain" // 1行に1つのパス
+	ListNull  = "null"  // NUL区切りのパス（改行を含むパスも扱える）
+	ListCSV   = "csv"   // パス・段階・エラーの種類・エラーとエラーコード・操作・エラー番号・再試行回数のCSV（ヘッダ付き）
+)
+
+// ValidateListFormat は失敗したファイルの一覧の形式を検証する
+func ValidateListFormat(format string) error {
+	switch format {
+	case ListPlain, ListNull, ListCSV:
+		return nil
+	default:
+		return fmt.Errorf("無効な一覧の形式: %s (plain, null, csvのいずれかを指定してください)", format)
+	}
+}
+
+// WriteFailedFiles は失敗したファイルの相対パスを--files-fromで読み込める形式で書き出す
+// plain・nullでは同じパスを1回だけ書き出す。ソース・宛先そのものの失敗（絶対パス）は
+// ファイル単位で再試行できないため含めない
+func WriteFailedFiles(w io.Writer, failures []Failure, format string) error {
+	if err := ValidateListFormat(format); err != nil {
+		return err
+	}
+
+	failures = retryableFailures(failures)
+	bw := bufio.NewWriter(w)
+	switch format {
+	case ListCSV:
+		cw := csv.NewWriter(bw)
+		cw.Write([]string{"path", "stage", "code", "error", "error_code", "op", "errno", "retries"})
+		for _, f := range failures {
+			cw.Write(append([]string{f.Path, f.Stage, f.Code, f.Error}, detailColumns(f.Detail)...))
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+	default:
+		terminator := "\n"
+		if format == ListNull {
+			terminator = "\x00"
+		}
+		seen := make(map[string]bool)
+		for _, f := range failures {
+			if seen[f.Path] {
+				continue
+			}
+			seen[f.Path] = true
+			if _, err := io.WriteString(bw, f.Path+terminator); err != nil {
+				return err
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// detailColumns はエラーの機械可読な情報をCSVの列に変換する（値がない列は空）
+func detailColumns(d *errcode.Detail) []string {
+	if d == nil {
+		return []string{"", "", "", ""}
+	}
+	columns := []string{string(d.Code), d.Op, "", ""}
+	if d.Errno != 0 {
+		columns[2] = strconv.Itoa(d.Errno)
+	}
+	if d.Retries != 0 {
+		columns[3] = strconv.Itoa(d.Retries)
+	}
+	return columns
+}
+
+// SaveFailedFiles は失敗したファイルの一覧をファイルに保存する（失敗がない場合は空のファイル）
+func SaveFailedFiles(path string, failures []Failure, format string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("失敗したファイルの一覧の作成エラー: %w", err)
+	}
+	defer file.Close()
+
+	if err := WriteFailedFiles(file, failures, format); err != nil {
+		return fmt.Errorf("失敗したファイルの一覧の書き込みエラー: %w", err)
+	}
+	return file.Close()
+}
+
+// retryableFailures はファイル単位の失敗をパス順に並べて返す
+func retryableFailures(failures []Failure) []Failure {
+	var result []Failure
+	for _, f := range failures {
+		if f.Path == "" || filepath.IsAbs(f.Path) {
+			continue
+		}
+		result = append(result, f)
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Path < result[j].Path })
+	return result
+}
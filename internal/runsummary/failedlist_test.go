@@ -0,0 +1,41 @@
+package runsummary
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFailedFiles(t *testing.T) {
+	s := &Summary{}
+	s.AddFailure("b.txt", StageCopy, errors.New("アクセスが拒否されました"))
+	s.AddFailure("dir/a,\"1\".txt", StageVerify, errors.New("ハッシュ値が一致しません"))
+	s.AddFailure("b.txt", StageVerify, nil)
+	s.AddFailure(filepath.Join(t.TempDir(), "src"), StageVerify, errors.New("ソースが見つかりません"))
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{ListPlain, "b.txt\ndir/a,\"1\".txt\n"},
+		{ListNull, "b.txt\x00dir/a,\"1\".txt\x00"},
+		{ListCSV, "path,stage,code,error,error_code,op,errno,retries\n" +
+			"b.txt,copy,error,アクセスが拒否されました,E_UNKNOWN,,,\n" +
+			"b.txt,verify,,,,,,\n" +
+			"\"dir/a,\"\"1\"\".txt\",verify,error,ハッシュ値が一致しません,E_UNKNOWN,,,\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := WriteFailedFiles(&buf, s.Failures, tt.format); err != nil {
+			t.Fatalf("WriteFailedFiles(%s) error = %v", tt.format, err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("WriteFailedFiles(%s) = %q, want %q", tt.format, buf.String(), tt.want)
+		}
+	}
+
+	if err := WriteFailedFiles(&bytes.Buffer{}, s.Failures, "json"); err == nil {
+		t.Error("無効な形式でエラーになるべきです")
+	}
+}
@@ -0,0 +1,172 @@
+package runsummary
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sakuhanight/gopier/internal/database"
+)
+
+// 判定に使用する検証結果の項目
+const (
+	MetricFailed       = "failed"        // 一致しなかったファイルの合計（mismatched・missing・errors・link_mismatch）
+	MetricMismatched   = "mismatched"    // 内容が一致しない
+	MetricMissing      = "missing"       // 宛先が存在しない
+	MetricErrors       = "errors"        // 読み込めない・ハッシュを計算できない
+	MetricLinkMismatch = "link_mismatch" // リンク先が一致しない
+	MetricIntermittent = "intermittent"  // 再検証で一致した
+	MetricExtra        = "extra"         // 宛先にのみ存在する
+)
+
+// metrics は判定に使用できる項目（表示順）
+var metrics = []string{MetricFailed, MetricMismatched, MetricMissing, MetricErrors, MetricLinkMismatch, MetricIntermittent, MetricExtra}
+
+// 判定の結果
+const (
+	GradePass = "pass"
+	GradeFail = "fail"
+)
+
+// Threshold は検証結果の1つの項目の合格の基準を表す構造体
+// 件数（Percentがfalse）または検証したファイル数に対する割合（%）がLimitを超えた場合に不合格とする
+type Threshold struct {
+	Metric  string
+	Limit   float64
+	Percent bool
+}
+
+// String は基準を--verify-thresholdの形式で返す
+func (t Threshold) String() string {
+	limit := strconv.FormatFloat(t.Limit, 'f', -1, 64)
+	if t.Percent {
+		limit += "%"
+	}
+	return t.Metric + "=" + limit
+}
+
+// ParseThresholds は--verify-thresholdの指定（例: "mismatched=0,missing=0.01%"）を解析する
+// 空の場合はnilを返す（判定しない）
+func ParseThresholds(spec string) ([]Threshold, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, nil
+	}
+
+	var thresholds []Threshold
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		metric, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("合格の基準は項目=値の形式で指定してください: %q", item)
+		}
+		t := Threshold{Metric: strings.ToLower(strings.TrimSpace(metric))}
+		if !validMetric(t.Metric) {
+			return nil, fmt.Errorf("合格の基準の項目が不正です: %q (%s)", metric, strings.Join(metrics, ", "))
+		}
+		if seen[t.Metric] {
+			return nil, fmt.Errorf("合格の基準の項目が重複しています: %s", t.Metric)
+		}
+		seen[t.Metric] = true
+
+		value = strings.TrimSpace(value)
+		if strings.HasSuffix(value, "%") {
+			t.Percent = true
+			value = strings.TrimSuffix(value, "%")
+		}
+		limit, err := strconv.ParseFloat(value, 64)
+		if err != nil || limit < 0 || (t.Percent && limit > 100) || (!t.Percent && limit != float64(int64(limit))) {
+			return nil, fmt.Errorf("合格の基準の値が不正です: %q (0以上の件数、または0〜100%%の割合)", item)
+		}
+		t.Limit = limit
+		thresholds = append(thresholds, t)
+	}
+	return thresholds, nil
+}
+
+func validMetric(metric string) bool {
+	for _, m := range metrics {
+		if m == metric {
+			return true
+		}
+	}
+	return false
+}
+
+// metricCount は検証結果の項目の件数を返す
+func metricCount(summary database.VerificationSummary, metric string) int {
+	switch metric {
+	case MetricFailed:
+		return summary.Mismatched + summary.MissingDest + summary.Errors + summary.LinkMismatch
+	case MetricMismatched:
+		return summary.Mismatched
+	case MetricMissing:
+		return summary.MissingDest
+	case MetricErrors:
+		return summary.Errors
+	case MetricLinkMismatch:
+		return summary.LinkMismatch
+	case MetricIntermittent:
+		return summary.Intermittent
+	case MetricExtra:
+		return summary.Extra
+	}
+	return 0
+}
+
+// Violation は合格の基準を超えた項目を表す構造体
+type Violation struct {
+	Metric    string  `json:"metric"`
+	Count     int     `json:"count"`
+	Percent   float64 `json:"percent"`   // 検証したファイル数に対する割合（%）
+	Threshold string  `json:"threshold"` // 超えた基準（例: "missing=0.01%"）
+}
+
+// Grade は検証結果の判定を表す構造体
+type Grade struct {
+	Result     string      `json:"result"`     // pass または fail
+	Verified   int         `json:"verified"`   // 判定に使用した検証したファイル数
+	Thresholds []string    `json:"thresholds"` // 合格の基準
+	Violations []Violation `json:"violations"` // 基準を超えた項目（合格の場合は空）
+}
+
+// Passed は合格かどうかを返す
+func (g *Grade) Passed() bool {
+	return g.Result == GradePass
+}
+
+// Evaluate は検証結果を合格の基準で判定する
+// 割合は宛先にのみ存在するファイルを除く検証したファイル数に対して計算する（0件の場合は0%）
+func Evaluate(thresholds []Threshold, summary database.VerificationSummary) *Grade {
+	grade := &Grade{Result: GradePass, Verified: summary.Verified(), Thresholds: []string{}, Violations: []Violation{}}
+	for _, t := range thresholds {
+		grade.Thresholds = append(grade.Thresholds, t.String())
+
+		count := metricCount(summary, t.Metric)
+		var percent float64
+		if grade.Verified > 0 {
+			percent = float64(count) * 100 / float64(grade.Verified)
+		}
+		exceeded := float64(count) > t.Limit
+		if t.Percent {
+			exceeded = percent > t.Limit
+		}
+		if exceeded {
+			grade.Result = GradeFail
+			grade.Violations = append(grade.Violations, Violation{Metric: t.Metric, Count: count, Percent: percent, Threshold: t.String()})
+		}
+	}
+	return grade
+}
+
+// String は判定の結果を1行で返す
+func (g *Grade) String() string {
+	if g.Passed() {
+		return fmt.Sprintf("合格 (%s)", strings.Join(g.Thresholds, ", "))
+	}
+	var violations []string
+	for _, v := range g.Violations {
+		violations = append(violations, fmt.Sprintf("%s: %d件 (%.4g%%) > %s", v.Metric, v.Count, v.Percent, v.Threshold))
+	}
+	return fmt.Sprintf("不合格 (%s)", strings.Join(violations, ", "))
+}
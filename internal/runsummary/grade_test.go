@@ -0,0 +1,64 @@
+package runsummary
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sakuhanight/gopier/internal/database"
+)
+
+func TestParseThresholds(t *testing.T) {
+	thresholds, err := ParseThresholds(" mismatched=0, Missing=0.01% ,extra=5")
+	if err != nil {
+		t.Fatalf("ParseThresholds() error = %v", err)
+	}
+	want := []Threshold{{Metric: MetricMismatched}, {Metric: MetricMissing, Limit: 0.01, Percent: true}, {Metric: MetricExtra, Limit: 5}}
+	if len(thresholds) != len(want) {
+		t.Fatalf("ParseThresholds() = %+v", thresholds)
+	}
+	for i := range want {
+		if thresholds[i] != want[i] {
+			t.Errorf("[%d] = %+v, want %+v", i, thresholds[i], want[i])
+		}
+	}
+	if thresholds[1].String() != "missing=0.01%" {
+		t.Errorf("String() = %q", thresholds[1].String())
+	}
+
+	if thresholds, err := ParseThresholds(""); thresholds != nil || err != nil {
+		t.Errorf("空の指定: %+v, %v", thresholds, err)
+	}
+	for _, spec := range []string{"mismatched", "unknown=0", "errors=-1", "errors=1.5", "missing=101%", "errors=0,errors=1", "errors=x"} {
+		if _, err := ParseThresholds(spec); err == nil {
+			t.Errorf("ParseThresholds(%q) でエラーが発生しませんでした", spec)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	summary := database.VerificationSummary{Matched: 9998, Mismatched: 0, MissingDest: 2, Intermittent: 3, Extra: 10}
+
+	thresholds, _ := ParseThresholds("mismatched=0,missing=0.01%")
+	grade := Evaluate(thresholds, summary)
+	if grade.Passed() || grade.Verified != 10000 || len(grade.Violations) != 1 {
+		t.Fatalf("Evaluate() = %+v", grade)
+	}
+	v := grade.Violations[0]
+	if v.Metric != MetricMissing || v.Count != 2 || v.Percent != 0.02 || v.Threshold != "missing=0.01%" {
+		t.Errorf("Violations[0] = %+v", v)
+	}
+	if !strings.Contains(grade.String(), "missing: 2件") {
+		t.Errorf("String() = %q", grade.String())
+	}
+
+	thresholds, _ = ParseThresholds("failed=2,missing=0.02%,intermittent=3,extra=10")
+	if grade := Evaluate(thresholds, summary); !grade.Passed() || len(grade.Thresholds) != 4 {
+		t.Errorf("基準以下で不合格になりました: %+v", grade)
+	}
+
+	// 検証したファイルがない場合は割合を0%とする
+	thresholds, _ = ParseThresholds("missing=0%")
+	if grade := Evaluate(thresholds, database.VerificationSummary{}); !grade.Passed() {
+		t.Errorf("Evaluate() = %+v", grade)
+	}
+}
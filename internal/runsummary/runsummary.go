@@ -0,0 +1,376 @@
+// Package runsummary は1回の実行結果（件数・スループット・失敗したファイル）をJSONに保存し、
+// 2回の実行結果を比較する
+package runsummary
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/errcode"
+)
+
+// formatVersion はファイル形式のバージョン
+const formatVersion = 1
+
+// 失敗した段階
+const (
+	StageCopy   = "copy"   // コピー
+	StageVerify = "verify" // 検証
+)
+
+// Failure は失敗したファイルを表す構造体
+type Failure struct {
+	Path   string          `json:"path"`
+	Stage  string          `json:"stage"`
+	Code   string          `json:"code,omitempty"` // エラーの種類（errcode.Code）
+	Error  string          `json:"error,omitempty"`
+	Detail *errcode.Detail `json:"detail,omitempty"` // エラーコード・エラー番号・再試行回数
+}
+
+// Folder はフォルダごとのコピー結果を表す構造体（--folder-statsを指定した場合のみ記録する）
+type Folder struct {
+	Folder            string  `json:"folder"`
+	FilesCopied       int64   `json:"files_copied"`
+	FilesSkipped      int64   `json:"files_skipped"`
+	FilesFailed       int64   `json:"files_failed"`
+	BytesCopied       int64   `json:"bytes_copied"`
+	BytesSkipped      int64   `json:"bytes_skipped"`
+	CompletionPercent float64 `json:"completion_percent"` // 失敗せずに宛先に揃ったファイルの割合
+}
+
+// SlowFile は処理時間の長いファイルを表す構造体（--slowestを指定した場合のみ記録する）
+type SlowFile struct {
+	Path       string  `json:"path"`
+	Bytes      int64   `json:"bytes"`
+	Seconds    float64 `json:"seconds"`
+	Throughput float64 `json:"throughput_bytes_per_sec"`
+}
+
+// SlowDir は直下のファイルの処理時間の合計が長いディレクトリを表す構造体（--slowestを指定した場合のみ記録する）
+type SlowDir struct {
+	Dir     string  `json:"dir"`
+	Files   int64   `json:"files"`
+	Bytes   int64   `json:"bytes"`
+	Seconds float64 `json:"seconds"`
+}
+
+// CatchUp はコピー中に変更されたファイルの再コピーの結果を表す構造体（--catch-up-passesで再コピーした場合のみ記録する）
+type CatchUp struct {
+	Passes   int      `json:"passes"`
+	Recopied int64    `json:"recopied"`
+	Unstable []string `json:"unstable,omitempty"` // 上限の回数を実行しても変更され続けたファイル
+}
+
+// SourceDrift は開始時から終了時までのソースの変化を表す構造体（--detect-source-changesを指定した場合のみ記録する）
+type SourceDrift struct {
+	StartedAt     time.Time `json:"started_at"`      // 開始時の走査の日時
+	FinishedAt    time.Time `json:"finished_at"`     // 終了時の走査の日時
+	FilesBefore   int64     `json:"files_before"`    // 開始時のファイル数
+	FilesAfter    int64     `json:"files_after"`     // 終了時のファイル数
+	BytesBefore   int64     `json:"bytes_before"`    // 開始時の合計サイズ
+	BytesAfter    int64     `json:"bytes_after"`     // 終了時の合計サイズ
+	NewestModTime time.Time `json:"newest_mod_time"` // 開始時の更新日時の最大値
+	ChangedFiles  int64     `json:"changed_files"`   // 開始時の更新日時の最大値より後に更新されたファイル数
+	ChangedBytes  int64     `json:"changed_bytes"`
+	Changed       bool      `json:"changed"` // 差分のコピーの再実行が必要かどうか
+}
+
+// Rename は名前の大文字・小文字のみ変わったため、宛先で名前を変更したファイル・ディレクトリを表す構造体
+type Rename struct {
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Destination string `json:"destination"`
+}
+
+// Summary は1回の実行結果を表す構造体
+type Summary struct {
+	Version         int                           `json:"version"`
+	Source          string                        `json:"source,omitempty"`
+	Destination     string                        `json:"destination,omitempty"`
+	Label           string                        `json:"label,omitempty"`
+	Tags            map[string]string             `json:"tags,omitempty"`
+	StartedAt       time.Time                     `json:"started_at"`
+	FinishedAt      time.Time                     `json:"finished_at"`
+	UpToDate        bool                          `json:"up_to_date,omitempty"` // --idempotentでソースが前回の実行から変わっていないため、コピーを省略した
+	CopySeconds     float64                       `json:"copy_seconds"`
+	FilesCopied     int64                         `json:"files_copied"`
+	FilesSkipped    int64                         `json:"files_skipped"`
+	FilesFailed     int64                         `json:"files_failed"`
+	FilesIgnored    int64                         `json:"files_ignored"`
+	FilesConflicted int64                         `json:"files_conflicted"`   // 宛先の方が新しかったため上書きしなかったファイル数
+	SharingRetries  int64                         `json:"sharing_retries"`    // 共有違反（ウイルス対策ソフトなどが使用中）による再試行の回数
+	FilesLocked     int64                         `json:"files_locked"`       // 他のプロセスが使用中のためコピーできなかったファイル数
+	MetaUpdated     int64                         `json:"files_meta_updated"` // 内容が同じため更新日時とアクセス権のみ更新したファイル数（スキップにも含める）
+	FilesPermFailed int64                         `json:"files_perm_failed"`  // 内容はコピーしたがアクセス権をコピーできなかったファイル数（コピーに含め、失敗には含めない）
+	BytesCopied     int64                         `json:"bytes_copied"`
+	Throughput      float64                       `json:"throughput_bytes_per_sec"` // コピーしたバイト数をコピーの秒数で割った値
+	Verification    *database.VerificationSummary `json:"verification,omitempty"`
+	Grade           *Grade                        `json:"grade,omitempty"` // 検証結果の判定（--verify-thresholdを指定した場合のみ記録する）
+	CatchUp         *CatchUp                      `json:"catch_up,omitempty"`
+	SourceDrift     *SourceDrift                  `json:"source_drift,omitempty"`
+	Renames         []Rename                      `json:"renames,omitempty"` // 宛先で名前の大文字・小文字を変更したファイル・ディレクトリ
+	Folders         []Folder                      `json:"folders,omitempty"`
+	SlowestFiles    []SlowFile                    `json:"slowest_files,omitempty"`
+	SlowestDirs     []SlowDir                     `json:"slowest_dirs,omitempty"`
+	Failures        []Failure                     `json:"failures"`
+	PermFailures    []Failure                     `json:"perm_failures,omitempty"` // 内容はコピーしたがアクセス権をコピーできなかったファイル（失敗の比較には含めない）
+}
+
+// NewFailure は失敗したファイルの記録を作成する
+func NewFailure(path, stage string, err error) Failure {
+	failure := Failure{Path: path, Stage: stage}
+	if err != nil {
+		failure.Code = string(errcode.Of(err))
+		failure.Error = err.Error()
+		failure.Detail = errcode.Describe(err)
+	}
+	return failure
+}
+
+// AddFailure は失敗したファイルを追加する
+func (s *Summary) AddFailure(path, stage string, err error) {
+	s.Failures = append(s.Failures, NewFailure(path, stage, err))
+}
+
+// Save は実行結果をJSONで保存する
+func Save(path string, s *Summary) error {
+	s.Version = formatVersion
+	if s.Failures == nil {
+		s.Failures = []Failure{}
+	}
+	sort.Slice(s.Failures, func(i, j int) bool { return s.Failures[i].Path < s.Failures[j].Path })
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("実行結果のシリアライズエラー: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Load は実行結果のJSONを読み込む
+// db export --format jsonで書き出したファイル情報の配列も受け付け、失敗・不一致のファイルを失敗として扱う
+func Load(path string) (*Summary, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var files []database.FileInfo
+		if err := json.Unmarshal(trimmed, &files); err != nil {
+			return nil, fmt.Errorf("%s: ファイル情報の形式が不正です: %w", path, err)
+		}
+		return fromFiles(files), nil
+	}
+
+	var s Summary
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("%s: 実行結果の形式が不正です: %w", path, err)
+	}
+	if s.Version > formatVersion {
+		return nil, fmt.Errorf("%s: 未対応の実行結果のバージョンです: %d", path, s.Version)
+	}
+	return &s, nil
+}
+
+// fromFiles はデータベースのファイル情報から実行結果を作成する（件数のみで、スループットは含まない）
+func fromFiles(files []database.FileInfo) *Summary {
+	s := &Summary{Version: formatVersion}
+	for _, file := range files {
+		switch file.Status {
+		case database.StatusSuccess, database.StatusVerified, database.StatusIntermittent, database.StatusDataOKPermissionFailed:
+			s.FilesCopied++
+			s.BytesCopied += file.Size
+		case database.StatusSkipped:
+			s.FilesSkipped++
+		case database.StatusFailed:
+			s.FilesFailed++
+			s.Failures = append(s.Failures, Failure{Path: file.Path, Stage: StageCopy, Error: file.LastError, Detail: file.Error})
+		case database.StatusMismatch:
+			s.Failures = append(s.Failures, Failure{Path: file.Path, Stage: StageVerify, Error: file.LastError, Detail: file.Error})
+		}
+	}
+	return s
+}
+
+// Diff は2回の実行結果の比較を表す構造体
+type Diff struct {
+	LabelBefore      string    `json:"label_before,omitempty"`
+	LabelAfter       string    `json:"label_after,omitempty"`
+	NewlyFailed      []Failure `json:"newly_failed"`  // 前回は失敗していなかったが、今回失敗したファイル
+	Fixed            []Failure `json:"fixed"`         // 前回は失敗したが、今回は失敗しなかったファイル（前回の失敗内容）
+	StillFailing     []Failure `json:"still_failing"` // 両方で失敗したファイル（今回の失敗内容）
+	FailedBefore     int       `json:"failed_before"`
+	FailedAfter      int       `json:"failed_after"`
+	ThroughputBefore float64   `json:"throughput_before"`
+	ThroughputAfter  float64   `json:"throughput_after"`
+	ThroughputChange float64   `json:"throughput_change_percent"` // どちらかのスループットが不明な場合は0
+
+	// 確認済みの不一致として報告しなかった今回の失敗の件数（--suppress-acknowledged）
+	Suppressed int `json:"suppressed,omitempty"`
+}
+
+// Clean は今回の実行に失敗したファイルがないかどうかを返す
+func (d *Diff) Clean() bool {
+	return d.FailedAfter == 0
+}
+
+// Compare は前回（before）と今回（after）の実行結果を比較する
+// 同じファイルが両方の段階で失敗した場合は、1件の失敗として扱う
+func Compare(before, after *Summary) *Diff {
+	beforeFailures := indexFailures(before.Failures)
+	afterFailures := indexFailures(after.Failures)
+
+	d := &Diff{
+		LabelBefore:      before.Label,
+		LabelAfter:       after.Label,
+		NewlyFailed:      []Failure{},
+		Fixed:            []Failure{},
+		StillFailing:     []Failure{},
+		FailedBefore:     len(beforeFailures),
+		FailedAfter:      len(afterFailures),
+		ThroughputBefore: before.Throughput,
+		ThroughputAfter:  after.Throughput,
+	}
+	for path, failure := range afterFailures {
+		if _, ok := beforeFailures[path]; ok {
+			d.StillFailing = append(d.StillFailing, failure)
+		} else {
+			d.NewlyFailed = append(d.NewlyFailed, failure)
+		}
+	}
+	for path, failure := range beforeFailures {
+		if _, ok := afterFailures[path]; !ok {
+			d.Fixed = append(d.Fixed, failure)
+		}
+	}
+	for _, list := range [][]Failure{d.NewlyFailed, d.Fixed, d.StillFailing} {
+		sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+	}
+
+	if before.Throughput > 0 && after.Throughput > 0 {
+		d.ThroughputChange = (after.Throughput - before.Throughput) / before.Throughput * 100
+	}
+	return d
+}
+
+// Suppress はmatchがtrueを返すパスの失敗を比較結果から除き、除いた今回の失敗の件数を返す
+// 前回の失敗も除くため、確認済みのファイルは解消したファイルとしても報告しない
+func (d *Diff) Suppress(match func(path string) bool) int {
+	filter := func(failures []Failure, before, after bool) []Failure {
+		kept := failures[:0]
+		for _, f := range failures {
+			if !match(f.Path) {
+				kept = append(kept, f)
+				continue
+			}
+			if before {
+				d.FailedBefore--
+			}
+			if after {
+				d.FailedAfter--
+				d.Suppressed++
+			}
+		}
+		return kept
+	}
+
+	suppressed := d.Suppressed
+	d.NewlyFailed = filter(d.NewlyFailed, false, true)
+	d.StillFailing = filter(d.StillFailing, true, true)
+	d.Fixed = filter(d.Fixed, true, false)
+	return d.Suppressed - suppressed
+}
+
+// indexFailures は失敗をパスごとにまとめる（コピーと検証の両方で失敗した場合はコピーの失敗を残す）
+func indexFailures(failures []Failure) map[string]Failure {
+	index := make(map[string]Failure, len(failures))
+	for _, failure := range failures {
+		if existing, ok := index[failure.Path]; ok && existing.Stage == StageCopy {
+			continue
+		}
+		index[failure.Path] = failure
+	}
+	return index
+}
+
+// WriteJSON は比較結果をJSONで書き出す
+func WriteJSON(w io.Writer, d *Diff) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(d)
+}
+
+// WriteText は比較結果を人が読む形式で書き出す
+func WriteText(w io.Writer, d *Diff) error {
+	if d.LabelBefore != "" || d.LabelAfter != "" {
+		fmt.Fprintf(w, "ラベル: %s -> %s\n", labelOrDash(d.LabelBefore), labelOrDash(d.LabelAfter))
+	}
+	fmt.Fprintf(w, "失敗: %d件 -> %d件（新たに失敗: %d件, 解消: %d件, 継続: %d件）\n",
+		d.FailedBefore, d.FailedAfter, len(d.NewlyFailed), len(d.Fixed), len(d.StillFailing))
+	if d.Suppressed > 0 {
+		fmt.Fprintf(w, "確認済みの不一致として除外: %d件\n", d.Suppressed)
+	}
+	if d.ThroughputBefore > 0 && d.ThroughputAfter > 0 {
+		fmt.Fprintf(w, "スループット: %s/s -> %s/s (%+.1f%%)\n",
+			formatBytes(d.ThroughputBefore), formatBytes(d.ThroughputAfter), d.ThroughputChange)
+	} else {
+		fmt.Fprintf(w, "スループット: 比較できません（記録がありません）\n")
+	}
+
+	sections := []struct {
+		title    string
+		failures []Failure
+	}{
+		{"新たに失敗したファイル", d.NewlyFailed},
+		{"解消したファイル", d.Fixed},
+		{"失敗が続いているファイル", d.StillFailing},
+	}
+	for _, section := range sections {
+		if len(section.failures) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s:\n", section.title)
+		for _, f := range section.failures {
+			if f.Error != "" {
+				fmt.Fprintf(w, "  %s [%s] %s\n", f.Path, f.Stage, f.Error)
+			} else {
+				fmt.Fprintf(w, "  %s [%s]\n", f.Path, f.Stage)
+			}
+		}
+	}
+
+	if d.Clean() {
+		_, err := fmt.Fprintf(w, "\n今回の実行に失敗したファイルはありません\n")
+		return err
+	}
+	return nil
+}
+
+// formatBytes はバイト数を読みやすい形式にフォーマットする
+func formatBytes(n float64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%.0f B", n)
+	}
+	div, exp := float64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", n/div, "KMGTPE"[exp])
+}
+
+// labelOrDash はラベルが指定されていない場合に"-"を返す
+func labelOrDash(label string) string {
+	if label == "" {
+		return "-"
+	}
+	return label
+}
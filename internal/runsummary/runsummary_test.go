@@ -0,0 +1,123 @@
+package runsummary
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sakuhanight/gopier/internal/database"
+)
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.json")
+	s := &Summary{FilesCopied: 2, BytesCopied: 100, Throughput: 50}
+	s.AddFailure("b.txt", StageVerify, errors.New("ハッシュ値が一致しません"))
+	s.AddFailure("a.txt", StageCopy, nil)
+	if err := Save(path, s); err != nil {
+		t.Fatalf("Saveが失敗しました: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Loadが失敗しました: %v", err)
+	}
+	if loaded.Version != formatVersion || loaded.FilesCopied != 2 || loaded.Throughput != 50 {
+		t.Errorf("読み込んだ実行結果 = %+v", loaded)
+	}
+	if len(loaded.Failures) != 2 || loaded.Failures[0].Path != "a.txt" || loaded.Failures[1].Error == "" {
+		t.Errorf("失敗したファイル = %+v", loaded.Failures)
+	}
+}
+
+func TestLoad_DBExport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.json")
+	data, _ := json.Marshal([]database.FileInfo{
+		{Path: "ok.txt", Size: 10, Status: database.StatusSuccess},
+		{Path: "bad.txt", Status: database.StatusFailed, LastError: "アクセスが拒否されました"},
+		{Path: "diff.txt", Status: database.StatusMismatch},
+	})
+	os.WriteFile(path, data, 0644)
+
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Loadが失敗しました: %v", err)
+	}
+	if s.FilesCopied != 1 || s.FilesFailed != 1 || len(s.Failures) != 2 {
+		t.Errorf("ファイル情報からの実行結果 = %+v", s)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	before := &Summary{Throughput: 100, Failures: []Failure{
+		{Path: "fixed.txt", Stage: StageCopy},
+		{Path: "still.txt", Stage: StageCopy},
+		{Path: "still.txt", Stage: StageVerify},
+	}}
+	after := &Summary{Label: "wave3", Throughput: 150, Failures: []Failure{
+		{Path: "still.txt", Stage: StageVerify},
+		{Path: "new.txt", Stage: StageCopy, Error: "タイムアウト"},
+	}}
+
+	d := Compare(before, after)
+	if len(d.NewlyFailed) != 1 || d.NewlyFailed[0].Path != "new.txt" {
+		t.Errorf("新たに失敗 = %+v", d.NewlyFailed)
+	}
+	if len(d.Fixed) != 1 || d.Fixed[0].Path != "fixed.txt" {
+		t.Errorf("解消 = %+v", d.Fixed)
+	}
+	if len(d.StillFailing) != 1 || d.StillFailing[0].Path != "still.txt" {
+		t.Errorf("継続 = %+v", d.StillFailing)
+	}
+	if d.FailedBefore != 2 || d.FailedAfter != 2 || d.ThroughputChange != 50 {
+		t.Errorf("比較結果 = %+v", d)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteText(&buf, d); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "new.txt [copy] タイムアウト") || !strings.Contains(buf.String(), "+50.0%") || !strings.Contains(buf.String(), "ラベル: - -> wave3") {
+		t.Errorf("テキスト出力 = %s", buf.String())
+	}
+
+	if d := Compare(before, &Summary{}); !d.Clean() || len(d.Fixed) != 2 || d.ThroughputChange != 0 {
+		t.Errorf("失敗のない実行との比較 = %+v", d)
+	}
+}
+
+func TestDiff_Suppress(t *testing.T) {
+	before := &Summary{Failures: []Failure{
+		{Path: "$RECYCLE.BIN/a", Stage: StageVerify},
+		{Path: "$RECYCLE.BIN/old", Stage: StageVerify},
+		{Path: "fixed.txt", Stage: StageCopy},
+	}}
+	after := &Summary{Failures: []Failure{
+		{Path: "$RECYCLE.BIN/a", Stage: StageVerify},
+		{Path: "$RECYCLE.BIN/b", Stage: StageVerify},
+		{Path: "new.txt", Stage: StageCopy},
+	}}
+
+	d := Compare(before, after)
+	removed := d.Suppress(func(path string) bool { return strings.HasPrefix(path, "$RECYCLE.BIN/") })
+	if removed != 2 || d.Suppressed != 2 {
+		t.Errorf("除外した件数 = %d, %d, want 2", removed, d.Suppressed)
+	}
+	if len(d.NewlyFailed) != 1 || len(d.StillFailing) != 0 || len(d.Fixed) != 1 || d.Fixed[0].Path != "fixed.txt" {
+		t.Errorf("除外後の比較結果 = %+v", d)
+	}
+	if d.FailedBefore != 1 || d.FailedAfter != 1 {
+		t.Errorf("失敗件数 = %d -> %d, want 1 -> 1", d.FailedBefore, d.FailedAfter)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteText(&buf, d); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "確認済みの不一致として除外: 2件") {
+		t.Errorf("テキスト出力 = %s", buf.String())
+	}
+}
@@ -0,0 +1,295 @@
+// Package schedule は定期的な同期を登録するためのsystemdのユニットとWindowsのタスクスケジューラのXMLを生成する
+package schedule
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// Job は定期的に実行するgopierのジョブ
+type Job struct {
+	Name        string   // ユニット名・タスク名
+	Description string   // 説明
+	Executable  string   // gopierの実行ファイルの絶対パス
+	Args        []string // 実行時の引数（--configなど）
+	WorkDir     string   // 作業ディレクトリ（相対パスのデータベース・ログの基準）
+	Hour        int      // 実行する時刻（時）
+	Minute      int      // 実行する時刻（分）
+	// Interval は繰り返しの間隔（0の場合は1日1回）
+	// systemdでは起動後と前回の実行から、Windowsでは指定した時刻から数える
+	Interval time.Duration
+}
+
+// ParseTime は"HH:MM"形式の時刻を解析する
+func ParseTime(s string) (hour, minute int, err error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("時刻の形式が不正です（HH:MM形式で指定してください）: %s", s)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// Validate はジョブの設定を検証する
+func (j Job) Validate() error {
+	if j.Name == "" {
+		return fmt.Errorf("ジョブの名前が指定されていません")
+	}
+	for _, r := range j.Name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.@", r)) {
+			return fmt.Errorf("ジョブの名前に使用できない文字が含まれています（英数字と-_.@のみ）: %s", j.Name)
+		}
+	}
+	if j.Executable == "" {
+		return fmt.Errorf("実行ファイルが指定されていません")
+	}
+	if j.Hour < 0 || j.Hour > 23 || j.Minute < 0 || j.Minute > 59 {
+		return fmt.Errorf("時刻が不正です: %02d:%02d", j.Hour, j.Minute)
+	}
+	if j.Interval < 0 || j.Interval%time.Minute != 0 {
+		return fmt.Errorf("繰り返しの間隔は1分単位で指定してください: %s", j.Interval)
+	}
+	if j.Interval > 31*24*time.Hour {
+		return fmt.Errorf("繰り返しの間隔は31日以内で指定してください: %s", j.Interval)
+	}
+	return nil
+}
+
+// WriteSystemdService はジョブを1回実行するsystemdのサービスユニットを書き出す
+func WriteSystemdService(w io.Writer, j Job) error {
+	if err := j.Validate(); err != nil {
+		return err
+	}
+
+	args := []string{systemdQuote(j.Executable)}
+	for _, arg := range j.Args {
+		args = append(args, systemdQuote(arg))
+	}
+
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	fmt.Fprintf(&b, "Description=%s\n", systemdEscape(j.description()))
+	b.WriteString("Wants=network-online.target\n")
+	b.WriteString("After=network-online.target\n")
+	b.WriteString("\n[Service]\n")
+	b.WriteString("Type=oneshot\n")
+	if j.WorkDir != "" {
+		fmt.Fprintf(&b, "WorkingDirectory=%s\n", systemdEscape(j.WorkDir))
+	}
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(args, " "))
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteSystemdTimer はサービスユニットを定期的に起動するsystemdのタイマーユニットを書き出す
+func WriteSystemdTimer(w io.Writer, j Job) error {
+	if err := j.Validate(); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	fmt.Fprintf(&b, "Description=%s\n", systemdEscape(j.description()))
+	b.WriteString("\n[Timer]\n")
+	if j.Interval > 0 {
+		b.WriteString("OnBootSec=5min\n")
+		fmt.Fprintf(&b, "OnUnitActiveSec=%dmin\n", int64(j.Interval/time.Minute))
+	} else {
+		fmt.Fprintf(&b, "OnCalendar=*-*-* %02d:%02d:00\n", j.Hour, j.Minute)
+		// 停止中に実行時刻を過ぎた場合は、起動後に実行する
+		b.WriteString("Persistent=true\n")
+	}
+	fmt.Fprintf(&b, "Unit=%s.service\n", j.Name)
+	b.WriteString("\n[Install]\n")
+	b.WriteString("WantedBy=timers.target\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Windowsのタスクスケジューラのタスク定義（必要な要素のみ）
+type task struct {
+	XMLName          xml.Name         `xml:"Task"`
+	Version          string           `xml:"version,attr"`
+	Xmlns            string           `xml:"xmlns,attr"`
+	RegistrationInfo registrationInfo `xml:"RegistrationInfo"`
+	Triggers         triggers         `xml:"Triggers"`
+	Settings         settings         `xml:"Settings"`
+	Actions          actions          `xml:"Actions"`
+}
+
+type registrationInfo struct {
+	Description string `xml:"Description"`
+}
+
+type triggers struct {
+	CalendarTrigger *calendarTrigger `xml:"CalendarTrigger,omitempty"`
+	TimeTrigger     *timeTrigger     `xml:"TimeTrigger,omitempty"`
+}
+
+type calendarTrigger struct {
+	StartBoundary string `xml:"StartBoundary"`
+	Enabled       bool   `xml:"Enabled"`
+	DaysInterval  int    `xml:"ScheduleByDay>DaysInterval"`
+}
+
+type timeTrigger struct {
+	Interval      string `xml:"Repetition>Interval"`
+	StartBoundary string `xml:"StartBoundary"`
+	Enabled       bool   `xml:"Enabled"`
+}
+
+type settings struct {
+	MultipleInstancesPolicy    string `xml:"MultipleInstancesPolicy"`
+	DisallowStartIfOnBatteries bool   `xml:"DisallowStartIfOnBatteries"`
+	StopIfGoingOnBatteries     bool   `xml:"StopIfGoingOnBatteries"`
+	StartWhenAvailable         bool   `xml:"StartWhenAvailable"`
+	RunOnlyIfNetworkAvailable  bool   `xml:"RunOnlyIfNetworkAvailable"`
+	Enabled                    bool   `xml:"Enabled"`
+	ExecutionTimeLimit         string `xml:"ExecutionTimeLimit"`
+}
+
+type actions struct {
+	Context string     `xml:"Context,attr"`
+	Exec    execAction `xml:"Exec"`
+}
+
+type execAction struct {
+	Command          string `xml:"Command"`
+	Arguments        string `xml:"Arguments,omitempty"`
+	WorkingDirectory string `xml:"WorkingDirectory,omitempty"`
+}
+
+// TaskXML はWindowsのタスクスケジューラのタスク定義を返す
+// startは最初に実行する日付（時刻はジョブの時刻を使用する）
+func TaskXML(j Job, start time.Time) (string, error) {
+	if err := j.Validate(); err != nil {
+		return "", err
+	}
+
+	boundary := time.Date(start.Year(), start.Month(), start.Day(), j.Hour, j.Minute, 0, 0, time.Local).
+		Format("2006-01-02T15:04:05")
+
+	args := make([]string, len(j.Args))
+	for i, arg := range j.Args {
+		args[i] = windowsQuote(arg)
+	}
+
+	t := task{
+		Version:          "1.2",
+		Xmlns:            "http://schemas.microsoft.com/windows/2004/02/mit/task",
+		RegistrationInfo: registrationInfo{Description: j.description()},
+		Settings: settings{
+			// 前回の実行が終わっていない場合は新たに起動しない
+			MultipleInstancesPolicy: "IgnoreNew",
+			StartWhenAvailable:      true,
+			Enabled:                 true,
+			// 大量のファイルのコピーは長時間かかるため、実行時間を制限しない
+			ExecutionTimeLimit: "PT0S",
+		},
+		Actions: actions{
+			Context: "Author",
+			Exec: execAction{
+				Command:          j.Executable,
+				Arguments:        strings.Join(args, " "),
+				WorkingDirectory: j.WorkDir,
+			},
+		},
+	}
+	if j.Interval > 0 {
+		t.Triggers.TimeTrigger = &timeTrigger{
+			Interval:      fmt.Sprintf("PT%dM", int64(j.Interval/time.Minute)),
+			StartBoundary: boundary,
+			Enabled:       true,
+		}
+	} else {
+		t.Triggers.CalendarTrigger = &calendarTrigger{
+			StartBoundary: boundary,
+			Enabled:       true,
+			DaysInterval:  1,
+		}
+	}
+
+	data, err := xml.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return `<?xml version="1.0" encoding="UTF-16"?>` + "\n" + string(data) + "\n", nil
+}
+
+// WriteTaskXML はWindowsのタスクスケジューラのタスク定義を書き出す
+// schtasks /Create /XMLで読み込めるよう、BOM付きのUTF-16（リトルエンディアン）で書き出す
+func WriteTaskXML(w io.Writer, j Job, start time.Time) error {
+	text, err := TaskXML(j, start)
+	if err != nil {
+		return err
+	}
+
+	text = strings.ReplaceAll(text, "\n", "\r\n")
+	var buf bytes.Buffer
+	buf.Write([]byte{0xFF, 0xFE})
+	for _, u := range utf16.Encode([]rune(text)) {
+		binary.Write(&buf, binary.LittleEndian, u)
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// description はジョブの説明を返す（指定されていない場合は名前から作成する）
+func (j Job) description() string {
+	if j.Description != "" {
+		return j.Description
+	}
+	return "gopier: " + j.Name
+}
+
+// systemdEscape はsystemdの設定値で指定子・環境変数として解釈される文字をエスケープする
+func systemdEscape(s string) string {
+	s = strings.ReplaceAll(s, "%", "%%")
+	return strings.ReplaceAll(s, "$", "$$")
+}
+
+// systemdQuote はExecStartの引数として1つの引数になるように引用符で囲む
+func systemdQuote(s string) string {
+	s = systemdEscape(s)
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\;") {
+		return s
+	}
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// windowsQuote はCommandLineToArgvWの規則で1つの引数になるように引用符で囲む
+func windowsQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\"") {
+		return s
+	}
+
+	var b strings.Builder
+	b.WriteByte('"')
+	backslashes := 0
+	for _, r := range s {
+		switch r {
+		case '\\':
+			backslashes++
+			continue
+		case '"':
+			// 引用符の前のバックスラッシュは2倍にしてから引用符をエスケープする
+			b.WriteString(strings.Repeat(`\`, backslashes*2+1))
+		default:
+			b.WriteString(strings.Repeat(`\`, backslashes))
+		}
+		backslashes = 0
+		b.WriteRune(r)
+	}
+	// 閉じる引用符の前のバックスラッシュも2倍にする
+	b.WriteString(strings.Repeat(`\`, backslashes*2))
+	b.WriteByte('"')
+	return b.String()
+}
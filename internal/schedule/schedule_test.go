@@ -0,0 +1,160 @@
+package schedule
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+func testJob() Job {
+	return Job{
+		Name:       "gopier-nightly",
+		Executable: "/usr/local/bin/gopier",
+		Args:       []string{"--config", "/etc/gopier/nightly job.yaml"},
+		WorkDir:    "/var/lib/gopier",
+		Hour:       2,
+		Minute:     30,
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	hour, minute, err := ParseTime("02:30")
+	if err != nil || hour != 2 || minute != 30 {
+		t.Errorf("ParseTime() = %d, %d, %v", hour, minute, err)
+	}
+	for _, s := range []string{"", "25:00", "2:3", "02:30:00"} {
+		if _, _, err := ParseTime(s); err == nil {
+			t.Errorf("ParseTime(%q) はエラーになるべきです", s)
+		}
+	}
+}
+
+func TestJob_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Job)
+		wantErr bool
+	}{
+		{"正常", func(j *Job) {}, false},
+		{"間隔あり", func(j *Job) { j.Interval = 4 * time.Hour }, false},
+		{"名前なし", func(j *Job) { j.Name = "" }, true},
+		{"名前に空白", func(j *Job) { j.Name = "gopier nightly" }, true},
+		{"名前にパス", func(j *Job) { j.Name = "../gopier" }, true},
+		{"実行ファイルなし", func(j *Job) { j.Executable = "" }, true},
+		{"分単位でない間隔", func(j *Job) { j.Interval = 90 * time.Second }, true},
+		{"長すぎる間隔", func(j *Job) { j.Interval = 32 * 24 * time.Hour }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := testJob()
+			tt.modify(&j)
+			if err := j.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWriteSystemd(t *testing.T) {
+	j := testJob()
+	j.Args = append(j.Args, "--label", "100%")
+
+	var service bytes.Buffer
+	if err := WriteSystemdService(&service, j); err != nil {
+		t.Fatalf("WriteSystemdService() error = %v", err)
+	}
+	for _, want := range []string{
+		"Description=gopier: gopier-nightly\n",
+		"Type=oneshot\n",
+		"WorkingDirectory=/var/lib/gopier\n",
+		`ExecStart=/usr/local/bin/gopier --config "/etc/gopier/nightly job.yaml" --label 100%%` + "\n",
+	} {
+		if !strings.Contains(service.String(), want) {
+			t.Errorf("サービスユニットに %q が含まれていません:\n%s", want, service.String())
+		}
+	}
+
+	var timer bytes.Buffer
+	if err := WriteSystemdTimer(&timer, j); err != nil {
+		t.Fatalf("WriteSystemdTimer() error = %v", err)
+	}
+	for _, want := range []string{"OnCalendar=*-*-* 02:30:00\n", "Persistent=true\n", "Unit=gopier-nightly.service\n", "WantedBy=timers.target\n"} {
+		if !strings.Contains(timer.String(), want) {
+			t.Errorf("タイマーユニットに %q が含まれていません:\n%s", want, timer.String())
+		}
+	}
+
+	j.Interval = 6 * time.Hour
+	timer.Reset()
+	if err := WriteSystemdTimer(&timer, j); err != nil {
+		t.Fatalf("WriteSystemdTimer() error = %v", err)
+	}
+	if !strings.Contains(timer.String(), "OnUnitActiveSec=360min\n") || strings.Contains(timer.String(), "OnCalendar") {
+		t.Errorf("間隔を指定したタイマーユニットが不正です:\n%s", timer.String())
+	}
+}
+
+func TestTaskXML(t *testing.T) {
+	j := testJob()
+	j.Executable = `C:\Program Files\gopier\gopier.exe`
+	j.Args = []string{"--config", `C:\gopier\nightly job.yaml`, "--label", `a"b`}
+	start := time.Date(2025, 1, 10, 12, 0, 0, 0, time.Local)
+
+	text, err := TaskXML(j, start)
+	if err != nil {
+		t.Fatalf("TaskXML() error = %v", err)
+	}
+	for _, want := range []string{
+		`<?xml version="1.0" encoding="UTF-16"?>`,
+		"<StartBoundary>2025-01-10T02:30:00</StartBoundary>",
+		"<DaysInterval>1</DaysInterval>",
+		"<MultipleInstancesPolicy>IgnoreNew</MultipleInstancesPolicy>",
+		`<Command>C:\Program Files\gopier\gopier.exe</Command>`,
+		`<Arguments>--config &#34;C:\gopier\nightly job.yaml&#34; --label &#34;a\&#34;b&#34;</Arguments>`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("タスク定義に %q が含まれていません:\n%s", want, text)
+		}
+	}
+
+	j.Interval = 30 * time.Minute
+	if text, _ = TaskXML(j, start); !strings.Contains(text, "<Interval>PT30M</Interval>") || strings.Contains(text, "CalendarTrigger") {
+		t.Errorf("間隔を指定したタスク定義が不正です:\n%s", text)
+	}
+
+	// schtasksで読み込めるようBOM付きのUTF-16で書き出す
+	var buf bytes.Buffer
+	if err := WriteTaskXML(&buf, j, start); err != nil {
+		t.Fatalf("WriteTaskXML() error = %v", err)
+	}
+	data := buf.Bytes()
+	if !bytes.HasPrefix(data, []byte{0xFF, 0xFE}) {
+		t.Fatalf("BOMがありません: % x", data[:2])
+	}
+	units := make([]uint16, (len(data)-2)/2)
+	binary.Read(bytes.NewReader(data[2:]), binary.LittleEndian, units)
+	if decoded := string(utf16.Decode(units)); !strings.Contains(decoded, "<Interval>PT30M</Interval>\r\n") {
+		t.Errorf("UTF-16の内容が不正です:\n%s", decoded)
+	}
+}
+
+func TestWindowsQuote(t *testing.T) {
+	tests := map[string]string{
+		"plain":         "plain",
+		"":              `""`,
+		"with space":    `"with space"`,
+		`C:\dir\`:       `C:\dir\`,
+		`C:\my dir\`:    `"C:\my dir\\"`,
+		`say "hi"`:      `"say \"hi\""`,
+		`back\"slash`:   `"back\\\"slash"`,
+		`C:\a b\c.yaml`: `"C:\a b\c.yaml"`,
+	}
+	for in, want := range tests {
+		if got := windowsQuote(in); got != want {
+			t.Errorf("windowsQuote(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package seeder
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/filter"
+	"github.com/sakuhanight/gopier/internal/fsmeta"
+	"github.com/sakuhanight/gopier/internal/hasher"
+	"github.com/sakuhanight/gopier/internal/logger"
+	"github.com/sakuhanight/gopier/internal/pathkey"
+)
+
+// Options はシード処理のオプション
+type Options struct {
+	BufferSize    int    // ハッシュ計算のバッファサイズ
+	Recursive     bool   // 再帰的に処理するかどうか
+	HashAlgorithm string // ハッシュアルゴリズム
+	MaxConcurrent int    // 最大並行ハッシュ計算数
+	Force         bool   // 変更のないエントリもハッシュを再計算するかどうか
+}
+
+// DefaultOptions はデフォルトのオプションを返す
+func DefaultOptions() Options {
+	return Options{
+		BufferSize:    32 * 1024 * 1024, // 32MB
+		Recursive:     true,
+		HashAlgorithm: string(hasher.SHA256),
+		MaxConcurrent: 4,
+	}
+}
+
+// Result はシード処理の結果
+type Result struct {
+	Scanned     int64 // 走査したファイル数
+	Hashed      int64 // ハッシュを計算したファイル数
+	Fresh       int64 // 記録が最新のため再計算しなかったファイル数
+	Failed      int64 // 失敗したファイル数
+	HashedBytes int64 // ハッシュを計算したバイト数
+}
+
+// Seeder はコピーせずにソースのハッシュ一覧をデータベースに記録する
+type Seeder struct {
+	sourceDir string
+	options   Options
+	filter    *filter.Filter
+	db        *database.SyncDB
+	logger    *logger.Logger
+	hasher    *hasher.Hasher
+	result    Result
+	wg        sync.WaitGroup
+	semaphore chan struct{}
+	ctx       context.Context
+	cancel    context.CancelFunc
+}
+
+// NewSeeder は新しいSeederを作成する
+func NewSeeder(sourceDir string, options Options, fileFilter *filter.Filter, syncDB *database.SyncDB, log *logger.Logger) *Seeder {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if options.MaxConcurrent < 1 {
+		options.MaxConcurrent = 1
+	}
+
+	return &Seeder{
+		sourceDir: sourceDir,
+		options:   options,
+		filter:    fileFilter,
+		db:        syncDB,
+		logger:    log,
+		hasher:    hasher.NewHasher(hasher.Algorithm(options.HashAlgorithm), options.BufferSize),
+		semaphore: make(chan struct{}, options.MaxConcurrent),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// Cancel はシード処理をキャンセルする
+func (s *Seeder) Cancel() {
+	s.cancel()
+}
+
+// Run はソースを走査し、変更のあったファイルのハッシュを記録する
+func (s *Seeder) Run() (Result, error) {
+	if s.db == nil {
+		return s.result, fmt.Errorf("データベースが指定されていません")
+	}
+	if _, err := os.Stat(s.sourceDir); err != nil {
+		return s.result, fmt.Errorf("ソースディレクトリ(%s)の確認エラー: %w", s.sourceDir, err)
+	}
+
+	err := filepath.Walk(s.sourceDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			atomic.AddInt64(&s.result.Failed, 1)
+			if s.logger != nil {
+				s.logger.Error("走査エラー: %s: %v", path, err)
+			}
+			return nil
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return fmt.Errorf("シード処理がキャンセルされました")
+		default:
+		}
+
+		if info.IsDir() {
+			if path != s.sourceDir && !s.options.Recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		if s.filter != nil && !s.filter.ShouldInclude(path) {
+			return nil
+		}
+
+		atomic.AddInt64(&s.result.Scanned, 1)
+		s.seedFileAsync(path, info)
+		return nil
+	})
+
+	s.wg.Wait()
+
+	return s.result, err
+}
+
+// seedFileAsync は非同期でファイルのハッシュを記録する
+func (s *Seeder) seedFileAsync(path string, info os.FileInfo) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		s.semaphore <- struct{}{}
+		defer func() {
+			<-s.semaphore
+		}()
+
+		if err := s.seedFile(path, info); err != nil {
+			atomic.AddInt64(&s.result.Failed, 1)
+			if s.logger != nil {
+				s.logger.Error("%v", err)
+			}
+		}
+	}()
+}
+
+// seedFile は単一ファイルのハッシュを記録する
+// 記録済みのサイズ・更新日時・アルゴリズムが一致する場合は再計算しない
+func (s *Seeder) seedFile(path string, info os.FileInfo) error {
+	relPath, err := pathkey.Rel(s.sourceDir, path)
+	if err != nil {
+		relPath = pathkey.Normalize(filepath.Base(path))
+	}
+
+	existing, err := s.db.GetFile(relPath)
+	if err != nil {
+		existing = nil
+	}
+
+	changed := existing == nil || existing.Size != info.Size() || !existing.ModTime.Equal(info.ModTime())
+	if !s.options.Force && !changed && existing.SourceHash != "" && existing.HashAlgo == s.options.HashAlgorithm {
+		// メタデータを持たない旧形式のレコードは、ハッシュを再計算せずにメタデータだけ記録する
+		if existing.Meta == nil {
+			if meta, err := fsmeta.Collect(path); err == nil {
+				existing.Meta = meta
+				if err := s.db.AddFile(*existing); err != nil {
+					return fmt.Errorf("データベース記録エラー: %s: %w", relPath, err)
+				}
+			}
+		}
+		atomic.AddInt64(&s.result.Fresh, 1)
+		return nil
+	}
+
+	hash, err := s.hasher.HashFile(path)
+	if err != nil {
+		return fmt.Errorf("ハッシュ計算エラー: %s: %w", relPath, err)
+	}
+
+	// 既存のレコードは同期状態を保ったままハッシュを更新する
+	// 内容が変わっている場合は宛先が古くなっているため同期待ちに戻す
+	record := database.FileInfo{
+		Path:   relPath,
+		Status: database.StatusPending,
+	}
+	if existing != nil {
+		record = *existing
+		if changed {
+			record.Status = database.StatusPending
+			record.DestHash = ""
+		}
+	}
+	record.Size = info.Size()
+	record.ModTime = info.ModTime()
+	record.SourceHash = hash
+	record.HashAlgo = s.options.HashAlgorithm
+	if meta, err := fsmeta.Collect(path); err == nil {
+		record.Meta = meta
+	}
+
+	if err := s.db.AddFile(record); err != nil {
+		return fmt.Errorf("データベース記録エラー: %s: %w", relPath, err)
+	}
+
+	atomic.AddInt64(&s.result.Hashed, 1)
+	atomic.AddInt64(&s.result.HashedBytes, info.Size())
+
+	if s.logger != nil && s.logger.Verbose {
+		s.logger.Info("ハッシュを記録: %s (%s)", relPath, hash)
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package seeder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/filter"
+)
+
+func setupSeedTest(t *testing.T) (string, *database.SyncDB) {
+	t.Helper()
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	os.MkdirAll(filepath.Join(sourceDir, "sub"), 0755)
+	os.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("aaa"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "sub", "b.txt"), []byte("bbb"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "skip.tmp"), []byte("tmp"), 0644)
+
+	syncDB, err := database.NewSyncDB(filepath.Join(tempDir, "sync.db"), database.NormalSync)
+	if err != nil {
+		t.Fatalf("データベース作成に失敗: %v", err)
+	}
+	t.Cleanup(func() { syncDB.Close() })
+
+	return sourceDir, syncDB
+}
+
+func TestSeeder_Run(t *testing.T) {
+	sourceDir, syncDB := setupSeedTest(t)
+
+	s := NewSeeder(sourceDir, DefaultOptions(), filter.NewFilter("", "*.tmp"), syncDB, nil)
+	result, err := s.Run()
+	if err != nil {
+		t.Fatalf("Runが失敗しました: %v", err)
+	}
+	if result.Scanned != 2 || result.Hashed != 2 || result.Fresh != 0 || result.Failed != 0 {
+		t.Errorf("結果が期待値と異なります: %+v", result)
+	}
+	if result.HashedBytes != 6 {
+		t.Errorf("HashedBytes: 期待値=6, 実際=%d", result.HashedBytes)
+	}
+
+	file, err := syncDB.GetFile("sub/b.txt")
+	if err != nil || file == nil {
+		t.Fatalf("ファイル情報の取得に失敗: %v", err)
+	}
+	if file.Status != database.StatusPending || file.SourceHash == "" || file.HashAlgo != "sha256" || file.Size != 3 {
+		t.Errorf("記録内容が期待値と異なります: %+v", file)
+	}
+	if f, _ := syncDB.GetFile("skip.tmp"); f != nil {
+		t.Error("除外されたファイルが記録されました")
+	}
+
+	// コピーせずに記録するだけなので、宛先は作成されない
+	if _, err := os.Stat(filepath.Join(filepath.Dir(sourceDir), "dest")); !os.IsNotExist(err) {
+		t.Error("宛先が作成されました")
+	}
+}
+
+func TestSeeder_IncrementalRefresh(t *testing.T) {
+	sourceDir, syncDB := setupSeedTest(t)
+
+	if _, err := NewSeeder(sourceDir, DefaultOptions(), nil, syncDB, nil).Run(); err != nil {
+		t.Fatalf("1回目のRunが失敗しました: %v", err)
+	}
+
+	// 同期済みのレコードは状態を保ったままにする
+	b, _ := syncDB.GetFile("sub/b.txt")
+	b.Status = database.StatusSuccess
+	b.DestHash = b.SourceHash
+	syncDB.AddFile(*b)
+
+	// a.txtのみ変更する
+	aPath := filepath.Join(sourceDir, "a.txt")
+	os.WriteFile(aPath, []byte("changed"), 0644)
+	os.Chtimes(aPath, time.Now(), time.Now().Add(time.Minute))
+
+	result, err := NewSeeder(sourceDir, DefaultOptions(), nil, syncDB, nil).Run()
+	if err != nil {
+		t.Fatalf("2回目のRunが失敗しました: %v", err)
+	}
+	if result.Hashed != 1 || result.Fresh != 2 {
+		t.Errorf("変更されたファイルのみ再計算されるべきです: %+v", result)
+	}
+
+	b, _ = syncDB.GetFile("sub/b.txt")
+	if b.Status != database.StatusSuccess || b.DestHash == "" {
+		t.Errorf("変更のないレコードの状態が変わりました: %+v", b)
+	}
+
+	// アルゴリズムが異なる場合や強制指定時は再計算する
+	options := DefaultOptions()
+	options.HashAlgorithm = "md5"
+	result, _ = NewSeeder(sourceDir, options, nil, syncDB, nil).Run()
+	if result.Hashed != 3 {
+		t.Errorf("アルゴリズム変更時はすべて再計算されるべきです: %+v", result)
+	}
+	b, _ = syncDB.GetFile("sub/b.txt")
+	if b.HashAlgo != "md5" || b.Status != database.StatusSuccess {
+		t.Errorf("内容が変わっていないレコードは同期状態を保つべきです: %+v", b)
+	}
+
+	options.Force = true
+	result, _ = NewSeeder(sourceDir, options, nil, syncDB, nil).Run()
+	if result.Hashed != 3 || result.Fresh != 0 {
+		t.Errorf("強制指定時はすべて再計算されるべきです: %+v", result)
+	}
+}
+
+func TestSeeder_NoDatabase(t *testing.T) {
+	if _, err := NewSeeder(t.TempDir(), DefaultOptions(), nil, nil, nil).Run(); err == nil {
+		t.Error("データベースがない場合はエラーを返すべきです")
+	}
+
+	_, syncDB := setupSeedTest(t)
+	if _, err := NewSeeder(filepath.Join(t.TempDir(), "missing"), DefaultOptions(), nil, syncDB, nil).Run(); err == nil {
+		t.Error("ソースが存在しない場合はエラーを返すべきです")
+	}
+}
+
+func TestSeeder_BackfillsMetadata(t *testing.T) {
+	sourceDir, syncDB := setupSeedTest(t)
+
+	// メタデータを持たない最新のレコード（旧バージョンで作成されたもの）
+	info, err := os.Stat(filepath.Join(sourceDir, "a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	syncDB.AddFile(database.FileInfo{
+		Path:       "a.txt",
+		Size:       info.Size(),
+		ModTime:    info.ModTime(),
+		Status:     database.StatusSuccess,
+		SourceHash: "recorded",
+		HashAlgo:   "sha256",
+	})
+
+	s := NewSeeder(sourceDir, DefaultOptions(), filter.NewFilter("a.txt", ""), syncDB, nil)
+	result, err := s.Run()
+	if err != nil {
+		t.Fatalf("Runが失敗しました: %v", err)
+	}
+	if result.Fresh != 1 || result.Hashed != 0 {
+		t.Errorf("結果が期待値と異なります: %+v", result)
+	}
+
+	file, err := syncDB.GetFile("a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Meta == nil || !file.Meta.Mode.IsRegular() {
+		t.Errorf("メタデータが記録されていません: %+v", file.Meta)
+	}
+	if file.SourceHash != "recorded" || file.Status != database.StatusSuccess {
+		t.Errorf("ハッシュや同期状態が変更されました: %+v", file)
+	}
+}
@@ -0,0 +1,188 @@
+// Package sidecar は宛先のファイルシステムで失われるメタデータ（所有者・ACL・拡張属性・更新日時）を
+// ディレクトリごとのJSONファイルに保存し、対応したファイルシステムにコピーし直した後で復元する
+package sidecar
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sakuhanight/gopier/internal/fsmeta"
+)
+
+// FileName はメタデータを保存するファイルの名前
+const FileName = ".gopier.meta"
+
+// SelfEntry はディレクトリ自身のメタデータのキー
+const SelfEntry = "."
+
+// formatVersion はファイル形式のバージョン
+const formatVersion = 1
+
+// File はディレクトリ内のエントリのメタデータを表す構造体
+type File struct {
+	Version int                         `json:"version"`
+	Entries map[string]*fsmeta.Snapshot `json:"entries"` // キーはエントリ名（ディレクトリ自身はSelfEntry）
+}
+
+// Write はディレクトリにメタデータのファイルを書き込む
+// 途中で失敗しても既存のファイルが壊れないよう、一時ファイルに書き込んでから置き換える
+func Write(dir string, entries map[string]*fsmeta.Snapshot) error {
+	data, err := json.MarshalIndent(File{Version: formatVersion, Entries: entries}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("メタデータのシリアライズエラー: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, FileName+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("一時ファイルの作成エラー: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("メタデータの書き込みエラー: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("メタデータの書き込みエラー: %w", err)
+	}
+	// 一時ファイルは所有者のみ読み書きできる状態で作成されるため、通常のファイルと同じにする
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("メタデータの書き込みエラー: %w", err)
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, FileName))
+}
+
+// Read はディレクトリのメタデータのファイルを読み込む
+func Read(dir string) (*File, error) {
+	data, err := os.ReadFile(filepath.Join(dir, FileName))
+	if err != nil {
+		return nil, err
+	}
+
+	var file File
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("メタデータの形式が不正です: %w", err)
+	}
+	if file.Version > formatVersion {
+		return nil, fmt.Errorf("未対応のメタデータのバージョンです: %d", file.Version)
+	}
+	return &file, nil
+}
+
+// RestoreResult は復元の結果を表す構造体
+type RestoreResult struct {
+	Files   int // 読み込んだメタデータのファイル数
+	Applied int // メタデータを復元したエントリ数
+	Missing int // 記録されているが存在しないエントリ数
+	Failed  int // 復元に失敗したエントリ数
+	Removed int // 削除したメタデータのファイル数
+}
+
+// RestoreOptions は復元のオプションを表す構造体
+type RestoreOptions struct {
+	Remove  bool                         // メタデータのファイルを削除するかどうか
+	DryRun  bool                         // 実際には復元しない
+	OnError func(path string, err error) // エントリごとのエラーの通知先（nilの場合は通知しない）
+}
+
+// Restore はroot以下のメタデータのファイルを探し、記録されたメタデータを各エントリに復元する
+// 親ディレクトリの更新日時が子の変更で変わらないよう、深い階層のディレクトリから処理し、
+// ディレクトリ自身のメタデータはその中のエントリの後に復元する
+// opts.Removeを指定した場合、メタデータのファイルはすべてのエントリを復元できたディレクトリのみ削除する
+func Restore(root string, opts RestoreOptions) (*RestoreResult, error) {
+	var dirs []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && d.Name() == FileName {
+			dirs = append(dirs, filepath.Dir(path))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		return strings.Count(dirs[i], string(filepath.Separator)) > strings.Count(dirs[j], string(filepath.Separator))
+	})
+
+	result := &RestoreResult{}
+	report := func(path string, err error) {
+		if opts.OnError != nil {
+			opts.OnError(path, err)
+		}
+	}
+
+	for _, dir := range dirs {
+		file, err := Read(dir)
+		if err != nil {
+			result.Failed++
+			report(filepath.Join(dir, FileName), err)
+			continue
+		}
+		result.Files++
+
+		names := make([]string, 0, len(file.Entries))
+		for name := range file.Entries {
+			if name != SelfEntry {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		if _, ok := file.Entries[SelfEntry]; ok {
+			names = append(names, SelfEntry)
+		}
+
+		failed := false
+		for _, name := range names {
+			// 記録を書き換えてディレクトリの外を指すことがないよう、エントリ名を検証する
+			if file.Entries[name] == nil || (name != SelfEntry && (name != filepath.Base(name) || name == ".." || strings.ContainsAny(name, `/\`))) {
+				result.Failed++
+				failed = true
+				report(filepath.Join(dir, FileName), fmt.Errorf("不正なエントリ名: %q", name))
+				continue
+			}
+
+			path := filepath.Join(dir, name)
+			if _, err := os.Lstat(path); os.IsNotExist(err) {
+				result.Missing++
+				continue
+			}
+			if opts.DryRun {
+				result.Applied++
+				continue
+			}
+			if err := file.Entries[name].Apply(path); err != nil {
+				result.Failed++
+				failed = true
+				report(path, err)
+				continue
+			}
+			result.Applied++
+		}
+
+		// 復元に失敗したエントリがある場合は、再度復元できるようメタデータのファイルを残す
+		if !opts.Remove || opts.DryRun || failed {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, FileName)); err != nil {
+			report(filepath.Join(dir, FileName), err)
+			continue
+		}
+		result.Removed++
+		// 削除による更新日時の変化が残らないよう、ディレクトリ自身のメタデータを設定し直す
+		if self := file.Entries[SelfEntry]; self != nil {
+			if err := self.Apply(dir); err != nil {
+				result.Failed++
+				report(dir, err)
+			}
+		}
+	}
+	return result, nil
+}
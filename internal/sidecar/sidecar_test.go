@@ -0,0 +1,101 @@
+package sidecar
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/fsmeta"
+)
+
+func TestWriteRead(t *testing.T) {
+	dir := t.TempDir()
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	entries := map[string]*fsmeta.Snapshot{
+		SelfEntry: {Mode: os.ModeDir | 0755, ModTime: modTime},
+		"a.txt":   {Mode: 0600, ModTime: modTime, Owner: "1000:1000", Xattrs: map[string][]byte{"user.tag": {0, 1, 2}}},
+	}
+	if err := Write(dir, entries); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	file, err := Read(dir)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	got := file.Entries["a.txt"]
+	if got == nil || got.Mode != 0600 || !got.ModTime.Equal(modTime) || got.Owner != "1000:1000" || string(got.Xattrs["user.tag"]) != "\x00\x01\x02" {
+		t.Errorf("Read() = %+v", got)
+	}
+
+	// 一時ファイルが残っていないこと
+	names, _ := os.ReadDir(dir)
+	if len(names) != 1 || names[0].Name() != FileName {
+		t.Errorf("ディレクトリの内容 = %v", names)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("パーミッションのビットはWindowsでは検証できません")
+	}
+
+	root := t.TempDir()
+	sub := filepath.Join(root, "sub")
+	os.MkdirAll(sub, 0755)
+	os.WriteFile(filepath.Join(sub, "a.txt"), []byte("a"), 0644)
+
+	fileTime := time.Date(2020, 5, 6, 7, 8, 9, 0, time.UTC)
+	dirTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	Write(sub, map[string]*fsmeta.Snapshot{
+		SelfEntry:   {Mode: os.ModeDir | 0750, ModTime: dirTime},
+		"a.txt":     {Mode: 0600, ModTime: fileTime},
+		"gone.txt":  {Mode: 0644, ModTime: fileTime},
+		"../escape": {Mode: 0644, ModTime: fileTime},
+	})
+
+	var errs []string
+	result, err := Restore(root, RestoreOptions{Remove: true, OnError: func(path string, err error) {
+		errs = append(errs, err.Error())
+	}})
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	// 復元に失敗したエントリがある場合はメタデータのファイルを削除しない
+	want := RestoreResult{Files: 1, Applied: 2, Missing: 1, Failed: 1}
+	if *result != want {
+		t.Errorf("Restore() = %+v, want %+v (errors: %v)", *result, want, errs)
+	}
+	if _, err := os.Stat(filepath.Join(sub, FileName)); err != nil {
+		t.Errorf("復元に失敗したメタデータのファイルが削除されています: %v", err)
+	}
+
+	info, _ := os.Stat(filepath.Join(sub, "a.txt"))
+	if info.Mode().Perm() != 0600 || !info.ModTime().Equal(fileTime) {
+		t.Errorf("ファイル: mode=%o, modTime=%v", info.Mode().Perm(), info.ModTime())
+	}
+
+	// すべてのエントリを復元できた場合はメタデータのファイルを削除する
+	Write(sub, map[string]*fsmeta.Snapshot{
+		SelfEntry: {Mode: os.ModeDir | 0750, ModTime: dirTime},
+		"a.txt":   {Mode: 0600, ModTime: fileTime},
+	})
+	result, err = Restore(root, RestoreOptions{Remove: true})
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	want = RestoreResult{Files: 1, Applied: 2, Removed: 1}
+	if *result != want {
+		t.Errorf("Restore() = %+v, want %+v", *result, want)
+	}
+	// メタデータのファイルを削除した後もディレクトリの更新日時が復元されていること
+	info, _ = os.Stat(sub)
+	if info.Mode().Perm() != 0750 || !info.ModTime().Equal(dirTime) {
+		t.Errorf("ディレクトリ: mode=%o, modTime=%v", info.Mode().Perm(), info.ModTime())
+	}
+	if _, err := os.Stat(filepath.Join(sub, FileName)); !os.IsNotExist(err) {
+		t.Error("メタデータのファイルが削除されていません")
+	}
+}
@@ -0,0 +1,211 @@
+// Package stampcheck は宛先のファイルの拡張属性（WindowsではADS）に記録したハッシュと、ファイルの内容を比較する
+// 同期DBやソースにアクセスできない環境でも、宛先のツリーのみで内容が変わっていないことを検証できる
+package stampcheck
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/filter"
+	"github.com/sakuhanight/gopier/internal/fsmeta"
+	"github.com/sakuhanight/gopier/internal/hasher"
+	"github.com/sakuhanight/gopier/internal/pathkey"
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+// 結果の種類
+const (
+	KindMismatch  = "mismatch"  // 内容が記録したハッシュと一致しない
+	KindUnstamped = "unstamped" // ハッシュが記録されていない
+	KindError     = "error"     // ファイルまたは記録を読み込めない
+)
+
+// Entry は一致しなかったファイルを表す構造体
+type Entry struct {
+	Path      string `json:"path"`
+	Kind      string `json:"kind"`
+	Algorithm string `json:"algo,omitempty"`
+	Expected  string `json:"expected,omitempty"` // 記録したハッシュ
+	Actual    string `json:"actual,omitempty"`   // 計算したハッシュ
+	StampedAt string `json:"stamped_at,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Result は検証結果全体を表す構造体
+type Result struct {
+	Dir     string         `json:"dir"`
+	Files   int            `json:"files"`   // 検証したファイル数
+	Matched int            `json:"matched"` // 記録したハッシュと一致したファイル数
+	Counts  map[string]int `json:"counts"`  // 種類ごとの件数
+	Entries []Entry        `json:"entries"` // 一致しなかったファイル（パス順）
+	mu      sync.Mutex
+}
+
+// Damaged は内容が一致しない、または読み込めないファイルがあるかどうかを返す（記録がないファイルは含めない）
+func (r *Result) Damaged() bool {
+	return r.Counts[KindMismatch] > 0 || r.Counts[KindError] > 0
+}
+
+// Options は検証のオプションを表す構造体
+type Options struct {
+	Filter    *filter.Filter // 検証するファイルのフィルタ
+	Workers   int            // 並行してハッシュを計算するファイル数（0以下はデフォルト）
+	DropCache bool           // キャッシュを経由せずにディスクから読み込む
+}
+
+// defaultWorkers は並行してハッシュを計算するファイル数のデフォルト
+const defaultWorkers = 4
+
+// Verify はディレクトリ以下のすべてのファイルの内容を、拡張属性に記録したハッシュと比較する
+func Verify(dir string, opts Options) (*Result, error) {
+	if info, err := vfs.OS.Stat(dir); err != nil {
+		return nil, fmt.Errorf("ディレクトリにアクセスできません: %w", err)
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("ディレクトリを指定してください: %s", dir)
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	result := &Result{Dir: dir, Counts: map[string]int{}, Entries: []Entry{}}
+	paths := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				result.add(dir, path, verifyFile(path, opts))
+			}
+		}()
+	}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			result.add(dir, path, &Entry{Kind: KindError, Error: err.Error()})
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if path != dir && opts.Filter != nil && opts.Filter.ExcludesDir(path) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || (opts.Filter != nil && !opts.Filter.ShouldInclude(path)) {
+			return nil
+		}
+		paths <- path
+		return nil
+	})
+	close(paths)
+	wg.Wait()
+	if err != nil {
+		return nil, fmt.Errorf("ディレクトリの走査に失敗: %w", err)
+	}
+
+	sort.Slice(result.Entries, func(i, j int) bool { return result.Entries[i].Path < result.Entries[j].Path })
+	return result, nil
+}
+
+// add はファイルごとの結果を集計する（一致した場合はentryがnil）
+func (r *Result) add(dir, path string, entry *Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Files++
+	if entry == nil {
+		r.Matched++
+		return
+	}
+	entry.Path = path
+	if rel, err := pathkey.Rel(dir, path); err == nil {
+		entry.Path = rel
+	}
+	r.Entries = append(r.Entries, *entry)
+	r.Counts[entry.Kind]++
+}
+
+// verifyFile は1つのファイルの内容を記録したハッシュと比較する（一致した場合はnil）
+func verifyFile(path string, opts Options) *Entry {
+	stamp, err := fsmeta.ReadStamp(path)
+	if err != nil {
+		return &Entry{Kind: KindError, Error: err.Error()}
+	}
+	if stamp == nil {
+		return &Entry{Kind: KindUnstamped}
+	}
+
+	entry := &Entry{Algorithm: stamp.Algorithm, Expected: stamp.Hash, StampedAt: stamp.StampedAt.Format(time.RFC3339)}
+	actual, err := hashFile(path, stamp.Algorithm, opts.DropCache)
+	if err != nil {
+		entry.Kind, entry.Error = KindError, err.Error()
+		return entry
+	}
+	if actual != stamp.Hash {
+		entry.Kind, entry.Actual = KindMismatch, actual
+		return entry
+	}
+	return nil
+}
+
+// hashFile は記録したアルゴリズムでファイルのハッシュ値を計算する
+func hashFile(path, algorithm string, dropCache bool) (string, error) {
+	open := vfs.OS.Open
+	if dropCache {
+		open = func(name string) (vfs.File, error) { return vfs.OpenUncached(vfs.OS, name) }
+	}
+	file, err := open(path)
+	if err != nil {
+		return "", fmt.Errorf("ファイルを開けません: %w", err)
+	}
+	defer file.Close()
+	return hasher.NewHasher(hasher.Algorithm(algorithm), 0).HashReader(file)
+}
+
+// WriteText は一致しなかったファイルと集計を人が読む形式で書き出す
+func WriteText(w io.Writer, result *Result) error {
+	for _, entry := range result.Entries {
+		switch entry.Kind {
+		case KindMismatch:
+			fmt.Fprintf(w, "不一致: %s (記録: %s, 計算: %s, 記録日時: %s)\n", entry.Path, entry.Expected, entry.Actual, entry.StampedAt)
+		case KindUnstamped:
+			fmt.Fprintf(w, "記録なし: %s\n", entry.Path)
+		default:
+			fmt.Fprintf(w, "エラー: %s: %s\n", entry.Path, entry.Error)
+		}
+	}
+	_, err := fmt.Fprintf(w, "検証: %d件, 一致: %d件, 不一致: %d件, 記録なし: %d件, エラー: %d件\n",
+		result.Files, result.Matched, result.Counts[KindMismatch], result.Counts[KindUnstamped], result.Counts[KindError])
+	return err
+}
+
+// WriteCSV は一致しなかったファイルをCSVで書き出す
+func WriteCSV(w io.Writer, result *Result) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"path", "kind", "algo", "expected", "actual", "stamped_at", "error"})
+	for _, entry := range result.Entries {
+		writer.Write([]string{entry.Path, entry.Kind, entry.Algorithm, entry.Expected, entry.Actual, entry.StampedAt, entry.Error})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSON は検証結果をJSONで書き出す
+func WriteJSON(w io.Writer, result *Result) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
@@ -0,0 +1,94 @@
+package stampcheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/fsmeta"
+	"github.com/sakuhanight/gopier/internal/hasher"
+)
+
+// stamp はファイルの現在の内容のハッシュを記録する（拡張属性に対応していない環境ではスキップ）
+func stamp(t *testing.T, path string) {
+	t.Helper()
+	hash, err := hasher.NewHasher(hasher.SHA256, 0).HashFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fsmeta.WriteStamp(path, fsmeta.Stamp{Algorithm: string(hasher.SHA256), Hash: hash, StampedAt: time.Now()})
+	if errors.Is(err, fsmeta.ErrStampUnsupported) || errors.Is(err, os.ErrPermission) {
+		t.Skipf("拡張属性に対応していないファイルシステムです: %v", err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "sub"), 0755)
+	for _, name := range []string{"a.txt", "sub/b.txt", "sub/c.txt", "d.txt"} {
+		os.WriteFile(filepath.Join(dir, name), []byte(name), 0644)
+	}
+	stamp(t, filepath.Join(dir, "a.txt"))
+	stamp(t, filepath.Join(dir, "sub/b.txt"))
+	stamp(t, filepath.Join(dir, "sub/c.txt"))
+
+	// 記録した後に内容を書き換える（拡張属性は残る）
+	file, err := os.OpenFile(filepath.Join(dir, "sub/c.txt"), os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString("changed")
+	file.Close()
+
+	result, err := Verify(dir, Options{})
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if result.Files != 4 || result.Matched != 2 {
+		t.Errorf("Files = %d, Matched = %d", result.Files, result.Matched)
+	}
+	if len(result.Entries) != 2 ||
+		result.Entries[0].Path != "d.txt" || result.Entries[0].Kind != KindUnstamped ||
+		result.Entries[1].Path != "sub/c.txt" || result.Entries[1].Kind != KindMismatch {
+		t.Errorf("Entries = %+v", result.Entries)
+	}
+	if !result.Damaged() {
+		t.Error("Damaged() = false")
+	}
+
+	var buf bytes.Buffer
+	WriteText(&buf, result)
+	if !strings.Contains(buf.String(), "不一致: sub/c.txt") || !strings.Contains(buf.String(), "記録なし: d.txt") {
+		t.Errorf("WriteText() = %s", buf.String())
+	}
+	buf.Reset()
+	if err := WriteJSON(&buf, result); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Result
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || decoded.Counts[KindMismatch] != 1 {
+		t.Errorf("WriteJSON() = %s, %v", buf.String(), err)
+	}
+
+	// 記録のないファイルのみの場合は破損として扱わない
+	os.Remove(filepath.Join(dir, "sub/c.txt"))
+	if result, err := Verify(dir, Options{}); err != nil || result.Damaged() {
+		t.Errorf("Verify() = %+v, %v", result, err)
+	}
+}
+
+func TestVerify_NotDir(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	os.WriteFile(path, []byte("a"), 0644)
+	if _, err := Verify(path, Options{}); err == nil {
+		t.Error("ファイルを指定してもエラーが発生しませんでした")
+	}
+}
@@ -0,0 +1,146 @@
+package stats
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/errcode"
+)
+
+// maxRecentErrors は保持する直近のエラーの件数
+const maxRecentErrors = 20
+
+// ErrorEntry は処理中に発生したエラーの記録
+type ErrorEntry struct {
+	Time    time.Time
+	Path    string
+	Code    errcode.Code
+	Message string
+}
+
+// activity はワーカーの稼働状況を管理する
+type activity struct {
+	mu      sync.Mutex
+	workers []string // スロットごとの処理中ファイル（空文字列は待機中）
+	errors  []ErrorEntry
+	queued  queue
+}
+
+// queue は見つけたがまだコピーを開始していないファイルを、見つけた時刻とともに管理する
+// 番号は見つけた順に割り当てるため、残っている最小の番号が最も長く待っているファイルになる
+type queue struct {
+	mu      sync.Mutex
+	next    uint64               // 次に割り当てる番号
+	oldest  uint64               // 残っている最小の番号の候補（これより小さい番号はすべて処理済み）
+	pending map[uint64]time.Time // 処理待ちのファイルを見つけた時刻
+}
+
+// BeginWork はワーカーがファイルの処理を開始したことを記録し、割り当てたスロット番号を返す
+func (s *Stats) BeginWork(path string) int {
+	s.activity.mu.Lock()
+	defer s.activity.mu.Unlock()
+
+	for i, current := range s.activity.workers {
+		if current == "" {
+			s.activity.workers[i] = path
+			return i
+		}
+	}
+	s.activity.workers = append(s.activity.workers, path)
+	return len(s.activity.workers) - 1
+}
+
+// EndWork はスロットの処理が完了したことを記録する
+func (s *Stats) EndWork(slot int) {
+	s.activity.mu.Lock()
+	defer s.activity.mu.Unlock()
+
+	if slot >= 0 && slot < len(s.activity.workers) {
+		s.activity.workers[slot] = ""
+	}
+}
+
+// Enqueue は処理待ちのファイルを見つけたことを記録し、Dequeueに渡す番号を返す
+func (s *Stats) Enqueue() uint64 {
+	q := &s.activity.queued
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.pending == nil {
+		q.pending = make(map[uint64]time.Time)
+	}
+	ticket := q.next
+	q.next++
+	q.pending[ticket] = time.Now()
+	return ticket
+}
+
+// Dequeue は処理待ちのファイルの処理を開始したことを記録する
+func (s *Stats) Dequeue(ticket uint64) {
+	q := &s.activity.queued
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.pending, ticket)
+}
+
+// GetQueued は処理待ちのファイル数を取得する
+func (s *Stats) GetQueued() int64 {
+	q := &s.activity.queued
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return int64(len(q.pending))
+}
+
+// GetOldestQueuedAge は最も長く処理を待っているファイルの待ち時間を取得する（処理待ちがない場合は0）
+// 処理待ちが増え続け、待ち時間も伸び続ける場合はコピーが、処理待ちがほとんどない場合はソースの走査が律速している
+func (s *Stats) GetOldestQueuedAge(now time.Time) time.Duration {
+	q := &s.activity.queued
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.pending) == 0 {
+		q.oldest = q.next
+		return 0
+	}
+	// 処理済みの番号を読み飛ばす（番号は一度しか読み飛ばさないため、全体で処理待ちの件数分の処理で済む）
+	for {
+		if enqueued, ok := q.pending[q.oldest]; ok {
+			return max(now.Sub(enqueued), 0)
+		}
+		q.oldest++
+	}
+}
+
+// GetWorkers はスロットごとの処理中ファイルを取得する
+func (s *Stats) GetWorkers() []string {
+	s.activity.mu.Lock()
+	defer s.activity.mu.Unlock()
+	return append([]string(nil), s.activity.workers...)
+}
+
+// RecordError はエラーを記録する（直近のものだけを保持する）
+func (s *Stats) RecordError(path string, err error) {
+	if err == nil {
+		return
+	}
+
+	s.activity.mu.Lock()
+	defer s.activity.mu.Unlock()
+
+	s.activity.errors = append(s.activity.errors, ErrorEntry{
+		Time:    time.Now(),
+		Path:    path,
+		Code:    errcode.Of(err),
+		Message: err.Error(),
+	})
+	if len(s.activity.errors) > maxRecentErrors {
+		s.activity.errors = s.activity.errors[len(s.activity.errors)-maxRecentErrors:]
+	}
+}
+
+// GetRecentErrors は直近のエラーを古い順に取得する
+func (s *Stats) GetRecentErrors() []ErrorEntry {
+	s.activity.mu.Lock()
+	defer s.activity.mu.Unlock()
+	return append([]ErrorEntry(nil), s.activity.errors...)
+}
@@ -0,0 +1,100 @@
+package stats
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestWorkerActivity(t *testing.T) {
+	stats := NewStats()
+
+	a := stats.BeginWork("a.txt")
+	b := stats.BeginWork("b.txt")
+	if a != 0 || b != 1 {
+		t.Errorf("スロット番号が期待値と異なります: a=%d, b=%d", a, b)
+	}
+
+	// 空いたスロットが再利用される
+	stats.EndWork(a)
+	if c := stats.BeginWork("c.txt"); c != 0 {
+		t.Errorf("空きスロットが再利用されませんでした: %d", c)
+	}
+
+	workers := stats.GetWorkers()
+	if len(workers) != 2 || workers[0] != "c.txt" || workers[1] != "b.txt" {
+		t.Errorf("処理中ファイルが期待値と異なります: %v", workers)
+	}
+
+	ticket := stats.Enqueue()
+	stats.Enqueue()
+	stats.Enqueue()
+	stats.Dequeue(ticket)
+	if stats.GetQueued() != 2 {
+		t.Errorf("処理待ち数が期待値と異なります: 期待値=2, 実際=%d", stats.GetQueued())
+	}
+
+	stats.Reset()
+	if len(stats.GetWorkers()) != 0 || stats.GetQueued() != 0 {
+		t.Error("Reset後に稼働状況がリセットされていません")
+	}
+}
+
+func TestOldestQueuedAge(t *testing.T) {
+	stats := NewStats()
+	if age := stats.GetOldestQueuedAge(time.Now()); age != 0 {
+		t.Errorf("処理待ちがない場合の待ち時間 = %v, want 0", age)
+	}
+
+	first := stats.Enqueue()
+	second := stats.Enqueue()
+	third := stats.Enqueue()
+	now := time.Now().Add(time.Minute)
+	if age := stats.GetOldestQueuedAge(now); age < time.Minute {
+		t.Errorf("待ち時間 = %v, want 1分以上", age)
+	}
+
+	// 見つけた順に処理されなくても、残っているファイルのうち最も古いものの待ち時間を返す
+	stats.Dequeue(second)
+	stats.Dequeue(first)
+	if age := stats.GetOldestQueuedAge(now); age < time.Minute {
+		t.Errorf("待ち時間 = %v, want 1分以上", age)
+	}
+	stats.Dequeue(third)
+	if age := stats.GetOldestQueuedAge(now); age != 0 || stats.GetQueued() != 0 {
+		t.Errorf("すべて処理した後の待ち時間 = %v, 処理待ち = %d", age, stats.GetQueued())
+	}
+
+	// Reset後に見つけたファイルも正しく扱う
+	stats.Enqueue()
+	stats.Reset()
+	stats.Enqueue()
+	later := time.Now().Add(time.Hour)
+	if stats.GetQueued() != 1 || stats.GetOldestQueuedAge(later) < 59*time.Minute {
+		t.Errorf("Reset後の処理待ち = %d, 待ち時間 = %v", stats.GetQueued(), stats.GetOldestQueuedAge(later))
+	}
+}
+
+func TestRecordError(t *testing.T) {
+	stats := NewStats()
+
+	stats.RecordError("ignored.txt", nil)
+	if len(stats.GetRecentErrors()) != 0 {
+		t.Error("nilエラーが記録されました")
+	}
+
+	for i := 0; i < maxRecentErrors+5; i++ {
+		stats.RecordError(fmt.Sprintf("file%d.txt", i), fmt.Errorf("エラー%d", i))
+	}
+
+	errors := stats.GetRecentErrors()
+	if len(errors) != maxRecentErrors {
+		t.Fatalf("保持件数が期待値と異なります: 期待値=%d, 実際=%d", maxRecentErrors, len(errors))
+	}
+	if errors[len(errors)-1].Path != fmt.Sprintf("file%d.txt", maxRecentErrors+4) {
+		t.Errorf("最新のエラーが末尾にありません: %+v", errors[len(errors)-1])
+	}
+	if errors[0].Path != "file5.txt" {
+		t.Errorf("古いエラーが破棄されていません: %+v", errors[0])
+	}
+}
@@ -8,12 +8,20 @@ import (
 
 // Stats は同期処理の統計情報を管理する構造体
 type Stats struct {
-	FilesCopied  int64 // コピーしたファイル数
-	FilesSkipped int64 // スキップしたファイル数
-	FilesFailed  int64 // 失敗したファイル数
-	BytesCopied  int64 // コピーしたバイト数
-	BytesSkipped int64 // スキップしたバイト数
-	mu           sync.Mutex
+	FilesCopied    int64 // コピーしたファイル数
+	FilesSkipped   int64 // スキップしたファイル数
+	FilesFailed    int64 // 失敗したファイル数
+	FilesIgnored   int64 // エラーを無視したファイル数（失敗には含めない）
+	Conflicts      int64 // 宛先の方が新しかったファイル数（スキップまたは失敗にも含める）
+	SharingRetries int64 // 共有違反（ウイルス対策ソフトなどが使用中）による再試行の回数
+	FilesLocked    int64 // 他のプロセスが使用中のためコピーできなかったファイル数（失敗にも含める）
+	MetaUpdated    int64 // 内容が同じため更新日時とアクセス権のみ更新したファイル数（スキップにも含める）
+	PermFailed     int64 // 内容はコピーしたがアクセス権をコピーできなかったファイル数（コピーに含め、失敗には含めない）
+	BytesCopied    int64 // コピーしたバイト数
+	BytesSkipped   int64 // スキップしたバイト数
+	mu             sync.Mutex
+	activity       activity // ワーカーの稼働状況
+	timings        timings  // 処理時間の長いファイルとディレクトリ
 }
 
 // NewStats は新しい統計情報オブジェクトを作成する
@@ -38,6 +46,36 @@ func (s *Stats) IncrementFailed() {
 	atomic.AddInt64(&s.FilesFailed, 1)
 }
 
+// IncrementIgnored はエラーを無視したファイル数を増加させる
+func (s *Stats) IncrementIgnored() {
+	atomic.AddInt64(&s.FilesIgnored, 1)
+}
+
+// IncrementConflicted は宛先の方が新しかったファイル数を増加させる
+func (s *Stats) IncrementConflicted() {
+	atomic.AddInt64(&s.Conflicts, 1)
+}
+
+// IncrementSharingRetries は共有違反による再試行の回数を増加させる
+func (s *Stats) IncrementSharingRetries() {
+	atomic.AddInt64(&s.SharingRetries, 1)
+}
+
+// IncrementLocked は他のプロセスが使用中のためコピーできなかったファイル数を増加させる
+func (s *Stats) IncrementLocked() {
+	atomic.AddInt64(&s.FilesLocked, 1)
+}
+
+// IncrementMetaUpdated は更新日時とアクセス権のみ更新したファイル数を増加させる
+func (s *Stats) IncrementMetaUpdated() {
+	atomic.AddInt64(&s.MetaUpdated, 1)
+}
+
+// IncrementPermFailed は内容はコピーしたがアクセス権をコピーできなかったファイル数を増加させる
+func (s *Stats) IncrementPermFailed() {
+	atomic.AddInt64(&s.PermFailed, 1)
+}
+
 // GetCopiedCount はコピーしたファイル数を取得する
 func (s *Stats) GetCopiedCount() int64 {
 	return atomic.LoadInt64(&s.FilesCopied)
@@ -53,6 +91,36 @@ func (s *Stats) GetFailedCount() int64 {
 	return atomic.LoadInt64(&s.FilesFailed)
 }
 
+// GetIgnoredCount はエラーを無視したファイル数を取得する
+func (s *Stats) GetIgnoredCount() int64 {
+	return atomic.LoadInt64(&s.FilesIgnored)
+}
+
+// GetConflictedCount は宛先の方が新しかったファイル数を取得する
+func (s *Stats) GetConflictedCount() int64 {
+	return atomic.LoadInt64(&s.Conflicts)
+}
+
+// GetSharingRetries は共有違反による再試行の回数を取得する
+func (s *Stats) GetSharingRetries() int64 {
+	return atomic.LoadInt64(&s.SharingRetries)
+}
+
+// GetLockedCount は他のプロセスが使用中のためコピーできなかったファイル数を取得する
+func (s *Stats) GetLockedCount() int64 {
+	return atomic.LoadInt64(&s.FilesLocked)
+}
+
+// GetMetaUpdatedCount は更新日時とアクセス権のみ更新したファイル数を取得する
+func (s *Stats) GetMetaUpdatedCount() int64 {
+	return atomic.LoadInt64(&s.MetaUpdated)
+}
+
+// GetPermFailedCount は内容はコピーしたがアクセス権をコピーできなかったファイル数を取得する
+func (s *Stats) GetPermFailedCount() int64 {
+	return atomic.LoadInt64(&s.PermFailed)
+}
+
 // GetCopiedBytes はコピーしたバイト数を取得する
 func (s *Stats) GetCopiedBytes() int64 {
 	return atomic.LoadInt64(&s.BytesCopied)
@@ -65,7 +133,7 @@ func (s *Stats) GetSkippedBytes() int64 {
 
 // GetTotalFiles は処理したファイルの合計数を取得する
 func (s *Stats) GetTotalFiles() int64 {
-	return s.GetCopiedCount() + s.GetSkippedCount() + s.GetFailedCount()
+	return s.GetCopiedCount() + s.GetSkippedCount() + s.GetFailedCount() + s.GetIgnoredCount()
 }
 
 // GetTotalBytes は処理したバイトの合計数を取得する
@@ -77,8 +145,8 @@ func (s *Stats) GetTotalBytes() int64 {
 func (s *Stats) String() string {
 	return fmt.Sprintf(
 		"コピー: %d ファイル (%s), スキップ: %d ファイル (%s), 失敗: %d ファイル",
-		s.GetCopiedCount(), formatBytes(s.GetCopiedBytes()),
-		s.GetSkippedCount(), formatBytes(s.GetSkippedBytes()),
+		s.GetCopiedCount(), FormatBytes(s.GetCopiedBytes()),
+		s.GetSkippedCount(), FormatBytes(s.GetSkippedBytes()),
 		s.GetFailedCount(),
 	)
 }
@@ -104,12 +172,33 @@ func (s *Stats) Reset() {
 	atomic.StoreInt64(&s.FilesCopied, 0)
 	atomic.StoreInt64(&s.FilesSkipped, 0)
 	atomic.StoreInt64(&s.FilesFailed, 0)
+	atomic.StoreInt64(&s.FilesIgnored, 0)
+	atomic.StoreInt64(&s.Conflicts, 0)
+	atomic.StoreInt64(&s.SharingRetries, 0)
+	atomic.StoreInt64(&s.FilesLocked, 0)
+	atomic.StoreInt64(&s.MetaUpdated, 0)
+	atomic.StoreInt64(&s.PermFailed, 0)
 	atomic.StoreInt64(&s.BytesCopied, 0)
 	atomic.StoreInt64(&s.BytesSkipped, 0)
+
+	s.activity.mu.Lock()
+	s.activity.workers = nil
+	s.activity.errors = nil
+	s.activity.mu.Unlock()
+
+	s.activity.queued.mu.Lock()
+	s.activity.queued.pending = nil
+	s.activity.queued.oldest = s.activity.queued.next
+	s.activity.queued.mu.Unlock()
+
+	s.timings.mu.Lock()
+	s.timings.slowest = nil
+	s.timings.dirs = nil
+	s.timings.mu.Unlock()
 }
 
-// formatBytes はバイト数を読みやすい形式にフォーマットする
-func formatBytes(bytes int64) string {
+// FormatBytes はバイト数を読みやすい形式にフォーマットする
+func FormatBytes(bytes int64) string {
 	const unit = 1024
 	if bytes < unit {
 		return fmt.Sprintf("%d B", bytes)
@@ -0,0 +1,118 @@
+package stats
+
+import (
+	"path"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FileTiming はファイルごとの処理時間
+type FileTiming struct {
+	Path     string
+	Bytes    int64
+	Duration time.Duration
+}
+
+// Throughput は秒あたりのバイト数を返す
+func (t FileTiming) Throughput() float64 {
+	if t.Duration <= 0 {
+		return 0
+	}
+	return float64(t.Bytes) / t.Duration.Seconds()
+}
+
+// DirTiming はディレクトリごと（直下のファイルのみ）の処理時間の合計
+type DirTiming struct {
+	Dir      string
+	Files    int64
+	Bytes    int64
+	Duration time.Duration
+}
+
+// timings は処理時間の長いファイルとディレクトリを記録する
+type timings struct {
+	mu      sync.Mutex
+	limit   int                   // 記録する件数（0は記録しない）
+	slowest []FileTiming          // 処理時間の長い順
+	dirs    map[string]*DirTiming // ディレクトリごとの合計
+}
+
+// EnableTimings はファイルごとの処理時間の記録を有効にし、処理時間の長いファイル・ディレクトリをlimit件まで保持する
+func (s *Stats) EnableTimings(limit int) {
+	s.timings.mu.Lock()
+	defer s.timings.mu.Unlock()
+	s.timings.limit = limit
+	s.timings.slowest = nil
+	s.timings.dirs = nil
+}
+
+// TimingsEnabled は処理時間を記録するかどうかを返す
+func (s *Stats) TimingsEnabled() bool {
+	s.timings.mu.Lock()
+	defer s.timings.mu.Unlock()
+	return s.timings.limit > 0
+}
+
+// RecordTiming はファイルの処理時間を記録する（パスはスラッシュ区切りの相対パス）
+func (s *Stats) RecordTiming(relPath string, bytes int64, d time.Duration) {
+	t := &s.timings
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.limit <= 0 {
+		return
+	}
+
+	if t.dirs == nil {
+		t.dirs = make(map[string]*DirTiming)
+	}
+	dir := path.Dir(relPath)
+	dt := t.dirs[dir]
+	if dt == nil {
+		dt = &DirTiming{Dir: dir}
+		t.dirs[dir] = dt
+	}
+	dt.Files++
+	dt.Bytes += bytes
+	dt.Duration += d
+
+	// 処理時間の長い順に保持し、上限を超えた分は捨てる
+	if len(t.slowest) == t.limit && d <= t.slowest[len(t.slowest)-1].Duration {
+		return
+	}
+	i := sort.Search(len(t.slowest), func(i int) bool { return t.slowest[i].Duration < d })
+	t.slowest = append(t.slowest, FileTiming{})
+	copy(t.slowest[i+1:], t.slowest[i:])
+	t.slowest[i] = FileTiming{Path: relPath, Bytes: bytes, Duration: d}
+	if len(t.slowest) > t.limit {
+		t.slowest = t.slowest[:t.limit]
+	}
+}
+
+// GetSlowestFiles は処理時間の長いファイルを長い順に取得する
+func (s *Stats) GetSlowestFiles() []FileTiming {
+	s.timings.mu.Lock()
+	defer s.timings.mu.Unlock()
+	return append([]FileTiming(nil), s.timings.slowest...)
+}
+
+// GetSlowestDirs は直下のファイルの処理時間の合計が長いディレクトリを長い順に取得する
+func (s *Stats) GetSlowestDirs() []DirTiming {
+	s.timings.mu.Lock()
+	defer s.timings.mu.Unlock()
+
+	dirs := make([]DirTiming, 0, len(s.timings.dirs))
+	for _, dt := range s.timings.dirs {
+		dirs = append(dirs, *dt)
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		if dirs[i].Duration != dirs[j].Duration {
+			return dirs[i].Duration > dirs[j].Duration
+		}
+		return dirs[i].Dir < dirs[j].Dir
+	})
+	if len(dirs) > s.timings.limit {
+		dirs = dirs[:s.timings.limit]
+	}
+	return dirs
+}
@@ -0,0 +1,40 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimings(t *testing.T) {
+	stats := NewStats()
+
+	// 無効な場合は記録しない
+	stats.RecordTiming("a.txt", 10, time.Second)
+	if stats.TimingsEnabled() || len(stats.GetSlowestFiles()) != 0 || len(stats.GetSlowestDirs()) != 0 {
+		t.Fatal("無効な場合に処理時間が記録されました")
+	}
+
+	stats.EnableTimings(2)
+	stats.RecordTiming("a.txt", 100, 1*time.Second)
+	stats.RecordTiming("dir/b.bin", 4000, 4*time.Second)
+	stats.RecordTiming("dir/c.bin", 200, 2*time.Second)
+	stats.RecordTiming("dir/sub/d.bin", 300, 3*time.Second)
+
+	files := stats.GetSlowestFiles()
+	if len(files) != 2 || files[0].Path != "dir/b.bin" || files[1].Path != "dir/sub/d.bin" {
+		t.Errorf("処理時間の長いファイル = %+v", files)
+	}
+	if got := files[0].Throughput(); got != 1000 {
+		t.Errorf("Throughput() = %f, want 1000", got)
+	}
+
+	dirs := stats.GetSlowestDirs()
+	if len(dirs) != 2 || dirs[0].Dir != "dir" || dirs[0].Files != 2 || dirs[0].Duration != 6*time.Second || dirs[1].Dir != "dir/sub" {
+		t.Errorf("処理時間の長いディレクトリ = %+v", dirs)
+	}
+
+	stats.Reset()
+	if len(stats.GetSlowestFiles()) != 0 || !stats.TimingsEnabled() {
+		t.Error("Reset後も処理時間が残っています")
+	}
+}
@@ -0,0 +1,388 @@
+package status
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/copier"
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/stats"
+)
+
+// 実行状態
+const (
+	StateRunning   = "running"
+	StateCompleted = "completed"
+	StateFailed    = "failed"
+	StateCancelled = "cancelled"
+)
+
+// Job は監視対象の処理が満たすインターフェース
+type Job interface {
+	GetStats() *stats.Stats
+	GetSessionID() int64
+}
+
+// Controller は実行中の処理を操作するためのインターフェース
+type Controller interface {
+	Pause()
+	Resume()
+	Cancel()
+	IsPaused() bool
+	SetBandwidthLimit(limit int64)
+	GetBandwidthLimit() int64
+}
+
+// JobInfo は監視対象の処理の概要
+type JobInfo struct {
+	Source      string            `json:"source"`
+	Destination string            `json:"destination"`
+	Mode        string            `json:"mode"`
+	Workers     int               `json:"workers"`
+	Label       string            `json:"label,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+}
+
+// StatusResponse は/statusのレスポンス
+type StatusResponse struct {
+	State           string    `json:"state"`
+	StartedAt       time.Time `json:"started_at"`
+	ElapsedSeconds  float64   `json:"elapsed_seconds"`
+	FilesCopied     int64     `json:"files_copied"`
+	FilesSkipped    int64     `json:"files_skipped"`
+	FilesFailed     int64     `json:"files_failed"`
+	FilesIgnored    int64     `json:"files_ignored"`
+	FilesConflicted int64     `json:"files_conflicted"`
+	SharingRetries  int64     `json:"sharing_retries"`    // 共有違反（ウイルス対策ソフトなどが使用中）による再試行の回数
+	FilesLocked     int64     `json:"files_locked"`       // 他のプロセスが使用中のためコピーできなかったファイル数
+	MetaUpdated     int64     `json:"files_meta_updated"` // 内容が同じため更新日時とアクセス権のみ更新したファイル数
+	FilesPermFailed int64     `json:"files_perm_failed"`  // 内容はコピーしたがアクセス権をコピーできなかったファイル数
+	BytesCopied     int64     `json:"bytes_copied"`
+	BytesSkipped    int64     `json:"bytes_skipped"`
+	Queued          int64     `json:"queued"`
+	OldestQueuedSec float64   `json:"oldest_queued_seconds"` // 最も長く処理を待っているファイルの待ち時間（秒）
+	ActiveFiles     []string  `json:"active_files"`
+	BytesPerSecond  float64   `json:"bytes_per_second"`
+	Paused          bool      `json:"paused"`
+	BandwidthLimit  int64     `json:"bandwidth_limit"`
+	ErrorCode       string    `json:"error_code,omitempty"` // 処理が失敗した場合のエラーの種類
+}
+
+// ControlResponse は操作用エンドポイントのレスポンス
+type ControlResponse struct {
+	State          string `json:"state"`
+	Paused         bool   `json:"paused"`
+	BandwidthLimit int64  `json:"bandwidth_limit"`
+}
+
+// ErrorResponse は/errorsの各要素
+type ErrorResponse struct {
+	Time    time.Time `json:"time"`
+	Path    string    `json:"path"`
+	Code    string    `json:"code"`
+	Message string    `json:"message"`
+}
+
+// SessionResponse は/sessionのレスポンス
+type SessionResponse struct {
+	SessionID int64     `json:"session_id"`
+	StartedAt time.Time `json:"started_at"`
+	JobInfo
+}
+
+// Server は実行中の処理の状況を公開するHTTPサーバー
+// 操作用エンドポイントはEnableControlで有効にした場合のみ使用できる
+type Server struct {
+	job       Job
+	info      JobInfo
+	ctrl      Controller
+	token     string
+	startedAt time.Time
+	mux       *http.ServeMux
+	srv       *http.Server
+
+	mu      sync.Mutex
+	state   string
+	errCode errcode.Code // 処理が失敗した場合のエラーの種類
+}
+
+// NewServer は新しいServerを作成する
+func NewServer(job Job, info JobInfo) *Server {
+	s := &Server{
+		job:       job,
+		info:      info,
+		startedAt: time.Now(),
+		mux:       http.NewServeMux(),
+		state:     StateRunning,
+	}
+
+	s.mux.HandleFunc("/status", s.handleStatus)
+	s.mux.HandleFunc("/errors", s.handleErrors)
+	s.mux.HandleFunc("/session", s.handleSession)
+	s.mux.HandleFunc("/pause", s.handleControl(s.pause))
+	s.mux.HandleFunc("/resume", s.handleControl(s.resume))
+	s.mux.HandleFunc("/cancel", s.handleControl(s.cancelJob))
+	s.mux.HandleFunc("/set-bwlimit", s.handleControl(s.setBandwidthLimit))
+
+	return s
+}
+
+// EnableControl は操作用エンドポイントを有効にする
+// 操作にはAuthorizationヘッダーでBearerトークンを指定する必要がある
+func (s *Server) EnableControl(ctrl Controller, token string) {
+	s.ctrl = ctrl
+	s.token = token
+}
+
+// Handler はHTTPハンドラーを返す
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
+
+// Start は指定されたアドレスで待ち受けを開始する
+// 待ち受けに失敗した場合はエラーを返し、以降の処理はバックグラウンドで行う
+func (s *Server) Start(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("ステータスAPIの待ち受けエラー(%s): %w", addr, err)
+	}
+
+	s.srv = &http.Server{
+		Handler:           s.mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go s.srv.Serve(listener)
+
+	return nil
+}
+
+// Close はサーバーを停止する
+func (s *Server) Close() error {
+	if s.srv == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.srv.Shutdown(ctx)
+}
+
+// SetState は実行状態を設定する
+func (s *Server) SetState(state string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = state
+}
+
+// Finish は処理の終了を記録する（キャンセル済みの場合は状態を変更しない）
+func (s *Server) Finish(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.state != StateRunning {
+		return
+	}
+	s.errCode = errcode.Of(err)
+	switch {
+	case errors.Is(err, errcode.ErrCancelled):
+		s.state = StateCancelled
+	case err != nil:
+		s.state = StateFailed
+	default:
+		s.state = StateCompleted
+	}
+}
+
+// getState は実行状態を取得する
+func (s *Server) getState() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
+// getErrorCode は処理が失敗した場合のエラーの種類を取得する
+func (s *Server) getErrorCode() errcode.Code {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.errCode
+}
+
+// handleStatus は進捗状況を返す
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if !allowRead(w, r) {
+		return
+	}
+
+	st := s.job.GetStats()
+	elapsed := time.Since(s.startedAt).Seconds()
+
+	var active []string
+	for _, path := range st.GetWorkers() {
+		if path != "" {
+			active = append(active, path)
+		}
+	}
+
+	resp := StatusResponse{
+		State:           s.getState(),
+		ErrorCode:       string(s.getErrorCode()),
+		StartedAt:       s.startedAt,
+		ElapsedSeconds:  elapsed,
+		FilesCopied:     st.GetCopiedCount(),
+		FilesSkipped:    st.GetSkippedCount(),
+		FilesFailed:     st.GetFailedCount(),
+		FilesIgnored:    st.GetIgnoredCount(),
+		FilesConflicted: st.GetConflictedCount(),
+		SharingRetries:  st.GetSharingRetries(),
+		FilesLocked:     st.GetLockedCount(),
+		MetaUpdated:     st.GetMetaUpdatedCount(),
+		FilesPermFailed: st.GetPermFailedCount(),
+		BytesCopied:     st.GetCopiedBytes(),
+		BytesSkipped:    st.GetSkippedBytes(),
+		Queued:          st.GetQueued(),
+		OldestQueuedSec: st.GetOldestQueuedAge(time.Now()).Seconds(),
+		ActiveFiles:     active,
+	}
+	if s.ctrl != nil {
+		resp.Paused = s.ctrl.IsPaused()
+		resp.BandwidthLimit = s.ctrl.GetBandwidthLimit()
+	}
+	if elapsed > 0 {
+		resp.BytesPerSecond = float64(resp.BytesCopied) / elapsed
+	}
+
+	writeJSON(w, resp)
+}
+
+// handleErrors は直近のエラーを返す
+func (s *Server) handleErrors(w http.ResponseWriter, r *http.Request) {
+	if !allowRead(w, r) {
+		return
+	}
+
+	resp := []ErrorResponse{}
+	for _, e := range s.job.GetStats().GetRecentErrors() {
+		resp = append(resp, ErrorResponse{Time: e.Time, Path: e.Path, Code: string(e.Code), Message: e.Message})
+	}
+
+	writeJSON(w, resp)
+}
+
+// handleSession は実行中のセッションの情報を返す
+func (s *Server) handleSession(w http.ResponseWriter, r *http.Request) {
+	if !allowRead(w, r) {
+		return
+	}
+
+	writeJSON(w, SessionResponse{
+		SessionID: s.job.GetSessionID(),
+		StartedAt: s.startedAt,
+		JobInfo:   s.info,
+	})
+}
+
+// handleControl は操作用エンドポイントの認証を行い、操作を実行する
+func (s *Server) handleControl(action func(r *http.Request) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.ctrl == nil || s.token == "" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !s.authorized(r) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if s.getState() != StateRunning {
+			http.Error(w, "job is not running", http.StatusConflict)
+			return
+		}
+
+		if err := action(r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		writeJSON(w, ControlResponse{
+			State:          s.getState(),
+			Paused:         s.ctrl.IsPaused(),
+			BandwidthLimit: s.ctrl.GetBandwidthLimit(),
+		})
+	}
+}
+
+// authorized はBearerトークンを検証する
+func (s *Server) authorized(r *http.Request) bool {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return false
+	}
+	token := strings.TrimPrefix(header, "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+// pause は処理を一時停止する
+func (s *Server) pause(r *http.Request) error {
+	s.ctrl.Pause()
+	return nil
+}
+
+// resume は処理を再開する
+func (s *Server) resume(r *http.Request) error {
+	s.ctrl.Resume()
+	return nil
+}
+
+// cancelJob は処理をキャンセルする
+func (s *Server) cancelJob(r *http.Request) error {
+	s.ctrl.Cancel()
+	s.SetState(StateCancelled)
+	return nil
+}
+
+// setBandwidthLimit は帯域制限を変更する（limitパラメータで指定、0は無制限）
+func (s *Server) setBandwidthLimit(r *http.Request) error {
+	value := r.FormValue("limit")
+	if value == "" {
+		return fmt.Errorf("limitパラメータを指定してください")
+	}
+	limit, err := copier.ParseBandwidth(value)
+	if err != nil {
+		return err
+	}
+	s.ctrl.SetBandwidthLimit(limit)
+	return nil
+}
+
+// allowRead は読み取り用のメソッドのみを許可する
+func allowRead(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	return true
+}
+
+// writeJSON はJSONレスポンスを書き込む
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,245 @@
+package status
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/stats"
+)
+
+// fakeJob はテスト用のJob
+type fakeJob struct {
+	stats     *stats.Stats
+	sessionID int64
+}
+
+func (j *fakeJob) GetStats() *stats.Stats { return j.stats }
+func (j *fakeJob) GetSessionID() int64    { return j.sessionID }
+
+// fakeController はテスト用のController
+type fakeController struct {
+	paused    bool
+	cancelled bool
+	limit     int64
+}
+
+func (c *fakeController) Pause()                        { c.paused = true }
+func (c *fakeController) Resume()                       { c.paused = false }
+func (c *fakeController) Cancel()                       { c.cancelled = true }
+func (c *fakeController) IsPaused() bool                { return c.paused }
+func (c *fakeController) SetBandwidthLimit(limit int64) { c.limit = limit }
+func (c *fakeController) GetBandwidthLimit() int64      { return c.limit }
+
+func newTestServer() (*Server, *fakeJob) {
+	job := &fakeJob{stats: stats.NewStats(), sessionID: 42}
+	server := NewServer(job, JobInfo{Source: "/src", Destination: "/dst", Mode: "copy", Workers: 4})
+	return server, job
+}
+
+func get(t *testing.T, server *Server, path string, v interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	if v != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("レスポンスのデコードに失敗: %v: %s", err, rec.Body.String())
+		}
+	}
+	return rec
+}
+
+func TestHandleStatus(t *testing.T) {
+	server, job := newTestServer()
+	job.stats.IncrementCopied(1000)
+	job.stats.IncrementSkipped(10)
+	job.stats.BeginWork("dir/file.txt")
+	job.stats.Enqueue()
+	job.stats.Enqueue()
+
+	var resp StatusResponse
+	rec := get(t, server, "/status", &resp)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ステータスコード: 期待値=200, 実際=%d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type: %s", ct)
+	}
+	if resp.State != StateRunning || resp.FilesCopied != 1 || resp.BytesCopied != 1000 || resp.FilesSkipped != 1 {
+		t.Errorf("レスポンスが期待値と異なります: %+v", resp)
+	}
+	if resp.Queued != 2 || resp.OldestQueuedSec < 0 || len(resp.ActiveFiles) != 1 || resp.ActiveFiles[0] != "dir/file.txt" {
+		t.Errorf("稼働状況が期待値と異なります: %+v", resp)
+	}
+
+	server.SetState(StateCompleted)
+	get(t, server, "/status", &resp)
+	if resp.State != StateCompleted {
+		t.Errorf("状態: 期待値=%s, 実際=%s", StateCompleted, resp.State)
+	}
+}
+
+func TestHandleErrors(t *testing.T) {
+	server, job := newTestServer()
+
+	var resp []ErrorResponse
+	get(t, server, "/errors", &resp)
+	if resp == nil || len(resp) != 0 {
+		t.Errorf("エラーがない場合は空の配列を返すべきです: %v", resp)
+	}
+
+	job.stats.RecordError("bad.txt", errors.New("disk full"))
+	get(t, server, "/errors", &resp)
+	if len(resp) != 1 || resp[0].Path != "bad.txt" || resp[0].Message != "disk full" {
+		t.Errorf("エラー一覧が期待値と異なります: %+v", resp)
+	}
+}
+
+func TestHandleSession(t *testing.T) {
+	server, _ := newTestServer()
+
+	var resp SessionResponse
+	get(t, server, "/session", &resp)
+	if resp.SessionID != 42 || resp.Source != "/src" || resp.Destination != "/dst" || resp.Workers != 4 {
+		t.Errorf("セッション情報が期待値と異なります: %+v", resp)
+	}
+}
+
+func TestReadOnlyMethods(t *testing.T) {
+	server, _ := newTestServer()
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/status", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POSTは拒否されるべきです: %d", rec.Code)
+	}
+}
+
+func TestStartAndClose(t *testing.T) {
+	server, _ := newTestServer()
+
+	if err := server.Start("127.0.0.1:0"); err != nil {
+		t.Fatalf("待ち受けの開始に失敗: %v", err)
+	}
+	if err := server.Close(); err != nil {
+		t.Errorf("停止に失敗: %v", err)
+	}
+
+	if err := NewServer(&fakeJob{stats: stats.NewStats()}, JobInfo{}).Start("invalid-address"); err == nil {
+		t.Error("不正なアドレスでエラーが発生しませんでした")
+	}
+}
+
+func post(server *Server, path, token string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	server.Handler().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestControlDisabled(t *testing.T) {
+	server, _ := newTestServer()
+
+	if rec := post(server, "/pause", "secret"); rec.Code != http.StatusNotFound {
+		t.Errorf("操作が無効な場合は404を返すべきです: %d", rec.Code)
+	}
+}
+
+func TestControlEndpoints(t *testing.T) {
+	server, _ := newTestServer()
+	ctrl := &fakeController{}
+	server.EnableControl(ctrl, "secret")
+
+	// 認証エラー
+	if rec := post(server, "/pause", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("トークンなし: 期待値=401, 実際=%d", rec.Code)
+	}
+	if rec := post(server, "/pause", "wrong"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("不正なトークン: 期待値=401, 実際=%d", rec.Code)
+	}
+	if ctrl.paused {
+		t.Fatal("認証に失敗したのに一時停止されました")
+	}
+
+	// GETは拒否される
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/pause", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: 期待値=405, 実際=%d", rec.Code)
+	}
+
+	// 一時停止と再開
+	rec = post(server, "/pause", "secret")
+	var resp ControlResponse
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if rec.Code != http.StatusOK || !ctrl.paused || !resp.Paused {
+		t.Errorf("一時停止に失敗: %d %s", rec.Code, rec.Body.String())
+	}
+
+	var status StatusResponse
+	get(t, server, "/status", &status)
+	if !status.Paused {
+		t.Error("/statusに一時停止状態が反映されていません")
+	}
+
+	if rec := post(server, "/resume", "secret"); rec.Code != http.StatusOK || ctrl.paused {
+		t.Errorf("再開に失敗: %d", rec.Code)
+	}
+
+	// 帯域制限
+	if rec := post(server, "/set-bwlimit?limit=10M", "secret"); rec.Code != http.StatusOK || ctrl.limit != 10*1024*1024 {
+		t.Errorf("帯域制限の設定に失敗: %d, limit=%d", rec.Code, ctrl.limit)
+	}
+	if rec := post(server, "/set-bwlimit?limit=abc", "secret"); rec.Code != http.StatusBadRequest {
+		t.Errorf("不正な帯域制限: 期待値=400, 実際=%d", rec.Code)
+	}
+	if rec := post(server, "/set-bwlimit", "secret"); rec.Code != http.StatusBadRequest {
+		t.Errorf("limitなし: 期待値=400, 実際=%d", rec.Code)
+	}
+
+	// キャンセル後は終了処理で状態が上書きされず、以降の操作は拒否される
+	if rec := post(server, "/cancel", "secret"); rec.Code != http.StatusOK || !ctrl.cancelled {
+		t.Errorf("キャンセルに失敗: %d", rec.Code)
+	}
+	server.Finish(errors.New("cancelled"))
+	get(t, server, "/status", &status)
+	if status.State != StateCancelled {
+		t.Errorf("状態: 期待値=%s, 実際=%s", StateCancelled, status.State)
+	}
+	rec = post(server, "/pause", "secret")
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "not running") {
+		t.Errorf("終了後の操作: 期待値=409, 実際=%d", rec.Code)
+	}
+}
+
+func TestFinish(t *testing.T) {
+	server, _ := newTestServer()
+	server.Finish(nil)
+	if server.getState() != StateCompleted {
+		t.Errorf("状態: 期待値=%s, 実際=%s", StateCompleted, server.getState())
+	}
+
+	server, _ = newTestServer()
+	server.Finish(errors.New("failed"))
+	if server.getState() != StateFailed {
+		t.Errorf("状態: 期待値=%s, 実際=%s", StateFailed, server.getState())
+	}
+	if server.getErrorCode() != errcode.CodeUnknown {
+		t.Errorf("エラーの種類: 期待値=%s, 実際=%s", errcode.CodeUnknown, server.getErrorCode())
+	}
+
+	// キャンセルによる終了はキャンセルとして扱う
+	server, _ = newTestServer()
+	server.Finish(errcode.Errorf(errcode.ErrCancelled, "コピー処理がキャンセルされました"))
+	if server.getState() != StateCancelled || server.getErrorCode() != errcode.CodeCancelled {
+		t.Errorf("状態: 期待値=%s/%s, 実際=%s/%s", StateCancelled, errcode.CodeCancelled, server.getState(), server.getErrorCode())
+	}
+}
@@ -0,0 +1,316 @@
+// Package structcheck はハッシュ値を計算せずに、ディレクトリごとのエントリ数と名前のみをソースと宛先で比較する
+// ファイルの内容を読み込まないため、巨大なツリーでも時間のかかるハッシュの検証の前に短時間で構造の欠落や余分なエントリを確認できる
+package structcheck
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sakuhanight/gopier/internal/filter"
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+// Dir はディレクトリごとのエントリ数と比較結果を表す構造体
+type Dir struct {
+	Path         string   `json:"path"` // ソースからの相対パス（ルートは"."）
+	SourceFiles  int      `json:"source_files"`
+	SourceDirs   int      `json:"source_dirs"`
+	DestFiles    int      `json:"dest_files"`
+	DestDirs     int      `json:"dest_dirs"`
+	MissingDest  bool     `json:"missing_dest,omitempty"`   // 比較中に宛先のディレクトリがなくなった
+	OnlyInSource []string `json:"only_in_source,omitempty"` // ソースにのみ存在するエントリの名前
+	OnlyInDest   []string `json:"only_in_dest,omitempty"`   // 宛先にのみ存在するエントリの名前
+	Error        string   `json:"error,omitempty"`
+}
+
+// Match はエントリ数と名前がソースと宛先で一致するかどうかを返す
+func (d *Dir) Match() bool {
+	return !d.MissingDest && d.Error == "" && len(d.OnlyInSource) == 0 && len(d.OnlyInDest) == 0 &&
+		d.SourceFiles == d.DestFiles && d.SourceDirs == d.DestDirs
+}
+
+// Result は比較結果全体を表す構造体
+type Result struct {
+	Source      string `json:"source"`
+	Dest        string `json:"dest"`
+	Dirs        int    `json:"dirs"` // 比較したディレクトリ数
+	SourceFiles int64  `json:"source_files"`
+	SourceDirs  int64  `json:"source_dirs"`
+	DestFiles   int64  `json:"dest_files"`
+	DestDirs    int64  `json:"dest_dirs"`
+	Mismatched  int    `json:"mismatched"` // 一致しないディレクトリ数
+	Entries     []Dir  `json:"entries"`    // 一致しないディレクトリ（AllDirsの場合はすべてのディレクトリ）
+}
+
+// Options は比較のオプションを表す構造体
+type Options struct {
+	Filter  *filter.Filter // 比較するエントリのフィルタ
+	FS      vfs.FS         // ソース・宛先のファイルシステム（nilの場合はOSのファイルシステム）
+	Workers int            // 並行して読み込むディレクトリ数（0以下はデフォルト）
+	AllDirs bool           // 一致したディレクトリのエントリ数も結果に含める
+}
+
+// defaultWorkers は並行して読み込むディレクトリ数のデフォルト
+// ネットワーク上のファイルシステムでは一覧の取得の待ち時間が大半を占めるため、CPU数より多く並行させる
+const defaultWorkers = 16
+
+// comparer は比較中の状態
+type comparer struct {
+	source, dest string
+	opts         Options
+	fs           vfs.FS
+	result       *Result
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   []string // 比較を待つディレクトリ（ソースからの相対パス）
+	pending int      // 比較を待つディレクトリと比較中のディレクトリの数
+}
+
+// Compare はソースと宛先のディレクトリツリーを、ディレクトリごとのエントリ数と名前で比較する
+// 一方にのみ存在するディレクトリは親のディレクトリの差分として1件で報告し、配下は走査しない
+func Compare(source, dest string, opts Options) (*Result, error) {
+	fsys := vfs.Or(opts.FS)
+	for _, dir := range []string{source, dest} {
+		info, err := fsys.Stat(dir)
+		if err != nil {
+			return nil, fmt.Errorf("ディレクトリにアクセスできません: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("ディレクトリを指定してください: %s", dir)
+		}
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	c := &comparer{
+		source:  source,
+		dest:    dest,
+		opts:    opts,
+		fs:      fsys,
+		result:  &Result{Source: source, Dest: dest, Entries: []Dir{}},
+		queue:   []string{"."},
+		pending: 1,
+	}
+	c.cond = sync.NewCond(&c.mu)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.work()
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(c.result.Entries, func(i, j int) bool { return c.result.Entries[i].Path < c.result.Entries[j].Path })
+	return c.result, nil
+}
+
+// work はすべてのディレクトリを比較し終えるまで、待っているディレクトリを取り出して比較する
+// 両方に存在するサブディレクトリは比較を待つディレクトリに加える
+func (c *comparer) work() {
+	for {
+		c.mu.Lock()
+		for len(c.queue) == 0 && c.pending > 0 {
+			c.cond.Wait()
+		}
+		if len(c.queue) == 0 {
+			c.mu.Unlock()
+			return
+		}
+		rel := c.queue[len(c.queue)-1]
+		c.queue = c.queue[:len(c.queue)-1]
+		c.mu.Unlock()
+
+		dir, subdirs := c.compareEntries(rel)
+
+		c.mu.Lock()
+		c.add(dir)
+		for _, name := range subdirs {
+			c.queue = append(c.queue, path.Join(rel, name))
+		}
+		c.pending += len(subdirs) - 1
+		c.cond.Broadcast()
+		c.mu.Unlock()
+	}
+}
+
+// add はディレクトリの比較結果を集計する（c.muを保持して呼び出す）
+func (c *comparer) add(dir Dir) {
+	c.result.Dirs++
+	c.result.SourceFiles += int64(dir.SourceFiles)
+	c.result.SourceDirs += int64(dir.SourceDirs)
+	c.result.DestFiles += int64(dir.DestFiles)
+	c.result.DestDirs += int64(dir.DestDirs)
+	if !dir.Match() {
+		c.result.Mismatched++
+	}
+	if !dir.Match() || c.opts.AllDirs {
+		c.result.Entries = append(c.result.Entries, dir)
+	}
+}
+
+// compareEntries はディレクトリのエントリ数と名前を比較し、両方に存在するサブディレクトリの名前を返す
+func (c *comparer) compareEntries(rel string) (Dir, []string) {
+	dir := Dir{Path: rel}
+	sourceEntries, err := c.readDir(c.source, rel)
+	if err != nil {
+		dir.Error = fmt.Sprintf("ソース: %v", err)
+		return dir, nil
+	}
+	destEntries, err := c.readDir(c.dest, rel)
+	if errors.Is(err, fs.ErrNotExist) {
+		dir.MissingDest = true
+	} else if err != nil {
+		dir.Error = fmt.Sprintf("宛先: %v", err)
+	}
+
+	dir.SourceFiles, dir.SourceDirs = count(sourceEntries)
+	dir.DestFiles, dir.DestDirs = count(destEntries)
+
+	var subdirs []string
+	for name, isDir := range sourceEntries {
+		destIsDir, ok := destEntries[name]
+		switch {
+		case dir.MissingDest || dir.Error != "":
+			// 宛先を読み込めない場合は個別のエントリを報告しない
+		case !ok:
+			dir.OnlyInSource = append(dir.OnlyInSource, entryName(name, isDir))
+		case isDir && destIsDir:
+			subdirs = append(subdirs, name)
+		case isDir != destIsDir:
+			// ファイルとディレクトリが入れ替わっている場合は両方に報告する
+			dir.OnlyInSource = append(dir.OnlyInSource, entryName(name, isDir))
+			dir.OnlyInDest = append(dir.OnlyInDest, entryName(name, destIsDir))
+		}
+	}
+	for name, isDir := range destEntries {
+		if _, ok := sourceEntries[name]; !ok {
+			dir.OnlyInDest = append(dir.OnlyInDest, entryName(name, isDir))
+		}
+	}
+	sort.Strings(dir.OnlyInSource)
+	sort.Strings(dir.OnlyInDest)
+	sort.Strings(subdirs)
+	return dir, subdirs
+}
+
+// readDir はディレクトリのエントリを名前とディレクトリかどうかの対応で返す（フィルタで除外したエントリは含めない）
+func (c *comparer) readDir(root, rel string) (map[string]bool, error) {
+	dirPath := filepath.Join(root, filepath.FromSlash(rel))
+	entries, err := c.fs.ReadDir(dirPath)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		entryPath := filepath.Join(dirPath, entry.Name())
+		if c.opts.Filter != nil {
+			if entry.IsDir() && c.opts.Filter.ExcludesDir(entryPath) {
+				continue
+			}
+			if !entry.IsDir() && !c.opts.Filter.ShouldInclude(entryPath) {
+				continue
+			}
+		}
+		names[entry.Name()] = entry.IsDir()
+	}
+	return names, nil
+}
+
+// count はエントリのファイル数とディレクトリ数を返す
+func count(entries map[string]bool) (files, dirs int) {
+	for _, isDir := range entries {
+		if isDir {
+			dirs++
+		} else {
+			files++
+		}
+	}
+	return files, dirs
+}
+
+// entryName は報告するエントリの名前を返す（ディレクトリは末尾に/を付ける）
+func entryName(name string, isDir bool) string {
+	if isDir {
+		return name + "/"
+	}
+	return name
+}
+
+// WriteJSON は比較結果をJSONで書き出す
+func WriteJSON(w io.Writer, result *Result) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+// WriteCSV は比較結果をCSVで書き出す（1行に1つのディレクトリ、エントリの名前は;で区切る）
+func WriteCSV(w io.Writer, result *Result) error {
+	writer := csv.NewWriter(w)
+	header := []string{"path", "source_files", "source_dirs", "dest_files", "dest_dirs", "missing_dest", "only_in_source", "only_in_dest", "error"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, dir := range result.Entries {
+		row := []string{
+			dir.Path,
+			strconv.Itoa(dir.SourceFiles),
+			strconv.Itoa(dir.SourceDirs),
+			strconv.Itoa(dir.DestFiles),
+			strconv.Itoa(dir.DestDirs),
+			strconv.FormatBool(dir.MissingDest),
+			strings.Join(dir.OnlyInSource, ";"),
+			strings.Join(dir.OnlyInDest, ";"),
+			dir.Error,
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteText は一致しないディレクトリを人が読む形式で書き出す
+// 行頭の記号は - ソースのみ, + 宛先のみ
+func WriteText(w io.Writer, result *Result) error {
+	for _, dir := range result.Entries {
+		if dir.Match() {
+			continue
+		}
+		switch {
+		case dir.Error != "":
+			fmt.Fprintf(w, "%s: エラー: %s\n", dir.Path, dir.Error)
+			continue
+		case dir.MissingDest:
+			fmt.Fprintf(w, "%s: 宛先にディレクトリがありません（ソース: %dファイル, %dディレクトリ）\n", dir.Path, dir.SourceFiles, dir.SourceDirs)
+			continue
+		}
+		fmt.Fprintf(w, "%s: ソース: %dファイル, %dディレクトリ / 宛先: %dファイル, %dディレクトリ\n",
+			dir.Path, dir.SourceFiles, dir.SourceDirs, dir.DestFiles, dir.DestDirs)
+		for _, name := range dir.OnlyInSource {
+			fmt.Fprintf(w, "  - %s\n", name)
+		}
+		for _, name := range dir.OnlyInDest {
+			fmt.Fprintf(w, "  + %s\n", name)
+		}
+	}
+	_, err := fmt.Fprintf(w, "比較: %dディレクトリ, ソース: %dファイル, %dディレクトリ, 宛先: %dファイル, %dディレクトリ, 不一致: %dディレクトリ\n",
+		result.Dirs, result.SourceFiles, result.SourceDirs, result.DestFiles, result.DestDirs, result.Mismatched)
+	return err
+}
@@ -0,0 +1,105 @@
+package structcheck
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sakuhanight/gopier/internal/filter"
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+func TestCompare(t *testing.T) {
+	tempDir := t.TempDir()
+	source := filepath.Join(tempDir, "source")
+	dest := filepath.Join(tempDir, "dest")
+	files := map[string][]string{
+		source: {"a.txt", "docs/report.doc", "docs/old.doc", "photos/2024/1.jpg", "missing/x.txt", "swap", "node_modules/lib.js"},
+		dest:   {"a.txt", "docs/report.doc", "docs/extra.doc", "photos/2024/1.jpg", "swap/inner.txt"},
+	}
+	for root, paths := range files {
+		for _, p := range paths {
+			path := filepath.Join(root, filepath.FromSlash(p))
+			os.MkdirAll(filepath.Dir(path), 0755)
+			os.WriteFile(path, []byte(p), 0644)
+		}
+	}
+
+	f := filter.NewFilter("", "")
+	profile, _ := filter.ResolveProfiles("dev-projects", nil)
+	f.AddProfile(profile)
+	result, err := Compare(source, dest, Options{Filter: f, Workers: 2})
+	if err != nil {
+		t.Fatalf("Compareが失敗しました: %v", err)
+	}
+
+	// 一方にのみ存在するディレクトリは親のディレクトリで報告し、配下は走査しない
+	if result.Dirs != 4 || result.Mismatched != 2 {
+		t.Errorf("比較したディレクトリ数 = %d, 不一致 = %d, 期待値 4, 2", result.Dirs, result.Mismatched)
+	}
+	if result.SourceFiles != 5 || result.DestFiles != 4 {
+		t.Errorf("ファイル数 = ソース %d, 宛先 %d, 期待値 5, 4", result.SourceFiles, result.DestFiles)
+	}
+
+	var paths []string
+	for _, dir := range result.Entries {
+		paths = append(paths, dir.Path)
+	}
+	if !reflect.DeepEqual(paths, []string{".", "docs"}) {
+		t.Fatalf("一致しないディレクトリ = %v", paths)
+	}
+	root, docs := result.Entries[0], result.Entries[1]
+	if !reflect.DeepEqual(root.OnlyInSource, []string{"missing/", "swap"}) || !reflect.DeepEqual(root.OnlyInDest, []string{"swap/"}) {
+		t.Errorf("ルートの差分 = ソースのみ %v, 宛先のみ %v", root.OnlyInSource, root.OnlyInDest)
+	}
+	if docs.SourceFiles != 2 || docs.DestFiles != 2 || !reflect.DeepEqual(docs.OnlyInSource, []string{"old.doc"}) || !reflect.DeepEqual(docs.OnlyInDest, []string{"extra.doc"}) {
+		t.Errorf("docsの差分 = %+v", docs)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteText(&buf, result); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"  - old.doc", "  + extra.doc", "不一致: 2ディレクトリ"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("テキスト出力に %q がありません:\n%s", want, buf.String())
+		}
+	}
+
+	// AllDirsの場合は一致したディレクトリのエントリ数も含める
+	result, err = Compare(source, dest, Options{Filter: f, AllDirs: true})
+	if err != nil {
+		t.Fatalf("Compareが失敗しました: %v", err)
+	}
+	if len(result.Entries) != 4 {
+		t.Errorf("AllDirsの結果 = %d件, 期待値 4件", len(result.Entries))
+	}
+	buf.Reset()
+	if err := WriteCSV(&buf, result); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 5 {
+		t.Errorf("CSVの行数 = %d, 期待値 5:\n%s", lines, buf.String())
+	}
+}
+
+func TestCompare_MemFS(t *testing.T) {
+	mem := vfs.NewMem()
+	mem.WriteFile("/src/a/1.txt", []byte("1"), 0644)
+	mem.WriteFile("/dst/a/1.txt", []byte("1"), 0644)
+
+	result, err := Compare("/src", "/dst", Options{FS: mem})
+	if err != nil {
+		t.Fatalf("Compareが失敗しました: %v", err)
+	}
+	if result.Dirs != 2 || result.Mismatched != 0 || len(result.Entries) != 0 {
+		t.Errorf("結果 = %+v, 期待値 不一致なし", result)
+	}
+
+	if _, err := Compare("/src", "/none", Options{FS: mem}); err == nil {
+		t.Error("存在しない宛先でエラーになりません")
+	}
+}
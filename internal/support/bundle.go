@@ -0,0 +1,325 @@
+package support
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sakuhanight/gopier/internal/database"
+)
+
+// redactedValue は伏せ字にした値の表記
+const redactedValue = "********"
+
+// maxFailedFiles はDBサマリーに含める失敗ファイルの最大件数
+const maxFailedFiles = 50
+
+// secretKeyPattern は値を伏せ字にする設定キーのパターン
+var secretKeyPattern = regexp.MustCompile(`(?i)(password|passwd|secret|token|credential|api_?key|access_?key|private_?key)`)
+
+// urlUserinfoPattern はURLに埋め込まれた認証情報のパターン
+var urlUserinfoPattern = regexp.MustCompile(`(://)[^/@\s]+@`)
+
+// Options はサポートバンドルの作成オプション
+type Options struct {
+	ConfigPath string // 設定ファイルのパス
+	LogFile    string // ログファイルのパス
+	LogLines   int    // ログファイルから含める末尾の行数
+	DBPath     string // 同期状態データベースのパス
+	ReportPath string // 最後の実行レポートのパス
+	Version    string // gopierのバージョン
+	BuildTime  string // ビルド日時
+}
+
+// Result はサポートバンドルの作成結果
+type Result struct {
+	Included []string // バンドルに含めたファイル
+	Problems []string // 収集できなかった情報とその理由
+}
+
+// DBSummary はバンドルに含めるデータベースの概要
+type DBSummary struct {
+	Path          string                `json:"path"`
+	SizeBytes     int64                 `json:"size_bytes"`
+	Stats         map[string]int        `json:"stats"`
+	LatestCopy    *database.SyncSession `json:"latest_copy_session,omitempty"`
+	LatestVerify  *database.SyncSession `json:"latest_verify_session,omitempty"`
+	FailedFiles   []FailedFile          `json:"failed_files,omitempty"`
+	TargetsFailed map[string]int        `json:"targets_failed,omitempty"`
+}
+
+// FailedFile は失敗したファイルの概要
+type FailedFile struct {
+	Path      string `json:"path"`
+	FailCount int    `json:"fail_count"`
+	LastError string `json:"last_error"`
+}
+
+// CreateBundle はサポートバンドル（zip）を作成する
+// 個々の情報が収集できなくても作成は継続し、その理由をproblems.txtに記録する
+func CreateBundle(outputPath string, opts Options) (*Result, error) {
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("バンドルファイルの作成に失敗: %w", err)
+	}
+
+	zw := zip.NewWriter(file)
+	result := &Result{}
+	b := &bundle{zw: zw, result: result}
+
+	b.add("version.txt", func(w io.Writer) error {
+		return writeVersion(w, opts)
+	})
+
+	if opts.ConfigPath != "" {
+		b.add("config.yaml", func(w io.Writer) error {
+			data, err := os.ReadFile(opts.ConfigPath)
+			if err != nil {
+				return err
+			}
+			redacted, err := RedactConfig(data)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(redacted)
+			return err
+		})
+	} else {
+		result.Problems = append(result.Problems, "config.yaml: 設定ファイルが使用されていません")
+	}
+
+	if opts.LogFile != "" {
+		b.add("logs/"+filepath.Base(opts.LogFile), func(w io.Writer) error {
+			return tailFile(w, opts.LogFile, opts.LogLines)
+		})
+	} else {
+		result.Problems = append(result.Problems, "logs: ログファイルが設定されていません")
+	}
+
+	if opts.DBPath != "" {
+		b.add("db_summary.json", func(w io.Writer) error {
+			summary, err := summarizeDB(opts.DBPath)
+			if err != nil {
+				return err
+			}
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(summary)
+		})
+	}
+
+	if opts.ReportPath != "" {
+		b.add("report/"+filepath.Base(opts.ReportPath), func(w io.Writer) error {
+			return copyFile(w, opts.ReportPath)
+		})
+	}
+
+	if len(result.Problems) > 0 {
+		if fw, err := zw.Create("problems.txt"); err == nil {
+			fmt.Fprintln(fw, strings.Join(result.Problems, "\n"))
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("バンドルの書き込みに失敗: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("バンドルの書き込みに失敗: %w", err)
+	}
+
+	return result, nil
+}
+
+// bundle はzipへのエントリ追加と結果の記録を行う
+type bundle struct {
+	zw     *zip.Writer
+	result *Result
+}
+
+// add はエントリを追加する
+// 内容の生成に失敗した場合はエントリを含めず、理由を記録する
+func (b *bundle) add(name string, write func(w io.Writer) error) {
+	// 失敗したときに不完全なエントリを残さないよう、先にメモリ上で内容を生成する
+	var buf strings.Builder
+	if err := write(&buf); err != nil {
+		b.result.Problems = append(b.result.Problems, fmt.Sprintf("%s: %v", name, err))
+		return
+	}
+
+	w, err := b.zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err == nil {
+		_, err = io.WriteString(w, buf.String())
+	}
+	if err != nil {
+		b.result.Problems = append(b.result.Problems, fmt.Sprintf("%s: %v", name, err))
+		return
+	}
+
+	b.result.Included = append(b.result.Included, name)
+}
+
+// writeVersion はバージョンと実行環境の情報を書き込む
+func writeVersion(w io.Writer, opts Options) error {
+	_, err := fmt.Fprintf(w, "gopier version: %s\nbuild time: %s\ngo version: %s\nplatform: %s/%s\ncpus: %d\ncreated: %s\n",
+		opts.Version, opts.BuildTime, runtime.Version(), runtime.GOOS, runtime.GOARCH,
+		runtime.NumCPU(), time.Now().Format(time.RFC3339))
+	return err
+}
+
+// RedactConfig は設定ファイルの内容から秘密情報を伏せ字にする
+// パスワードやトークンなどのキーの値と、URLに埋め込まれた認証情報が対象
+func RedactConfig(data []byte) ([]byte, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("設定ファイルの解析に失敗: %w", err)
+	}
+
+	redactNode(&root, false)
+
+	out, err := yaml.Marshal(&root)
+	if err != nil {
+		return nil, fmt.Errorf("設定ファイルの出力に失敗: %w", err)
+	}
+	return out, nil
+}
+
+// redactNode はYAMLノードを再帰的にたどって秘密情報を伏せ字にする
+func redactNode(node *yaml.Node, secret bool) {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			redactNode(child, secret)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			redactNode(node.Content[i+1], secret || secretKeyPattern.MatchString(key.Value))
+		}
+	case yaml.ScalarNode:
+		if secret && node.Value != "" {
+			node.Value = redactedValue
+			node.Tag = "!!str"
+			node.Style = 0
+			return
+		}
+		node.Value = urlUserinfoPattern.ReplaceAllString(node.Value, "${1}"+redactedValue+"@")
+	case yaml.AliasNode:
+		// エイリアスの参照先は別途たどられる
+	}
+}
+
+// tailFile はファイルの末尾から指定行数を書き込む
+func tailFile(w io.Writer, path string, lines int) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if lines <= 0 {
+		_, err = io.Copy(w, file)
+		return err
+	}
+
+	// 末尾の行だけをリングバッファに保持する
+	ring := make([]string, lines)
+	count := 0
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		ring[count%lines] = scanner.Text()
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	start := 0
+	if count > lines {
+		start = count - lines
+	}
+	for i := start; i < count; i++ {
+		if _, err := fmt.Fprintln(w, ring[i%lines]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyFile はファイルの内容をそのまま書き込む
+func copyFile(w io.Writer, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(w, file)
+	return err
+}
+
+// summarizeDB は同期状態データベースの概要を作成する
+func summarizeDB(path string) (*DBSummary, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// 調査対象のデータベースを変更しないよう、読み取り専用で開く（形式の移行も行わない）
+	syncDB, err := database.OpenReadOnly(path)
+	if err != nil {
+		return nil, err
+	}
+	defer syncDB.Close()
+
+	summary := &DBSummary{Path: path, SizeBytes: info.Size()}
+
+	if summary.Stats, err = syncDB.GetSyncStats(); err != nil {
+		return nil, err
+	}
+	if summary.LatestCopy, err = syncDB.GetLatestSession(database.SessionCopy); err != nil {
+		return nil, err
+	}
+	if summary.LatestVerify, err = syncDB.GetLatestSession(database.SessionVerify); err != nil {
+		return nil, err
+	}
+
+	err = syncDB.ForEachFile(false, func(file database.FileInfo) error {
+		if file.Status == database.StatusFailed && len(summary.FailedFiles) < maxFailedFiles {
+			summary.FailedFiles = append(summary.FailedFiles, FailedFile{
+				Path:      file.Path,
+				FailCount: file.FailCount,
+				LastError: file.LastError,
+			})
+		}
+		for dest, target := range file.Targets {
+			if target.Status == database.StatusFailed {
+				if summary.TargetsFailed == nil {
+					summary.TargetsFailed = make(map[string]int)
+				}
+				summary.TargetsFailed[dest]++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,201 @@
+package toollog
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/filter"
+)
+
+// 不一致の種類
+const (
+	KindMissing    = "missing"     // 前のツールが処理したが、DBに記録がない（gopierが処理していない）
+	KindPrevFailed = "prev_failed" // 前のツールで失敗し、DBに記録がない
+	KindFailed     = "failed"      // DBの記録が失敗・不一致・処理待ち
+	KindRemoved    = "removed"     // gopierが余分なファイルとして削除・隔離した（ソースから削除された可能性がある）
+)
+
+// Discrepancy は前のツールのログとDBの記録の不一致を表す構造体
+type Discrepancy struct {
+	Path   string `json:"path"`
+	Kind   string `json:"kind"`
+	Action string `json:"action"`           // 前のツールでの処理結果
+	Status string `json:"status,omitempty"` // DBの同期状態（記録がない場合は空）
+	Error  string `json:"error,omitempty"`  // 前のツールまたはDBに記録されたエラー
+}
+
+// Result は突き合わせの結果を表す構造体
+type Result struct {
+	Format   string         `json:"format"`   // 前のツールのログの形式
+	Root     string         `json:"root"`     // 前のツールのソースのルート
+	Files    int            `json:"files"`    // 突き合わせたファイル数（宛先にのみ存在するファイル・除外したファイルを除く）
+	Matched  int            `json:"matched"`  // gopierでも処理済みのファイル数
+	Extras   int            `json:"extras"`   // 前のツールのログで宛先にのみ存在したファイル数（突き合わせの対象外）
+	Excluded int            `json:"excluded"` // 除外パターンに一致したファイル数
+	Counts   map[string]int `json:"counts"`   // 不一致の種類ごとの件数
+	Entries  []Discrepancy  `json:"entries"`  // 不一致（パス順）
+}
+
+// HasDiscrepancies は不一致があるかどうかを返す
+func (r *Result) HasDiscrepancies() bool {
+	return len(r.Entries) > 0
+}
+
+// CompareOptions は突き合わせのオプションを表す構造体
+type CompareOptions struct {
+	Filter     *filter.Filter // 突き合わせるファイルのフィルタ（gopierで意図して除外したファイルを除く）
+	IgnoreCase bool           // パスの大文字・小文字を区別しない（Windowsのソース向け）
+}
+
+// record はDBのファイルごとの記録のうち、突き合わせに使用する項目
+type record struct {
+	status database.FileStatus
+	err    string
+}
+
+// Compare は前のツールのログとDBの記録を突き合わせる
+func Compare(db *database.SyncDB, log *Log, opts CompareOptions) (*Result, error) {
+	records := make(map[string]record)
+	err := db.ForEachFile(false, func(file database.FileInfo) error {
+		records[compareKey(file.Path, opts.IgnoreCase)] = record{status: file.Status, err: file.LastError}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("データベースの読み込みに失敗: %w", err)
+	}
+
+	result := &Result{
+		Format:  log.Format,
+		Root:    log.Root,
+		Counts:  map[string]int{},
+		Entries: []Discrepancy{},
+	}
+	for _, entry := range log.Entries {
+		if entry.Action == ActionExtra {
+			result.Extras++
+			continue
+		}
+		if opts.Filter != nil && !opts.Filter.ShouldInclude(entry.Path) {
+			result.Excluded++
+			continue
+		}
+		result.Files++
+
+		rec, ok := records[compareKey(entry.Path, opts.IgnoreCase)]
+		d := Discrepancy{Path: entry.Path, Action: entry.Action, Status: string(rec.status), Error: entry.Error}
+		switch {
+		case !ok && entry.Action == ActionFailed:
+			d.Kind = KindPrevFailed
+		case !ok:
+			d.Kind = KindMissing
+		case rec.status == database.StatusDeleted || rec.status == database.StatusQuarantined:
+			d.Kind = KindRemoved
+		case !completed(rec.status):
+			d.Kind = KindFailed
+			if rec.err != "" {
+				d.Error = rec.err
+			}
+		default:
+			result.Matched++
+			continue
+		}
+		result.Entries = append(result.Entries, d)
+		result.Counts[d.Kind]++
+	}
+	return result, nil
+}
+
+// completed はDBの同期状態がコピー済み（宛先に内容がある）かどうかを返す
+func completed(status database.FileStatus) bool {
+	switch status {
+	case database.StatusSuccess, database.StatusVerified, database.StatusSkipped,
+		database.StatusDataOKPermissionFailed, database.StatusIntermittent:
+		return true
+	}
+	return false
+}
+
+func compareKey(path string, ignoreCase bool) string {
+	if ignoreCase {
+		return strings.ToLower(path)
+	}
+	return path
+}
+
+// WriteText は不一致を1行に1件と集計を書き出す
+func WriteText(w io.Writer, result *Result) error {
+	bw := bufio.NewWriter(w)
+	for _, d := range result.Entries {
+		line := fmt.Sprintf("%-11s %s", d.Kind, d.Path)
+		if d.Status != "" {
+			line += fmt.Sprintf(" (DB: %s)", d.Status)
+		}
+		if d.Error != "" {
+			line += ": " + d.Error
+		}
+		fmt.Fprintln(bw, line)
+	}
+	if len(result.Entries) > 0 {
+		fmt.Fprintln(bw)
+	}
+	writeSummary(bw, result)
+	return bw.Flush()
+}
+
+// WriteSummary は集計のみを書き出す
+func WriteSummary(w io.Writer, result *Result) error {
+	bw := bufio.NewWriter(w)
+	writeSummary(bw, result)
+	return bw.Flush()
+}
+
+func writeSummary(w io.Writer, result *Result) {
+	fmt.Fprintf(w, "ログ: %s (ソース: %s)\n", result.Format, result.Root)
+	fmt.Fprintf(w, "突き合わせ: %d件, 処理済み: %d件, 不一致: %d件\n", result.Files, result.Matched, len(result.Entries))
+	for _, kind := range []string{KindMissing, KindPrevFailed, KindFailed, KindRemoved} {
+		if n := result.Counts[kind]; n > 0 {
+			fmt.Fprintf(w, "  %-11s %d件\n", kind, n)
+		}
+	}
+	if result.Extras > 0 || result.Excluded > 0 {
+		fmt.Fprintf(w, "対象外: 宛先のみ %d件, 除外 %d件\n", result.Extras, result.Excluded)
+	}
+}
+
+// WriteCSV は不一致を1行に1件のCSV（ヘッダ付き）で書き出す
+func WriteCSV(w io.Writer, result *Result) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"path", "kind", "action", "status", "error"})
+	for _, d := range result.Entries {
+		cw.Write([]string{d.Path, d.Kind, d.Action, d.Status, d.Error})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON は結果全体をJSONで書き出す
+func WriteJSON(w io.Writer, result *Result) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+// WriteList は不一致のパスを--files-fromで読み込める形式（1行に1つの相対パス）で書き出す
+// gopierで削除・隔離したファイルはソースに存在しない可能性があるため含めない
+func WriteList(w io.Writer, result *Result) error {
+	bw := bufio.NewWriter(w)
+	for _, d := range result.Entries {
+		if d.Kind == KindRemoved {
+			continue
+		}
+		if _, err := io.WriteString(bw, d.Path+"\n"); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
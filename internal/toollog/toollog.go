@@ -0,0 +1,388 @@
+// Package toollog は別のコピーツール（rsync、robocopy）のログを読み込み、同期DBの記録と突き合わせる
+// 移行の途中でツールを切り替えた場合に、前のツールが処理したファイルをgopierが漏れなく処理したかを確認するために使用する
+package toollog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/sakuhanight/gopier/internal/pathkey"
+)
+
+// ログの形式
+const (
+	FormatAuto     = "auto"     // 内容から判定する
+	FormatRsync    = "rsync"    // rsync --itemize-changes（-i）の出力、または--log-fileのログ
+	FormatRobocopy = "robocopy" // robocopyの/LOG・/UNILOGのログ
+)
+
+// 前のツールでのファイルの処理結果
+const (
+	ActionCopied = "copied" // 転送した（新規・更新）
+	ActionSame   = "same"   // 宛先と同じため転送しなかった（属性のみの更新を含む）
+	ActionFailed = "failed" // エラーで転送できなかった
+	ActionExtra  = "extra"  // 宛先にのみ存在する（削除したものを含む、突き合わせの対象外）
+)
+
+// 自動判定のために読み込む最大の行数
+const detectLines = 1000
+
+// Entry は前のツールのログに記録された1つのファイルを表す構造体
+type Entry struct {
+	Path   string `json:"path"`            // ソースからの相対パス（DBのキー形式）
+	Action string `json:"action"`          // 処理結果
+	Size   int64  `json:"size"`            // ログに記録されたサイズ（記録されていない場合は-1）
+	Error  string `json:"error,omitempty"` // エラーの内容（失敗した場合）
+}
+
+// Log は読み込んだログ全体を表す構造体
+type Log struct {
+	Format  string  // ログの形式
+	Root    string  // 前のツールのソースのルート（ログから判定した場合を含む）
+	Entries []Entry // ファイルごとの最終的な処理結果（パス順）
+}
+
+// Options はログの読み込みのオプションを表す構造体
+type Options struct {
+	Format      string // ログの形式（空はauto）
+	Root        string // 前のツールのソースのルート（ログ中の絶対パスをこの基準の相対パスに変換する。robocopyでは省略時にログのSourceを使用する）
+	StripPrefix string // 相対パスから取り除く接頭辞（rsyncでソースの末尾に/を付けずに実行した場合のディレクトリ名など）
+}
+
+// parser はログの形式ごとの行の解析処理
+type parser interface {
+	line(text string)
+}
+
+// Parse はログを読み込み、ファイルごとの最終的な処理結果を返す
+// 再試行で同じファイルが複数回記録されている場合は、最後の記録を使用する
+func Parse(r io.Reader, opts Options) (*Log, error) {
+	format := opts.Format
+	if format == "" {
+		format = FormatAuto
+	}
+	switch format {
+	case FormatAuto, FormatRsync, FormatRobocopy:
+	default:
+		return nil, fmt.Errorf("サポートされていないログの形式: %s (auto, rsync, robocopyのいずれかを指定してください)", format)
+	}
+
+	br := bufio.NewReader(r)
+	reader, err := decodeReader(br)
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	log := &Log{Format: format, Root: opts.Root}
+	files := make(map[string]Entry)
+	var p parser
+	var pending []string
+	for scanner.Scan() {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if p == nil {
+			if log.Format == FormatAuto {
+				log.Format = detect(text)
+				if log.Format == FormatAuto {
+					// 判定できるまでの行は、判定後に解析する
+					pending = append(pending, text)
+					if len(pending) >= detectLines {
+						return nil, fmt.Errorf("ログの形式を判定できません（--log-formatで指定してください）")
+					}
+					continue
+				}
+			}
+			p = newParser(log, files, opts)
+			for _, line := range pending {
+				p.line(line)
+			}
+			pending = nil
+		}
+		p.line(text)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ログの読み込みエラー: %w", err)
+	}
+	if p == nil {
+		return nil, fmt.Errorf("ログの形式を判定できません（--log-formatで指定してください）")
+	}
+
+	log.Entries = make([]Entry, 0, len(files))
+	for _, entry := range files {
+		log.Entries = append(log.Entries, entry)
+	}
+	sort.Slice(log.Entries, func(i, j int) bool { return log.Entries[i].Path < log.Entries[j].Path })
+	return log, nil
+}
+
+func newParser(log *Log, files map[string]Entry, opts Options) parser {
+	if log.Format == FormatRobocopy {
+		return &robocopyParser{log: log, files: files, prefix: opts.StripPrefix}
+	}
+	return &rsyncParser{log: log, files: files, prefix: opts.StripPrefix}
+}
+
+// detect は1行の内容からログの形式を判定する（判定できない場合はauto）
+func detect(text string) string {
+	switch {
+	case strings.Contains(text, "ROBOCOPY") || robocopyErrorPattern.MatchString(text):
+		return FormatRobocopy
+	case rsyncItemPattern.MatchString(stripRsyncLogPrefix(text)):
+		return FormatRsync
+	}
+	return FormatAuto
+}
+
+// decodeReader はUTF-16（/UNILOGのログ）の場合はUTF-8に変換するReaderを返す（UTF-8のBOMは取り除く）
+func decodeReader(br *bufio.Reader) (io.Reader, error) {
+	bom, err := br.Peek(3)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("ログの読み込みエラー: %w", err)
+	}
+	switch {
+	case len(bom) >= 2 && bom[0] == 0xFF && bom[1] == 0xFE:
+		br.Discard(2)
+		return &utf16Reader{r: br}, nil
+	case len(bom) >= 3 && bom[0] == 0xEF && bom[1] == 0xBB && bom[2] == 0xBF:
+		br.Discard(3)
+	}
+	return br, nil
+}
+
+// utf16Reader はUTF-16LEのテキストをUTF-8に変換して読み込む
+type utf16Reader struct {
+	r   *bufio.Reader
+	buf []byte
+}
+
+func (u *utf16Reader) Read(p []byte) (int, error) {
+	for len(u.buf) == 0 {
+		unit, err := u.readUnit()
+		if err != nil {
+			return 0, err
+		}
+		r := rune(unit)
+		if utf16.IsSurrogate(r) {
+			low, err := u.readUnit()
+			if err != nil {
+				return 0, err
+			}
+			r = utf16.DecodeRune(r, rune(low))
+		}
+		u.buf = []byte(string(r))
+	}
+	n := copy(p, u.buf)
+	u.buf = u.buf[n:]
+	return n, nil
+}
+
+func (u *utf16Reader) readUnit() (uint16, error) {
+	var b [2]byte
+	if _, err := io.ReadFull(u.r, b[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		return 0, err
+	}
+	return uint16(b[0]) | uint16(b[1])<<8, nil
+}
+
+// relPath はログ中のパスをソースからの相対パス（DBのキー形式）に変換する（対象外のパスは空）
+func relPath(root, prefix, p string) string {
+	key := pathkey.Normalize(p)
+	if root != "" {
+		base := strings.TrimSuffix(pathkey.Normalize(root), "/") + "/"
+		if len(key) >= len(base) && strings.EqualFold(key[:len(base)], base) {
+			key = key[len(base):]
+		}
+	}
+	if isAbs(key) {
+		// ルートの外、またはルートを指定していない場合の絶対パスは対象外
+		return ""
+	}
+	if prefix != "" {
+		key = strings.TrimPrefix(key, strings.TrimSuffix(pathkey.Normalize(prefix), "/")+"/")
+	}
+	if key == "" || strings.HasSuffix(key, "/") {
+		return ""
+	}
+	return path.Clean(key)
+}
+
+// isAbs はキー形式のパスがWindows・UNIXのどちらかの絶対パスかどうかを返す
+func isAbs(key string) bool {
+	return strings.HasPrefix(key, "/") || (len(key) >= 3 && key[1] == ':' && key[2] == '/')
+}
+
+// rsyncの--itemize-changesの行（YXcstpoguax パス）
+var rsyncItemPattern = regexp.MustCompile(`^([<>ch.*])([fdLDS])([.+?a-zA-Z ]{9,10}) (.+)$`)
+
+// rsyncの--log-fileの行頭（日時とプロセスID）
+var rsyncLogPrefixPattern = regexp.MustCompile(`^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2} \[\d+\] `)
+
+// rsyncのファイルごとのエラー（rsync: [sender] send_files failed to open "/src/a": Permission denied (13)）
+var rsyncErrorPattern = regexp.MustCompile(`^rsync: (?:\[\w+\] )?[^"]*"([^"]+)"[^:]*: (.+)$`)
+
+func stripRsyncLogPrefix(text string) string {
+	if loc := rsyncLogPrefixPattern.FindStringIndex(text); loc != nil {
+		return text[loc[1]:]
+	}
+	return text
+}
+
+// rsyncParser はrsync --itemize-changesの出力を解析する
+type rsyncParser struct {
+	log    *Log
+	files  map[string]Entry
+	prefix string
+}
+
+func (p *rsyncParser) line(text string) {
+	text = stripRsyncLogPrefix(text)
+	if m := rsyncErrorPattern.FindStringSubmatch(text); m != nil {
+		if rel := relPath(p.log.Root, p.prefix, m[1]); rel != "" {
+			p.files[rel] = Entry{Path: rel, Action: ActionFailed, Size: -1, Error: m[2]}
+		}
+		return
+	}
+	if strings.HasPrefix(text, "*deleting ") {
+		if rel := relPath("", p.prefix, strings.TrimSpace(strings.TrimPrefix(text, "*deleting "))); rel != "" {
+			p.files[rel] = Entry{Path: rel, Action: ActionExtra, Size: -1}
+		}
+		return
+	}
+
+	m := rsyncItemPattern.FindStringSubmatch(text)
+	if m == nil || m[2] != "f" {
+		return
+	}
+	name := m[4]
+	// ハードリンク（=>）は名前のみ使用する
+	if i := strings.Index(name, " => "); i >= 0 {
+		name = name[:i]
+	}
+	rel := relPath("", p.prefix, name)
+	if rel == "" {
+		return
+	}
+	action := ActionCopied
+	if m[1] == "." {
+		action = ActionSame
+	}
+	p.files[rel] = Entry{Path: rel, Action: action, Size: -1}
+}
+
+// robocopyのファイルごとのエラー（2024/01/02 03:04:05 ERROR 5 (0x00000005) Copying File C:\src\a.txt）
+var robocopyErrorPattern = regexp.MustCompile(`^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2} ERROR \d+ \(0x[0-9A-Fa-f]+\) .*?((?:[A-Za-z]:\\|\\\\).*)$`)
+
+// robocopyのヘッダのソース（   Source : C:\src\）
+var robocopySourcePattern = regexp.MustCompile(`^\s*Source\s*:\s*(.+?)\s*$`)
+
+// robocopyParser はrobocopyのログを解析する
+// ファイルの行はディレクトリの行に続いて名前のみで記録されるため、直前のディレクトリを保持する
+type robocopyParser struct {
+	log     *Log
+	files   map[string]Entry
+	prefix  string
+	dir     string // 直前のディレクトリ（ソースの外のディレクトリの場合は空）
+	failed  string // 直前にエラーになったファイル（次の行のエラーの内容を記録する）
+	started bool   // ファイルの一覧の区切り線を読み込んだ
+}
+
+func (p *robocopyParser) line(text string) {
+	if p.failed != "" {
+		// エラーの行の次の行がエラーの内容
+		if msg := strings.TrimSpace(text); msg != "" {
+			entry := p.files[p.failed]
+			entry.Error = msg
+			p.files[p.failed] = entry
+		}
+		p.failed = ""
+		return
+	}
+	if m := robocopyErrorPattern.FindStringSubmatch(text); m != nil {
+		if rel := relPath(p.log.Root, p.prefix, m[1]); rel != "" {
+			p.files[rel] = Entry{Path: rel, Action: ActionFailed, Size: -1}
+			p.failed = rel
+		}
+		return
+	}
+	if p.log.Root == "" {
+		if m := robocopySourcePattern.FindStringSubmatch(text); m != nil {
+			p.log.Root = m[1]
+			return
+		}
+	}
+	if !strings.HasPrefix(text, "\t") {
+		return
+	}
+
+	fields := splitTabs(text)
+	if len(fields) < 2 {
+		return
+	}
+	name := fields[len(fields)-1]
+	if strings.HasSuffix(name, `\`) {
+		// ディレクトリの行（種類と件数、フルパス）。宛先の余分なディレクトリの中のファイルは対象外
+		if strings.HasPrefix(fields[0], "*EXTRA") {
+			p.dir = ""
+			return
+		}
+		if p.log.Root == "" {
+			// ソースが記録されていないログでは、最初のディレクトリをルートとする
+			p.log.Root = name
+		}
+		p.dir = name
+		return
+	}
+
+	label, size := "", int64(-1)
+	if len(fields) >= 3 {
+		label = fields[0]
+	}
+	if n, err := strconv.ParseInt(fields[len(fields)-2], 10, 64); err == nil {
+		size = n
+	} else if len(fields) == 2 {
+		label = fields[0]
+	}
+
+	full := name
+	if !isAbs(pathkey.Normalize(name)) {
+		if p.dir == "" {
+			return
+		}
+		full = p.dir + name
+	}
+	rel := relPath(p.log.Root, p.prefix, full)
+	if rel == "" {
+		return
+	}
+
+	action := ActionCopied
+	switch lower := strings.ToLower(label); {
+	case strings.HasPrefix(lower, "*extra"):
+		action = ActionExtra
+	case lower == "same":
+		action = ActionSame
+	}
+	p.files[rel] = Entry{Path: rel, Action: action, Size: size}
+}
+
+// splitTabs はタブ区切りの各項目の前後の空白を取り除き、空の項目を除いて返す
+func splitTabs(text string) []string {
+	var fields []string
+	for _, field := range strings.Split(text, "\t") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
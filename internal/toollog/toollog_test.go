@@ -0,0 +1,246 @@
+package toollog
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/filter"
+)
+
+const rsyncLog = `sending incremental file list
+cd+++++++++ docs/
+>f+++++++++ docs/a.txt
+>f.st...... docs/b.txt
+.f..t...... docs/same.txt
+hf+++++++++ docs/link.txt => docs/a.txt
+*deleting   docs/old.txt
+rsync: [sender] send_files failed to open "/mnt/share/docs/locked.txt": Permission denied (13)
+2024/01/02 03:04:05 [123] >f+++++++++ logged.txt
+
+sent 1,234 bytes  received 56 bytes  2,580.00 bytes/sec
+`
+
+const robocopyLog = "-------------------------------------------------------------------------------\r\n" +
+	"   ROBOCOPY     ::     Robust File Copy for Windows\r\n" +
+	"-------------------------------------------------------------------------------\r\n" +
+	"\r\n" +
+	"   Source : C:\\Share\\\r\n" +
+	"     Dest : D:\\Backup\\\r\n" +
+	"\r\n" +
+	"\t                   2\tC:\\Share\\\r\n" +
+	"\t    New File  \t\t      12\ta.txt\r\n" +
+	"\t    Newer     \t\t      34\tb.txt\r\n" +
+	"\t  New Dir          2\tC:\\Share\\Sub\\\r\n" +
+	"\t    New File  \t\t   1.2 m\tbig.bin\r\n" +
+	"\t    New File  \t\t     100\tlocked.txt\r\n" +
+	"2024/01/02 03:04:06 ERROR 5 (0x00000005) Copying File C:\\Share\\Sub\\locked.txt\r\n" +
+	"Access is denied.\r\n" +
+	"\t    New File  \t\t      10\tretry.txt\r\n" +
+	"2024/01/02 03:04:07 ERROR 32 (0x00000020) Copying File C:\\Share\\Sub\\retry.txt\r\n" +
+	"The process cannot access the file because it is being used by another process.\r\n" +
+	"Waiting 30 seconds... Retrying...\r\n" +
+	"\t    New File  \t\t      10\tretry.txt\r\n" +
+	"\t*EXTRA File  \t\t       5\told.txt\r\n" +
+	"\t*EXTRA Dir        -1\tD:\\Backup\\Gone\\\r\n" +
+	"\t*EXTRA File  \t\t       5\tgone.txt\r\n" +
+	"\r\n" +
+	"------------------------------------------------------------------------------\r\n" +
+	"               Total    Copied   Skipped  Mismatch    FAILED    Extras\r\n" +
+	"    Dirs :         2         1         1         0         0         1\r\n"
+
+func entriesByPath(log *Log) map[string]Entry {
+	entries := make(map[string]Entry)
+	for _, entry := range log.Entries {
+		entries[entry.Path] = entry
+	}
+	return entries
+}
+
+func TestParse_Rsync(t *testing.T) {
+	log, err := Parse(strings.NewReader(rsyncLog), Options{Root: "/mnt/share/"})
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if log.Format != FormatRsync {
+		t.Errorf("Format = %s, want rsync", log.Format)
+	}
+
+	entries := entriesByPath(log)
+	want := map[string]string{
+		"docs/a.txt":      ActionCopied,
+		"docs/b.txt":      ActionCopied,
+		"docs/same.txt":   ActionSame,
+		"docs/link.txt":   ActionCopied,
+		"docs/old.txt":    ActionExtra,
+		"docs/locked.txt": ActionFailed,
+		"logged.txt":      ActionCopied,
+	}
+	if len(entries) != len(want) {
+		t.Errorf("Entries = %+v", log.Entries)
+	}
+	for path, action := range want {
+		if entries[path].Action != action {
+			t.Errorf("%s: Action = %q, want %q", path, entries[path].Action, action)
+		}
+	}
+	if entries["docs/locked.txt"].Error != "Permission denied (13)" {
+		t.Errorf("Error = %q", entries["docs/locked.txt"].Error)
+	}
+	if log.Entries[0].Path != "docs/a.txt" {
+		t.Errorf("パス順に並んでいません: %+v", log.Entries)
+	}
+
+	// ソースの末尾に/を付けずに実行したログ
+	log, err = Parse(strings.NewReader(">f+++++++++ share/docs/a.txt\n"), Options{Format: FormatRsync, StripPrefix: "share"})
+	if err != nil || len(log.Entries) != 1 || log.Entries[0].Path != "docs/a.txt" {
+		t.Errorf("StripPrefix: %+v, %v", log, err)
+	}
+}
+
+func TestParse_Robocopy(t *testing.T) {
+	log, err := Parse(strings.NewReader(robocopyLog), Options{})
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if log.Format != FormatRobocopy || log.Root != `C:\Share\` {
+		t.Errorf("Format = %s, Root = %s", log.Format, log.Root)
+	}
+
+	entries := entriesByPath(log)
+	want := map[string]string{
+		"a.txt":          ActionCopied,
+		"b.txt":          ActionCopied,
+		"Sub/big.bin":    ActionCopied,
+		"Sub/locked.txt": ActionFailed,
+		"Sub/retry.txt":  ActionCopied, // 再試行で成功した
+		"Sub/old.txt":    ActionExtra,
+	}
+	if len(entries) != len(want) {
+		t.Errorf("Entries = %+v", log.Entries)
+	}
+	for path, action := range want {
+		if entries[path].Action != action {
+			t.Errorf("%s: Action = %q, want %q", path, entries[path].Action, action)
+		}
+	}
+	if entries["a.txt"].Size != 12 || entries["Sub/big.bin"].Size != -1 {
+		t.Errorf("Size = %d, %d", entries["a.txt"].Size, entries["Sub/big.bin"].Size)
+	}
+	if entries["Sub/locked.txt"].Error != "Access is denied." {
+		t.Errorf("Error = %q", entries["Sub/locked.txt"].Error)
+	}
+
+	// /UNILOGのログ（UTF-16LE、BOM付き）
+	units := utf16.Encode([]rune(robocopyLog))
+	data := []byte{0xFF, 0xFE}
+	for _, u := range units {
+		data = append(data, byte(u), byte(u>>8))
+	}
+	log, err = Parse(bytes.NewReader(data), Options{Format: FormatRobocopy})
+	if err != nil || len(log.Entries) != len(want) {
+		t.Errorf("UTF-16: %+v, %v", log, err)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	if _, err := Parse(strings.NewReader(rsyncLog), Options{Format: "xcopy"}); err == nil {
+		t.Error("サポートされていない形式でエラーが発生しませんでした")
+	}
+	if _, err := Parse(strings.NewReader("hello\nworld\n"), Options{}); err == nil {
+		t.Error("判定できないログでエラーが発生しませんでした")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	db, err := database.NewSyncDB(filepath.Join(t.TempDir(), "test.db"), database.NormalSync)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	for _, file := range []database.FileInfo{
+		{Path: "a.txt", Status: database.StatusSuccess, LastSyncTime: now},
+		{Path: "b.txt", Status: database.StatusFailed, LastError: "access denied", LastSyncTime: now},
+		{Path: "Sub/locked.txt", Status: database.StatusVerified, LastSyncTime: now},
+		{Path: "Sub/retry.txt", Status: database.StatusDeleted, LastSyncTime: now},
+	} {
+		if err := db.AddFile(file); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	log := &Log{Format: FormatRobocopy, Entries: []Entry{
+		{Path: "a.txt", Action: ActionCopied},
+		{Path: "b.txt", Action: ActionCopied},
+		{Path: "c.txt", Action: ActionSame},
+		{Path: "d.tmp", Action: ActionCopied},
+		{Path: "Sub/failed.txt", Action: ActionFailed, Error: "Access is denied."},
+		{Path: "Sub/locked.txt", Action: ActionFailed},
+		{Path: "Sub/old.txt", Action: ActionExtra},
+		{Path: "Sub/retry.txt", Action: ActionCopied},
+	}}
+	result, err := Compare(db, log, CompareOptions{Filter: filter.NewFilter("", "*.tmp")})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+
+	kinds := make(map[string]string)
+	for _, d := range result.Entries {
+		kinds[d.Path] = d.Kind
+	}
+	want := map[string]string{
+		"b.txt":          KindFailed,
+		"c.txt":          KindMissing,
+		"Sub/failed.txt": KindPrevFailed,
+		"Sub/retry.txt":  KindRemoved,
+	}
+	if len(kinds) != len(want) {
+		t.Errorf("Entries = %+v", result.Entries)
+	}
+	for path, kind := range want {
+		if kinds[path] != kind {
+			t.Errorf("%s: Kind = %q, want %q", path, kinds[path], kind)
+		}
+	}
+	if result.Files != 6 || result.Matched != 2 || result.Extras != 1 || result.Excluded != 1 {
+		t.Errorf("Files = %d, Matched = %d, Extras = %d, Excluded = %d", result.Files, result.Matched, result.Extras, result.Excluded)
+	}
+	if !result.HasDiscrepancies() {
+		t.Error("HasDiscrepancies() = false")
+	}
+
+	// 大文字・小文字を区別しない
+	log = &Log{Entries: []Entry{{Path: "A.TXT", Action: ActionCopied}}}
+	if result, _ := Compare(db, log, CompareOptions{IgnoreCase: true}); result.HasDiscrepancies() {
+		t.Errorf("IgnoreCase: %+v", result.Entries)
+	}
+
+	var buf bytes.Buffer
+	log = &Log{Entries: []Entry{{Path: "b.txt", Action: ActionCopied}, {Path: "c.txt", Action: ActionCopied}, {Path: "Sub/retry.txt", Action: ActionCopied}}}
+	result, _ = Compare(db, log, CompareOptions{})
+	if err := WriteList(&buf, result); err != nil || buf.String() != "b.txt\nc.txt\n" {
+		t.Errorf("WriteList() = %q, %v", buf.String(), err)
+	}
+
+	buf.Reset()
+	if err := WriteJSON(&buf, result); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Result
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || decoded.Counts[KindMissing] != 1 {
+		t.Errorf("WriteJSON() = %s, %v", buf.String(), err)
+	}
+
+	buf.Reset()
+	WriteText(&buf, result)
+	if !strings.Contains(buf.String(), "failed      b.txt (DB: failed): access denied") {
+		t.Errorf("WriteText() = %s", buf.String())
+	}
+}
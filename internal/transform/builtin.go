@@ -0,0 +1,179 @@
+package transform
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image/jpeg"
+	"io"
+	"strconv"
+)
+
+func init() {
+	Register("lf", func(arg string) (Transformer, error) {
+		return lineEnding{crlf: false}, noArg("lf", arg)
+	})
+	Register("crlf", func(arg string) (Transformer, error) {
+		return lineEnding{crlf: true}, noArg("crlf", arg)
+	})
+	Register("strip-exif", func(arg string) (Transformer, error) {
+		return stripEXIF{}, noArg("strip-exif", arg)
+	})
+	Register("jpeg-recompress", func(arg string) (Transformer, error) {
+		quality := jpeg.DefaultQuality
+		if arg != "" {
+			q, err := strconv.Atoi(arg)
+			if err != nil || q < 1 || q > 100 {
+				return nil, fmt.Errorf("jpeg-recompressの品質は1〜100で指定してください: %s", arg)
+			}
+			quality = q
+		}
+		return jpegRecompress{quality: quality}, nil
+	})
+}
+
+// noArg は引数を取らない変換に引数が指定された場合のエラーを返す
+func noArg(name, arg string) error {
+	if arg != "" {
+		return fmt.Errorf("%sは引数を取りません: %s", name, arg)
+	}
+	return nil
+}
+
+// lineEnding は改行コードをLFまたはCRLFに統一する変換
+// 単独のCRは改行として扱わずそのまま残す
+type lineEnding struct {
+	crlf bool
+}
+
+func (l lineEnding) Name() string {
+	if l.crlf {
+		return "crlf"
+	}
+	return "lf"
+}
+
+func (l lineEnding) Transform(dst io.Writer, src io.Reader) error {
+	r := bufio.NewReader(src)
+	w := bufio.NewWriter(dst)
+	pendingCR := false
+	for {
+		b, err := r.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if pendingCR {
+			pendingCR = false
+			if b != '\n' {
+				w.WriteByte('\r')
+			}
+		}
+		switch b {
+		case '\r':
+			pendingCR = true
+			continue
+		case '\n':
+			if l.crlf {
+				w.WriteByte('\r')
+			}
+		}
+		w.WriteByte(b)
+	}
+	if pendingCR {
+		w.WriteByte('\r')
+	}
+	return w.Flush()
+}
+
+// stripEXIF はJPEGからEXIF（APP1セグメント）を取り除く変換
+// JPEGでない内容はそのまま出力する
+type stripEXIF struct{}
+
+func (stripEXIF) Name() string { return "strip-exif" }
+
+// exifHeader はEXIFを含むAPP1セグメントの識別子
+var exifHeader = []byte("Exif\x00\x00")
+
+func (stripEXIF) Transform(dst io.Writer, src io.Reader) error {
+	r := bufio.NewReader(src)
+	soi, err := r.Peek(2)
+	if err != nil || soi[0] != 0xFF || soi[1] != 0xD8 {
+		_, err := io.Copy(dst, r)
+		return err
+	}
+	r.Discard(2)
+	if _, err := dst.Write([]byte{0xFF, 0xD8}); err != nil {
+		return err
+	}
+
+	for {
+		var marker [2]byte
+		if _, err := io.ReadFull(r, marker[:]); err != nil {
+			return fmt.Errorf("JPEGのマーカーを読み込めません: %w", err)
+		}
+		if marker[0] != 0xFF {
+			return fmt.Errorf("JPEGのマーカーが不正です: 0x%02x%02x", marker[0], marker[1])
+		}
+
+		// 長さを持たないマーカー
+		if marker[1] == 0xD9 || (marker[1] >= 0xD0 && marker[1] <= 0xD7) || marker[1] == 0x01 {
+			if _, err := dst.Write(marker[:]); err != nil {
+				return err
+			}
+			if marker[1] == 0xD9 {
+				_, err := io.Copy(dst, r)
+				return err
+			}
+			continue
+		}
+
+		var length [2]byte
+		if _, err := io.ReadFull(r, length[:]); err != nil {
+			return fmt.Errorf("JPEGのセグメント長を読み込めません: %w", err)
+		}
+		size := int(binary.BigEndian.Uint16(length[:]))
+		if size < 2 {
+			return fmt.Errorf("JPEGのセグメント長が不正です: %d", size)
+		}
+		payload := make([]byte, size-2)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return fmt.Errorf("JPEGのセグメントを読み込めません: %w", err)
+		}
+
+		if marker[1] == 0xE1 && bytes.HasPrefix(payload, exifHeader) {
+			continue
+		}
+		for _, chunk := range [][]byte{marker[:], length[:], payload} {
+			if _, err := dst.Write(chunk); err != nil {
+				return err
+			}
+		}
+
+		// SOS以降は画像データなのでそのまま出力する
+		if marker[1] == 0xDA {
+			_, err := io.Copy(dst, r)
+			return err
+		}
+	}
+}
+
+// jpegRecompress はJPEGを指定した品質で再圧縮する変換
+// 再エンコードによりEXIFなどのメタデータは失われる
+type jpegRecompress struct {
+	quality int
+}
+
+func (j jpegRecompress) Name() string { return "jpeg-recompress:" + strconv.Itoa(j.quality) }
+
+func (j jpegRecompress) Transform(dst io.Writer, src io.Reader) error {
+	img, err := jpeg.Decode(src)
+	if err != nil {
+		return fmt.Errorf("JPEGのデコードに失敗: %w", err)
+	}
+	return jpeg.Encode(dst, img, &jpeg.Options{Quality: j.quality})
+}
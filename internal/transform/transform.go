@@ -0,0 +1,244 @@
+// Package transform はコピー時にファイルの内容を変換するパイプラインを提供する
+package transform
+
+import (
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SniffLen はMIMEタイプの判定に使用する先頭のバイト数
+const SniffLen = 512
+
+// Transformer はファイルの内容を変換するインターフェース
+// 同じ入力に対して常に同じ出力を返す必要がある（検証時に変換をやり直して比較するため）
+type Transformer interface {
+	// Name は変換の名前（引数を含む）を返す
+	Name() string
+	// Transform はsrcの内容を変換してdstに書き込む
+	Transform(dst io.Writer, src io.Reader) error
+}
+
+// Factory は引数（"名前:引数"の引数部分、省略時は空）から変換を作成する関数
+type Factory func(arg string) (Transformer, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register は変換を名前で登録する。同じ名前で登録した場合は上書きする
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[strings.ToLower(name)] = factory
+}
+
+// Names は登録されている変換の名前を返す
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New は"名前"または"名前:引数"の形式の指定から変換を作成する
+func New(spec string) (Transformer, error) {
+	name, arg, _ := strings.Cut(strings.TrimSpace(spec), ":")
+	registryMu.RLock()
+	factory, ok := registry[strings.ToLower(name)]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("未知の変換: %s (使用可能: %s)", name, strings.Join(Names(), ", "))
+	}
+	return factory(arg)
+}
+
+// rule は対象（拡張子またはMIMEタイプ）と適用する変換の組
+type rule struct {
+	matchers     []string
+	transformers []Transformer
+}
+
+// Pipeline は拡張子・MIMEタイプごとの変換の規則を表す構造体
+type Pipeline struct {
+	rules []rule
+}
+
+// Parse は変換の規則を解析する
+// 書式は "対象,...=変換+変換;..." で、対象は ".jpg" のような拡張子か "image/jpeg"、"text/*" のようなMIMEタイプ
+// 例: ".jpg,.jpeg=strip-exif;text/*=lf"
+// 1つのファイルには最初に一致した規則の変換を順に適用する。空の場合はnilを返す
+func Parse(spec string) (*Pipeline, error) {
+	var p Pipeline
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		targets, chain, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("変換の規則に'='がありません: %s", part)
+		}
+
+		var r rule
+		for _, m := range strings.Split(targets, ",") {
+			m = strings.ToLower(strings.TrimSpace(m))
+			if m == "" {
+				continue
+			}
+			if !strings.HasPrefix(m, ".") && !strings.Contains(m, "/") {
+				return nil, fmt.Errorf("変換の対象は拡張子（.jpg）またはMIMEタイプ（image/jpeg）で指定してください: %s", m)
+			}
+			r.matchers = append(r.matchers, m)
+		}
+		for _, name := range strings.Split(chain, "+") {
+			if strings.TrimSpace(name) == "" {
+				continue
+			}
+			t, err := New(name)
+			if err != nil {
+				return nil, err
+			}
+			r.transformers = append(r.transformers, t)
+		}
+		if len(r.matchers) == 0 || len(r.transformers) == 0 {
+			return nil, fmt.Errorf("変換の規則が不完全です: %s", part)
+		}
+		p.rules = append(p.rules, r)
+	}
+
+	if len(p.rules) == 0 {
+		return nil, nil
+	}
+	return &p, nil
+}
+
+// NeedsContent はMIMEタイプによる規則があり、判定にファイルの先頭の内容が必要かどうかを返す
+func (p *Pipeline) NeedsContent() bool {
+	if p == nil {
+		return false
+	}
+	for _, r := range p.rules {
+		for _, m := range r.matchers {
+			if strings.Contains(m, "/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Select はファイル名と先頭の内容（SniffLenバイトまで、不要な場合はnil）から適用する変換を返す
+func (p *Pipeline) Select(name string, head []byte) []Transformer {
+	if p == nil {
+		return nil
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	var mimeType string
+	for _, r := range p.rules {
+		for _, m := range r.matchers {
+			if strings.HasPrefix(m, ".") {
+				if m == ext {
+					return r.transformers
+				}
+				continue
+			}
+			if mimeType == "" {
+				mimeType = DetectMIME(name, head)
+			}
+			if matchMIME(m, mimeType) {
+				return r.transformers
+			}
+		}
+	}
+	return nil
+}
+
+// DetectMIME は内容からMIMEタイプを判定する。内容から判定できない場合は拡張子から判定する
+func DetectMIME(name string, head []byte) string {
+	detected := "application/octet-stream"
+	if len(head) > 0 {
+		detected = http.DetectContentType(head)
+	}
+	if detected == "application/octet-stream" {
+		if byExt := mime.TypeByExtension(filepath.Ext(name)); byExt != "" {
+			detected = byExt
+		}
+	}
+	mediaType, _, _ := strings.Cut(detected, ";")
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
+
+// matchMIME はMIMEタイプがパターン（"image/*" のようなワイルドカードを含む）に一致するかどうかを返す
+func matchMIME(pattern, mimeType string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return strings.HasPrefix(mimeType, prefix+"/")
+	}
+	return pattern == mimeType
+}
+
+// NameList は変換の名前の一覧を返す
+func NameList(transformers []Transformer) []string {
+	names := make([]string, len(transformers))
+	for i, t := range transformers {
+		names[i] = t.Name()
+	}
+	return names
+}
+
+// NewReader はsrcに変換を順に適用した内容を読み込むReaderを返す
+// 変換ごとにゴルーチンで処理するため、読み終えたら（途中でやめる場合も）必ずCloseすること
+func NewReader(src io.Reader, transformers []Transformer) io.ReadCloser {
+	var closers []*io.PipeReader
+	current := src
+	for _, t := range transformers {
+		pr, pw := io.Pipe()
+		go func(t Transformer, in io.Reader) {
+			err := t.Transform(pw, in)
+			if err == nil {
+				// 変換が入力を最後まで読まない場合も、上流（ハッシュ計算など）が全体を読めるように読み捨てる
+				_, err = io.Copy(io.Discard, in)
+			}
+			if err != nil {
+				err = fmt.Errorf("変換(%s)エラー: %w", t.Name(), err)
+			}
+			pw.CloseWithError(err)
+		}(t, current)
+		closers = append(closers, pr)
+		current = pr
+	}
+	return &chainReader{Reader: current, pipes: closers}
+}
+
+// chainReader は変換の連鎖の出力を読み込むReader
+type chainReader struct {
+	io.Reader
+	pipes []*io.PipeReader
+}
+
+// Close はすべての変換のパイプを閉じ、処理中のゴルーチンを終了させる
+func (c *chainReader) Close() error {
+	for _, pr := range c.pipes {
+		pr.Close()
+	}
+	return nil
+}
+
+// Digest はsrcに変換を適用した内容を読み込み、変換前の内容をoriginalに、変換後の内容をoutputに書き込む
+// 戻り値は変換後のサイズ
+func Digest(src io.Reader, transformers []Transformer, original, output hash.Hash) (int64, error) {
+	reader := NewReader(io.TeeReader(src, original), transformers)
+	defer reader.Close()
+	return io.Copy(output, reader)
+}
@@ -0,0 +1,146 @@
+package transform
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	"image/jpeg"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	p, err := Parse(".jpg,.JPEG=strip-exif; text/*=lf")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !p.NeedsContent() {
+		t.Error("MIMEタイプの規則があるのにNeedsContent()がfalseです")
+	}
+
+	tests := []struct {
+		name string
+		head []byte
+		want []string
+	}{
+		{"photo.JPG", nil, []string{"strip-exif"}},
+		{"photo.jpeg", nil, []string{"strip-exif"}},
+		{"notes.md", []byte("hello\r\nworld\r\n"), []string{"lf"}},
+		{"data.bin", []byte{0x00, 0x01, 0x02, 0xff}, []string{}},
+	}
+	for _, tt := range tests {
+		got := NameList(p.Select(tt.name, tt.head))
+		if strings.Join(got, "+") != strings.Join(tt.want, "+") {
+			t.Errorf("Select(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if p, err := Parse(""); err != nil || p != nil {
+		t.Errorf("Parse(\"\") = %v, %v; want nil, nil", p, err)
+	}
+	for _, spec := range []string{".txt", ".txt=unknown", "txt=lf", ".txt=", ".jpg=jpeg-recompress:0", ".txt=lf:1"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) がエラーになりません", spec)
+		}
+	}
+}
+
+func TestLineEnding(t *testing.T) {
+	tests := []struct {
+		spec, in, want string
+	}{
+		{"lf", "a\r\nb\nc\rd\r\n", "a\nb\nc\rd\n"},
+		{"crlf", "a\r\nb\nc\r", "a\r\nb\r\nc\r"},
+	}
+	for _, tt := range tests {
+		tr, err := New(tt.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		if err := tr.Transform(&out, strings.NewReader(tt.in)); err != nil {
+			t.Fatalf("%s: Transform() error = %v", tt.spec, err)
+		}
+		if out.String() != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.spec, out.String(), tt.want)
+		}
+	}
+}
+
+// jpegWithEXIF はEXIFのAPP1セグメントを含むJPEGを作成する
+func jpegWithEXIF(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	payload := append([]byte("Exif\x00\x00"), []byte("GPS data")...)
+	segment := append([]byte{0xFF, 0xE1, 0x00, byte(len(payload) + 2)}, payload...)
+
+	var out []byte
+	out = append(out, data[:2]...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+func TestStripEXIF(t *testing.T) {
+	src := jpegWithEXIF(t)
+	tr, _ := New("strip-exif")
+
+	var out bytes.Buffer
+	if err := tr.Transform(&out, bytes.NewReader(src)); err != nil {
+		t.Fatalf("Transform() error = %v", err)
+	}
+	if bytes.Contains(out.Bytes(), []byte("GPS data")) {
+		t.Error("EXIFが取り除かれていません")
+	}
+	if out.Len() != len(src)-14-4 {
+		t.Errorf("出力サイズ = %d, want %d", out.Len(), len(src)-18)
+	}
+	if _, err := jpeg.Decode(&out); err != nil {
+		t.Errorf("変換後のJPEGをデコードできません: %v", err)
+	}
+
+	// JPEGでない内容はそのまま出力する
+	out.Reset()
+	if err := tr.Transform(&out, strings.NewReader("plain")); err != nil || out.String() != "plain" {
+		t.Errorf("JPEG以外の内容 = %q, %v", out.String(), err)
+	}
+}
+
+func TestDigest(t *testing.T) {
+	lf, _ := New("lf")
+	crlf, _ := New("crlf")
+	in := "a\r\nb\n"
+
+	original, output := sha256.New(), sha256.New()
+	size, err := Digest(strings.NewReader(in), []Transformer{lf, crlf}, original, output)
+	if err != nil {
+		t.Fatalf("Digest() error = %v", err)
+	}
+	if size != 6 {
+		t.Errorf("サイズ = %d, want 6", size)
+	}
+	sum := func(s string) string {
+		h := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(h[:])
+	}
+	if got := hex.EncodeToString(original.Sum(nil)); got != sum(in) {
+		t.Errorf("変換前のハッシュ = %s, want %s", got, sum(in))
+	}
+	if got := hex.EncodeToString(output.Sum(nil)); got != sum("a\r\nb\r\n") {
+		t.Errorf("変換後のハッシュ = %s, want %s", got, sum("a\r\nb\r\n"))
+	}
+}
+
+func TestNewReader_Error(t *testing.T) {
+	tr, _ := New("jpeg-recompress:80")
+	reader := NewReader(strings.NewReader("not a jpeg"), []Transformer{tr})
+	defer reader.Close()
+	if _, err := io.ReadAll(reader); err == nil || !strings.Contains(err.Error(), "jpeg-recompress:80") {
+		t.Errorf("変換のエラーが伝わりません: %v", err)
+	}
+}
@@ -0,0 +1,295 @@
+// Package treediff はコピーの関係にない任意の2つのディレクトリツリーを比較する
+// ファイルの比較には検証処理（verifier）を使用し、同期DBは使用しない。
+// どちらか一方にのみ存在するパス、内容が異なるファイル、内容は同じでメタデータ（更新日時・パーミッション）が異なるファイルを報告する
+package treediff
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/filter"
+	"github.com/sakuhanight/gopier/internal/pathkey"
+	"github.com/sakuhanight/gopier/internal/verifier"
+)
+
+// 差分の種類
+const (
+	KindOnlyInA  = "only_in_a" // Aにのみ存在する
+	KindOnlyInB  = "only_in_b" // Bにのみ存在する
+	KindContent  = "content"   // 内容が異なる
+	KindMetadata = "metadata"  // 内容は同じで、更新日時またはパーミッションが異なる
+	KindError    = "error"     // ファイルを読み込めない
+)
+
+// Entry はパスごとの比較結果を表す構造体
+type Entry struct {
+	Path  string `json:"path"`
+	Kind  string `json:"kind"`
+	Dir   bool   `json:"dir,omitempty"` // ディレクトリ全体が一方にのみ存在する
+	SizeA int64  `json:"size_a,omitempty"`
+	SizeB int64  `json:"size_b,omitempty"`
+	HashA string `json:"hash_a,omitempty"`
+	HashB string `json:"hash_b,omitempty"`
+	TimeA string `json:"time_a,omitempty"` // 更新日時（RFC 3339）
+	TimeB string `json:"time_b,omitempty"`
+	ModeA string `json:"mode_a,omitempty"`
+	ModeB string `json:"mode_b,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// Result は比較結果全体を表す構造体
+type Result struct {
+	A        string         `json:"a"`
+	B        string         `json:"b"`
+	Compared int            `json:"compared"` // 両方に存在し、内容を比較したファイル数
+	Counts   map[string]int `json:"counts"`   // 差分の種類ごとの件数
+	Entries  []Entry        `json:"entries"`
+}
+
+// HasDifferences は差分またはエラーがあるかどうかを返す
+func (r *Result) HasDifferences() bool {
+	return len(r.Entries) > 0
+}
+
+// add は差分を結果に追加する
+func (r *Result) add(entry Entry) {
+	r.Entries = append(r.Entries, entry)
+	r.Counts[entry.Kind]++
+}
+
+// Options は比較のオプションを表す構造体
+type Options struct {
+	HashAlgorithm string         // ハッシュアルゴリズム
+	BufferSize    int            // ハッシュ計算のバッファサイズ（0以下はデフォルト）
+	Workers       int            // 並行して比較するファイル数（0以下はデフォルト）
+	Filter        *filter.Filter // 比較するファイルのフィルタ
+	IgnoreMeta    bool           // 更新日時とパーミッションを比較しない
+	ModifyWindow  time.Duration  // 更新日時の差をこの範囲まで同じとみなす（FATやSMBなど精度の低いファイルシステム向け）
+}
+
+// Compare はAとBのディレクトリツリーを比較する
+// どちらもソース・宛先として扱わないため、双方の余分なファイルは変更・削除しない
+func Compare(a, b string, opts Options) (*Result, error) {
+	absA, err := absDir(a)
+	if err != nil {
+		return nil, err
+	}
+	absB, err := absDir(b)
+	if err != nil {
+		return nil, err
+	}
+
+	vopts := verifier.DefaultOptions()
+	if opts.HashAlgorithm != "" {
+		vopts.HashAlgorithm = opts.HashAlgorithm
+	}
+	if opts.BufferSize > 0 {
+		vopts.BufferSize = opts.BufferSize
+	}
+	if opts.Workers > 0 {
+		vopts.MaxConcurrent = opts.Workers
+	}
+	vopts.ExtrasAction = verifier.ExtrasReport
+
+	v := verifier.NewVerifier(absA, absB, vopts, opts.Filter, nil)
+	if err := v.Verify(); err != nil && !errors.Is(err, errcode.ErrVerifyFailed) {
+		return nil, err
+	}
+
+	result := &Result{A: a, B: b, Counts: map[string]int{}, Entries: []Entry{}}
+	for _, r := range v.GetResults() {
+		if r.SourceExists && r.DestExists {
+			result.Compared++
+		}
+		if entry, ok := classify(absA, absB, r, opts); ok {
+			result.add(entry)
+		}
+	}
+	sort.Slice(result.Entries, func(i, j int) bool { return result.Entries[i].Path < result.Entries[j].Path })
+	return result, nil
+}
+
+// absDir はディレクトリであることを確認して絶対パスを返す
+// 検証処理はディレクトリの欠落と余分なファイルを結合したパスで報告するため、相対パスに戻せるよう絶対パスで比較する
+func absDir(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("ディレクトリにアクセスできません: %w", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("ディレクトリを指定してください: %s", path)
+	}
+	return filepath.Abs(path)
+}
+
+// classify は検証結果を差分に変換する。差分がない場合はfalseを返す
+func classify(a, b string, r verifier.VerificationResult, opts Options) (Entry, bool) {
+	entry := Entry{Path: r.Path, SizeA: r.SourceSize, SizeB: r.DestSize, HashA: r.SourceHash, HashB: r.DestHash}
+
+	switch {
+	case !r.SourceExists && r.DestExists:
+		// Bにのみ存在するパスはBの中のパスで報告される
+		entry.Kind = KindOnlyInB
+		entry.Path = relTo(b, r.Path)
+		entry.Dir = isDir(r.Path)
+		entry.TimeB = formatTime(r.DestTime)
+		return entry, true
+	case r.SourceExists && !r.DestExists:
+		// ディレクトリの欠落はBの中のパスで報告される
+		entry.Kind = KindOnlyInA
+		if filepath.IsAbs(r.Path) {
+			entry.Path = relTo(b, r.Path)
+			entry.Dir = true
+		}
+		entry.TimeA = formatTime(r.SourceTime)
+		return entry, true
+	case !r.SourceExists:
+		entry.Kind = KindError
+		entry.Path = relTo(a, r.Path)
+		if r.Error != nil {
+			entry.Error = r.Error.Error()
+		}
+		return entry, true
+	}
+
+	if !r.HashMatch {
+		entry.Kind = KindError
+		if errors.Is(r.Error, errcode.ErrSizeMismatch) || errors.Is(r.Error, errcode.ErrHashMismatch) || errors.Is(r.Error, errcode.ErrLinkMismatch) {
+			entry.Kind = KindContent
+		} else if r.Error != nil {
+			entry.Error = r.Error.Error()
+		}
+		return entry, true
+	}
+
+	if opts.IgnoreMeta {
+		return entry, false
+	}
+	native := pathkey.ToNative(r.Path)
+	infoA, errA := os.Stat(filepath.Join(a, native))
+	infoB, errB := os.Stat(filepath.Join(b, native))
+	if errA != nil || errB != nil {
+		// 比較中に削除された
+		return entry, false
+	}
+	timeDiff := infoA.ModTime().Sub(infoB.ModTime())
+	if timeDiff < 0 {
+		timeDiff = -timeDiff
+	}
+	if timeDiff <= opts.ModifyWindow && infoA.Mode().Perm() == infoB.Mode().Perm() {
+		return entry, false
+	}
+	entry.Kind = KindMetadata
+	entry.TimeA, entry.TimeB = formatTime(infoA.ModTime()), formatTime(infoB.ModTime())
+	entry.ModeA, entry.ModeB = infoA.Mode().Perm().String(), infoB.Mode().Perm().String()
+	return entry, true
+}
+
+// relTo は検証処理が報告した絶対パスを比較するディレクトリからの相対パスに変換する
+func relTo(root, path string) string {
+	if !filepath.IsAbs(path) {
+		return path
+	}
+	if rel, err := pathkey.Rel(root, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+// isDir はパスがディレクトリかどうかを返す
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// WriteJSON は比較結果をJSONで書き出す
+func WriteJSON(w io.Writer, result *Result) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+// WriteCSV は比較結果をCSVで書き出す（1行に1つのパス）
+func WriteCSV(w io.Writer, result *Result) error {
+	writer := csv.NewWriter(w)
+	header := []string{"path", "kind", "dir", "size_a", "size_b", "hash_a", "hash_b", "time_a", "time_b", "mode_a", "mode_b", "error"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, e := range result.Entries {
+		row := []string{e.Path, e.Kind, fmt.Sprint(e.Dir), fmt.Sprint(e.SizeA), fmt.Sprint(e.SizeB), e.HashA, e.HashB,
+			e.TimeA, e.TimeB, e.ModeA, e.ModeB, e.Error}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// formatTime は出力する時刻を整形する（ゼロ値は空欄）
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// kindLabels は差分の種類ごとの説明（集計の表示順）
+var kindLabels = []struct {
+	kind, label, mark string
+}{
+	{KindOnlyInA, "Aのみ", "-"},
+	{KindOnlyInB, "Bのみ", "+"},
+	{KindContent, "内容の差分", "M"},
+	{KindMetadata, "メタデータの差分", "T"},
+	{KindError, "エラー", "!"},
+}
+
+// WriteText は比較結果を1行に1つのパスで書き出し、最後に集計を書き出す
+// 行頭の記号は「-」がAのみ、「+」がBのみ、「M」が内容の差分、「T」がメタデータの差分、「!」がエラー
+func WriteText(w io.Writer, result *Result) error {
+	for _, e := range result.Entries {
+		for _, k := range kindLabels {
+			if k.kind != e.Kind {
+				continue
+			}
+			path := e.Path
+			if e.Dir {
+				path += "/"
+			}
+			switch e.Kind {
+			case KindContent:
+				fmt.Fprintf(w, "%s %s (サイズ A: %d, B: %d)\n", k.mark, path, e.SizeA, e.SizeB)
+			case KindMetadata:
+				fmt.Fprintf(w, "%s %s (更新日時 A: %s, B: %s, パーミッション A: %s, B: %s)\n", k.mark, path,
+					e.TimeA, e.TimeB, e.ModeA, e.ModeB)
+			case KindError:
+				fmt.Fprintf(w, "%s %s: %s\n", k.mark, path, e.Error)
+			default:
+				fmt.Fprintf(w, "%s %s\n", k.mark, path)
+			}
+		}
+	}
+	return WriteSummary(w, result)
+}
+
+// WriteSummary は比較結果の集計のみを書き出す
+func WriteSummary(w io.Writer, result *Result) error {
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "比較: %d件", result.Compared)
+	for _, k := range kindLabels {
+		fmt.Fprintf(&summary, ", %s: %d件", k.label, result.Counts[k.kind])
+	}
+	_, err := fmt.Fprintln(w, summary.String())
+	return err
+}
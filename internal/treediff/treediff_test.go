@@ -0,0 +1,147 @@
+package treediff
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/filter"
+)
+
+func setupTrees(t *testing.T) (string, string) {
+	t.Helper()
+	base := t.TempDir()
+	a := filepath.Join(base, "a")
+	b := filepath.Join(base, "b")
+	mtime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, dir := range []string{a, b} {
+		os.MkdirAll(filepath.Join(dir, "sub"), 0755)
+		os.WriteFile(filepath.Join(dir, "same.txt"), []byte("same"), 0644)
+		os.WriteFile(filepath.Join(dir, "touched.txt"), []byte("same"), 0644)
+		os.Chtimes(filepath.Join(dir, "same.txt"), mtime, mtime)
+	}
+	os.WriteFile(filepath.Join(a, "sub", "edited.txt"), []byte("before"), 0644)
+	os.WriteFile(filepath.Join(b, "sub", "edited.txt"), []byte("after!"), 0644)
+	os.WriteFile(filepath.Join(a, "sub", "resized.txt"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(b, "sub", "resized.txt"), []byte("xyz"), 0644)
+	os.Chtimes(filepath.Join(a, "touched.txt"), mtime, mtime)
+	os.Chtimes(filepath.Join(b, "touched.txt"), mtime.Add(time.Hour), mtime.Add(time.Hour))
+	os.WriteFile(filepath.Join(a, "only-a.txt"), []byte("a"), 0644)
+	os.MkdirAll(filepath.Join(a, "dir-a", "nested"), 0755)
+	os.WriteFile(filepath.Join(a, "dir-a", "nested", "file.txt"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(b, "sub", "only-b.txt"), []byte("b"), 0644)
+	os.MkdirAll(filepath.Join(b, "dir-b"), 0755)
+	os.WriteFile(filepath.Join(a, "skip.tmp"), []byte("a"), 0644)
+	return a, b
+}
+
+func TestCompare(t *testing.T) {
+	a, b := setupTrees(t)
+
+	result, err := Compare(a, b, Options{Filter: filter.NewFilter("", "*.tmp")})
+	if err != nil {
+		t.Fatalf("Compareが失敗しました: %v", err)
+	}
+
+	want := map[string]string{
+		"dir-a":           KindOnlyInA,
+		"dir-b":           KindOnlyInB,
+		"only-a.txt":      KindOnlyInA,
+		"sub/edited.txt":  KindContent,
+		"sub/only-b.txt":  KindOnlyInB,
+		"sub/resized.txt": KindContent,
+		"touched.txt":     KindMetadata,
+	}
+	if len(result.Entries) != len(want) {
+		t.Fatalf("差分 = %+v", result.Entries)
+	}
+	for _, e := range result.Entries {
+		if want[e.Path] != e.Kind {
+			t.Errorf("%s: 種類 = %s, want %s", e.Path, e.Kind, want[e.Path])
+		}
+		if (e.Path == "dir-a" || e.Path == "dir-b") != e.Dir {
+			t.Errorf("%s: Dir = %v", e.Path, e.Dir)
+		}
+	}
+	// 両方に存在するファイル: same, touched, edited, resized
+	if result.Compared != 4 || result.Counts[KindContent] != 2 {
+		t.Errorf("比較: %d件, 件数 = %v", result.Compared, result.Counts)
+	}
+
+	// 比較したディレクトリは変更しない
+	if _, err := os.Stat(filepath.Join(b, "dir-b")); err != nil {
+		t.Errorf("Bのみのディレクトリが変更されました: %v", err)
+	}
+
+	// メタデータを比較しない場合と、更新日時の差を許容する場合
+	for _, opts := range []Options{{IgnoreMeta: true}, {ModifyWindow: 2 * time.Hour}} {
+		result, err := Compare(a, b, opts)
+		if err != nil {
+			t.Fatalf("Compareが失敗しました: %v", err)
+		}
+		if result.Counts[KindMetadata] != 0 {
+			t.Errorf("%+v: メタデータの差分 = %d件", opts, result.Counts[KindMetadata])
+		}
+	}
+}
+
+func TestCompare_NotDirectory(t *testing.T) {
+	a, b := setupTrees(t)
+	if _, err := Compare(filepath.Join(a, "same.txt"), b, Options{}); err == nil {
+		t.Error("ファイルを指定した場合はエラーになるべきです")
+	}
+	if _, err := Compare(a, filepath.Join(b, "missing"), Options{}); err == nil {
+		t.Error("存在しないディレクトリを指定した場合はエラーになるべきです")
+	}
+}
+
+func TestWriters(t *testing.T) {
+	result := &Result{
+		A:        "a",
+		B:        "b",
+		Compared: 2,
+		Counts:   map[string]int{KindOnlyInA: 1, KindContent: 1},
+		Entries: []Entry{
+			{Path: "docs", Kind: KindOnlyInA, Dir: true},
+			{Path: "x.txt", Kind: KindContent, SizeA: 1, SizeB: 2, HashA: "h1", HashB: "h2"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteText(&buf, result); err != nil {
+		t.Fatal(err)
+	}
+	text := buf.String()
+	if !strings.Contains(text, "- docs/\n") || !strings.Contains(text, "M x.txt (サイズ A: 1, B: 2)") || !strings.Contains(text, "内容の差分: 1件") {
+		t.Errorf("テキスト出力 = %s", text)
+	}
+
+	buf.Reset()
+	if err := WriteSummary(&buf, result); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "x.txt") || !strings.HasPrefix(buf.String(), "比較: 2件, Aのみ: 1件") {
+		t.Errorf("集計の出力 = %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteCSV(&buf, result); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 3 || lines[2] != "x.txt,content,false,1,2,h1,h2,,,,," {
+		t.Errorf("CSV出力 = %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteJSON(&buf, result); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Result
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || decoded.Counts[KindContent] != 1 || !decoded.Entries[0].Dir {
+		t.Errorf("JSON出力 = %s, %v", buf.String(), err)
+	}
+}
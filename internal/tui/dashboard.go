@@ -0,0 +1,292 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/sakuhanight/gopier/internal/stats"
+)
+
+const (
+	// historySize はスループットグラフに表示するサンプル数
+	historySize = 60
+	// shownErrors はエラー欄に表示する件数
+	shownErrors = 5
+	// pathWidth はファイルパスの最大表示幅
+	pathWidth = 60
+)
+
+// sparkBlocks はスループットグラフの描画に使う文字
+var sparkBlocks = []rune(" ▁▂▃▄▅▆▇█")
+
+// Controller はダッシュボードのキー操作で実行中の処理を操作するためのインターフェース
+type Controller interface {
+	Pause()
+	Resume()
+	Cancel()
+	IsPaused() bool
+}
+
+// tickMsg は描画の間隔ごとに送るメッセージ
+type tickMsg time.Time
+
+// stopMsg は処理の終了を知らせ、最終状態を描画して終了するメッセージ
+type stopMsg struct{}
+
+// Dashboard は実行中の処理状況を端末に表示し、キー操作で一時停止・中断できるライブダッシュボード
+// キー操作: q・Ctrl+Cで中断、p・スペースで一時停止・再開、↑↓・k・jで直近のエラーをスクロール、
+// End・Gで最新のエラーの表示に戻る
+type Dashboard struct {
+	stats    *stats.Stats
+	control  Controller
+	in       io.Reader
+	out      io.Writer
+	title    string
+	interval time.Duration
+	workers  int
+
+	start      time.Time
+	history    []float64 // 秒あたりのバイト数
+	lastBytes  int64
+	lastSample time.Time
+
+	errScroll  int  // 最新のエラーから遡った件数（0は最新のエラーを追従して表示する）
+	cancelling bool // 中断を要求した
+
+	program  *tea.Program
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewDashboard は新しいDashboardを作成する
+// controlがnilの場合はキー操作による一時停止・中断を行わない。inがnilの場合はキー操作を受け付けない
+func NewDashboard(st *stats.Stats, control Controller, in io.Reader, out io.Writer, title string, workers int) *Dashboard {
+	return &Dashboard{
+		stats:    st,
+		control:  control,
+		in:       in,
+		out:      out,
+		title:    title,
+		interval: time.Second,
+		workers:  workers,
+		done:     make(chan struct{}),
+	}
+}
+
+// Start は描画とキー入力の処理を開始する
+func (d *Dashboard) Start() {
+	now := time.Now()
+	d.start = now
+	d.lastSample = now
+
+	// シグナルはコマンドのキャンセル処理に任せ、端末の入力はキー操作として扱う
+	d.program = tea.NewProgram(d, tea.WithInput(d.in), tea.WithOutput(d.out), tea.WithoutSignalHandler())
+	go func() {
+		defer close(d.done)
+		d.program.Run()
+	}()
+}
+
+// Stop は描画を終了し、最終状態を表示して端末を元に戻す
+func (d *Dashboard) Stop() {
+	d.stopOnce.Do(func() {
+		if d.program == nil {
+			return
+		}
+		d.program.Send(stopMsg{})
+		<-d.done
+	})
+}
+
+// Init は最初の描画のタイマーを開始する（tea.Modelの実装）
+func (d *Dashboard) Init() tea.Cmd {
+	return d.tick()
+}
+
+// Update はキー操作とタイマーを処理する（tea.Modelの実装）
+func (d *Dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tickMsg:
+		d.sample(time.Time(msg))
+		return d, d.tick()
+	case stopMsg:
+		d.sample(time.Now())
+		return d, tea.Quit
+	case tea.KeyMsg:
+		d.handleKey(msg)
+	}
+	return d, nil
+}
+
+// View は現在の状態を描画する（tea.Modelの実装）
+func (d *Dashboard) View() string {
+	return d.Render(time.Now())
+}
+
+// tick は次の描画のタイマーを返す
+func (d *Dashboard) tick() tea.Cmd {
+	return tea.Tick(d.interval, func(t time.Time) tea.Msg {
+		return tickMsg(t)
+	})
+}
+
+// handleKey はキー操作を処理する
+func (d *Dashboard) handleKey(key tea.KeyMsg) {
+	switch key.String() {
+	case "q", "ctrl+c":
+		// 処理の終了を待ってから最終状態を表示するため、ダッシュボードはStopまで表示を続ける
+		if d.control != nil && !d.cancelling {
+			d.cancelling = true
+			d.control.Cancel()
+		}
+	case "p", " ":
+		if d.control == nil || d.cancelling {
+			return
+		}
+		if d.control.IsPaused() {
+			d.control.Resume()
+		} else {
+			d.control.Pause()
+		}
+	case "up", "k":
+		if d.errScroll < len(d.stats.GetRecentErrors())-shownErrors {
+			d.errScroll++
+		}
+	case "down", "j":
+		if d.errScroll > 0 {
+			d.errScroll--
+		}
+	case "end", "G":
+		d.errScroll = 0
+	}
+}
+
+// sample はスループットのサンプルを記録する
+func (d *Dashboard) sample(now time.Time) {
+	elapsed := now.Sub(d.lastSample).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+
+	bytes := d.stats.GetCopiedBytes()
+	d.history = append(d.history, float64(bytes-d.lastBytes)/elapsed)
+	if len(d.history) > historySize {
+		d.history = d.history[len(d.history)-historySize:]
+	}
+	d.lastBytes = bytes
+	d.lastSample = now
+}
+
+// Render は現在の状態を描画した文字列を返す
+func (d *Dashboard) Render(now time.Time) string {
+	var b strings.Builder
+	line := func(format string, args ...interface{}) {
+		b.WriteString(fmt.Sprintf(format, args...))
+		b.WriteString("\n")
+	}
+
+	state := ""
+	switch {
+	case d.cancelling:
+		state = "  [中断しています]"
+	case d.control != nil && d.control.IsPaused():
+		state = "  [一時停止中]"
+	}
+	elapsed := now.Sub(d.start).Truncate(time.Second)
+	line("%s  経過時間: %s%s", d.title, elapsed, state)
+	line("")
+
+	copied := d.stats.GetCopiedCount()
+	skipped := d.stats.GetSkippedCount()
+	failed := d.stats.GetFailedCount()
+	line("コピー: %d (%s)  スキップ: %d  失敗: %d  処理待ち: %d (最長 %s)",
+		copied, stats.FormatBytes(d.stats.GetCopiedBytes()), skipped, failed,
+		d.stats.GetQueued(), d.stats.GetOldestQueuedAge(now).Truncate(time.Second))
+
+	var current, average float64
+	if len(d.history) > 0 {
+		current = d.history[len(d.history)-1]
+	}
+	if secs := now.Sub(d.start).Seconds(); secs > 0 {
+		average = float64(d.stats.GetCopiedBytes()) / secs
+	}
+	line("スループット: %s/s (平均 %s/s)", stats.FormatBytes(int64(current)), stats.FormatBytes(int64(average)))
+	line("%s", sparkline(d.history))
+	line("")
+
+	line("ワーカー:")
+	workers := d.stats.GetWorkers()
+	for i := 0; i < d.workers || i < len(workers); i++ {
+		path := ""
+		if i < len(workers) {
+			path = workers[i]
+		}
+		if path == "" {
+			path = "(待機中)"
+		}
+		line("  #%-2d %s", i+1, truncatePath(path, pathWidth))
+	}
+	line("")
+
+	errors := d.stats.GetRecentErrors()
+	end := len(errors) - d.errScroll
+	if end < 0 {
+		end = 0
+	}
+	begin := end - shownErrors
+	if begin < 0 {
+		begin = 0
+	}
+	if d.errScroll > 0 {
+		line("直近のエラー: %d件（%d-%d件目を表示）", failed, begin+1, end)
+	} else {
+		line("直近のエラー: %d件", failed)
+	}
+	for _, e := range errors[begin:end] {
+		line("  %s %s: %s", e.Time.Format("15:04:05"), truncatePath(e.Path, pathWidth), e.Message)
+	}
+
+	if d.control != nil {
+		line("")
+		line("q: 中断  p: 一時停止・再開  ↑↓: エラーのスクロール  End: 最新のエラー")
+	}
+	return b.String()
+}
+
+// sparkline は値の推移を1行のグラフとして描画する
+func sparkline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	var max float64
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+
+	runes := make([]rune, len(values))
+	for i, v := range values {
+		idx := 0
+		if max > 0 {
+			idx = int(v / max * float64(len(sparkBlocks)-1))
+		}
+		runes[i] = sparkBlocks[idx]
+	}
+	return string(runes)
+}
+
+// truncatePath は長いパスを末尾が見えるように省略する
+func truncatePath(path string, width int) string {
+	runes := []rune(path)
+	if len(runes) <= width {
+		return path
+	}
+	return "..." + string(runes[len(runes)-width+3:])
+}
@@ -0,0 +1,155 @@
+package tui
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/sakuhanight/gopier/internal/stats"
+)
+
+func TestDashboardRender(t *testing.T) {
+	st := stats.NewStats()
+	st.IncrementCopied(2048)
+	st.IncrementFailed()
+	st.BeginWork("dir/current.txt")
+	for i := 0; i < 4; i++ {
+		st.Enqueue()
+	}
+	st.RecordError("dir/broken.txt", errors.New("permission denied"))
+
+	d := NewDashboard(st, nil, nil, &bytes.Buffer{}, "gopier", 2)
+	d.start = time.Now().Add(-10 * time.Second)
+	d.history = []float64{0, 512, 1024}
+
+	out := d.Render(time.Now())
+	for _, want := range []string{
+		"コピー: 1 (2.0 KB)",
+		"失敗: 1",
+		"処理待ち: 4 (最長 ",
+		"#1  dir/current.txt",
+		"#2  (待機中)",
+		"dir/broken.txt: permission denied",
+		"1.0 KB/s",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("描画結果に %q が含まれていません:\n%s", want, out)
+		}
+	}
+}
+
+// fakeController はキー操作による一時停止・中断を記録する
+type fakeController struct {
+	paused    bool
+	cancelled bool
+}
+
+func (c *fakeController) Pause()         { c.paused = true }
+func (c *fakeController) Resume()        { c.paused = false }
+func (c *fakeController) Cancel()        { c.cancelled = true }
+func (c *fakeController) IsPaused() bool { return c.paused }
+
+func TestDashboardStartStop(t *testing.T) {
+	var buf bytes.Buffer
+	d := NewDashboard(stats.NewStats(), nil, nil, &buf, "gopier", 1)
+	d.interval = 10 * time.Millisecond
+
+	d.Start()
+	time.Sleep(30 * time.Millisecond)
+	d.Stop()
+	// 二重に停止しても問題ない
+	d.Stop()
+
+	if !strings.Contains(buf.String(), "ワーカー:") {
+		t.Error("ダッシュボードが描画されていません")
+	}
+	if len(d.history) == 0 {
+		t.Error("スループットが記録されていません")
+	}
+}
+
+func TestDashboardKeys(t *testing.T) {
+	st := stats.NewStats()
+	for i := 0; i < 8; i++ {
+		st.RecordError(fmt.Sprintf("file%d.txt", i), errors.New("read error"))
+	}
+	control := &fakeController{}
+	d := NewDashboard(st, control, nil, &bytes.Buffer{}, "gopier", 1)
+	key := func(s string) {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+		switch s {
+		case "up":
+			msg = tea.KeyMsg{Type: tea.KeyUp}
+		case "end":
+			msg = tea.KeyMsg{Type: tea.KeyEnd}
+		case "ctrl+c":
+			msg = tea.KeyMsg{Type: tea.KeyCtrlC}
+		}
+		d.Update(msg)
+	}
+
+	// 一時停止・再開
+	key("p")
+	if !control.paused || !strings.Contains(d.View(), "[一時停止中]") {
+		t.Errorf("一時停止されていません: %+v", control)
+	}
+	key("p")
+	if control.paused {
+		t.Error("再開されていません")
+	}
+
+	// 最新の5件を表示し、上にスクロールすると古いエラーを表示する（表示できる範囲を超えてスクロールしない）
+	if out := d.View(); !strings.Contains(out, "file7.txt") || strings.Contains(out, "file2.txt") {
+		t.Errorf("最新のエラーが表示されていません:\n%s", out)
+	}
+	for i := 0; i < 5; i++ {
+		key("up")
+	}
+	if out := d.View(); !strings.Contains(out, "file0.txt") || strings.Contains(out, "file5.txt") || !strings.Contains(out, "1-5件目") {
+		t.Errorf("スクロールした位置が表示されていません:\n%s", out)
+	}
+	key("j")
+	if out := d.View(); !strings.Contains(out, "file5.txt") || strings.Contains(out, "file0.txt") {
+		t.Errorf("下にスクロールできません:\n%s", out)
+	}
+	key("end")
+	if d.errScroll != 0 {
+		t.Errorf("最新のエラーに戻っていません: %d", d.errScroll)
+	}
+
+	// 中断は処理の終了まで表示を続け、一時停止の操作は受け付けない
+	key("ctrl+c")
+	if !control.cancelled || !strings.Contains(d.View(), "[中断しています]") {
+		t.Errorf("中断されていません: %+v", control)
+	}
+	key("p")
+	if control.paused {
+		t.Error("中断した後に一時停止されました")
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if sparkline(nil) != "" {
+		t.Error("空の入力で空文字列を返すべきです")
+	}
+	if got := sparkline([]float64{0, 4, 8}); got != " ▄█" {
+		t.Errorf("期待値=%q, 実際=%q", " ▄█", got)
+	}
+	if got := sparkline([]float64{0, 0}); got != "  " {
+		t.Errorf("すべて0の場合: 期待値=%q, 実際=%q", "  ", got)
+	}
+}
+
+func TestTruncatePath(t *testing.T) {
+	if got := truncatePath("short.txt", 20); got != "short.txt" {
+		t.Errorf("短いパスが変更されました: %s", got)
+	}
+	if got := truncatePath("a/very/long/path/file.txt", 10); got != "...ile.txt" {
+		t.Errorf("期待値=%q, 実際=%q", "...ile.txt", got)
+	}
+}
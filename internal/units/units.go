@@ -0,0 +1,129 @@
+// Package units はコマンドラインと設定ファイルで指定するサイズ・時間を解析する
+// すべてのコマンドと設定ファイルで同じ形式（例: 64M, 1.5G, 30s, 2h）を受け付ける
+package units
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// サイズの単位（1024倍）
+const (
+	Byte int64 = 1
+	KiB        = 1024 * Byte
+	MiB        = 1024 * KiB
+	GiB        = 1024 * MiB
+	TiB        = 1024 * GiB
+)
+
+// sizeSuffixes はサイズの単位の接頭辞と倍率
+var sizeSuffixes = map[byte]int64{'K': KiB, 'M': MiB, 'G': GiB, 'T': TiB}
+
+// ParseSize はサイズの指定（例: "512K", "64M", "1.5G", "2TiB", "100B"）をバイト数に変換する
+// 単位は1024倍で、大文字・小文字を区別しない。空文字列は0を表す。
+// 単位のない数値はunitの倍数として扱う（以前はMB単位の整数で指定した--bufferなどとの互換性のため）
+func ParseSize(s string, unit int64) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	if value == "" {
+		return 0, nil
+	}
+
+	multiplier := unit
+	switch {
+	case strings.HasSuffix(value, "IB"):
+		value = strings.TrimSuffix(value, "IB")
+		multiplier = 0
+	case strings.HasSuffix(value, "B"):
+		value = strings.TrimSuffix(value, "B")
+		multiplier = Byte
+	}
+	if value != "" {
+		if m, ok := sizeSuffixes[value[len(value)-1]]; ok {
+			value, multiplier = value[:len(value)-1], m
+		}
+	}
+	if multiplier == 0 {
+		return 0, fmt.Errorf("サイズの指定が不正です: %q (例: 512K, 64M, 1.5G)", s)
+	}
+
+	number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, fmt.Errorf("サイズの指定が不正です: %q (例: 512K, 64M, 1.5G)", s)
+	}
+	if number < 0 {
+		return 0, fmt.Errorf("サイズには0以上の値を指定してください: %q", s)
+	}
+	size := number * float64(multiplier)
+	if size >= math.MaxInt64 {
+		return 0, fmt.Errorf("サイズが大きすぎます: %q", s)
+	}
+	return int64(size), nil
+}
+
+// ParseDuration は時間の指定（例: "500ms", "30s", "1.5h", "2h30m", "7d"）を解析する
+// time.ParseDurationの形式に加えて、日（d）を受け付ける。空文字列は0を表す。
+// 単位のない数値はunitの倍数として扱う（以前は秒・ミリ秒の整数で指定した--waitなどとの互換性のため）。
+// unitが0の場合は単位を省略できない
+func ParseDuration(s string, unit time.Duration) (time.Duration, error) {
+	value := strings.TrimSpace(s)
+	if value == "" {
+		return 0, nil
+	}
+
+	if number, err := strconv.ParseFloat(value, 64); err == nil {
+		if unit == 0 && number != 0 {
+			return 0, fmt.Errorf("時間には単位を指定してください: %q (例: 500ms, 30s, 2h)", s)
+		}
+		return scaleDuration(s, number, unit)
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		number, err := strconv.ParseFloat(days, 64)
+		if err != nil {
+			return 0, fmt.Errorf("時間の指定が不正です: %q (例: 500ms, 30s, 2h, 7d)", s)
+		}
+		return scaleDuration(s, number, 24*time.Hour)
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("時間の指定が不正です: %q (例: 500ms, 30s, 2h, 7d)", s)
+	}
+	return d, nil
+}
+
+// scaleDuration は数値に単位を掛けた時間を返す
+func scaleDuration(s string, number float64, unit time.Duration) (time.Duration, error) {
+	d := number * float64(unit)
+	if math.IsNaN(d) || math.Abs(d) >= math.MaxInt64 {
+		return 0, fmt.Errorf("時間の指定が不正です: %q", s)
+	}
+	return time.Duration(d), nil
+}
+
+// DurationValue はParseDurationで解析するフラグの値（pflag.Value）
+type DurationValue struct {
+	p    *time.Duration
+	unit time.Duration
+}
+
+// NewDurationValue はParseDurationの形式で指定するフラグの値を作成する（FlagSet.Varに渡す）
+// pにvalueを設定し、フラグの指定で上書きする
+func NewDurationValue(p *time.Duration, value, unit time.Duration) *DurationValue {
+	*p = value
+	return &DurationValue{p: p, unit: unit}
+}
+
+func (v *DurationValue) Set(s string) error {
+	d, err := ParseDuration(s, v.unit)
+	if err != nil {
+		return err
+	}
+	*v.p = d
+	return nil
+}
+
+func (v *DurationValue) String() string { return v.p.String() }
+
+func (v *DurationValue) Type() string { return "duration" }
@@ -0,0 +1,82 @@
+package units
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input string
+		unit  int64
+		want  int64
+	}{
+		{"", Byte, 0},
+		{"0", Byte, 0},
+		{"512K", Byte, 512 * KiB},
+		{"64m", Byte, 64 * MiB},
+		{"1.5G", Byte, 3 * GiB / 2},
+		{"2TiB", Byte, 2 * TiB},
+		{"64MB", Byte, 64 * MiB},
+		{"100B", MiB, 100},
+		{" 8 ", MiB, 8 * MiB}, // 単位を省略した場合はunitの倍数
+		{"8", Byte, 8},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.input, tt.unit)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseSize(%q, %d) = %d, %v, want %d", tt.input, tt.unit, got, err, tt.want)
+		}
+	}
+
+	for _, input := range []string{"abc", "-1M", "1X", "M", "2iB", "NaN", "99999999T"} {
+		if _, err := ParseSize(input, Byte); err == nil {
+			t.Errorf("ParseSize(%q) はエラーになるべきです", input)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input string
+		unit  time.Duration
+		want  time.Duration
+	}{
+		{"", time.Second, 0},
+		{"500ms", time.Second, 500 * time.Millisecond},
+		{"2h30m", 0, 150 * time.Minute},
+		{"7d", 0, 7 * 24 * time.Hour},
+		{"1.5d", 0, 36 * time.Hour},
+		{"5", time.Second, 5 * time.Second}, // 単位を省略した場合はunitの倍数
+		{"200", time.Millisecond, 200 * time.Millisecond},
+		{"0", 0, 0},
+		{"-1s", 0, -time.Second},
+	}
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.input, tt.unit)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseDuration(%q, %v) = %v, %v, want %v", tt.input, tt.unit, got, err, tt.want)
+		}
+	}
+
+	// unitが0の場合は単位を省略できない
+	for _, input := range []string{"5", "abc", "1x", "d", "1e300d"} {
+		if _, err := ParseDuration(input, 0); err == nil {
+			t.Errorf("ParseDuration(%q, 0) はエラーになるべきです", input)
+		}
+	}
+}
+
+func TestDurationValue(t *testing.T) {
+	var d time.Duration
+	value := NewDurationValue(&d, time.Minute, 0)
+	if d != time.Minute || value.String() != "1m0s" || value.Type() != "duration" {
+		t.Errorf("NewDurationValue() = %v, %q", d, value.String())
+	}
+	if err := value.Set("2d"); err != nil || d != 48*time.Hour {
+		t.Errorf("Set(2d) = %v, %v", d, err)
+	}
+	if err := value.Set("10"); err == nil {
+		t.Error("単位のない値でエラーが発生しませんでした")
+	}
+}
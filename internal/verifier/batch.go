@@ -0,0 +1,129 @@
+package verifier
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/batch"
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/pathkey"
+)
+
+// batchedDest はコピー時にセグメントにまとめて書き込んだファイルの、ファイル情報と内容のハッシュ値を計算する関数を返す
+// DBにセグメントの中の位置の記録がない場合はfalseを返す
+func (v *Verifier) batchedDest(relPath string) (os.FileInfo, func() (string, error), bool) {
+	if v.db == nil {
+		return nil, nil, false
+	}
+	member, err := v.db.GetBatchMember(relPath)
+	if err != nil || member == nil {
+		return nil, nil, false
+	}
+
+	segmentPath := filepath.Join(v.destDir, filepath.FromSlash(member.Segment))
+	hash := func() (string, error) {
+		file, err := v.fs.Open(segmentPath)
+		if err != nil {
+			return "", fmt.Errorf("セグメント(%s)を開けません: %w", member.Segment, err)
+		}
+		defer file.Close()
+		return v.hasher.HashReader(batch.Section(file, member.Offset, member.Size))
+	}
+	return batch.FileInfo(relPath, member.Size, member.ModTime), hash, true
+}
+
+// findBatchedExtras はセグメントにまとめて書き込んだファイルのうち、ソースから削除されたものを探し、見つかるごとにfoundを呼び出す
+// セグメントの中のファイルは宛先を走査しても見つからないため、DBのセグメントの中の位置の記録から探す
+func (v *Verifier) findBatchedExtras(found func(destPath string, entry database.BatchEntry)) error {
+	if v.db == nil {
+		return nil
+	}
+	entries, err := v.db.GetBatchEntries()
+	if err != nil {
+		return fmt.Errorf("セグメントの中の位置の記録を読み込めません: %w", err)
+	}
+
+	for _, entry := range entries {
+		if !v.options.Recursive && strings.Contains(entry.Path, "/") {
+			continue
+		}
+		if _, err := v.fs.Lstat(filepath.Join(v.sourceDir, pathkey.ToNative(entry.Path))); !os.IsNotExist(err) {
+			continue
+		}
+		destPath := filepath.Join(v.destDir, pathkey.ToNative(entry.Path))
+		if v.filter != nil && !v.filter.ShouldInclude(destPath) {
+			continue
+		}
+		found(destPath, entry)
+	}
+	return nil
+}
+
+// checkBatchedExtras はセグメントにまとめて書き込んだファイルのうち、ソースから削除されたものを余分なファイルとして処理する
+func (v *Verifier) checkBatchedExtras() error {
+	return v.findBatchedExtras(func(destPath string, entry database.BatchEntry) {
+		action := v.extrasActionFor(destPath)
+		if action == ExtrasIgnore {
+			return
+		}
+
+		result := VerificationResult{
+			Path:         destPath,
+			SourceExists: false,
+			DestExists:   true,
+			DestSize:     entry.Size,
+			DestTime:     entry.ModTime,
+			Error:        fmt.Errorf("余分なファイルが存在します"),
+		}
+		v.handleBatchedExtra(&result, entry, action)
+		v.addResult(result)
+		v.recordExtra(result, batch.FileInfo(entry.Path, entry.Size, entry.ModTime), action)
+	})
+}
+
+// handleBatchedExtra はactionに従ってセグメントの中の余分なファイルを処理し、結果に反映する
+// 削除する場合はセグメントの中の位置の記録を削除し（セグメント自体はunbatch --pruneで削除する）、
+// 隔離する場合は内容を隔離先に書き出してから位置の記録を削除する
+func (v *Verifier) handleBatchedExtra(result *VerificationResult, entry database.BatchEntry, action ExtrasAction) {
+	switch action {
+	case ExtrasDelete:
+		if err := v.db.DeleteBatchMember(entry.Path); err != nil {
+			result.Error = errcode.Errorf(errcode.ErrDestWrite, "余分なファイルの削除エラー: %w", err)
+			return
+		}
+		result.Action = "deleted"
+	case ExtrasQuarantine:
+		target := filepath.Join(v.quarantineDir(), pathkey.ToNative(entry.Path))
+		if _, err := v.fs.Lstat(target); err == nil {
+			// 既に同名のファイルが隔離されている場合はタイムスタンプを付与
+			target = fmt.Sprintf("%s.%s", target, time.Now().Format("20060102150405"))
+		}
+		err := v.extractBatched(entry, target)
+		if err == nil {
+			err = v.db.DeleteBatchMember(entry.Path)
+		}
+		if err != nil {
+			result.Error = errcode.Errorf(errcode.ErrDestWrite, "余分なファイルの隔離エラー: %w", err)
+			return
+		}
+		result.Action = "quarantined"
+	default:
+		return
+	}
+	result.DestExists = false
+	result.Error = nil
+}
+
+// extractBatched はセグメントの中のファイルの内容をtargetに書き出す
+func (v *Verifier) extractBatched(entry database.BatchEntry, target string) error {
+	segment, err := v.fs.Open(filepath.Join(v.destDir, filepath.FromSlash(entry.Segment)))
+	if err != nil {
+		return fmt.Errorf("セグメント(%s)を開けません: %w", entry.Segment, err)
+	}
+	defer segment.Close()
+	return batch.Extract(v.fs, segment, entry.Offset, entry.Size, entry.ModTime, target)
+}
@@ -0,0 +1,127 @@
+package verifier
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/batch"
+	"github.com/sakuhanight/gopier/internal/database"
+)
+
+func TestVerify_BatchedFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(filepath.Join(sourceDir, "dir"), 0755)
+	os.MkdirAll(filepath.Join(destDir, "dir"), 0755)
+	os.MkdirAll(filepath.Join(destDir, batch.Dir), 0755)
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	files := map[string]string{"a.txt": "aaa", "dir/b.txt": "bbbb", "changed.txt": "new"}
+	for name, content := range files {
+		path := filepath.Join(sourceDir, filepath.FromSlash(name))
+		os.WriteFile(path, []byte(content), 0644)
+		os.Chtimes(path, modTime, modTime)
+	}
+
+	syncDB, err := database.NewSyncDB(filepath.Join(tempDir, "test.db"), database.NormalSync)
+	if err != nil {
+		t.Fatalf("データベースの作成に失敗: %v", err)
+	}
+	defer syncDB.Close()
+
+	// セグメントにはchanged.txtの古い内容を書き込む
+	segmentName := batch.SegmentName(1, 1)
+	segment, err := os.Create(filepath.Join(destDir, filepath.FromSlash(segmentName)))
+	if err != nil {
+		t.Fatalf("セグメントの作成に失敗: %v", err)
+	}
+	w := batch.NewWriter(segment)
+	for _, name := range []string{"a.txt", "dir/b.txt", "changed.txt"} {
+		content := files[name]
+		if name == "changed.txt" {
+			content = "old"
+		}
+		offset, err := w.Add(name, int64(len(content)), modTime, 0644, strings.NewReader(content))
+		if err != nil {
+			t.Fatalf("セグメントへの追加に失敗: %v", err)
+		}
+		syncDB.AddBatchedFile(database.FileInfo{Path: name, Size: int64(len(content)), ModTime: modTime, Status: database.StatusSuccess},
+			database.BatchMember{Segment: segmentName, Offset: offset, Size: int64(len(content)), ModTime: modTime})
+	}
+	w.Close()
+	segment.Close()
+
+	v := NewVerifier(sourceDir, destDir, DefaultOptions(), nil, syncDB)
+	v.Verify()
+
+	// セグメントの中のファイルを宛先のファイルとして検証し、セグメント自体は余分なファイルとして扱わない
+	failures := v.GetFailures()
+	if len(failures) != 1 || failures[0].Path != "changed.txt" {
+		t.Fatalf("失敗 = %+v, want changed.txtのみ", failures)
+	}
+	for _, name := range []string{"a.txt", "dir/b.txt"} {
+		record, _ := syncDB.GetFile(name)
+		if record == nil || record.Status != database.StatusVerified {
+			t.Errorf("%s の記録 = %+v", name, record)
+		}
+	}
+
+	// ソースから削除したファイルは余分なファイルとして、セグメントの中の位置の記録を削除する
+	os.Remove(filepath.Join(sourceDir, "a.txt"))
+	options := DefaultOptions()
+	options.ExtrasAction = ExtrasDelete
+	v = NewVerifier(sourceDir, destDir, options, nil, syncDB)
+	v.Verify()
+	if member, _ := syncDB.GetBatchMember("a.txt"); member != nil {
+		t.Errorf("ソースから削除したファイルの位置の記録が残っています: %+v", member)
+	}
+	if record, _ := syncDB.GetFile("a.txt"); record == nil || record.Status != database.StatusDeleted {
+		t.Errorf("ソースから削除したファイルの記録 = %+v", record)
+	}
+	if member, _ := syncDB.GetBatchMember("dir/b.txt"); member == nil {
+		t.Error("ソースにあるファイルの位置の記録が削除されました")
+	}
+}
+
+func TestVerify_BatchedExtrasQuarantine(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(sourceDir, 0755)
+	os.MkdirAll(filepath.Join(destDir, batch.Dir), 0755)
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	syncDB, err := database.NewSyncDB(filepath.Join(tempDir, "test.db"), database.NormalSync)
+	if err != nil {
+		t.Fatalf("データベースの作成に失敗: %v", err)
+	}
+	defer syncDB.Close()
+
+	segmentName := batch.SegmentName(1, 1)
+	segment, _ := os.Create(filepath.Join(destDir, filepath.FromSlash(segmentName)))
+	w := batch.NewWriter(segment)
+	offset, _ := w.Add("gone.txt", 4, modTime, 0644, strings.NewReader("gone"))
+	w.Close()
+	segment.Close()
+	syncDB.AddBatchedFile(database.FileInfo{Path: "gone.txt", Size: 4, ModTime: modTime, Status: database.StatusSuccess},
+		database.BatchMember{Segment: segmentName, Offset: offset, Size: 4, ModTime: modTime})
+
+	// 隔離する場合はセグメントの中の内容を隔離先に書き出す
+	options := DefaultOptions()
+	options.ExtrasAction = ExtrasQuarantine
+	v := NewVerifier(sourceDir, destDir, options, nil, syncDB)
+	v.Verify()
+	quarantined := filepath.Join(v.quarantineDir(), "gone.txt")
+	if data, _ := os.ReadFile(quarantined); string(data) != "gone" {
+		t.Errorf("隔離したファイル = %q", data)
+	}
+	if member, _ := syncDB.GetBatchMember("gone.txt"); member != nil {
+		t.Errorf("隔離したファイルの位置の記録が残っています: %+v", member)
+	}
+	if record, _ := syncDB.GetFile("gone.txt"); record == nil || record.Status != database.StatusQuarantined {
+		t.Errorf("隔離したファイルの記録 = %+v", record)
+	}
+}
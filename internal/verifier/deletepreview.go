@@ -0,0 +1,96 @@
+package verifier
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+// DeleteEntry は削除の確認に表示する、削除するファイル
+type DeleteEntry struct {
+	Path string // 宛先のパス
+	Size int64
+}
+
+// DeletePreview は余分なファイルを削除する前に確認する、削除するファイルの一覧と合計サイズ
+// 余分なディレクトリは中のファイルをすべて一覧に含める
+type DeletePreview struct {
+	Files []DeleteEntry
+	Dirs  int   // 削除するディレクトリ数（余分なディレクトリとその中のディレクトリ）
+	Bytes int64 // 削除で空く容量（ファイルのサイズの合計）
+}
+
+// confirmDelete は余分なファイルを削除する場合に、削除するファイルを調べてOptions.ConfirmDeleteで確認する
+// 確認で削除しないことになった場合は、以降の余分なファイルを報告のみ行う
+func (v *Verifier) confirmDelete() error {
+	if !v.usesExtrasAction(ExtrasDelete) || v.options.ConfirmDelete == nil {
+		return nil
+	}
+
+	preview, err := v.previewDelete()
+	if err != nil {
+		return fmt.Errorf("削除するファイルの確認エラー: %w", err)
+	}
+	if len(preview.Files) == 0 && preview.Dirs == 0 {
+		return nil
+	}
+	if !v.options.ConfirmDelete(preview) {
+		v.deleteDeclined = true
+	}
+	return nil
+}
+
+// previewDelete は余分なファイルを削除せずに探し、削除するファイルの一覧を返す
+// 規則で削除以外の処理を行うファイル・ディレクトリは一覧に含めない
+func (v *Verifier) previewDelete() (*DeletePreview, error) {
+	preview := &DeletePreview{}
+	var walkErr error
+	err := v.findExtras(v.sourceDir, v.destDir, func(destPath string, info os.FileInfo) {
+		if v.extrasActionFor(destPath) != ExtrasDelete {
+			return
+		}
+		if info != nil {
+			preview.add(destPath, info)
+			return
+		}
+		// ディレクトリは中のファイルもすべて削除する
+		err := vfs.Walk(v.fs, destPath, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				preview.Dirs++
+				return nil
+			}
+			preview.add(path, info)
+			return nil
+		})
+		if err != nil && walkErr == nil {
+			walkErr = err
+		}
+	})
+	if err == nil {
+		err = walkErr
+	}
+	if err == nil {
+		// セグメントの中のファイルの容量は、unbatch --pruneでセグメントを削除するまで空かないため合計に含めない
+		err = v.findBatchedExtras(func(destPath string, entry database.BatchEntry) {
+			if v.extrasActionFor(destPath) == ExtrasDelete {
+				preview.Files = append(preview.Files, DeleteEntry{Path: destPath, Size: entry.Size})
+			}
+		})
+	}
+	return preview, err
+}
+
+func (p *DeletePreview) add(path string, info os.FileInfo) {
+	p.Files = append(p.Files, DeleteEntry{Path: path, Size: info.Size()})
+	p.Bytes += info.Size()
+}
+
+// DeleteDeclined は削除の確認で削除しないことになったかどうかを返す
+func (v *Verifier) DeleteDeclined() bool {
+	return v.deleteDeclined
+}
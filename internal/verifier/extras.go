@@ -0,0 +1,76 @@
+package verifier
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sakuhanight/gopier/internal/filter"
+	"github.com/sakuhanight/gopier/internal/pathkey"
+)
+
+// ExtrasRule はパターンに一致する余分なファイル・ディレクトリの処理方法
+type ExtrasRule struct {
+	Pattern string       // 宛先からの相対パスに一致させるパターン（いずれかの階層の名前、またはスラッシュを含むパス）
+	Action  ExtrasAction // 一致した場合の処理方法
+}
+
+// ParseExtrasRules は「パターン=処理方法」の形式の規則を解析する
+func ParseExtrasRules(specs []string) ([]ExtrasRule, error) {
+	rules := make([]ExtrasRule, 0, len(specs))
+	for _, spec := range specs {
+		pattern, action, ok := strings.Cut(spec, "=")
+		pattern = strings.TrimSpace(pattern)
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("余分なファイルの規則の形式が不正です: %q (パターン=処理方法の形式で指定してください)", spec)
+		}
+		if strings.TrimSpace(action) == "" {
+			return nil, fmt.Errorf("余分なファイルの規則に処理方法がありません: %q", spec)
+		}
+		parsed, err := ParseExtrasAction(strings.TrimSpace(action))
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, ExtrasRule{Pattern: pattern, Action: parsed})
+	}
+	return rules, nil
+}
+
+// extrasActionFor は余分なファイル・ディレクトリの処理方法を返す
+// 最初に一致した規則の処理方法を使用し、一致する規則がない場合はOptions.ExtrasActionを使用する。
+// 削除の確認で削除しないことになった場合は、削除の代わりに報告のみ行う
+func (v *Verifier) extrasActionFor(destPath string) ExtrasAction {
+	action := v.options.ExtrasAction
+	if len(v.options.ExtrasRules) > 0 {
+		relPath, err := pathkey.Rel(v.destDir, destPath)
+		if err == nil {
+			for _, rule := range v.options.ExtrasRules {
+				if filter.MatchesUnder(relPath, rule.Pattern) {
+					action = rule.Action
+					break
+				}
+			}
+		}
+	}
+	if action == "" || (action == ExtrasDelete && v.deleteDeclined) {
+		return ExtrasReport
+	}
+	return action
+}
+
+// usesExtrasAction は規則を含め、いずれかの余分なファイルをactionで処理する設定かどうかを返す
+func (v *Verifier) usesExtrasAction(action ExtrasAction) bool {
+	if v.options.ExtrasAction == action {
+		return true
+	}
+	for _, rule := range v.options.ExtrasRules {
+		if rule.Action == action {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoresAllExtras は規則がなく、すべての余分なファイルを無視する設定かどうかを返す
+func (v *Verifier) ignoresAllExtras() bool {
+	return v.options.IgnoreExtra || (v.options.ExtrasAction == ExtrasIgnore && len(v.options.ExtrasRules) == 0)
+}
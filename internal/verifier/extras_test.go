@@ -0,0 +1,136 @@
+package verifier
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sakuhanight/gopier/internal/database"
+)
+
+func TestParseExtrasRules(t *testing.T) {
+	rules, err := ParseExtrasRules([]string{"*.tmp=delete", " logs/old = move-to-quarantine ", "Thumbs.db=ignore", "keep=report"})
+	if err != nil {
+		t.Fatalf("ParseExtrasRules() error = %v", err)
+	}
+	want := []ExtrasRule{
+		{"*.tmp", ExtrasDelete},
+		{"logs/old", ExtrasQuarantine},
+		{"Thumbs.db", ExtrasIgnore},
+		{"keep", ExtrasReport},
+	}
+	if len(rules) != len(want) {
+		t.Fatalf("ParseExtrasRules() = %+v", rules)
+	}
+	for i := range want {
+		if rules[i] != want[i] {
+			t.Errorf("rules[%d] = %+v, want %+v", i, rules[i], want[i])
+		}
+	}
+
+	for _, spec := range []string{"*.tmp", "=delete", "*.tmp=", "*.tmp=remove"} {
+		if _, err := ParseExtrasRules([]string{spec}); err == nil {
+			t.Errorf("ParseExtrasRules(%q) がエラーになりません", spec)
+		}
+	}
+}
+
+// TestVerify_ExtrasRules はパターンごとの余分なファイルの処理をテスト
+func TestVerify_ExtrasRules(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(filepath.Join(sourceDir, "logs"), 0755)
+	os.MkdirAll(filepath.Join(destDir, "logs", "old"), 0755)
+	os.WriteFile(filepath.Join(sourceDir, "keep.txt"), []byte("keep"), 0644)
+	os.WriteFile(filepath.Join(destDir, "keep.txt"), []byte("keep"), 0644)
+	for _, name := range []string{"work.tmp", "Thumbs.db", "extra.txt", filepath.Join("logs", "old", "a.log")} {
+		os.WriteFile(filepath.Join(destDir, name), []byte("extra"), 0644)
+	}
+
+	syncDB, err := database.NewSyncDB(filepath.Join(tempDir, "test.db"), database.NormalSync)
+	if err != nil {
+		t.Fatalf("データベースの作成に失敗: %v", err)
+	}
+	defer syncDB.Close()
+
+	options := DefaultOptions()
+	options.ExtrasAction = ExtrasIgnore
+	options.ExtrasRules = []ExtrasRule{
+		{"*.tmp", ExtrasDelete},
+		{"logs/old", ExtrasQuarantine},
+		{"extra.txt", ExtrasReport},
+	}
+	var preview *DeletePreview
+	options.ConfirmDelete = func(p *DeletePreview) bool {
+		preview = p
+		return true
+	}
+	v := NewVerifier(sourceDir, destDir, options, nil, syncDB)
+	v.Verify()
+
+	// 削除の確認には削除するファイルのみを含める
+	if preview == nil || len(preview.Files) != 1 || preview.Files[0].Path != filepath.Join(destDir, "work.tmp") {
+		t.Errorf("削除の確認 = %+v", preview)
+	}
+
+	actions := make(map[string]string)
+	for _, result := range v.GetResults() {
+		if !result.SourceExists {
+			actions[result.Path] = result.Action
+		}
+	}
+	want := map[string]string{
+		filepath.Join(destDir, "work.tmp"):    "deleted",
+		filepath.Join(destDir, "logs", "old"): "quarantined",
+		filepath.Join(destDir, "extra.txt"):   "",
+	}
+	if len(actions) != len(want) {
+		t.Errorf("余分なファイルの結果 = %v", actions)
+	}
+	for path, action := range want {
+		if got, ok := actions[path]; !ok || got != action {
+			t.Errorf("%s の処理 = %q, %t, want %q", path, got, ok, action)
+		}
+	}
+	if v.GetErrorCount() != 1 {
+		t.Errorf("期待されるエラー数: 1, 実際: %d", v.GetErrorCount())
+	}
+
+	// 無視したファイルは宛先に残し、データベースにも記録しない
+	if _, err := os.Stat(filepath.Join(destDir, "Thumbs.db")); err != nil {
+		t.Errorf("無視したファイルがありません: %v", err)
+	}
+	if info, _ := syncDB.GetFile("Thumbs.db"); info != nil {
+		t.Errorf("無視したファイルが記録されています: %+v", info)
+	}
+	if _, err := os.Stat(filepath.Join(destDir+".quarantine", "logs", "old", "a.log")); err != nil {
+		t.Errorf("隔離先にファイルがありません: %v", err)
+	}
+	if info, err := syncDB.GetFile("work.tmp"); err != nil || info == nil || info.Status != database.StatusDeleted {
+		t.Errorf("work.tmp の記録 = %+v, %v", info, err)
+	}
+	if info, err := syncDB.GetFile("extra.txt"); err != nil || info == nil || info.Status != database.StatusMismatch {
+		t.Errorf("extra.txt の記録 = %+v, %v", info, err)
+	}
+}
+
+// TestVerify_ExtrasIgnore は余分なファイルをすべて無視する設定をテスト
+func TestVerify_ExtrasIgnore(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(sourceDir, 0755)
+	os.MkdirAll(destDir, 0755)
+	os.WriteFile(filepath.Join(destDir, "extra.txt"), []byte("extra"), 0644)
+
+	options := DefaultOptions()
+	options.ExtrasAction = ExtrasIgnore
+	v := NewVerifier(sourceDir, destDir, options, nil, nil)
+	if err := v.Verify(); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+	if results := v.GetResults(); len(results) != 0 || v.GetErrorCount() != 0 {
+		t.Errorf("結果 = %+v, エラー数 = %d", results, v.GetErrorCount())
+	}
+}
@@ -0,0 +1,49 @@
+package verifier
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/sakuhanight/gopier/internal/filter"
+)
+
+// ignoreErrors は検証結果のパスのエラーを無視する（失敗として扱わない）かどうかを判断する
+func (v *Verifier) ignoreErrors(path string) bool {
+	if v.options.IgnoreErrorsOn == "" {
+		return false
+	}
+	return filter.MatchesUnder(v.relResultPath(path), v.options.IgnoreErrorsOn)
+}
+
+// suppress は検証結果のパスが確認済みの不一致として報告しないパスかどうかを判断する
+func (v *Verifier) suppress(path string) bool {
+	if v.options.Suppress == nil {
+		return false
+	}
+	return v.options.Suppress(v.relResultPath(path))
+}
+
+// relResultPath は検証結果のパスをソース・宛先のルートからの相対パスにする
+// 結果のパスは相対パスのほか、ソース・宛先のディレクトリを含むパスの場合がある
+func (v *Verifier) relResultPath(path string) string {
+	for _, root := range []string{v.sourceDir, v.destDir} {
+		if rest, ok := strings.CutPrefix(path, filepath.Clean(root)+string(filepath.Separator)); ok {
+			return rest
+		}
+	}
+	return path
+}
+
+// GetSuppressedCount は確認済みの不一致として報告しなかった検証結果の数を返す
+func (v *Verifier) GetSuppressedCount() int64 {
+	v.errCountMutex.Lock()
+	defer v.errCountMutex.Unlock()
+	return v.suppressed
+}
+
+// GetIgnoredCount はエラーを無視した検証結果の数を返す
+func (v *Verifier) GetIgnoredCount() int64 {
+	v.errCountMutex.Lock()
+	defer v.errCountMutex.Unlock()
+	return v.ignoredCount
+}
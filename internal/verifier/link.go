@@ -0,0 +1,98 @@
+package verifier
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/overlap"
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+// linkTarget はpathがシンボリックリンク・ジャンクションの場合にリンク先とtrueを返す
+// WindowsのジャンクションなどのリパースポイントはModeIrregularとして返るため、リンク先を読み取れるかどうかで判別する
+func (v *Verifier) linkTarget(path string) (string, bool) {
+	info, err := v.fs.Lstat(path)
+	if err != nil || info.Mode()&(os.ModeSymlink|os.ModeIrregular) == 0 {
+		return "", false
+	}
+	target, err := vfs.Readlink(v.fs, path)
+	if err != nil {
+		return "", false
+	}
+	return target, true
+}
+
+// verifyLink は宛先がシンボリックリンク・ジャンクションの場合に、内容をハッシュせずにソースとリンク先を比較する
+// コピーはリンクをたどって内容をコピーするため、ソースのみがリンクの場合は対象とせずfalseを返し、通常どおり内容を比較する
+// 宛先のみがリンクの場合は、ソースとは別の場所の内容を指している可能性があるため不一致とする
+func (v *Verifier) verifyLink(result *VerificationResult, sourcePath, destPath string) bool {
+	destTarget, ok := v.linkTarget(destPath)
+	if !ok {
+		return false
+	}
+	sourceTarget, sourceIsLink := v.linkTarget(sourcePath)
+	result.SourceLink, result.DestLink = sourceTarget, destTarget
+
+	switch {
+	case !sourceIsLink:
+		if _, err := v.fs.Lstat(sourcePath); err != nil {
+			result.SourceExists = false
+			result.Error = fmt.Errorf("ソースファイル確認エラー: %w", err)
+			return true
+		}
+		result.Error = errcode.Errorf(errcode.ErrLinkMismatch, "宛先はリンクですが、ソースはリンクではありません (宛先のリンク先: %s)", destTarget)
+	case !v.sameLinkTarget(sourceTarget, destTarget):
+		result.Error = errcode.Errorf(errcode.ErrLinkMismatch, "リンク先が一致しません (ソース: %s, 宛先: %s)", sourceTarget, destTarget)
+	default:
+		result.SizeMatch, result.HashMatch = true, true
+	}
+
+	// データベースに記録
+	if v.db != nil {
+		fileInfo := database.FileInfo{
+			Path:         result.Path,
+			Status:       database.StatusVerified,
+			LastSyncTime: time.Now(),
+		}
+		if result.Error != nil {
+			fileInfo.Status = database.StatusMismatch
+			fileInfo.LastError = result.Error.Error()
+			fileInfo.Error = errcode.Describe(result.Error)
+		}
+		v.recordFile(fileInfo)
+	}
+	return true
+}
+
+// sameLinkTarget はソースと宛先のリンク先が同じかどうかを返す
+// ソースの中を指す絶対パスのリンク先は、宛先の対応する場所を指している場合も一致とする
+func (v *Verifier) sameLinkTarget(sourceTarget, destTarget string) bool {
+	if sameTarget(sourceTarget, destTarget) {
+		return true
+	}
+	if !filepath.IsAbs(sourceTarget) || !filepath.IsAbs(destTarget) {
+		return false
+	}
+	if !overlap.Within(v.sourceDir, sourceTarget) && !overlap.SamePath(v.sourceDir, sourceTarget) {
+		return false
+	}
+	rel, err := filepath.Rel(v.sourceDir, sourceTarget)
+	if err != nil {
+		return false
+	}
+	return overlap.SamePath(filepath.Join(v.destDir, rel), destTarget)
+}
+
+// sameTarget はリンク先の文字列が同じかどうかを返す（Windowsでは区切り文字と大文字・小文字を区別しない）
+func sameTarget(a, b string) bool {
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(filepath.Clean(a), filepath.Clean(b))
+	}
+	return a == b
+}
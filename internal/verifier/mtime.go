@@ -0,0 +1,21 @@
+package verifier
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/units"
+)
+
+// ParseModTimePrecision は更新日時を比較する精度の指定（"1ns"、"100ns"、"1s"、"2s"など）を解析する
+// 空の場合は比較しない（0）
+func ParseModTimePrecision(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	precision, err := units.ParseDuration(s, 0)
+	if err != nil || precision <= 0 {
+		return 0, fmt.Errorf("更新日時の比較の精度は1ns、100ns、1s、2sなどの形式で指定してください: %s", s)
+	}
+	return precision, nil
+}
@@ -0,0 +1,63 @@
+package verifier
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/errcode"
+)
+
+func TestParseModTimePrecision(t *testing.T) {
+	for input, want := range map[string]time.Duration{"": 0, "1ns": time.Nanosecond, "100ns": 100 * time.Nanosecond, "2s": 2 * time.Second} {
+		if got, err := ParseModTimePrecision(input); err != nil || got != want {
+			t.Errorf("ParseModTimePrecision(%q) = %v, %v; want %v", input, got, err, want)
+		}
+	}
+	for _, input := range []string{"0", "-1s", "fast"} {
+		if _, err := ParseModTimePrecision(input); err == nil {
+			t.Errorf("ParseModTimePrecision(%q) でエラーになりませんでした", input)
+		}
+	}
+}
+
+func TestVerifyFile_ModTimePrecision(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceFile := filepath.Join(tempDir, "source.txt")
+	destFile := filepath.Join(tempDir, "dest.txt")
+	os.WriteFile(sourceFile, []byte("content"), 0644)
+	os.WriteFile(destFile, []byte("content"), 0644)
+
+	// 宛先の更新日時はソースより500ナノ秒遅い
+	modTime := time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC)
+	os.Chtimes(sourceFile, modTime, modTime)
+	os.Chtimes(destFile, modTime, modTime.Add(500*time.Nanosecond))
+	if info, _ := os.Stat(sourceFile); !info.ModTime().Equal(modTime) {
+		t.Skipf("ファイルシステムがナノ秒の更新日時に対応していません: %v", info.ModTime())
+	}
+
+	for _, tc := range []struct {
+		precision time.Duration
+		match     bool
+	}{
+		{0, true},
+		{time.Microsecond, true},
+		{100 * time.Nanosecond, false},
+	} {
+		options := DefaultOptions()
+		options.ModTimePrecision = tc.precision
+		v := NewVerifier(tempDir, tempDir, options, nil, nil)
+		result, err := v.verifyFile(sourceFile, destFile)
+		if err != nil {
+			t.Fatalf("verifyFile() error = %v", err)
+		}
+		if tc.match && (result.Error != nil || !result.HashMatch) {
+			t.Errorf("精度%vで一致しません: %+v", tc.precision, result)
+		}
+		if !tc.match && !errors.Is(result.Error, errcode.ErrModTimeMismatch) {
+			t.Errorf("精度%vで更新日時の不一致になりません: %v", tc.precision, result.Error)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package verifier
+
+import (
+	"fmt"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/recheck"
+)
+
+// recheck はハッシュが一致しなかったファイルを、ソースと宛先を読み直して最大MismatchRetries回再検証する
+// hashBothは期待するハッシュと宛先のハッシュを計算し直す関数で、再検証で一致した場合は一致したハッシュとtrueを返す
+// 再検証した回数は結果のRechecksに記録する
+func (v *Verifier) recheck(result *VerificationResult, hashBoth func() (expected, dest string, err error)) (string, string, bool) {
+	opts := recheck.Options{Retries: v.options.MismatchRetries, Delay: v.options.MismatchRetryDelay, Logger: v.options.Logger}
+	expected, dest, attempts, ok := recheck.Run(v.ctx, opts, result.Path, hashBoth)
+	result.Rechecks = attempts
+	return expected, dest, ok
+}
+
+// recheckNote は再検証しても一致しなかった場合にエラーに付け加える説明を返す
+func recheckNote(result *VerificationResult) string {
+	if result.Rechecks == 0 {
+		return ""
+	}
+	return fmt.Sprintf("（%d回の再検証でも一致しません）", result.Rechecks)
+}
+
+// verifiedStatus は検証で一致したファイルのDBの状態と記録するメッセージ・理由を返す
+func verifiedStatus(result *VerificationResult) (status database.FileStatus, message string, reason database.Reason) {
+	if result.Intermittent {
+		return database.StatusIntermittent, fmt.Sprintf("ハッシュ値が一度一致せず、%d回目の再検証で一致しました", result.Rechecks), database.ReasonIntermittent
+	}
+	return database.StatusVerified, "", ""
+}
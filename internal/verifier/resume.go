@@ -0,0 +1,199 @@
+package verifier
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/units"
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+// resumeChunkAlign は途中の状態を記録するチャンクの大きさの単位
+// キャッシュを経由しない読み込みでもセクタの境界から再開できるよう、チャンクの大きさをこの倍数にする
+const resumeChunkAlign = units.MiB
+
+// resumeChunkSize は途中の状態を記録するチャンクの大きさを返す（記録しない場合は0）
+func (v *Verifier) resumeChunkSize() int64 {
+	if v.db == nil {
+		return 0
+	}
+	return v.options.ResumeChunkSize - v.options.ResumeChunkSize%resumeChunkAlign
+}
+
+// hashResumable はチャンクより大きいファイルのソースと宛先を並行して読み込み、ハッシュ値を計算する
+// チャンクの境界ごとにハッシュ関数の内部状態をDBに記録し、前回の検証が中断した場合は
+// ソース・宛先が変更されていなければ記録した位置から再開する。内容を比較するため、
+// 最初に一致しなかったチャンクの位置も返す（一致した場合は-1）。
+// 対象外のファイルやハッシュ関数の状態を記録できない場合はokにfalseを返す
+func (v *Verifier) hashResumable(relPath, sourcePath, destPath string, sourceInfo, destInfo os.FileInfo) (sourceHash, destHash string, firstMismatch int64, ok bool, err error) {
+	chunkSize := v.resumeChunkSize()
+	size := sourceInfo.Size()
+	if chunkSize <= 0 || size <= chunkSize || destInfo.Size() != size {
+		return "", "", -1, false, nil
+	}
+	sourceState, destState, ok := v.newResumableHashes()
+	if !ok {
+		return "", "", -1, false, nil
+	}
+
+	checkpoint := database.VerifyCheckpoint{
+		Path:          relPath,
+		HashAlgo:      v.options.HashAlgorithm,
+		Size:          size,
+		SourceModTime: sourceInfo.ModTime(),
+		DestModTime:   destInfo.ModTime(),
+		ChunkSize:     chunkSize,
+		FirstMismatch: -1,
+	}
+	if prev := v.loadCheckpoint(checkpoint, sourceState, destState); prev != nil {
+		checkpoint.Offset, checkpoint.FirstMismatch = prev.Offset, prev.FirstMismatch
+		if v.options.Logger != nil {
+			v.options.Logger.Info("中断した検証を再開します: %s (%d/%d bytes)", relPath, prev.Offset, size)
+		}
+	}
+
+	source, err := v.openResumable(sourcePath, checkpoint.Offset)
+	if err != nil {
+		return "", "", -1, true, errcode.Errorf(errcode.ErrSourceRead, "ソースファイルのハッシュ計算エラー: %w", err)
+	}
+	defer source.Close()
+	dest, err := v.openResumable(destPath, checkpoint.Offset)
+	if err != nil {
+		return "", "", -1, true, errcode.Errorf(errcode.ErrDestRead, "宛先ファイルのハッシュ計算エラー: %w", err)
+	}
+	defer dest.Close()
+
+	bufferSize := int64(v.options.BufferSize)
+	if bufferSize <= 0 || bufferSize > chunkSize {
+		bufferSize = chunkSize
+	}
+	sourceBuf, destBuf := make([]byte, bufferSize), make([]byte, bufferSize)
+	for checkpoint.Offset < size {
+		select {
+		case <-v.ctx.Done():
+			return "", "", -1, true, errcode.Errorf(errcode.ErrCancelled, "検証処理がキャンセルされました")
+		default:
+		}
+
+		// チャンクの境界を越えないように読み込み、境界に達したら状態を記録する
+		chunkStart := checkpoint.Offset - checkpoint.Offset%chunkSize
+		n := min(bufferSize, chunkStart+chunkSize-checkpoint.Offset, size-checkpoint.Offset)
+		if _, err := io.ReadFull(source, sourceBuf[:n]); err != nil {
+			return "", "", -1, true, errcode.Errorf(errcode.ErrSourceRead, "ソースファイルのハッシュ計算エラー: %w", readError(err))
+		}
+		if _, err := io.ReadFull(dest, destBuf[:n]); err != nil {
+			return "", "", -1, true, errcode.Errorf(errcode.ErrDestRead, "宛先ファイルのハッシュ計算エラー: %w", readError(err))
+		}
+		sourceState.Write(sourceBuf[:n])
+		destState.Write(destBuf[:n])
+		if checkpoint.FirstMismatch < 0 && !bytes.Equal(sourceBuf[:n], destBuf[:n]) {
+			checkpoint.FirstMismatch = chunkStart
+		}
+		checkpoint.Offset += n
+
+		if checkpoint.Offset%chunkSize == 0 && checkpoint.Offset < size {
+			v.saveCheckpoint(checkpoint, sourceState, destState)
+		}
+	}
+
+	if err := v.db.DeleteVerifyCheckpoint(relPath); err != nil && v.options.Logger != nil {
+		v.options.Logger.Warn("検証の途中の状態を削除できません: %s: %v", relPath, err)
+	}
+	return hex.EncodeToString(sourceState.Sum(nil)), hex.EncodeToString(destState.Sum(nil)), checkpoint.FirstMismatch, true, nil
+}
+
+// newResumableHashes はソースと宛先のハッシュ関数を作成する
+// 内部状態を記録できない（encoding.BinaryMarshalerを実装しない）ハッシュ関数の場合はokにfalseを返す
+func (v *Verifier) newResumableHashes() (source, dest hash.Hash, ok bool) {
+	source, err := v.hasher.NewHash()
+	if err != nil {
+		return nil, nil, false
+	}
+	dest, err = v.hasher.NewHash()
+	if err != nil {
+		return nil, nil, false
+	}
+	_, sourceOK := source.(encoding.BinaryMarshaler)
+	_, destOK := dest.(encoding.BinaryUnmarshaler)
+	return source, dest, sourceOK && destOK
+}
+
+// loadCheckpoint は前回の検証の途中の状態を読み込み、ハッシュ関数に復元する
+// ファイルが変更された場合や設定が異なる場合は記録を使用しない（nilを返す）
+func (v *Verifier) loadCheckpoint(current database.VerifyCheckpoint, sourceState, destState hash.Hash) *database.VerifyCheckpoint {
+	prev, err := v.db.GetVerifyCheckpoint(current.Path)
+	if err != nil || prev == nil {
+		return nil
+	}
+	if prev.HashAlgo != current.HashAlgo || prev.Size != current.Size || prev.ChunkSize != current.ChunkSize ||
+		!prev.SourceModTime.Equal(current.SourceModTime) || !prev.DestModTime.Equal(current.DestModTime) ||
+		prev.Offset <= 0 || prev.Offset >= prev.Size || prev.Offset%prev.ChunkSize != 0 {
+		return nil
+	}
+	if sourceState.(encoding.BinaryUnmarshaler).UnmarshalBinary(prev.SourceState) != nil ||
+		destState.(encoding.BinaryUnmarshaler).UnmarshalBinary(prev.DestState) != nil {
+		sourceState.Reset()
+		destState.Reset()
+		return nil
+	}
+	return prev
+}
+
+// saveCheckpoint はチャンクの境界まで計算したハッシュ関数の内部状態を記録する
+// 記録できない場合も検証は続ける（中断した場合は前回記録した位置、または最初からやり直す）
+func (v *Verifier) saveCheckpoint(checkpoint database.VerifyCheckpoint, sourceState, destState hash.Hash) {
+	var err error
+	if checkpoint.SourceState, err = sourceState.(encoding.BinaryMarshaler).MarshalBinary(); err == nil {
+		if checkpoint.DestState, err = destState.(encoding.BinaryMarshaler).MarshalBinary(); err == nil {
+			checkpoint.UpdatedAt = time.Now()
+			err = v.db.SaveVerifyCheckpoint(checkpoint)
+		}
+	}
+	if err != nil && v.options.Logger != nil {
+		v.options.Logger.Warn("検証の途中の状態を記録できません: %s: %v", checkpoint.Path, err)
+	}
+}
+
+// openResumable はファイルを開き、offsetの位置に移動する
+func (v *Verifier) openResumable(path string, offset int64) (vfs.File, error) {
+	open := v.fs.Open
+	if v.options.DropCache {
+		open = func(name string) (vfs.File, error) { return vfs.OpenUncached(v.fs, name) }
+	}
+	file, err := open(path)
+	if err != nil {
+		return nil, fmt.Errorf("ファイルを開けません: %w", err)
+	}
+	if offset > 0 {
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("ファイル読み込みエラー: %w", err)
+		}
+	}
+	return file, nil
+}
+
+// readError は読み込みのエラーを返す（検証中にファイルが短くなった場合はその旨を返す）
+func readError(err error) error {
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return fmt.Errorf("検証中にファイルのサイズが変更されました")
+	}
+	return fmt.Errorf("ファイル読み込みエラー: %w", err)
+}
+
+// mismatchNote はチャンクごとに比較した場合に、内容が最初に一致しなかった位置の説明を返す
+func mismatchNote(firstMismatch int64) string {
+	if firstMismatch < 0 {
+		return ""
+	}
+	return fmt.Sprintf("（%d bytesからのチャンクで最初に一致しません）", firstMismatch)
+}
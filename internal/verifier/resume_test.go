@@ -0,0 +1,125 @@
+package verifier
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/units"
+)
+
+// TestVerify_ResumeChunks は大きいファイルの検証を記録した途中の状態から再開するテスト
+func TestVerify_ResumeChunks(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(sourceDir, 0755)
+	os.MkdirAll(destDir, 0755)
+
+	content := bytes.Repeat([]byte("0123456789abcdef"), int(3*units.MiB/16)+10)
+	for _, dir := range []string{sourceDir, destDir} {
+		os.WriteFile(filepath.Join(dir, "big.bin"), content, 0644)
+		os.WriteFile(filepath.Join(dir, "small.txt"), []byte("small"), 0644)
+	}
+	corrupted := append([]byte(nil), content...)
+	corrupted[2*units.MiB+5] ^= 0xff
+	os.WriteFile(filepath.Join(sourceDir, "bad.bin"), content, 0644)
+	os.WriteFile(filepath.Join(destDir, "bad.bin"), corrupted, 0644)
+
+	syncDB, err := database.NewSyncDB(filepath.Join(tempDir, "test.db"), database.NormalSync)
+	if err != nil {
+		t.Fatalf("データベースの作成に失敗: %v", err)
+	}
+	defer syncDB.Close()
+
+	// 記録した状態から再開したことを確認するため、最初のチャンクとは異なる内容で計算した状態を記録する
+	sourceInfo, _ := os.Stat(filepath.Join(sourceDir, "big.bin"))
+	destInfo, _ := os.Stat(filepath.Join(destDir, "big.bin"))
+	state := sha256.New()
+	state.Write(make([]byte, units.MiB))
+	marshaled, _ := state.(encoding.BinaryMarshaler).MarshalBinary()
+	checkpoint := database.VerifyCheckpoint{Path: "big.bin", HashAlgo: "sha256", Size: sourceInfo.Size(),
+		SourceModTime: sourceInfo.ModTime(), DestModTime: destInfo.ModTime(), ChunkSize: units.MiB,
+		Offset: units.MiB, SourceState: marshaled, DestState: marshaled, FirstMismatch: -1}
+	if err := syncDB.SaveVerifyCheckpoint(checkpoint); err != nil {
+		t.Fatal(err)
+	}
+	state.Write(content[units.MiB:])
+	resumedHash := hex.EncodeToString(state.Sum(nil))
+
+	// 変更されたファイルの途中の状態は使用しない
+	stale := checkpoint
+	stale.Path, stale.SourceModTime = "bad.bin", time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	syncDB.SaveVerifyCheckpoint(stale)
+
+	options := DefaultOptions()
+	options.BufferSize = 256 * 1024
+	options.ResumeChunkSize = units.MiB + 100 // チャンクの大きさは1MiB単位に切り捨てる
+	v := NewVerifier(sourceDir, destDir, options, nil, syncDB)
+	v.Verify()
+
+	results := make(map[string]VerificationResult)
+	for _, result := range v.GetResults() {
+		results[result.Path] = result
+	}
+	if got := results["big.bin"]; !got.HashMatch || got.SourceHash != resumedHash {
+		t.Errorf("big.bin の結果 = %+v, 期待するハッシュ値 %s", got, resumedHash)
+	}
+	full := sha256.Sum256(content)
+	if got := results["bad.bin"]; got.HashMatch || got.SourceHash != hex.EncodeToString(full[:]) ||
+		got.Error == nil || !strings.Contains(got.Error.Error(), "2097152 bytes") {
+		t.Errorf("bad.bin の結果 = %+v", got)
+	}
+	if got := results["small.txt"]; !got.HashMatch {
+		t.Errorf("small.txt の結果 = %+v", got)
+	}
+
+	// 完了したファイルの途中の状態は削除する
+	for _, name := range []string{"big.bin", "bad.bin"} {
+		if got, _ := syncDB.GetVerifyCheckpoint(name); got != nil {
+			t.Errorf("%s の途中の状態が残っています: %+v", name, got)
+		}
+	}
+}
+
+// TestVerify_ResumeChunksInterrupted は読み込みに失敗した位置までの途中の状態を記録するテスト
+func TestVerify_ResumeChunksInterrupted(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(sourceDir, 0755)
+	os.MkdirAll(destDir, 0755)
+	content := bytes.Repeat([]byte{1}, int(3*units.MiB))
+	os.WriteFile(filepath.Join(sourceDir, "big.bin"), content, 0644)
+	os.WriteFile(filepath.Join(destDir, "big.bin"), content, 0644)
+
+	syncDB, err := database.NewSyncDB(filepath.Join(tempDir, "test.db"), database.NormalSync)
+	if err != nil {
+		t.Fatalf("データベースの作成に失敗: %v", err)
+	}
+	defer syncDB.Close()
+
+	options := DefaultOptions()
+	options.ResumeChunkSize = units.MiB
+	v := NewVerifier(sourceDir, destDir, options, nil, syncDB)
+
+	// 2つ目のチャンクの途中で宛先が短くなった場合は、検証済みのチャンクの境界を記録する
+	sourceInfo, _ := os.Stat(filepath.Join(sourceDir, "big.bin"))
+	destInfo, _ := os.Stat(filepath.Join(destDir, "big.bin"))
+	os.Truncate(filepath.Join(destDir, "big.bin"), units.MiB+10)
+	_, _, _, ok, err := v.hashResumable("big.bin", filepath.Join(sourceDir, "big.bin"), filepath.Join(destDir, "big.bin"), sourceInfo, destInfo)
+	if !ok || err == nil {
+		t.Fatalf("hashResumable() = %t, %v", ok, err)
+	}
+	checkpoint, _ := syncDB.GetVerifyCheckpoint("big.bin")
+	if checkpoint == nil || checkpoint.Offset != units.MiB || len(checkpoint.SourceState) == 0 {
+		t.Errorf("記録した途中の状態 = %+v", checkpoint)
+	}
+}
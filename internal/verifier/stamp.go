@@ -0,0 +1,42 @@
+package verifier
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/fsmeta"
+	"github.com/sakuhanight/gopier/internal/vfs"
+)
+
+// stampDest は一致した宛先のファイルの拡張属性（WindowsではADS）にハッシュを記録する
+// hashは宛先の内容のハッシュ、sourceHashは変換前のソースのハッシュ（変換しない場合は同じ値）。
+// 記録できなくても検証は成功として扱い、警告のみ出力する
+func (v *Verifier) stampDest(relPath, destPath string, sourceInfo os.FileInfo, hash, sourceHash string) {
+	if !v.options.StampXattr || !vfs.IsOS(v.fs) {
+		return
+	}
+
+	stamp := fsmeta.Stamp{
+		Algorithm: v.options.HashAlgorithm,
+		Hash:      hash,
+		Size:      sourceInfo.Size(),
+		ModTime:   sourceInfo.ModTime(),
+		StampedAt: time.Now(),
+	}
+	if sourceHash != hash {
+		stamp.SourceHash = sourceHash
+	}
+	err := fsmeta.WriteStamp(v.actionPath(destPath), stamp)
+	if err == nil || v.options.Logger == nil {
+		return
+	}
+	// 対応していないファイルシステムでは、ファイルごとに警告しないよう最初の1件のみ出力する
+	if errors.Is(err, fsmeta.ErrStampUnsupported) {
+		if !v.stampWarned.Swap(true) {
+			v.options.Logger.Warn("宛先のファイルシステムはハッシュの記録（拡張属性）に対応していません: %s", destPath)
+		}
+		return
+	}
+	v.options.Logger.Warn("ハッシュを拡張属性に記録できません: %s: %v", relPath, err)
+}
@@ -0,0 +1,181 @@
+package verifier
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/pathkey"
+)
+
+// SubtreeSummary は最上位のディレクトリごとの検証結果の集計を表す構造体
+type SubtreeSummary struct {
+	Dir          string                       `json:"dir"` // ソースからの相対パス（ソースの直下のファイルは"."）
+	StartedAt    time.Time                    `json:"started_at"`
+	FinishedAt   time.Time                    `json:"finished_at"`
+	Verification database.VerificationSummary `json:"verification"`
+}
+
+// Clean は一致しなかったファイルがないかどうかを返す
+// 余分なファイルはすべてのディレクトリの検証の後に確認するため含まない
+func (s SubtreeSummary) Clean() bool {
+	return s.Verification.Verified() == s.Verification.Matched
+}
+
+// SubtreeCallback は最上位のディレクトリの検証が完了するごとに呼び出される関数型
+// 呼び出しは直列化される
+type SubtreeCallback func(summary SubtreeSummary)
+
+// SetSubtreeCallback は最上位のディレクトリの検証が完了した際のコールバック関数を設定する
+// Options.SubtreeWorkersを指定した場合のVerifyでのみ呼び出される
+func (v *Verifier) SetSubtreeCallback(callback SubtreeCallback) {
+	v.subtreeFunc = callback
+}
+
+// GetSubtreeSummaries は完了した最上位のディレクトリごとの検証結果を完了した順に返す
+func (v *Verifier) GetSubtreeSummaries() []SubtreeSummary {
+	v.subtreeMu.Lock()
+	defer v.subtreeMu.Unlock()
+	return append([]SubtreeSummary(nil), v.subtreeResults...)
+}
+
+// subtree は最上位のディレクトリの検証中のファイル数と結果の集計
+type subtree struct {
+	dir     string
+	started time.Time
+	pending sync.WaitGroup
+
+	mu      sync.Mutex
+	summary database.VerificationSummary
+}
+
+func newSubtree(dir string) *subtree {
+	return &subtree{dir: dir, started: time.Now()}
+}
+
+// begin はファイルの検証の開始を記録する（stがnilの場合は何もしない）
+func (st *subtree) begin() {
+	if st != nil {
+		st.pending.Add(1)
+	}
+}
+
+// done はファイルの検証の完了を記録する
+func (st *subtree) done() {
+	if st != nil {
+		st.pending.Done()
+	}
+}
+
+// add は検証結果を集計に加える
+func (st *subtree) add(result VerificationResult) {
+	if st == nil {
+		return
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.summary.Add(result.outcome(), result.SourceSize)
+}
+
+// wait は配下のすべてのファイルの検証が完了するのを待ち、集計を返す
+func (st *subtree) wait() SubtreeSummary {
+	st.pending.Wait()
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return SubtreeSummary{Dir: st.dir, StartedAt: st.started, FinishedAt: time.Now(), Verification: st.summary}
+}
+
+// subtreeRunner は最上位のディレクトリを並行して検証するワーカーの管理
+type subtreeRunner struct {
+	slots chan struct{}
+	wg    sync.WaitGroup
+
+	mu  sync.Mutex
+	err error // 最初に発生したディレクトリの走査のエラー
+}
+
+func (r *subtreeRunner) setErr(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *subtreeRunner) getErr() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// addResultTo は検証結果を追加し、最上位のディレクトリの集計にも加える
+func (v *Verifier) addResultTo(st *subtree, result VerificationResult) {
+	if v.addResult(result) {
+		st.add(result)
+	}
+}
+
+// verifySubtrees はソースの最上位のディレクトリごとに並行して検証し、完了したものから集計を報告する
+// ソースの直下のファイルは"."として、すべてのディレクトリの後に報告する
+func (v *Verifier) verifySubtrees() error {
+	v.subtrees = &subtreeRunner{slots: make(chan struct{}, v.options.SubtreeWorkers)}
+	root := newSubtree(".")
+
+	err := v.verifyTree(v.sourceDir, v.destDir, root)
+	v.subtrees.wg.Wait()
+	if err == nil {
+		err = v.subtrees.getErr()
+	}
+
+	summary := root.wait()
+	if err == nil && (summary.Verification.Verified() > 0 || summary.Verification.Extra > 0) {
+		v.finishSubtree(summary)
+	}
+	return err
+}
+
+// startSubtree は最上位のディレクトリの検証を空いているワーカーで開始する（空くまで待つ）
+func (v *Verifier) startSubtree(sourcePath, destPath string) error {
+	r := v.subtrees
+	select {
+	case r.slots <- struct{}{}:
+	case <-v.ctx.Done():
+		return errcode.Errorf(errcode.ErrCancelled, "検証処理がキャンセルされました")
+	}
+	// ほかのディレクトリの走査に失敗した場合は、新たに開始しない
+	if err := r.getErr(); err != nil {
+		<-r.slots
+		return err
+	}
+
+	dir, err := pathkey.Rel(v.sourceDir, sourcePath)
+	if err != nil {
+		dir = sourcePath
+	}
+	st := newSubtree(dir)
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer func() { <-r.slots }()
+
+		err := v.verifyTree(sourcePath, destPath, st)
+		summary := st.wait()
+		if err != nil {
+			r.setErr(err)
+			return
+		}
+		v.finishSubtree(summary)
+	}()
+	return nil
+}
+
+// finishSubtree は最上位のディレクトリの検証結果を記録し、コールバック関数に通知する
+func (v *Verifier) finishSubtree(summary SubtreeSummary) {
+	v.subtreeMu.Lock()
+	defer v.subtreeMu.Unlock()
+	v.subtreeResults = append(v.subtreeResults, summary)
+	if v.subtreeFunc != nil {
+		v.subtreeFunc(summary)
+	}
+}
@@ -0,0 +1,129 @@
+package verifier
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/transform"
+)
+
+// selectTransforms はソースファイルにコピー時に適用した変換を返す
+func (v *Verifier) selectTransforms(sourcePath string) []transform.Transformer {
+	pipeline := v.options.Transforms
+	if pipeline == nil {
+		return nil
+	}
+
+	var head []byte
+	if pipeline.NeedsContent() {
+		if file, err := v.fs.Open(sourcePath); err == nil {
+			buf := make([]byte, transform.SniffLen)
+			n, _ := io.ReadFull(file, buf)
+			head = buf[:n]
+			file.Close()
+		}
+	}
+	return pipeline.Select(sourcePath, head)
+}
+
+// verifyTransformed はソースに変換を適用した内容のハッシュを宛先のハッシュと比較する
+// 結果のSourceHashには比較に使用した変換後のハッシュを設定し、DBには変換前のハッシュを記録する
+func (v *Verifier) verifyTransformed(result *VerificationResult, sourcePath, destPath string, sourceInfo os.FileInfo, transformers []transform.Transformer) *VerificationResult {
+	record := database.FileInfo{
+		Path:         result.Path,
+		Size:         sourceInfo.Size(),
+		ModTime:      sourceInfo.ModTime(),
+		LastSyncTime: time.Now(),
+	}
+	fail := func(status database.FileStatus, err error) *VerificationResult {
+		result.Error = err
+		if v.db != nil {
+			record.Status = status
+			record.LastError = err.Error()
+			record.Error = errcode.Describe(err)
+			v.recordFile(record)
+		}
+		return result
+	}
+
+	info, err := v.digestTransformed(sourcePath, transformers)
+	if err != nil {
+		return fail(database.StatusFailed, errcode.Errorf(errcode.ErrSourceRead, "ソースファイルの変換後のハッシュ計算エラー: %w", err))
+	}
+	record.Transform = info
+	record.SourceHash = info.OriginalHash
+	record.HashAlgo = v.options.HashAlgorithm
+	result.SourceHash = info.OutputHash
+	result.SizeMatch = info.OutputSize == result.DestSize
+
+	destHash, err := v.hashFile(destPath)
+	if err != nil {
+		return fail(database.StatusFailed, errcode.Errorf(errcode.ErrDestRead, "宛先ファイルのハッシュ計算エラー: %w", err))
+	}
+	destHash = v.options.Faults.CorruptHash(destHash)
+	result.DestHash = destHash
+	record.DestHash = destHash
+
+	result.HashMatch = result.SizeMatch && info.OutputHash == destHash
+	if !result.HashMatch && result.SizeMatch {
+		// 一致しない場合は変換をやり直し、宛先を読み直して再検証する
+		rehash := func() (string, string, error) {
+			info, err := v.digestTransformed(sourcePath, transformers)
+			if err != nil {
+				return "", "", fmt.Errorf("ソースファイルの変換後のハッシュ計算エラー: %w", err)
+			}
+			destHash, err := v.hashFile(destPath)
+			if err != nil {
+				return "", "", fmt.Errorf("宛先ファイルのハッシュ計算エラー: %w", err)
+			}
+			return info.OutputHash, v.options.Faults.CorruptHash(destHash), nil
+		}
+		if expected, d, ok := v.recheck(result, rehash); ok {
+			result.SourceHash, result.DestHash, record.DestHash = expected, d, d
+			result.HashMatch, result.Intermittent = true, true
+		}
+	}
+	if !result.HashMatch {
+		return fail(database.StatusMismatch, errcode.Errorf(errcode.ErrHashMismatch, "変換後のハッシュ値が一致しません (変換後: %s, 宛先: %s)%s", info.OutputHash, result.DestHash, recheckNote(result)))
+	}
+
+	v.stampDest(result.Path, destPath, sourceInfo, result.DestHash, info.OriginalHash)
+	if v.db != nil {
+		record.Status, record.LastError, record.Reason = verifiedStatus(result)
+		v.recordFile(record)
+	}
+	return result
+}
+
+// digestTransformed はソースファイルの変換前と変換後の内容のハッシュを計算する
+func (v *Verifier) digestTransformed(sourcePath string, transformers []transform.Transformer) (*database.TransformInfo, error) {
+	file, err := v.fs.Open(sourcePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	original, err := v.hasher.NewHash()
+	if err != nil {
+		return nil, err
+	}
+	output, err := v.hasher.NewHash()
+	if err != nil {
+		return nil, err
+	}
+	size, err := transform.Digest(file, transformers, original, output)
+	if err != nil {
+		return nil, err
+	}
+	return &database.TransformInfo{
+		Transformers: transform.NameList(transformers),
+		OriginalHash: hex.EncodeToString(original.Sum(nil)),
+		OutputHash:   hex.EncodeToString(output.Sum(nil)),
+		OutputSize:   size,
+	}, nil
+}
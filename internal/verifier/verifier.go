@@ -2,31 +2,117 @@ package verifier
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/sakuhanight/gopier/internal/audit"
+	"github.com/sakuhanight/gopier/internal/batch"
 	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/errcode"
+	"github.com/sakuhanight/gopier/internal/faultinject"
 	"github.com/sakuhanight/gopier/internal/filter"
+	"github.com/sakuhanight/gopier/internal/fsmeta"
 	"github.com/sakuhanight/gopier/internal/hasher"
+	"github.com/sakuhanight/gopier/internal/logger"
+	"github.com/sakuhanight/gopier/internal/overlap"
+	"github.com/sakuhanight/gopier/internal/pathkey"
+	"github.com/sakuhanight/gopier/internal/sidecar"
 	"github.com/sakuhanight/gopier/internal/stats"
+	"github.com/sakuhanight/gopier/internal/transform"
+	"github.com/sakuhanight/gopier/internal/vfs"
 )
 
 // ProgressCallback は進捗報告のためのコールバック関数型
 type ProgressCallback func(current, total int64, currentFile string)
 
+// ExtrasAction は宛先にのみ存在する余分なファイルの扱いを表す型
+type ExtrasAction string
+
+const (
+	// ExtrasReport は余分なファイルを報告のみする
+	ExtrasReport ExtrasAction = "report"
+	// ExtrasIgnore は余分なファイルを報告せず、データベースにも記録しない
+	ExtrasIgnore ExtrasAction = "ignore"
+	// ExtrasDelete は余分なファイルを削除する
+	ExtrasDelete ExtrasAction = "delete"
+	// ExtrasQuarantine は余分なファイルを隔離ディレクトリへ移動する
+	ExtrasQuarantine ExtrasAction = "move-to-quarantine"
+)
+
+// ParseExtrasAction は文字列をExtrasActionに変換する
+func ParseExtrasAction(s string) (ExtrasAction, error) {
+	switch ExtrasAction(s) {
+	case "", ExtrasReport:
+		return ExtrasReport, nil
+	case ExtrasIgnore:
+		return ExtrasIgnore, nil
+	case ExtrasDelete:
+		return ExtrasDelete, nil
+	case ExtrasQuarantine:
+		return ExtrasQuarantine, nil
+	default:
+		return "", fmt.Errorf("無効な余分ファイルの処理方法: %s (report, ignore, delete, move-to-quarantineのいずれかを指定してください)", s)
+	}
+}
+
 // Options は検証オプションを表す構造体
 type Options struct {
-	BufferSize       int           // ハッシュ計算のバッファサイズ
-	Recursive        bool          // 再帰的に検証するかどうか
-	HashAlgorithm    string        // ハッシュアルゴリズム
-	ProgressInterval time.Duration // 進捗報告の間隔
-	MaxConcurrent    int           // 最大並行検証数
-	FailFast         bool          // 最初のエラーで停止するかどうか
-	IgnoreMissing    bool          // 存在しないファイルを無視するかどうか
-	IgnoreExtra      bool          // 余分なファイルを無視するかどうか
+	BufferSize         int                 // ハッシュ計算のバッファサイズ
+	Recursive          bool                // 再帰的に検証するかどうか
+	HashAlgorithm      string              // ハッシュアルゴリズム
+	ProgressInterval   time.Duration       // 進捗報告の間隔
+	MaxConcurrent      int                 // 最大並行検証数
+	SubtreeWorkers     int                 // 並行して検証する最上位のディレクトリ数（0の場合は最上位のディレクトリごとに集計しない）
+	FailFast           bool                // 最初のエラーで停止するかどうか
+	IgnoreMissing      bool                // 存在しないファイルを無視するかどうか
+	IgnoreExtra        bool                // 余分なファイルを無視するかどうか
+	ExtrasAction       ExtrasAction        // 余分なファイルの処理方法
+	ExtrasRules        []ExtrasRule        // パターンごとの余分なファイルの処理方法（最初に一致した規則を使用し、一致しない場合はExtrasAction）
+	QuarantineDir      string              // 隔離先ディレクトリ（空の場合は宛先ディレクトリ名に.quarantineを付与）
+	ActionDest         string              // 余分なファイルの削除・隔離とハッシュの記録を行う実際の宛先ディレクトリ（宛先を別の経路から読み込んで検証する場合、空の場合は検証する宛先）
+	IncludeHidden      bool                // 隠しファイル（ドットファイルを含む）を検証するかどうか
+	IncludeSystem      bool                // システムファイル（Windowsのみ）を検証するかどうか
+	Transforms         *transform.Pipeline // コピー時に適用した変換の規則（変換後の内容と比較する）
+	MetaSidecar        bool                // コピー時にメタデータのファイル（.gopier.meta）を書き込んだかどうか
+	IgnoreErrorsOn     string              // エラーを無視するパスのパターン（カンマ区切り、一致したパス以下の失敗は別に数える）
+	MismatchRetries    int                 // ハッシュが一致しなかった場合に、ソースと宛先を読み直して再検証する回数（0は再検証しない）
+	MismatchRetryDelay time.Duration       // 再検証の前の待ち時間
+	Owner              *fsmeta.Owner       // 宛先の所有者として期待する値（nilの場合は比較しない）
+	DropCache          bool                // キャッシュを経由せずにディスクから読み込んでハッシュ値を計算するかどうか
+	ModTimePrecision   time.Duration       // 更新日時を比較する精度（差がこの値未満であれば一致、0の場合は比較しない）
+	StampXattr         bool                // 一致した宛先のファイルの拡張属性（WindowsではADS）にハッシュを記録するかどうか
+	DetailedReport     bool                // レポートにファイルごとの処理時間・再検証回数・ワーカー・スループットを含めるかどうか
+	ResumeChunkSize    int64               // 大きいファイルの検証を中断した位置から再開できるよう、ハッシュ値の計算の途中の状態を記録する間隔（0の場合は記録しない、DBが必要）
+
+	// 余分なファイルを削除する前に、削除するファイルの一覧を渡して呼び出す（nilの場合は確認せずに削除する）
+	// falseを返した場合は削除せず、余分なファイルを報告のみ行う
+	ConfirmDelete func(preview *DeletePreview) bool
+
+	// 再検証の経過を記録するロガー（nilの場合は記録しない）
+	Logger *logger.Logger
+
+	// 動作確認のために擬似的な障害を発生させる（nilの場合は発生させない）
+	Faults *faultinject.Injector
+
+	// 完了した操作を記録する監査ログ（nilの場合は記録しない）
+	Audit *audit.Log
+
+	// 確認済みの不一致（db ack）として報告しないパスかどうかを判定する（nilの場合は除外しない）
+	// 一致したパスの失敗は検証結果・集計・レポートに含めず、件数のみ数える
+	Suppress func(path string) bool
+
+	// ソース・宛先のファイルシステム（nilの場合はOSのファイルシステムを使用する）
+	FS vfs.FS
+
+	// ハッシュ値の計算の実装（nilの場合はHashAlgorithmの組み込みの実装を使用する）
+	// 指定した場合はHashAlgorithmの代わりに実装のアルゴリズムの名前をDBと監査ログに記録する
+	Hasher hasher.Interface
 }
 
 // DefaultOptions はデフォルトのオプションを返す
@@ -40,23 +126,74 @@ func DefaultOptions() Options {
 		FailFast:         false,
 		IgnoreMissing:    false,
 		IgnoreExtra:      false,
+		ExtrasAction:     ExtrasReport,
+		IncludeHidden:    true,
+		IncludeSystem:    true,
 	}
 }
 
 // VerificationResult は検証結果を表す構造体
 type VerificationResult struct {
-	Path         string    // ファイルパス（相対パス）
-	SourceExists bool      // ソースファイルが存在するかどうか
-	DestExists   bool      // 宛先ファイルが存在するかどうか
-	SizeMatch    bool      // サイズが一致するかどうか
-	HashMatch    bool      // ハッシュが一致するかどうか
-	SourceHash   string    // ソースファイルのハッシュ
-	DestHash     string    // 宛先ファイルのハッシュ
-	SourceSize   int64     // ソースファイルのサイズ
-	DestSize     int64     // 宛先ファイルのサイズ
-	SourceTime   time.Time // ソースファイルの更新時間
-	DestTime     time.Time // 宛先ファイルの更新時間
-	Error        error     // エラー情報
+	Path         string        // ファイルパス（相対パス）
+	SourceExists bool          // ソースファイルが存在するかどうか
+	DestExists   bool          // 宛先ファイルが存在するかどうか
+	SizeMatch    bool          // サイズが一致するかどうか
+	HashMatch    bool          // ハッシュが一致するかどうか
+	SourceHash   string        // ソースファイルのハッシュ
+	DestHash     string        // 宛先ファイルのハッシュ
+	SourceSize   int64         // ソースファイルのサイズ
+	DestSize     int64         // 宛先ファイルのサイズ
+	SourceTime   time.Time     // ソースファイルの更新時間
+	DestTime     time.Time     // 宛先ファイルの更新時間
+	SourceLink   string        // ソースのシンボリックリンク・ジャンクションのリンク先（リンクとして比較した場合のみ）
+	DestLink     string        // 宛先のシンボリックリンク・ジャンクションのリンク先（リンクとして比較した場合のみ）
+	Action       string        // 余分なファイルに対して実行した処理（deleted, quarantined）
+	Error        error         // エラー情報
+	Ignored      bool          // エラーを無視するパスのため、失敗として扱わなかったかどうか
+	Rechecks     int           // ハッシュが一致しなかったため再検証した回数
+	Intermittent bool          // 一度一致せず、再検証で一致したかどうか
+	Duration     time.Duration // 検証にかかった時間（ワーカーを待っていた時間は含めない）
+	Worker       int           // 検証したワーカーの番号（0から）
+}
+
+// Throughput は検証したソースのバイト数を検証にかかった時間で割った値（バイト/秒）を返す
+func (r VerificationResult) Throughput() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.SourceSize) / r.Duration.Seconds()
+}
+
+// isFailure は検証結果が失敗として扱われるかどうかを判断する
+func (r VerificationResult) isFailure() bool {
+	// 余分なファイルの削除・隔離に成功した場合は失敗として扱わない
+	if r.Action != "" && !r.SourceExists && r.Error == nil {
+		return false
+	}
+	return r.Error != nil || !r.HashMatch || !r.SourceExists || !r.DestExists
+}
+
+// outcome は検証結果を集計用に分類する
+func (r VerificationResult) outcome() database.VerifyOutcome {
+	switch {
+	case !r.SourceExists && r.DestExists:
+		return database.VerifyExtra
+	case !r.SourceExists:
+		return database.VerifyError
+	case !r.DestExists:
+		return database.VerifyMissingDest
+	case errors.Is(r.Error, errcode.ErrLinkMismatch):
+		return database.VerifyLinkMismatch
+	case r.HashMatch && r.Intermittent:
+		return database.VerifyIntermittent
+	case r.HashMatch:
+		return database.VerifyMatched
+	case !r.SizeMatch || (r.SourceHash != "" && r.DestHash != ""):
+		return database.VerifyMismatched
+	default:
+		// ハッシュ計算に失敗した
+		return database.VerifyError
+	}
 }
 
 // Verifier はファイル検証処理を管理する構造体
@@ -66,18 +203,40 @@ type Verifier struct {
 	options       Options
 	stats         *stats.Stats
 	filter        *filter.Filter
-	hasher        *hasher.Hasher
+	hasher        hasher.Interface
+	fs            vfs.FS
 	db            *database.SyncDB
 	progressChan  chan string
 	progressFunc  ProgressCallback
 	wg            sync.WaitGroup
-	semaphore     chan struct{}
+	semaphore     chan int // 空いているワーカーの番号（取り出してから検証し、終了後に戻す）
 	ctx           context.Context
 	cancel        context.CancelFunc
 	results       []VerificationResult
 	resultsMutex  sync.Mutex
 	errCount      int64
+	ignoredCount  int64
+	suppressed    int64
 	errCountMutex sync.Mutex
+	sessionID     int64
+
+	// VerifyFilesで検証する、宛先のパスがソースと異なるファイルの宛先の相対パス（ソースの相対パスがキー）
+	destPaths map[string]string
+
+	// 削除の確認で削除しないことになった場合はtrue（余分なファイルは報告のみ行う）
+	deleteDeclined bool
+
+	// ソースの中にある宛先（検証中に走査しない）
+	destGuard *overlap.Guard
+
+	// ハッシュを記録できないファイルシステムの警告を出力した
+	stampWarned atomic.Bool
+
+	// 最上位のディレクトリごとの検証（SubtreeWorkersを指定した場合のみ）
+	subtrees       *subtreeRunner
+	subtreeFunc    SubtreeCallback
+	subtreeResults []SubtreeSummary
+	subtreeMu      sync.Mutex
 }
 
 // NewVerifier は新しいVerifierを作成する
@@ -85,11 +244,18 @@ func NewVerifier(sourceDir, destDir string, options Options, fileFilter *filter.
 	ctx, cancel := context.WithCancel(context.Background())
 
 	// セマフォの初期化
-	semaphore := make(chan struct{}, options.MaxConcurrent)
+	semaphore := make(chan int, options.MaxConcurrent)
+	for i := 0; i < options.MaxConcurrent; i++ {
+		semaphore <- i
+	}
 
 	// ハッシャーの初期化
-	hashAlgo := hasher.Algorithm(options.HashAlgorithm)
-	fileHasher := hasher.NewHasher(hashAlgo, options.BufferSize)
+	var fileHasher hasher.Interface = options.Hasher
+	if fileHasher != nil {
+		options.HashAlgorithm = fileHasher.GetAlgorithmName()
+	} else {
+		fileHasher = hasher.NewHasher(hasher.Algorithm(options.HashAlgorithm), options.BufferSize)
+	}
 
 	return &Verifier{
 		sourceDir:    sourceDir,
@@ -98,6 +264,7 @@ func NewVerifier(sourceDir, destDir string, options Options, fileFilter *filter.
 		stats:        stats.NewStats(),
 		filter:       fileFilter,
 		hasher:       fileHasher,
+		fs:           vfs.Or(options.FS),
 		db:           syncDB,
 		progressChan: make(chan string, 100),
 		ctx:          ctx,
@@ -127,6 +294,20 @@ func (v *Verifier) GetResults() []VerificationResult {
 	return v.results
 }
 
+// GetFailures は失敗として扱った検証結果を返す（エラーを無視したものは含まない）
+func (v *Verifier) GetFailures() []VerificationResult {
+	v.resultsMutex.Lock()
+	defer v.resultsMutex.Unlock()
+
+	var failures []VerificationResult
+	for _, r := range v.results {
+		if r.isFailure() && !r.Ignored {
+			failures = append(failures, r)
+		}
+	}
+	return failures
+}
+
 // GetErrorCount はエラー数を返す
 func (v *Verifier) GetErrorCount() int64 {
 	v.errCountMutex.Lock()
@@ -134,14 +315,60 @@ func (v *Verifier) GetErrorCount() int64 {
 	return v.errCount
 }
 
+// GetSummary は検証結果の集計を返す
+func (v *Verifier) GetSummary() database.VerificationSummary {
+	v.resultsMutex.Lock()
+	defer v.resultsMutex.Unlock()
+
+	var summary database.VerificationSummary
+	for _, r := range v.results {
+		summary.Add(r.outcome(), r.SourceSize)
+	}
+	return summary
+}
+
+// GetGradingSummary は合格の基準の判定に使用する検証結果の集計を返す
+// --ignore-errors-onに一致したため失敗として扱わなかった結果は含めない
+func (v *Verifier) GetGradingSummary() database.VerificationSummary {
+	v.resultsMutex.Lock()
+	defer v.resultsMutex.Unlock()
+
+	var summary database.VerificationSummary
+	for _, r := range v.results {
+		if !r.Ignored {
+			summary.Add(r.outcome(), r.SourceSize)
+		}
+	}
+	return summary
+}
+
 // addResult は検証結果を追加する
-func (v *Verifier) addResult(result VerificationResult) {
+// 確認済みの不一致として報告しない結果は追加せず、falseを返す
+func (v *Verifier) addResult(result VerificationResult) bool {
+	if result.isFailure() && v.suppress(result.Path) {
+		v.writeAudit(result)
+		v.errCountMutex.Lock()
+		v.suppressed++
+		v.errCountMutex.Unlock()
+		return false
+	}
+
+	// エラーを無視するパスの失敗は別に数え、終了コードや即時エラー停止に影響させない
+	if result.isFailure() && v.ignoreErrors(result.Path) {
+		result.Ignored = true
+		v.errCountMutex.Lock()
+		v.ignoredCount++
+		v.errCountMutex.Unlock()
+	}
+
+	v.writeAudit(result)
+
 	v.resultsMutex.Lock()
 	defer v.resultsMutex.Unlock()
 	v.results = append(v.results, result)
 
 	// エラーカウントの更新
-	if result.Error != nil || !result.HashMatch || !result.SourceExists || !result.DestExists {
+	if result.isFailure() && !result.Ignored {
 		v.errCountMutex.Lock()
 		v.errCount++
 		v.errCountMutex.Unlock()
@@ -151,45 +378,144 @@ func (v *Verifier) addResult(result VerificationResult) {
 			v.cancel()
 		}
 	}
+	return true
+}
+
+// writeAudit は検証結果と余分なファイルに対して実行した処理を監査ログに記録する
+func (v *Verifier) writeAudit(result VerificationResult) {
+	if v.options.Audit == nil {
+		return
+	}
+
+	// 余分なファイルの結果は宛先のパスのため、他のレコードと同じく相対パスで記録する
+	path := result.Path
+	if !result.SourceExists {
+		if rel, err := pathkey.Rel(v.destDir, path); err == nil {
+			path = rel
+		}
+	}
+
+	rec := audit.Record{
+		SessionID:  v.sessionID,
+		Action:     audit.ActionVerify,
+		Path:       pathkey.Normalize(path),
+		Size:       result.SourceSize,
+		SourceHash: result.SourceHash,
+		DestHash:   result.DestHash,
+		Result:     audit.VerifyResult(result.outcome()),
+	}
+	switch result.Action {
+	case "deleted":
+		rec.Action, rec.Result, rec.Size = audit.ActionDelete, audit.ResultSuccess, result.DestSize
+	case "quarantined":
+		rec.Action, rec.Result, rec.Size = audit.ActionQuarantine, audit.ResultSuccess, result.DestSize
+	}
+	if rec.SourceHash != "" || rec.DestHash != "" {
+		rec.HashAlgo = v.options.HashAlgorithm
+	}
+	if result.Error != nil {
+		rec.Error = result.Error.Error()
+	}
+	// 書き込みエラーは監査ログに保持され、実行の終了時に報告する
+	v.options.Audit.Write(rec)
 }
 
 // Verify はファイルの検証を行う
 func (v *Verifier) Verify() error {
+	return v.run(func() error {
+		// ソースディレクトリの存在確認
+		sourceInfo, err := v.fs.Stat(v.sourceDir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				err = errcode.Wrap(errcode.ErrSourceMissing, err)
+			}
+			return fmt.Errorf("ソースディレクトリの確認エラー: %w", err)
+		}
+
+		// ソースがディレクトリの場合
+		if sourceInfo.IsDir() {
+			// ディレクトリの検証（ソースの中にある宛先は対象外）
+			v.destGuard = overlap.NewGuard(v.fs.Stat, v.destDir)
+			if v.options.SubtreeWorkers > 0 {
+				err = v.verifySubtrees()
+			} else {
+				err = v.verifyDirectory(v.sourceDir, v.destDir)
+			}
+
+			// 余分なファイルのチェック（すべての余分なファイルを無視する場合を除く）
+			if err == nil && !v.ignoresAllExtras() {
+				if err = v.confirmDelete(); err == nil {
+					err = v.checkExtraFiles(v.sourceDir, v.destDir)
+				}
+			}
+			return err
+		}
+
+		// 単一ファイルの検証
+		destPath := filepath.Join(v.destDir, filepath.Base(v.sourceDir))
+		_, err = v.verifyFile(v.sourceDir, destPath)
+		return err
+	})
+}
+
+// VerifyPaths は指定された相対パスのファイルのみを検証する
+// パスはスラッシュ区切りのデータベースキー形式で指定する
+func (v *Verifier) VerifyPaths(relPaths []string) error {
+	files := make([]database.FileInfo, len(relPaths))
+	for i, relPath := range relPaths {
+		files[i].Path = relPath
+	}
+	return v.VerifyFiles(files)
+}
+
+// VerifyFiles はデータベースに記録されたファイルのみを検証する
+// 宛先のパスがソースと異なるファイル（フラット化など）は、記録された宛先のパスと比較する
+func (v *Verifier) VerifyFiles(files []database.FileInfo) error {
+	v.destPaths = make(map[string]string)
+	for _, file := range files {
+		if file.DestPath != "" {
+			v.destPaths[file.Path] = file.DestPath
+		}
+	}
+
+	return v.run(func() error {
+		for _, file := range files {
+			// コンテキストのキャンセル確認
+			select {
+			case <-v.ctx.Done():
+				return errcode.Errorf(errcode.ErrCancelled, "検証処理がキャンセルされました")
+			default:
+			}
+
+			destRel := file.Path
+			if file.DestPath != "" {
+				destRel = file.DestPath
+			}
+			v.verifyFileAsync(filepath.Join(v.sourceDir, pathkey.ToNative(file.Path)), filepath.Join(v.destDir, pathkey.ToNative(destRel)))
+		}
+		return nil
+	})
+}
+
+// run は検証セッションの開始・終了と進捗報告を管理しながら検証処理を実行する
+func (v *Verifier) run(walk func() error) error {
 	// 同期セッションの開始
 	var sessionID int64
 	var err error
 	if v.db != nil {
-		sessionID, err = v.db.StartSyncSession()
+		sessionID, err = v.db.StartSession(database.SessionVerify)
 		if err != nil {
 			return fmt.Errorf("同期セッション開始エラー: %w", err)
 		}
 	}
+	v.sessionID = sessionID
 
 	// 進捗報告ゴルーチンの開始
 	if v.progressFunc != nil {
 		go v.reportProgress()
 	}
 
-	// ソースディレクトリの存在確認
-	sourceInfo, err := os.Stat(v.sourceDir)
-	if err != nil {
-		return fmt.Errorf("ソースディレクトリの確認エラー: %w", err)
-	}
-
-	// ソースがディレクトリの場合
-	if sourceInfo.IsDir() {
-		// ディレクトリの検証
-		err = v.verifyDirectory(v.sourceDir, v.destDir)
-
-		// 余分なファイルのチェック（IgnoreExtraがfalseの場合）
-		if err == nil && !v.options.IgnoreExtra {
-			err = v.checkExtraFiles(v.sourceDir, v.destDir)
-		}
-	} else {
-		// 単一ファイルの検証
-		destPath := filepath.Join(v.destDir, filepath.Base(v.sourceDir))
-		_, err = v.verifyFile(v.sourceDir, destPath)
-	}
+	err = walk()
 
 	// すべてのゴルーチンの完了を待つ
 	v.wg.Wait()
@@ -215,11 +541,16 @@ func (v *Verifier) Verify() error {
 			// セッション終了エラーはログに記録するが、元のエラーを返す
 			fmt.Printf("同期セッション終了エラー: %v\n", endErr)
 		}
+
+		// 傾向を分析できるよう、検証結果の集計をセッションに記録する
+		if recErr := v.db.RecordVerification(sessionID, v.GetSummary()); recErr != nil {
+			fmt.Printf("検証結果の記録エラー: %v\n", recErr)
+		}
 	}
 
 	// エラーが発生したかどうかを返す
 	if v.GetErrorCount() > 0 {
-		return fmt.Errorf("%d 個のファイルで不一致が検出されました", v.GetErrorCount())
+		return errcode.Errorf(errcode.ErrVerifyFailed, "%d 個のファイルで不一致が検出されました", v.GetErrorCount())
 	}
 
 	return err
@@ -227,29 +558,42 @@ func (v *Verifier) Verify() error {
 
 // verifyDirectory はディレクトリを再帰的に検証する
 func (v *Verifier) verifyDirectory(sourceDir, destDir string) error {
+	return v.verifyTree(sourceDir, destDir, nil)
+}
+
+// verifyTree はディレクトリを再帰的に検証し、結果を最上位のディレクトリの集計（stがnilの場合は集計しない）にも加える
+func (v *Verifier) verifyTree(sourceDir, destDir string, st *subtree) error {
 	// コンテキストのキャンセル確認
 	select {
 	case <-v.ctx.Done():
-		return fmt.Errorf("検証処理がキャンセルされました")
+		return errcode.Errorf(errcode.ErrCancelled, "検証処理がキャンセルされました")
 	default:
 	}
 
 	// ソースディレクトリを開く
-	entries, err := os.ReadDir(sourceDir)
+	entries, err := v.fs.ReadDir(sourceDir)
 	if err != nil {
+		if sourceDir != v.sourceDir && v.ignoreErrors(sourceDir) {
+			v.addResultTo(st, VerificationResult{
+				Path:         sourceDir,
+				SourceExists: true,
+				Error:        fmt.Errorf("ディレクトリ読み込みエラー: %w", err),
+			})
+			return nil
+		}
 		return fmt.Errorf("ディレクトリ読み込みエラー: %w", err)
 	}
 
 	// 宛先ディレクトリの存在確認
-	if _, err := os.Stat(destDir); os.IsNotExist(err) {
+	if _, err := v.fs.Stat(destDir); os.IsNotExist(err) {
 		if !v.options.IgnoreMissing {
 			result := VerificationResult{
 				Path:         destDir,
 				SourceExists: true,
 				DestExists:   false,
-				Error:        fmt.Errorf("宛先ディレクトリが存在しません"),
+				Error:        errcode.Errorf(errcode.ErrDestMissing, "宛先ディレクトリが存在しません"),
 			}
-			v.addResult(result)
+			v.addResultTo(st, result)
 		}
 		return nil
 	}
@@ -259,14 +603,37 @@ func (v *Verifier) verifyDirectory(sourceDir, destDir string) error {
 		sourcePath := filepath.Join(sourceDir, entry.Name())
 		destPath := filepath.Join(destDir, entry.Name())
 
+		// コピー対象外の隠しファイル・システムファイルと、コピー時に書き込んだメタデータのファイル
+		if v.excludedByAttributes(entry) || v.isSidecar(entry) {
+			continue
+		}
+
 		// ディレクトリの場合
 		if entry.IsDir() {
 			if !v.options.Recursive {
 				continue
 			}
+			info, err := entry.Info()
+			if err != nil {
+				info = nil
+			}
+			if v.destGuard.IsDest(sourcePath, info) {
+				continue
+			}
+			if v.filter != nil && v.filter.ExcludesDir(sourcePath) {
+				continue
+			}
+
+			// 最上位のディレクトリごとに集計する場合は、空いているワーカーで並行して検証する
+			if v.subtrees != nil && sourceDir == v.sourceDir {
+				if err := v.startSubtree(sourcePath, destPath); err != nil {
+					return err
+				}
+				continue
+			}
 
 			// 再帰的に検証
-			if err := v.verifyDirectory(sourcePath, destPath); err != nil {
+			if err := v.verifyTree(sourcePath, destPath, st); err != nil {
 				return err
 			}
 			continue
@@ -280,7 +647,7 @@ func (v *Verifier) verifyDirectory(sourceDir, destDir string) error {
 				Path:  sourcePath,
 				Error: fmt.Errorf("ファイル情報取得エラー: %w", err),
 			}
-			v.addResult(result)
+			v.addResultTo(st, result)
 			continue
 		}
 
@@ -292,29 +659,59 @@ func (v *Verifier) verifyDirectory(sourceDir, destDir string) error {
 		}
 
 		// 非同期でファイルを検証
-		v.wg.Add(1)
-		go func(src, dst string) {
-			defer v.wg.Done()
-
-			// セマフォの取得
-			v.semaphore <- struct{}{}
-			defer func() {
-				<-v.semaphore
-			}()
+		v.verifyFileAsyncTo(st, sourcePath, destPath)
+	}
 
-			result, err := v.verifyFile(src, dst)
-			if err != nil {
-				fmt.Printf("ファイル検証エラー: %v\n", err)
-			}
+	return nil
+}
 
-			// 結果を追加
-			if result != nil {
-				v.addResult(*result)
-			}
-		}(sourcePath, destPath)
+// excludedByAttributes は隠し・システム属性によってエントリを検証対象外とするかどうかを判断する
+func (v *Verifier) excludedByAttributes(entry os.DirEntry) bool {
+	if v.options.IncludeHidden && v.options.IncludeSystem {
+		return false
 	}
+	info, _ := entry.Info()
+	attrs := fsmeta.FileAttributes(entry.Name(), info)
+	return (attrs.Hidden && !v.options.IncludeHidden) || (attrs.System && !v.options.IncludeSystem)
+}
 
-	return nil
+// isSidecar はエントリがコピー時に書き込んだメタデータのファイルかどうかを判断する
+func (v *Verifier) isSidecar(entry os.DirEntry) bool {
+	return v.options.MetaSidecar && !entry.IsDir() && entry.Name() == sidecar.FileName
+}
+
+// verifyFileAsync はファイルの検証をゴルーチンで実行し、結果を追加する
+func (v *Verifier) verifyFileAsync(sourcePath, destPath string) {
+	v.verifyFileAsyncTo(nil, sourcePath, destPath)
+}
+
+// verifyFileAsyncTo はファイルの検証をゴルーチンで実行し、結果を最上位のディレクトリの集計にも加える
+func (v *Verifier) verifyFileAsyncTo(st *subtree, sourcePath, destPath string) {
+	v.wg.Add(1)
+	st.begin()
+	go func(src, dst string) {
+		defer v.wg.Done()
+		defer st.done()
+
+		// セマフォの取得
+		worker := <-v.semaphore
+		defer func() {
+			v.semaphore <- worker
+		}()
+
+		start := time.Now()
+		result, err := v.verifyFile(src, dst)
+		if err != nil {
+			fmt.Printf("ファイル検証エラー: %v\n", err)
+		}
+
+		// 結果を追加
+		if result != nil {
+			result.Duration = time.Since(start)
+			result.Worker = worker
+			v.addResultTo(st, *result)
+		}
+	}(sourcePath, destPath)
 }
 
 // verifyFile は単一ファイルを検証する
@@ -322,12 +719,12 @@ func (v *Verifier) verifyFile(sourcePath, destPath string) (*VerificationResult,
 	// コンテキストのキャンセル確認
 	select {
 	case <-v.ctx.Done():
-		return nil, fmt.Errorf("検証処理がキャンセルされました")
+		return nil, errcode.Errorf(errcode.ErrCancelled, "検証処理がキャンセルされました")
 	default:
 	}
 
 	// 相対パスの計算
-	relPath, err := filepath.Rel(v.sourceDir, sourcePath)
+	relPath, err := pathkey.Rel(v.sourceDir, sourcePath)
 	if err != nil {
 		relPath = filepath.Base(sourcePath)
 	}
@@ -351,8 +748,13 @@ func (v *Verifier) verifyFile(sourcePath, destPath string) (*VerificationResult,
 		HashMatch:    false,
 	}
 
+	// 宛先がシンボリックリンク・ジャンクションの場合は、リンクをたどらずにリンク先を比較する
+	if v.verifyLink(result, sourcePath, destPath) {
+		return result, nil
+	}
+
 	// ソースファイルの情報を取得
-	sourceInfo, err := os.Stat(sourcePath)
+	sourceInfo, err := v.fs.Stat(sourcePath)
 	if err != nil {
 		result.SourceExists = false
 		result.Error = fmt.Errorf("ソースファイル確認エラー: %w", err)
@@ -362,8 +764,15 @@ func (v *Verifier) verifyFile(sourcePath, destPath string) (*VerificationResult,
 	result.SourceSize = sourceInfo.Size()
 	result.SourceTime = sourceInfo.ModTime()
 
-	// 宛先ファイルの情報を取得
-	destInfo, err := os.Stat(destPath)
+	// 宛先ファイルの情報を取得（個別のファイルがない場合は、セグメントにまとめて書き込んだ内容と比較する）
+	destInfo, err := v.fs.Stat(destPath)
+	hashDest := func() (string, error) { return v.hashFile(destPath) }
+	batched := false
+	if os.IsNotExist(err) {
+		if info, hash, ok := v.batchedDest(relPath); ok {
+			destInfo, hashDest, batched, err = info, hash, true, nil
+		}
+	}
 	if err != nil {
 		result.DestExists = false
 
@@ -373,7 +782,7 @@ func (v *Verifier) verifyFile(sourcePath, destPath string) (*VerificationResult,
 			return nil, nil
 		}
 
-		result.Error = fmt.Errorf("宛先ファイル確認エラー: %w", err)
+		result.Error = fmt.Errorf("宛先ファイル確認エラー: %w", errcode.Wrap(errcode.ErrDestMissing, err))
 
 		// データベースに記録
 		if v.db != nil {
@@ -384,8 +793,9 @@ func (v *Verifier) verifyFile(sourcePath, destPath string) (*VerificationResult,
 				Status:       database.StatusMismatch,
 				LastSyncTime: time.Now(),
 				LastError:    "宛先ファイルが存在しません",
+				Error:        errcode.Describe(result.Error),
 			}
-			v.db.AddFile(fileInfo)
+			v.recordFile(fileInfo)
 		}
 
 		return result, nil
@@ -394,10 +804,9 @@ func (v *Verifier) verifyFile(sourcePath, destPath string) (*VerificationResult,
 	result.DestSize = destInfo.Size()
 	result.DestTime = destInfo.ModTime()
 
-	// サイズの比較
-	result.SizeMatch = sourceInfo.Size() == destInfo.Size()
-	if !result.SizeMatch {
-		result.Error = fmt.Errorf("ファイルサイズが一致しません (ソース: %d, 宛先: %d)", sourceInfo.Size(), destInfo.Size())
+	// 所有者を指定してコピーした場合は宛先の所有者も比較する（セグメントの中のファイルには所有者がない）
+	if v.options.Owner != nil && !batched && !v.options.Owner.Matches(destInfo) {
+		result.Error = errcode.Errorf(errcode.ErrOwnerMismatch, "所有者が一致しません (指定: %s)", v.options.Owner)
 
 		// データベースに記録
 		if v.db != nil {
@@ -407,18 +816,19 @@ func (v *Verifier) verifyFile(sourcePath, destPath string) (*VerificationResult,
 				ModTime:      sourceInfo.ModTime(),
 				Status:       database.StatusMismatch,
 				LastSyncTime: time.Now(),
-				LastError:    fmt.Sprintf("ファイルサイズが一致しません (ソース: %d, 宛先: %d)", sourceInfo.Size(), destInfo.Size()),
+				LastError:    fmt.Sprintf("所有者が一致しません (指定: %s)", v.options.Owner),
+				Error:        errcode.Describe(result.Error),
 			}
-			v.db.AddFile(fileInfo)
+			v.recordFile(fileInfo)
 		}
 
 		return result, nil
 	}
 
-	// ソースファイルのハッシュを計算
-	sourceHash, err := v.hasher.HashFile(sourcePath)
-	if err != nil {
-		result.Error = fmt.Errorf("ソースファイルのハッシュ計算エラー: %w", err)
+	// 精度を指定した場合は更新日時も比較する（セグメントの中のファイルは記録した更新日時と比較する）
+	if !fsmeta.ModTimeWithin(sourceInfo.ModTime(), destInfo.ModTime(), v.options.ModTimePrecision) {
+		result.Error = errcode.Errorf(errcode.ErrModTimeMismatch, "更新日時が一致しません (ソース: %s, 宛先: %s)",
+			sourceInfo.ModTime().Format(time.RFC3339Nano), destInfo.ModTime().Format(time.RFC3339Nano))
 
 		// データベースに記録
 		if v.db != nil {
@@ -426,22 +836,26 @@ func (v *Verifier) verifyFile(sourcePath, destPath string) (*VerificationResult,
 				Path:         relPath,
 				Size:         sourceInfo.Size(),
 				ModTime:      sourceInfo.ModTime(),
-				Status:       database.StatusFailed,
+				Status:       database.StatusMismatch,
 				LastSyncTime: time.Now(),
-				LastError:    fmt.Sprintf("ソースハッシュ計算エラー: %v", err),
+				LastError:    result.Error.Error(),
+				Error:        errcode.Describe(result.Error),
 			}
-			v.db.AddFile(fileInfo)
+			v.recordFile(fileInfo)
 		}
 
 		return result, nil
 	}
 
-	result.SourceHash = sourceHash
+	// コピー時に内容を変換したファイルは、変換をやり直した結果と比較する
+	if transformers := v.selectTransforms(sourcePath); len(transformers) > 0 {
+		return v.verifyTransformed(result, sourcePath, destPath, sourceInfo, transformers), nil
+	}
 
-	// 宛先ファイルのハッシュを計算
-	destHash, err := v.hasher.HashFile(destPath)
-	if err != nil {
-		result.Error = fmt.Errorf("宛先ファイルのハッシュ計算エラー: %w", err)
+	// サイズの比較
+	result.SizeMatch = sourceInfo.Size() == destInfo.Size()
+	if !result.SizeMatch {
+		result.Error = errcode.Errorf(errcode.ErrSizeMismatch, "ファイルサイズが一致しません (ソース: %d, 宛先: %d)", sourceInfo.Size(), destInfo.Size())
 
 		// データベースに記録
 		if v.db != nil {
@@ -449,27 +863,123 @@ func (v *Verifier) verifyFile(sourcePath, destPath string) (*VerificationResult,
 				Path:         relPath,
 				Size:         sourceInfo.Size(),
 				ModTime:      sourceInfo.ModTime(),
-				Status:       database.StatusFailed,
+				Status:       database.StatusMismatch,
 				LastSyncTime: time.Now(),
-				LastError:    fmt.Sprintf("宛先ハッシュ計算エラー: %v", err),
+				LastError:    fmt.Sprintf("ファイルサイズが一致しません (ソース: %d, 宛先: %d)", sourceInfo.Size(), destInfo.Size()),
+				Error:        errcode.Describe(result.Error),
 			}
-			v.db.AddFile(fileInfo)
+			v.recordFile(fileInfo)
 		}
 
 		return result, nil
 	}
 
+	// 大きいファイルは中断した位置から再開できるよう、途中の状態を記録しながらソースと宛先を並行して読み込む
+	var sourceHash, destHash string
+	firstMismatch, resumed := int64(-1), false
+	if !batched {
+		sourceHash, destHash, firstMismatch, resumed, err = v.hashResumable(relPath, sourcePath, destPath, sourceInfo, destInfo)
+		if errors.Is(err, errcode.ErrCancelled) {
+			return nil, err
+		}
+		if err != nil {
+			result.Error = err
+
+			// データベースに記録
+			if v.db != nil {
+				fileInfo := database.FileInfo{
+					Path:         relPath,
+					Size:         sourceInfo.Size(),
+					ModTime:      sourceInfo.ModTime(),
+					Status:       database.StatusFailed,
+					LastSyncTime: time.Now(),
+					LastError:    err.Error(),
+					Error:        errcode.Describe(result.Error),
+				}
+				v.recordFile(fileInfo)
+			}
+
+			return result, nil
+		}
+	}
+
+	if !resumed {
+		// ソースファイルのハッシュを計算
+		sourceHash, err = v.hashFile(sourcePath)
+		if err != nil {
+			result.Error = errcode.Errorf(errcode.ErrSourceRead, "ソースファイルのハッシュ計算エラー: %w", err)
+
+			// データベースに記録
+			if v.db != nil {
+				fileInfo := database.FileInfo{
+					Path:         relPath,
+					Size:         sourceInfo.Size(),
+					ModTime:      sourceInfo.ModTime(),
+					Status:       database.StatusFailed,
+					LastSyncTime: time.Now(),
+					LastError:    fmt.Sprintf("ソースハッシュ計算エラー: %v", err),
+					Error:        errcode.Describe(result.Error),
+				}
+				v.recordFile(fileInfo)
+			}
+
+			return result, nil
+		}
+
+		// 宛先ファイルのハッシュを計算
+		destHash, err = hashDest()
+		if err != nil {
+			result.Error = errcode.Errorf(errcode.ErrDestRead, "宛先ファイルのハッシュ計算エラー: %w", err)
+
+			// データベースに記録
+			if v.db != nil {
+				fileInfo := database.FileInfo{
+					Path:         relPath,
+					Size:         sourceInfo.Size(),
+					ModTime:      sourceInfo.ModTime(),
+					Status:       database.StatusFailed,
+					LastSyncTime: time.Now(),
+					LastError:    fmt.Sprintf("宛先ハッシュ計算エラー: %v", err),
+					Error:        errcode.Describe(result.Error),
+				}
+				v.recordFile(fileInfo)
+			}
+
+			return result, nil
+		}
+	}
+	destHash = v.options.Faults.CorruptHash(destHash)
+
+	result.SourceHash = sourceHash
 	result.DestHash = destHash
 
 	// ハッシュ値をデータベースに記録
 	if v.db != nil {
-		v.db.UpdateFileHash(relPath, sourceHash, destHash)
+		v.db.UpdateFileHash(relPath, sourceHash, destHash, v.options.HashAlgorithm)
 	}
 
-	// ハッシュ値の比較
+	// ハッシュ値の比較（一致しない場合は読み直して再検証する）
 	result.HashMatch = sourceHash == destHash
 	if !result.HashMatch {
-		result.Error = fmt.Errorf("ハッシュ値が一致しません (ソース: %s, 宛先: %s)", sourceHash, destHash)
+		rehash := func() (string, string, error) {
+			sourceHash, err := v.hashFile(sourcePath)
+			if err != nil {
+				return "", "", fmt.Errorf("ソースファイルのハッシュ計算エラー: %w", err)
+			}
+			destHash, err := hashDest()
+			if err != nil {
+				return "", "", fmt.Errorf("宛先ファイルのハッシュ計算エラー: %w", err)
+			}
+			return sourceHash, v.options.Faults.CorruptHash(destHash), nil
+		}
+		if s, d, ok := v.recheck(result, rehash); ok {
+			sourceHash, destHash = s, d
+			result.SourceHash, result.DestHash = s, d
+			result.HashMatch, result.Intermittent = true, true
+		}
+	}
+	if !result.HashMatch {
+		result.Error = errcode.Errorf(errcode.ErrHashMismatch, "ハッシュ値が一致しません (ソース: %s, 宛先: %s)%s%s", sourceHash, destHash, mismatchNote(firstMismatch), recheckNote(result))
 
 		// データベースに記録
 		if v.db != nil {
@@ -480,36 +990,126 @@ func (v *Verifier) verifyFile(sourcePath, destPath string) (*VerificationResult,
 				Status:       database.StatusMismatch,
 				SourceHash:   sourceHash,
 				DestHash:     destHash,
+				HashAlgo:     v.options.HashAlgorithm,
 				LastSyncTime: time.Now(),
-				LastError:    "ハッシュ値が一致しません",
+				LastError:    "ハッシュ値が一致しません" + mismatchNote(firstMismatch),
+				Error:        errcode.Describe(result.Error),
 			}
-			v.db.AddFile(fileInfo)
+			v.recordFile(fileInfo)
 		}
 
 		return result, nil
 	}
 
 	// 検証成功の記録
+	v.stampDest(relPath, destPath, sourceInfo, destHash, sourceHash)
 	if v.db != nil {
+		status, message, reason := verifiedStatus(result)
 		fileInfo := database.FileInfo{
 			Path:         relPath,
 			Size:         sourceInfo.Size(),
 			ModTime:      sourceInfo.ModTime(),
-			Status:       database.StatusVerified,
+			Status:       status,
 			SourceHash:   sourceHash,
 			DestHash:     destHash,
+			HashAlgo:     v.options.HashAlgorithm,
 			LastSyncTime: time.Now(),
+			LastError:    message,
+			Reason:       reason,
 		}
-		v.db.AddFile(fileInfo)
+		v.recordFile(fileInfo)
 	}
 
 	return result, nil
 }
 
 // checkExtraFiles は宛先ディレクトリに余分なファイルがないかチェックする
+// セグメントにまとめて書き込んだファイルのうちソースから削除されたものも、余分なファイルとして扱う
 func (v *Verifier) checkExtraFiles(sourceDir, destDir string) error {
+	err := v.findExtras(sourceDir, destDir, func(destPath string, info os.FileInfo) {
+		action := v.extrasActionFor(destPath)
+		if action == ExtrasIgnore {
+			return
+		}
+
+		// ディレクトリの場合
+		if info == nil {
+			// 余分なディレクトリとして報告
+			result := VerificationResult{
+				Path:         destPath,
+				SourceExists: false,
+				DestExists:   true,
+				Error:        fmt.Errorf("余分なディレクトリが存在します"),
+			}
+			v.handleExtra(&result, destPath, action)
+			v.addResult(result)
+			return
+		}
+
+		// 余分なファイルとして報告
+		result := VerificationResult{
+			Path:         destPath,
+			SourceExists: false,
+			DestExists:   true,
+			DestSize:     info.Size(),
+			DestTime:     info.ModTime(),
+			Error:        fmt.Errorf("余分なファイルが存在します"),
+		}
+		v.handleExtra(&result, destPath, action)
+		v.addResult(result)
+		v.recordExtra(result, info, action)
+	})
+	if err != nil {
+		return err
+	}
+	return v.checkBatchedExtras()
+}
+
+// recordFile は検証したファイルの結果をデータベースに記録する
+// 宛先のパスがソースと異なるファイルは、記録されていた宛先のパスを引き継ぐ
+func (v *Verifier) recordFile(info database.FileInfo) {
+	info.DestPath = v.destPaths[info.Path]
+	v.db.AddFile(info)
+}
+
+// recordExtra は余分なファイルの処理結果をデータベースに記録する
+func (v *Verifier) recordExtra(result VerificationResult, info os.FileInfo, action ExtrasAction) {
+	if v.db == nil {
+		return
+	}
+	relPath, _ := pathkey.Rel(v.destDir, result.Path)
+	fileInfo := database.FileInfo{
+		Path:         relPath,
+		Size:         info.Size(),
+		ModTime:      info.ModTime(),
+		Status:       database.StatusMismatch,
+		LastSyncTime: time.Now(),
+		LastError:    "ソースに存在しない余分なファイルです",
+		Reason:       database.ReasonExtra,
+	}
+	switch result.Action {
+	case "deleted":
+		fileInfo.Status = database.StatusDeleted
+		fileInfo.LastError, fileInfo.Reason = "ソースに存在しない余分なファイルを削除しました", database.ReasonExtraDeleted
+		fileInfo.SessionID, fileInfo.Change = v.sessionID, database.ChangeDeleted
+	case "quarantined":
+		fileInfo.Status = database.StatusQuarantined
+		fileInfo.LastError, fileInfo.Reason = "ソースに存在しない余分なファイルを隔離しました", database.ReasonExtraQuarantined
+		fileInfo.SessionID, fileInfo.Change = v.sessionID, database.ChangeDeleted
+	}
+	if result.Error != nil && result.Action == "" && action != ExtrasReport {
+		// 削除・隔離に失敗した場合
+		fileInfo.LastError, fileInfo.Reason = result.Error.Error(), ""
+		fileInfo.Error = errcode.Describe(result.Error)
+	}
+	v.db.AddFile(fileInfo)
+}
+
+// findExtras は宛先ディレクトリからソースに存在しないファイル・ディレクトリを探し、見つかるごとにfoundを呼び出す
+// foundにはファイルの場合はファイル情報を、ディレクトリの場合はnilを渡す（ディレクトリの中は探さない）
+func (v *Verifier) findExtras(sourceDir, destDir string, found func(destPath string, info os.FileInfo)) error {
 	// 宛先ディレクトリを開く
-	entries, err := os.ReadDir(destDir)
+	entries, err := v.fs.ReadDir(destDir)
 	if err != nil {
 		return fmt.Errorf("宛先ディレクトリ読み込みエラー: %w", err)
 	}
@@ -519,27 +1119,38 @@ func (v *Verifier) checkExtraFiles(sourceDir, destDir string) error {
 		destPath := filepath.Join(destDir, entry.Name())
 		sourcePath := filepath.Join(sourceDir, entry.Name())
 
+		// 隔離ディレクトリ自体と、小さいファイルをまとめて書き込んだセグメントのディレクトリは対象外
+		if v.usesExtrasAction(ExtrasQuarantine) && v.actionPath(destPath) == v.quarantineDir() {
+			continue
+		}
+		if destPath == filepath.Join(v.destDir, filepath.FromSlash(batch.Dir)) {
+			continue
+		}
+
+		// コピー対象外の隠しファイル・システムファイルと、コピー時に書き込んだメタデータのファイルは余分なファイルとして扱わない
+		if v.excludedByAttributes(entry) || v.isSidecar(entry) {
+			continue
+		}
+
 		// ディレクトリの場合
 		if entry.IsDir() {
 			if !v.options.Recursive {
 				continue
 			}
 
+			// 除外プロファイルに一致するディレクトリは、ソースになくても余分なディレクトリとして扱わない
+			if v.filter != nil && v.filter.ExcludesDir(destPath) {
+				continue
+			}
+
 			// ソースディレクトリの存在確認
-			if _, err := os.Stat(sourcePath); os.IsNotExist(err) {
-				// 余分なディレクトリとして報告
-				result := VerificationResult{
-					Path:         destPath,
-					SourceExists: false,
-					DestExists:   true,
-					Error:        fmt.Errorf("余分なディレクトリが存在します"),
-				}
-				v.addResult(result)
+			if _, err := v.fs.Stat(sourcePath); os.IsNotExist(err) {
+				found(destPath, nil)
 				continue
 			}
 
 			// 再帰的にチェック
-			if err := v.checkExtraFiles(sourcePath, destPath); err != nil {
+			if err := v.findExtras(sourcePath, destPath, found); err != nil {
 				return err
 			}
 			continue
@@ -551,42 +1162,140 @@ func (v *Verifier) checkExtraFiles(sourceDir, destDir string) error {
 			continue
 		}
 
-		// ソースファイルの存在確認
-		if _, err := os.Stat(sourcePath); os.IsNotExist(err) {
+		// ソースファイルの存在確認（リンク先が存在しないリンクもソースにあるものとして扱う）
+		if _, err := v.fs.Lstat(sourcePath); os.IsNotExist(err) {
 			// フィルタリング
 			if v.filter != nil && !v.filter.ShouldInclude(destPath) {
 				// ファイルをスキップ
 				continue
 			}
+			found(destPath, info)
+		}
+	}
 
-			// 余分なファイルとして報告
-			result := VerificationResult{
-				Path:         destPath,
-				SourceExists: false,
-				DestExists:   true,
-				DestSize:     info.Size(),
-				DestTime:     info.ModTime(),
-				Error:        fmt.Errorf("余分なファイルが存在します"),
-			}
-			v.addResult(result)
+	return nil
+}
 
-			// データベースに記録
-			if v.db != nil {
-				relPath, _ := filepath.Rel(v.destDir, destPath)
-				fileInfo := database.FileInfo{
-					Path:         relPath,
-					Size:         info.Size(),
-					ModTime:      info.ModTime(),
-					Status:       database.StatusMismatch,
-					LastSyncTime: time.Now(),
-					LastError:    "ソースに存在しない余分なファイルです",
-				}
-				v.db.AddFile(fileInfo)
-			}
+// handleExtra はactionに従って余分なファイル・ディレクトリを処理し、結果に反映する
+func (v *Verifier) handleExtra(result *VerificationResult, destPath string, action ExtrasAction) {
+	switch action {
+	case ExtrasDelete:
+		if err := v.fs.RemoveAll(v.actionPath(destPath)); err != nil {
+			result.Error = errcode.Errorf(errcode.ErrDestWrite, "余分なファイルの削除エラー: %w", err)
+			return
+		}
+		result.Action = "deleted"
+		result.DestExists = false
+		result.Error = nil
+	case ExtrasQuarantine:
+		relPath, err := pathkey.Rel(v.destDir, destPath)
+		if err != nil {
+			relPath = filepath.Base(destPath)
+		}
+		target := filepath.Join(v.quarantineDir(), pathkey.ToNative(relPath))
+		if _, err := v.fs.Lstat(target); err == nil {
+			// 既に同名のファイルが隔離されている場合はタイムスタンプを付与
+			target = fmt.Sprintf("%s.%s", target, time.Now().Format("20060102150405"))
 		}
+		if err := v.movePath(v.actionPath(destPath), target); err != nil {
+			result.Error = errcode.Errorf(errcode.ErrDestWrite, "余分なファイルの隔離エラー: %w", err)
+			return
+		}
+		result.Action = "quarantined"
+		result.DestExists = false
+		result.Error = nil
 	}
+}
 
-	return nil
+// hashFile はファイルシステムからファイルを開き、ハッシュ値を計算する
+// DropCacheを指定した場合は、キャッシュに残った内容ではなくディスク上の内容から計算する
+func (v *Verifier) hashFile(path string) (string, error) {
+	open := v.fs.Open
+	if v.options.DropCache {
+		open = func(name string) (vfs.File, error) { return vfs.OpenUncached(v.fs, name) }
+	}
+	file, err := open(path)
+	if err != nil {
+		return "", fmt.Errorf("ファイルを開けません: %w", err)
+	}
+	defer file.Close()
+
+	return v.hasher.HashReader(file)
+}
+
+// quarantineDir は隔離先ディレクトリのパスを返す
+func (v *Verifier) quarantineDir() string {
+	if v.options.QuarantineDir != "" {
+		return v.options.QuarantineDir
+	}
+	return filepath.Clean(v.actionDir()) + ".quarantine"
+}
+
+// actionDir は余分なファイルを削除・隔離する宛先ディレクトリを返す
+// 宛先を別の経路から読み込んで検証する場合も、削除・隔離は実際の宛先に対して行う
+func (v *Verifier) actionDir() string {
+	if v.options.ActionDest != "" {
+		return v.options.ActionDest
+	}
+	return v.destDir
+}
+
+// actionPath は検証する宛先のパスを、削除・隔離やハッシュの記録を行う宛先のパスに付け替える
+func (v *Verifier) actionPath(destPath string) string {
+	if v.options.ActionDest == "" {
+		return destPath
+	}
+	relPath, err := pathkey.Rel(v.destDir, destPath)
+	if err != nil {
+		return destPath
+	}
+	return filepath.Join(v.options.ActionDest, pathkey.ToNative(relPath))
+}
+
+// movePath はファイルまたはディレクトリを移動する
+// リネームできない場合（別ファイルシステム等）はコピーしてから削除する
+func (v *Verifier) movePath(src, dst string) error {
+	if err := v.fs.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	if err := v.fs.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	err := vfs.Walk(v.fs, src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return v.fs.MkdirAll(target, info.Mode().Perm()|0700)
+		}
+		in, err := v.fs.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := v.fs.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		if err := out.Close(); err != nil {
+			return err
+		}
+		return v.fs.Chtimes(target, time.Now(), info.ModTime())
+	})
+	if err != nil {
+		return err
+	}
+	return v.fs.RemoveAll(src)
 }
 
 // reportProgress は進捗報告を行うゴルーチン
@@ -636,7 +1345,11 @@ func (v *Verifier) GenerateReport(reportPath string) error {
 	defer file.Close()
 
 	// ヘッダー行を書き込む
-	_, err = file.WriteString("ファイルパス,ソース存在,宛先存在,サイズ一致,ハッシュ一致,ソースハッシュ,宛先ハッシュ,ソースサイズ,宛先サイズ,ソース更新日時,宛先更新日時,エラー\n")
+	header := "ファイルパス,ソース存在,宛先存在,サイズ一致,ハッシュ一致,ソースハッシュ,宛先ハッシュ,ソースサイズ,宛先サイズ,ソース更新日時,宛先更新日時,処理,エラー,エラー無視"
+	if v.options.DetailedReport {
+		header += ",処理時間(秒),再検証回数,ワーカー,スループット(バイト/秒)"
+	}
+	_, err = file.WriteString(header + "\n")
 	if err != nil {
 		return fmt.Errorf("ヘッダー書き込みエラー: %w", err)
 	}
@@ -650,7 +1363,7 @@ func (v *Verifier) GenerateReport(reportPath string) error {
 		}
 
 		line := fmt.Sprintf(
-			"%s,%t,%t,%t,%t,%s,%s,%d,%d,%s,%s,%s\n",
+			"%s,%t,%t,%t,%t,%s,%s,%d,%d,%s,%s,%s,%s,%t",
 			result.Path,
 			result.SourceExists,
 			result.DestExists,
@@ -662,9 +1375,14 @@ func (v *Verifier) GenerateReport(reportPath string) error {
 			result.DestSize,
 			result.SourceTime.Format(time.RFC3339),
 			result.DestTime.Format(time.RFC3339),
+			result.Action,
 			errorMsg,
+			result.Ignored,
 		)
-		_, err = file.WriteString(line)
+		if v.options.DetailedReport {
+			line += fmt.Sprintf(",%.3f,%d,%d,%.0f", result.Duration.Seconds(), result.Rechecks, result.Worker, result.Throughput())
+		}
+		_, err = file.WriteString(line + "\n")
 		if err != nil {
 			return fmt.Errorf("データ書き込みエラー: %w", err)
 		}
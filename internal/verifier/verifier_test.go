@@ -1,17 +1,30 @@
 package verifier
 
 import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"hash"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/sakuhanight/gopier/internal/audit"
 	"github.com/sakuhanight/gopier/internal/database"
+	"github.com/sakuhanight/gopier/internal/errcode"
 	"github.com/sakuhanight/gopier/internal/filter"
+	"github.com/sakuhanight/gopier/internal/fsmeta"
 	"github.com/sakuhanight/gopier/internal/hasher"
+	"github.com/sakuhanight/gopier/internal/sidecar"
+	"github.com/sakuhanight/gopier/internal/transform"
+	"github.com/sakuhanight/gopier/internal/vfs"
 )
 
 // TestDefaultOptions はDefaultOptions関数のテスト
@@ -394,6 +407,10 @@ func TestVerifyDirectory(t *testing.T) {
 	for _, result := range results {
 		if result.Path == "test.txt" && result.HashMatch {
 			successFound = true
+			// 検証したワーカーと処理時間を記録する
+			if result.Duration <= 0 || result.Worker < 0 || result.Worker >= DefaultOptions().MaxConcurrent {
+				t.Errorf("Duration = %v, Worker = %d", result.Duration, result.Worker)
+			}
 			break
 		}
 	}
@@ -514,6 +531,227 @@ func TestCheckExtraFiles(t *testing.T) {
 	}
 }
 
+// TestCheckExtraFiles_Actions は余分なファイルの削除・隔離をテスト
+func TestCheckExtraFiles_Actions(t *testing.T) {
+	tests := []struct {
+		name           string
+		action         ExtrasAction
+		expectedAction string
+		expectedStatus database.FileStatus
+	}{
+		{"報告のみ", ExtrasReport, "", database.StatusMismatch},
+		{"削除", ExtrasDelete, "deleted", database.StatusDeleted},
+		{"隔離", ExtrasQuarantine, "quarantined", database.StatusQuarantined},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			sourceDir := filepath.Join(tempDir, "source")
+			destDir := filepath.Join(tempDir, "dest")
+			os.MkdirAll(sourceDir, 0755)
+			os.MkdirAll(filepath.Join(destDir, "sub"), 0755)
+			os.MkdirAll(filepath.Join(sourceDir, "sub"), 0755)
+
+			extraFile := filepath.Join(destDir, "sub", "extra.txt")
+			if err := os.WriteFile(extraFile, []byte("extra"), 0644); err != nil {
+				t.Fatalf("余分なファイルの作成に失敗: %v", err)
+			}
+
+			db, err := database.NewSyncDB(filepath.Join(tempDir, "test.db"), database.NormalSync)
+			if err != nil {
+				t.Fatalf("データベース作成に失敗: %v", err)
+			}
+			defer db.Close()
+
+			options := DefaultOptions()
+			options.ExtrasAction = tt.action
+			v := NewVerifier(sourceDir, destDir, options, nil, db)
+			if err := v.checkExtraFiles(sourceDir, destDir); err != nil {
+				t.Fatalf("checkExtraFilesでエラーが発生: %v", err)
+			}
+
+			results := v.GetResults()
+			if len(results) != 1 {
+				t.Fatalf("期待される結果数: 1, 実際: %d", len(results))
+			}
+			if results[0].Action != tt.expectedAction {
+				t.Errorf("期待される処理: %q, 実際: %q", tt.expectedAction, results[0].Action)
+			}
+
+			_, statErr := os.Stat(extraFile)
+			if tt.action == ExtrasReport && statErr != nil {
+				t.Error("報告のみの場合はファイルが残るべき")
+			}
+			if tt.action != ExtrasReport && statErr == nil {
+				t.Error("余分なファイルが宛先に残っています")
+			}
+			if tt.action == ExtrasQuarantine {
+				if _, err := os.Stat(filepath.Join(destDir+".quarantine", "sub", "extra.txt")); err != nil {
+					t.Errorf("隔離先にファイルがありません: %v", err)
+				}
+			}
+
+			// 削除・隔離に成功した場合はエラーとして数えない
+			expectedErrors := int64(1)
+			if tt.action != ExtrasReport {
+				expectedErrors = 0
+			}
+			if v.GetErrorCount() != expectedErrors {
+				t.Errorf("期待されるエラー数: %d, 実際: %d", expectedErrors, v.GetErrorCount())
+			}
+
+			fileInfo, err := db.GetFile(filepath.Join("sub", "extra.txt"))
+			if err != nil {
+				t.Fatalf("DBからの取得に失敗: %v", err)
+			}
+			if fileInfo.Status != tt.expectedStatus {
+				t.Errorf("期待されるステータス: %s, 実際: %s", tt.expectedStatus, fileInfo.Status)
+			}
+		})
+	}
+
+	if _, err := ParseExtrasAction("invalid"); err == nil {
+		t.Error("無効な処理方法でエラーが発生しませんでした")
+	}
+}
+
+// TestVerify_ConfirmDelete は余分なファイルを削除する前の確認をテスト
+func TestVerify_ConfirmDelete(t *testing.T) {
+	for _, confirm := range []bool{true, false} {
+		t.Run(fmt.Sprintf("confirm=%v", confirm), func(t *testing.T) {
+			tempDir := t.TempDir()
+			sourceDir := filepath.Join(tempDir, "source")
+			destDir := filepath.Join(tempDir, "dest")
+			os.MkdirAll(sourceDir, 0755)
+			os.MkdirAll(filepath.Join(destDir, "old", "deep"), 0755)
+			os.WriteFile(filepath.Join(sourceDir, "keep.txt"), []byte("keep"), 0644)
+			os.WriteFile(filepath.Join(destDir, "keep.txt"), []byte("keep"), 0644)
+			os.WriteFile(filepath.Join(destDir, "extra.txt"), []byte("extra"), 0644)
+			os.WriteFile(filepath.Join(destDir, "old", "deep", "a.bin"), make([]byte, 100), 0644)
+
+			var preview *DeletePreview
+			options := DefaultOptions()
+			options.ExtrasAction = ExtrasDelete
+			options.ConfirmDelete = func(p *DeletePreview) bool {
+				preview = p
+				return confirm
+			}
+			v := NewVerifier(sourceDir, destDir, options, nil, nil)
+			err := v.Verify()
+
+			// 削除する前に、余分なディレクトリの中のファイルも含めて一覧を渡す
+			if preview == nil || len(preview.Files) != 2 || preview.Dirs != 2 || preview.Bytes != 105 {
+				t.Fatalf("削除の確認 = %+v", preview)
+			}
+			_, statErr := os.Stat(filepath.Join(destDir, "extra.txt"))
+			if confirm {
+				if err != nil || statErr == nil || v.DeleteDeclined() {
+					t.Errorf("確認した場合は削除されるべき: err=%v, stat=%v", err, statErr)
+				}
+				return
+			}
+			// 削除しない場合は余分なファイルを報告のみ行う
+			if err == nil || statErr != nil || !v.DeleteDeclined() {
+				t.Errorf("確認しなかった場合は削除されないべき: err=%v, stat=%v", err, statErr)
+			}
+			if v.GetErrorCount() != 2 {
+				t.Errorf("期待されるエラー数: 2, 実際: %d", v.GetErrorCount())
+			}
+		})
+	}
+}
+
+// TestVerifyPaths は指定されたパスのみの検証をテスト
+func TestVerifyPaths(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(filepath.Join(sourceDir, "sub"), 0755)
+	os.MkdirAll(filepath.Join(destDir, "sub"), 0755)
+
+	os.WriteFile(filepath.Join(sourceDir, "sub", "ok.txt"), []byte("same"), 0644)
+	os.WriteFile(filepath.Join(destDir, "sub", "ok.txt"), []byte("same"), 0644)
+	// 対象外のファイルは不一致でも検証されない
+	os.WriteFile(filepath.Join(sourceDir, "other.txt"), []byte("source"), 0644)
+	os.WriteFile(filepath.Join(destDir, "other.txt"), []byte("changed"), 0644)
+
+	v := NewVerifier(sourceDir, destDir, DefaultOptions(), nil, nil)
+	if err := v.VerifyPaths([]string{"sub/ok.txt"}); err != nil {
+		t.Errorf("VerifyPathsが失敗しました: %v", err)
+	}
+
+	results := v.GetResults()
+	if len(results) != 1 {
+		t.Fatalf("期待される結果数: 1, 実際: %d", len(results))
+	}
+	if results[0].Path != "sub/ok.txt" || !results[0].HashMatch {
+		t.Errorf("検証結果が正しくありません: %+v", results[0])
+	}
+
+	v2 := NewVerifier(sourceDir, destDir, DefaultOptions(), nil, nil)
+	if err := v2.VerifyPaths([]string{"other.txt"}); err == nil {
+		t.Error("不一致のファイルでエラーが発生しませんでした")
+	}
+}
+
+// TestVerifyFiles_DestPath は宛先のパスがソースと異なるファイル（フラット化）の検証をテスト
+func TestVerifyFiles_DestPath(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(filepath.Join(sourceDir, "a", "b"), 0755)
+	os.MkdirAll(destDir, 0755)
+	os.WriteFile(filepath.Join(sourceDir, "a", "b", "log.txt"), []byte("log"), 0644)
+	os.WriteFile(filepath.Join(destDir, "log.txt"), []byte("log"), 0644)
+
+	syncDB, err := database.NewSyncDB(filepath.Join(tempDir, "test.db"), database.NormalSync)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer syncDB.Close()
+
+	v := NewVerifier(sourceDir, destDir, DefaultOptions(), nil, syncDB)
+	files := []database.FileInfo{{Path: "a/b/log.txt", DestPath: "log.txt", Status: database.StatusSuccess}}
+	if err := v.VerifyFiles(files); err != nil {
+		t.Fatalf("記録された宛先のパスで検証されませんでした: %v", err)
+	}
+
+	// 検証結果の記録でも宛先のパスを引き継ぐ
+	info, err := syncDB.GetFile("a/b/log.txt")
+	if err != nil || info == nil {
+		t.Fatalf("GetFile() = %v, %v", info, err)
+	}
+	if info.Status != database.StatusVerified || info.DestPath != "log.txt" {
+		t.Errorf("記録 = %+v, want 検証済みで宛先のパスがlog.txt", info)
+	}
+}
+
+func TestVerify_DropCache(t *testing.T) {
+	if !vfs.UncachedSupported {
+		t.Skip("この環境ではキャッシュを経由しない読み込みに対応していません")
+	}
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(sourceDir, 0755)
+	os.MkdirAll(destDir, 0755)
+	os.WriteFile(filepath.Join(sourceDir, "ok.txt"), []byte("same"), 0644)
+	os.WriteFile(filepath.Join(destDir, "ok.txt"), []byte("same"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "bad.txt"), []byte("source"), 0644)
+	os.WriteFile(filepath.Join(destDir, "bad.txt"), []byte("broken"), 0644)
+
+	options := DefaultOptions()
+	options.DropCache = true
+	v := NewVerifier(sourceDir, destDir, options, nil, nil)
+	v.Verify()
+
+	summary := v.GetSummary()
+	if summary.Matched != 1 || summary.Mismatched != 1 {
+		t.Errorf("検証結果 = %+v, want 一致 1, 不一致 1", summary)
+	}
+}
+
 // TestCheckExtraFiles_EdgeCases はcheckExtraFiles関数のエッジケースをテスト
 func TestCheckExtraFiles_EdgeCases(t *testing.T) {
 	tempDir := t.TempDir()
@@ -726,6 +964,44 @@ func TestGenerateReport(t *testing.T) {
 	if !strings.Contains(contentStr, "abc123") {
 		t.Error("レポートにハッシュ値が含まれていません")
 	}
+	if strings.Contains(contentStr, "処理時間") {
+		t.Error("詳細を指定していないレポートに処理時間が含まれています")
+	}
+}
+
+// TestGenerateReport_Detailed は詳細なレポートにファイルごとの処理時間・ワーカーが含まれることを確認する
+func TestGenerateReport_Detailed(t *testing.T) {
+	options := DefaultOptions()
+	options.DetailedReport = true
+	verifier := NewVerifier("/source", "/dest", options, nil, nil)
+	verifier.addResult(VerificationResult{
+		Path:         "big.bin",
+		SourceExists: true,
+		DestExists:   true,
+		SizeMatch:    true,
+		HashMatch:    true,
+		SourceSize:   4 << 20,
+		DestSize:     4 << 20,
+		Rechecks:     2,
+		Duration:     2 * time.Second,
+		Worker:       3,
+	})
+
+	reportPath := filepath.Join(t.TempDir(), "report.csv")
+	if err := verifier.GenerateReport(reportPath); err != nil {
+		t.Fatalf("レポート生成でエラーが発生: %v", err)
+	}
+	content, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], ",処理時間(秒),再検証回数,ワーカー,スループット(バイト/秒)") {
+		t.Fatalf("レポートのヘッダー = %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], ",2.000,2,3,2097152") {
+		t.Errorf("レポートの行 = %q", lines[1])
+	}
 }
 
 // TestGenerateReport_EdgeCases はGenerateReport関数のエッジケースをテスト
@@ -855,6 +1131,46 @@ func TestVerifyWithDatabase(t *testing.T) {
 	}
 }
 
+func TestVerify_CustomHasher(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(sourceDir, 0755)
+	os.MkdirAll(destDir, 0755)
+	os.WriteFile(filepath.Join(sourceDir, "same.txt"), []byte("same"), 0644)
+	os.WriteFile(filepath.Join(destDir, "same.txt"), []byte("same"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "diff.txt"), []byte("src1"), 0644)
+	os.WriteFile(filepath.Join(destDir, "diff.txt"), []byte("dst1"), 0644)
+
+	syncDB, err := database.NewSyncDB(filepath.Join(tempDir, "test.db"), database.NormalSync)
+	if err != nil {
+		t.Fatalf("データベースの作成に失敗: %v", err)
+	}
+	defer syncDB.Close()
+
+	var calls atomic.Int64
+	options := DefaultOptions()
+	options.Hasher = hasher.NewCustomHasher("hsm-sha256", func() hash.Hash {
+		calls.Add(1)
+		return sha256.New()
+	}, 0)
+	v := NewVerifier(sourceDir, destDir, options, nil, syncDB)
+	v.Verify()
+
+	if calls.Load() == 0 {
+		t.Error("指定した実装でハッシュ値が計算されていません")
+	}
+	for _, name := range []string{"same.txt", "diff.txt"} {
+		record, err := syncDB.GetFile(name)
+		if err != nil || record == nil {
+			t.Fatalf("%s の記録が取得できません: %v", name, err)
+		}
+		if record.HashAlgo != "hsm-sha256" {
+			t.Errorf("%s のハッシュのアルゴリズム = %q, 期待値 hsm-sha256", name, record.HashAlgo)
+		}
+	}
+}
+
 // TestVerifyWithProgressCallback は進捗コールバック付きの検証テスト
 func TestVerifyWithProgressCallback(t *testing.T) {
 	// テスト用の一時ディレクトリを作成
@@ -1125,3 +1441,523 @@ func BenchmarkVerifyAll_WithFilter(b *testing.B) {
 		}
 	}
 }
+
+func TestVerify_RecordsSummaryInSession(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(sourceDir, 0755)
+	os.MkdirAll(destDir, 0755)
+
+	os.WriteFile(filepath.Join(sourceDir, "ok.txt"), []byte("same"), 0644)
+	os.WriteFile(filepath.Join(destDir, "ok.txt"), []byte("same"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "bad.txt"), []byte("abcd"), 0644)
+	os.WriteFile(filepath.Join(destDir, "bad.txt"), []byte("abce"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "missing.txt"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(destDir, "extra.txt"), []byte("x"), 0644)
+
+	db, err := database.NewSyncDB(filepath.Join(tempDir, "test.db"), database.NormalSync)
+	if err != nil {
+		t.Fatalf("データベース作成に失敗: %v", err)
+	}
+	defer db.Close()
+
+	v := NewVerifier(sourceDir, destDir, DefaultOptions(), nil, db)
+	if err := v.Verify(); err == nil {
+		t.Error("不一致があるのにエラーになりません")
+	}
+
+	session, err := db.GetLatestSession(database.SessionVerify)
+	if err != nil || session == nil {
+		t.Fatalf("検証セッションが取得できません: %v", err)
+	}
+	got := session.Verification
+	if got == nil {
+		t.Fatal("検証結果がセッションに記録されていません")
+	}
+	want := database.VerificationSummary{Matched: 1, Mismatched: 1, MissingDest: 1, Extra: 1, Bytes: 8}
+	if *got != want {
+		t.Errorf("検証結果 = %+v, want %+v", *got, want)
+	}
+	if got.MismatchRate() != 0.5 {
+		t.Errorf("MismatchRate() = %v, want 0.5", got.MismatchRate())
+	}
+}
+
+func TestVerify_ExcludeHidden(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(filepath.Join(sourceDir, ".cache"), 0755)
+	os.MkdirAll(destDir, 0755)
+
+	os.WriteFile(filepath.Join(sourceDir, "ok.txt"), []byte("same"), 0644)
+	os.WriteFile(filepath.Join(destDir, "ok.txt"), []byte("same"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, ".cache", "data"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(destDir, ".DS_Store"), []byte("x"), 0644)
+
+	options := DefaultOptions()
+	options.IncludeHidden = false
+	v := NewVerifier(sourceDir, destDir, options, nil, nil)
+	if err := v.Verify(); err != nil {
+		t.Errorf("除外した隠しファイルが不一致として報告されました: %v", err)
+	}
+	if results := v.GetResults(); len(results) != 1 {
+		t.Errorf("検証結果の件数 = %d, want 1", len(results))
+	}
+}
+
+func TestVerify_Transform(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(sourceDir, 0755)
+	os.MkdirAll(destDir, 0755)
+
+	os.WriteFile(filepath.Join(sourceDir, "ok.txt"), []byte("a\nb\n"), 0644)
+	os.WriteFile(filepath.Join(destDir, "ok.txt"), []byte("a\r\nb\r\n"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "bad.txt"), []byte("a\nb\n"), 0644)
+	os.WriteFile(filepath.Join(destDir, "bad.txt"), []byte("a\nb\n"), 0644)
+
+	pipeline, err := transform.Parse(".txt=crlf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := DefaultOptions()
+	options.Transforms = pipeline
+	v := NewVerifier(sourceDir, destDir, options, nil, nil)
+	if err := v.Verify(); err == nil {
+		t.Error("変換されていないファイルがあるのにエラーになりません")
+	}
+
+	for _, result := range v.GetResults() {
+		switch result.Path {
+		case "ok.txt":
+			if !result.HashMatch || result.Error != nil {
+				t.Errorf("ok.txt: 変換後の内容と一致しません: %+v", result)
+			}
+		case "bad.txt":
+			if result.HashMatch {
+				t.Errorf("bad.txt: 変換されていないのに一致と判定されました")
+			}
+		}
+	}
+}
+
+func TestVerify_IgnoresMetaSidecar(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(sourceDir, 0755)
+	os.MkdirAll(destDir, 0755)
+	os.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(destDir, "a.txt"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(destDir, sidecar.FileName), []byte("{}"), 0644)
+
+	if err := NewVerifier(sourceDir, destDir, DefaultOptions(), nil, nil).Verify(); err == nil {
+		t.Error("メタデータのファイルが余分なファイルとして報告されません")
+	}
+
+	options := DefaultOptions()
+	options.MetaSidecar = true
+	if err := NewVerifier(sourceDir, destDir, options, nil, nil).Verify(); err != nil {
+		t.Errorf("メタデータのファイルが余分なファイルとして報告されました: %v", err)
+	}
+}
+
+func TestVerify_IgnoreErrorsOn(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(filepath.Join(sourceDir, "legacy", "broken"), 0755)
+	os.MkdirAll(filepath.Join(destDir, "legacy", "broken"), 0755)
+
+	os.WriteFile(filepath.Join(sourceDir, "ok.txt"), []byte("same"), 0644)
+	os.WriteFile(filepath.Join(destDir, "ok.txt"), []byte("same"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "legacy", "broken", "bad.txt"), []byte("abc"), 0644)
+	os.WriteFile(filepath.Join(destDir, "legacy", "broken", "bad.txt"), []byte("abd"), 0644)
+	os.WriteFile(filepath.Join(destDir, "legacy", "broken", "extra.txt"), []byte("x"), 0644)
+
+	options := DefaultOptions()
+	options.IgnoreErrorsOn = "legacy/broken"
+	options.FailFast = true
+	v := NewVerifier(sourceDir, destDir, options, nil, nil)
+	if err := v.Verify(); err != nil {
+		t.Errorf("エラーを無視するパスの不一致が失敗として扱われました: %v", err)
+	}
+	if v.GetErrorCount() != 0 || v.GetIgnoredCount() != 2 {
+		t.Errorf("エラー数 = %d, 無視した数 = %d; want 0, 2", v.GetErrorCount(), v.GetIgnoredCount())
+	}
+
+	// 一致しないパスの不一致は従来どおり失敗とする
+	os.WriteFile(filepath.Join(destDir, "ok.txt"), []byte("diff"), 0644)
+	v = NewVerifier(sourceDir, destDir, options, nil, nil)
+	if err := v.Verify(); err == nil {
+		t.Error("エラーを無視しないパスの不一致が検出されませんでした")
+	}
+}
+
+func TestVerify_Suppress(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(filepath.Join(sourceDir, "acked"), 0755)
+	os.MkdirAll(filepath.Join(destDir, "acked"), 0755)
+
+	os.WriteFile(filepath.Join(sourceDir, "ok.txt"), []byte("same"), 0644)
+	os.WriteFile(filepath.Join(destDir, "ok.txt"), []byte("same"), 0644)
+	os.WriteFile(filepath.Join(sourceDir, "acked", "bad.txt"), []byte("abc"), 0644)
+	os.WriteFile(filepath.Join(destDir, "acked", "bad.txt"), []byte("abd"), 0644)
+	os.WriteFile(filepath.Join(destDir, "acked", "extra.txt"), []byte("x"), 0644)
+
+	options := DefaultOptions()
+	options.Suppress = func(path string) bool {
+		return strings.HasPrefix(path, "acked"+string(filepath.Separator))
+	}
+	v := NewVerifier(sourceDir, destDir, options, nil, nil)
+	if err := v.Verify(); err != nil {
+		t.Errorf("確認済みの不一致が失敗として扱われました: %v", err)
+	}
+	if v.GetErrorCount() != 0 || v.GetSuppressedCount() != 2 {
+		t.Errorf("エラー数 = %d, 報告しなかった数 = %d; want 0, 2", v.GetErrorCount(), v.GetSuppressedCount())
+	}
+	if summary := v.GetGradingSummary(); summary.Mismatched != 0 || summary.Extra != 0 || summary.Matched != 1 {
+		t.Errorf("判定の集計に確認済みの不一致が含まれています: %+v", summary)
+	}
+
+	// 最終検証レポートにも含めない
+	reportPath := filepath.Join(tempDir, "report.csv")
+	if err := v.GenerateReport(reportPath); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "bad.txt") || strings.Contains(string(data), "extra.txt") {
+		t.Errorf("レポートに確認済みの不一致が含まれています:\n%s", data)
+	}
+	if !strings.Contains(string(data), "ok.txt") {
+		t.Errorf("レポートに一致したファイルが含まれていません:\n%s", data)
+	}
+
+	// 一致しないパスの不一致は従来どおり失敗とする
+	os.WriteFile(filepath.Join(destDir, "ok.txt"), []byte("diff"), 0644)
+	v = NewVerifier(sourceDir, destDir, options, nil, nil)
+	if err := v.Verify(); err == nil {
+		t.Error("確認済みでないパスの不一致が検出されませんでした")
+	}
+}
+
+func TestVerify_MemFS(t *testing.T) {
+	mem := vfs.NewMem()
+	sourceDir := filepath.Join("/", "source")
+	destDir := filepath.Join("/", "dest")
+	mem.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("a"), 0644)
+	mem.WriteFile(filepath.Join(sourceDir, "sub", "b.txt"), []byte("bbb"), 0644)
+	mem.WriteFile(filepath.Join(destDir, "a.txt"), []byte("a"), 0644)
+	mem.WriteFile(filepath.Join(destDir, "sub", "b.txt"), []byte("xxx"), 0644)
+	mem.WriteFile(filepath.Join(destDir, "sub", "extra.txt"), []byte("extra"), 0644)
+
+	options := DefaultOptions()
+	options.FS = mem
+	options.ExtrasAction = ExtrasQuarantine
+	v := NewVerifier(sourceDir, destDir, options, nil, nil)
+	if err := v.Verify(); !errors.Is(err, errcode.ErrVerifyFailed) {
+		t.Errorf("Verify() = %v, want 検証失敗", err)
+	}
+
+	failures := v.GetFailures()
+	if len(failures) != 1 || failures[0].Path != filepath.Join("sub", "b.txt") {
+		t.Errorf("失敗 = %+v", failures)
+	}
+
+	// 余分なファイルはメモリ上のファイルシステム内で隔離される
+	if _, err := mem.Stat(filepath.Join(destDir, "sub", "extra.txt")); !os.IsNotExist(err) {
+		t.Errorf("余分なファイルが宛先に残っています: %v", err)
+	}
+	if data, err := mem.ReadFile(filepath.Join(destDir+".quarantine", "sub", "extra.txt")); err != nil || string(data) != "extra" {
+		t.Errorf("隔離先の内容 = %q, %v", data, err)
+	}
+}
+
+func TestVerify_ActionDest(t *testing.T) {
+	// 検証用の経路（via）は宛先と同じ内容を指す別のパスとして、宛先の内容を複製しておく
+	mem := vfs.NewMem()
+	sourceDir := filepath.Join("/", "source")
+	destDir := filepath.Join("/", "dest")
+	viaDir := filepath.Join("/", "via")
+	mem.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("a"), 0644)
+	for _, dir := range []string{destDir, viaDir} {
+		mem.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0644)
+		mem.WriteFile(filepath.Join(dir, "sub", "extra.txt"), []byte("extra"), 0644)
+		mem.WriteFile(filepath.Join(dir, "old.txt"), []byte("old"), 0644)
+	}
+
+	options := DefaultOptions()
+	options.FS = mem
+	options.ExtrasAction = ExtrasQuarantine
+	options.ExtrasRules = []ExtrasRule{{Pattern: "old.txt", Action: ExtrasDelete}}
+	options.ActionDest = destDir
+	if err := NewVerifier(sourceDir, viaDir, options, nil, nil).Verify(); err != nil {
+		t.Fatalf("Verify() = %v", err)
+	}
+
+	// 削除・隔離は実際の宛先に対して行い、隔離先も実際の宛先を基準にする
+	for _, path := range []string{filepath.Join(destDir, "sub", "extra.txt"), filepath.Join(destDir, "old.txt")} {
+		if _, err := mem.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("余分なファイルが宛先に残っています: %s (%v)", path, err)
+		}
+	}
+	if data, err := mem.ReadFile(filepath.Join(destDir+".quarantine", "sub", "extra.txt")); err != nil || string(data) != "extra" {
+		t.Errorf("隔離先の内容 = %q, %v", data, err)
+	}
+	if _, err := mem.Stat(filepath.Join(viaDir, "sub", "extra.txt")); err != nil {
+		t.Errorf("検証用の経路のファイルが変更されました: %v", err)
+	}
+}
+
+func TestVerify_AuditLog(t *testing.T) {
+	mem := vfs.NewMem()
+	sourceDir := filepath.Join("/", "source")
+	destDir := filepath.Join("/", "dest")
+	mem.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("a"), 0644)
+	mem.WriteFile(filepath.Join(sourceDir, "b.txt"), []byte("bbb"), 0644)
+	mem.WriteFile(filepath.Join(destDir, "a.txt"), []byte("a"), 0644)
+	mem.WriteFile(filepath.Join(destDir, "b.txt"), []byte("xxx"), 0644)
+	mem.WriteFile(filepath.Join(destDir, "extra.txt"), []byte("extra"), 0644)
+
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditLog, err := audit.Open(auditPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	options := DefaultOptions()
+	options.FS = mem
+	options.ExtrasAction = ExtrasDelete
+	options.Audit = auditLog
+	v := NewVerifier(sourceDir, destDir, options, nil, nil)
+	v.Verify()
+	auditLog.Close()
+
+	data, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var rec audit.Record
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatal(err)
+		}
+		results[rec.Path] = rec.Action + "/" + rec.Result
+	}
+
+	want := map[string]string{
+		"a.txt":     "verify/matched",
+		"b.txt":     "verify/mismatched",
+		"extra.txt": "delete/success",
+	}
+	for path, result := range want {
+		if results[path] != result {
+			t.Errorf("%s の記録 = %q, want %q", path, results[path], result)
+		}
+	}
+}
+
+func TestVerify_MismatchRetries(t *testing.T) {
+	mem := vfs.NewMem()
+	sourceDir := filepath.Join("/", "source")
+	destDir := filepath.Join("/", "dest")
+	mem.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("abc"), 0644)
+	mem.WriteFile(filepath.Join(destDir, "a.txt"), []byte("abd"), 0644)
+
+	// 実際に破損している場合は再検証しても一致しない
+	options := DefaultOptions()
+	options.FS = mem
+	options.MismatchRetries = 2
+	options.MismatchRetryDelay = 0
+	v := NewVerifier(sourceDir, destDir, options, nil, nil)
+	if err := v.Verify(); !errors.Is(err, errcode.ErrVerifyFailed) {
+		t.Errorf("Verify() = %v, want 検証失敗", err)
+	}
+	failures := v.GetFailures()
+	if len(failures) != 1 || failures[0].Rechecks != 2 || !strings.Contains(failures[0].Error.Error(), "2回の再検証") {
+		t.Errorf("失敗 = %+v", failures)
+	}
+
+	// 再検証で一致した場合は一時的な不一致として扱う
+	attempts := 0
+	result := &VerificationResult{Path: "a.txt", SourceExists: true, DestExists: true, SizeMatch: true}
+	expected, dest, ok := v.recheck(result, func() (string, string, error) {
+		attempts++
+		if attempts == 1 {
+			return "", "", errors.New("読み込みエラー")
+		}
+		return "ccc", "ccc", nil
+	})
+	if !ok || expected != "ccc" || dest != "ccc" {
+		t.Errorf("recheck() = %q, %q, %v", expected, dest, ok)
+	}
+	if result.Rechecks != 2 {
+		t.Errorf("再検証の回数 = %d, want 2", result.Rechecks)
+	}
+
+	result.HashMatch, result.Intermittent = true, true
+	if got := result.outcome(); got != database.VerifyIntermittent {
+		t.Errorf("outcome() = %v, want VerifyIntermittent", got)
+	}
+	if status, _, _ := verifiedStatus(result); status != database.StatusIntermittent {
+		t.Errorf("verifiedStatus() = %v, want %v", status, database.StatusIntermittent)
+	}
+}
+
+func TestVerify_Owner(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windowsでは所有者の指定に対応していません")
+	}
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(sourceDir, 0755)
+	os.MkdirAll(destDir, 0755)
+	os.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("same"), 0644)
+	os.WriteFile(filepath.Join(destDir, "a.txt"), []byte("same"), 0644)
+
+	options := DefaultOptions()
+	options.Owner, _ = fsmeta.ParseOwner(strconv.Itoa(os.Getuid()))
+	v := NewVerifier(sourceDir, destDir, options, nil, nil)
+	if err := v.Verify(); err != nil {
+		t.Errorf("所有者が一致するのに検証に失敗しました: %v", err)
+	}
+
+	// 宛先の所有者が指定と異なる場合は不一致とする
+	options.Owner, _ = fsmeta.ParseOwner(strconv.Itoa(os.Getuid() + 1))
+	v = NewVerifier(sourceDir, destDir, options, nil, nil)
+	if err := v.Verify(); !errors.Is(err, errcode.ErrVerifyFailed) {
+		t.Errorf("Verify() = %v, want 検証失敗", err)
+	}
+	failures := v.GetFailures()
+	if len(failures) != 1 || !errors.Is(failures[0].Error, errcode.ErrOwnerMismatch) {
+		t.Errorf("失敗 = %+v", failures)
+	}
+}
+
+func TestVerify_Links(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	os.MkdirAll(sourceDir, 0755)
+	os.MkdirAll(destDir, 0755)
+	for _, dir := range []string{sourceDir, destDir} {
+		os.WriteFile(filepath.Join(dir, "real.txt"), []byte("same"), 0644)
+	}
+	os.WriteFile(filepath.Join(sourceDir, "plain.txt"), []byte("same"), 0644)
+
+	links := []struct {
+		name         string
+		source, dest string // リンク先（sourceが空の場合はソースにリンクを作成しない）
+	}{
+		{"same", "real.txt", "real.txt"},
+		{"different", "real.txt", "other.txt"},
+		{"dangling", "missing.txt", "missing.txt"},
+		{"dir", "sub", "sub"},
+		// ソースの中を指す絶対パスは、宛先の対応する場所を指していれば一致とする
+		{"absolute", filepath.Join(sourceDir, "real.txt"), filepath.Join(destDir, "real.txt")},
+		// 宛先のみがリンクの場合は不一致とする
+		{"plain.txt", "", "real.txt"},
+	}
+	for _, l := range links {
+		if l.source != "" {
+			if err := os.Symlink(l.source, filepath.Join(sourceDir, l.name)); err != nil {
+				t.Skipf("シンボリックリンクを作成できません: %v", err)
+			}
+		}
+		if err := os.Symlink(l.dest, filepath.Join(destDir, l.name)); err != nil {
+			t.Skipf("シンボリックリンクを作成できません: %v", err)
+		}
+	}
+
+	v := NewVerifier(sourceDir, destDir, DefaultOptions(), nil, nil)
+	if err := v.Verify(); !errors.Is(err, errcode.ErrVerifyFailed) {
+		t.Errorf("Verify() = %v, want 検証失敗", err)
+	}
+
+	// リンクはたどらずにリンク先を比較し、一致しないものは専用の結果として数える
+	summary := v.GetSummary()
+	if summary.Matched != 5 || summary.LinkMismatch != 2 || summary.Errors != 0 {
+		t.Errorf("集計 = %+v", summary)
+	}
+	var failed []string
+	for _, r := range v.GetFailures() {
+		if !errors.Is(r.Error, errcode.ErrLinkMismatch) {
+			t.Errorf("%s: エラー = %v, want リンク先の不一致", r.Path, r.Error)
+		}
+		failed = append(failed, r.Path)
+	}
+	sort.Strings(failed)
+	if strings.Join(failed, ",") != "different,plain.txt" {
+		t.Errorf("不一致 = %v", failed)
+	}
+}
+
+func TestVerify_Subtrees(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	destDir := filepath.Join(tempDir, "dest")
+	files := map[string]string{
+		"root.txt":         "root",
+		"share1/a.txt":     "a",
+		"share1/sub/b.txt": "b",
+		"share2/c.txt":     "c",
+		"share3/d.txt":     "d",
+	}
+	for name, content := range files {
+		for _, dir := range []string{sourceDir, destDir} {
+			path := filepath.Join(dir, filepath.FromSlash(name))
+			os.MkdirAll(filepath.Dir(path), 0755)
+			os.WriteFile(path, []byte(content), 0644)
+		}
+	}
+	os.WriteFile(filepath.Join(destDir, "share2", "c.txt"), []byte("changed"), 0644)
+	os.Remove(filepath.Join(destDir, "share3", "d.txt"))
+
+	options := DefaultOptions()
+	options.SubtreeWorkers = 2
+	v := NewVerifier(sourceDir, destDir, options, nil, nil)
+	var called []string
+	v.SetSubtreeCallback(func(summary SubtreeSummary) {
+		called = append(called, summary.Dir)
+	})
+	if err := v.Verify(); err == nil {
+		t.Error("不一致があるのにエラーが返されませんでした")
+	}
+
+	summaries := v.GetSubtreeSummaries()
+	if len(summaries) != 4 || len(called) != 4 {
+		t.Fatalf("GetSubtreeSummaries() = %+v, コールバック = %v", summaries, called)
+	}
+	// ソースの直下のファイルはすべてのディレクトリの後に報告する
+	if summaries[3].Dir != "." || summaries[3].Verification.Matched != 1 {
+		t.Errorf("直下のファイルの集計 = %+v", summaries[3])
+	}
+	byDir := make(map[string]SubtreeSummary)
+	for _, s := range summaries {
+		byDir[s.Dir] = s
+	}
+	if s := byDir["share1"]; !s.Clean() || s.Verification.Matched != 2 {
+		t.Errorf("share1 = %+v", s)
+	}
+	if s := byDir["share2"]; s.Clean() || s.Verification.Mismatched != 1 {
+		t.Errorf("share2 = %+v", s)
+	}
+	if s := byDir["share3"]; s.Clean() || s.Verification.MissingDest != 1 {
+		t.Errorf("share3 = %+v", s)
+	}
+
+	// 全体の集計は最上位のディレクトリごとに集計しない場合と同じ
+	if summary := v.GetSummary(); summary.Matched != 3 || summary.Mismatched != 1 || summary.MissingDest != 1 {
+		t.Errorf("GetSummary() = %+v", summary)
+	}
+}
@@ -0,0 +1,530 @@
+package vfs
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mem はメモリ上のファイルシステム
+// ディスクに触れずにコピーや検証を試すためのもので、シンボリックリンクや所有者などのメタデータは扱わない
+// ルートディレクトリは常に存在し、それ以外のディレクトリはMkdirAllで作成する
+type Mem struct {
+	mu      sync.Mutex
+	nodes   map[string]*memNode
+	tempSeq int
+}
+
+// memNode はメモリ上のファイルまたはディレクトリ
+type memNode struct {
+	mu      sync.Mutex
+	name    string
+	mode    os.FileMode
+	modTime time.Time
+	data    []byte
+}
+
+// NewMem は空のメモリ上のファイルシステムを作成する
+func NewMem() *Mem {
+	return &Mem{nodes: make(map[string]*memNode)}
+}
+
+// WriteFile はファイルを作成して内容を書き込む（親ディレクトリも作成する）
+// テストの準備を簡単にするためのもので、更新日時は現在時刻になる
+func (m *Mem) WriteFile(name string, data []byte, perm os.FileMode) error {
+	if err := m.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	f, err := m.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadFile はファイルの内容を返す
+func (m *Mem) ReadFile(name string) ([]byte, error) {
+	f, err := m.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// memPath はパスを正規化する
+func memPath(name string) string {
+	return filepath.Clean(name)
+}
+
+// isRoot はパスがルートディレクトリかどうかを返す
+func isRoot(path string) bool {
+	return filepath.Dir(path) == path
+}
+
+// lookup はパスのノードを返す（呼び出し元でm.muをロックする）
+func (m *Mem) lookup(path string) (*memNode, bool) {
+	if isRoot(path) {
+		return &memNode{name: path, mode: os.ModeDir | 0755}, true
+	}
+	node, ok := m.nodes[path]
+	return node, ok
+}
+
+// parentDir は親ディレクトリが存在することを確認する（呼び出し元でm.muをロックする）
+func (m *Mem) parentDir(op, path string) error {
+	parent, ok := m.lookup(filepath.Dir(path))
+	if !ok {
+		return &os.PathError{Op: op, Path: path, Err: os.ErrNotExist}
+	}
+	if !parent.mode.IsDir() {
+		return &os.PathError{Op: op, Path: path, Err: errNotDir}
+	}
+	return nil
+}
+
+// errNotDir はディレクトリでないパスをディレクトリとして扱おうとした場合のエラー
+var errNotDir = errors.New("not a directory")
+
+// errIsDir はディレクトリをファイルとして扱おうとした場合のエラー
+var errIsDir = errors.New("is a directory")
+
+// Stat はファイル情報を返す
+func (m *Mem) Stat(name string) (os.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	node, ok := m.lookup(memPath(name))
+	if !ok {
+		return nil, &os.PathError{Op: "stat", Path: name, Err: os.ErrNotExist}
+	}
+	return node.info(), nil
+}
+
+// Lstat はシンボリックリンクを扱わないためStatと同じ
+func (m *Mem) Lstat(name string) (os.FileInfo, error) {
+	return m.Stat(name)
+}
+
+// ReadDir はディレクトリのエントリを名前順に返す
+func (m *Mem) ReadDir(name string) ([]os.DirEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dir := memPath(name)
+	node, ok := m.lookup(dir)
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	if !node.mode.IsDir() {
+		return nil, &os.PathError{Op: "readdirent", Path: name, Err: errNotDir}
+	}
+
+	var entries []os.DirEntry
+	for path, child := range m.nodes {
+		if filepath.Dir(path) == dir && path != dir {
+			entries = append(entries, fs.FileInfoToDirEntry(child.info()))
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// Open はファイルを読み込み用に開く
+func (m *Mem) Open(name string) (File, error) {
+	return m.OpenFile(name, os.O_RDONLY, 0)
+}
+
+// Create はファイルを作成する（既存のファイルは空にする）
+func (m *Mem) Create(name string) (File, error) {
+	return m.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+// OpenFile はフラグとアクセス権を指定してファイルを開く
+func (m *Mem) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	path := memPath(name)
+	node, ok := m.lookup(path)
+	switch {
+	case ok && flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0:
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrExist}
+	case ok && node.mode.IsDir() && flag&(os.O_WRONLY|os.O_RDWR) != 0:
+		return nil, &os.PathError{Op: "open", Path: name, Err: errIsDir}
+	case !ok && flag&os.O_CREATE == 0:
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	case !ok:
+		if err := m.parentDir("open", path); err != nil {
+			return nil, err
+		}
+		node = &memNode{name: filepath.Base(path), mode: perm.Perm(), modTime: time.Now()}
+		m.nodes[path] = node
+	}
+
+	if flag&os.O_TRUNC != 0 && !node.mode.IsDir() {
+		node.mu.Lock()
+		node.data = nil
+		node.modTime = time.Now()
+		node.mu.Unlock()
+	}
+
+	return &memFile{
+		node:   node,
+		name:   name,
+		read:   flag&os.O_WRONLY == 0,
+		write:  flag&(os.O_WRONLY|os.O_RDWR) != 0,
+		append: flag&os.O_APPEND != 0,
+	}, nil
+}
+
+// CreateTemp はディレクトリに重複しない名前のファイルを作成する
+// パターンに*を含む場合はその位置に、含まない場合は末尾に連番を付与する
+func (m *Mem) CreateTemp(dir, pattern string) (File, error) {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	prefix, suffix := pattern, ""
+	if i := strings.LastIndex(pattern, "*"); i >= 0 {
+		prefix, suffix = pattern[:i], pattern[i+1:]
+	}
+
+	for {
+		m.mu.Lock()
+		m.tempSeq++
+		seq := m.tempSeq
+		m.mu.Unlock()
+
+		name := filepath.Join(dir, prefix+strconv.Itoa(seq)+suffix)
+		f, err := m.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+		if !errors.Is(err, os.ErrExist) {
+			return f, err
+		}
+	}
+}
+
+// MkdirAll はディレクトリを親ディレクトリも含めて作成する
+func (m *Mem) MkdirAll(path string, perm os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.mkdirAll(memPath(path), perm)
+}
+
+// mkdirAll はMkdirAllの本体（呼び出し元でm.muをロックする）
+func (m *Mem) mkdirAll(path string, perm os.FileMode) error {
+	if node, ok := m.lookup(path); ok {
+		if !node.mode.IsDir() {
+			return &os.PathError{Op: "mkdir", Path: path, Err: errNotDir}
+		}
+		return nil
+	}
+	if err := m.mkdirAll(filepath.Dir(path), perm); err != nil {
+		return err
+	}
+	m.nodes[path] = &memNode{name: filepath.Base(path), mode: os.ModeDir | perm.Perm(), modTime: time.Now()}
+	return nil
+}
+
+// Remove はファイルまたは空のディレクトリを削除する
+func (m *Mem) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	path := memPath(name)
+	node, ok := m.nodes[path]
+	if !ok {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+	if node.mode.IsDir() && m.hasChildren(path) {
+		return &os.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+	}
+	delete(m.nodes, path)
+	return nil
+}
+
+// RemoveAll はパスとその配下をすべて削除する（存在しない場合はエラーにしない）
+func (m *Mem) RemoveAll(path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	root := memPath(path)
+	for p := range m.nodes {
+		if p == root || within(root, p) {
+			delete(m.nodes, p)
+		}
+	}
+	return nil
+}
+
+// Rename はファイルまたはディレクトリを移動する（移動先の既存のファイルは置き換える）
+func (m *Mem) Rename(oldpath, newpath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	from, to := memPath(oldpath), memPath(newpath)
+	node, ok := m.nodes[from]
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrNotExist}
+	}
+	if err := m.parentDir("rename", to); err != nil {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: err.(*os.PathError).Err}
+	}
+	if dest, ok := m.nodes[to]; ok && dest.mode.IsDir() && m.hasChildren(to) {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: errors.New("directory not empty")}
+	}
+
+	moved := make(map[string]*memNode)
+	for p, child := range m.nodes {
+		if within(from, p) {
+			moved[to+p[len(from):]] = child
+			delete(m.nodes, p)
+		}
+	}
+	for p, child := range moved {
+		m.nodes[p] = child
+	}
+	delete(m.nodes, from)
+	node.name = filepath.Base(to)
+	m.nodes[to] = node
+	return nil
+}
+
+// Chtimes は更新日時を設定する（アクセス日時は保持しない）
+func (m *Mem) Chtimes(name string, atime, mtime time.Time) error {
+	node, err := m.node("chtimes", name)
+	if err != nil {
+		return err
+	}
+	node.mu.Lock()
+	node.modTime = mtime
+	node.mu.Unlock()
+	return nil
+}
+
+// Chmod はアクセス権を設定する
+func (m *Mem) Chmod(name string, mode os.FileMode) error {
+	node, err := m.node("chmod", name)
+	if err != nil {
+		return err
+	}
+	node.mu.Lock()
+	node.mode = node.mode.Type() | mode.Perm()
+	node.mu.Unlock()
+	return nil
+}
+
+// node はルート以外の既存のノードを返す
+func (m *Mem) node(op, name string) (*memNode, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	node, ok := m.nodes[memPath(name)]
+	if !ok {
+		return nil, &os.PathError{Op: op, Path: name, Err: os.ErrNotExist}
+	}
+	return node, nil
+}
+
+// hasChildren はディレクトリに配下のノードがあるかどうかを返す（呼び出し元でm.muをロックする）
+func (m *Mem) hasChildren(dir string) bool {
+	for p := range m.nodes {
+		if within(dir, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// within はpathがdirの配下かどうかを返す
+func within(dir, path string) bool {
+	if isRoot(dir) {
+		return path != dir && strings.HasPrefix(path, dir)
+	}
+	return strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// info はノードのファイル情報を返す
+func (n *memNode) info() os.FileInfo {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return &memInfo{name: n.name, size: int64(len(n.data)), mode: n.mode, modTime: n.modTime}
+}
+
+// memInfo はメモリ上のファイルのファイル情報
+type memInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func (i *memInfo) Name() string       { return i.name }
+func (i *memInfo) Size() int64        { return i.size }
+func (i *memInfo) Mode() os.FileMode  { return i.mode }
+func (i *memInfo) ModTime() time.Time { return i.modTime }
+func (i *memInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i *memInfo) Sys() any           { return nil }
+
+// memFile はメモリ上の開いたファイル
+type memFile struct {
+	node   *memNode
+	name   string
+	offset int64
+	read   bool
+	write  bool
+	append bool
+	closed bool
+}
+
+func (f *memFile) Name() string {
+	return f.name
+}
+
+func (f *memFile) Stat() (os.FileInfo, error) {
+	if f.closed {
+		return nil, f.pathErr("stat", os.ErrClosed)
+	}
+	return f.node.info(), nil
+}
+
+func (f *memFile) Read(p []byte) (int, error) {
+	n, err := f.ReadAt(p, f.offset)
+	f.offset += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+func (f *memFile) ReadAt(p []byte, off int64) (int, error) {
+	if err := f.check("read", f.read); err != nil {
+		return 0, err
+	}
+	f.node.mu.Lock()
+	defer f.node.mu.Unlock()
+
+	if off >= int64(len(f.node.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.node.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	if f.append {
+		f.node.mu.Lock()
+		f.offset = int64(len(f.node.data))
+		f.node.mu.Unlock()
+	}
+	n, err := f.WriteAt(p, f.offset)
+	f.offset += int64(n)
+	return n, err
+}
+
+func (f *memFile) WriteAt(p []byte, off int64) (int, error) {
+	if err := f.check("write", f.write); err != nil {
+		return 0, err
+	}
+	f.node.mu.Lock()
+	defer f.node.mu.Unlock()
+
+	if end := off + int64(len(p)); end > int64(len(f.node.data)) {
+		f.node.grow(end)
+	}
+	copy(f.node.data[off:], p)
+	f.node.modTime = time.Now()
+	return len(p), nil
+}
+
+func (f *memFile) Seek(offset int64, whence int) (int64, error) {
+	if f.closed {
+		return 0, f.pathErr("seek", os.ErrClosed)
+	}
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		f.node.mu.Lock()
+		offset += int64(len(f.node.data))
+		f.node.mu.Unlock()
+	}
+	if offset < 0 {
+		return 0, f.pathErr("seek", errors.New("invalid argument"))
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *memFile) Truncate(size int64) error {
+	if err := f.check("truncate", f.write); err != nil {
+		return err
+	}
+	f.node.mu.Lock()
+	defer f.node.mu.Unlock()
+
+	if size > int64(len(f.node.data)) {
+		f.node.grow(size)
+	} else {
+		f.node.data = f.node.data[:size]
+	}
+	f.node.modTime = time.Now()
+	return nil
+}
+
+func (f *memFile) Sync() error {
+	return f.check("sync", true)
+}
+
+func (f *memFile) Close() error {
+	if f.closed {
+		return f.pathErr("close", os.ErrClosed)
+	}
+	f.closed = true
+	return nil
+}
+
+// check はファイルが開いていて、操作が許可されているかどうかを確認する
+func (f *memFile) check(op string, allowed bool) error {
+	if f.closed {
+		return f.pathErr(op, os.ErrClosed)
+	}
+	if f.node.mode.IsDir() {
+		return f.pathErr(op, errIsDir)
+	}
+	if !allowed {
+		return f.pathErr(op, os.ErrPermission)
+	}
+	return nil
+}
+
+func (f *memFile) pathErr(op string, err error) error {
+	return &os.PathError{Op: op, Path: f.name, Err: err}
+}
+
+// grow はデータをsizeまで0で埋めて拡張する（呼び出し元でn.muをロックする）
+func (n *memNode) grow(size int64) {
+	if size <= int64(cap(n.data)) {
+		old := len(n.data)
+		n.data = n.data[:size]
+		clear(n.data[old:])
+		return
+	}
+	data := make([]byte, size, size+size/4)
+	copy(data, n.data)
+	n.data = data
+}
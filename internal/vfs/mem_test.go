@@ -0,0 +1,148 @@
+package vfs
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMem_ReadWrite(t *testing.T) {
+	m := NewMem()
+	path := filepath.Join("/", "dir", "a.txt")
+
+	// 親ディレクトリがない場合は作成できない
+	if _, err := m.Create(path); !os.IsNotExist(err) {
+		t.Fatalf("親ディレクトリがない場合のエラー = %v", err)
+	}
+
+	if err := m.WriteFile(path, []byte("hello"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	data, err := m.ReadFile(path)
+	if err != nil || string(data) != "hello" {
+		t.Fatalf("ReadFile() = %q, %v", data, err)
+	}
+	info, err := m.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != 5 || info.Mode().Perm() != 0640 || info.IsDir() {
+		t.Errorf("ファイル情報 = size %d, mode %v", info.Size(), info.Mode())
+	}
+
+	// 読み込み用に開いたファイルには書き込めない
+	f, _ := m.Open(path)
+	if _, err := f.Write([]byte("x")); err == nil {
+		t.Error("読み込み用のファイルに書き込めました")
+	}
+	f.Close()
+
+	// 位置を指定した書き込みと切り詰め
+	f, _ = m.OpenFile(path, os.O_RDWR, 0)
+	f.WriteAt([]byte("XY"), 7)
+	buf := make([]byte, 9)
+	if n, _ := f.ReadAt(buf, 0); n != 9 || !bytes.Equal(buf, []byte("hello\x00\x00XY")) {
+		t.Errorf("ReadAt() = %q", buf[:n])
+	}
+	f.Truncate(2)
+	f.Truncate(4)
+	f.Close()
+	if data, _ := m.ReadFile(path); !bytes.Equal(data, []byte("he\x00\x00")) {
+		t.Errorf("切り詰め後の内容 = %q", data)
+	}
+
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := m.Chtimes(path, time.Now(), mtime); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := m.Stat(path); !info.ModTime().Equal(mtime) {
+		t.Errorf("更新日時 = %v", info.ModTime())
+	}
+}
+
+func TestMem_Directories(t *testing.T) {
+	m := NewMem()
+	root := filepath.Join("/", "src")
+	m.WriteFile(filepath.Join(root, "b.txt"), []byte("b"), 0644)
+	m.WriteFile(filepath.Join(root, "a.txt"), []byte("a"), 0644)
+	m.WriteFile(filepath.Join(root, "sub", "c.txt"), []byte("c"), 0644)
+
+	entries, err := m.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if !reflect.DeepEqual(names, []string{"a.txt", "b.txt", "sub"}) || !entries[2].IsDir() {
+		t.Errorf("ReadDir() = %v", names)
+	}
+
+	var walked []string
+	Walk(m, root, func(path string, info os.FileInfo, err error) error {
+		rel, _ := filepath.Rel(root, path)
+		walked = append(walked, filepath.ToSlash(rel))
+		return err
+	})
+	if want := []string{".", "a.txt", "b.txt", "sub", "sub/c.txt"}; !reflect.DeepEqual(walked, want) {
+		t.Errorf("Walk() = %v, want %v", walked, want)
+	}
+
+	// 空でないディレクトリは削除できない
+	if err := m.Remove(root); err == nil {
+		t.Error("空でないディレクトリを削除できました")
+	}
+
+	// ディレクトリの移動は配下も移動する
+	dst := filepath.Join("/", "moved")
+	if err := m.Rename(root, dst); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := m.ReadFile(filepath.Join(dst, "sub", "c.txt")); err != nil || string(data) != "c" {
+		t.Errorf("移動後の内容 = %q, %v", data, err)
+	}
+	if _, err := m.Stat(filepath.Join(root, "sub")); !os.IsNotExist(err) {
+		t.Errorf("移動元が残っています: %v", err)
+	}
+
+	if err := m.RemoveAll(dst); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := m.ReadDir(string(filepath.Separator)); len(entries) != 0 {
+		t.Errorf("RemoveAll() 後のエントリ = %d", len(entries))
+	}
+}
+
+func TestMem_CreateTemp(t *testing.T) {
+	m := NewMem()
+	dir := filepath.Join("/", "tmp")
+	m.MkdirAll(dir, 0755)
+
+	a, err := m.CreateTemp(dir, ".probe-*.tmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := m.CreateTemp(dir, ".probe-*.tmp")
+	if a.Name() == b.Name() {
+		t.Errorf("同じ名前の一時ファイル: %s", a.Name())
+	}
+	base := filepath.Base(a.Name())
+	if !strings.HasPrefix(base, ".probe-") || !strings.HasSuffix(base, ".tmp") {
+		t.Errorf("一時ファイルの名前 = %s", base)
+	}
+}
+
+func TestOr(t *testing.T) {
+	if Or(nil) != OS || !IsOS(nil) {
+		t.Error("nilはOSのファイルシステムとして扱うべき")
+	}
+	m := NewMem()
+	if Or(m) != FS(m) || IsOS(m) {
+		t.Error("指定したファイルシステムを使用するべき")
+	}
+}
@@ -0,0 +1,18 @@
+package vfs
+
+// Resumable は書き込みを中断しても、次回の実行で続きから再開できるファイルシステム
+// オブジェクトストレージのマルチパートアップロードなど、書き込みの途中の状態を宛先に保持できるストレージで実装する。
+// 再開に必要な情報（アップロードのIDや送信済みのパートの一覧など）はストレージごとに異なるため、文字列のトークンとして扱う
+type Resumable interface {
+	FS
+
+	// Checkpoint は書き込み中のファイルの再開用のトークンと、再開できる位置（宛先に保存済みのサイズ）を返す
+	// まだ再開できる状態でない場合は空のトークンを返す
+	Checkpoint(f File) (token string, offset int64, err error)
+	// Suspend は書き込みを完了させずにファイルを閉じ、トークンで再開できる状態を残す
+	Suspend(f File) error
+	// Resume はトークンが表す書き込みを再開するファイルを開き、書き込みを続ける位置を返す
+	Resume(name, token string) (File, int64, error)
+	// Discard は再開しない書き込みを破棄する
+	Discard(name, token string) error
+}
@@ -0,0 +1,14 @@
+package vfs
+
+// UncachedSupported はこの環境でキャッシュを経由せずにファイルを読み込めるかどうか
+const UncachedSupported = uncachedSupported
+
+// OpenUncached はキャッシュを経由せずにディスクから読み込むためにファイルを開く
+// ビット腐敗の検出など、キャッシュに残った内容ではなくディスク上の内容のハッシュ値を計算する場合に使用する
+// 先頭から順に読み込む（Read）ことのみを想定する。OS以外のファイルシステムでは通常どおり開く
+func OpenUncached(fsys FS, name string) (File, error) {
+	if !IsOS(fsys) {
+		return fsys.Open(name)
+	}
+	return openUncached(name)
+}
@@ -0,0 +1,45 @@
+package vfs
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+const uncachedSupported = true
+
+// openUncached はページキャッシュにあるファイルの内容を破棄してから開く
+func openUncached(name string) (File, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	// ディスクに書き出していない内容はキャッシュから破棄できないため、先に書き出す
+	// （コピーした直後の宛先を検証する場合など）
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("ファイルの書き出しに失敗: %w", err)
+	}
+	if err := dropCache(file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("ページキャッシュの破棄に失敗: %w", err)
+	}
+	return &uncachedFile{File: file}, nil
+}
+
+// uncachedFile は閉じる際に読み込んだ内容をページキャッシュから破棄する
+// 検証で読み込んだファイルで、ほかのファイルのキャッシュを押し出さないようにする
+type uncachedFile struct {
+	*os.File
+}
+
+func (f *uncachedFile) Close() error {
+	dropCache(f.File)
+	return f.File.Close()
+}
+
+// dropCache はファイル全体の内容をページキャッシュから破棄する
+func dropCache(file *os.File) error {
+	return unix.Fadvise(int(file.Fd()), 0, 0, unix.FADV_DONTNEED)
+}
@@ -0,0 +1,15 @@
+//go:build !linux && !windows
+
+package vfs
+
+import (
+	"errors"
+	"os"
+)
+
+const uncachedSupported = false
+
+// openUncached はこの環境では対応していないため、常にErrUnsupportedを返す
+func openUncached(name string) (File, error) {
+	return nil, &os.PathError{Op: "open", Path: name, Err: errors.ErrUnsupported}
+}
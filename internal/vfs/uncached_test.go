@@ -0,0 +1,55 @@
+package vfs
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenUncached(t *testing.T) {
+	// セクタの境界に揃わないサイズでも最後まで読み込める
+	data := bytes.Repeat([]byte("0123456789"), 300*1024+7)
+	path := filepath.Join(t.TempDir(), "a.bin")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := OpenUncached(OS, path)
+	if !UncachedSupported {
+		if !errors.Is(err, errors.ErrUnsupported) {
+			t.Errorf("対応していない環境でのエラー = %v", err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("OpenUncached() エラー: %v", err)
+	}
+	got, err := io.ReadAll(file)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("読み込んだ内容が一致しません: %dバイト, %v", len(got), err)
+	}
+	if err := file.Close(); err != nil {
+		t.Errorf("Close() エラー: %v", err)
+	}
+
+	if _, err := OpenUncached(OS, filepath.Join(t.TempDir(), "missing")); !os.IsNotExist(err) {
+		t.Errorf("存在しないファイルのエラー = %v", err)
+	}
+}
+
+func TestOpenUncached_Mem(t *testing.T) {
+	// OS以外のファイルシステムでは通常どおり開く
+	m := NewMem()
+	m.WriteFile("/a.txt", []byte("hello"), 0644)
+	file, err := OpenUncached(m, "/a.txt")
+	if err != nil {
+		t.Fatalf("OpenUncached() エラー: %v", err)
+	}
+	defer file.Close()
+	if got, _ := io.ReadAll(file); string(got) != "hello" {
+		t.Errorf("読み込んだ内容 = %q", got)
+	}
+}
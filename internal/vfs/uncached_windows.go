@@ -0,0 +1,72 @@
+package vfs
+
+import (
+	"io"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const uncachedSupported = true
+
+const (
+	// sectorAlign はFILE_FLAG_NO_BUFFERINGで読み込むサイズとバッファのアドレスを揃える境界
+	// 一般的なディスクのセクタサイズ（512・4096バイト）のいずれにも揃うよう4096バイトにする
+	sectorAlign = 4096
+	// uncachedBufferSize は1回に読み込むサイズ
+	uncachedBufferSize = 1024 * 1024
+)
+
+// openUncached はファイルシステムのキャッシュを経由しない（FILE_FLAG_NO_BUFFERING）でファイルを開く
+func openUncached(name string) (File, error) {
+	pathp, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: name, Err: err}
+	}
+	handle, err := windows.CreateFile(pathp, windows.GENERIC_READ,
+		windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE|windows.FILE_SHARE_DELETE,
+		nil, windows.OPEN_EXISTING, windows.FILE_FLAG_NO_BUFFERING|windows.FILE_FLAG_SEQUENTIAL_SCAN, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &uncachedFile{File: os.NewFile(uintptr(handle), name), buf: alignedBuffer(uncachedBufferSize)}, nil
+}
+
+// uncachedFile はセクタの境界に揃えたバッファで読み込み、呼び出し元のバッファに渡す
+type uncachedFile struct {
+	*os.File
+	buf  []byte // sectorAlignの境界に揃えたバッファ
+	data []byte // bufのうちまだ渡していない部分
+	err  error  // 最後の読み込みで発生したエラー（io.EOFを含む）
+}
+
+func (f *uncachedFile) Read(p []byte) (int, error) {
+	if len(f.data) == 0 {
+		if f.err != nil {
+			return 0, f.err
+		}
+		n, err := f.File.Read(f.buf)
+		f.data = f.buf[:n]
+		if err == nil && n == 0 {
+			err = io.EOF
+		}
+		f.err = err
+		if n == 0 {
+			return 0, f.err
+		}
+	}
+	n := copy(p, f.data)
+	f.data = f.data[n:]
+	return n, nil
+}
+
+// alignedBuffer はアドレスがsectorAlignの境界に揃ったバッファを確保する
+func alignedBuffer(size int) []byte {
+	buf := make([]byte, size+sectorAlign)
+	offset := int(uintptr(unsafe.Pointer(&buf[0])) & (sectorAlign - 1))
+	if offset != 0 {
+		offset = sectorAlign - offset
+	}
+	return buf[offset : offset+size]
+}
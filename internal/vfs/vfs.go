@@ -0,0 +1,181 @@
+// Package vfs はコピーと検証で使用するファイルシステムの操作を抽象化する
+// 通常はOSのファイルシステム（OS）を使用し、テストなどではメモリ上のファイルシステム（NewMem）に差し替えられる
+package vfs
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// File は開いたファイルに対する操作
+type File interface {
+	io.Reader
+	io.ReaderAt
+	io.Writer
+	io.WriterAt
+	io.Seeker
+	io.Closer
+
+	Name() string
+	Stat() (os.FileInfo, error)
+	Truncate(size int64) error
+	Sync() error
+}
+
+// FS はファイルシステムに対する操作
+// エラーはosパッケージと同様に*os.PathErrorで返すため、os.IsNotExistなどで判定できる
+type FS interface {
+	Stat(name string) (os.FileInfo, error)
+	Lstat(name string) (os.FileInfo, error)
+	ReadDir(name string) ([]os.DirEntry, error)
+	Open(name string) (File, error)
+	Create(name string) (File, error)
+	OpenFile(name string, flag int, perm os.FileMode) (File, error)
+	CreateTemp(dir, pattern string) (File, error)
+	MkdirAll(path string, perm os.FileMode) error
+	Remove(name string) error
+	RemoveAll(path string) error
+	Rename(oldpath, newpath string) error
+	Chtimes(name string, atime, mtime time.Time) error
+	Chmod(name string, mode os.FileMode) error
+}
+
+// OS はOSのファイルシステム
+var OS FS = osFS{}
+
+// Or はfsysがnilの場合にOSを返す
+func Or(fsys FS) FS {
+	if fsys == nil {
+		return OS
+	}
+	return fsys
+}
+
+// IsOS はfsysがOSのファイルシステムかどうかを返す
+// アクセス権やメタデータなど、パスを直接扱う処理を行えるかどうかの判定に使用する
+func IsOS(fsys FS) bool {
+	return fsys == nil || fsys == OS
+}
+
+// Readlink はシンボリックリンク・ジャンクションのリンク先を返す
+// リンクを扱わないファイルシステム（Memなど）ではerrors.ErrUnsupportedを返す
+func Readlink(fsys FS, name string) (string, error) {
+	if r, ok := Or(fsys).(interface{ Readlink(string) (string, error) }); ok {
+		return r.Readlink(name)
+	}
+	return "", &os.PathError{Op: "readlink", Path: name, Err: errors.ErrUnsupported}
+}
+
+// Walk はfilepath.Walkと同様にroot以下を辞書順にたどる
+func Walk(fsys FS, root string, fn filepath.WalkFunc) error {
+	info, err := fsys.Lstat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = walk(fsys, root, info, fn)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+// walk はpath以下を再帰的にたどる
+func walk(fsys FS, path string, info os.FileInfo, fn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+
+	entries, err := fsys.ReadDir(path)
+	fnErr := fn(path, info, err)
+	if err != nil || fnErr != nil {
+		return fnErr
+	}
+
+	for _, entry := range entries {
+		child := filepath.Join(path, entry.Name())
+		childInfo, err := fsys.Lstat(child)
+		if err != nil {
+			if err := fn(child, childInfo, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+		if err := walk(fsys, child, childInfo, fn); err != nil {
+			if !childInfo.IsDir() || err != filepath.SkipDir {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// osFS はOSのファイルシステム
+type osFS struct{}
+
+func (osFS) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFS) Lstat(name string) (os.FileInfo, error) {
+	return os.Lstat(name)
+}
+
+func (osFS) Readlink(name string) (string, error) {
+	return os.Readlink(name)
+}
+
+func (osFS) ReadDir(name string) ([]os.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+func (osFS) Open(name string) (File, error) {
+	return fileOrNil(os.Open(name))
+}
+
+func (osFS) Create(name string) (File, error) {
+	return fileOrNil(os.Create(name))
+}
+
+func (osFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	return fileOrNil(os.OpenFile(name, flag, perm))
+}
+
+func (osFS) CreateTemp(dir, pattern string) (File, error) {
+	return fileOrNil(os.CreateTemp(dir, pattern))
+}
+
+func (osFS) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+func (osFS) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (osFS) RemoveAll(path string) error {
+	return os.RemoveAll(path)
+}
+
+func (osFS) Rename(oldpath, newpath string) error {
+	return os.Rename(oldpath, newpath)
+}
+
+func (osFS) Chtimes(name string, atime, mtime time.Time) error {
+	return os.Chtimes(name, atime, mtime)
+}
+
+func (osFS) Chmod(name string, mode os.FileMode) error {
+	return os.Chmod(name, mode)
+}
+
+// fileOrNil はエラーの場合に型付きのnilではなくnilのインターフェースを返す
+func fileOrNil(f *os.File, err error) (File, error) {
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}